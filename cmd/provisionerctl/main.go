@@ -0,0 +1,28 @@
+package main
+
+import (
+	"provisioner/pkg/cli"
+	"provisioner/pkg/cli/auditctl"
+	"provisioner/pkg/cli/backupctl"
+	"provisioner/pkg/cli/daemon"
+	"provisioner/pkg/cli/environmentctl"
+	"provisioner/pkg/cli/jobctl"
+	"provisioner/pkg/cli/templatectl"
+	"provisioner/pkg/cli/workspacectl"
+)
+
+func main() {
+	cli.Main(&cli.Command{
+		Name:    "provisionerctl",
+		Summary: "Unified CLI for OpenTofu Workspace Scheduler.",
+		Commands: []*cli.Command{
+			workspacectl.Command(),
+			jobctl.Command(),
+			templatectl.Command(),
+			environmentctl.Command(),
+			daemon.Command(),
+			auditctl.Command(),
+			backupctl.Command(),
+		},
+	})
+}
@@ -1,19 +1,34 @@
 // OpenTofu Workspace Provisioner
-// All indirect dependencies are from github.com/opentofu/tofudl
-// for secure OpenTofu binary management and cryptographic verification
+// Indirect dependencies are from github.com/opentofu/tofudl, for secure
+// OpenTofu binary management and cryptographic verification, from
+// github.com/hashicorp/hcl/v2 for HCL config files and from
+// google.golang.org/grpc for the control socket and agents
 module provisioner
 
 go 1.25.1
 
-require github.com/opentofu/tofudl v0.0.1
+require (
+	github.com/fsnotify/fsnotify v1.9.0
+	github.com/hashicorp/hcl/v2 v2.24.0
+	github.com/opentofu/tofudl v0.0.1
+	github.com/zclconf/go-cty v1.16.3
+	google.golang.org/grpc v1.82.1
+	google.golang.org/protobuf v1.36.11
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/ProtonMail/go-crypto v1.3.0 // indirect
 	github.com/ProtonMail/go-mime v0.0.0-20230322103455-7d82a3887f2f // indirect
 	github.com/ProtonMail/gopenpgp/v2 v2.9.0 // indirect
+	github.com/agext/levenshtein v1.2.1 // indirect
+	github.com/apparentlymart/go-textseg/v15 v15.0.0 // indirect
 	github.com/cloudflare/circl v1.6.1 // indirect
+	github.com/mitchellh/go-wordwrap v1.0.1 // indirect
 	github.com/pkg/errors v0.9.1 // indirect
-	golang.org/x/crypto v0.42.0 // indirect
-	golang.org/x/sys v0.36.0 // indirect
-	golang.org/x/text v0.29.0 // indirect
+	golang.org/x/crypto v0.50.0 // indirect
+	golang.org/x/net v0.53.0 // indirect
+	golang.org/x/sys v0.43.0 // indirect
+	golang.org/x/text v0.36.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260414002931-afd174a4e478 // indirect
 )
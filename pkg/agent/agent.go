@@ -0,0 +1,145 @@
+// Package agent runs the deploys and destroys of workspaces assigned to an agent ("agent" in their
+// config) on remote hosts with access to the workspace's network or cloud, while the daemon keeps
+// scheduling them. Agents open a gRPC stream to the daemon over TLS and authenticate with a shared
+// token; the daemon then sends them operations over that stream, so agents need no inbound access.
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"provisioner/pkg/agent/agentpb"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/statefile"
+)
+
+// StateFile lists the connected agents in the state directory
+const StateFile = "agents.json"
+
+// Operations an agent runs
+const (
+	OperationDeploy  = "deploy"
+	OperationDestroy = "destroy"
+	OperationRefresh = "refresh"
+)
+
+// pingInterval is how often the daemon and its agents check that an idle connection is still up
+const pingInterval = 15 * time.Second
+
+// handshakeTimeout bounds how long a new agent may take to introduce itself and be welcomed
+const handshakeTimeout = 10 * time.Second
+
+// operationError returns the error of an operation run on an agent as the local client would have
+// returned it
+func operationError(reply *agentpb.OperationReply) error {
+	if reply.Cancelled {
+		return &opentofu.CancelledError{Step: reply.Step, CompletedSteps: reply.CompletedSteps}
+	}
+	if reply.TimedOut != nil {
+		return &opentofu.TimeoutError{Timeout: reply.TimedOut.AsDuration(), Step: reply.Step, CompletedSteps: reply.CompletedSteps}
+	}
+	if reply.DegradedHook != "" {
+		return &opentofu.DegradedError{Hook: reply.DegradedHook, Err: errors.New(reply.Error)}
+	}
+	if reply.BlockedHook != "" {
+		return &opentofu.DestroyBlockedError{Hook: reply.BlockedHook, Err: errors.New(reply.Error)}
+	}
+	if reply.Error != "" {
+		return fmt.Errorf("%s", reply.Error)
+	}
+	return nil
+}
+
+// operationReply describes the outcome of an operation for the daemon
+func operationReply(err error) *agentpb.OperationReply {
+	reply := &agentpb.OperationReply{}
+	var cancelled *opentofu.CancelledError
+	var timedOut *opentofu.TimeoutError
+	var degraded *opentofu.DegradedError
+	var blocked *opentofu.DestroyBlockedError
+	switch {
+	case errors.As(err, &cancelled):
+		reply.Cancelled = true
+		reply.Step = cancelled.Step
+		reply.CompletedSteps = cancelled.CompletedSteps
+	case errors.As(err, &timedOut):
+		reply.TimedOut = durationpb.New(timedOut.Timeout)
+		reply.Step = timedOut.Step
+		reply.CompletedSteps = timedOut.CompletedSteps
+	case errors.As(err, &degraded):
+		reply.DegradedHook = degraded.Hook
+		reply.Error = degraded.Err.Error()
+	case errors.As(err, &blocked):
+		reply.BlockedHook = blocked.Hook
+		reply.Error = blocked.Err.Error()
+	case err != nil:
+		reply.Error = err.Error()
+	}
+	return reply
+}
+
+// outputsToProto converts a workspace's outputs for the daemon
+func outputsToProto(outputs map[string]opentofu.OutputValue) map[string]*agentpb.OutputValue {
+	converted := make(map[string]*agentpb.OutputValue, len(outputs))
+	for name, output := range outputs {
+		converted[name] = &agentpb.OutputValue{Sensitive: output.Sensitive, Type: output.Type, Value: output.Value}
+	}
+	return converted
+}
+
+// outputsFromProto converts the outputs an agent returned
+func outputsFromProto(outputs map[string]*agentpb.OutputValue) map[string]opentofu.OutputValue {
+	converted := make(map[string]opentofu.OutputValue, len(outputs))
+	for name, output := range outputs {
+		converted[name] = opentofu.OutputValue{Sensitive: output.Sensitive, Type: output.Type, Value: output.Value}
+	}
+	return converted
+}
+
+// Info describes a connected agent
+type Info struct {
+	Name        string    `json:"name"`
+	Hostname    string    `json:"hostname"`
+	Version     string    `json:"version"`
+	Address     string    `json:"address"` // Remote address of the agent's connection
+	ConnectedAt time.Time `json:"connected_at"`
+	Operations  []string  `json:"operations,omitempty"` // Workspaces the agent is running an operation of
+}
+
+// LoadAgents returns the agents connected to the daemon as recorded in stateDir
+func LoadAgents(stateDir string) ([]Info, error) {
+	path := filepath.Join(stateDir, StateFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	data, _, err := statefile.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read agents: %w", err)
+	}
+	var agents []Info
+	if err := json.Unmarshal(data, &agents); err != nil {
+		return nil, fmt.Errorf("failed to parse agents: %w", err)
+	}
+	return agents, nil
+}
+
+// saveAgents records the connected agents in stateDir
+func saveAgents(stateDir string, agents []Info) error {
+	data, err := json.MarshalIndent(agents, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal agents: %w", err)
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := statefile.Write(filepath.Join(stateDir, StateFile), data); err != nil {
+		return fmt.Errorf("failed to write agents: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package agent
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+const testToken = "s3cret"
+
+// fakeClient records the operations it runs instead of running tofu
+type fakeClient struct {
+	mu        sync.Mutex
+	calls     []string
+	err       error
+	outputs   map[string]opentofu.OutputValue
+	cancelled bool
+}
+
+func (f *fakeClient) record(call string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+	return f.err
+}
+
+func (f *fakeClient) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+func (f *fakeClient) Deploy(ws *workspace.Workspace) error { return f.record("deploy " + ws.Name) }
+func (f *fakeClient) DeployInMode(ws *workspace.Workspace, mode string) error {
+	return f.record("deploy " + ws.Name + " " + mode)
+}
+func (f *fakeClient) DestroyWorkspace(ws *workspace.Workspace) error {
+	return f.record("destroy " + ws.Name)
+}
+func (f *fakeClient) RefreshWorkspace(ws *workspace.Workspace, mode string) error {
+	return f.record("refresh " + ws.Name)
+}
+func (f *fakeClient) RefreshOutputs(ws *workspace.Workspace, mode string) (map[string]opentofu.OutputValue, error) {
+	_ = f.record("refresh outputs " + ws.Name)
+	return f.outputs, nil
+}
+func (f *fakeClient) Cancel(workspaceName string) bool {
+	_ = f.record("cancel " + workspaceName)
+	return f.cancelled
+}
+func (f *fakeClient) Init(workingDir string) error                { return f.record("init") }
+func (f *fakeClient) Plan(workingDir string) error                { return f.record("plan") }
+func (f *fakeClient) Apply(workingDir string) error               { return f.record("apply") }
+func (f *fakeClient) Destroy(workingDir string) error             { return f.record("destroy") }
+func (f *fakeClient) PlanWithMode(workingDir, mode string) error  { return f.record("plan " + mode) }
+func (f *fakeClient) ApplyWithMode(workingDir, mode string) error { return f.record("apply " + mode) }
+func (f *fakeClient) Output(workingDir string) (map[string]opentofu.OutputValue, error) {
+	_ = f.record("output " + filepath.Base(workingDir))
+	return f.outputs, nil
+}
+
+// writeCertificate writes a self-signed certificate for 127.0.0.1 and its key into dir
+func writeCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "provisioner"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// startHub starts a hub on a free loopback port with its state in a temporary directory
+func startHub(t *testing.T) (*Hub, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", dir)
+	certFile, keyFile := writeCertificate(t, dir)
+
+	hub, err := NewHub("127.0.0.1:0", testToken, certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewHub failed: %v", err)
+	}
+	if err := hub.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	t.Cleanup(func() { _ = hub.Close() })
+	return hub, certFile
+}
+
+// startAgent connects a worker to the hub and waits until the hub accepted it
+func startAgent(t *testing.T, hub *Hub, caFile, name string, client *fakeClient) {
+	t.Helper()
+	worker := &Worker{name: name, client: client, load: func(workspaceName string) (*workspace.Workspace, error) {
+		if workspaceName == "missing" {
+			return nil, fmt.Errorf("workspace '%s' not found on agent", workspaceName)
+		}
+		return &workspace.Workspace{Name: workspaceName, Config: workspace.Config{Agent: name}}, nil
+	}}
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- Connect(Options{Name: name, Servers: []string{hub.Addr()}, Token: testToken, CAFile: caFile}, worker, stop)
+	}()
+	t.Cleanup(func() {
+		close(stop)
+		<-done
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.agent(name) == nil {
+		if time.Now().After(deadline) {
+			t.Fatalf("Agent '%s' did not connect", name)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClientRunsAgentWorkspacesOnTheirAgent(t *testing.T) {
+	hub, caFile := startHub(t)
+	remote := &fakeClient{outputs: map[string]opentofu.OutputValue{"ip": {Value: []byte(`"10.0.0.1"`)}}, cancelled: true}
+	startAgent(t, hub, caFile, "aws-vpc", remote)
+
+	local := &fakeClient{}
+	agentOf := map[string]string{"db": "aws-vpc"}
+	client := hub.Client(local, func(name string) string { return agentOf[name] })
+
+	db := &workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "aws-vpc"}}
+	web := &workspace.Workspace{Name: "web"}
+	if err := client.Deploy(db); err != nil {
+		t.Fatalf("Deploy on agent failed: %v", err)
+	}
+	if err := client.DeployInMode(db, "busy"); err != nil {
+		t.Fatalf("DeployInMode on agent failed: %v", err)
+	}
+	if err := client.DestroyWorkspace(db); err != nil {
+		t.Fatalf("Destroy on agent failed: %v", err)
+	}
+	if err := client.Deploy(web); err != nil {
+		t.Fatalf("Local deploy failed: %v", err)
+	}
+
+	outputs, err := client.Output(opentofu.GetWorkingDir("db"))
+	if err != nil || outputs["ip"].String() != "10.0.0.1" {
+		t.Errorf("Expected the agent's outputs, got %v %v", outputs, err)
+	}
+	if !client.Cancel("db") {
+		t.Error("Expected the cancel to reach the agent")
+	}
+	if err := client.Init("/tmp/deployments/db"); err != nil {
+		t.Errorf("Init failed: %v", err)
+	}
+
+	expected := []string{"deploy db", "deploy db busy", "destroy db", "output db", "cancel db"}
+	if calls := remote.Calls(); fmt.Sprint(calls) != fmt.Sprint(expected) {
+		t.Errorf("Expected the agent to run %v, got %v", expected, calls)
+	}
+	if calls := local.Calls(); fmt.Sprint(calls) != fmt.Sprint([]string{"deploy web", "init"}) {
+		t.Errorf("Expected the daemon host to run the rest, got %v", calls)
+	}
+
+	agents, err := LoadAgents(os.Getenv("PROVISIONER_STATE_DIR"))
+	if err != nil || len(agents) != 1 || agents[0].Name != "aws-vpc" || len(agents[0].Operations) != 0 {
+		t.Errorf("Expected agents.json to list the idle agent, got %+v %v", agents, err)
+	}
+}
+
+func TestClientReturnsAgentErrors(t *testing.T) {
+	hub, caFile := startHub(t)
+	remote := &fakeClient{err: &opentofu.CancelledError{Step: "apply", CompletedSteps: []string{"init", "plan"}}}
+	startAgent(t, hub, caFile, "edge", remote)
+	client := hub.Client(&fakeClient{}, func(string) string { return "edge" })
+
+	err := client.Deploy(&workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "edge"}})
+	var cancelled *opentofu.CancelledError
+	if !errors.As(err, &cancelled) || cancelled.Step != "apply" || len(cancelled.CompletedSteps) != 2 {
+		t.Errorf("Expected the agent's cancellation, got %v", err)
+	}
+
+	remote.mu.Lock()
+	remote.err = fmt.Errorf("apply failed: %w", &opentofu.TimeoutError{Timeout: time.Hour, Step: "apply", CompletedSteps: []string{"init"}})
+	remote.mu.Unlock()
+	err = client.Deploy(&workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "edge"}})
+	var timedOut *opentofu.TimeoutError
+	if !errors.As(err, &timedOut) || timedOut.Timeout != time.Hour || timedOut.Step != "apply" {
+		t.Errorf("Expected the agent's timeout, got %v", err)
+	}
+
+	remote.mu.Lock()
+	remote.err = &opentofu.DegradedError{Hook: "./smoke.sh", Err: fmt.Errorf("exit status 1")}
+	remote.mu.Unlock()
+	err = client.Deploy(&workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "edge"}})
+	var degraded *opentofu.DegradedError
+	if !errors.As(err, &degraded) || degraded.Hook != "./smoke.sh" || degraded.Err.Error() != "exit status 1" {
+		t.Errorf("Expected the agent's failed hook, got %v", err)
+	}
+
+	remote.mu.Lock()
+	remote.err = &opentofu.DestroyBlockedError{Hook: "./no-sessions.sh", Err: fmt.Errorf("exit status 2")}
+	remote.mu.Unlock()
+	err = client.DestroyWorkspace(&workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "edge"}})
+	if !errors.Is(err, opentofu.ErrDestroyBlocked) || !strings.Contains(err.Error(), "./no-sessions.sh") {
+		t.Errorf("Expected the agent's blocked destroy, got %v", err)
+	}
+
+	remote.mu.Lock()
+	remote.err = fmt.Errorf("apply failed: quota exceeded")
+	remote.mu.Unlock()
+	err = client.DestroyWorkspace(&workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "edge"}})
+	if err == nil || err.Error() != "apply failed: quota exceeded" {
+		t.Errorf("Expected the agent's error, got %v", err)
+	}
+
+	err = client.Deploy(&workspace.Workspace{Name: "missing", Config: workspace.Config{Agent: "edge"}})
+	if err == nil || err.Error() != "workspace 'missing' not found on agent" {
+		t.Errorf("Expected the agent to report the unknown workspace, got %v", err)
+	}
+
+	err = client.Deploy(&workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "gcp"}})
+	if err == nil || err.Error() != "agent 'gcp' is not connected" {
+		t.Errorf("Expected a disconnected agent to fail the deploy, got %v", err)
+	}
+}
+
+func TestHubRejectsAgents(t *testing.T) {
+	hub, caFile := startHub(t)
+	worker := NewWorker("edge", &fakeClient{})
+
+	err := Connect(Options{Name: "edge", Servers: []string{hub.Addr()}, Token: "wrong", CAFile: caFile}, worker, make(chan struct{}))
+	var rejected *RejectedError
+	if !errors.As(err, &rejected) || rejected.Retry || rejected.Reason != "invalid token" {
+		t.Errorf("Expected an invalid token to be rejected for good, got %v", err)
+	}
+
+	// A standby refuses agents until it leads
+	hub.AcceptWhen(func() error { return fmt.Errorf("standing by, node-a:1 leads") })
+	rootCAs := x509.NewCertPool()
+	data, _ := os.ReadFile(caFile)
+	rootCAs.AppendCertsFromPEM(data)
+	_, err = serve(Options{Name: "edge", Token: testToken}, hub.Addr(), rootCAs, worker, make(chan struct{}))
+	if !errors.As(err, &rejected) || !rejected.Retry {
+		t.Errorf("Expected a standby to ask the agent to retry, got %v", err)
+	}
+}
+
+func TestHubUnregistersDisconnectedAgent(t *testing.T) {
+	hub, caFile := startHub(t)
+	worker := NewWorker("edge", &fakeClient{})
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- Connect(Options{Name: "edge", Servers: []string{hub.Addr()}, Token: testToken, CAFile: caFile}, worker, stop)
+	}()
+	deadline := time.Now().Add(5 * time.Second)
+	for hub.agent("edge") == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("Agent did not connect")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The agent's stream ends with the agent, without waiting for a ping to fail
+	close(stop)
+	if err := <-done; err != nil {
+		t.Fatalf("Connect failed: %v", err)
+	}
+	for len(hub.Agents()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the agent to be unregistered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	client := hub.Client(&fakeClient{}, func(string) string { return "edge" })
+	if err := client.Deploy(&workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "edge"}}); err == nil || err.Error() != "agent 'edge' is not connected" {
+		t.Errorf("Expected the deploy to fail without the agent, got %v", err)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	valid := Options{Name: "aws-vpc", Servers: []string{"provisioner.example.com:8092"}, Token: testToken}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Expected valid options, got %v", err)
+	}
+
+	for _, opts := range []Options{
+		{Name: "aws vpc", Servers: valid.Servers, Token: testToken},
+		{Name: "aws-vpc", Token: testToken},
+		{Name: "aws-vpc", Servers: []string{"provisioner.example.com"}, Token: testToken},
+		{Name: "aws-vpc", Servers: valid.Servers},
+	} {
+		if err := opts.Validate(); err == nil {
+			t.Errorf("Expected error for %+v", opts)
+		}
+	}
+}
@@ -0,0 +1,1045 @@
+// Service remote agents connect to on the daemon. Agents need no inbound access: the agent opens
+// the stream, introduces itself, and the daemon then sends it requests over that stream.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: pkg/agent/agentpb/agent.proto
+
+package agentpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// AgentMessage is sent by an agent
+type AgentMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*AgentMessage_Hello
+	//	*AgentMessage_Response
+	Message       isAgentMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentMessage) Reset() {
+	*x = AgentMessage{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMessage) ProtoMessage() {}
+
+func (x *AgentMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMessage.ProtoReflect.Descriptor instead.
+func (*AgentMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AgentMessage) GetMessage() isAgentMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetHello() *Hello {
+	if x != nil {
+		if x, ok := x.Message.(*AgentMessage_Hello); ok {
+			return x.Hello
+		}
+	}
+	return nil
+}
+
+func (x *AgentMessage) GetResponse() *Response {
+	if x != nil {
+		if x, ok := x.Message.(*AgentMessage_Response); ok {
+			return x.Response
+		}
+	}
+	return nil
+}
+
+type isAgentMessage_Message interface {
+	isAgentMessage_Message()
+}
+
+type AgentMessage_Hello struct {
+	Hello *Hello `protobuf:"bytes,1,opt,name=hello,proto3,oneof"`
+}
+
+type AgentMessage_Response struct {
+	Response *Response `protobuf:"bytes,2,opt,name=response,proto3,oneof"`
+}
+
+func (*AgentMessage_Hello) isAgentMessage_Message() {}
+
+func (*AgentMessage_Response) isAgentMessage_Message() {}
+
+// HubMessage is sent by the daemon
+type HubMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Message:
+	//
+	//	*HubMessage_Welcome
+	//	*HubMessage_Request
+	Message       isHubMessage_Message `protobuf_oneof:"message"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HubMessage) Reset() {
+	*x = HubMessage{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HubMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HubMessage) ProtoMessage() {}
+
+func (x *HubMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HubMessage.ProtoReflect.Descriptor instead.
+func (*HubMessage) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HubMessage) GetMessage() isHubMessage_Message {
+	if x != nil {
+		return x.Message
+	}
+	return nil
+}
+
+func (x *HubMessage) GetWelcome() *Welcome {
+	if x != nil {
+		if x, ok := x.Message.(*HubMessage_Welcome); ok {
+			return x.Welcome
+		}
+	}
+	return nil
+}
+
+func (x *HubMessage) GetRequest() *Request {
+	if x != nil {
+		if x, ok := x.Message.(*HubMessage_Request); ok {
+			return x.Request
+		}
+	}
+	return nil
+}
+
+type isHubMessage_Message interface {
+	isHubMessage_Message()
+}
+
+type HubMessage_Welcome struct {
+	Welcome *Welcome `protobuf:"bytes,1,opt,name=welcome,proto3,oneof"`
+}
+
+type HubMessage_Request struct {
+	Request *Request `protobuf:"bytes,2,opt,name=request,proto3,oneof"`
+}
+
+func (*HubMessage_Welcome) isHubMessage_Message() {}
+
+func (*HubMessage_Request) isHubMessage_Message() {}
+
+// Hello introduces an agent
+type Hello struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Shared token of the daemon's agents
+	Token         string `protobuf:"bytes,2,opt,name=token,proto3" json:"token,omitempty"`
+	Hostname      string `protobuf:"bytes,3,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	Version       string `protobuf:"bytes,4,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Hello) Reset() {
+	*x = Hello{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Hello) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hello) ProtoMessage() {}
+
+func (x *Hello) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hello.ProtoReflect.Descriptor instead.
+func (*Hello) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Hello) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Hello) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+func (x *Hello) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *Hello) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+// Welcome accepts an agent
+type Welcome struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Welcome) Reset() {
+	*x = Welcome{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Welcome) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Welcome) ProtoMessage() {}
+
+func (x *Welcome) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Welcome.ProtoReflect.Descriptor instead.
+func (*Welcome) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{3}
+}
+
+// Request is a call of the daemon on an agent
+type Request struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Types that are valid to be assigned to Call:
+	//
+	//	*Request_Run
+	//	*Request_Output
+	//	*Request_Cancel
+	Call          isRequest_Call `protobuf_oneof:"call"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Request) Reset() {
+	*x = Request{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Request) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Request) ProtoMessage() {}
+
+func (x *Request) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Request.ProtoReflect.Descriptor instead.
+func (*Request) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Request) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Request) GetCall() isRequest_Call {
+	if x != nil {
+		return x.Call
+	}
+	return nil
+}
+
+func (x *Request) GetRun() *OperationRequest {
+	if x != nil {
+		if x, ok := x.Call.(*Request_Run); ok {
+			return x.Run
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetOutput() *WorkspaceRequest {
+	if x != nil {
+		if x, ok := x.Call.(*Request_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *Request) GetCancel() *WorkspaceRequest {
+	if x != nil {
+		if x, ok := x.Call.(*Request_Cancel); ok {
+			return x.Cancel
+		}
+	}
+	return nil
+}
+
+type isRequest_Call interface {
+	isRequest_Call()
+}
+
+type Request_Run struct {
+	Run *OperationRequest `protobuf:"bytes,2,opt,name=run,proto3,oneof"`
+}
+
+type Request_Output struct {
+	Output *WorkspaceRequest `protobuf:"bytes,3,opt,name=output,proto3,oneof"`
+}
+
+type Request_Cancel struct {
+	Cancel *WorkspaceRequest `protobuf:"bytes,4,opt,name=cancel,proto3,oneof"`
+}
+
+func (*Request_Run) isRequest_Call() {}
+
+func (*Request_Output) isRequest_Call() {}
+
+func (*Request_Cancel) isRequest_Call() {}
+
+// Response answers the request with the same id
+type Response struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Why the call failed, e.g. for a workspace the agent doesn't know
+	Error string `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// Types that are valid to be assigned to Result:
+	//
+	//	*Response_Run
+	//	*Response_Output
+	//	*Response_Cancel
+	Result        isResponse_Result `protobuf_oneof:"result"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Response) Reset() {
+	*x = Response{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Response) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Response) ProtoMessage() {}
+
+func (x *Response) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Response.ProtoReflect.Descriptor instead.
+func (*Response) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Response) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Response) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Response) GetResult() isResponse_Result {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *Response) GetRun() *OperationReply {
+	if x != nil {
+		if x, ok := x.Result.(*Response_Run); ok {
+			return x.Run
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetOutput() *OutputReply {
+	if x != nil {
+		if x, ok := x.Result.(*Response_Output); ok {
+			return x.Output
+		}
+	}
+	return nil
+}
+
+func (x *Response) GetCancel() *CancelReply {
+	if x != nil {
+		if x, ok := x.Result.(*Response_Cancel); ok {
+			return x.Cancel
+		}
+	}
+	return nil
+}
+
+type isResponse_Result interface {
+	isResponse_Result()
+}
+
+type Response_Run struct {
+	Run *OperationReply `protobuf:"bytes,3,opt,name=run,proto3,oneof"`
+}
+
+type Response_Output struct {
+	Output *OutputReply `protobuf:"bytes,4,opt,name=output,proto3,oneof"`
+}
+
+type Response_Cancel struct {
+	Cancel *CancelReply `protobuf:"bytes,5,opt,name=cancel,proto3,oneof"`
+}
+
+func (*Response_Run) isResponse_Result() {}
+
+func (*Response_Output) isResponse_Result() {}
+
+func (*Response_Cancel) isResponse_Result() {}
+
+// OperationRequest runs a workspace operation on the agent
+type OperationRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Workspace string                 `protobuf:"bytes,1,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	// deploy, destroy or refresh
+	Operation string `protobuf:"bytes,2,opt,name=operation,proto3" json:"operation,omitempty"`
+	// Deployment mode, empty for a normal deploy
+	Mode string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Correlation ID of the operation on the daemon
+	CorrelationId string `protobuf:"bytes,4,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OperationRequest) Reset() {
+	*x = OperationRequest{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OperationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OperationRequest) ProtoMessage() {}
+
+func (x *OperationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OperationRequest.ProtoReflect.Descriptor instead.
+func (*OperationRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *OperationRequest) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+func (x *OperationRequest) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *OperationRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *OperationRequest) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+// OperationReply is the outcome of an operation. Errors are carried in the reply rather than
+// failing the call, so a cancelled operation is still recognized as one by the daemon.
+type OperationReply struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Error     string                 `protobuf:"bytes,1,opt,name=error,proto3" json:"error,omitempty"`
+	Cancelled bool                   `protobuf:"varint,2,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	// Timeout that stopped the operation, unset if it didn't time out
+	TimedOut *durationpb.Duration `protobuf:"bytes,3,opt,name=timed_out,json=timedOut,proto3" json:"timed_out,omitempty"`
+	// Step that was running when the operation was cancelled or timed out
+	Step string `protobuf:"bytes,4,opt,name=step,proto3" json:"step,omitempty"`
+	// Steps that finished before
+	CompletedSteps []string `protobuf:"bytes,5,rep,name=completed_steps,json=completedSteps,proto3" json:"completed_steps,omitempty"`
+	// post_deploy hook that failed after a successful apply, error holds why
+	DegradedHook string `protobuf:"bytes,6,opt,name=degraded_hook,json=degradedHook,proto3" json:"degraded_hook,omitempty"`
+	// pre_destroy hook that stopped a destroy, error holds why
+	BlockedHook   string `protobuf:"bytes,7,opt,name=blocked_hook,json=blockedHook,proto3" json:"blocked_hook,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OperationReply) Reset() {
+	*x = OperationReply{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OperationReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OperationReply) ProtoMessage() {}
+
+func (x *OperationReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OperationReply.ProtoReflect.Descriptor instead.
+func (*OperationReply) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *OperationReply) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *OperationReply) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+func (x *OperationReply) GetTimedOut() *durationpb.Duration {
+	if x != nil {
+		return x.TimedOut
+	}
+	return nil
+}
+
+func (x *OperationReply) GetStep() string {
+	if x != nil {
+		return x.Step
+	}
+	return ""
+}
+
+func (x *OperationReply) GetCompletedSteps() []string {
+	if x != nil {
+		return x.CompletedSteps
+	}
+	return nil
+}
+
+func (x *OperationReply) GetDegradedHook() string {
+	if x != nil {
+		return x.DegradedHook
+	}
+	return ""
+}
+
+func (x *OperationReply) GetBlockedHook() string {
+	if x != nil {
+		return x.BlockedHook
+	}
+	return ""
+}
+
+// WorkspaceRequest identifies the workspace of an output or cancel request
+type WorkspaceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workspace     string                 `protobuf:"bytes,1,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkspaceRequest) Reset() {
+	*x = WorkspaceRequest{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceRequest) ProtoMessage() {}
+
+func (x *WorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*WorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *WorkspaceRequest) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+// OutputReply holds the outputs of a workspace's state
+type OutputReply struct {
+	state         protoimpl.MessageState  `protogen:"open.v1"`
+	Outputs       map[string]*OutputValue `protobuf:"bytes,1,rep,name=outputs,proto3" json:"outputs,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputReply) Reset() {
+	*x = OutputReply{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputReply) ProtoMessage() {}
+
+func (x *OutputReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputReply.ProtoReflect.Descriptor instead.
+func (*OutputReply) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *OutputReply) GetOutputs() map[string]*OutputValue {
+	if x != nil {
+		return x.Outputs
+	}
+	return nil
+}
+
+// OutputValue is a tofu output, its type and value as JSON
+type OutputValue struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sensitive     bool                   `protobuf:"varint,1,opt,name=sensitive,proto3" json:"sensitive,omitempty"`
+	Type          []byte                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Value         []byte                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OutputValue) Reset() {
+	*x = OutputValue{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OutputValue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OutputValue) ProtoMessage() {}
+
+func (x *OutputValue) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OutputValue.ProtoReflect.Descriptor instead.
+func (*OutputValue) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *OutputValue) GetSensitive() bool {
+	if x != nil {
+		return x.Sensitive
+	}
+	return false
+}
+
+func (x *OutputValue) GetType() []byte {
+	if x != nil {
+		return x.Type
+	}
+	return nil
+}
+
+func (x *OutputValue) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+// CancelReply tells whether an operation was running and got cancelled
+type CancelReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cancelled     bool                   `protobuf:"varint,1,opt,name=cancelled,proto3" json:"cancelled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CancelReply) Reset() {
+	*x = CancelReply{}
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CancelReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CancelReply) ProtoMessage() {}
+
+func (x *CancelReply) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_agent_agentpb_agent_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CancelReply.ProtoReflect.Descriptor instead.
+func (*CancelReply) Descriptor() ([]byte, []int) {
+	return file_pkg_agent_agentpb_agent_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *CancelReply) GetCancelled() bool {
+	if x != nil {
+		return x.Cancelled
+	}
+	return false
+}
+
+var File_pkg_agent_agentpb_agent_proto protoreflect.FileDescriptor
+
+const file_pkg_agent_agentpb_agent_proto_rawDesc = "" +
+	"\n" +
+	"\x1dpkg/agent/agentpb/agent.proto\x12\x14provisioner.agent.v1\x1a\x1egoogle/protobuf/duration.proto\"\x8c\x01\n" +
+	"\fAgentMessage\x123\n" +
+	"\x05hello\x18\x01 \x01(\v2\x1b.provisioner.agent.v1.HelloH\x00R\x05hello\x12<\n" +
+	"\bresponse\x18\x02 \x01(\v2\x1e.provisioner.agent.v1.ResponseH\x00R\bresponseB\t\n" +
+	"\amessage\"\x8d\x01\n" +
+	"\n" +
+	"HubMessage\x129\n" +
+	"\awelcome\x18\x01 \x01(\v2\x1d.provisioner.agent.v1.WelcomeH\x00R\awelcome\x129\n" +
+	"\arequest\x18\x02 \x01(\v2\x1d.provisioner.agent.v1.RequestH\x00R\arequestB\t\n" +
+	"\amessage\"g\n" +
+	"\x05Hello\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05token\x18\x02 \x01(\tR\x05token\x12\x1a\n" +
+	"\bhostname\x18\x03 \x01(\tR\bhostname\x12\x18\n" +
+	"\aversion\x18\x04 \x01(\tR\aversion\"\t\n" +
+	"\aWelcome\"\xe1\x01\n" +
+	"\aRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12:\n" +
+	"\x03run\x18\x02 \x01(\v2&.provisioner.agent.v1.OperationRequestH\x00R\x03run\x12@\n" +
+	"\x06output\x18\x03 \x01(\v2&.provisioner.agent.v1.WorkspaceRequestH\x00R\x06output\x12@\n" +
+	"\x06cancel\x18\x04 \x01(\v2&.provisioner.agent.v1.WorkspaceRequestH\x00R\x06cancelB\x06\n" +
+	"\x04call\"\xee\x01\n" +
+	"\bResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\x128\n" +
+	"\x03run\x18\x03 \x01(\v2$.provisioner.agent.v1.OperationReplyH\x00R\x03run\x12;\n" +
+	"\x06output\x18\x04 \x01(\v2!.provisioner.agent.v1.OutputReplyH\x00R\x06output\x12;\n" +
+	"\x06cancel\x18\x05 \x01(\v2!.provisioner.agent.v1.CancelReplyH\x00R\x06cancelB\b\n" +
+	"\x06result\"\x89\x01\n" +
+	"\x10OperationRequest\x12\x1c\n" +
+	"\tworkspace\x18\x01 \x01(\tR\tworkspace\x12\x1c\n" +
+	"\toperation\x18\x02 \x01(\tR\toperation\x12\x12\n" +
+	"\x04mode\x18\x03 \x01(\tR\x04mode\x12%\n" +
+	"\x0ecorrelation_id\x18\x04 \x01(\tR\rcorrelationId\"\x81\x02\n" +
+	"\x0eOperationReply\x12\x14\n" +
+	"\x05error\x18\x01 \x01(\tR\x05error\x12\x1c\n" +
+	"\tcancelled\x18\x02 \x01(\bR\tcancelled\x126\n" +
+	"\ttimed_out\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\btimedOut\x12\x12\n" +
+	"\x04step\x18\x04 \x01(\tR\x04step\x12'\n" +
+	"\x0fcompleted_steps\x18\x05 \x03(\tR\x0ecompletedSteps\x12#\n" +
+	"\rdegraded_hook\x18\x06 \x01(\tR\fdegradedHook\x12!\n" +
+	"\fblocked_hook\x18\a \x01(\tR\vblockedHook\"0\n" +
+	"\x10WorkspaceRequest\x12\x1c\n" +
+	"\tworkspace\x18\x01 \x01(\tR\tworkspace\"\xb6\x01\n" +
+	"\vOutputReply\x12H\n" +
+	"\aoutputs\x18\x01 \x03(\v2..provisioner.agent.v1.OutputReply.OutputsEntryR\aoutputs\x1a]\n" +
+	"\fOutputsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x127\n" +
+	"\x05value\x18\x02 \x01(\v2!.provisioner.agent.v1.OutputValueR\x05value:\x028\x01\"U\n" +
+	"\vOutputValue\x12\x1c\n" +
+	"\tsensitive\x18\x01 \x01(\bR\tsensitive\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\fR\x04type\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\fR\x05value\"+\n" +
+	"\vCancelReply\x12\x1c\n" +
+	"\tcancelled\x18\x01 \x01(\bR\tcancelled2_\n" +
+	"\bAgentHub\x12S\n" +
+	"\aConnect\x12\".provisioner.agent.v1.AgentMessage\x1a .provisioner.agent.v1.HubMessage(\x010\x01B\x1fZ\x1dprovisioner/pkg/agent/agentpbb\x06proto3"
+
+var (
+	file_pkg_agent_agentpb_agent_proto_rawDescOnce sync.Once
+	file_pkg_agent_agentpb_agent_proto_rawDescData []byte
+)
+
+func file_pkg_agent_agentpb_agent_proto_rawDescGZIP() []byte {
+	file_pkg_agent_agentpb_agent_proto_rawDescOnce.Do(func() {
+		file_pkg_agent_agentpb_agent_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_agent_agentpb_agent_proto_rawDesc), len(file_pkg_agent_agentpb_agent_proto_rawDesc)))
+	})
+	return file_pkg_agent_agentpb_agent_proto_rawDescData
+}
+
+var file_pkg_agent_agentpb_agent_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_pkg_agent_agentpb_agent_proto_goTypes = []any{
+	(*AgentMessage)(nil),        // 0: provisioner.agent.v1.AgentMessage
+	(*HubMessage)(nil),          // 1: provisioner.agent.v1.HubMessage
+	(*Hello)(nil),               // 2: provisioner.agent.v1.Hello
+	(*Welcome)(nil),             // 3: provisioner.agent.v1.Welcome
+	(*Request)(nil),             // 4: provisioner.agent.v1.Request
+	(*Response)(nil),            // 5: provisioner.agent.v1.Response
+	(*OperationRequest)(nil),    // 6: provisioner.agent.v1.OperationRequest
+	(*OperationReply)(nil),      // 7: provisioner.agent.v1.OperationReply
+	(*WorkspaceRequest)(nil),    // 8: provisioner.agent.v1.WorkspaceRequest
+	(*OutputReply)(nil),         // 9: provisioner.agent.v1.OutputReply
+	(*OutputValue)(nil),         // 10: provisioner.agent.v1.OutputValue
+	(*CancelReply)(nil),         // 11: provisioner.agent.v1.CancelReply
+	nil,                         // 12: provisioner.agent.v1.OutputReply.OutputsEntry
+	(*durationpb.Duration)(nil), // 13: google.protobuf.Duration
+}
+var file_pkg_agent_agentpb_agent_proto_depIdxs = []int32{
+	2,  // 0: provisioner.agent.v1.AgentMessage.hello:type_name -> provisioner.agent.v1.Hello
+	5,  // 1: provisioner.agent.v1.AgentMessage.response:type_name -> provisioner.agent.v1.Response
+	3,  // 2: provisioner.agent.v1.HubMessage.welcome:type_name -> provisioner.agent.v1.Welcome
+	4,  // 3: provisioner.agent.v1.HubMessage.request:type_name -> provisioner.agent.v1.Request
+	6,  // 4: provisioner.agent.v1.Request.run:type_name -> provisioner.agent.v1.OperationRequest
+	8,  // 5: provisioner.agent.v1.Request.output:type_name -> provisioner.agent.v1.WorkspaceRequest
+	8,  // 6: provisioner.agent.v1.Request.cancel:type_name -> provisioner.agent.v1.WorkspaceRequest
+	7,  // 7: provisioner.agent.v1.Response.run:type_name -> provisioner.agent.v1.OperationReply
+	9,  // 8: provisioner.agent.v1.Response.output:type_name -> provisioner.agent.v1.OutputReply
+	11, // 9: provisioner.agent.v1.Response.cancel:type_name -> provisioner.agent.v1.CancelReply
+	13, // 10: provisioner.agent.v1.OperationReply.timed_out:type_name -> google.protobuf.Duration
+	12, // 11: provisioner.agent.v1.OutputReply.outputs:type_name -> provisioner.agent.v1.OutputReply.OutputsEntry
+	10, // 12: provisioner.agent.v1.OutputReply.OutputsEntry.value:type_name -> provisioner.agent.v1.OutputValue
+	0,  // 13: provisioner.agent.v1.AgentHub.Connect:input_type -> provisioner.agent.v1.AgentMessage
+	1,  // 14: provisioner.agent.v1.AgentHub.Connect:output_type -> provisioner.agent.v1.HubMessage
+	14, // [14:15] is the sub-list for method output_type
+	13, // [13:14] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_pkg_agent_agentpb_agent_proto_init() }
+func file_pkg_agent_agentpb_agent_proto_init() {
+	if File_pkg_agent_agentpb_agent_proto != nil {
+		return
+	}
+	file_pkg_agent_agentpb_agent_proto_msgTypes[0].OneofWrappers = []any{
+		(*AgentMessage_Hello)(nil),
+		(*AgentMessage_Response)(nil),
+	}
+	file_pkg_agent_agentpb_agent_proto_msgTypes[1].OneofWrappers = []any{
+		(*HubMessage_Welcome)(nil),
+		(*HubMessage_Request)(nil),
+	}
+	file_pkg_agent_agentpb_agent_proto_msgTypes[4].OneofWrappers = []any{
+		(*Request_Run)(nil),
+		(*Request_Output)(nil),
+		(*Request_Cancel)(nil),
+	}
+	file_pkg_agent_agentpb_agent_proto_msgTypes[5].OneofWrappers = []any{
+		(*Response_Run)(nil),
+		(*Response_Output)(nil),
+		(*Response_Cancel)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_agent_agentpb_agent_proto_rawDesc), len(file_pkg_agent_agentpb_agent_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pkg_agent_agentpb_agent_proto_goTypes,
+		DependencyIndexes: file_pkg_agent_agentpb_agent_proto_depIdxs,
+		MessageInfos:      file_pkg_agent_agentpb_agent_proto_msgTypes,
+	}.Build()
+	File_pkg_agent_agentpb_agent_proto = out.File
+	file_pkg_agent_agentpb_agent_proto_goTypes = nil
+	file_pkg_agent_agentpb_agent_proto_depIdxs = nil
+}
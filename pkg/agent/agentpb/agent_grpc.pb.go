@@ -0,0 +1,130 @@
+// Service remote agents connect to on the daemon. Agents need no inbound access: the agent opens
+// the stream, introduces itself, and the daemon then sends it requests over that stream.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: pkg/agent/agentpb/agent.proto
+
+package agentpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AgentHub_Connect_FullMethodName = "/provisioner.agent.v1.AgentHub/Connect"
+)
+
+// AgentHubClient is the client API for AgentHub service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// AgentHub is served by the daemon to its agents
+type AgentHubClient interface {
+	// Connect opens the stream of an agent. The agent sends a Hello first; the daemon answers with
+	// a Welcome or ends the stream with an error: PERMISSION_DENIED for good, FAILED_PRECONDITION if
+	// the agent may be accepted later. The daemon then sends requests that the agent answers with a
+	// response carrying the same id, in any order.
+	Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AgentMessage, HubMessage], error)
+}
+
+type agentHubClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAgentHubClient(cc grpc.ClientConnInterface) AgentHubClient {
+	return &agentHubClient{cc}
+}
+
+func (c *agentHubClient) Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AgentMessage, HubMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AgentHub_ServiceDesc.Streams[0], AgentHub_Connect_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AgentMessage, HubMessage]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentHub_ConnectClient = grpc.BidiStreamingClient[AgentMessage, HubMessage]
+
+// AgentHubServer is the server API for AgentHub service.
+// All implementations must embed UnimplementedAgentHubServer
+// for forward compatibility.
+//
+// AgentHub is served by the daemon to its agents
+type AgentHubServer interface {
+	// Connect opens the stream of an agent. The agent sends a Hello first; the daemon answers with
+	// a Welcome or ends the stream with an error: PERMISSION_DENIED for good, FAILED_PRECONDITION if
+	// the agent may be accepted later. The daemon then sends requests that the agent answers with a
+	// response carrying the same id, in any order.
+	Connect(grpc.BidiStreamingServer[AgentMessage, HubMessage]) error
+	mustEmbedUnimplementedAgentHubServer()
+}
+
+// UnimplementedAgentHubServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAgentHubServer struct{}
+
+func (UnimplementedAgentHubServer) Connect(grpc.BidiStreamingServer[AgentMessage, HubMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedAgentHubServer) mustEmbedUnimplementedAgentHubServer() {}
+func (UnimplementedAgentHubServer) testEmbeddedByValue()                  {}
+
+// UnsafeAgentHubServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AgentHubServer will
+// result in compilation errors.
+type UnsafeAgentHubServer interface {
+	mustEmbedUnimplementedAgentHubServer()
+}
+
+func RegisterAgentHubServer(s grpc.ServiceRegistrar, srv AgentHubServer) {
+	// If the following call pancis, it indicates UnimplementedAgentHubServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AgentHub_ServiceDesc, srv)
+}
+
+func _AgentHub_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AgentHubServer).Connect(&grpc.GenericServerStream[AgentMessage, HubMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AgentHub_ConnectServer = grpc.BidiStreamingServer[AgentMessage, HubMessage]
+
+// AgentHub_ServiceDesc is the grpc.ServiceDesc for AgentHub service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AgentHub_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "provisioner.agent.v1.AgentHub",
+	HandlerType: (*AgentHubServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _AgentHub_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "pkg/agent/agentpb/agent.proto",
+}
@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/paths"
+)
+
+// RunListCommand lists the agents connected to the daemon
+func RunListCommand(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("agents takes no arguments")
+	}
+
+	agents, err := LoadAgents(paths.StateDir())
+	if err != nil {
+		return err
+	}
+	if len(agents) == 0 {
+		fmt.Println("No agents connected")
+		return nil
+	}
+
+	fmt.Printf("%-20s %-24s %-12s %-25s %s\n", "AGENT", "HOST", "VERSION", "CONNECTED", "RUNNING")
+	for _, agent := range agents {
+		running := "-"
+		if len(agent.Operations) > 0 {
+			running = strings.Join(agent.Operations, ", ")
+		}
+		fmt.Printf("%-20s %-24s %-12s %-25s %s\n", agent.Name, agent.Hostname, agent.Version, logging.FormatTime(agent.ConnectedAt), running)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"provisioner/pkg/agent/agentpb"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+// Client runs the operations of workspaces assigned to an agent on that agent and everything else
+// with the daemon's own OpenTofu client. Jobs always run on the daemon host.
+type Client struct {
+	hub     *Hub
+	local   opentofu.TofuClient
+	agentOf func(workspaceName string) string // Agent a workspace is assigned to, empty for the daemon host
+}
+
+// Ensure Client implements TofuClient interface
+var _ opentofu.TofuClient = (*Client)(nil)
+
+// Client returns an OpenTofu client sending the operations of agent workspaces to their agents.
+// agentOf looks up the agent of a workspace by name, for requests that only name the workspace.
+func (h *Hub) Client(local opentofu.TofuClient, agentOf func(workspaceName string) string) *Client {
+	return &Client{hub: h, local: local, agentOf: agentOf}
+}
+
+func (c *Client) Deploy(ws *workspace.Workspace) error {
+	if ws.Config.Agent == "" {
+		return c.local.Deploy(ws)
+	}
+	return c.run(ws, OperationDeploy, "")
+}
+
+func (c *Client) DeployInMode(ws *workspace.Workspace, mode string) error {
+	if ws.Config.Agent == "" {
+		return c.local.DeployInMode(ws, mode)
+	}
+	return c.run(ws, OperationDeploy, mode)
+}
+
+func (c *Client) DestroyWorkspace(ws *workspace.Workspace) error {
+	if ws.Config.Agent == "" {
+		return c.local.DestroyWorkspace(ws)
+	}
+	return c.run(ws, OperationDestroy, "")
+}
+
+func (c *Client) RefreshWorkspace(ws *workspace.Workspace, mode string) error {
+	if ws.Config.Agent == "" {
+		return c.local.RefreshWorkspace(ws, mode)
+	}
+	return c.run(ws, OperationRefresh, mode)
+}
+
+// RefreshOutputs refreshes a workspace and returns its outputs, on its agent if it has one
+func (c *Client) RefreshOutputs(ws *workspace.Workspace, mode string) (map[string]opentofu.OutputValue, error) {
+	if ws.Config.Agent == "" {
+		return c.local.RefreshOutputs(ws, mode)
+	}
+	if err := c.run(ws, OperationRefresh, mode); err != nil {
+		return nil, err
+	}
+	return c.Output(opentofu.GetWorkingDir(ws.Name))
+}
+
+// Cancel cancels a workspace's running operation, on its agent if it has one
+func (c *Client) Cancel(workspaceName string) bool {
+	name := c.agentOf(workspaceName)
+	if name == "" {
+		return c.local.Cancel(workspaceName)
+	}
+	agent := c.hub.agent(name)
+	if agent == nil {
+		return false
+	}
+	response, err := agent.call(&agentpb.Request{Call: &agentpb.Request_Cancel{Cancel: &agentpb.WorkspaceRequest{Workspace: workspaceName}}})
+	if err != nil {
+		logging.LogSystemd("Failed to cancel %s on agent '%s': %v", workspaceName, name, err)
+		return false
+	}
+	return response.GetCancel().GetCancelled()
+}
+
+// Output returns the outputs of the workspace whose deployment directory is workingDir, from its
+// agent if it has one
+func (c *Client) Output(workingDir string) (map[string]opentofu.OutputValue, error) {
+	workspaceName := filepath.Base(workingDir)
+	name := c.agentOf(workspaceName)
+	if name == "" {
+		return c.local.Output(workingDir)
+	}
+	agent := c.hub.agent(name)
+	if agent == nil {
+		return nil, fmt.Errorf("agent '%s' is not connected", name)
+	}
+	response, err := agent.call(&agentpb.Request{Call: &agentpb.Request_Output{Output: &agentpb.WorkspaceRequest{Workspace: workspaceName}}})
+	if err != nil {
+		return nil, fmt.Errorf("agent '%s': %w", name, err)
+	}
+	return outputsFromProto(response.GetOutput().GetOutputs()), nil
+}
+
+// Low-level operations run the daemon host's workspace jobs
+
+func (c *Client) Init(workingDir string) error {
+	return c.local.Init(workingDir)
+}
+
+func (c *Client) Plan(workingDir string) error {
+	return c.local.Plan(workingDir)
+}
+
+func (c *Client) Apply(workingDir string) error {
+	return c.local.Apply(workingDir)
+}
+
+func (c *Client) Destroy(workingDir string) error {
+	return c.local.Destroy(workingDir)
+}
+
+func (c *Client) PlanWithMode(workingDir, mode string) error {
+	return c.local.PlanWithMode(workingDir, mode)
+}
+
+func (c *Client) ApplyWithMode(workingDir, mode string) error {
+	return c.local.ApplyWithMode(workingDir, mode)
+}
+
+// run sends an operation to the workspace's agent and waits for its outcome
+func (c *Client) run(ws *workspace.Workspace, operation, mode string) error {
+	name := ws.Config.Agent
+	agent := c.hub.agent(name)
+	if agent == nil {
+		return fmt.Errorf("agent '%s' is not connected", name)
+	}
+	defer c.hub.track(agent, ws.Name)()
+
+	logging.LogWorkspaceOperation(ws.Name, "AGENT", "Running %s on agent '%s' (%s)", operation, name, agent.info.Hostname)
+	request := &agentpb.OperationRequest{Workspace: ws.Name, Operation: operation, Mode: mode, CorrelationId: logging.CorrelationID(ws.Name)}
+	response, err := agent.call(&agentpb.Request{Call: &agentpb.Request_Run{Run: request}})
+	if err != nil {
+		// The operation may still be running or have finished on the agent
+		return fmt.Errorf("lost agent '%s' during %s, check the workspace log on %s: %w", name, operation, agent.info.Hostname, err)
+	}
+	return operationError(response.GetRun())
+}
@@ -0,0 +1,369 @@
+package agent
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"provisioner/pkg/agent/agentpb"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/paths"
+	"provisioner/pkg/workspace"
+)
+
+// Hub accepts the connections of agents and hands them the operations of their workspaces
+type Hub struct {
+	agentpb.UnimplementedAgentHubServer
+
+	addr       string
+	token      string
+	tlsConfig  *tls.Config
+	stateDir   string
+	listener   net.Listener
+	grpcServer *grpc.Server
+	accepting  func() error // Refuses agents for the time being, e.g. at a standby daemon
+
+	mu     sync.Mutex
+	agents map[string]*connection // Connected agents by name
+	saveMu sync.Mutex             // Keeps agents.json writes in order
+}
+
+// connection is the stream of an agent connected to the hub
+type connection struct {
+	info    Info
+	stream  agentpb.AgentHub_ConnectServer
+	welcome bool // The agent was welcomed and takes requests
+
+	sendMu sync.Mutex
+	done   chan struct{} // Closed when the stream ended
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]chan *agentpb.Response // Requests waiting for their response by id
+}
+
+// NewHub creates a hub listening on addr for agents presenting token, serving TLS with the
+// certificate and key in certFile and keyFile
+func NewHub(addr, token, certFile, keyFile string) (*Hub, error) {
+	if token == "" {
+		return nil, fmt.Errorf("agents require a token")
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, fmt.Errorf("agents require a TLS certificate and key")
+	}
+	certificate, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	return &Hub{
+		addr:      addr,
+		token:     token,
+		tlsConfig: &tls.Config{Certificates: []tls.Certificate{certificate}, MinVersion: tls.VersionTLS12},
+		stateDir:  paths.StateDir(),
+		agents:    make(map[string]*connection),
+	}, nil
+}
+
+// Start listens for agents in the background
+func (h *Hub) Start() error {
+	listener, err := net.Listen("tcp", h.addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for agents: %w", err)
+	}
+	h.listener = listener
+
+	// Connections that stop answering pings are dropped, and with them their agent. Close waits
+	// for the streams to end so that their agents are unregistered once it returns.
+	h.grpcServer = grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(h.tlsConfig)),
+		grpc.KeepaliveParams(keepalive.ServerParameters{Time: pingInterval, Timeout: pingInterval}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: pingInterval / 2}),
+		grpc.WaitForHandlers(true),
+	)
+	agentpb.RegisterAgentHubServer(h.grpcServer, h)
+
+	// Agents recorded by a daemon that died are gone; a standby leaves the leader's record alone
+	if h.accepting == nil || h.accepting() == nil {
+		h.saveState()
+	}
+
+	go func() { _ = h.grpcServer.Serve(listener) }()
+
+	logging.LogSystemd("Agent listener on %s", listener.Addr())
+	return nil
+}
+
+// AcceptWhen makes the hub refuse agents while check returns an error; refused agents retry
+func (h *Hub) AcceptWhen(check func() error) {
+	h.accepting = check
+}
+
+// Addr returns the address the hub listens on (for testing)
+func (h *Hub) Addr() string {
+	return h.listener.Addr().String()
+}
+
+// Close stops listening and disconnects all agents
+func (h *Hub) Close() error {
+	if h.listener == nil {
+		return nil
+	}
+	h.grpcServer.Stop()
+	return nil
+}
+
+// Agents returns the connected agents sorted by name
+func (h *Hub) Agents() []Info {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	agents := make([]Info, 0, len(h.agents))
+	for _, agent := range h.agents {
+		info := agent.info
+		info.Operations = append([]string(nil), agent.info.Operations...)
+		agents = append(agents, info)
+	}
+	sort.Slice(agents, func(i, j int) bool { return agents[i].Name < agents[j].Name })
+	return agents
+}
+
+// Connect serves the stream of an agent: it checks the agent's hello, registers it and delivers
+// its responses until the stream ends
+func (h *Hub) Connect(stream agentpb.AgentHub_ConnectServer) error {
+	address := ""
+	if p, ok := peer.FromContext(stream.Context()); ok {
+		address = p.Addr.String()
+	}
+
+	greeting, err := receiveHello(stream)
+	if err != nil {
+		logging.LogSystemd("Agent connection from %s rejected: invalid hello", address)
+		return status.Error(codes.InvalidArgument, "invalid hello")
+	}
+
+	agent := &connection{
+		info:    Info{Name: greeting.Name, Hostname: greeting.Hostname, Version: greeting.Version, Address: address, ConnectedAt: time.Now()},
+		stream:  stream,
+		done:    make(chan struct{}),
+		pending: make(map[uint64]chan *agentpb.Response),
+	}
+	if err := h.admit(agent, greeting.Token); err != nil {
+		logging.LogSystemd("Agent '%s' from %s rejected: %v", greeting.Name, address, err)
+		code := codes.PermissionDenied
+		var later *laterError
+		if errors.As(err, &later) {
+			code = codes.FailedPrecondition
+		}
+		return status.Error(code, err.Error())
+	}
+	defer agent.close()
+
+	if err := agent.send(&agentpb.HubMessage{Message: &agentpb.HubMessage_Welcome{Welcome: &agentpb.Welcome{}}}); err != nil {
+		h.disconnect(agent, err)
+		return err
+	}
+	h.mu.Lock()
+	agent.welcome = true
+	h.mu.Unlock()
+	h.saveState()
+
+	logging.LogSystemd("Agent '%s' connected from %s (%s, version %s)", agent.info.Name, agent.info.Address, agent.info.Hostname, agent.info.Version)
+	h.disconnect(agent, agent.receive())
+	return nil
+}
+
+// receiveHello waits for the hello an agent sends first on its stream
+func receiveHello(stream agentpb.AgentHub_ConnectServer) (*agentpb.Hello, error) {
+	type received struct {
+		message *agentpb.AgentMessage
+		err     error
+	}
+	first := make(chan received, 1)
+	go func() {
+		message, err := stream.Recv()
+		first <- received{message, err}
+	}()
+
+	select {
+	case r := <-first:
+		if r.err != nil {
+			return nil, r.err
+		}
+		if hello := r.message.GetHello(); hello != nil {
+			return hello, nil
+		}
+		return nil, fmt.Errorf("first message is not a hello")
+	case <-time.After(handshakeTimeout):
+		return nil, fmt.Errorf("no hello within %v", handshakeTimeout)
+	}
+}
+
+// laterError refuses an agent that may be accepted when it tries again
+type laterError struct {
+	reason string
+}
+
+func (e *laterError) Error() string {
+	return e.reason
+}
+
+// admit registers an agent presenting token, refusing a second agent of the same name
+func (h *Hub) admit(agent *connection, token string) error {
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+		return fmt.Errorf("invalid token")
+	}
+	if !workspace.AgentNamePattern.MatchString(agent.info.Name) {
+		return fmt.Errorf("invalid agent name '%s'", agent.info.Name)
+	}
+	if h.accepting != nil {
+		if err := h.accepting(); err != nil {
+			return &laterError{reason: err.Error()}
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if existing := h.agents[agent.info.Name]; existing != nil {
+		// The old connection may be a dead one the next ping removes
+		return &laterError{reason: fmt.Sprintf("agent '%s' is already connected from %s", agent.info.Name, existing.info.Address)}
+	}
+	h.agents[agent.info.Name] = agent
+	return nil
+}
+
+// disconnect unregisters an agent whose stream ended
+func (h *Hub) disconnect(agent *connection, reason error) {
+	h.mu.Lock()
+	registered := h.agents[agent.info.Name] == agent
+	if registered {
+		delete(h.agents, agent.info.Name)
+	}
+	h.mu.Unlock()
+
+	if registered {
+		h.saveState()
+		logging.LogSystemd("Agent '%s' disconnected: %v", agent.info.Name, reason)
+	}
+}
+
+// agent returns the connected agent with the given name, nil if it is not connected or still
+// being welcomed
+func (h *Hub) agent(name string) *connection {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if agent := h.agents[name]; agent != nil && agent.welcome {
+		return agent
+	}
+	return nil
+}
+
+// track records that an agent runs an operation of a workspace until the returned function is called
+func (h *Hub) track(agent *connection, workspaceName string) func() {
+	h.mu.Lock()
+	agent.info.Operations = append(agent.info.Operations, workspaceName)
+	h.mu.Unlock()
+	h.saveState()
+
+	return func() {
+		h.mu.Lock()
+		for i, name := range agent.info.Operations {
+			if name == workspaceName {
+				agent.info.Operations = append(agent.info.Operations[:i], agent.info.Operations[i+1:]...)
+				break
+			}
+		}
+		h.mu.Unlock()
+		h.saveState()
+	}
+}
+
+// saveState records the connected agents for provisioner agents
+func (h *Hub) saveState() {
+	h.saveMu.Lock()
+	defer h.saveMu.Unlock()
+	if err := saveAgents(h.stateDir, h.Agents()); err != nil {
+		logging.LogSystemd("Failed to record agents: %v", err)
+	}
+}
+
+// send sends a message to the agent, failing once its stream ended
+func (c *connection) send(message *agentpb.HubMessage) error {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	select {
+	case <-c.done:
+		return fmt.Errorf("connection closed")
+	default:
+	}
+	return c.stream.Send(message)
+}
+
+// close marks the stream as ended, failing the requests still waiting for a response
+func (c *connection) close() {
+	c.sendMu.Lock()
+	defer c.sendMu.Unlock()
+	close(c.done)
+}
+
+// receive delivers the agent's responses to the requests waiting for them until the stream ends
+func (c *connection) receive() error {
+	for {
+		message, err := c.stream.Recv()
+		if err != nil {
+			return err
+		}
+		response := message.GetResponse()
+		if response == nil {
+			continue
+		}
+		c.mu.Lock()
+		waiting := c.pending[response.Id]
+		delete(c.pending, response.Id)
+		c.mu.Unlock()
+		if waiting != nil {
+			waiting <- response
+		}
+	}
+}
+
+// call sends a request to the agent and waits for its response; a response with an error fails
+// the call
+func (c *connection) call(request *agentpb.Request) (*agentpb.Response, error) {
+	waiting := make(chan *agentpb.Response, 1)
+	c.mu.Lock()
+	c.nextID++
+	request.Id = c.nextID
+	c.pending[request.Id] = waiting
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, request.Id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.send(&agentpb.HubMessage{Message: &agentpb.HubMessage_Request{Request: request}}); err != nil {
+		return nil, err
+	}
+	var response *agentpb.Response
+	select {
+	case response = <-waiting:
+	case <-c.done:
+		return nil, fmt.Errorf("connection closed")
+	}
+	if response.Error != "" {
+		return nil, errors.New(response.Error)
+	}
+	return response, nil
+}
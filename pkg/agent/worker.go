@@ -0,0 +1,304 @@
+package agent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+
+	"provisioner/pkg/agent/agentpb"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/version"
+	"provisioner/pkg/workspace"
+)
+
+// Reconnection delays after the connection to the daemon failed
+const (
+	minReconnectDelay = 5 * time.Second
+	maxReconnectDelay = time.Minute
+)
+
+// Options configures how an agent connects to the daemon
+type Options struct {
+	Name    string   // Agent name workspaces refer to
+	Servers []string // Addresses of the agent listeners, HOST:PORT, tried in turn (e.g. of daemons sharing a state directory)
+	Token   string   // Shared token of the daemon's agents
+	CAFile  string   // CA certificate verifying the daemon, the system roots if empty
+}
+
+// Validate checks the options for missing or invalid values
+func (o *Options) Validate() error {
+	if !workspace.AgentNamePattern.MatchString(o.Name) {
+		return fmt.Errorf("agent name '%s' must contain only letters, numbers, '-' and '_'", o.Name)
+	}
+	if len(o.Servers) == 0 {
+		return fmt.Errorf("agents require a server address")
+	}
+	for _, server := range o.Servers {
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			return fmt.Errorf("invalid server address '%s': %w", server, err)
+		}
+	}
+	if o.Token == "" {
+		return fmt.Errorf("agents require a token")
+	}
+	return nil
+}
+
+// Worker runs the operations the daemon sends to an agent over the agent's stream
+type Worker struct {
+	name   string
+	client opentofu.TofuClient
+	load   func(workspaceName string) (*workspace.Workspace, error)
+}
+
+// NewWorker creates the worker of the named agent running operations with client. Workspaces are
+// loaded from the agent's own config directory, which must hold the configs of its workspaces.
+func NewWorker(name string, client opentofu.TofuClient) *Worker {
+	return &Worker{name: name, client: client, load: loadWorkspace}
+}
+
+// loadWorkspace loads a workspace from the workspaces directory
+func loadWorkspace(workspaceName string) (*workspace.Workspace, error) {
+	workspaces, err := workspace.LoadWorkspaces(workspace.GetDefaultWorkspacesDir())
+	if err != nil {
+		return nil, err
+	}
+	for i := range workspaces {
+		if workspaces[i].Name == workspaceName {
+			return &workspaces[i], nil
+		}
+	}
+	return nil, fmt.Errorf("workspace '%s' not found on agent", workspaceName)
+}
+
+// handle answers a request of the daemon
+func (w *Worker) handle(request *agentpb.Request) *agentpb.Response {
+	response := &agentpb.Response{Id: request.Id}
+	switch call := request.Call.(type) {
+	case *agentpb.Request_Run:
+		response.Result = &agentpb.Response_Run{Run: w.Run(call.Run)}
+	case *agentpb.Request_Output:
+		reply, err := w.Output(call.Output)
+		if err != nil {
+			response.Error = err.Error()
+		} else {
+			response.Result = &agentpb.Response_Output{Output: reply}
+		}
+	case *agentpb.Request_Cancel:
+		response.Result = &agentpb.Response_Cancel{Cancel: w.Cancel(call.Cancel)}
+	default:
+		response.Error = "unknown request"
+	}
+	return response
+}
+
+// Run runs a deploy, destroy or refresh of a workspace
+func (w *Worker) Run(request *agentpb.OperationRequest) *agentpb.OperationReply {
+	ws, err := w.load(request.Workspace)
+	if err != nil {
+		return &agentpb.OperationReply{Error: err.Error()}
+	}
+
+	if request.CorrelationId != "" {
+		logging.SetCorrelationID(ws.Name, request.CorrelationId)
+		defer logging.ClearCorrelationID(ws.Name)
+	}
+	logging.LogSystemd("Running %s of %s for the daemon", request.Operation, ws.Name)
+
+	switch request.Operation {
+	case OperationDeploy:
+		if request.Mode != "" {
+			err = w.client.DeployInMode(ws, request.Mode)
+		} else {
+			err = w.client.Deploy(ws)
+		}
+	case OperationDestroy:
+		err = w.client.DestroyWorkspace(ws)
+	case OperationRefresh:
+		err = w.client.RefreshWorkspace(ws, request.Mode)
+	default:
+		err = fmt.Errorf("unknown operation '%s'", request.Operation)
+	}
+
+	logging.LogSystemd("Finished %s of %s: %s", request.Operation, ws.Name, outcome(err))
+	return operationReply(err)
+}
+
+// Output returns the outputs of a workspace's state
+func (w *Worker) Output(request *agentpb.WorkspaceRequest) (*agentpb.OutputReply, error) {
+	if _, err := w.load(request.Workspace); err != nil {
+		return nil, err
+	}
+	outputs, err := w.client.Output(opentofu.GetWorkingDir(request.Workspace))
+	if err != nil {
+		return nil, err
+	}
+	return &agentpb.OutputReply{Outputs: outputsToProto(outputs)}, nil
+}
+
+// Cancel cancels the running operation of a workspace
+func (w *Worker) Cancel(request *agentpb.WorkspaceRequest) *agentpb.CancelReply {
+	return &agentpb.CancelReply{Cancelled: w.client.Cancel(request.Workspace)}
+}
+
+// outcome describes an operation's error for the agent log
+func outcome(err error) string {
+	if err != nil {
+		return err.Error()
+	}
+	return "succeeded"
+}
+
+// Connect keeps the agent connected to the daemon until stop is closed, trying the servers in turn
+// and backing off when none accepts it. It only returns early if a daemon rejects the agent for
+// good, e.g. for an invalid token.
+func Connect(opts Options, worker *Worker, stop <-chan struct{}) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	var rootCAs *x509.CertPool
+	if opts.CAFile != "" {
+		pem, err := os.ReadFile(opts.CAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in %s", opts.CAFile)
+		}
+	}
+
+	delay := minReconnectDelay
+	for attempt := 0; ; attempt++ {
+		server := opts.Servers[attempt%len(opts.Servers)]
+		connected, err := serve(opts, server, rootCAs, worker, stop)
+		var rejected *RejectedError
+		if errors.As(err, &rejected) && !rejected.Retry {
+			return err
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+		if connected {
+			delay = minReconnectDelay
+		}
+
+		// Try the next server right away, and wait once all of them failed
+		if (attempt+1)%len(opts.Servers) != 0 {
+			logging.LogSystemd("Connection to %s failed, trying the next server: %v", server, err)
+			continue
+		}
+		logging.LogSystemd("Connection to %s failed, retrying in %v: %v", server, delay, err)
+		select {
+		case <-stop:
+			return nil
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > maxReconnectDelay {
+			delay = maxReconnectDelay
+		}
+	}
+}
+
+// RejectedError is returned when a daemon refuses the agent
+type RejectedError struct {
+	Reason string
+	Retry  bool // The daemon may accept the agent later, e.g. once it leads
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("daemon rejected the agent: %s", e.Reason)
+}
+
+// serve connects to the daemon once and serves its requests until the stream ends or stop is
+// closed. It reports whether the daemon accepted the agent.
+func serve(opts Options, server string, rootCAs *x509.CertPool, worker *Worker, stop <-chan struct{}) (bool, error) {
+	host, _, _ := net.SplitHostPort(server)
+	tlsConfig := &tls.Config{ServerName: host, RootCAs: rootCAs, MinVersion: tls.VersionTLS12}
+	conn, err := grpc.NewClient(server,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: pingInterval, Timeout: pingInterval}),
+	)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	stream, err := agentpb.NewAgentHubClient(conn).Connect(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// The daemon answers the hello with a welcome, or ends the stream with why it refused the agent
+	hostname, _ := os.Hostname()
+	handshake := time.AfterFunc(handshakeTimeout, cancel)
+	hello := &agentpb.Hello{Name: opts.Name, Token: opts.Token, Hostname: hostname, Version: version.GetVersion()}
+	err = stream.Send(&agentpb.AgentMessage{Message: &agentpb.AgentMessage_Hello{Hello: hello}})
+	var answer *agentpb.HubMessage
+	if err == nil {
+		answer, err = stream.Recv()
+	}
+	handshake.Stop()
+	if err != nil {
+		switch status.Code(err) {
+		case codes.PermissionDenied, codes.InvalidArgument:
+			return false, &RejectedError{Reason: status.Convert(err).Message()}
+		case codes.FailedPrecondition:
+			return false, &RejectedError{Reason: status.Convert(err).Message(), Retry: true}
+		}
+		return false, fmt.Errorf("no welcome from daemon: %w", err)
+	}
+	if answer.GetWelcome() == nil {
+		return false, fmt.Errorf("no welcome from daemon")
+	}
+	logging.LogSystemd("Connected to %s as agent '%s'", server, opts.Name)
+
+	// Requests are handled concurrently, so that a cancel reaches the operation it cancels
+	var sendMu sync.Mutex
+	for {
+		message, err := stream.Recv()
+		if err != nil {
+			select {
+			case <-stop:
+				return true, nil
+			default:
+			}
+			return true, fmt.Errorf("daemon closed the connection: %w", err)
+		}
+		request := message.GetRequest()
+		if request == nil {
+			continue
+		}
+		go func() {
+			response := worker.handle(request)
+			sendMu.Lock()
+			defer sendMu.Unlock()
+			_ = stream.Send(&agentpb.AgentMessage{Message: &agentpb.AgentMessage_Response{Response: response}})
+		}()
+	}
+}
@@ -0,0 +1,211 @@
+// Package audit keeps an append-only log of the operations the daemon and the CLIs perform:
+// deploys, destroys, job runs and kills, and environment switches, with what triggered them
+// and how they ended. Each line of the log is one JSON entry.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// FileName is the audit log file name in the state directory
+const FileName = "audit.jsonl"
+
+// Audited operations
+const (
+	OperationDeploy            = "deploy"
+	OperationDestroy           = "destroy"
+	OperationJobRun            = "job-run"
+	OperationJobKill           = "job-kill"
+	OperationJobDestroy        = "job-destroy" // Destroy of a template job's deployment
+	OperationEnvironmentSwitch = "environment-switch"
+)
+
+// Outcomes of audited operations
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeCancelled = "cancelled"
+	OutcomeTimedOut  = "timed_out" // Stopped after deploy_timeout or destroy_timeout
+	OutcomeDegraded  = "degraded"  // Deployed, but a post_deploy hook failed
+)
+
+// Sources that trigger operations
+const (
+	SourceSchedule  = "schedule"  // Workspace or job schedules, retries, lifetimes and idle checks
+	SourceEvent     = "event"     // Jobs run on deployment events, the event is the actor
+	SourceCLI       = "cli"       // The CLIs, directly or through the daemon; the OS user is the actor
+	SourceWebhook   = "webhook"   // Webhook triggers; the webhook name is the actor
+	SourceDashboard = "dashboard" // The web dashboard; the client address is the actor
+)
+
+// Trigger describes who or what started an operation
+type Trigger struct {
+	Source string `json:"trigger"`
+	Actor  string `json:"actor,omitempty"`
+}
+
+// Entry is one audited operation
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Operation   string    `json:"operation"`
+	Workspace   string    `json:"workspace,omitempty"`
+	Job         string    `json:"job,omitempty"`
+	Environment string    `json:"environment,omitempty"`
+	Mode        string    `json:"mode,omitempty"`
+	Trigger
+	Outcome       string `json:"outcome"`
+	Error         string `json:"error,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
+
+// Filter selects entries when reading the audit log
+type Filter struct {
+	Workspace string    // Only entries of this workspace, all if empty
+	Since     time.Time // Only entries recorded at or after this time, all if zero
+}
+
+// Matches reports whether an entry is selected by the filter
+func (f Filter) Matches(entry Entry) bool {
+	if f.Workspace != "" && entry.Workspace != f.Workspace {
+		return false
+	}
+	return f.Since.IsZero() || !entry.Time.Before(f.Since)
+}
+
+// Log is the audit log in a state directory
+type Log struct {
+	path string
+}
+
+// NewLog returns the audit log in stateDir
+func NewLog(stateDir string) *Log {
+	return &Log{path: filepath.Join(stateDir, FileName)}
+}
+
+// Path returns the audit log file path
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record appends an entry, timestamped now unless it has a time. Failing to write the audit log
+// never fails the operation; the error is logged instead.
+func (l *Log) Record(entry Entry) {
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	entry.Error = logging.RedactWorkspace(entry.Workspace, entry.Error)
+
+	if err := l.append(entry); err != nil {
+		logging.LogSystemd("Failed to write audit log: %v", err)
+	}
+}
+
+// append writes an entry as a single line. O_APPEND writes of a line are not interleaved with
+// those of the daemon or other CLIs.
+func (l *Log) append(entry Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Read returns the entries selected by filter, oldest first. Lines that are not valid entries,
+// such as one cut short by a crash, are skipped.
+func (l *Log) Read(filter Filter) ([]Entry, error) {
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+var (
+	triggers  = make(map[string]Trigger)
+	triggerMu sync.RWMutex
+)
+
+// SetTrigger records what started the operation running for key, a workspace name or JobKey,
+// until it is cleared
+func SetTrigger(key string, trigger Trigger) {
+	triggerMu.Lock()
+	defer triggerMu.Unlock()
+	triggers[key] = trigger
+}
+
+// CurrentTrigger returns what started the operation running for key, if it was set
+func CurrentTrigger(key string) (Trigger, bool) {
+	triggerMu.RLock()
+	defer triggerMu.RUnlock()
+	trigger, ok := triggers[key]
+	return trigger, ok
+}
+
+// ClearTrigger removes the trigger of key once its operation has finished
+func ClearTrigger(key string) {
+	triggerMu.Lock()
+	defer triggerMu.Unlock()
+	delete(triggers, key)
+}
+
+// JobKey returns the trigger key of a job; standalone jobs use their workspace ID as well
+func JobKey(workspaceID, jobName string) string {
+	return workspaceID + "/" + jobName
+}
+
+// CLITrigger returns the trigger of an operation run by the CLI in this process
+func CLITrigger() Trigger {
+	return Trigger{Source: SourceCLI, Actor: CurrentUser()}
+}
+
+// CurrentUser returns the name of the user running this process
+func CurrentUser() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return fmt.Sprintf("uid %d", os.Getuid())
+}
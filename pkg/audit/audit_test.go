@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndRead(t *testing.T) {
+	log := NewLog(t.TempDir())
+	start := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	log.Record(Entry{Time: start, Operation: OperationDeploy, Workspace: "web", Trigger: Trigger{Source: SourceSchedule}, Outcome: OutcomeSucceeded})
+	log.Record(Entry{Time: start.Add(time.Hour), Operation: OperationJobRun, Workspace: "api", Job: "backup", Trigger: Trigger{Source: SourceCLI, Actor: "alice"}, Outcome: OutcomeFailed, Error: "exit status 1"})
+	log.Record(Entry{Time: start.Add(2 * time.Hour), Operation: OperationDestroy, Workspace: "web", Trigger: Trigger{Source: SourceWebhook, Actor: "ci"}, Outcome: OutcomeSucceeded})
+
+	entries, err := log.Read(Filter{})
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 3 || entries[1].Actor != "alice" || entries[1].Error != "exit status 1" {
+		t.Fatalf("Unexpected entries: %+v", entries)
+	}
+
+	entries, err = log.Read(Filter{Workspace: "web", Since: start.Add(30 * time.Minute)})
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Operation != OperationDestroy || entries[0].Source != SourceWebhook {
+		t.Errorf("Expected only the destroy of web, got %+v", entries)
+	}
+}
+
+func TestReadSkipsPartialLines(t *testing.T) {
+	log := NewLog(t.TempDir())
+	log.Record(Entry{Operation: OperationDeploy, Workspace: "web", Outcome: OutcomeSucceeded})
+
+	// A crash while appending leaves a cut-off line
+	file, err := os.OpenFile(log.Path(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	_, _ = file.WriteString(`{"time":"2026-03-10T09:00:00Z","operat`)
+	_ = file.Close()
+
+	entries, err := log.Read(Filter{})
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Time.IsZero() {
+		t.Errorf("Expected the complete entry with its time set, got %+v", entries)
+	}
+}
+
+func TestReadMissingLog(t *testing.T) {
+	entries, err := NewLog(t.TempDir()).Read(Filter{})
+	if err != nil || len(entries) != 0 {
+		t.Errorf("Expected no entries and no error, got %v, %v", entries, err)
+	}
+}
+
+func TestRecordWritesOneLinePerEntry(t *testing.T) {
+	log := NewLog(t.TempDir())
+	log.Record(Entry{Operation: OperationDeploy, Workspace: "web", Outcome: OutcomeFailed, Error: "line one\nline two"})
+	log.Record(Entry{Operation: OperationDeploy, Workspace: "web", Outcome: OutcomeSucceeded})
+
+	data, err := os.ReadFile(log.Path())
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected 2 lines, got %d:\n%s", lines, data)
+	}
+}
+
+func TestTriggers(t *testing.T) {
+	key := JobKey("web", "backup")
+	if _, ok := CurrentTrigger(key); ok {
+		t.Fatal("Expected no trigger before one is set")
+	}
+
+	SetTrigger(key, Trigger{Source: SourceCLI, Actor: "alice"})
+	if trigger, ok := CurrentTrigger(key); !ok || trigger.Actor != "alice" {
+		t.Errorf("Expected the trigger that was set, got %+v", trigger)
+	}
+
+	ClearTrigger(key)
+	if _, ok := CurrentTrigger(key); ok {
+		t.Error("Expected the trigger to be cleared")
+	}
+}
@@ -0,0 +1,452 @@
+// Package backup writes the provisioner's configuration and state into a gzipped tarball and
+// restores it, for disaster recovery and moving the provisioner to another host. Unlike a
+// support bundle nothing is masked, so a backup holds every secret of the configuration.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/configfile"
+	"provisioner/pkg/control"
+	"provisioner/pkg/paths"
+	"provisioner/pkg/version"
+)
+
+// Sections of a backup, each restorable on its own
+const (
+	SectionWorkspaces   = "workspaces"   // workspaces/ in the config directory, including _defaults
+	SectionJobs         = "jobs"         // Standalone job configs
+	SectionEnvironments = "environments" // Environment configs in the config directory
+	SectionSettings     = "settings"     // provisioner.json and notifications.json
+	SectionTemplates    = "templates"    // Templates and their registry in the state directory
+	SectionState        = "state"        // Scheduler, job and audit state, deploy results and debug toggles
+	SectionDeployments  = "deployments"  // Deployment directories with OpenTofu state, state backups and deploy history
+)
+
+// Sections lists every section in the order they are written
+var Sections = []string{
+	SectionWorkspaces, SectionJobs, SectionEnvironments, SectionSettings,
+	SectionTemplates, SectionState, SectionDeployments,
+}
+
+// ManifestFile describes the backup; it is the first file of the archive
+const ManifestFile = "manifest.json"
+
+// settingsNames are the config files of SectionSettings without their extension
+var settingsNames = []string{"provisioner", "notifications"}
+
+// stateDirs are the state directory's subdirectories of SectionState; the others are caches
+// (tofu, leases), job runs and artifacts, or belong to another section
+var stateDirs = []string{"results", "debug"}
+
+// deploymentDirs are the state directory's subdirectories of SectionDeployments
+var deploymentDirs = []string{"deployments", "state-backups", "history"}
+
+// Options selects the directories and sections of a backup or restore
+type Options struct {
+	ConfigDir string
+	StateDir  string
+	Sections  []string // Sections to include, all if empty
+}
+
+// DefaultOptions returns options using the auto-discovered directories and every section
+func DefaultOptions() Options {
+	return Options{ConfigDir: paths.ConfigDir(), StateDir: paths.StateDir()}
+}
+
+// includes reports whether a section is selected
+func (o Options) includes(section string) bool {
+	return len(o.Sections) == 0 || slices.Contains(o.Sections, section)
+}
+
+// Manifest describes a backup
+type Manifest struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Version   string         `json:"version"`
+	Hostname  string         `json:"hostname,omitempty"`
+	ConfigDir string         `json:"config_dir"`
+	StateDir  string         `json:"state_dir"`
+	Files     map[string]int `json:"files"` // Number of files by section
+}
+
+// file is a file selected for a backup, by its archive name
+type file struct {
+	path    string
+	name    string // Slash-separated, below config/ or state/
+	section string
+}
+
+// Create writes a backup of the selected sections to w and returns its manifest
+func Create(w io.Writer, opts Options) (*Manifest, error) {
+	files, err := collect(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+	manifest := &Manifest{
+		CreatedAt: time.Now(),
+		Version:   version.GetVersion(),
+		Hostname:  hostname,
+		ConfigDir: opts.ConfigDir,
+		StateDir:  opts.StateDir,
+		Files:     make(map[string]int),
+	}
+	for _, f := range files {
+		manifest.Files[f.section]++
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	header := &tar.Header{Name: ManifestFile, Mode: 0644, Size: int64(len(manifestData)), ModTime: manifest.CreatedAt}
+	if err := tw.WriteHeader(header); err != nil {
+		return nil, fmt.Errorf("failed to add %s: %w", ManifestFile, err)
+	}
+	if _, err := tw.Write(manifestData); err != nil {
+		return nil, fmt.Errorf("failed to add %s: %w", ManifestFile, err)
+	}
+
+	for _, f := range files {
+		if err := addFile(tw, f); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// addFile adds a file to the archive with its permissions and modification time
+func addFile(tw *tar.Writer, f file) error {
+	in, err := os.Open(f.path)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", f.name, err)
+	}
+	defer func() { _ = in.Close() }()
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", f.name, err)
+	}
+
+	header := &tar.Header{Name: f.name, Mode: int64(info.Mode().Perm()), Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s: %w", f.name, err)
+	}
+	// A file growing while it is copied is cut at the size in the header
+	if _, err := io.CopyN(tw, in, info.Size()); err != nil {
+		return fmt.Errorf("failed to add %s: %w", f.name, err)
+	}
+	return nil
+}
+
+// collect lists the files of the selected sections
+func collect(opts Options) ([]file, error) {
+	var files []file
+	add := func(root, prefix, section string) error {
+		found, err := walk(root, prefix, section)
+		files = append(files, found...)
+		return err
+	}
+
+	if opts.includes(SectionWorkspaces) {
+		if err := add(filepath.Join(opts.ConfigDir, "workspaces"), "config/workspaces", SectionWorkspaces); err != nil {
+			return nil, err
+		}
+	}
+	if opts.includes(SectionJobs) {
+		if err := add(filepath.Join(opts.ConfigDir, "jobs"), "config/jobs", SectionJobs); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := os.ReadDir(opts.ConfigDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		name := "config/" + entry.Name()
+		if section := sectionOf(name); section != "" && opts.includes(section) {
+			files = append(files, file{path: filepath.Join(opts.ConfigDir, entry.Name()), name: name, section: section})
+		}
+	}
+
+	if opts.includes(SectionTemplates) {
+		if err := add(filepath.Join(opts.StateDir, "templates"), "state/templates", SectionTemplates); err != nil {
+			return nil, err
+		}
+	}
+	if opts.includes(SectionState) {
+		entries, err := os.ReadDir(opts.StateDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read state directory: %w", err)
+		}
+		for _, entry := range entries {
+			name := "state/" + entry.Name()
+			if entry.Type().IsRegular() && sectionOf(name) == SectionState {
+				files = append(files, file{path: filepath.Join(opts.StateDir, entry.Name()), name: name, section: SectionState})
+			}
+		}
+		for _, dir := range stateDirs {
+			if err := add(filepath.Join(opts.StateDir, dir), "state/"+dir, SectionState); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if opts.includes(SectionDeployments) {
+		for _, dir := range deploymentDirs {
+			if err := add(filepath.Join(opts.StateDir, dir), "state/"+dir, SectionDeployments); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// walk lists the regular files below root, skipping provider caches, plans and lock files that
+// the next operation recreates
+func walk(root, prefix, section string) ([]file, error) {
+	var files []file
+	err := filepath.WalkDir(root, func(p string, entry os.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == root {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".terraform" || entry.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() || skipFile(entry.Name()) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, file{path: p, name: prefix + "/" + filepath.ToSlash(rel), section: section})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", root, err)
+	}
+	return files, nil
+}
+
+// skipFile reports whether a file is left out of backups
+func skipFile(name string) bool {
+	return strings.HasSuffix(name, ".tfplan") || strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, ".tmp")
+}
+
+// sectionOf returns the section of a file by its archive name, empty for files no section has
+func sectionOf(name string) string {
+	parts := strings.SplitN(name, "/", 3)
+	if len(parts) < 2 {
+		return ""
+	}
+	switch parts[0] {
+	case "config":
+		if len(parts) == 3 {
+			switch parts[1] {
+			case "workspaces":
+				return SectionWorkspaces
+			case "jobs":
+				return SectionJobs
+			}
+			return ""
+		}
+		base, ok := configfile.TrimExtension(parts[1])
+		if !ok || strings.HasPrefix(parts[1], ".") {
+			return ""
+		}
+		if slices.Contains(settingsNames, base) {
+			return SectionSettings
+		}
+		if base == "config" || strings.Contains(parts[1], "scheduler") || strings.Contains(parts[1], "jobs") {
+			return "" // Skipped by environment.ConfigFiles too
+		}
+		return SectionEnvironments
+	case "state":
+		if len(parts) == 3 {
+			switch {
+			case parts[1] == "templates":
+				return SectionTemplates
+			case slices.Contains(stateDirs, parts[1]):
+				return SectionState
+			case slices.Contains(deploymentDirs, parts[1]):
+				return SectionDeployments
+			}
+			return ""
+		}
+		if parts[1] == control.SocketName || skipFile(parts[1]) || strings.HasSuffix(parts[1], ".bak") {
+			return ""
+		}
+		if filepath.Ext(parts[1]) == ".json" || parts[1] == audit.FileName {
+			return SectionState
+		}
+	}
+	return ""
+}
+
+// RestoreResult describes a restore
+type RestoreResult struct {
+	Manifest    *Manifest
+	Files       map[string]int // Files restored, or that would be with dryRun, by section
+	Overwritten int            // Existing files replaced
+}
+
+// Restore writes the selected sections of the backup in archivePath into the directories of
+// opts. Existing files are only replaced with force; without it nothing is written if any
+// would be. With dryRun nothing is written at all.
+func Restore(archivePath string, opts Options, force, dryRun bool) (*RestoreResult, error) {
+	result := &RestoreResult{Files: make(map[string]int)}
+	var conflicts []string
+
+	// The first pass checks the archive and what would be replaced, the second writes
+	err := readArchive(archivePath, func(header *tar.Header, r io.Reader) error {
+		if header.Name == ManifestFile {
+			manifest := &Manifest{}
+			if err := json.NewDecoder(r).Decode(manifest); err != nil {
+				return fmt.Errorf("invalid %s: %w", ManifestFile, err)
+			}
+			result.Manifest = manifest
+			return nil
+		}
+		target, section, err := restorePath(header.Name, opts)
+		if err != nil || section == "" {
+			return err
+		}
+		result.Files[section]++
+		if _, err := os.Stat(target); err == nil {
+			conflicts = append(conflicts, header.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Manifest == nil {
+		return nil, fmt.Errorf("%s is not a provisioner backup: it has no %s", archivePath, ManifestFile)
+	}
+	result.Overwritten = len(conflicts)
+	if len(conflicts) > 0 && !force && !dryRun {
+		sort.Strings(conflicts)
+		return nil, fmt.Errorf("%d files already exist, e.g. %s; use --force to replace them", len(conflicts), conflicts[0])
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	err = readArchive(archivePath, func(header *tar.Header, r io.Reader) error {
+		target, section, err := restorePath(header.Name, opts)
+		if err != nil || section == "" {
+			return err
+		}
+		return writeFile(target, r, os.FileMode(header.Mode).Perm())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readArchive calls fn with every regular file of a backup archive
+func readArchive(archivePath string, fn func(header *tar.Header, r io.Reader) error) error {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return fmt.Errorf("failed to open backup: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("%s is not a provisioner backup: %w", archivePath, err)
+	}
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue // Backups only hold regular files; links could point outside the directories
+		}
+		if err := fn(header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// restorePath returns where a file of the archive is restored and its section, empty if the
+// section is not selected
+func restorePath(name string, opts Options) (string, string, error) {
+	if path.IsAbs(name) || name != path.Clean(name) || strings.HasPrefix(name, "../") {
+		return "", "", fmt.Errorf("invalid file name in backup: %s", name)
+	}
+	section := sectionOf(name)
+	if section == "" || !opts.includes(section) {
+		return "", "", nil
+	}
+
+	root, rel := opts.ConfigDir, strings.TrimPrefix(name, "config/")
+	if strings.HasPrefix(name, "state/") {
+		root, rel = opts.StateDir, strings.TrimPrefix(name, "state/")
+	}
+	return filepath.Join(root, filepath.FromSlash(rel)), section, nil
+}
+
+// writeFile writes a restored file through a temporary file, so a failed restore never leaves
+// a truncated config or state file
+func writeFile(target string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	tmp := target + ".restore.tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to restore %s: %w", target, err)
+	}
+	return nil
+}
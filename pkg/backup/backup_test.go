@@ -0,0 +1,244 @@
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFiles creates files relative to root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// newSource returns options for a config and state directory holding a file of every section
+func newSource(t *testing.T) Options {
+	t.Helper()
+	opts := Options{ConfigDir: t.TempDir(), StateDir: t.TempDir()}
+	writeFiles(t, opts.ConfigDir, map[string]string{
+		"provisioner.json":                    `{"max_concurrent_deployments": 2}`,
+		"notifications.yaml":                  "channels: []\n",
+		"staging.json":                        `{"name": "staging"}`,
+		"workspaces/web/config.json":          `{"enabled": true}`,
+		"workspaces/web/main.tf":              `resource "null_resource" "x" {}`,
+		"workspaces/web/.terraform/providers": "cache",
+		"workspaces/web/web.tfplan":           "plan",
+		"workspaces/_defaults/config.json":    `{"description": "defaults"}`,
+		"jobs/cleanup.json":                   `{"name": "cleanup"}`,
+	})
+	writeFiles(t, opts.StateDir, map[string]string{
+		"scheduler.json":                    `{"workspaces": {}}`,
+		"scheduler.json.bak":                "old",
+		"scheduler.json.lock":               "",
+		"audit.jsonl":                       "{}\n",
+		"results/web.json":                  "{}",
+		"templates/registry.json":           "{}",
+		"templates/base/main.tf":            "",
+		"deployments/web/terraform.tfstate": `{"version": 4}`,
+		"state-backups/web/1.tfstate":       `{"version": 4}`,
+		"tofu/providers/cache":              "cache",
+		"provisioner.sock":                  "",
+	})
+	return opts
+}
+
+// createBackup writes a backup of opts into a file and returns its path
+func createBackup(t *testing.T, opts Options) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := Create(&buf, opts); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	source := newSource(t)
+	var buf bytes.Buffer
+	manifest, err := Create(&buf, source)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	expected := map[string]int{
+		SectionWorkspaces: 3, SectionJobs: 1, SectionEnvironments: 1, SectionSettings: 2,
+		SectionTemplates: 2, SectionState: 3, SectionDeployments: 2,
+	}
+	for section, count := range expected {
+		if manifest.Files[section] != count {
+			t.Errorf("Expected %d files in %s, got %d", count, section, manifest.Files[section])
+		}
+	}
+	archive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if err := os.WriteFile(archive, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	target := Options{ConfigDir: t.TempDir(), StateDir: t.TempDir()}
+	result, err := Restore(archive, target, false, false)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if result.Manifest == nil || result.Manifest.ConfigDir != source.ConfigDir {
+		t.Errorf("Expected the manifest of the backup, got %+v", result.Manifest)
+	}
+	if result.Overwritten != 0 {
+		t.Errorf("Expected no overwritten files, got %d", result.Overwritten)
+	}
+
+	for _, name := range []string{"provisioner.json", "staging.json", "workspaces/web/main.tf", "workspaces/_defaults/config.json", "jobs/cleanup.json"} {
+		restored, err := os.ReadFile(filepath.Join(target.ConfigDir, name))
+		if err != nil {
+			t.Errorf("Expected %s to be restored: %v", name, err)
+			continue
+		}
+		original, _ := os.ReadFile(filepath.Join(source.ConfigDir, name))
+		if !bytes.Equal(restored, original) {
+			t.Errorf("Expected %s to be restored unchanged, got %q", name, restored)
+		}
+	}
+	for _, name := range []string{"scheduler.json", "audit.jsonl", "results/web.json", "templates/base/main.tf", "deployments/web/terraform.tfstate"} {
+		if _, err := os.Stat(filepath.Join(target.StateDir, name)); err != nil {
+			t.Errorf("Expected %s to be restored: %v", name, err)
+		}
+	}
+	for _, name := range []string{"workspaces/web/.terraform/providers", "workspaces/web/web.tfplan"} {
+		if _, err := os.Stat(filepath.Join(target.ConfigDir, name)); err == nil {
+			t.Errorf("Expected %s to be left out of the backup", name)
+		}
+	}
+	for _, name := range []string{"scheduler.json.bak", "scheduler.json.lock", "tofu/providers/cache", "provisioner.sock"} {
+		if _, err := os.Stat(filepath.Join(target.StateDir, name)); err == nil {
+			t.Errorf("Expected %s to be left out of the backup", name)
+		}
+	}
+}
+
+func TestCreateSelectedSections(t *testing.T) {
+	source := newSource(t)
+	source.Sections = []string{SectionJobs, SectionSettings}
+	var buf bytes.Buffer
+	manifest, err := Create(&buf, source)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if len(manifest.Files) != 2 || manifest.Files[SectionJobs] != 1 || manifest.Files[SectionSettings] != 2 {
+		t.Errorf("Expected only jobs and settings, got %v", manifest.Files)
+	}
+}
+
+func TestRestoreSelectedSections(t *testing.T) {
+	archive := createBackup(t, newSource(t))
+	target := Options{ConfigDir: t.TempDir(), StateDir: t.TempDir(), Sections: []string{SectionState}}
+
+	result, err := Restore(archive, target, false, false)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[SectionState] != 3 {
+		t.Errorf("Expected only the state section, got %v", result.Files)
+	}
+	if _, err := os.Stat(filepath.Join(target.StateDir, "scheduler.json")); err != nil {
+		t.Errorf("Expected scheduler.json to be restored: %v", err)
+	}
+	for _, name := range []string{"workspaces", "jobs", "provisioner.json"} {
+		if _, err := os.Stat(filepath.Join(target.ConfigDir, name)); err == nil {
+			t.Errorf("Expected %s not to be restored", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target.StateDir, "deployments")); err == nil {
+		t.Error("Expected deployments not to be restored")
+	}
+}
+
+func TestRestoreExistingFiles(t *testing.T) {
+	archive := createBackup(t, newSource(t))
+	target := Options{ConfigDir: t.TempDir(), StateDir: t.TempDir()}
+	writeFiles(t, target.ConfigDir, map[string]string{"jobs/cleanup.json": "local"})
+
+	if _, err := Restore(archive, target, false, false); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("Expected restoring over existing files to fail, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(target.ConfigDir, "provisioner.json")); err == nil {
+		t.Error("Expected nothing to be restored when a file exists")
+	}
+
+	result, err := Restore(archive, target, false, true)
+	if err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if result.Overwritten != 1 || result.Files[SectionJobs] != 1 {
+		t.Errorf("Expected the dry run to report 1 replaced job file, got %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(target.ConfigDir, "provisioner.json")); err == nil {
+		t.Error("Expected a dry run not to write files")
+	}
+
+	if _, err := Restore(archive, target, true, false); err != nil {
+		t.Fatalf("Forced restore failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(target.ConfigDir, "jobs/cleanup.json"))
+	if string(data) != `{"name": "cleanup"}` {
+		t.Errorf("Expected the job config to be replaced, got %q", data)
+	}
+}
+
+func TestRestoreInvalidArchives(t *testing.T) {
+	target := Options{ConfigDir: t.TempDir(), StateDir: t.TempDir()}
+	write := func(entries map[string]string) string {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		tw := tar.NewWriter(gz)
+		for name, content := range entries {
+			if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		_ = tw.Close()
+		_ = gz.Close()
+		path := filepath.Join(t.TempDir(), "archive.tar.gz")
+		if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if _, err := Restore(write(map[string]string{"config/provisioner.json": "{}"}), target, false, false); err == nil || !strings.Contains(err.Error(), ManifestFile) {
+		t.Errorf("Expected an archive without manifest to be rejected, got %v", err)
+	}
+
+	traversal := write(map[string]string{ManifestFile: "{}", "config/../../escaped.json": "{}"})
+	if _, err := Restore(traversal, target, true, false); err == nil || !strings.Contains(err.Error(), "invalid file name") {
+		t.Errorf("Expected a path outside the directories to be rejected, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(target.ConfigDir), "escaped.json")); err == nil {
+		t.Error("Expected no file to be written outside the config directory")
+	}
+
+	notArchive := filepath.Join(t.TempDir(), "backup.tar.gz")
+	if err := os.WriteFile(notArchive, []byte("not gzip"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Restore(notArchive, target, false, false); err == nil {
+		t.Error("Expected a file that is not a gzipped tarball to be rejected")
+	}
+}
@@ -0,0 +1,31 @@
+// Package bytesize parses sizes such as 512M or 2G. Job limits in workspace and standalone
+// job configs share it.
+package bytesize
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a size in bytes with an optional K, M, G or T suffix (powers of 1024, also
+// written Ki/KB/KiB), e.g. 512M or 2G
+func Parse(value string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	text = strings.TrimSuffix(text, "B")
+	text = strings.TrimSuffix(text, "I")
+
+	multiplier := int64(1)
+	if n := len(text); n > 0 {
+		if shift := strings.IndexByte("KMGT", text[n-1]); shift >= 0 {
+			multiplier = 1 << (10 * (shift + 1))
+			text = text[:n-1]
+		}
+	}
+
+	number, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("expected a positive size such as 512M or 2G")
+	}
+	return int64(number * float64(multiplier)), nil
+}
@@ -0,0 +1,41 @@
+package bytesize
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		value       string
+		expected    int64
+		expectError bool
+	}{
+		{"1048576", 1048576, false},
+		{"512M", 512 << 20, false},
+		{"2G", 2 << 30, false},
+		{"1.5g", 3 << 29, false},
+		{"64KiB", 64 << 10, false},
+		{"256Mi", 256 << 20, false},
+		{"1TB", 1 << 40, false},
+		{"", 0, true},
+		{"M", 0, true},
+		{"-1G", 0, true},
+		{"lots", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			size, err := Parse(tt.value)
+			if tt.expectError {
+				if err == nil {
+					t.Errorf("Expected error for %q, got %d", tt.value, size)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error for %q: %v", tt.value, err)
+			}
+			if size != tt.expected {
+				t.Errorf("Expected %d for %q, got %d", tt.expected, tt.value, size)
+			}
+		})
+	}
+}
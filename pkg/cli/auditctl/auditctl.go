@@ -0,0 +1,144 @@
+// Package auditctl implements provisionerctl's audit command, which queries the audit log of
+// deploys, destroys, job runs and kills, and environment switches.
+package auditctl
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/cli"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/output"
+	"provisioner/pkg/scheduler"
+)
+
+func printUsage(prog string) {
+	fmt.Printf(`Usage: %s [OPTIONS]
+
+Show the audit log: every deploy, destroy, job run, job kill and environment switch with what
+triggered it and how it ended, oldest first.
+
+Options:
+  --workspace NAME         Only show operations on this workspace
+  --since DURATION         Only show operations within this duration (e.g. 24h, 30m)
+  --output FORMAT          Print json, yaml or table (default)
+  --help                   Show this help
+
+Triggers:
+  schedule                 Workspace or job schedules, retries and lifetimes
+  event                    Jobs run on a deployment event (actor: the event)
+  cli                      The CLIs, directly or through the daemon (actor: OS user)
+  webhook                  Webhook triggers (actor: webhook name)
+  dashboard                The web dashboard (actor: client address)
+
+Examples:
+  %s                                  # Show the whole audit log
+  %s --workspace web --since 24h      # Operations on 'web' in the last day
+  %s --since 7h --output json         # Recent operations as JSON, including errors
+`, prog, prog, prog, prog)
+}
+
+// Command returns provisionerctl's audit command
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:    "audit",
+		Summary: "Show the audit log of deploys, destroys, job runs and environment switches",
+		Usage:   printUsage,
+		Run:     cli.RunArgs(runAudit),
+	}
+}
+
+// runAudit prints the audit log entries selected by the options
+func runAudit(args []string) error {
+	if _, help := cli.ExtractFlag(args, "--help"); help {
+		return cli.ErrHelp
+	}
+
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	filter, err := parseFilter(args, time.Now())
+	if err != nil {
+		return err
+	}
+
+	entries, err := scheduler.NewQuiet().AuditLog().Read(filter)
+	if err != nil {
+		return err
+	}
+
+	if format.Structured() {
+		if entries == nil {
+			entries = []audit.Entry{}
+		}
+		return output.Print(format, entries)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No audit entries found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tOPERATION\tTARGET\tTRIGGER\tACTOR\tOUTCOME\tCORRELATION ID\tERROR")
+	for _, entry := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			logging.FormatTime(entry.Time), entry.Operation, describeTarget(entry), entry.Source,
+			orDash(entry.Actor), entry.Outcome, orDash(entry.CorrelationID), orDash(entry.Error))
+	}
+	return w.Flush()
+}
+
+// parseFilter parses --workspace and --since
+func parseFilter(args []string, now time.Time) (audit.Filter, error) {
+	var filter audit.Filter
+
+	args, workspaceName, err := cli.ExtractOption(args, "--workspace")
+	if err != nil {
+		return filter, err
+	}
+	filter.Workspace = workspaceName
+
+	args, since, err := cli.ExtractOption(args, "--since")
+	if err != nil {
+		return filter, err
+	}
+	if since != "" {
+		duration, err := time.ParseDuration(since)
+		if err != nil || duration <= 0 {
+			return filter, cli.Usagef("invalid --since duration '%s' (e.g. 24h, 30m)", since)
+		}
+		filter.Since = now.Add(-duration)
+	}
+
+	if len(args) > 0 {
+		return filter, cli.Usagef("unknown argument '%s'", args[0])
+	}
+	return filter, nil
+}
+
+// describeTarget returns what an operation acted on for the table
+func describeTarget(entry audit.Entry) string {
+	switch {
+	case entry.Environment != "":
+		return fmt.Sprintf("%s -> %s", entry.Environment, entry.Workspace)
+	case entry.Job != "" && entry.Workspace == "":
+		return entry.Job + " (standalone)"
+	case entry.Job != "":
+		return entry.Workspace + "/" + entry.Job
+	case entry.Mode != "":
+		return fmt.Sprintf("%s (mode %s)", entry.Workspace, entry.Mode)
+	}
+	return entry.Workspace
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,154 @@
+// Package backupctl implements provisionerctl's backup commands, which write the configuration and
+// state into a tarball and restore it on the same or another host.
+package backupctl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"provisioner/pkg/backup"
+	"provisioner/pkg/cli"
+	"provisioner/pkg/control"
+)
+
+func printUsage(prog string) {
+	fmt.Printf(`Usage: %s COMMAND [ARGUMENTS...]
+
+Back up the configuration and state of the provisioner and restore it, for disaster recovery
+and moving to another host. Backups are not sanitized: they hold every secret of the
+configuration, variables and OpenTofu state.
+
+Commands:
+  create FILE.tar.gz       Write a backup of the selected sections, all by default
+  restore FILE.tar.gz      Restore the selected sections of a backup, all by default
+                           (--force to replace existing files, --dry-run to only list them)
+
+Sections:
+  --workspaces             Workspace configs and OpenTofu files, including _defaults
+  --jobs                   Standalone job configs
+  --environments           Environment configs
+  --settings               provisioner.json and notifications.json
+  --templates              Templates and their registry
+  --state                  Scheduler, job and audit state, deploy results and debug toggles
+  --deployments            Deployment directories with OpenTofu state, state backups and history
+
+Examples:
+  %s create /backup/provisioner.tar.gz                 # Back up everything
+  %s create config.tar.gz --workspaces --jobs           # Only the workspace and job configs
+  %s restore /backup/provisioner.tar.gz --dry-run      # List what a restore would write
+  %s restore /backup/provisioner.tar.gz --state --force  # Put back the scheduler state
+`, prog, prog, prog, prog, prog)
+}
+
+// Command returns provisionerctl's backup commands
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:    "backup",
+		Summary: "Back up and restore configuration and state (create, restore)",
+		Usage:   printUsage,
+		Commands: []*cli.Command{
+			{Name: "create", Run: cli.RunArgs(runCreate)},
+			{Name: "restore", Run: cli.RunArgs(runRestore)},
+		},
+	}
+}
+
+// extractSections removes the section flags from args and returns the sections selected
+func extractSections(args []string) ([]string, []string) {
+	var sections []string
+	for _, section := range backup.Sections {
+		var selected bool
+		if args, selected = cli.ExtractFlag(args, "--"+section); selected {
+			sections = append(sections, section)
+		}
+	}
+	return args, sections
+}
+
+// runCreate writes a backup: FILE [SECTIONS]
+func runCreate(args []string) error {
+	opts := backup.DefaultOptions()
+	args, opts.Sections = extractSections(args)
+	if err := cli.Args(args, 1, 1, "create requires exactly one FILE and optional section flags"); err != nil {
+		return err
+	}
+	output := args[0]
+
+	// Backups hold secrets, keep them private to the user creating them
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+	manifest, err := backup.Create(file, opts)
+	if err != nil {
+		_ = file.Close()
+		_ = os.Remove(output)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	fmt.Printf("Backup written to %s\n", output)
+	printSections(manifest.Files)
+	fmt.Println("The backup is not sanitized and holds secrets; store it accordingly.")
+	return nil
+}
+
+// runRestore restores a backup: FILE [SECTIONS] [--force] [--dry-run]
+func runRestore(args []string) error {
+	opts := backup.DefaultOptions()
+	args, opts.Sections = extractSections(args)
+	args, force := cli.ExtractFlag(args, "--force")
+	args, dryRun := cli.ExtractFlag(args, "--dry-run")
+	if err := cli.Args(args, 1, 1, "restore requires exactly one FILE, optional section flags, --force and --dry-run"); err != nil {
+		return err
+	}
+
+	// The daemon would overwrite restored state with its own and act on half-restored configs
+	if !dryRun {
+		if client, err := control.Dial(); err == nil {
+			_ = client.Close()
+			return fmt.Errorf("the daemon is running; stop it before restoring a backup")
+		}
+	}
+
+	result, err := backup.Restore(args[0], opts, force, dryRun)
+	if err != nil {
+		return err
+	}
+
+	manifest := result.Manifest
+	fmt.Printf("Backup of %s taken %s by provisioner %s\n", manifest.Hostname, manifest.CreatedAt.Format("2006-01-02 15:04:05 MST"), manifest.Version)
+	if dryRun {
+		fmt.Printf("Would restore into %s and %s:\n", opts.ConfigDir, opts.StateDir)
+		printSections(result.Files)
+		if result.Overwritten > 0 {
+			fmt.Printf("%d existing files would be replaced (requires --force)\n", result.Overwritten)
+		}
+		return nil
+	}
+
+	fmt.Printf("Restored into %s and %s:\n", opts.ConfigDir, opts.StateDir)
+	printSections(result.Files)
+	if result.Overwritten > 0 {
+		fmt.Printf("Replaced %d existing files\n", result.Overwritten)
+	}
+	return nil
+}
+
+// printSections prints the number of files of each section
+func printSections(files map[string]int) {
+	var empty []string
+	for _, section := range backup.Sections {
+		if count, ok := files[section]; ok {
+			fmt.Printf("  %-14s %d files\n", section, count)
+		} else {
+			empty = append(empty, section)
+		}
+	}
+	if len(empty) > 0 && len(empty) < len(backup.Sections) {
+		fmt.Printf("  (none of %s)\n", strings.Join(empty, ", "))
+	}
+}
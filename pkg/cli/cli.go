@@ -0,0 +1,313 @@
+// Package cli is the command framework shared by provisionerctl and the provisioner, workspacectl,
+// jobctl, templatectl and environmentctl binaries. It parses the global options, dispatches to
+// subcommands and turns errors into messages and exit codes.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"provisioner/pkg/control"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/version"
+)
+
+// Command is a command or a group of subcommands. A command with subcommands passes its
+// arguments to Run when they don't name one of them, and prints its usage if it has no Run.
+type Command struct {
+	Name     string
+	Summary  string // One line describing the command in its parent's usage
+	Commands []*Command
+
+	// Usage prints the command's help. prog is the command line invoking it, e.g. "workspacectl"
+	// or "provisionerctl workspace". Commands without Usage list their subcommands.
+	Usage func(prog string)
+
+	// Flags adds options before the subcommand to the global ones, e.g. jobctl's --workspace
+	Flags func(fs *flag.FlagSet)
+
+	Run func(prog string, args []string) error
+}
+
+// UsageError is an error in the arguments of a command; the usage is printed after it
+type UsageError struct {
+	Message string
+}
+
+func (e *UsageError) Error() string {
+	return e.Message
+}
+
+// Usagef returns a UsageError with a formatted message
+func Usagef(format string, args ...interface{}) error {
+	return &UsageError{Message: fmt.Sprintf(format, args...)}
+}
+
+// ErrHelp is returned by help commands to print the usage
+var ErrHelp = errors.New("help requested")
+
+// ExitError ends a command with an exit code without printing anything, for commands that
+// report their result themselves, e.g. jobctl wait
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
+
+// Main runs a binary's root command with the process arguments and exits with its exit code
+func Main(root *Command) {
+	os.Exit(root.Execute(os.Args[0], os.Args[1:]))
+}
+
+// Execute runs the command and returns the exit code: 0 on success, 1 on errors and 2 on
+// usage errors
+func (c *Command) Execute(prog string, args []string) int {
+	return c.execute(prog, args, nil, false)
+}
+
+// execute runs the command. Groups parse the global options before their subcommand, while
+// commands without subcommands get their arguments as given and print the usage of their
+// parent (parentUsage) unless they have their own.
+func (c *Command) execute(prog string, args []string, parentUsage func(), utc bool) int {
+	usage := func() { c.printUsage(prog) }
+	if c.Usage == nil && len(c.Commands) == 0 && parentUsage != nil {
+		usage = parentUsage
+	}
+	if len(c.Commands) == 0 && parentUsage != nil {
+		return fail(c.Run(prog, args), usage)
+	}
+
+	fs := flag.NewFlagSet(prog, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	useUTC := fs.Bool("utc", false, "Show timestamps in UTC")
+	showVersion := fs.Bool("version", false, "Show version information")
+	showFullVersion := fs.Bool("version-full", false, "Show detailed version information")
+	showHelp := fs.Bool("help", false, "Show help information")
+	if c.Flags != nil {
+		c.Flags(fs)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			usage()
+			return 0
+		}
+		return fail(Usagef("%v", err), usage)
+	}
+
+	switch {
+	case *showHelp:
+		usage()
+		return 0
+	case *showVersion:
+		fmt.Println(version.GetVersion())
+		return 0
+	case *showFullVersion:
+		fmt.Println(version.GetFullVersion())
+		return 0
+	}
+
+	// --utc applies to the subcommands of the command it was given to
+	if parentUsage == nil || *useUTC {
+		utc = utc || *useUTC
+		if err := logging.ConfigureDisplayTimezone(utc); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v, using local time\n", err)
+		}
+	}
+
+	args = fs.Args()
+	if len(args) > 0 {
+		for _, sub := range c.Commands {
+			if sub.Name == args[0] {
+				return sub.execute(prog+" "+sub.Name, args[1:], usage, utc)
+			}
+		}
+	}
+
+	if c.Run == nil {
+		if len(args) == 0 {
+			return fail(Usagef("no command specified"), usage)
+		}
+		return fail(Usagef("unknown command '%s'", args[0]), usage)
+	}
+	return fail(c.Run(prog, args), usage)
+}
+
+// fail prints an error returned by a command and returns its exit code
+func fail(err error, usage func()) int {
+	var usageErr *UsageError
+	var exitErr *ExitError
+	switch {
+	case err == nil:
+		return 0
+	case errors.Is(err, ErrHelp):
+		usage()
+		return 0
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &usageErr):
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", usageErr)
+		usage()
+		return 2
+	}
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	return 1
+}
+
+func (c *Command) printUsage(prog string) {
+	if c.Usage != nil {
+		c.Usage(prog)
+		return
+	}
+
+	fmt.Printf("Usage: %s [OPTIONS] COMMAND [ARGUMENTS...]\n", prog)
+	if c.Summary != "" {
+		fmt.Printf("\n%s\n", c.Summary)
+	}
+
+	commands := make([]*Command, len(c.Commands))
+	copy(commands, c.Commands)
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+
+	fmt.Println("\nCommands:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, sub := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", sub.Name, sub.Summary)
+	}
+	_ = w.Flush()
+
+	fmt.Printf(`
+Options:
+  --utc           Show timestamps in UTC
+  --help          Show this help
+  --version       Show version
+  --version-full  Show detailed version
+
+Run '%s COMMAND --help' for the options of a command.
+`, prog)
+}
+
+// RunArgs adapts a command function that only takes its arguments
+func RunArgs(command func(args []string) error) func(prog string, args []string) error {
+	return func(_ string, args []string) error {
+		return command(args)
+	}
+}
+
+// Args checks the number of positional arguments of a command
+func Args(args []string, min, max int, usage string) error {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		return Usagef("%s", usage)
+	}
+	return nil
+}
+
+// ExtractFlag removes a boolean flag from args, returning the remaining arguments and whether
+// it was present
+func ExtractFlag(args []string, name string) ([]string, bool) {
+	var rest []string
+	found := false
+	for _, arg := range args {
+		if arg == name {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return rest, found
+}
+
+// ExtractOption removes an option with a value, given as "--name VALUE" or "--name=VALUE",
+// from args and returns the remaining arguments and its last value
+func ExtractOption(args []string, name string) ([]string, string, error) {
+	var rest []string
+	value := ""
+	for i := 0; i < len(args); i++ {
+		if v, ok := strings.CutPrefix(args[i], name+"="); ok {
+			value = v
+			continue
+		}
+		if args[i] == name {
+			if i+1 >= len(args) {
+				return nil, "", Usagef("%s requires a value", name)
+			}
+			i++
+			value = args[i]
+			continue
+		}
+		rest = append(rest, args[i])
+	}
+	return rest, value, nil
+}
+
+// timeLayouts are the layouts ParseTime accepts for times without a UTC offset, read in the
+// display timezone
+var timeLayouts = []string{"2006-01-02 15:04", "2006-01-02 15:04:05", "2006-01-02T15:04", "2006-01-02T15:04:05"}
+
+// ParseTime parses a time given on the command line: "+DURATION" from now, "YYYY-MM-DD HH:MM[:SS]"
+// in the display timezone (--utc for UTC) or RFC 3339 with a UTC offset
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if offset, ok := strings.CutPrefix(value, "+"); ok {
+		duration, err := time.ParseDuration(offset)
+		if err != nil || duration <= 0 {
+			return time.Time{}, Usagef("invalid duration '%s' (e.g. +2h, +30m)", value)
+		}
+		return now.Add(duration), nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, logging.DisplayLocation()); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, Usagef("invalid time '%s' (e.g. \"2025-07-01 09:00\", 2025-07-01T09:00:00Z or +2h)", value)
+}
+
+// CallDaemon runs an operation through the daemon's control socket and prints its message.
+// It returns false if the daemon is not running so the caller can fall back to direct access.
+func CallDaemon(operation func(*control.Client) (string, error)) (bool, error) {
+	client, err := control.Dial()
+	if err != nil {
+		return false, nil
+	}
+	defer func() { _ = client.Close() }()
+
+	message, err := operation(client)
+	if err != nil {
+		return true, err
+	}
+	fmt.Println(message)
+	return true, nil
+}
+
+// ConfirmName reads a line from in and reports whether it is name, for confirmations that
+// require typing what is about to be destroyed. It reads byte by byte so later prompts reading
+// the same input, e.g. with fmt.Scanln, get the following lines.
+func ConfirmName(in io.Reader, name string) bool {
+	var line []byte
+	buf := make([]byte, 1)
+	for {
+		n, err := in.Read(buf)
+		if n == 1 {
+			if buf[0] == '\n' {
+				break
+			}
+			line = append(line, buf[0])
+		}
+		if err != nil {
+			break
+		}
+	}
+	return name != "" && strings.TrimSpace(string(line)) == name
+}
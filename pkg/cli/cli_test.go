@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// call records how a command was run
+type call struct {
+	prog string
+	args []string
+}
+
+func testCommand(calls *[]call, workspace *string) *Command {
+	record := func(err error) func(string, []string) error {
+		return func(prog string, args []string) error {
+			*calls = append(*calls, call{prog, args})
+			return err
+		}
+	}
+
+	return &Command{
+		Name:    "job",
+		Summary: "Manage jobs",
+		Usage:   func(string) {},
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(workspace, "workspace", "", "Workspace")
+		},
+		Commands: []*Command{
+			{Name: "list", Run: record(nil)},
+			{Name: "kill", Run: record(Usagef("kill command requires job name"))},
+			{Name: "run", Run: record(errors.New("job failed"))},
+			{Name: "wait", Run: record(&ExitError{Code: 3})},
+			{Name: "help", Run: record(ErrHelp)},
+		},
+	}
+}
+
+func TestExecute(t *testing.T) {
+	var calls []call
+	var workspace string
+	root := &Command{Name: "provisionerctl", Commands: []*Command{testCommand(&calls, &workspace)}}
+
+	if code := root.Execute("provisionerctl", []string{"job", "--workspace", "web", "list", "--force", "--filter", "status=failed"}); code != 0 {
+		t.Fatalf("Expected exit code 0, got %d", code)
+	}
+	if workspace != "web" {
+		t.Errorf("Expected --workspace before the command to be parsed, got '%s'", workspace)
+	}
+	list := calls[0]
+	if list.prog != "provisionerctl job list" {
+		t.Errorf("Expected prog of the command line, got '%s'", list.prog)
+	}
+	if strings.Join(list.args, " ") != "--force --filter status=failed" {
+		t.Errorf("Expected options after the command to be passed to it, got %v", list.args)
+	}
+
+	tests := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"job", "kill"}, 2},
+		{[]string{"job", "run", "backup"}, 1},
+		{[]string{"job", "wait"}, 3},
+		{[]string{"job", "help"}, 0},
+		{[]string{"job"}, 2},
+		{[]string{"job", "unknown"}, 2},
+		{[]string{"--unknown-option", "job", "list"}, 2},
+		{[]string{"--help"}, 0},
+		{[]string{}, 2},
+	}
+	for _, test := range tests {
+		if code := root.Execute("provisionerctl", test.args); code != test.code {
+			t.Errorf("Expected exit code %d for %v, got %d", test.code, test.args, code)
+		}
+	}
+
+	if len(calls) != 5 {
+		t.Errorf("Expected 5 commands to run, got %d: %v", len(calls), calls)
+	}
+}
+
+func TestExecuteRunsGroupWithoutCommand(t *testing.T) {
+	var runArgs []string
+	ran := false
+	daemon := &Command{
+		Name: "daemon",
+		Run: func(_ string, args []string) error {
+			ran, runArgs = true, args
+			if len(args) > 0 {
+				return Usagef("unknown argument '%s'", args[0])
+			}
+			return nil
+		},
+		Commands: []*Command{{Name: "pause-all", Run: func(string, []string) error { return nil }}},
+	}
+
+	if code := daemon.Execute("provisioner", []string{"--utc"}); code != 0 || !ran {
+		t.Errorf("Expected the group to run without a command, got exit code %d", code)
+	}
+	if code := daemon.Execute("provisioner", []string{"bogus"}); code != 2 || len(runArgs) != 1 {
+		t.Errorf("Expected unknown commands to be passed to Run, got exit code %d and %v", code, runArgs)
+	}
+}
+
+func TestExtractOption(t *testing.T) {
+	rest, value, err := ExtractOption([]string{"web", "--reason", "release freeze"}, "--reason")
+	if err != nil || value != "release freeze" || strings.Join(rest, " ") != "web" {
+		t.Errorf("Unexpected result: %v %q %v", rest, value, err)
+	}
+
+	rest, value, err = ExtractOption([]string{"--reason=audit", "web"}, "--reason")
+	if err != nil || value != "audit" || strings.Join(rest, " ") != "web" {
+		t.Errorf("Unexpected result: %v %q %v", rest, value, err)
+	}
+
+	var usageErr *UsageError
+	if _, _, err := ExtractOption([]string{"web", "--reason"}, "--reason"); !errors.As(err, &usageErr) {
+		t.Errorf("Expected usage error for missing value, got %v", err)
+	}
+}
+
+func TestArgs(t *testing.T) {
+	if err := Args([]string{"web"}, 1, 2, "usage"); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := Args(nil, 1, 2, "usage"); err == nil {
+		t.Error("Expected error for missing arguments")
+	}
+	if err := Args([]string{"a", "b", "c"}, 1, 2, "usage"); err == nil {
+		t.Error("Expected error for extra arguments")
+	}
+	if err := Args([]string{"a", "b", "c"}, 0, -1, "usage"); err != nil {
+		t.Errorf("Expected any number of arguments with max -1, got %v", err)
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	defer logging.SetDisplayLocation(logging.DisplayLocation())
+	logging.SetDisplayLocation(time.FixedZone("CEST", 2*60*60))
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]time.Time{
+		"+2h":                  now.Add(2 * time.Hour),
+		"2025-07-01 09:00":     time.Date(2025, 7, 1, 7, 0, 0, 0, time.UTC),
+		"2025-07-01 09:00:30":  time.Date(2025, 7, 1, 7, 0, 30, 0, time.UTC),
+		"2025-07-01T09:00":     time.Date(2025, 7, 1, 7, 0, 0, 0, time.UTC),
+		"2025-07-01T09:00:00Z": time.Date(2025, 7, 1, 9, 0, 0, 0, time.UTC),
+	}
+	for value, expected := range tests {
+		got, err := ParseTime(value, now)
+		if err != nil || !got.Equal(expected) {
+			t.Errorf("ParseTime(%q) = %v, %v; expected %v", value, got, err, expected)
+		}
+	}
+
+	var usageErr *UsageError
+	for _, value := range []string{"", "tomorrow", "+-1h", "+0s", "2025-07-01"} {
+		if _, err := ParseTime(value, now); !errors.As(err, &usageErr) {
+			t.Errorf("Expected usage error for %q, got %v", value, err)
+		}
+	}
+}
+
+func TestConfirmName(t *testing.T) {
+	in := strings.NewReader("prod-db\n  prod-db  \nprod\n")
+	if !ConfirmName(in, "prod-db") {
+		t.Error("Expected the typed name to confirm")
+	}
+	if !ConfirmName(in, "prod-db") {
+		t.Error("Expected surrounding spaces to be ignored")
+	}
+	if ConfirmName(in, "prod-db") {
+		t.Error("Expected a different name not to confirm")
+	}
+	if ConfirmName(in, "prod-db") {
+		t.Error("Expected no confirmation at the end of the input")
+	}
+	if ConfirmName(strings.NewReader("\n"), "") {
+		t.Error("Expected an empty name never to be confirmed")
+	}
+}
@@ -0,0 +1,106 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"provisioner/pkg/agent"
+	"provisioner/pkg/cli"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/systemd"
+)
+
+// startAgentHub listens for agents on PROVISIONER_AGENT_LISTEN and makes the scheduler run the
+// workspaces assigned to them there. It returns nil if no address is configured or the listener
+// failed, in which case agent workspaces fail to deploy until it is fixed.
+func startAgentHub(sched *scheduler.Scheduler) *agent.Hub {
+	addr := os.Getenv("PROVISIONER_AGENT_LISTEN")
+	if addr == "" {
+		return nil
+	}
+
+	hub, err := agent.NewHub(addr, os.Getenv("PROVISIONER_AGENT_TOKEN"), os.Getenv("PROVISIONER_AGENT_TLS_CERT"), os.Getenv("PROVISIONER_AGENT_TLS_KEY"))
+	if err == nil {
+		// Agents of a standby would never receive an operation; they retry with the next server
+		hub.AcceptWhen(func() error {
+			if leader, standby := sched.Standby(); standby {
+				return fmt.Errorf("standing by, %s leads", leader)
+			}
+			return nil
+		})
+		err = hub.Start()
+	}
+	if err != nil {
+		logging.LogSystemd("Agents disabled: %v", err)
+		return nil
+	}
+
+	sched.WrapClient(func(local opentofu.TofuClient) opentofu.TofuClient {
+		return hub.Client(local, func(workspaceName string) string {
+			if ws := sched.GetWorkspace(workspaceName); ws != nil {
+				return ws.Config.Agent
+			}
+			return ""
+		})
+	})
+	return hub
+}
+
+// runAgent runs this host as an agent of the daemon until it is stopped:
+// --name NAME --server HOST:PORT[,HOST:PORT...] [--ca FILE]
+func runAgent(args []string) error {
+	var opts agent.Options
+	var err error
+	if args, opts.Name, err = cli.ExtractOption(args, "--name"); err != nil {
+		return err
+	}
+	if args, opts.CAFile, err = cli.ExtractOption(args, "--ca"); err != nil {
+		return err
+	}
+	var servers string
+	if args, servers, err = cli.ExtractOption(args, "--server"); err != nil {
+		return err
+	}
+	if servers != "" {
+		opts.Servers = strings.Split(servers, ",")
+	}
+	if err := cli.Args(args, 0, 0, "agent takes --name, --server and --ca only"); err != nil {
+		return err
+	}
+	opts.Token = os.Getenv("PROVISIONER_AGENT_TOKEN")
+	if opts.Name == "" || len(opts.Servers) == 0 {
+		return cli.Usagef("agent requires --name and --server")
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+
+	client, err := opentofu.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+	}
+	client.SetAgent(opts.Name)
+
+	stop := make(chan struct{})
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		logging.LogSystemd("Shutting down agent...")
+		_ = systemd.Stopping()
+		close(stop)
+	}()
+
+	if err := systemd.Ready(); err != nil {
+		logging.LogSystemd("Failed to notify systemd: %v", err)
+	}
+	logging.LogSystemd("Agent '%s' connecting to %v", opts.Name, opts.Servers)
+	err = agent.Connect(opts, agent.NewWorker(opts.Name, client), stop)
+	logging.GetLogger().Close()
+	return err
+}
@@ -0,0 +1,256 @@
+// Package daemon implements the scheduler daemon command and its maintenance commands.
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"provisioner/pkg/agent"
+	"provisioner/pkg/cli"
+	"provisioner/pkg/control"
+	"provisioner/pkg/dashboard"
+	"provisioner/pkg/gitops"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/metrics"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/support"
+	"provisioner/pkg/systemd"
+	"provisioner/pkg/version"
+	"provisioner/pkg/webhook"
+)
+
+func printUsage(prog string) {
+	fmt.Printf(`Usage: %s [OPTIONS] [COMMAND]
+
+OpenTofu Workspace Scheduler - Automatically manages OpenTofu workspaces on CRON schedules.
+
+This daemon runs in the background and deploys/destroys workspaces based on their configured schedules.
+
+Related Tools:
+  provisionerctl  All commands in one CLI (workspace, job, template, environment, daemon)
+  workspacectl    Manage workspaces (list, deploy, destroy, status, logs)
+  templatectl      Manage templates (add, list, show, update, remove)
+
+Commands:
+  versions [--json]  Report tofu, provider and template versions per workspace
+  success-rates [--json]
+                     Report deploy and job success rates against their objectives
+  support-bundle     Write a sanitized tarball of config, state, logs and diagnostics
+                     [--output FILE] [--log-lines N] [--no-logs] [--no-state]
+  pause-all          Skip scheduled operations of all workspaces until resume-all
+  resume-all         Resume scheduled operations (workspaces paused on their own stay paused)
+  gitops             Show the repository configs are synced from and the last sync
+  leader             Show which daemon leads when several share the state directory
+  agents             List the agents connected to the daemon
+  agent --name NAME --server HOST:PORT[,HOST:PORT...] [--ca FILE]
+                     Run the deploys and destroys of the workspaces assigned to agent NAME
+                     on this host (token in PROVISIONER_AGENT_TOKEN)
+
+Options:
+  --utc            Show timestamps in UTC
+  --help           Show this help
+  --version        Show version
+  --version-full   Show detailed version
+
+Examples:
+  %s               # Run scheduler daemon (default)
+  %s --version     # Show version information
+  %s versions --json  # Export version report for compliance
+  %s success-rates    # Check which workspaces and jobs miss their objectives
+  %s support-bundle   # Collect a bundle to attach to bug reports
+  %s pause-all        # Stop all scheduled operations during maintenance
+  %s agent --name aws-vpc --server provisioner.example.com:8092  # Serve as agent 'aws-vpc'
+
+For manual operations, use the related CLI tools:
+  workspacectl list              # List all workspaces
+  workspacectl deploy my-app     # Deploy workspace immediately
+  workspacectl status my-app     # Show workspace status
+  templatectl list                 # List all templates
+`, prog, prog, prog, prog, prog, prog, prog, prog)
+}
+
+// Command returns the scheduler daemon and its maintenance commands, the provisioner binary and
+// provisionerctl's daemon group. Without a command it runs the daemon.
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:    "daemon",
+		Summary: "Run the scheduler daemon (versions, success-rates, support-bundle, pause-all, resume-all, gitops, leader, agents, agent)",
+		Usage:   printUsage,
+		Run:     runDaemon,
+		Commands: []*cli.Command{
+			{Name: "versions", Run: cli.RunArgs(opentofu.RunVersionsCommand)},
+			{Name: "success-rates", Run: cli.RunArgs(scheduler.RunSuccessRatesCommand)},
+			{Name: "support-bundle", Run: cli.RunArgs(support.RunSupportBundleCommand)},
+			{Name: "pause-all", Run: func(string, []string) error { return runPauseAllCommand(true) }},
+			{Name: "resume-all", Run: func(string, []string) error { return runPauseAllCommand(false) }},
+			{Name: "gitops", Run: cli.RunArgs(gitops.RunStatusCommand)},
+			{Name: "leader", Run: cli.RunArgs(scheduler.RunLeaderCommand)},
+			{Name: "agents", Run: cli.RunArgs(agent.RunListCommand)},
+			{Name: "agent", Run: cli.RunArgs(runAgent)},
+		},
+	}
+}
+
+// runDaemon runs the scheduler until it is interrupted or terminated
+func runDaemon(_ string, args []string) error {
+	if len(args) > 0 {
+		return cli.Usagef("unknown argument '%s'", args[0])
+	}
+
+	logging.LogSystemd("Starting Workspace Scheduler %s", version.GetVersion())
+
+	// Initialize scheduler
+	sched := scheduler.New()
+
+	// The socket in the shared state directory would be taken over by each host's daemon in turn
+	if sched.HighAvailability() && os.Getenv("PROVISIONER_SOCKET") == "" {
+		return fmt.Errorf("high_availability requires PROVISIONER_SOCKET to point to a path on this host, e.g. /run/provisioner/provisioner.sock")
+	}
+
+	// Run the operations of agent workspaces on their agents when a listener is configured
+	agentHub := startAgentHub(sched)
+
+	// Load workspaces and state
+	if err := sched.LoadWorkspaces(); err != nil {
+		logging.LogSystemd("Error loading workspaces: %v", err)
+	}
+
+	if err := sched.LoadState(); err != nil {
+		logging.LogSystemd("Error loading state: %v", err)
+	}
+
+	// Start scheduler
+	schedulerDone := make(chan struct{})
+	go func() {
+		sched.Start()
+		close(schedulerDone)
+	}()
+
+	// Serve CLI requests so operations go through the daemon's in-memory state
+	controlServer, err := control.NewServer(sched, control.SocketPath())
+	if err == nil {
+		err = controlServer.Start()
+	}
+	if err != nil {
+		logging.LogSystemd("Control socket unavailable, CLIs will use direct file access: %v", err)
+		controlServer = nil
+	}
+
+	// Listen for incoming webhook triggers when an address is configured
+	var webhookServer *webhook.Server
+	if addr := os.Getenv("PROVISIONER_WEBHOOK_LISTEN"); addr != "" {
+		webhookServer = webhook.NewServer(sched, addr)
+		if err := webhookServer.Start(); err != nil {
+			logging.LogSystemd("Webhook triggers disabled: %v", err)
+			webhookServer = nil
+		}
+	}
+
+	// Serve success-rate metrics when an address is configured
+	var metricsServer *metrics.Server
+	if addr := os.Getenv("PROVISIONER_METRICS_LISTEN"); addr != "" {
+		metricsServer = metrics.NewServer(sched, addr)
+		if err := metricsServer.Start(); err != nil {
+			logging.LogSystemd("Metrics disabled: %v", err)
+			metricsServer = nil
+		}
+	}
+
+	// Serve the web dashboard when an address is configured
+	var dashboardServer *dashboard.Server
+	if addr := os.Getenv("PROVISIONER_DASHBOARD_LISTEN"); addr != "" {
+		dashboardServer = dashboard.NewServer(sched, addr, os.Getenv("PROVISIONER_DASHBOARD_TOKEN"))
+		if err := dashboardServer.Start(); err != nil {
+			logging.LogSystemd("Dashboard disabled: %v", err)
+			dashboardServer = nil
+		}
+	}
+
+	// Handle graceful shutdown
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	logging.LogSystemd("Workspace Scheduler started. Press Ctrl+C to stop.")
+
+	var exitErr error
+	select {
+	case <-sigChan:
+	case <-schedulerDone:
+		if sched.LeadershipLost() {
+			// Exit so systemd restarts the daemon as a standby, ending the operations still running
+			exitErr = fmt.Errorf("another daemon took over as leader")
+			break
+		}
+		<-sigChan
+	}
+	logging.LogSystemd("Shutting down...")
+	_ = systemd.Stopping()
+
+	if controlServer != nil {
+		_ = controlServer.Close()
+	}
+	if webhookServer != nil {
+		_ = webhookServer.Close()
+	}
+	if metricsServer != nil {
+		_ = metricsServer.Close()
+	}
+	if dashboardServer != nil {
+		_ = dashboardServer.Close()
+	}
+	if agentHub != nil {
+		_ = agentHub.Close()
+	}
+
+	// Save state on shutdown, unless another daemon leads and owns it
+	if _, standby := sched.Standby(); !standby && !sched.LeadershipLost() {
+		if err := sched.SaveState(); err != nil {
+			logging.LogSystemd("Error saving state: %v", err)
+		}
+		sched.ReleaseLeadership()
+	}
+
+	// Close log files
+	logging.GetLogger().Close()
+
+	logging.LogSystemd("Workspace Scheduler stopped.")
+	return exitErr
+}
+
+// runPauseAllCommand pauses or resumes scheduling through the running daemon, or in the state
+// file when the daemon is stopped
+func runPauseAllCommand(pause bool) error {
+	if client, err := control.Dial(); err == nil {
+		defer func() { _ = client.Close() }()
+		call := client.ResumeAll
+		if pause {
+			call = client.PauseAll
+		}
+		message, err := call()
+		if err != nil {
+			return err
+		}
+		fmt.Println(message)
+		return nil
+	}
+
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if !pause {
+		if err := sched.ResumeAll(); err != nil {
+			return err
+		}
+		fmt.Println("Scheduling resumed for all workspaces")
+		return nil
+	}
+	if err := sched.PauseAll(); err != nil {
+		return err
+	}
+	fmt.Println("Scheduling paused for all workspaces, scheduled operations are skipped until resume-all")
+	return nil
+}
@@ -0,0 +1,561 @@
+// Package environmentctl implements the environment traffic management commands.
+package environmentctl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/cli"
+	"provisioner/pkg/control"
+	"provisioner/pkg/environment"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/output"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/version"
+)
+
+// Command returns the environment traffic management commands, the environmentctl binary and
+// provisionerctl's environment group
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:    "environment",
+		Summary: "Manage environment traffic (status, switch, list)",
+		Usage:   showUsage,
+		Commands: []*cli.Command{
+			{Name: "status", Run: handleStatus},
+			{Name: "switch", Run: handleSwitch},
+			{Name: "list", Run: handleList},
+			{Name: "version", Run: showVersion},
+			{Name: "help", Run: func(string, []string) error { return cli.ErrHelp }},
+		},
+	}
+}
+
+func showUsage(prog string) {
+	fmt.Printf(`Usage: %s COMMAND [ARGUMENTS...]
+
+Environment traffic management for OpenTofu Workspace Scheduler.
+
+Commands:
+  status [ENVIRONMENT]           Show environment status
+  switch ENV WORKSPACE           Switch environment to workspace
+      [--deploy] [--mode MODE]   Deploy the workspace first if it is not deployed
+  list                           List all environments
+  version                        Show version information
+  help                           Show this help message
+
+Status and List Options:
+  --output FORMAT                Print json, yaml or table (default)
+
+Examples:
+  %s status                          # Show all environments
+  %s status production               # Show production environment only
+  %s switch production blue          # Switch production to blue workspace
+  %s switch production green --deploy  # Deploy green if needed, then switch production to it
+  %s list                            # List configured environments
+  %s status --output json            # Show all environments and their health as JSON
+`, prog, prog, prog, prog, prog, prog, prog)
+}
+
+func showVersion(_ string, _ []string) error {
+	info := version.GetBuildInfo()
+	fmt.Printf("environmentctl %s\n", info.Version)
+	fmt.Printf("Built: %s\n", info.BuildDate)
+	fmt.Printf("Commit: %s\n", info.GitCommit)
+	return nil
+}
+
+func handleStatus(_ string, args []string) error {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	switch len(args) {
+	case 0:
+		// Show all environments
+		return showAllEnvironments(format)
+	case 1:
+		// Show specific environment
+		return showEnvironment(args[0], format)
+	}
+	return cli.Usagef("status takes at most one ENVIRONMENT")
+}
+
+func handleSwitch(_ string, args []string) error {
+	var positional []string
+	deploy, mode := false, ""
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--deploy" {
+			deploy = true
+		} else if strings.HasPrefix(arg, "--mode=") {
+			mode = strings.TrimPrefix(arg, "--mode=")
+		} else if arg == "--mode" && i+1 < len(args) {
+			mode = args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--") {
+			return cli.Usagef("unknown option: %s", arg)
+		} else {
+			positional = append(positional, arg)
+		}
+	}
+
+	if len(positional) != 2 {
+		return cli.Usagef("switch requires ENVIRONMENT and WORKSPACE")
+	}
+	if mode != "" && !deploy {
+		return cli.Usagef("--mode requires --deploy")
+	}
+
+	return performSwitch(positional[0], positional[1], deploy, mode)
+}
+
+func handleList(_ string, args []string) error {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	if len(args) != 0 {
+		return cli.Usagef("list takes no arguments")
+	}
+
+	return listEnvironments(format)
+}
+
+func showAllEnvironments(format output.Format) error {
+	environments, err := environment.LoadAllEnvironments()
+	if err != nil {
+		return fmt.Errorf("failed to load environments: %w", err)
+	}
+
+	if format.Structured() {
+		return printEnvironments(environments, format)
+	}
+
+	if len(environments) == 0 {
+		fmt.Println("No environments configured.")
+		fmt.Println("Environment configurations should be placed in /etc/provisioner/ or current directory.")
+		return nil
+	}
+
+	// Sort environments by name for consistent output
+	sort.Slice(environments, func(i, j int) bool {
+		return environments[i].Name < environments[j].Name
+	})
+
+	// The daemon's health checks are shown when the scheduler state can be read
+	sched, _ := loadScheduler()
+
+	fmt.Println("Environment Status:")
+	fmt.Println("")
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENVIRONMENT\tDOMAIN\tASSIGNED WORKSPACE\tTRAFFIC\tHEALTH CHECK\tSTATUS")
+	fmt.Fprintln(w, "-----------\t------\t------------------\t-------\t------------\t------")
+
+	for _, env := range environments {
+		trafficStr := describeTraffic(env.Config)
+		healthCheckStr := env.Config.HealthCheck.Type
+		if env.Config.HealthCheck.Port > 0 {
+			healthCheckStr += fmt.Sprintf(":%d", env.Config.HealthCheck.Port)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			env.Name,
+			env.Config.Domain,
+			env.Config.AssignedWorkspace,
+			trafficStr,
+			healthCheckStr,
+			describeHealth(sched, env))
+	}
+
+	return w.Flush()
+}
+
+func showEnvironment(environmentName string, format output.Format) error {
+	env, err := environment.LoadEnvironment(environmentName)
+	if err != nil {
+		return fmt.Errorf("failed to load environment '%s': %w", environmentName, err)
+	}
+
+	if format.Structured() {
+		// The daemon's recorded health is reported instead of checking the servers now
+		sched, _ := loadScheduler()
+		return output.Print(format, newEnvironmentOutput(sched, *env))
+	}
+
+	fmt.Printf("Environment: %s\n", env.Name)
+	fmt.Printf("Configuration file: %s\n", env.Path)
+	fmt.Printf("Domain: %s\n", env.Config.Domain)
+	fmt.Printf("Assigned workspace: %s\n", env.Config.AssignedWorkspace)
+	fmt.Printf("Traffic: %s\n", describeTraffic(env.Config))
+	fmt.Printf("Health check: %s", env.Config.HealthCheck.Type)
+
+	switch env.Config.HealthCheck.Type {
+	case "http":
+		fmt.Printf(" %s:%d (timeout: %s)", env.Config.HealthCheck.Path, env.Config.HealthCheck.Port, env.Config.HealthCheck.Timeout)
+	case "tcp":
+		fmt.Printf(" port %d (timeout: %s)", env.Config.HealthCheck.Port, env.Config.HealthCheck.Timeout)
+	case "command":
+		fmt.Printf(" '%s' (timeout: %s)", env.Config.HealthCheck.Command, env.Config.HealthCheck.Timeout)
+	}
+	fmt.Println("")
+	showMonitor(env)
+
+	// Perform health check on current environment
+	fmt.Printf("\nPerforming health check on current workspace '%s'...\n", env.Config.AssignedWorkspace)
+	return performHealthCheck(env)
+}
+
+func listEnvironments(format output.Format) error {
+	environments, err := environment.LoadAllEnvironments()
+	if err != nil {
+		return fmt.Errorf("failed to load environments: %w", err)
+	}
+
+	if format.Structured() {
+		return printEnvironments(environments, format)
+	}
+
+	if len(environments) == 0 {
+		fmt.Println("No environments configured.")
+		return nil
+	}
+
+	// Sort environments by name
+	sort.Slice(environments, func(i, j int) bool {
+		return environments[i].Name < environments[j].Name
+	})
+
+	fmt.Println("Configured environments:")
+	for _, env := range environments {
+		fmt.Printf("  %s (assigned to: %s)\n", env.Name, env.Config.AssignedWorkspace)
+	}
+	return nil
+}
+
+func performSwitch(environmentName, workspaceName string, deploy bool, mode string) error {
+	fmt.Printf("Switching environment '%s' to workspace '%s'...\n", environmentName, workspaceName)
+
+	// Load environment
+	env, err := environment.LoadEnvironment(environmentName)
+	if err != nil {
+		return fmt.Errorf("failed to load environment '%s': %w", environmentName, err)
+	}
+
+	// Check if already assigned to this workspace
+	if env.Config.AssignedWorkspace == workspaceName {
+		fmt.Printf("Environment '%s' is already assigned to workspace '%s'\n", environmentName, workspaceName)
+		return nil
+	}
+
+	// The scheduler must consider the target deployed, unless it may be deployed first
+	sched, needsDeploy, err := checkSwitchTarget(workspaceName, deploy, mode)
+	if err != nil {
+		return err
+	}
+
+	// Confirm the switch
+	fmt.Printf("Current assignment: %s -> %s\n", environmentName, env.Config.AssignedWorkspace)
+	fmt.Printf("New assignment: %s -> %s\n", environmentName, workspaceName)
+	fmt.Printf("Traffic to switch: %s\n", describeTraffic(env.Config))
+	if needsDeploy {
+		fmt.Printf("Workspace '%s' is not deployed and will be deployed first\n", workspaceName)
+	}
+	fmt.Printf("\nThis will switch production traffic. Continue? (y/N): ")
+
+	var response string
+	if _, err := fmt.Scanln(&response); err != nil {
+		fmt.Println("\nCancelled.")
+		return nil
+	}
+
+	if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+		fmt.Println("Cancelled.")
+		return nil
+	}
+
+	if needsDeploy {
+		fmt.Printf("\nDeploying workspace '%s'...\n", workspaceName)
+		if sched, err = deploySwitchTarget(sched, workspaceName, mode); err != nil {
+			return err
+		}
+	}
+
+	// Perform the switch
+	previousWorkspace := env.Config.AssignedWorkspace
+	switchOp := &environment.SwitchOperation{
+		Environment:     env,
+		TargetWorkspace: workspaceName,
+	}
+
+	fmt.Println("\n--- Starting Environment Switch ---")
+	result := switchOp.PerformSwitch()
+	auditSwitch(sched, environmentName, workspaceName, result)
+
+	if result.Success {
+		fmt.Printf("✓ Success: %s\n", result.Message)
+		fmt.Printf("Environment '%s' is now assigned to workspace '%s'\n", environmentName, workspaceName)
+		if err := recordSwitch(sched, environmentName, previousWorkspace, workspaceName); err != nil {
+			fmt.Printf("Warning: failed to record the switch in workspace history: %v\n", err)
+		}
+	} else {
+		fmt.Printf("✗ Failed: %s\n", result.Message)
+		if result.Error != nil {
+			fmt.Printf("Error details: %v\n", result.Error)
+		}
+		if result.RolledBack {
+			fmt.Printf("Traffic was returned to the previous servers, environment '%s' stays assigned to '%s'\n", environmentName, previousWorkspace)
+		}
+		if result.RollbackRequired {
+			fmt.Printf("Rollback failed. Check the %s manually.\n", env.Config.Backend)
+		}
+		return &cli.ExitError{Code: 1}
+	}
+	return nil
+}
+
+// auditSwitch records the outcome of an environment switch in the audit log
+func auditSwitch(sched *scheduler.Scheduler, environmentName, workspaceName string, result environment.SwitchResult) {
+	entry := audit.Entry{
+		Operation:   audit.OperationEnvironmentSwitch,
+		Workspace:   workspaceName,
+		Environment: environmentName,
+		Trigger:     audit.CLITrigger(),
+		Outcome:     audit.OutcomeSucceeded,
+	}
+	if !result.Success {
+		entry.Outcome = audit.OutcomeFailed
+		entry.Error = result.Message
+		if result.Error != nil {
+			entry.Error = fmt.Sprintf("%s: %v", result.Message, result.Error)
+		}
+	}
+	sched.AuditLog().Record(entry)
+}
+
+// loadScheduler loads the workspaces and the scheduler state the daemon saves
+func loadScheduler() (*scheduler.Scheduler, error) {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return nil, fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return nil, fmt.Errorf("failed to load state: %w", err)
+	}
+	return sched, nil
+}
+
+// checkSwitchTarget verifies the scheduler state of the workspace an environment is switched to.
+// Returns whether it has to be deployed first, which is only allowed with deploy.
+func checkSwitchTarget(workspaceName string, deploy bool, mode string) (*scheduler.Scheduler, bool, error) {
+	sched, err := loadScheduler()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = sched.CheckSwitchTarget(workspaceName)
+	if err == nil || !errors.Is(err, scheduler.ErrSwitchTargetNotDeployed) {
+		return sched, false, err
+	}
+	if !deploy {
+		return nil, false, fmt.Errorf("%v, deploy it first or use --deploy", err)
+	}
+
+	ws := sched.GetWorkspace(workspaceName)
+	if modeSchedules, _ := ws.Config.GetModeSchedules(); len(modeSchedules) > 0 && mode == "" {
+		return nil, false, fmt.Errorf("workspace '%s' uses mode_schedules, use --mode MODE with --deploy", workspaceName)
+	}
+	return sched, true, nil
+}
+
+// deploySwitchTarget deploys the workspace through the daemon when it is running, otherwise
+// directly, and returns the scheduler with the resulting state once the workspace is deployed
+func deploySwitchTarget(sched *scheduler.Scheduler, workspaceName, mode string) (*scheduler.Scheduler, error) {
+	if client, err := control.Dial(); err == nil {
+		message, err := client.Deploy(workspaceName, mode, "", false)
+		_ = client.Close()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Println(message)
+	} else {
+		if mode != "" {
+			err = sched.ManualDeployInMode(workspaceName, mode)
+		} else {
+			err = sched.ManualDeploy(workspaceName)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sched, err := loadScheduler()
+	if err != nil {
+		return nil, err
+	}
+	if err := sched.CheckSwitchTarget(workspaceName); err != nil {
+		return nil, fmt.Errorf("deployment of workspace '%s' did not succeed, environment not switched: %w", workspaceName, err)
+	}
+	return sched, nil
+}
+
+// recordSwitch records the switch in the workspace history through the daemon when it is
+// running, otherwise in the state file the daemon loads on start
+func recordSwitch(sched *scheduler.Scheduler, environmentName, from, to string) error {
+	if client, err := control.Dial(); err == nil {
+		defer func() { _ = client.Close() }()
+		_, err := client.RecordEnvironmentSwitch(environmentName, from, to)
+		return err
+	}
+	return sched.RecordEnvironmentSwitch(environmentName, from, to)
+}
+
+// environmentHealth returns the health the daemon recorded for an environment, nil if it is not
+// monitored or was not checked yet by the daemon
+func environmentHealth(sched *scheduler.Scheduler, env environment.Environment) *scheduler.EnvironmentHealth {
+	if sched == nil || env.Config.Monitor.Disabled {
+		return nil
+	}
+	health := sched.GetEnvironmentHealth(env.Name)
+	if health == nil || health.Workspace != env.Config.AssignedWorkspace {
+		// Checks of a previously assigned workspace don't apply
+		return nil
+	}
+	return health
+}
+
+// describeHealth summarizes the daemon's health checks of an environment
+func describeHealth(sched *scheduler.Scheduler, env environment.Environment) string {
+	if env.Config.Monitor.Disabled {
+		return "not monitored"
+	}
+	health := environmentHealth(sched, env)
+	if health == nil {
+		return scheduler.EnvironmentUnchecked
+	}
+	switch health.Status() {
+	case scheduler.EnvironmentDegraded, scheduler.EnvironmentFailing:
+		return fmt.Sprintf("%s (%d failed checks)", health.Status(), health.ConsecutiveFailures)
+	}
+	return health.Status()
+}
+
+// showMonitor prints the daemon's periodic health checks of an environment
+func showMonitor(env *environment.Environment) {
+	monitor := env.Config.Monitor
+	if monitor.Disabled {
+		fmt.Println("Monitoring: disabled")
+		return
+	}
+	fmt.Printf("Monitoring: every %s, degraded after %d failed checks\n", monitor.Interval, monitor.FailureThreshold)
+
+	sched, err := loadScheduler()
+	if err != nil {
+		fmt.Printf("Status: unknown (%v)\n", err)
+		return
+	}
+	health := environmentHealth(sched, *env)
+	if health == nil {
+		fmt.Println("Status: not checked by the daemon yet")
+		return
+	}
+
+	switch health.Status() {
+	case scheduler.EnvironmentDegraded:
+		fmt.Printf("Status: DEGRADED since %s (%d consecutive failed checks)\n", logging.FormatTime(*health.DegradedSince), health.ConsecutiveFailures)
+	case scheduler.EnvironmentFailing:
+		fmt.Printf("Status: failing (%d of %d failed checks before degraded)\n", health.ConsecutiveFailures, monitor.FailureThreshold)
+	default:
+		fmt.Printf("Status: %s\n", health.Status())
+	}
+	if health.LastCheck != nil {
+		fmt.Printf("Last check: %s\n", logging.FormatTime(*health.LastCheck))
+	}
+	if health.LastHealthy != nil && health.ConsecutiveFailures > 0 {
+		fmt.Printf("Last healthy: %s\n", logging.FormatTime(*health.LastHealthy))
+	}
+	if health.LastError != "" {
+		fmt.Printf("Last error: %s\n", health.LastError)
+	}
+}
+
+// environmentOutput is the structured output of status and list
+type environmentOutput struct {
+	Name string `json:"name"`
+	Path string `json:"path"`
+	environment.Config
+	Traffic string                       `json:"traffic"`
+	Status  string                       `json:"status"` // healthy, failing, degraded, unchecked or not monitored
+	Health  *scheduler.EnvironmentHealth `json:"health,omitempty"`
+}
+
+func newEnvironmentOutput(sched *scheduler.Scheduler, env environment.Environment) environmentOutput {
+	out := environmentOutput{
+		Name:    env.Name,
+		Path:    env.Path,
+		Config:  env.Config,
+		Traffic: describeTraffic(env.Config),
+		Status:  scheduler.EnvironmentUnchecked,
+		Health:  environmentHealth(sched, env),
+	}
+	if env.Config.Monitor.Disabled {
+		out.Status = "not monitored"
+	} else if out.Health != nil {
+		out.Status = out.Health.Status()
+	}
+	return out
+}
+
+// printEnvironments prints environments sorted by name with the daemon's recorded health
+func printEnvironments(environments []environment.Environment, format output.Format) error {
+	sort.Slice(environments, func(i, j int) bool {
+		return environments[i].Name < environments[j].Name
+	})
+
+	sched, _ := loadScheduler()
+	entries := make([]environmentOutput, 0, len(environments))
+	for _, env := range environments {
+		entries = append(entries, newEnvironmentOutput(sched, env))
+	}
+	return output.Print(format, entries)
+}
+
+// describeTraffic describes where an environment's backend sends traffic
+func describeTraffic(config environment.Config) string {
+	if config.Backend.Type == environment.BackendReservedIP {
+		return strings.Join(config.ReservedIPs, ", ")
+	}
+	return config.Backend.String()
+}
+
+// performHealthCheck checks the addresses serving the environment's assigned workspace
+func performHealthCheck(env *environment.Environment) error {
+	endpoints, err := env.CurrentEndpoints()
+	if err != nil {
+		return err
+	}
+
+	healthCheck := env.Config.HealthCheck
+	fmt.Printf("Checking %s, up to %d attempt(s) %s apart...\n",
+		strings.Join(endpoints, ", "), healthCheck.Attempts, healthCheck.Interval)
+
+	results := healthCheck.PerformBulkHealthChecks(endpoints)
+	for i, result := range results {
+		if result.Success {
+			fmt.Printf("  ✓ %s: %s\n", endpoints[i], result.Message)
+		} else {
+			fmt.Printf("  ✗ %s: %s\n", endpoints[i], result.Message)
+		}
+	}
+	if !environment.AllHealthy(results) {
+		fmt.Printf("Use 'workspacectl status %s' to check the workspace's deployment\n", env.Config.AssignedWorkspace)
+		return &cli.ExitError{Code: 1}
+	}
+	return nil
+}
@@ -0,0 +1,1502 @@
+// Package jobctl implements the job management commands.
+package jobctl
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"provisioner/pkg/cli"
+	"provisioner/pkg/control"
+	"provisioner/pkg/gitops"
+	"provisioner/pkg/job"
+	"provisioner/pkg/listing"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/output"
+	"provisioner/pkg/scheduler"
+)
+
+func printUsage(prog string) {
+	fmt.Printf(`Usage: %s [OPTIONS] COMMAND [ARGUMENTS...]
+
+Job management CLI for OpenTofu Workspace Scheduler.
+
+Commands:
+  list [OPTIONS]               List all jobs with their status and next run
+  status [JOB] [--run ID]      Show status of all jobs, a specific job, or a specific run
+  run JOB [--detach]           Run specific job immediately (--detach returns a run ID)
+  wait JOB --run ID            Wait for a detached run to finish
+  kill JOB                     Kill running job
+  history JOB                  Show the last runs of a job with their exit codes
+  show-run JOB RUN_ID          Show a run from the history with its captured output
+  artifacts JOB [RUN_ID]       List the artifacts of a job's runs (--download DIR copies a run's)
+  import-crontab FILE          Convert crontab entries into standalone job files
+  logs JOB                     Show recent logs for specific job (coming soon)
+
+Run Options:
+  --detach                     Start the job in the background and print its run ID
+  --run ID                     Select a detached run (status, wait)
+  --timeout DURATION           Give up waiting after DURATION (wait only, e.g. 30m)
+  --download DIR               Copy the artifacts of a run into DIR (artifacts only)
+  --output FORMAT              Print json, yaml or table (default) (list, status, history, show-run, artifacts)
+
+List/Status Options:
+  --filter FIELD=VALUE         Only show jobs whose field matches VALUE (glob patterns allowed, repeatable)
+  --filter FIELD==VALUE        Only show jobs whose field is VALUE (FIELD!=VALUE: is not)
+  --columns FIELD,...          Show these fields as table columns, e.g. job,status,next_run
+  --sort [-]FIELD              Sort by field, prefix with - for descending order
+  --limit N                    Show at most N jobs per page
+  --page N                     Show page N (requires --limit)
+  Fields: name (or job), type, enabled, status, last-run, next-run
+
+Import Options:
+  --system                     Crontab has a user field (/etc/crontab, /etc/cron.d)
+  --prefix PREFIX              Prefix for generated job names
+  --disabled                   Create imported jobs disabled for review
+  --dry-run                    Print generated jobs without writing files
+  --force                      Overwrite existing job files
+
+Options:
+  --workspace NAME             Operate on jobs within the specified workspace
+  --utc                        Show timestamps in UTC
+  --help                       Show this help
+  --version                    Show version
+  --version-full               Show detailed version
+
+Examples:
+  # Standalone jobs (default)
+  %s list                              # List all standalone jobs
+  %s list --filter status=failed --sort -last-run  # Failed jobs, most recent first
+  %s status                            # Show status of all standalone jobs
+  %s status cleanup-temp               # Show status of 'cleanup-temp' standalone job
+  %s status cleanup-temp --output json # Machine-readable status of 'cleanup-temp'
+  %s status --filter status==failed --columns job,last_run,next_run  # Report of failed jobs
+  %s run cleanup-temp                  # Run 'cleanup-temp' standalone job immediately
+  %s kill long-job                     # Kill running standalone job
+  %s run cleanup-temp --detach         # Start job in background, print run ID
+  %s wait cleanup-temp --run RUN_ID    # Wait for detached run to finish
+  %s history cleanup-temp              # Show the last runs of 'cleanup-temp'
+  %s show-run cleanup-temp RUN_ID      # Show the output of a run of 'cleanup-temp'
+  %s artifacts backup-db RUN_ID --download ./backup  # Copy the files a run collected
+  %s import-crontab /etc/crontab --system --dry-run  # Preview crontab import
+
+  # Workspace jobs (with --workspace flag)
+  %s --workspace my-app list           # List all jobs in 'my-app' workspace
+  %s --workspace my-app status         # Show status of all jobs in 'my-app'
+  %s --workspace my-app status backup-db # Show status of 'backup-db' job
+  %s --workspace my-app run backup-db  # Run 'backup-db' job immediately
+  %s --workspace my-app kill backup-db # Kill running job
+  %s history backup-db --workspace my-app # Show the last runs of 'backup-db'
+
+Notes:
+  By default, jobctl operates on standalone jobs (defined in jobs/ directory).
+  Use --workspace flag to operate on jobs within a specific workspace.
+  Workspace jobs are defined in workspace configuration files (workspaces/*/config.json, .yaml or .hcl).
+
+Related Tools:
+  provisioner      Workspace scheduler daemon
+  provisionerctl   Unified CLI, 'provisionerctl job' runs these commands
+  workspacectl     Workspace management CLI
+  templatectl      Template management CLI
+`, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog)
+}
+
+// Command returns the job management commands, the jobctl binary and provisionerctl's job group.
+// Commands operate on standalone jobs unless --workspace is given before them.
+func Command() *cli.Command {
+	var workspaceName string
+	withWorkspace := func(command func(prog, workspaceName string, args []string) error) func(string, []string) error {
+		return func(prog string, args []string) error {
+			return command(prog, workspaceName, args)
+		}
+	}
+
+	return &cli.Command{
+		Name:    "job",
+		Summary: "Manage standalone and workspace jobs (list, status, run, wait, kill, history)",
+		Usage:   printUsage,
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&workspaceName, "workspace", "", "Operate on jobs within the specified workspace")
+		},
+		Commands: []*cli.Command{
+			{Name: "list", Run: withWorkspace(listCommand)},
+			{Name: "status", Run: withWorkspace(statusCommand)},
+			{Name: "run", Run: withWorkspace(runCommand)},
+			{Name: "wait", Run: withWorkspace(waitCommand)},
+			{Name: "kill", Run: withWorkspace(killCommand)},
+			{Name: "history", Run: withWorkspace(historyCommand)},
+			{Name: "show-run", Run: withWorkspace(showRunCommand)},
+			{Name: "artifacts", Run: withWorkspace(artifactsCommand)},
+			{Name: "logs", Run: withWorkspace(logsCommand)},
+			{Name: "import-crontab", Run: withWorkspace(importCrontabCommand)},
+		},
+	}
+}
+
+func listCommand(_, workspaceName string, args []string) error {
+	opts, format, err := parseListOptions(args)
+	if err != nil {
+		return err
+	}
+	if workspaceName != "" {
+		return runWorkspaceListCommand(workspaceName, opts, format)
+	}
+	return runStandaloneListCommand(opts, format)
+}
+
+func statusCommand(_, workspaceName string, args []string) error {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	listOpts, args, err := listing.ParseArgs(args)
+	if err == nil {
+		err = listOpts.Validate(scheduler.JobListFields)
+	}
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	opts, err := parseRunOptions(args)
+	if err != nil {
+		return err
+	}
+	selects := len(listOpts.Filters) > 0 || listOpts.Sort != "" || listOpts.Limit > 0 || len(listOpts.Columns) > 0
+	if selects && opts.jobName != "" {
+		return cli.Usagef("--filter, --sort, --limit and --columns apply to the status of all jobs, not of a single job")
+	}
+
+	if opts.runID != "" {
+		if opts.jobName == "" {
+			return cli.Usagef("status --run requires job name")
+		}
+		if workspaceName != "" {
+			return runWorkspaceRunStatusCommand(workspaceName, opts.jobName, opts.runID, format)
+		}
+		return runStandaloneRunStatusCommand(opts.jobName, opts.runID, format)
+	}
+
+	if workspaceName != "" {
+		return runWorkspaceStatusCommand(workspaceName, opts.jobName, listOpts, format)
+	}
+	return runStandaloneStatusCommand(opts.jobName, listOpts, format)
+}
+
+func runCommand(prog, workspaceName string, args []string) error {
+	opts, err := parseRunOptions(args)
+	if err != nil {
+		return err
+	}
+	if opts.jobName == "" {
+		return cli.Usagef("run command requires job name")
+	}
+
+	if workspaceName != "" {
+		switch {
+		case opts.detach:
+			return runWorkspaceDetachCommand(prog, workspaceName, opts.jobName)
+		case opts.runID != "":
+			return runWorkspaceRecordedRunCommand(workspaceName, opts.jobName, opts.runID)
+		}
+		return runWorkspaceJobCommand(workspaceName, opts.jobName)
+	}
+
+	switch {
+	case opts.detach:
+		return runStandaloneDetachCommand(prog, opts.jobName)
+	case opts.runID != "":
+		return runStandaloneRecordedRunCommand(opts.jobName, opts.runID)
+	}
+	return runStandaloneRunCommand(opts.jobName)
+}
+
+func waitCommand(_, workspaceName string, args []string) error {
+	opts, err := parseRunOptions(args)
+	if err != nil {
+		return err
+	}
+	if opts.jobName == "" || opts.runID == "" {
+		return cli.Usagef("wait command requires job name and --run ID")
+	}
+
+	if workspaceName != "" {
+		return runWorkspaceWaitCommand(workspaceName, opts.jobName, opts.runID, opts.timeout)
+	}
+	return runStandaloneWaitCommand(opts.jobName, opts.runID, opts.timeout)
+}
+
+func killCommand(_, workspaceName string, args []string) error {
+	if err := cli.Args(args, 1, 1, "kill command requires job name"); err != nil {
+		return err
+	}
+	if workspaceName != "" {
+		return runWorkspaceKillCommand(workspaceName, args[0])
+	}
+	return runStandaloneKillCommand(args[0])
+}
+
+func historyCommand(_, workspaceName string, args []string) error {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	args, workspaceName, err = workspaceOption(args, workspaceName)
+	if err != nil {
+		return err
+	}
+	if err := cli.Args(args, 1, 1, "history command requires job name"); err != nil {
+		return err
+	}
+
+	if workspaceName != "" {
+		return runWorkspaceHistoryCommand(workspaceName, args[0], format)
+	}
+	return runStandaloneHistoryCommand(args[0], format)
+}
+
+func showRunCommand(_, workspaceName string, args []string) error {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	args, workspaceName, err = workspaceOption(args, workspaceName)
+	if err != nil {
+		return err
+	}
+	if err := cli.Args(args, 2, 2, "show-run command requires job name and run ID"); err != nil {
+		return err
+	}
+
+	if workspaceName != "" {
+		return runWorkspaceShowRunCommand(workspaceName, args[0], args[1], format)
+	}
+	return runStandaloneShowRunCommand(args[0], args[1], format)
+}
+
+func artifactsCommand(_, workspaceName string, args []string) error {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	args, workspaceName, err = workspaceOption(args, workspaceName)
+	if err != nil {
+		return err
+	}
+	args, downloadDir, err := cli.ExtractOption(args, "--download")
+	if err != nil {
+		return err
+	}
+	if err := cli.Args(args, 1, 2, "artifacts command requires job name"); err != nil {
+		return err
+	}
+
+	jobName, runID := args[0], ""
+	if len(args) == 2 {
+		runID = args[1]
+	}
+	if downloadDir != "" && runID == "" {
+		return cli.Usagef("--download requires a run ID")
+	}
+
+	if workspaceName != "" {
+		return runWorkspaceArtifactsCommand(workspaceName, jobName, runID, downloadDir, format)
+	}
+	return runStandaloneArtifactsCommand(jobName, runID, downloadDir, format)
+}
+
+// workspaceOption accepts --workspace after the command as well as before it
+func workspaceOption(args []string, workspaceName string) ([]string, string, error) {
+	args, value, err := cli.ExtractOption(args, "--workspace")
+	if err != nil {
+		return nil, "", err
+	}
+	if value != "" {
+		workspaceName = value
+	}
+	return args, workspaceName, nil
+}
+
+func logsCommand(_, workspaceName string, args []string) error {
+	if err := cli.Args(args, 1, 1, "logs command requires job name"); err != nil {
+		return err
+	}
+	fmt.Printf("Job logs feature coming soon!\n")
+	if workspaceName != "" {
+		fmt.Printf("For now, check workspace logs: workspacectl logs %s\n", workspaceName)
+	} else {
+		fmt.Printf("For now, check system logs: journalctl -u provisioner\n")
+	}
+	return nil
+}
+
+func importCrontabCommand(_, workspaceName string, args []string) error {
+	if workspaceName != "" {
+		return cli.Usagef("import-crontab creates standalone jobs and doesn't take --workspace")
+	}
+	return runImportCrontabCommand(args)
+}
+
+// runOptions holds the arguments shared by run, status and wait
+type runOptions struct {
+	jobName string
+	runID   string
+	detach  bool
+	timeout time.Duration
+}
+
+// parseRunOptions parses a job name plus --detach, --run, --run-id and --timeout flags
+func parseRunOptions(args []string) (runOptions, error) {
+	var opts runOptions
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--detach":
+			opts.detach = true
+		case strings.HasPrefix(arg, "--run="):
+			opts.runID = strings.TrimPrefix(arg, "--run=")
+		case (arg == "--run" || arg == "--run-id") && i+1 < len(args):
+			opts.runID = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--timeout="), arg == "--timeout" && i+1 < len(args):
+			value := strings.TrimPrefix(arg, "--timeout=")
+			if arg == "--timeout" {
+				value = args[i+1]
+				i++
+			}
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return opts, cli.Usagef("invalid timeout '%s': %v", value, err)
+			}
+			opts.timeout = timeout
+		case strings.HasPrefix(arg, "--"):
+			return opts, cli.Usagef("unknown option '%s'", arg)
+		case opts.jobName == "":
+			opts.jobName = arg
+		default:
+			return opts, cli.Usagef("unexpected argument '%s'", arg)
+		}
+	}
+
+	if opts.detach && opts.runID != "" {
+		return opts, cli.Usagef("--detach cannot be combined with --run")
+	}
+
+	return opts, nil
+}
+
+// parseListOptions parses list filtering, paging and output options
+func parseListOptions(args []string) (listing.Options, output.Format, error) {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return listing.Options{}, format, cli.Usagef("%v", err)
+	}
+	opts, rest, err := listing.ParseArgs(args)
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("unexpected argument '%s' for list", rest[0])
+	}
+	if err != nil {
+		return opts, format, cli.Usagef("%v", err)
+	}
+	return opts, format, nil
+}
+
+// spawnDetachedRun re-executes the CLI in a new session to carry out a recorded run. prog is the
+// command line of the run command, e.g. "provisionerctl job run", whose groups are passed on.
+func spawnDetachedRun(prog, workspaceName, jobName, runID string) (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate jobctl executable: %w", err)
+	}
+
+	commands := strings.Fields(strings.TrimPrefix(prog, os.Args[0]))
+	childArgs := commands[:len(commands)-1]
+	if workspaceName != "" {
+		childArgs = append(childArgs, "--workspace", workspaceName)
+	}
+	childArgs = append(childArgs, "run", jobName, "--run-id", runID)
+
+	cmd := exec.Command(executable, childArgs...)
+	// Detach from the terminal so the run survives the CLI exiting
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start background run: %w", err)
+	}
+
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	return pid, nil
+}
+
+// Standalone job functions
+
+func runStandaloneListCommand(opts listing.Options, format output.Format) error {
+	if err := opts.Validate(scheduler.JobListFields); err != nil {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+
+	summaries, err := sched.StandaloneJobSummaries(time.Now())
+	if err != nil {
+		return err
+	}
+	if len(summaries) == 0 && !format.Structured() {
+		fmt.Printf("No standalone jobs configured\n")
+		return nil
+	}
+
+	return scheduler.ShowJobList(summaries, opts, format)
+}
+
+func runStandaloneStatusCommand(jobName string, opts listing.Options, format output.Format) error {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return fmt.Errorf("standalone job manager not available")
+	}
+
+	if jobName != "" {
+		return showStandaloneJobStatus(standaloneJobManager, jobName, format)
+	} else {
+		return showAllStandaloneJobsStatus(sched, standaloneJobManager, opts, format)
+	}
+}
+
+func runStandaloneRunCommand(jobName string) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		fmt.Printf("Running standalone job '%s' via daemon...\n", jobName)
+		return client.RunJob("", jobName)
+	}); handled {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return fmt.Errorf("standalone job manager not available")
+	}
+
+	fmt.Printf("Running standalone job '%s'...\n", jobName)
+
+	if err := standaloneJobManager.ExecuteStandaloneJob(jobName); err != nil {
+		return fmt.Errorf("failed to execute standalone job: %w", err)
+	}
+
+	fmt.Printf("Standalone job '%s' completed successfully\n", jobName)
+	return nil
+}
+
+func runStandaloneDetachCommand(prog, jobName string) error {
+	sched := scheduler.NewQuiet()
+
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return fmt.Errorf("standalone job manager not available")
+	}
+
+	run, err := standaloneJobManager.CreateStandaloneRun(jobName)
+	if err != nil {
+		return fmt.Errorf("failed to create run: %w", err)
+	}
+
+	pid, err := spawnDetachedRun(prog, "", jobName, run.ID)
+	if err != nil {
+		return err
+	}
+
+	// Record the PID so waiters notice if the process dies before it takes over the record
+	_ = sched.GetJobManager().SetRunPID(run.WorkspaceID, jobName, run.ID, pid)
+
+	fmt.Printf("Started standalone job '%s' in background\n", jobName)
+	fmt.Printf("Run ID: %s\n", run.ID)
+	fmt.Printf("Check progress with: jobctl status %s --run %s\n", jobName, run.ID)
+	return nil
+}
+
+func runStandaloneRecordedRunCommand(jobName, runID string) error {
+	sched := scheduler.NewQuiet()
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return fmt.Errorf("standalone job manager not available")
+	}
+
+	return standaloneJobManager.ExecuteStandaloneJobRun(jobName, runID)
+}
+
+func runStandaloneRunStatusCommand(jobName, runID string, format output.Format) error {
+	sched := scheduler.NewQuiet()
+
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return fmt.Errorf("standalone job manager not available")
+	}
+
+	run, err := standaloneJobManager.GetStandaloneRun(jobName, runID)
+	if err != nil {
+		return err
+	}
+
+	return showRunStatus(run, "", format)
+}
+
+func runStandaloneWaitCommand(jobName, runID string, timeout time.Duration) error {
+	sched := scheduler.NewQuiet()
+
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return fmt.Errorf("standalone job manager not available")
+	}
+
+	fmt.Printf("Waiting for run '%s' of standalone job '%s'...\n", runID, jobName)
+
+	run, err := standaloneJobManager.WaitForStandaloneRun(jobName, runID, timeout)
+	if err != nil {
+		return err
+	}
+
+	return reportFinishedRun(run)
+}
+
+func runImportCrontabCommand(args []string) error {
+	var crontabPath string
+	var opts job.CrontabImportOptions
+	dryRun := false
+	force := false
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--system":
+			opts.System = true
+		case arg == "--disabled":
+			opts.Disabled = true
+		case arg == "--dry-run":
+			dryRun = true
+		case arg == "--force":
+			force = true
+		case strings.HasPrefix(arg, "--prefix="):
+			opts.NamePrefix = strings.TrimPrefix(arg, "--prefix=")
+		case arg == "--prefix" && i+1 < len(args):
+			opts.NamePrefix = args[i+1]
+			i++
+		case strings.HasPrefix(arg, "--"):
+			return fmt.Errorf("unknown option '%s'", arg)
+		case crontabPath == "":
+			crontabPath = arg
+		default:
+			return fmt.Errorf("unexpected argument '%s'", arg)
+		}
+	}
+
+	if crontabPath == "" {
+		return fmt.Errorf("import-crontab requires a crontab file path")
+	}
+	if !dryRun {
+		if err := gitops.CheckEditable(); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.Open(crontabPath)
+	if err != nil {
+		return fmt.Errorf("failed to open crontab: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	result, err := job.ParseCrontab(file, opts)
+	if err != nil {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return fmt.Errorf("standalone job manager not available")
+	}
+
+	imported := 0
+	for _, jobConfig := range result.Jobs {
+		if dryRun {
+			data, err := json.MarshalIndent(jobConfig, "", "  ")
+			if err != nil {
+				return fmt.Errorf("failed to marshal job '%s': %w", jobConfig.Name, err)
+			}
+			fmt.Printf("# %s.json\n%s\n\n", jobConfig.Name, data)
+			imported++
+			continue
+		}
+
+		jobPath, err := standaloneJobManager.SaveStandaloneJobConfig(jobConfig, force)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("%s: %v", jobConfig.Name, err))
+			continue
+		}
+		fmt.Printf("Created %s\n", jobPath)
+		imported++
+	}
+
+	for _, warning := range result.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+	for _, skipped := range result.Skipped {
+		fmt.Printf("Skipped: %s\n", skipped)
+	}
+
+	if dryRun {
+		fmt.Printf("\n%d jobs would be imported into %s (%d skipped)\n", imported, standaloneJobManager.GetJobsDir(), len(result.Skipped))
+	} else {
+		fmt.Printf("\nImported %d jobs into %s (%d skipped)\n", imported, standaloneJobManager.GetJobsDir(), len(result.Skipped))
+	}
+
+	return nil
+}
+
+func runStandaloneKillCommand(jobName string) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.KillJob("", jobName)
+	}); handled {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return fmt.Errorf("standalone job manager not available")
+	}
+
+	fmt.Printf("Killing standalone job '%s'...\n", jobName)
+
+	if err := standaloneJobManager.KillStandaloneJob(jobName); err != nil {
+		return fmt.Errorf("failed to kill standalone job: %w", err)
+	}
+
+	fmt.Printf("Standalone job '%s' killed successfully\n", jobName)
+	return nil
+}
+
+func runStandaloneHistoryCommand(jobName string, format output.Format) error {
+	standaloneJobManager, err := loadStandaloneJobManager()
+	if err != nil {
+		return err
+	}
+
+	history, err := standaloneJobManager.GetStandaloneHistory(jobName)
+	if err != nil {
+		return err
+	}
+
+	return showHistory(history, jobName, "", format)
+}
+
+func runStandaloneShowRunCommand(jobName, runID string, format output.Format) error {
+	standaloneJobManager, err := loadStandaloneJobManager()
+	if err != nil {
+		return err
+	}
+
+	entry, err := standaloneJobManager.GetStandaloneHistoryEntry(jobName, runID)
+	if err != nil {
+		return err
+	}
+
+	return showHistoryEntry(entry, jobName, "", "_standalone_", format)
+}
+
+func runStandaloneArtifactsCommand(jobName, runID, downloadDir string, format output.Format) error {
+	standaloneJobManager, err := loadStandaloneJobManager()
+	if err != nil {
+		return err
+	}
+
+	if downloadDir != "" {
+		artifacts, err := standaloneJobManager.DownloadStandaloneArtifacts(jobName, runID, downloadDir)
+		if err != nil {
+			return err
+		}
+		return showDownloadedArtifacts(artifacts, runID, downloadDir)
+	}
+
+	artifacts, err := standaloneJobManager.GetStandaloneArtifacts(jobName, runID)
+	if err != nil {
+		return err
+	}
+	return showArtifacts(artifacts, jobName, "", format)
+}
+
+// loadStandaloneJobManager returns the standalone job manager with the job state loaded
+func loadStandaloneJobManager() (*job.StandaloneJobManager, error) {
+	sched := scheduler.NewQuiet()
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return nil, fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+
+	standaloneJobManager := sched.GetStandaloneJobManager()
+	if standaloneJobManager == nil {
+		return nil, fmt.Errorf("standalone job manager not available")
+	}
+	return standaloneJobManager, nil
+}
+
+// Workspace job functions
+
+func runWorkspaceListCommand(workspaceName string, opts listing.Options, format output.Format) error {
+	if err := opts.Validate(scheduler.JobListFields); err != nil {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+
+	summaries, err := sched.WorkspaceJobSummaries(workspaceName, time.Now())
+	if err != nil {
+		return err
+	}
+	if len(summaries) == 0 && !format.Structured() {
+		fmt.Printf("No jobs defined for workspace '%s'\n", workspaceName)
+		return nil
+	}
+
+	return scheduler.ShowJobList(summaries, opts, format)
+}
+
+func runWorkspaceStatusCommand(workspaceName, jobName string, opts listing.Options, format output.Format) error {
+	sched := scheduler.NewQuiet()
+
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+
+	if jobName != "" {
+		return showWorkspaceJobStatus(sched, workspaceName, jobName, format)
+	} else {
+		return showAllWorkspaceJobsStatus(sched, workspaceName, opts, format)
+	}
+}
+
+func runWorkspaceJobCommand(workspaceName, jobName string) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		fmt.Printf("Running job '%s' in workspace '%s' via daemon...\n", jobName, workspaceName)
+		return client.RunJob(workspaceName, jobName)
+	}); handled {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	fmt.Printf("Running job '%s' in workspace '%s'...\n", jobName, workspaceName)
+
+	if err := sched.ManualExecuteJob(workspaceName, jobName); err != nil {
+		return fmt.Errorf("failed to execute job: %w", err)
+	}
+
+	fmt.Printf("Job '%s' completed successfully\n", jobName)
+	return nil
+}
+
+func runWorkspaceDetachCommand(prog, workspaceName, jobName string) error {
+	sched := scheduler.NewQuiet()
+
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+
+	run, err := sched.CreateJobRun(workspaceName, jobName)
+	if err != nil {
+		return fmt.Errorf("failed to create run: %w", err)
+	}
+
+	pid, err := spawnDetachedRun(prog, workspaceName, jobName, run.ID)
+	if err != nil {
+		return err
+	}
+
+	// Record the PID so waiters notice if the process dies before it takes over the record
+	_ = sched.GetJobManager().SetRunPID(workspaceName, jobName, run.ID, pid)
+
+	fmt.Printf("Started job '%s' in workspace '%s' in background\n", jobName, workspaceName)
+	fmt.Printf("Run ID: %s\n", run.ID)
+	fmt.Printf("Check progress with: jobctl --workspace %s status %s --run %s\n", workspaceName, jobName, run.ID)
+	return nil
+}
+
+func runWorkspaceRecordedRunCommand(workspaceName, jobName, runID string) error {
+	sched := scheduler.NewQuiet()
+
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+
+	return sched.ManualExecuteJobRun(workspaceName, jobName, runID)
+}
+
+func runWorkspaceRunStatusCommand(workspaceName, jobName, runID string, format output.Format) error {
+	sched := scheduler.NewQuiet()
+
+	run, err := sched.GetJobRun(workspaceName, jobName, runID)
+	if err != nil {
+		return err
+	}
+
+	return showRunStatus(run, workspaceName, format)
+}
+
+func runWorkspaceWaitCommand(workspaceName, jobName, runID string, timeout time.Duration) error {
+	sched := scheduler.NewQuiet()
+
+	fmt.Printf("Waiting for run '%s' of job '%s' in workspace '%s'...\n", runID, jobName, workspaceName)
+
+	run, err := sched.WaitForJobRun(workspaceName, jobName, runID, timeout)
+	if err != nil {
+		return err
+	}
+
+	return reportFinishedRun(run)
+}
+
+func runWorkspaceKillCommand(workspaceName, jobName string) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.KillJob(workspaceName, jobName)
+	}); handled {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	fmt.Printf("Killing job '%s' in workspace '%s'...\n", jobName, workspaceName)
+
+	if err := sched.KillJob(workspaceName, jobName); err != nil {
+		return fmt.Errorf("failed to kill job: %w", err)
+	}
+
+	fmt.Printf("Job '%s' killed successfully\n", jobName)
+	return nil
+}
+
+func runWorkspaceHistoryCommand(workspaceName, jobName string, format output.Format) error {
+	sched, err := loadWorkspaceJobs()
+	if err != nil {
+		return err
+	}
+
+	history, err := sched.GetJobHistory(workspaceName, jobName)
+	if err != nil {
+		return err
+	}
+
+	return showHistory(history, jobName, workspaceName, format)
+}
+
+func runWorkspaceShowRunCommand(workspaceName, jobName, runID string, format output.Format) error {
+	sched, err := loadWorkspaceJobs()
+	if err != nil {
+		return err
+	}
+
+	entry, err := sched.GetJobHistoryEntry(workspaceName, jobName, runID)
+	if err != nil {
+		return err
+	}
+
+	return showHistoryEntry(entry, jobName, workspaceName, workspaceName, format)
+}
+
+func runWorkspaceArtifactsCommand(workspaceName, jobName, runID, downloadDir string, format output.Format) error {
+	sched, err := loadWorkspaceJobs()
+	if err != nil {
+		return err
+	}
+
+	if downloadDir != "" {
+		artifacts, err := sched.DownloadJobArtifacts(workspaceName, jobName, runID, downloadDir)
+		if err != nil {
+			return err
+		}
+		return showDownloadedArtifacts(artifacts, runID, downloadDir)
+	}
+
+	artifacts, err := sched.GetJobArtifacts(workspaceName, jobName, runID)
+	if err != nil {
+		return err
+	}
+	return showArtifacts(artifacts, jobName, workspaceName, format)
+}
+
+// loadWorkspaceJobs returns a scheduler with the workspaces and job state loaded
+func loadWorkspaceJobs() (*scheduler.Scheduler, error) {
+	sched := scheduler.NewQuiet()
+
+	if err := sched.LoadWorkspaces(); err != nil {
+		return nil, fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if jobManager := sched.GetJobManager(); jobManager != nil {
+		if err := jobManager.LoadState(); err != nil {
+			return nil, fmt.Errorf("failed to load job state: %w", err)
+		}
+	}
+	return sched, nil
+}
+
+// Status display functions
+
+func showStandaloneJobStatus(standaloneJobManager *job.StandaloneJobManager, jobName string, format output.Format) error {
+	jobStates := standaloneJobManager.GetStandaloneJobStates()
+	jobState, exists := jobStates[jobName]
+	if !exists {
+		return fmt.Errorf("standalone job '%s' not found", jobName)
+	}
+	if format.Structured() {
+		return output.Print(format, redactJobState(*jobState))
+	}
+
+	fmt.Printf("Job: %s\n", jobName)
+	fmt.Printf("Type: standalone\n")
+	fmt.Printf("Status: %s\n", jobState.Status)
+	fmt.Printf("Run Count: %d\n", jobState.RunCount)
+	fmt.Printf("Success Count: %d\n", jobState.SuccessCount)
+	fmt.Printf("Failure Count: %d\n", jobState.FailureCount)
+	fmt.Printf("Retry Count: %d\n", jobState.RetryCount)
+
+	if jobState.LastRun != nil {
+		fmt.Printf("Last Run: %s\n", logging.FormatTime(*jobState.LastRun))
+	} else {
+		fmt.Printf("Last Run: Never\n")
+	}
+
+	if jobState.LastAttempts > 1 {
+		fmt.Printf("Last Run Attempts: %d\n", jobState.LastAttempts)
+	}
+
+	if jobState.LastSuccess != nil {
+		fmt.Printf("Last Success: %s\n", logging.FormatTime(*jobState.LastSuccess))
+	} else {
+		fmt.Printf("Last Success: Never\n")
+	}
+
+	if jobState.LastFailure != nil {
+		fmt.Printf("Last Failure: %s\n", logging.FormatTime(*jobState.LastFailure))
+	} else {
+		fmt.Printf("Last Failure: Never\n")
+	}
+
+	if jobState.LastError != "" {
+		fmt.Printf("Last Error: %s\n", logging.RedactWorkspace(jobState.WorkspaceID, jobState.LastError))
+	}
+
+	if jobState.LastCorrelationID != "" {
+		fmt.Printf("Last Correlation ID: %s\n", jobState.LastCorrelationID)
+	}
+
+	if jobState.NextRun != nil {
+		fmt.Printf("Next Run: %s\n", logging.FormatTime(*jobState.NextRun))
+	}
+
+	showTemplateDeployment(jobState)
+	return nil
+}
+
+func showAllStandaloneJobsStatus(sched *scheduler.Scheduler, standaloneJobManager *job.StandaloneJobManager, opts listing.Options, format output.Format) error {
+	summaries, err := sched.StandaloneJobSummaries(time.Now())
+	if err != nil {
+		return err
+	}
+
+	jobStates := standaloneJobManager.GetStandaloneJobStates()
+	result := listing.Apply(summaries, opts)
+	if format.Structured() {
+		const standaloneWorkspaceID = "_standalone_"
+		states := make([]job.JobState, 0, len(result.Entries))
+		for _, summary := range result.Entries {
+			states = append(states, jobStatusEntry(summary.Name, standaloneWorkspaceID, summary.Enabled, jobStates[summary.Name]))
+		}
+		return output.Print(format, states)
+	}
+
+	if len(summaries) == 0 {
+		fmt.Printf("No standalone jobs configured\n")
+		return nil
+	}
+	if len(opts.Columns) > 0 {
+		return scheduler.ShowJobList(summaries, opts, format)
+	}
+
+	fmt.Printf("Standalone jobs:\n\n")
+	printJobStatusTable(result, jobStates)
+	return nil
+}
+
+func showWorkspaceJobStatus(sched *scheduler.Scheduler, workspaceName, jobName string, format output.Format) error {
+	jobState := sched.GetJobState(workspaceName, jobName)
+	if jobState == nil {
+		return fmt.Errorf("job '%s' not found in workspace '%s'", jobName, workspaceName)
+	}
+	if format.Structured() {
+		return output.Print(format, redactJobState(*jobState))
+	}
+
+	fmt.Printf("Job: %s\n", jobName)
+	fmt.Printf("Workspace: %s\n", workspaceName)
+	fmt.Printf("Status: %s\n", jobState.Status)
+	fmt.Printf("Run Count: %d\n", jobState.RunCount)
+	fmt.Printf("Success Count: %d\n", jobState.SuccessCount)
+	fmt.Printf("Failure Count: %d\n", jobState.FailureCount)
+	fmt.Printf("Retry Count: %d\n", jobState.RetryCount)
+
+	if jobState.LastRun != nil {
+		fmt.Printf("Last Run: %s\n", logging.FormatTime(*jobState.LastRun))
+	} else {
+		fmt.Printf("Last Run: Never\n")
+	}
+
+	if jobState.LastAttempts > 1 {
+		fmt.Printf("Last Run Attempts: %d\n", jobState.LastAttempts)
+	}
+
+	if jobState.LastSuccess != nil {
+		fmt.Printf("Last Success: %s\n", logging.FormatTime(*jobState.LastSuccess))
+	} else {
+		fmt.Printf("Last Success: Never\n")
+	}
+
+	if jobState.LastFailure != nil {
+		fmt.Printf("Last Failure: %s\n", logging.FormatTime(*jobState.LastFailure))
+	} else {
+		fmt.Printf("Last Failure: Never\n")
+	}
+
+	if jobState.LastError != "" {
+		fmt.Printf("Last Error: %s\n", logging.RedactWorkspace(jobState.WorkspaceID, jobState.LastError))
+	}
+
+	if jobState.LastCorrelationID != "" {
+		fmt.Printf("Last Correlation ID: %s\n", jobState.LastCorrelationID)
+	}
+
+	if jobState.NextRun != nil {
+		fmt.Printf("Next Run: %s\n", logging.FormatTime(*jobState.NextRun))
+	}
+
+	showTemplateDeployment(jobState)
+	return nil
+}
+
+func showAllWorkspaceJobsStatus(sched *scheduler.Scheduler, workspaceName string, opts listing.Options, format output.Format) error {
+	summaries, err := sched.WorkspaceJobSummaries(workspaceName, time.Now())
+	if err != nil {
+		return err
+	}
+
+	jobStates := sched.GetJobStates(workspaceName)
+	result := listing.Apply(summaries, opts)
+	if format.Structured() {
+		states := make([]job.JobState, 0, len(result.Entries))
+		for _, summary := range result.Entries {
+			states = append(states, jobStatusEntry(summary.Name, workspaceName, summary.Enabled, jobStates[summary.Name]))
+		}
+		return output.Print(format, states)
+	}
+
+	if len(summaries) == 0 {
+		fmt.Printf("No jobs defined for workspace '%s'\n", workspaceName)
+		return nil
+	}
+	if len(opts.Columns) > 0 {
+		return scheduler.ShowJobList(summaries, opts, format)
+	}
+
+	fmt.Printf("Jobs in workspace '%s':\n\n", workspaceName)
+	printJobStatusTable(result, jobStates)
+	return nil
+}
+
+// printJobStatusTable prints the status, run counts and next run of the selected jobs
+func printJobStatusTable(result listing.Result[scheduler.JobSummary], jobStates map[string]*job.JobState) {
+	fmt.Printf("%-20s %-12s %-8s %-8s %-8s %-22s %-22s\n", "JOB NAME", "STATUS", "SUCCESS", "FAILED", "RETRIES", "LAST RUN", "NEXT RUN")
+	fmt.Printf("%-20s %-12s %-8s %-8s %-8s %-22s %-22s\n", "--------", "------", "-------", "------", "-------", "--------", "--------")
+
+	for _, summary := range result.Entries {
+		successCount := 0
+		failureCount := 0
+		retryCount := 0
+		lastRun, nextRun := "Never", "-"
+
+		if jobState, exists := jobStates[summary.Name]; exists {
+			successCount = jobState.SuccessCount
+			failureCount = jobState.FailureCount
+			retryCount = jobState.RetryCount
+		}
+		if summary.LastRun != nil {
+			lastRun = logging.FormatTimeShort(*summary.LastRun)
+		}
+		if summary.NextRun != nil {
+			nextRun = logging.FormatTimeShort(*summary.NextRun)
+		}
+
+		fmt.Printf("%-20s %-12s %-8d %-8d %-8d %-22s %-22s\n",
+			summary.Name,
+			summary.Status,
+			successCount,
+			failureCount,
+			retryCount,
+			lastRun,
+			nextRun)
+	}
+
+	if summary := result.Summary(); summary != "" {
+		fmt.Printf("\n%s\n", summary)
+	}
+}
+
+// showTemplateDeployment prints the state of a template job's deployment, with the resources
+// currently in its OpenTofu state
+func showTemplateDeployment(jobState *job.JobState) {
+	deployment := jobState.Deployment
+	if deployment == nil {
+		return
+	}
+
+	workingDir := job.TemplateWorkingDirectory(opentofu.GetWorkingDir(jobState.WorkspaceID), jobState.Name)
+
+	fmt.Printf("\nDeployment:\n")
+	fmt.Printf("  Template: %s\n", deployment.Template)
+	fmt.Printf("  Status: %s\n", deployment.Status)
+	fmt.Printf("  Directory: %s\n", workingDir)
+	fmt.Printf("  Resources: %d\n", opentofu.StateResources(workingDir))
+	if deployment.DeployedAt != nil {
+		fmt.Printf("  Deployed: %s\n", logging.FormatTime(*deployment.DeployedAt))
+	}
+	if deployment.DestroyAt != nil && deployment.IsActive() {
+		fmt.Printf("  Destroy At: %s\n", logging.FormatTime(*deployment.DestroyAt))
+	}
+	if deployment.DestroyedAt != nil {
+		fmt.Printf("  Destroyed: %s\n", logging.FormatTime(*deployment.DestroyedAt))
+	}
+	if deployment.LastError != "" {
+		fmt.Printf("  Last Error: %s\n", logging.RedactWorkspace(jobState.WorkspaceID, deployment.LastError))
+	}
+
+	if len(deployment.Outputs) > 0 {
+		names := make([]string, 0, len(deployment.Outputs))
+		for name := range deployment.Outputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		fmt.Printf("  Outputs:\n")
+		for _, name := range names {
+			fmt.Printf("    %s = %s\n", name, logging.RedactWorkspace(jobState.WorkspaceID, deployment.Outputs[name]))
+		}
+	}
+}
+
+// jobStatusEntry returns a job's state for structured status output, or its pending or disabled
+// status if it never ran
+func jobStatusEntry(name, workspaceID string, enabled bool, state *job.JobState) job.JobState {
+	if state != nil {
+		return redactJobState(*state)
+	}
+	status := job.JobStatusPending
+	if !enabled {
+		status = job.JobStatusDisabled
+	}
+	return job.JobState{Name: name, WorkspaceID: workspaceID, Status: status}
+}
+
+// redactJobState masks secrets in a job state's error for structured output and leaves out its
+// run history, which the history command shows
+func redactJobState(state job.JobState) job.JobState {
+	state.LastError = logging.RedactWorkspace(state.WorkspaceID, state.LastError)
+	state.History = nil
+	return state
+}
+
+func showRunStatus(run *job.RunRecord, workspaceName string, format output.Format) error {
+	if format.Structured() {
+		redacted := *run
+		redacted.Error = logging.RedactWorkspace(run.WorkspaceID, run.Error)
+		return output.Print(format, redacted)
+	}
+
+	status := string(run.Status)
+	if run.IsOrphaned() {
+		status += " (process exited without recording a result)"
+	}
+
+	fmt.Printf("Run: %s\n", run.ID)
+	fmt.Printf("Job: %s\n", run.JobName)
+	if workspaceName != "" {
+		fmt.Printf("Workspace: %s\n", workspaceName)
+	}
+	fmt.Printf("Status: %s\n", status)
+	fmt.Printf("Started: %s\n", logging.FormatTime(run.StartTime))
+
+	if run.EndTime != nil {
+		fmt.Printf("Finished: %s\n", logging.FormatTime(*run.EndTime))
+		fmt.Printf("Duration: %v\n", run.EndTime.Sub(run.StartTime).Round(time.Second))
+		fmt.Printf("Exit Code: %d\n", run.ExitCode)
+	} else if run.PID > 0 {
+		fmt.Printf("PID: %d\n", run.PID)
+	}
+
+	if run.Error != "" {
+		fmt.Printf("Error: %s\n", logging.RedactWorkspace(run.WorkspaceID, run.Error))
+	}
+	return nil
+}
+
+func showHistory(history []job.HistoryEntry, jobName, workspaceName string, format output.Format) error {
+	if format.Structured() {
+		runs := make([]job.HistoryEntry, len(history))
+		for i, entry := range history {
+			// The output is left to show-run
+			entry.Output = ""
+			runs[i] = entry
+		}
+		return output.Print(format, runs)
+	}
+
+	if len(history) == 0 {
+		fmt.Printf("No runs recorded for job '%s'\n", jobName)
+		return nil
+	}
+
+	if workspaceName != "" {
+		fmt.Printf("Runs of job '%s' in workspace '%s':\n\n", jobName, workspaceName)
+	} else {
+		fmt.Printf("Runs of job '%s':\n\n", jobName)
+	}
+	fmt.Printf("%-24s %-10s %-10s %-22s %-10s %-6s %-8s\n", "RUN ID", "OPERATION", "STATUS", "STARTED", "DURATION", "EXIT", "ATTEMPTS")
+	fmt.Printf("%-24s %-10s %-10s %-22s %-10s %-6s %-8s\n", "------", "---------", "------", "-------", "--------", "----", "--------")
+
+	for _, entry := range history {
+		attempts := entry.Attempts
+		if attempts == 0 {
+			attempts = 1
+		}
+		operation := entry.Operation
+		if operation == "" {
+			operation = "run"
+		}
+		fmt.Printf("%-24s %-10s %-10s %-22s %-10s %-6d %-8d\n",
+			entry.RunID,
+			operation,
+			entry.Status,
+			logging.FormatTimeShort(entry.StartTime),
+			entry.Duration().Round(time.Second),
+			entry.ExitCode,
+			attempts)
+	}
+
+	return nil
+}
+
+func showHistoryEntry(entry *job.HistoryEntry, jobName, workspaceName, workspaceID string, format output.Format) error {
+	// Output and error were masked when the run was recorded, mask again for redaction patterns added since
+	redacted := *entry
+	redacted.Error = logging.RedactWorkspace(workspaceID, entry.Error)
+	redacted.Output = logging.RedactWorkspace(workspaceID, entry.Output)
+	if format.Structured() {
+		return output.Print(format, redacted)
+	}
+
+	fmt.Printf("Run: %s\n", redacted.RunID)
+	if redacted.Operation != "" {
+		fmt.Printf("Operation: %s\n", redacted.Operation)
+	}
+	fmt.Printf("Job: %s\n", jobName)
+	if workspaceName != "" {
+		fmt.Printf("Workspace: %s\n", workspaceName)
+	}
+	fmt.Printf("Status: %s\n", redacted.Status)
+	fmt.Printf("Started: %s\n", logging.FormatTime(redacted.StartTime))
+	fmt.Printf("Finished: %s\n", logging.FormatTime(redacted.EndTime))
+	fmt.Printf("Duration: %v\n", redacted.Duration().Round(time.Second))
+	fmt.Printf("Exit Code: %d\n", redacted.ExitCode)
+	if redacted.Attempts > 1 {
+		fmt.Printf("Attempts: %d\n", redacted.Attempts)
+	}
+	if redacted.CorrelationID != "" {
+		fmt.Printf("Correlation ID: %s\n", redacted.CorrelationID)
+	}
+	if redacted.Error != "" {
+		fmt.Printf("Error: %s\n", redacted.Error)
+	}
+	if len(redacted.Artifacts) > 0 {
+		fmt.Printf("Artifacts: %s\n", strings.Join(redacted.Artifacts, ", "))
+	}
+
+	if redacted.Output == "" {
+		fmt.Printf("\nNo output captured\n")
+		return nil
+	}
+	if redacted.OutputTruncated {
+		fmt.Printf("\nOutput (last %d bytes):\n", job.HistoryOutputLimit)
+	} else {
+		fmt.Printf("\nOutput:\n")
+	}
+	fmt.Print(redacted.Output)
+	if !strings.HasSuffix(redacted.Output, "\n") {
+		fmt.Println()
+	}
+	return nil
+}
+
+func showArtifacts(artifacts []job.Artifact, jobName, workspaceName string, format output.Format) error {
+	if format.Structured() {
+		if artifacts == nil {
+			artifacts = []job.Artifact{}
+		}
+		return output.Print(format, artifacts)
+	}
+
+	if len(artifacts) == 0 {
+		fmt.Printf("No artifacts kept for job '%s'\n", jobName)
+		return nil
+	}
+
+	if workspaceName != "" {
+		fmt.Printf("Artifacts of job '%s' in workspace '%s':\n\n", jobName, workspaceName)
+	} else {
+		fmt.Printf("Artifacts of job '%s':\n\n", jobName)
+	}
+	fmt.Printf("%-24s %-10s %-22s %s\n", "RUN ID", "SIZE", "COLLECTED", "FILE")
+	fmt.Printf("%-24s %-10s %-22s %s\n", "------", "----", "---------", "----")
+
+	for _, artifact := range artifacts {
+		fmt.Printf("%-24s %-10s %-22s %s\n",
+			artifact.RunID,
+			formatSize(artifact.Size),
+			logging.FormatTimeShort(artifact.Collected),
+			artifact.Path)
+	}
+
+	return nil
+}
+
+func showDownloadedArtifacts(artifacts []job.Artifact, runID, downloadDir string) error {
+	for _, artifact := range artifacts {
+		fmt.Printf("%s\n", filepath.Join(downloadDir, artifact.Path))
+	}
+	fmt.Printf("Downloaded %d artifacts of run '%s' into %s\n", len(artifacts), runID, downloadDir)
+	return nil
+}
+
+// formatSize formats a file size in bytes with a binary unit
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value, exponent := float64(size)/unit, 0
+	for value >= unit && exponent < 3 {
+		value /= unit
+		exponent++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[exponent])
+}
+
+func reportFinishedRun(run *job.RunRecord) error {
+	if run.Status != job.JobStatusSuccess {
+		return fmt.Errorf("run '%s' finished with status %s: %s", run.ID, run.Status, logging.RedactWorkspace(run.WorkspaceID, run.Error))
+	}
+
+	fmt.Printf("Run '%s' completed successfully\n", run.ID)
+	return nil
+}
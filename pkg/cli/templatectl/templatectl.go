@@ -0,0 +1,148 @@
+// Package templatectl implements the template management commands.
+package templatectl
+
+import (
+	"fmt"
+
+	"provisioner/pkg/cli"
+	"provisioner/pkg/control"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/template"
+	"provisioner/pkg/workspace"
+)
+
+func printUsage(prog string) {
+	fmt.Printf(`Usage: %s COMMAND [ARGUMENTS...]
+
+Template management CLI for OpenTofu Workspace Scheduler.
+
+Commands:
+  init NAME [OPTIONS]      Generate a skeleton template in a local directory
+  add NAME URL [OPTIONS]   Add new template from URL
+  list [--detailed]        List all available templates
+  show NAME                Show detailed template information
+  update NAME|--all        Update template(s) from source (--force to accept rewritten branches or moved tags)
+  remove NAME [--force]    Remove template
+  validate NAME|--all      Validate template configuration and run tofu validate (--fmt, --no-tofu)
+
+Add Options:
+  --path PATH              Path within repository (default: root)
+  --ref REF                Git reference (branch/tag/commit, default: main) or OCI tag/digest
+  --description DESC       Template description
+  --ssh-key FILE           Private key for SSH repository URLs
+  --token-env VAR          Environment variable holding an HTTPS access token or registry credentials
+  --verify-key FILE        Public key verifying the cosign signature of OCI templates
+
+List and Show Options:
+  --output FORMAT          Print json, yaml or table (default)
+
+Init Options:
+  --dir DIR                Directory to create (default: ./NAME)
+  --description DESC       Template description for the manifest
+
+Global Options:
+  --utc                    Show timestamps in UTC
+  --help                   Show this help
+  --version                Show version
+  --version-full           Show detailed version
+
+Examples:
+  %s init web-app                                # Create a skeleton template in ./web-app
+  %s list                                        # List all templates
+  %s add web-app https://github.com/org/templates --path web --ref v1.0
+  %s add infra git@github.com:org/private.git --ssh-key /etc/provisioner/deploy_key
+  %s add web oci://registry.example.com/templates/web:1.2.0 --verify-key cosign.pub
+  %s show web-app                                # Show template details
+  %s list --output json                          # List templates as JSON
+  %s update web-app                              # Update specific template
+  %s update --all                                # Update all templates
+  %s remove web-app                              # Remove template
+  %s validate --all                              # Validate all templates
+  %s validate web-app --fmt                       # Also check the formatting of web-app
+
+Related Tools:
+  provisioner      Workspace scheduler daemon
+  provisionerctl   Unified CLI, 'provisionerctl template' runs these commands
+  workspacectl   Workspace management CLI
+`, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog)
+}
+
+// Command returns the template management commands, the templatectl binary and provisionerctl's
+// template group
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:    "template",
+		Summary: "Manage templates (init, add, list, show, update, remove, validate)",
+		Usage:   printUsage,
+		Commands: []*cli.Command{
+			{Name: "init", Run: cli.RunArgs(template.RunInitCommand)},
+			{Name: "add", Run: cli.RunArgs(template.RunAddCommand)},
+			{Name: "list", Run: cli.RunArgs(template.RunListCommand)},
+			{Name: "show", Run: cli.RunArgs(template.RunShowCommand)},
+			{Name: "update", Run: cli.RunArgs(runUpdateCommand)},
+			{Name: "remove", Run: cli.RunArgs(template.RunRemoveCommand)},
+			{Name: "validate", Run: validateCommand},
+		},
+	}
+}
+
+// validateCommand validates templates and checks them with tofu validate, and tofu fmt with --fmt
+func validateCommand(_ string, args []string) error {
+	args, noTofu := cli.ExtractFlag(args, "--no-tofu")
+	args, format := cli.ExtractFlag(args, "--fmt")
+	if noTofu && format {
+		return fmt.Errorf("--fmt requires tofu, it cannot be combined with --no-tofu")
+	}
+
+	var check workspace.ConfigChecker
+	if !noTofu {
+		var err error
+		if check, err = opentofu.NewConfigChecker(format); err != nil {
+			return err
+		}
+	}
+	return template.RunValidateCommand(args, check)
+}
+
+// runUpdateCommand updates templates through the daemon when it is running so
+// template hashes change in step with its deployments, otherwise directly
+func runUpdateCommand(args []string) error {
+	name, force, err := template.ParseUpdateArgs(args)
+	if err != nil {
+		return err
+	}
+
+	client, err := control.Dial()
+	if err != nil {
+		return template.RunUpdateCommand(args)
+	}
+	defer func() { _ = client.Close() }()
+
+	if name != "" {
+		message, err := client.UpdateTemplate(name, force)
+		if err != nil {
+			return err
+		}
+		fmt.Println(message)
+		return nil
+	}
+
+	templates, err := template.NewManager(template.GetDefaultTemplatesDir()).ListTemplates()
+	if err != nil {
+		return err
+	}
+	failed := 0
+	for _, tmpl := range templates {
+		fmt.Printf("Updating template '%s'...\n", tmpl.Name)
+		if message, err := client.UpdateTemplate(tmpl.Name, force); err != nil {
+			fmt.Printf("  Error: %v\n", err)
+			failed++
+		} else {
+			fmt.Printf("  %s\n", message)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d templates failed to update", failed, len(templates))
+	}
+	return nil
+}
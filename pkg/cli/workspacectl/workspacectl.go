@@ -0,0 +1,1757 @@
+// Package workspacectl implements the workspace management commands.
+package workspacectl
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/cli"
+	"provisioner/pkg/control"
+	"provisioner/pkg/listing"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/output"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/workspace"
+)
+
+func printUsage(prog string) {
+	fmt.Printf(`Usage: %s COMMAND [ARGUMENTS...]
+
+Workspace management CLI for OpenTofu Workspace Scheduler.
+
+Commands:
+  deploy WORKSPACE [MODE]  Deploy specific workspace immediately (with optional mode, --follow)
+  plan WORKSPACE [MODE]    Show what a deploy would change without applying it
+  upgrade WORKSPACE        Redeploy with the current template version after showing the plan (--yes)
+  history WORKSPACE        Show recorded deploys with template version, mode and plan (--output)
+  rollback WORKSPACE [N]   Redeploy the files and variables of revision N or the previous deploy (--yes)
+  state list WORKSPACE     List OpenTofu state backups taken before applies and destroys (--output)
+  state backup WORKSPACE   Back up the current OpenTofu state
+  state restore WORKSPACE  Restore the newest state backup or --version N (--yes)
+  destroy WORKSPACE        Destroy specific workspace immediately (--force for protected workspaces, --follow)
+  approve WORKSPACE        Run the scheduled deploy or destroy awaiting approval (--follow)
+  cancel WORKSPACE         Cancel an in-progress deploy or destroy
+  unschedule WORKSPACE     Drop the deploy and destroy scheduled with --at (or only 'deploy' or 'destroy')
+  freeze WORKSPACE         Pin workspace to its current deployment (--reason TEXT)
+  unfreeze WORKSPACE       Resume scheduled operations of a frozen workspace
+  pause WORKSPACE          Skip scheduled operations until resumed (manual operations still run)
+  resume WORKSPACE         Resume scheduled operations of a paused workspace
+  mode WORKSPACE MODE      Change workspace to specific mode
+  status [WORKSPACE]       Show status of all workspaces, specific workspace or a pattern (--template, --enabled-only)
+  list [--detailed]        List all configured workspaces (--outdated for stale template versions)
+  logs WORKSPACE           Show recent logs for specific workspace (--follow, --lines N, --since DURATION)
+  outputs WORKSPACE        Show OpenTofu outputs of a deployed workspace (--show-sensitive to reveal)
+  add NAME [OPTIONS]       Add new workspace
+  import NAME [OPTIONS]    Create workspace from an existing OpenTofu project (--from-dir DIR, --move-state)
+  show NAME                Show detailed workspace information
+  update NAME [OPTIONS]    Update existing workspace
+  remove NAME [--force]    Remove workspace
+  validate NAME|--all      Validate workspace configuration and run tofu validate (--fmt, --no-tofu, --strict)
+  lint NAME|--all          Lint OpenTofu configuration (--template NAME, --no-validate, --json)
+  graph [--dot]            Show workspace dependencies in deploy order (--dot for Graphviz)
+  vars set NAME KEY=VALUE  Set OpenTofu variables for workspace (--secret to mask)
+  vars get NAME KEY        Show the effective value of a workspace variable
+  vars list NAME           List config and set variables (--show-secrets to reveal)
+  vars unset NAME KEY      Remove workspace variable
+  debug NAME on|off|status Toggle OpenTofu debug logging (TF_LOG=DEBUG) at runtime
+  prune [--dry-run]        Destroy and remove what removed workspaces left behind (--yes)
+
+Add/Update Options:
+  --template TEMPLATE            Use specified template
+  --description DESC             Workspace description
+  --deploy-schedule CRON         Deploy schedule (CRON expression)
+  --destroy-schedule CRON        Destroy schedule (CRON expression, or +DURATION after deploys)
+  --disabled                     Create disabled workspace (add only)
+  --enable/--disable             Enable/disable workspace (update only)
+
+List/Status Options:
+  --filter FIELD=VALUE           Only show workspaces whose field matches (glob patterns, repeatable)
+  --filter FIELD==VALUE          Only show workspaces whose field is VALUE (FIELD!=VALUE: is not)
+  --columns FIELD,...            Show these fields as table columns, e.g. workspace,status,next_run
+  --sort [-]FIELD                Sort by field, "-" for descending
+  --limit N                      Show N workspaces per page
+  --page N                       Show page N (requires --limit)
+  --outdated                     Only show workspaces deployed from an older template version
+  --output FORMAT                Print json, yaml or table (default); also for show
+  Fields: name (or workspace), status, enabled, tier, template, labels, outdated, errors, last-deployed, last-destroyed, next-run, next-deploy, next-destroy
+
+Deploy/Destroy/Mode Options:
+  --force-unlock                 Remove a stale deployment lock before running
+  --force                        Destroy a protected workspace after typing its name (destroy only)
+  --confirm NAME                 Confirm destroying the protected workspace NAME without the prompt (destroy only)
+  --ignore-budget                Deploy even if the estimated cost exceeds max_monthly_cost (deploy only)
+  --at TIME                      Run the deploy or destroy once at TIME instead of now
+  --destroy-at TIME              Also destroy the workspace once at TIME (deploy only)
+  TIME: "YYYY-MM-DD HH:MM" in the display timezone, RFC 3339 (2025-07-01T09:00:00Z) or +DURATION from now
+
+Bulk Deploy/Destroy Options (a glob pattern such as 'pr-*' in place of WORKSPACE also selects):
+  --all                          Select all workspaces
+  --template TEMPLATE            Only select workspaces of the template (glob pattern)
+  --enabled-only                 Only select enabled workspaces
+  --yes                          Destroy the selected workspaces without confirmation (destroy only)
+
+Global Options:
+  --utc                          Show timestamps in UTC
+  --help                         Show this help
+  --version                      Show version
+  --version-full                 Show detailed version
+
+Examples:
+  %s list                                    # List all workspaces
+  %s deploy my-app                          # Deploy 'my-app' (prompts for mode if needed)
+  %s deploy my-app busy                     # Deploy 'my-app' in 'busy' mode
+  %s deploy my-app --follow                 # Deploy 'my-app' and print OpenTofu output as it runs
+  %s plan my-app                            # Preview the changes a deploy of 'my-app' would make
+  %s list --outdated                        # Workspaces running stale template versions
+  %s upgrade my-app                         # Redeploy 'my-app' with its updated template
+  %s history my-app                         # Show the deploys of 'my-app'
+  %s rollback my-app                        # Redeploy the deploy of 'my-app' before the last one
+  %s state restore my-app --version 3       # Put back version 3 of the state of 'my-app'
+  %s mode my-app hibernation                # Change 'my-app' to hibernation mode
+  %s destroy test-workspace                 # Destroy 'test-workspace' immediately
+  %s destroy 'pr-*'                         # Destroy all pull request workspaces after confirmation
+  %s deploy --all --enabled-only            # Deploy every enabled workspace
+  %s deploy demo --at "2025-07-01 09:00" --destroy-at "2025-07-01 18:00"  # Bring up 'demo' for a day
+  %s destroy my-app --at +2h                # Destroy 'my-app' in two hours
+  %s approve billing                        # Run the scheduled deploy of 'billing' awaiting approval
+  %s cancel my-app                          # Cancel the running deploy of 'my-app'
+  %s freeze my-app --reason "release demo"  # Keep 'my-app' deployed as it is
+  %s pause my-app                           # Stop scheduling 'my-app' without editing its config
+  %s status                                 # Show status of all workspaces
+  %s status my-app                          # Show detailed status of 'my-app'
+  %s status --template web-app              # Status of the workspaces of the 'web-app' template
+  %s list --filter status=deployed --sort next-run --limit 20  # First 20 deployed workspaces by next run
+  %s status my-app --output json            # Machine-readable status of 'my-app'
+  %s status --filter 'status==deploy_failed' --columns workspace,status,next_run  # Failed deploys report
+  %s logs my-app                            # Show recent logs for 'my-app'
+  %s logs my-app --follow                   # Watch a running deploy of 'my-app'
+  %s outputs my-app                         # Show OpenTofu outputs of 'my-app'
+  %s add dev-server --template web-app      # Add workspace using template
+  %s import billing --from-dir /srv/billing --move-state  # Take over an existing project and its state
+  %s update my-app --deploy-schedule "0 9 * * 1-5"  # Update deploy schedule
+  %s validate --all --strict                # Check every config file, failing on unknown fields
+  %s lint --all --no-validate               # Check all workspaces for deprecated syntax
+  %s graph --dot | dot -Tpng > deps.png     # Render the workspace dependency graph
+  %s vars set my-app instance_count=2       # Set OpenTofu variable for 'my-app'
+  %s debug my-app on                        # Capture OpenTofu debug logs for 'my-app'
+  %s prune --dry-run                        # List deployments and logs of removed workspaces
+
+Related Tools:
+  provisioner      Workspace scheduler daemon
+  provisionerctl   Unified CLI, 'provisionerctl workspace' runs these commands
+  templatectl      Template management CLI
+`, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog, prog)
+}
+
+// Command returns the workspace management commands, the workspacectl binary and provisionerctl's
+// workspace group
+func Command() *cli.Command {
+	return &cli.Command{
+		Name:    "workspace",
+		Summary: "Manage workspaces (list, deploy, destroy, status, logs)",
+		Usage:   printUsage,
+		Commands: []*cli.Command{
+			{Name: "deploy", Run: deployCommand},
+			{Name: "destroy", Run: destroyCommand},
+			{Name: "approve", Run: approveCommand},
+			{Name: "cancel", Run: cancelCommand},
+			{Name: "unschedule", Run: unscheduleCommand},
+			{Name: "freeze", Run: freezeCommand(true)},
+			{Name: "unfreeze", Run: freezeCommand(false)},
+			{Name: "pause", Run: pauseCommand(true)},
+			{Name: "resume", Run: pauseCommand(false)},
+			{Name: "mode", Run: modeCommand},
+			{Name: "status", Run: statusCommand},
+			{Name: "upgrade", Run: upgradeCommand},
+			{Name: "history", Run: historyCommand},
+			{Name: "rollback", Run: rollbackCommand},
+			{
+				Name:    "state",
+				Summary: "Back up and restore OpenTofu state",
+				Commands: []*cli.Command{
+					{Name: "list", Summary: "List state backups of a workspace, newest first (--output)", Run: stateListCommand},
+					{Name: "backup", Summary: "Back up the current state of a workspace", Run: stateBackupCommand},
+					{Name: "restore", Summary: "Restore the newest state backup of a workspace or --version N (--yes)", Run: stateRestoreCommand},
+				},
+			},
+			{Name: "list", Run: listCommand},
+			{Name: "logs", Run: logsCommand},
+			{Name: "outputs", Run: outputsCommand},
+			{Name: "prune", Run: pruneCommand},
+			{Name: "add", Run: cli.RunArgs(workspace.RunAddCommand)},
+			{Name: "import", Run: cli.RunArgs(workspace.RunImportCommand)},
+			{Name: "graph", Run: cli.RunArgs(workspace.RunGraphCommand)},
+			{Name: "show", Run: cli.RunArgs(workspace.RunShowCommand)},
+			{Name: "update", Run: cli.RunArgs(workspace.RunUpdateCommand)},
+			{Name: "remove", Run: cli.RunArgs(workspace.RunRemoveCommand)},
+			{Name: "validate", Run: validateCommand},
+			{Name: "plan", Run: cli.RunArgs(opentofu.RunPlanCommand)},
+			{Name: "lint", Run: cli.RunArgs(opentofu.RunLintCommand)},
+			{Name: "vars", Run: cli.RunArgs(workspace.RunVarsCommand)},
+			{Name: "debug", Run: cli.RunArgs(workspace.RunDebugCommand)},
+		},
+	}
+}
+
+// deployCommand deploys a workspace, or the workspaces a pattern or --all selects, in an optional mode.
+// With --at the deploy is scheduled instead, and --destroy-at schedules a destroy after it.
+func deployCommand(_ string, args []string) error {
+	args, forceUnlock := cli.ExtractFlag(args, "--force-unlock")
+	args, ignoreBudget := cli.ExtractFlag(args, "--ignore-budget")
+	args, follow := cli.ExtractFlag(args, "--follow")
+	args, at, err := cli.ExtractOption(args, "--at")
+	if err != nil {
+		return err
+	}
+	args, destroyAt, err := cli.ExtractOption(args, "--destroy-at")
+	if err != nil {
+		return err
+	}
+	args, selection, err := extractSelection(args)
+	if err != nil {
+		return err
+	}
+	if selection != nil {
+		if forceUnlock || follow || at != "" || destroyAt != "" {
+			return cli.Usagef("--force-unlock, --follow, --at and --destroy-at only apply to a single workspace")
+		}
+		if err := cli.Args(args, 0, 1, "deploy command accepts an optional mode after the selection"); err != nil {
+			return err
+		}
+		mode := ""
+		if len(args) == 1 {
+			mode = args[0]
+		}
+		return runBulkDeployCommand(*selection, mode, ignoreBudget)
+	}
+	if err := cli.Args(args, 1, 2, "deploy command requires workspace name and optional mode"); err != nil {
+		return err
+	}
+
+	workspaceName := args[0]
+	var mode string
+	if len(args) == 2 {
+		mode = args[1]
+	}
+
+	if at != "" && (forceUnlock || ignoreBudget || follow) {
+		return cli.Usagef("--force-unlock, --ignore-budget and --follow don't apply to deploys scheduled with --at")
+	}
+	if at != "" || destroyAt != "" {
+		if err := scheduleDeployCommand(workspaceName, mode, at, destroyAt); err != nil || at != "" {
+			return err
+		}
+	}
+
+	if err := runForceUnlock(workspaceName, forceUnlock); err != nil {
+		return err
+	}
+	return followLogWhile(workspaceName, follow, func() error {
+		return runDeployCommand(workspaceName, mode, ignoreBudget)
+	})
+}
+
+// scheduleDeployCommand schedules the deploy of --at and the destroy of --destroy-at. Without --at
+// the caller deploys right away once the destroy is scheduled.
+func scheduleDeployCommand(workspaceName, mode, at, destroyAt string) error {
+	now := time.Now()
+	var deployTime, destroyTime time.Time
+	var err error
+	if at != "" {
+		if deployTime, err = cli.ParseTime(at, now); err != nil {
+			return err
+		}
+	}
+	if destroyAt != "" {
+		if destroyTime, err = cli.ParseTime(destroyAt, now); err != nil {
+			return err
+		}
+		if at != "" && !destroyTime.After(deployTime) {
+			return cli.Usagef("--destroy-at must be after --at")
+		}
+	}
+
+	if at != "" {
+		if err := runScheduleCommand(workspaceName, scheduler.OperationDeploy, mode, deployTime); err != nil {
+			return err
+		}
+	}
+	if destroyAt != "" {
+		return runScheduleCommand(workspaceName, scheduler.OperationDestroy, "", destroyTime)
+	}
+	return nil
+}
+
+// destroyCommand destroys a workspace, or the workspaces a pattern or --all selects
+func destroyCommand(_ string, args []string) error {
+	args, forceUnlock := cli.ExtractFlag(args, "--force-unlock")
+	args, force := cli.ExtractFlag(args, "--force")
+	args, follow := cli.ExtractFlag(args, "--follow")
+	args, yes := cli.ExtractFlag(args, "--yes")
+	args, confirm, err := cli.ExtractOption(args, "--confirm")
+	if err != nil {
+		return err
+	}
+	args, at, err := cli.ExtractOption(args, "--at")
+	if err != nil {
+		return err
+	}
+	args, selection, err := extractSelection(args)
+	if err != nil {
+		return err
+	}
+	if selection != nil {
+		if forceUnlock || follow || confirm != "" || at != "" {
+			return cli.Usagef("--force-unlock, --follow, --confirm and --at only apply to a single workspace")
+		}
+		if err := cli.Args(args, 0, 0, "destroy command accepts a workspace name or a selection, not both"); err != nil {
+			return err
+		}
+		return runBulkDestroyCommand(*selection, force, yes)
+	}
+	if err := cli.Args(args, 1, 1, "destroy command requires exactly one workspace name"); err != nil {
+		return err
+	}
+
+	if at != "" {
+		if forceUnlock || force || follow || confirm != "" {
+			return cli.Usagef("--force-unlock, --force, --follow and --confirm don't apply to destroys scheduled with --at")
+		}
+		destroyTime, err := cli.ParseTime(at, time.Now())
+		if err != nil {
+			return err
+		}
+		return runScheduleCommand(args[0], scheduler.OperationDestroy, "", destroyTime)
+	}
+
+	if err := runForceUnlock(args[0], forceUnlock); err != nil {
+		return err
+	}
+	return followLogWhile(args[0], follow, func() error {
+		return runDestroyCommand(args[0], force, confirm)
+	})
+}
+
+// extractSelection removes --all, --template and --enabled-only from the arguments of deploy or
+// destroy and returns the selection of a bulk operation, nil if the first argument names a single
+// workspace. A glob pattern as first argument selects the workspaces whose names match it.
+func extractSelection(args []string) ([]string, *scheduler.Selection, error) {
+	args, all := cli.ExtractFlag(args, "--all")
+	args, enabledOnly := cli.ExtractFlag(args, "--enabled-only")
+	args, template, err := cli.ExtractOption(args, "--template")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selection := &scheduler.Selection{Template: template, EnabledOnly: enabledOnly}
+	switch {
+	case len(args) > 0 && scheduler.IsWorkspacePattern(args[0]):
+		if all {
+			return nil, nil, cli.Usagef("--all cannot be combined with a workspace pattern")
+		}
+		selection.Pattern = args[0]
+		return args[1:], selection, nil
+	case all:
+		return args, selection, nil
+	case template != "" || enabledOnly:
+		return nil, nil, cli.Usagef("--template and --enabled-only narrow --all or a workspace pattern")
+	}
+	return args, nil, nil
+}
+
+// followLogWhile runs an operation and, with follow, prints the workspace's log meanwhile,
+// including the OpenTofu output streamed to it
+func followLogWhile(workspaceName string, follow bool, run func() error) error {
+	if !follow {
+		return run()
+	}
+	stop := scheduler.NewQuiet().FollowLog(os.Stdout, workspaceName)
+	err := run()
+	if followErr := stop(); followErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to follow the log: %v\n", followErr)
+	}
+	return err
+}
+
+// approveCommand runs the scheduled operation awaiting a workspace's approval
+func approveCommand(_ string, args []string) error {
+	args, follow := cli.ExtractFlag(args, "--follow")
+	if err := cli.Args(args, 1, 1, "approve command requires exactly one workspace name"); err != nil {
+		return err
+	}
+	return followLogWhile(args[0], follow, func() error {
+		return runApproveCommand(args[0])
+	})
+}
+
+// unscheduleCommand drops the one-shot operations scheduled with --at, optionally only the deploy or destroy
+func unscheduleCommand(_ string, args []string) error {
+	if err := cli.Args(args, 1, 2, "unschedule command requires workspace name and optional operation (deploy or destroy)"); err != nil {
+		return err
+	}
+	operation := ""
+	if len(args) == 2 {
+		operation = args[1]
+		if operation != scheduler.OperationDeploy && operation != scheduler.OperationDestroy {
+			return cli.Usagef("unknown operation '%s', expected deploy or destroy", operation)
+		}
+	}
+	return runUnscheduleCommand(args[0], operation)
+}
+
+func cancelCommand(_ string, args []string) error {
+	if err := cli.Args(args, 1, 1, "cancel command requires exactly one workspace name"); err != nil {
+		return err
+	}
+	return runCancelCommand(args[0])
+}
+
+// freezeCommand returns the freeze or unfreeze command; freeze takes an optional --reason
+func freezeCommand(freeze bool) func(prog string, args []string) error {
+	return func(_ string, args []string) error {
+		reason := ""
+		if freeze {
+			var err error
+			if args, reason, err = cli.ExtractOption(args, "--reason"); err != nil {
+				return err
+			}
+		}
+		if len(args) != 1 {
+			command := "unfreeze"
+			if freeze {
+				command = "freeze"
+			}
+			return cli.Usagef("%s command requires exactly one workspace name", command)
+		}
+		return runFreezeCommand(args[0], freeze, reason)
+	}
+}
+
+// pauseCommand returns the pause or resume command
+func pauseCommand(pause bool) func(prog string, args []string) error {
+	return func(_ string, args []string) error {
+		if len(args) != 1 {
+			command := "resume"
+			if pause {
+				command = "pause"
+			}
+			return cli.Usagef("%s command requires exactly one workspace name", command)
+		}
+		return runPauseCommand(args[0], pause)
+	}
+}
+
+func modeCommand(_ string, args []string) error {
+	args, forceUnlock := cli.ExtractFlag(args, "--force-unlock")
+	if err := cli.Args(args, 2, 2, "mode command requires workspace name and mode"); err != nil {
+		return err
+	}
+
+	if err := runForceUnlock(args[0], forceUnlock); err != nil {
+		return err
+	}
+	return runModeCommand(args[0], args[1])
+}
+
+// statusCommand shows the status of all workspaces or one workspace
+func statusCommand(_ string, args []string) error {
+	var opts listing.Options
+	format, rest, err := output.ParseArgs(args)
+	if err == nil {
+		opts, rest, err = listing.ParseArgs(rest)
+	}
+	var template string
+	if err == nil {
+		rest, template, err = cli.ExtractOption(rest, "--template")
+	}
+	rest, enabledOnly := cli.ExtractFlag(rest, "--enabled-only")
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	if err := cli.Args(rest, 0, 1, "status command accepts at most one workspace name or pattern"); err != nil {
+		return err
+	}
+
+	workspaceName := ""
+	if len(rest) == 1 {
+		workspaceName = rest[0]
+	}
+
+	// A pattern, --template and --enabled-only select the workspaces of the status table
+	selection := scheduler.Selection{Template: template, EnabledOnly: enabledOnly}
+	if scheduler.IsWorkspacePattern(workspaceName) {
+		selection.Pattern, workspaceName = workspaceName, ""
+	}
+	if workspaceName != "" && (template != "" || enabledOnly) {
+		return cli.Usagef("--template and --enabled-only select workspaces, they cannot be combined with a workspace name")
+	}
+	opts.Filters = append(opts.Filters, selection.Filters()...)
+	return runStatusCommand(workspaceName, opts, format)
+}
+
+func upgradeCommand(_ string, args []string) error {
+	args, yes := cli.ExtractFlag(args, "--yes")
+	if err := cli.Args(args, 1, 1, "upgrade command requires exactly one workspace name"); err != nil {
+		return err
+	}
+	return runUpgradeCommand(args[0], yes)
+}
+
+// historyCommand shows the deployment history of a workspace
+func historyCommand(_ string, args []string) error {
+	format, rest, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	if err := cli.Args(rest, 1, 1, "history command requires exactly one workspace name"); err != nil {
+		return err
+	}
+	return runHistoryCommand(rest[0], format)
+}
+
+// rollbackCommand redeploys an earlier revision of a workspace, the previous deploy by default
+func rollbackCommand(_ string, args []string) error {
+	args, yes := cli.ExtractFlag(args, "--yes")
+	if err := cli.Args(args, 1, 2, "rollback command requires workspace name and optional revision"); err != nil {
+		return err
+	}
+
+	revision := 0
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n <= 0 {
+			return cli.Usagef("invalid revision '%s'", args[1])
+		}
+		revision = n
+	}
+	return runRollbackCommand(args[0], revision, yes)
+}
+
+// stateListCommand lists the state backups of a workspace
+func stateListCommand(_ string, args []string) error {
+	format, rest, err := output.ParseArgs(args)
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+	if err := cli.Args(rest, 1, 1, "state list command requires exactly one workspace name"); err != nil {
+		return err
+	}
+	return runStateListCommand(rest[0], format)
+}
+
+// stateBackupCommand backs up the current state of a workspace
+func stateBackupCommand(_ string, args []string) error {
+	if err := cli.Args(args, 1, 1, "state backup command requires exactly one workspace name"); err != nil {
+		return err
+	}
+	return runStateBackupCommand(args[0])
+}
+
+// stateRestoreCommand restores a state backup of a workspace, the newest by default
+func stateRestoreCommand(_ string, args []string) error {
+	args, yes := cli.ExtractFlag(args, "--yes")
+	args, versionArg, err := cli.ExtractOption(args, "--version")
+	if err != nil {
+		return err
+	}
+	if err := cli.Args(args, 1, 1, "state restore command requires exactly one workspace name"); err != nil {
+		return err
+	}
+
+	version := 0
+	if versionArg != "" {
+		n, err := strconv.Atoi(versionArg)
+		if err != nil || n <= 0 {
+			return cli.Usagef("invalid version '%s'", versionArg)
+		}
+		version = n
+	}
+	return runStateRestoreCommand(args[0], version, yes)
+}
+
+func listCommand(_ string, args []string) error {
+	var opts listing.Options
+	format, rest, err := output.ParseArgs(args)
+	if err == nil {
+		opts, rest, err = listing.ParseArgs(rest)
+	}
+	rest, outdated := cli.ExtractFlag(rest, "--outdated")
+	if outdated {
+		opts.Filters = append(opts.Filters, listing.Filter{Field: "outdated", Pattern: "true"})
+	}
+	rest, detailed := cli.ExtractFlag(rest, "--detailed")
+	if err == nil && len(rest) > 0 {
+		err = fmt.Errorf("unexpected list arguments: %s", strings.Join(rest, " "))
+	}
+	if err != nil {
+		return cli.Usagef("%v", err)
+	}
+
+	return scheduler.NewQuiet().ShowWorkspaceList(opts, detailed, format)
+}
+
+func logsCommand(_ string, args []string) error {
+	if len(args) < 1 {
+		return cli.Usagef("logs command requires exactly one workspace name")
+	}
+	return runLogsCommand(args)
+}
+
+func outputsCommand(_ string, args []string) error {
+	args, showSensitive := cli.ExtractFlag(args, "--show-sensitive")
+	if err := cli.Args(args, 1, 1, "outputs command requires exactly one workspace name and optional --show-sensitive"); err != nil {
+		return err
+	}
+	return runOutputsCommand(args[0], showSensitive)
+}
+
+// validateCommand validates workspaces and checks them with tofu validate, and tofu fmt with --fmt.
+// With --all the daemon, notification, standalone job and environment configs are checked too.
+func validateCommand(_ string, args []string) error {
+	args, noTofu := cli.ExtractFlag(args, "--no-tofu")
+	args, format := cli.ExtractFlag(args, "--fmt")
+	args, strict := cli.ExtractFlag(args, "--strict")
+	if noTofu && format {
+		return fmt.Errorf("--fmt requires tofu, it cannot be combined with --no-tofu")
+	}
+
+	var check workspace.ConfigChecker
+	if !noTofu {
+		var err error
+		if check, err = opentofu.NewConfigChecker(format); err != nil {
+			return err
+		}
+	}
+	err := workspace.RunValidateCommand(args, check, strict)
+	if len(args) == 0 || args[0] != "--all" {
+		return err
+	}
+
+	files, filesErr := scheduler.SharedConfigFiles()
+	if filesErr != nil {
+		return filesErr
+	}
+	hasErrors := false
+	for _, file := range files {
+		if checkErr := workspace.CheckConfigFile(file.Path, file.Value, strict); checkErr != nil {
+			fmt.Printf("✗ %s: %v\n", file.Name, checkErr)
+			hasErrors = true
+		} else {
+			fmt.Printf("✓ %s: valid\n", file.Name)
+		}
+	}
+	if err == nil && hasErrors {
+		err = fmt.Errorf("some config files have validation errors")
+	}
+	return err
+}
+
+// pruneCommand lists what removed workspaces left behind and destroys and removes it
+func pruneCommand(_ string, args []string) error {
+	args, dryRun := cli.ExtractFlag(args, "--dry-run")
+	args, yes := cli.ExtractFlag(args, "--yes")
+	if err := cli.Args(args, 0, 0, "prune command accepts only --dry-run and --yes"); err != nil {
+		return err
+	}
+	return runPruneCommand(dryRun, yes)
+}
+
+// runDestroyCommand destroys a workspace through the daemon when it is running, otherwise directly.
+// force also destroys protected workspaces once their name is typed, or given as confirm.
+func runDestroyCommand(workspaceName string, force bool, confirm string) error {
+	// Initialize scheduler in quiet mode for CLI
+	sched := scheduler.NewQuiet()
+
+	// Load workspaces to validate the specified workspace exists
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+
+	// Load state to check current workspace status
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if force && isProtected(sched, workspaceName) {
+		if confirm == "" {
+			fmt.Printf("Workspace '%s' is protected. Type its name to destroy it: ", workspaceName)
+			if cli.ConfirmName(os.Stdin, workspaceName) {
+				confirm = workspaceName
+			}
+		}
+		if confirm != workspaceName {
+			return fmt.Errorf("destruction of protected workspace '%s' was not confirmed", workspaceName)
+		}
+	}
+
+	correlationID := logging.NewCorrelationID(time.Now())
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.Destroy(workspaceName, correlationID, force)
+	}); handled {
+		return err
+	}
+
+	fmt.Printf("Correlation ID: %s\n", correlationID)
+	stop := cancelOnInterrupt(sched, workspaceName)
+	defer stop()
+	if err := sched.WithCorrelationID(workspaceName, correlationID, func() error {
+		if force {
+			return sched.ManualDestroyForce(workspaceName)
+		}
+		return sched.ManualDestroy(workspaceName)
+	}); err != nil {
+		return err
+	}
+	if sched.IsWorkspaceCancelled(workspaceName) {
+		return fmt.Errorf("destruction of workspace '%s' was cancelled", workspaceName)
+	}
+	return nil
+}
+
+func runStatusCommand(workspaceName string, opts listing.Options, format output.Format) error {
+	// Initialize scheduler in quiet mode for CLI
+	sched := scheduler.NewQuiet()
+
+	// Use the ShowStatus method
+	return sched.ShowStatus(workspaceName, opts, format)
+}
+
+func runLogsCommand(args []string) error {
+	opts := scheduler.LogOptions{Lines: scheduler.DefaultLogLines}
+	linesGiven := false
+	var workspaceName string
+
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--follow", "-f":
+			opts.Follow = true
+		case "--lines", "-n":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a number of lines", arg)
+			}
+			i++
+			lines, err := strconv.Atoi(args[i])
+			if err != nil || lines < 0 {
+				return fmt.Errorf("invalid number of lines '%s'", args[i])
+			}
+			opts.Lines = lines
+			linesGiven = true
+		case "--since":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--since requires a duration")
+			}
+			i++
+			since, err := time.ParseDuration(args[i])
+			if err != nil || since <= 0 {
+				return fmt.Errorf("invalid duration '%s'", args[i])
+			}
+			opts.Since = since
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return fmt.Errorf("unknown logs option '%s'", arg)
+			}
+			if workspaceName != "" {
+				return fmt.Errorf("logs command requires exactly one workspace name")
+			}
+			workspaceName = arg
+		}
+	}
+
+	if workspaceName == "" {
+		return fmt.Errorf("logs command requires exactly one workspace name")
+	}
+
+	// An explicit time window replaces the default line limit
+	if opts.Since > 0 && !linesGiven {
+		opts.Lines = 0
+	}
+
+	// Initialize scheduler in quiet mode for CLI
+	sched := scheduler.NewQuiet()
+
+	// Use the ShowLogs method
+	return sched.ShowLogs(workspaceName, opts)
+}
+
+func runOutputsCommand(workspaceName string, showSensitive bool) error {
+	// Initialize scheduler in quiet mode for CLI
+	sched := scheduler.NewQuiet()
+
+	// Load workspaces to validate the specified workspace exists
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if sched.GetWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found", workspaceName)
+	}
+
+	workingDir := opentofu.GetWorkingDir(workspaceName)
+	if !opentofu.HasState(workingDir) {
+		fmt.Printf("Workspace '%s' has not been deployed, no outputs available\n", workspaceName)
+		return nil
+	}
+
+	client, err := opentofu.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+	}
+
+	outputs, err := client.Output(workingDir)
+	if err != nil {
+		return fmt.Errorf("failed to read outputs: %w", err)
+	}
+
+	if len(outputs) == 0 {
+		fmt.Printf("No outputs defined for workspace '%s'\n", workspaceName)
+		return nil
+	}
+
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(w, "NAME\tVALUE"); err != nil {
+		return err
+	}
+	for _, name := range names {
+		value := outputs[name].String()
+		if outputs[name].Sensitive && !showSensitive {
+			value = workspace.MaskValue(value)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\n", name, value); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+func runDeployCommand(workspaceName, mode string, ignoreBudget bool) error {
+	// Initialize scheduler in quiet mode for CLI
+	sched := scheduler.NewQuiet()
+
+	// Load workspaces to validate the specified workspace exists
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+
+	// Load state to check current workspace status
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	// If mode is specified, deploy in that mode
+	if mode != "" {
+		return deployWorkspace(sched, workspaceName, mode, ignoreBudget)
+	}
+
+	// Check if workspace uses mode scheduling
+	workspace := sched.GetWorkspace(workspaceName)
+	if workspace == nil {
+		return fmt.Errorf("workspace '%s' not found", workspaceName)
+	}
+
+	// Handle mode-based workspaces
+	if len(workspace.Config.ModeSchedules) > 0 {
+		// Get available modes
+		modeSchedules, err := workspace.Config.GetModeSchedules()
+		if err != nil {
+			return fmt.Errorf("failed to get mode schedules: %w", err)
+		}
+
+		modes := make([]string, 0, len(modeSchedules))
+		for mode := range modeSchedules {
+			modes = append(modes, mode)
+		}
+
+		// Prompt user for mode selection
+		selectedMode, err := promptForMode(modes)
+		if err != nil {
+			return err
+		}
+
+		return deployWorkspace(sched, workspaceName, selectedMode, ignoreBudget)
+	}
+
+	// Handle traditional deploy_schedule workspaces
+	return deployWorkspace(sched, workspaceName, "", ignoreBudget)
+}
+
+// deployWorkspace deploys through the daemon when it is running, otherwise directly.
+// ignoreBudget deploys even if the estimated cost exceeds max_monthly_cost.
+func deployWorkspace(sched *scheduler.Scheduler, workspaceName, mode string, ignoreBudget bool) error {
+	correlationID := logging.NewCorrelationID(time.Now())
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.Deploy(workspaceName, mode, correlationID, ignoreBudget)
+	}); handled {
+		return err
+	}
+
+	fmt.Printf("Correlation ID: %s\n", correlationID)
+	stop := cancelOnInterrupt(sched, workspaceName)
+	defer stop()
+
+	err := sched.WithCorrelationID(workspaceName, correlationID, func() error {
+		return manualDeploy(sched, workspaceName, mode, ignoreBudget)
+	})
+	if err != nil {
+		return err
+	}
+	if sched.IsWorkspaceCancelled(workspaceName) {
+		return fmt.Errorf("deployment of workspace '%s' was cancelled", workspaceName)
+	}
+	return nil
+}
+
+// manualDeploy deploys a workspace directly, in mode if set
+func manualDeploy(sched *scheduler.Scheduler, workspaceName, mode string, ignoreBudget bool) error {
+	switch {
+	case mode != "" && ignoreBudget:
+		return sched.ManualDeployInModeIgnoringBudget(workspaceName, mode)
+	case mode != "":
+		return sched.ManualDeployInMode(workspaceName, mode)
+	case ignoreBudget:
+		return sched.ManualDeployIgnoringBudget(workspaceName)
+	}
+	return sched.ManualDeploy(workspaceName)
+}
+
+// runBulkDeployCommand deploys the selected workspaces, in mode if set, and prints their results
+func runBulkDeployCommand(selection scheduler.Selection, mode string, ignoreBudget bool) error {
+	sched, workspaces, err := loadSelection(selection)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Deploying %d workspaces: %s\n\n", len(workspaces), strings.Join(workspaces, ", "))
+	return runBulkOperation(sched, workspaces, "deploy",
+		func(client *control.Client, workspaceName, correlationID string) error {
+			_, err := client.Deploy(workspaceName, mode, correlationID, ignoreBudget)
+			return err
+		},
+		func(workspaceName string) error {
+			return manualDeploy(sched, workspaceName, mode, ignoreBudget)
+		},
+		sched.GetDeployError)
+}
+
+// runBulkDestroyCommand destroys the selected workspaces after confirmation and prints their
+// results. force also destroys protected workspaces.
+func runBulkDestroyCommand(selection scheduler.Selection, force, yes bool) error {
+	sched, workspaces, err := loadSelection(selection)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Workspaces matching %s:\n", selection)
+	for _, workspaceName := range workspaces {
+		fmt.Printf("  %s\n", workspaceName)
+	}
+	if !yes {
+		fmt.Printf("\nDestroy these %d workspaces? (y/N): ", len(workspaces))
+		var response string
+		if _, err := fmt.Scanln(&response); err != nil {
+			fmt.Println("Cancelled")
+			return nil
+		}
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+	fmt.Println()
+
+	// Protected workspaces are only destroyed once their name is typed, even with --yes
+	if force {
+		var confirmed []string
+		for _, workspaceName := range workspaces {
+			if isProtected(sched, workspaceName) {
+				fmt.Printf("Workspace '%s' is protected. Type its name to destroy it: ", workspaceName)
+				if !cli.ConfirmName(os.Stdin, workspaceName) {
+					fmt.Printf("Skipping '%s'\n", workspaceName)
+					continue
+				}
+			}
+			confirmed = append(confirmed, workspaceName)
+		}
+		if len(confirmed) == 0 {
+			fmt.Println("Cancelled")
+			return nil
+		}
+		workspaces = confirmed
+		fmt.Println()
+	}
+
+	return runBulkOperation(sched, workspaces, "destroy",
+		func(client *control.Client, workspaceName, correlationID string) error {
+			_, err := client.Destroy(workspaceName, correlationID, force)
+			return err
+		},
+		func(workspaceName string) error {
+			if force {
+				return sched.ManualDestroyForce(workspaceName)
+			}
+			return sched.ManualDestroy(workspaceName)
+		},
+		sched.GetDestroyError)
+}
+
+// isProtected reports whether a loaded workspace is protected
+func isProtected(sched *scheduler.Scheduler, workspaceName string) bool {
+	ws := sched.GetWorkspace(workspaceName)
+	return ws != nil && ws.Config.IsProtected()
+}
+
+// loadSelection loads the workspaces and state and returns the names of the selected workspaces
+func loadSelection(selection scheduler.Selection) (*scheduler.Scheduler, []string, error) {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return nil, nil, fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return nil, nil, fmt.Errorf("failed to load state: %w", err)
+	}
+
+	workspaces, err := sched.SelectWorkspaces(selection)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sched, workspaces, nil
+}
+
+// runBulkOperation runs a deploy or destroy on each workspace through the daemon when it is
+// running, otherwise directly, and prints a table of their results. Operations wait for free
+// slots under max_concurrent_operations either way. The operations only fail for refusals, so
+// outcome reads how each one ended from the state.
+func runBulkOperation(sched *scheduler.Scheduler, workspaces []string, operation string,
+	viaDaemon func(client *control.Client, workspaceName, correlationID string) error,
+	direct func(workspaceName string) error,
+	outcome func(workspaceName string) error) error {
+	var results []scheduler.BulkResult
+	if client, err := control.Dial(); err == nil {
+		results = scheduler.RunBulk(workspaces, func(workspaceName, correlationID string) error {
+			return viaDaemon(client, workspaceName, correlationID)
+		})
+		_ = client.Close()
+
+		// The daemon recorded the outcomes in the state file
+		if err := sched.LoadState(); err != nil {
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+	} else {
+		stop := cancelOnInterrupt(sched, workspaces...)
+		results = scheduler.RunBulk(workspaces, func(workspaceName, correlationID string) error {
+			return sched.WithCorrelationID(workspaceName, correlationID, func() error {
+				return direct(workspaceName)
+			})
+		})
+		stop()
+	}
+
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = outcome(results[i].Workspace)
+		}
+	}
+	return scheduler.PrintBulkResults(os.Stdout, operation, results)
+}
+
+// runUpgradeCommand shows how a workspace's template changed since its last deploy and the plan
+// of redeploying it, then redeploys through the daemon when it is running, otherwise directly
+func runUpgradeCommand(workspaceName string, yes bool) error {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	upgrade, err := sched.CheckUpgrade(workspaceName)
+	if err != nil {
+		return err
+	}
+	if !upgrade.Outdated() {
+		fmt.Printf("Workspace '%s' is up to date, %s\n", workspaceName, upgrade)
+		return nil
+	}
+	fmt.Printf("Workspace '%s': %s\n\n", workspaceName, upgrade)
+
+	ws := sched.GetWorkspace(workspaceName)
+	if ws.Config.CustomDeploy != nil {
+		fmt.Printf("Workspace deploys with custom commands, no plan is shown.\n\n")
+	} else {
+		client, err := opentofu.New()
+		if err != nil {
+			return fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+		}
+		summary, err := client.PlanWorkspace(ws, upgrade.Mode)
+		if err != nil {
+			return err
+		}
+		fmt.Println(opentofu.FormatPlanSummary(summary))
+	}
+
+	if !yes {
+		fmt.Printf("Redeploy workspace '%s' with the new template version? (y/N): ", workspaceName)
+		var response string
+		if _, err := fmt.Scanln(&response); err != nil {
+			fmt.Println("Cancelled")
+			return nil
+		}
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	correlationID := logging.NewCorrelationID(time.Now())
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.Upgrade(workspaceName, correlationID)
+	}); handled {
+		return err
+	}
+
+	fmt.Printf("Correlation ID: %s\n", correlationID)
+	stop := cancelOnInterrupt(sched, workspaceName)
+	defer stop()
+
+	if err := sched.WithCorrelationID(workspaceName, correlationID, func() error {
+		return sched.UpgradeWorkspace(workspaceName)
+	}); err != nil {
+		return err
+	}
+	if sched.IsWorkspaceCancelled(workspaceName) {
+		return fmt.Errorf("upgrade of workspace '%s' was cancelled", workspaceName)
+	}
+	fmt.Printf("Workspace '%s' upgraded\n", workspaceName)
+	return nil
+}
+
+// runHistoryCommand prints a workspace's recorded deploys, newest first
+func runHistoryCommand(workspaceName string, format output.Format) error {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if sched.GetWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found", workspaceName)
+	}
+
+	records, err := opentofu.LoadDeploymentHistory(workspaceName)
+	if err != nil {
+		return err
+	}
+	newestFirst := make([]opentofu.DeploymentRecord, 0, len(records))
+	for i := len(records) - 1; i >= 0; i-- {
+		newestFirst = append(newestFirst, records[i])
+	}
+
+	if format.Structured() {
+		return output.Print(format, newestFirst)
+	}
+	if len(newestFirst) == 0 {
+		fmt.Printf("No deploys recorded for workspace '%s'\n", workspaceName)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "REVISION\tDEPLOYED\tMODE\tTEMPLATE\tVERSION\tPLAN\tRESULT\tNOTES")
+	for _, record := range newestFirst {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			record.Revision, logging.FormatTime(record.StartedAt), orDash(record.Mode), orDash(record.TemplateName),
+			describeRevisionVersion(record), describeRevisionPlan(record), describeRevisionResult(record), describeRevisionNotes(record))
+	}
+	return w.Flush()
+}
+
+// runRollbackCommand shows the revision a rollback redeploys and redeploys it through the daemon
+// when it is running, otherwise directly
+func runRollbackCommand(workspaceName string, revision int, yes bool) error {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	target, err := sched.CheckRollback(workspaceName, revision)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Workspace '%s' will be redeployed as revision %d, deployed %s\n", workspaceName, target.Revision, logging.FormatTime(target.StartedAt))
+	if target.TemplateName != "" {
+		fmt.Printf("  Template:  %s (%s)\n", target.TemplateName, describeRevisionVersion(*target))
+	} else {
+		fmt.Printf("  Files:     %s\n", describeRevisionVersion(*target))
+	}
+	if target.Mode != "" {
+		fmt.Printf("  Mode:      %s\n", target.Mode)
+	}
+	keys := make([]string, 0, len(target.Variables))
+	for key := range target.Variables {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Printf("  Variable:  %s = %v\n", key, target.Variables[key])
+	}
+	fmt.Println()
+
+	if !yes {
+		fmt.Printf("Roll back workspace '%s' to revision %d? (y/N): ", workspaceName, target.Revision)
+		var response string
+		if _, err := fmt.Scanln(&response); err != nil {
+			fmt.Println("Cancelled")
+			return nil
+		}
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	correlationID := logging.NewCorrelationID(time.Now())
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.Rollback(workspaceName, target.Revision, correlationID)
+	}); handled {
+		return err
+	}
+
+	fmt.Printf("Correlation ID: %s\n", correlationID)
+	stop := cancelOnInterrupt(sched, workspaceName)
+	defer stop()
+
+	if err := sched.WithCorrelationID(workspaceName, correlationID, func() error {
+		return sched.RollbackWorkspace(workspaceName, target.Revision)
+	}); err != nil {
+		return err
+	}
+	if sched.IsWorkspaceCancelled(workspaceName) {
+		return fmt.Errorf("rollback of workspace '%s' was cancelled", workspaceName)
+	}
+	fmt.Printf("Workspace '%s' rolled back to revision %d\n", workspaceName, target.Revision)
+	return nil
+}
+
+// loadStateWorkspace loads the workspace whose OpenTofu state a state command works on
+func loadStateWorkspace(workspaceName string) (*workspace.Workspace, error) {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return nil, fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	ws := sched.GetWorkspace(workspaceName)
+	if ws == nil {
+		return nil, fmt.Errorf("workspace '%s' not found", workspaceName)
+	}
+	return ws, nil
+}
+
+// runStateListCommand prints a workspace's state backups, newest first
+func runStateListCommand(workspaceName string, format output.Format) error {
+	if _, err := loadStateWorkspace(workspaceName); err != nil {
+		return err
+	}
+
+	backups, err := opentofu.ListStateBackups(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	if format.Structured() {
+		if backups == nil {
+			backups = []opentofu.StateBackup{}
+		}
+		return output.Print(format, backups)
+	}
+	if len(backups) == 0 {
+		fmt.Printf("No state backups of workspace '%s'\n", workspaceName)
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tCREATED\tREASON\tSERIAL\tRESOURCES\tSIZE")
+	for _, backup := range backups {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%d\n",
+			backup.Version, logging.FormatTime(backup.CreatedAt), backup.Reason, backup.Serial, backup.Resources, backup.Size)
+	}
+	return w.Flush()
+}
+
+// runStateBackupCommand backs up a workspace's current state
+func runStateBackupCommand(workspaceName string) error {
+	ws, err := loadStateWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	client, err := opentofu.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+	}
+	backup, err := client.BackupState(ws)
+	if err != nil {
+		return err
+	}
+	if backup == nil {
+		fmt.Printf("Workspace '%s' has no state to back up or its newest backup is identical\n", workspaceName)
+		return nil
+	}
+	fmt.Printf("Backed up state of workspace '%s' as version %d (serial %d, %d resources)\n",
+		workspaceName, backup.Version, backup.Serial, backup.Resources)
+	return nil
+}
+
+// runStateRestoreCommand replaces a workspace's state with a backup after confirmation
+func runStateRestoreCommand(workspaceName string, version int, yes bool) error {
+	ws, err := loadStateWorkspace(workspaceName)
+	if err != nil {
+		return err
+	}
+
+	if !yes {
+		target := "the newest state backup"
+		if version > 0 {
+			target = fmt.Sprintf("state backup version %d", version)
+		}
+		fmt.Printf("Replace the OpenTofu state of workspace '%s' with %s? (y/N): ", workspaceName, target)
+		var response string
+		if _, err := fmt.Scanln(&response); err != nil {
+			fmt.Println("Cancelled")
+			return nil
+		}
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	client, err := opentofu.New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+	}
+	backup, err := client.RestoreState(ws, version)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored state of workspace '%s' from version %d (serial %d, %d resources)\n",
+		workspaceName, backup.Version, backup.Serial, backup.Resources)
+	fmt.Printf("The replaced state was backed up; run 'plan' to compare the restored state with the real infrastructure\n")
+	return nil
+}
+
+// runPruneCommand lists the orphaned workspaces, then destroys their remaining resources and
+// removes their deployments and logs after confirmation. A running daemon drops their state
+// entries itself, otherwise they are removed from the state file here.
+func runPruneCommand(dryRun, yes bool) error {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	orphans, err := sched.FindOrphans()
+	if err != nil {
+		return err
+	}
+	if len(orphans) == 0 {
+		fmt.Println("No orphaned workspaces found")
+		return nil
+	}
+
+	resources := 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tRESOURCES\tLEFT BEHIND")
+	for _, orphan := range orphans {
+		resources += orphan.Resources
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", orphan.Name, orDash(string(orphan.Status)), orphan.Resources, orphan.Leftovers())
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if dryRun {
+		fmt.Printf("\nDry run, nothing was destroyed or removed\n")
+		return nil
+	}
+
+	if !yes {
+		if resources > 0 {
+			fmt.Printf("\nDestroy %d resources and remove what %d removed workspaces left behind? (y/N): ", resources, len(orphans))
+		} else {
+			fmt.Printf("\nRemove what %d removed workspaces left behind? (y/N): ", len(orphans))
+		}
+		var response string
+		if _, err := fmt.Scanln(&response); err != nil {
+			fmt.Println("Cancelled")
+			return nil
+		}
+		if strings.ToLower(response) != "y" && strings.ToLower(response) != "yes" {
+			fmt.Println("Cancelled")
+			return nil
+		}
+	}
+
+	daemonRunning := false
+	if client, err := control.Dial(); err == nil {
+		daemonRunning = true
+		_ = client.Close()
+	}
+
+	failed := 0
+	for _, orphan := range orphans {
+		if err := sched.PruneOrphan(orphan, !daemonRunning); err != nil {
+			fmt.Printf("Failed to prune '%s': %v\n", orphan.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("Pruned '%s'\n", orphan.Name)
+	}
+
+	if !daemonRunning {
+		if err := sched.SaveState(); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d orphaned workspaces could not be pruned", failed, len(orphans))
+	}
+	return nil
+}
+
+// describeRevisionVersion identifies the template version of a revision by its source commit,
+// or its content hash for templates and local files without one
+func describeRevisionVersion(record opentofu.DeploymentRecord) string {
+	switch {
+	case record.TemplateCommit != "":
+		return "commit " + shortVersion(strings.TrimPrefix(record.TemplateCommit, "sha256:"))
+	case record.TemplateHash != "":
+		return "hash " + shortVersion(record.TemplateHash)
+	}
+	return "-"
+}
+
+// describeRevisionPlan summarizes what a revision's plan changed, e.g. "+2 ~1 -0"
+func describeRevisionPlan(record opentofu.DeploymentRecord) string {
+	if record.Plan == nil {
+		return "-"
+	}
+	return fmt.Sprintf("+%d ~%d -%d", record.Plan.Add, record.Plan.Change, record.Plan.Destroy)
+}
+
+// describeRevisionResult returns whether a revision deployed and whether it can be rolled back to
+func describeRevisionResult(record opentofu.DeploymentRecord) string {
+	switch {
+	case !record.Success:
+		return "failed"
+	case record.Snapshot:
+		return "deployed"
+	}
+	return "deployed (files pruned)"
+}
+
+// describeRevisionNotes returns the rollback a revision was and the error it failed with
+func describeRevisionNotes(record opentofu.DeploymentRecord) string {
+	var notes []string
+	if record.RollbackOf > 0 {
+		notes = append(notes, fmt.Sprintf("rollback to %d", record.RollbackOf))
+	}
+	if record.ConfigCommit != "" {
+		notes = append(notes, "config "+shortVersion(record.ConfigCommit))
+	}
+	if record.Error != "" {
+		notes = append(notes, record.Error)
+	}
+	if len(notes) == 0 {
+		return "-"
+	}
+	return strings.Join(notes, "; ")
+}
+
+func shortVersion(version string) string {
+	if len(version) > 12 {
+		return version[:12]
+	}
+	return version
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+// cancelOnInterrupt cancels a direct operation on Ctrl-C so the tofu process group is stopped cleanly.
+// With several workspaces, the operations in progress are cancelled, and so are those still waiting
+// for a slot once they start. The returned function stops listening for signals.
+func cancelOnInterrupt(sched *scheduler.Scheduler, workspaceNames ...string) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+
+		if len(workspaceNames) == 1 {
+			fmt.Fprintf(os.Stderr, "\nCancelling operation on workspace '%s'...\n", workspaceNames[0])
+			if err := sched.CancelWorkspace(workspaceNames[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			}
+			return
+		}
+
+		fmt.Fprintf(os.Stderr, "\nCancelling operations on %d workspaces...\n", len(workspaceNames))
+		cancelled := make(map[string]bool)
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			for _, workspaceName := range workspaceNames {
+				if !cancelled[workspaceName] && sched.CancelWorkspace(workspaceName) == nil {
+					cancelled[workspaceName] = true
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// runForceUnlock removes a workspace's deployment lock left by a hung or crashed operation
+func runForceUnlock(workspaceName string, forceUnlock bool) error {
+	if !forceUnlock {
+		return nil
+	}
+
+	holder, err := opentofu.ForceUnlock(workspaceName)
+	if err != nil {
+		return err
+	}
+	if holder == nil {
+		fmt.Printf("Workspace '%s' was not locked\n", workspaceName)
+	} else {
+		fmt.Printf("Removed deployment lock of workspace '%s' held by %s\n", workspaceName, holder)
+	}
+	return nil
+}
+
+// runApproveCommand runs the scheduled deploy or destroy awaiting a workspace's approval through
+// the daemon when it is running, otherwise directly
+func runApproveCommand(workspaceName string) error {
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	correlationID := logging.NewCorrelationID(time.Now())
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.Approve(workspaceName, correlationID)
+	}); handled {
+		return err
+	}
+
+	fmt.Printf("Correlation ID: %s\n", correlationID)
+	stop := cancelOnInterrupt(sched, workspaceName)
+	defer stop()
+	if err := sched.WithCorrelationID(workspaceName, correlationID, func() error {
+		return sched.ApproveWorkspace(workspaceName)
+	}); err != nil {
+		return err
+	}
+	if sched.IsWorkspaceCancelled(workspaceName) {
+		return fmt.Errorf("approved operation of workspace '%s' was cancelled", workspaceName)
+	}
+	return nil
+}
+
+func runCancelCommand(workspaceName string) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.Cancel(workspaceName)
+	}); handled {
+		return err
+	}
+
+	return fmt.Errorf("the provisioner daemon is not running; an operation started directly by workspacectl can be cancelled with Ctrl-C in its terminal")
+}
+
+// runFreezeCommand freezes or unfreezes a workspace through the daemon when it is running,
+// otherwise in the state file the daemon loads on start
+func runFreezeCommand(workspaceName string, freeze bool, reason string) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		if freeze {
+			return client.Freeze(workspaceName, reason)
+		}
+		return client.Unfreeze(workspaceName)
+	}); handled {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if !freeze {
+		if err := sched.UnfreezeWorkspace(workspaceName); err != nil {
+			return err
+		}
+		fmt.Printf("Workspace '%s' unfrozen\n", workspaceName)
+		return nil
+	}
+	if err := sched.FreezeWorkspace(workspaceName, reason); err != nil {
+		return err
+	}
+	fmt.Printf("Workspace '%s' frozen, automatic operations are skipped until it is unfrozen\n", workspaceName)
+	return nil
+}
+
+// runScheduleCommand schedules a one-shot deploy or destroy through the daemon when it is running,
+// otherwise in the state file the daemon loads on start
+func runScheduleCommand(workspaceName, operation, mode string, at time.Time) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.Schedule(workspaceName, operation, mode, at)
+	}); handled {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if err := sched.ScheduleOperation(workspaceName, operation, mode, at, audit.CurrentUser()); err != nil {
+		return err
+	}
+	if mode != "" {
+		operation += " in mode " + mode
+	}
+	fmt.Printf("Scheduled %s of workspace '%s' at %s\n", operation, workspaceName, logging.FormatTime(at))
+	return nil
+}
+
+func runUnscheduleCommand(workspaceName, operation string) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		return client.Unschedule(workspaceName, operation)
+	}); handled {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if err := sched.UnscheduleOperation(workspaceName, operation); err != nil {
+		return err
+	}
+	fmt.Printf("Scheduled operations of workspace '%s' dropped\n", workspaceName)
+	return nil
+}
+
+func runPauseCommand(workspaceName string, pause bool) error {
+	if handled, err := cli.CallDaemon(func(client *control.Client) (string, error) {
+		if pause {
+			return client.Pause(workspaceName)
+		}
+		return client.Resume(workspaceName)
+	}); handled {
+		return err
+	}
+
+	sched := scheduler.NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if !pause {
+		if err := sched.ResumeWorkspace(workspaceName); err != nil {
+			return err
+		}
+		fmt.Printf("Workspace '%s' resumed\n", workspaceName)
+		return nil
+	}
+	if err := sched.PauseWorkspace(workspaceName); err != nil {
+		return err
+	}
+	fmt.Printf("Workspace '%s' paused, scheduled operations are skipped until it is resumed\n", workspaceName)
+	return nil
+}
+
+func runModeCommand(workspaceName, mode string) error {
+	// Initialize scheduler in quiet mode for CLI
+	sched := scheduler.NewQuiet()
+
+	// Load workspaces to validate the specified workspace exists
+	if err := sched.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+
+	// Load state to check current workspace status
+	if err := sched.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	// Execute the mode change
+	return deployWorkspace(sched, workspaceName, mode, false)
+}
+
+func promptForMode(modes []string) (string, error) {
+	fmt.Printf("Workspace uses mode-based scheduling. Select deployment mode:\n")
+	for i, mode := range modes {
+		fmt.Printf("%d) %s\n", i+1, mode)
+	}
+
+	fmt.Printf("Enter choice (1-%d): ", len(modes))
+	var choice int
+	if _, err := fmt.Scanln(&choice); err != nil {
+		return "", fmt.Errorf("invalid input: %w", err)
+	}
+
+	if choice < 1 || choice > len(modes) {
+		return "", fmt.Errorf("choice must be between 1 and %d", len(modes))
+	}
+
+	return modes[choice-1], nil
+}
@@ -0,0 +1,347 @@
+// Package configfile reads config files written in JSON, YAML or HCL. Other formats are converted
+// to JSON, so workspace, standalone job and environment configs are decoded and validated the
+// same way whatever their format.
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+	"gopkg.in/yaml.v3"
+)
+
+// Extensions are the supported config file formats, in the order they are looked up
+var Extensions = []string{".json", ".yaml", ".yml", ".hcl"}
+
+// Path returns the path of the config file called name in dir, in whichever supported format
+// exists, or the JSON one if there is none. A config in more than one format is an error.
+func Path(dir, name string) (string, error) {
+	var found []string
+	for _, ext := range Extensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			found = append(found, path)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return filepath.Join(dir, name+".json"), nil
+	case 1:
+		return found[0], nil
+	default:
+		return found[0], fmt.Errorf("%s is defined more than once: %s", name, strings.Join(baseNames(found), ", "))
+	}
+}
+
+// TrimExtension returns a file name without its extension if it is a supported config format
+func TrimExtension(filename string) (string, bool) {
+	ext := filepath.Ext(filename)
+	for _, supported := range Extensions {
+		if ext == supported {
+			return strings.TrimSuffix(filename, ext), true
+		}
+	}
+	return filename, false
+}
+
+// ReadJSON reads a config file, converting YAML and HCL to JSON
+func ReadJSON(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ToJSON(path, data)
+}
+
+// ToJSON converts the content of a config file to JSON according to the file's extension
+func ToJSON(path string, data []byte) ([]byte, error) {
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return yamlToJSON(path, data)
+	case ".hcl":
+		return hclToJSON(path, data)
+	default:
+		return data, nil
+	}
+}
+
+// yamlToJSON converts the first document of a YAML file
+func yamlToJSON(path string, data []byte) ([]byte, error) {
+	var value interface{}
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("invalid YAML in %s: %w", filepath.Base(path), err)
+	}
+	value, err := stringKeys(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid YAML in %s: %w", filepath.Base(path), err)
+	}
+	if value == nil {
+		return []byte("{}"), nil // An empty file or one with only comments
+	}
+	return json.Marshal(value)
+}
+
+// stringKeys converts the maps of a decoded YAML value to maps with string keys, as in JSON
+func stringKeys(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			converted, err := stringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = converted
+		}
+		return v, nil
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("key %v is not a string", key)
+			}
+			converted, err := stringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			result[name] = converted
+		}
+		return result, nil
+	case []interface{}:
+		for i, item := range v {
+			converted, err := stringKeys(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+		return v, nil
+	default:
+		return v, nil
+	}
+}
+
+// hclToJSON converts an HCL file of attributes, e.g. `deploy_schedule = "0 9 * * 1-5"`. Objects and
+// lists are written as expressions (`variables = { ... }`, `jobs = [{ ... }]`); blocks, variables
+// and functions are not supported.
+func hclToJSON(path string, data []byte) ([]byte, error) {
+	file, diags := hclsyntax.ParseConfig(data, filepath.Base(path), hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("invalid HCL: %w", diags)
+	}
+	attributes, diags := file.Body.JustAttributes()
+	if diags.HasErrors() {
+		return nil, fmt.Errorf("invalid HCL: %w", diags)
+	}
+
+	fields := make(map[string]json.RawMessage, len(attributes))
+	for name, attribute := range attributes {
+		value, diags := attribute.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid HCL: %w", diags)
+		}
+		encoded, err := ctyjson.Marshal(value, value.Type())
+		if err != nil {
+			return nil, fmt.Errorf("invalid HCL: %s: %w", name, err)
+		}
+		fields[name] = encoded
+	}
+	return json.Marshal(fields)
+}
+
+// Writable returns true if Write can rewrite a config file; HCL files are only read
+func Writable(path string) bool {
+	return filepath.Ext(path) != ".hcl"
+}
+
+// Write writes v to a config file in the file's format. Rewriting a YAML file keeps the comments
+// and key order of the fields it already has.
+func Write(path string, v interface{}) error {
+	if !Writable(path) {
+		return fmt.Errorf("%s cannot be changed by the provisioner, edit it directly", filepath.Base(path))
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		data, err = updateYAML(path, data)
+		if err != nil {
+			return err
+		}
+	default:
+		data = append(data, '\n')
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// updateYAML returns the YAML file at path with the fields of the JSON config
+func updateYAML(path string, data []byte) ([]byte, error) {
+	value, err := decodeJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	var document yaml.Node
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(existing, &document); err != nil {
+		return nil, fmt.Errorf("invalid YAML in %s: %w", filepath.Base(path), err)
+	}
+
+	if len(document.Content) == 0 {
+		if err := document.Encode(value); err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+	} else if err := updateNode(document.Content[0], value); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeJSON decodes JSON keeping integers, so they are not written as floats
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return numbers(value), nil
+}
+
+// numbers replaces the json.Number values of a decoded value with int64 or float64
+func numbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = numbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = numbers(item)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	}
+	return value
+}
+
+// updateNode sets a YAML node to value, keeping the comments and order of the mapping keys and
+// sequence items that remain
+func updateNode(node *yaml.Node, value interface{}) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if node.Kind == yaml.MappingNode {
+			content := node.Content[:0:0]
+			seen := make(map[string]bool, len(v))
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, item := node.Content[i], node.Content[i+1]
+				newValue, ok := v[key.Value]
+				if !ok || seen[key.Value] {
+					continue // Removed
+				}
+				if err := updateNode(item, newValue); err != nil {
+					return err
+				}
+				seen[key.Value] = true
+				content = append(content, key, item)
+			}
+
+			var added []string
+			for key := range v {
+				if !seen[key] {
+					added = append(added, key)
+				}
+			}
+			sort.Strings(added)
+			for _, key := range added {
+				var keyNode, item yaml.Node
+				if err := keyNode.Encode(key); err != nil {
+					return err
+				}
+				if err := item.Encode(v[key]); err != nil {
+					return err
+				}
+				content = append(content, &keyNode, &item)
+			}
+			node.Content = content
+			return nil
+		}
+	case []interface{}:
+		if node.Kind == yaml.SequenceNode {
+			content := node.Content
+			if len(content) > len(v) {
+				content = content[:len(v)]
+			}
+			for i, item := range v {
+				if i < len(content) {
+					if err := updateNode(content[i], item); err != nil {
+						return err
+					}
+					continue
+				}
+				var itemNode yaml.Node
+				if err := itemNode.Encode(item); err != nil {
+					return err
+				}
+				content = append(content, &itemNode)
+			}
+			node.Content = content
+			return nil
+		}
+	}
+
+	// Scalars and values of a different kind are replaced, keeping the comments
+	var replacement yaml.Node
+	if err := replacement.Encode(value); err != nil {
+		return err
+	}
+	replacement.HeadComment = node.HeadComment
+	replacement.LineComment = node.LineComment
+	replacement.FootComment = node.FootComment
+	*node = replacement
+	return nil
+}
+
+// baseNames returns the file names of paths
+func baseNames(paths []string) []string {
+	names := make([]string, len(paths))
+	for i, path := range paths {
+		names[i] = filepath.Base(path)
+	}
+	return names
+}
@@ -0,0 +1,219 @@
+package configfile
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testConfig is a config with the kinds of fields workspace and job configs have
+type testConfig struct {
+	Enabled        bool                   `json:"enabled"`
+	DeploySchedule interface{}            `json:"deploy_schedule"`
+	Variables      map[string]interface{} `json:"variables,omitempty"`
+	Jobs           []map[string]string    `json:"jobs,omitempty"`
+}
+
+func decodeTestConfig(t *testing.T, name, content string) testConfig {
+	t.Helper()
+	data, err := ToJSON(name, []byte(content))
+	if err != nil {
+		t.Fatalf("failed to convert %s: %v", name, err)
+	}
+	var config testConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("failed to unmarshal %s: %v", data, err)
+	}
+	return config
+}
+
+func TestToJSON(t *testing.T) {
+	expected := testConfig{
+		Enabled:        true,
+		DeploySchedule: []interface{}{"0 9 * * 1-5", "0 13 * * 1-5"},
+		Variables:      map[string]interface{}{"instance_count": float64(2), "region": "fra1"},
+		Jobs:           []map[string]string{{"name": "backup", "schedule": "0 2 * * *"}},
+	}
+
+	files := map[string]string{
+		"config.json": `{
+  "enabled": true,
+  "deploy_schedule": ["0 9 * * 1-5", "0 13 * * 1-5"],
+  "variables": {"instance_count": 2, "region": "fra1"},
+  "jobs": [{"name": "backup", "schedule": "0 2 * * *"}]
+}`,
+		"config.yaml": `# Office hours
+enabled: true
+deploy_schedule:
+  - "0 9 * * 1-5"   # Morning
+  - 0 13 * * 1-5
+variables:
+  instance_count: 2
+  region: fra1
+jobs:
+  - name: backup
+    schedule: "0 2 * * *"
+`,
+		"config.hcl": `# Office hours
+enabled = true
+deploy_schedule = ["0 9 * * 1-5", "0 13 * * 1-5"]
+variables = {
+  instance_count = 2
+  region         = "fra1"
+}
+jobs = [{ name = "backup", schedule = "0 2 * * *" }]
+`,
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			if config := decodeTestConfig(t, name, content); !reflect.DeepEqual(config, expected) {
+				t.Errorf("expected %+v, got %+v", expected, config)
+			}
+		})
+	}
+}
+
+func TestToJSONErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"config.yaml", "enabled: [true", "invalid YAML in config.yaml"},
+		{"config.yaml", "1: one", "key 1 is not a string"},
+		{"config.hcl", "enabled = ", "invalid HCL"},
+		{"config.hcl", "job \"backup\" {\n}\n", "invalid HCL"},
+		{"config.hcl", "enabled = var.enabled", "invalid HCL"},
+	}
+
+	for _, tt := range tests {
+		_, err := ToJSON(tt.name, []byte(tt.content))
+		if err == nil || !strings.Contains(err.Error(), tt.expected) {
+			t.Errorf("%s %q: expected error containing %q, got %v", tt.name, tt.content, tt.expected, err)
+		}
+	}
+}
+
+func TestToJSONEmptyYAML(t *testing.T) {
+	data, err := ToJSON("config.yaml", []byte("# Nothing set yet\n"))
+	if err != nil || string(data) != "{}" {
+		t.Errorf("expected an empty object, got %s, %v", data, err)
+	}
+}
+
+func TestPath(t *testing.T) {
+	dir := t.TempDir()
+
+	path, err := Path(dir, "config")
+	if err != nil || path != filepath.Join(dir, "config.json") {
+		t.Errorf("expected config.json without a config file, got %s, %v", path, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.yaml"), []byte("enabled: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path, err = Path(dir, "config")
+	if err != nil || path != filepath.Join(dir, "config.yaml") {
+		t.Errorf("expected config.yaml, got %s, %v", path, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Path(dir, "config"); err == nil || !strings.Contains(err.Error(), "config.json, config.yaml") {
+		t.Errorf("expected an error for a config in two formats, got %v", err)
+	}
+}
+
+func TestTrimExtension(t *testing.T) {
+	tests := map[string]struct {
+		name string
+		ok   bool
+	}{
+		"backup.json": {"backup", true},
+		"backup.yaml": {"backup", true},
+		"backup.yml":  {"backup", true},
+		"backup.hcl":  {"backup", true},
+		"main.tf":     {"main.tf", false},
+		"README":      {"README", false},
+	}
+	for filename, expected := range tests {
+		if name, ok := TrimExtension(filename); name != expected.name || ok != expected.ok {
+			t.Errorf("TrimExtension(%q) = %q, %v, expected %q, %v", filename, name, ok, expected.name, expected.ok)
+		}
+	}
+}
+
+func TestWriteYAMLKeepsComments(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "production.yaml")
+	original := `# Production environment
+domain: example.com
+assigned_workspace: blue # Switched by environmentctl
+healthcheck:
+  type: http
+  port: 80
+`
+	if err := os.WriteFile(path, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := map[string]interface{}{
+		"domain":             "example.com",
+		"assigned_workspace": "green",
+		"healthcheck":        map[string]interface{}{"type": "http", "port": 8080},
+		"reserved_ips":       []string{"10.0.0.1"},
+	}
+	if err := Write(path, config); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `# Production environment
+domain: example.com
+assigned_workspace: green # Switched by environmentctl
+healthcheck:
+  type: http
+  port: 8080
+reserved_ips:
+  - 10.0.0.1
+`
+	if string(data) != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, data)
+	}
+}
+
+func TestWriteJSONAndHCL(t *testing.T) {
+	dir := t.TempDir()
+	config := testConfig{Enabled: true, DeploySchedule: "0 9 * * *"}
+
+	jsonPath := filepath.Join(dir, "config.json")
+	if err := Write(jsonPath, config); err != nil {
+		t.Fatalf("failed to write JSON: %v", err)
+	}
+	data, err := ReadJSON(jsonPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var written testConfig
+	if err := json.Unmarshal(data, &written); err != nil || !reflect.DeepEqual(written, config) {
+		t.Errorf("expected %+v, got %+v (%v)", config, written, err)
+	}
+
+	hclPath := filepath.Join(dir, "config.hcl")
+	if err := os.WriteFile(hclPath, []byte("enabled = false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Write(hclPath, config); err == nil {
+		t.Error("expected writing an HCL config to fail")
+	}
+	if data, _ := os.ReadFile(hclPath); string(data) != "enabled = false\n" {
+		t.Errorf("expected the HCL config to be unchanged, got %s", data)
+	}
+}
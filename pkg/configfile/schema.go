@@ -0,0 +1,510 @@
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a field of a config file that doesn't match the schema of its Go type
+type Problem struct {
+	File    string // Config file, as given to Check
+	Path    string // Field path, e.g. jobs[0].schedule
+	Line    int    // Line of the field in the file, 0 if unknown
+	Message string
+	Unknown bool // An unknown field, ignored when the config is loaded
+}
+
+// String formats the problem as "workspaces/my-app/config.json: line 7: unknown field 'deploy_schedul'"
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("%s: line %d: %s", p.File, p.Line, p.Message)
+	}
+	return fmt.Sprintf("%s: %s", p.File, p.Message)
+}
+
+// Check checks the fields of a config file against the schema given by the json tags of v's
+// type, reporting values of the wrong type and unknown fields, e.g. misspelt ones, with their
+// lines. It fails if the file cannot be parsed at all.
+func Check(path string, data []byte, v interface{}) ([]Problem, error) {
+	converted, err := ToJSON(path, data)
+	if err != nil {
+		return nil, err
+	}
+	value, err := decodeJSON(converted)
+	if err != nil {
+		return nil, syntaxError(path, data, err)
+	}
+
+	var problems []Problem
+	checkValue(reflect.TypeOf(v), value, nil, &problems)
+	for i := range problems {
+		problems[i].File = path
+		problems[i].Line = locate(path, data, problems[i].Path)
+	}
+	return problems, nil
+}
+
+// CheckFile reads a config file and checks it like Check. A file that doesn't exist has no problems.
+func CheckFile(path string, v interface{}) ([]Problem, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return Check(path, data, v)
+}
+
+// Unmarshal decodes a config file's content, already converted to JSON, into v. Errors name the
+// file and, where possible, the line and field of the problem.
+func Unmarshal(path string, original, data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*json.SyntaxError); ok {
+		return syntaxError(path, original, err)
+	}
+	if problems, checkErr := Check(path, original, v); checkErr == nil {
+		for _, problem := range problems {
+			if !problem.Unknown {
+				return errors.New(problem.String())
+			}
+		}
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+// syntaxError adds the file and line to a JSON syntax error
+func syntaxError(path string, data []byte, err error) error {
+	var syntaxErr *json.SyntaxError
+	if filepath.Ext(path) == ".json" && errors.As(err, &syntaxErr) {
+		return fmt.Errorf("%s: line %d: %w", path, lineAt(data, syntaxErr.Offset), err)
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
+
+// checkValue checks a decoded JSON value against a Go type
+func checkValue(t reflect.Type, value interface{}, path []string, problems *[]Problem) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil || t.Kind() == reflect.Interface || implementsUnmarshaler(t) {
+		return
+	}
+
+	mismatch := func(expected string) {
+		*problems = append(*problems, Problem{
+			Path:    strings.Join(path, ""),
+			Message: fmt.Sprintf("%s must be %s, got %s", fieldName(path), expected, jsonKind(value)),
+		})
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			mismatch("an object")
+			return
+		}
+		fields := structFields(t)
+		for _, key := range sortedKeys(object) {
+			fieldPath := append(append([]string(nil), path...), fieldSegment(path, key))
+			field, ok := fields[key]
+			if !ok {
+				field, ok = foldedField(key, fields) // encoding/json matches names case-insensitively
+			}
+			if !ok {
+				message := fmt.Sprintf("unknown field '%s'", key)
+				if suggestion := closestField(key, fields); suggestion != "" {
+					message += fmt.Sprintf(", did you mean '%s'?", suggestion)
+				}
+				*problems = append(*problems, Problem{Path: strings.Join(fieldPath, ""), Message: message, Unknown: true})
+				continue
+			}
+			checkValue(field, object[key], fieldPath, problems)
+		}
+	case reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			mismatch("an object")
+			return
+		}
+		for _, key := range sortedKeys(object) {
+			checkValue(t.Elem(), object[key], append(append([]string(nil), path...), fieldSegment(path, key)), problems)
+		}
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]interface{})
+		if !ok {
+			mismatch("a list")
+			return
+		}
+		for i, item := range items {
+			checkValue(t.Elem(), item, append(append([]string(nil), path...), fmt.Sprintf("[%d]", i)), problems)
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			mismatch("a string")
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			mismatch("true or false")
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, ok := value.(int64); !ok {
+			mismatch("a whole number")
+		}
+	case reflect.Float32, reflect.Float64:
+		switch value.(type) {
+		case int64, float64:
+		default:
+			mismatch("a number")
+		}
+	}
+}
+
+// implementsUnmarshaler returns true if a type decodes itself, so its JSON cannot be checked
+func implementsUnmarshaler(t reflect.Type) bool {
+	unmarshaler := reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	return t.Implements(unmarshaler) || reflect.PointerTo(t).Implements(unmarshaler)
+}
+
+// structFields returns the types of a struct's fields by their JSON names, including those of
+// embedded structs
+func structFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			for embeddedName, embeddedType := range structFields(fieldType) {
+				if _, exists := fields[embeddedName]; !exists {
+					fields[embeddedName] = embeddedType
+				}
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// foldedField returns the field whose name matches key ignoring case
+func foldedField(key string, fields map[string]reflect.Type) (reflect.Type, bool) {
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return nil, false
+}
+
+// closestField returns the field a misspelt key most likely meant, if any is close enough
+func closestField(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", len(key)/3+1
+	for name := range fields {
+		if distance := editDistance(strings.ToLower(key), name); distance < bestDistance || (distance == bestDistance && best != "" && name < best) {
+			best, bestDistance = name, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// fieldSegment returns the path segment of an object key
+func fieldSegment(path []string, key string) string {
+	if len(path) == 0 {
+		return key
+	}
+	return "." + key
+}
+
+// fieldName returns how a problem refers to the field at path
+func fieldName(path []string) string {
+	if len(path) == 0 {
+		return "the config"
+	}
+	return fmt.Sprintf("'%s'", strings.Join(path, ""))
+}
+
+// jsonKind describes the kind of a decoded JSON value
+func jsonKind(value interface{}) string {
+	switch value.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "a list"
+	case string:
+		return "a string"
+	case bool:
+		return "true or false"
+	case int64, float64:
+		return "a number"
+	default:
+		return "null"
+	}
+}
+
+// sortedKeys returns the keys of an object in sorted order
+func sortedKeys(object map[string]interface{}) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// splitPath splits a field path such as jobs[0].schedule into object keys and list indexes
+func splitPath(path string) []interface{} {
+	var segments []interface{}
+	for _, part := range strings.Split(path, ".") {
+		name, indexes, _ := strings.Cut(part, "[")
+		if name != "" {
+			segments = append(segments, name)
+		}
+		for _, index := range strings.Split(indexes, "[") {
+			if n, err := strconv.Atoi(strings.TrimSuffix(index, "]")); err == nil {
+				segments = append(segments, n)
+			}
+		}
+	}
+	return segments
+}
+
+// locate returns the line of the field at path in a config file, 0 if it cannot be found
+func locate(path string, data []byte, fieldPath string) int {
+	segments := splitPath(fieldPath)
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return locateYAML(data, segments)
+	case ".hcl":
+		return locateHCL(path, data, segments)
+	default:
+		return locateJSON(data, segments)
+	}
+}
+
+// locateJSON finds a field by walking the tokens of a JSON document
+func locateJSON(data []byte, segments []interface{}) int {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	// skip reads past the value starting with token
+	var skip func(token json.Token) bool
+	skip = func(token json.Token) bool {
+		if delim, ok := token.(json.Delim); ok && (delim == '{' || delim == '[') {
+			for decoder.More() {
+				if delim == '{' {
+					if _, err := decoder.Token(); err != nil {
+						return false
+					}
+				}
+				next, err := decoder.Token()
+				if err != nil || !skip(next) {
+					return false
+				}
+			}
+			_, err := decoder.Token()
+			return err == nil
+		}
+		return true
+	}
+
+	// The first token of a list item is read to find its line before the item is walked
+	var pending json.Token
+	next := func() (json.Token, error) {
+		if token := pending; token != nil {
+			pending = nil
+			return token, nil
+		}
+		return decoder.Token()
+	}
+
+	line := 0
+	for _, segment := range segments {
+		token, err := next()
+		if err != nil {
+			return line
+		}
+		switch want := segment.(type) {
+		case string:
+			if token != json.Delim('{') {
+				return line
+			}
+			found := false
+			for decoder.More() {
+				key, err := decoder.Token()
+				if err != nil {
+					return line
+				}
+				if key == want {
+					line = lineAt(data, decoder.InputOffset())
+					found = true
+					break
+				}
+				value, err := decoder.Token()
+				if err != nil || !skip(value) {
+					return line
+				}
+			}
+			if !found {
+				return line
+			}
+		case int:
+			if token != json.Delim('[') {
+				return line
+			}
+			for i := 0; i < want; i++ {
+				value, err := decoder.Token()
+				if err != nil || !skip(value) {
+					return line
+				}
+			}
+			if !decoder.More() {
+				return line
+			}
+			if pending, err = decoder.Token(); err != nil {
+				return line
+			}
+			line = lineAt(data, decoder.InputOffset())
+		}
+	}
+	return line
+}
+
+// lineAt returns the line of a byte offset
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// locateYAML finds a field in the node tree of a YAML document
+func locateYAML(data []byte, segments []interface{}) int {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil || len(document.Content) == 0 {
+		return 0
+	}
+
+	node, line := document.Content[0], 0
+	for _, segment := range segments {
+		for node.Kind == yaml.AliasNode && node.Alias != nil {
+			node = node.Alias
+		}
+		var next *yaml.Node
+		switch want := segment.(type) {
+		case string:
+			if node.Kind != yaml.MappingNode {
+				return line
+			}
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == want {
+					line, next = node.Content[i].Line, node.Content[i+1]
+				}
+			}
+		case int:
+			if node.Kind == yaml.SequenceNode && want < len(node.Content) {
+				next = node.Content[want]
+				line = next.Line
+			}
+		}
+		if next == nil {
+			return line
+		}
+		node = next
+	}
+	return line
+}
+
+// locateHCL finds a field in the attributes and object and tuple expressions of an HCL file
+func locateHCL(path string, data []byte, segments []interface{}) int {
+	file, diags := hclsyntax.ParseConfig(data, filepath.Base(path), hcl.InitialPos)
+	if diags.HasErrors() || len(segments) == 0 {
+		return 0
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return 0
+	}
+	name, _ := segments[0].(string)
+	attribute, ok := body.Attributes[name]
+	if !ok {
+		return 0
+	}
+
+	expr, line := attribute.Expr, attribute.NameRange.Start.Line
+	for _, segment := range segments[1:] {
+		var next hclsyntax.Expression
+		switch want := segment.(type) {
+		case string:
+			object, ok := expr.(*hclsyntax.ObjectConsExpr)
+			if !ok {
+				return line
+			}
+			for _, item := range object.Items {
+				key, diags := item.KeyExpr.Value(nil)
+				if !diags.HasErrors() && key.Type() == cty.String && key.IsKnown() && !key.IsNull() && key.AsString() == want {
+					line, next = item.KeyExpr.Range().Start.Line, item.ValueExpr
+				}
+			}
+		case int:
+			tuple, ok := expr.(*hclsyntax.TupleConsExpr)
+			if ok && want < len(tuple.Exprs) {
+				next = tuple.Exprs[want]
+				line = next.Range().Start.Line
+			}
+		}
+		if next == nil {
+			return line
+		}
+		expr = next
+	}
+	return line
+}
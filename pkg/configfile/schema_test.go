@@ -0,0 +1,130 @@
+package configfile
+
+import (
+	"strings"
+	"testing"
+)
+
+// schemaConfig has the kinds of fields Check walks through
+type schemaConfig struct {
+	Enabled        bool                   `json:"enabled"`
+	DeploySchedule interface{}            `json:"deploy_schedule"`
+	Timeout        string                 `json:"timeout,omitempty"`
+	Variables      map[string]interface{} `json:"variables,omitempty"`
+	Jobs           []schemaJob            `json:"jobs,omitempty"`
+}
+
+type schemaJob struct {
+	Name     string `json:"name"`
+	Schedule string `json:"schedule"`
+	Retries  int    `json:"retries,omitempty"`
+}
+
+func TestCheck(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{
+  "enabled": true,
+  "deploy_schedul": "0 9 * * *",
+  "variables": {"anything": [1, 2]},
+  "jobs": [
+    {"name": "backup", "schedule": "0 2 * * *"},
+    {
+      "name": "report",
+      "schedule": "0 6 * * *",
+      "retries": "3"
+    }
+  ]
+}`,
+		"config.yaml": `enabled: true
+deploy_schedul: "0 9 * * *"
+variables:
+  anything: [1, 2]
+jobs:
+  - name: backup
+    schedule: "0 2 * * *"
+  - name: report
+    schedule: "0 6 * * *"
+
+    retries: "3"
+`,
+		"config.hcl": `enabled = true
+deploy_schedul = "0 9 * * *"
+variables = { anything = [1, 2] }
+jobs = [
+  { name = "backup", schedule = "0 2 * * *" },
+  {
+    name     = "report"
+    schedule = "0 6 * * *"
+
+    retries = "3"
+  },
+]
+`,
+	}
+	expected := map[string][]int{
+		"config.json": {3, 10},
+		"config.yaml": {2, 11},
+		"config.hcl":  {2, 10},
+	}
+
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			problems, err := Check(name, []byte(content), &schemaConfig{})
+			if err != nil {
+				t.Fatalf("failed to check: %v", err)
+			}
+			if len(problems) != 2 {
+				t.Fatalf("expected 2 problems, got %v", problems)
+			}
+
+			unknown, mismatch := problems[0], problems[1]
+			if !unknown.Unknown || unknown.Line != expected[name][0] ||
+				unknown.Message != "unknown field 'deploy_schedul', did you mean 'deploy_schedule'?" {
+				t.Errorf("unexpected unknown field problem %+v", unknown)
+			}
+			if mismatch.Unknown || mismatch.Line != expected[name][1] || mismatch.Path != "jobs[1].retries" ||
+				mismatch.Message != "'jobs[1].retries' must be a whole number, got a string" {
+				t.Errorf("unexpected type problem %+v", mismatch)
+			}
+		})
+	}
+}
+
+func TestCheckFollowsDecoding(t *testing.T) {
+	// Names match case-insensitively and null leaves a field unset, as when the config is decoded
+	content := `{"Enabled": true, "timeout": null, "deploy_schedule": ["0 9 * * *"], "jobs": null}`
+	problems, err := Check("config.json", []byte(content), &schemaConfig{})
+	if err != nil || len(problems) != 0 {
+		t.Errorf("expected no problems, got %v, %v", problems, err)
+	}
+}
+
+func TestProblemString(t *testing.T) {
+	problem := Problem{File: "workspaces/my-app/config.json", Line: 7, Message: "unknown field 'deploy_schedul'"}
+	if expected := "workspaces/my-app/config.json: line 7: unknown field 'deploy_schedul'"; problem.String() != expected {
+		t.Errorf("expected %q, got %q", expected, problem.String())
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"config.json", "{\n  \"enabled\": true,\n  \"timeout\": 30\n}", "config.json: line 3: 'timeout' must be a string, got a number"},
+		{"config.json", "{\n  \"enabled\": true\n  \"timeout\": \"30m\"\n}", "config.json: line 3: invalid character"},
+		{"config.yaml", "enabled: yes please\n", "config.yaml: line 1: 'enabled' must be true or false, got a string"},
+	}
+
+	for _, tt := range tests {
+		data, err := ToJSON(tt.name, []byte(tt.content))
+		if err != nil {
+			data = []byte(tt.content)
+		}
+		err = Unmarshal(tt.name, []byte(tt.content), data, &schemaConfig{})
+		if err == nil || !strings.HasPrefix(err.Error(), tt.expected) {
+			t.Errorf("%s %q: expected error starting with %q, got %v", tt.name, tt.content, tt.expected, err)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+package control
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/control/controlpb"
+)
+
+// ErrDaemonNotRunning is returned by Dial when no daemon is listening on the control socket
+var ErrDaemonNotRunning = errors.New("daemon is not running")
+
+// dialTimeout bounds how long Dial waits for the daemon to accept the connection
+const dialTimeout = 2 * time.Second
+
+// Client calls the daemon's control socket
+type Client struct {
+	conn         *grpc.ClientConn
+	workspaces   controlpb.WorkspaceServiceClient
+	jobs         controlpb.JobServiceClient
+	templates    controlpb.TemplateServiceClient
+	environments controlpb.EnvironmentServiceClient
+	scheduler    controlpb.SchedulerServiceClient
+}
+
+// Dial connects to the daemon at the default socket path
+func Dial() (*Client, error) {
+	return DialPath(SocketPath())
+}
+
+// DialPath connects to the daemon at the given socket path
+func DialPath(socketPath string) (*Client, error) {
+	conn, err := grpc.NewClient("unix:"+socketPath, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("invalid control socket path: %w", err)
+	}
+	if !connected(conn) {
+		_ = conn.Close()
+		return nil, ErrDaemonNotRunning
+	}
+	return &Client{
+		conn:         conn,
+		workspaces:   controlpb.NewWorkspaceServiceClient(conn),
+		jobs:         controlpb.NewJobServiceClient(conn),
+		templates:    controlpb.NewTemplateServiceClient(conn),
+		environments: controlpb.NewEnvironmentServiceClient(conn),
+		scheduler:    controlpb.NewSchedulerServiceClient(conn),
+	}, nil
+}
+
+// connected connects conn and waits until it is ready, giving up on the first failed attempt
+// rather than retrying while no daemon listens
+func connected(conn *grpc.ClientConn) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return true
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return false
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Deploy asks the daemon to deploy a workspace, optionally in a mode; ignoreBudget deploys even
+// if the estimated cost exceeds max_monthly_cost. An empty correlationID lets the daemon generate one.
+func (c *Client) Deploy(name, mode, correlationID string, ignoreBudget bool) (string, error) {
+	return reply(c.workspaces.Deploy(context.Background(), &controlpb.WorkspaceRequest{Name: name, Mode: mode, CorrelationId: correlationID, IgnoreBudget: ignoreBudget, User: audit.CurrentUser()}))
+}
+
+// Upgrade asks the daemon to redeploy a workspace whose template changed since its last deploy
+func (c *Client) Upgrade(name, correlationID string) (string, error) {
+	return reply(c.workspaces.Upgrade(context.Background(), &controlpb.WorkspaceRequest{Name: name, CorrelationId: correlationID, User: audit.CurrentUser()}))
+}
+
+// Rollback asks the daemon to redeploy a workspace with the files and variables of revision of its
+// deployment history, or of the deploy before the last one if revision is 0
+func (c *Client) Rollback(name string, revision int, correlationID string) (string, error) {
+	return reply(c.workspaces.Rollback(context.Background(), &controlpb.WorkspaceRequest{Name: name, Revision: int32(revision), CorrelationId: correlationID, User: audit.CurrentUser()}))
+}
+
+// Destroy asks the daemon to destroy a workspace; force also destroys protected workspaces
+func (c *Client) Destroy(name, correlationID string, force bool) (string, error) {
+	return reply(c.workspaces.Destroy(context.Background(), &controlpb.WorkspaceRequest{Name: name, CorrelationId: correlationID, Force: force, User: audit.CurrentUser()}))
+}
+
+// Approve asks the daemon to run the scheduled deploy or destroy awaiting a workspace's approval
+func (c *Client) Approve(name, correlationID string) (string, error) {
+	return reply(c.workspaces.Approve(context.Background(), &controlpb.WorkspaceRequest{Name: name, CorrelationId: correlationID, User: audit.CurrentUser()}))
+}
+
+// Cancel asks the daemon to cancel a workspace's in-flight deploy or destroy
+func (c *Client) Cancel(name string) (string, error) {
+	return reply(c.workspaces.Cancel(context.Background(), &controlpb.WorkspaceRequest{Name: name, User: audit.CurrentUser()}))
+}
+
+// Freeze asks the daemon to freeze a workspace
+func (c *Client) Freeze(name, reason string) (string, error) {
+	return reply(c.workspaces.Freeze(context.Background(), &controlpb.WorkspaceRequest{Name: name, Reason: reason, User: audit.CurrentUser()}))
+}
+
+// Unfreeze asks the daemon to unfreeze a workspace
+func (c *Client) Unfreeze(name string) (string, error) {
+	return reply(c.workspaces.Unfreeze(context.Background(), &controlpb.WorkspaceRequest{Name: name, User: audit.CurrentUser()}))
+}
+
+// Pause asks the daemon to pause a workspace's scheduled operations
+func (c *Client) Pause(name string) (string, error) {
+	return reply(c.workspaces.Pause(context.Background(), &controlpb.WorkspaceRequest{Name: name, User: audit.CurrentUser()}))
+}
+
+// Resume asks the daemon to resume a paused workspace
+func (c *Client) Resume(name string) (string, error) {
+	return reply(c.workspaces.Resume(context.Background(), &controlpb.WorkspaceRequest{Name: name, User: audit.CurrentUser()}))
+}
+
+// Schedule asks the daemon to run a one-shot deploy or destroy of a workspace at the given time
+func (c *Client) Schedule(name, operation, mode string, at time.Time) (string, error) {
+	return reply(c.workspaces.Schedule(context.Background(), &controlpb.WorkspaceRequest{Name: name, Operation: operation, Mode: mode, At: timestamppb.New(at), User: audit.CurrentUser()}))
+}
+
+// Unschedule asks the daemon to drop a workspace's one-shot operations, all if operation is empty
+func (c *Client) Unschedule(name, operation string) (string, error) {
+	return reply(c.workspaces.Unschedule(context.Background(), &controlpb.WorkspaceRequest{Name: name, Operation: operation, User: audit.CurrentUser()}))
+}
+
+// PauseAll asks the daemon to pause the scheduled operations of all workspaces
+func (c *Client) PauseAll() (string, error) {
+	return reply(c.scheduler.PauseAll(context.Background(), &controlpb.SchedulerRequest{}))
+}
+
+// ResumeAll asks the daemon to resume the scheduled operations of all workspaces
+func (c *Client) ResumeAll() (string, error) {
+	return reply(c.scheduler.ResumeAll(context.Background(), &controlpb.SchedulerRequest{}))
+}
+
+// RunJob asks the daemon to run a job; an empty workspace means a standalone job
+func (c *Client) RunJob(workspaceName, jobName string) (string, error) {
+	return reply(c.jobs.Run(context.Background(), &controlpb.JobRequest{Workspace: workspaceName, Job: jobName, User: audit.CurrentUser()}))
+}
+
+// KillJob asks the daemon to kill a running job
+func (c *Client) KillJob(workspaceName, jobName string) (string, error) {
+	return reply(c.jobs.Kill(context.Background(), &controlpb.JobRequest{Workspace: workspaceName, Job: jobName, User: audit.CurrentUser()}))
+}
+
+// UpdateTemplate asks the daemon to update a template from its source; force accepts a
+// rewritten branch or moved tag
+func (c *Client) UpdateTemplate(name string, force bool) (string, error) {
+	return reply(c.templates.Update(context.Background(), &controlpb.TemplateRequest{Name: name, Force: force}))
+}
+
+// RecordEnvironmentSwitch asks the daemon to record a completed environment switch in the history
+// of the workspaces involved
+func (c *Client) RecordEnvironmentSwitch(environmentName, from, to string) (string, error) {
+	return reply(c.environments.RecordSwitch(context.Background(), &controlpb.EnvironmentRequest{Environment: environmentName, From: from, To: to}))
+}
+
+// reply returns the message of a control call's reply, or the daemon's error as it reported it
+func reply(r *controlpb.Reply, err error) (string, error) {
+	if err != nil {
+		switch status.Code(err) {
+		case codes.Unavailable, codes.Canceled, codes.DeadlineExceeded:
+			return "", fmt.Errorf("control socket call failed: %w", err)
+		}
+		return "", errors.New(status.Convert(err).Message())
+	}
+	return r.GetMessage(), nil
+}
@@ -0,0 +1,22 @@
+// Package control serves the daemon's control socket, the gRPC services of controlpb through
+// which the CLIs run operations and change state, and the client the CLIs call them with.
+package control
+
+import (
+	"os"
+	"path/filepath"
+
+	"provisioner/pkg/paths"
+)
+
+// SocketName is the control socket file name in the state directory
+const SocketName = "provisioner.sock"
+
+// SocketPath returns the control socket path, PROVISIONER_SOCKET or the socket in the state
+// directory. Daemons sharing a state directory need a socket of their own on each host.
+func SocketPath() string {
+	if socketPath := os.Getenv("PROVISIONER_SOCKET"); socketPath != "" {
+		return socketPath
+	}
+	return filepath.Join(paths.StateDir(), SocketName)
+}
@@ -0,0 +1,229 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/workspace"
+)
+
+// setupScheduler creates a scheduler with one workspace backed by a mock client
+func setupScheduler(t *testing.T) (*scheduler.Scheduler, *opentofu.MockTofuClient, string) {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	stateDir := filepath.Join(tempDir, "state")
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+	t.Setenv("PROVISIONER_LOG_DIR", filepath.Join(tempDir, "logs"))
+
+	workspaceDir := filepath.Join(configDir, "workspaces", "web")
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		t.Fatalf("Failed to create workspace dir: %v", err)
+	}
+	config := `{"enabled": true, "deploy_schedule": "0 9 * * *", "destroy_schedule": "0 17 * * *"}`
+	if err := os.WriteFile(filepath.Join(workspaceDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workspaceDir, "main.tf"), []byte("# test\n"), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+
+	mockClient := opentofu.NewMockTofuClient()
+	sched := scheduler.NewWithClient(mockClient)
+	if err := sched.LoadWorkspaces(); err != nil {
+		t.Fatalf("Failed to load workspaces: %v", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	return sched, mockClient, filepath.Join(tempDir, SocketName)
+}
+
+func startServer(t *testing.T, sched *scheduler.Scheduler, socketPath string) {
+	t.Helper()
+
+	server, err := NewServer(sched, socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if err := server.Start(); err != nil {
+		t.Fatalf("Failed to start server: %v", err)
+	}
+	t.Cleanup(func() { _ = server.Close() })
+}
+
+func TestDeployAndDestroyThroughDaemon(t *testing.T) {
+	sched, mockClient, socketPath := setupScheduler(t)
+	startServer(t, sched, socketPath)
+
+	client, err := DialPath(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	if _, err := client.Deploy("web", "", "", false); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+	if mockClient.DeployCallCount != 1 {
+		t.Errorf("Expected 1 deploy call, got %d", mockClient.DeployCallCount)
+	}
+
+	if _, err := client.Destroy("web", "", false); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	if mockClient.DestroyCallCount != 1 {
+		t.Errorf("Expected 1 destroy call, got %d", mockClient.DestroyCallCount)
+	}
+
+	// Errors from the scheduler are returned unchanged
+	_, err = client.Deploy("missing", "", "", false)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected not found error, got %v", err)
+	}
+	_, err = client.Approve("web", "")
+	if err == nil || !strings.Contains(err.Error(), "awaiting approval") {
+		t.Errorf("Expected error without a pending approval, got %v", err)
+	}
+}
+
+func TestDialWithoutDaemon(t *testing.T) {
+	_, err := DialPath(filepath.Join(t.TempDir(), SocketName))
+	if !errors.Is(err, ErrDaemonNotRunning) {
+		t.Errorf("Expected ErrDaemonNotRunning, got %v", err)
+	}
+}
+
+func TestServerRejectsSecondDaemon(t *testing.T) {
+	sched, _, socketPath := setupScheduler(t)
+	startServer(t, sched, socketPath)
+
+	second, err := NewServer(sched, socketPath)
+	if err != nil {
+		t.Fatalf("Failed to create server: %v", err)
+	}
+	if err := second.Start(); err == nil {
+		_ = second.Close()
+		t.Error("Expected error when another daemon is listening")
+	}
+}
+
+func TestServerReplacesStaleSocket(t *testing.T) {
+	sched, _, socketPath := setupScheduler(t)
+
+	// A leftover file from a crashed daemon must not block startup
+	if err := os.WriteFile(socketPath, nil, 0644); err != nil {
+		t.Fatalf("Failed to create stale socket: %v", err)
+	}
+	startServer(t, sched, socketPath)
+
+	client, err := DialPath(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to dial after replacing stale socket: %v", err)
+	}
+	_ = client.Close()
+}
+
+func TestCancelThroughDaemon(t *testing.T) {
+	sched, mockClient, socketPath := setupScheduler(t)
+	startServer(t, sched, socketPath)
+
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	mockClient.DeployFunc = func(ws *workspace.Workspace) error {
+		close(started)
+		<-cancelled
+		return &opentofu.CancelledError{Step: "apply", CompletedSteps: []string{"init", "plan"}}
+	}
+	mockClient.CancelFunc = func(name string) bool {
+		close(cancelled)
+		return true
+	}
+
+	deployClient, err := DialPath(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer func() { _ = deployClient.Close() }()
+
+	result := make(chan error, 1)
+	go func() {
+		_, err := deployClient.Deploy("web", "", "", false)
+		result <- err
+	}()
+	<-started
+
+	cancelClient, err := DialPath(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer func() { _ = cancelClient.Close() }()
+
+	if _, err := cancelClient.Cancel("web"); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+
+	err = <-result
+	if err == nil || !strings.Contains(err.Error(), "cancelled") {
+		t.Errorf("Expected deploy to report cancellation, got %v", err)
+	}
+	if !sched.IsWorkspaceCancelled("web") {
+		t.Error("Expected workspace to be cancelled")
+	}
+
+	// Nothing left to cancel
+	if _, err := cancelClient.Cancel("web"); err == nil {
+		t.Error("Expected error cancelling an idle workspace")
+	}
+}
+
+func TestScheduleThroughDaemon(t *testing.T) {
+	sched, _, socketPath := setupScheduler(t)
+	startServer(t, sched, socketPath)
+
+	client, err := DialPath(socketPath)
+	if err != nil {
+		t.Fatalf("Failed to dial: %v", err)
+	}
+	defer func() { _ = client.Close() }()
+
+	// The time reaches the daemon unchanged
+	at := time.Now().Add(time.Hour).Truncate(time.Minute)
+	message, err := client.Schedule("web", scheduler.OperationDeploy, "", at)
+	if err != nil {
+		t.Fatalf("Schedule failed: %v", err)
+	}
+	if !strings.Contains(message, logging.FormatTime(at)) {
+		t.Errorf("Expected the deploy to be scheduled at %s, got %q", logging.FormatTime(at), message)
+	}
+
+	// The CLI's user is recorded as the actor of the scheduled deploy
+	data, err := os.ReadFile(filepath.Join(os.Getenv("PROVISIONER_STATE_DIR"), "scheduler.json"))
+	if err != nil {
+		t.Fatalf("Failed to read state: %v", err)
+	}
+	var state scheduler.State
+	if err := json.Unmarshal(data, &state); err != nil {
+		t.Fatalf("Failed to parse state: %v", err)
+	}
+	ops := state.Workspaces["web"].ScheduledOperations
+	if len(ops) != 1 || ops[0].RequestedBy != audit.CurrentUser() {
+		t.Errorf("Expected the deploy to be scheduled by %s, got %+v", audit.CurrentUser(), ops)
+	}
+
+	if _, err := client.Schedule("web", scheduler.OperationDeploy, "", time.Now().Add(-time.Hour)); err == nil {
+		t.Error("Expected scheduling in the past to fail")
+	}
+}
@@ -0,0 +1,561 @@
+// Control service of the provisioner daemon, served on its Unix socket. The CLIs change state
+// through these calls so that every change goes through the daemon.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: pkg/control/controlpb/control.proto
+
+package controlpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// WorkspaceRequest identifies a workspace operation
+type WorkspaceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Deployment mode, empty for a normal deploy
+	Mode string `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	// Correlation ID chosen by the CLI, generated by the daemon if empty
+	CorrelationId string `protobuf:"bytes,3,opt,name=correlation_id,json=correlationId,proto3" json:"correlation_id,omitempty"`
+	// Destroy even if the workspace is protected
+	Force bool `protobuf:"varint,4,opt,name=force,proto3" json:"force,omitempty"`
+	// Why the workspace is frozen
+	Reason string `protobuf:"bytes,5,opt,name=reason,proto3" json:"reason,omitempty"`
+	// Deployment history revision to roll back to, 0 for the previous deploy
+	Revision int32 `protobuf:"varint,6,opt,name=revision,proto3" json:"revision,omitempty"`
+	// Deploy even if the estimated cost exceeds max_monthly_cost
+	IgnoreBudget bool `protobuf:"varint,7,opt,name=ignore_budget,json=ignoreBudget,proto3" json:"ignore_budget,omitempty"`
+	// OS user running the CLI, recorded in the audit log
+	User string `protobuf:"bytes,8,opt,name=user,proto3" json:"user,omitempty"`
+	// deploy or destroy, of a one-shot operation
+	Operation string `protobuf:"bytes,9,opt,name=operation,proto3" json:"operation,omitempty"`
+	// When a one-shot operation runs
+	At            *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=at,proto3" json:"at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkspaceRequest) Reset() {
+	*x = WorkspaceRequest{}
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkspaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkspaceRequest) ProtoMessage() {}
+
+func (x *WorkspaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkspaceRequest.ProtoReflect.Descriptor instead.
+func (*WorkspaceRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_control_controlpb_control_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *WorkspaceRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *WorkspaceRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *WorkspaceRequest) GetCorrelationId() string {
+	if x != nil {
+		return x.CorrelationId
+	}
+	return ""
+}
+
+func (x *WorkspaceRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+func (x *WorkspaceRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *WorkspaceRequest) GetRevision() int32 {
+	if x != nil {
+		return x.Revision
+	}
+	return 0
+}
+
+func (x *WorkspaceRequest) GetIgnoreBudget() bool {
+	if x != nil {
+		return x.IgnoreBudget
+	}
+	return false
+}
+
+func (x *WorkspaceRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+func (x *WorkspaceRequest) GetOperation() string {
+	if x != nil {
+		return x.Operation
+	}
+	return ""
+}
+
+func (x *WorkspaceRequest) GetAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.At
+	}
+	return nil
+}
+
+// JobRequest identifies a job operation; an empty workspace means a standalone job
+type JobRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Workspace string                 `protobuf:"bytes,1,opt,name=workspace,proto3" json:"workspace,omitempty"`
+	Job       string                 `protobuf:"bytes,2,opt,name=job,proto3" json:"job,omitempty"`
+	// OS user running the CLI, recorded in the audit log
+	User          string `protobuf:"bytes,3,opt,name=user,proto3" json:"user,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *JobRequest) Reset() {
+	*x = JobRequest{}
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *JobRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*JobRequest) ProtoMessage() {}
+
+func (x *JobRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use JobRequest.ProtoReflect.Descriptor instead.
+func (*JobRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_control_controlpb_control_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *JobRequest) GetWorkspace() string {
+	if x != nil {
+		return x.Workspace
+	}
+	return ""
+}
+
+func (x *JobRequest) GetJob() string {
+	if x != nil {
+		return x.Job
+	}
+	return ""
+}
+
+func (x *JobRequest) GetUser() string {
+	if x != nil {
+		return x.User
+	}
+	return ""
+}
+
+// TemplateRequest identifies a template operation
+type TemplateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// Accept a rewritten branch or moved tag
+	Force         bool `protobuf:"varint,2,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TemplateRequest) Reset() {
+	*x = TemplateRequest{}
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TemplateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TemplateRequest) ProtoMessage() {}
+
+func (x *TemplateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TemplateRequest.ProtoReflect.Descriptor instead.
+func (*TemplateRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_control_controlpb_control_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TemplateRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *TemplateRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+// EnvironmentRequest describes a completed environment switch
+type EnvironmentRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Environment string                 `protobuf:"bytes,1,opt,name=environment,proto3" json:"environment,omitempty"`
+	// Workspace the environment was assigned to before, empty if none
+	From          string `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	To            string `protobuf:"bytes,3,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnvironmentRequest) Reset() {
+	*x = EnvironmentRequest{}
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnvironmentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnvironmentRequest) ProtoMessage() {}
+
+func (x *EnvironmentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnvironmentRequest.ProtoReflect.Descriptor instead.
+func (*EnvironmentRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_control_controlpb_control_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EnvironmentRequest) GetEnvironment() string {
+	if x != nil {
+		return x.Environment
+	}
+	return ""
+}
+
+func (x *EnvironmentRequest) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *EnvironmentRequest) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+// SchedulerRequest is the empty request of operations on the scheduler as a whole
+type SchedulerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SchedulerRequest) Reset() {
+	*x = SchedulerRequest{}
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SchedulerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SchedulerRequest) ProtoMessage() {}
+
+func (x *SchedulerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SchedulerRequest.ProtoReflect.Descriptor instead.
+func (*SchedulerRequest) Descriptor() ([]byte, []int) {
+	return file_pkg_control_controlpb_control_proto_rawDescGZIP(), []int{4}
+}
+
+// Reply is returned by all control operations
+type Reply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Reply) Reset() {
+	*x = Reply{}
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Reply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Reply) ProtoMessage() {}
+
+func (x *Reply) ProtoReflect() protoreflect.Message {
+	mi := &file_pkg_control_controlpb_control_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Reply.ProtoReflect.Descriptor instead.
+func (*Reply) Descriptor() ([]byte, []int) {
+	return file_pkg_control_controlpb_control_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Reply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+var File_pkg_control_controlpb_control_proto protoreflect.FileDescriptor
+
+const file_pkg_control_controlpb_control_proto_rawDesc = "" +
+	"\n" +
+	"#pkg/control/controlpb/control.proto\x12\x16provisioner.control.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xae\x02\n" +
+	"\x10WorkspaceRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\tR\x04mode\x12%\n" +
+	"\x0ecorrelation_id\x18\x03 \x01(\tR\rcorrelationId\x12\x14\n" +
+	"\x05force\x18\x04 \x01(\bR\x05force\x12\x16\n" +
+	"\x06reason\x18\x05 \x01(\tR\x06reason\x12\x1a\n" +
+	"\brevision\x18\x06 \x01(\x05R\brevision\x12#\n" +
+	"\rignore_budget\x18\a \x01(\bR\fignoreBudget\x12\x12\n" +
+	"\x04user\x18\b \x01(\tR\x04user\x12\x1c\n" +
+	"\toperation\x18\t \x01(\tR\toperation\x12*\n" +
+	"\x02at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x02at\"P\n" +
+	"\n" +
+	"JobRequest\x12\x1c\n" +
+	"\tworkspace\x18\x01 \x01(\tR\tworkspace\x12\x10\n" +
+	"\x03job\x18\x02 \x01(\tR\x03job\x12\x12\n" +
+	"\x04user\x18\x03 \x01(\tR\x04user\";\n" +
+	"\x0fTemplateRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05force\x18\x02 \x01(\bR\x05force\"Z\n" +
+	"\x12EnvironmentRequest\x12 \n" +
+	"\venvironment\x18\x01 \x01(\tR\venvironment\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x03 \x01(\tR\x02to\"\x12\n" +
+	"\x10SchedulerRequest\"!\n" +
+	"\x05Reply\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage2\x82\b\n" +
+	"\x10WorkspaceService\x12Q\n" +
+	"\x06Deploy\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12R\n" +
+	"\aUpgrade\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12S\n" +
+	"\bRollback\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12R\n" +
+	"\aDestroy\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12R\n" +
+	"\aApprove\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12Q\n" +
+	"\x06Cancel\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12Q\n" +
+	"\x06Freeze\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12S\n" +
+	"\bUnfreeze\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12P\n" +
+	"\x05Pause\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12Q\n" +
+	"\x06Resume\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12S\n" +
+	"\bSchedule\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply\x12U\n" +
+	"\n" +
+	"Unschedule\x12(.provisioner.control.v1.WorkspaceRequest\x1a\x1d.provisioner.control.v1.Reply2\xa1\x01\n" +
+	"\n" +
+	"JobService\x12H\n" +
+	"\x03Run\x12\".provisioner.control.v1.JobRequest\x1a\x1d.provisioner.control.v1.Reply\x12I\n" +
+	"\x04Kill\x12\".provisioner.control.v1.JobRequest\x1a\x1d.provisioner.control.v1.Reply2c\n" +
+	"\x0fTemplateService\x12P\n" +
+	"\x06Update\x12'.provisioner.control.v1.TemplateRequest\x1a\x1d.provisioner.control.v1.Reply2o\n" +
+	"\x12EnvironmentService\x12Y\n" +
+	"\fRecordSwitch\x12*.provisioner.control.v1.EnvironmentRequest\x1a\x1d.provisioner.control.v1.Reply2\xbd\x01\n" +
+	"\x10SchedulerService\x12S\n" +
+	"\bPauseAll\x12(.provisioner.control.v1.SchedulerRequest\x1a\x1d.provisioner.control.v1.Reply\x12T\n" +
+	"\tResumeAll\x12(.provisioner.control.v1.SchedulerRequest\x1a\x1d.provisioner.control.v1.ReplyB#Z!provisioner/pkg/control/controlpbb\x06proto3"
+
+var (
+	file_pkg_control_controlpb_control_proto_rawDescOnce sync.Once
+	file_pkg_control_controlpb_control_proto_rawDescData []byte
+)
+
+func file_pkg_control_controlpb_control_proto_rawDescGZIP() []byte {
+	file_pkg_control_controlpb_control_proto_rawDescOnce.Do(func() {
+		file_pkg_control_controlpb_control_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pkg_control_controlpb_control_proto_rawDesc), len(file_pkg_control_controlpb_control_proto_rawDesc)))
+	})
+	return file_pkg_control_controlpb_control_proto_rawDescData
+}
+
+var file_pkg_control_controlpb_control_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_pkg_control_controlpb_control_proto_goTypes = []any{
+	(*WorkspaceRequest)(nil),      // 0: provisioner.control.v1.WorkspaceRequest
+	(*JobRequest)(nil),            // 1: provisioner.control.v1.JobRequest
+	(*TemplateRequest)(nil),       // 2: provisioner.control.v1.TemplateRequest
+	(*EnvironmentRequest)(nil),    // 3: provisioner.control.v1.EnvironmentRequest
+	(*SchedulerRequest)(nil),      // 4: provisioner.control.v1.SchedulerRequest
+	(*Reply)(nil),                 // 5: provisioner.control.v1.Reply
+	(*timestamppb.Timestamp)(nil), // 6: google.protobuf.Timestamp
+}
+var file_pkg_control_controlpb_control_proto_depIdxs = []int32{
+	6,  // 0: provisioner.control.v1.WorkspaceRequest.at:type_name -> google.protobuf.Timestamp
+	0,  // 1: provisioner.control.v1.WorkspaceService.Deploy:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 2: provisioner.control.v1.WorkspaceService.Upgrade:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 3: provisioner.control.v1.WorkspaceService.Rollback:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 4: provisioner.control.v1.WorkspaceService.Destroy:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 5: provisioner.control.v1.WorkspaceService.Approve:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 6: provisioner.control.v1.WorkspaceService.Cancel:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 7: provisioner.control.v1.WorkspaceService.Freeze:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 8: provisioner.control.v1.WorkspaceService.Unfreeze:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 9: provisioner.control.v1.WorkspaceService.Pause:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 10: provisioner.control.v1.WorkspaceService.Resume:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 11: provisioner.control.v1.WorkspaceService.Schedule:input_type -> provisioner.control.v1.WorkspaceRequest
+	0,  // 12: provisioner.control.v1.WorkspaceService.Unschedule:input_type -> provisioner.control.v1.WorkspaceRequest
+	1,  // 13: provisioner.control.v1.JobService.Run:input_type -> provisioner.control.v1.JobRequest
+	1,  // 14: provisioner.control.v1.JobService.Kill:input_type -> provisioner.control.v1.JobRequest
+	2,  // 15: provisioner.control.v1.TemplateService.Update:input_type -> provisioner.control.v1.TemplateRequest
+	3,  // 16: provisioner.control.v1.EnvironmentService.RecordSwitch:input_type -> provisioner.control.v1.EnvironmentRequest
+	4,  // 17: provisioner.control.v1.SchedulerService.PauseAll:input_type -> provisioner.control.v1.SchedulerRequest
+	4,  // 18: provisioner.control.v1.SchedulerService.ResumeAll:input_type -> provisioner.control.v1.SchedulerRequest
+	5,  // 19: provisioner.control.v1.WorkspaceService.Deploy:output_type -> provisioner.control.v1.Reply
+	5,  // 20: provisioner.control.v1.WorkspaceService.Upgrade:output_type -> provisioner.control.v1.Reply
+	5,  // 21: provisioner.control.v1.WorkspaceService.Rollback:output_type -> provisioner.control.v1.Reply
+	5,  // 22: provisioner.control.v1.WorkspaceService.Destroy:output_type -> provisioner.control.v1.Reply
+	5,  // 23: provisioner.control.v1.WorkspaceService.Approve:output_type -> provisioner.control.v1.Reply
+	5,  // 24: provisioner.control.v1.WorkspaceService.Cancel:output_type -> provisioner.control.v1.Reply
+	5,  // 25: provisioner.control.v1.WorkspaceService.Freeze:output_type -> provisioner.control.v1.Reply
+	5,  // 26: provisioner.control.v1.WorkspaceService.Unfreeze:output_type -> provisioner.control.v1.Reply
+	5,  // 27: provisioner.control.v1.WorkspaceService.Pause:output_type -> provisioner.control.v1.Reply
+	5,  // 28: provisioner.control.v1.WorkspaceService.Resume:output_type -> provisioner.control.v1.Reply
+	5,  // 29: provisioner.control.v1.WorkspaceService.Schedule:output_type -> provisioner.control.v1.Reply
+	5,  // 30: provisioner.control.v1.WorkspaceService.Unschedule:output_type -> provisioner.control.v1.Reply
+	5,  // 31: provisioner.control.v1.JobService.Run:output_type -> provisioner.control.v1.Reply
+	5,  // 32: provisioner.control.v1.JobService.Kill:output_type -> provisioner.control.v1.Reply
+	5,  // 33: provisioner.control.v1.TemplateService.Update:output_type -> provisioner.control.v1.Reply
+	5,  // 34: provisioner.control.v1.EnvironmentService.RecordSwitch:output_type -> provisioner.control.v1.Reply
+	5,  // 35: provisioner.control.v1.SchedulerService.PauseAll:output_type -> provisioner.control.v1.Reply
+	5,  // 36: provisioner.control.v1.SchedulerService.ResumeAll:output_type -> provisioner.control.v1.Reply
+	19, // [19:37] is the sub-list for method output_type
+	1,  // [1:19] is the sub-list for method input_type
+	1,  // [1:1] is the sub-list for extension type_name
+	1,  // [1:1] is the sub-list for extension extendee
+	0,  // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_pkg_control_controlpb_control_proto_init() }
+func file_pkg_control_controlpb_control_proto_init() {
+	if File_pkg_control_controlpb_control_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pkg_control_controlpb_control_proto_rawDesc), len(file_pkg_control_controlpb_control_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   5,
+		},
+		GoTypes:           file_pkg_control_controlpb_control_proto_goTypes,
+		DependencyIndexes: file_pkg_control_controlpb_control_proto_depIdxs,
+		MessageInfos:      file_pkg_control_controlpb_control_proto_msgTypes,
+	}.Build()
+	File_pkg_control_controlpb_control_proto = out.File
+	file_pkg_control_controlpb_control_proto_goTypes = nil
+	file_pkg_control_controlpb_control_proto_depIdxs = nil
+}
@@ -0,0 +1,468 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/control/controlpb"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/scheduler"
+)
+
+// Server exposes scheduler operations to the CLIs as gRPC services on a Unix socket so that
+// state changes go through the daemon instead of being written by the CLI
+type Server struct {
+	socketPath string
+	listener   net.Listener
+	grpcServer *grpc.Server
+}
+
+// WorkspaceService handles workspace operations
+type WorkspaceService struct {
+	controlpb.UnimplementedWorkspaceServiceServer
+	sched *scheduler.Scheduler
+}
+
+// JobService handles workspace and standalone job operations
+type JobService struct {
+	controlpb.UnimplementedJobServiceServer
+	sched *scheduler.Scheduler
+}
+
+// TemplateService handles template operations
+type TemplateService struct {
+	controlpb.UnimplementedTemplateServiceServer
+	sched *scheduler.Scheduler
+}
+
+// EnvironmentService records environment switches made by environmentctl
+type EnvironmentService struct {
+	controlpb.UnimplementedEnvironmentServiceServer
+	sched *scheduler.Scheduler
+}
+
+// SchedulerService handles operations on all workspaces at once
+type SchedulerService struct {
+	controlpb.UnimplementedSchedulerServiceServer
+	sched *scheduler.Scheduler
+}
+
+// NewServer creates a control server for the scheduler
+func NewServer(sched *scheduler.Scheduler, socketPath string) (*Server, error) {
+	grpcServer := grpc.NewServer()
+	controlpb.RegisterWorkspaceServiceServer(grpcServer, &WorkspaceService{sched: sched})
+	controlpb.RegisterJobServiceServer(grpcServer, &JobService{sched: sched})
+	controlpb.RegisterTemplateServiceServer(grpcServer, &TemplateService{sched: sched})
+	controlpb.RegisterEnvironmentServiceServer(grpcServer, &EnvironmentService{sched: sched})
+	controlpb.RegisterSchedulerServiceServer(grpcServer, &SchedulerService{sched: sched})
+
+	return &Server{socketPath: socketPath, grpcServer: grpcServer}, nil
+}
+
+// Start listens on the control socket and serves requests in the background
+func (s *Server) Start() error {
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0755); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	// Remove a stale socket left behind by a previous daemon
+	if conn, err := net.Dial("unix", s.socketPath); err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("another daemon is already listening on %s", s.socketPath)
+	}
+	_ = os.Remove(s.socketPath)
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+
+	// Only the provisioner user and group may control the daemon
+	if err := os.Chmod(s.socketPath, 0660); err != nil {
+		_ = listener.Close()
+		return fmt.Errorf("failed to set control socket permissions: %w", err)
+	}
+
+	s.listener = listener
+	go func() { _ = s.grpcServer.Serve(listener) }()
+
+	logging.LogSystemd("Control socket listening on %s", s.socketPath)
+	return nil
+}
+
+// Close stops the server, ending calls in progress, and removes the socket
+func (s *Server) Close() error {
+	if s.listener == nil {
+		return nil
+	}
+	s.grpcServer.Stop()
+	_ = os.Remove(s.socketPath)
+	return nil
+}
+
+// checkReady rejects requests that arrive before the scheduler has initialized, or at a standby
+func checkReady(sched *scheduler.Scheduler) error {
+	if leader, standby := sched.Standby(); standby {
+		return fmt.Errorf("this daemon is standing by; run the command on the host of the leader (%s)", leader)
+	}
+	if !sched.IsReady() {
+		return fmt.Errorf("daemon is still starting, try again shortly")
+	}
+	return nil
+}
+
+// logAccess records a control socket request in the daemon log
+func logAccess(method, target, correlationID string) {
+	if correlationID == "" {
+		logging.LogSystemd("ACCESS control %s %s", method, target)
+		return
+	}
+	logging.LogSystemd("ACCESS control %s %s correlation_id=%s", method, target, correlationID)
+}
+
+// requestCorrelationID returns the CLI's correlation ID, generating one for older clients
+func requestCorrelationID(req *controlpb.WorkspaceRequest) string {
+	if req.CorrelationId != "" {
+		return req.CorrelationId
+	}
+	return logging.NewCorrelationID(time.Now())
+}
+
+// runOperation runs a workspace operation under the CLI's correlation ID, recording the CLI's
+// user as its trigger in the audit log
+func (ws *WorkspaceService) runOperation(req *controlpb.WorkspaceRequest, correlationID string, fn func() error) error {
+	trigger := audit.Trigger{Source: audit.SourceCLI, Actor: req.User}
+	return ws.sched.WithTrigger(req.Name, trigger, func() error {
+		return ws.sched.WithCorrelationID(req.Name, correlationID, fn)
+	})
+}
+
+// Deploy deploys a workspace, optionally in a specific mode
+func (ws *WorkspaceService) Deploy(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	correlationID := requestCorrelationID(req)
+	logAccess("WorkspaceService.Deploy", workspaceTarget(req), correlationID)
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.runOperation(req, correlationID, func() error {
+		switch {
+		case req.Mode != "" && req.IgnoreBudget:
+			return ws.sched.ManualDeployInModeIgnoringBudget(req.Name, req.Mode)
+		case req.Mode != "":
+			return ws.sched.ManualDeployInMode(req.Name, req.Mode)
+		case req.IgnoreBudget:
+			return ws.sched.ManualDeployIgnoringBudget(req.Name)
+		}
+		return ws.sched.ManualDeploy(req.Name)
+	}); err != nil {
+		return nil, err
+	}
+	if ws.sched.IsWorkspaceCancelled(req.Name) {
+		return nil, fmt.Errorf("deployment of workspace '%s' was cancelled (correlation ID %s)", req.Name, correlationID)
+	}
+
+	if req.Mode != "" {
+		return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' deployed in mode '%s' (correlation ID %s)", req.Name, req.Mode, correlationID)}, nil
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' deployed (correlation ID %s)", req.Name, correlationID)}, nil
+}
+
+// Upgrade redeploys a workspace whose template changed since its last deploy
+func (ws *WorkspaceService) Upgrade(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	correlationID := requestCorrelationID(req)
+	logAccess("WorkspaceService.Upgrade", workspaceTarget(req), correlationID)
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.runOperation(req, correlationID, func() error {
+		return ws.sched.UpgradeWorkspace(req.Name)
+	}); err != nil {
+		return nil, err
+	}
+	if ws.sched.IsWorkspaceCancelled(req.Name) {
+		return nil, fmt.Errorf("upgrade of workspace '%s' was cancelled (correlation ID %s)", req.Name, correlationID)
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' upgraded (correlation ID %s)", req.Name, correlationID)}, nil
+}
+
+// Rollback redeploys a workspace with the files and variables of an earlier deploy
+func (ws *WorkspaceService) Rollback(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	correlationID := requestCorrelationID(req)
+	logAccess("WorkspaceService.Rollback", fmt.Sprintf("%s revision=%d", workspaceTarget(req), req.Revision), correlationID)
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.runOperation(req, correlationID, func() error {
+		return ws.sched.RollbackWorkspace(req.Name, int(req.Revision))
+	}); err != nil {
+		return nil, err
+	}
+	if ws.sched.IsWorkspaceCancelled(req.Name) {
+		return nil, fmt.Errorf("rollback of workspace '%s' was cancelled (correlation ID %s)", req.Name, correlationID)
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' rolled back (correlation ID %s)", req.Name, correlationID)}, nil
+}
+
+// Destroy destroys a workspace
+func (ws *WorkspaceService) Destroy(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	correlationID := requestCorrelationID(req)
+	logAccess("WorkspaceService.Destroy", workspaceTarget(req), correlationID)
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.runOperation(req, correlationID, func() error {
+		if req.Force {
+			return ws.sched.ManualDestroyForce(req.Name)
+		}
+		return ws.sched.ManualDestroy(req.Name)
+	}); err != nil {
+		return nil, err
+	}
+	if ws.sched.IsWorkspaceCancelled(req.Name) {
+		return nil, fmt.Errorf("destruction of workspace '%s' was cancelled (correlation ID %s)", req.Name, correlationID)
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' destroyed (correlation ID %s)", req.Name, correlationID)}, nil
+}
+
+// Approve runs the scheduled deploy or destroy awaiting a workspace's approval
+func (ws *WorkspaceService) Approve(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	correlationID := requestCorrelationID(req)
+	logAccess("WorkspaceService.Approve", workspaceTarget(req), correlationID)
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.runOperation(req, correlationID, func() error {
+		return ws.sched.ApproveWorkspace(req.Name)
+	}); err != nil {
+		return nil, err
+	}
+	if ws.sched.IsWorkspaceCancelled(req.Name) {
+		return nil, fmt.Errorf("approved operation of workspace '%s' was cancelled (correlation ID %s)", req.Name, correlationID)
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Approved operation of workspace '%s' finished (correlation ID %s)", req.Name, correlationID)}, nil
+}
+
+// Cancel cancels a workspace's in-flight deploy or destroy
+func (ws *WorkspaceService) Cancel(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	logAccess("WorkspaceService.Cancel", workspaceTarget(req), logging.CorrelationID(req.Name))
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.sched.CancelWorkspace(req.Name); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Cancellation requested for workspace '%s'", req.Name)}, nil
+}
+
+// Freeze pins a workspace to its current deployment
+func (ws *WorkspaceService) Freeze(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	logAccess("WorkspaceService.Freeze", workspaceTarget(req), "")
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.sched.FreezeWorkspace(req.Name, req.Reason); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' frozen, automatic operations are skipped until it is unfrozen", req.Name)}, nil
+}
+
+// Unfreeze resumes a frozen workspace's automatic operations
+func (ws *WorkspaceService) Unfreeze(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	logAccess("WorkspaceService.Unfreeze", workspaceTarget(req), "")
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.sched.UnfreezeWorkspace(req.Name); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' unfrozen", req.Name)}, nil
+}
+
+// Pause skips a workspace's scheduled operations until it is resumed
+func (ws *WorkspaceService) Pause(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	logAccess("WorkspaceService.Pause", workspaceTarget(req), "")
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.sched.PauseWorkspace(req.Name); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' paused, scheduled operations are skipped until it is resumed", req.Name)}, nil
+}
+
+// Resume resumes a paused workspace's scheduled operations
+func (ws *WorkspaceService) Resume(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	logAccess("WorkspaceService.Resume", workspaceTarget(req), "")
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.sched.ResumeWorkspace(req.Name); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Workspace '%s' resumed", req.Name)}, nil
+}
+
+// Schedule schedules a one-shot deploy or destroy of a workspace
+func (ws *WorkspaceService) Schedule(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	logAccess("WorkspaceService.Schedule", workspaceTarget(req), "")
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	at := req.At.AsTime().Local()
+	if err := ws.sched.ScheduleOperation(req.Name, req.Operation, req.Mode, at, req.User); err != nil {
+		return nil, err
+	}
+	operation := req.Operation
+	if req.Mode != "" {
+		operation += " in mode " + req.Mode
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Scheduled %s of workspace '%s' at %s", operation, req.Name, logging.FormatTime(at))}, nil
+}
+
+// Unschedule drops a workspace's one-shot operations, only those of req.Operation if set
+func (ws *WorkspaceService) Unschedule(ctx context.Context, req *controlpb.WorkspaceRequest) (*controlpb.Reply, error) {
+	logAccess("WorkspaceService.Unschedule", workspaceTarget(req), "")
+	if err := checkReady(ws.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ws.sched.UnscheduleOperation(req.Name, req.Operation); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Scheduled operations of workspace '%s' dropped", req.Name)}, nil
+}
+
+// Run executes a job immediately and waits for it to finish
+func (js *JobService) Run(ctx context.Context, req *controlpb.JobRequest) (*controlpb.Reply, error) {
+	logAccess("JobService.Run", jobTarget(req), "")
+	if err := checkReady(js.sched); err != nil {
+		return nil, err
+	}
+
+	// The job manager records the CLI's user as the trigger in the audit log
+	key := audit.JobKey(req.Workspace, req.Job)
+	audit.SetTrigger(key, audit.Trigger{Source: audit.SourceCLI, Actor: req.User})
+	defer audit.ClearTrigger(key)
+
+	if req.Workspace == "" {
+		if err := js.sched.GetStandaloneJobManager().ExecuteStandaloneJob(req.Job); err != nil {
+			return nil, fmt.Errorf("failed to execute standalone job: %w", err)
+		}
+	} else if err := js.sched.ManualExecuteJob(req.Workspace, req.Job); err != nil {
+		return nil, fmt.Errorf("failed to execute job: %w", err)
+	}
+
+	return &controlpb.Reply{Message: fmt.Sprintf("Job '%s' completed successfully", req.Job)}, nil
+}
+
+// Kill stops a running job
+func (js *JobService) Kill(ctx context.Context, req *controlpb.JobRequest) (*controlpb.Reply, error) {
+	logAccess("JobService.Kill", jobTarget(req), "")
+	if err := checkReady(js.sched); err != nil {
+		return nil, err
+	}
+
+	// The job manager records the CLI's user as the trigger in the audit log
+	key := audit.JobKey(req.Workspace, req.Job)
+	audit.SetTrigger(key, audit.Trigger{Source: audit.SourceCLI, Actor: req.User})
+	defer audit.ClearTrigger(key)
+
+	if req.Workspace == "" {
+		if err := js.sched.GetStandaloneJobManager().KillStandaloneJob(req.Job); err != nil {
+			return nil, fmt.Errorf("failed to kill standalone job: %w", err)
+		}
+	} else if err := js.sched.KillJob(req.Workspace, req.Job); err != nil {
+		return nil, fmt.Errorf("failed to kill job: %w", err)
+	}
+
+	return &controlpb.Reply{Message: fmt.Sprintf("Job '%s' killed successfully", req.Job)}, nil
+}
+
+// Update refreshes a template from its source
+func (ts *TemplateService) Update(ctx context.Context, req *controlpb.TemplateRequest) (*controlpb.Reply, error) {
+	logAccess("TemplateService.Update", "template="+req.Name, "")
+	result, err := ts.sched.GetTemplateManager().UpdateTemplate(req.Name, req.Force)
+	if err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: result.String()}, nil
+}
+
+// RecordSwitch records a completed environment switch in the history of the workspaces involved
+func (es *EnvironmentService) RecordSwitch(ctx context.Context, req *controlpb.EnvironmentRequest) (*controlpb.Reply, error) {
+	logAccess("EnvironmentService.RecordSwitch", fmt.Sprintf("environment=%s workspace=%s", req.Environment, req.To), "")
+	if err := checkReady(es.sched); err != nil {
+		return nil, err
+	}
+
+	if err := es.sched.RecordEnvironmentSwitch(req.Environment, req.From, req.To); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: fmt.Sprintf("Switch of environment '%s' to workspace '%s' recorded", req.Environment, req.To)}, nil
+}
+
+// PauseAll skips the scheduled operations of all workspaces until ResumeAll
+func (ss *SchedulerService) PauseAll(ctx context.Context, req *controlpb.SchedulerRequest) (*controlpb.Reply, error) {
+	logAccess("SchedulerService.PauseAll", "all workspaces", "")
+	if err := checkReady(ss.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ss.sched.PauseAll(); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: "Scheduling paused for all workspaces, scheduled operations are skipped until resume-all"}, nil
+}
+
+// ResumeAll resumes the scheduled operations of all workspaces not paused on their own
+func (ss *SchedulerService) ResumeAll(ctx context.Context, req *controlpb.SchedulerRequest) (*controlpb.Reply, error) {
+	logAccess("SchedulerService.ResumeAll", "all workspaces", "")
+	if err := checkReady(ss.sched); err != nil {
+		return nil, err
+	}
+
+	if err := ss.sched.ResumeAll(); err != nil {
+		return nil, err
+	}
+	return &controlpb.Reply{Message: "Scheduling resumed for all workspaces"}, nil
+}
+
+// jobTarget describes a job request for the access log
+func jobTarget(req *controlpb.JobRequest) string {
+	target := "job=" + req.Job
+	if req.Workspace != "" {
+		target = fmt.Sprintf("workspace=%s job=%s", req.Workspace, req.Job)
+	}
+	return withUser(target, req.User)
+}
+
+// workspaceTarget describes a workspace request for the access log
+func workspaceTarget(req *controlpb.WorkspaceRequest) string {
+	return withUser("workspace="+req.Name, req.User)
+}
+
+// withUser adds the CLI's user to an access log target; older clients don't send it
+func withUser(target, user string) string {
+	if user == "" {
+		return target
+	}
+	return target + " user=" + user
+}
@@ -0,0 +1,475 @@
+// Package cron parses CRON expressions and computes the times they match. Workspace and job
+// schedules share it.
+package cron
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed CRON expression. A nil field matches every value.
+type Schedule struct {
+	Second     []int // Only used when the expression has a seconds field
+	Minute     []int // Support ranges and lists
+	Hour       []int
+	Day        []int
+	Month      []int
+	DOW        []int          // Day of week
+	HasSeconds bool           // Expression has 6 fields with a leading seconds field
+	Location   *time.Location // Timezone from a CRON_TZ= prefix, nil to use the caller's time zone
+	Holidays   string         // Holiday calendar from a SKIP_HOLIDAYS= prefix whose days never match
+	Special    string         // Special schedules like "@deployment", "@reboot"
+}
+
+// cronAliases map shorthand schedules to standard 5-field expressions
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var cronMonthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+var cronDayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// Parse parses a 5-field (minute precision) or 6-field (leading seconds) CRON expression.
+// Expressions may use @hourly/@daily style aliases, a "CRON_TZ=Zone " prefix and a
+// "SKIP_HOLIDAYS=calendar " prefix.
+func Parse(cronExpr string) (*Schedule, error) {
+	cronExpr = strings.TrimSpace(cronExpr)
+
+	var location *time.Location
+	var holidays string
+	for {
+		prefix, rest, _ := strings.Cut(cronExpr, " ")
+		key, value, found := strings.Cut(prefix, "=")
+		if !found {
+			break
+		}
+		switch key {
+		case "CRON_TZ", "TZ":
+			// Per-expression timezone override
+			loc, err := time.LoadLocation(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timezone '%s': %w", value, err)
+			}
+			location = loc
+		case "SKIP_HOLIDAYS":
+			if value == "" {
+				return nil, fmt.Errorf("SKIP_HOLIDAYS requires a calendar name")
+			}
+			holidays = value
+		default:
+			return nil, fmt.Errorf("unknown schedule prefix '%s'", key)
+		}
+		cronExpr = strings.TrimSpace(rest)
+	}
+
+	// Expand aliases, anything else starting with @ is an event-based schedule
+	if alias, ok := cronAliases[strings.ToLower(cronExpr)]; ok {
+		cronExpr = alias
+	} else if strings.HasPrefix(cronExpr, "@") {
+		if holidays != "" {
+			return nil, fmt.Errorf("event-based schedule %s can't skip holidays", cronExpr)
+		}
+		return parseSpecialSchedule(cronExpr)
+	}
+
+	fields := strings.Fields(cronExpr)
+	if len(fields) != 5 && len(fields) != 6 {
+		return nil, fmt.Errorf("invalid cron expression: expected 5 or 6 fields, got %d", len(fields))
+	}
+
+	schedule := &Schedule{Location: location, Holidays: holidays}
+	var err error
+
+	// Parse optional seconds (0-59)
+	if len(fields) == 6 {
+		schedule.HasSeconds = true
+		schedule.Second, err = parseField(fields[0], 0, 59, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid second field '%s': %w", fields[0], err)
+		}
+		fields = fields[1:]
+	}
+
+	// Parse minute (0-59)
+	schedule.Minute, err = parseField(fields[0], 0, 59, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minute field '%s': %w", fields[0], err)
+	}
+
+	// Parse hour (0-23)
+	schedule.Hour, err = parseField(fields[1], 0, 23, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hour field '%s': %w", fields[1], err)
+	}
+
+	// Parse day (1-31)
+	schedule.Day, err = parseField(fields[2], 1, 31, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid day field '%s': %w", fields[2], err)
+	}
+
+	// Parse month (1-12)
+	schedule.Month, err = parseField(fields[3], 1, 12, cronMonthNames)
+	if err != nil {
+		return nil, fmt.Errorf("invalid month field '%s': %w", fields[3], err)
+	}
+
+	// Parse day of week (0-6, Sunday=0)
+	schedule.DOW, err = parseField(fields[4], 0, 6, cronDayNames)
+	if err != nil {
+		return nil, fmt.Errorf("invalid day of week field '%s': %w", fields[4], err)
+	}
+
+	return schedule, nil
+}
+
+// parseSpecialSchedule handles special event-based schedules
+func parseSpecialSchedule(cronExpr string) (*Schedule, error) {
+	validSpecials := map[string]bool{
+		"@deployment":        true,
+		"@deployment-failed": true,
+		"@destroy":           true,
+		"@destroy-failed":    true,
+		"@reboot":            true,
+	}
+
+	if !validSpecials[cronExpr] {
+		return nil, fmt.Errorf("unsupported special schedule: %s", cronExpr)
+	}
+
+	return &Schedule{
+		Special: cronExpr,
+	}, nil
+}
+
+// parseField parses a CRON field supporting *, ranges (1-5), lists (1,3,5), steps (*/2, 1-10/3, 5/15)
+// and, where names is set, month or weekday names (jan, mon-fri)
+func parseField(field string, min, max int, names map[string]int) ([]int, error) {
+	if field == "*" {
+		// Return nil to indicate "match all"
+		return nil, nil
+	}
+
+	var values []int
+
+	// Handle comma-separated lists (1,3,5)
+	for _, part := range strings.Split(field, ",") {
+		base, stepStr, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid interval: %s", part)
+			}
+			if step <= 0 {
+				return nil, fmt.Errorf("interval must be positive: %d", step)
+			}
+		}
+
+		start, end := min, max
+		switch {
+		case base == "*":
+			// Full range, usually with a step (*/2)
+		case strings.Contains(base, "-"):
+			// Handle ranges (1-5)
+			rangeParts := strings.Split(base, "-")
+			if len(rangeParts) != 2 {
+				return nil, fmt.Errorf("invalid range format: %s", part)
+			}
+			var err error
+			if start, err = parseFieldValue(rangeParts[0], names); err != nil {
+				return nil, fmt.Errorf("invalid range start: %s", rangeParts[0])
+			}
+			if end, err = parseFieldValue(rangeParts[1], names); err != nil {
+				return nil, fmt.Errorf("invalid range end: %s", rangeParts[1])
+			}
+			if start < min || start > max || end < min || end > max {
+				return nil, fmt.Errorf("range values out of bounds [%d-%d]: %d-%d", min, max, start, end)
+			}
+			if start > end {
+				return nil, fmt.Errorf("invalid range: start > end: %d-%d", start, end)
+			}
+		default:
+			// Handle single values, or a start point when stepped (5/15)
+			value, err := parseFieldValue(base, names)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value: %s", base)
+			}
+			if value < min || value > max {
+				return nil, fmt.Errorf("value out of range [%d-%d]: %d", min, max, value)
+			}
+			start = value
+			if !hasStep {
+				end = value
+			}
+		}
+
+		for i := start; i <= end; i += step {
+			if !slices.Contains(values, i) {
+				values = append(values, i)
+			}
+		}
+	}
+
+	slices.Sort(values)
+	return values, nil
+}
+
+// parseFieldValue parses a number or, when names is set, a case-insensitive name
+func parseFieldValue(value string, names map[string]int) (int, error) {
+	if n, ok := names[strings.ToLower(value)]; ok {
+		return n, nil
+	}
+	return strconv.Atoi(value)
+}
+
+// ShouldRun reports whether the schedule matches the given time.
+// Seconds are only compared for 6-field expressions.
+func (c *Schedule) ShouldRun(now time.Time) bool {
+	// Special schedules are event-based, not time-based
+	if c.Special != "" {
+		return false // Special schedules don't run on time, only on events
+	}
+
+	if c.Location != nil {
+		now = now.In(c.Location)
+	}
+
+	if c.HasSeconds && !matchField(c.Second, now.Second()) {
+		return false
+	}
+
+	return matchField(c.Minute, now.Minute()) && matchField(c.Hour, now.Hour()) && c.matchesDay(now)
+}
+
+// matchesDay reports whether the schedule runs on the date of t.
+// Like standard cron, when both day of month and day of week are restricted either may match.
+// Holidays of the schedule's calendar never match.
+func (c *Schedule) matchesDay(t time.Time) bool {
+	if !matchField(c.Month, int(t.Month())) {
+		return false
+	}
+	if c.Holidays != "" && IsHoliday(c.Holidays, t) {
+		return false
+	}
+
+	dayMatch := matchField(c.Day, t.Day())
+	dowMatch := matchField(c.DOW, int(t.Weekday()))
+	if c.Day != nil && c.DOW != nil {
+		return dayMatch || dowMatch
+	}
+	return dayMatch && dowMatch
+}
+
+// maxCronSearchDays bounds NextRun/PrevRun for schedules that rarely or never match (e.g. Feb 30)
+const maxCronSearchDays = 5 * 366
+
+// LastRunToday returns the most recent time at or before now, on the same calendar day,
+// that matches the schedule, or nil if it has not matched yet today.
+// The day is taken in the schedule's CRON_TZ location if set, otherwise in now's location.
+func (c *Schedule) LastRunToday(now time.Time) *time.Time {
+	if c.Location != nil {
+		now = now.In(c.Location)
+	}
+
+	prev := c.PrevRun(now.Truncate(time.Second).Add(time.Second))
+	if prev == nil {
+		return nil
+	}
+
+	year, month, day := now.Date()
+	prevYear, prevMonth, prevDay := prev.Date()
+	if year != prevYear || month != prevMonth || day != prevDay {
+		return nil
+	}
+	return prev
+}
+
+// NextRun returns the first time strictly after the given time that matches the schedule,
+// or nil for event-based schedules and schedules that never match
+func (c *Schedule) NextRun(after time.Time) *time.Time {
+	if c.Special != "" {
+		return nil
+	}
+
+	if c.Location != nil {
+		after = after.In(c.Location)
+	}
+
+	// Start at the next whole second (or minute for 5-field schedules)
+	unit := c.resolution()
+	t := after.Truncate(unit).Add(unit)
+
+	hour, minute, second := t.Hour(), t.Minute(), t.Second()
+	for i := 0; i < maxCronSearchDays; i++ {
+		if c.matchesDay(t) {
+			if h, m, sec, ok := c.firstTimeFrom(hour, minute, second); ok {
+				match := time.Date(t.Year(), t.Month(), t.Day(), h, m, sec, 0, t.Location())
+				return &match
+			}
+		}
+
+		// Continue from midnight of the following day
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		hour, minute, second = 0, 0, 0
+	}
+
+	return nil
+}
+
+// PrevRun returns the last time strictly before the given time that matches the schedule,
+// or nil for event-based schedules and schedules that never match
+func (c *Schedule) PrevRun(before time.Time) *time.Time {
+	if c.Special != "" {
+		return nil
+	}
+
+	if c.Location != nil {
+		before = before.In(c.Location)
+	}
+
+	// Start at the latest whole second (or minute for 5-field schedules) before the given time
+	t := before.Add(-time.Nanosecond).Truncate(c.resolution())
+
+	hour, minute, second := t.Hour(), t.Minute(), t.Second()
+	for i := 0; i < maxCronSearchDays; i++ {
+		if c.matchesDay(t) {
+			if h, m, sec, ok := c.lastTimeFrom(hour, minute, second); ok {
+				match := time.Date(t.Year(), t.Month(), t.Day(), h, m, sec, 0, t.Location())
+				return &match
+			}
+		}
+
+		// Continue from the end of the previous day
+		t = time.Date(t.Year(), t.Month(), t.Day()-1, 23, 59, 59, 0, t.Location())
+		hour, minute, second = 23, 59, 59
+	}
+
+	return nil
+}
+
+// resolution is the smallest time step the schedule can match on
+func (c *Schedule) resolution() time.Duration {
+	if c.HasSeconds {
+		return time.Second
+	}
+	return time.Minute
+}
+
+// firstTimeFrom finds the earliest matching time of day at or after hour:minute:second
+func (c *Schedule) firstTimeFrom(hour, minute, second int) (int, int, int, bool) {
+	for h := hour; h <= 23; h++ {
+		if !matchField(c.Hour, h) {
+			continue
+		}
+
+		startMinute := 0
+		if h == hour {
+			startMinute = minute
+		}
+		for m := startMinute; m <= 59; m++ {
+			if !matchField(c.Minute, m) {
+				continue
+			}
+
+			startSecond := 0
+			if h == hour && m == minute {
+				startSecond = second
+			}
+			if sec, ok := c.firstSecondFrom(startSecond); ok {
+				return h, m, sec, true
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// lastTimeFrom finds the latest matching time of day at or before hour:minute:second
+func (c *Schedule) lastTimeFrom(hour, minute, second int) (int, int, int, bool) {
+	for h := hour; h >= 0; h-- {
+		if !matchField(c.Hour, h) {
+			continue
+		}
+
+		startMinute := 59
+		if h == hour {
+			startMinute = minute
+		}
+		for m := startMinute; m >= 0; m-- {
+			if !matchField(c.Minute, m) {
+				continue
+			}
+
+			startSecond := 59
+			if h == hour && m == minute {
+				startSecond = second
+			}
+			if sec, ok := c.lastSecondFrom(startSecond); ok {
+				return h, m, sec, true
+			}
+		}
+	}
+	return 0, 0, 0, false
+}
+
+// firstSecondFrom finds the earliest matching second at or after from
+func (c *Schedule) firstSecondFrom(from int) (int, bool) {
+	// 5-field schedules fire at the start of the minute
+	if !c.HasSeconds {
+		return 0, from == 0
+	}
+
+	for second := from; second <= 59; second++ {
+		if matchField(c.Second, second) {
+			return second, true
+		}
+	}
+	return 0, false
+}
+
+// lastSecondFrom finds the latest matching second at or before from
+func (c *Schedule) lastSecondFrom(from int) (int, bool) {
+	// 5-field schedules fire at the start of the minute
+	if !c.HasSeconds {
+		return 0, true
+	}
+
+	for second := from; second >= 0; second-- {
+		if matchField(c.Second, second) {
+			return second, true
+		}
+	}
+	return 0, false
+}
+
+// matchField reports whether value is allowed by a parsed field (nil matches all)
+func matchField(values []int, value int) bool {
+	return values == nil || slices.Contains(values, value)
+}
+
+// IsSpecialSchedule returns true if this is an event-based schedule
+func (c *Schedule) IsSpecialSchedule() bool {
+	return c.Special != ""
+}
+
+// GetSpecialSchedule returns the special schedule type
+func (c *Schedule) GetSpecialSchedule() string {
+	return c.Special
+}
@@ -0,0 +1,464 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		cronExpr    string
+		expectError bool
+	}{
+		{"valid basic", "0 9 * * 1", false},
+		{"valid interval", "*/5 * * * *", false},
+		{"valid complex", "15 14 1 * *", false},
+		{"invalid fields", "0 9 * *", true},
+		{"invalid minute", "60 9 * * *", true},
+		{"invalid hour", "0 25 * * *", true},
+		{"invalid day", "0 9 32 * *", true},
+		{"invalid month", "0 9 * 13 *", true},
+		{"invalid dow", "0 9 * * 7", true},
+		{"invalid interval", "*/abc * * * *", true},
+		{"valid range", "0 9 * * 1-5", false},
+		{"valid list", "0 9,17 * * 1,3,5", false},
+		{"mixed individual values", "0 9 * * 1,2,4,5", false},
+		{"range plus individual", "0 9 * * 1-3,5", false},
+		{"individual plus range", "0 9 * * 1,3-5", false},
+		{"multiple ranges", "0 9 * * 1-2,4-5", false},
+		{"complex mixed", "0 9 * * 1,3,5-6", false},
+		{"invalid range format", "0 9 * * 1-5-7", true},
+		{"invalid range order", "0 9 * * 5-1", true},
+		{"range out of bounds", "0 9 * * 1-8", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.cronExpr)
+			if tt.expectError && err == nil {
+				t.Errorf("expected error for %s but got none", tt.cronExpr)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error for %s: %v", tt.cronExpr, err)
+			}
+		})
+	}
+}
+
+func TestCronShouldRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		cronExpr string
+		testTime time.Time
+		expected bool
+	}{
+		{
+			name:     "exact match",
+			cronExpr: "30 14 17 6 1",
+			testTime: time.Date(2024, 6, 17, 14, 30, 0, 0, time.UTC), // Monday (day=17, month=6, dow=1)
+			expected: true,
+		},
+		{
+			name:     "wrong minute",
+			cronExpr: "30 14 * * *",
+			testTime: time.Date(2024, 6, 15, 14, 29, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "interval match",
+			cronExpr: "*/5 * * * *",
+			testTime: time.Date(2024, 6, 15, 14, 25, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "interval no match",
+			cronExpr: "*/5 * * * *",
+			testTime: time.Date(2024, 6, 15, 14, 23, 0, 0, time.UTC),
+			expected: false,
+		},
+		{
+			name:     "wildcard hour",
+			cronExpr: "0 * * * *",
+			testTime: time.Date(2024, 6, 15, 14, 0, 0, 0, time.UTC),
+			expected: true,
+		},
+		{
+			name:     "all wildcards",
+			cronExpr: "* * * * *",
+			testTime: time.Date(2024, 6, 15, 14, 23, 0, 0, time.UTC),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.cronExpr)
+			if err != nil {
+				t.Fatalf("failed to parse cron %s: %v", tt.cronExpr, err)
+			}
+
+			result := schedule.ShouldRun(tt.testTime)
+			if result != tt.expected {
+				t.Errorf("expected %v for %s at %s, got %v",
+					tt.expected, tt.cronExpr, tt.testTime.Format("2006-01-02 15:04:05"), result)
+			}
+		})
+	}
+}
+
+func TestCronRanges(t *testing.T) {
+	tests := []struct {
+		name     string
+		cronExpr string
+		testTime time.Time
+		expected bool
+	}{
+		{
+			name:     "weekday range match Monday",
+			cronExpr: "0 9 * * 1-5",                                // Monday-Friday at 9am
+			testTime: time.Date(2024, 6, 17, 9, 0, 0, 0, time.UTC), // Monday
+			expected: true,
+		},
+		{
+			name:     "weekday range match Friday",
+			cronExpr: "0 9 * * 1-5",                                // Monday-Friday at 9am
+			testTime: time.Date(2024, 6, 21, 9, 0, 0, 0, time.UTC), // Friday
+			expected: true,
+		},
+		{
+			name:     "weekday range no match Saturday",
+			cronExpr: "0 9 * * 1-5",                                // Monday-Friday at 9am
+			testTime: time.Date(2024, 6, 22, 9, 0, 0, 0, time.UTC), // Saturday
+			expected: false,
+		},
+		{
+			name:     "weekday range no match Sunday",
+			cronExpr: "0 9 * * 1-5",                                // Monday-Friday at 9am
+			testTime: time.Date(2024, 6, 23, 9, 0, 0, 0, time.UTC), // Sunday
+			expected: false,
+		},
+		{
+			name:     "hour range match",
+			cronExpr: "0 9-17 * * *",                                // Every hour from 9am-5pm
+			testTime: time.Date(2024, 6, 17, 14, 0, 0, 0, time.UTC), // 2pm
+			expected: true,
+		},
+		{
+			name:     "hour range no match",
+			cronExpr: "0 9-17 * * *",                               // Every hour from 9am-5pm
+			testTime: time.Date(2024, 6, 17, 8, 0, 0, 0, time.UTC), // 8am
+			expected: false,
+		},
+		{
+			name:     "list match first",
+			cronExpr: "0 9 * * 1,3,5",                              // Monday, Wednesday, Friday
+			testTime: time.Date(2024, 6, 17, 9, 0, 0, 0, time.UTC), // Monday
+			expected: true,
+		},
+		{
+			name:     "list match middle",
+			cronExpr: "0 9 * * 1,3,5",                              // Monday, Wednesday, Friday
+			testTime: time.Date(2024, 6, 19, 9, 0, 0, 0, time.UTC), // Wednesday
+			expected: true,
+		},
+		{
+			name:     "list no match",
+			cronExpr: "0 9 * * 1,3,5",                              // Monday, Wednesday, Friday
+			testTime: time.Date(2024, 6, 18, 9, 0, 0, 0, time.UTC), // Tuesday
+			expected: false,
+		},
+		{
+			name:     "combined range and time",
+			cronExpr: "30 17 * * 1-5",                                // 5:30pm weekdays
+			testTime: time.Date(2024, 6, 19, 17, 30, 0, 0, time.UTC), // Wednesday 5:30pm
+			expected: true,
+		},
+		{
+			name:     "combined range wrong time",
+			cronExpr: "30 17 * * 1-5",                               // 5:30pm weekdays
+			testTime: time.Date(2024, 6, 19, 17, 0, 0, 0, time.UTC), // Wednesday 5:00pm
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.cronExpr)
+			if err != nil {
+				t.Fatalf("failed to parse cron %s: %v", tt.cronExpr, err)
+			}
+
+			result := schedule.ShouldRun(tt.testTime)
+			if result != tt.expected {
+				t.Errorf("expected %v for %s at %s (dow=%d), got %v",
+					tt.expected, tt.cronExpr, tt.testTime.Format("2006-01-02 15:04:05 Mon"), int(tt.testTime.Weekday()), result)
+			}
+		})
+	}
+}
+
+func TestCronMixedRangesAndValues(t *testing.T) {
+	tests := []struct {
+		name     string
+		cronExpr string
+		expected []int
+	}{
+		{
+			name:     "individual values excluding Wednesday",
+			cronExpr: "0 9 * * 1,2,4,5",
+			expected: []int{1, 2, 4, 5}, // Mon,Tue,Thu,Fri
+		},
+		{
+			name:     "range plus individual",
+			cronExpr: "0 9 * * 1-3,5",
+			expected: []int{1, 2, 3, 5}, // Mon-Wed,Fri
+		},
+		{
+			name:     "individual plus range",
+			cronExpr: "0 9 * * 1,3-5",
+			expected: []int{1, 3, 4, 5}, // Mon,Wed-Fri
+		},
+		{
+			name:     "multiple ranges",
+			cronExpr: "0 9 * * 1-2,4-5",
+			expected: []int{1, 2, 4, 5}, // Mon-Tue,Thu-Fri
+		},
+		{
+			name:     "complex mixed",
+			cronExpr: "0 9 * * 1,3,5-6",
+			expected: []int{1, 3, 5, 6}, // Mon,Wed,Fri-Sat
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.cronExpr)
+			if err != nil {
+				t.Fatalf("failed to parse cron %s: %v", tt.cronExpr, err)
+			}
+
+			if len(schedule.DOW) != len(tt.expected) {
+				t.Errorf("expected %d DOW values, got %d", len(tt.expected), len(schedule.DOW))
+			}
+
+			for i, expected := range tt.expected {
+				if i >= len(schedule.DOW) || schedule.DOW[i] != expected {
+					t.Errorf("expected DOW values %v, got %v", tt.expected, schedule.DOW)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCronAliasesAndExtendedSyntax(t *testing.T) {
+	monday9 := time.Date(2024, 6, 17, 9, 0, 0, 0, time.UTC) // Monday
+
+	tests := []struct {
+		name     string
+		cronExpr string
+		testTime time.Time
+		expected bool
+	}{
+		{"hourly alias", "@hourly", time.Date(2024, 6, 17, 13, 0, 0, 0, time.UTC), true},
+		{"hourly alias off the hour", "@hourly", time.Date(2024, 6, 17, 13, 1, 0, 0, time.UTC), false},
+		{"daily alias", "@daily", time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC), true},
+		{"weekly alias", "@weekly", time.Date(2024, 6, 16, 0, 0, 0, 0, time.UTC), true}, // Sunday
+		{"monthly alias", "@monthly", time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC), true},
+		{"yearly alias", "@yearly", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"weekday names", "0 9 * * mon-fri", monday9, true},
+		{"month names", "0 9 * JUN *", monday9, true},
+		{"stepped range", "0 8-18/2 * * *", time.Date(2024, 6, 17, 14, 0, 0, 0, time.UTC), true},
+		{"stepped range miss", "0 8-18/2 * * *", time.Date(2024, 6, 17, 15, 0, 0, 0, time.UTC), false},
+		{"stepped start", "5/20 * * * *", time.Date(2024, 6, 17, 14, 45, 0, 0, time.UTC), true},
+		{"seconds match", "30 0 9 * * *", time.Date(2024, 6, 17, 9, 0, 30, 0, time.UTC), true},
+		{"seconds mismatch", "30 0 9 * * *", time.Date(2024, 6, 17, 9, 0, 0, 0, time.UTC), false},
+		{"five fields ignore seconds", "0 9 * * *", time.Date(2024, 6, 17, 9, 0, 42, 0, time.UTC), true},
+		{"day or weekday (day)", "0 9 1 * 5", time.Date(2024, 7, 1, 9, 0, 0, 0, time.UTC), true},      // Monday the 1st
+		{"day or weekday (weekday)", "0 9 1 * 5", time.Date(2024, 6, 21, 9, 0, 0, 0, time.UTC), true}, // Friday
+		{"day or weekday (neither)", "0 9 1 * 5", monday9, false},
+		{"cron tz prefix", "CRON_TZ=Asia/Tokyo 0 18 * * *", monday9, true}, // 09:00 UTC is 18:00 JST
+		{"cron tz prefix miss", "CRON_TZ=Asia/Tokyo 0 9 * * *", monday9, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.cronExpr)
+			if err != nil {
+				t.Fatalf("failed to parse cron %s: %v", tt.cronExpr, err)
+			}
+			if result := schedule.ShouldRun(tt.testTime); result != tt.expected {
+				t.Errorf("expected %v for %s at %s, got %v", tt.expected, tt.cronExpr, tt.testTime.Format(time.RFC3339), result)
+			}
+		})
+	}
+
+	for _, invalid := range []string{"@fortnightly", "CRON_TZ=Mars/Base 0 9 * * *", "60 0 9 * * *", "0 9 * * * * *", "0 9 * foo *"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestCronLastRunToday(t *testing.T) {
+	now := time.Date(2024, 6, 17, 14, 37, 20, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		cronExpr string
+		expected *time.Time
+	}{
+		{"earlier today", "0 9 * * *", timePtr(time.Date(2024, 6, 17, 9, 0, 0, 0, time.UTC))},
+		{"latest of several", "0 9,12,18 * * *", timePtr(time.Date(2024, 6, 17, 12, 0, 0, 0, time.UTC))},
+		{"current minute", "37 14 * * *", timePtr(time.Date(2024, 6, 17, 14, 37, 0, 0, time.UTC))},
+		{"later today", "0 18 * * *", nil},
+		{"other weekday", "0 9 * * 2", nil},
+		{"seconds in current minute", "*/15 37 14 * * *", timePtr(time.Date(2024, 6, 17, 14, 37, 15, 0, time.UTC))},
+		{"seconds in earlier minute", "50 36 14 * * *", timePtr(time.Date(2024, 6, 17, 14, 36, 50, 0, time.UTC))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.cronExpr)
+			if err != nil {
+				t.Fatalf("failed to parse cron %s: %v", tt.cronExpr, err)
+			}
+			result := schedule.LastRunToday(now)
+			if tt.expected == nil {
+				if result != nil {
+					t.Errorf("expected no run today, got %s", result)
+				}
+				return
+			}
+			if result == nil || !result.Equal(*tt.expected) {
+				t.Errorf("expected %s, got %v", tt.expected, result)
+			}
+		})
+	}
+
+	// "Today" follows the schedule's timezone: 14:37 UTC is already the 18th in Auckland
+	schedule, err := Parse("CRON_TZ=Pacific/Auckland 0 1 * * *")
+	if err != nil {
+		t.Fatalf("failed to parse cron: %v", err)
+	}
+	result := schedule.LastRunToday(now)
+	if result == nil || result.UTC().Day() != 17 || result.UTC().Hour() != 13 {
+		t.Errorf("expected 01:00 NZST on the 18th (13:00 UTC on the 17th), got %v", result)
+	}
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func TestCronNextAndPrevRun(t *testing.T) {
+	from := time.Date(2024, 6, 17, 14, 37, 20, 0, time.UTC) // Monday
+
+	tests := []struct {
+		name     string
+		cronExpr string
+		next     time.Time
+		prev     time.Time
+	}{
+		{"every minute", "* * * * *",
+			time.Date(2024, 6, 17, 14, 38, 0, 0, time.UTC),
+			time.Date(2024, 6, 17, 14, 37, 0, 0, time.UTC)},
+		{"daily", "0 9 * * *",
+			time.Date(2024, 6, 18, 9, 0, 0, 0, time.UTC),
+			time.Date(2024, 6, 17, 9, 0, 0, 0, time.UTC)},
+		{"weekdays", "0 18 * * 1-5",
+			time.Date(2024, 6, 17, 18, 0, 0, 0, time.UTC),
+			time.Date(2024, 6, 14, 18, 0, 0, 0, time.UTC)}, // Previous Friday
+		{"monthly", "@monthly",
+			time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)},
+		{"yearly across years", "0 0 29 2 *",
+			time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"seconds", "*/15 * * * * *",
+			time.Date(2024, 6, 17, 14, 37, 30, 0, time.UTC),
+			time.Date(2024, 6, 17, 14, 37, 15, 0, time.UTC)},
+		{"cron tz", "CRON_TZ=Asia/Tokyo 0 9 * * *",
+			time.Date(2024, 6, 18, 0, 0, 0, 0, time.UTC), // 09:00 JST
+			time.Date(2024, 6, 17, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.cronExpr)
+			if err != nil {
+				t.Fatalf("failed to parse cron %s: %v", tt.cronExpr, err)
+			}
+
+			next := schedule.NextRun(from)
+			if next == nil || !next.Equal(tt.next) {
+				t.Errorf("NextRun: expected %s, got %v", tt.next, next)
+			}
+			prev := schedule.PrevRun(from)
+			if prev == nil || !prev.Equal(tt.prev) {
+				t.Errorf("PrevRun: expected %s, got %v", tt.prev, prev)
+			}
+
+			// Both bounds are exclusive
+			if again := schedule.NextRun(*next); again == nil || !again.After(*next) {
+				t.Errorf("NextRun(%s) should be strictly later, got %v", next, again)
+			}
+			if again := schedule.PrevRun(*prev); again == nil || !again.Before(*prev) {
+				t.Errorf("PrevRun(%s) should be strictly earlier, got %v", prev, again)
+			}
+		})
+	}
+
+	// Schedules that can never match and event schedules have no runs
+	for _, expr := range []string{"0 0 30 2 *", "@deployment"} {
+		schedule, err := Parse(expr)
+		if err != nil {
+			t.Fatalf("failed to parse cron %s: %v", expr, err)
+		}
+		if next := schedule.NextRun(from); next != nil {
+			t.Errorf("expected no next run for %s, got %s", expr, next)
+		}
+		if prev := schedule.PrevRun(from); prev != nil {
+			t.Errorf("expected no previous run for %s, got %s", expr, prev)
+		}
+	}
+}
+
+func TestCronSkipHolidays(t *testing.T) {
+	SetHolidays("test-bank", []time.Time{time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC)})
+
+	schedule, err := Parse("SKIP_HOLIDAYS=test-bank CRON_TZ=UTC 0 8 * * 1-5")
+	if err != nil {
+		t.Fatalf("failed to parse: %v", err)
+	}
+	if schedule.Holidays != "test-bank" || schedule.Location == nil {
+		t.Fatalf("expected both prefixes to be parsed, got %+v", schedule)
+	}
+
+	christmas := time.Date(2024, 12, 25, 8, 0, 0, 0, time.UTC) // Wednesday
+	if schedule.ShouldRun(christmas) {
+		t.Error("expected the schedule not to run on a holiday")
+	}
+	if !schedule.ShouldRun(christmas.AddDate(0, 0, 1)) {
+		t.Error("expected the schedule to run the day after the holiday")
+	}
+	if next := schedule.NextRun(christmas.Add(-time.Hour)); next == nil || next.Day() != 26 {
+		t.Errorf("expected the next run to skip the holiday, got %v", next)
+	}
+	if prev := schedule.PrevRun(christmas.Add(time.Hour)); prev == nil || prev.Day() != 24 {
+		t.Errorf("expected the previous run to skip the holiday, got %v", prev)
+	}
+
+	// Unknown calendars have no holidays
+	unknown, _ := Parse("SKIP_HOLIDAYS=nowhere 0 8 * * 1-5")
+	if !unknown.ShouldRun(christmas) {
+		t.Error("expected a schedule skipping an unknown calendar to run")
+	}
+
+	for _, invalid := range []string{"SKIP_HOLIDAYS= 0 8 * * *", "SKIP_HOLIDAYS=uk @deployment", "HOLIDAYS=uk 0 8 * * *"} {
+		if _, err := Parse(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
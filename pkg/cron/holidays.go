@@ -0,0 +1,41 @@
+package cron
+
+import (
+	"sync"
+	"time"
+)
+
+// holidayCalendars holds the days of each registered holiday calendar as "2006-01-02" dates
+var (
+	holidayMu        sync.RWMutex
+	holidayCalendars = map[string]map[string]bool{}
+)
+
+// SetHolidays registers the days of a holiday calendar, replacing any it had before.
+// Schedules with a SKIP_HOLIDAYS= prefix never match on these days.
+func SetHolidays(calendar string, days []time.Time) {
+	dates := make(map[string]bool, len(days))
+	for _, day := range days {
+		dates[day.Format(time.DateOnly)] = true
+	}
+
+	holidayMu.Lock()
+	defer holidayMu.Unlock()
+	holidayCalendars[calendar] = dates
+}
+
+// HasHolidays reports whether a holiday calendar is registered
+func HasHolidays(calendar string) bool {
+	holidayMu.RLock()
+	defer holidayMu.RUnlock()
+	_, ok := holidayCalendars[calendar]
+	return ok
+}
+
+// IsHoliday reports whether the date of t, in t's location, is a day of the holiday calendar.
+// Calendars that were never registered have no holidays.
+func IsHoliday(calendar string, t time.Time) bool {
+	holidayMu.RLock()
+	defer holidayMu.RUnlock()
+	return holidayCalendars[calendar][t.Format(time.DateOnly)]
+}
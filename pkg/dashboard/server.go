@@ -0,0 +1,328 @@
+// Package dashboard serves a browser view of the daemon: workspace status with deploy and
+// destroy buttons, job run history and workspace logs, behind a shared token.
+package dashboard
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/job"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/scheduler"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// TokenHeader carries the dashboard token; "Authorization: Bearer <token>" works as well
+const TokenHeader = "X-Provisioner-Token"
+
+// CorrelationIDHeader returns the correlation ID of a triggered operation
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// runHistoryLimit is the number of recorded runs returned per job
+const runHistoryLimit = 10
+
+// maxLogLines caps the log lines a single request can ask for
+const maxLogLines = 5000
+
+// standaloneWorkspaceID is the workspace ID the job manager uses for standalone jobs
+const standaloneWorkspaceID = "_standalone_"
+
+// Server serves the dashboard page and the JSON API behind it
+type Server struct {
+	sched      *scheduler.Scheduler
+	token      string
+	httpServer *http.Server
+	actions    sync.WaitGroup // Operations still running after their request was answered
+}
+
+// response is the JSON body returned for operations and errors
+type response struct {
+	Status        string `json:"status"`
+	Workspace     string `json:"workspace,omitempty"`
+	Action        string `json:"action,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+// jobOutput is a job's state with its most recent recorded runs
+type jobOutput struct {
+	job.JobState
+	Runs []*job.RunRecord `json:"runs"`
+}
+
+// NewServer creates a dashboard server for the scheduler listening on addr. Every API request
+// must carry token.
+func NewServer(sched *scheduler.Scheduler, addr, token string) *Server {
+	s := &Server{sched: sched, token: token}
+
+	static, _ := fs.Sub(staticFiles, "static")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(static))
+	mux.HandleFunc("GET /api/workspaces", s.authorized(s.handleWorkspaces))
+	mux.HandleFunc("POST /api/workspaces/{name}/{action}", s.authorized(s.handleAction))
+	mux.HandleFunc("GET /api/workspaces/{name}/logs", s.authorized(s.handleLogs))
+	mux.HandleFunc("GET /api/jobs", s.authorized(s.handleJobs))
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           accessLog(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler (for testing)
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// Start listens for dashboard requests in the background
+func (s *Server) Start() error {
+	if s.token == "" {
+		return errors.New("no dashboard token configured")
+	}
+
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for dashboard: %w", err)
+	}
+
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logging.LogSystemd("Dashboard server stopped: %v", err)
+		}
+	}()
+
+	logging.LogSystemd("Dashboard listening on %s", listener.Addr())
+	return nil
+}
+
+// Close stops the dashboard server
+func (s *Server) Close() error {
+	return s.httpServer.Close()
+}
+
+// authorized rejects API requests without the dashboard token
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get(TokenHeader)
+		if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			token = bearer
+		}
+		if s.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeResponse(w, http.StatusUnauthorized, response{Status: "error", Error: "invalid token"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleWorkspaces returns the status of all workspaces
+func (s *Server) handleWorkspaces(w http.ResponseWriter, r *http.Request) {
+	summaries := s.sched.WorkspaceSummaries(time.Now())
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Workspace.Name < summaries[j].Workspace.Name })
+	writeJSON(w, http.StatusOK, summaries)
+}
+
+// handleAction starts a deploy, destroy or the operation awaiting approval and answers before it finishes
+func (s *Server) handleAction(w http.ResponseWriter, r *http.Request) {
+	workspaceName := r.PathValue("name")
+	action := r.PathValue("action")
+
+	if action != "deploy" && action != "destroy" && action != "approve" {
+		writeResponse(w, http.StatusNotFound, response{Status: "error", Error: fmt.Sprintf("unknown action '%s'", action)})
+		return
+	}
+	if s.sched.GetWorkspace(workspaceName) == nil {
+		writeResponse(w, http.StatusNotFound, response{Status: "error", Error: "workspace not found"})
+		return
+	}
+	if !s.sched.IsReady() {
+		writeResponse(w, http.StatusServiceUnavailable, response{Status: "error", Error: "daemon is still starting"})
+		return
+	}
+	if s.sched.IsWorkspaceBusy(workspaceName) {
+		writeResponse(w, http.StatusConflict, response{Status: "error", Error: "workspace is busy"})
+		return
+	}
+	if action == "approve" && !s.sched.AwaitsApproval(workspaceName) {
+		writeResponse(w, http.StatusConflict, response{Status: "error", Error: "nothing awaits approval"})
+		return
+	}
+
+	correlationID := logging.NewCorrelationID(time.Now())
+	w.Header().Set(CorrelationIDHeader, correlationID)
+
+	logging.LogWorkspaceOperation(workspaceName, "DASHBOARD", "%s requested from %s (correlation ID %s)",
+		action, r.RemoteAddr, correlationID)
+
+	trigger := audit.Trigger{Source: audit.SourceDashboard, Actor: r.RemoteAddr}
+
+	// Operations take minutes, so run them after responding
+	s.actions.Add(1)
+	go func() {
+		defer s.actions.Done()
+		err := s.sched.WithTrigger(workspaceName, trigger, func() error {
+			return s.sched.WithCorrelationID(workspaceName, correlationID, func() error {
+				switch action {
+				case "destroy":
+					return s.sched.ManualDestroy(workspaceName)
+				case "approve":
+					return s.sched.ApproveWorkspace(workspaceName)
+				}
+				return s.sched.ManualDeploy(workspaceName)
+			})
+		})
+		if err != nil {
+			logging.LogWorkspace(workspaceName, "DASHBOARD: %s failed: %v", action, err)
+		}
+	}()
+
+	writeResponse(w, http.StatusAccepted, response{
+		Status:        "accepted",
+		Workspace:     workspaceName,
+		Action:        action,
+		CorrelationID: correlationID,
+	})
+}
+
+// handleLogs returns the last lines of a workspace log as plain text
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	lines := scheduler.DefaultLogLines
+	if value := r.URL.Query().Get("lines"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n <= 0 {
+			writeResponse(w, http.StatusBadRequest, response{Status: "error", Error: "lines must be a positive number"})
+			return
+		}
+		lines = min(n, maxLogLines)
+	}
+
+	var buf bytes.Buffer
+	if err := s.sched.WriteLogs(&buf, r.PathValue("name"), scheduler.LogOptions{Lines: lines}); err != nil {
+		writeResponse(w, http.StatusNotFound, response{Status: "error", Error: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// handleJobs returns the state and recent runs of workspace and standalone jobs, optionally
+// limited to one workspace
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	manager := s.sched.GetJobManager()
+	if manager == nil {
+		writeResponse(w, http.StatusServiceUnavailable, response{Status: "error", Error: "daemon is still starting"})
+		return
+	}
+
+	workspaceFilter := r.URL.Query().Get("workspace")
+	var states []*job.JobState
+	for _, summary := range s.sched.WorkspaceSummaries(time.Now()) {
+		if workspaceFilter != "" && summary.Workspace.Name != workspaceFilter {
+			continue
+		}
+		for _, state := range manager.GetAllJobStates(summary.Workspace.Name) {
+			states = append(states, state)
+		}
+	}
+	if standalone := s.sched.GetStandaloneJobManager(); standalone != nil && workspaceFilter == "" {
+		for _, state := range standalone.GetStandaloneJobStates() {
+			states = append(states, state)
+		}
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		if states[i].WorkspaceID != states[j].WorkspaceID {
+			return states[i].WorkspaceID < states[j].WorkspaceID
+		}
+		return states[i].Name < states[j].Name
+	})
+
+	jobs := make([]jobOutput, 0, len(states))
+	for _, state := range states {
+		output := jobOutput{JobState: *state, Runs: []*job.RunRecord{}}
+		output.LastError = logging.RedactWorkspace(redactionScope(state.WorkspaceID), state.LastError)
+
+		runs, err := manager.ListRuns(state.WorkspaceID, state.Name, runHistoryLimit)
+		if err != nil {
+			logging.LogSystemd("Dashboard: failed to list runs of job '%s': %v", state.Name, err)
+		}
+		for _, run := range runs {
+			run.Error = logging.RedactWorkspace(redactionScope(run.WorkspaceID), run.Error)
+			output.Runs = append(output.Runs, run)
+		}
+		jobs = append(jobs, output)
+	}
+
+	writeJSON(w, http.StatusOK, jobs)
+}
+
+// redactionScope returns the workspace whose secrets are redacted from a job's errors
+func redactionScope(workspaceID string) string {
+	if workspaceID == standaloneWorkspaceID {
+		return ""
+	}
+	return workspaceID
+}
+
+// statusRecorder captures the response status for the access log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// accessLog logs every API request with its outcome and correlation ID
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		// Page assets and polling reads would flood the log
+		if !strings.HasPrefix(r.URL.Path, "/api/") || (r.Method == http.MethodGet && recorder.status == http.StatusOK) {
+			return
+		}
+
+		line := fmt.Sprintf("ACCESS dashboard %s %s %d %s from %s",
+			r.Method, r.URL.Path, recorder.status, time.Since(start).Round(time.Millisecond), r.RemoteAddr)
+		if correlationID := w.Header().Get(CorrelationIDHeader); correlationID != "" {
+			line += " correlation_id=" + correlationID
+		}
+		logging.LogSystemd("%s", line)
+	})
+}
+
+// writeResponse writes a JSON status response
+func writeResponse(w http.ResponseWriter, status int, resp response) {
+	writeJSON(w, status, resp)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,238 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/job"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/workspace"
+)
+
+const testToken = "dashboard-token"
+
+func setupServer(t *testing.T) (*Server, *scheduler.Scheduler, *opentofu.MockTofuClient) {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_STATE_DIR", filepath.Join(tempDir, "state"))
+	t.Setenv("PROVISIONER_LOG_DIR", filepath.Join(tempDir, "logs"))
+
+	workspaceDir := filepath.Join(configDir, "workspaces", "web")
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		t.Fatalf("Failed to create workspace dir: %v", err)
+	}
+	config := `{
+  "enabled": true,
+  "description": "Web frontend",
+  "deploy_schedule": "0 9 * * *",
+  "destroy_schedule": "0 17 * * *"
+}`
+	if err := os.WriteFile(filepath.Join(workspaceDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workspaceDir, "main.tf"), []byte("# test\n"), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+
+	mockClient := opentofu.NewMockTofuClient()
+	sched := scheduler.NewWithClient(mockClient)
+	if err := sched.LoadWorkspaces(); err != nil {
+		t.Fatalf("Failed to load workspaces: %v", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	server := NewServer(sched, "127.0.0.1:0", testToken)
+	// Let triggered operations finish saving state before the next test or directory cleanup
+	t.Cleanup(server.actions.Wait)
+	return server, sched, mockClient
+}
+
+func request(server *Server, method, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestServesPageWithoutToken(t *testing.T) {
+	server, _, _ := setupServer(t)
+
+	recorder := request(server, http.MethodGet, "/", "")
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", recorder.Code)
+	}
+	if !strings.Contains(recorder.Body.String(), "Dashboard token") {
+		t.Errorf("Expected the dashboard page, got %s", recorder.Body.String())
+	}
+}
+
+func TestRejectsRequestsWithoutToken(t *testing.T) {
+	server, _, mockClient := setupServer(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+	}{
+		{"no token", http.MethodGet, "/api/workspaces", ""},
+		{"wrong token", http.MethodGet, "/api/workspaces", "wrong"},
+		{"deploy", http.MethodPost, "/api/workspaces/web/deploy", ""},
+		{"logs", http.MethodGet, "/api/workspaces/web/logs", "wrong"},
+		{"jobs", http.MethodGet, "/api/jobs", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := request(server, tt.method, tt.path, tt.token)
+			if recorder.Code != http.StatusUnauthorized {
+				t.Errorf("Expected 401, got %d: %s", recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+
+	if mockClient.DeployCallCount != 0 {
+		t.Errorf("Expected no deploys, got %d", mockClient.DeployCallCount)
+	}
+}
+
+func TestStartRequiresToken(t *testing.T) {
+	if err := NewServer(nil, "127.0.0.1:0", "").Start(); err == nil {
+		t.Error("Expected dashboard without a token to refuse to start")
+	}
+}
+
+func TestListsWorkspaces(t *testing.T) {
+	server, _, _ := setupServer(t)
+
+	recorder := request(server, http.MethodGet, "/api/workspaces", testToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var workspaces []struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Status      string `json:"status"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &workspaces); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(workspaces) != 1 || workspaces[0].Name != "web" || workspaces[0].Description != "Web frontend" {
+		t.Errorf("Unexpected workspaces: %+v", workspaces)
+	}
+}
+
+func TestDeployRunsInBackground(t *testing.T) {
+	server, _, mockClient := setupServer(t)
+
+	calls := make(chan string, 1)
+	mockClient.DeployFunc = func(ws *workspace.Workspace) error {
+		calls <- ws.Name
+		return nil
+	}
+
+	recorder := request(server, http.MethodPost, "/api/workspaces/web/deploy", testToken)
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Header().Get(CorrelationIDHeader) == "" {
+		t.Error("Expected a correlation ID header")
+	}
+
+	select {
+	case name := <-calls:
+		if name != "web" {
+			t.Errorf("Expected deploy of web, got %s", name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for deploy")
+	}
+}
+
+func TestRejectsUnknownWorkspacesAndActions(t *testing.T) {
+	server, _, _ := setupServer(t)
+
+	tests := []struct {
+		path     string
+		expected int
+	}{
+		{"/api/workspaces/missing/deploy", http.StatusNotFound},
+		{"/api/workspaces/web/upgrade", http.StatusNotFound},
+		{"/api/workspaces/web/approve", http.StatusConflict},
+	}
+	for _, tt := range tests {
+		if recorder := request(server, http.MethodPost, tt.path, testToken); recorder.Code != tt.expected {
+			t.Errorf("Expected %d for %s, got %d", tt.expected, tt.path, recorder.Code)
+		}
+	}
+}
+
+func TestShowsLogs(t *testing.T) {
+	server, _, _ := setupServer(t)
+
+	logDir := os.Getenv("PROVISIONER_LOG_DIR")
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		t.Fatalf("Failed to create log dir: %v", err)
+	}
+	content := "first\nsecond\nthird\n"
+	if err := os.WriteFile(filepath.Join(logDir, "web.log"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write log: %v", err)
+	}
+
+	recorder := request(server, http.MethodGet, "/api/workspaces/web/logs?lines=2", testToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if recorder.Body.String() != "second\nthird\n" {
+		t.Errorf("Expected the last two lines, got %q", recorder.Body.String())
+	}
+
+	if recorder := request(server, http.MethodGet, "/api/workspaces/missing/logs", testToken); recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown workspace, got %d", recorder.Code)
+	}
+	if recorder := request(server, http.MethodGet, "/api/workspaces/web/logs?lines=abc", testToken); recorder.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for invalid line count, got %d", recorder.Code)
+	}
+}
+
+func TestListsJobRuns(t *testing.T) {
+	server, sched, _ := setupServer(t)
+
+	manager := sched.GetJobManager()
+	if err := manager.LoadState(); err != nil {
+		t.Fatalf("Failed to load job state: %v", err)
+	}
+	manager.GetJobState("web", "backup") // Records the job, as jobs are listed from their state
+	if err := manager.SaveRun(&job.RunRecord{ID: "20260101-090000-aaaaaa", JobName: "backup", WorkspaceID: "web", Status: job.JobStatusSuccess}); err != nil {
+		t.Fatalf("Failed to save run: %v", err)
+	}
+
+	recorder := request(server, http.MethodGet, "/api/jobs?workspace=web", testToken)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	var jobs []jobOutput
+	if err := json.Unmarshal(recorder.Body.Bytes(), &jobs); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Name != "backup" || len(jobs[0].Runs) != 1 || jobs[0].Runs[0].ID != "20260101-090000-aaaaaa" {
+		t.Errorf("Unexpected jobs: %+v", jobs)
+	}
+}
@@ -0,0 +1,225 @@
+package environment
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Traffic backend types
+const (
+	BackendReservedIP   = "reserved_ip"   // DigitalOcean Reserved IPs, assigned to droplets with doctl
+	BackendRoute53      = "route53"       // Route53 A record, updated with the aws CLI
+	BackendCloudflare   = "cloudflare"    // Cloudflare A records, updated through the Cloudflare API
+	BackendLoadBalancer = "load_balancer" // AWS target group, whose targets are swapped with the aws CLI
+)
+
+// Backend points an environment's traffic at the servers of a workspace. Targets are the
+// workspace's load_balancers output, resolved to IPs for backends that need addresses.
+type Backend interface {
+	// Targets returns the servers the environment's traffic currently goes to
+	Targets() ([]string, error)
+	// Switch points the environment's traffic at targets
+	Switch(targets []string) error
+	// Endpoints returns the addresses to health check once traffic goes to targets
+	Endpoints(targets []string) []string
+	// TargetsAreIPs reports whether targets are IP addresses rather than server IDs
+	TargetsAreIPs() bool
+}
+
+// BackendConfig selects and configures an environment's traffic backend
+type BackendConfig struct {
+	Type           string `json:"type,omitempty"`             // reserved_ip (default), route53, cloudflare or load_balancer
+	ZoneID         string `json:"zone_id,omitempty"`          // Hosted zone (route53) or zone (cloudflare) of the record
+	Record         string `json:"record,omitempty"`           // DNS record to update, defaults to the domain
+	TTL            int    `json:"ttl,omitempty"`              // TTL of the DNS record in seconds (default 60)
+	TokenEnv       string `json:"token_env,omitempty"`        // Environment variable holding the Cloudflare API token (default CLOUDFLARE_API_TOKEN)
+	TargetGroupARN string `json:"target_group_arn,omitempty"` // Target group whose targets are swapped (load_balancer)
+	Port           int    `json:"port,omitempty"`             // Port targets are registered with, defaults to the target group's
+	Endpoint       string `json:"endpoint,omitempty"`         // Address health checked after a load balancer switch, defaults to the domain
+}
+
+// Validate checks the backend configuration and fills in defaults
+func (b *BackendConfig) Validate(config *Config) error {
+	if b.Type == "" {
+		b.Type = BackendReservedIP
+	}
+
+	switch b.Type {
+	case BackendReservedIP:
+		if len(config.ReservedIPs) == 0 {
+			return fmt.Errorf("at least one reserved IP is required")
+		}
+		for i, ip := range config.ReservedIPs {
+			if ip == "" {
+				return fmt.Errorf("reserved IP at index %d is empty", i)
+			}
+		}
+	case BackendRoute53, BackendCloudflare:
+		if b.ZoneID == "" {
+			return fmt.Errorf("zone_id is required for %s backends", b.Type)
+		}
+		if b.Record == "" {
+			b.Record = config.Domain
+		}
+		if b.TTL == 0 {
+			b.TTL = 60
+		}
+		if b.Type == BackendCloudflare && b.TokenEnv == "" {
+			b.TokenEnv = "CLOUDFLARE_API_TOKEN"
+		}
+	case BackendLoadBalancer:
+		if b.TargetGroupARN == "" {
+			return fmt.Errorf("target_group_arn is required for load_balancer backends")
+		}
+		if b.Endpoint == "" {
+			b.Endpoint = config.Domain
+		}
+	default:
+		return fmt.Errorf("invalid backend type '%s', must be 'reserved_ip', 'route53', 'cloudflare' or 'load_balancer'", b.Type)
+	}
+	return nil
+}
+
+// String describes where the backend sends traffic
+func (b BackendConfig) String() string {
+	switch b.Type {
+	case BackendRoute53, BackendCloudflare:
+		return fmt.Sprintf("%s record %s", b.Type, b.Record)
+	case BackendLoadBalancer:
+		return fmt.Sprintf("target group %s", b.TargetGroupARN)
+	default:
+		return "reserved IPs"
+	}
+}
+
+// NewBackend returns the traffic backend configured for an environment
+func NewBackend(config Config) Backend {
+	switch config.Backend.Type {
+	case BackendRoute53:
+		return &route53Backend{config: config.Backend}
+	case BackendCloudflare:
+		return &cloudflareBackend{config: config.Backend}
+	case BackendLoadBalancer:
+		return &loadBalancerBackend{config: config.Backend}
+	default:
+		return &reservedIPBackend{reservedIPs: config.ReservedIPs, provider: doctlProvider{}}
+	}
+}
+
+// CurrentEndpoints returns the addresses serving the environment's assigned workspace
+func (e *Environment) CurrentEndpoints() ([]string, error) {
+	backend := NewBackend(e.Config)
+	var targets []string
+	if backend.TargetsAreIPs() {
+		var err error
+		if targets, err = backend.Targets(); err != nil {
+			return nil, err
+		}
+	}
+	return backend.Endpoints(targets), nil
+}
+
+// ReservedIPProvider assigns Reserved IPs to servers
+type ReservedIPProvider interface {
+	// AssignedServer returns the server a Reserved IP is assigned to, or "" if unassigned
+	AssignedServer(reservedIP string) (string, error)
+	Assign(reservedIP, serverID string) error
+	Unassign(reservedIP string) error
+}
+
+// reservedIPBackend assigns each Reserved IP to the target at the same position
+type reservedIPBackend struct {
+	reservedIPs []string
+	provider    ReservedIPProvider
+}
+
+// NewReservedIPBackend returns a backend assigning reservedIPs with provider
+func NewReservedIPBackend(reservedIPs []string, provider ReservedIPProvider) Backend {
+	return &reservedIPBackend{reservedIPs: reservedIPs, provider: provider}
+}
+
+func (b *reservedIPBackend) Targets() ([]string, error) {
+	targets := make([]string, len(b.reservedIPs))
+	for i, reservedIP := range b.reservedIPs {
+		server, err := b.provider.AssignedServer(reservedIP)
+		if err != nil {
+			return nil, err
+		}
+		targets[i] = server
+	}
+	return targets, nil
+}
+
+// Switch assigns the Reserved IPs in order; a Reserved IP whose target is "" is unassigned
+func (b *reservedIPBackend) Switch(targets []string) error {
+	if len(targets) < len(b.reservedIPs) {
+		return fmt.Errorf("%d targets for %d reserved IPs", len(targets), len(b.reservedIPs))
+	}
+	for i, reservedIP := range b.reservedIPs {
+		if targets[i] == "" {
+			if err := b.provider.Unassign(reservedIP); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := b.provider.Assign(reservedIP, targets[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *reservedIPBackend) Endpoints(targets []string) []string {
+	return b.reservedIPs
+}
+
+func (b *reservedIPBackend) TargetsAreIPs() bool {
+	return false
+}
+
+// doctlProvider assigns DigitalOcean Reserved IPs with doctl
+type doctlProvider struct{}
+
+// AssignedServer returns the droplet a Reserved IP is assigned to
+func (doctlProvider) AssignedServer(reservedIP string) (string, error) {
+	output, err := runCommand(30*time.Second, "doctl", "compute", "reserved-ip", "get", reservedIP, "--format", "DropletID", "--no-header")
+	if err != nil {
+		return "", fmt.Errorf("failed to get Reserved IP %s: %w", reservedIP, err)
+	}
+	return strings.TrimSpace(output), nil
+}
+
+// Assign assigns a Reserved IP to a specific server
+func (doctlProvider) Assign(reservedIP, serverID string) error {
+	if _, err := runCommand(60*time.Second, "doctl", "compute", "reserved-ip-action", "assign", reservedIP, "--resource", serverID, "--wait"); err != nil {
+		return fmt.Errorf("failed to assign Reserved IP %s to server %s: %w", reservedIP, serverID, err)
+	}
+	return nil
+}
+
+// Unassign removes a Reserved IP from its server
+func (doctlProvider) Unassign(reservedIP string) error {
+	if _, err := runCommand(60*time.Second, "doctl", "compute", "reserved-ip-action", "unassign", reservedIP, "--wait"); err != nil {
+		return fmt.Errorf("failed to unassign Reserved IP %s: %w", reservedIP, err)
+	}
+	return nil
+}
+
+// runCommand runs a provider CLI and returns its standard output
+func runCommand(timeout time.Duration, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return "", fmt.Errorf("%w\nOutput: %s", err, string(exitErr.Stderr))
+		}
+		return "", err
+	}
+	return string(output), nil
+}
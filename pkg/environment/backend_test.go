@@ -0,0 +1,163 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeCloudflare serves the DNS records API of one zone
+type fakeCloudflare struct {
+	mu      sync.Mutex
+	records map[string]cloudflareRecord
+	nextID  int
+}
+
+func newFakeCloudflare(t *testing.T, contents ...string) *fakeCloudflare {
+	t.Helper()
+	cloudflare := &fakeCloudflare{records: make(map[string]cloudflareRecord)}
+	for _, content := range contents {
+		cloudflare.add(cloudflareRecord{Type: "A", Name: "app.example.com", Content: content, TTL: 60})
+	}
+	server := httptest.NewServer(cloudflare)
+	t.Cleanup(server.Close)
+
+	previous := cloudflareAPI
+	cloudflareAPI = server.URL
+	t.Cleanup(func() { cloudflareAPI = previous })
+	t.Setenv("CLOUDFLARE_API_TOKEN", "test-token")
+	return cloudflare
+}
+
+func (c *fakeCloudflare) add(record cloudflareRecord) {
+	c.nextID++
+	record.ID = fmt.Sprintf("record-%d", c.nextID)
+	c.records[record.ID] = record
+}
+
+func (c *fakeCloudflare) contents() []string {
+	var contents []string
+	for _, record := range c.records {
+		contents = append(contents, record.Content)
+	}
+	sort.Strings(contents)
+	return contents
+}
+
+func (c *fakeCloudflare) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer test-token" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"success": false, "errors": [{"message": "Authentication error"}]}`))
+		return
+	}
+
+	var result interface{}
+	id := strings.TrimPrefix(r.URL.Path, "/zones/zone-1/dns_records/")
+	switch r.Method {
+	case http.MethodGet:
+		records := []cloudflareRecord{}
+		for _, record := range c.records {
+			if record.Name == r.URL.Query().Get("name") {
+				records = append(records, record)
+			}
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+		result = records
+	case http.MethodPost, http.MethodPut:
+		var record cloudflareRecord
+		_ = json.NewDecoder(r.Body).Decode(&record)
+		if r.Method == http.MethodPut {
+			record.ID = id
+			c.records[id] = record
+		} else {
+			c.add(record)
+		}
+	case http.MethodDelete:
+		delete(c.records, id)
+	}
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "result": result})
+}
+
+func TestCloudflareBackend(t *testing.T) {
+	cloudflare := newFakeCloudflare(t, "192.0.2.1", "192.0.2.2")
+	config := Config{Domain: "app.example.com", AssignedWorkspace: "blue", HealthCheck: HealthCheck{Type: "tcp", Port: 443},
+		Backend: BackendConfig{Type: BackendCloudflare, ZoneID: "zone-1"}}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	backend := NewBackend(config)
+
+	targets, err := backend.Targets()
+	if err != nil || strings.Join(targets, ",") != "192.0.2.1,192.0.2.2" {
+		t.Fatalf("Unexpected targets %v (%v)", targets, err)
+	}
+
+	// Fewer targets update the first record and delete the others
+	if err := backend.Switch([]string{"198.51.100.1"}); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+	if contents := cloudflare.contents(); strings.Join(contents, ",") != "198.51.100.1" {
+		t.Errorf("Expected one record for the new target, got %v", contents)
+	}
+
+	// More targets add records
+	if err := backend.Switch([]string{"198.51.100.1", "198.51.100.2", "198.51.100.3"}); err != nil {
+		t.Fatalf("Switch failed: %v", err)
+	}
+	if contents := cloudflare.contents(); len(contents) != 3 {
+		t.Errorf("Expected a record per target, got %v", contents)
+	}
+	if endpoints := backend.Endpoints([]string{"198.51.100.1"}); len(endpoints) != 1 || endpoints[0] != "198.51.100.1" {
+		t.Errorf("Expected the targets to be checked, got %v", endpoints)
+	}
+
+	t.Setenv("CLOUDFLARE_API_TOKEN", "wrong")
+	if _, err := backend.Targets(); err == nil || !strings.Contains(err.Error(), "Authentication error") {
+		t.Errorf("Expected the API error to be reported, got %v", err)
+	}
+}
+
+func TestBackendConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr string
+	}{
+		{"reserved IPs by default", Config{ReservedIPs: []string{"203.0.113.10"}}, ""},
+		{"reserved IPs required", Config{}, "reserved IP"},
+		{"route53 needs a zone", Config{Backend: BackendConfig{Type: BackendRoute53}}, "zone_id"},
+		{"route53", Config{Backend: BackendConfig{Type: BackendRoute53, ZoneID: "Z123"}}, ""},
+		{"load balancer needs a target group", Config{Backend: BackendConfig{Type: BackendLoadBalancer}}, "target_group_arn"},
+		{"unknown type", Config{Backend: BackendConfig{Type: "f5"}}, "invalid backend type"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := tt.config
+			config.Domain, config.AssignedWorkspace = "app.example.com", "blue"
+			config.HealthCheck = HealthCheck{Type: "tcp", Port: 443}
+			err := config.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Unexpected error: %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Expected error containing '%s', got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	config := Config{Domain: "app.example.com", AssignedWorkspace: "blue", HealthCheck: HealthCheck{Type: "tcp", Port: 443},
+		Backend: BackendConfig{Type: BackendRoute53, ZoneID: "Z123"}}
+	if err := config.Validate(); err != nil || config.Backend.Record != "app.example.com" || config.Backend.TTL != 60 {
+		t.Errorf("Expected the record and TTL to default, got %+v (%v)", config.Backend, err)
+	}
+	if config.ReservedIPs != nil {
+		t.Error("Expected no reserved IPs for a DNS backend")
+	}
+}
@@ -1,12 +1,13 @@
 package environment
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"provisioner/pkg/configfile"
 )
 
 // HealthCheck represents the health check configuration for an environment
@@ -16,14 +17,20 @@ type HealthCheck struct {
 	Port    int    `json:"port,omitempty"`    // Port number (for http/tcp types)
 	Command string `json:"command,omitempty"` // Command to execute (for command type)
 	Timeout string `json:"timeout"`           // Timeout duration (e.g., "30s", "1m")
+
+	Attempts         int    `json:"attempts,omitempty"`          // Checks per server before it counts as unhealthy (default 3)
+	Interval         string `json:"interval,omitempty"`          // Wait between checks of a server (default "5s")
+	HealthyThreshold int    `json:"healthy_threshold,omitempty"` // Consecutive successful checks a server needs (default 1)
 }
 
 // Config represents an environment configuration
 type Config struct {
-	Domain            string      `json:"domain"`
-	ReservedIPs       []string    `json:"reserved_ips"`
-	AssignedWorkspace string      `json:"assigned_workspace"`
-	HealthCheck       HealthCheck `json:"healthcheck"`
+	Domain            string        `json:"domain"`
+	ReservedIPs       []string      `json:"reserved_ips,omitempty"` // For the reserved_ip backend
+	AssignedWorkspace string        `json:"assigned_workspace"`
+	HealthCheck       HealthCheck   `json:"healthcheck"`
+	Backend           BackendConfig `json:"backend"` // How traffic is switched, DigitalOcean Reserved IPs by default
+	Monitor           Monitor       `json:"monitor"` // Periodic health checks by the daemon
 }
 
 // Environment represents a loaded environment with its configuration
@@ -36,7 +43,10 @@ type Environment struct {
 // LoadEnvironment loads a specific environment configuration
 func LoadEnvironment(environmentName string) (*Environment, error) {
 	configDir := getConfigDir()
-	configPath := filepath.Join(configDir, fmt.Sprintf("%s.json", environmentName))
+	configPath, err := configfile.Path(configDir, environmentName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environment '%s': %w", environmentName, err)
+	}
 
 	config, err := loadConfigFile(configPath)
 	if err != nil {
@@ -52,27 +62,14 @@ func LoadEnvironment(environmentName string) (*Environment, error) {
 
 // LoadAllEnvironments loads all environment configurations from the config directory
 func LoadAllEnvironments() ([]Environment, error) {
-	configDir := getConfigDir()
-
-	// List all .json files in the config directory
-	files, err := filepath.Glob(filepath.Join(configDir, "*.json"))
+	files, err := ConfigFiles()
 	if err != nil {
-		return nil, fmt.Errorf("failed to list environment files: %w", err)
+		return nil, err
 	}
 
 	var environments []Environment
 	for _, file := range files {
-		// Skip non-environment files
-		filename := filepath.Base(file)
-		if strings.HasPrefix(filename, ".") ||
-		   filename == "config.json" ||
-		   strings.Contains(filename, "scheduler") ||
-		   strings.Contains(filename, "jobs") {
-			continue
-		}
-
-		environmentName := strings.TrimSuffix(filename, ".json")
-
+		environmentName, _ := configfile.TrimExtension(filepath.Base(file))
 		config, err := loadConfigFile(file)
 		if err != nil {
 			fmt.Printf("Warning: failed to load environment '%s': %v\n", environmentName, err)
@@ -89,6 +86,45 @@ func LoadAllEnvironments() ([]Environment, error) {
 	return environments, nil
 }
 
+// ConfigFiles returns the paths of the environment config files in the config directory.
+// Environments defined in more than one format are skipped with a warning.
+func ConfigFiles() ([]string, error) {
+	configDir := getConfigDir()
+
+	// List all .json, .yaml and .hcl files in the config directory
+	entries, err := os.ReadDir(configDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list environment files: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		// Skip non-environment files
+		filename := entry.Name()
+		environmentName, ok := configfile.TrimExtension(filename)
+		if entry.IsDir() || !ok ||
+		   strings.HasPrefix(filename, ".") ||
+		   environmentName == "config" ||
+		   environmentName == "provisioner" ||
+		   environmentName == "notifications" ||
+		   strings.Contains(filename, "scheduler") ||
+		   strings.Contains(filename, "jobs") {
+			continue
+		}
+
+		file := filepath.Join(configDir, filename)
+		if found, err := configfile.Path(configDir, environmentName); err != nil {
+			if found == file {
+				fmt.Printf("Warning: failed to load environment '%s': %v\n", environmentName, err)
+			}
+			continue
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
 // GetAssignedWorkspaces returns a map of workspace names to environment names
 // for all workspaces that are currently assigned to any environment
 func GetAssignedWorkspaces() (map[string]string, error) {
@@ -109,24 +145,18 @@ func GetAssignedWorkspaces() (map[string]string, error) {
 
 // EnvironmentExists checks if an environment configuration file exists
 func EnvironmentExists(environmentName string) bool {
-	configDir := getConfigDir()
-	configPath := filepath.Join(configDir, fmt.Sprintf("%s.json", environmentName))
-	_, err := os.Stat(configPath)
+	configPath, err := configfile.Path(getConfigDir(), environmentName)
+	if err != nil {
+		return true // Defined more than once
+	}
+	_, err = os.Stat(configPath)
 	return err == nil
 }
 
 // SaveEnvironment saves an environment configuration to disk
 func (e *Environment) SaveEnvironment() error {
-	data, err := json.MarshalIndent(e.Config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal environment config: %w", err)
-	}
-
-	if err := os.WriteFile(e.Path, data, 0644); err != nil {
-		return fmt.Errorf("failed to write environment config: %w", err)
-	}
-
-	return nil
+	// Written in the format of its file; YAML keeps its comments
+	return configfile.Write(e.Path, e.Config)
 }
 
 // Validate validates the environment configuration
@@ -135,16 +165,8 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("domain is required")
 	}
 
-	if len(c.ReservedIPs) == 0 {
-		return fmt.Errorf("at least one reserved IP is required")
-	}
-
-	// Validate each Reserved IP format (basic validation)
-	for i, ip := range c.ReservedIPs {
-		if ip == "" {
-			return fmt.Errorf("reserved IP at index %d is empty", i)
-		}
-		// TODO: Add more sophisticated IP validation if needed
+	if err := c.Backend.Validate(c); err != nil {
+		return fmt.Errorf("invalid backend configuration: %w", err)
 	}
 
 	if c.AssignedWorkspace == "" {
@@ -156,6 +178,10 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid health check configuration: %w", err)
 	}
 
+	if err := c.Monitor.Validate(); err != nil {
+		return fmt.Errorf("invalid monitor configuration: %w", err)
+	}
+
 	return nil
 }
 
@@ -194,6 +220,25 @@ func (h *HealthCheck) Validate() error {
 		return fmt.Errorf("invalid timeout format '%s': %w", h.Timeout, err)
 	}
 
+	if h.Attempts < 0 || h.HealthyThreshold < 0 {
+		return fmt.Errorf("attempts and healthy_threshold must not be negative")
+	}
+	if h.Attempts == 0 {
+		h.Attempts = 3
+	}
+	if h.HealthyThreshold == 0 {
+		h.HealthyThreshold = 1
+	}
+	if h.HealthyThreshold > h.Attempts {
+		return fmt.Errorf("healthy_threshold %d exceeds attempts %d", h.HealthyThreshold, h.Attempts)
+	}
+	if h.Interval == "" {
+		h.Interval = "5s"
+	}
+	if _, err := time.ParseDuration(h.Interval); err != nil {
+		return fmt.Errorf("invalid interval format '%s': %w", h.Interval, err)
+	}
+
 	return nil
 }
 
@@ -210,13 +255,17 @@ func loadConfigFile(configPath string) (Config, error) {
 		return config, fmt.Errorf("configuration file does not exist: %s", configPath)
 	}
 
-	data, err := os.ReadFile(configPath)
+	content, err := os.ReadFile(configPath)
 	if err != nil {
 		return config, fmt.Errorf("failed to read config file: %w", err)
 	}
+	data, err := configfile.ToJSON(configPath, content)
+	if err != nil {
+		return config, err
+	}
 
-	if err := json.Unmarshal(data, &config); err != nil {
-		return config, fmt.Errorf("failed to unmarshal config: %w", err)
+	if err := configfile.Unmarshal(configPath, content, data, &config); err != nil {
+		return config, err
 	}
 
 	// Validate the configuration
@@ -0,0 +1,79 @@
+package environment
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadAllEnvironmentsConfigFormats(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+
+	files := map[string]string{
+		"production.yaml": `# Production, switched between blue and green
+domain: example.com
+reserved_ips: [203.0.113.10]
+assigned_workspace: blue # Live workspace
+healthcheck:
+  type: http
+  port: 8080
+`,
+		"staging.hcl": `domain = "staging.example.com"
+reserved_ips = ["203.0.113.20"]
+assigned_workspace = "staging-blue"
+healthcheck = { type = "tcp", port = 22 }
+`,
+		"provisioner.json":   `{}`,
+		"notifications.yaml": "channels: {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(configDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	environments, err := LoadAllEnvironments()
+	if err != nil {
+		t.Fatalf("failed to load environments: %v", err)
+	}
+	if len(environments) != 2 || environments[0].Name != "production" || environments[1].Name != "staging" {
+		t.Fatalf("expected the production and staging environments, got %+v", environments)
+	}
+	if healthCheck := environments[0].Config.HealthCheck; healthCheck.Type != "http" || healthCheck.Port != 8080 {
+		t.Errorf("unexpected health check %+v", healthCheck)
+	}
+	if !EnvironmentExists("production") || EnvironmentExists("development") {
+		t.Error("expected only production to exist")
+	}
+
+	// Switching rewrites the YAML file, keeping its comments
+	env, err := LoadEnvironment("production")
+	if err != nil {
+		t.Fatalf("failed to load environment: %v", err)
+	}
+	env.Config.AssignedWorkspace = "green"
+	if err := env.SaveEnvironment(); err != nil {
+		t.Fatalf("failed to save environment: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(configDir, "production.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "assigned_workspace: green # Live workspace") {
+		t.Errorf("expected the assignment to keep its comment, got:\n%s", data)
+	}
+	if env, err := LoadEnvironment("production"); err != nil || env.Config.AssignedWorkspace != "green" {
+		t.Errorf("expected the saved environment to load, got %v", err)
+	}
+
+	// HCL environments cannot be switched by rewriting them
+	staging, err := LoadEnvironment("staging")
+	if err != nil {
+		t.Fatalf("failed to load environment: %v", err)
+	}
+	if err := staging.SaveEnvironment(); err == nil {
+		t.Error("expected saving an HCL environment to fail")
+	}
+}
@@ -0,0 +1,244 @@
+package environment
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// route53Backend points an A record at the targets' IPs with the aws CLI. Clients still
+// resolving the old record reach the previous workspace until the TTL expires.
+type route53Backend struct {
+	config BackendConfig
+}
+
+// route53RecordSet is an A record set as used by the Route53 API
+type route53RecordSet struct {
+	Name            string `json:"Name"`
+	Type            string `json:"Type"`
+	TTL             int    `json:"TTL"`
+	ResourceRecords []struct {
+		Value string `json:"Value"`
+	} `json:"ResourceRecords"`
+}
+
+// current returns the record set, or nil if the record has no A record
+func (b *route53Backend) current() (*route53RecordSet, error) {
+	output, err := runCommand(30*time.Second, "aws", "route53", "list-resource-record-sets",
+		"--hosted-zone-id", b.config.ZoneID, "--start-record-name", b.config.Record, "--start-record-type", "A",
+		"--max-items", "1", "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get Route53 record %s: %w", b.config.Record, err)
+	}
+
+	var result struct {
+		ResourceRecordSets []route53RecordSet `json:"ResourceRecordSets"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse Route53 records: %w", err)
+	}
+	for _, recordSet := range result.ResourceRecordSets {
+		if strings.TrimSuffix(recordSet.Name, ".") == strings.TrimSuffix(b.config.Record, ".") && recordSet.Type == "A" {
+			return &recordSet, nil
+		}
+	}
+	return nil, nil
+}
+
+func (b *route53Backend) Targets() ([]string, error) {
+	recordSet, err := b.current()
+	if err != nil || recordSet == nil {
+		return nil, err
+	}
+	targets := make([]string, 0, len(recordSet.ResourceRecords))
+	for _, record := range recordSet.ResourceRecords {
+		targets = append(targets, record.Value)
+	}
+	return targets, nil
+}
+
+// Switch upserts the A record with the targets, or deletes it for no targets, and waits for
+// the change to reach Route53's name servers
+func (b *route53Backend) Switch(targets []string) error {
+	recordSet := route53RecordSet{Name: b.config.Record, Type: "A", TTL: b.config.TTL}
+	action := "UPSERT"
+	if len(targets) == 0 {
+		current, err := b.current()
+		if err != nil || current == nil {
+			return err
+		}
+		action, recordSet = "DELETE", *current
+	}
+	for _, target := range targets {
+		recordSet.ResourceRecords = append(recordSet.ResourceRecords, struct {
+			Value string `json:"Value"`
+		}{target})
+	}
+
+	changeBatch, err := json.Marshal(map[string]interface{}{
+		"Comment": "provisioner environment switch",
+		"Changes": []map[string]interface{}{{"Action": action, "ResourceRecordSet": recordSet}},
+	})
+	if err != nil {
+		return err
+	}
+	output, err := runCommand(60*time.Second, "aws", "route53", "change-resource-record-sets",
+		"--hosted-zone-id", b.config.ZoneID, "--change-batch", string(changeBatch), "--output", "json")
+	if err != nil {
+		return fmt.Errorf("failed to update Route53 record %s: %w", b.config.Record, err)
+	}
+
+	var change struct {
+		ChangeInfo struct {
+			ID string `json:"Id"`
+		} `json:"ChangeInfo"`
+	}
+	if err := json.Unmarshal([]byte(output), &change); err != nil || change.ChangeInfo.ID == "" {
+		return fmt.Errorf("failed to parse Route53 change of record %s: %v", b.config.Record, err)
+	}
+	if _, err := runCommand(5*time.Minute, "aws", "route53", "wait", "resource-record-sets-changed", "--id", change.ChangeInfo.ID); err != nil {
+		return fmt.Errorf("Route53 change of record %s did not complete: %w", b.config.Record, err)
+	}
+	return nil
+}
+
+// Endpoints returns the targets: resolvers may serve the old record until its TTL expires
+func (b *route53Backend) Endpoints(targets []string) []string {
+	return targets
+}
+
+func (b *route53Backend) TargetsAreIPs() bool {
+	return true
+}
+
+// cloudflareAPI is the Cloudflare API base URL, replaced in tests
+var cloudflareAPI = "https://api.cloudflare.com/client/v4"
+
+// cloudflareBackend keeps one A record per target through the Cloudflare API
+type cloudflareBackend struct {
+	config BackendConfig
+}
+
+// cloudflareRecord is a DNS record of the Cloudflare API
+type cloudflareRecord struct {
+	ID      string `json:"id,omitempty"`
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	TTL     int    `json:"ttl"`
+}
+
+// request calls the Cloudflare API and decodes the result into result if not nil
+func (b *cloudflareBackend) request(method, path string, body, result interface{}) error {
+	token := os.Getenv(b.config.TokenEnv)
+	if token == "" {
+		return fmt.Errorf("environment variable %s with the Cloudflare API token is not set", b.config.TokenEnv)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, cloudflareAPI+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Cloudflare API request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var response struct {
+		Success bool                       `json:"success"`
+		Errors  []struct{ Message string } `json:"errors"`
+		Result  json.RawMessage            `json:"result"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&response); err != nil {
+		return fmt.Errorf("Cloudflare API returned %s", resp.Status)
+	}
+	if !response.Success {
+		messages := make([]string, 0, len(response.Errors))
+		for _, apiErr := range response.Errors {
+			messages = append(messages, apiErr.Message)
+		}
+		return fmt.Errorf("Cloudflare API returned %s: %s", resp.Status, strings.Join(messages, "; "))
+	}
+	if result != nil {
+		return json.Unmarshal(response.Result, result)
+	}
+	return nil
+}
+
+// records returns the A records of the configured name
+func (b *cloudflareBackend) records() ([]cloudflareRecord, error) {
+	var records []cloudflareRecord
+	query := url.Values{"type": {"A"}, "name": {b.config.Record}}
+	if err := b.request(http.MethodGet, fmt.Sprintf("/zones/%s/dns_records?%s", b.config.ZoneID, query.Encode()), nil, &records); err != nil {
+		return nil, fmt.Errorf("failed to get Cloudflare records of %s: %w", b.config.Record, err)
+	}
+	return records, nil
+}
+
+func (b *cloudflareBackend) Targets() ([]string, error) {
+	records, err := b.records()
+	if err != nil {
+		return nil, err
+	}
+	targets := make([]string, 0, len(records))
+	for _, record := range records {
+		targets = append(targets, record.Content)
+	}
+	return targets, nil
+}
+
+// Switch updates the existing A records to the targets, creating or deleting records so there is
+// one per target. Records are updated before surplus ones are deleted, so the name keeps resolving.
+func (b *cloudflareBackend) Switch(targets []string) error {
+	records, err := b.records()
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/zones/%s/dns_records", b.config.ZoneID)
+	for i, target := range targets {
+		record := cloudflareRecord{Type: "A", Name: b.config.Record, Content: target, TTL: b.config.TTL}
+		if i < len(records) {
+			err = b.request(http.MethodPut, path+"/"+records[i].ID, record, nil)
+		} else {
+			err = b.request(http.MethodPost, path, record, nil)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to point %s at %s: %w", b.config.Record, target, err)
+		}
+	}
+	for _, record := range records[min(len(targets), len(records)):] {
+		if err := b.request(http.MethodDelete, path+"/"+record.ID, nil, nil); err != nil {
+			return fmt.Errorf("failed to remove %s from %s: %w", record.Content, b.config.Record, err)
+		}
+	}
+	return nil
+}
+
+// Endpoints returns the targets: resolvers may serve the old records until their TTL expires
+func (b *cloudflareBackend) Endpoints(targets []string) []string {
+	return targets
+}
+
+func (b *cloudflareBackend) TargetsAreIPs() bool {
+	return true
+}
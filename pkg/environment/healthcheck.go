@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 // HealthCheckResult represents the result of a health check
@@ -46,6 +47,45 @@ func (h *HealthCheck) PerformHealthCheck(serverIP string) HealthCheckResult {
 	}
 }
 
+// CheckServer checks a server up to Attempts times, Interval apart, until HealthyThreshold
+// consecutive checks succeed. The result of the last check is returned; it fails once the
+// remaining attempts can no longer reach the threshold.
+func (h *HealthCheck) CheckServer(serverIP string) HealthCheckResult {
+	attempts, threshold := max(h.Attempts, 1), max(h.HealthyThreshold, 1)
+	interval, err := time.ParseDuration(h.Interval)
+	if err != nil && h.Interval != "" {
+		return HealthCheckResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Invalid interval configuration: %v", err),
+		}
+	}
+
+	var result HealthCheckResult
+	consecutive, attempt := 0, 0
+	for attempt < attempts {
+		if attempt > 0 {
+			time.Sleep(interval)
+		}
+		attempt++
+
+		result = h.PerformHealthCheck(serverIP)
+		if result.Success {
+			consecutive++
+		} else {
+			consecutive = 0
+		}
+		if consecutive >= threshold || consecutive+attempts-attempt < threshold {
+			break
+		}
+	}
+
+	if attempt > 1 {
+		result.Message = fmt.Sprintf("%s (after %d checks)", result.Message, attempt)
+	}
+	return result
+}
+
 // performHTTPCheck performs an HTTP health check
 func (h *HealthCheck) performHTTPCheck(ctx context.Context, serverIP string) HealthCheckResult {
 	// Construct URL
@@ -152,7 +192,7 @@ func (h *HealthCheck) performCommandCheck(ctx context.Context, serverIP string)
 	}
 }
 
-// PerformBulkHealthChecks performs health checks on multiple servers and returns results
+// PerformBulkHealthChecks checks multiple servers with CheckServer and returns results
 func (h *HealthCheck) PerformBulkHealthChecks(serverIPs []string) []HealthCheckResult {
 	results := make([]HealthCheckResult, len(serverIPs))
 
@@ -164,7 +204,7 @@ func (h *HealthCheck) PerformBulkHealthChecks(serverIPs []string) []HealthCheckR
 
 	for i, serverIP := range serverIPs {
 		go func(index int, ip string) {
-			result := h.PerformHealthCheck(ip)
+			result := h.CheckServer(ip)
 			resultChan <- struct {
 				index  int
 				result HealthCheckResult
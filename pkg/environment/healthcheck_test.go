@@ -0,0 +1,92 @@
+package environment
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// newFlakyServer serves /health, failing the first failures requests; returns the server's port
+func newFlakyServer(t *testing.T, failures int32) (int, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	_, port, _ := net.SplitHostPort(strings.TrimPrefix(server.URL, "http://"))
+	portNumber, _ := strconv.Atoi(port)
+	return portNumber, &requests
+}
+
+func TestCheckServerRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int32
+		attempts  int
+		threshold int
+		healthy   bool
+		requests  int32
+	}{
+		{"healthy at once", 0, 3, 1, true, 1},
+		{"recovers within attempts", 2, 3, 1, true, 3},
+		{"unhealthy after attempts", 5, 3, 1, false, 3},
+		{"needs consecutive successes", 1, 3, 2, true, 3},
+		{"gives up once the threshold is out of reach", 2, 3, 2, false, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, requests := newFlakyServer(t, tt.failures)
+			check := HealthCheck{Type: "http", Path: "/health", Port: port, Timeout: "1s", Attempts: tt.attempts, Interval: "1ms", HealthyThreshold: tt.threshold}
+			if err := check.Validate(); err != nil {
+				t.Fatalf("Validate failed: %v", err)
+			}
+
+			result := check.CheckServer("127.0.0.1")
+			if result.Success != tt.healthy {
+				t.Errorf("Expected healthy %t, got %+v", tt.healthy, result)
+			}
+			if requests.Load() != tt.requests {
+				t.Errorf("Expected %d requests, got %d", tt.requests, requests.Load())
+			}
+		})
+	}
+}
+
+func TestHealthCheckDefaults(t *testing.T) {
+	check := HealthCheck{Type: "tcp", Port: 22}
+	if err := check.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if check.Attempts != 3 || check.Interval != "5s" || check.HealthyThreshold != 1 || check.Timeout != "30s" {
+		t.Errorf("Unexpected defaults %+v", check)
+	}
+
+	check = HealthCheck{Type: "tcp", Port: 22, Attempts: 2, HealthyThreshold: 3}
+	if err := check.Validate(); err == nil {
+		t.Error("Expected a threshold above the attempts to be refused")
+	}
+}
+
+func TestMonitorValidate(t *testing.T) {
+	monitor := Monitor{}
+	if err := monitor.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if monitor.Interval != "5m" || monitor.FailureThreshold != 3 {
+		t.Errorf("Expected a 5m interval and a threshold of 3 by default, got %+v", monitor)
+	}
+
+	for _, invalid := range []Monitor{{Interval: "often"}, {Interval: "0s"}, {FailureThreshold: -1}} {
+		if err := invalid.Validate(); err == nil {
+			t.Errorf("Expected %+v to be invalid", invalid)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package environment
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// loadBalancerBackend swaps the targets of an AWS target group with the aws CLI: the new targets
+// are registered and must pass the target group's health checks before the old ones are
+// deregistered
+type loadBalancerBackend struct {
+	config BackendConfig
+}
+
+func (b *loadBalancerBackend) Targets() ([]string, error) {
+	output, err := runCommand(30*time.Second, "aws", "elbv2", "describe-target-health",
+		"--target-group-arn", b.config.TargetGroupARN, "--output", "json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get targets of %s: %w", b.config.TargetGroupARN, err)
+	}
+
+	var result struct {
+		TargetHealthDescriptions []struct {
+			Target struct {
+				ID string `json:"Id"`
+			} `json:"Target"`
+			TargetHealth struct {
+				State string `json:"State"`
+			} `json:"TargetHealth"`
+		} `json:"TargetHealthDescriptions"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return nil, fmt.Errorf("failed to parse targets of %s: %w", b.config.TargetGroupARN, err)
+	}
+
+	var targets []string
+	for _, description := range result.TargetHealthDescriptions {
+		// Targets on their way out of a previous swap no longer receive traffic
+		if description.TargetHealth.State != "draining" {
+			targets = append(targets, description.Target.ID)
+		}
+	}
+	return targets, nil
+}
+
+func (b *loadBalancerBackend) Switch(targets []string) error {
+	current, err := b.Targets()
+	if err != nil {
+		return err
+	}
+
+	keep := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		keep[target] = true
+	}
+	var remove []string
+	for _, target := range current {
+		if !keep[target] {
+			remove = append(remove, target)
+		}
+	}
+
+	if len(targets) > 0 {
+		args := b.targetArgs(targets)
+		if _, err := runCommand(60*time.Second, "aws", append([]string{"elbv2", "register-targets"}, args...)...); err != nil {
+			return fmt.Errorf("failed to register targets with %s: %w", b.config.TargetGroupARN, err)
+		}
+		if _, err := runCommand(10*time.Minute, "aws", append([]string{"elbv2", "wait", "target-in-service"}, args...)...); err != nil {
+			return fmt.Errorf("targets did not become healthy in %s: %w", b.config.TargetGroupARN, err)
+		}
+	}
+	if len(remove) > 0 {
+		if _, err := runCommand(60*time.Second, "aws", append([]string{"elbv2", "deregister-targets"}, b.targetArgs(remove)...)...); err != nil {
+			return fmt.Errorf("failed to deregister targets from %s: %w", b.config.TargetGroupARN, err)
+		}
+	}
+	return nil
+}
+
+// targetArgs returns the target group and --targets arguments of the aws CLI for targets
+func (b *loadBalancerBackend) targetArgs(targets []string) []string {
+	args := []string{"--target-group-arn", b.config.TargetGroupARN, "--targets"}
+	for _, target := range targets {
+		if b.config.Port > 0 {
+			args = append(args, fmt.Sprintf("Id=%s,Port=%d", target, b.config.Port))
+		} else {
+			args = append(args, "Id="+target)
+		}
+	}
+	return args
+}
+
+// Endpoints returns the load balancer's address, which serves the new targets once they are
+// in service
+func (b *loadBalancerBackend) Endpoints(targets []string) []string {
+	return []string{b.config.Endpoint}
+}
+
+func (b *loadBalancerBackend) TargetsAreIPs() bool {
+	return false
+}
@@ -0,0 +1,60 @@
+package environment
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Monitor configures the daemon's periodic health checks of an environment
+type Monitor struct {
+	Disabled         bool   `json:"disabled,omitempty"`          // Don't check the environment from the daemon
+	Interval         string `json:"interval,omitempty"`          // Time between checks (default "5m")
+	FailureThreshold int    `json:"failure_threshold,omitempty"` // Consecutive failed checks before the environment is degraded (default 3)
+}
+
+// Validate checks the monitor configuration and fills in defaults
+func (m *Monitor) Validate() error {
+	if m.Interval == "" {
+		m.Interval = "5m"
+	}
+	interval, err := time.ParseDuration(m.Interval)
+	if err != nil {
+		return fmt.Errorf("invalid interval format '%s': %w", m.Interval, err)
+	}
+	if interval <= 0 {
+		return fmt.Errorf("interval must be positive: %s", m.Interval)
+	}
+
+	if m.FailureThreshold < 0 {
+		return fmt.Errorf("failure_threshold must not be negative: %d", m.FailureThreshold)
+	}
+	if m.FailureThreshold == 0 {
+		m.FailureThreshold = 3
+	}
+	return nil
+}
+
+// GetIntervalDuration returns the time between checks as a time.Duration
+func (m *Monitor) GetIntervalDuration() (time.Duration, error) {
+	return time.ParseDuration(m.Interval)
+}
+
+// CheckHealth runs the environment's health check against the endpoints currently serving its
+// traffic, returning an error listing the servers that failed
+func (e *Environment) CheckHealth() error {
+	endpoints, err := e.CurrentEndpoints()
+	if err != nil {
+		return err
+	}
+	if len(endpoints) == 0 {
+		return fmt.Errorf("no endpoints to check, %s point nowhere", e.Config.Backend)
+	}
+
+	results := e.Config.HealthCheck.PerformBulkHealthChecks(endpoints)
+	if failures := GetFailedHealthChecks(results, endpoints); len(failures) > 0 {
+		return errors.New(strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -3,20 +3,25 @@ package environment
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
+	"provisioner/pkg/configfile"
 	"provisioner/pkg/opentofu"
 	"provisioner/pkg/workspace"
 )
 
-// SwitchOperation represents a Reserved IP switching operation
+// SwitchOperation represents a traffic switching operation
 type SwitchOperation struct {
 	Environment     *Environment
 	TargetWorkspace string
 	LoadBalancers   []string // Server IDs/IPs from Terraform output
+	Backend         Backend  // Defaults to the environment's configured backend
 }
 
 // SwitchResult represents the result of a switching operation
@@ -24,25 +29,30 @@ type SwitchResult struct {
 	Success          bool
 	Error            error
 	Message          string
-	RollbackRequired bool
+	RollbackRequired bool // A rollback was needed but failed, traffic must be checked manually
+	RolledBack       bool // Traffic was returned to its original servers
 	RollbackData     *RollbackData
 }
 
 // RollbackData contains information needed to rollback a partial switch
 type RollbackData struct {
 	OriginalWorkspace string
-	IPAssignments     []IPAssignment
-}
-
-// IPAssignment represents the assignment of a Reserved IP to a server
-type IPAssignment struct {
-	ReservedIP string
-	ServerID   string
-	Success    bool
+	OriginalTargets   []string // Servers traffic went to before the switch
+	Targets           []string // Servers traffic was switched to
 }
 
 // PerformSwitch executes the environment switch operation
 func (so *SwitchOperation) PerformSwitch() SwitchResult {
+	// The assignment is written back after the switch, which HCL files don't allow
+	if !configfile.Writable(so.Environment.Path) {
+		err := fmt.Errorf("%s cannot record the new assignment, use JSON or YAML", filepath.Base(so.Environment.Path))
+		return SwitchResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Environment config is read-only: %v", err),
+		}
+	}
+
 	// Step 1: Validate target workspace
 	if err := so.validateTargetWorkspace(); err != nil {
 		return SwitchResult{
@@ -65,7 +75,7 @@ func (so *SwitchOperation) PerformSwitch() SwitchResult {
 	so.LoadBalancers = loadBalancers
 
 	// Step 3: Validate sufficient load balancers
-	if len(so.LoadBalancers) < len(so.Environment.Config.ReservedIPs) {
+	if so.Environment.Config.Backend.Type == BackendReservedIP && len(so.LoadBalancers) < len(so.Environment.Config.ReservedIPs) {
 		return SwitchResult{
 			Success: false,
 			Error:   fmt.Errorf("insufficient load balancers"),
@@ -82,7 +92,7 @@ func (so *SwitchOperation) PerformSwitch() SwitchResult {
 		}
 	}
 
-	// Step 5: Perform atomic Reserved IP switch with rollback capability
+	// Step 5: Switch traffic with rollback capability
 	return so.performAtomicSwitch()
 }
 
@@ -240,50 +250,64 @@ func (so *SwitchOperation) getDropletIP(dropletID string) (string, error) {
 	return dropletID, nil
 }
 
-// performAtomicSwitch executes the Reserved IP switching with rollback capability
+// performAtomicSwitch switches traffic to the load balancers, checks the environment's endpoints
+// afterwards and returns traffic to the original servers if the switch or the checks fail
 func (so *SwitchOperation) performAtomicSwitch() SwitchResult {
-	originalWorkspace := so.Environment.Config.AssignedWorkspace
-
-	// Prepare rollback data
-	rollbackData := &RollbackData{
-		OriginalWorkspace: originalWorkspace,
-		IPAssignments:     make([]IPAssignment, len(so.Environment.Config.ReservedIPs)),
+	backend := so.Backend
+	if backend == nil {
+		backend = NewBackend(so.Environment.Config)
 	}
 
-	// Perform IP reassignments
-	for i, reservedIP := range so.Environment.Config.ReservedIPs {
-		targetServerID := so.LoadBalancers[i]
-
-		// Assign Reserved IP to new server
-		err := so.assignReservedIP(reservedIP, targetServerID)
-		rollbackData.IPAssignments[i] = IPAssignment{
-			ReservedIP: reservedIP,
-			ServerID:   targetServerID,
-			Success:    err == nil,
-		}
-
+	targets := so.LoadBalancers
+	if backend.TargetsAreIPs() {
+		ips, err := so.resolveLoadBalancerIPs()
 		if err != nil {
-			// Switch failed, perform rollback
-			so.performRollback(rollbackData)
 			return SwitchResult{
-				Success:          false,
-				Error:            err,
-				Message:          fmt.Sprintf("Reserved IP assignment failed for %s: %v", reservedIP, err),
-				RollbackRequired: true,
-				RollbackData:     rollbackData,
+				Success: false,
+				Error:   err,
+				Message: fmt.Sprintf("Failed to resolve load balancer IPs: %v", err),
 			}
 		}
+		targets = ips
+	}
+
+	original, err := backend.Targets()
+	if err != nil {
+		return SwitchResult{
+			Success: false,
+			Error:   err,
+			Message: fmt.Sprintf("Failed to look up where traffic currently goes: %v", err),
+		}
+	}
+	rollbackData := &RollbackData{
+		OriginalWorkspace: so.Environment.Config.AssignedWorkspace,
+		OriginalTargets:   original,
+		Targets:           targets,
 	}
 
-	// All IP assignments successful, update environment config
+	if err := backend.Switch(targets); err != nil {
+		return so.rollbackResult(backend, rollbackData, err, fmt.Sprintf("Traffic switch failed: %v", err))
+	}
+
+	// Traffic now goes to the target workspace, check it arrives there
+	endpoints := backend.Endpoints(targets)
+	fmt.Printf("Checking %s after the switch...\n", strings.Join(endpoints, ", "))
+	results := so.Environment.Config.HealthCheck.PerformBulkHealthChecks(endpoints)
+	if !AllHealthy(results) {
+		failures := GetFailedHealthChecks(results, endpoints)
+		err := fmt.Errorf("post-switch health check failures:\n%s", strings.Join(failures, "\n"))
+		return so.rollbackResult(backend, rollbackData, err, "Post-switch health checks failed")
+	}
+
+	// Traffic switched, update environment config
 	so.Environment.Config.AssignedWorkspace = so.TargetWorkspace
 	if err := so.Environment.SaveEnvironment(); err != nil {
-		// Config update failed, but IPs are already switched
+		// Config update failed, but traffic is already switched
 		// This is a partial success state
 		return SwitchResult{
 			Success: false,
 			Error:   err,
-			Message: fmt.Sprintf("Reserved IPs switched successfully, but failed to update config: %v", err),
+			Message: fmt.Sprintf("Traffic switched successfully, but failed to update config: %v", err),
 		}
 	}
 
@@ -293,46 +317,28 @@ func (so *SwitchOperation) performAtomicSwitch() SwitchResult {
 	}
 }
 
-// assignReservedIP assigns a Reserved IP to a specific server
-func (so *SwitchOperation) assignReservedIP(reservedIP, serverID string) error {
-	// Use DigitalOcean CLI or API to assign Reserved IP
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "doctl", "compute", "reserved-ip-action", "assign", reservedIP, "--resource", serverID, "--wait")
-
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to assign Reserved IP %s to server %s: %w\nOutput: %s", reservedIP, serverID, err, string(output))
-	}
-
-	return nil
-}
-
-// performRollback attempts to rollback partial IP assignments
-func (so *SwitchOperation) performRollback(rollbackData *RollbackData) {
-	// This is a best-effort rollback
-	// In a production system, you'd want more sophisticated rollback logic
-
+// rollbackResult rolls back a failed switch and describes the outcome
+func (so *SwitchOperation) rollbackResult(backend Backend, rollbackData *RollbackData, cause error, message string) SwitchResult {
 	fmt.Printf("Performing rollback for environment '%s'...\n", so.Environment.Name)
-
-	// Try to get the original server assignments
-	// This would require storing the original assignments or querying them
-	// For now, we'll log the rollback attempt
-	for _, assignment := range rollbackData.IPAssignments {
-		if assignment.Success {
-			fmt.Printf("Attempting to rollback Reserved IP %s assignment...\n", assignment.ReservedIP)
-			// In a real implementation, you'd reassign the IP back to the original server
+	if err := backend.Switch(rollbackData.OriginalTargets); err != nil {
+		return SwitchResult{
+			Success:          false,
+			Error:            errors.Join(cause, fmt.Errorf("rollback failed: %w", err)),
+			Message:          fmt.Sprintf("%s and the rollback failed", message),
+			RollbackRequired: true,
+			RollbackData:     rollbackData,
 		}
 	}
+	return SwitchResult{
+		Success:      false,
+		Error:        cause,
+		Message:      fmt.Sprintf("%s, switch rolled back to workspace '%s'", message, rollbackData.OriginalWorkspace),
+		RolledBack:   true,
+		RollbackData: rollbackData,
+	}
 }
 
-// isIPAddress checks if a string is a valid IP address (simple check)
+// isIPAddress checks if a string is a valid IP address
 func isIPAddress(str string) bool {
-	parts := strings.Split(str, ".")
-	if len(parts) != 4 {
-		return false
-	}
-	// This is a very basic check - in production you'd use net.ParseIP
-	return true
-}
\ No newline at end of file
+	return net.ParseIP(str) != nil
+}
@@ -0,0 +1,118 @@
+package environment
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeProvider keeps Reserved IP assignments in memory, failing assignments to failServer
+type fakeProvider struct {
+	assigned   map[string]string
+	failServer string
+}
+
+func (p *fakeProvider) AssignedServer(reservedIP string) (string, error) {
+	return p.assigned[reservedIP], nil
+}
+
+func (p *fakeProvider) Assign(reservedIP, serverID string) error {
+	if serverID == p.failServer {
+		return fmt.Errorf("server %s is unavailable", serverID)
+	}
+	p.assigned[reservedIP] = serverID
+	return nil
+}
+
+func (p *fakeProvider) Unassign(reservedIP string) error {
+	delete(p.assigned, reservedIP)
+	return nil
+}
+
+// newTestSwitch switches an environment served by blue, with Reserved IP 127.0.0.1 checked on port, to green
+func newTestSwitch(t *testing.T, port int, provider *fakeProvider) *SwitchOperation {
+	t.Helper()
+	env := &Environment{
+		Name: "production",
+		Path: filepath.Join(t.TempDir(), "production.json"),
+		Config: Config{
+			Domain:            "example.com",
+			ReservedIPs:       []string{"127.0.0.1"},
+			AssignedWorkspace: "blue",
+			HealthCheck:       HealthCheck{Type: "http", Path: "/health", Port: port, Timeout: "1s", Attempts: 2, Interval: "1ms"},
+		},
+	}
+	if err := env.Config.Validate(); err != nil {
+		t.Fatalf("Invalid test environment: %v", err)
+	}
+	return &SwitchOperation{Environment: env, TargetWorkspace: "green", LoadBalancers: []string{"green-1"},
+		Backend: NewReservedIPBackend(env.Config.ReservedIPs, provider)}
+}
+
+func TestSwitchChecksReservedIPs(t *testing.T) {
+	port, _ := newFlakyServer(t, 0)
+	provider := &fakeProvider{assigned: map[string]string{"127.0.0.1": "blue-1"}}
+	switchOp := newTestSwitch(t, port, provider)
+
+	result := switchOp.performAtomicSwitch()
+	if !result.Success {
+		t.Fatalf("Expected the switch to succeed, got %+v", result)
+	}
+	if provider.assigned["127.0.0.1"] != "green-1" || switchOp.Environment.Config.AssignedWorkspace != "green" {
+		t.Errorf("Expected the Reserved IP and config to be switched, got %v", provider.assigned)
+	}
+	saved, err := loadConfigFile(switchOp.Environment.Path)
+	if err != nil || saved.AssignedWorkspace != "green" {
+		t.Errorf("Expected the saved config to be switched, got %+v (%v)", saved, err)
+	}
+}
+
+func TestSwitchRollsBackFailedPostSwitchChecks(t *testing.T) {
+	port, _ := newFlakyServer(t, 10)
+	provider := &fakeProvider{assigned: map[string]string{"127.0.0.1": "blue-1"}}
+	switchOp := newTestSwitch(t, port, provider)
+
+	result := switchOp.performAtomicSwitch()
+	if result.Success || !result.RolledBack || result.RollbackRequired {
+		t.Fatalf("Expected the switch to be rolled back, got %+v", result)
+	}
+	if !strings.Contains(result.Error.Error(), "post-switch") {
+		t.Errorf("Expected the failed checks to be reported, got %v", result.Error)
+	}
+	if provider.assigned["127.0.0.1"] != "blue-1" || switchOp.Environment.Config.AssignedWorkspace != "blue" {
+		t.Errorf("Expected the Reserved IP to be returned to blue, got %v", provider.assigned)
+	}
+}
+
+func TestSwitchRollsBackFailedAssignment(t *testing.T) {
+	port, _ := newFlakyServer(t, 0)
+	provider := &fakeProvider{assigned: map[string]string{"127.0.0.1": "blue-1"}}
+	switchOp := newTestSwitch(t, port, provider)
+	switchOp.Environment.Config.ReservedIPs = []string{"127.0.0.1", "127.0.0.2"}
+	switchOp.LoadBalancers = []string{"green-1", "green-2"}
+	switchOp.Backend = NewReservedIPBackend(switchOp.Environment.Config.ReservedIPs, provider)
+	provider.failServer = "green-2"
+
+	result := switchOp.performAtomicSwitch()
+	if result.Success || !result.RolledBack {
+		t.Fatalf("Expected the switch to be rolled back, got %+v", result)
+	}
+	if provider.assigned["127.0.0.1"] != "blue-1" {
+		t.Errorf("Expected the switched Reserved IP to be returned, got %v", provider.assigned)
+	}
+	if _, assigned := provider.assigned["127.0.0.2"]; assigned {
+		t.Errorf("Expected the unassigned Reserved IP to stay unassigned, got %v", provider.assigned)
+	}
+
+	// A rollback that fails too is reported for manual recovery
+	provider.failServer = "blue-1"
+	switchOp.LoadBalancers = []string{"green-1", "green-2"}
+	switchOp.Environment.Config.ReservedIPs = []string{"127.0.0.1"}
+	switchOp.Backend = NewReservedIPBackend(switchOp.Environment.Config.ReservedIPs, provider)
+	port, _ = newFlakyServer(t, 10)
+	switchOp.Environment.Config.HealthCheck.Port = port
+	if result := switchOp.performAtomicSwitch(); !result.RollbackRequired || result.RolledBack {
+		t.Errorf("Expected the failed rollback to be reported, got %+v", result)
+	}
+}
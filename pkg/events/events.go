@@ -0,0 +1,111 @@
+// Package events is the daemon's internal event bus. The scheduler publishes what happened to
+// workspaces and jobs; job triggers, notifications, the audit log and success-rate objectives
+// subscribe to it, and new reactions are added as further subscribers.
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Type identifies an event
+type Type string
+
+// Published events
+const (
+	WorkspaceDeployed  Type = "workspace_deployed"
+	DeployFailed       Type = "deploy_failed"
+	DeployCancelled    Type = "deploy_cancelled"
+	DeployDegraded     Type = "deploy_degraded" // A deploy applied its changes, but a post_deploy hook failed
+	WorkspaceDestroyed Type = "workspace_destroyed"
+	DestroyFailed      Type = "destroy_failed"
+	DestroyCancelled   Type = "destroy_cancelled"
+	JobCompleted       Type = "job_completed"   // A job run finished, whatever its outcome
+	ConfigReloaded     Type = "config_reloaded" // A workspace's config was reloaded; the time is its modification
+	ModeChanged        Type = "mode_changed"    // A deploy put a workspace in another mode
+)
+
+// Event is something that happened to a workspace or job. Fields that do not apply to the
+// event's type are empty.
+type Event struct {
+	Type          Type
+	Time          time.Time
+	Workspace     string
+	Mode          string // Deploy mode, or the mode entered for ModeChanged
+	PreviousMode  string // Mode left for ModeChanged, empty if the workspace was not deployed
+	Trigger       string // What started a mode change: schedule or manual
+	Job           string
+	JobStatus     string
+	OnFailure     string // What the job's config does about a failed run: continue, abort-dependents or notify
+	CorrelationID string
+	Err           error // Why an operation or job failed or was cancelled
+}
+
+// Handler reacts to an event
+type Handler func(Event)
+
+type subscription struct {
+	id      int
+	handler Handler
+	types   map[Type]bool // nil for all events
+}
+
+// Bus delivers published events to their subscribers
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions []subscription
+	nextID        int
+}
+
+// NewBus creates an event bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls handler for every published event of the given types, or of any type if none
+// are given. The returned function removes the subscription.
+func (b *Bus) Subscribe(handler Handler, types ...Type) func() {
+	sub := subscription{handler: handler}
+	if len(types) > 0 {
+		sub.types = make(map[Type]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	sub.id = b.nextID
+	b.subscriptions = append(b.subscriptions, sub)
+	b.mu.Unlock()
+
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		for i, s := range b.subscriptions {
+			if s.id == sub.id {
+				b.subscriptions = append(b.subscriptions[:i:i], b.subscriptions[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Publish delivers an event to its subscribers in the order they subscribed, before returning.
+// Handlers run on the publisher's goroutine, so they see the state the event was published in
+// and may publish events themselves. The event's time defaults to now.
+func (b *Bus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	subscriptions := b.subscriptions
+	b.mu.RUnlock()
+
+	for _, sub := range subscriptions {
+		if sub.types == nil || sub.types[event.Type] {
+			sub.handler(event)
+		}
+	}
+}
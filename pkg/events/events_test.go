@@ -0,0 +1,94 @@
+package events
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPublishDeliversToMatchingSubscribers(t *testing.T) {
+	bus := NewBus()
+
+	var all, failures []Type
+	bus.Subscribe(func(e Event) { all = append(all, e.Type) })
+	bus.Subscribe(func(e Event) { failures = append(failures, e.Type) }, DeployFailed, DestroyFailed)
+
+	bus.Publish(Event{Type: WorkspaceDeployed, Workspace: "web"})
+	bus.Publish(Event{Type: DeployFailed, Workspace: "web", Err: errors.New("boom")})
+	bus.Publish(Event{Type: DestroyFailed, Workspace: "web"})
+
+	if want := []Type{WorkspaceDeployed, DeployFailed, DestroyFailed}; !reflect.DeepEqual(all, want) {
+		t.Errorf("Expected all events %v, got %v", want, all)
+	}
+	if want := []Type{DeployFailed, DestroyFailed}; !reflect.DeepEqual(failures, want) {
+		t.Errorf("Expected failure events %v, got %v", want, failures)
+	}
+}
+
+func TestPublishRunsHandlersInSubscriptionOrder(t *testing.T) {
+	bus := NewBus()
+
+	var order []string
+	bus.Subscribe(func(Event) { order = append(order, "audit") })
+	bus.Subscribe(func(Event) { order = append(order, "notify") })
+
+	bus.Publish(Event{Type: JobCompleted})
+
+	if want := []string{"audit", "notify"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("Expected handlers to run in order %v, got %v", want, order)
+	}
+}
+
+func TestPublishSetsTime(t *testing.T) {
+	bus := NewBus()
+
+	var got Event
+	bus.Subscribe(func(e Event) { got = e })
+
+	bus.Publish(Event{Type: ConfigReloaded})
+	if got.Time.IsZero() {
+		t.Error("Expected the event time to default to now")
+	}
+
+	at := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	bus.Publish(Event{Type: ConfigReloaded, Time: at})
+	if !got.Time.Equal(at) {
+		t.Errorf("Expected the event time %v to be kept, got %v", at, got.Time)
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	bus := NewBus()
+
+	var first, second int
+	unsubscribe := bus.Subscribe(func(Event) { first++ })
+	bus.Subscribe(func(Event) { second++ })
+
+	bus.Publish(Event{Type: ModeChanged})
+	unsubscribe()
+	unsubscribe() // Removing twice is harmless
+	bus.Publish(Event{Type: ModeChanged})
+
+	if first != 1 || second != 2 {
+		t.Errorf("Expected the removed handler to see 1 event and the other 2, got %d and %d", first, second)
+	}
+}
+
+func TestHandlersCanPublish(t *testing.T) {
+	bus := NewBus()
+
+	var seen []Type
+	bus.Subscribe(func(e Event) {
+		seen = append(seen, e.Type)
+		if e.Type == WorkspaceDeployed {
+			bus.Publish(Event{Type: ModeChanged, Workspace: e.Workspace})
+		}
+	})
+
+	bus.Publish(Event{Type: WorkspaceDeployed, Workspace: "web"})
+
+	if want := []Type{WorkspaceDeployed, ModeChanged}; !reflect.DeepEqual(seen, want) {
+		t.Errorf("Expected %v, got %v", want, seen)
+	}
+}
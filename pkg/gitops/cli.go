@@ -0,0 +1,51 @@
+package gitops
+
+import (
+	"fmt"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/paths"
+)
+
+// RunStatusCommand shows the repository configs are synced from and the last sync
+func RunStatusCommand(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("gitops takes no arguments")
+	}
+
+	config, err := Load(paths.ConfigDir())
+	if err != nil {
+		return err
+	}
+	if config == nil {
+		fmt.Println("GitOps is not configured; workspaces, jobs and environments are edited locally")
+		return nil
+	}
+
+	fmt.Printf("Repository:  %s\n", config.Repository)
+	fmt.Printf("Branch:      %s\n", config.GetBranch())
+	if config.Path != "" {
+		fmt.Printf("Path:        %s\n", config.Path)
+	}
+	fmt.Printf("Interval:    %s\n", config.GetInterval())
+
+	state, err := LoadState(paths.StateDir())
+	if err != nil {
+		return err
+	}
+	if state.Commit == "" {
+		fmt.Println("Commit:      - (not synced yet)")
+	} else {
+		fmt.Printf("Commit:      %s (%d files)\n", state.Commit, len(state.Files))
+	}
+	if state.SyncedAt != nil {
+		fmt.Printf("Last sync:   %s\n", logging.FormatTime(*state.SyncedAt))
+	}
+	if state.LastError != "" {
+		if state.LastAttempt != nil {
+			fmt.Printf("Failed:      %s\n", logging.FormatTime(*state.LastAttempt))
+		}
+		fmt.Printf("Error:       %s\n", state.LastError)
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package gitops syncs the workspace, job and environment configs from a git repository into the
+// config directory, so the repository is their source of truth (gitops in provisioner.json).
+package gitops
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"provisioner/pkg/paths"
+	"provisioner/pkg/statefile"
+)
+
+// DefaultBranch is the branch followed when none is configured
+const DefaultBranch = "main"
+
+// DefaultInterval is how often the repository is synced when no interval is configured
+const DefaultInterval = time.Minute
+
+// StateFile records the last sync in the state directory
+const StateFile = "gitops.json"
+
+// Config syncs configs from a git repository (gitops in provisioner.json)
+type Config struct {
+	Repository string `json:"repository"`          // URL of the git repository
+	Branch     string `json:"branch,omitempty"`    // Branch to follow, default main
+	Path       string `json:"path,omitempty"`      // Directory in the repository holding workspaces/, jobs/ and environments/, default its root
+	Interval   string `json:"interval,omitempty"`  // How often to sync, at least 1m, default 1m
+	SSHKey     string `json:"ssh_key,omitempty"`   // Private key for SSH repository URLs
+	TokenEnv   string `json:"token_env,omitempty"` // Environment variable holding an access token for HTTPS repository URLs
+}
+
+// Validate checks the GitOps settings for invalid values
+func (c *Config) Validate() error {
+	if c.Repository == "" {
+		return fmt.Errorf("'repository' is required")
+	}
+	if c.Path != "" {
+		clean := filepath.Clean(c.Path)
+		if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("path '%s' must be relative to the repository root", c.Path)
+		}
+	}
+	if c.Interval != "" {
+		interval, err := time.ParseDuration(c.Interval)
+		if err != nil {
+			return fmt.Errorf("invalid interval '%s'", c.Interval)
+		}
+		// The scheduler loop syncs at most once per minute
+		if interval < time.Minute {
+			return fmt.Errorf("interval must be at least 1m: %s", c.Interval)
+		}
+	}
+	if c.SSHKey != "" && c.TokenEnv != "" {
+		return fmt.Errorf("cannot specify both 'ssh_key' and 'token_env'")
+	}
+	return nil
+}
+
+// GetBranch returns the branch followed
+func (c *Config) GetBranch() string {
+	if c.Branch == "" {
+		return DefaultBranch
+	}
+	return c.Branch
+}
+
+// GetInterval returns how often the repository is synced
+func (c *Config) GetInterval() time.Duration {
+	if interval, err := time.ParseDuration(c.Interval); err == nil && interval >= time.Minute {
+		return interval
+	}
+	return DefaultInterval
+}
+
+// Load returns the GitOps settings of provisioner.json in configDir, nil if GitOps is not configured
+func Load(configDir string) (*Config, error) {
+	data, err := os.ReadFile(filepath.Join(configDir, "provisioner.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read daemon config: %w", err)
+	}
+
+	var daemonConfig struct {
+		GitOps *Config `json:"gitops"`
+	}
+	if err := json.Unmarshal(data, &daemonConfig); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon config: %w", err)
+	}
+	return daemonConfig.GitOps, nil
+}
+
+// CheckEditable returns an error if the configs are synced from a repository, so commands
+// changing workspace, job or environment configs are refused rather than overwritten by the next sync
+func CheckEditable() error {
+	config, err := Load(paths.ConfigDir())
+	if err != nil || config == nil {
+		// The daemon doesn't sync with an unreadable provisioner.json either
+		return nil
+	}
+	return fmt.Errorf("configs are synced from %s (branch %s); commit the change to the repository instead", config.Repository, config.GetBranch())
+}
+
+// State records the last sync of the repository
+type State struct {
+	Repository  string            `json:"repository"`
+	Branch      string            `json:"branch"`
+	Commit      string            `json:"commit,omitempty"`    // Commit the configs were last synced from
+	SyncedAt    *time.Time        `json:"synced_at,omitempty"` // Last successful sync
+	LastAttempt *time.Time        `json:"last_attempt,omitempty"`
+	LastError   string            `json:"last_error,omitempty"` // Error of the last sync, empty if it succeeded
+	Files       map[string]string `json:"files,omitempty"`      // SHA-256 of the synced files, by their path in the repository's config directory
+}
+
+// LoadState returns the last sync recorded in stateDir, an empty state if there was none
+func LoadState(stateDir string) (*State, error) {
+	path := filepath.Join(stateDir, StateFile)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return &State{}, nil
+	}
+	data, _, err := statefile.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GitOps state: %w", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse GitOps state: %w", err)
+	}
+	return &state, nil
+}
+
+// save writes the state into stateDir
+func (s *State) save(stateDir string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal GitOps state: %w", err)
+	}
+	if err := os.MkdirAll(stateDir, 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := statefile.Write(filepath.Join(stateDir, StateFile), data); err != nil {
+		return fmt.Errorf("failed to write GitOps state: %w", err)
+	}
+	return nil
+}
+
+// ConfigCommit returns the commit the configs were last synced from, empty without GitOps.
+// Deploys record it to tell which config change produced them.
+func ConfigCommit() string {
+	if config, err := Load(paths.ConfigDir()); err != nil || config == nil {
+		return ""
+	}
+	state, err := LoadState(paths.StateDir())
+	if err != nil {
+		return ""
+	}
+	return state.Commit
+}
@@ -0,0 +1,388 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"provisioner/pkg/configfile"
+)
+
+// Directories of the repository synced into the config directory. Environments are written to
+// the config directory itself, where the environment configs live.
+const (
+	dirWorkspaces   = "workspaces"
+	dirJobs         = "jobs"
+	dirEnvironments = "environments"
+)
+
+// gitTimeout bounds each git command, so an unreachable remote can't stall the scheduler loop
+const gitTimeout = 5 * time.Minute
+
+// Result describes a sync
+type Result struct {
+	Commit   string
+	Previous string   // Commit of the previous sync, empty on the first
+	Written  []string // Files added or changed in the repository
+	Reverted []string // Files edited locally, overwritten with the repository's version
+	Removed  []string // Files removed from the repository
+	Kept     []string // Environment configs changed locally by switches, left as they are
+}
+
+// Changed reports whether the sync changed any file in the config directory
+func (r *Result) Changed() bool {
+	return len(r.Written) > 0 || len(r.Reverted) > 0 || len(r.Removed) > 0
+}
+
+// Sync fetches the configured branch and makes the workspaces, jobs and environments of the
+// config directory match the repository. Files the repository never had are left alone. The
+// commit and the files synced are recorded in the state directory, as is a failure.
+func Sync(config *Config, configDir, stateDir string) (*Result, error) {
+	previous, err := LoadState(stateDir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	state := *previous
+	state.Repository, state.Branch, state.LastAttempt = config.Repository, config.GetBranch(), &now
+	result, err := sync(config, configDir, stateDir, previous, &state)
+	if err != nil {
+		state.LastError = err.Error()
+		if saveErr := state.save(stateDir); saveErr != nil {
+			return nil, fmt.Errorf("%w (%v)", err, saveErr)
+		}
+		return nil, err
+	}
+
+	state.LastError = ""
+	state.SyncedAt = &now
+	if err := state.save(stateDir); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// sync checks out the branch and applies it to the config directory, updating state
+func sync(config *Config, configDir, stateDir string, previous, state *State) (*Result, error) {
+	repo, err := newGitRepo(filepath.Join(stateDir, "gitops", "repo"), config)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.init(); err != nil {
+		return nil, err
+	}
+	if _, err := repo.run("fetch", "-q", "--no-tags", "--depth", "1", "origin", "refs/heads/"+config.GetBranch()); err != nil {
+		return nil, err
+	}
+	commit, err := repo.run("rev-parse", "FETCH_HEAD^{commit}")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := repo.run("checkout", "-q", "--force", "--detach", commit); err != nil {
+		return nil, err
+	}
+
+	root := repo.dir
+	if config.Path != "" {
+		root = filepath.Join(repo.dir, filepath.Clean(config.Path))
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			return nil, fmt.Errorf("path '%s' not found in %s at %s", config.Path, config.Repository, ShortCommit(commit))
+		}
+	}
+	files, err := listFiles(root)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Commit: commit, Previous: previous.Commit}
+	if err := apply(root, configDir, files, previous.Files, result); err != nil {
+		return nil, err
+	}
+	state.Commit = commit
+	state.Files = files
+	return result, nil
+}
+
+// listFiles returns the SHA-256 of the files the repository holds in its workspaces, jobs and
+// environments directories, by their slash-separated path below root
+func listFiles(root string) (map[string]string, error) {
+	files := make(map[string]string)
+	for _, dir := range []string{dirWorkspaces, dirJobs} {
+		err := filepath.WalkDir(filepath.Join(root, dir), func(path string, entry os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == filepath.Join(root, dir) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if entry.IsDir() {
+				if entry.Name() == ".git" || entry.Name() == ".terraform" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !entry.Type().IsRegular() {
+				return nil
+			}
+			return addFile(files, root, path)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+		}
+	}
+
+	// Environments are one file each; the config directory holds nothing below them
+	entries, err := os.ReadDir(filepath.Join(root, dirEnvironments))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", dirEnvironments, err)
+	}
+	for _, entry := range entries {
+		if entry.Type().IsRegular() && isEnvironmentFile(entry.Name()) {
+			if err := addFile(files, root, filepath.Join(root, dirEnvironments, entry.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return files, nil
+}
+
+// addFile records the hash of a file of the repository
+func addFile(files map[string]string, root, path string) error {
+	hash, err := hashFile(path)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return err
+	}
+	files[filepath.ToSlash(rel)] = hash
+	return nil
+}
+
+// isEnvironmentFile reports whether a file of the environments directory is an environment
+// config, leaving out names the config directory uses for other files
+func isEnvironmentFile(filename string) bool {
+	name, ok := configfile.TrimExtension(filename)
+	return ok && !strings.HasPrefix(filename, ".") &&
+		name != "config" && name != "provisioner" && name != "notifications" &&
+		!strings.Contains(filename, "scheduler") && !strings.Contains(filename, "jobs")
+}
+
+// targetPath returns where a file of the repository is written in the config directory
+func targetPath(configDir, name string) string {
+	if rest, ok := strings.CutPrefix(name, dirEnvironments+"/"); ok {
+		return filepath.Join(configDir, rest)
+	}
+	return filepath.Join(configDir, filepath.FromSlash(name))
+}
+
+// apply writes the files of the repository that differ from the config directory and removes
+// the ones synced before that the repository no longer has. Environment switches rewrite the
+// environment's config, so an environment config is only overwritten when the repository changed it.
+func apply(root, configDir string, files, synced map[string]string, result *Result) error {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		hash, target := files[name], targetPath(configDir, name)
+		local, err := hashFile(target)
+		switch {
+		case err == nil && local == hash:
+			continue
+		case err == nil && synced[name] == hash && strings.HasPrefix(name, dirEnvironments+"/"):
+			result.Kept = append(result.Kept, name)
+			continue
+		case err != nil && !os.IsNotExist(err):
+			return fmt.Errorf("failed to read %s: %w", target, err)
+		}
+
+		if err := writeFile(filepath.Join(root, filepath.FromSlash(name)), target); err != nil {
+			return err
+		}
+		if err == nil && synced[name] == hash {
+			result.Reverted = append(result.Reverted, name)
+		} else {
+			result.Written = append(result.Written, name)
+		}
+	}
+
+	var removed []string
+	for name := range synced {
+		if _, ok := files[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		target := targetPath(configDir, name)
+		if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", target, err)
+		}
+		removeEmptyDirs(filepath.Dir(target), configDir)
+		result.Removed = append(result.Removed, name)
+	}
+	return nil
+}
+
+// writeFile copies a file of the repository into the config directory through a temporary file,
+// so the daemon never loads a half-written config
+func writeFile(src, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	defer func() { _ = in.Close() }()
+
+	tmp := target + ".gitops.tmp"
+	out, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %w", target, err)
+	}
+	return nil
+}
+
+// removeEmptyDirs removes dir and its parents while they are empty, stopping at the workspaces
+// and jobs directories
+func removeEmptyDirs(dir, configDir string) {
+	stop := map[string]bool{
+		filepath.Clean(configDir):               true,
+		filepath.Join(configDir, dirWorkspaces): true,
+		filepath.Join(configDir, dirJobs):       true,
+	}
+	for !stop[filepath.Clean(dir)] {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// hashFile returns the SHA-256 of a file's content
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// gitRepo is the clone of the config repository, kept between syncs so they only fetch changes
+type gitRepo struct {
+	dir string
+	url string
+	env []string
+}
+
+// newGitRepo prepares git access to the repository with its configured authentication
+func newGitRepo(dir string, config *Config) (*gitRepo, error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if config.SSHKey != "" {
+		if _, err := os.Stat(config.SSHKey); err != nil {
+			return nil, fmt.Errorf("ssh key of the config repository: %w", err)
+		}
+		env = append(env, "GIT_SSH_COMMAND=ssh -i "+shellQuote(config.SSHKey)+" -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new")
+	}
+
+	if config.TokenEnv != "" {
+		token := os.Getenv(config.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("token of the config repository: environment variable %s is not set", config.TokenEnv)
+		}
+		// Passed as config through the environment so the token never shows up in process arguments
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials)
+	}
+
+	return &gitRepo{dir: dir, url: config.Repository, env: env}, nil
+}
+
+// run runs git in the clone, including its output in the error if it fails
+func (r *gitRepo) run(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = r.env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return "", fmt.Errorf("git %s failed: %w\n\nDetailed output:\n%s", args[0], err, detail)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// init creates the clone on first use
+func (r *gitRepo) init() error {
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); err == nil {
+		_, err := r.run("remote", "set-url", "origin", r.url)
+		return err
+	}
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create clone directory: %w", err)
+	}
+	if _, err := r.run("init", "-q"); err != nil {
+		return err
+	}
+	_, err := r.run("remote", "add", "origin", r.url)
+	return err
+}
+
+// shellQuote quotes a path for GIT_SSH_COMMAND, which git runs through the shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ShortCommit abbreviates a commit hash for display
+func ShortCommit(commit string) string {
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
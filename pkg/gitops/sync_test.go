@@ -0,0 +1,256 @@
+package gitops
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// newTestRepo creates a local repository on branch main with one commit of files and returns its directory
+func newTestRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	testGit(t, dir, "init", "-q", "-b", "main")
+	commitFiles(t, dir, files, nil, "initial")
+	return dir
+}
+
+// commitFiles writes and removes files of a test repository and commits the change
+func commitFiles(t *testing.T, dir string, files map[string]string, removed []string, message string) string {
+	t.Helper()
+	writeFiles(t, dir, files)
+	for _, name := range removed {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	testGit(t, dir, "add", "-A")
+	testGit(t, dir, "commit", "-q", "-m", message)
+	return testGit(t, dir, "rev-parse", "HEAD")
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func testGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func TestSync(t *testing.T) {
+	repoDir := newTestRepo(t, map[string]string{
+		"config/workspaces/web/config.json":    `{"enabled": true}`,
+		"config/workspaces/web/main.tf":        "# v1",
+		"config/jobs/cleanup.json":             `{"name": "cleanup"}`,
+		"config/environments/staging.json":     `{"domain": "staging.example.com"}`,
+		"config/environments/provisioner.json": `{}`,
+		"README.md":                            "docs",
+	})
+	config := &Config{Repository: "file://" + repoDir, Path: "config"}
+	configDir, stateDir := t.TempDir(), t.TempDir()
+	writeFiles(t, configDir, map[string]string{"workspaces/local/config.json": `{}`})
+
+	result, err := Sync(config, configDir, stateDir)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	head := testGit(t, repoDir, "rev-parse", "HEAD")
+	if result.Commit != head || result.Previous != "" || len(result.Written) != 4 {
+		t.Errorf("Expected 4 files written at %s, got %+v", head, result)
+	}
+	if readFile(t, filepath.Join(configDir, "workspaces/web/main.tf")) != "# v1" {
+		t.Error("Expected the workspace files to be synced")
+	}
+	if readFile(t, filepath.Join(configDir, "staging.json")) != `{"domain": "staging.example.com"}` {
+		t.Error("Expected the environment config in the config directory")
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "provisioner.json")); err == nil {
+		t.Error("Expected provisioner.json in environments/ not to be synced")
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "workspaces/local/config.json")); err != nil {
+		t.Error("Expected a workspace the repository never had to be left alone")
+	}
+
+	state, err := LoadState(stateDir)
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if state.Commit != head || state.SyncedAt == nil || state.LastError != "" || state.Branch != "main" {
+		t.Errorf("Expected the sync to be recorded, got %+v", state)
+	}
+
+	// Local edits are reverted, except environment configs the repository didn't change
+	writeFiles(t, configDir, map[string]string{
+		"workspaces/web/main.tf": "# edited",
+		"staging.json":           `{"domain": "staging.example.com", "assigned_workspace": "web"}`,
+	})
+	result, err = Sync(config, configDir, stateDir)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if !slices.Equal(result.Reverted, []string{"workspaces/web/main.tf"}) || len(result.Written) != 0 {
+		t.Errorf("Expected the edited workspace file to be reverted, got %+v", result)
+	}
+	if !slices.Equal(result.Kept, []string{"environments/staging.json"}) {
+		t.Errorf("Expected the switched environment to be kept, got %+v", result.Kept)
+	}
+	if readFile(t, filepath.Join(configDir, "workspaces/web/main.tf")) != "# v1" {
+		t.Error("Expected the local edit to be reverted")
+	}
+
+	// Changes and removals in the repository are applied
+	second := commitFiles(t, repoDir, map[string]string{
+		"config/workspaces/web/main.tf":    "# v2",
+		"config/environments/staging.json": `{"domain": "new.example.com"}`,
+	}, nil, "change")
+	third := commitFiles(t, repoDir, nil, []string{"config/jobs/cleanup.json"}, "remove job")
+	result, err = Sync(config, configDir, stateDir)
+	if err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if result.Commit != third || result.Previous != head || second == third {
+		t.Errorf("Expected the sync to move from %s to %s, got %+v", head, third, result)
+	}
+	if !slices.Equal(result.Written, []string{"environments/staging.json", "workspaces/web/main.tf"}) {
+		t.Errorf("Expected the changed files to be written, got %v", result.Written)
+	}
+	if !slices.Equal(result.Removed, []string{"jobs/cleanup.json"}) {
+		t.Errorf("Expected the removed job to be removed, got %v", result.Removed)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "jobs")); err != nil {
+		t.Error("Expected the jobs directory to be kept")
+	}
+	if readFile(t, filepath.Join(configDir, "staging.json")) != `{"domain": "new.example.com"}` {
+		t.Error("Expected the environment changed in the repository to be overwritten")
+	}
+}
+
+func TestSyncRemovesWorkspaceDirectories(t *testing.T) {
+	repoDir := newTestRepo(t, map[string]string{
+		"workspaces/web/config.json": `{}`,
+		"workspaces/api/config.json": `{}`,
+	})
+	config := &Config{Repository: "file://" + repoDir}
+	configDir, stateDir := t.TempDir(), t.TempDir()
+	if _, err := Sync(config, configDir, stateDir); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	commitFiles(t, repoDir, nil, []string{"workspaces/api/config.json"}, "remove api")
+	if _, err := Sync(config, configDir, stateDir); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "workspaces/api")); err == nil {
+		t.Error("Expected the removed workspace's directory to be removed")
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "workspaces/web/config.json")); err != nil {
+		t.Error("Expected the other workspace to be kept")
+	}
+}
+
+func TestSyncFailureIsRecorded(t *testing.T) {
+	repoDir := newTestRepo(t, map[string]string{"workspaces/web/config.json": `{}`})
+	configDir, stateDir := t.TempDir(), t.TempDir()
+	if _, err := Sync(&Config{Repository: "file://" + repoDir}, configDir, stateDir); err != nil {
+		t.Fatalf("Sync failed: %v", err)
+	}
+
+	if _, err := Sync(&Config{Repository: "file://" + repoDir, Branch: "missing"}, configDir, stateDir); err == nil {
+		t.Fatal("Expected syncing a missing branch to fail")
+	}
+	state, err := LoadState(stateDir)
+	if err != nil {
+		t.Fatalf("LoadState failed: %v", err)
+	}
+	if state.LastError == "" || state.Commit != testGit(t, repoDir, "rev-parse", "HEAD") {
+		t.Errorf("Expected the failure recorded with the last synced commit kept, got %+v", state)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "workspaces/web/config.json")); err != nil {
+		t.Error("Expected a failed sync to keep the configs")
+	}
+
+	if _, err := Sync(&Config{Repository: "file://" + repoDir, Path: "missing"}, configDir, stateDir); err == nil || !strings.Contains(err.Error(), "path 'missing' not found") {
+		t.Errorf("Expected a missing path to fail, got %v", err)
+	}
+}
+
+func TestCheckEditableAndConfigCommit(t *testing.T) {
+	configDir, stateDir := t.TempDir(), t.TempDir()
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+
+	if err := CheckEditable(); err != nil {
+		t.Errorf("Expected edits to be allowed without GitOps, got %v", err)
+	}
+	writeFiles(t, stateDir, map[string]string{StateFile: `{"commit": "0123456789abcdef"}`})
+	if commit := ConfigCommit(); commit != "" {
+		t.Errorf("Expected no config commit without GitOps, got %s", commit)
+	}
+
+	writeFiles(t, configDir, map[string]string{"provisioner.json": `{"gitops": {"repository": "https://git.example.com/infra.git", "branch": "prod"}}`})
+	if err := CheckEditable(); err == nil || !strings.Contains(err.Error(), "https://git.example.com/infra.git (branch prod)") {
+		t.Errorf("Expected edits to be refused with GitOps, got %v", err)
+	}
+	if commit := ConfigCommit(); commit != "0123456789abcdef" {
+		t.Errorf("Expected the synced commit, got %q", commit)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		config Config
+		err    string
+	}{
+		{Config{Repository: "https://git.example.com/infra.git", Interval: "5m", Path: "provisioner"}, ""},
+		{Config{}, "'repository' is required"},
+		{Config{Repository: "r", Path: "../outside"}, "must be relative"},
+		{Config{Repository: "r", Interval: "30s"}, "at least 1m"},
+		{Config{Repository: "r", Interval: "often"}, "invalid interval"},
+		{Config{Repository: "r", SSHKey: "/key", TokenEnv: "TOKEN"}, "cannot specify both"},
+	}
+	for _, tt := range tests {
+		err := tt.config.Validate()
+		if tt.err == "" && err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", tt.config, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("Expected %+v to fail with %q, got %v", tt.config, tt.err, err)
+		}
+	}
+
+	config := Config{Repository: "r"}
+	if config.GetBranch() != DefaultBranch || config.GetInterval() != DefaultInterval {
+		t.Errorf("Expected the default branch and interval, got %s and %s", config.GetBranch(), config.GetInterval())
+	}
+}
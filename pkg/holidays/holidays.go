@@ -0,0 +1,233 @@
+// Package holidays loads the holiday calendars schedules can skip (holiday_calendars in
+// provisioner.json) from inline dates, iCal feeds or JSON lists of dates.
+package holidays
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// RefreshInterval is how often calendars with a URL are fetched again
+const RefreshInterval = 24 * time.Hour
+
+// maxCalendarSize bounds the calendars read from files and URLs
+const maxCalendarSize = 4 << 20
+
+// maxEventDays bounds the days a single iCal event can span
+const maxEventDays = 366
+
+// NamePattern matches valid calendar names, as referenced by skip_holidays
+var NamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Calendar is a holiday calendar (an entry of holiday_calendars in provisioner.json). Its holidays
+// are the inline dates and those of its file or URL.
+type Calendar struct {
+	URL   string   `json:"url,omitempty"`   // iCal or JSON calendar fetched daily
+	File  string   `json:"file,omitempty"`  // iCal or JSON calendar, relative to the config directory
+	Dates []string `json:"dates,omitempty"` // Holidays as YYYY-MM-DD
+}
+
+// Validate checks the calendar for missing or invalid values
+func (c *Calendar) Validate() error {
+	if c.URL == "" && c.File == "" && len(c.Dates) == 0 {
+		return fmt.Errorf("requires 'url', 'file' or 'dates'")
+	}
+	if c.URL != "" && c.File != "" {
+		return fmt.Errorf("cannot specify both 'url' and 'file'")
+	}
+	if c.URL != "" {
+		parsed, err := url.Parse(c.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("url must be an http or https URL: %s", c.URL)
+		}
+	}
+	for _, date := range c.Dates {
+		if _, err := time.Parse(time.DateOnly, date); err != nil {
+			return fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", date)
+		}
+	}
+	return nil
+}
+
+// Days returns the holidays of the calendar's inline dates and file, read relative to configDir.
+// The holidays of a URL are fetched separately with Fetch.
+func (c *Calendar) Days(configDir string) ([]time.Time, error) {
+	var days []time.Time
+	for _, date := range c.Dates {
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", date)
+		}
+		days = append(days, day)
+	}
+
+	if c.File != "" {
+		path := c.File
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(configDir, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read calendar: %w", err)
+		}
+		fileDays, err := Parse(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", c.File, err)
+		}
+		days = append(days, fileDays...)
+	}
+	return days, nil
+}
+
+// Fetch downloads the calendar at url
+func Fetch(url string) ([]byte, error) {
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCalendarSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxCalendarSize {
+		return nil, fmt.Errorf("calendar exceeds %d bytes", maxCalendarSize)
+	}
+	return data, nil
+}
+
+// Parse returns the holidays of an iCal calendar (all-day VEVENTs) or a JSON calendar: an array
+// of YYYY-MM-DD dates or of objects with a "date" field, optionally under an "events" field
+func Parse(data []byte) ([]time.Time, error) {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if bytes.HasPrefix(data, []byte("BEGIN:VCALENDAR")) {
+		return parseICal(string(data))
+	}
+	if len(data) > 0 && (data[0] == '[' || data[0] == '{') {
+		return parseJSON(data)
+	}
+	return nil, fmt.Errorf("calendar is neither iCal nor JSON")
+}
+
+// parseJSON parses a JSON calendar
+func parseJSON(data []byte) ([]time.Time, error) {
+	var entries []json.RawMessage
+	if data[0] == '{' {
+		var wrapper struct {
+			Events []json.RawMessage `json:"events"`
+		}
+		if err := json.Unmarshal(data, &wrapper); err != nil {
+			return nil, fmt.Errorf("invalid JSON calendar: %w", err)
+		}
+		entries = wrapper.Events
+	} else if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid JSON calendar: %w", err)
+	}
+
+	days := make([]time.Time, 0, len(entries))
+	for i, entry := range entries {
+		var date string
+		if err := json.Unmarshal(entry, &date); err != nil {
+			var event struct {
+				Date string `json:"date"`
+			}
+			if err := json.Unmarshal(entry, &event); err != nil || event.Date == "" {
+				return nil, fmt.Errorf("entry %d must be a date or an object with a date", i+1)
+			}
+			date = event.Date
+		}
+		day, err := time.Parse(time.DateOnly, date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", date)
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// parseICal parses the events of an iCal calendar. Every day an event spans is a holiday; the
+// DTEND of all-day events is exclusive. Recurrence rules are not expanded.
+func parseICal(data string) ([]time.Time, error) {
+	// Long lines are folded, continuing on lines starting with a space or tab
+	data = strings.NewReplacer("\r\n ", "", "\r\n\t", "", "\n ", "", "\n\t", "").Replace(data)
+
+	var days []time.Time
+	var start, end string
+	inEvent := false
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		name, value, _ := strings.Cut(line, ":")
+		name, _, _ = strings.Cut(name, ";")
+		switch strings.ToUpper(name) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, start, end = true, "", ""
+			}
+		case "DTSTART":
+			start = value
+		case "DTEND":
+			end = value
+		case "END":
+			if !strings.EqualFold(value, "VEVENT") || !inEvent {
+				continue
+			}
+			inEvent = false
+			eventDays, err := icalEventDays(start, end)
+			if err != nil {
+				return nil, err
+			}
+			days = append(days, eventDays...)
+		}
+	}
+	return days, nil
+}
+
+// icalEventDays returns the days an iCal event spans from its DTSTART and DTEND values
+func icalEventDays(start, end string) ([]time.Time, error) {
+	first, allDay, err := parseICalDate(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DTSTART '%s'", start)
+	}
+	if end == "" {
+		return []time.Time{first}, nil
+	}
+	last, endAllDay, err := parseICalDate(end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DTEND '%s'", end)
+	}
+	// All-day events end the day before DTEND, timed events ending at midnight too
+	if endAllDay || (!allDay && strings.HasSuffix(strings.TrimSuffix(end, "Z"), "T000000")) {
+		last = last.AddDate(0, 0, -1)
+	}
+
+	days := []time.Time{first}
+	for day := first.AddDate(0, 0, 1); !day.After(last) && len(days) < maxEventDays; day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// parseICalDate parses the date of an iCal DATE (20241225) or DATE-TIME (20241225T090000Z) value
+// and reports whether it was a DATE
+func parseICalDate(value string) (time.Time, bool, error) {
+	if len(value) < 8 {
+		return time.Time{}, false, fmt.Errorf("too short")
+	}
+	day, err := time.Parse("20060102", value[:8])
+	return day, len(value) == 8, err
+}
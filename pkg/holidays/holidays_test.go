@@ -0,0 +1,96 @@
+package holidays
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func formatDays(days []time.Time) string {
+	dates := make([]string, len(days))
+	for i, day := range days {
+		dates[i] = day.Format(time.DateOnly)
+	}
+	return strings.Join(dates, ",")
+}
+
+func TestParseICal(t *testing.T) {
+	data := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20241225\r\nDTEND;VALUE=DATE:20241227\r\nSUMMARY:Christmas Day and\r\n  Boxing Day\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250101\r\nSUMMARY:New Year's Day\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART:20250418T090000Z\r\nDTEND:20250419T000000Z\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	days, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Expected the calendar to parse, got %v", err)
+	}
+	if got, want := formatDays(days), "2024-12-25,2024-12-26,2025-01-01,2025-04-18"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	if _, err := Parse([]byte("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART:2025\nEND:VEVENT\nEND:VCALENDAR")); err == nil {
+		t.Error("Expected an invalid DTSTART to fail")
+	}
+}
+
+func TestParseJSON(t *testing.T) {
+	for _, tt := range []struct {
+		data string
+		want string
+	}{
+		{`["2025-05-05", "2025-05-26"]`, "2025-05-05,2025-05-26"},
+		{`[{"title": "Early May bank holiday", "date": "2025-05-05"}]`, "2025-05-05"},
+		{`{"division": "england-and-wales", "events": [{"date": "2025-08-25"}]}`, "2025-08-25"},
+	} {
+		days, err := Parse([]byte(tt.data))
+		if err != nil {
+			t.Errorf("Expected %s to parse, got %v", tt.data, err)
+			continue
+		}
+		if got := formatDays(days); got != tt.want {
+			t.Errorf("Expected %s for %s, got %s", tt.want, tt.data, got)
+		}
+	}
+
+	for _, invalid := range []string{`["05/05/2025"]`, `[42]`, `holidays`} {
+		if _, err := Parse([]byte(invalid)); err == nil {
+			t.Errorf("Expected error for %s", invalid)
+		}
+	}
+}
+
+func TestCalendarDays(t *testing.T) {
+	configDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(configDir, "uk.json"), []byte(`["2025-12-25"]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	calendar := Calendar{File: "uk.json", Dates: []string{"2025-12-24"}}
+	if err := calendar.Validate(); err != nil {
+		t.Fatalf("Expected the calendar to be valid, got %v", err)
+	}
+	days, err := calendar.Days(configDir)
+	if err != nil {
+		t.Fatalf("Expected the days to load, got %v", err)
+	}
+	if got := formatDays(days); got != "2025-12-24,2025-12-25" {
+		t.Errorf("Expected the inline and file days, got %s", got)
+	}
+
+	for _, tt := range []struct {
+		calendar Calendar
+		errMsg   string
+	}{
+		{Calendar{}, "requires"},
+		{Calendar{URL: "https://example.com/uk.ics", File: "uk.json"}, "both"},
+		{Calendar{URL: "ftp://example.com/uk.ics"}, "http or https"},
+		{Calendar{Dates: []string{"25/12/2025"}}, "invalid date"},
+	} {
+		if err := tt.calendar.Validate(); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("Expected error containing %q for %+v, got %v", tt.errMsg, tt.calendar, err)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package job
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// ArtifactsDir is the directory below the state directory holding the artifacts of job runs,
+// in artifacts/<workspace>/<job>/<run-id>/
+const ArtifactsDir = "artifacts"
+
+// Artifact is a file collected from a job's working directory after a run
+type Artifact struct {
+	RunID     string    `json:"run_id"`
+	Path      string    `json:"path"` // Relative to the working directory and the run's artifacts directory
+	Size      int64     `json:"size"`
+	Collected time.Time `json:"collected"`
+}
+
+// ArtifactDirectory returns the directory holding the artifacts of a job's run
+func ArtifactDirectory(stateDir, workspaceID, jobName, runID string) string {
+	return filepath.Join(stateDir, ArtifactsDir, workspaceID, jobName, runID)
+}
+
+// GetArtifactPatterns returns the glob patterns of the files collected after each run
+func (j *Job) GetArtifactPatterns() ([]string, error) {
+	return normalizeScheduleField(j.Artifacts)
+}
+
+// GetArtifactRetention returns how long the artifacts of a run are kept, 0 for as long as the
+// run is in the job's history
+func (j *Job) GetArtifactRetention() (time.Duration, error) {
+	if j.ArtifactRetention == "" {
+		return 0, nil
+	}
+	retention, err := time.ParseDuration(j.ArtifactRetention)
+	if err != nil || retention <= 0 {
+		return 0, fmt.Errorf("invalid artifact_retention duration '%s'", j.ArtifactRetention)
+	}
+	return retention, nil
+}
+
+// validateArtifacts checks the artifacts and artifact_retention fields of a job
+func (j *Job) validateArtifacts() error {
+	patterns, err := j.GetArtifactPatterns()
+	if err != nil {
+		return fmt.Errorf("invalid artifacts: %w", err)
+	}
+	for _, pattern := range patterns {
+		if err := validateArtifactPattern(pattern); err != nil {
+			return err
+		}
+	}
+	_, err = j.GetArtifactRetention()
+	return err
+}
+
+// validateArtifactPattern checks that a pattern is a valid glob within the working directory
+func validateArtifactPattern(pattern string) error {
+	if pattern == "" || filepath.IsAbs(pattern) || strings.HasPrefix(filepath.Clean(pattern), "..") {
+		return fmt.Errorf("invalid artifacts pattern '%s': must be relative to the working directory", pattern)
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid artifacts pattern '%s': %w", pattern, err)
+	}
+	return nil
+}
+
+// CollectArtifacts copies the files matching a job's artifacts patterns from its working
+// directory into destDir, keeping their relative paths. A pattern matching a directory collects
+// the files in it; symlinks and other special files are skipped.
+func (e *Executor) CollectArtifacts(job *Job, destDir string) ([]string, error) {
+	patterns, err := job.GetArtifactPatterns()
+	if err != nil || len(patterns) == 0 {
+		return nil, err
+	}
+
+	workingDir := job.GetWorkingDirectory(e.workspaceDeploymentDir)
+	collected := make(map[string]bool)
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(workingDir, pattern))
+		if err != nil {
+			return paths, fmt.Errorf("invalid artifacts pattern '%s': %w", pattern, err)
+		}
+		for _, match := range matches {
+			err := filepath.WalkDir(match, func(path string, entry fs.DirEntry, err error) error {
+				if err != nil || !entry.Type().IsRegular() {
+					return err
+				}
+				relative, err := filepath.Rel(workingDir, path)
+				if err != nil || collected[relative] {
+					return err
+				}
+				if err := copyArtifact(path, filepath.Join(destDir, relative)); err != nil {
+					return err
+				}
+				collected[relative] = true
+				paths = append(paths, relative)
+				return nil
+			})
+			if err != nil {
+				return paths, fmt.Errorf("failed to collect %s: %w", match, err)
+			}
+		}
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// copyArtifact copies a file, creating the directories of the target
+func copyArtifact(source, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// collectArtifacts collects the artifacts of a finished run into the run's artifacts directory,
+// recording them in the execution. Artifacts are collected whatever the run's outcome.
+func (m *Manager) collectArtifacts(job *Job, executor *Executor, execution *JobExecution) {
+	if job.Artifacts == nil {
+		return
+	}
+
+	destDir := ArtifactDirectory(m.stateDir, job.WorkspaceID, job.Name, execution.RunID)
+	paths, err := executor.CollectArtifacts(job, destDir)
+	execution.Artifacts = paths
+	if err != nil {
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed to collect artifacts: %v", job.Name, err)
+	}
+	if len(paths) > 0 {
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Collected %d artifacts into %s", job.Name, len(paths), destDir)
+	}
+}
+
+// pruneArtifacts removes the artifacts of runs that left the job's history or are older than
+// its artifact retention
+func (m *Manager) pruneArtifacts(job *Job, now time.Time) {
+	jobDir := filepath.Join(m.stateDir, ArtifactsDir, job.WorkspaceID, job.Name)
+	entries, err := os.ReadDir(jobDir)
+	if err != nil {
+		return
+	}
+
+	kept := make(map[string]bool)
+	for _, entry := range m.stateManager.GetJobState(job.WorkspaceID, job.Name).History {
+		kept[entry.RunID] = true
+	}
+	retention, _ := job.GetArtifactRetention()
+
+	for _, entry := range entries {
+		expired := !kept[entry.Name()]
+		if info, err := entry.Info(); err == nil && retention > 0 && now.Sub(info.ModTime()) > retention {
+			expired = true
+		}
+		if !expired {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(jobDir, entry.Name())); err != nil {
+			logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed to remove artifacts of run %s: %v", job.Name, entry.Name(), err)
+		}
+	}
+}
+
+// ListArtifacts returns the artifacts of a job's run, or of all its runs if runID is empty,
+// newest run first
+func (m *Manager) ListArtifacts(workspaceID, jobName, runID string) ([]Artifact, error) {
+	if strings.ContainsAny(runID, `/\`) || runID == "." || runID == ".." {
+		return nil, fmt.Errorf("invalid run ID '%s'", runID)
+	}
+
+	jobDir := filepath.Join(m.stateDir, ArtifactsDir, workspaceID, jobName)
+	runIDs := []string{runID}
+	if runID == "" {
+		entries, err := os.ReadDir(jobDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		runIDs = nil
+		for _, entry := range entries {
+			if entry.IsDir() {
+				runIDs = append(runIDs, entry.Name())
+			}
+		}
+		// Run IDs start with their start time
+		sort.Sort(sort.Reverse(sort.StringSlice(runIDs)))
+	} else if _, err := os.Stat(filepath.Join(jobDir, runID)); err != nil {
+		return nil, fmt.Errorf("no artifacts kept for run '%s' of job '%s'", runID, jobName)
+	}
+
+	var artifacts []Artifact
+	for _, id := range runIDs {
+		runDir := filepath.Join(jobDir, id)
+		err := filepath.WalkDir(runDir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || !entry.Type().IsRegular() {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			relative, err := filepath.Rel(runDir, path)
+			if err != nil {
+				return err
+			}
+			artifacts = append(artifacts, Artifact{RunID: id, Path: relative, Size: info.Size(), Collected: info.ModTime()})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list artifacts of run '%s': %w", id, err)
+		}
+	}
+	return artifacts, nil
+}
+
+// DownloadArtifacts copies the artifacts of a job's run into destDir, keeping their relative
+// paths. Existing files are not overwritten.
+func (m *Manager) DownloadArtifacts(workspaceID, jobName, runID, destDir string) ([]Artifact, error) {
+	artifacts, err := m.ListArtifacts(workspaceID, jobName, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, artifact := range artifacts {
+		if target := filepath.Join(destDir, artifact.Path); fileExists(target) {
+			return nil, fmt.Errorf("%s already exists", target)
+		}
+	}
+
+	runDir := ArtifactDirectory(m.stateDir, workspaceID, jobName, runID)
+	for _, artifact := range artifacts {
+		if err := copyArtifact(filepath.Join(runDir, artifact.Path), filepath.Join(destDir, artifact.Path)); err != nil {
+			return nil, fmt.Errorf("failed to download %s: %w", artifact.Path, err)
+		}
+	}
+	return artifacts, nil
+}
+
+// fileExists returns true if a file or directory exists at path
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
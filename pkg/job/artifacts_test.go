@@ -0,0 +1,97 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExecuteJobCollectsArtifacts(t *testing.T) {
+	manager := newTestManager(t, nil)
+
+	job, err := JobConfigToJob("test-workspace", map[string]interface{}{
+		"name":      "backup",
+		"type":      "script",
+		"script":    "mkdir -p reports\necho dump > backup.sql\necho ok > reports/summary.txt\necho skip > notes.txt",
+		"artifacts": []interface{}{"*.sql", "reports"},
+	})
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+	job.RunID = "20250927-020000-abcdef"
+
+	execution := manager.ExecuteJob(job)
+	if execution.Status != JobStatusSuccess {
+		t.Fatalf("Expected the job to succeed, got %s: %s", execution.Status, execution.Error)
+	}
+
+	artifacts, err := manager.ListArtifacts("test-workspace", "backup", "")
+	if err != nil {
+		t.Fatalf("ListArtifacts failed: %v", err)
+	}
+	if len(artifacts) != 2 || artifacts[0].Path != "backup.sql" || artifacts[1].Path != filepath.Join("reports", "summary.txt") || artifacts[0].RunID != job.RunID {
+		t.Fatalf("Expected backup.sql and reports/summary.txt, got %+v", artifacts)
+	}
+	if entry, _ := manager.GetHistoryEntry("test-workspace", "backup", job.RunID); entry == nil || len(entry.Artifacts) != 2 {
+		t.Errorf("Expected the artifacts in the run's history entry, got %+v", entry)
+	}
+
+	downloadDir := t.TempDir()
+	if _, err := manager.DownloadArtifacts("test-workspace", "backup", job.RunID, downloadDir); err != nil {
+		t.Fatalf("DownloadArtifacts failed: %v", err)
+	}
+	if content, err := os.ReadFile(filepath.Join(downloadDir, "reports", "summary.txt")); err != nil || string(content) != "ok\n" {
+		t.Errorf("Expected the downloaded summary, got %q (%v)", content, err)
+	}
+	if _, err := manager.DownloadArtifacts("test-workspace", "backup", job.RunID, downloadDir); err == nil {
+		t.Error("Expected an error when the download would overwrite files")
+	}
+
+	if _, err := manager.ListArtifacts("test-workspace", "backup", "../backup"); err == nil {
+		t.Error("Expected an error for a run ID outside the job's artifacts")
+	}
+}
+
+func TestPruneArtifacts(t *testing.T) {
+	manager := newTestManager(t, nil)
+	job := &Job{Name: "backup", WorkspaceID: "test-workspace", JobType: JobTypeScript, Script: "true", ArtifactRetention: "24h"}
+
+	jobState := manager.stateManager.GetJobState("test-workspace", "backup")
+	jobState.addHistoryEntry(HistoryEntry{RunID: "recent"})
+	jobState.addHistoryEntry(HistoryEntry{RunID: "old"})
+	manager.stateManager.SetJobState("test-workspace", "backup", jobState)
+
+	for _, runID := range []string{"recent", "old", "dropped"} {
+		dir := ArtifactDirectory(manager.stateDir, "test-workspace", "backup", runID)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatalf("Failed to create artifacts dir: %v", err)
+		}
+	}
+	old := time.Now().Add(-48 * time.Hour)
+	if err := os.Chtimes(ArtifactDirectory(manager.stateDir, "test-workspace", "backup", "old"), old, old); err != nil {
+		t.Fatalf("Failed to age artifacts: %v", err)
+	}
+
+	manager.pruneArtifacts(job, time.Now())
+
+	for runID, kept := range map[string]bool{"recent": true, "old": false, "dropped": false} {
+		_, err := os.Stat(ArtifactDirectory(manager.stateDir, "test-workspace", "backup", runID))
+		if kept != (err == nil) {
+			t.Errorf("Expected the artifacts of run %s kept: %v, got error %v", runID, kept, err)
+		}
+	}
+}
+
+func TestArtifactsValidation(t *testing.T) {
+	for _, config := range []map[string]interface{}{
+		{"name": "backup", "type": "script", "script": "true", "artifacts": "/etc/*"},
+		{"name": "backup", "type": "script", "script": "true", "artifacts": "../*.sql"},
+		{"name": "backup", "type": "script", "script": "true", "artifacts": "[.sql"},
+		{"name": "backup", "type": "script", "script": "true", "artifacts": "*.sql", "artifact_retention": "a week"},
+	} {
+		if _, err := JobConfigToJob("test-workspace", config); err == nil {
+			t.Errorf("Expected error for %v", config)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package job
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// CrontabImportResult holds the jobs converted from a crontab and any issues found
+type CrontabImportResult struct {
+	Jobs     []StandaloneJobConfig
+	Warnings []string // Lines converted with caveats
+	Skipped  []string // Lines that could not be converted
+}
+
+// CrontabImportOptions controls how crontab lines are converted
+type CrontabImportOptions struct {
+	System     bool   // System crontab format (/etc/crontab, cron.d) with a user field
+	NamePrefix string // Prefix for generated job names
+	Disabled   bool   // Create jobs disabled so they can be reviewed first
+}
+
+// cronMacros maps crontab shorthand schedules to 5-field expressions
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]string{
+	"jan": "1", "feb": "2", "mar": "3", "apr": "4", "may": "5", "jun": "6",
+	"jul": "7", "aug": "8", "sep": "9", "oct": "10", "nov": "11", "dec": "12",
+}
+
+var dowNames = map[string]string{
+	"sun": "0", "mon": "1", "tue": "2", "wed": "3", "thu": "4", "fri": "5", "sat": "6",
+}
+
+// cronEnvPattern matches crontab environment assignments (KEY=value)
+var cronEnvPattern = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
+
+// shellSyntaxChars indicate a command that needs a shell to run
+const shellSyntaxChars = "|&;<>()$`\\\"'*?[]~{}"
+
+// jobNameSanitizer replaces characters not allowed in job names
+var jobNameSanitizer = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// ParseCrontab converts crontab content into standalone job configurations
+func ParseCrontab(r io.Reader, opts CrontabImportOptions) (*CrontabImportResult, error) {
+	result := &CrontabImportResult{}
+	environment := make(map[string]string)
+	usedNames := make(map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		// Skip blank lines and comments
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		// Environment assignments apply to all following entries
+		if match := cronEnvPattern.FindStringSubmatch(line); match != nil {
+			key, value := match[1], strings.Trim(match[2], `"'`)
+			switch key {
+			case "MAILTO", "MAILFROM", "SHELL", "CRON_TZ":
+				result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: %s is not supported and was ignored", lineNum, key))
+			default:
+				environment[key] = value
+			}
+			continue
+		}
+
+		job, warnings, err := parseCrontabEntry(line, opts, environment)
+		if err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("line %d: %v: %s", lineNum, err, line))
+			continue
+		}
+
+		// Ensure generated names are unique within the import
+		baseName := opts.NamePrefix + job.Name
+		usedNames[baseName]++
+		job.Name = baseName
+		if usedNames[baseName] > 1 {
+			job.Name = fmt.Sprintf("%s-%d", baseName, usedNames[baseName])
+		}
+
+		for _, warning := range warnings {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("line %d (%s): %s", lineNum, job.Name, warning))
+		}
+
+		if err := job.Validate(); err != nil {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("line %d: %v: %s", lineNum, err, line))
+			continue
+		}
+
+		result.Jobs = append(result.Jobs, job)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read crontab: %w", err)
+	}
+
+	return result, nil
+}
+
+// parseCrontabEntry converts a single crontab schedule line into a job configuration
+func parseCrontabEntry(line string, opts CrontabImportOptions, environment map[string]string) (StandaloneJobConfig, []string, error) {
+	var job StandaloneJobConfig
+	var warnings []string
+
+	// Split schedule fields from the command
+	var scheduleFields []string
+	rest := line
+	if strings.HasPrefix(line, "@") {
+		macro, remainder := splitField(line)
+		if macro == "@reboot" {
+			return job, nil, fmt.Errorf("@reboot is not supported")
+		}
+		expr, ok := cronMacros[macro]
+		if !ok {
+			return job, nil, fmt.Errorf("unknown schedule macro %s", macro)
+		}
+		scheduleFields = strings.Fields(expr)
+		rest = remainder
+	} else {
+		for i := 0; i < 5; i++ {
+			var field string
+			field, rest = splitField(rest)
+			if field == "" {
+				return job, nil, fmt.Errorf("expected 5 schedule fields")
+			}
+			scheduleFields = append(scheduleFields, field)
+		}
+	}
+
+	// System crontabs carry the user to run as before the command
+	if opts.System {
+		var user string
+		user, rest = splitField(rest)
+		if user == "" {
+			return job, nil, fmt.Errorf("missing user field")
+		}
+		if user != "root" {
+			warnings = append(warnings, fmt.Sprintf("originally ran as user '%s', jobs run as the provisioner user", user))
+		}
+	}
+
+	command := strings.TrimSpace(rest)
+	if command == "" {
+		return job, nil, fmt.Errorf("missing command")
+	}
+
+	// An unescaped % in crontab starts stdin input, which jobs cannot reproduce
+	if strings.Contains(strings.ReplaceAll(command, `\%`, ""), "%") {
+		return job, nil, fmt.Errorf("'%%' stdin syntax is not supported")
+	}
+	command = strings.ReplaceAll(command, `\%`, "%")
+
+	schedule, err := convertCronSchedule(scheduleFields)
+	if err != nil {
+		return job, nil, err
+	}
+
+	job = StandaloneJobConfig{
+		Name:        crontabJobName(command),
+		Schedule:    schedule,
+		Enabled:     !opts.Disabled,
+		Description: fmt.Sprintf("Imported from crontab: %s", line),
+	}
+
+	if len(environment) > 0 {
+		job.Environment = make(map[string]string, len(environment))
+		for key, value := range environment {
+			job.Environment[key] = value
+		}
+	}
+
+	// Command jobs run without a shell, so shell syntax needs a script job
+	if strings.ContainsAny(command, shellSyntaxChars) {
+		job.Type = "script"
+		job.Script = "#!/bin/bash\n" + command
+		warnings = append(warnings, "uses shell syntax, converted to a script job")
+	} else {
+		job.Type = "command"
+		job.Command = command
+	}
+
+	return job, warnings, nil
+}
+
+// convertCronSchedule normalizes crontab fields into the scheduler's supported syntax
+func convertCronSchedule(fields []string) (string, error) {
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+	names := []map[string]string{nil, nil, nil, monthNames, dowNames}
+	fieldNames := []string{"minute", "hour", "day", "month", "day of week"}
+
+	converted := make([]string, len(fields))
+	for i, field := range fields {
+		value, err := convertCronField(strings.ToLower(field), bounds[i][0], bounds[i][1], names[i])
+		if err != nil {
+			return "", fmt.Errorf("unsupported %s field '%s': %w", fieldNames[i], field, err)
+		}
+
+		// Both 0 and 7 mean Sunday in crontab, the scheduler only knows 0
+		if i == 4 && value != "*" {
+			value = normalizeSunday(value)
+		}
+		converted[i] = value
+	}
+
+	return strings.Join(converted, " "), nil
+}
+
+// convertCronField translates names and expands stepped ranges into plain lists
+func convertCronField(field string, min, max int, names map[string]string) (string, error) {
+	if field == "*" {
+		return field, nil
+	}
+
+	parts := strings.Split(field, ",")
+	for i, part := range parts {
+		for name, number := range names {
+			part = strings.ReplaceAll(part, name, number)
+		}
+
+		base, step, hasStep := strings.Cut(part, "/")
+		if !hasStep {
+			if err := checkCronValues(part, min, max); err != nil {
+				return "", err
+			}
+			parts[i] = part
+			continue
+		}
+
+		stepValue, err := strconv.Atoi(step)
+		if err != nil || stepValue <= 0 {
+			return "", fmt.Errorf("invalid step '%s'", step)
+		}
+
+		// */N is supported directly
+		if base == "*" {
+			parts[i] = part
+			continue
+		}
+
+		// Expand "a-b/N" and "a/N" into an explicit list
+		start, end := min, max
+		if from, to, isRange := strings.Cut(base, "-"); isRange {
+			if start, err = strconv.Atoi(from); err != nil {
+				return "", fmt.Errorf("invalid value '%s'", from)
+			}
+			if end, err = strconv.Atoi(to); err != nil {
+				return "", fmt.Errorf("invalid value '%s'", to)
+			}
+		} else if start, err = strconv.Atoi(base); err != nil {
+			return "", fmt.Errorf("invalid value '%s'", base)
+		}
+		if start < min || end > max || start > end {
+			return "", fmt.Errorf("range %d-%d out of bounds", start, end)
+		}
+
+		var values []string
+		for v := start; v <= end; v += stepValue {
+			values = append(values, strconv.Itoa(v))
+		}
+		parts[i] = strings.Join(values, ",")
+	}
+
+	return strings.Join(parts, ","), nil
+}
+
+// checkCronValues validates a single value or range against field bounds
+func checkCronValues(part string, min, max int) error {
+	values := []string{part}
+	if from, to, isRange := strings.Cut(part, "-"); isRange {
+		values = []string{from, to}
+	}
+
+	for _, value := range values {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid value '%s'", value)
+		}
+		if n < min || n > max {
+			return fmt.Errorf("value %d out of range [%d-%d]", n, min, max)
+		}
+	}
+	return nil
+}
+
+// normalizeSunday rewrites day-of-week 7 as 0, expanding ranges that end on 7
+func normalizeSunday(field string) string {
+	parts := strings.Split(field, ",")
+	for i, part := range parts {
+		if part == "7" {
+			parts[i] = "0"
+		} else if from, to, isRange := strings.Cut(part, "-"); isRange && to == "7" {
+			if from == "6" {
+				parts[i] = "6,0"
+			} else {
+				parts[i] = from + "-6,0"
+			}
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// crontabJobName derives a job name from the command's executable
+func crontabJobName(command string) string {
+	executable, _ := splitField(command)
+	name := strings.ToLower(filepath.Base(executable))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = strings.Trim(jobNameSanitizer.ReplaceAllString(name, "-"), "-")
+	if name == "" {
+		name = "job"
+	}
+	return "cron-" + name
+}
+
+// splitField returns the first whitespace-delimited field and the remainder
+func splitField(s string) (string, string) {
+	s = strings.TrimLeft(s, " \t")
+	idx := strings.IndexAny(s, " \t")
+	if idx < 0 {
+		return s, ""
+	}
+	return s[:idx], s[idx+1:]
+}
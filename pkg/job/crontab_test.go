@@ -0,0 +1,142 @@
+package job
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCrontab(t *testing.T) {
+	crontab := `# Maintenance jobs
+PATH=/usr/local/bin:/usr/bin
+MAILTO=ops@example.com
+
+0 2 * * * /usr/local/bin/cleanup.sh
+*/15 * * * * /usr/bin/curl -fsS https://example.com/ping > /dev/null
+@daily /opt/backup/run-backup
+30 4 * * mon-fri /usr/local/bin/report
+0 1-12/3 * * 7 /usr/local/bin/cleanup.sh --deep
+@reboot /usr/local/bin/startup
+0 0 * * * echo "date: %Y"
+bad line
+`
+
+	result, err := ParseCrontab(strings.NewReader(crontab), CrontabImportOptions{})
+	if err != nil {
+		t.Fatalf("ParseCrontab failed: %v", err)
+	}
+
+	if len(result.Jobs) != 5 {
+		t.Fatalf("Expected 5 jobs, got %d: %+v", len(result.Jobs), result.Jobs)
+	}
+
+	expected := []struct {
+		name     string
+		jobType  string
+		schedule string
+	}{
+		{"cron-cleanup", "command", "0 2 * * *"},
+		{"cron-curl", "script", "*/15 * * * *"},
+		{"cron-run-backup", "command", "0 0 * * *"},
+		{"cron-report", "command", "30 4 * * 1-5"},
+		{"cron-cleanup-2", "command", "0 1,4,7,10 * * 0"},
+	}
+
+	for i, exp := range expected {
+		job := result.Jobs[i]
+		if job.Name != exp.name {
+			t.Errorf("Job %d: expected name %s, got %s", i, exp.name, job.Name)
+		}
+		if job.Type != exp.jobType {
+			t.Errorf("Job %s: expected type %s, got %s", job.Name, exp.jobType, job.Type)
+		}
+		if job.Schedule != exp.schedule {
+			t.Errorf("Job %s: expected schedule %q, got %q", job.Name, exp.schedule, job.Schedule)
+		}
+		if job.Environment["PATH"] != "/usr/local/bin:/usr/bin" {
+			t.Errorf("Job %s: expected PATH environment to be carried over", job.Name)
+		}
+		if !job.Enabled {
+			t.Errorf("Job %s: expected job to be enabled", job.Name)
+		}
+	}
+
+	if !strings.HasPrefix(result.Jobs[1].Script, "#!/bin/bash\n") {
+		t.Errorf("Expected shell command to be wrapped in a script, got %q", result.Jobs[1].Script)
+	}
+
+	// @reboot, % syntax and the malformed line are skipped
+	if len(result.Skipped) != 3 {
+		t.Errorf("Expected 3 skipped lines, got %d: %v", len(result.Skipped), result.Skipped)
+	}
+
+	// MAILTO and shell conversion produce warnings
+	if len(result.Warnings) != 2 {
+		t.Errorf("Expected 2 warnings, got %d: %v", len(result.Warnings), result.Warnings)
+	}
+}
+
+func TestParseCrontabSystemFormat(t *testing.T) {
+	crontab := "17 * * * * root cd / && run-parts --report /etc/cron.hourly\n25 6 * * * www-data /usr/bin/php /var/www/cron.php\n"
+
+	result, err := ParseCrontab(strings.NewReader(crontab), CrontabImportOptions{System: true, NamePrefix: "sys-", Disabled: true})
+	if err != nil {
+		t.Fatalf("ParseCrontab failed: %v", err)
+	}
+
+	if len(result.Jobs) != 2 {
+		t.Fatalf("Expected 2 jobs, got %d (skipped: %v)", len(result.Jobs), result.Skipped)
+	}
+
+	if result.Jobs[0].Name != "sys-cron-cd" || result.Jobs[0].Type != "script" {
+		t.Errorf("Unexpected first job: %+v", result.Jobs[0])
+	}
+	if result.Jobs[1].Command != "/usr/bin/php /var/www/cron.php" {
+		t.Errorf("Expected user field to be stripped, got command %q", result.Jobs[1].Command)
+	}
+	if result.Jobs[1].Enabled {
+		t.Error("Expected imported jobs to be disabled")
+	}
+
+	// Non-root user is flagged
+	found := false
+	for _, warning := range result.Warnings {
+		if strings.Contains(warning, "www-data") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected warning about www-data user, got %v", result.Warnings)
+	}
+}
+
+func TestConvertCronSchedule(t *testing.T) {
+	tests := []struct {
+		input       string
+		expected    string
+		expectError bool
+	}{
+		{"0 9 * * 1-5", "0 9 * * 1-5", false},
+		{"0 0 1 jan,jul *", "0 0 1 1,7 *", false},
+		{"5/20 * * * *", "5,25,45 * * * *", false},
+		{"0 0 * * 5-7", "0 0 * * 5-6,0", false},
+		{"60 * * * *", "", true},
+		{"0 0 * * L", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := convertCronSchedule(strings.Fields(tt.input))
+		if tt.expectError {
+			if err == nil {
+				t.Errorf("convertCronSchedule(%q): expected error, got %q", tt.input, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("convertCronSchedule(%q): unexpected error: %v", tt.input, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("convertCronSchedule(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
@@ -3,12 +3,14 @@ package job
 import (
 	"fmt"
 	"slices"
+	"sync"
 )
 
 // DependencyResolver handles job dependency checking and execution ordering
 type DependencyResolver struct {
 	jobs          []*Job
 	jobsByName    map[string]*Job
+	mu            sync.Mutex // Guards completedJobs and failedJobs, updated as concurrent jobs finish
 	completedJobs map[string]bool
 	failedJobs    map[string]bool
 }
@@ -32,23 +34,31 @@ func NewDependencyResolver(jobs []*Job) *DependencyResolver {
 
 // SetJobCompleted marks a job as completed successfully
 func (dr *DependencyResolver) SetJobCompleted(jobName string) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
 	dr.completedJobs[jobName] = true
 	delete(dr.failedJobs, jobName) // Remove from failed if it was there
 }
 
 // SetJobFailed marks a job as failed
 func (dr *DependencyResolver) SetJobFailed(jobName string) {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
 	dr.failedJobs[jobName] = true
 	delete(dr.completedJobs, jobName) // Remove from completed if it was there
 }
 
 // IsJobCompleted checks if a job has completed successfully
 func (dr *DependencyResolver) IsJobCompleted(jobName string) bool {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
 	return dr.completedJobs[jobName]
 }
 
 // IsJobFailed checks if a job has failed
 func (dr *DependencyResolver) IsJobFailed(jobName string) bool {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
 	return dr.failedJobs[jobName]
 }
 
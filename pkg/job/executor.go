@@ -35,13 +35,19 @@ func NewExecutor(workspaceDeploymentDir string, tofuClient opentofu.TofuClient,
 // ExecuteJob executes a job and returns the execution result
 func (e *Executor) ExecuteJob(job *Job) *JobExecution {
 	execution := &JobExecution{
-		JobName:     job.Name,
-		WorkspaceID: job.WorkspaceID,
-		Status:      JobStatusRunning,
-		StartTime:   time.Now(),
+		JobName:       job.Name,
+		WorkspaceID:   job.WorkspaceID,
+		Status:        JobStatusRunning,
+		StartTime:     time.Now(),
+		CorrelationID: job.CorrelationID,
 	}
 
-	logging.LogWorkspace(job.WorkspaceID, "JOB %s: Starting execution", job.Name)
+	// Scheduled and manual runs are operations of their own
+	if execution.CorrelationID == "" {
+		execution.CorrelationID = logging.NewCorrelationID(execution.StartTime)
+	}
+
+	logging.LogWorkspace(job.WorkspaceID, "JOB %s: Starting execution (correlation ID %s)", job.Name, execution.CorrelationID)
 
 	// Get timeout duration
 	timeout, err := job.GetTimeoutDuration()
@@ -86,8 +92,8 @@ func (e *Executor) executeScript(ctx context.Context, job *Job, execution *JobEx
 
 	// Execute script
 	cmd := exec.CommandContext(ctx, "/bin/bash", scriptFile)
-	e.setupCommand(cmd, job)
-	e.runCommand(cmd, execution)
+	e.setupCommand(cmd, job, execution)
+	e.runCommand(cmd, job, execution)
 }
 
 // executeCommand runs a single command
@@ -101,8 +107,8 @@ func (e *Executor) executeCommand(ctx context.Context, job *Job, execution *JobE
 	}
 
 	cmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
-	e.setupCommand(cmd, job)
-	e.runCommand(cmd, execution)
+	e.setupCommand(cmd, job, execution)
+	e.runCommand(cmd, job, execution)
 }
 
 // executeTemplate deploys or updates a template within the workspace
@@ -121,7 +127,7 @@ func (e *Executor) executeTemplate(ctx context.Context, job *Job, execution *Job
 	}
 
 	// Create subdirectory for this template job
-	jobWorkingDir := filepath.Join(e.workspaceDeploymentDir, "jobs", job.Name)
+	jobWorkingDir := TemplateWorkingDirectory(e.workspaceDeploymentDir, job.Name)
 	if err := os.MkdirAll(jobWorkingDir, 0755); err != nil {
 		execution.Status = JobStatusFailed
 		execution.Error = fmt.Sprintf("Failed to create job working directory: %v", err)
@@ -156,7 +162,68 @@ func (e *Executor) executeTemplate(ctx context.Context, job *Job, execution *Job
 	}
 
 	execution.Status = JobStatusSuccess
-	execution.Output = fmt.Sprintf("Template '%s' deployed successfully in job working directory", job.Template)
+	execution.Output = fmt.Sprintf("Template '%s' deployed successfully in job working directory\n", job.Template)
+
+	// Capture the deployment's outputs for the job state and history
+	outputs, err := e.tofuClient.Output(jobWorkingDir)
+	if err != nil {
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed to read template outputs: %v", job.Name, err)
+		return
+	}
+	execution.Outputs = templateOutputs(outputs)
+	if len(execution.Outputs) > 0 {
+		execution.Output += "\nOutputs:\n" + formatOutputs(execution.Outputs)
+	}
+}
+
+// DestroyTemplate destroys the deployment of a template job in its working directory
+func (e *Executor) DestroyTemplate(job *Job) *JobExecution {
+	execution := &JobExecution{
+		JobName:       job.Name,
+		WorkspaceID:   job.WorkspaceID,
+		Status:        JobStatusRunning,
+		StartTime:     time.Now(),
+		CorrelationID: job.CorrelationID,
+	}
+	if execution.CorrelationID == "" {
+		execution.CorrelationID = logging.NewCorrelationID(execution.StartTime)
+	}
+
+	logging.LogWorkspace(job.WorkspaceID, "JOB %s: Destroying template deployment (correlation ID %s)", job.Name, execution.CorrelationID)
+	e.destroyTemplate(job, execution)
+	e.finishExecution(execution)
+	return execution
+}
+
+// destroyTemplate runs OpenTofu destroy in a template job's working directory
+func (e *Executor) destroyTemplate(job *Job, execution *JobExecution) {
+	if e.tofuClient == nil {
+		execution.Status = JobStatusFailed
+		execution.Error = "OpenTofu client not available for template jobs"
+		return
+	}
+
+	jobWorkingDir := TemplateWorkingDirectory(e.workspaceDeploymentDir, job.Name)
+	if _, err := os.Stat(jobWorkingDir); err != nil {
+		execution.Status = JobStatusFailed
+		execution.Error = fmt.Sprintf("No deployment directory for template job: %v", err)
+		return
+	}
+
+	if err := e.tofuClient.Init(jobWorkingDir); err != nil {
+		execution.Status = JobStatusFailed
+		execution.Error = fmt.Sprintf("Template init failed: %v", err)
+		return
+	}
+
+	if err := e.tofuClient.Destroy(jobWorkingDir); err != nil {
+		execution.Status = JobStatusFailed
+		execution.Error = fmt.Sprintf("Template destroy failed: %v", err)
+		return
+	}
+
+	execution.Status = JobStatusSuccess
+	execution.Output = fmt.Sprintf("Template '%s' destroyed in job working directory\n", job.Template)
 }
 
 // copyTemplateFiles copies template files to the job working directory
@@ -187,7 +254,7 @@ func (e *Executor) copyTemplateFiles(srcDir, dstDir string) error {
 }
 
 // setupCommand configures the command with environment and working directory
-func (e *Executor) setupCommand(cmd *exec.Cmd, job *Job) {
+func (e *Executor) setupCommand(cmd *exec.Cmd, job *Job, execution *JobExecution) {
 	// Set working directory
 	cmd.Dir = job.GetWorkingDirectory(e.workspaceDeploymentDir)
 
@@ -204,15 +271,41 @@ func (e *Executor) setupCommand(cmd *exec.Cmd, job *Job) {
 		fmt.Sprintf("WORKSPACE_ID=%s", job.WorkspaceID),
 		fmt.Sprintf("JOB_NAME=%s", job.Name),
 		fmt.Sprintf("WORKSPACE_DEPLOYMENT_DIR=%s", e.workspaceDeploymentDir),
+		fmt.Sprintf("%s=%s", logging.CorrelationIDEnv, execution.CorrelationID),
 	)
+
+	// Add outputs of the workspace's deployed state as TF_OUTPUT_* variables
+	cmd.Env = append(cmd.Env, e.outputEnvironment(job)...)
+}
+
+// outputEnvironment returns the workspace's OpenTofu outputs as environment variables.
+// Workspaces that have not been deployed have no state and therefore no outputs.
+func (e *Executor) outputEnvironment(job *Job) []string {
+	if e.tofuClient == nil {
+		return nil
+	}
+	if !opentofu.HasState(e.workspaceDeploymentDir) {
+		return nil
+	}
+
+	outputs, err := e.tofuClient.Output(e.workspaceDeploymentDir)
+	if err != nil {
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed to read OpenTofu outputs: %v", job.Name, err)
+		return nil
+	}
+	return opentofu.OutputEnvironment(outputs)
 }
 
 // runCommand executes the command and captures output
-func (e *Executor) runCommand(cmd *exec.Cmd, execution *JobExecution) {
+func (e *Executor) runCommand(cmd *exec.Cmd, job *Job, execution *JobExecution) {
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
 
+	// Apply the job's resource limits to the process
+	limiter := e.prepareLimits(cmd, job)
+	defer limiter.release()
+
 	// Store PID when process starts
 	err := cmd.Start()
 	if err != nil {
@@ -223,6 +316,7 @@ func (e *Executor) runCommand(cmd *exec.Cmd, execution *JobExecution) {
 
 	execution.PID = cmd.Process.Pid
 	logging.LogWorkspace(execution.WorkspaceID, "JOB %s: Process started with PID %d", execution.JobName, execution.PID)
+	limiter.started(execution.PID)
 
 	// Wait for command to complete
 	err = cmd.Wait()
@@ -0,0 +1,98 @@
+package job
+
+import (
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"provisioner/pkg/logging"
+)
+
+// HistoryLimit is the number of runs kept in the history of each job
+const HistoryLimit = 20
+
+// HistoryOutputLimit is the number of bytes of output kept per run; longer output keeps its end
+const HistoryOutputLimit = 16 * 1024
+
+// HistoryOperationDestroy marks the destroy of a template job's deployment in the job's history
+const HistoryOperationDestroy = "destroy"
+
+// HistoryEntry is a finished run of a job as kept in the job's history
+type HistoryEntry struct {
+	RunID           string    `json:"run_id"`
+	Operation       string    `json:"operation,omitempty"` // Empty for runs, destroy for template deployment destroys
+	Status          JobStatus `json:"status"`
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	ExitCode        int       `json:"exit_code"`
+	Attempts        int       `json:"attempts,omitempty"`
+	Error           string    `json:"error,omitempty"`
+	Output          string    `json:"output,omitempty"`
+	OutputTruncated bool      `json:"output_truncated,omitempty"` // The start of the output was dropped
+	Artifacts       []string  `json:"artifacts,omitempty"`        // Files collected after the run
+	CorrelationID   string    `json:"correlation_id,omitempty"`
+}
+
+// Duration returns how long the run took, including retries
+func (h *HistoryEntry) Duration() time.Duration {
+	return h.EndTime.Sub(h.StartTime)
+}
+
+// newHistoryEntry records a finished execution, masking secrets in its output and error
+func newHistoryEntry(execution *JobExecution) HistoryEntry {
+	entry := HistoryEntry{
+		RunID:         execution.RunID,
+		Status:        execution.Status,
+		StartTime:     execution.StartTime,
+		EndTime:       time.Now(),
+		ExitCode:      execution.ExitCode,
+		Attempts:      execution.Attempts,
+		Error:         logging.RedactWorkspace(execution.WorkspaceID, execution.Error),
+		Output:        logging.RedactWorkspace(execution.WorkspaceID, execution.Output),
+		Artifacts:     execution.Artifacts,
+		CorrelationID: execution.CorrelationID,
+	}
+	if execution.EndTime != nil {
+		entry.EndTime = *execution.EndTime
+	}
+	if entry.RunID == "" {
+		entry.RunID = NewRunID()
+	}
+	if len(entry.Output) > HistoryOutputLimit {
+		start := len(entry.Output) - HistoryOutputLimit
+		for start < len(entry.Output) && !utf8.RuneStart(entry.Output[start]) {
+			start++
+		}
+		entry.Output = entry.Output[start:]
+		entry.OutputTruncated = true
+	}
+	return entry
+}
+
+// addHistoryEntry appends a run to a job's history, dropping the oldest runs beyond HistoryLimit
+func (js *JobState) addHistoryEntry(entry HistoryEntry) {
+	js.History = append(js.History, entry)
+	if excess := len(js.History) - HistoryLimit; excess > 0 {
+		js.History = append([]HistoryEntry(nil), js.History[excess:]...)
+	}
+}
+
+// GetHistory returns the kept runs of a job, newest first
+func (m *Manager) GetHistory(workspaceID, jobName string) []HistoryEntry {
+	history := m.stateManager.GetJobState(workspaceID, jobName).History
+	runs := make([]HistoryEntry, len(history))
+	for i, entry := range history {
+		runs[len(history)-1-i] = entry
+	}
+	return runs
+}
+
+// GetHistoryEntry returns a run from a job's history
+func (m *Manager) GetHistoryEntry(workspaceID, jobName, runID string) (*HistoryEntry, error) {
+	for _, entry := range m.GetHistory(workspaceID, jobName) {
+		if entry.RunID == runID {
+			return &entry, nil
+		}
+	}
+	return nil, fmt.Errorf("run '%s' not found in the history of job '%s'", runID, jobName)
+}
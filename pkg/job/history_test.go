@@ -0,0 +1,87 @@
+package job
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHistoryKeepsLastRuns(t *testing.T) {
+	sm := NewStateManager(t.TempDir() + "/jobs.json")
+	if err := sm.LoadState(); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	start := time.Date(2025, 9, 27, 2, 0, 0, 0, time.UTC)
+	for i := 0; i < HistoryLimit+5; i++ {
+		end := start.Add(time.Duration(i)*time.Hour + time.Second)
+		sm.UpdateJobExecution(&JobExecution{
+			RunID:       "run-" + string(rune('a'+i)),
+			JobName:     "backup",
+			WorkspaceID: "test-workspace",
+			Status:      JobStatusSuccess,
+			StartTime:   start.Add(time.Duration(i) * time.Hour),
+			EndTime:     &end,
+		})
+	}
+
+	history := sm.GetJobState("test-workspace", "backup").History
+	if len(history) != HistoryLimit {
+		t.Fatalf("Expected %d runs in the history, got %d", HistoryLimit, len(history))
+	}
+	if history[0].RunID != "run-f" || history[HistoryLimit-1].RunID != "run-y" {
+		t.Errorf("Expected the oldest runs to be dropped, history runs from %s to %s", history[0].RunID, history[HistoryLimit-1].RunID)
+	}
+	if history[0].Duration() != time.Second {
+		t.Errorf("Expected a duration of 1s, got %v", history[0].Duration())
+	}
+}
+
+func TestHistoryTruncatesOutput(t *testing.T) {
+	output := strings.Repeat("x", HistoryOutputLimit) + "last line\n"
+	entry := newHistoryEntry(&JobExecution{JobName: "noisy", WorkspaceID: "test-workspace", Status: JobStatusSuccess, Output: output})
+
+	if !entry.OutputTruncated || len(entry.Output) != HistoryOutputLimit || !strings.HasSuffix(entry.Output, "last line\n") {
+		t.Errorf("Expected the last %d bytes of the output, got %d bytes (truncated %v)", HistoryOutputLimit, len(entry.Output), entry.OutputTruncated)
+	}
+	if entry.RunID == "" {
+		t.Error("Expected a run ID to be generated")
+	}
+}
+
+func TestExecuteJobRecordsHistory(t *testing.T) {
+	manager := newTestManager(t, nil)
+
+	job, err := JobConfigToJob("test-workspace", map[string]interface{}{
+		"name":        "flaky",
+		"type":        "script",
+		"script":      "echo trying\nexit 3",
+		"retries":     1,
+		"retry_delay": "1ms",
+	})
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+	job.RunID = "20250927-020000-abcdef"
+
+	execution := manager.ExecuteJob(job)
+	history := manager.GetHistory("test-workspace", "flaky")
+	if len(history) != 1 {
+		t.Fatalf("Expected one run in the history, got %d", len(history))
+	}
+
+	entry, err := manager.GetHistoryEntry("test-workspace", "flaky", job.RunID)
+	if err != nil {
+		t.Fatalf("GetHistoryEntry failed: %v", err)
+	}
+	if entry.Status != JobStatusFailed || entry.ExitCode != 3 || entry.Attempts != 2 || !strings.Contains(entry.Output, "trying") {
+		t.Errorf("Unexpected history entry %+v", entry)
+	}
+	if !entry.StartTime.Equal(execution.StartTime) || entry.CorrelationID != execution.CorrelationID {
+		t.Errorf("Expected the run to start with its first attempt, got %v", entry.StartTime)
+	}
+
+	if _, err := manager.GetHistoryEntry("test-workspace", "flaky", "unknown"); err == nil {
+		t.Error("Expected an error for an unknown run")
+	}
+}
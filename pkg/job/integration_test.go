@@ -441,3 +441,40 @@ func TestJobWorkingDirectory(t *testing.T) {
 		t.Errorf("Expected job to succeed with custom working directory, got status %s with error: %s", execution.Status, execution.Error)
 	}
 }
+
+// TestJobOutputEnvironmentVariables tests that deployed workspace outputs reach jobs as TF_OUTPUT_* variables
+func TestJobOutputEnvironmentVariables(t *testing.T) {
+	workspaceDir := filepath.Join(t.TempDir(), "deployments", "output-test")
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		t.Fatalf("Failed to create workspace directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workspaceDir, "terraform.tfstate"), []byte("{}"), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	mockClient := opentofu.NewMockTofuClient()
+	mockClient.OutputFunc = func(workingDir string) (map[string]opentofu.OutputValue, error) {
+		return map[string]opentofu.OutputValue{
+			"lb_ip": {Value: []byte(`"10.0.0.5"`)},
+		}, nil
+	}
+
+	job := &Job{
+		Name:        "post-deploy",
+		WorkspaceID: "output-test",
+		JobType:     JobTypeScript,
+		Script:      "#!/bin/bash\ntest \"$TF_OUTPUT_LB_IP\" = \"10.0.0.5\"",
+		Timeout:     "30s",
+		Enabled:     true,
+	}
+
+	executor := NewExecutor(workspaceDir, mockClient, template.NewManager(t.TempDir()))
+	execution := executor.ExecuteJob(job)
+
+	if execution.Status != JobStatusSuccess {
+		t.Errorf("Expected job to see TF_OUTPUT_LB_IP, got status %s with error: %s", execution.Status, execution.Error)
+	}
+	if mockClient.OutputCallCount != 1 {
+		t.Errorf("Expected outputs to be read once, got %d", mockClient.OutputCallCount)
+	}
+}
@@ -0,0 +1,52 @@
+package job
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"time"
+)
+
+// DefaultSpreadWindow is the window spread_by_name distributes starts over when no jitter is set
+const DefaultSpreadWindow = 5 * time.Minute
+
+// GetJitterDuration parses the jitter string, returning 0 if no jitter is configured
+func (j *Job) GetJitterDuration() (time.Duration, error) {
+	if j.Jitter == "" {
+		return 0, nil
+	}
+	jitter, err := time.ParseDuration(j.Jitter)
+	if err != nil || jitter < 0 {
+		return 0, fmt.Errorf("invalid jitter duration '%s'", j.Jitter)
+	}
+	return jitter, nil
+}
+
+// startWindow returns the window scheduled starts are delayed within, 0 if they start immediately
+func (j *Job) startWindow() time.Duration {
+	jitter, err := j.GetJitterDuration()
+	if err != nil {
+		return 0
+	}
+	if jitter == 0 && j.SpreadByName {
+		return DefaultSpreadWindow
+	}
+	return jitter
+}
+
+// StartDelay returns how long a scheduled run waits before it starts. With spread_by_name the
+// delay is derived from the workspace and job name, so each job keeps the same slot every run;
+// otherwise it is random within the jitter window.
+func (j *Job) StartDelay() time.Duration {
+	window := j.startWindow()
+	if window <= 0 {
+		return 0
+	}
+
+	if j.SpreadByName {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(j.WorkspaceID + "/" + j.Name))
+		return time.Duration(h.Sum64() % uint64(window))
+	}
+	return rand.N(window)
+}
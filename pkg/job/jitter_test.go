@@ -0,0 +1,107 @@
+package job
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartDelay(t *testing.T) {
+	job := &Job{Name: "nightly-backup", WorkspaceID: "_standalone_"}
+	if delay := job.StartDelay(); delay != 0 {
+		t.Errorf("Expected no delay without jitter, got %v", delay)
+	}
+
+	job.Jitter = "5m"
+	for i := 0; i < 100; i++ {
+		if delay := job.StartDelay(); delay < 0 || delay >= 5*time.Minute {
+			t.Fatalf("Expected delay within jitter window, got %v", delay)
+		}
+	}
+
+	// Spread by name keeps the same slot every run, and different jobs get different slots
+	job.SpreadByName = true
+	first := job.StartDelay()
+	if first < 0 || first >= 5*time.Minute || job.StartDelay() != first {
+		t.Errorf("Expected stable delay within window, got %v then %v", first, job.StartDelay())
+	}
+	other := &Job{Name: "nightly-report", WorkspaceID: "_standalone_", Jitter: "5m", SpreadByName: true}
+	if other.StartDelay() == first {
+		t.Errorf("Expected different jobs to be spread apart, both got %v", first)
+	}
+
+	// Without jitter, spread_by_name uses the default window
+	job.Jitter = ""
+	if delay := job.StartDelay(); delay < 0 || delay >= DefaultSpreadWindow {
+		t.Errorf("Expected delay within default spread window, got %v", delay)
+	}
+}
+
+func TestJobConfigToJobJitter(t *testing.T) {
+	config := map[string]interface{}{
+		"name":           "nightly-backup",
+		"type":           "command",
+		"command":        "backup.sh",
+		"schedule":       "0 2 * * *",
+		"jitter":         "10m",
+		"spread_by_name": true,
+	}
+
+	job, err := JobConfigToJob("_standalone_", config)
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+	if job.Jitter != "10m" || !job.SpreadByName {
+		t.Errorf("Expected jitter 10m with spread_by_name, got %q/%v", job.Jitter, job.SpreadByName)
+	}
+
+	config["jitter"] = "-1m"
+	if _, err := JobConfigToJob("_standalone_", config); err == nil {
+		t.Error("Expected error for negative jitter")
+	}
+	config["jitter"] = "later"
+	if _, err := JobConfigToJob("_standalone_", config); err == nil {
+		t.Error("Expected error for invalid jitter")
+	}
+}
+
+func TestScheduledRunWaitsForStartDelay(t *testing.T) {
+	manager := newTestManager(t, nil)
+
+	finished := make(chan *JobExecution, 1)
+	manager.SetJobFinishedHandler(func(execution *JobExecution) { finished <- execution })
+
+	jobConfig := map[string]interface{}{
+		"name":     "spread-job",
+		"type":     "command",
+		"command":  "true",
+		"schedule": "0 2 * * *",
+		"jitter":   "300ms",
+	}
+	job, err := JobConfigToJob("test-workspace", jobConfig)
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+
+	now := time.Now()
+	manager.triggerScheduledRun(job, now, "", nil)
+
+	// A delayed run is not triggered again by the next scheduling pass
+	if manager.ShouldRunJob(job, now) && manager.isDelayed(job) {
+		t.Error("Expected job waiting for its start delay not to be triggered again")
+	}
+
+	select {
+	case execution := <-finished:
+		if execution.StartTime.Before(now) || execution.StartTime.After(now.Add(time.Second)) {
+			t.Errorf("Expected start within the jitter window, started at %v", execution.StartTime.Sub(now))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Delayed job did not run")
+	}
+
+	// The delay marker is cleared once the run has finished
+	time.Sleep(50 * time.Millisecond)
+	if manager.isDelayed(job) {
+		t.Error("Expected delay marker to be cleared after the run")
+	}
+}
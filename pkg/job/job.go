@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"path/filepath"
 	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/cron"
 )
 
 // JobType defines the type of job to execute
@@ -29,23 +32,52 @@ const (
 
 // Job represents a scheduled job within a workspace
 type Job struct {
-	Name        string            `json:"name"`
-	WorkspaceID string            `json:"workspace_id"`
-	JobType     JobType           `json:"type"`
-	Schedule    interface{}       `json:"schedule"`              // String or []string for CRON expressions
-	Script      string            `json:"script,omitempty"`      // Shell script content
-	Command     string            `json:"command,omitempty"`     // Single command to execute
-	Template    string            `json:"template,omitempty"`    // Template name for template jobs
-	Environment map[string]string `json:"environment,omitempty"` // Environment variables
-	WorkingDir  string            `json:"working_dir,omitempty"` // Working directory (relative to workspace)
-	Timeout     string            `json:"timeout,omitempty"`     // Timeout duration (e.g., "30m", "1h")
-	Enabled     bool              `json:"enabled"`
-	Description string            `json:"description,omitempty"`
-	DependsOn   []string          `json:"depends_on,omitempty"` // Job dependencies
+	Name         string            `json:"name"`
+	WorkspaceID  string            `json:"workspace_id"`
+	JobType      JobType           `json:"type"`
+	Schedule     interface{}       `json:"schedule"`              // String or []string for CRON expressions
+	Script       string            `json:"script,omitempty"`      // Shell script content
+	Command      string            `json:"command,omitempty"`     // Single command to execute
+	Template     string            `json:"template,omitempty"`    // Template name for template jobs
+	Environment  map[string]string `json:"environment,omitempty"` // Environment variables
+	WorkingDir   string            `json:"working_dir,omitempty"` // Working directory (relative to workspace)
+	Timeout      string            `json:"timeout,omitempty"`     // Timeout duration (e.g., "30m", "1h")
+	Enabled      bool              `json:"enabled"`
+	Description  string            `json:"description,omitempty"`
+	DependsOn    []string          `json:"depends_on,omitempty"`     // Job dependencies
+	Throttle     []string          `json:"throttle,omitempty"`       // Throttle buckets limiting concurrent runs
+	Jitter       string            `json:"jitter,omitempty"`         // Random delay of scheduled starts up to this duration (e.g., "5m")
+	SpreadByName bool              `json:"spread_by_name,omitempty"` // Delay scheduled starts by a stable offset derived from the job name
+	Retries      int               `json:"retries,omitempty"`        // Attempts after a failed one before the run fails
+	RetryDelay   string            `json:"retry_delay,omitempty"`    // Wait between attempts (default 30s)
+	OnFailure    string            `json:"on_failure,omitempty"`     // continue, abort-dependents or notify (default)
+
+	// Lifecycle of a template job's deployment
+	DestroyAfter    string      `json:"destroy_after,omitempty"`    // Destroy the deployment this long after a deploy (e.g., "8h")
+	DestroySchedule interface{} `json:"destroy_schedule,omitempty"` // String or []string of CRON expressions or events destroying the deployment
+
+	// Resource limits of a script or command job's processes
+	MaxCPU    float64 `json:"max_cpu,omitempty"`    // CPU cores (e.g., 0.5), needs cgroups v2
+	MaxMemory string  `json:"max_memory,omitempty"` // Memory (e.g., "512M", "2G")
+	Nice      int     `json:"nice,omitempty"`       // Scheduling priority from -20 to 19
+
+	// Files collected from the working directory after each run
+	Artifacts         interface{} `json:"artifacts,omitempty"`          // String or []string of glob patterns
+	ArtifactRetention string      `json:"artifact_retention,omitempty"` // Remove a run's artifacts after this long (e.g., "168h")
+
+	// CorrelationID ties an event-triggered run to the operation that triggered it
+	CorrelationID string `json:"-"`
+
+	// Trigger is what started the run for the audit log, its schedule if unset
+	Trigger audit.Trigger `json:"-"`
+
+	// RunID identifies the run in the job's history, generated if unset
+	RunID string `json:"-"`
 }
 
 // JobExecution represents a single execution instance of a job
 type JobExecution struct {
+	RunID       string        `json:"run_id,omitempty"`
 	JobName     string        `json:"job_name"`
 	WorkspaceID string        `json:"workspace_id"`
 	Status      JobStatus     `json:"status"`
@@ -56,6 +88,16 @@ type JobExecution struct {
 	Output      string        `json:"output,omitempty"`
 	Error       string        `json:"error,omitempty"`
 	PID         int           `json:"pid,omitempty"`
+	Attempts    int           `json:"attempts,omitempty"`   // Attempts the run took, including retries
+	OnFailure   string        `json:"on_failure,omitempty"` // What happens when the run failed, from the job's config
+
+	// Outputs of a template job's deployment
+	Outputs map[string]string `json:"outputs,omitempty"`
+
+	// Artifacts collected from the working directory, relative to it
+	Artifacts []string `json:"artifacts,omitempty"`
+
+	CorrelationID string `json:"correlation_id,omitempty"`
 }
 
 // JobState tracks the persistent state of a job across scheduler restarts
@@ -73,6 +115,15 @@ type JobState struct {
 	FailureCount       int        `json:"failure_count"`
 	LastConfigModified *time.Time `json:"last_config_modified,omitempty"`
 	NextRun            *time.Time `json:"next_run,omitempty"`
+	LastCorrelationID  string     `json:"last_correlation_id,omitempty"`
+	LastAttempts       int        `json:"last_attempts,omitempty"` // Attempts the last run took, including retries
+	RetryCount         int        `json:"retry_count"`             // Retried attempts across all runs
+
+	// History holds the last HistoryLimit finished runs, oldest first
+	History []HistoryEntry `json:"history,omitempty"`
+
+	// Deployment is the OpenTofu deployment of a template job
+	Deployment *TemplateDeployment `json:"deployment,omitempty"`
 }
 
 // GetSchedules returns job schedules as a slice, handling both string and []string formats
@@ -144,9 +195,15 @@ func (j *Job) Validate() error {
 
 	// Validate schedule if provided
 	if j.Schedule != nil {
-		if _, err := j.GetSchedules(); err != nil {
+		schedules, err := j.GetSchedules()
+		if err != nil {
 			return fmt.Errorf("invalid schedule: %w", err)
 		}
+		for _, schedule := range schedules {
+			if _, err := cron.Parse(schedule); err != nil {
+				return fmt.Errorf("invalid schedule '%s': %w", schedule, err)
+			}
+		}
 	}
 
 	// Validate timeout if provided
@@ -156,6 +213,27 @@ func (j *Job) Validate() error {
 		}
 	}
 
+	// Validate jitter if provided
+	if _, err := j.GetJitterDuration(); err != nil {
+		return err
+	}
+
+	if err := j.validateRetryPolicy(); err != nil {
+		return err
+	}
+
+	if err := j.validateTemplateLifecycle(); err != nil {
+		return err
+	}
+
+	if err := j.validateResourceLimits(); err != nil {
+		return err
+	}
+
+	if err := j.validateArtifacts(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -250,6 +328,73 @@ func JobConfigToJob(workspaceID string, config interface{}) (*Job, error) {
 		}
 	}
 
+	// Extract throttle buckets
+	switch buckets := configMap["throttle"].(type) {
+	case []string:
+		job.Throttle = buckets
+	case []interface{}:
+		for _, bucket := range buckets {
+			if strBucket, ok := bucket.(string); ok {
+				job.Throttle = append(job.Throttle, strBucket)
+			}
+		}
+	}
+
+	// Extract start spreading
+	if jitter, ok := configMap["jitter"].(string); ok {
+		job.Jitter = jitter
+	}
+	if spread, ok := configMap["spread_by_name"].(bool); ok {
+		job.SpreadByName = spread
+	}
+
+	// Extract retry policy; retries are an int from configs built in code and a float64 from JSON
+	switch retries := configMap["retries"].(type) {
+	case int:
+		job.Retries = retries
+	case float64:
+		job.Retries = int(retries)
+	}
+	if retryDelay, ok := configMap["retry_delay"].(string); ok {
+		job.RetryDelay = retryDelay
+	}
+	if onFailure, ok := configMap["on_failure"].(string); ok {
+		job.OnFailure = onFailure
+	}
+
+	// Extract the template deployment's lifecycle
+	if destroyAfter, ok := configMap["destroy_after"].(string); ok {
+		job.DestroyAfter = destroyAfter
+	}
+	if destroySchedule, exists := configMap["destroy_schedule"]; exists && destroySchedule != nil {
+		job.DestroySchedule = destroySchedule
+	}
+
+	// Extract resource limits; numbers are an int or float64 like retries
+	switch maxCPU := configMap["max_cpu"].(type) {
+	case int:
+		job.MaxCPU = float64(maxCPU)
+	case float64:
+		job.MaxCPU = maxCPU
+	}
+	if maxMemory, ok := configMap["max_memory"].(string); ok {
+		job.MaxMemory = maxMemory
+	}
+	switch nice := configMap["nice"].(type) {
+	case int:
+		job.Nice = nice
+	case float64:
+		job.Nice = int(nice)
+	}
+
+	// Extract artifacts
+	if artifacts, exists := configMap["artifacts"]; exists && artifacts != nil {
+		job.Artifacts = artifacts
+	}
+	if retention, ok := configMap["artifact_retention"].(string); ok {
+		job.ArtifactRetention = retention
+	}
+
 	// Validate the job
 	if err := job.Validate(); err != nil {
 		return nil, fmt.Errorf("job validation failed: %w", err)
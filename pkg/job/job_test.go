@@ -1,10 +1,30 @@
 package job
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/template"
 )
 
+// newTestManager returns a job manager with the deployment directory of test-workspace,
+// running OpenTofu through client
+func newTestManager(t *testing.T, client opentofu.TofuClient) *Manager {
+	t.Helper()
+	stateDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(stateDir, "deployments", "test-workspace"), 0755); err != nil {
+		t.Fatalf("Failed to create deployment dir: %v", err)
+	}
+	manager := NewManager(stateDir, client, template.NewManager(filepath.Join(stateDir, "templates")))
+	if err := manager.LoadState(); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	return manager
+}
+
 func TestJobValidation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -293,3 +313,83 @@ func TestJobStateTransitions(t *testing.T) {
 		t.Errorf("JobState.SuccessCount = %v, expected 1", state.SuccessCount)
 	}
 }
+
+func TestJobConfigToJobThrottle(t *testing.T) {
+	for _, throttle := range []interface{}{
+		[]string{"digitalocean-fra1"},
+		[]interface{}{"digitalocean-fra1"},
+	} {
+		job, err := JobConfigToJob("my-app", map[string]interface{}{
+			"name":     "snapshot",
+			"type":     "command",
+			"command":  "echo snapshot",
+			"throttle": throttle,
+		})
+		if err != nil {
+			t.Fatalf("JobConfigToJob failed: %v", err)
+		}
+		if len(job.Throttle) != 1 || job.Throttle[0] != "digitalocean-fra1" {
+			t.Errorf("Expected throttle [digitalocean-fra1] from %T, got %v", throttle, job.Throttle)
+		}
+	}
+}
+
+func ptrTime(t time.Time) *time.Time {
+	return &t
+}
+
+func TestShouldRunJobFollowsSchedule(t *testing.T) {
+	manager := NewManager(t.TempDir(), nil, nil)
+	if err := manager.LoadState(); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+	at := func(hour, minute, second int) time.Time {
+		return time.Date(2026, 3, 10, hour, minute, second, 0, time.Local)
+	}
+
+	tests := []struct {
+		name     string
+		schedule string
+		lastRun  *time.Time
+		now      time.Time
+		expected bool
+	}{
+		{"never run, schedule passed today", "*/5 * * * *", nil, at(10, 2, 0), true},
+		{"every 5 minutes, ran in current slot", "*/5 * * * *", ptrTime(at(10, 0, 3)), at(10, 4, 30), false},
+		{"every 5 minutes, next slot passed", "*/5 * * * *", ptrTime(at(10, 0, 3)), at(10, 5, 30), true},
+		{"daily, ran today", "0 2 * * *", ptrTime(at(2, 0, 10)), at(23, 0, 0), false},
+		{"daily, ran yesterday", "0 2 * * *", ptrTime(at(2, 0, 10).AddDate(0, 0, -1)), at(2, 0, 30), true},
+		{"daily, not yet today", "0 2 * * *", ptrTime(at(2, 0, 10).AddDate(0, 0, -1)), at(1, 59, 0), false},
+		{"event schedule", "@deployment", nil, at(10, 0, 30), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := &Job{Name: "sync", WorkspaceID: "test-workspace", JobType: JobTypeCommand, Command: "true", Enabled: true, Schedule: tt.schedule}
+			manager.stateManager.SetJobState(job.WorkspaceID, job.Name, &JobState{Name: job.Name, WorkspaceID: job.WorkspaceID, Status: JobStatusSuccess, LastRun: tt.lastRun})
+
+			if got := manager.ShouldRunJob(job, tt.now); got != tt.expected {
+				t.Errorf("ShouldRunJob at %s with last run %v = %v, expected %v", tt.now.Format("15:04:05"), tt.lastRun, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetNextRunTime(t *testing.T) {
+	sm := NewStateManager(filepath.Join(t.TempDir(), "jobs.json"))
+	now := time.Date(2026, 3, 10, 10, 7, 0, 0, time.UTC)
+
+	job := &Job{Name: "sync", Schedule: []string{"0 12 * * *", "*/15 * * * *"}}
+	next, err := sm.GetNextRunTime(job, now)
+	if err != nil {
+		t.Fatalf("GetNextRunTime failed: %v", err)
+	}
+	if expected := time.Date(2026, 3, 10, 10, 15, 0, 0, time.UTC); next == nil || !next.Equal(expected) {
+		t.Errorf("Expected next run %v, got %v", expected, next)
+	}
+
+	job.Schedule = "@deployment"
+	if next, err := sm.GetNextRunTime(job, now); err != nil || next != nil {
+		t.Errorf("Expected no next run for an event schedule, got %v (%v)", next, err)
+	}
+}
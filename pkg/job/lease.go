@@ -0,0 +1,201 @@
+package job
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// leaseGracePeriod is added to a job's timeout so a lease outlives its run
+const leaseGracePeriod = 5 * time.Minute
+
+// RunLease records which host executes a scheduled run of a job. Leases live in the state
+// directory so provisioners sharing it over NFS see each other's runs.
+type RunLease struct {
+	Generation int        `json:"generation"`
+	Holder     string     `json:"holder"` // hostname:pid of the provisioner running the job
+	StartedAt  time.Time  `json:"started_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Active returns true if the lease's run is still executing and has not expired
+func (l *RunLease) Active(now time.Time) bool {
+	return l.FinishedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// LastRun returns when the lease's run last counted as run: its finish time, or its start while running
+func (l *RunLease) LastRun() time.Time {
+	if l.FinishedAt != nil {
+		return *l.FinishedAt
+	}
+	return l.StartedAt
+}
+
+// LeaseManager claims job runs through generation-numbered lease files. Each run creates the
+// next generation with link(2), which is atomic on NFS, so exactly one host wins every run.
+type LeaseManager struct {
+	dir    string
+	holder string
+}
+
+// NewLeaseManager creates a lease manager storing leases below dir
+func NewLeaseManager(dir string) *LeaseManager {
+	host, _ := os.Hostname()
+	return &LeaseManager{
+		dir:    dir,
+		holder: fmt.Sprintf("%s:%d", host, os.Getpid()),
+	}
+}
+
+// jobDir returns the directory holding a job's lease generations
+func (lm *LeaseManager) jobDir(workspaceID, jobName string) string {
+	return filepath.Join(lm.dir, workspaceID, jobName)
+}
+
+// generationPath returns the lease file of a generation; zero padding keeps them sorted by name
+func (lm *LeaseManager) generationPath(workspaceID, jobName string, generation int) string {
+	return filepath.Join(lm.jobDir(workspaceID, jobName), fmt.Sprintf("%010d.json", generation))
+}
+
+// Current returns the newest lease of a job, or nil if it never ran under a lease
+func (lm *LeaseManager) Current(workspaceID, jobName string) (*RunLease, error) {
+	generations, err := lm.generations(workspaceID, jobName)
+	if err != nil || len(generations) == 0 {
+		return nil, err
+	}
+
+	latest := generations[len(generations)-1]
+	data, err := os.ReadFile(lm.generationPath(workspaceID, jobName, latest))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lease: %w", err)
+	}
+
+	var lease RunLease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return nil, fmt.Errorf("failed to parse lease: %w", err)
+	}
+	return &lease, nil
+}
+
+// generations returns the existing lease generations of a job in ascending order
+func (lm *LeaseManager) generations(workspaceID, jobName string) ([]int, error) {
+	entries, err := os.ReadDir(lm.jobDir(workspaceID, jobName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read lease directory: %w", err)
+	}
+
+	var generations []int
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if generation, err := strconv.Atoi(name); err == nil {
+			generations = append(generations, generation)
+		}
+	}
+	sort.Ints(generations)
+	return generations, nil
+}
+
+// Acquire claims the next run of a job until its start window, timeout and a grace period have passed.
+// It returns nil without error when another host is running the job or claimed the run first.
+func (lm *LeaseManager) Acquire(job *Job, now time.Time) (*RunLease, error) {
+	current, err := lm.Current(job.WorkspaceID, job.Name)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil && current.Active(now) {
+		return nil, nil
+	}
+
+	timeout, err := job.GetTimeoutDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	lease := &RunLease{
+		Generation: 1,
+		Holder:     lm.holder,
+		StartedAt:  now,
+		ExpiresAt:  now.Add(job.startWindow() + timeout + leaseGracePeriod),
+	}
+	if current != nil {
+		lease.Generation = current.Generation + 1
+	}
+
+	dir := lm.jobDir(job.WorkspaceID, job.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lease directory: %w", err)
+	}
+
+	// Write the complete lease first so other hosts never read a partial one
+	tempPath, err := lm.writeTemp(dir, lease)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = os.Remove(tempPath) }()
+
+	if err := os.Link(tempPath, lm.generationPath(job.WorkspaceID, job.Name, lease.Generation)); err != nil {
+		if errors.Is(err, os.ErrExist) {
+			return nil, nil // Another host claimed this generation
+		}
+		return nil, fmt.Errorf("failed to claim lease: %w", err)
+	}
+
+	lm.prune(job.WorkspaceID, job.Name, lease.Generation)
+	return lease, nil
+}
+
+// Release marks a lease's run as finished so other hosts count it as the job's last run
+func (lm *LeaseManager) Release(job *Job, lease *RunLease, now time.Time) error {
+	lease.FinishedAt = &now
+
+	dir := lm.jobDir(job.WorkspaceID, job.Name)
+	tempPath, err := lm.writeTemp(dir, lease)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, lm.generationPath(job.WorkspaceID, job.Name, lease.Generation)); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to release lease: %w", err)
+	}
+	return nil
+}
+
+// writeTemp writes a lease to a file unique to this process
+func (lm *LeaseManager) writeTemp(dir string, lease *RunLease) (string, error) {
+	data, err := json.MarshalIndent(lease, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal lease: %w", err)
+	}
+
+	tempPath := filepath.Join(dir, fmt.Sprintf(".%d.%s.tmp", lease.Generation, strings.ReplaceAll(lm.holder, string(filepath.Separator), "_")))
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write lease: %w", err)
+	}
+	return tempPath, nil
+}
+
+// prune removes lease generations older than the previous one
+func (lm *LeaseManager) prune(workspaceID, jobName string, latest int) {
+	generations, err := lm.generations(workspaceID, jobName)
+	if err != nil {
+		return
+	}
+	for _, generation := range generations {
+		if generation < latest-1 {
+			_ = os.Remove(lm.generationPath(workspaceID, jobName, generation))
+		}
+	}
+}
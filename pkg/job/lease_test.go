@@ -0,0 +1,148 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newLeaseTestJob() *Job {
+	return &Job{
+		Name:        "nightly-report",
+		WorkspaceID: "_standalone_",
+		JobType:     JobTypeCommand,
+		Command:     "true",
+		Schedule:    "0 2 * * *",
+		Timeout:     "10m",
+		Enabled:     true,
+	}
+}
+
+func TestLeaseAcquireRelease(t *testing.T) {
+	leaseDir := t.TempDir()
+	primary := NewLeaseManager(leaseDir)
+	standby := NewLeaseManager(leaseDir)
+	standby.holder = "standby:1"
+
+	job := newLeaseTestJob()
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+
+	lease, err := primary.Acquire(job, now)
+	if err != nil || lease == nil {
+		t.Fatalf("Expected primary to claim the run, got %v (err %v)", lease, err)
+	}
+	if lease.Generation != 1 || !lease.ExpiresAt.Equal(now.Add(10*time.Minute+leaseGracePeriod)) {
+		t.Errorf("Unexpected lease: %+v", lease)
+	}
+
+	// The standby sees the run in progress and does not start its own
+	if other, err := standby.Acquire(job, now.Add(time.Second)); err != nil || other != nil {
+		t.Fatalf("Expected standby to skip the claimed run, got %v (err %v)", other, err)
+	}
+
+	finished := now.Add(3 * time.Minute)
+	if err := primary.Release(job, lease, finished); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	current, err := standby.Current(job.WorkspaceID, job.Name)
+	if err != nil || current == nil || current.Active(finished) || !current.LastRun().Equal(finished) {
+		t.Fatalf("Expected released lease with last run %s, got %+v (err %v)", finished, current, err)
+	}
+
+	// The next run may go to either host
+	next, err := standby.Acquire(job, now.Add(24*time.Hour))
+	if err != nil || next == nil || next.Generation != 2 || next.Holder != "standby:1" {
+		t.Fatalf("Expected standby to claim generation 2, got %+v (err %v)", next, err)
+	}
+}
+
+func TestLeaseExpiredHolderIsTakenOver(t *testing.T) {
+	leaseDir := t.TempDir()
+	crashed := NewLeaseManager(leaseDir)
+	standby := NewLeaseManager(leaseDir)
+
+	job := newLeaseTestJob()
+	now := time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)
+
+	if lease, err := crashed.Acquire(job, now); err != nil || lease == nil {
+		t.Fatalf("Failed to acquire lease: %v", err)
+	}
+
+	// Never released, e.g. the host crashed during the run
+	if lease, err := standby.Acquire(job, now.Add(10*time.Minute)); err != nil || lease != nil {
+		t.Fatalf("Expected lease to still be held before expiry, got %v (err %v)", lease, err)
+	}
+	lease, err := standby.Acquire(job, now.Add(time.Hour))
+	if err != nil || lease == nil || lease.Generation != 2 {
+		t.Fatalf("Expected expired lease to be taken over, got %+v (err %v)", lease, err)
+	}
+
+	// Old generations are pruned, keeping the previous one
+	if _, err := standby.Acquire(job, now.Add(3*time.Hour)); err != nil {
+		t.Fatalf("Failed to acquire lease: %v", err)
+	}
+	if _, err := os.Stat(standby.generationPath(job.WorkspaceID, job.Name, 1)); !os.IsNotExist(err) {
+		t.Errorf("Expected generation 1 to be pruned, got %v", err)
+	}
+}
+
+func TestSharedStandaloneJobRunsOnce(t *testing.T) {
+	sharedStateDir := t.TempDir()
+	jobConfig := map[string]interface{}{
+		"name":     "nightly-report",
+		"type":     "command",
+		"command":  "true",
+		"schedule": "0 2 * * *",
+		"timeout":  "1m",
+		"enabled":  true,
+	}
+
+	var mu sync.Mutex
+	executions := 0
+	var wg sync.WaitGroup
+
+	// Two hosts: separate job state, shared lease directory
+	var managers []*Manager
+	for i := 0; i < 2; i++ {
+		hostStateDir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(hostStateDir, "deployments", "_standalone_"), 0755); err != nil {
+			t.Fatalf("Failed to create deployment dir: %v", err)
+		}
+		manager := NewManager(hostStateDir, nil, nil)
+		if err := manager.LoadState(); err != nil {
+			t.Fatalf("Failed to load state: %v", err)
+		}
+		manager.SetJobFinishedHandler(func(*JobExecution) {
+			mu.Lock()
+			executions++
+			mu.Unlock()
+			wg.Done()
+		})
+		managers = append(managers, manager)
+	}
+
+	leaseDir := filepath.Join(sharedStateDir, "leases")
+	now := time.Now()
+	wg.Add(1)
+	for _, manager := range managers {
+		manager.processJobs("_standalone_", []interface{}{jobConfig}, now, NewLeaseManager(leaseDir))
+	}
+	wg.Wait()
+
+	// Give a wrongly started second run time to report
+	time.Sleep(200 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if executions != 1 {
+		t.Errorf("Expected exactly one host to run the job, got %d executions", executions)
+	}
+
+	// The host that skipped counts the other host's run as the job's last run
+	for i, manager := range managers {
+		if state := manager.GetJobState("_standalone_", "nightly-report"); state.LastRun == nil {
+			t.Errorf("Expected host %d to record the job's last run", i)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package job
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"provisioner/pkg/bytesize"
+	"provisioner/pkg/logging"
+)
+
+// cgroupRoot is where the cgroup v2 hierarchy is mounted
+var cgroupRoot = "/sys/fs/cgroup"
+
+// procSelfCgroup lists the cgroups of the daemon
+var procSelfCgroup = "/proc/self/cgroup"
+
+// daemonCgroup is the leaf below its own cgroup the daemon moves into, so the cgroups of
+// runs with limits can be created next to it
+const daemonCgroup = "daemon"
+
+// jobCgroupParentMutex guards setting up the parent of the cgroups of runs
+var jobCgroupParentMutex sync.Mutex
+
+// cpuPeriod is the cgroup cpu.max period in microseconds
+const cpuPeriod = 100000
+
+// ResourceLimits caps the resources of a job's processes
+type ResourceLimits struct {
+	CPU    float64 // CPU cores, 0 for no limit
+	Memory int64   // Bytes, 0 for no limit
+	Nice   int     // Scheduling priority from -20 (highest) to 19 (lowest), 0 to keep the daemon's
+}
+
+// IsZero returns true if no limit is set
+func (l ResourceLimits) IsZero() bool {
+	return l == ResourceLimits{}
+}
+
+// GetResourceLimits returns the limits of the job's max_cpu, max_memory and nice fields
+func (j *Job) GetResourceLimits() (ResourceLimits, error) {
+	limits := ResourceLimits{CPU: j.MaxCPU, Nice: j.Nice}
+	if j.MaxCPU < 0 {
+		return limits, fmt.Errorf("max_cpu must not be negative, got %g", j.MaxCPU)
+	}
+	if j.Nice < -20 || j.Nice > 19 {
+		return limits, fmt.Errorf("nice must be between -20 and 19, got %d", j.Nice)
+	}
+	if j.MaxMemory != "" {
+		memory, err := bytesize.Parse(j.MaxMemory)
+		if err != nil {
+			return limits, fmt.Errorf("invalid max_memory '%s': %w", j.MaxMemory, err)
+		}
+		limits.Memory = memory
+	}
+	return limits, nil
+}
+
+// validateResourceLimits checks the max_cpu, max_memory and nice fields of a job
+func (j *Job) validateResourceLimits() error {
+	limits, err := j.GetResourceLimits()
+	if err != nil {
+		return err
+	}
+	if !limits.IsZero() && j.JobType == JobTypeTemplate {
+		return fmt.Errorf("max_cpu, max_memory and nice are only supported for script and command jobs")
+	}
+	return nil
+}
+
+// jobLimiter enforces the resource limits of one run of a job. With cgroups v2 the process
+// starts in a cgroup of its own capping CPU and memory; otherwise its address space is
+// limited with setrlimit and CPU is not limited. Niceness is set once the process started.
+type jobLimiter struct {
+	job       *Job
+	limits    ResourceLimits
+	cgroupDir string
+	cgroupFD  *os.File
+}
+
+// prepareLimits sets up the limits of a job's command before it starts, nil if it has none
+func (e *Executor) prepareLimits(cmd *exec.Cmd, job *Job) *jobLimiter {
+	limits, err := job.GetResourceLimits()
+	if err != nil || limits.IsZero() {
+		return nil
+	}
+
+	limiter := &jobLimiter{job: job, limits: limits}
+	if limits.CPU == 0 && limits.Memory == 0 {
+		return limiter
+	}
+
+	if !cgroupV2Available() {
+		if limits.CPU > 0 {
+			logging.LogWorkspace(job.WorkspaceID, "JOB %s: max_cpu needs cgroups v2 and is not enforced", job.Name)
+		}
+		return limiter
+	}
+
+	cgroupDir, err := createJobCgroup(job, limits)
+	if err != nil {
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed to create cgroup, falling back to setrlimit: %v", job.Name, err)
+		return limiter
+	}
+	fd, err := os.Open(cgroupDir)
+	if err != nil {
+		_ = os.Remove(cgroupDir)
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed to open cgroup, falling back to setrlimit: %v", job.Name, err)
+		return limiter
+	}
+
+	limiter.cgroupDir = cgroupDir
+	limiter.cgroupFD = fd
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(fd.Fd())
+	return limiter
+}
+
+// started applies the limits that are set on the running process
+func (l *jobLimiter) started(pid int) {
+	if l == nil {
+		return
+	}
+
+	if l.limits.Nice != 0 {
+		if err := syscall.Setpriority(syscall.PRIO_PROCESS, pid, l.limits.Nice); err != nil {
+			logging.LogWorkspace(l.job.WorkspaceID, "JOB %s: Failed to set nice %d: %v", l.job.Name, l.limits.Nice, err)
+		}
+	}
+
+	if l.limits.Memory > 0 && l.cgroupDir == "" {
+		if err := setAddressSpaceLimit(pid, l.limits.Memory); err != nil {
+			logging.LogWorkspace(l.job.WorkspaceID, "JOB %s: Failed to limit memory: %v", l.job.Name, err)
+		}
+	}
+}
+
+// release removes the run's cgroup once its processes have exited
+func (l *jobLimiter) release() {
+	if l == nil || l.cgroupFD == nil {
+		return
+	}
+	_ = l.cgroupFD.Close()
+	if err := os.Remove(l.cgroupDir); err != nil {
+		logging.LogWorkspace(l.job.WorkspaceID, "JOB %s: Failed to remove cgroup %s: %v", l.job.Name, l.cgroupDir, err)
+	}
+}
+
+// cgroupV2Available returns true if the unified cgroup hierarchy is mounted
+func cgroupV2Available() bool {
+	_, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.controllers"))
+	return err == nil
+}
+
+// jobCgroupParent returns the daemon's own cgroup, which holds the cgroups of runs with limits.
+// cgroups v2 only enables controllers for the children of a cgroup without processes of its
+// own, so the daemon first moves into a leaf, which with systemd needs Delegate=yes.
+func jobCgroupParent() (string, error) {
+	jobCgroupParentMutex.Lock()
+	defer jobCgroupParentMutex.Unlock()
+
+	content, err := os.ReadFile(procSelfCgroup)
+	if err != nil {
+		return "", err
+	}
+	var own string
+	for _, line := range strings.Split(string(content), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			own = path
+		}
+	}
+	if own == "" {
+		return "", fmt.Errorf("daemon is not in a cgroup v2 hierarchy")
+	}
+
+	// Run cgroups are created next to the daemon's leaf
+	if filepath.Base(own) == daemonCgroup {
+		own = filepath.Dir(own)
+	}
+	parent := filepath.Join(cgroupRoot, own)
+
+	controllers, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return "", err
+	}
+	enabled := strings.Fields(string(controllers))
+	if slices.Contains(enabled, "cpu") && slices.Contains(enabled, "memory") {
+		return parent, nil
+	}
+
+	// The root cgroup may have processes and enable controllers at once
+	if own != "/" {
+		leaf := filepath.Join(parent, daemonCgroup)
+		if err := os.Mkdir(leaf, 0755); err != nil && !os.IsExist(err) {
+			return "", err
+		}
+		if err := os.WriteFile(filepath.Join(leaf, "cgroup.procs"), []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+			return "", fmt.Errorf("failed to move the daemon into %s: %w", leaf, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte("+cpu +memory"), 0644); err != nil {
+		return "", fmt.Errorf("failed to enable the cpu and memory controllers: %w", err)
+	}
+	return parent, nil
+}
+
+// createJobCgroup creates the cgroup of a run next to the daemon's with its CPU and memory caps
+func createJobCgroup(job *Job, limits ResourceLimits) (string, error) {
+	parent, err := jobCgroupParent()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(parent, fmt.Sprintf("job-%s.%s.%d", job.WorkspaceID, job.Name, time.Now().UnixNano()))
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return "", err
+	}
+
+	if limits.CPU > 0 {
+		quota := int64(limits.CPU * cpuPeriod)
+		if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(fmt.Sprintf("%d %d", quota, cpuPeriod)), 0644); err != nil {
+			_ = os.Remove(dir)
+			return "", fmt.Errorf("failed to set cpu.max: %w", err)
+		}
+	}
+	if limits.Memory > 0 {
+		if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatInt(limits.Memory, 10)), 0644); err != nil {
+			_ = os.Remove(dir)
+			return "", fmt.Errorf("failed to set memory.max: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// setAddressSpaceLimit limits the virtual memory of a running process with prlimit
+func setAddressSpaceLimit(pid int, bytes int64) error {
+	limit := syscall.Rlimit{Cur: uint64(bytes), Max: uint64(bytes)}
+	_, _, errno := syscall.RawSyscall6(syscall.SYS_PRLIMIT64, uintptr(pid), uintptr(syscall.RLIMIT_AS),
+		uintptr(unsafe.Pointer(&limit)), 0, 0, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
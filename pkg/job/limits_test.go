@@ -0,0 +1,114 @@
+package job
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestJobConfigToJobResourceLimits(t *testing.T) {
+	job, err := JobConfigToJob("test-workspace", map[string]interface{}{
+		"name":       "backup",
+		"type":       "script",
+		"script":     "echo backup",
+		"max_cpu":    0.5,
+		"max_memory": "512M",
+		"nice":       float64(10),
+	})
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+
+	limits, err := job.GetResourceLimits()
+	if err != nil {
+		t.Fatalf("GetResourceLimits failed: %v", err)
+	}
+	if limits.CPU != 0.5 || limits.Memory != 512<<20 || limits.Nice != 10 {
+		t.Errorf("Unexpected limits %+v", limits)
+	}
+}
+
+func TestResourceLimitsValidation(t *testing.T) {
+	for _, config := range []map[string]interface{}{
+		{"name": "backup", "type": "script", "script": "true", "max_cpu": -1},
+		{"name": "backup", "type": "script", "script": "true", "max_memory": "lots"},
+		{"name": "backup", "type": "script", "script": "true", "nice": 20},
+		{"name": "backup", "type": "script", "script": "true", "nice": -21},
+		{"name": "preview-env", "type": "template", "template": "preview", "max_memory": "1G"},
+	} {
+		if _, err := JobConfigToJob("test-workspace", config); err == nil {
+			t.Errorf("Expected error for %v", config)
+		}
+	}
+}
+
+func TestExecuteJobLimitsWithoutCgroups(t *testing.T) {
+	previousRoot := cgroupRoot
+	cgroupRoot = t.TempDir()
+	defer func() { cgroupRoot = previousRoot }()
+
+	manager := newTestManager(t, nil)
+	job, err := JobConfigToJob("test-workspace", map[string]interface{}{
+		"name":       "backup",
+		"type":       "script",
+		"script":     "sleep 0.2\nawk '{print $19}' /proc/$$/stat\nulimit -v",
+		"max_cpu":    1,
+		"max_memory": "1G",
+		"nice":       5,
+	})
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+
+	execution := manager.ExecuteJob(job)
+	if execution.Status != JobStatusSuccess {
+		t.Fatalf("Expected the job to succeed, got %s: %s", execution.Status, execution.Error)
+	}
+	if lines := strings.Fields(execution.Output); len(lines) != 2 || lines[0] != "5" || lines[1] != "1048576" {
+		t.Errorf("Expected nice 5 and a 1048576 KiB address space limit, got %q", execution.Output)
+	}
+}
+
+func TestCreateJobCgroup(t *testing.T) {
+	previousRoot, previousSelf := cgroupRoot, procSelfCgroup
+	cgroupRoot = t.TempDir()
+	procSelfCgroup = filepath.Join(t.TempDir(), "cgroup")
+	defer func() { cgroupRoot, procSelfCgroup = previousRoot, previousSelf }()
+
+	service := filepath.Join(cgroupRoot, "system.slice", "provisioner.service")
+	if err := os.MkdirAll(service, 0755); err != nil {
+		t.Fatalf("Failed to create cgroup: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(service, "cgroup.subtree_control"), nil, 0644); err != nil {
+		t.Fatalf("Failed to write subtree_control: %v", err)
+	}
+	if err := os.WriteFile(procSelfCgroup, []byte("0::/system.slice/provisioner.service\n"), 0644); err != nil {
+		t.Fatalf("Failed to write cgroup: %v", err)
+	}
+
+	job := &Job{Name: "backup", WorkspaceID: "test-workspace"}
+	dir, err := createJobCgroup(job, ResourceLimits{CPU: 0.5, Memory: 512 << 20})
+	if err != nil {
+		t.Fatalf("createJobCgroup failed: %v", err)
+	}
+	if filepath.Dir(dir) != service {
+		t.Errorf("Expected the cgroup next to the daemon's, got %s", dir)
+	}
+
+	for file, expected := range map[string]string{
+		filepath.Join(service, daemonCgroup, "cgroup.procs"): strconv.Itoa(os.Getpid()),
+		filepath.Join(service, "cgroup.subtree_control"):     "+cpu +memory",
+		filepath.Join(dir, "cpu.max"):                        "50000 100000",
+		filepath.Join(dir, "memory.max"):                     "536870912",
+	} {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if string(content) != expected {
+			t.Errorf("Expected %q in %s, got %q", expected, filepath.Base(file), content)
+		}
+	}
+}
@@ -2,10 +2,13 @@ package job
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
-	"strings"
+	"sync"
 	"time"
 
+	"provisioner/pkg/audit"
+	"provisioner/pkg/cron"
 	"provisioner/pkg/logging"
 	"provisioner/pkg/opentofu"
 	"provisioner/pkg/template"
@@ -17,6 +20,11 @@ type Manager struct {
 	templateManager *template.Manager
 	tofuClient      opentofu.TofuClient
 	stateDir        string
+	onJobFinished   func(*JobExecution)
+	throttle        func(*Job) func()
+
+	delayedMu sync.Mutex
+	delayed   map[string]bool // Jobs waiting out their start delay, keyed by workspace/job
 }
 
 // NewManager creates a new job manager
@@ -32,6 +40,17 @@ func NewManager(stateDir string, tofuClient opentofu.TofuClient, templateManager
 	}
 }
 
+// SetJobFinishedHandler registers a callback invoked after every job execution
+func (m *Manager) SetJobFinishedHandler(handler func(*JobExecution)) {
+	m.onJobFinished = handler
+}
+
+// SetThrottleHandler registers a callback that blocks until a job may run under its throttle
+// buckets and returns a function releasing them
+func (m *Manager) SetThrottleHandler(handler func(*Job) func()) {
+	m.throttle = handler
+}
+
 // LoadState loads job states from disk
 func (m *Manager) LoadState() error {
 	return m.stateManager.LoadState()
@@ -56,21 +75,89 @@ func (m *Manager) ExecuteJob(job *Job) *JobExecution {
 		logging.LogWorkspace(job.WorkspaceID, "Failed to save job state: %v", err)
 	}
 
-	// Execute the job
-	execution := executor.ExecuteJob(job)
+	// Execute the job, retrying failed attempts
+	execution := m.executeWithRetries(job, executor)
+	m.collectArtifacts(job, executor, execution)
 
 	// Update state with execution results
 	m.stateManager.UpdateJobExecution(execution)
+	if job.JobType == JobTypeTemplate {
+		m.stateManager.updateTemplateDeployment(job, execution)
+	}
+	m.pruneArtifacts(job, time.Now())
 	if err := m.stateManager.SaveState(); err != nil {
 		logging.LogWorkspace(job.WorkspaceID, "Failed to save job state after execution: %v", err)
 	}
 
+	m.auditRun(job, execution)
+
+	if m.onJobFinished != nil {
+		m.onJobFinished(execution)
+	}
+
 	return execution
 }
 
+// auditRun records a finished job run in the audit log
+func (m *Manager) auditRun(job *Job, execution *JobExecution) {
+	trigger := job.Trigger
+	if trigger.Source == "" {
+		trigger = audit.Trigger{Source: audit.SourceSchedule}
+	}
+
+	outcome := audit.OutcomeFailed
+	if execution.Status == JobStatusSuccess {
+		outcome = audit.OutcomeSucceeded
+	}
+
+	audit.NewLog(m.stateDir).Record(audit.Entry{
+		Operation:     audit.OperationJobRun,
+		Workspace:     auditWorkspace(job.WorkspaceID),
+		Job:           job.Name,
+		Trigger:       trigger,
+		Outcome:       outcome,
+		Error:         execution.Error,
+		CorrelationID: execution.CorrelationID,
+	})
+}
+
+// auditWorkspace returns the workspace of a job in the audit log, empty for standalone jobs
+func auditWorkspace(workspaceID string) string {
+	const standaloneWorkspaceID = "_standalone_"
+	if workspaceID == standaloneWorkspaceID {
+		return ""
+	}
+	return workspaceID
+}
+
+// manualTrigger returns what started a manual operation on a job: the caller of the daemon if
+// it set a trigger, otherwise the user running this CLI
+func manualTrigger(workspaceID, jobName string) audit.Trigger {
+	if trigger, ok := audit.CurrentTrigger(audit.JobKey(auditWorkspace(workspaceID), jobName)); ok {
+		return trigger
+	}
+	return audit.CLITrigger()
+}
+
 // ExecuteJobAsync executes a job asynchronously
 func (m *Manager) ExecuteJobAsync(job *Job) {
+	m.executeJobAsync(job, 0, nil)
+}
+
+// executeJobAsync executes a job in the background after delay and calls done, if set, when it finishes
+func (m *Manager) executeJobAsync(job *Job, delay time.Duration, done func()) {
+	if delay > 0 {
+		m.setDelayed(job, true)
+	}
+
 	go func() {
+		if done != nil {
+			defer done()
+		}
+		if delay > 0 {
+			defer m.setDelayed(job, false)
+			time.Sleep(delay)
+		}
 		execution := m.ExecuteJob(job)
 		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Async execution completed with status %s",
 			job.Name, execution.Status)
@@ -96,8 +183,8 @@ func (m *Manager) ShouldRunJob(job *Job, now time.Time) bool {
 		return false
 	}
 
-	// Don't run if already running
-	if jobState.Status == JobStatusRunning {
+	// Don't run if already running or waiting to start
+	if jobState.Status == JobStatusRunning || m.isDelayed(job) {
 		return false
 	}
 
@@ -117,12 +204,8 @@ func (m *Manager) ShouldRunJob(job *Job, now time.Time) bool {
 		return false // No schedule defined
 	}
 
-	// Use the same schedule checking logic as workspace scheduling
-	// This is a simplified check - you would integrate with the existing CRON parsing
 	for _, scheduleStr := range schedules {
-		// For now, this is a placeholder - you would use the existing ParseCron function
-		// and getLastScheduledTimeToday logic from the scheduler package
-		if m.shouldRunForSchedule(scheduleStr, now, jobState) {
+		if m.shouldRunForSchedule(job, scheduleStr, now, jobState) {
 			return true
 		}
 	}
@@ -130,25 +213,32 @@ func (m *Manager) ShouldRunJob(job *Job, now time.Time) bool {
 	return false
 }
 
-// shouldRunForSchedule checks if a job should run for a specific schedule
-func (m *Manager) shouldRunForSchedule(scheduleStr string, now time.Time, jobState *JobState) bool {
-	// Skip special schedules in time-based processing
-	if strings.HasPrefix(scheduleStr, "@") {
-		return false // Special schedules are event-based, not time-based
+// shouldRunForSchedule checks if a schedule fired today and the job has not run since, like the
+// deploy schedules of workspaces. Event schedules (@deployment, ...) never fire on time.
+func (m *Manager) shouldRunForSchedule(job *Job, scheduleStr string, now time.Time, jobState *JobState) bool {
+	schedule, err := cron.Parse(scheduleStr)
+	if err != nil {
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed to parse schedule '%s': %v", job.Name, scheduleStr, err)
+		return false
 	}
 
-	// For CRON schedules, we need a simpler check here since we can't import scheduler
-	// This is a basic time-based check - in practice, you would use proper CRON parsing
-	if jobState.LastRun == nil {
-		return true // Never run before
+	// Find the most recent time this schedule should have run today
+	lastScheduledTime := schedule.LastRunToday(now)
+	if lastScheduledTime == nil || !now.After(*lastScheduledTime) {
+		return false
 	}
 
-	// Run if last run was more than 1 hour ago (simplified CRON check)
-	return now.Sub(*jobState.LastRun) > time.Hour
+	return jobState.LastRun == nil || jobState.LastRun.Before(*lastScheduledTime)
 }
 
 // ProcessWorkspaceJobs processes all jobs for a workspace configuration
 func (m *Manager) ProcessWorkspaceJobs(workspaceID string, jobConfigs []interface{}, now time.Time) {
+	m.processJobs(workspaceID, jobConfigs, now, nil)
+}
+
+// processJobs starts the jobs that are due. With leases, each run is claimed first so
+// provisioners sharing the state directory never execute the same run twice.
+func (m *Manager) processJobs(workspaceID string, jobConfigs []interface{}, now time.Time, leases *LeaseManager) {
 	// Convert job configs to job objects
 	activeJobs := make([]string, 0, len(jobConfigs))
 	jobs := make([]*Job, 0, len(jobConfigs))
@@ -169,43 +259,188 @@ func (m *Manager) ProcessWorkspaceJobs(workspaceID string, jobConfigs []interfac
 
 	// Check each job to see if it should run
 	for _, job := range jobs {
-		if m.ShouldRunJob(job, now) {
-			logging.LogWorkspace(workspaceID, "JOB %s: Triggering execution", job.Name)
-			m.ExecuteJobAsync(job)
+		if m.shouldDestroyTemplate(job, now) {
+			m.destroyTemplateDeploymentAsync(job, audit.Trigger{Source: audit.SourceSchedule}, "lifecycle")
+			continue
+		}
+
+		if leases == nil {
+			if m.ShouldRunJob(job, now) {
+				m.triggerScheduledRun(job, now, "", nil)
+			}
+			continue
+		}
+
+		m.syncLastRunFromLease(leases, job)
+		if !m.ShouldRunJob(job, now) {
+			continue
+		}
+
+		lease, err := leases.Acquire(job, now)
+		if err != nil {
+			logging.LogWorkspace(workspaceID, "JOB %s: Failed to claim run lease: %v", job.Name, err)
+			continue
 		}
+		if lease == nil {
+			logging.LogWorkspace(workspaceID, "JOB %s: Run claimed by another host, skipping", job.Name)
+			continue
+		}
+
+		m.triggerScheduledRun(job, now, fmt.Sprintf(" (lease %d)", lease.Generation), func() {
+			if err := leases.Release(job, lease, time.Now()); err != nil {
+				logging.LogWorkspace(workspaceID, "JOB %s: %v", job.Name, err)
+			}
+		})
+	}
+}
+
+// triggerScheduledRun starts a due job, delayed by its jitter or name-based spread
+func (m *Manager) triggerScheduledRun(job *Job, now time.Time, detail string, done func()) {
+	delay := job.StartDelay()
+	if delay <= 0 {
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Triggering execution%s", job.Name, detail)
+		m.executeJobAsync(job, 0, done)
+		return
+	}
+
+	start := now.Add(delay)
+	m.stateManager.SetJobNextRun(job.WorkspaceID, job.Name, &start)
+	logging.LogWorkspace(job.WorkspaceID, "JOB %s: Triggering execution%s in %s at %s", job.Name, detail,
+		delay.Round(time.Second), logging.FormatTime(start))
+	m.executeJobAsync(job, delay, done)
+}
+
+// setDelayed marks whether a job is waiting out its start delay
+func (m *Manager) setDelayed(job *Job, delayed bool) {
+	m.delayedMu.Lock()
+	defer m.delayedMu.Unlock()
+	if m.delayed == nil {
+		m.delayed = make(map[string]bool)
+	}
+	key := job.WorkspaceID + "/" + job.Name
+	if delayed {
+		m.delayed[key] = true
+	} else {
+		delete(m.delayed, key)
+	}
+}
+
+// isDelayed returns true if a job was triggered and is waiting out its start delay
+func (m *Manager) isDelayed(job *Job) bool {
+	m.delayedMu.Lock()
+	defer m.delayedMu.Unlock()
+	return m.delayed[job.WorkspaceID+"/"+job.Name]
+}
+
+// syncLastRunFromLease counts a run executed by another host as the job's last run
+func (m *Manager) syncLastRunFromLease(leases *LeaseManager, job *Job) {
+	lease, err := leases.Current(job.WorkspaceID, job.Name)
+	if err != nil {
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed to read run lease: %v", job.Name, err)
+		return
+	}
+	if lease == nil {
+		return
+	}
+
+	jobState := m.stateManager.GetJobState(job.WorkspaceID, job.Name)
+	if jobState.LastRun == nil || lease.LastRun().After(*jobState.LastRun) {
+		m.stateManager.SetJobLastRun(job.WorkspaceID, job.Name, lease.LastRun())
 	}
 }
 
 // ManualExecuteJob executes a job immediately, bypassing schedule checks
 func (m *Manager) ManualExecuteJob(workspaceID, jobName string, jobConfig interface{}) error {
+	_, err := m.manualExecute(workspaceID, jobName, "", jobConfig)
+	return err
+}
+
+// ManualExecuteJobRun executes a job like ManualExecuteJob, recording progress under an existing run record
+func (m *Manager) ManualExecuteJobRun(workspaceID, jobName, runID string, jobConfig interface{}) error {
+	run, err := m.GetRun(workspaceID, jobName, runID)
+	if err != nil {
+		return err
+	}
+
+	run.Status = JobStatusRunning
+	run.PID = os.Getpid()
+	if err := m.SaveRun(run); err != nil {
+		logging.LogWorkspace(workspaceID, "JOB %s: Failed to save run %s: %v", jobName, runID, err)
+	}
+
+	execution, execErr := m.manualExecute(workspaceID, jobName, runID, jobConfig)
+
+	now := time.Now()
+	run.EndTime = &now
+	if execution != nil {
+		run.Status = execution.Status
+		run.ExitCode = execution.ExitCode
+		run.Error = execution.Error
+	} else {
+		// Job never started (invalid configuration or already running)
+		run.Status = JobStatusFailed
+		run.Error = execErr.Error()
+	}
+
+	if err := m.SaveRun(run); err != nil {
+		logging.LogWorkspace(workspaceID, "JOB %s: Failed to save run %s: %v", jobName, runID, err)
+	}
+
+	return execErr
+}
+
+// manualExecute runs a job synchronously, returning the execution if the job was started. The run
+// is kept in the job's history under runID, or a new ID if empty.
+func (m *Manager) manualExecute(workspaceID, jobName, runID string, jobConfig interface{}) (*JobExecution, error) {
 	job, err := JobConfigToJob(workspaceID, jobConfig)
 	if err != nil {
-		return fmt.Errorf("invalid job configuration: %w", err)
+		return nil, fmt.Errorf("invalid job configuration: %w", err)
 	}
 
 	if job.Name != jobName {
-		return fmt.Errorf("job name mismatch: expected %s, got %s", jobName, job.Name)
+		return nil, fmt.Errorf("job name mismatch: expected %s, got %s", jobName, job.Name)
 	}
 
 	jobState := m.stateManager.GetJobState(workspaceID, jobName)
 	if jobState.Status == JobStatusRunning {
-		return fmt.Errorf("job '%s' is already running", jobName)
+		return nil, fmt.Errorf("job '%s' is already running", jobName)
 	}
 
 	logging.LogWorkspace(workspaceID, "JOB %s: Manual execution requested", jobName)
+	job.Trigger = manualTrigger(workspaceID, jobName)
+	job.RunID = runID
 
 	// Execute synchronously for immediate feedback
 	execution := m.ExecuteJob(job)
 
 	if execution.Status == JobStatusSuccess {
-		return nil
+		return execution, nil
 	} else {
-		return fmt.Errorf("job execution failed: %s", execution.Error)
+		return execution, fmt.Errorf("job execution failed: %s", execution.Error)
 	}
 }
 
 // KillJob attempts to kill a running job
 func (m *Manager) KillJob(workspaceID, jobName string) error {
+	err := m.killJob(workspaceID, jobName)
+
+	entry := audit.Entry{
+		Operation: audit.OperationJobKill,
+		Workspace: auditWorkspace(workspaceID),
+		Job:       jobName,
+		Trigger:   manualTrigger(workspaceID, jobName),
+		Outcome:   audit.OutcomeSucceeded,
+	}
+	if err != nil {
+		entry.Outcome = audit.OutcomeFailed
+		entry.Error = err.Error()
+	}
+	audit.NewLog(m.stateDir).Record(entry)
+
+	return err
+}
+
+func (m *Manager) killJob(workspaceID, jobName string) error {
 	jobState := m.stateManager.GetJobState(workspaceID, jobName)
 	if jobState.Status != JobStatusRunning {
 		return fmt.Errorf("job '%s' is not running", jobName)
@@ -249,7 +484,11 @@ func (m *Manager) updateResolverWithCurrentStates(workspaceID string, resolver *
 		case JobStatusSuccess:
 			resolver.SetJobCompleted(jobName)
 		case JobStatusFailed, JobStatusTimeout:
-			resolver.SetJobFailed(jobName)
+			if job := resolver.jobsByName[jobName]; job != nil && job.GetOnFailure() == OnFailureContinue {
+				resolver.SetJobCompleted(jobName)
+			} else {
+				resolver.SetJobFailed(jobName)
+			}
 		}
 	}
 }
@@ -264,6 +503,9 @@ func (m *Manager) ExecuteJobWithDependencyTracking(job *Job, resolver *Dependenc
 		if execution.Status == JobStatusSuccess {
 			resolver.SetJobCompleted(job.Name)
 			logging.LogWorkspace(job.WorkspaceID, "JOB %s: Completed successfully, checking dependent jobs", job.Name)
+		} else if job.GetOnFailure() == OnFailureContinue {
+			resolver.SetJobCompleted(job.Name)
+			logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed, running dependent jobs anyway (on_failure: continue)", job.Name)
 		} else {
 			resolver.SetJobFailed(job.Name)
 			logging.LogWorkspace(job.WorkspaceID, "JOB %s: Failed, dependent jobs will not run", job.Name)
@@ -340,8 +582,17 @@ func (m *Manager) ProcessWorkspaceJobsForEvent(workspaceID string, jobConfigs []
 
 		activeJobs = append(activeJobs, job.Name)
 
+		// Destroy template deployments whose destroy schedule matches the event
+		if m.shouldDestroyTemplateForEvent(job, event) {
+			job.CorrelationID = logging.CorrelationID(workspaceID)
+			m.destroyTemplateDeploymentAsync(job, audit.Trigger{Source: audit.SourceEvent, Actor: event.GetType()}, "event "+event.GetType())
+			continue
+		}
+
 		// Only include jobs that should run for this event
 		if m.ShouldRunJobForEvent(job, event) {
+			job.CorrelationID = logging.CorrelationID(workspaceID)
+			job.Trigger = audit.Trigger{Source: audit.SourceEvent, Actor: event.GetType()}
 			eventTriggeredJobs = append(eventTriggeredJobs, job)
 		}
 	}
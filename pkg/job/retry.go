@@ -0,0 +1,103 @@
+package job
+
+import (
+	"fmt"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// What happens when a job run fails after its retries
+const (
+	OnFailureContinue        = "continue"         // Dependent jobs run anyway, the failure is not notified
+	OnFailureAbortDependents = "abort-dependents" // Dependent jobs do not run, the failure is not notified
+	OnFailureNotify          = "notify"           // Dependent jobs do not run and the failure is notified
+)
+
+// DefaultRetryDelay is the wait between attempts of a job with retries but no retry_delay
+const DefaultRetryDelay = 30 * time.Second
+
+// GetRetryDelay returns the wait between attempts of a failed run
+func (j *Job) GetRetryDelay() (time.Duration, error) {
+	if j.RetryDelay == "" {
+		return DefaultRetryDelay, nil
+	}
+	delay, err := time.ParseDuration(j.RetryDelay)
+	if err != nil || delay < 0 {
+		return 0, fmt.Errorf("invalid retry_delay duration '%s'", j.RetryDelay)
+	}
+	return delay, nil
+}
+
+// GetOnFailure returns what happens when a run fails, notify unless configured
+func (j *Job) GetOnFailure() string {
+	if j.OnFailure == "" {
+		return OnFailureNotify
+	}
+	return j.OnFailure
+}
+
+// validateRetryPolicy checks the retries, retry_delay and on_failure fields of a job
+func (j *Job) validateRetryPolicy() error {
+	if j.Retries < 0 {
+		return fmt.Errorf("retries must not be negative, got %d", j.Retries)
+	}
+	if _, err := j.GetRetryDelay(); err != nil {
+		return err
+	}
+	switch j.OnFailure {
+	case "", OnFailureContinue, OnFailureAbortDependents, OnFailureNotify:
+		return nil
+	default:
+		return fmt.Errorf("invalid on_failure '%s' (must be %s, %s or %s)", j.OnFailure, OnFailureContinue, OnFailureAbortDependents, OnFailureNotify)
+	}
+}
+
+// executeWithRetries runs a job until it succeeds or has used up its retries, waiting the job's
+// retry delay between attempts. All attempts share the run ID and correlation ID of the first,
+// the run starts with the first attempt, and the throttle buckets are only held while an attempt
+// runs.
+func (m *Manager) executeWithRetries(job *Job, executor *Executor) *JobExecution {
+	delay, err := job.GetRetryDelay()
+	if err != nil {
+		delay = DefaultRetryDelay
+	}
+
+	attemptJob := *job
+	if attemptJob.RunID == "" {
+		attemptJob.RunID = NewRunID()
+	}
+	var started time.Time
+	attempts := job.Retries + 1
+	for attempt := 1; ; attempt++ {
+		execution := m.executeAttempt(&attemptJob, executor)
+		execution.RunID = attemptJob.RunID
+		execution.Attempts = attempt
+		execution.OnFailure = job.GetOnFailure()
+		if attempt == 1 {
+			started = execution.StartTime
+		} else {
+			execution.StartTime = started
+			if execution.EndTime != nil {
+				execution.Duration = execution.EndTime.Sub(started)
+			}
+		}
+
+		if execution.Status == JobStatusSuccess || attempt >= attempts {
+			return execution
+		}
+
+		attemptJob.CorrelationID = execution.CorrelationID
+		logging.LogWorkspace(job.WorkspaceID, "JOB %s: Attempt %d of %d failed, retrying in %s", job.Name, attempt, attempts, delay)
+		time.Sleep(delay)
+	}
+}
+
+// executeAttempt runs a job once, waiting for its throttle buckets first
+func (m *Manager) executeAttempt(job *Job, executor *Executor) *JobExecution {
+	if m.throttle != nil && len(job.Throttle) > 0 {
+		release := m.throttle(job)
+		defer release()
+	}
+	return executor.ExecuteJob(job)
+}
@@ -0,0 +1,125 @@
+package job
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJobConfigToJobRetryPolicy(t *testing.T) {
+	config := map[string]interface{}{
+		"name":        "flaky",
+		"type":        "command",
+		"command":     "true",
+		"retries":     float64(2), // As decoded from JSON
+		"retry_delay": "10s",
+		"on_failure":  "continue",
+	}
+	job, err := JobConfigToJob("test-workspace", config)
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+	if delay, _ := job.GetRetryDelay(); job.Retries != 2 || delay != 10*time.Second || job.GetOnFailure() != OnFailureContinue {
+		t.Errorf("Unexpected retry policy: retries %d, delay %v, on_failure %s", job.Retries, delay, job.GetOnFailure())
+	}
+
+	for field, value := range map[string]interface{}{"retries": -1, "retry_delay": "soon", "on_failure": "ignore"} {
+		invalid := map[string]interface{}{"name": "flaky", "type": "command", "command": "true", field: value}
+		if _, err := JobConfigToJob("test-workspace", invalid); err == nil {
+			t.Errorf("Expected error for %s %v", field, value)
+		}
+	}
+}
+
+func TestFailedRunIsRetried(t *testing.T) {
+	manager := newTestManager(t, nil)
+	counter := filepath.Join(t.TempDir(), "attempts")
+
+	// Fails on the first two attempts and succeeds on the third
+	job, err := JobConfigToJob("test-workspace", map[string]interface{}{
+		"name":        "flaky",
+		"type":        "script",
+		"script":      "echo x >> " + counter + "\n[ $(wc -l < " + counter + ") -ge 3 ]",
+		"retries":     3,
+		"retry_delay": "1ms",
+	})
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+
+	execution := manager.ExecuteJob(job)
+	if execution.Status != JobStatusSuccess || execution.Attempts != 3 {
+		t.Fatalf("Expected success on the third attempt, got %s after %d attempts", execution.Status, execution.Attempts)
+	}
+
+	state := manager.GetJobState("test-workspace", "flaky")
+	if state.RunCount != 1 || state.LastAttempts != 3 || state.RetryCount != 2 || state.FailureCount != 0 {
+		t.Errorf("Expected one successful run after 2 retries, got %+v", state)
+	}
+}
+
+func TestRunFailsAfterRetries(t *testing.T) {
+	manager := newTestManager(t, nil)
+
+	finished := 0
+	manager.SetJobFinishedHandler(func(*JobExecution) { finished++ })
+
+	job, err := JobConfigToJob("test-workspace", map[string]interface{}{
+		"name":        "broken",
+		"type":        "command",
+		"command":     "false",
+		"retries":     2,
+		"retry_delay": "1ms",
+		"on_failure":  "abort-dependents",
+	})
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+
+	execution := manager.ExecuteJob(job)
+	if execution.Status != JobStatusFailed || execution.Attempts != 3 || execution.OnFailure != OnFailureAbortDependents {
+		t.Fatalf("Expected failure after 3 attempts, got %s after %d attempts (on_failure %s)", execution.Status, execution.Attempts, execution.OnFailure)
+	}
+	if finished != 1 {
+		t.Errorf("Expected the finished handler to run once for the run, ran %d times", finished)
+	}
+
+	state := manager.GetJobState("test-workspace", "broken")
+	if state.RunCount != 1 || state.FailureCount != 1 || state.RetryCount != 2 {
+		t.Errorf("Expected one failed run after 2 retries, got %+v", state)
+	}
+}
+
+func TestOnFailureContinueRunsDependents(t *testing.T) {
+	for _, tt := range []struct {
+		onFailure     string
+		dependentRuns bool
+	}{
+		{OnFailureContinue, true},
+		{OnFailureAbortDependents, false},
+		{"", false},
+	} {
+		t.Run("on_failure="+tt.onFailure, func(t *testing.T) {
+			manager := newTestManager(t, nil)
+
+			finished := make(chan *JobExecution, 2)
+			manager.SetJobFinishedHandler(func(execution *JobExecution) { finished <- execution })
+
+			failing := &Job{Name: "migrate", WorkspaceID: "test-workspace", JobType: JobTypeCommand, Command: "false", Enabled: true, OnFailure: tt.onFailure}
+			dependent := &Job{Name: "smoke-test", WorkspaceID: "test-workspace", JobType: JobTypeCommand, Command: "true", Enabled: true, DependsOn: []string{"migrate"}}
+			manager.ExecuteJobWithDependencyTracking(failing, NewDependencyResolver([]*Job{failing, dependent}))
+
+			<-finished
+			select {
+			case execution := <-finished:
+				if !tt.dependentRuns {
+					t.Errorf("Expected %s not to run, it ran with status %s", execution.JobName, execution.Status)
+				}
+			case <-time.After(500 * time.Millisecond):
+				if tt.dependentRuns {
+					t.Error("Expected the dependent job to run after the failure")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package job
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"provisioner/pkg/statefile"
+)
+
+// runIDPattern matches the IDs generated by NewRunID
+var runIDPattern = regexp.MustCompile(`^\d{8}-\d{6}-[0-9a-f]{6}$`)
+
+// RunRecord tracks a single manually triggered job run so it can be inspected
+// after the CLI that started it has returned
+type RunRecord struct {
+	ID          string     `json:"id"`
+	JobName     string     `json:"job_name"`
+	WorkspaceID string     `json:"workspace_id"`
+	Status      JobStatus  `json:"status"`
+	StartTime   time.Time  `json:"start_time"`
+	EndTime     *time.Time `json:"end_time,omitempty"`
+	PID         int        `json:"pid,omitempty"`
+	ExitCode    int        `json:"exit_code"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// IsFinished returns true once the run has reached a terminal status
+func (r *RunRecord) IsFinished() bool {
+	switch r.Status {
+	case JobStatusSuccess, JobStatusFailed, JobStatusTimeout:
+		return true
+	}
+	return false
+}
+
+// IsOrphaned returns true if the run claims to be in progress but its process is gone
+func (r *RunRecord) IsOrphaned() bool {
+	if r.IsFinished() || r.PID <= 0 {
+		return false
+	}
+	return syscall.Kill(r.PID, 0) == syscall.ESRCH
+}
+
+// NewRunID generates a sortable, unique identifier for a job run
+func NewRunID() string {
+	now := time.Now()
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		// Fall back to the sub-second clock if the random source is unavailable
+		return fmt.Sprintf("%s-%06x", now.Format("20060102-150405"), now.Nanosecond()&0xffffff)
+	}
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// ValidateRunID checks that a run ID, e.g. given with --run, has the format generated by NewRunID
+func ValidateRunID(runID string) error {
+	if !runIDPattern.MatchString(runID) {
+		return fmt.Errorf("invalid run ID '%s', expected a run ID like 20060102-150405-a1b2c3", runID)
+	}
+	return nil
+}
+
+// getRunDir returns the directory holding the run records of a job
+func (m *Manager) getRunDir(workspaceID, jobName string) string {
+	return filepath.Join(m.stateDir, "runs", workspaceID, jobName)
+}
+
+// getRunPath returns the path to the record file for a run
+func (m *Manager) getRunPath(workspaceID, jobName, runID string) (string, error) {
+	if err := ValidateRunID(runID); err != nil {
+		return "", err
+	}
+	return filepath.Join(m.getRunDir(workspaceID, jobName), runID+".json"), nil
+}
+
+// lockRuns serializes writers of a job's run records, e.g. a detached run and the CLI that
+// started it, across processes
+func (m *Manager) lockRuns(workspaceID, jobName string) (func(), error) {
+	runDir := m.getRunDir(workspaceID, jobName)
+	if err := os.MkdirAll(runDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create run directory: %w", err)
+	}
+	return statefile.Lock(runDir)
+}
+
+// SaveRun persists a run record to disk
+func (m *Manager) SaveRun(run *RunRecord) error {
+	unlock, err := m.lockRuns(run.WorkspaceID, run.JobName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return m.writeRun(run)
+}
+
+// SetRunPID records the process running a run without touching the rest of its record, which
+// that process may be updating
+func (m *Manager) SetRunPID(workspaceID, jobName, runID string, pid int) error {
+	unlock, err := m.lockRuns(workspaceID, jobName)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	run, err := m.GetRun(workspaceID, jobName, runID)
+	if err != nil {
+		return err
+	}
+	if run.PID == pid {
+		return nil
+	}
+	run.PID = pid
+	return m.writeRun(run)
+}
+
+// writeRun writes a run record, the caller holds the lock of the job's runs
+func (m *Manager) writeRun(run *RunRecord) error {
+	runPath, err := m.getRunPath(run.WorkspaceID, run.JobName, run.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal run record: %w", err)
+	}
+
+	// Write via a temporary file so readers polling the record never see partial content
+	tmp, err := os.CreateTemp(filepath.Dir(runPath), filepath.Base(runPath)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write run record: %w", err)
+	}
+	tmpPath := tmp.Name()
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, runPath)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to write run record: %w", err)
+	}
+
+	return nil
+}
+
+// GetRun loads a run record from disk
+func (m *Manager) GetRun(workspaceID, jobName, runID string) (*RunRecord, error) {
+	runPath, err := m.getRunPath(workspaceID, jobName, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(runPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("run '%s' not found for job '%s'", runID, jobName)
+		}
+		return nil, fmt.Errorf("failed to read run record: %w", err)
+	}
+
+	var run RunRecord
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal run record: %w", err)
+	}
+
+	return &run, nil
+}
+
+// ListRuns returns the recorded runs of a job, newest first, at most limit of them (0 for all)
+func (m *Manager) ListRuns(workspaceID, jobName string, limit int) ([]*RunRecord, error) {
+	entries, err := os.ReadDir(m.getRunDir(workspaceID, jobName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read run directory: %w", err)
+	}
+
+	// Run IDs start with their creation time, so names sort chronologically
+	var ids []string
+	for _, entry := range entries {
+		if id, ok := strings.CutSuffix(entry.Name(), ".json"); ok && !entry.IsDir() && ValidateRunID(id) == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	if limit > 0 && len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	runs := make([]*RunRecord, 0, len(ids))
+	for _, id := range ids {
+		run, err := m.GetRun(workspaceID, jobName, id)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// CreateRun registers a new pending run for a job and returns its record
+func (m *Manager) CreateRun(workspaceID, jobName string) (*RunRecord, error) {
+	run := &RunRecord{
+		ID:          NewRunID(),
+		JobName:     jobName,
+		WorkspaceID: workspaceID,
+		Status:      JobStatusPending,
+		StartTime:   time.Now(),
+	}
+
+	if err := m.SaveRun(run); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// WaitForRun polls a run record until it finishes or the timeout expires (0 waits forever)
+func (m *Manager) WaitForRun(workspaceID, jobName, runID string, timeout, pollInterval time.Duration) (*RunRecord, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	for {
+		run, err := m.GetRun(workspaceID, jobName, runID)
+		if err != nil {
+			return nil, err
+		}
+
+		if run.IsFinished() {
+			return run, nil
+		}
+
+		if run.IsOrphaned() {
+			return run, fmt.Errorf("run '%s' process %d exited without recording a result", runID, run.PID)
+		}
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return run, fmt.Errorf("timed out waiting for run '%s' after %v", runID, timeout)
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
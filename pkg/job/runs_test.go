@@ -0,0 +1,240 @@
+package job
+
+import (
+	"os"
+	"regexp"
+	"testing"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/opentofu"
+)
+
+func TestNewRunID(t *testing.T) {
+	pattern := regexp.MustCompile(`^\d{8}-\d{6}-[0-9a-f]{6}$`)
+	seen := make(map[string]bool)
+
+	for i := 0; i < 100; i++ {
+		id := NewRunID()
+		if !pattern.MatchString(id) {
+			t.Fatalf("Run ID %q does not match expected format", id)
+		}
+		if seen[id] {
+			t.Fatalf("Duplicate run ID generated: %s", id)
+		}
+		seen[id] = true
+	}
+}
+
+func TestRunIDsAreValidated(t *testing.T) {
+	manager := newTestManager(t, &opentofu.MockTofuClient{})
+
+	for _, id := range []string{"../../../etc/passwd", "20260101-090000-aaaaaa/../x", "", "latest"} {
+		if _, err := manager.GetRun("test-workspace", "backup", id); err == nil {
+			t.Errorf("Expected run ID %q to be rejected", id)
+		}
+		if err := manager.SaveRun(&RunRecord{ID: id, JobName: "backup", WorkspaceID: "test-workspace"}); err == nil {
+			t.Errorf("Expected saving run ID %q to be rejected", id)
+		}
+	}
+}
+
+func TestSetRunPIDKeepsStatus(t *testing.T) {
+	manager := newTestManager(t, &opentofu.MockTofuClient{})
+
+	run, err := manager.CreateRun("test-workspace", "backup")
+	if err != nil {
+		t.Fatalf("Failed to create run: %v", err)
+	}
+
+	// The detached process marks the run running before the CLI that started it records the PID
+	run.Status = JobStatusRunning
+	if err := manager.SaveRun(run); err != nil {
+		t.Fatalf("Failed to save run: %v", err)
+	}
+	if err := manager.SetRunPID("test-workspace", "backup", run.ID, 4242); err != nil {
+		t.Fatalf("Failed to set PID: %v", err)
+	}
+
+	loaded, err := manager.GetRun("test-workspace", "backup", run.ID)
+	if err != nil {
+		t.Fatalf("Failed to load run: %v", err)
+	}
+	if loaded.Status != JobStatusRunning || loaded.PID != 4242 {
+		t.Errorf("Expected the running run with PID 4242, got %+v", loaded)
+	}
+
+	entries, err := os.ReadDir(manager.getRunDir("test-workspace", "backup"))
+	if err != nil || len(entries) != 1 {
+		t.Errorf("Expected only the run record in the run directory, got %v (%v)", entries, err)
+	}
+}
+
+func TestCreateAndGetRun(t *testing.T) {
+	manager := newTestManager(t, &opentofu.MockTofuClient{})
+
+	run, err := manager.CreateRun("test-workspace", "backup")
+	if err != nil {
+		t.Fatalf("Failed to create run: %v", err)
+	}
+
+	if run.Status != JobStatusPending {
+		t.Errorf("Expected new run to be pending, got %s", run.Status)
+	}
+
+	loaded, err := manager.GetRun("test-workspace", "backup", run.ID)
+	if err != nil {
+		t.Fatalf("Failed to load run: %v", err)
+	}
+
+	if loaded.ID != run.ID || loaded.JobName != "backup" || loaded.WorkspaceID != "test-workspace" {
+		t.Errorf("Loaded run does not match created run: %+v", loaded)
+	}
+
+	if _, err := manager.GetRun("test-workspace", "backup", "missing"); err == nil {
+		t.Error("Expected error for unknown run ID")
+	}
+}
+
+func TestListRuns(t *testing.T) {
+	manager := newTestManager(t, &opentofu.MockTofuClient{})
+
+	if runs, err := manager.ListRuns("test-workspace", "backup", 0); err != nil || len(runs) != 0 {
+		t.Fatalf("Expected no runs before any were created, got %v (%v)", runs, err)
+	}
+
+	for _, id := range []string{"20260101-090000-aaaaaa", "20260103-090000-cccccc", "20260102-090000-bbbbbb"} {
+		if err := manager.SaveRun(&RunRecord{ID: id, JobName: "backup", WorkspaceID: "test-workspace", Status: JobStatusSuccess}); err != nil {
+			t.Fatalf("Failed to save run: %v", err)
+		}
+	}
+
+	runs, err := manager.ListRuns("test-workspace", "backup", 2)
+	if err != nil {
+		t.Fatalf("Failed to list runs: %v", err)
+	}
+	if len(runs) != 2 || runs[0].ID != "20260103-090000-cccccc" || runs[1].ID != "20260102-090000-bbbbbb" {
+		t.Errorf("Expected the two newest runs first, got %+v", runs)
+	}
+}
+
+func TestManualExecuteJobRunRecordsResult(t *testing.T) {
+	workspaceID := "test-workspace"
+	manager := newTestManager(t, &opentofu.MockTofuClient{})
+
+	tests := []struct {
+		name           string
+		script         string
+		expectedStatus JobStatus
+		expectedExit   int
+	}{
+		{"success-job", "#!/bin/bash\necho ok", JobStatusSuccess, 0},
+		{"failing-job", "#!/bin/bash\nexit 3", JobStatusFailed, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := map[string]interface{}{
+				"name":     tt.name,
+				"type":     "script",
+				"schedule": "0 * * * *",
+				"script":   tt.script,
+				"enabled":  true,
+			}
+
+			run, err := manager.CreateRun(workspaceID, tt.name)
+			if err != nil {
+				t.Fatalf("Failed to create run: %v", err)
+			}
+
+			_ = manager.ManualExecuteJobRun(workspaceID, tt.name, run.ID, config)
+
+			finished, err := manager.GetRun(workspaceID, tt.name, run.ID)
+			if err != nil {
+				t.Fatalf("Failed to load run: %v", err)
+			}
+
+			if finished.Status != tt.expectedStatus {
+				t.Errorf("Expected status %s, got %s", tt.expectedStatus, finished.Status)
+			}
+			if finished.ExitCode != tt.expectedExit {
+				t.Errorf("Expected exit code %d, got %d", tt.expectedExit, finished.ExitCode)
+			}
+			if finished.EndTime == nil {
+				t.Error("Expected end time to be recorded")
+			}
+			if finished.PID != os.Getpid() {
+				t.Errorf("Expected PID %d, got %d", os.Getpid(), finished.PID)
+			}
+		})
+	}
+}
+
+func TestManualJobOperationsAreAudited(t *testing.T) {
+	workspaceID := "test-workspace"
+	manager := newTestManager(t, &opentofu.MockTofuClient{})
+
+	config := map[string]interface{}{
+		"name":     "backup",
+		"type":     "script",
+		"schedule": "0 * * * *",
+		"script":   "#!/bin/bash\nexit 2",
+		"enabled":  true,
+	}
+
+	key := audit.JobKey(workspaceID, "backup")
+	audit.SetTrigger(key, audit.Trigger{Source: audit.SourceCLI, Actor: "alice"})
+	_ = manager.ManualExecuteJob(workspaceID, "backup", config)
+	audit.ClearTrigger(key)
+
+	if err := manager.KillJob(workspaceID, "backup"); err == nil {
+		t.Fatal("Expected killing a job that is not running to fail")
+	}
+
+	entries, err := audit.NewLog(manager.stateDir).Read(audit.Filter{Workspace: workspaceID})
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected a run and a kill in the audit log, got %+v", entries)
+	}
+
+	run := entries[0]
+	if run.Operation != audit.OperationJobRun || run.Job != "backup" || run.Actor != "alice" || run.Outcome != audit.OutcomeFailed {
+		t.Errorf("Expected the failed run by alice, got %+v", run)
+	}
+	kill := entries[1]
+	if kill.Operation != audit.OperationJobKill || kill.Source != audit.SourceCLI || kill.Outcome != audit.OutcomeFailed || kill.Error == "" {
+		t.Errorf("Expected the failed kill by the CLI user, got %+v", kill)
+	}
+}
+
+func TestWaitForRun(t *testing.T) {
+	manager := newTestManager(t, &opentofu.MockTofuClient{})
+
+	run, err := manager.CreateRun("test-workspace", "backup")
+	if err != nil {
+		t.Fatalf("Failed to create run: %v", err)
+	}
+
+	// Pending run should time out
+	if _, err := manager.WaitForRun("test-workspace", "backup", run.ID, 50*time.Millisecond, 10*time.Millisecond); err == nil {
+		t.Error("Expected timeout waiting for pending run")
+	}
+
+	// Finished run should return immediately
+	now := time.Now()
+	run.Status = JobStatusSuccess
+	run.EndTime = &now
+	if err := manager.SaveRun(run); err != nil {
+		t.Fatalf("Failed to save run: %v", err)
+	}
+
+	finished, err := manager.WaitForRun("test-workspace", "backup", run.ID, time.Second, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error waiting for finished run: %v", err)
+	}
+	if finished.Status != JobStatusSuccess {
+		t.Errorf("Expected success, got %s", finished.Status)
+	}
+}
@@ -5,24 +5,73 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
+
+	"provisioner/pkg/configfile"
+	"provisioner/pkg/cron"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/slo"
 )
 
 // StandaloneJobConfig represents a job configuration file
 type StandaloneJobConfig struct {
-	Name        string            `json:"name"`
-	Type        string            `json:"type"`     // "script", "command", "template"
-	Schedule    interface{}       `json:"schedule"` // String or []string for CRON expressions
-	Script      string            `json:"script,omitempty"`
-	Command     string            `json:"command,omitempty"`
-	Template    string            `json:"template,omitempty"`
-	Environment map[string]string `json:"environment,omitempty"`
-	WorkingDir  string            `json:"working_dir,omitempty"`
-	Timeout     string            `json:"timeout,omitempty"`
-	Enabled     bool              `json:"enabled"`
-	Description string            `json:"description,omitempty"`
-	Tags        []string          `json:"tags,omitempty"`
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`     // "script", "command", "template"
+	Schedule     interface{}       `json:"schedule"` // String or []string for CRON expressions
+	Script       string            `json:"script,omitempty"`
+	Command      string            `json:"command,omitempty"`
+	Template     string            `json:"template,omitempty"`
+	Environment  map[string]string `json:"environment,omitempty"`
+	WorkingDir   string            `json:"working_dir,omitempty"`
+	Timeout      string            `json:"timeout,omitempty"`
+	Enabled      bool              `json:"enabled"`
+	Description  string            `json:"description,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Throttle     []string          `json:"throttle,omitempty"`       // Throttle buckets limiting concurrent runs
+	Jitter       string            `json:"jitter,omitempty"`         // Random delay of scheduled starts up to this duration
+	SpreadByName bool              `json:"spread_by_name,omitempty"` // Delay scheduled starts by a stable offset derived from the job name
+	Retries      int               `json:"retries,omitempty"`        // Attempts after a failed one before the run fails
+	RetryDelay   string            `json:"retry_delay,omitempty"`    // Wait between attempts (default 30s)
+	OnFailure    string            `json:"on_failure,omitempty"`     // continue, abort-dependents or notify (default)
+	SLO          *SLOConfig        `json:"slo,omitempty"`            // Success-rate objective of the job's runs
+
+	// Lifecycle of a template job's deployment
+	DestroyAfter    string      `json:"destroy_after,omitempty"`    // Destroy the deployment this long after a deploy
+	DestroySchedule interface{} `json:"destroy_schedule,omitempty"` // String or []string of CRON expressions destroying the deployment
+
+	// Resource limits of a script or command job's processes
+	MaxCPU    float64 `json:"max_cpu,omitempty"`    // CPU cores (e.g., 0.5)
+	MaxMemory string  `json:"max_memory,omitempty"` // Memory (e.g., "512M", "2G")
+	Nice      int     `json:"nice,omitempty"`       // Scheduling priority from -20 to 19
+
+	// Files collected from the working directory after each run
+	Artifacts         interface{} `json:"artifacts,omitempty"`          // String or []string of glob patterns
+	ArtifactRetention string      `json:"artifact_retention,omitempty"` // Remove a run's artifacts after this long
+}
+
+// SLOConfig sets an objective for the success rate of a standalone job's runs, computed over the
+// most recent window_runs runs of the last window_days days
+type SLOConfig struct {
+	SuccessRate float64 `json:"success_rate,omitempty"` // Minimum percentage of successful runs
+	WindowRuns  int     `json:"window_runs,omitempty"`  // Runs counted (default 30)
+	WindowDays  int     `json:"window_days,omitempty"`  // Days counted (default 30)
+}
+
+// GetWindow returns the window the success rate is computed over, the default window without an
+// objective
+func (c *SLOConfig) GetWindow() slo.Window {
+	if c == nil {
+		return slo.Window{}
+	}
+	return slo.Window{Runs: c.WindowRuns, Days: c.WindowDays}
+}
+
+// GetObjective returns the minimum success rate, 0 without an objective
+func (c *SLOConfig) GetObjective() float64 {
+	if c == nil {
+		return 0
+	}
+	return c.SuccessRate
 }
 
 // Validate validates the standalone job configuration
@@ -69,10 +118,18 @@ func (sjc *StandaloneJobConfig) Validate() error {
 		if schedule == "" {
 			return fmt.Errorf("empty schedule expression found")
 		}
-		// Basic CRON format check (5 fields separated by spaces)
-		fields := strings.Fields(schedule)
-		if len(fields) != 5 {
-			return fmt.Errorf("invalid schedule expression '%s': expected 5 fields, got %d", schedule, len(fields))
+		if _, err := cron.Parse(schedule); err != nil {
+			return fmt.Errorf("invalid schedule expression '%s': %w", schedule, err)
+		}
+	}
+
+	// Validate the success-rate objective
+	if sjc.SLO != nil {
+		if err := slo.ValidateObjective("success_rate", sjc.SLO.SuccessRate); err != nil {
+			return fmt.Errorf("invalid slo: %w", err)
+		}
+		if err := sjc.SLO.GetWindow().Validate(); err != nil {
+			return fmt.Errorf("invalid slo: %w", err)
 		}
 	}
 
@@ -82,14 +139,30 @@ func (sjc *StandaloneJobConfig) Validate() error {
 // ToJob converts the standalone job configuration to a Job
 func (sjc *StandaloneJobConfig) ToJob() (*Job, error) {
 	job := &Job{
-		Name:        sjc.Name,
-		WorkspaceID: "_standalone_",
-		Schedule:    sjc.Schedule,
-		Environment: sjc.Environment,
-		WorkingDir:  sjc.WorkingDir,
-		Timeout:     sjc.Timeout,
-		Enabled:     sjc.Enabled,
-		Description: sjc.Description,
+		Name:         sjc.Name,
+		WorkspaceID:  "_standalone_",
+		Schedule:     sjc.Schedule,
+		Environment:  sjc.Environment,
+		WorkingDir:   sjc.WorkingDir,
+		Timeout:      sjc.Timeout,
+		Enabled:      sjc.Enabled,
+		Description:  sjc.Description,
+		Throttle:     sjc.Throttle,
+		Jitter:       sjc.Jitter,
+		SpreadByName: sjc.SpreadByName,
+		Retries:      sjc.Retries,
+		RetryDelay:   sjc.RetryDelay,
+		OnFailure:    sjc.OnFailure,
+
+		DestroyAfter:    sjc.DestroyAfter,
+		DestroySchedule: sjc.DestroySchedule,
+
+		MaxCPU:    sjc.MaxCPU,
+		MaxMemory: sjc.MaxMemory,
+		Nice:      sjc.Nice,
+
+		Artifacts:         sjc.Artifacts,
+		ArtifactRetention: sjc.ArtifactRetention,
 	}
 
 	// Set job type and type-specific fields
@@ -120,6 +193,7 @@ type StandaloneJobManager struct {
 	jobsDir  string
 	stateDir string
 	manager  *Manager
+	leases   *LeaseManager
 }
 
 // NewStandaloneJobManager creates a new standalone job manager
@@ -128,6 +202,7 @@ func NewStandaloneJobManager(jobsDir, stateDir string, manager *Manager) *Standa
 		jobsDir:  jobsDir,
 		stateDir: stateDir,
 		manager:  manager,
+		leases:   NewLeaseManager(filepath.Join(stateDir, "leases")),
 	}
 }
 
@@ -140,18 +215,27 @@ func (sjm *StandaloneJobManager) LoadStandaloneJobs() ([]StandaloneJobConfig, er
 		return jobs, nil // No jobs directory, return empty list
 	}
 
-	// Read all .json files in the jobs directory
+	// Read all .json, .yaml and .hcl files in the jobs directory
 	entries, err := os.ReadDir(sjm.jobsDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read jobs directory: %w", err)
 	}
 
 	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+		baseName, ok := configfile.TrimExtension(entry.Name())
+		if entry.IsDir() || !ok {
 			continue
 		}
 
+		// A job defined in several formats is skipped, reported once
 		jobPath := filepath.Join(sjm.jobsDir, entry.Name())
+		if found, err := configfile.Path(sjm.jobsDir, baseName); err != nil {
+			if found == jobPath {
+				fmt.Printf("Warning: failed to load job %s: %v\n", baseName, err)
+			}
+			continue
+		}
+
 		jobConfig, err := sjm.loadStandaloneJobConfig(jobPath)
 		if err != nil {
 			fmt.Printf("Warning: failed to load job %s: %v\n", entry.Name(), err)
@@ -160,7 +244,7 @@ func (sjm *StandaloneJobManager) LoadStandaloneJobs() ([]StandaloneJobConfig, er
 
 		// If no name is specified, derive from filename
 		if jobConfig.Name == "" {
-			jobConfig.Name = strings.TrimSuffix(entry.Name(), ".json")
+			jobConfig.Name = baseName
 		}
 
 		jobs = append(jobs, jobConfig)
@@ -173,13 +257,17 @@ func (sjm *StandaloneJobManager) LoadStandaloneJobs() ([]StandaloneJobConfig, er
 func (sjm *StandaloneJobManager) loadStandaloneJobConfig(configPath string) (StandaloneJobConfig, error) {
 	var config StandaloneJobConfig
 
-	data, err := os.ReadFile(configPath)
+	content, err := os.ReadFile(configPath)
 	if err != nil {
 		return config, fmt.Errorf("failed to read config file: %w", err)
 	}
+	data, err := configfile.ToJSON(configPath, content)
+	if err != nil {
+		return config, err
+	}
 
-	if err := json.Unmarshal(data, &config); err != nil {
-		return config, fmt.Errorf("failed to unmarshal config: %w", err)
+	if err := configfile.Unmarshal(configPath, content, data, &config); err != nil {
+		return config, err
 	}
 
 	return config, nil
@@ -204,17 +292,30 @@ func (sjm *StandaloneJobManager) ProcessStandaloneJobs() error {
 		}
 
 		configMap := map[string]interface{}{
-			"name":        jobConfig.Name,
-			"type":        jobConfig.Type,
-			"schedule":    jobConfig.Schedule,
-			"script":      jobConfig.Script,
-			"command":     jobConfig.Command,
-			"template":    jobConfig.Template,
-			"environment": jobConfig.Environment,
-			"working_dir": jobConfig.WorkingDir,
-			"timeout":     jobConfig.Timeout,
-			"enabled":     jobConfig.Enabled,
-			"description": jobConfig.Description,
+			"name":               jobConfig.Name,
+			"type":               jobConfig.Type,
+			"schedule":           jobConfig.Schedule,
+			"script":             jobConfig.Script,
+			"command":            jobConfig.Command,
+			"template":           jobConfig.Template,
+			"environment":        jobConfig.Environment,
+			"working_dir":        jobConfig.WorkingDir,
+			"timeout":            jobConfig.Timeout,
+			"enabled":            jobConfig.Enabled,
+			"description":        jobConfig.Description,
+			"throttle":           jobConfig.Throttle,
+			"jitter":             jobConfig.Jitter,
+			"spread_by_name":     jobConfig.SpreadByName,
+			"retries":            jobConfig.Retries,
+			"retry_delay":        jobConfig.RetryDelay,
+			"on_failure":         jobConfig.OnFailure,
+			"destroy_after":      jobConfig.DestroyAfter,
+			"destroy_schedule":   jobConfig.DestroySchedule,
+			"max_cpu":            jobConfig.MaxCPU,
+			"max_memory":         jobConfig.MaxMemory,
+			"nice":               jobConfig.Nice,
+			"artifacts":          jobConfig.Artifacts,
+			"artifact_retention": jobConfig.ArtifactRetention,
 		}
 
 		jobConfigInterfaces = append(jobConfigInterfaces, configMap)
@@ -224,7 +325,8 @@ func (sjm *StandaloneJobManager) ProcessStandaloneJobs() error {
 	// Process jobs using the standard job manager with special workspace ID
 	const standaloneWorkspaceID = "_standalone_"
 	if len(jobConfigInterfaces) > 0 {
-		sjm.manager.ProcessWorkspaceJobs(standaloneWorkspaceID, jobConfigInterfaces, time.Now())
+		// Claim each run so provisioners sharing the jobs and state directories run it once
+		sjm.manager.processJobs(standaloneWorkspaceID, jobConfigInterfaces, time.Now(), sjm.leases)
 	}
 
 	// Cleanup old job states that no longer exist
@@ -233,6 +335,51 @@ func (sjm *StandaloneJobManager) ProcessStandaloneJobs() error {
 	return nil
 }
 
+// ResetChangedJobs resets failed or timed out standalone jobs whose config file
+// was modified after since, so fixed jobs run again on their next schedule.
+// It returns the names of the jobs whose config changed.
+func (sjm *StandaloneJobManager) ResetChangedJobs(since time.Time) []string {
+	const standaloneWorkspaceID = "_standalone_"
+
+	entries, err := os.ReadDir(sjm.jobsDir)
+	if err != nil {
+		return nil // No jobs directory, nothing to reset
+	}
+
+	var changed []string
+	for _, entry := range entries {
+		baseName, ok := configfile.TrimExtension(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil || !info.ModTime().After(since) {
+			continue
+		}
+
+		jobPath := filepath.Join(sjm.jobsDir, entry.Name())
+		logging.LogSystemd("Job config file changed: %s (modified: %s)", jobPath, logging.FormatTime(info.ModTime()))
+
+		// Job state is keyed by name, which defaults to the filename
+		jobName := baseName
+		if jobConfig, err := sjm.loadStandaloneJobConfig(jobPath); err == nil && jobConfig.Name != "" {
+			jobName = jobConfig.Name
+		}
+
+		logging.LogSystemd("Standalone job %s configuration updated, resetting failed state if applicable", jobName)
+		jobState := sjm.manager.GetJobState(standaloneWorkspaceID, jobName)
+		if jobState != nil && (jobState.Status == JobStatusFailed || jobState.Status == JobStatusTimeout) {
+			logging.LogWorkspace(standaloneWorkspaceID, "JOB %s: Configuration changed, resetting %s state", jobName, jobState.Status)
+		}
+		sjm.manager.stateManager.SetJobConfigModified(standaloneWorkspaceID, jobName, info.ModTime())
+
+		changed = append(changed, jobName)
+	}
+
+	return changed
+}
+
 // validateStandaloneJob validates a standalone job configuration
 func (sjm *StandaloneJobManager) validateStandaloneJob(job StandaloneJobConfig) error {
 	if job.Name == "" {
@@ -273,9 +420,40 @@ func (sjm *StandaloneJobManager) GetStandaloneJobStates() map[string]*JobState {
 
 // ExecuteStandaloneJob executes a standalone job immediately
 func (sjm *StandaloneJobManager) ExecuteStandaloneJob(jobName string) error {
+	configMap, err := sjm.getStandaloneJobConfigMap(jobName)
+	if err != nil {
+		return err
+	}
+
+	const standaloneWorkspaceID = "_standalone_"
+	return sjm.manager.ManualExecuteJob(standaloneWorkspaceID, jobName, configMap)
+}
+
+// ExecuteStandaloneJobRun executes a standalone job, recording progress under an existing run record
+func (sjm *StandaloneJobManager) ExecuteStandaloneJobRun(jobName, runID string) error {
+	const standaloneWorkspaceID = "_standalone_"
+
+	configMap, err := sjm.getStandaloneJobConfigMap(jobName)
+	if err != nil {
+		// Record the failure so anyone waiting on the run sees it
+		if run, getErr := sjm.manager.GetRun(standaloneWorkspaceID, jobName, runID); getErr == nil {
+			now := time.Now()
+			run.Status = JobStatusFailed
+			run.Error = err.Error()
+			run.EndTime = &now
+			_ = sjm.manager.SaveRun(run)
+		}
+		return err
+	}
+
+	return sjm.manager.ManualExecuteJobRun(standaloneWorkspaceID, jobName, runID, configMap)
+}
+
+// getStandaloneJobConfigMap finds a standalone job and converts it to the job manager's config format
+func (sjm *StandaloneJobManager) getStandaloneJobConfigMap(jobName string) (map[string]interface{}, error) {
 	jobs, err := sjm.LoadStandaloneJobs()
 	if err != nil {
-		return fmt.Errorf("failed to load standalone jobs: %w", err)
+		return nil, fmt.Errorf("failed to load standalone jobs: %w", err)
 	}
 
 	// Find the job
@@ -288,26 +466,95 @@ func (sjm *StandaloneJobManager) ExecuteStandaloneJob(jobName string) error {
 	}
 
 	if targetJob == nil {
-		return fmt.Errorf("standalone job '%s' not found", jobName)
+		return nil, fmt.Errorf("standalone job '%s' not found", jobName)
 	}
 
 	// Convert to interface{} format
-	configMap := map[string]interface{}{
-		"name":        targetJob.Name,
-		"type":        targetJob.Type,
-		"schedule":    targetJob.Schedule,
-		"script":      targetJob.Script,
-		"command":     targetJob.Command,
-		"template":    targetJob.Template,
-		"environment": targetJob.Environment,
-		"working_dir": targetJob.WorkingDir,
-		"timeout":     targetJob.Timeout,
-		"enabled":     targetJob.Enabled,
-		"description": targetJob.Description,
+	return map[string]interface{}{
+		"name":               targetJob.Name,
+		"type":               targetJob.Type,
+		"schedule":           targetJob.Schedule,
+		"script":             targetJob.Script,
+		"command":            targetJob.Command,
+		"template":           targetJob.Template,
+		"environment":        targetJob.Environment,
+		"working_dir":        targetJob.WorkingDir,
+		"timeout":            targetJob.Timeout,
+		"enabled":            targetJob.Enabled,
+		"description":        targetJob.Description,
+		"throttle":           targetJob.Throttle,
+		"jitter":             targetJob.Jitter,
+		"spread_by_name":     targetJob.SpreadByName,
+		"retries":            targetJob.Retries,
+		"retry_delay":        targetJob.RetryDelay,
+		"on_failure":         targetJob.OnFailure,
+		"destroy_after":      targetJob.DestroyAfter,
+		"destroy_schedule":   targetJob.DestroySchedule,
+		"max_cpu":            targetJob.MaxCPU,
+		"max_memory":         targetJob.MaxMemory,
+		"nice":               targetJob.Nice,
+		"artifacts":          targetJob.Artifacts,
+		"artifact_retention": targetJob.ArtifactRetention,
+	}, nil
+}
+
+// CreateStandaloneRun registers a new pending run for a standalone job
+func (sjm *StandaloneJobManager) CreateStandaloneRun(jobName string) (*RunRecord, error) {
+	if _, err := sjm.getStandaloneJobConfigMap(jobName); err != nil {
+		return nil, err
 	}
 
 	const standaloneWorkspaceID = "_standalone_"
-	return sjm.manager.ManualExecuteJob(standaloneWorkspaceID, jobName, configMap)
+	return sjm.manager.CreateRun(standaloneWorkspaceID, jobName)
+}
+
+// GetStandaloneRun returns the record of a standalone job run
+func (sjm *StandaloneJobManager) GetStandaloneRun(jobName, runID string) (*RunRecord, error) {
+	const standaloneWorkspaceID = "_standalone_"
+	return sjm.manager.GetRun(standaloneWorkspaceID, jobName, runID)
+}
+
+// WaitForStandaloneRun blocks until a standalone job run finishes or the timeout expires
+func (sjm *StandaloneJobManager) WaitForStandaloneRun(jobName, runID string, timeout time.Duration) (*RunRecord, error) {
+	const standaloneWorkspaceID = "_standalone_"
+	return sjm.manager.WaitForRun(standaloneWorkspaceID, jobName, runID, timeout, time.Second)
+}
+
+// GetStandaloneHistory returns the kept runs of a standalone job, newest first
+func (sjm *StandaloneJobManager) GetStandaloneHistory(jobName string) ([]HistoryEntry, error) {
+	if _, err := sjm.getStandaloneJobConfigMap(jobName); err != nil {
+		return nil, err
+	}
+
+	const standaloneWorkspaceID = "_standalone_"
+	return sjm.manager.GetHistory(standaloneWorkspaceID, jobName), nil
+}
+
+// GetStandaloneHistoryEntry returns a run from the history of a standalone job
+func (sjm *StandaloneJobManager) GetStandaloneHistoryEntry(jobName, runID string) (*HistoryEntry, error) {
+	const standaloneWorkspaceID = "_standalone_"
+	return sjm.manager.GetHistoryEntry(standaloneWorkspaceID, jobName, runID)
+}
+
+// GetStandaloneArtifacts returns the artifacts of a standalone job's run, or of all its runs if
+// runID is empty
+func (sjm *StandaloneJobManager) GetStandaloneArtifacts(jobName, runID string) ([]Artifact, error) {
+	if _, err := sjm.getStandaloneJobConfigMap(jobName); err != nil {
+		return nil, err
+	}
+
+	const standaloneWorkspaceID = "_standalone_"
+	return sjm.manager.ListArtifacts(standaloneWorkspaceID, jobName, runID)
+}
+
+// DownloadStandaloneArtifacts copies the artifacts of a standalone job's run into destDir
+func (sjm *StandaloneJobManager) DownloadStandaloneArtifacts(jobName, runID, destDir string) ([]Artifact, error) {
+	if _, err := sjm.getStandaloneJobConfigMap(jobName); err != nil {
+		return nil, err
+	}
+
+	const standaloneWorkspaceID = "_standalone_"
+	return sjm.manager.DownloadArtifacts(standaloneWorkspaceID, jobName, runID, destDir)
 }
 
 // KillStandaloneJob kills a running standalone job
@@ -333,14 +580,15 @@ func (sjm *StandaloneJobManager) CreateStandaloneJob(jobName string, config Stan
 		return fmt.Errorf("invalid job configuration: %w", err)
 	}
 
-	// Write the configuration file
-	jobPath := filepath.Join(sjm.jobsDir, jobName+".json")
-
-	// Check if job already exists
-	if _, err := os.Stat(jobPath); err == nil {
+	// Check if job already exists, in any format
+	existing, err := configfile.Path(sjm.jobsDir, jobName)
+	if _, statErr := os.Stat(existing); err != nil || statErr == nil {
 		return fmt.Errorf("job '%s' already exists", jobName)
 	}
 
+	// Write the configuration file
+	jobPath := filepath.Join(sjm.jobsDir, jobName+".json")
+
 	data, err := json.MarshalIndent(config, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal job config: %w", err)
@@ -355,7 +603,10 @@ func (sjm *StandaloneJobManager) CreateStandaloneJob(jobName string, config Stan
 
 // RemoveStandaloneJob removes a standalone job configuration
 func (sjm *StandaloneJobManager) RemoveStandaloneJob(jobName string) error {
-	jobPath := filepath.Join(sjm.jobsDir, jobName+".json")
+	jobPath, err := configfile.Path(sjm.jobsDir, jobName)
+	if err != nil {
+		return err
+	}
 
 	if _, err := os.Stat(jobPath); os.IsNotExist(err) {
 		return fmt.Errorf("job '%s' does not exist", jobName)
@@ -385,3 +636,37 @@ func parseScheduleField(schedule interface{}) ([]string, error) {
 		return nil, fmt.Errorf("schedule must be a string or array of strings, got %T", schedule)
 	}
 }
+
+// GetJobsDir returns the directory standalone job definitions are loaded from
+func (sjm *StandaloneJobManager) GetJobsDir() string {
+	return sjm.jobsDir
+}
+
+// SaveStandaloneJobConfig writes a standalone job definition to the jobs directory
+func (sjm *StandaloneJobManager) SaveStandaloneJobConfig(config StandaloneJobConfig, overwrite bool) (string, error) {
+	if err := config.Validate(); err != nil {
+		return "", fmt.Errorf("invalid job '%s': %w", config.Name, err)
+	}
+
+	// Ensure jobs directory exists
+	if err := os.MkdirAll(sjm.jobsDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create jobs directory: %w", err)
+	}
+
+	// An existing job is overwritten in its own format
+	jobPath, err := configfile.Path(sjm.jobsDir, config.Name)
+	if err != nil {
+		return "", err
+	}
+	if !overwrite {
+		if _, err := os.Stat(jobPath); err == nil {
+			return "", fmt.Errorf("job file %s already exists", jobPath)
+		}
+	}
+
+	if err := configfile.Write(jobPath, config); err != nil {
+		return "", err
+	}
+
+	return jobPath, nil
+}
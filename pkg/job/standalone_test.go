@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -121,6 +122,30 @@ func TestStandaloneJobConfigValidation(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "success-rate objective",
+			config: StandaloneJobConfig{
+				Name:     "test-slo",
+				Type:     "command",
+				Schedule: "0 * * * *",
+				Command:  "uptime",
+				Enabled:  true,
+				SLO:      &SLOConfig{SuccessRate: 99, WindowRuns: 10},
+			},
+			wantErr: false,
+		},
+		{
+			name: "success-rate objective above 100",
+			config: StandaloneJobConfig{
+				Name:     "test-slo-invalid",
+				Type:     "command",
+				Schedule: "0 * * * *",
+				Command:  "uptime",
+				Enabled:  true,
+				SLO:      &SLOConfig{SuccessRate: 150},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -291,6 +316,67 @@ func TestStandaloneJobManagerFileOperations(t *testing.T) {
 	}
 }
 
+func TestStandaloneJobConfigFormats(t *testing.T) {
+	tempDir := t.TempDir()
+	jobsDir := filepath.Join(tempDir, "jobs")
+	stateDir := filepath.Join(tempDir, "state")
+	if err := os.MkdirAll(jobsDir, 0755); err != nil {
+		t.Fatalf("Failed to create jobs directory: %v", err)
+	}
+
+	jobManager := NewManager(stateDir, &opentofu.MockTofuClient{}, template.NewManager(filepath.Join(stateDir, "templates")))
+	sjm := NewStandaloneJobManager(jobsDir, stateDir, jobManager)
+
+	files := map[string]string{
+		"backup.yaml": "# Nightly backup\ntype: command\ncommand: /usr/local/bin/backup.sh\nschedule: \"0 2 * * *\" # 2am\nenabled: true\n",
+		"cleanup.hcl": "type = \"command\"\ncommand = \"rm -rf /tmp/cache\"\nschedule = [\"0 3 * * *\"]\nenabled = true\n",
+		"dup.json":    `{"type": "command", "command": "true", "schedule": "@daily", "enabled": true}`,
+		"dup.yml":     "type: command\ncommand: \"true\"\nschedule: \"@daily\"\nenabled: true\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(jobsDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// A job defined in two formats is skipped
+	jobs, err := sjm.LoadStandaloneJobs()
+	if err != nil {
+		t.Fatalf("Failed to load jobs: %v", err)
+	}
+	if len(jobs) != 2 || jobs[0].Name != "backup" || jobs[1].Name != "cleanup" {
+		t.Fatalf("Expected the backup and cleanup jobs, got %+v", jobs)
+	}
+	if jobs[0].Command != "/usr/local/bin/backup.sh" || jobs[0].Schedule != "0 2 * * *" {
+		t.Errorf("Unexpected YAML job %+v", jobs[0])
+	}
+
+	// Saving a job rewrites it in its own format, keeping comments
+	jobs[0].Description = "Nightly backup"
+	jobPath, err := sjm.SaveStandaloneJobConfig(jobs[0], true)
+	if err != nil {
+		t.Fatalf("Failed to save job: %v", err)
+	}
+	if jobPath != filepath.Join(jobsDir, "backup.yaml") {
+		t.Errorf("Expected backup.yaml to be rewritten, got %s", jobPath)
+	}
+	data, err := os.ReadFile(jobPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# 2am") || !strings.Contains(string(data), "description: Nightly backup") {
+		t.Errorf("Expected comments kept and description added, got:\n%s", data)
+	}
+
+	// HCL jobs are not written
+	if _, err := sjm.SaveStandaloneJobConfig(jobs[1], true); err == nil {
+		t.Error("Expected saving an HCL job to fail")
+	}
+	if err := sjm.CreateStandaloneJob("cleanup", jobs[1]); err == nil {
+		t.Error("Expected creating a job defined in HCL to fail")
+	}
+}
+
 func TestStandaloneJobExecution(t *testing.T) {
 	// Create temporary directories
 	tempDir := t.TempDir()
@@ -686,3 +772,85 @@ func TestStandaloneJobMultipleSchedules(t *testing.T) {
 		})
 	}
 }
+
+func TestStandaloneJobConfigChangeResetsFailedState(t *testing.T) {
+	tempDir := t.TempDir()
+	jobsDir := filepath.Join(tempDir, "jobs")
+	stateDir := filepath.Join(tempDir, "state")
+
+	if err := os.MkdirAll(jobsDir, 0755); err != nil {
+		t.Fatalf("Failed to create jobs directory: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(stateDir, "deployments", "_standalone_"), 0755); err != nil {
+		t.Fatalf("Failed to create deployment directory: %v", err)
+	}
+
+	mockClient := &opentofu.MockTofuClient{}
+	templateManager := template.NewManager(filepath.Join(stateDir, "templates"))
+	jobManager := NewManager(stateDir, mockClient, templateManager)
+	if err := jobManager.LoadState(); err != nil {
+		t.Fatalf("Failed to load initial state: %v", err)
+	}
+
+	sjm := NewStandaloneJobManager(jobsDir, stateDir, jobManager)
+
+	// Name differs from the filename to check state is reset by job name
+	jobConfig := StandaloneJobConfig{
+		Name:     "nightly-report",
+		Type:     "command",
+		Schedule: "0 2 * * *",
+		Command:  "exit 1",
+		Enabled:  true,
+	}
+	jobData, err := json.MarshalIndent(jobConfig, "", "  ")
+	if err != nil {
+		t.Fatalf("Failed to marshal job config: %v", err)
+	}
+	jobFile := filepath.Join(jobsDir, "report.json")
+	if err := os.WriteFile(jobFile, jobData, 0644); err != nil {
+		t.Fatalf("Failed to write job file: %v", err)
+	}
+
+	if err := sjm.ExecuteStandaloneJob("nightly-report"); err == nil {
+		t.Fatal("Expected failing job to return an error")
+	}
+	jobState := jobManager.GetJobState("_standalone_", "nightly-report")
+	if jobState.Status != JobStatusFailed {
+		t.Fatalf("Expected job status %s, got %s", JobStatusFailed, jobState.Status)
+	}
+
+	lastCheck := time.Now()
+
+	// Unchanged since the last check: failed state is kept
+	past := lastCheck.Add(-time.Minute)
+	if err := os.Chtimes(jobFile, past, past); err != nil {
+		t.Fatalf("Failed to set job file time: %v", err)
+	}
+	if changed := sjm.ResetChangedJobs(lastCheck); len(changed) != 0 {
+		t.Errorf("Expected no changed jobs, got %v", changed)
+	}
+	jobState = jobManager.GetJobState("_standalone_", "nightly-report")
+	if jobState.Status != JobStatusFailed {
+		t.Errorf("Expected job status to stay %s, got %s", JobStatusFailed, jobState.Status)
+	}
+
+	// Edited after the last check: failed state is reset
+	edited := lastCheck.Add(time.Minute)
+	if err := os.Chtimes(jobFile, edited, edited); err != nil {
+		t.Fatalf("Failed to set job file time: %v", err)
+	}
+	changed := sjm.ResetChangedJobs(lastCheck)
+	if len(changed) != 1 || changed[0] != "nightly-report" {
+		t.Fatalf("Expected [nightly-report] to be changed, got %v", changed)
+	}
+	jobState = jobManager.GetJobState("_standalone_", "nightly-report")
+	if jobState.Status != JobStatusPending {
+		t.Errorf("Expected job status %s after config change, got %s", JobStatusPending, jobState.Status)
+	}
+	if jobState.LastError != "" {
+		t.Errorf("Expected error to be cleared, got '%s'", jobState.LastError)
+	}
+	if jobState.LastConfigModified == nil || !jobState.LastConfigModified.Equal(edited) {
+		t.Errorf("Expected config modified time %v, got %v", edited, jobState.LastConfigModified)
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sync"
 	"time"
+
+	"provisioner/pkg/cron"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/statefile"
 )
 
 // StateManager handles persistence of job states
 type StateManager struct {
 	statePath string
+	mu        sync.Mutex // Guards state, updated by jobs running concurrently
 	state     *State
 }
 
@@ -29,6 +34,9 @@ func NewStateManager(statePath string) *StateManager {
 
 // LoadState loads job state from disk
 func (sm *StateManager) LoadState() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
 	// Initialize empty state if file doesn't exist
 	if _, err := os.Stat(sm.statePath); os.IsNotExist(err) {
 		sm.state = &State{
@@ -38,10 +46,13 @@ func (sm *StateManager) LoadState() error {
 		return nil
 	}
 
-	data, err := os.ReadFile(sm.statePath)
+	data, recovered, err := statefile.Read(sm.statePath)
 	if err != nil {
 		return fmt.Errorf("failed to read job state file: %w", err)
 	}
+	if recovered {
+		logging.LogSystemd("Warning: %s was corrupt and has been restored from its backup", sm.statePath)
+	}
 
 	var state State
 	if err := json.Unmarshal(data, &state); err != nil {
@@ -58,31 +69,44 @@ func (sm *StateManager) LoadState() error {
 
 // SaveState saves job state to disk
 func (sm *StateManager) SaveState() error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
 	if sm.state == nil {
 		return fmt.Errorf("no state to save")
 	}
 
 	sm.state.LastUpdated = time.Now()
 
-	// Ensure state directory exists
-	if err := os.MkdirAll(filepath.Dir(sm.statePath), 0755); err != nil {
-		return fmt.Errorf("failed to create state directory: %w", err)
-	}
-
 	data, err := json.MarshalIndent(sm.state, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal job state: %w", err)
 	}
 
-	if err := os.WriteFile(sm.statePath, data, 0644); err != nil {
+	// Replace atomically under a lock so the daemon and CLIs can't truncate or interleave writes
+	if err := statefile.Write(sm.statePath, data); err != nil {
 		return fmt.Errorf("failed to write job state file: %w", err)
 	}
 
 	return nil
 }
 
-// GetJobState returns the state for a specific job
+// GetJobState returns a copy of the state for a specific job, as running jobs update it
+// concurrently. Changes are stored with SetJobState.
 func (sm *StateManager) GetJobState(workspaceID, jobName string) *JobState {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	jobState := sm.jobState(workspaceID, jobName)
+	if jobState == nil {
+		return nil
+	}
+	stateCopy := *jobState
+	return &stateCopy
+}
+
+// jobState returns the state for a job, creating it if needed; the caller holds mu
+func (sm *StateManager) jobState(workspaceID, jobName string) *JobState {
 	if sm.state == nil {
 		return nil
 	}
@@ -104,6 +128,13 @@ func (sm *StateManager) GetJobState(workspaceID, jobName string) *JobState {
 
 // SetJobState updates the state for a specific job
 func (sm *StateManager) SetJobState(workspaceID, jobName string, jobState *JobState) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	sm.setJobState(workspaceID, jobName, jobState)
+}
+
+// setJobState stores the state for a job; the caller holds mu
+func (sm *StateManager) setJobState(workspaceID, jobName string, jobState *JobState) {
 	if sm.state == nil {
 		sm.state = &State{
 			Jobs:        make(map[string]*JobState),
@@ -117,13 +148,21 @@ func (sm *StateManager) SetJobState(workspaceID, jobName string, jobState *JobSt
 
 // UpdateJobExecution updates job state based on execution results
 func (sm *StateManager) UpdateJobExecution(execution *JobExecution) {
-	jobState := sm.GetJobState(execution.WorkspaceID, execution.JobName)
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	jobState := sm.jobState(execution.WorkspaceID, execution.JobName)
 	if jobState == nil {
 		return // Cannot update execution if we can't get/create job state
 	}
 
 	jobState.Status = execution.Status
 	jobState.RunCount++
+	jobState.LastCorrelationID = execution.CorrelationID
+	jobState.LastAttempts = execution.Attempts
+	if execution.Attempts > 1 {
+		jobState.RetryCount += execution.Attempts - 1
+	}
 
 	now := time.Now()
 	jobState.LastRun = &now
@@ -140,22 +179,43 @@ func (sm *StateManager) UpdateJobExecution(execution *JobExecution) {
 		jobState.LastExitCode = execution.ExitCode
 	}
 
-	sm.SetJobState(execution.WorkspaceID, execution.JobName, jobState)
+	jobState.addHistoryEntry(newHistoryEntry(execution))
+
+	sm.setJobState(execution.WorkspaceID, execution.JobName, jobState)
 }
 
 // SetJobStatus updates just the status of a job
 func (sm *StateManager) SetJobStatus(workspaceID, jobName string, status JobStatus) {
-	jobState := sm.GetJobState(workspaceID, jobName)
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	jobState := sm.jobState(workspaceID, jobName)
 	if jobState == nil {
 		return // Cannot set status if we can't get/create job state
 	}
 	jobState.Status = status
-	sm.SetJobState(workspaceID, jobName, jobState)
+	sm.setJobState(workspaceID, jobName, jobState)
+}
+
+// SetJobLastRun records a run of a job executed elsewhere, e.g. by another host sharing the state directory
+func (sm *StateManager) SetJobLastRun(workspaceID, jobName string, lastRun time.Time) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	jobState := sm.jobState(workspaceID, jobName)
+	if jobState == nil {
+		return // Cannot set last run if we can't get/create job state
+	}
+	jobState.LastRun = &lastRun
+	sm.setJobState(workspaceID, jobName, jobState)
 }
 
 // SetJobConfigModified marks a job's configuration as modified
 func (sm *StateManager) SetJobConfigModified(workspaceID, jobName string, modTime time.Time) {
-	jobState := sm.GetJobState(workspaceID, jobName)
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	jobState := sm.jobState(workspaceID, jobName)
 	if jobState == nil {
 		return // Cannot set config modified if we can't get/create job state
 	}
@@ -167,11 +227,14 @@ func (sm *StateManager) SetJobConfigModified(workspaceID, jobName string, modTim
 		jobState.LastError = ""
 	}
 
-	sm.SetJobState(workspaceID, jobName, jobState)
+	sm.setJobState(workspaceID, jobName, jobState)
 }
 
-// GetAllJobStates returns all job states for a workspace
+// GetAllJobStates returns copies of all job states for a workspace
 func (sm *StateManager) GetAllJobStates(workspaceID string) map[string]*JobState {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
 	if sm.state == nil {
 		return make(map[string]*JobState)
 	}
@@ -182,7 +245,8 @@ func (sm *StateManager) GetAllJobStates(workspaceID string) map[string]*JobState
 	for key, jobState := range sm.state.Jobs {
 		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
 			jobName := key[len(prefix):]
-			result[jobName] = jobState
+			stateCopy := *jobState
+			result[jobName] = &stateCopy
 		}
 	}
 
@@ -191,6 +255,9 @@ func (sm *StateManager) GetAllJobStates(workspaceID string) map[string]*JobState
 
 // CleanupJobStates removes job states for jobs that no longer exist in configuration
 func (sm *StateManager) CleanupJobStates(workspaceID string, activeJobs []string) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
 	if sm.state == nil {
 		return
 	}
@@ -217,38 +284,42 @@ func (sm *StateManager) CleanupJobStates(workspaceID string, activeJobs []string
 	}
 }
 
-// GetNextRunTime calculates the next run time for a job based on its schedule
-func (sm *StateManager) GetNextRunTime(job *Job) (*time.Time, error) {
+// GetNextRunTime returns the earliest time after now one of the job's time-based schedules
+// fires, or nil if it only runs on events or has no schedule
+func (sm *StateManager) GetNextRunTime(job *Job, now time.Time) (*time.Time, error) {
 	schedules, err := job.GetSchedules()
 	if err != nil {
 		return nil, err
 	}
 
-	if len(schedules) == 0 {
-		return nil, nil // No schedule defined
-	}
-
-	// For simplicity, use the first schedule to calculate next run
-	// In a full implementation, you might want to find the earliest next run across all schedules
-	if len(schedules) > 0 {
-		// This is a simplified calculation - you would want to use the existing CRON parsing logic
-		// For now, just return a time 1 hour from now as a placeholder
-		nextRun := time.Now().Add(1 * time.Hour)
-		return &nextRun, nil
+	var next *time.Time
+	for _, scheduleStr := range schedules {
+		schedule, err := cron.Parse(scheduleStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule '%s': %w", scheduleStr, err)
+		}
+		if run := schedule.NextRun(now); run != nil && (next == nil || run.Before(*next)) {
+			next = run
+		}
 	}
-
-	return nil, nil
+	return next, nil
 }
 
 // SetJobNextRun sets the next scheduled run time for a job
 func (sm *StateManager) SetJobNextRun(workspaceID, jobName string, nextRun *time.Time) {
-	jobState := sm.GetJobState(workspaceID, jobName)
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	jobState := sm.jobState(workspaceID, jobName)
 	jobState.NextRun = nextRun
-	sm.SetJobState(workspaceID, jobName, jobState)
+	sm.setJobState(workspaceID, jobName, jobState)
 }
 
 // GetLastUpdateTime returns the last update time of the state
 func (sm *StateManager) GetLastUpdateTime() time.Time {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
 	if sm.state == nil {
 		return time.Now()
 	}
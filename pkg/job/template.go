@@ -0,0 +1,297 @@
+package job
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/cron"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+)
+
+// Status of a template job's deployment
+const (
+	DeploymentStatusDeployed      = "deployed"       // The last deploy succeeded
+	DeploymentStatusFailed        = "failed"         // The last deploy failed, resources may be left
+	DeploymentStatusDestroyed     = "destroyed"      // Destroyed by its lifecycle
+	DeploymentStatusDestroyFailed = "destroy-failed" // The destroy failed, it is not retried until the next deploy
+)
+
+// TemplateDeployment tracks the OpenTofu deployment a template job manages in its own
+// directory below the workspace's deployment
+type TemplateDeployment struct {
+	Template    string            `json:"template"`
+	Status      string            `json:"status"`
+	DeployedAt  *time.Time        `json:"deployed_at,omitempty"`
+	DestroyAt   *time.Time        `json:"destroy_at,omitempty"` // When destroy_after destroys it, set on deploy
+	DestroyedAt *time.Time        `json:"destroyed_at,omitempty"`
+	Outputs     map[string]string `json:"outputs,omitempty"` // Outputs of the last deploy, sensitive values masked
+	LastError   string            `json:"last_error,omitempty"`
+}
+
+// IsActive returns true while the deployment may hold resources its lifecycle destroys
+func (d *TemplateDeployment) IsActive() bool {
+	return d.Status == DeploymentStatusDeployed || d.Status == DeploymentStatusFailed
+}
+
+// TemplateWorkingDirectory returns the directory of a template job's deployment
+func TemplateWorkingDirectory(workspaceDeploymentDir, jobName string) string {
+	return filepath.Join(workspaceDeploymentDir, opentofu.TemplateJobsDir, jobName)
+}
+
+// GetDestroyAfter returns how long after a deploy the template deployment is destroyed, 0 for never
+func (j *Job) GetDestroyAfter() (time.Duration, error) {
+	if j.DestroyAfter == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(j.DestroyAfter)
+	if err != nil || duration <= 0 {
+		return 0, fmt.Errorf("invalid destroy_after duration '%s'", j.DestroyAfter)
+	}
+	return duration, nil
+}
+
+// GetDestroySchedules returns the schedules destroying the template deployment
+func (j *Job) GetDestroySchedules() ([]string, error) {
+	return normalizeScheduleField(j.DestroySchedule)
+}
+
+// HasLifecycle returns true if the template deployment is destroyed after a time or on a schedule
+func (j *Job) HasLifecycle() bool {
+	return j.DestroyAfter != "" || j.DestroySchedule != nil
+}
+
+// validateTemplateLifecycle checks the destroy_after and destroy_schedule fields of a job
+func (j *Job) validateTemplateLifecycle() error {
+	if !j.HasLifecycle() {
+		return nil
+	}
+	if j.JobType != JobTypeTemplate {
+		return fmt.Errorf("destroy_after and destroy_schedule are only supported for template jobs")
+	}
+	if _, err := j.GetDestroyAfter(); err != nil {
+		return err
+	}
+
+	schedules, err := j.GetDestroySchedules()
+	if err != nil {
+		return fmt.Errorf("invalid destroy_schedule: %w", err)
+	}
+	for _, schedule := range schedules {
+		if _, err := cron.Parse(schedule); err != nil {
+			return fmt.Errorf("invalid destroy_schedule '%s': %w", schedule, err)
+		}
+	}
+	return nil
+}
+
+// destroyDue checks if a template deployment's destroy_after has passed or one of its destroy
+// schedules fired since the deploy. Event schedules only fire through ProcessWorkspaceJobsForEvent.
+func (j *Job) destroyDue(deployment *TemplateDeployment, now time.Time) bool {
+	if deployment == nil || !deployment.IsActive() || deployment.DeployedAt == nil {
+		return false
+	}
+	if deployment.DestroyAt != nil && !now.Before(*deployment.DestroyAt) {
+		return true
+	}
+
+	schedules, err := j.GetDestroySchedules()
+	if err != nil {
+		return false
+	}
+	for _, scheduleStr := range schedules {
+		schedule, err := cron.Parse(scheduleStr)
+		if err != nil {
+			continue
+		}
+		last := schedule.LastRunToday(now)
+		if last != nil && now.After(*last) && deployment.DeployedAt.Before(*last) {
+			return true
+		}
+	}
+	return false
+}
+
+// destroyMatchesEvent checks if one of the job's destroy schedules matches a deployment event
+func (j *Job) destroyMatchesEvent(event DeploymentEvent) bool {
+	schedules, err := j.GetDestroySchedules()
+	if err != nil {
+		return false
+	}
+	for _, schedule := range schedules {
+		if event.MatchesSchedule(schedule) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldDestroyTemplate checks if a template job's deployment is due to be destroyed by its
+// destroy_after or a time-based destroy schedule
+func (m *Manager) shouldDestroyTemplate(job *Job, now time.Time) bool {
+	if job.JobType != JobTypeTemplate || !job.HasLifecycle() {
+		return false
+	}
+	jobState := m.stateManager.GetJobState(job.WorkspaceID, job.Name)
+	if jobState.Status == JobStatusRunning || m.isDelayed(job) {
+		return false
+	}
+	return job.destroyDue(jobState.Deployment, now)
+}
+
+// shouldDestroyTemplateForEvent checks if a deployment event destroys a template job's deployment
+func (m *Manager) shouldDestroyTemplateForEvent(job *Job, event DeploymentEvent) bool {
+	if job.JobType != JobTypeTemplate || job.DestroySchedule == nil || job.WorkspaceID != event.GetWorkspaceID() {
+		return false
+	}
+	jobState := m.stateManager.GetJobState(job.WorkspaceID, job.Name)
+	if jobState.Status == JobStatusRunning || jobState.Deployment == nil || !jobState.Deployment.IsActive() {
+		return false
+	}
+	return job.destroyMatchesEvent(event)
+}
+
+// templateOutputs converts a deployment's outputs to text, masking sensitive values
+func templateOutputs(outputs map[string]opentofu.OutputValue) map[string]string {
+	if len(outputs) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(outputs))
+	for name, output := range outputs {
+		if output.Sensitive {
+			values[name] = "(sensitive)"
+		} else {
+			values[name] = output.String()
+		}
+	}
+	return values
+}
+
+// formatOutputs lists outputs as "name = value" lines sorted by name
+func formatOutputs(outputs map[string]string) string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	text := ""
+	for _, name := range names {
+		text += fmt.Sprintf("%s = %s\n", name, outputs[name])
+	}
+	return text
+}
+
+// updateTemplateDeployment records a template job's deploy in its deployment state. Resources may
+// be left by a failed deploy, so the lifecycle destroys those as well.
+func (sm *StateManager) updateTemplateDeployment(job *Job, execution *JobExecution) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	jobState := sm.jobState(job.WorkspaceID, job.Name)
+	if jobState == nil {
+		return
+	}
+
+	deployedAt := time.Now()
+	if execution.EndTime != nil {
+		deployedAt = *execution.EndTime
+	}
+	deployment := &TemplateDeployment{
+		Template:   job.Template,
+		Status:     DeploymentStatusDeployed,
+		DeployedAt: &deployedAt,
+		Outputs:    execution.Outputs,
+	}
+	if execution.Status != JobStatusSuccess {
+		deployment.Status = DeploymentStatusFailed
+		deployment.LastError = execution.Error
+		if jobState.Deployment != nil && deployment.Outputs == nil {
+			deployment.Outputs = jobState.Deployment.Outputs
+		}
+	}
+	if destroyAfter, err := job.GetDestroyAfter(); err == nil && destroyAfter > 0 {
+		destroyAt := deployedAt.Add(destroyAfter)
+		deployment.DestroyAt = &destroyAt
+	}
+
+	jobState.Deployment = deployment
+	sm.setJobState(job.WorkspaceID, job.Name, jobState)
+}
+
+// DestroyTemplateDeployment destroys the deployment of a template job. The job is running while
+// its deployment is destroyed, and the destroy is kept in the job's history.
+func (m *Manager) DestroyTemplateDeployment(job *Job, trigger audit.Trigger) *JobExecution {
+	previousStatus := m.stateManager.GetJobState(job.WorkspaceID, job.Name).Status
+	m.stateManager.SetJobStatus(job.WorkspaceID, job.Name, JobStatusRunning)
+	return m.destroyTemplateDeployment(job, trigger, previousStatus)
+}
+
+// destroyTemplateDeploymentAsync destroys a template job's deployment in the background
+func (m *Manager) destroyTemplateDeploymentAsync(job *Job, trigger audit.Trigger, reason string) {
+	logging.LogWorkspace(job.WorkspaceID, "JOB %s: Destroying template deployment (%s)", job.Name, reason)
+
+	// Mark the job running before returning so the next check doesn't start the destroy again
+	previousStatus := m.stateManager.GetJobState(job.WorkspaceID, job.Name).Status
+	m.stateManager.SetJobStatus(job.WorkspaceID, job.Name, JobStatusRunning)
+	go m.destroyTemplateDeployment(job, trigger, previousStatus)
+}
+
+// destroyTemplateDeployment destroys a template job's deployment, restoring the job's status after
+func (m *Manager) destroyTemplateDeployment(job *Job, trigger audit.Trigger, previousStatus JobStatus) *JobExecution {
+	if err := m.stateManager.SaveState(); err != nil {
+		logging.LogWorkspace(job.WorkspaceID, "Failed to save job state: %v", err)
+	}
+
+	executor := NewExecutor(filepath.Join(m.stateDir, "deployments", job.WorkspaceID), m.tofuClient, m.templateManager)
+	execution := executor.DestroyTemplate(job)
+	execution.RunID = NewRunID()
+	execution.Attempts = 1
+
+	jobState := m.stateManager.GetJobState(job.WorkspaceID, job.Name)
+	jobState.Status = previousStatus
+	if jobState.Deployment == nil {
+		jobState.Deployment = &TemplateDeployment{Template: job.Template}
+	}
+	if execution.Status == JobStatusSuccess {
+		jobState.Deployment.Status = DeploymentStatusDestroyed
+		jobState.Deployment.DestroyedAt = execution.EndTime
+		jobState.Deployment.Outputs = nil
+		jobState.Deployment.LastError = ""
+	} else {
+		jobState.Deployment.Status = DeploymentStatusDestroyFailed
+		jobState.Deployment.LastError = execution.Error
+	}
+
+	entry := newHistoryEntry(execution)
+	entry.Operation = HistoryOperationDestroy
+	jobState.addHistoryEntry(entry)
+	m.stateManager.SetJobState(job.WorkspaceID, job.Name, jobState)
+	if err := m.stateManager.SaveState(); err != nil {
+		logging.LogWorkspace(job.WorkspaceID, "Failed to save job state after destroy: %v", err)
+	}
+
+	m.auditDestroy(job, execution, trigger)
+	return execution
+}
+
+// auditDestroy records the destroy of a template job's deployment in the audit log
+func (m *Manager) auditDestroy(job *Job, execution *JobExecution, trigger audit.Trigger) {
+	outcome := audit.OutcomeFailed
+	if execution.Status == JobStatusSuccess {
+		outcome = audit.OutcomeSucceeded
+	}
+
+	audit.NewLog(m.stateDir).Record(audit.Entry{
+		Operation:     audit.OperationJobDestroy,
+		Workspace:     auditWorkspace(job.WorkspaceID),
+		Job:           job.Name,
+		Trigger:       trigger,
+		Outcome:       outcome,
+		Error:         execution.Error,
+		CorrelationID: execution.CorrelationID,
+	})
+}
@@ -0,0 +1,111 @@
+package job
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/opentofu"
+)
+
+func TestTemplateJobLifecycle(t *testing.T) {
+	mockClient := &opentofu.MockTofuClient{
+		OutputFunc: func(string) (map[string]opentofu.OutputValue, error) {
+			return map[string]opentofu.OutputValue{
+				"url":   {Value: json.RawMessage(`"https://preview.example.com"`)},
+				"token": {Sensitive: true, Value: json.RawMessage(`"secret"`)},
+			}, nil
+		},
+	}
+	manager := newTestManager(t, mockClient)
+	templateDir := filepath.Join(manager.stateDir, "templates", "preview")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "main.tf"), []byte("output \"url\" {\n  value = \"https://preview.example.com\"\n}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	job, err := JobConfigToJob("test-workspace", map[string]interface{}{
+		"name":          "preview-env",
+		"type":          "template",
+		"template":      "preview",
+		"destroy_after": "1h",
+	})
+	if err != nil {
+		t.Fatalf("JobConfigToJob failed: %v", err)
+	}
+
+	if execution := manager.ExecuteJob(job); execution.Status != JobStatusSuccess {
+		t.Fatalf("Expected the template to deploy, got %s: %s", execution.Status, execution.Error)
+	}
+
+	deployment := manager.GetJobState("test-workspace", "preview-env").Deployment
+	if deployment == nil || deployment.Status != DeploymentStatusDeployed || deployment.DestroyAt == nil {
+		t.Fatalf("Expected a deployed deployment with a destroy time, got %+v", deployment)
+	}
+	if deployment.Outputs["url"] != "https://preview.example.com" || deployment.Outputs["token"] != "(sensitive)" {
+		t.Errorf("Expected the outputs with the sensitive one masked, got %v", deployment.Outputs)
+	}
+
+	if manager.shouldDestroyTemplate(job, time.Now()) {
+		t.Error("Expected the deployment to be kept before destroy_after passed")
+	}
+	if !manager.shouldDestroyTemplate(job, time.Now().Add(2*time.Hour)) {
+		t.Fatal("Expected the deployment to be destroyed after destroy_after passed")
+	}
+
+	if execution := manager.DestroyTemplateDeployment(job, audit.Trigger{Source: audit.SourceSchedule}); execution.Status != JobStatusSuccess {
+		t.Fatalf("Expected the deployment to be destroyed, got %s: %s", execution.Status, execution.Error)
+	}
+	if mockClient.DestroyDirCallCount != 1 {
+		t.Errorf("Expected one destroy, got %d", mockClient.DestroyDirCallCount)
+	}
+
+	state := manager.GetJobState("test-workspace", "preview-env")
+	if state.Status != JobStatusSuccess || state.Deployment.Status != DeploymentStatusDestroyed || state.Deployment.Outputs != nil {
+		t.Errorf("Expected a destroyed deployment and the job's status restored, got %s and %+v", state.Status, state.Deployment)
+	}
+	if history := manager.GetHistory("test-workspace", "preview-env"); len(history) != 2 || history[0].Operation != HistoryOperationDestroy {
+		t.Errorf("Expected the destroy in the job's history, got %+v", history)
+	}
+	if manager.shouldDestroyTemplate(job, time.Now().Add(2*time.Hour)) {
+		t.Error("Expected a destroyed deployment not to be destroyed again")
+	}
+}
+
+func TestTemplateDestroySchedule(t *testing.T) {
+	job := &Job{Name: "preview-env", WorkspaceID: "test-workspace", JobType: JobTypeTemplate, Template: "preview", DestroySchedule: []string{"0 19 * * *", "@destroy"}}
+	deployedAt := time.Date(2025, 9, 27, 8, 0, 0, 0, time.Local)
+	deployment := &TemplateDeployment{Status: DeploymentStatusDeployed, DeployedAt: &deployedAt}
+
+	if job.destroyDue(deployment, deployedAt.Add(10*time.Hour)) {
+		t.Error("Expected the deployment to be kept before the destroy schedule fired")
+	}
+	if !job.destroyDue(deployment, deployedAt.Add(11*time.Hour+time.Minute)) {
+		t.Error("Expected the deployment to be destroyed after the destroy schedule fired")
+	}
+
+	if !job.destroyMatchesEvent(NewSimpleDeploymentEvent("destroy-completed", "test-workspace")) {
+		t.Error("Expected @destroy to match the workspace's destroy")
+	}
+	if job.destroyMatchesEvent(NewSimpleDeploymentEvent("deployment-completed", "test-workspace")) {
+		t.Error("Expected @destroy not to match a deploy")
+	}
+}
+
+func TestTemplateLifecycleValidation(t *testing.T) {
+	for _, config := range []map[string]interface{}{
+		{"name": "cleanup", "type": "command", "command": "true", "destroy_after": "1h"},
+		{"name": "preview-env", "type": "template", "template": "preview", "destroy_after": "soon"},
+		{"name": "preview-env", "type": "template", "template": "preview", "destroy_after": "-1h"},
+		{"name": "preview-env", "type": "template", "template": "preview", "destroy_schedule": "not a schedule"},
+	} {
+		if _, err := JobConfigToJob("test-workspace", config); err == nil {
+			t.Errorf("Expected error for %v", config)
+		}
+	}
+}
@@ -0,0 +1,286 @@
+// Package lint checks OpenTofu configurations for deprecated and risky syntax that
+// `tofu validate` accepts: provider version arguments, archived providers, missing
+// required_version constraints and module sources that are not pinned to a version.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Finding severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Rules reported by the linter
+const (
+	RuleDeprecatedProviderVersion = "deprecated-provider-version"
+	RuleDeprecatedProvider        = "deprecated-provider"
+	RuleMissingRequiredVersion    = "missing-required-version"
+	RuleUnpinnedModuleSource      = "unpinned-module-source"
+	RuleValidate                  = "validate" // Diagnostics of tofu validate
+)
+
+// Rules lists every rule that can be skipped in a workspace's lint configuration
+var Rules = []string{
+	RuleDeprecatedProviderVersion,
+	RuleDeprecatedProvider,
+	RuleMissingRequiredVersion,
+	RuleUnpinnedModuleSource,
+	RuleValidate,
+}
+
+// deprecatedProviders maps archived provider sources to their replacement
+var deprecatedProviders = map[string]string{
+	"hashicorp/template": "use the built-in templatefile() function or the cloudinit provider",
+}
+
+// Finding is a single problem found in a configuration
+type Finding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+	Message  string `json:"message"`
+}
+
+// String formats the finding as "file:line: severity: message [rule]"
+func (f Finding) String() string {
+	location := f.File
+	if location != "" && f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, f.Line)
+	}
+	if location != "" {
+		location += ": "
+	}
+	return fmt.Sprintf("%s%s: %s [%s]", location, f.Severity, f.Message, f.Rule)
+}
+
+// Report holds the findings of linting one configuration
+type Report struct {
+	Findings []Finding `json:"findings"`
+}
+
+// Add appends findings, dropping those of skipped rules
+func (r *Report) Add(findings []Finding, skip []string) {
+	for _, finding := range findings {
+		if !contains(skip, finding.Rule) {
+			r.Findings = append(r.Findings, finding)
+		}
+	}
+}
+
+// Count returns the number of findings with a severity
+func (r *Report) Count(severity string) int {
+	count := 0
+	for _, finding := range r.Findings {
+		if finding.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed reports whether the findings should stop a deploy: any error, or any warning with failOnWarnings
+func (r *Report) Failed(failOnWarnings bool) bool {
+	return r.Count(SeverityError) > 0 || (failOnWarnings && r.Count(SeverityWarning) > 0)
+}
+
+// Sort orders findings by file and line
+func (r *Report) Sort() {
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		if r.Findings[i].File != r.Findings[j].File {
+			return r.Findings[i].File < r.Findings[j].File
+		}
+		return r.Findings[i].Line < r.Findings[j].Line
+	})
+}
+
+// ValidateSkip checks that skipped rules exist
+func ValidateSkip(skip []string) error {
+	for _, rule := range skip {
+		if !contains(Rules, rule) {
+			return fmt.Errorf("unknown lint rule '%s' (valid: %s)", rule, strings.Join(Rules, ", "))
+		}
+	}
+	return nil
+}
+
+// CheckDir runs the custom checks on the .tf files of a configuration directory
+func CheckDir(dir string) ([]Finding, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no .tf files found in %s", dir)
+	}
+	sort.Strings(paths)
+
+	var findings []Finding
+	hasRequiredVersion := false
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+
+		fileFindings, requiredVersion := checkFile(filepath.Base(path), parse(data))
+		findings = append(findings, fileFindings...)
+		hasRequiredVersion = hasRequiredVersion || requiredVersion
+	}
+
+	if !hasRequiredVersion {
+		findings = append(findings, Finding{
+			Rule:     RuleMissingRequiredVersion,
+			Severity: SeverityWarning,
+			Message:  "no terraform block sets required_version; pin the OpenTofu versions the configuration supports",
+		})
+	}
+
+	return findings, nil
+}
+
+// ModuleSource is the source of a module block in a configuration file
+type ModuleSource struct {
+	File   string
+	Line   int
+	Module string
+	Source string
+}
+
+// ModuleSources returns the sources of the module blocks in the .tf files directly in dir
+func ModuleSources(dir string) ([]ModuleSource, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var sources []ModuleSource
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", filepath.Base(path), err)
+		}
+		for _, b := range parse(data).children("module") {
+			if source := b.attrs["source"]; source != nil && source.str != "" {
+				sources = append(sources, ModuleSource{File: filepath.Base(path), Line: source.line, Module: b.label(0), Source: source.str})
+			}
+		}
+	}
+	return sources, nil
+}
+
+// checkFile checks the top-level blocks of one file, reporting whether it sets required_version
+func checkFile(file string, body *block) ([]Finding, bool) {
+	var findings []Finding
+	hasRequiredVersion := false
+
+	add := func(rule string, line int, format string, args ...interface{}) {
+		findings = append(findings, Finding{Rule: rule, Severity: SeverityWarning, File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	for _, b := range body.blocks {
+		switch b.typ {
+		case "terraform":
+			if _, ok := b.attrs["required_version"]; ok {
+				hasRequiredVersion = true
+			}
+			for _, providers := range b.children("required_providers") {
+				for name, attr := range providers.attrs {
+					if attr.object == nil {
+						continue
+					}
+					if source := attr.object.attrs["source"]; source != nil {
+						if hint, ok := deprecatedProviders[strings.ToLower(source.str)]; ok {
+							add(RuleDeprecatedProvider, source.line, "provider %q uses the archived %s provider; %s", name, source.str, hint)
+						}
+					}
+				}
+			}
+
+		case "provider":
+			name := b.label(0)
+			if version := b.attrs["version"]; version != nil {
+				add(RuleDeprecatedProviderVersion, version.line, "provider %q sets version in the provider block; move the constraint to terraform { required_providers }", name)
+			}
+			if hint, ok := deprecatedProviders["hashicorp/"+name]; ok {
+				add(RuleDeprecatedProvider, b.line, "provider %q is archived; %s", name, hint)
+			}
+
+		case "resource", "data":
+			providerName, _, _ := strings.Cut(b.label(0), "_")
+			if hint, ok := deprecatedProviders["hashicorp/"+providerName]; ok {
+				add(RuleDeprecatedProvider, b.line, "%s %q uses the archived %s provider; %s", b.typ, b.label(0), providerName, hint)
+			}
+
+		case "module":
+			source := b.attrs["source"]
+			if source == nil {
+				continue
+			}
+			if reason := unpinnedReason(source.str, b.attrs["version"] != nil); reason != "" {
+				add(RuleUnpinnedModuleSource, source.line, "module %q %s", b.label(0), reason)
+			}
+		}
+	}
+
+	return findings, hasRequiredVersion
+}
+
+// unpinnedReason explains why a module source isn't pinned, or returns "" if it is
+func unpinnedReason(source string, hasVersion bool) string {
+	switch {
+	case source == "":
+		return ""
+	case strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../"):
+		// Local modules are versioned with the configuration
+		return ""
+	case isGitSource(source):
+		if strings.Contains(source, "?ref=") || strings.Contains(source, "&ref=") {
+			return ""
+		}
+		return "uses a git source without ?ref=; pin it to a tag or commit"
+	case strings.Contains(source, "://") || strings.Contains(source, "::"):
+		// Archives and other remote sources carry their version in the URL
+		return ""
+	case isRegistrySource(source):
+		if hasVersion {
+			return ""
+		}
+		return "uses a registry source without a version constraint"
+	}
+	return ""
+}
+
+// isGitSource reports whether a module source is fetched with git
+func isGitSource(source string) bool {
+	return strings.HasPrefix(source, "git::") ||
+		strings.HasPrefix(source, "git@") ||
+		strings.HasPrefix(source, "github.com/") ||
+		strings.HasPrefix(source, "bitbucket.org/") ||
+		strings.Contains(source, ".git?") ||
+		strings.HasSuffix(source, ".git")
+}
+
+// isRegistrySource reports whether a module source is a registry address:
+// NAMESPACE/NAME/PROVIDER with an optional hostname
+func isRegistrySource(source string) bool {
+	parts := strings.Split(strings.SplitN(source, "//", 2)[0], "/")
+	return len(parts) == 3 || (len(parts) == 4 && strings.Contains(parts[0], "."))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
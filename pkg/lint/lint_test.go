@@ -0,0 +1,166 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func rulesAt(findings []Finding) map[string][]int {
+	result := make(map[string][]int)
+	for _, finding := range findings {
+		result[finding.Rule] = append(result[finding.Rule], finding.Line)
+	}
+	return result
+}
+
+func TestCheckDir(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.tf": `# provider "template" {} in a comment is ignored
+provider "aws" {
+  region  = "eu-west-1"
+  version = "~> 4.0"
+}
+
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+
+module "pinned_vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.1.0"
+}
+
+module "net" {
+  source = "git::https://example.com/net.git"
+}
+
+module "pinned_net" {
+  source = "git::https://example.com/net.git?ref=v1.2.0"
+}
+
+module "local" {
+  source = "./modules/local"
+}
+
+resource "aws_instance" "web" {
+  tags = { Name = "web-${var.name}" }
+  user_data = <<-EOT
+    #!/bin/sh
+    echo "provider \"template\" {" }
+  EOT
+}
+
+data "template_file" "init" {
+  template = file("init.tpl")
+}
+`,
+	})
+
+	findings, err := CheckDir(dir)
+	if err != nil {
+		t.Fatalf("CheckDir failed: %v", err)
+	}
+
+	rules := rulesAt(findings)
+	expected := map[string][]int{
+		RuleDeprecatedProviderVersion: {4},
+		RuleUnpinnedModuleSource:      {8, 17},
+		RuleDeprecatedProvider:        {36},
+		RuleMissingRequiredVersion:    {0},
+	}
+	for rule, lines := range expected {
+		if len(rules[rule]) != len(lines) {
+			t.Errorf("Expected %s at lines %v, got %v", rule, lines, rules[rule])
+			continue
+		}
+		for i := range lines {
+			if rules[rule][i] != lines[i] {
+				t.Errorf("Expected %s at lines %v, got %v", rule, lines, rules[rule])
+			}
+		}
+	}
+	if len(findings) != 5 {
+		t.Errorf("Expected 5 findings, got %d: %v", len(findings), findings)
+	}
+}
+
+func TestCheckDirRequiredProviders(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"versions.tf": `terraform {
+  required_version = ">= 1.6"
+
+  required_providers {
+    aws = {
+      source  = "hashicorp/aws"
+      version = "~> 5.0"
+    }
+    template = { source = "hashicorp/template", version = "2.2.0" }
+  }
+}
+`,
+		"main.tf": `provider "aws" {
+  region = "eu-west-1"
+}
+`,
+	})
+
+	findings, err := CheckDir(dir)
+	if err != nil {
+		t.Fatalf("CheckDir failed: %v", err)
+	}
+	if len(findings) != 1 || findings[0].Rule != RuleDeprecatedProvider || findings[0].File != "versions.tf" || findings[0].Line != 9 {
+		t.Errorf("Expected only the archived template provider at versions.tf:9, got %v", findings)
+	}
+}
+
+func TestCheckDirWithoutConfiguration(t *testing.T) {
+	if _, err := CheckDir(t.TempDir()); err == nil {
+		t.Error("Expected error for a directory without .tf files")
+	}
+}
+
+func TestReport(t *testing.T) {
+	report := &Report{}
+	report.Add([]Finding{
+		{Rule: RuleValidate, Severity: SeverityWarning, File: "main.tf", Line: 9},
+		{Rule: RuleMissingRequiredVersion, Severity: SeverityWarning},
+		{Rule: RuleUnpinnedModuleSource, Severity: SeverityWarning, File: "main.tf", Line: 2, Message: `module "net" is not pinned`},
+	}, []string{RuleMissingRequiredVersion})
+	report.Sort()
+
+	if len(report.Findings) != 2 || report.Findings[0].Line != 2 {
+		t.Errorf("Expected skipped rule dropped and findings sorted, got %v", report.Findings)
+	}
+	if report.Failed(false) || !report.Failed(true) {
+		t.Error("Expected warnings to fail only with failOnWarnings")
+	}
+
+	report.Add([]Finding{{Rule: RuleValidate, Severity: SeverityError, Message: "Unsupported argument"}}, nil)
+	if !report.Failed(false) {
+		t.Error("Expected errors to fail")
+	}
+	if got := report.Findings[0].String(); got != `main.tf:2: warning: module "net" is not pinned [unpinned-module-source]` {
+		t.Errorf("Unexpected formatting: %q", got)
+	}
+}
+
+func TestValidateSkip(t *testing.T) {
+	if err := ValidateSkip([]string{RuleMissingRequiredVersion, RuleValidate}); err != nil {
+		t.Errorf("Expected known rules to be valid, got %v", err)
+	}
+	if err := ValidateSkip([]string{"no-such-rule"}); err == nil {
+		t.Error("Expected error for unknown rule")
+	}
+}
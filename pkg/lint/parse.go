@@ -0,0 +1,322 @@
+package lint
+
+import (
+	"strings"
+)
+
+// The parser below reads just enough HCL to find blocks, their labels and attributes
+// holding string literals or objects. Expressions are skipped, not evaluated.
+
+// block is a parsed HCL block; the file body is a block without a type
+type block struct {
+	typ    string
+	labels []string
+	line   int
+	attrs  map[string]*attribute
+	blocks []*block
+}
+
+// attribute is a parsed attribute: str is set for string literals, object for object values
+type attribute struct {
+	line   int
+	str    string
+	object *block
+}
+
+func (b *block) label(i int) string {
+	if i < len(b.labels) {
+		return b.labels[i]
+	}
+	return ""
+}
+
+// children returns the nested blocks of a type
+func (b *block) children(typ string) []*block {
+	var result []*block
+	for _, child := range b.blocks {
+		if child.typ == typ {
+			result = append(result, child)
+		}
+	}
+	return result
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenString
+	tokenNewline
+	tokenPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+// tokenize splits HCL source into words, strings, newlines and punctuation, dropping comments
+func tokenize(src []byte) []token {
+	s := string(src)
+	var tokens []token
+	line := 1
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == '\n':
+			tokens = append(tokens, token{tokenNewline, "\n", line})
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case c == '#' || strings.HasPrefix(s[i:], "//"):
+			for i < len(s) && s[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(s[i:], "/*"):
+			end := strings.Index(s[i+2:], "*/")
+			if end < 0 {
+				end = len(s) - i - 2
+			}
+			line += strings.Count(s[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			value, next := readString(s, i+1)
+			tokens = append(tokens, token{tokenString, value, line})
+			line += strings.Count(s[i:next], "\n")
+			i = next
+		case strings.HasPrefix(s[i:], "<<"):
+			start := line
+			value, next := readHeredoc(s, i+2)
+			if next < 0 {
+				// Not a heredoc after all
+				tokens = append(tokens, token{tokenPunct, "<", line})
+				i++
+				continue
+			}
+			tokens = append(tokens, token{tokenString, value, start})
+			line += strings.Count(s[i:next], "\n")
+			i = next
+		case strings.ContainsRune("{}()[],=", rune(c)):
+			// Comparison and lambda operators are not assignments
+			if c == '=' && i+1 < len(s) && (s[i+1] == '=' || s[i+1] == '>') {
+				tokens = append(tokens, token{tokenPunct, s[i : i+2], line})
+				i += 2
+				continue
+			}
+			tokens = append(tokens, token{tokenPunct, string(c), line})
+			i++
+		case isWordChar(c):
+			start := i
+			for i < len(s) && isWordChar(s[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenWord, s[start:i], line})
+		default:
+			// Operators; a trailing '=' belongs to them (!=, <=, >=)
+			end := i + 1
+			if end < len(s) && s[end] == '=' {
+				end++
+			}
+			tokens = append(tokens, token{tokenPunct, s[i:end], line})
+			i = end
+		}
+	}
+
+	return tokens
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || c == '*' ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// readString reads a quoted string starting after the opening quote, including
+// interpolations with nested strings, returning its raw content and the index after it
+func readString(s string, i int) (string, int) {
+	start := i
+	depth := 0
+	for i < len(s) {
+		switch {
+		case s[i] == '\\':
+			i += 2
+			continue
+		case strings.HasPrefix(s[i:], "${") || strings.HasPrefix(s[i:], "%{"):
+			depth++
+			i += 2
+			continue
+		case depth > 0 && s[i] == '}':
+			depth--
+		case depth > 0 && s[i] == '"':
+			_, i = readString(s, i+1)
+			continue
+		case depth == 0 && s[i] == '"':
+			return s[start:i], i + 1
+		case depth == 0 && s[i] == '\n':
+			// Unterminated string
+			return s[start:i], i
+		}
+		i++
+	}
+	return s[start:], len(s)
+}
+
+// readHeredoc reads a heredoc starting after "<<", returning its content and the index after
+// the closing marker, or -1 if the text is not a heredoc
+func readHeredoc(s string, i int) (string, int) {
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	start := i
+	for i < len(s) && isWordChar(s[i]) {
+		i++
+	}
+	marker := s[start:i]
+	if marker == "" || i >= len(s) || (s[i] != '\n' && s[i] != '\r') {
+		return "", -1
+	}
+
+	lineStart := strings.IndexByte(s[i:], '\n') + i + 1
+	contentStart := lineStart
+	for lineStart < len(s) {
+		lineEnd := strings.IndexByte(s[lineStart:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(s)
+		} else {
+			lineEnd += lineStart
+		}
+		if strings.TrimSpace(s[lineStart:lineEnd]) == marker {
+			return s[contentStart:lineStart], lineEnd
+		}
+		lineStart = lineEnd + 1
+	}
+	return s[contentStart:], len(s)
+}
+
+// parser builds blocks from tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// parse reads the blocks and attributes of an HCL file
+func parse(src []byte) *block {
+	p := &parser{tokens: tokenize(src)}
+	return p.body(1)
+}
+
+func (p *parser) peek() *token {
+	if p.pos < len(p.tokens) {
+		return &p.tokens[p.pos]
+	}
+	return nil
+}
+
+func (p *parser) isPunct(text string) bool {
+	t := p.peek()
+	return t != nil && t.kind == tokenPunct && t.text == text
+}
+
+// body parses statements until a closing brace or the end of input
+func (p *parser) body(line int) *block {
+	b := &block{line: line, attrs: make(map[string]*attribute)}
+
+	for {
+		t := p.peek()
+		if t == nil {
+			return b
+		}
+		if t.kind == tokenNewline || p.isPunct(",") {
+			p.pos++
+			continue
+		}
+		if p.isPunct("}") {
+			p.pos++
+			return b
+		}
+		if t.kind != tokenWord && t.kind != tokenString {
+			p.skipStatement()
+			continue
+		}
+
+		name := t.text
+		p.pos++
+
+		if p.isPunct("=") || p.isPunct(":") {
+			p.pos++
+			b.attrs[name] = p.value(t.line)
+			continue
+		}
+
+		// Block: type, labels, then an opening brace
+		var labels []string
+		for next := p.peek(); next != nil && (next.kind == tokenString || next.kind == tokenWord); next = p.peek() {
+			labels = append(labels, next.text)
+			p.pos++
+		}
+		if !p.isPunct("{") {
+			p.skipStatement()
+			continue
+		}
+		p.pos++
+		child := p.body(t.line)
+		child.typ = name
+		child.labels = labels
+		b.blocks = append(b.blocks, child)
+	}
+}
+
+// value parses an attribute value: objects are parsed as bodies, string literals are kept,
+// and any other expression is skipped up to the end of the statement
+func (p *parser) value(line int) *attribute {
+	attr := &attribute{line: line}
+
+	if p.isPunct("{") {
+		p.pos++
+		attr.object = p.body(line)
+		return attr
+	}
+
+	if t := p.peek(); t != nil && t.kind == tokenString {
+		attr.str = t.text
+		if next := p.pos + 1; next >= len(p.tokens) || p.tokens[next].kind == tokenNewline ||
+			(p.tokens[next].kind == tokenPunct && (p.tokens[next].text == "}" || p.tokens[next].text == ",")) {
+			p.pos++
+			return attr
+		}
+		// Part of a larger expression
+		attr.str = ""
+	}
+
+	p.skipStatement()
+	return attr
+}
+
+// skipStatement skips tokens up to the end of the statement, stopping before a closing
+// brace of the enclosing body
+func (p *parser) skipStatement() {
+	depth := 0
+	for t := p.peek(); t != nil; t = p.peek() {
+		if t.kind == tokenPunct {
+			switch t.text {
+			case "{", "(", "[":
+				depth++
+			case "}", ")", "]":
+				if depth == 0 {
+					return
+				}
+				depth--
+			case ",":
+				if depth == 0 {
+					return
+				}
+			}
+		}
+		if t.kind == tokenNewline && depth == 0 {
+			return
+		}
+		p.pos++
+	}
+}
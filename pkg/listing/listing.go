@@ -0,0 +1,298 @@
+// Package listing filters, sorts and pages the entries of CLI list commands, so fleets
+// with hundreds of workspaces or jobs stay navigable in a terminal.
+package listing
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Options select, order and page the entries of a list command
+type Options struct {
+	Filters    []Filter // All filters must match
+	Sort       string   // Field to sort by, empty keeps the default order
+	Descending bool     // Sort in descending order ("--sort -FIELD")
+	Limit      int      // Entries per page, 0 shows all
+	Page       int      // 1-based page number, 0 means the first page
+	Columns    []string // Table columns ("--columns a,b"), empty shows the command's default table
+}
+
+// Operator is how a filter compares a field with its pattern
+type Operator string
+
+const (
+	Match    Operator = ""   // FIELD=PATTERN, the field matches the shell-style glob pattern
+	Equal    Operator = "==" // FIELD==VALUE, the field is the value
+	NotEqual Operator = "!=" // FIELD!=VALUE, the field is not the value
+)
+
+// Filter matches a field against a value or shell-style glob pattern, e.g. status=deployed,
+// name=web-* or status!=deployed
+type Filter struct {
+	Field   string
+	Pattern string
+	Op      Operator
+}
+
+// Entry is a list entry exposing its fields by name. Time fields return FormatField values
+// so they sort chronologically; an empty value means the field is not set.
+type Entry interface {
+	ListField(name string) string
+}
+
+// TableEntry is an entry that can be printed with the columns of --columns. TableField returns
+// a field for display, e.g. times in the display timezone.
+type TableEntry interface {
+	Entry
+	TableField(name string) string
+}
+
+// Result is one page of filtered and sorted entries
+type Result[E Entry] struct {
+	Entries []E
+	Total   int // Entries before filtering
+	Matched int // Entries matching the filters
+	Offset  int // Matching entries before this page
+	Page    int
+	Pages   int
+}
+
+// FormatField formats a time for ListField so that string order is chronological
+func FormatField(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// fieldName normalizes a field name given on the command line; next_run is next-run
+func fieldName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// parseFilter parses FIELD=PATTERN, FIELD==VALUE or FIELD!=VALUE
+func parseFilter(value string) (Filter, error) {
+	i := strings.IndexAny(value, "=!")
+	if i <= 0 {
+		return Filter{}, fmt.Errorf("invalid filter '%s' (expected FIELD=PATTERN, FIELD==VALUE or FIELD!=VALUE)", value)
+	}
+	filter := Filter{Field: fieldName(strings.TrimSpace(value[:i]))}
+	rest := value[i:]
+	switch {
+	case strings.HasPrefix(rest, "=="):
+		filter.Op, filter.Pattern = Equal, rest[2:]
+	case strings.HasPrefix(rest, "!="):
+		filter.Op, filter.Pattern = NotEqual, rest[2:]
+	case strings.HasPrefix(rest, "="):
+		filter.Pattern = rest[1:]
+		if _, err := path.Match(filter.Pattern, ""); err != nil {
+			return Filter{}, fmt.Errorf("invalid filter pattern '%s': %w", filter.Pattern, err)
+		}
+	default:
+		return Filter{}, fmt.Errorf("invalid filter '%s' (expected FIELD=PATTERN, FIELD==VALUE or FIELD!=VALUE)", value)
+	}
+	return filter, nil
+}
+
+// ParseArgs extracts --filter, --sort, --limit, --page and --columns from a command's arguments,
+// returning the options and the remaining arguments
+func ParseArgs(args []string) (Options, []string, error) {
+	var opts Options
+	var remaining []string
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--filter", "--sort", "--limit", "--page", "--columns":
+		default:
+			remaining = append(remaining, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("%s requires a value", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		switch name {
+		case "--filter":
+			filter, err := parseFilter(value)
+			if err != nil {
+				return opts, nil, err
+			}
+			opts.Filters = append(opts.Filters, filter)
+		case "--sort":
+			opts.Sort, opts.Descending = strings.CutPrefix(value, "-")
+			opts.Sort = fieldName(opts.Sort)
+		case "--columns":
+			for _, column := range strings.Split(value, ",") {
+				if column = strings.TrimSpace(column); column != "" {
+					opts.Columns = append(opts.Columns, fieldName(column))
+				}
+			}
+			if len(opts.Columns) == 0 {
+				return opts, nil, fmt.Errorf("--columns requires at least one field")
+			}
+		case "--limit", "--page":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 {
+				return opts, nil, fmt.Errorf("%s must be a positive number: %s", name, value)
+			}
+			if name == "--limit" {
+				opts.Limit = n
+			} else {
+				opts.Page = n
+			}
+		}
+	}
+
+	if opts.Page > 0 && opts.Limit == 0 {
+		return opts, nil, fmt.Errorf("--page requires --limit")
+	}
+	return opts, remaining, nil
+}
+
+// Validate checks that filters, sorting and columns only use the given fields
+func (o Options) Validate(fields []string) error {
+	known := func(field string) bool {
+		for _, f := range fields {
+			if f == field {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, filter := range o.Filters {
+		if !known(filter.Field) {
+			return fmt.Errorf("unknown filter field '%s' (available: %s)", filter.Field, strings.Join(fields, ", "))
+		}
+	}
+	if o.Sort != "" && !known(o.Sort) {
+		return fmt.Errorf("unknown sort field '%s' (available: %s)", o.Sort, strings.Join(fields, ", "))
+	}
+	for _, column := range o.Columns {
+		if !known(column) {
+			return fmt.Errorf("unknown column '%s' (available: %s)", column, strings.Join(fields, ", "))
+		}
+	}
+	return nil
+}
+
+// Apply filters, sorts and pages entries. Entries keep their order when no sort field is
+// given or their sort values are equal; entries without a value sort last either way.
+func Apply[E Entry](entries []E, opts Options) Result[E] {
+	result := Result[E]{Total: len(entries), Page: 1, Pages: 1}
+
+	matched := make([]E, 0, len(entries))
+	for _, entry := range entries {
+		if opts.matches(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	result.Matched = len(matched)
+
+	if opts.Sort != "" {
+		sort.SliceStable(matched, func(i, j int) bool {
+			a, b := matched[i].ListField(opts.Sort), matched[j].ListField(opts.Sort)
+			if a == "" || b == "" {
+				return a != "" && b == ""
+			}
+			if opts.Descending {
+				return a > b
+			}
+			return a < b
+		})
+	}
+
+	if opts.Limit > 0 {
+		result.Pages = max(1, (len(matched)+opts.Limit-1)/opts.Limit)
+		result.Page = max(1, opts.Page)
+		result.Offset = min((result.Page-1)*opts.Limit, len(matched))
+		matched = matched[result.Offset:min(result.Offset+opts.Limit, len(matched))]
+	}
+
+	result.Entries = matched
+	return result
+}
+
+// matches returns true if the entry matches every filter; values compare case-insensitively
+func (o Options) matches(entry Entry) bool {
+	for _, filter := range o.Filters {
+		value := strings.ToLower(entry.ListField(filter.Field))
+		pattern := strings.ToLower(filter.Pattern)
+		var ok bool
+		switch filter.Op {
+		case Equal:
+			ok = value == pattern
+		case NotEqual:
+			ok = value != pattern
+		default:
+			ok, _ = path.Match(pattern, value)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteColumns writes entries as a table of the given columns, "-" marking fields that are not set
+func WriteColumns[E TableEntry](out io.Writer, entries []E, columns []string) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		headers[i] = strings.ToUpper(strings.ReplaceAll(column, "-", " "))
+	}
+	if _, err := fmt.Fprintln(w, strings.Join(headers, "\t")); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		values := make([]string, len(columns))
+		for i, column := range columns {
+			if values[i] = entry.TableField(column); values[i] == "" {
+				values[i] = "-"
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(values, "\t")); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// Summary describes which entries are shown, e.g. "Showing 21-40 of 57 matching (312 total), page 2 of 3".
+// It returns "" when all entries are shown.
+func (r Result[E]) Summary() string {
+	shown := len(r.Entries)
+	if shown == r.Total {
+		return ""
+	}
+
+	var summary string
+	switch {
+	case shown == 0 && r.Matched > 0:
+		return fmt.Sprintf("No entries on page %d, %d matching entries fit on %d pages", r.Page, r.Matched, r.Pages)
+	case shown < r.Matched:
+		summary = fmt.Sprintf("Showing %d-%d of %d", r.Offset+1, r.Offset+shown, r.Matched)
+	default:
+		summary = fmt.Sprintf("Showing %d", shown)
+	}
+	if r.Matched != r.Total {
+		summary += fmt.Sprintf(" matching (%d total)", r.Total)
+	}
+	if r.Pages > 1 {
+		summary += fmt.Sprintf(", page %d of %d", r.Page, r.Pages)
+	}
+	return summary
+}
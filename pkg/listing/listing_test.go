@@ -0,0 +1,201 @@
+package listing
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testEntry map[string]string
+
+func (e testEntry) ListField(name string) string {
+	return e[name]
+}
+
+func names(entries []testEntry) []string {
+	result := make([]string, len(entries))
+	for i, entry := range entries {
+		result[i] = entry["name"]
+	}
+	return result
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestParseArgs(t *testing.T) {
+	opts, rest, err := ParseArgs([]string{"--detailed", "--filter", "status=deployed", "--filter=name=web-*", "--sort", "-next-run", "--limit=20", "--page", "2"})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	if !equal(rest, []string{"--detailed"}) {
+		t.Errorf("Expected other arguments to be kept, got %v", rest)
+	}
+	if len(opts.Filters) != 2 || opts.Filters[0] != (Filter{"status", "deployed", Match}) || opts.Filters[1] != (Filter{"name", "web-*", Match}) {
+		t.Errorf("Unexpected filters: %+v", opts.Filters)
+	}
+	if opts.Sort != "next-run" || !opts.Descending || opts.Limit != 20 || opts.Page != 2 {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	invalid := [][]string{
+		{"--filter", "status"},
+		{"--filter", "name=[web"},
+		{"--limit", "0"},
+		{"--limit", "many"},
+		{"--page", "2"},
+		{"--sort"},
+	}
+	for _, args := range invalid {
+		if _, _, err := ParseArgs(args); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	fields := []string{"name", "status"}
+	if err := (Options{Filters: []Filter{{"status", "deployed", Match}}, Sort: "name"}).Validate(fields); err != nil {
+		t.Errorf("Expected known fields to be valid, got %v", err)
+	}
+	if err := (Options{Filters: []Filter{{"owner", "me", Match}}}).Validate(fields); err == nil {
+		t.Error("Expected error for unknown filter field")
+	}
+	if err := (Options{Sort: "owner"}).Validate(fields); err == nil {
+		t.Error("Expected error for unknown sort field")
+	}
+	if err := (Options{Columns: []string{"name", "owner"}}).Validate(fields); err == nil {
+		t.Error("Expected error for unknown column")
+	}
+}
+
+func TestParseArgsOperatorsAndColumns(t *testing.T) {
+	opts, _, err := ParseArgs([]string{"--filter", "status==deploy_failed", "--filter=tier!=prod", "--sort", "-next_run", "--columns", "name, status,next_run"})
+	if err != nil {
+		t.Fatalf("ParseArgs failed: %v", err)
+	}
+	expected := []Filter{{"status", "deploy_failed", Equal}, {"tier", "prod", NotEqual}}
+	if len(opts.Filters) != 2 || opts.Filters[0] != expected[0] || opts.Filters[1] != expected[1] {
+		t.Errorf("Unexpected filters: %+v", opts.Filters)
+	}
+	if opts.Sort != "next-run" || !equal(opts.Columns, []string{"name", "status", "next-run"}) {
+		t.Errorf("Expected field names with underscores to be normalized, got %+v", opts)
+	}
+
+	for _, args := range [][]string{{"--filter", "==x"}, {"--filter", "status!x"}, {"--columns", " , "}} {
+		if _, _, err := ParseArgs(args); err == nil {
+			t.Errorf("Expected error for %v", args)
+		}
+	}
+}
+
+func TestApplyOperators(t *testing.T) {
+	entries := []testEntry{
+		{"name": "web", "status": "deploy_failed"},
+		{"name": "web-*", "status": "Deployed"},
+		{"name": "db", "status": "deployed"},
+	}
+
+	if got := names(Apply(entries, Options{Filters: []Filter{{"name", "web-*", Equal}}}).Entries); !equal(got, []string{"web-*"}) {
+		t.Errorf("Expected == to compare literally, got %v", got)
+	}
+	if got := names(Apply(entries, Options{Filters: []Filter{{"status", "deployed", NotEqual}}}).Entries); !equal(got, []string{"web"}) {
+		t.Errorf("Expected != to exclude case-insensitive matches, got %v", got)
+	}
+}
+
+type tableEntry map[string]string
+
+func (e tableEntry) ListField(name string) string  { return e[name] }
+func (e tableEntry) TableField(name string) string { return strings.ToUpper(e[name]) }
+
+func TestWriteColumns(t *testing.T) {
+	var out bytes.Buffer
+	entries := []tableEntry{{"name": "web", "next-run": "soon"}, {"name": "database"}}
+	if err := WriteColumns(&out, entries, []string{"name", "next-run"}); err != nil {
+		t.Fatalf("WriteColumns failed: %v", err)
+	}
+	expected := "NAME      NEXT RUN\nWEB       SOON\nDATABASE  -\n"
+	if out.String() != expected {
+		t.Errorf("Expected table:\n%s\ngot:\n%s", expected, out.String())
+	}
+}
+
+func TestApply(t *testing.T) {
+	entries := []testEntry{
+		{"name": "web-b", "status": "deployed", "next-run": "2026-01-02T09:00:00Z"},
+		{"name": "db", "status": "destroyed", "next-run": "2026-01-01T09:00:00Z"},
+		{"name": "web-a", "status": "Deployed"},
+		{"name": "web-c", "status": "deployed", "next-run": "2026-01-01T18:00:00Z"},
+	}
+
+	result := Apply(entries, Options{Filters: []Filter{{"status", "deployed", Match}, {"name", "WEB-*", Match}}})
+	if got := names(result.Entries); !equal(got, []string{"web-b", "web-a", "web-c"}) {
+		t.Errorf("Expected case-insensitive filtering in original order, got %v", got)
+	}
+	if result.Total != 4 || result.Matched != 3 {
+		t.Errorf("Expected 3 of 4 matching, got %d of %d", result.Matched, result.Total)
+	}
+
+	// Entries without a value sort last in both directions
+	if got := names(Apply(entries, Options{Sort: "next-run"}).Entries); !equal(got, []string{"db", "web-c", "web-b", "web-a"}) {
+		t.Errorf("Unexpected ascending order: %v", got)
+	}
+	if got := names(Apply(entries, Options{Sort: "next-run", Descending: true}).Entries); !equal(got, []string{"web-b", "web-c", "db", "web-a"}) {
+		t.Errorf("Unexpected descending order: %v", got)
+	}
+
+	paged := Apply(entries, Options{Sort: "name", Limit: 3, Page: 2})
+	if got := names(paged.Entries); !equal(got, []string{"web-c"}) || paged.Page != 2 || paged.Pages != 2 || paged.Offset != 3 {
+		t.Errorf("Unexpected second page: %v (page %d of %d, offset %d)", got, paged.Page, paged.Pages, paged.Offset)
+	}
+	if beyond := Apply(entries, Options{Limit: 3, Page: 5}); len(beyond.Entries) != 0 {
+		t.Errorf("Expected no entries beyond the last page, got %v", names(beyond.Entries))
+	}
+}
+
+func TestSummary(t *testing.T) {
+	entries := make([]testEntry, 57)
+	for i := range entries {
+		entries[i] = testEntry{"name": "ws", "status": "deployed"}
+	}
+	for i := 0; i < 5; i++ {
+		entries = append(entries, testEntry{"name": "ws", "status": "destroyed"})
+	}
+
+	tests := []struct {
+		opts     Options
+		expected string
+	}{
+		{Options{}, ""},
+		{Options{Filters: []Filter{{"status", "destroyed", Match}}}, "Showing 5 matching (62 total)"},
+		{Options{Filters: []Filter{{"status", "deployed", Match}}, Limit: 20, Page: 2}, "Showing 21-40 of 57 matching (62 total), page 2 of 3"},
+		{Options{Limit: 50}, "Showing 1-50 of 62, page 1 of 2"},
+		{Options{Limit: 50, Page: 4}, "No entries on page 4, 62 matching entries fit on 2 pages"},
+	}
+	for _, test := range tests {
+		if got := Apply(entries, test.opts).Summary(); got != test.expected {
+			t.Errorf("Summary for %+v: expected %q, got %q", test.opts, test.expected, got)
+		}
+	}
+}
+
+func TestFormatField(t *testing.T) {
+	if FormatField(nil) != "" {
+		t.Error("Expected empty value for nil time")
+	}
+	local := time.Date(2026, 1, 1, 9, 0, 0, 0, time.FixedZone("CET", 3600))
+	if got := FormatField(&local); got != "2026-01-01T08:00:00Z" {
+		t.Errorf("Expected UTC RFC3339 value, got %q", got)
+	}
+}
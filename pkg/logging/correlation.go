@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// CorrelationIDEnv is the environment variable carrying the correlation ID into job and OpenTofu processes
+const CorrelationIDEnv = "PROVISIONER_CORRELATION_ID"
+
+var (
+	correlationIDs = make(map[string]string)
+	correlationMu  sync.RWMutex
+)
+
+// NewCorrelationID returns an ID of the form 20250310T090000Z-3fa2c1.
+// Operations started by the same schedule tick share the timestamp, so one
+// wave can be found with a single grep while each operation stays unique.
+func NewCorrelationID(t time.Time) string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		// Fall back to the clock; uniqueness within a wave is best effort
+		nanos := time.Now().UnixNano()
+		suffix = []byte{byte(nanos >> 16), byte(nanos >> 8), byte(nanos)}
+	}
+	return t.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// SetCorrelationID sets the correlation ID of a workspace's current operation.
+// Workspace log lines are tagged with it until it is cleared.
+func SetCorrelationID(workspaceName, id string) {
+	correlationMu.Lock()
+	defer correlationMu.Unlock()
+	correlationIDs[workspaceName] = id
+}
+
+// CorrelationID returns the correlation ID of a workspace's current operation, or ""
+func CorrelationID(workspaceName string) string {
+	correlationMu.RLock()
+	defer correlationMu.RUnlock()
+	return correlationIDs[workspaceName]
+}
+
+// ClearCorrelationID removes a workspace's correlation ID once its operation has finished
+func ClearCorrelationID(workspaceName string) {
+	correlationMu.Lock()
+	defer correlationMu.Unlock()
+	delete(correlationIDs, workspaceName)
+}
+
+// withCorrelation prefixes a workspace log message with the active correlation ID
+func withCorrelation(workspaceName, message string) string {
+	if id := CorrelationID(workspaceName); id != "" {
+		return "[" + id + "] " + message
+	}
+	return message
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewCorrelationID(t *testing.T) {
+	at := time.Date(2025, 3, 10, 10, 0, 0, 0, time.FixedZone("CET", 3600))
+	id := NewCorrelationID(at)
+
+	if !regexp.MustCompile(`^20250310T090000Z-[0-9a-f]{6}$`).MatchString(id) {
+		t.Errorf("unexpected correlation ID format: %s", id)
+	}
+	if other := NewCorrelationID(at); other == id {
+		t.Errorf("expected unique IDs for the same time, got %s twice", id)
+	}
+}
+
+func TestWorkspaceLogsTaggedWithCorrelationID(t *testing.T) {
+	logDir := t.TempDir()
+	t.Setenv("PROVISIONER_LOG_DIR", logDir)
+	ResetSingleton()
+	defer ResetSingleton()
+
+	LogWorkspace("tagged", "before operation")
+	SetCorrelationID("tagged", "20250310T090000Z-abc123")
+	LogWorkspaceOperation("tagged", "DEPLOY", "Starting deployment")
+	ClearCorrelationID("tagged")
+	LogWorkspace("tagged", "after operation")
+	GetLogger().Close()
+
+	data, err := os.ReadFile(filepath.Join(logDir, "tagged.log"))
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 log lines, got %d: %q", len(lines), lines)
+	}
+	if strings.Contains(lines[0], "20250310T090000Z") || strings.Contains(lines[2], "20250310T090000Z") {
+		t.Errorf("Expected only the operation line to be tagged: %q", lines)
+	}
+	if !strings.HasSuffix(lines[1], "DEPLOY: [20250310T090000Z-abc123] Starting deployment") {
+		t.Errorf("Expected tagged operation line, got %q", lines[1])
+	}
+}
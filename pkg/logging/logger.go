@@ -7,6 +7,9 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
+
+	"provisioner/pkg/paths"
 )
 
 // Logger handles both systemd and per-workspace file logging
@@ -70,7 +73,7 @@ func (l *Logger) getWorkspaceLogger(workspaceName string) *log.Logger {
 				file, err = os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 				if err == nil {
 					// Success after creating directory
-					logger := log.New(file, "", log.LstdFlags)
+					logger := newWorkspaceFileLogger(file)
 					l.workspaceLoggers[workspaceName] = logger
 					return logger
 				}
@@ -87,19 +90,24 @@ func (l *Logger) getWorkspaceLogger(workspaceName string) *log.Logger {
 	}
 
 	// Create logger with timestamp for file output
-	logger := log.New(file, "", log.LstdFlags)
+	logger := newWorkspaceFileLogger(file)
 	l.workspaceLoggers[workspaceName] = logger
 	return logger
 }
 
+// newWorkspaceFileLogger creates a logger stamping entries with the display timezone and offset
+func newWorkspaceFileLogger(file *os.File) *log.Logger {
+	return log.New(&timestampWriter{out: file, now: time.Now}, "", 0)
+}
+
 // LogSystemd logs to systemd/journalctl (no timestamp)
 func (l *Logger) LogSystemd(format string, v ...interface{}) {
-	l.systemdLogger.Printf(format, v...)
+	l.systemdLogger.Print(Redact(fmt.Sprintf(format, v...)))
 }
 
 // LogWorkspace logs to both systemd and workspace-specific file
 func (l *Logger) LogWorkspace(workspaceName, format string, v ...interface{}) {
-	message := fmt.Sprintf(format, v...)
+	message := withCorrelation(workspaceName, RedactWorkspace(workspaceName, fmt.Sprintf(format, v...)))
 
 	// Log to systemd (no timestamp)
 	l.systemdLogger.Printf("[%s] %s", workspaceName, message)
@@ -111,7 +119,7 @@ func (l *Logger) LogWorkspace(workspaceName, format string, v ...interface{}) {
 
 // LogWorkspaceOperation logs deployment/destruction operations
 func (l *Logger) LogWorkspaceOperation(workspaceName, operation, format string, v ...interface{}) {
-	message := fmt.Sprintf(format, v...)
+	message := withCorrelation(workspaceName, RedactWorkspace(workspaceName, fmt.Sprintf(format, v...)))
 
 	// Log to systemd (no timestamp)
 	l.systemdLogger.Printf("[%s] %s: %s", workspaceName, operation, message)
@@ -123,7 +131,7 @@ func (l *Logger) LogWorkspaceOperation(workspaceName, operation, format string,
 
 // LogWorkspaceOnly logs only to workspace file (not systemd)
 func (l *Logger) LogWorkspaceOnly(workspaceName, format string, v ...interface{}) {
-	message := fmt.Sprintf(format, v...)
+	message := withCorrelation(workspaceName, RedactWorkspace(workspaceName, fmt.Sprintf(format, v...)))
 
 	// Log only to workspace file (with timestamp)
 	workspaceLogger := l.getWorkspaceLogger(workspaceName)
@@ -169,24 +177,12 @@ func ResetSingleton() {
 	once = sync.Once{}
 }
 
-// getLogDir determines the log directory using auto-discovery
+// getLogDir determines the log directory like paths.LogDir, creating the system log directory on
+// the first run after installation
 func getLogDir() string {
-	// First check workspace variable (explicit override)
-	if logDir := os.Getenv("PROVISIONER_LOG_DIR"); logDir != "" {
-		return logDir
-	}
-
-	// Auto-detect system installation by checking if /var/log/provisioner exists or can be created
-	systemLogDir := "/var/log/provisioner"
-	if _, err := os.Stat(systemLogDir); err == nil {
-		return systemLogDir
-	}
-
-	// Try to create system log directory (in case this is first run after installation)
-	if err := os.MkdirAll(systemLogDir, 0755); err == nil {
-		return systemLogDir
+	if os.Getenv("PROVISIONER_LOG_DIR") == "" {
+		// Only succeeds with the permissions of an installed daemon
+		_ = os.MkdirAll(paths.SystemLogDir, 0755)
 	}
-
-	// Fall back to development default
-	return "logs"
+	return paths.LogDir()
 }
@@ -0,0 +1,215 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"provisioner/pkg/paths"
+)
+
+// RedactionMask replaces any text matched by a redaction pattern
+const RedactionMask = "[REDACTED]"
+
+// Redactor masks sensitive values in text before it is logged or displayed
+type Redactor interface {
+	Redact(text string) string
+}
+
+// PatternRedactor masks every match of a set of regular expressions.
+// If a pattern has a capture group named "secret", only that group is masked.
+type PatternRedactor struct {
+	patterns []*regexp.Regexp
+}
+
+// RedactionConfig is the global redaction configuration (redaction.json in the config directory)
+type RedactionConfig struct {
+	Patterns        []string `json:"patterns,omitempty"`         // Extra regular expressions to mask
+	RedactIPs       bool     `json:"redact_ips,omitempty"`       // Mask IPv4 addresses
+	DisableDefaults bool     `json:"disable_defaults,omitempty"` // Skip the built-in secret patterns
+}
+
+// defaultRedactionPatterns match common credential formats
+var defaultRedactionPatterns = []string{
+	`dop_v1_[a-f0-9]{64}`,          // DigitalOcean personal access tokens
+	`AKIA[0-9A-Z]{16}`,             // AWS access key IDs
+	`gh[pousr]_[A-Za-z0-9]{36,}`,   // GitHub tokens
+	`xox[abprs]-[A-Za-z0-9-]{10,}`, // Slack tokens
+	`(?i)bearer\s+(?P<secret>[A-Za-z0-9._~+/=-]{8,})`,
+	`(?i)(?:password|passwd|secret|token|api[_-]?key|access[_-]?key)\s*[=:]\s*"?(?P<secret>[^\s"',;]+)`,
+	`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`,
+}
+
+// ipv4Pattern matches dotted-quad IPv4 addresses
+const ipv4Pattern = `\b(?:\d{1,3}\.){3}\d{1,3}\b`
+
+// NewPatternRedactor compiles the given patterns into a redactor
+func NewPatternRedactor(patterns []string) (*PatternRedactor, error) {
+	r := &PatternRedactor{}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern '%s': %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Redact masks all pattern matches in text
+func (r *PatternRedactor) Redact(text string) string {
+	for _, re := range r.patterns {
+		secretIndex := re.SubexpIndex("secret")
+		if secretIndex < 0 {
+			text = re.ReplaceAllString(text, RedactionMask)
+			continue
+		}
+
+		// Only mask the secret part, keeping the key name for context
+		text = re.ReplaceAllStringFunc(text, func(match string) string {
+			loc := re.FindStringSubmatchIndex(match)
+			start, end := loc[2*secretIndex], loc[2*secretIndex+1]
+			if start < 0 {
+				return match
+			}
+			return match[:start] + RedactionMask + match[end:]
+		})
+	}
+	return text
+}
+
+// redactionRegistry holds the global redactors and per-workspace extras
+type redactionRegistry struct {
+	mu         sync.RWMutex
+	loaded     bool
+	global     []Redactor
+	workspaces map[string]Redactor
+}
+
+var redaction = &redactionRegistry{workspaces: make(map[string]Redactor)}
+
+// RegisterRedactor adds a redactor applied to all log and status output
+func RegisterRedactor(r Redactor) {
+	redaction.ensureLoaded()
+
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+	redaction.global = append(redaction.global, r)
+}
+
+// SetWorkspaceRedactPatterns sets extra redaction patterns for a single workspace
+func SetWorkspaceRedactPatterns(workspaceName string, patterns []string) error {
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+
+	if len(patterns) == 0 {
+		delete(redaction.workspaces, workspaceName)
+		return nil
+	}
+
+	r, err := NewPatternRedactor(patterns)
+	if err != nil {
+		return err
+	}
+	redaction.workspaces[workspaceName] = r
+	return nil
+}
+
+// Redact masks sensitive values using the global redactors
+func Redact(text string) string {
+	return RedactWorkspace("", text)
+}
+
+// RedactWorkspace masks sensitive values using the global and workspace-specific redactors
+func RedactWorkspace(workspaceName, text string) string {
+	if text == "" {
+		return text
+	}
+
+	redaction.ensureLoaded()
+
+	redaction.mu.RLock()
+	defer redaction.mu.RUnlock()
+
+	for _, r := range redaction.global {
+		text = r.Redact(text)
+	}
+	if r, exists := redaction.workspaces[workspaceName]; exists {
+		text = r.Redact(text)
+	}
+	return text
+}
+
+// ResetRedaction clears all redactors and reloads configuration on next use (for testing only)
+func ResetRedaction() {
+	redaction.mu.Lock()
+	defer redaction.mu.Unlock()
+
+	redaction.loaded = false
+	redaction.global = nil
+	redaction.workspaces = make(map[string]Redactor)
+}
+
+// ensureLoaded builds the global redactors from the defaults and redaction.json
+func (reg *redactionRegistry) ensureLoaded() {
+	reg.mu.RLock()
+	loaded := reg.loaded
+	reg.mu.RUnlock()
+	if loaded {
+		return
+	}
+
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.loaded {
+		return
+	}
+	reg.loaded = true
+
+	config, err := LoadRedactionConfig(filepath.Join(paths.ConfigDir(), "redaction.json"))
+	if err != nil {
+		// Logging is not ready yet, report directly to stderr
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		config = &RedactionConfig{}
+	}
+
+	var patterns []string
+	if !config.DisableDefaults {
+		patterns = append(patterns, defaultRedactionPatterns...)
+	}
+	if config.RedactIPs {
+		patterns = append(patterns, ipv4Pattern)
+	}
+
+	// Compile configured patterns individually so one bad pattern does not disable the rest
+	patterns = append(patterns, config.Patterns...)
+	for _, pattern := range patterns {
+		r, err := NewPatternRedactor([]string{pattern})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			continue
+		}
+		reg.global = append(reg.global, r)
+	}
+}
+
+// LoadRedactionConfig loads redaction settings, returning an empty config if the file doesn't exist
+func LoadRedactionConfig(configPath string) (*RedactionConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RedactionConfig{}, nil
+		}
+		return nil, fmt.Errorf("failed to read redaction config: %w", err)
+	}
+
+	var config RedactionConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse redaction config: %w", err)
+	}
+
+	return &config, nil
+}
@@ -0,0 +1,132 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"provisioner/pkg/paths"
+)
+
+const (
+	// TimestampLayout is used for timestamps rendered by the CLIs
+	TimestampLayout = "2006-01-02 15:04:05 -0700"
+	// TimestampLayoutShort is used where seconds are not meaningful (schedules, next runs)
+	TimestampLayoutShort = "2006-01-02 15:04 -0700"
+	// LogTimestampLayout prefixes every entry in workspace log files
+	LogTimestampLayout = "2006/01/02 15:04:05 -0700"
+	// LegacyLogTimestampLayout is the log.LstdFlags prefix written before offsets were included
+	LegacyLogTimestampLayout = "2006/01/02 15:04:05"
+
+	// DisplayTimezoneEnv overrides the display_timezone setting of provisioner.json
+	DisplayTimezoneEnv = "PROVISIONER_DISPLAY_TIMEZONE"
+)
+
+var (
+	displayMu       sync.RWMutex
+	displayLocation = time.Local
+)
+
+// SetDisplayLocation sets the timezone timestamps are rendered in
+func SetDisplayLocation(loc *time.Location) {
+	if loc == nil {
+		loc = time.Local
+	}
+	displayMu.Lock()
+	defer displayMu.Unlock()
+	displayLocation = loc
+}
+
+// DisplayLocation returns the timezone timestamps are rendered in
+func DisplayLocation() *time.Location {
+	displayMu.RLock()
+	defer displayMu.RUnlock()
+	return displayLocation
+}
+
+// LoadDisplayTimezone resolves a display timezone name; "local" and "" mean the server's timezone
+func LoadDisplayTimezone(name string) (*time.Location, error) {
+	switch name {
+	case "", "local", "Local":
+		return time.Local, nil
+	case "utc", "UTC":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid display timezone '%s': %w", name, err)
+	}
+	return loc, nil
+}
+
+// ConfigureDisplayTimezone selects the display timezone: UTC if utc is set (the --utc flag),
+// otherwise PROVISIONER_DISPLAY_TIMEZONE, otherwise display_timezone in provisioner.json,
+// otherwise the server's local timezone. An invalid setting leaves local time in place.
+func ConfigureDisplayTimezone(utc bool) error {
+	if utc {
+		SetDisplayLocation(time.UTC)
+		return nil
+	}
+
+	name := os.Getenv(DisplayTimezoneEnv)
+	if name == "" {
+		name = loadConfiguredTimezone(filepath.Join(paths.ConfigDir(), "provisioner.json"))
+	}
+
+	loc, err := LoadDisplayTimezone(name)
+	if err != nil {
+		SetDisplayLocation(time.Local)
+		return err
+	}
+	SetDisplayLocation(loc)
+	return nil
+}
+
+// loadConfiguredTimezone reads display_timezone from the daemon config, ignoring a missing or invalid file
+func loadConfiguredTimezone(configPath string) string {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return ""
+	}
+
+	var config struct {
+		DisplayTimezone string `json:"display_timezone"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ""
+	}
+	return config.DisplayTimezone
+}
+
+// FormatTime renders a timestamp in the display timezone including its UTC offset
+func FormatTime(t time.Time) string {
+	return t.In(DisplayLocation()).Format(TimestampLayout)
+}
+
+// FormatTimeShort renders a timestamp without seconds in the display timezone including its UTC offset
+func FormatTimeShort(t time.Time) string {
+	return t.In(DisplayLocation()).Format(TimestampLayoutShort)
+}
+
+// timestampWriter prefixes each log entry with the current time in the display timezone
+type timestampWriter struct {
+	mu  sync.Mutex
+	out io.Writer
+	now func() time.Time
+}
+
+// Write writes one log entry; log.Logger calls Write once per entry
+func (w *timestampWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	prefix := w.now().In(DisplayLocation()).Format(LogTimestampLayout) + " "
+	if _, err := w.out.Write(append([]byte(prefix), p...)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,87 @@
+package logging
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func setupDisplayTimezone(t *testing.T, config string) {
+	t.Helper()
+
+	configDir := t.TempDir()
+	if config != "" {
+		if err := os.WriteFile(filepath.Join(configDir, "provisioner.json"), []byte(config), 0644); err != nil {
+			t.Fatalf("Failed to write daemon config: %v", err)
+		}
+	}
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv(DisplayTimezoneEnv, "")
+
+	t.Cleanup(func() { SetDisplayLocation(time.Local) })
+}
+
+func TestFormatTimeIncludesOffset(t *testing.T) {
+	setupDisplayTimezone(t, "")
+
+	ts := time.Date(2026, 3, 10, 12, 30, 45, 0, time.UTC)
+	SetDisplayLocation(time.FixedZone("UTC+2", 2*60*60))
+
+	if got := FormatTime(ts); got != "2026-03-10 14:30:45 +0200" {
+		t.Errorf("Expected time in display timezone with offset, got %q", got)
+	}
+	if got := FormatTimeShort(ts); got != "2026-03-10 14:30 +0200" {
+		t.Errorf("Expected short time in display timezone with offset, got %q", got)
+	}
+}
+
+func TestConfigureDisplayTimezone(t *testing.T) {
+	setupDisplayTimezone(t, `{"display_timezone": "Europe/Berlin"}`)
+
+	if err := ConfigureDisplayTimezone(false); err != nil {
+		t.Fatalf("ConfigureDisplayTimezone failed: %v", err)
+	}
+	if DisplayLocation().String() != "Europe/Berlin" {
+		t.Errorf("Expected display_timezone from provisioner.json, got %s", DisplayLocation())
+	}
+
+	t.Setenv(DisplayTimezoneEnv, "America/New_York")
+	if err := ConfigureDisplayTimezone(false); err != nil {
+		t.Fatalf("ConfigureDisplayTimezone failed: %v", err)
+	}
+	if DisplayLocation().String() != "America/New_York" {
+		t.Errorf("Expected %s to override provisioner.json, got %s", DisplayTimezoneEnv, DisplayLocation())
+	}
+
+	if err := ConfigureDisplayTimezone(true); err != nil {
+		t.Fatalf("ConfigureDisplayTimezone failed: %v", err)
+	}
+	if DisplayLocation() != time.UTC {
+		t.Errorf("Expected --utc to override all settings, got %s", DisplayLocation())
+	}
+
+	t.Setenv(DisplayTimezoneEnv, "Mars/Olympus_Mons")
+	if err := ConfigureDisplayTimezone(false); err == nil {
+		t.Error("Expected error for unknown timezone")
+	}
+	if DisplayLocation() != time.Local {
+		t.Errorf("Expected local time after invalid setting, got %s", DisplayLocation())
+	}
+}
+
+func TestWorkspaceLogTimestamps(t *testing.T) {
+	setupDisplayTimezone(t, "")
+	SetDisplayLocation(time.UTC)
+
+	var buf bytes.Buffer
+	now := time.Date(2026, 3, 10, 12, 30, 45, 0, time.FixedZone("UTC-5", -5*60*60))
+	logger := log.New(&timestampWriter{out: &buf, now: func() time.Time { return now }}, "", 0)
+	logger.Printf("Starting deployment")
+
+	if buf.String() != "2026/03/10 17:30:45 +0000 Starting deployment\n" {
+		t.Errorf("Expected entry stamped in display timezone with offset, got %q", buf.String())
+	}
+}
@@ -0,0 +1,123 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/scheduler"
+)
+
+// ContentType is the Prometheus text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Server exposes the scheduler's success rates for Prometheus to scrape
+type Server struct {
+	sched      *scheduler.Scheduler
+	httpServer *http.Server
+}
+
+// NewServer creates a metrics server for the scheduler listening on addr
+func NewServer(sched *scheduler.Scheduler, addr string) *Server {
+	s := &Server{sched: sched}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler (for testing)
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// Start listens for scrapes in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for metrics scrapes: %w", err)
+	}
+
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logging.LogSystemd("Metrics server stopped: %v", err)
+		}
+	}()
+
+	logging.LogSystemd("Metrics listener on %s", listener.Addr())
+	return nil
+}
+
+// Close stops the metrics server
+func (s *Server) Close() error {
+	return s.httpServer.Close()
+}
+
+// handleMetrics writes the current success rates
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", ContentType)
+	WriteSuccessRates(w, s.sched.SuccessRateReports(time.Now()))
+}
+
+// WriteSuccessRates writes success rates and their objectives in the Prometheus text format.
+// Ratios are only written for rates with runs, objectives only where one is set.
+func WriteSuccessRates(w io.Writer, reports []scheduler.SuccessRateReport) {
+	gauges := []struct {
+		name, help string
+		value      func(scheduler.SuccessRateReport) (float64, bool)
+	}{
+		{"provisioner_success_rate_runs", "Runs counted by the success rate of a deploy or job.",
+			func(r scheduler.SuccessRateReport) (float64, bool) { return float64(r.Rate.Runs), true }},
+		{"provisioner_success_rate_succeeded", "Successful runs counted by the success rate of a deploy or job.",
+			func(r scheduler.SuccessRateReport) (float64, bool) { return float64(r.Rate.Succeeded), true }},
+		{"provisioner_success_rate_ratio", "Share of successful runs within the SLO window.",
+			func(r scheduler.SuccessRateReport) (float64, bool) { return r.Rate.Percent / 100, r.Rate.Runs > 0 }},
+		{"provisioner_slo_objective_ratio", "Minimum share of successful runs set by the SLO.",
+			func(r scheduler.SuccessRateReport) (float64, bool) { return r.Objective / 100, r.Objective > 0 }},
+		{"provisioner_slo_breached", "Whether the success rate is below its objective.",
+			func(r scheduler.SuccessRateReport) (float64, bool) { return boolValue(r.Breached), r.Objective > 0 }},
+	}
+
+	for _, gauge := range gauges {
+		_, _ = fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", gauge.name, gauge.help, gauge.name)
+		for _, report := range reports {
+			if value, ok := gauge.value(report); ok {
+				_, _ = fmt.Fprintf(w, "%s%s %g\n", gauge.name, labels(report), value)
+			}
+		}
+	}
+}
+
+// labels returns the label set identifying a success rate
+func labels(report scheduler.SuccessRateReport) string {
+	operation := scheduler.OperationDeploy
+	if report.Job != "" {
+		operation = "job"
+	}
+	return fmt.Sprintf(`{workspace="%s",operation="%s",job="%s"}`,
+		escapeLabel(report.Workspace), operation, escapeLabel(report.Job))
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabel escapes a label value for the text format
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/slo"
+	"provisioner/pkg/workspace"
+)
+
+func TestMetricsExposeSuccessRates(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_STATE_DIR", filepath.Join(tempDir, "state"))
+	t.Setenv("PROVISIONER_LOG_DIR", filepath.Join(tempDir, "logs"))
+
+	workspaceDir := filepath.Join(configDir, "workspaces", "web")
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		t.Fatalf("Failed to create workspace dir: %v", err)
+	}
+	config := `{"enabled": true, "deploy_schedule": "0 9 * * *", "destroy_schedule": "0 17 * * *", "slo": {"deploy_success_rate": 90}}`
+	if err := os.WriteFile(filepath.Join(workspaceDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workspaceDir, "main.tf"), []byte("# test\n"), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+
+	mockClient := opentofu.NewMockTofuClient()
+	sched := scheduler.NewWithClient(mockClient)
+	if err := sched.LoadWorkspaces(); err != nil {
+		t.Fatalf("Failed to load workspaces: %v", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	// One failed and one successful deploy
+	mockClient.DeployFunc = func(*workspace.Workspace) error { return errors.New("apply failed") }
+	if err := sched.ManualDeploy("web"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+	mockClient.DeployFunc = nil
+	if err := sched.ManualDeploy("web"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	NewServer(sched, "127.0.0.1:0").Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if recorder.Code != http.StatusOK || recorder.Header().Get("Content-Type") != ContentType {
+		t.Fatalf("Unexpected response %d %q", recorder.Code, recorder.Header().Get("Content-Type"))
+	}
+
+	body := recorder.Body.String()
+	for _, expected := range []string{
+		`provisioner_success_rate_runs{workspace="web",operation="deploy",job=""} 2`,
+		`provisioner_success_rate_ratio{workspace="web",operation="deploy",job=""} 0.5`,
+		`provisioner_slo_objective_ratio{workspace="web",operation="deploy",job=""} 0.9`,
+		`provisioner_slo_breached{workspace="web",operation="deploy",job=""} 1`,
+	} {
+		if !strings.Contains(body, expected) {
+			t.Errorf("Expected %q in metrics, got:\n%s", expected, body)
+		}
+	}
+}
+
+func TestWriteSuccessRatesSkipsMissingValues(t *testing.T) {
+	var out strings.Builder
+	WriteSuccessRates(&out, []scheduler.SuccessRateReport{
+		{Job: `clean"up`, Rate: slo.Rate{}},
+	})
+
+	body := out.String()
+	if !strings.Contains(body, `provisioner_success_rate_runs{workspace="",operation="job",job="clean\"up"} 0`) {
+		t.Errorf("Expected the standalone job's run count with an escaped label, got:\n%s", body)
+	}
+	for _, name := range []string{"provisioner_success_rate_ratio{", "provisioner_slo_objective_ratio{", "provisioner_slo_breached{"} {
+		if strings.Contains(body, name) {
+			t.Errorf("Expected no %s sample without runs or objective, got:\n%s", name, body)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package notify
+
+import (
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultSMTPPort is the SMTP submission port used when an email destination doesn't specify one
+const DefaultSMTPPort = 587
+
+// defaultEmailSubject is the subject template of email notifications
+const defaultEmailSubject = `[provisioner] {{.Text}}`
+
+// EmailConfig configures email notifications sent through an SMTP server
+type EmailConfig struct {
+	Subscription
+	SMTPHost    string   `json:"smtp_host"`
+	SMTPPort    int      `json:"smtp_port,omitempty"` // Default 587; STARTTLS is used when the server offers it
+	Username    string   `json:"username,omitempty"`  // Authenticate with PLAIN auth when set
+	Password    string   `json:"password,omitempty"`
+	PasswordEnv string   `json:"password_env,omitempty"` // Environment variable holding the password
+	From        string   `json:"from"`
+	To          []string `json:"to"`
+	Subject     string   `json:"subject,omitempty"`   // Subject template, default "[provisioner] {{.Text}}"
+	LogLines    int      `json:"log_lines,omitempty"` // Lines of log excerpt (default 20, -1 disables)
+}
+
+// validate checks the email destination settings
+func (c EmailConfig) validate() error {
+	if c.SMTPHost == "" {
+		return fmt.Errorf("smtp_host is required")
+	}
+	if c.From == "" {
+		return fmt.Errorf("from is required")
+	}
+	if len(c.To) == 0 {
+		return fmt.Errorf("at least one recipient in 'to' is required")
+	}
+	if c.SMTPPort < 0 || c.SMTPPort > 65535 {
+		return fmt.Errorf("invalid smtp_port: %d", c.SMTPPort)
+	}
+	if c.Password != "" && c.PasswordEnv != "" {
+		return fmt.Errorf("cannot specify both 'password' and 'password_env'")
+	}
+	if c.Subject != "" {
+		if _, err := parseTemplate("email subject", c.Subject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// password returns the SMTP password from the config or its environment variable
+func (c EmailConfig) password() string {
+	if c.PasswordEnv != "" {
+		return os.Getenv(c.PasswordEnv)
+	}
+	return c.Password
+}
+
+// emailSender sends notifications as plain text emails
+type emailSender struct {
+	config EmailConfig
+}
+
+// Send delivers the notification to all recipients
+func (e *emailSender) Send(notification Notification) error {
+	port := e.config.SMTPPort
+	if port == 0 {
+		port = DefaultSMTPPort
+	}
+	addr := net.JoinHostPort(e.config.SMTPHost, strconv.Itoa(port))
+
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return err
+	}
+	_ = conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	client, err := smtp.NewClient(conn, e.config.SMTPHost)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer func() { _ = client.Close() }()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: e.config.SMTPHost}); err != nil {
+			return fmt.Errorf("STARTTLS failed: %w", err)
+		}
+	}
+	if e.config.Username != "" {
+		auth := smtp.PlainAuth("", e.config.Username, e.config.password(), e.config.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(e.config.From); err != nil {
+		return err
+	}
+	for _, to := range e.config.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s rejected: %w", to, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(e.message(notification)); err != nil {
+		_ = w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// message builds the email with headers and a plain text body
+func (e *emailSender) message(notification Notification) []byte {
+	subjectTemplate := e.config.Subject
+	if subjectTemplate == "" {
+		subjectTemplate = defaultEmailSubject
+	}
+	subject := notification.Text
+	if tmpl, err := template.New("subject").Parse(subjectTemplate); err == nil {
+		subject = execute(tmpl, notification)
+	}
+	subject = strings.Join(strings.Fields(subject), " ")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.config.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.config.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", notification.Timestamp.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(e.body(notification), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+// body returns the plain text body with the notification's details
+func (e *emailSender) body(notification Notification) string {
+	var b strings.Builder
+	b.WriteString(notification.Text + "\n\n")
+
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"Event", notification.Event},
+		{"Workspace", notification.Workspace},
+		{"Job", notification.Job},
+		{"Mode", notification.Mode},
+		{"Correlation ID", notification.CorrelationID},
+		{"Host", notification.Host},
+		{"Time", notification.Timestamp.Format(time.RFC3339)},
+		{"Log File", notification.LogFile},
+	}
+	for _, field := range fields {
+		if field.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.label, field.value)
+		}
+	}
+
+	if notification.Message != "" {
+		fmt.Fprintf(&b, "\n%s\n", notification.Message)
+	}
+	if notification.Error != "" {
+		fmt.Fprintf(&b, "\nError:\n%s\n", notification.Error)
+	}
+	if len(notification.NextSteps) > 0 {
+		b.WriteString("\nNext steps:\n")
+		for _, step := range notification.NextSteps {
+			fmt.Fprintf(&b, "  %s\n", step)
+		}
+	}
+	if excerpt := logExcerpt(notification, e.config.LogLines); len(excerpt) > 0 {
+		b.WriteString("\nLog excerpt:\n")
+		for _, line := range excerpt {
+			fmt.Fprintf(&b, "  %s\n", line)
+		}
+	}
+	return b.String()
+}
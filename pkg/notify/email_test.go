@@ -0,0 +1,117 @@
+package notify
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startSMTPServer accepts one SMTP session and returns the received message on the channel
+func startSMTPServer(t *testing.T) (string, int, <-chan string) {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { _ = listener.Close() })
+
+	messages := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+		reply("220 test ESMTP")
+
+		var data strings.Builder
+		inData := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			if inData {
+				if line == ".\r\n" {
+					inData = false
+					messages <- data.String()
+					reply("250 OK")
+					continue
+				}
+				data.WriteString(line)
+				continue
+			}
+
+			switch command := strings.ToUpper(strings.Fields(line)[0]); command {
+			case "EHLO", "HELO", "MAIL", "RCPT":
+				reply("250 OK")
+			case "DATA":
+				inData = true
+				reply("354 Go ahead")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return host, portNumber, messages
+}
+
+func TestSendEmail(t *testing.T) {
+	host, port, messages := startSMTPServer(t)
+
+	notifier := NewWithConfig(&Config{Email: []EmailConfig{{
+		SMTPHost: host,
+		SMTPPort: port,
+		From:     "provisioner@example.com",
+		To:       []string{"oncall@example.com", "team@example.com"},
+	}}})
+
+	notifier.Send(Notification{
+		Event:         EventJobFailed,
+		Workspace:     "billing",
+		Job:           "backup",
+		Error:         "exit status 2\nbackup target unreachable",
+		CorrelationID: "abc123",
+	})
+
+	select {
+	case message := <-messages:
+		for _, expected := range []string{
+			"To: oncall@example.com, team@example.com\r\n",
+			"Subject: [provisioner] Job backup in billing failed: exit status 2\r\n",
+			"Correlation ID: abc123\r\n",
+			"Error:\r\nexit status 2\r\nbackup target unreachable\r\n",
+			"  jobctl --workspace billing status backup\r\n",
+		} {
+			if !strings.Contains(message, expected) {
+				t.Errorf("Expected %q in email:\n%s", expected, message)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Email was not delivered")
+	}
+}
+
+func TestEmailSubjectTemplate(t *testing.T) {
+	sender := &emailSender{config: EmailConfig{From: "a@example.com", To: []string{"b@example.com"}, Subject: "{{.Event}}: {{.Workspace}}", LogLines: -1}}
+	message := string(sender.message(Notification{Event: EventDestroyFailed, Workspace: "web", Text: "Destroy of web failed"}))
+
+	if !strings.Contains(message, "Subject: destroy_failed: web\r\n") {
+		t.Errorf("Expected subject from template, got:\n%s", message)
+	}
+	if !strings.Contains(message, "\r\n\r\nDestroy of web failed\r\n") {
+		t.Errorf("Expected message text to start the body, got:\n%s", message)
+	}
+}
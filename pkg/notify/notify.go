@@ -0,0 +1,381 @@
+// Package notify sends notifications about deploys, destroys, jobs and alerts to
+// webhooks, Slack and email. Destinations subscribe to events and channels, and each
+// notification carries a message rendered from a per-event template.
+package notify
+
+import (
+	"bufio"
+
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"provisioner/pkg/configfile"
+	"provisioner/pkg/logging"
+)
+
+// Event types sent to notification destinations
+const (
+	EventDeploySucceeded  = "deploy_succeeded"
+	EventDeployFailed     = "deploy_failed"
+	EventDeployDegraded   = "deploy_degraded"
+	EventDestroySucceeded = "destroy_succeeded"
+	EventDestroyFailed    = "destroy_failed"
+	EventJobFailed        = "job_failed"
+	EventLifetimeExceeded = "lifetime_exceeded"
+	EventApprovalRequired = "approval_required"
+	EventApprovalExpired  = "approval_expired"
+	EventDriftDetected    = "drift_detected"
+	EventWorkspaceIdle    = "workspace_idle"
+	EventSLOBreached      = "slo_breached"
+
+	EventEnvironmentDegraded  = "environment_degraded"
+	EventEnvironmentRecovered = "environment_recovered"
+)
+
+// DefaultLogLines is the number of log lines included when a destination doesn't specify one
+const DefaultLogLines = 20
+
+// maxErrorSummary is the maximum length of an error summary in messages
+const maxErrorSummary = 200
+
+// Notification is the JSON payload posted to operation webhooks and the data of message templates
+type Notification struct {
+	Event         string    `json:"event"`
+	Workspace     string    `json:"workspace,omitempty"`
+	Environment   string    `json:"environment,omitempty"`
+	Job           string    `json:"job,omitempty"`
+	Mode          string    `json:"mode,omitempty"`
+	Error         string    `json:"error,omitempty"`
+	Message       string    `json:"message,omitempty"`
+	Text          string    `json:"text,omitempty"` // Message rendered from the event's template
+	CorrelationID string    `json:"correlation_id,omitempty"`
+	Channel       string    `json:"channel,omitempty"` // Workspace's notification channel, empty for all destinations
+	Timestamp     time.Time `json:"timestamp"`
+	Host          string    `json:"host,omitempty"`
+	LogFile       string    `json:"log_file,omitempty"`
+	LogExcerpt    []string  `json:"log_excerpt,omitempty"`
+	NextSteps     []string  `json:"next_steps,omitempty"`
+}
+
+// ErrorSummary returns the first line of the error, shortened for chat messages and subjects
+func (n Notification) ErrorSummary() string {
+	for _, line := range strings.Split(n.Error, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if runes := []rune(line); len(runes) > maxErrorSummary {
+			line = string(runes[:maxErrorSummary-3]) + "..."
+		}
+		return line
+	}
+	return ""
+}
+
+// Subscription selects the notifications a destination receives
+type Subscription struct {
+	Events  []string `json:"events,omitempty"`  // Empty means failures and alerts only, "*" means all events
+	Channel string   `json:"channel,omitempty"` // Only receive notifications of this channel; empty receives all
+}
+
+// WebhookConfig configures a single operation webhook
+type WebhookConfig struct {
+	Subscription
+	URL      string            `json:"url"`
+	LogLines int               `json:"log_lines,omitempty"` // Lines of log excerpt (default 20, -1 disables)
+	Headers  map[string]string `json:"headers,omitempty"`
+}
+
+// Config is the notification configuration: the "notifications" section of provisioner.json,
+// or notifications.json in the config directory
+type Config struct {
+	Webhooks  []WebhookConfig   `json:"webhooks,omitempty"`
+	Slack     []SlackConfig     `json:"slack,omitempty"`
+	Email     []EmailConfig     `json:"email,omitempty"`
+	Templates map[string]string `json:"templates,omitempty"` // Message templates by event, replacing the defaults
+}
+
+// Sender delivers notifications to one destination. Send receives notifications with Text
+// rendered and the destination's subscription already checked.
+type Sender interface {
+	Send(notification Notification) error
+}
+
+// destination is a sender with the notifications it subscribed to
+type destination struct {
+	kind         string // Destination type for logs: webhook, slack or email
+	subscription Subscription
+	sender       Sender
+}
+
+// Notifier sends notifications to every subscribed destination
+type Notifier struct {
+	destinations []destination
+	templates    *templates
+}
+
+// LoadConfig loads notification settings, returning an empty config if the file doesn't exist
+func LoadConfig(configPath string) (*Config, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Config{}, nil
+		}
+		return nil, fmt.Errorf("failed to read notification config: %w", err)
+	}
+
+	var config Config
+	if err := configfile.Unmarshal(configPath, data, data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse notification config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate checks every destination and template of the configuration
+func (c *Config) Validate() error {
+	for i, webhook := range c.Webhooks {
+		if webhook.URL == "" {
+			return fmt.Errorf("webhook %d: url is required", i)
+		}
+	}
+	for i, slack := range c.Slack {
+		if err := slack.validate(); err != nil {
+			return fmt.Errorf("slack %d: %w", i, err)
+		}
+	}
+	for i, email := range c.Email {
+		if err := email.validate(); err != nil {
+			return fmt.Errorf("email %d: %w", i, err)
+		}
+	}
+	if _, err := newTemplates(c.Templates); err != nil {
+		return err
+	}
+	return nil
+}
+
+// New creates a notifier from the notifications.json file in configDir
+func New(configDir string) (*Notifier, error) {
+	config, err := LoadConfig(filepath.Join(configDir, "notifications.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithConfig(config), nil
+}
+
+// NewWithConfig creates a notifier from an already loaded and validated configuration
+func NewWithConfig(config *Config) *Notifier {
+	templates, err := newTemplates(config.Templates)
+	if err != nil {
+		logging.LogSystemd("Using default notification templates: %v", err)
+		templates, _ = newTemplates(nil)
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	n := &Notifier{templates: templates}
+	for _, webhook := range config.Webhooks {
+		n.add("webhook", webhook.Subscription, &webhookSender{config: webhook, client: client})
+	}
+	for _, slack := range config.Slack {
+		n.add("slack", slack.Subscription, &slackSender{config: slack, client: client})
+	}
+	for _, email := range config.Email {
+		n.add("email", email.Subscription, &emailSender{config: email})
+	}
+	return n
+}
+
+// AddSender registers an additional destination receiving the notifications of subscription
+func (n *Notifier) AddSender(kind string, subscription Subscription, sender Sender) {
+	n.add(kind, subscription, sender)
+}
+
+func (n *Notifier) add(kind string, subscription Subscription, sender Sender) {
+	n.destinations = append(n.destinations, destination{kind: kind, subscription: subscription, sender: sender})
+}
+
+// Enabled returns true if any destinations are configured
+func (n *Notifier) Enabled() bool {
+	return n != nil && len(n.destinations) > 0
+}
+
+// Send delivers the notification to every destination subscribed to its event and channel
+func (n *Notifier) Send(notification Notification) {
+	if !n.Enabled() {
+		return
+	}
+
+	if notification.Timestamp.IsZero() {
+		notification.Timestamp = time.Now()
+	}
+	if notification.Host == "" {
+		notification.Host, _ = os.Hostname()
+	}
+	if notification.NextSteps == nil {
+		notification.NextSteps = SuggestNextSteps(notification)
+	}
+	notification.Error = logging.RedactWorkspace(notification.Workspace, notification.Error)
+	notification.Message = logging.RedactWorkspace(notification.Workspace, notification.Message)
+	notification.Text = n.templates.render(notification)
+
+	for _, dest := range n.destinations {
+		if !dest.subscription.wantsEvent(notification.Event) || !dest.subscription.wantsChannel(notification.Channel) {
+			continue
+		}
+		if err := dest.sender.Send(notification); err != nil {
+			logging.LogSystemd("Failed to send %s notification to %s: %v", notification.Event, dest.kind, err)
+		}
+	}
+}
+
+// wantsEvent reports whether the subscription includes an event
+func (s Subscription) wantsEvent(event string) bool {
+	if len(s.Events) == 0 {
+		return strings.HasSuffix(event, "_failed") || event == EventDeployDegraded || event == EventLifetimeExceeded || event == EventApprovalRequired || event == EventApprovalExpired || event == EventDriftDetected ||
+			event == EventSLOBreached || event == EventEnvironmentDegraded || event == EventEnvironmentRecovered
+	}
+	for _, e := range s.Events {
+		if e == event || e == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// wantsChannel reports whether the subscription receives notifications of a channel. Destinations
+// without a channel receive every notification; destinations with one only their channel's.
+func (s Subscription) wantsChannel(channel string) bool {
+	return s.Channel == "" || s.Channel == channel
+}
+
+// SuggestNextSteps returns CLI commands a responder can run for the notification
+func SuggestNextSteps(notification Notification) []string {
+	ws := notification.Workspace
+
+	switch notification.Event {
+	case EventDeployFailed, EventDeployDegraded:
+		retry := fmt.Sprintf("workspacectl deploy %s", ws)
+		if notification.Mode != "" {
+			retry = fmt.Sprintf("workspacectl deploy %s %s", ws, notification.Mode)
+		}
+		return []string{
+			fmt.Sprintf("workspacectl logs %s", ws),
+			fmt.Sprintf("workspacectl status %s", ws),
+			retry,
+		}
+	case EventDestroyFailed:
+		return []string{
+			fmt.Sprintf("workspacectl logs %s", ws),
+			fmt.Sprintf("workspacectl status %s", ws),
+			fmt.Sprintf("workspacectl destroy %s", ws),
+		}
+	case EventApprovalRequired:
+		return []string{
+			fmt.Sprintf("workspacectl status %s", ws),
+			fmt.Sprintf("workspacectl approve %s", ws),
+		}
+	case EventApprovalExpired:
+		return []string{
+			fmt.Sprintf("workspacectl status %s", ws),
+			fmt.Sprintf("workspacectl logs %s", ws),
+		}
+	case EventLifetimeExceeded:
+		return []string{
+			fmt.Sprintf("workspacectl status %s", ws),
+			fmt.Sprintf("workspacectl destroy %s", ws),
+		}
+	case EventWorkspaceIdle:
+		return []string{
+			fmt.Sprintf("workspacectl status %s", ws),
+			fmt.Sprintf("workspacectl deploy %s", ws),
+		}
+	case EventDriftDetected:
+		return []string{
+			fmt.Sprintf("workspacectl logs %s", ws),
+			fmt.Sprintf("workspacectl deploy %s", ws),
+		}
+	case EventEnvironmentDegraded:
+		return []string{
+			fmt.Sprintf("environmentctl status %s", notification.Environment),
+			fmt.Sprintf("workspacectl status %s", ws),
+			fmt.Sprintf("workspacectl logs %s", ws),
+		}
+	case EventJobFailed:
+		jobctl := "jobctl"
+		if ws != "" {
+			jobctl = fmt.Sprintf("jobctl --workspace %s", ws)
+		}
+		return []string{
+			fmt.Sprintf("%s status %s", jobctl, notification.Job),
+			fmt.Sprintf("%s run %s", jobctl, notification.Job),
+		}
+	case EventSLOBreached:
+		if notification.Job == "" {
+			return []string{
+				fmt.Sprintf("workspacectl status %s", ws),
+				fmt.Sprintf("workspacectl logs %s", ws),
+			}
+		}
+		jobctl := "jobctl"
+		if ws != "" {
+			jobctl = fmt.Sprintf("jobctl --workspace %s", ws)
+		}
+		return []string{
+			fmt.Sprintf("%s status %s", jobctl, notification.Job),
+			"provisioner success-rates",
+		}
+	}
+
+	return nil
+}
+
+// logExcerpt returns the redacted last lines of the notification's log file; lines is a
+// destination's log_lines setting (0 for the default, negative to disable)
+func logExcerpt(notification Notification, lines int) []string {
+	if lines < 0 || notification.LogFile == "" {
+		return nil
+	}
+	if lines == 0 {
+		lines = DefaultLogLines
+	}
+
+	excerpt, err := TailFile(notification.LogFile, lines)
+	if err != nil {
+		logging.LogSystemd("Failed to read log excerpt for notification: %v", err)
+	}
+	for i, line := range excerpt {
+		excerpt[i] = logging.RedactWorkspace(notification.Workspace, line)
+	}
+	return excerpt
+}
+
+// TailFile returns the last n lines of a file
+func TailFile(path string, n int) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	lines := make([]string, 0, n)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(lines) == n {
+			lines = lines[1:]
+		}
+		lines = append(lines, scanner.Text())
+	}
+
+	return lines, scanner.Err()
+}
@@ -0,0 +1,254 @@
+package notify
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTailFile(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "test.log")
+	var content strings.Builder
+	for i := 1; i <= 10; i++ {
+		fmt.Fprintf(&content, "line %d\n", i)
+	}
+	if err := os.WriteFile(logFile, []byte(content.String()), 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	lines, err := TailFile(logFile, 3)
+	if err != nil {
+		t.Fatalf("TailFile failed: %v", err)
+	}
+	expected := []string{"line 8", "line 9", "line 10"}
+	if strings.Join(lines, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, lines)
+	}
+
+	lines, err = TailFile(logFile, 50)
+	if err != nil {
+		t.Fatalf("TailFile failed: %v", err)
+	}
+	if len(lines) != 10 {
+		t.Errorf("Expected all 10 lines, got %d", len(lines))
+	}
+}
+
+func TestSuggestNextSteps(t *testing.T) {
+	tests := []struct {
+		notification Notification
+		expected     string
+	}{
+		{Notification{Event: EventDeployFailed, Workspace: "web"}, "workspacectl deploy web"},
+		{Notification{Event: EventDeployFailed, Workspace: "web", Mode: "busy"}, "workspacectl deploy web busy"},
+		{Notification{Event: EventDestroyFailed, Workspace: "web"}, "workspacectl destroy web"},
+		{Notification{Event: EventApprovalRequired, Workspace: "web"}, "workspacectl approve web"},
+		{Notification{Event: EventJobFailed, Workspace: "web", Job: "backup"}, "jobctl --workspace web run backup"},
+		{Notification{Event: EventJobFailed, Job: "cleanup"}, "jobctl run cleanup"},
+		{Notification{Event: EventSLOBreached, Workspace: "web"}, "workspacectl status web"},
+		{Notification{Event: EventSLOBreached, Job: "cleanup"}, "jobctl status cleanup"},
+		{Notification{Event: EventEnvironmentDegraded, Workspace: "blue", Environment: "production"}, "environmentctl status production"},
+	}
+
+	for _, tt := range tests {
+		steps := SuggestNextSteps(tt.notification)
+		found := false
+		for _, step := range steps {
+			if step == tt.expected {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected %q in next steps for %+v, got %v", tt.expected, tt.notification, steps)
+		}
+	}
+
+	if steps := SuggestNextSteps(Notification{Event: EventDeploySucceeded, Workspace: "web"}); len(steps) != 0 {
+		t.Errorf("Expected no next steps for success, got %v", steps)
+	}
+}
+
+func TestSendIncludesLogExcerpt(t *testing.T) {
+	var received []Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "abc" {
+			t.Errorf("Expected custom header to be sent")
+		}
+		var n Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		received = append(received, n)
+	}))
+	defer server.Close()
+
+	logFile := filepath.Join(t.TempDir(), "web.log")
+	logContent := "starting\napplying\nError: token=supersecretvalue rejected\n"
+	if err := os.WriteFile(logFile, []byte(logContent), 0644); err != nil {
+		t.Fatalf("Failed to write log file: %v", err)
+	}
+
+	notifier := NewWithConfig(&Config{Webhooks: []WebhookConfig{
+		{URL: server.URL, LogLines: 2, Headers: map[string]string{"X-Token": "abc"}},
+	}})
+
+	// Default subscription only sends failures
+	notifier.Send(Notification{Event: EventDeploySucceeded, Workspace: "web", LogFile: logFile})
+	notifier.Send(Notification{Event: EventDeployFailed, Workspace: "web", Error: "apply failed", LogFile: logFile})
+
+	if len(received) != 1 {
+		t.Fatalf("Expected 1 notification, got %d", len(received))
+	}
+
+	n := received[0]
+	if n.Event != EventDeployFailed || n.LogFile != logFile {
+		t.Errorf("Unexpected payload: %+v", n)
+	}
+	if len(n.LogExcerpt) != 2 || n.LogExcerpt[0] != "applying" {
+		t.Errorf("Expected last 2 log lines, got %v", n.LogExcerpt)
+	}
+	if strings.Contains(n.LogExcerpt[1], "supersecretvalue") {
+		t.Errorf("Expected secret to be redacted in excerpt, got %q", n.LogExcerpt[1])
+	}
+	if len(n.NextSteps) == 0 {
+		t.Error("Expected next steps in payload")
+	}
+	if n.Timestamp.IsZero() {
+		t.Error("Expected timestamp to be set")
+	}
+}
+
+func TestWantsEvent(t *testing.T) {
+	defaults := Subscription{}
+	if defaults.wantsEvent(EventDeploySucceeded) || !defaults.wantsEvent(EventDeployFailed) || !defaults.wantsEvent(EventDriftDetected) ||
+		!defaults.wantsEvent(EventSLOBreached) {
+		t.Error("Expected default subscription to receive failures and alerts only")
+	}
+	if !defaults.wantsEvent(EventEnvironmentDegraded) || !defaults.wantsEvent(EventEnvironmentRecovered) {
+		t.Error("Expected default subscription to receive environment health alerts and their recovery")
+	}
+	if !defaults.wantsEvent(EventDeployDegraded) {
+		t.Error("Expected default subscription to receive degraded deploys")
+	}
+	if !defaults.wantsEvent(EventApprovalRequired) || !defaults.wantsEvent(EventApprovalExpired) {
+		t.Error("Expected default subscription to receive approval requests and their expiry")
+	}
+
+	all := Subscription{Events: []string{"*"}}
+	if !all.wantsEvent(EventDeploySucceeded) {
+		t.Error("Expected wildcard to match every event")
+	}
+
+	specific := Subscription{Events: []string{EventJobFailed}}
+	if specific.wantsEvent(EventDeployFailed) || !specific.wantsEvent(EventJobFailed) {
+		t.Error("Expected only subscribed events to match")
+	}
+}
+
+func TestWantsChannel(t *testing.T) {
+	everything := Subscription{}
+	if !everything.wantsChannel("") || !everything.wantsChannel("prod-oncall") {
+		t.Error("Expected destination without channel to receive every notification")
+	}
+
+	oncall := Subscription{Channel: "prod-oncall"}
+	if !oncall.wantsChannel("prod-oncall") || oncall.wantsChannel("") || oncall.wantsChannel("dev") {
+		t.Error("Expected channel destination to receive only its channel")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config, err := LoadConfig(filepath.Join(tempDir, "missing.json"))
+	if err != nil || len(config.Webhooks) != 0 {
+		t.Fatalf("Expected empty config for missing file, got %v, %v", config, err)
+	}
+
+	invalid := filepath.Join(tempDir, "notifications.json")
+	if err := os.WriteFile(invalid, []byte(`{"webhooks":[{"events":["deploy_failed"]}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if _, err := LoadConfig(invalid); err == nil {
+		t.Error("Expected error for webhook without url")
+	}
+
+	invalidConfigs := map[string]Config{
+		"slack without url":    {Slack: []SlackConfig{{}}},
+		"email without host":   {Email: []EmailConfig{{From: "a@example.com", To: []string{"b@example.com"}}}},
+		"email without to":     {Email: []EmailConfig{{SMTPHost: "smtp", From: "a@example.com"}}},
+		"email both passwords": {Email: []EmailConfig{{SMTPHost: "smtp", From: "a@example.com", To: []string{"b@example.com"}, Password: "p", PasswordEnv: "P"}}},
+		"unparsable template":  {Templates: map[string]string{EventDeployFailed: "{{.Workspace"}},
+		"unknown field":        {Templates: map[string]string{EventDeployFailed: "{{.Owner}}"}},
+	}
+	for name, config := range invalidConfigs {
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected validation error for %s", name)
+		}
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	templates, err := newTemplates(map[string]string{EventJobFailed: "{{.Job}} broke on {{.Host}}"})
+	if err != nil {
+		t.Fatalf("newTemplates failed: %v", err)
+	}
+
+	tests := []struct {
+		notification Notification
+		expected     string
+	}{
+		{Notification{Event: EventDeployFailed, Workspace: "web", Mode: "busy", Error: "\nError: quota exceeded\nmore detail"}, "Deploy of web in mode busy failed: Error: quota exceeded"},
+		{Notification{Event: EventDestroySucceeded, Workspace: "web"}, "Destroy of web succeeded"},
+		{Notification{Event: EventDriftDetected, Workspace: "web", Message: "2 resources changed"}, "Drift detected in web: 2 resources changed"},
+		{Notification{Event: EventJobFailed, Job: "backup", Host: "ops-1"}, "backup broke on ops-1"},
+		{Notification{Event: "custom_event", Workspace: "web"}, "custom_event for web"},
+	}
+	for _, tt := range tests {
+		if got := templates.render(tt.notification); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+	}
+
+	long := Notification{Error: strings.Repeat("x", 500)}
+	if summary := long.ErrorSummary(); len(summary) != maxErrorSummary || !strings.HasSuffix(summary, "...") {
+		t.Errorf("Expected error summary shortened to %d characters, got %d", maxErrorSummary, len(summary))
+	}
+}
+
+func TestSendToSlack(t *testing.T) {
+	var received []slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slackMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		received = append(received, message)
+	}))
+	defer server.Close()
+
+	notifier := NewWithConfig(&Config{Slack: []SlackConfig{
+		{WebhookURL: server.URL, Subscription: Subscription{Channel: "prod-oncall"}},
+		{WebhookURL: server.URL, Subscription: Subscription{Events: []string{EventDeploySucceeded}}, Template: ":rocket: {{.Workspace}} is up"},
+	}})
+
+	notifier.Send(Notification{Event: EventDeployFailed, Workspace: "billing", Error: "apply failed", Channel: "prod-oncall", CorrelationID: "abc123"})
+	notifier.Send(Notification{Event: EventDeployFailed, Workspace: "sandbox", Error: "apply failed", Channel: "dev"})
+	notifier.Send(Notification{Event: EventDeploySucceeded, Workspace: "sandbox"})
+
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 Slack messages, got %d: %v", len(received), received)
+	}
+	failure := received[0].Text
+	if !strings.HasPrefix(failure, "Deploy of billing failed: apply failed") || !strings.Contains(failure, "abc123") || !strings.Contains(failure, "workspacectl logs billing") {
+		t.Errorf("Unexpected failure message: %q", failure)
+	}
+	if !strings.HasPrefix(received[1].Text, ":rocket: sandbox is up") {
+		t.Errorf("Expected destination template to be used, got %q", received[1].Text)
+	}
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// SlackConfig configures a Slack incoming webhook
+type SlackConfig struct {
+	Subscription
+	WebhookURL string `json:"webhook_url"`
+	Template   string `json:"template,omitempty"` // Message template replacing the event's template for this destination
+}
+
+// validate checks the Slack destination settings
+func (c SlackConfig) validate() error {
+	if c.WebhookURL == "" {
+		return fmt.Errorf("webhook_url is required")
+	}
+	if c.Template != "" {
+		if _, err := parseTemplate("slack", c.Template); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slackMessage is the payload of a Slack incoming webhook
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// slackSender posts notifications to a Slack incoming webhook
+type slackSender struct {
+	config SlackConfig
+	client *http.Client
+}
+
+// Send posts the notification's message with its next steps
+func (s *slackSender) Send(notification Notification) error {
+	return postJSON(s.client, s.config.WebhookURL, nil, slackMessage{Text: s.format(notification)})
+}
+
+// format builds the Slack message text
+func (s *slackSender) format(notification Notification) string {
+	text := notification.Text
+	if s.config.Template != "" {
+		if tmpl, err := template.New("slack").Parse(s.config.Template); err == nil {
+			text = execute(tmpl, notification)
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(text)
+
+	var details []string
+	if notification.CorrelationID != "" {
+		details = append(details, "correlation ID `"+notification.CorrelationID+"`")
+	}
+	if notification.Host != "" {
+		details = append(details, "host `"+notification.Host+"`")
+	}
+	if len(details) > 0 {
+		b.WriteString("\n" + strings.Join(details, ", "))
+	}
+
+	if len(notification.NextSteps) > 0 {
+		b.WriteString("\nNext steps:\n```\n" + strings.Join(notification.NextSteps, "\n") + "\n```")
+	}
+	return b.String()
+}
@@ -0,0 +1,91 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+)
+
+// defaultTemplates are the messages of each event; templates in the configuration replace them.
+// Templates are Go text/template strings over Notification, e.g. {{.Workspace}} or {{.ErrorSummary}}.
+var defaultTemplates = map[string]string{
+	EventDeploySucceeded:  `Deploy of {{.Workspace}}{{if .Mode}} in mode {{.Mode}}{{end}} succeeded`,
+	EventDeployFailed:     `Deploy of {{.Workspace}}{{if .Mode}} in mode {{.Mode}}{{end}} failed{{if .Error}}: {{.ErrorSummary}}{{end}}`,
+	EventDeployDegraded:   `Deploy of {{.Workspace}}{{if .Mode}} in mode {{.Mode}}{{end}} is degraded{{if .Error}}: {{.ErrorSummary}}{{end}}`,
+	EventDestroySucceeded: `Destroy of {{.Workspace}} succeeded`,
+	EventDestroyFailed:    `Destroy of {{.Workspace}} failed{{if .Error}}: {{.ErrorSummary}}{{end}}`,
+	EventJobFailed:        `Job {{.Job}}{{if .Workspace}} in {{.Workspace}}{{end}} failed{{if .Error}}: {{.ErrorSummary}}{{end}}`,
+	EventLifetimeExceeded: `{{.Workspace}} exceeded its max lifetime{{if .Message}}: {{.Message}}{{end}}`,
+	EventApprovalRequired: `{{.Workspace}} is waiting for approval{{if .Message}}: {{.Message}}{{end}}`,
+	EventApprovalExpired:  `Approval request of {{.Workspace}} expired{{if .Message}}: {{.Message}}{{end}}`,
+	EventDriftDetected:    `Drift detected in {{.Workspace}}{{if .Message}}: {{.Message}}{{end}}`,
+	EventWorkspaceIdle:    `{{.Workspace}} is idle{{if .Message}}: {{.Message}}{{end}}`,
+	EventSLOBreached:      `{{if .Job}}Job {{.Job}}{{if .Workspace}} in {{.Workspace}}{{end}}{{else}}{{.Workspace}}{{end}} is below its success-rate objective{{if .Message}}: {{.Message}}{{end}}`,
+
+	EventEnvironmentDegraded:  `Environment {{.Environment}} on {{.Workspace}} is degraded{{if .Message}} ({{.Message}}){{end}}{{if .Error}}: {{.ErrorSummary}}{{end}}`,
+	EventEnvironmentRecovered: `Environment {{.Environment}} on {{.Workspace}} recovered{{if .Message}}: {{.Message}}{{end}}`,
+}
+
+// fallbackTemplate is used for events without a template
+const fallbackTemplate = `{{.Event}}{{if .Workspace}} for {{.Workspace}}{{end}}{{if .Error}}: {{.ErrorSummary}}{{end}}`
+
+// templates holds the parsed message template of each event
+type templates struct {
+	byEvent  map[string]*template.Template
+	fallback *template.Template
+}
+
+// newTemplates parses the default templates with the given overrides applied
+func newTemplates(overrides map[string]string) (*templates, error) {
+	t := &templates{byEvent: make(map[string]*template.Template)}
+
+	texts := make(map[string]string, len(defaultTemplates)+len(overrides))
+	for event, text := range defaultTemplates {
+		texts[event] = text
+	}
+	for event, text := range overrides {
+		texts[event] = text
+	}
+
+	for event, text := range texts {
+		tmpl, err := parseTemplate(event, text)
+		if err != nil {
+			return nil, err
+		}
+		t.byEvent[event] = tmpl
+	}
+
+	t.fallback = template.Must(template.New("fallback").Parse(fallbackTemplate))
+	return t, nil
+}
+
+// parseTemplate parses a message template, checking it against an example notification
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template for %s: %w", name, err)
+	}
+	example := Notification{Event: name, Workspace: "example", Job: "example", Error: "example"}
+	if err := tmpl.Execute(&bytes.Buffer{}, example); err != nil {
+		return nil, fmt.Errorf("invalid template for %s: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// render returns the notification's message from the template of its event
+func (t *templates) render(notification Notification) string {
+	tmpl, ok := t.byEvent[notification.Event]
+	if !ok {
+		tmpl = t.fallback
+	}
+	return execute(tmpl, notification)
+}
+
+// execute renders a template, falling back to the event name if rendering fails
+func execute(tmpl *template.Template, notification Notification) string {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, notification); err != nil {
+		return notification.Event
+	}
+	return buf.String()
+}
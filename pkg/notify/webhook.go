@@ -0,0 +1,50 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// webhookSender posts the notification as JSON to an operation webhook
+type webhookSender struct {
+	config WebhookConfig
+	client *http.Client
+}
+
+// Send posts the notification with the webhook's log excerpt
+func (w *webhookSender) Send(notification Notification) error {
+	payload := notification
+	payload.LogExcerpt = logExcerpt(notification, w.config.LogLines)
+	return postJSON(w.client, w.config.URL, w.config.Headers, payload)
+}
+
+// postJSON posts a JSON payload, treating non-2xx responses as errors
+func postJSON(client *http.Client, url string, headers map[string]string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
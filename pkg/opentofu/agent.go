@@ -0,0 +1,46 @@
+package opentofu
+
+import (
+	"errors"
+	"fmt"
+
+	"provisioner/pkg/workspace"
+)
+
+// ErrWrongHost is returned (wrapped in a *WrongHostError) for operations of a workspace assigned
+// to another agent than the one the client runs on
+var ErrWrongHost = errors.New("workspace runs elsewhere")
+
+// WrongHostError reports where an operation's workspace runs instead
+type WrongHostError struct {
+	Workspace string
+	Agent     string // Agent the workspace is assigned to, empty for the daemon host
+	Host      string // Agent the client runs on, empty for the daemon host
+}
+
+func (e *WrongHostError) Error() string {
+	if e.Agent == "" {
+		return fmt.Sprintf("workspace '%s' runs on the daemon host, not on agent '%s'", e.Workspace, e.Host)
+	}
+	return fmt.Sprintf("workspace '%s' runs on agent '%s'; deploy and destroy it through the daemon", e.Workspace, e.Agent)
+}
+
+// Unwrap allows errors.Is(err, ErrWrongHost)
+func (e *WrongHostError) Unwrap() error {
+	return ErrWrongHost
+}
+
+// SetAgent makes the client run the operations of the workspaces assigned to the named agent
+// instead of those of the daemon host
+func (c *Client) SetAgent(name string) {
+	c.agent = name
+}
+
+// checkHost refuses operations of workspaces that run on another host, so a CLI falling back to
+// direct execution never applies a workspace from a network it was moved away from
+func (c *Client) checkHost(ws *workspace.Workspace) error {
+	if ws.Config.Agent != c.agent {
+		return &WrongHostError{Workspace: ws.Name, Agent: ws.Config.Agent, Host: c.agent}
+	}
+	return nil
+}
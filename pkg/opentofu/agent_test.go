@@ -0,0 +1,37 @@
+package opentofu
+
+import (
+	"errors"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestCheckHost(t *testing.T) {
+	local := &Client{}
+	remote := &Client{}
+	remote.SetAgent("aws-vpc")
+
+	daemonWorkspace := &workspace.Workspace{Name: "web"}
+	agentWorkspace := &workspace.Workspace{Name: "db", Config: workspace.Config{Agent: "aws-vpc"}}
+
+	if err := local.checkHost(daemonWorkspace); err != nil {
+		t.Errorf("Expected the daemon host to run its own workspace, got %v", err)
+	}
+	if err := remote.checkHost(agentWorkspace); err != nil {
+		t.Errorf("Expected the agent to run its workspace, got %v", err)
+	}
+
+	err := local.checkHost(agentWorkspace)
+	if !errors.Is(err, ErrWrongHost) || err.Error() != "workspace 'db' runs on agent 'aws-vpc'; deploy and destroy it through the daemon" {
+		t.Errorf("Expected the daemon host to refuse the agent's workspace, got %v", err)
+	}
+	if err := remote.checkHost(daemonWorkspace); !errors.Is(err, ErrWrongHost) {
+		t.Errorf("Expected the agent to refuse the daemon host's workspace, got %v", err)
+	}
+
+	// Operations are refused before they touch the deployment directory
+	if err := local.Deploy(agentWorkspace); !errors.Is(err, ErrWrongHost) {
+		t.Errorf("Expected Deploy to refuse the agent's workspace, got %v", err)
+	}
+}
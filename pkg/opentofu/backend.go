@@ -0,0 +1,186 @@
+package opentofu
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// Files generated in the working directory of workspaces with a remote backend
+const (
+	BackendFileName       = "provisioner_backend.tf" // Declares the backend type
+	BackendConfigFileName = "provisioner.tfbackend"  // Backend settings passed to "tofu init"
+)
+
+// backendSettings returns the OpenTofu backend type and settings for a workspace's remote backend.
+// The state's location defaults to one per workspace so workspaces can share a bucket.
+func backendSettings(ws *workspace.Workspace) (string, map[string]string) {
+	backend := ws.Config.Backend
+	settings := make(map[string]string, len(backend.Config))
+	for key, value := range backend.Config {
+		settings[key] = value
+	}
+	setDefault := func(key, value string) {
+		if _, ok := settings[key]; !ok {
+			settings[key] = value
+		}
+	}
+
+	backendType := backend.Type
+	switch backend.Type {
+	case workspace.BackendS3:
+		setDefault("key", fmt.Sprintf("provisioner/%s/terraform.tfstate", ws.Name))
+	case workspace.BackendGCS:
+		setDefault("prefix", fmt.Sprintf("provisioner/%s", ws.Name))
+	case workspace.BackendConsul:
+		setDefault("path", fmt.Sprintf("provisioner/%s", ws.Name))
+	case workspace.BackendSpaces:
+		// Spaces speaks the S3 API; the region names the Spaces datacenter, not an AWS region
+		backendType = workspace.BackendS3
+		setDefault("key", fmt.Sprintf("provisioner/%s/terraform.tfstate", ws.Name))
+		setDefault("endpoints.s3", fmt.Sprintf("https://%s.digitaloceanspaces.com", settings["region"]))
+		settings["region"] = "us-east-1"
+		for _, key := range []string{"skip_credentials_validation", "skip_region_validation",
+			"skip_requesting_account_id", "skip_metadata_api_check", "skip_s3_checksum"} {
+			setDefault(key, "true")
+		}
+	}
+	return backendType, settings
+}
+
+// renderBackendConfig renders backend settings as a .tfbackend file. Settings named
+// "block.attribute" become attributes of an object, e.g. endpoints = { s3 = "..." }.
+func renderBackendConfig(settings map[string]string) []byte {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	objects := make(map[string][]string)
+	var objectOrder []string
+	for _, key := range keys {
+		if object, attribute, ok := strings.Cut(key, "."); ok {
+			if _, seen := objects[object]; !seen {
+				objectOrder = append(objectOrder, object)
+			}
+			objects[object] = append(objects[object], fmt.Sprintf("%s = %s", attribute, hclValue(settings[key])))
+			continue
+		}
+		fmt.Fprintf(&buf, "%s = %s\n", key, hclValue(settings[key]))
+	}
+	for _, object := range objectOrder {
+		fmt.Fprintf(&buf, "%s = { %s }\n", object, strings.Join(objects[object], ", "))
+	}
+	return buf.Bytes()
+}
+
+// hclValue renders a backend setting as an HCL literal; true and false stay booleans
+func hclValue(value string) string {
+	if value == "true" || value == "false" {
+		return value
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "${", "$${", "%{", "%%{").Replace(value)
+	return `"` + escaped + `"`
+}
+
+// writeBackendFiles generates the backend block and settings of a workspace with a remote backend
+// in its working directory. Workspaces keeping local state get neither file, nor a stale copy of
+// a remote state.
+func writeBackendFiles(ws *workspace.Workspace, workingDir string) error {
+	if !ws.Config.HasRemoteBackend() {
+		for _, name := range []string{BackendFileName, BackendConfigFileName, workspace.RemoteStateFileName} {
+			if err := os.Remove(filepath.Join(workingDir, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+
+	backendType, settings := backendSettings(ws)
+	block := fmt.Sprintf("# Generated by provisioner from the workspace's backend config\nterraform {\n  backend %q {}\n}\n", backendType)
+	if err := os.WriteFile(filepath.Join(workingDir, BackendFileName), []byte(block), 0644); err != nil {
+		return fmt.Errorf("failed to write backend block: %w", err)
+	}
+
+	// Settings may include credentials, keep them private to the provisioner user
+	if err := os.WriteFile(filepath.Join(workingDir, BackendConfigFileName), renderBackendConfig(settings), 0600); err != nil {
+		return fmt.Errorf("failed to write backend config: %w", err)
+	}
+	return nil
+}
+
+// initArgs returns the arguments of "tofu init" for a working directory. With a generated backend
+// config, local state left from before the backend was configured is copied into it.
+func initArgs(workingDir string) []string {
+	if _, err := os.Stat(filepath.Join(workingDir, BackendConfigFileName)); err != nil {
+		return []string{"init"}
+	}
+	return []string{"init", "-input=false", "-force-copy", "-backend-config=" + BackendConfigFileName}
+}
+
+// pullRemoteState copies a workspace's remote state into the working directory so status,
+// resource counts and state backups see it. Local-state workspaces have nothing to pull.
+func (c *Client) pullRemoteState(ws *workspace.Workspace, workingDir string) error {
+	if !ws.Config.HasRemoteBackend() {
+		return nil
+	}
+
+	cmd, err := c.tofuCommand(workingDir, "state", "pull")
+	if err != nil {
+		return err
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return fmt.Errorf("failed to pull remote state: %w\n\nDetailed output:\n%s", err, stderr.String())
+		}
+		return fmt.Errorf("failed to pull remote state: %w", err)
+	}
+
+	// Replace atomically so status never reads a partial copy
+	statePath := filepath.Join(workingDir, workspace.RemoteStateFileName)
+	tmpPath := statePath + ".pull"
+	if err := os.WriteFile(tmpPath, stdout.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write remote state copy: %w", err)
+	}
+	if err := os.Rename(tmpPath, statePath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace remote state copy: %w", err)
+	}
+	return nil
+}
+
+// syncRemoteState refreshes the copy of a workspace's remote state once an operation has run.
+// The operation's outcome stands either way, so failures are only logged.
+func (c *Client) syncRemoteState(ws *workspace.Workspace, workingDir string) {
+	if err := c.pullRemoteState(ws, workingDir); err != nil {
+		logging.LogWorkspace(ws.Name, "Failed to refresh the copy of the remote state: %v", err)
+	}
+}
+
+// HasState reports whether a working directory holds state from a deploy, local or pulled from
+// a remote backend
+func HasState(workingDir string) bool {
+	_, err := os.Stat(stateFilePath(workingDir))
+	return err == nil
+}
+
+// stateFilePath returns the state file of a working directory: the copy of a remote state if
+// there is one, otherwise OpenTofu's local state
+func stateFilePath(workingDir string) string {
+	remotePath := filepath.Join(workingDir, workspace.RemoteStateFileName)
+	if _, err := os.Stat(remotePath); err == nil {
+		return remotePath
+	}
+	return filepath.Join(workingDir, "terraform.tfstate")
+}
@@ -0,0 +1,133 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestRenderBackendConfigForSpaces(t *testing.T) {
+	ws := &workspace.Workspace{Name: "web", Config: workspace.Config{Backend: &workspace.BackendConfig{
+		Type:   workspace.BackendSpaces,
+		Config: map[string]string{"bucket": "tf-state", "region": "fra1"},
+	}}}
+
+	backendType, settings := backendSettings(ws)
+	if backendType != "s3" {
+		t.Errorf("Expected Spaces to use the s3 backend, got %s", backendType)
+	}
+
+	rendered := string(renderBackendConfig(settings))
+	for _, want := range []string{
+		`bucket = "tf-state"`,
+		`key = "provisioner/web/terraform.tfstate"`,
+		`region = "us-east-1"`,
+		`skip_credentials_validation = true`,
+		`endpoints = { s3 = "https://fra1.digitaloceanspaces.com" }`,
+	} {
+		if !strings.Contains(rendered, want) {
+			t.Errorf("Expected %q in backend config:\n%s", want, rendered)
+		}
+	}
+}
+
+func TestBackendConfigKeepsExplicitSettings(t *testing.T) {
+	ws := &workspace.Workspace{Name: "web", Config: workspace.Config{Backend: &workspace.BackendConfig{
+		Type:   workspace.BackendGCS,
+		Config: map[string]string{"bucket": "tf-state", "prefix": `teams/"ops"`},
+	}}}
+
+	_, settings := backendSettings(ws)
+	rendered := string(renderBackendConfig(settings))
+	if !strings.Contains(rendered, `prefix = "teams/\"ops\""`) {
+		t.Errorf("Expected the configured prefix, escaped, got:\n%s", rendered)
+	}
+}
+
+func TestWriteBackendFiles(t *testing.T) {
+	workingDir := t.TempDir()
+	ws := &workspace.Workspace{Name: "web", Config: workspace.Config{Backend: &workspace.BackendConfig{
+		Type:   workspace.BackendConsul,
+		Config: map[string]string{"address": "consul:8500"},
+	}}}
+
+	if args := initArgs(workingDir); len(args) != 1 {
+		t.Errorf("Expected a plain init without backend config, got %v", args)
+	}
+
+	if err := writeBackendFiles(ws, workingDir); err != nil {
+		t.Fatalf("writeBackendFiles failed: %v", err)
+	}
+	block, err := os.ReadFile(filepath.Join(workingDir, BackendFileName))
+	if err != nil || !strings.Contains(string(block), `backend "consul" {}`) {
+		t.Errorf("Expected a consul backend block, got %q, %v", block, err)
+	}
+	info, err := os.Stat(filepath.Join(workingDir, BackendConfigFileName))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a private backend config file, got %v, %v", info, err)
+	}
+	if args := initArgs(workingDir); args[len(args)-1] != "-backend-config="+BackendConfigFileName {
+		t.Errorf("Expected init to pass the backend config, got %v", args)
+	}
+
+	// Dropping the backend removes the generated files
+	ws.Config.Backend = nil
+	if err := writeBackendFiles(ws, workingDir); err != nil {
+		t.Fatalf("writeBackendFiles failed: %v", err)
+	}
+	for _, name := range []string{BackendFileName, BackendConfigFileName} {
+		if _, err := os.Stat(filepath.Join(workingDir, name)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed, got %v", name, err)
+		}
+	}
+}
+
+func TestDeployWithRemoteBackendPullsState(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	dir := t.TempDir()
+	script := `#!/bin/sh
+echo "$@" >> "` + filepath.Join(dir, "calls") + `"
+if [ "$1 $2" = "state pull" ]; then
+  echo '{"serial":3,"resources":[{"mode":"managed","instances":[{},{},{}]}]}'
+fi
+exit 0
+`
+	binary := writeTestTofu(t, script)
+
+	ws := newResultTestWorkspace(t)
+	ws.Config.Backend = &workspace.BackendConfig{
+		Type:   workspace.BackendS3,
+		Config: map[string]string{"bucket": "tf-state", "region": "eu-west-1"},
+	}
+
+	client := &Client{binaryPath: binary}
+	if err := client.Deploy(ws); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	calls, _ := os.ReadFile(filepath.Join(dir, "calls"))
+	if !strings.Contains(string(calls), "init -input=false -force-copy -backend-config="+BackendConfigFileName) {
+		t.Errorf("Expected init with the backend config, got:\n%s", calls)
+	}
+
+	workingDir := GetWorkingDir(ws.Name)
+	if !HasState(workingDir) {
+		t.Fatal("Expected the remote state to be pulled into the working directory")
+	}
+	if _, err := os.Stat(filepath.Join(workingDir, "terraform.tfstate")); !os.IsNotExist(err) {
+		t.Errorf("Expected no local state file, got %v", err)
+	}
+
+	results, err := LoadOperationResults(ws.Name)
+	if err != nil || results.Deploy == nil || results.Deploy.Resources != 3 {
+		t.Errorf("Expected the deploy result to count the remote state's resources, got %+v, %v", results, err)
+	}
+	backups, err := ListStateBackups(ws.Name)
+	if err != nil || len(backups) != 1 || backups[0].Serial != 3 {
+		t.Errorf("Expected a backup of the pulled state before apply, got %+v, %v", backups, err)
+	}
+}
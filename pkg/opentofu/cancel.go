@@ -0,0 +1,174 @@
+package opentofu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// ErrCancelled is returned (wrapped in a *CancelledError) when an operation is cancelled
+var ErrCancelled = errors.New("operation cancelled")
+
+// cancelWaitDelay is how long a cancelled process group gets to exit after SIGTERM before it is killed
+const cancelWaitDelay = 30 * time.Second
+
+// CancelledError describes how far a cancelled operation got
+type CancelledError struct {
+	Step           string   // Step that was running when the operation was cancelled
+	CompletedSteps []string // Steps that finished before cancellation
+}
+
+func (e *CancelledError) Error() string {
+	if len(e.CompletedSteps) == 0 {
+		return fmt.Sprintf("%s during %s", ErrCancelled, e.Step)
+	}
+	return fmt.Sprintf("%s during %s (completed: %s)", ErrCancelled, e.Step, strings.Join(e.CompletedSteps, ", "))
+}
+
+// Unwrap allows errors.Is(err, ErrCancelled)
+func (e *CancelledError) Unwrap() error {
+	return ErrCancelled
+}
+
+// operation tracks an in-flight workspace operation so it can be cancelled
+type operation struct {
+	ctx           context.Context
+	cancel        context.CancelFunc
+	workspace     string // Workspace whose log the operation's command output is streamed to
+	step          string
+	completed     []string
+	debugLog      string           // TF_LOG_PATH for the operation's commands, empty when debug logging is off
+	correlationID string           // Passed to the operation's commands so provider API calls can be traced back
+	result        *OperationResult // Collects structured output of the operation's -json commands
+	plan          *PlanSummary     // What the operation's plan step would change, for the deployment history
+	timeout       time.Duration    // How long the operation may run, 0 without a limit
+	timer         *time.Timer      // Stops the operation once its timeout expires
+	timedOut      bool             // The operation was stopped by its timeout rather than cancelled
+	env           []string         // The workspace's environment variables, KEY=value
+}
+
+// beginOperation registers a cancellable operation for a working directory.
+// The returned function must be called when the operation finishes.
+func (c *Client) beginOperation(workingDir string) (*operation, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &operation{ctx: ctx, cancel: cancel}
+
+	c.mu.Lock()
+	if c.operations == nil {
+		c.operations = make(map[string]*operation)
+	}
+	c.operations[workingDir] = op
+	c.mu.Unlock()
+
+	return op, func() {
+		c.mu.Lock()
+		if c.operations[workingDir] == op {
+			delete(c.operations, workingDir)
+		}
+		if op.timer != nil {
+			op.timer.Stop()
+		}
+		c.mu.Unlock()
+		cancel()
+	}
+}
+
+// runStep runs one step of an operation, returning a *CancelledError if the operation was cancelled
+// or a *TimeoutError if it ran out of time
+func (c *Client) runStep(op *operation, step string, fn func() error) error {
+	c.mu.Lock()
+	op.step = step
+	c.mu.Unlock()
+
+	if op.ctx.Err() != nil {
+		return c.stoppedError(op)
+	}
+
+	err := fn()
+	if op.ctx.Err() != nil {
+		return c.stoppedError(op)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	op.completed = append(op.completed, step)
+	c.mu.Unlock()
+	return nil
+}
+
+// stoppedError snapshots the progress of an operation that was cancelled or timed out
+func (c *Client) stoppedError(op *operation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if op.timedOut {
+		return &TimeoutError{
+			Timeout:        op.timeout,
+			Step:           op.step,
+			CompletedSteps: append([]string(nil), op.completed...),
+		}
+	}
+	return &CancelledError{
+		Step:           op.step,
+		CompletedSteps: append([]string(nil), op.completed...),
+	}
+}
+
+// command creates a command that runs in its own process group and is terminated
+// together with its children when the working directory's operation is cancelled
+func (c *Client) command(workingDir, name string, args ...string) *exec.Cmd {
+	ctx := context.Background()
+	var env []string
+	c.mu.Lock()
+	if op, ok := c.operations[workingDir]; ok {
+		ctx = op.ctx
+		env = append(env, op.env...)
+		if op.debugLog != "" {
+			env = append(env, "TF_LOG=DEBUG", "TF_LOG_PATH="+op.debugLog)
+		}
+		if op.correlationID != "" {
+			env = append(env,
+				logging.CorrelationIDEnv+"="+op.correlationID,
+				"TF_APPEND_USER_AGENT=provisioner/"+op.correlationID,
+			)
+		}
+	}
+	c.mu.Unlock()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = workingDir
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		// Signal the whole group so providers and shell children stop too
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGTERM)
+	}
+	cmd.WaitDelay = cancelWaitDelay
+	return cmd
+}
+
+// Cancel cancels the in-flight deploy or destroy of a workspace.
+// Returns false if no operation is running for the workspace.
+func (c *Client) Cancel(workspaceName string) bool {
+	workingDir := GetWorkingDir(workspaceName)
+
+	c.mu.Lock()
+	op, ok := c.operations[workingDir]
+	c.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	op.cancel()
+	return true
+}
@@ -0,0 +1,67 @@
+package opentofu
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCancelTerminatesProcessGroup(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	workingDir := GetWorkingDir("cancel-test")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+
+	client := &Client{binaryPath: "tofu"}
+	op, done := client.beginOperation(workingDir)
+	defer done()
+
+	if err := client.runStep(op, "init", func() error { return nil }); err != nil {
+		t.Fatalf("Unexpected error from init step: %v", err)
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		// The shell forks sleep, so the child only stops if the whole group is signalled
+		result <- client.runStep(op, "apply", func() error {
+			return client.executeCustomCommand("sleep 30; echo done", workingDir)
+		})
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	if client.Cancel("other-workspace") {
+		t.Error("Expected Cancel to return false for a workspace with no operation")
+	}
+	if !client.Cancel("cancel-test") {
+		t.Fatal("Expected Cancel to find the in-flight operation")
+	}
+
+	select {
+	case err := <-result:
+		if !errors.Is(err, ErrCancelled) {
+			t.Fatalf("Expected ErrCancelled, got %v", err)
+		}
+		var cancelled *CancelledError
+		if !errors.As(err, &cancelled) {
+			t.Fatalf("Expected *CancelledError, got %T", err)
+		}
+		if cancelled.Step != "apply" {
+			t.Errorf("Expected cancelled step 'apply', got %q", cancelled.Step)
+		}
+		if len(cancelled.CompletedSteps) != 1 || cancelled.CompletedSteps[0] != "init" {
+			t.Errorf("Expected completed steps [init], got %v", cancelled.CompletedSteps)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Cancelled command did not exit; process group was not terminated")
+	}
+
+	// Later steps are not started once cancelled
+	ran := false
+	err := client.runStep(op, "plan", func() error { ran = true; return nil })
+	if ran || !errors.Is(err, ErrCancelled) {
+		t.Errorf("Expected step to be skipped after cancellation, ran=%v err=%v", ran, err)
+	}
+}
@@ -8,7 +8,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 
+	"provisioner/pkg/logging"
 	"provisioner/pkg/template"
 	"provisioner/pkg/workspace"
 
@@ -16,13 +18,29 @@ import (
 )
 
 type Client struct {
-	binaryPath string
+	binaryPath    string
+	infracostPath string          // Infracost binary estimating the cost of deploys, empty if not installed
+	versions      *VersionManager // Pinned OpenTofu versions, created on first use
+	agent         string          // Agent the client runs workspaces of, empty on the daemon host
+
+	mu         sync.Mutex
+	operations map[string]*operation // In-flight operations by working directory
 }
 
 func New() (*Client, error) {
+	// The daemon default version replaces the binary in PATH
+	if version := DefaultTofuVersion(); version != "" {
+		versions := NewVersionManager(getStateDir())
+		binaryPath, err := versions.Binary(version)
+		if err != nil {
+			return nil, err
+		}
+		return &Client{binaryPath: binaryPath, infracostPath: lookupInfracost(), versions: versions}, nil
+	}
+
 	// First try to find tofu in PATH
 	if binaryPath, err := exec.LookPath("tofu"); err == nil {
-		return &Client{binaryPath: binaryPath}, nil
+		return &Client{binaryPath: binaryPath, infracostPath: lookupInfracost()}, nil
 	}
 
 	// Fall back to downloading with TofuDL
@@ -58,41 +76,25 @@ func New() (*Client, error) {
 		return nil, fmt.Errorf("failed to make binary executable: %w", err)
 	}
 
-	return &Client{binaryPath: tmpFile.Name()}, nil
+	return &Client{binaryPath: tmpFile.Name(), infracostPath: lookupInfracost()}, nil
 }
 
 func (c *Client) Init(workingDir string) error {
-	cmd := exec.Command(c.binaryPath, "init")
-	cmd.Dir = workingDir
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	return c.run(workingDir, initArgs(workingDir)...)
+}
 
-	// Include detailed output in error for workspace logs
+// run runs a tofu command, including its output in the error if it fails
+func (c *Client) run(workingDir string, args ...string) error {
+	cmd, err := c.tofuCommand(workingDir, args...)
 	if err != nil {
-		if stderr.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stderr.String())
-		}
-		if stdout.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stdout.String())
-		}
+		return err
 	}
 
-	return err
-}
-
-func (c *Client) Plan(workingDir string) error {
-	cmd := exec.Command(c.binaryPath, "plan")
-	cmd.Dir = workingDir
-
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	flush := c.streamOutput(workingDir, cmd, &stdout, &stderr, false)
 
-	err := cmd.Run()
+	err = cmd.Run()
+	flush()
 
 	// Include detailed output in error for workspace logs
 	if err != nil {
@@ -107,99 +109,77 @@ func (c *Client) Plan(workingDir string) error {
 	return err
 }
 
-func (c *Client) Apply(workingDir string) error {
-	cmd := exec.Command(c.binaryPath, "apply", "-auto-approve")
-	cmd.Dir = workingDir
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
-
-	// Include detailed output in error for workspace logs
-	if err != nil {
-		if stderr.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stderr.String())
-		}
-		if stdout.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stdout.String())
-		}
-	}
-
+// runJSON runs a tofu command with machine-readable output. Applied changes are recorded
+// in the working directory's operation result, and error diagnostics become the error detail.
+func (c *Client) runJSON(workingDir string, args ...string) error {
+	_, err := c.runJSONOutput(workingDir, args...)
 	return err
 }
 
-func (c *Client) PlanWithMode(workingDir, mode string) error {
-	cmd := exec.Command(c.binaryPath, "plan", "-var", fmt.Sprintf("deployment_mode=%s", mode))
-	cmd.Dir = workingDir
+// runJSONOutput is runJSON returning the parsed output as well
+func (c *Client) runJSONOutput(workingDir string, args ...string) (*jsonOutput, error) {
+	cmd, err := c.tofuCommand(workingDir, append(args, "-json")...)
+	if err != nil {
+		return nil, err
+	}
 
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	flush := c.streamOutput(workingDir, cmd, &stdout, &stderr, true)
 
-	err := cmd.Run()
+	err = cmd.Run()
+	flush()
+	out := parseJSONOutput(stdout.Bytes())
 
-	// Include detailed output in error for workspace logs
-	if err != nil {
-		if stderr.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stderr.String())
-		}
-		if stdout.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stdout.String())
+	c.mu.Lock()
+	if op, ok := c.operations[workingDir]; ok && op.result != nil {
+		op.result.record(out)
+		if out.planned != nil {
+			op.plan = out.planSummary()
 		}
 	}
-
-	return err
-}
-
-func (c *Client) ApplyWithMode(workingDir, mode string) error {
-	cmd := exec.Command(c.binaryPath, "apply", "-auto-approve", "-var", fmt.Sprintf("deployment_mode=%s", mode))
-	cmd.Dir = workingDir
-
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	c.mu.Unlock()
 
 	// Include detailed output in error for workspace logs
 	if err != nil {
+		if len(out.errors) > 0 {
+			return out, fmt.Errorf("%w\n\nDetailed output:\n%s", err, strings.Join(out.errors, "\n\n"))
+		}
 		if stderr.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stderr.String())
+			return out, fmt.Errorf("%w\n\nDetailed output:\n%s", err, stderr.String())
 		}
 		if stdout.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stdout.String())
+			return out, fmt.Errorf("%w\n\nDetailed output:\n%s", err, stdout.String())
 		}
 	}
 
-	return err
+	return out, err
 }
 
-func (c *Client) Destroy(workingDir string) error {
-	cmd := exec.Command(c.binaryPath, "destroy", "-auto-approve")
-	cmd.Dir = workingDir
+func (c *Client) Plan(workingDir string) error {
+	return c.runJSON(workingDir, "plan")
+}
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+func (c *Client) Apply(workingDir string) error {
+	return c.runJSON(workingDir, "apply", "-auto-approve")
+}
 
-	err := cmd.Run()
+func (c *Client) PlanWithMode(workingDir, mode string) error {
+	return c.runJSON(workingDir, "plan", "-var", fmt.Sprintf("deployment_mode=%s", mode))
+}
 
-	// Include detailed output in error for workspace logs
-	if err != nil {
-		if stderr.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stderr.String())
-		}
-		if stdout.Len() > 0 {
-			return fmt.Errorf("%w\n\nDetailed output:\n%s", err, stdout.String())
-		}
-	}
+func (c *Client) ApplyWithMode(workingDir, mode string) error {
+	return c.runJSON(workingDir, "apply", "-auto-approve", "-var", fmt.Sprintf("deployment_mode=%s", mode))
+}
 
-	return err
+func (c *Client) Destroy(workingDir string) error {
+	return c.runJSON(workingDir, "destroy", "-auto-approve")
 }
 
-func (c *Client) Deploy(ws *workspace.Workspace) error {
+func (c *Client) Deploy(ws *workspace.Workspace) (err error) {
+	if err := c.checkHost(ws); err != nil {
+		return err
+	}
+
 	// Create persistent working directory based on workspace name
 	stateDir := getStateDir()
 	workingDir := filepath.Join(stateDir, "deployments", ws.Name)
@@ -209,33 +189,82 @@ func (c *Client) Deploy(ws *workspace.Workspace) error {
 		return fmt.Errorf("failed to create working directory: %w", err)
 	}
 
-	// Copy workspace template files to working directory (preserving state files)
-	if err := copyWorkspaceTemplateFiles(ws, workingDir); err != nil {
-		return fmt.Errorf("failed to copy workspace files: %w", err)
+	// Hold the deployment lock for the whole operation, including file preparation
+	unlock, err := acquireDeploymentLock(workingDir, "deploy")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Copy the template files and write the variables (preserving state files)
+	deployed, err := prepareDeployFiles(ws, workingDir, "")
+	if err != nil {
+		return err
+	}
+	history := beginDeployment(ws, workingDir, "", deployed)
+
+	// Register the operation so it can be cancelled
+	op, done := c.beginOperation(workingDir)
+	defer done()
+	op.workspace = ws.Name
+	op.correlationID = logging.CorrelationID(ws.Name)
+	c.limitOperation(op, DeployTimeout(ws))
+	c.enableDebugLog(op, ws, "deploy")
+	c.beginResult(op, "deploy")
+	defer func() { c.finishResult(op, ws.Name, workingDir, err) }()
+	defer c.syncRemoteState(ws, workingDir)
+	defer func() { history.finish(op, err) }()
+
+	// Resolve the workspace's environment variables before any command runs
+	if err := c.setEnvironment(op, ws); err != nil {
+		return err
 	}
 
 	// Check for custom deploy commands
 	if ws.Config.CustomDeploy != nil {
-		return c.deployWithCustomCommands(ws, workingDir, ws.Config.CustomDeploy)
+		if err := c.deployWithCustomCommands(op, ws, workingDir); err != nil {
+			return err
+		}
+		recordDeployedTemplate(ws, deployed)
+		return c.postDeployStep(op, ws, workingDir)
 	}
 
-	// Run OpenTofu sequence: init → plan → apply
-	if err := c.Init(workingDir); err != nil {
+	// Run OpenTofu sequence: init → lint → plan → cost estimate → state backup → apply
+	if err := c.runStep(op, "init", func() error { return c.initWorkingDir(ws, workingDir) }); err != nil {
 		return fmt.Errorf("init failed: %w", err)
 	}
 
-	if err := c.Plan(workingDir); err != nil {
+	if ws.Config.IsLintGateEnabled() {
+		if err := c.runStep(op, "lint", func() error { return c.lintBeforeDeploy(ws, workingDir) }); err != nil {
+			return fmt.Errorf("lint failed: %w", err)
+		}
+	}
+
+	if err := c.runStep(op, "plan", func() error { return c.Plan(workingDir) }); err != nil {
 		return fmt.Errorf("plan failed: %w", err)
 	}
 
-	if err := c.Apply(workingDir); err != nil {
+	if err := c.costStep(op, ws, workingDir, ""); err != nil {
+		return err
+	}
+
+	if err := c.backupStep(op, ws, BackupReasonApply); err != nil {
+		return err
+	}
+
+	if err := c.runStep(op, "apply", func() error { return c.Apply(workingDir) }); err != nil {
 		return fmt.Errorf("apply failed: %w", err)
 	}
 
-	return nil
+	recordDeployedTemplate(ws, deployed)
+	return c.postDeployStep(op, ws, workingDir)
 }
 
-func (c *Client) DeployInMode(ws *workspace.Workspace, mode string) error {
+func (c *Client) DeployInMode(ws *workspace.Workspace, mode string) (err error) {
+	if err := c.checkHost(ws); err != nil {
+		return err
+	}
+
 	// Create persistent working directory based on workspace name
 	stateDir := getStateDir()
 	workingDir := filepath.Join(stateDir, "deployments", ws.Name)
@@ -245,28 +274,73 @@ func (c *Client) DeployInMode(ws *workspace.Workspace, mode string) error {
 		return fmt.Errorf("failed to create working directory: %w", err)
 	}
 
-	// Copy workspace template files to working directory (preserving state files)
-	if err := copyWorkspaceTemplateFiles(ws, workingDir); err != nil {
-		return fmt.Errorf("failed to copy workspace files: %w", err)
+	// Hold the deployment lock for the whole operation, including file preparation
+	unlock, err := acquireDeploymentLock(workingDir, "deploy")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Copy the template files and write the variables (preserving state files)
+	deployed, err := prepareDeployFiles(ws, workingDir, mode)
+	if err != nil {
+		return err
+	}
+	history := beginDeployment(ws, workingDir, mode, deployed)
+
+	// Register the operation so it can be cancelled
+	op, done := c.beginOperation(workingDir)
+	defer done()
+	op.workspace = ws.Name
+	op.correlationID = logging.CorrelationID(ws.Name)
+	c.limitOperation(op, DeployTimeout(ws))
+	c.enableDebugLog(op, ws, "deploy")
+	c.beginResult(op, "deploy")
+	defer func() { c.finishResult(op, ws.Name, workingDir, err) }()
+	defer c.syncRemoteState(ws, workingDir)
+	defer func() { history.finish(op, err) }()
+
+	// Resolve the workspace's environment variables before any command runs
+	if err := c.setEnvironment(op, ws); err != nil {
+		return err
 	}
 
-	// Run OpenTofu sequence: init → plan → apply with mode variable
-	if err := c.Init(workingDir); err != nil {
+	// Run OpenTofu sequence: init → lint → plan → cost estimate → state backup → apply with mode variable
+	if err := c.runStep(op, "init", func() error { return c.initWorkingDir(ws, workingDir) }); err != nil {
 		return fmt.Errorf("init failed: %w", err)
 	}
 
-	if err := c.PlanWithMode(workingDir, mode); err != nil {
+	if ws.Config.IsLintGateEnabled() {
+		if err := c.runStep(op, "lint", func() error { return c.lintBeforeDeploy(ws, workingDir) }); err != nil {
+			return fmt.Errorf("lint failed: %w", err)
+		}
+	}
+
+	if err := c.runStep(op, "plan", func() error { return c.PlanWithMode(workingDir, mode) }); err != nil {
 		return fmt.Errorf("plan failed: %w", err)
 	}
 
-	if err := c.ApplyWithMode(workingDir, mode); err != nil {
+	if err := c.costStep(op, ws, workingDir, mode); err != nil {
+		return err
+	}
+
+	if err := c.backupStep(op, ws, BackupReasonApply); err != nil {
+		return err
+	}
+
+	if err := c.runStep(op, "apply", func() error { return c.ApplyWithMode(workingDir, mode) }); err != nil {
 		return fmt.Errorf("apply failed: %w", err)
 	}
 
-	return nil
+	recordDeployedTemplate(ws, deployed)
+	return c.postDeployStep(op, ws, workingDir)
 }
 
-func (c *Client) DestroyWorkspace(ws *workspace.Workspace) error {
+func (c *Client) DestroyWorkspace(ws *workspace.Workspace) (err error) {
+	if err := c.checkHost(ws); err != nil {
+		return err
+	}
+
 	// Use persistent working directory based on workspace name
 	stateDir := getStateDir()
 	workingDir := filepath.Join(stateDir, "deployments", ws.Name)
@@ -276,67 +350,137 @@ func (c *Client) DestroyWorkspace(ws *workspace.Workspace) error {
 		return fmt.Errorf("failed to create working directory: %w", err)
 	}
 
+	// Hold the deployment lock for the whole operation, including file preparation
+	unlock, err := acquireDeploymentLock(workingDir, "destroy")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
 	// Copy workspace template files to working directory (preserving state files)
-	if err := copyWorkspaceTemplateFiles(ws, workingDir); err != nil {
+	if _, err := copyWorkspaceTemplateFiles(ws, workingDir); err != nil {
 		return fmt.Errorf("failed to copy workspace files: %w", err)
 	}
 
+	// Write variables from the workspace config
+	if err := workspace.WriteConfigVarsFile(workingDir, ws.Config.GetVariables("")); err != nil {
+		return err
+	}
+	if err := pinTofuVersion(ws, workingDir); err != nil {
+		return err
+	}
+
+	if err := writeBackendFiles(ws, workingDir); err != nil {
+		return err
+	}
+
+	// Register the operation so it can be cancelled
+	op, done := c.beginOperation(workingDir)
+	defer done()
+	op.workspace = ws.Name
+	op.correlationID = logging.CorrelationID(ws.Name)
+	c.limitOperation(op, DestroyTimeout(ws))
+	c.enableDebugLog(op, ws, "destroy")
+	c.beginResult(op, "destroy")
+	defer func() { c.finishResult(op, ws.Name, workingDir, err) }()
+	defer c.syncRemoteState(ws, workingDir)
+
+	// Resolve the workspace's environment variables before any command runs
+	if err := c.setEnvironment(op, ws); err != nil {
+		return err
+	}
+
 	// Check for custom destroy commands
 	if ws.Config.CustomDestroy != nil {
-		return c.destroyWithCustomCommands(ws, workingDir, ws.Config.CustomDestroy)
+		return c.destroyWithCustomCommands(op, ws, workingDir)
 	}
 
-	// Run OpenTofu sequence: init → destroy
-	if err := c.Init(workingDir); err != nil {
+	// Run OpenTofu sequence: init → pre-destroy hooks → state backup → destroy
+	if err := c.runStep(op, "init", func() error { return c.initWorkingDir(ws, workingDir) }); err != nil {
 		return fmt.Errorf("init failed: %w", err)
 	}
 
-	if err := c.Destroy(workingDir); err != nil {
+	if err := c.preDestroyStep(op, ws, workingDir); err != nil {
+		return err
+	}
+
+	if err := c.backupStep(op, ws, BackupReasonDestroy); err != nil {
+		return err
+	}
+
+	if err := c.runStep(op, "destroy", func() error { return c.Destroy(workingDir) }); err != nil {
 		return fmt.Errorf("destroy failed: %w", err)
 	}
 
 	return nil
 }
 
-// copyWorkspaceTemplateFiles copies template files to working directory while preserving OpenTofu state
-func copyWorkspaceTemplateFiles(ws *workspace.Workspace, workingDir string) error {
+// prepareDeployFiles copies the files a deploy applies to the working directory and writes the
+// config variables for mode. Rollbacks restore the files and variables of their revision instead.
+// Either way the backend files come from the current config, so state stays where it is.
+func prepareDeployFiles(ws *workspace.Workspace, workingDir, mode string) (*template.Template, error) {
+	if ws.Revision > 0 {
+		deployed, err := restoreSnapshot(ws, workingDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore revision %d: %w", ws.Revision, err)
+		}
+		if err := pinTofuVersion(ws, workingDir); err != nil {
+			return nil, err
+		}
+		return deployed, writeBackendFiles(ws, workingDir)
+	}
+
+	deployed, err := copyWorkspaceTemplateFiles(ws, workingDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy workspace files: %w", err)
+	}
+
+	// Write variables from the workspace config
+	if err := workspace.WriteConfigVarsFile(workingDir, ws.Config.GetVariables(mode)); err != nil {
+		return nil, err
+	}
+	if err := pinTofuVersion(ws, workingDir); err != nil {
+		return nil, err
+	}
+	return deployed, writeBackendFiles(ws, workingDir)
+}
+
+// copyWorkspaceTemplateFiles copies template files to working directory while preserving OpenTofu state.
+// It returns the registry entry of the template copied, nil for workspaces with local files.
+func copyWorkspaceTemplateFiles(ws *workspace.Workspace, workingDir string) (*template.Template, error) {
 	// Determine source directory for templates
-	srcDir := ""
-	templateName := ""
-	templateHash := ""
+	srcDir := ws.Path
+	var copied *template.Template
 
 	if ws.IsUsingTemplate() {
 		// Using a template reference - copy from template directory
 		srcDir = ws.GetTemplateDir()
 		if srcDir == "" {
-			return fmt.Errorf("template directory not found for template '%s'", ws.Config.Template)
+			return nil, fmt.Errorf("template directory not found for template '%s'", ws.Config.Template)
 		}
-		templateName = ws.Config.Template
 
-		// Get template hash for change tracking
-		if hash, err := getTemplateHash(ws.Config.Template); err == nil {
-			templateHash = hash
+		// Remember the version for change tracking; it is recorded once a deploy succeeds
+		if tmpl, err := template.NewManager(getTemplatesDir()).GetTemplate(ws.Config.Template); err == nil {
+			copied = tmpl
 		}
-	} else {
-		// Using local files - copy from workspace directory
-		srcDir = ws.Path
 	}
 
 	// Copy template files while preserving state
 	if err := copyDirectoryFiles(srcDir, workingDir); err != nil {
-		return err
+		return nil, err
 	}
+	return copied, nil
+}
 
-	// Update deployment metadata with template information
-	if templateName != "" {
-		stateDir := getStateDir()
-		if err := workspace.UpdateDeploymentTemplate(stateDir, ws.Name, templateName, templateHash); err != nil {
-			// Log warning but don't fail deployment
-			fmt.Printf("Warning: failed to update deployment template metadata: %v\n", err)
-		}
+// recordDeployedTemplate records the template version a successful deploy applied
+func recordDeployedTemplate(ws *workspace.Workspace, deployed *template.Template) {
+	if deployed == nil {
+		return
+	}
+	if err := workspace.UpdateDeploymentTemplate(getStateDir(), ws.Name, deployed.Name, deployed.ContentHash, deployed.Commit); err != nil {
+		// Log warning but don't fail deployment
+		fmt.Printf("Warning: failed to update deployment template metadata: %v\n", err)
 	}
-
-	return nil
 }
 
 // copyDirectoryFiles copies files from src to dst while preserving OpenTofu state and workspace files
@@ -379,13 +523,15 @@ func copyDirectoryFiles(src, dst string) error {
 
 // shouldSkipFile determines if a file should be skipped during copy to preserve OpenTofu state
 func shouldSkipFile(relPath string) bool {
-	// Skip OpenTofu state files
-	if relPath == "terraform.tfstate" || relPath == "terraform.tfstate.backup" {
+	// Skip OpenTofu state files and the copy of a remote state
+	if relPath == "terraform.tfstate" || relPath == "terraform.tfstate.backup" || relPath == workspace.RemoteStateFileName {
 		return true
 	}
-	// Skip .terraform directory (provider cache, etc.)
-	if relPath == ".terraform" || strings.HasPrefix(relPath, ".terraform/") {
-		return true
+	// Skip .terraform directories (provider cache, etc.), also those of modules tried out locally
+	for _, part := range strings.Split(filepath.ToSlash(relPath), "/") {
+		if part == ".terraform" {
+			return true
+		}
 	}
 	// Skip plan files
 	if strings.HasSuffix(relPath, ".tfplan") {
@@ -417,13 +563,18 @@ func shouldPreserveFile(relPath string) bool {
 		return true
 	}
 
-	// Preserve provisioner metadata
-	if relPath == ".provisioner-metadata.json" {
+	// Preserve provisioner metadata, the pinned OpenTofu version and the fingerprint of the last init
+	if relPath == ".provisioner-metadata.json" || relPath == TofuVersionFile || relPath == InitFingerprintFile {
 		return true
 	}
 
 	// Preserve lock files
-	if relPath == ".terraform.lock.hcl" {
+	if relPath == ".terraform.lock.hcl" || relPath == LockFileName {
+		return true
+	}
+
+	// Preserve the deployments of template jobs
+	if relPath == TemplateJobsDir {
 		return true
 	}
 
@@ -484,36 +635,48 @@ func getStateDir() string {
 }
 
 // deployWithCustomCommands executes custom deployment commands
-func (c *Client) deployWithCustomCommands(ws *workspace.Workspace, workingDir string, customDeploy *workspace.CustomDeployConfig) error {
+func (c *Client) deployWithCustomCommands(op *operation, ws *workspace.Workspace, workingDir string) error {
+	customDeploy := ws.Config.CustomDeploy
+
 	// Execute custom init command (or fall back to default)
 	if customDeploy.InitCommand != "" {
-		if err := c.executeCustomCommand(customDeploy.InitCommand, workingDir); err != nil {
+		if err := c.runStep(op, "custom init", func() error { return c.executeCustomCommand(customDeploy.InitCommand, workingDir) }); err != nil {
 			return fmt.Errorf("custom init failed: %w", err)
 		}
 	} else {
-		if err := c.Init(workingDir); err != nil {
+		if err := c.runStep(op, "init", func() error { return c.initWorkingDir(ws, workingDir) }); err != nil {
 			return fmt.Errorf("init failed: %w", err)
 		}
 	}
 
+	if ws.Config.IsLintGateEnabled() {
+		if err := c.runStep(op, "lint", func() error { return c.lintBeforeDeploy(ws, workingDir) }); err != nil {
+			return fmt.Errorf("lint failed: %w", err)
+		}
+	}
+
 	// Execute custom plan command (or fall back to default)
 	if customDeploy.PlanCommand != "" {
-		if err := c.executeCustomCommand(customDeploy.PlanCommand, workingDir); err != nil {
+		if err := c.runStep(op, "custom plan", func() error { return c.executeCustomCommand(customDeploy.PlanCommand, workingDir) }); err != nil {
 			return fmt.Errorf("custom plan failed: %w", err)
 		}
 	} else {
-		if err := c.Plan(workingDir); err != nil {
+		if err := c.runStep(op, "plan", func() error { return c.Plan(workingDir) }); err != nil {
 			return fmt.Errorf("plan failed: %w", err)
 		}
 	}
 
+	if err := c.backupStep(op, ws, BackupReasonApply); err != nil {
+		return err
+	}
+
 	// Execute custom apply command (or fall back to default)
 	if customDeploy.ApplyCommand != "" {
-		if err := c.executeCustomCommand(customDeploy.ApplyCommand, workingDir); err != nil {
+		if err := c.runStep(op, "custom apply", func() error { return c.executeCustomCommand(customDeploy.ApplyCommand, workingDir) }); err != nil {
 			return fmt.Errorf("custom apply failed: %w", err)
 		}
 	} else {
-		if err := c.Apply(workingDir); err != nil {
+		if err := c.runStep(op, "apply", func() error { return c.Apply(workingDir) }); err != nil {
 			return fmt.Errorf("apply failed: %w", err)
 		}
 	}
@@ -522,25 +685,35 @@ func (c *Client) deployWithCustomCommands(ws *workspace.Workspace, workingDir st
 }
 
 // destroyWithCustomCommands executes custom destroy commands
-func (c *Client) destroyWithCustomCommands(ws *workspace.Workspace, workingDir string, customDestroy *workspace.CustomDestroyConfig) error {
+func (c *Client) destroyWithCustomCommands(op *operation, ws *workspace.Workspace, workingDir string) error {
+	customDestroy := ws.Config.CustomDestroy
+
 	// Execute custom init command (or fall back to default)
 	if customDestroy.InitCommand != "" {
-		if err := c.executeCustomCommand(customDestroy.InitCommand, workingDir); err != nil {
+		if err := c.runStep(op, "custom init", func() error { return c.executeCustomCommand(customDestroy.InitCommand, workingDir) }); err != nil {
 			return fmt.Errorf("custom init failed: %w", err)
 		}
 	} else {
-		if err := c.Init(workingDir); err != nil {
+		if err := c.runStep(op, "init", func() error { return c.initWorkingDir(ws, workingDir) }); err != nil {
 			return fmt.Errorf("init failed: %w", err)
 		}
 	}
 
+	if err := c.preDestroyStep(op, ws, workingDir); err != nil {
+		return err
+	}
+
+	if err := c.backupStep(op, ws, BackupReasonDestroy); err != nil {
+		return err
+	}
+
 	// Execute custom destroy command (or fall back to default)
 	if customDestroy.DestroyCommand != "" {
-		if err := c.executeCustomCommand(customDestroy.DestroyCommand, workingDir); err != nil {
+		if err := c.runStep(op, "custom destroy", func() error { return c.executeCustomCommand(customDestroy.DestroyCommand, workingDir) }); err != nil {
 			return fmt.Errorf("custom destroy failed: %w", err)
 		}
 	} else {
-		if err := c.Destroy(workingDir); err != nil {
+		if err := c.runStep(op, "destroy", func() error { return c.Destroy(workingDir) }); err != nil {
 			return fmt.Errorf("destroy failed: %w", err)
 		}
 	}
@@ -550,14 +723,13 @@ func (c *Client) destroyWithCustomCommands(ws *workspace.Workspace, workingDir s
 
 // executeCustomCommand runs a custom shell command in the working directory
 func (c *Client) executeCustomCommand(command, workingDir string) error {
-	cmd := exec.Command("sh", "-c", command)
-	cmd.Dir = workingDir
+	cmd := c.command(workingDir, "sh", "-c", command)
 
 	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	flush := c.streamOutput(workingDir, cmd, &stdout, &stderr, false)
 
 	err := cmd.Run()
+	flush()
 
 	// Include detailed output in error
 	if err != nil {
@@ -572,6 +744,9 @@ func (c *Client) executeCustomCommand(command, workingDir string) error {
 	return err
 }
 
+// TemplateJobsDir is the directory of a workspace's deployment holding its template jobs' deployments
+const TemplateJobsDir = "jobs"
+
 // GetWorkingDir returns the working directory for a workspace
 func GetWorkingDir(wsName string) string {
 	stateDir := getStateDir()
@@ -591,13 +766,6 @@ func CleanWorkingDir(wsName string) error {
 	return os.RemoveAll(workingDir)
 }
 
-// getTemplateHash gets the content hash for a template
-func getTemplateHash(templateName string) (string, error) {
-	templatesDir := getTemplatesDir()
-	manager := template.NewManager(templatesDir)
-	return manager.GetTemplateContentHash(templateName)
-}
-
 // getTemplatesDir returns the templates directory path
 func getTemplatesDir() string {
 	stateDir := getStateDir()
@@ -3,9 +3,37 @@ package opentofu
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"provisioner/pkg/workspace"
 )
 
+// writeTestTofu writes script as a tofu stand-in and returns its path
+func writeTestTofu(t *testing.T, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "tofu")
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake tofu: %v", err)
+	}
+	return path
+}
+
+// newTestWorkspace returns a workspace called name whose directory holds mainTF
+func newTestWorkspace(t *testing.T, name, mainTF string) *workspace.Workspace {
+	t.Helper()
+
+	wsPath := filepath.Join(t.TempDir(), name)
+	if err := os.MkdirAll(wsPath, 0755); err != nil {
+		t.Fatalf("Failed to create workspace dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(wsPath, "main.tf"), []byte(mainTF), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	return &workspace.Workspace{Name: name, Path: wsPath}
+}
+
 func TestCleanWorkingDirectory(t *testing.T) {
 	// Create temporary working directory
 	tempDir, err := os.MkdirTemp("", "test-working-dir")
@@ -193,6 +221,45 @@ func TestCopyDirectoryFilesWithCleanup(t *testing.T) {
 	}
 }
 
+func TestCopyDirectoryFilesWithModules(t *testing.T) {
+	srcDir := t.TempDir()
+	dstDir := t.TempDir()
+
+	files := map[string]string{
+		"network.tf":                       `module "vpc" { source = "./modules/vpc" }`,
+		"modules/vpc/main.tf":              "# vpc module",
+		"modules/vpc/templates/user.tftpl": "user=${name}",
+		"modules/vpc/.terraform/cache":     "# left from running tofu in the module",
+	}
+	for file, content := range files {
+		path := filepath.Join(srcDir, file)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", file, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", file, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(dstDir, "modules", "removed"), 0755); err != nil {
+		t.Fatalf("Failed to create stale module: %v", err)
+	}
+
+	if err := copyDirectoryFiles(srcDir, dstDir); err != nil {
+		t.Fatalf("copyDirectoryFiles failed: %v", err)
+	}
+
+	for _, file := range []string{"network.tf", "modules/vpc/main.tf", "modules/vpc/templates/user.tftpl"} {
+		if data, err := os.ReadFile(filepath.Join(dstDir, file)); err != nil || string(data) != files[file] {
+			t.Errorf("Expected %s to be copied, got %q (%v)", file, data, err)
+		}
+	}
+	for _, path := range []string{"modules/vpc/.terraform", "modules/removed"} {
+		if _, err := os.Stat(filepath.Join(dstDir, path)); !os.IsNotExist(err) {
+			t.Errorf("Expected %s not to be in the working directory", path)
+		}
+	}
+}
+
 func TestShouldPreserveFile(t *testing.T) {
 	testCases := []struct {
 		file     string
@@ -208,7 +275,9 @@ func TestShouldPreserveFile(t *testing.T) {
 		{"terraform.tfvars", true},
 		{"terraform.tfvars.json", true},
 		{".provisioner-metadata.json", true},
+		{".provisioner.lock", true},
 		{".terraform/providers/local.json", true},
+		{"jobs", true},
 
 		// Should not preserve (stale template files)
 		{"main.tf", false},
@@ -236,3 +305,25 @@ func TestCleanWorkingDirectoryNonExistent(t *testing.T) {
 		t.Errorf("cleanWorkingDirectory on non-existent directory should not error, got: %v", err)
 	}
 }
+
+func TestDeployInModeWritesConfigVariables(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+
+	ws := newResultTestWorkspace(t)
+	ws.Config.Variables = map[string]interface{}{"instance_count": 1, "region": "eu-west-1"}
+	ws.Config.ModeVariables = map[string]map[string]interface{}{"busy": {"instance_count": 4}}
+
+	client := &Client{binaryPath: writeFakeTofu(t, applyJSONOutput, 0)}
+	if err := client.DeployInMode(ws, "busy"); err != nil {
+		t.Fatalf("DeployInMode failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(stateDir, "deployments", ws.Name, "terraform.tfvars.json"))
+	if err != nil {
+		t.Fatalf("Expected terraform.tfvars.json to be written: %v", err)
+	}
+	if !strings.Contains(string(data), `"instance_count": 4`) || !strings.Contains(string(data), `"region": "eu-west-1"`) {
+		t.Errorf("Expected busy mode variables, got:\n%s", data)
+	}
+}
@@ -0,0 +1,125 @@
+package opentofu
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// CostEstimate is the estimated monthly cost of a deploy's configuration
+type CostEstimate struct {
+	MonthlyCost float64   `json:"monthly_cost"`
+	Currency    string    `json:"currency"`
+	EstimatedAt time.Time `json:"estimated_at"`
+}
+
+// String formats the estimate, e.g. "123.45 USD/month"
+func (e *CostEstimate) String() string {
+	return fmt.Sprintf("%.2f %s/month", e.MonthlyCost, e.Currency)
+}
+
+// lookupInfracost returns the Infracost binary in PATH, empty if it is not installed
+func lookupInfracost() string {
+	path, err := exec.LookPath("infracost")
+	if err != nil {
+		return ""
+	}
+	return path
+}
+
+// estimateCost runs "infracost breakdown" on a working directory, with the mode variable for
+// mode deploys. Infracost reads the variables files and its API key from the environment.
+func (c *Client) estimateCost(workingDir, mode string) (*CostEstimate, error) {
+	args := []string{"breakdown", "--path", workingDir, "--format", "json", "--no-color"}
+	if mode != "" {
+		args = append(args, "--terraform-var", fmt.Sprintf("deployment_mode=%s", mode))
+	}
+	cmd := c.command(workingDir, c.infracostPath, args...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%w\n\nDetailed output:\n%s", err, stderr.String())
+		}
+		return nil, err
+	}
+	return parseCostEstimate(stdout.Bytes())
+}
+
+// parseCostEstimate reads the total monthly cost of Infracost's JSON output. Configurations
+// without priced resources have no total and cost nothing.
+func parseCostEstimate(data []byte) (*CostEstimate, error) {
+	var breakdown struct {
+		Currency         string  `json:"currency"`
+		TotalMonthlyCost *string `json:"totalMonthlyCost"`
+	}
+	if err := json.Unmarshal(data, &breakdown); err != nil {
+		return nil, fmt.Errorf("failed to parse cost estimate: %w", err)
+	}
+
+	estimate := &CostEstimate{Currency: breakdown.Currency, EstimatedAt: time.Now()}
+	if estimate.Currency == "" {
+		estimate.Currency = "USD"
+	}
+	if breakdown.TotalMonthlyCost != nil {
+		cost, err := strconv.ParseFloat(*breakdown.TotalMonthlyCost, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid total monthly cost '%s': %w", *breakdown.TotalMonthlyCost, err)
+		}
+		estimate.MonthlyCost = cost
+	}
+	return estimate, nil
+}
+
+// costStep estimates a deploy's monthly cost after its plan and records it in the operation
+// result. A workspace with max_monthly_cost is not deployed over budget, or without an estimate,
+// unless the deploy ignores its budget. Without a budget a missing estimate only costs a log line.
+func (c *Client) costStep(op *operation, ws *workspace.Workspace, workingDir, mode string) error {
+	budget := ws.Config.MaxMonthlyCost
+	enforce := budget > 0 && !ws.IgnoreBudget
+
+	if c.infracostPath == "" {
+		if enforce {
+			return fmt.Errorf("cost estimate failed: infracost is not installed but max_monthly_cost is set, use --ignore-budget to deploy anyway")
+		}
+		return nil
+	}
+
+	var estimate *CostEstimate
+	if err := c.runStep(op, "cost estimate", func() error {
+		var err error
+		estimate, err = c.estimateCost(workingDir, mode)
+		return err
+	}); err != nil {
+		if errors.Is(err, ErrCancelled) || errors.Is(err, ErrTimedOut) || enforce {
+			return fmt.Errorf("cost estimate failed: %w", err)
+		}
+		logging.LogWorkspace(ws.Name, "Cost estimate failed, deploying without one: %s", firstLine(err.Error()))
+		return nil
+	}
+
+	c.mu.Lock()
+	if op.result != nil {
+		op.result.CostEstimate = estimate
+	}
+	c.mu.Unlock()
+	logging.LogWorkspace(ws.Name, "Estimated cost: %s", estimate)
+
+	if budget > 0 && estimate.MonthlyCost > budget {
+		if !enforce {
+			logging.LogWorkspace(ws.Name, "Estimated cost exceeds max_monthly_cost %.2f, deploying anyway as requested", budget)
+			return nil
+		}
+		return fmt.Errorf("estimated cost %s exceeds max_monthly_cost %.2f, use --ignore-budget to deploy anyway", estimate, budget)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFakeInfracost writes an infracost script that prints a breakdown with the given total
+func writeFakeInfracost(t *testing.T, totalMonthlyCost string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "infracost")
+	script := `#!/bin/sh
+echo '{"currency":"EUR","totalMonthlyCost":"` + totalMonthlyCost + `"}'
+`
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatalf("Failed to write fake infracost: %v", err)
+	}
+	return path
+}
+
+func TestParseCostEstimate(t *testing.T) {
+	estimate, err := parseCostEstimate([]byte(`{"currency":"USD","totalMonthlyCost":"123.456"}`))
+	if err != nil {
+		t.Fatalf("parseCostEstimate failed: %v", err)
+	}
+	if estimate.MonthlyCost != 123.456 || estimate.String() != "123.46 USD/month" {
+		t.Errorf("Unexpected estimate %+v (%s)", estimate, estimate)
+	}
+
+	// Nothing priced has no total
+	estimate, err = parseCostEstimate([]byte(`{"totalMonthlyCost":null}`))
+	if err != nil || estimate.MonthlyCost != 0 || estimate.Currency != "USD" {
+		t.Errorf("Expected a zero USD estimate, got %+v, %v", estimate, err)
+	}
+
+	if _, err := parseCostEstimate([]byte(`{"totalMonthlyCost":"lots"}`)); err == nil {
+		t.Error("Expected an error for an invalid total")
+	}
+}
+
+func TestDeployRecordsCostEstimate(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	ws := newResultTestWorkspace(t)
+	ws.Config.MaxMonthlyCost = 100
+	client := &Client{binaryPath: writeFakeTofu(t, applyJSONOutput, 0), infracostPath: writeFakeInfracost(t, "42.5")}
+	if err := client.Deploy(ws); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	results, err := LoadOperationResults(ws.Name)
+	if err != nil || results.Deploy == nil || results.Deploy.CostEstimate == nil {
+		t.Fatalf("Expected a deploy result with a cost estimate, got %+v, %v", results, err)
+	}
+	if estimate := results.Deploy.CostEstimate; estimate.MonthlyCost != 42.5 || estimate.Currency != "EUR" {
+		t.Errorf("Unexpected cost estimate %+v", estimate)
+	}
+}
+
+func TestDeployOverBudget(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	ws := newResultTestWorkspace(t)
+	ws.Config.MaxMonthlyCost = 100
+	client := &Client{binaryPath: writeFakeTofu(t, applyJSONOutput, 0), infracostPath: writeFakeInfracost(t, "250")}
+
+	err := client.Deploy(ws)
+	if err == nil || !strings.Contains(err.Error(), "exceeds max_monthly_cost 100.00") {
+		t.Fatalf("Expected the deploy to be blocked by its budget, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(GetWorkingDir(ws.Name), "terraform.tfstate")); !os.IsNotExist(err) {
+		t.Errorf("Expected no apply over budget, got state: %v", err)
+	}
+
+	// Ignoring the budget deploys anyway
+	ws.IgnoreBudget = true
+	if err := client.Deploy(ws); err != nil {
+		t.Fatalf("Deploy ignoring the budget failed: %v", err)
+	}
+}
+
+func TestDeployWithBudgetNeedsInfracost(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	ws := newResultTestWorkspace(t)
+	client := &Client{binaryPath: writeFakeTofu(t, applyJSONOutput, 0)}
+	if err := client.Deploy(ws); err != nil {
+		t.Fatalf("Expected deploys without a budget to skip cost estimation, got %v", err)
+	}
+
+	ws.Config.MaxMonthlyCost = 100
+	if err := client.Deploy(ws); err == nil || !strings.Contains(err.Error(), "infracost is not installed") {
+		t.Errorf("Expected a budget without infracost to block the deploy, got %v", err)
+	}
+}
@@ -0,0 +1,27 @@
+package opentofu
+
+import (
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// enableDebugLog captures OpenTofu debug output for the operation when the workspace has debug logging on.
+// The setting is read per operation so "workspacectl debug" takes effect without restarting the daemon.
+func (c *Client) enableDebugLog(op *operation, ws *workspace.Workspace, operationName string) {
+	if !ws.IsDebugLoggingEnabled() {
+		return
+	}
+
+	logPath, err := ws.NewDebugLogFile(operationName, time.Now())
+	if err != nil {
+		logging.LogWorkspace(ws.Name, "Debug logging disabled for this %s: %v", operationName, err)
+		return
+	}
+
+	c.mu.Lock()
+	op.debugLog = logPath
+	c.mu.Unlock()
+	logging.LogWorkspace(ws.Name, "Writing OpenTofu debug log to %s", logPath)
+}
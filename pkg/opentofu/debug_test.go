@@ -0,0 +1,44 @@
+package opentofu
+
+import (
+	"os"
+	"slices"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestDebugLogSetsTofuLogEnv(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+	t.Setenv("PROVISIONER_LOG_DIR", t.TempDir())
+
+	ws := &workspace.Workspace{Name: "debug-test"}
+	workingDir := GetWorkingDir(ws.Name)
+	client := &Client{binaryPath: "tofu"}
+
+	op, done := client.beginOperation(workingDir)
+	client.enableDebugLog(op, ws, "deploy")
+	if op.debugLog != "" || client.command(workingDir, "true").Env != nil {
+		t.Error("Expected no debug log when debug logging is off")
+	}
+	done()
+
+	if err := workspace.SetDebugOverride(os.Getenv("PROVISIONER_STATE_DIR"), ws.Name, true); err != nil {
+		t.Fatalf("Failed to enable debug logging: %v", err)
+	}
+
+	op, done = client.beginOperation(workingDir)
+	defer done()
+	client.enableDebugLog(op, ws, "deploy")
+	if op.debugLog == "" {
+		t.Fatal("Expected debug log to be created")
+	}
+	if _, err := os.Stat(op.debugLog); err != nil {
+		t.Errorf("Debug log file not created: %v", err)
+	}
+
+	env := client.command(workingDir, "true").Env
+	if !slices.Contains(env, "TF_LOG=DEBUG") || !slices.Contains(env, "TF_LOG_PATH="+op.debugLog) {
+		t.Errorf("Expected TF_LOG and TF_LOG_PATH in command env")
+	}
+}
@@ -0,0 +1,20 @@
+package opentofu
+
+import (
+	"fmt"
+
+	"provisioner/pkg/workspace"
+)
+
+// setEnvironment passes the workspace's environment variables, with secret references resolved,
+// to the operation's commands
+func (c *Client) setEnvironment(op *operation, ws *workspace.Workspace) error {
+	env, err := ws.Config.ResolveEnvironment()
+	if err != nil {
+		return fmt.Errorf("workspace '%s': %w", ws.Name, err)
+	}
+	c.mu.Lock()
+	op.env = env
+	c.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,57 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestWorkspaceEnvironmentPassedToCommands(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("do-secret\n"), 0600); err != nil {
+		t.Fatalf("Failed to write token file: %v", err)
+	}
+	t.Setenv("STAGING_AWS_KEY", "aws-secret")
+
+	ws := &workspace.Workspace{Name: "env-test", Config: workspace.Config{Environment: map[string]string{
+		"AWS_PROFILE":           "staging",
+		"AWS_ACCESS_KEY_ID":     "env:STAGING_AWS_KEY",
+		"DIGITALOCEAN_TOKEN":    "file:" + tokenFile,
+		"DIGITALOCEAN_API_HOST": "api.example.com",
+	}}}
+
+	workingDir := t.TempDir()
+	client := &Client{binaryPath: "tofu"}
+	op, done := client.beginOperation(workingDir)
+	defer done()
+	if err := client.setEnvironment(op, ws); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	check := `test "$AWS_PROFILE" = staging && test "$AWS_ACCESS_KEY_ID" = aws-secret && test "$DIGITALOCEAN_TOKEN" = do-secret`
+	if err := client.executeCustomCommand(check, workingDir); err != nil {
+		t.Errorf("Expected the workspace environment in the command, got %v", err)
+	}
+
+	// Other operations don't see it
+	if err := client.executeCustomCommand(`test -z "$AWS_PROFILE"`, t.TempDir()); err != nil {
+		t.Errorf("Expected no workspace environment outside the operation, got %v", err)
+	}
+}
+
+func TestWorkspaceEnvironmentUnresolved(t *testing.T) {
+	ws := &workspace.Workspace{Name: "env-test", Config: workspace.Config{Environment: map[string]string{
+		"AWS_SECRET_ACCESS_KEY": "env:PROVISIONER_TEST_UNSET_SECRET",
+	}}}
+
+	client := &Client{binaryPath: "tofu"}
+	op, done := client.beginOperation(t.TempDir())
+	defer done()
+	err := client.setEnvironment(op, ws)
+	if err == nil || !strings.Contains(err.Error(), "PROVISIONER_TEST_UNSET_SECRET is not set") {
+		t.Errorf("Expected an error for the unset secret, got %v", err)
+	}
+}
@@ -0,0 +1,366 @@
+package opentofu
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"provisioner/pkg/gitops"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/template"
+	"provisioner/pkg/workspace"
+)
+
+// historyLimit is the number of deploys kept in a workspace's deployment history
+const historyLimit = 50
+
+// snapshotLimit is the number of successful deploys whose files are kept for rollback
+const snapshotLimit = 10
+
+// historyFileName is the deployment history file in a workspace's history directory
+const historyFileName = "history.json"
+
+// PlanCounts is how many resources a deploy's plan would add, change and destroy
+type PlanCounts struct {
+	Add     int `json:"add"`
+	Change  int `json:"change"`
+	Destroy int `json:"destroy"`
+}
+
+// DeploymentRecord is one deploy in a workspace's deployment history
+type DeploymentRecord struct {
+	Revision       int                    `json:"revision"`
+	StartedAt      time.Time              `json:"started_at"`
+	FinishedAt     time.Time              `json:"finished_at"`
+	Mode           string                 `json:"mode,omitempty"`
+	TemplateName   string                 `json:"template_name,omitempty"`
+	TemplateHash   string                 `json:"template_hash,omitempty"` // Template content hash, or of the local files
+	TemplateCommit string                 `json:"template_commit,omitempty"`
+	ConfigCommit   string                 `json:"config_commit,omitempty"` // Commit of the gitops repository the workspace config was synced from
+	Variables      map[string]interface{} `json:"variables,omitempty"`     // Config and set variables, secrets masked
+	Plan           *PlanCounts            `json:"plan,omitempty"`          // Nil for custom commands and deploys that failed before planning
+	Success        bool                   `json:"success"`
+	Error          string                 `json:"error,omitempty"`
+	RollbackOf     int                    `json:"rollback_of,omitempty"` // Revision this deploy rolled back to
+	Snapshot       bool                   `json:"snapshot"`              // The deployed files are kept for rollback
+}
+
+// GetHistoryDir returns the directory holding a workspace's deployment history and snapshots
+func GetHistoryDir(stateDir, wsName string) string {
+	return filepath.Join(stateDir, "history", wsName)
+}
+
+// getSnapshotDir returns the directory of the files a revision deployed
+func getSnapshotDir(wsName string, revision int) string {
+	return filepath.Join(GetHistoryDir(getStateDir(), wsName), strconv.Itoa(revision))
+}
+
+// LoadDeploymentHistory returns a workspace's recorded deploys, oldest first.
+// Returns nil if none have been recorded.
+func LoadDeploymentHistory(wsName string) ([]DeploymentRecord, error) {
+	data, err := os.ReadFile(filepath.Join(GetHistoryDir(getStateDir(), wsName), historyFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read deployment history: %w", err)
+	}
+
+	var records []DeploymentRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse deployment history: %w", err)
+	}
+	return records, nil
+}
+
+// saveDeploymentHistory writes a workspace's deployment history
+func saveDeploymentHistory(wsName string, records []DeploymentRecord) error {
+	path := filepath.Join(GetHistoryDir(getStateDir(), wsName), historyFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal deployment history: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write deployment history: %w", err)
+	}
+	return nil
+}
+
+// FindRollbackTarget returns the deploy a rollback redeploys: revision, or with revision 0 the
+// successful deploy before the most recent one
+func FindRollbackTarget(records []DeploymentRecord, revision int) (*DeploymentRecord, error) {
+	if revision == 0 {
+		successful := 0
+		for i := len(records) - 1; i >= 0; i-- {
+			if !records[i].Success {
+				continue
+			}
+			if successful++; successful == 2 {
+				revision = records[i].Revision
+				break
+			}
+		}
+		if revision == 0 {
+			return nil, errors.New("no earlier successful deploy to roll back to")
+		}
+	}
+
+	for i := range records {
+		record := &records[i]
+		if record.Revision != revision {
+			continue
+		}
+		if !record.Success {
+			return nil, fmt.Errorf("revision %d failed to deploy and can't be rolled back to", revision)
+		}
+		if !record.Snapshot {
+			return nil, fmt.Errorf("the files of revision %d are no longer kept (only the last %d successful deploys are)", revision, snapshotLimit)
+		}
+		return record, nil
+	}
+	return nil, fmt.Errorf("revision %d not found in deployment history", revision)
+}
+
+// deployment tracks the history record of a deploy in progress
+type deployment struct {
+	ws         *workspace.Workspace
+	workingDir string
+	record     DeploymentRecord
+}
+
+// beginDeployment snapshots the files a deploy is about to apply under the next revision.
+// Failing to snapshot disables rollback to this deploy but never fails it.
+func beginDeployment(ws *workspace.Workspace, workingDir, mode string, deployed *template.Template) *deployment {
+	d := &deployment{ws: ws, workingDir: workingDir}
+	d.record = DeploymentRecord{StartedAt: time.Now(), Mode: mode, RollbackOf: ws.Revision}
+
+	records, err := LoadDeploymentHistory(ws.Name)
+	if err != nil {
+		logging.LogWorkspace(ws.Name, "Failed to load deployment history: %v", err)
+	}
+	d.record.Revision = 1
+	if len(records) > 0 {
+		d.record.Revision = records[len(records)-1].Revision + 1
+	}
+
+	snapshotDir := getSnapshotDir(ws.Name, d.record.Revision)
+	if err := snapshotWorkingDirectory(workingDir, snapshotDir); err != nil {
+		logging.LogWorkspace(ws.Name, "Failed to keep files of revision %d for rollback: %v", d.record.Revision, err)
+		_ = os.RemoveAll(snapshotDir)
+	} else {
+		d.record.Snapshot = true
+	}
+
+	if deployed != nil {
+		d.record.TemplateName = deployed.Name
+		d.record.TemplateHash = deployed.ContentHash
+		d.record.TemplateCommit = deployed.Commit
+	} else if hash, err := hashFiles(snapshotDir); err == nil && d.record.Snapshot {
+		d.record.TemplateHash = hash
+	}
+	d.record.Variables = snapshotVariables(ws.Name, snapshotDir)
+	d.record.ConfigCommit = gitops.ConfigCommit()
+	return d
+}
+
+// finish records the deploy's outcome and plan in the workspace's deployment history
+func (d *deployment) finish(op *operation, err error) {
+	d.record.FinishedAt = time.Now()
+	d.record.Success = err == nil
+	if err != nil {
+		d.record.Error = logging.RedactWorkspace(d.ws.Name, firstLine(err.Error()))
+	}
+	if op != nil && op.plan != nil {
+		d.record.Plan = &PlanCounts{Add: op.plan.Add, Change: op.plan.Change, Destroy: op.plan.Destroy}
+	}
+
+	records, loadErr := LoadDeploymentHistory(d.ws.Name)
+	if loadErr != nil {
+		records = nil
+	}
+	records = append(records, d.record)
+	if len(records) > historyLimit {
+		records = records[len(records)-historyLimit:]
+	}
+	records = pruneSnapshots(d.ws.Name, records)
+
+	if err := saveDeploymentHistory(d.ws.Name, records); err != nil {
+		logging.LogWorkspace(d.ws.Name, "Failed to record deployment history: %v", err)
+	}
+}
+
+// pruneSnapshots removes the files of failed deploys, of deploys beyond the most recent successful
+// snapshotLimit and of revisions dropped from the history
+func pruneSnapshots(wsName string, records []DeploymentRecord) []DeploymentRecord {
+	kept := make(map[string]bool)
+	successful := 0
+	for i := len(records) - 1; i >= 0; i-- {
+		record := &records[i]
+		if !record.Snapshot {
+			continue
+		}
+		if record.Success && successful < snapshotLimit {
+			successful++
+			kept[strconv.Itoa(record.Revision)] = true
+			continue
+		}
+		record.Snapshot = false
+	}
+
+	entries, err := os.ReadDir(GetHistoryDir(getStateDir(), wsName))
+	if err != nil {
+		return records
+	}
+	for _, entry := range entries {
+		if entry.IsDir() && !kept[entry.Name()] {
+			_ = os.RemoveAll(filepath.Join(GetHistoryDir(getStateDir(), wsName), entry.Name()))
+		}
+	}
+	return records
+}
+
+// snapshotWorkingDirectory copies the files a deploy applies, including its variables files,
+// leaving out OpenTofu state, caches, generated backend files and provisioner bookkeeping
+func snapshotWorkingDirectory(workingDir, snapshotDir string) error {
+	if err := os.RemoveAll(snapshotDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(snapshotDir, 0700); err != nil {
+		return err
+	}
+
+	return filepath.Walk(workingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(workingDir, path)
+		if err != nil || relPath == "." {
+			return err
+		}
+		if shouldSkipFile(relPath) || relPath == LockFileName || relPath == ".provisioner-metadata.json" ||
+			relPath == BackendFileName || relPath == BackendConfigFileName || relPath == InitFingerprintFile {
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		dstPath := filepath.Join(snapshotDir, relPath)
+		if info.IsDir() {
+			return os.MkdirAll(dstPath, info.Mode())
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dstPath, data, info.Mode())
+	})
+}
+
+// restoreSnapshot replaces the working directory's deployment files with those a revision
+// deployed. Its variables files replace the current ones, even if the revision had none.
+func restoreSnapshot(ws *workspace.Workspace, workingDir string) (*template.Template, error) {
+	records, err := LoadDeploymentHistory(ws.Name)
+	if err != nil {
+		return nil, err
+	}
+	record, err := FindRollbackTarget(records, ws.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range []string{workspace.ConfigVarsFileName, workspace.VarsFileName} {
+		if err := os.Remove(filepath.Join(workingDir, name)); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove variables file: %w", err)
+		}
+	}
+	if err := copyDirectoryFiles(getSnapshotDir(ws.Name, record.Revision), workingDir); err != nil {
+		return nil, err
+	}
+
+	if record.TemplateName == "" {
+		return nil, nil
+	}
+	return &template.Template{Name: record.TemplateName, ContentHash: record.TemplateHash, Commit: record.TemplateCommit}, nil
+}
+
+// snapshotVariables returns the variables a snapshot deploys with, secret values masked
+func snapshotVariables(wsName, snapshotDir string) map[string]interface{} {
+	vars := make(map[string]interface{})
+	if data, err := os.ReadFile(filepath.Join(snapshotDir, workspace.ConfigVarsFileName)); err == nil {
+		_ = json.Unmarshal(data, &vars)
+	}
+	if data, err := os.ReadFile(filepath.Join(snapshotDir, workspace.VarsFileName)); err == nil {
+		var setVars map[string]string
+		if json.Unmarshal(data, &setVars) == nil {
+			for key, value := range setVars {
+				vars[key] = value
+			}
+		}
+	}
+
+	var secretVars []string
+	if metadata, err := workspace.LoadDeploymentMetadata(getStateDir(), wsName); err == nil {
+		secretVars = metadata.SecretVars
+	}
+	for key := range vars {
+		if workspace.IsSecretVar(key, secretVars) {
+			vars[key] = workspace.MaskValue(fmt.Sprint(vars[key]))
+		}
+	}
+
+	if len(vars) == 0 {
+		return nil
+	}
+	return vars
+}
+
+// hashFiles hashes the names and contents of the files under dir, leaving out variables files
+func hashFiles(dir string) (string, error) {
+	var fileHashes []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relPath, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if relPath == workspace.ConfigVarsFileName || relPath == workspace.VarsFileName {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		fileHashes = append(fileHashes, fmt.Sprintf("%s:%x", relPath, sha256.Sum256(data)))
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(fileHashes)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(fileHashes, "\n")))), nil
+}
+
+// firstLine returns the first line of an error for the history, the details are in the log
+func firstLine(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	return line
+}
@@ -0,0 +1,140 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+const historyPlanOutput = `{"@level":"info","@message":"Plan: 1 to add, 0 to change, 0 to destroy.","type":"change_summary","changes":{"add":1,"change":0,"import":0,"remove":0,"operation":"plan"}}`
+
+// historyFakeTofu is a tofu stand-in whose plans add one resource and whose applies succeed
+// unless the configuration contains "fail"
+const historyFakeTofu = `#!/bin/sh
+case "$1" in
+  plan)
+    echo '` + historyPlanOutput + `'
+    ;;
+  apply)
+    grep -q fail main.tf && exit 1
+    ;;
+esac
+exit 0
+`
+
+func newHistoryTestWorkspace(t *testing.T, mainTF string, vars map[string]interface{}) *workspace.Workspace {
+	ws := newTestWorkspace(t, "history-test", mainTF)
+	ws.Config.Variables = vars
+	return ws
+}
+
+func TestDeployRecordsHistoryAndRollsBack(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+	client := &Client{binaryPath: writeTestTofu(t, historyFakeTofu)}
+
+	deploys := []struct {
+		mainTF string
+		vars   map[string]interface{}
+	}{
+		{`# v1`, map[string]interface{}{"size": "small", "db_password": "hunter2"}},
+		{`# v2`, map[string]interface{}{"size": "large", "db_password": "hunter2"}},
+		{`# v3 fail`, map[string]interface{}{"size": "huge"}},
+	}
+	for i, deploy := range deploys {
+		err := client.Deploy(newHistoryTestWorkspace(t, deploy.mainTF, deploy.vars))
+		if failed := i == 2; (err != nil) != failed {
+			t.Fatalf("Deploy %d: unexpected error %v", i+1, err)
+		}
+	}
+
+	records, err := LoadDeploymentHistory("history-test")
+	if err != nil {
+		t.Fatalf("Failed to load history: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("Expected 3 recorded deploys, got %d", len(records))
+	}
+	first := records[0]
+	if first.Revision != 1 || !first.Success || !first.Snapshot || first.TemplateHash == "" {
+		t.Errorf("Unexpected first record %+v", first)
+	}
+	if first.Plan == nil || first.Plan.Add != 1 {
+		t.Errorf("Expected the plan summary to be recorded, got %+v", first.Plan)
+	}
+	if first.Variables["size"] != "small" || first.Variables["db_password"] != "********" {
+		t.Errorf("Expected variables with masked secrets, got %v", first.Variables)
+	}
+	if records[1].TemplateHash == first.TemplateHash {
+		t.Error("Expected changed files to change the hash")
+	}
+	if failed := records[2]; failed.Success || failed.Snapshot || failed.Error == "" {
+		t.Errorf("Expected a failed deploy without kept files, got %+v", failed)
+	}
+	if _, err := os.Stat(getSnapshotDir("history-test", 3)); !os.IsNotExist(err) {
+		t.Error("Expected the files of the failed deploy to be removed")
+	}
+
+	target, err := FindRollbackTarget(records, 0)
+	if err != nil || target.Revision != 1 {
+		t.Fatalf("Expected revision 1 as rollback target, got %+v, %v", target, err)
+	}
+
+	// The rollback deploys revision 1's files and variables, not the workspace's current ones
+	ws := newHistoryTestWorkspace(t, `# v3 fail`, map[string]interface{}{"size": "huge"})
+	ws.Revision = 1
+	if err := client.Deploy(ws); err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+
+	workingDir := GetWorkingDir("history-test")
+	if data, _ := os.ReadFile(filepath.Join(workingDir, "main.tf")); string(data) != "# v1" {
+		t.Errorf("Expected revision 1's main.tf, got %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(workingDir, workspace.ConfigVarsFileName)); !strings.Contains(string(data), "small") {
+		t.Errorf("Expected revision 1's variables, got %s", data)
+	}
+
+	records, _ = LoadDeploymentHistory("history-test")
+	rollback := records[len(records)-1]
+	if rollback.Revision != 4 || rollback.RollbackOf != 1 || !rollback.Success || rollback.TemplateHash != first.TemplateHash {
+		t.Errorf("Unexpected rollback record %+v", rollback)
+	}
+}
+
+func TestFindRollbackTarget(t *testing.T) {
+	records := []DeploymentRecord{
+		{Revision: 1, Success: true},
+		{Revision: 2, Success: true, Snapshot: true},
+		{Revision: 3, Success: true, Snapshot: true},
+	}
+
+	tests := []struct {
+		revision int
+		expected int
+		err      string
+	}{
+		{0, 2, ""},
+		{3, 3, ""},
+		{1, 0, "no longer kept"},
+		{9, 0, "not found"},
+	}
+	for _, tt := range tests {
+		target, err := FindRollbackTarget(records, tt.revision)
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Revision %d: expected error containing %q, got %v", tt.revision, tt.err, err)
+			}
+			continue
+		}
+		if err != nil || target.Revision != tt.expected {
+			t.Errorf("Revision %d: expected target %d, got %+v, %v", tt.revision, tt.expected, target, err)
+		}
+	}
+
+	if _, err := FindRollbackTarget(records[:1], 0); err == nil {
+		t.Error("Expected no target without an earlier successful deploy")
+	}
+}
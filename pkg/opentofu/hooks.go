@@ -0,0 +1,95 @@
+package opentofu
+
+import (
+	"errors"
+	"fmt"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// ErrDegraded is returned (wrapped in a *DegradedError) when a deploy applied its changes but a
+// post_deploy hook failed
+var ErrDegraded = errors.New("post_deploy hook failed")
+
+// ErrDestroyBlocked is returned (wrapped in a *DestroyBlockedError) when a pre_destroy hook failed
+// and the destroy did not start
+var ErrDestroyBlocked = errors.New("destroy blocked by pre_destroy hook")
+
+// DegradedError describes the post_deploy hook that failed after a successful apply
+type DegradedError struct {
+	Hook string // Command of the failed hook
+	Err  error
+}
+
+func (e *DegradedError) Error() string {
+	return fmt.Sprintf("deployed, but %s: %s: %v", ErrDegraded, e.Hook, e.Err)
+}
+
+// Unwrap allows errors.Is(err, ErrDegraded)
+func (e *DegradedError) Unwrap() error {
+	return ErrDegraded
+}
+
+// DestroyBlockedError describes the pre_destroy hook that stopped a destroy
+type DestroyBlockedError struct {
+	Hook string // Command of the failed hook
+	Err  error
+}
+
+func (e *DestroyBlockedError) Error() string {
+	return fmt.Sprintf("%s: %s: %v", ErrDestroyBlocked, e.Hook, e.Err)
+}
+
+// Unwrap allows errors.Is(err, ErrDestroyBlocked)
+func (e *DestroyBlockedError) Unwrap() error {
+	return ErrDestroyBlocked
+}
+
+// postDeployStep runs the workspace's post_deploy hooks after a successful apply. A failing hook
+// is returned as a *DegradedError.
+func (c *Client) postDeployStep(op *operation, ws *workspace.Workspace, workingDir string) error {
+	hook, err := c.runHooks(op, ws, workingDir, "post_deploy", ws.Config.GetPostDeployHooks())
+	if hook != "" {
+		return &DegradedError{Hook: hook, Err: err}
+	}
+	return err
+}
+
+// preDestroyStep runs the workspace's pre_destroy hooks once the working directory is initialized.
+// A failing hook is returned as a *DestroyBlockedError, leaving the resources untouched.
+func (c *Client) preDestroyStep(op *operation, ws *workspace.Workspace, workingDir string) error {
+	hook, err := c.runHooks(op, ws, workingDir, "pre_destroy", ws.Config.GetPreDestroyHooks())
+	if hook != "" {
+		return &DestroyBlockedError{Hook: hook, Err: err}
+	}
+	return err
+}
+
+// runHooks runs hook commands in turn as the operation's step, with the workspace's outputs as
+// TF_OUTPUT_* environment variables. It stops at the first failing hook and returns it with its
+// error; a cancellation or timeout is returned without a hook.
+func (c *Client) runHooks(op *operation, ws *workspace.Workspace, workingDir, step string, hooks []string) (string, error) {
+	if len(hooks) == 0 {
+		return "", nil
+	}
+
+	outputs, err := c.Output(workingDir)
+	if err != nil {
+		logging.LogWorkspace(ws.Name, "Failed to read outputs for %s hooks: %s", step, firstLine(err.Error()))
+	}
+	c.mu.Lock()
+	op.env = append(op.env, OutputEnvironment(outputs)...)
+	c.mu.Unlock()
+
+	for _, hook := range hooks {
+		err := c.runStep(op, step, func() error { return c.executeCustomCommand(hook, workingDir) })
+		if errors.Is(err, ErrCancelled) || errors.Is(err, ErrTimedOut) {
+			return "", err
+		}
+		if err != nil {
+			return hook, err
+		}
+	}
+	return "", nil
+}
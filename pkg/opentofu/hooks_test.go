@@ -0,0 +1,89 @@
+package opentofu
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestPostDeployHooks(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+	script := `#!/bin/sh
+[ "$1" = "output" ] && echo '{"url":{"sensitive":false,"type":"string","value":"http://app.example.com"}}'
+exit 0
+`
+	client := &Client{binaryPath: writeTestTofu(t, script)}
+	workingDir := t.TempDir()
+
+	run := func(hooks ...string) error {
+		op, done := client.beginOperation(workingDir)
+		defer done()
+		ws := &workspace.Workspace{Name: "hooks-test", Config: workspace.Config{Hooks: &workspace.HooksConfig{PostDeploy: hooks}}}
+		return client.postDeployStep(op, ws, workingDir)
+	}
+
+	// Hooks see the outputs and run in turn
+	if err := run(`test "$TF_OUTPUT_URL" = http://app.example.com && touch first`, "touch second"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, name := range []string{"first", "second"} {
+		if _, err := os.Stat(filepath.Join(workingDir, name)); err != nil {
+			t.Errorf("Expected hook to create %s: %v", name, err)
+		}
+	}
+
+	// The first failure stops the others
+	err := run("echo unhealthy >&2; exit 1", "touch third")
+	var degraded *DegradedError
+	if !errors.As(err, &degraded) || degraded.Hook != "echo unhealthy >&2; exit 1" || !errors.Is(err, ErrDegraded) {
+		t.Fatalf("Expected a degraded deploy, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workingDir, "third")); !os.IsNotExist(err) {
+		t.Error("Expected no hook to run after a failed one")
+	}
+
+	if err := run(); err != nil {
+		t.Errorf("Expected nothing to run without hooks, got %v", err)
+	}
+}
+
+func TestPreDestroyHooks(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+	script := `#!/bin/sh
+[ "$1" = "output" ] && echo '{"sessions":{"sensitive":false,"type":"number","value":3}}'
+exit 0
+`
+	client := &Client{binaryPath: writeTestTofu(t, script)}
+	workingDir := t.TempDir()
+
+	run := func(hooks ...string) error {
+		op, done := client.beginOperation(workingDir)
+		defer done()
+		ws := &workspace.Workspace{Name: "hooks-test", Config: workspace.Config{Hooks: &workspace.HooksConfig{PreDestroy: hooks}}}
+		return client.preDestroyStep(op, ws, workingDir)
+	}
+
+	if err := run("touch checked"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workingDir, "checked")); err != nil {
+		t.Errorf("Expected the hook to run: %v", err)
+	}
+
+	// A failing check blocks the destroy
+	guard := `test "$TF_OUTPUT_SESSIONS" = 0`
+	err := run(guard, "touch second")
+	var blocked *DestroyBlockedError
+	if !errors.As(err, &blocked) || blocked.Hook != guard || !errors.Is(err, ErrDestroyBlocked) {
+		t.Fatalf("Expected a blocked destroy, got %v", err)
+	}
+	if errors.Is(err, ErrDegraded) {
+		t.Error("Expected a blocked destroy not to be a degraded deploy")
+	}
+	if _, err := os.Stat(filepath.Join(workingDir, "second")); !os.IsNotExist(err) {
+		t.Error("Expected no hook to run after a failed one")
+	}
+}
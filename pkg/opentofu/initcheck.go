@@ -0,0 +1,113 @@
+package opentofu
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// InitFingerprintFile records, in a working directory, the fingerprint of what the last
+// successful "tofu init" there depended on
+const InitFingerprintFile = ".provisioner-init"
+
+// initWorkingDir runs "tofu init" in a workspace's working directory unless it was initialized
+// before and neither the configuration, the dependency lock file nor the OpenTofu binary changed
+// since. Workspaces with always_init set are initialized every time.
+func (c *Client) initWorkingDir(ws *workspace.Workspace, workingDir string) error {
+	fingerprintPath := filepath.Join(workingDir, InitFingerprintFile)
+	fingerprint, err := c.initFingerprint(workingDir)
+	if err != nil {
+		return err
+	}
+
+	if !ws.Config.AlwaysInit {
+		recorded, _ := os.ReadFile(fingerprintPath)
+		if _, statErr := os.Stat(filepath.Join(workingDir, ".terraform")); statErr == nil && string(recorded) == fingerprint {
+			logging.LogWorkspace(ws.Name, "Skipping init, nothing changed since the last one")
+			return nil
+		}
+	}
+
+	// A failed or interrupted init leaves the directory in an unknown state
+	if err := os.Remove(fingerprintPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove %s: %w", InitFingerprintFile, err)
+	}
+	if err := c.Init(workingDir); err != nil {
+		return err
+	}
+
+	// Init may have written or updated the lock file
+	if fingerprint, err = c.initFingerprint(workingDir); err != nil {
+		return err
+	}
+	if err := os.WriteFile(fingerprintPath, []byte(fingerprint), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", InitFingerprintFile, err)
+	}
+	return nil
+}
+
+// initFingerprint hashes what "tofu init" depends on in a working directory: the configuration
+// files, which declare providers, modules and the backend, the backend settings, the dependency
+// lock file and the OpenTofu binary the directory runs with
+func (c *Client) initFingerprint(workingDir string) (string, error) {
+	hash := sha256.New()
+	err := filepath.Walk(workingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := filepath.Rel(workingDir, path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".terraform" || relPath == TemplateJobsDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !isInitInput(relPath) {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = file.Close() }()
+		_, _ = fmt.Fprintf(hash, "%s\x00", filepath.ToSlash(relPath))
+		_, err = io.Copy(hash, file)
+		return err
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint working directory: %w", err)
+	}
+
+	// The binary's path names a pinned version, its size and time tell an upgrade in place apart
+	binaryPath, err := c.tofuBinary(workingDir)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(binaryPath); err == nil {
+		_, _ = fmt.Fprintf(hash, "tofu\x00%s\x00%d\x00%d", binaryPath, info.Size(), info.ModTime().UnixNano())
+	} else {
+		_, _ = fmt.Fprintf(hash, "tofu\x00%s", binaryPath)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// isInitInput reports whether a working directory file affects "tofu init"
+func isInitInput(relPath string) bool {
+	for _, suffix := range []string{".tf", ".tf.json", ".tofu", ".tofu.json"} {
+		if strings.HasSuffix(relPath, suffix) {
+			return true
+		}
+	}
+	return relPath == ".terraform.lock.hcl" || relPath == BackendConfigFileName || relPath == TofuVersionFile
+}
@@ -0,0 +1,94 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestInitSkippedWhenNothingChanged(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+	dir := t.TempDir()
+	inits := filepath.Join(dir, "inits")
+	script := `#!/bin/sh
+if [ "$1" = "init" ]; then
+  echo init >> "` + inits + `"
+  mkdir -p .terraform
+  echo 'provider "registry.opentofu.org/hashicorp/null" {}' > .terraform.lock.hcl
+fi
+exit 0
+`
+	binaryPath := writeTestTofu(t, script)
+	countInits := func() int {
+		data, _ := os.ReadFile(inits)
+		return strings.Count(string(data), "init")
+	}
+
+	workingDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(workingDir, "main.tf"), []byte(`resource "null_resource" "a" {}`), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	client := &Client{binaryPath: binaryPath}
+	ws := &workspace.Workspace{Name: "init-test"}
+
+	for i := 0; i < 2; i++ {
+		if err := client.initWorkingDir(ws, workingDir); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if countInits() != 1 {
+		t.Fatalf("Expected the second init to be skipped, got %d inits", countInits())
+	}
+
+	// A changed configuration needs a new init
+	if err := os.WriteFile(filepath.Join(workingDir, "main.tf"), []byte(`module "net" { source = "./net" }`), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	if err := client.initWorkingDir(ws, workingDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if countInits() != 2 {
+		t.Errorf("Expected init after the configuration changed, got %d inits", countInits())
+	}
+
+	// So does a lost provider cache
+	if err := os.RemoveAll(filepath.Join(workingDir, ".terraform")); err != nil {
+		t.Fatalf("Failed to remove .terraform: %v", err)
+	}
+	if err := client.initWorkingDir(ws, workingDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if countInits() != 3 {
+		t.Errorf("Expected init without a .terraform directory, got %d inits", countInits())
+	}
+
+	// always_init runs it every time
+	ws.Config.AlwaysInit = true
+	if err := client.initWorkingDir(ws, workingDir); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if countInits() != 4 {
+		t.Errorf("Expected init with always_init, got %d inits", countInits())
+	}
+}
+
+func TestInitFingerprintInputs(t *testing.T) {
+	for relPath, want := range map[string]bool{
+		"main.tf":                    true,
+		"modules/net/main.tf":        true,
+		"override.tf.json":           true,
+		".terraform.lock.hcl":        true,
+		BackendConfigFileName:        true,
+		TofuVersionFile:              true,
+		"terraform.tfvars.json":      false,
+		"terraform.tfstate":          false,
+		".provisioner-metadata.json": false,
+	} {
+		if got := isInitInput(relPath); got != want {
+			t.Errorf("isInitInput(%q) = %v, want %v", relPath, got, want)
+		}
+	}
+}
@@ -8,6 +8,9 @@ type TofuClient interface {
 	Deploy(ws *workspace.Workspace) error
 	DeployInMode(ws *workspace.Workspace, mode string) error
 	DestroyWorkspace(ws *workspace.Workspace) error
+	RefreshWorkspace(ws *workspace.Workspace, mode string) error
+	RefreshOutputs(ws *workspace.Workspace, mode string) (map[string]OutputValue, error)
+	Cancel(workspaceName string) bool
 
 	// Low-level operations for job execution
 	Init(workingDir string) error
@@ -16,6 +19,7 @@ type TofuClient interface {
 	Destroy(workingDir string) error
 	PlanWithMode(workingDir, mode string) error
 	ApplyWithMode(workingDir, mode string) error
+	Output(workingDir string) (map[string]OutputValue, error)
 }
 
 // Ensure Client implements TofuClient interface
@@ -0,0 +1,335 @@
+package opentofu
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"provisioner/pkg/lint"
+	"provisioner/pkg/template"
+	"provisioner/pkg/workspace"
+)
+
+// validateOutput is the machine-readable result of `tofu validate -json`
+type validateOutput struct {
+	Diagnostics []struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+		Detail   string `json:"detail"`
+		Range    *struct {
+			Filename string `json:"filename"`
+			Start    struct {
+				Line int `json:"line"`
+			} `json:"start"`
+		} `json:"range"`
+	} `json:"diagnostics"`
+}
+
+// Validate runs tofu validate in an initialized working directory and returns its diagnostics
+func (c *Client) Validate(workingDir string) ([]lint.Finding, error) {
+	cmd, err := c.tofuCommand(workingDir, "validate", "-json", "-no-color")
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Invalid configurations exit non-zero with the diagnostics on stdout
+	runErr := cmd.Run()
+
+	var output validateOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		if runErr != nil && stderr.Len() > 0 {
+			return nil, fmt.Errorf("%w\n\nDetailed output:\n%s", runErr, stderr.String())
+		}
+		return nil, fmt.Errorf("failed to parse validate output: %w", err)
+	}
+
+	var findings []lint.Finding
+	for _, diag := range output.Diagnostics {
+		finding := lint.Finding{
+			Rule:     lint.RuleValidate,
+			Severity: lint.SeverityWarning,
+			Message:  diag.Summary,
+		}
+		if diag.Severity == "error" {
+			finding.Severity = lint.SeverityError
+		}
+		if diag.Detail != "" {
+			finding.Message += ": " + strings.Join(strings.Fields(diag.Detail), " ")
+		}
+		if diag.Range != nil {
+			finding.File = diag.Range.Filename
+			finding.Line = diag.Range.Start.Line
+		}
+		findings = append(findings, finding)
+	}
+
+	return findings, nil
+}
+
+// LintWorkspace lints the configuration a workspace deploys, its local files or its template.
+// With a client, the configuration is also checked by tofu validate in a scratch directory.
+func LintWorkspace(client *Client, ws *workspace.Workspace) (*lint.Report, error) {
+	srcDir, err := workspaceSourceDir(ws)
+	if err != nil {
+		return nil, err
+	}
+	return lintDirWithVersion(client, srcDir, ws.Config.GetLintSkip(), ws.Config.TofuVersion)
+}
+
+// LintDir lints a configuration directory, skipping the given rules. With a client, the
+// configuration is also checked by tofu validate in a scratch copy initialized without backend.
+func LintDir(client *Client, srcDir string, skip []string) (*lint.Report, error) {
+	return lintDirWithVersion(client, srcDir, skip, "")
+}
+
+// lintDirWithVersion is LintDir running tofu validate in tofuVersion if set
+func lintDirWithVersion(client *Client, srcDir string, skip []string, tofuVersion string) (*lint.Report, error) {
+	if client == nil {
+		return lintDir(srcDir, skip, nil)
+	}
+
+	scratchDir, err := os.MkdirTemp("", "provisioner-lint-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lint directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(scratchDir) }()
+
+	if err := copyDirectoryFiles(srcDir, scratchDir); err != nil {
+		return nil, fmt.Errorf("failed to copy configuration: %w", err)
+	}
+	if err := pinScratchVersion(scratchDir, tofuVersion); err != nil {
+		return nil, err
+	}
+
+	return lintDir(scratchDir, skip, func() ([]lint.Finding, error) {
+		if err := client.run(scratchDir, "init", "-backend=false", "-input=false"); err != nil {
+			return nil, fmt.Errorf("init failed: %w", err)
+		}
+		return client.Validate(scratchDir)
+	})
+}
+
+// lintDir runs the custom checks and, if given, validate on a directory
+func lintDir(dir string, skip []string, validate func() ([]lint.Finding, error)) (*lint.Report, error) {
+	report := &lint.Report{}
+
+	findings, err := lint.CheckDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	report.Add(findings, skip)
+
+	if validate != nil {
+		findings, err := validate()
+		if err != nil {
+			return nil, err
+		}
+		report.Add(findings, skip)
+	}
+
+	report.Sort()
+	return report, nil
+}
+
+// lintBeforeDeploy is the pre-deploy lint gate: it lints the initialized working directory
+// and fails on errors, or on warnings with fail_on_warnings. Other findings are printed.
+func (c *Client) lintBeforeDeploy(ws *workspace.Workspace, workingDir string) error {
+	report, err := lintDir(workingDir, ws.Config.GetLintSkip(), func() ([]lint.Finding, error) {
+		return c.Validate(workingDir)
+	})
+	if err != nil {
+		return err
+	}
+
+	if report.Failed(ws.Config.Lint.FailOnWarnings) {
+		return fmt.Errorf("%d error(s), %d warning(s)\n\nDetailed output:\n%s",
+			report.Count(lint.SeverityError), report.Count(lint.SeverityWarning), formatFindings(report))
+	}
+	for _, finding := range report.Findings {
+		fmt.Printf("Lint: %s\n", finding)
+	}
+	return nil
+}
+
+// formatFindings returns the findings one per line
+func formatFindings(report *lint.Report) string {
+	lines := make([]string, len(report.Findings))
+	for i, finding := range report.Findings {
+		lines[i] = finding.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// workspaceSourceDir returns the directory holding the configuration a workspace deploys
+func workspaceSourceDir(ws *workspace.Workspace) (string, error) {
+	if !ws.IsUsingTemplate() {
+		return ws.Path, nil
+	}
+	srcDir := ws.GetTemplateDir()
+	if srcDir == "" {
+		return "", fmt.Errorf("template directory not found for template '%s'", ws.Config.Template)
+	}
+	return srcDir, nil
+}
+
+// lintTarget is a configuration linted by the lint command
+type lintTarget struct {
+	Name           string       `json:"name"`
+	Kind           string       `json:"kind"` // workspace or template
+	Report         *lint.Report `json:"report,omitempty"`
+	Error          string       `json:"error,omitempty"`
+	failOnWarnings bool
+}
+
+// RunLintCommand lints workspaces or templates: NAME, --all or --template NAME, with
+// --no-validate to skip tofu validate and --json for machine-readable output
+func RunLintCommand(args []string) error {
+	validate := true
+	jsonOutput := false
+	all := false
+	var names, templates []string
+
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i]; arg {
+		case "--no-validate":
+			validate = false
+		case "--json":
+			jsonOutput = true
+		case "--all":
+			all = true
+		case "--template":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--template requires a template name")
+			}
+			i++
+			templates = append(templates, args[i])
+		default:
+			if strings.HasPrefix(arg, "-") {
+				return fmt.Errorf("unknown lint option '%s'", arg)
+			}
+			names = append(names, arg)
+		}
+	}
+	if !all && len(names) == 0 && len(templates) == 0 {
+		return fmt.Errorf("lint requires workspace NAME, --all or --template NAME")
+	}
+
+	var workspaces []workspace.Workspace
+	if all || len(names) > 0 {
+		loaded, err := workspace.LoadWorkspaces(workspace.GetDefaultWorkspacesDir())
+		if err != nil {
+			return err
+		}
+		workspaces = loaded
+	}
+
+	var selected []*workspace.Workspace
+	if all {
+		for i := range workspaces {
+			selected = append(selected, &workspaces[i])
+		}
+		names = nil
+	}
+	for _, name := range names {
+		found := false
+		for i := range workspaces {
+			if workspaces[i].Name == name {
+				selected = append(selected, &workspaces[i])
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("workspace '%s' not found", name)
+		}
+	}
+
+	var client *Client
+	if validate {
+		var err error
+		if client, err = New(); err != nil {
+			return fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+		}
+	}
+
+	var targets []lintTarget
+	for _, ws := range selected {
+		target := lintTarget{Name: ws.Name, Kind: "workspace", failOnWarnings: ws.Config.Lint != nil && ws.Config.Lint.FailOnWarnings}
+		report, err := LintWorkspace(client, ws)
+		target.Report = report
+		if err != nil {
+			target.Error = err.Error()
+		}
+		targets = append(targets, target)
+	}
+
+	templateManager := template.NewManager(getTemplatesDir())
+	for _, name := range templates {
+		target := lintTarget{Name: name, Kind: "template"}
+		if _, err := templateManager.GetTemplate(name); err != nil {
+			target.Error = err.Error()
+		} else if report, err := LintDir(client, templateManager.GetTemplatePath(name), nil); err != nil {
+			target.Error = err.Error()
+		} else {
+			target.Report = report
+		}
+		targets = append(targets, target)
+	}
+
+	failed := 0
+	for _, target := range targets {
+		if target.Error != "" || target.Report.Failed(target.failOnWarnings) {
+			failed++
+		}
+	}
+
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(targets); err != nil {
+			return fmt.Errorf("failed to marshal lint results: %w", err)
+		}
+	} else {
+		printLintResults(targets)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("lint failed for %d of %d configurations", failed, len(targets))
+	}
+	return nil
+}
+
+// printLintResults prints each target's findings
+func printLintResults(targets []lintTarget) {
+	for _, target := range targets {
+		name := target.Name
+		if target.Kind == "template" {
+			name = "template " + name
+		}
+
+		if target.Error != "" {
+			fmt.Printf("✗ %s: %s\n", name, target.Error)
+			continue
+		}
+
+		errors := target.Report.Count(lint.SeverityError)
+		warnings := target.Report.Count(lint.SeverityWarning)
+		switch {
+		case target.Report.Failed(target.failOnWarnings):
+			fmt.Printf("✗ %s: %d error(s), %d warning(s)\n", name, errors, warnings)
+		case warnings > 0:
+			fmt.Printf("! %s: %d warning(s)\n", name, warnings)
+		default:
+			fmt.Printf("✓ %s: no issues\n", name)
+		}
+		for _, finding := range target.Report.Findings {
+			fmt.Printf("    %s\n", finding)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package opentofu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/lint"
+	"provisioner/pkg/workspace"
+)
+
+// writeFakeValidate writes a tofu binary whose validate prints the given JSON and exits with code
+func writeFakeValidate(t *testing.T, output string, code int) string {
+	t.Helper()
+	script := fmt.Sprintf("#!/bin/sh\nif [ \"$1\" = \"validate\" ]; then\n  printf '%%s\\n' '%s'\n  exit %d\nfi\nexit 0\n", output, code)
+	return writeTestTofu(t, script)
+}
+
+func writeLintConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	config := `terraform {
+  required_version = ">= 1.6"
+}
+
+module "vpc" {
+  source = "terraform-aws-modules/vpc/aws"
+}
+`
+	if err := os.WriteFile(filepath.Join(dir, "main.tf"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	return dir
+}
+
+func TestValidate(t *testing.T) {
+	output := `{"valid":false,"error_count":1,"warning_count":0,"diagnostics":[{"severity":"error","summary":"Unsupported argument","detail":"An argument named \"regoin\" is\nnot expected here.","range":{"filename":"main.tf","start":{"line":3}}}]}`
+	client := &Client{binaryPath: writeFakeValidate(t, output, 1)}
+
+	findings, err := client.Validate(t.TempDir())
+	if err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if len(findings) != 1 {
+		t.Fatalf("Expected one finding, got %v", findings)
+	}
+	expected := lint.Finding{Rule: lint.RuleValidate, Severity: lint.SeverityError, File: "main.tf", Line: 3, Message: `Unsupported argument: An argument named "regoin" is not expected here.`}
+	if findings[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, findings[0])
+	}
+}
+
+func TestLintBeforeDeploy(t *testing.T) {
+	workingDir := writeLintConfig(t)
+	valid := `{"valid":true,"error_count":0,"warning_count":0,"diagnostics":[]}`
+	client := &Client{binaryPath: writeFakeValidate(t, valid, 0)}
+	ws := &workspace.Workspace{Name: "lint-app", Config: workspace.Config{Lint: &workspace.LintConfig{PreDeploy: true}}}
+
+	// Warnings alone don't stop the deploy
+	if err := client.lintBeforeDeploy(ws, workingDir); err != nil {
+		t.Errorf("Expected warnings to pass the gate, got %v", err)
+	}
+
+	ws.Config.Lint.FailOnWarnings = true
+	err := client.lintBeforeDeploy(ws, workingDir)
+	if err == nil || !strings.Contains(err.Error(), "unpinned-module-source") {
+		t.Errorf("Expected the unpinned module to fail the gate, got %v", err)
+	}
+
+	ws.Config.Lint.Skip = []string{lint.RuleUnpinnedModuleSource}
+	if err := client.lintBeforeDeploy(ws, workingDir); err != nil {
+		t.Errorf("Expected skipped rule not to fail the gate, got %v", err)
+	}
+
+	invalid := `{"valid":false,"error_count":1,"warning_count":0,"diagnostics":[{"severity":"error","summary":"Missing required argument"}]}`
+	client.binaryPath = writeFakeValidate(t, invalid, 1)
+	ws.Config.Lint.FailOnWarnings = false
+	if err := client.lintBeforeDeploy(ws, workingDir); err == nil || !strings.Contains(err.Error(), "Missing required argument") {
+		t.Errorf("Expected validate errors to fail the gate, got %v", err)
+	}
+}
+
+func TestLintWorkspaceWithoutValidate(t *testing.T) {
+	ws := &workspace.Workspace{Name: "lint-app", Path: writeLintConfig(t)}
+
+	report, err := LintWorkspace(nil, ws)
+	if err != nil {
+		t.Fatalf("LintWorkspace failed: %v", err)
+	}
+	if len(report.Findings) != 1 || report.Findings[0].Rule != lint.RuleUnpinnedModuleSource {
+		t.Errorf("Expected only the unpinned module, got %v", report.Findings)
+	}
+}
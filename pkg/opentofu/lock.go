@@ -0,0 +1,137 @@
+package opentofu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// LockFileName is the lock file guarding a deployment directory against concurrent tofu runs
+const LockFileName = ".provisioner.lock"
+
+// ErrLocked is returned (wrapped in a *LockedError) when another process holds the deployment lock
+var ErrLocked = errors.New("deployment is locked")
+
+// LockInfo describes the holder of a deployment lock
+type LockInfo struct {
+	Operation  string    `json:"operation"`
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+func (l *LockInfo) String() string {
+	return fmt.Sprintf("%s (PID %d on %s since %s)", l.Operation, l.PID, l.Host, logging.FormatTime(l.AcquiredAt))
+}
+
+// LockedError reports who holds the lock of a deployment directory
+type LockedError struct {
+	WorkingDir string
+	Holder     *LockInfo // nil if the holder did not record itself yet
+}
+
+func (e *LockedError) Error() string {
+	if e.Holder == nil {
+		return fmt.Sprintf("%s: %s", ErrLocked, e.WorkingDir)
+	}
+	return fmt.Sprintf("%s by %s", ErrLocked, e.Holder)
+}
+
+// Unwrap allows errors.Is(err, ErrLocked)
+func (e *LockedError) Unwrap() error {
+	return ErrLocked
+}
+
+// acquireDeploymentLock takes an exclusive flock on the deployment directory's lock file without
+// waiting, so the daemon and CLI never run tofu on the same state at once. The lock is released
+// by the returned function or, if the process dies, by the kernel.
+func acquireDeploymentLock(workingDir, operation string) (func(), error) {
+	lockPath := filepath.Join(workingDir, LockFileName)
+	file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = file.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			holder, _ := ReadLockInfo(workingDir)
+			return nil, &LockedError{WorkingDir: workingDir, Holder: holder}
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", lockPath, err)
+	}
+
+	// Record the holder for error messages and force-unlock; failure to do so does not affect locking
+	host, _ := os.Hostname()
+	info := LockInfo{Operation: operation, PID: os.Getpid(), Host: host, AcquiredAt: time.Now()}
+	if data, err := json.Marshal(info); err == nil {
+		if err := file.Truncate(0); err == nil {
+			_, _ = file.WriteAt(data, 0)
+		}
+	}
+
+	return func() {
+		// The file stays in place: removing it would let two processes lock different inodes
+		_ = file.Truncate(0)
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		_ = file.Close()
+	}, nil
+}
+
+// ReadLockInfo returns the recorded holder of a deployment directory's lock, or nil if it is not locked
+func ReadLockInfo(workingDir string) (*LockInfo, error) {
+	data, err := os.ReadFile(filepath.Join(workingDir, LockFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var info LockInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file: %w", err)
+	}
+	return &info, nil
+}
+
+// ForceUnlock removes a workspace's deployment lock file and returns the holder it recorded.
+// A process still holding the lock keeps running, but new operations no longer wait for it,
+// so this is only safe when the holder is known to be hung or gone.
+func ForceUnlock(wsName string) (*LockInfo, error) {
+	workingDir := GetWorkingDir(wsName)
+	holder, _ := ReadLockInfo(workingDir)
+
+	if err := os.Remove(filepath.Join(workingDir, LockFileName)); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return holder, nil
+}
+
+// DeploymentLockHolder reports whether a process holds a workspace's deployment lock, with the
+// holder it recorded. The kernel releases the lock of a process that died, so a lock found held
+// belongs to a live operation.
+func DeploymentLockHolder(wsName string) (*LockInfo, bool) {
+	workingDir := GetWorkingDir(wsName)
+	file, err := os.OpenFile(filepath.Join(workingDir, LockFileName), os.O_RDWR, 0)
+	if err != nil {
+		return nil, false
+	}
+	defer func() { _ = file.Close() }()
+
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		holder, _ := ReadLockInfo(workingDir)
+		return holder, errors.Is(err, syscall.EWOULDBLOCK)
+	}
+	_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+	return nil, false
+}
@@ -0,0 +1,139 @@
+package opentofu
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeploymentLockExcludesSecondHolder(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	workingDir := GetWorkingDir("lock-test")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+
+	unlock, err := acquireDeploymentLock(workingDir, "deploy")
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	// flock locks belong to the open file, so a second open conflicts even within one process
+	_, err = acquireDeploymentLock(workingDir, "destroy")
+	if !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected ErrLocked, got %v", err)
+	}
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.Holder == nil {
+		t.Fatalf("Expected *LockedError with holder, got %v", err)
+	}
+	if locked.Holder.Operation != "deploy" || locked.Holder.PID != os.Getpid() {
+		t.Errorf("Expected holder to be this process's deploy, got %+v", locked.Holder)
+	}
+
+	unlock()
+	if info, err := ReadLockInfo(workingDir); err != nil || info != nil {
+		t.Errorf("Expected no holder after unlock, got %+v (err %v)", info, err)
+	}
+
+	unlock, err = acquireDeploymentLock(workingDir, "destroy")
+	if err != nil {
+		t.Fatalf("Expected lock to be free after unlock: %v", err)
+	}
+	unlock()
+}
+
+func TestForceUnlock(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	workingDir := GetWorkingDir("lock-test")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+
+	// A hung holder never releases its lock
+	unlockHung, err := acquireDeploymentLock(workingDir, "deploy")
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+	defer unlockHung()
+
+	holder, err := ForceUnlock("lock-test")
+	if err != nil {
+		t.Fatalf("ForceUnlock failed: %v", err)
+	}
+	if holder == nil || holder.Operation != "deploy" {
+		t.Errorf("Expected removed holder to be the deploy, got %+v", holder)
+	}
+
+	unlock, err := acquireDeploymentLock(workingDir, "destroy")
+	if err != nil {
+		t.Fatalf("Expected lock to be available after force unlock: %v", err)
+	}
+	unlock()
+
+	if holder, err := ForceUnlock("unlocked"); err != nil || holder != nil {
+		t.Errorf("Expected no holder and no error for unlocked workspace, got %+v (err %v)", holder, err)
+	}
+}
+
+func TestDeployFailsWhileLocked(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	workingDir := GetWorkingDir("result-test")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+	unlock, err := acquireDeploymentLock(workingDir, "destroy")
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	client := &Client{binaryPath: writeFakeTofu(t, applyJSONOutput, 0)}
+	ws := newResultTestWorkspace(t)
+	if err := client.Deploy(ws); !errors.Is(err, ErrLocked) {
+		t.Fatalf("Expected deploy to fail with ErrLocked, got %v", err)
+	}
+
+	unlock()
+	if err := client.Deploy(ws); err != nil {
+		t.Fatalf("Expected deploy to succeed once unlocked: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(workingDir, LockFileName)); err != nil {
+		t.Errorf("Expected lock file to survive working directory cleanup: %v", err)
+	}
+}
+
+func TestDeploymentLockHolder(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	if holder, held := DeploymentLockHolder("lock-test"); held || holder != nil {
+		t.Errorf("Expected no holder without a deployment directory, got %+v", holder)
+	}
+
+	workingDir := GetWorkingDir("lock-test")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+	unlock, err := acquireDeploymentLock(workingDir, "deploy")
+	if err != nil {
+		t.Fatalf("Failed to acquire lock: %v", err)
+	}
+
+	holder, held := DeploymentLockHolder("lock-test")
+	if !held || holder == nil || holder.Operation != "deploy" {
+		t.Errorf("Expected lock held by the deploy, got %+v (held %v)", holder, held)
+	}
+
+	// Checking must not take the lock from its holder
+	if _, err := acquireDeploymentLock(workingDir, "destroy"); !errors.Is(err, ErrLocked) {
+		t.Errorf("Expected lock to stay held after checking, got %v", err)
+	}
+
+	unlock()
+	if _, held := DeploymentLockHolder("lock-test"); held {
+		t.Error("Expected lock to be free after unlock")
+	}
+}
@@ -1,13 +1,22 @@
 package opentofu
 
-import "provisioner/pkg/workspace"
+import (
+	"sync"
+
+	"provisioner/pkg/workspace"
+)
 
 // MockTofuClient is a mock implementation of TofuClient for testing
 type MockTofuClient struct {
+	mu sync.Mutex // Guards call tracking, as the scheduler runs operations in goroutines
+
 	// High-level operations
-	DeployFunc       func(ws *workspace.Workspace) error
-	DeployInModeFunc func(ws *workspace.Workspace, mode string) error
-	DestroyFunc      func(ws *workspace.Workspace) error
+	DeployFunc         func(ws *workspace.Workspace) error
+	DeployInModeFunc   func(ws *workspace.Workspace, mode string) error
+	DestroyFunc        func(ws *workspace.Workspace) error
+	RefreshFunc        func(ws *workspace.Workspace, mode string) error
+	RefreshOutputsFunc func(ws *workspace.Workspace, mode string) (map[string]OutputValue, error)
+	CancelFunc         func(workspaceName string) bool
 
 	// Low-level operations
 	InitFunc          func(workingDir string) error
@@ -16,15 +25,20 @@ type MockTofuClient struct {
 	DestroyDirFunc    func(workingDir string) error
 	PlanWithModeFunc  func(workingDir, mode string) error
 	ApplyWithModeFunc func(workingDir, mode string) error
+	OutputFunc        func(workingDir string) (map[string]OutputValue, error)
 
 	// Call tracking
-	DeployCallCount       int
-	DeployInModeCallCount int
-	DestroyCallCount      int
-	InitCallCount         int
-	PlanCallCount         int
-	ApplyCallCount        int
-	DestroyDirCallCount   int
+	DeployCallCount         int
+	DeployInModeCallCount   int
+	DestroyCallCount        int
+	RefreshCallCount        int
+	RefreshOutputsCallCount int
+	CancelCallCount         int
+	InitCallCount           int
+	PlanCallCount           int
+	ApplyCallCount          int
+	DestroyDirCallCount     int
+	OutputCallCount         int
 
 	DeployCallWorkspaces       []*workspace.Workspace
 	DeployInModeCallWorkspaces []*workspace.Workspace
@@ -52,8 +66,10 @@ func NewMockTofuClient() *MockTofuClient {
 
 // Deploy mocks the deploy operation
 func (m *MockTofuClient) Deploy(ws *workspace.Workspace) error {
+	m.mu.Lock()
 	m.DeployCallCount++
 	m.DeployCallWorkspaces = append(m.DeployCallWorkspaces, ws)
+	m.mu.Unlock()
 
 	if m.DeployFunc != nil {
 		return m.DeployFunc(ws)
@@ -65,9 +81,11 @@ func (m *MockTofuClient) Deploy(ws *workspace.Workspace) error {
 
 // DeployInMode mocks the deploy in mode operation
 func (m *MockTofuClient) DeployInMode(ws *workspace.Workspace, mode string) error {
+	m.mu.Lock()
 	m.DeployInModeCallCount++
 	m.DeployInModeCallWorkspaces = append(m.DeployInModeCallWorkspaces, ws)
 	m.DeployInModeCalls = append(m.DeployInModeCalls, mode)
+	m.mu.Unlock()
 
 	if m.DeployInModeFunc != nil {
 		return m.DeployInModeFunc(ws, mode)
@@ -79,8 +97,10 @@ func (m *MockTofuClient) DeployInMode(ws *workspace.Workspace, mode string) erro
 
 // DestroyWorkspace mocks the destroy operation
 func (m *MockTofuClient) DestroyWorkspace(ws *workspace.Workspace) error {
+	m.mu.Lock()
 	m.DestroyCallCount++
 	m.DestroyCallWorkspaces = append(m.DestroyCallWorkspaces, ws)
+	m.mu.Unlock()
 
 	if m.DestroyFunc != nil {
 		return m.DestroyFunc(ws)
@@ -90,15 +110,62 @@ func (m *MockTofuClient) DestroyWorkspace(ws *workspace.Workspace) error {
 	return nil
 }
 
+// RefreshWorkspace mocks reconciling the state of an interrupted operation
+func (m *MockTofuClient) RefreshWorkspace(ws *workspace.Workspace, mode string) error {
+	m.mu.Lock()
+	m.RefreshCallCount++
+	m.mu.Unlock()
+
+	if m.RefreshFunc != nil {
+		return m.RefreshFunc(ws, mode)
+	}
+
+	// Default success behavior
+	return nil
+}
+
+// RefreshOutputs mocks refreshing a workspace and reading its outputs, returning none by default
+func (m *MockTofuClient) RefreshOutputs(ws *workspace.Workspace, mode string) (map[string]OutputValue, error) {
+	m.mu.Lock()
+	m.RefreshOutputsCallCount++
+	m.mu.Unlock()
+
+	if m.RefreshOutputsFunc != nil {
+		return m.RefreshOutputsFunc(ws, mode)
+	}
+	return map[string]OutputValue{}, nil
+}
+
+// Cancel mocks cancelling an in-flight operation
+func (m *MockTofuClient) Cancel(workspaceName string) bool {
+	m.mu.Lock()
+	m.CancelCallCount++
+	m.mu.Unlock()
+
+	if m.CancelFunc != nil {
+		return m.CancelFunc(workspaceName)
+	}
+
+	// Default: nothing in flight
+	return false
+}
+
 // Reset clears all call counts and workspaces
 func (m *MockTofuClient) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	m.DeployCallCount = 0
 	m.DeployInModeCallCount = 0
 	m.DestroyCallCount = 0
+	m.RefreshCallCount = 0
+	m.RefreshOutputsCallCount = 0
+	m.CancelCallCount = 0
 	m.InitCallCount = 0
 	m.PlanCallCount = 0
 	m.ApplyCallCount = 0
 	m.DestroyDirCallCount = 0
+	m.OutputCallCount = 0
 
 	m.DeployCallWorkspaces = m.DeployCallWorkspaces[:0]
 	m.DeployInModeCallWorkspaces = m.DeployInModeCallWorkspaces[:0]
@@ -136,6 +203,9 @@ func (m *MockTofuClient) SetDestroySuccess() {
 
 // GetLastDeployWorkspace returns the workspace from the most recent deploy call
 func (m *MockTofuClient) GetLastDeployWorkspace() *workspace.Workspace {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if len(m.DeployCallWorkspaces) == 0 {
 		return nil
 	}
@@ -144,18 +214,44 @@ func (m *MockTofuClient) GetLastDeployWorkspace() *workspace.Workspace {
 
 // GetLastDestroyWorkspace returns the workspace from the most recent destroy call
 func (m *MockTofuClient) GetLastDestroyWorkspace() *workspace.Workspace {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if len(m.DestroyCallWorkspaces) == 0 {
 		return nil
 	}
 	return m.DestroyCallWorkspaces[len(m.DestroyCallWorkspaces)-1]
 }
 
+// GetDeployCallCount returns how many deploys were made, for polling while operations run
+func (m *MockTofuClient) GetDeployCallCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.DeployCallCount
+}
+
+// GetDeployInModeCallCount returns how many deploys in a mode were made, for polling while operations run
+func (m *MockTofuClient) GetDeployInModeCallCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.DeployInModeCallCount
+}
+
+// GetDestroyCallCount returns how many destroys were made, for polling while operations run
+func (m *MockTofuClient) GetDestroyCallCount() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.DestroyCallCount
+}
+
 // Low-level operation methods
 
 // Init mocks the init operation
 func (m *MockTofuClient) Init(workingDir string) error {
+	m.mu.Lock()
 	m.InitCallCount++
 	m.InitCallDirs = append(m.InitCallDirs, workingDir)
+	m.mu.Unlock()
 
 	if m.InitFunc != nil {
 		return m.InitFunc(workingDir)
@@ -165,8 +261,10 @@ func (m *MockTofuClient) Init(workingDir string) error {
 
 // Plan mocks the plan operation
 func (m *MockTofuClient) Plan(workingDir string) error {
+	m.mu.Lock()
 	m.PlanCallCount++
 	m.PlanCallDirs = append(m.PlanCallDirs, workingDir)
+	m.mu.Unlock()
 
 	if m.PlanFunc != nil {
 		return m.PlanFunc(workingDir)
@@ -176,8 +274,10 @@ func (m *MockTofuClient) Plan(workingDir string) error {
 
 // Apply mocks the apply operation
 func (m *MockTofuClient) Apply(workingDir string) error {
+	m.mu.Lock()
 	m.ApplyCallCount++
 	m.ApplyCallDirs = append(m.ApplyCallDirs, workingDir)
+	m.mu.Unlock()
 
 	if m.ApplyFunc != nil {
 		return m.ApplyFunc(workingDir)
@@ -187,8 +287,10 @@ func (m *MockTofuClient) Apply(workingDir string) error {
 
 // Destroy mocks the destroy operation on a directory
 func (m *MockTofuClient) Destroy(workingDir string) error {
+	m.mu.Lock()
 	m.DestroyDirCallCount++
 	m.DestroyDirCallDirs = append(m.DestroyDirCallDirs, workingDir)
+	m.mu.Unlock()
 
 	if m.DestroyDirFunc != nil {
 		return m.DestroyDirFunc(workingDir)
@@ -198,8 +300,10 @@ func (m *MockTofuClient) Destroy(workingDir string) error {
 
 // PlanWithMode mocks the plan operation with mode
 func (m *MockTofuClient) PlanWithMode(workingDir, mode string) error {
+	m.mu.Lock()
 	m.PlanCallCount++
 	m.PlanCallDirs = append(m.PlanCallDirs, workingDir)
+	m.mu.Unlock()
 
 	if m.PlanWithModeFunc != nil {
 		return m.PlanWithModeFunc(workingDir, mode)
@@ -209,8 +313,10 @@ func (m *MockTofuClient) PlanWithMode(workingDir, mode string) error {
 
 // ApplyWithMode mocks the apply operation with mode
 func (m *MockTofuClient) ApplyWithMode(workingDir, mode string) error {
+	m.mu.Lock()
 	m.ApplyCallCount++
 	m.ApplyCallDirs = append(m.ApplyCallDirs, workingDir)
+	m.mu.Unlock()
 
 	if m.ApplyWithModeFunc != nil {
 		return m.ApplyWithModeFunc(workingDir, mode)
@@ -218,5 +324,17 @@ func (m *MockTofuClient) ApplyWithMode(workingDir, mode string) error {
 	return nil
 }
 
+// Output mocks reading outputs, returning none by default
+func (m *MockTofuClient) Output(workingDir string) (map[string]OutputValue, error) {
+	m.mu.Lock()
+	m.OutputCallCount++
+	m.mu.Unlock()
+
+	if m.OutputFunc != nil {
+		return m.OutputFunc(workingDir)
+	}
+	return map[string]OutputValue{}, nil
+}
+
 // Ensure MockTofuClient implements TofuClient interface
 var _ TofuClient = (*MockTofuClient)(nil)
@@ -0,0 +1,77 @@
+package opentofu
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ListDeployments returns the names of the workspaces that have a deployment directory
+func ListDeployments() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(getStateDir(), "deployments"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read deployments directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// DeploymentResources counts the managed resource instances in a workspace's deployment state
+func DeploymentResources(wsName string) int {
+	return countStateResources(GetWorkingDir(wsName))
+}
+
+// StateResources counts the managed resource instances in the state of a working directory
+func StateResources(workingDir string) int {
+	return countStateResources(workingDir)
+}
+
+// DestroyDeployment destroys the resources left in the deployment directory of a workspace whose
+// config was removed. The files, variables and backend of its last deploy are still there, so
+// OpenTofu runs on them as they are; custom destroy commands are lost with the config.
+func DestroyDeployment(client TofuClient, wsName string) error {
+	workingDir := GetWorkingDir(wsName)
+	if _, err := os.Stat(workingDir); err != nil {
+		return fmt.Errorf("no deployment directory for workspace '%s'", wsName)
+	}
+
+	unlock, err := acquireDeploymentLock(workingDir, "destroy")
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := client.Init(workingDir); err != nil {
+		return fmt.Errorf("init failed: %w", err)
+	}
+	if err := client.Destroy(workingDir); err != nil {
+		return fmt.Errorf("destroy failed: %w", err)
+	}
+	return nil
+}
+
+// RemoveDeployment removes a workspace's deployment directory, deployment history and state
+// backups, returning the paths it removed
+func RemoveDeployment(wsName string) ([]string, error) {
+	stateDir := getStateDir()
+	var removed []string
+	for _, path := range []string{GetWorkingDir(wsName), GetHistoryDir(stateDir, wsName), GetStateBackupDir(stateDir, wsName)} {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
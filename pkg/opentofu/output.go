@@ -0,0 +1,87 @@
+package opentofu
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// OutputEnvPrefix prefixes the environment variables that expose outputs to jobs
+const OutputEnvPrefix = "TF_OUTPUT_"
+
+// OutputValue is a single root module output as reported by `tofu output -json`
+type OutputValue struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type,omitempty"`
+	Value     json.RawMessage `json:"value"`
+}
+
+// String returns the output value as plain text: strings unquoted, anything else as compact JSON
+func (o OutputValue) String() string {
+	var s string
+	if err := json.Unmarshal(o.Value, &s); err == nil {
+		return s
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, o.Value); err != nil {
+		return string(o.Value)
+	}
+	return compact.String()
+}
+
+// Output returns the root module outputs of the state in a working directory
+func (c *Client) Output(workingDir string) (map[string]OutputValue, error) {
+	cmd, err := c.tofuCommand(workingDir, "output", "-json")
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if stderr.Len() > 0 {
+			return nil, fmt.Errorf("%w\n\nDetailed output:\n%s", err, stderr.String())
+		}
+		return nil, err
+	}
+
+	outputs := make(map[string]OutputValue)
+	if err := json.Unmarshal(stdout.Bytes(), &outputs); err != nil {
+		return nil, fmt.Errorf("failed to parse outputs: %w", err)
+	}
+	return outputs, nil
+}
+
+// OutputEnvName returns the environment variable name for an output, e.g. lb_ip -> TF_OUTPUT_LB_IP
+func OutputEnvName(name string) string {
+	var b strings.Builder
+	b.WriteString(OutputEnvPrefix)
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// OutputEnvironment converts outputs to NAME=value environment entries sorted by output name
+func OutputEnvironment(outputs map[string]OutputValue) []string {
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, OutputEnvName(name)+"="+outputs[name].String())
+	}
+	return env
+}
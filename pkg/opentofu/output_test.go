@@ -0,0 +1,46 @@
+package opentofu
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestOutput(t *testing.T) {
+	dir := t.TempDir()
+	script := `#!/bin/sh
+if [ "$1" = "output" ] && [ "$2" = "-json" ]; then
+  echo '{"lb_ip":{"sensitive":false,"type":"string","value":"10.0.0.5"},"db_password":{"sensitive":true,"type":"string","value":"s3cret"}}'
+  exit 0
+fi
+exit 1
+`
+	client := &Client{binaryPath: writeTestTofu(t, script)}
+	outputs, err := client.Output(dir)
+	if err != nil {
+		t.Fatalf("Output failed: %v", err)
+	}
+	if got := outputs["lb_ip"].String(); got != "10.0.0.5" {
+		t.Errorf("Expected lb_ip 10.0.0.5, got %q", got)
+	}
+	if !outputs["db_password"].Sensitive {
+		t.Error("Expected db_password to be marked sensitive")
+	}
+}
+
+func TestOutputEnvironment(t *testing.T) {
+	outputs := map[string]OutputValue{
+		"lb_ip":   {Value: json.RawMessage(`"10.0.0.5"`)},
+		"ports":   {Value: json.RawMessage(`[80, 443]`)},
+		"db-host": {Value: json.RawMessage(`"db.internal"`)},
+	}
+
+	want := []string{
+		"TF_OUTPUT_DB_HOST=db.internal",
+		"TF_OUTPUT_LB_IP=10.0.0.5",
+		"TF_OUTPUT_PORTS=[80,443]",
+	}
+	if got := OutputEnvironment(outputs); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,194 @@
+package opentofu
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"provisioner/pkg/workspace"
+)
+
+// PlannedChange is a resource change a plan would make
+type PlannedChange struct {
+	Address string
+	Action  string // create, update, delete, replace, read, ...
+}
+
+// PlanSummary is what a deploy would change, from tofu plan's machine-readable output
+type PlanSummary struct {
+	Add         int
+	Change      int
+	Destroy     int
+	Changes     []PlannedChange
+	Diagnostics []string // Warning summaries; errors fail the plan
+}
+
+// HasChanges reports whether applying the plan would change any resource
+func (p *PlanSummary) HasChanges() bool {
+	return p.Add+p.Change+p.Destroy > 0
+}
+
+// PlanWorkspace prepares the workspace's deployment directory like a deploy and runs init and plan
+// without applying, optionally with a deployment mode. The deployment lock is held throughout.
+func (c *Client) PlanWorkspace(ws *workspace.Workspace, mode string) (*PlanSummary, error) {
+	if err := c.checkHost(ws); err != nil {
+		return nil, err
+	}
+	if ws.Config.CustomDeploy != nil {
+		return nil, fmt.Errorf("workspace '%s' deploys with custom commands, which can't be planned", ws.Name)
+	}
+
+	workingDir := GetWorkingDir(ws.Name)
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+
+	unlock, err := acquireDeploymentLock(workingDir, "plan")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if _, err := copyWorkspaceTemplateFiles(ws, workingDir); err != nil {
+		return nil, fmt.Errorf("failed to copy workspace files: %w", err)
+	}
+	if err := workspace.WriteConfigVarsFile(workingDir, ws.Config.GetVariables(mode)); err != nil {
+		return nil, err
+	}
+	if err := pinTofuVersion(ws, workingDir); err != nil {
+		return nil, err
+	}
+
+	// Register the operation for the workspace's environment
+	op, done := c.beginOperation(workingDir)
+	defer done()
+	if err := c.setEnvironment(op, ws); err != nil {
+		return nil, err
+	}
+
+	if err := c.initWorkingDir(ws, workingDir); err != nil {
+		return nil, fmt.Errorf("init failed: %w", err)
+	}
+
+	args := []string{"plan"}
+	if mode != "" {
+		args = append(args, "-var", fmt.Sprintf("deployment_mode=%s", mode))
+	}
+	out, err := c.runJSONOutput(workingDir, args...)
+	if err != nil {
+		return nil, fmt.Errorf("plan failed: %w", err)
+	}
+	return out.planSummary(), nil
+}
+
+// planSummary returns the plan's counts and changes
+func (out *jsonOutput) planSummary() *PlanSummary {
+	summary := &PlanSummary{Changes: out.changes, Diagnostics: out.diagnostics}
+	if out.planned != nil {
+		summary.Add, summary.Change, summary.Destroy = out.planned[0], out.planned[1], out.planned[2]
+	}
+	sort.SliceStable(summary.Changes, func(i, j int) bool {
+		return summary.Changes[i].Address < summary.Changes[j].Address
+	})
+	return summary
+}
+
+// planActionSymbols are the markers tofu uses for planned actions
+var planActionSymbols = map[string]string{
+	"create":  "+",
+	"update":  "~",
+	"delete":  "-",
+	"replace": "-/+",
+	"read":    "<=",
+}
+
+// FormatPlanSummary renders a plan summary as tofu-style change lines and a count line
+func FormatPlanSummary(summary *PlanSummary) string {
+	var b strings.Builder
+	for _, diagnostic := range summary.Diagnostics {
+		fmt.Fprintf(&b, "%s\n", diagnostic)
+	}
+	if len(summary.Diagnostics) > 0 {
+		b.WriteString("\n")
+	}
+
+	if !summary.HasChanges() && len(summary.Changes) == 0 {
+		b.WriteString("No changes. The deployment matches the configuration.\n")
+		return b.String()
+	}
+
+	for _, change := range summary.Changes {
+		symbol, ok := planActionSymbols[change.Action]
+		if !ok {
+			symbol = "?"
+		}
+		fmt.Fprintf(&b, "  %3s %s (%s)\n", symbol, change.Address, change.Action)
+	}
+	if len(summary.Changes) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Plan: %d to add, %d to change, %d to destroy.\n", summary.Add, summary.Change, summary.Destroy)
+	return b.String()
+}
+
+// RunPlanCommand previews what deploying a workspace would change: WORKSPACE [MODE]
+func RunPlanCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("plan requires a workspace name and an optional mode")
+	}
+	name, mode := args[0], ""
+	if len(args) == 2 {
+		mode = args[1]
+	}
+
+	workspaces, err := workspace.LoadWorkspaces(workspace.GetDefaultWorkspacesDir())
+	if err != nil {
+		return err
+	}
+	var ws *workspace.Workspace
+	for i := range workspaces {
+		if workspaces[i].Name == name {
+			ws = &workspaces[i]
+		}
+	}
+	if ws == nil {
+		return fmt.Errorf("workspace '%s' not found", name)
+	}
+
+	// Workspaces with mode schedules always deploy in one of their modes
+	if len(ws.Config.ModeSchedules) > 0 {
+		modeSchedules, err := ws.Config.GetModeSchedules()
+		if err != nil {
+			return fmt.Errorf("invalid mode schedules for workspace '%s': %w", name, err)
+		}
+		modes := make([]string, 0, len(modeSchedules))
+		for available := range modeSchedules {
+			modes = append(modes, available)
+		}
+		sort.Strings(modes)
+		if _, exists := modeSchedules[mode]; !exists {
+			if mode == "" {
+				return fmt.Errorf("workspace '%s' uses mode scheduling, specify a mode: %s", name, strings.Join(modes, ", "))
+			}
+			return fmt.Errorf("mode '%s' not available for workspace '%s', available modes: %s", mode, name, strings.Join(modes, ", "))
+		}
+	}
+
+	client, err := New()
+	if err != nil {
+		return fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+	}
+
+	if mode != "" {
+		fmt.Printf("Planning workspace '%s' in mode '%s'...\n\n", name, mode)
+	} else {
+		fmt.Printf("Planning workspace '%s'...\n\n", name)
+	}
+	summary, err := client.PlanWorkspace(ws, mode)
+	if err != nil {
+		return err
+	}
+	fmt.Print(FormatPlanSummary(summary))
+	return nil
+}
@@ -0,0 +1,45 @@
+package opentofu
+
+import (
+	"strings"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+const planJSONOutput = `{"@level":"info","@message":"OpenTofu 1.8.0","type":"version","tofu":"1.8.0"}
+{"@level":"info","@message":"null_resource.b: Plan to update","type":"planned_change","change":{"resource":{"addr":"null_resource.b"},"action":"update"}}
+{"@level":"info","@message":"null_resource.a: Plan to create","type":"planned_change","change":{"resource":{"addr":"null_resource.a"},"action":"create"}}
+{"@level":"warn","@message":"Warning: Deprecated attribute","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Deprecated attribute","detail":"Use something else"}}
+{"@level":"info","@message":"Plan: 1 to add, 1 to change, 0 to destroy.","type":"change_summary","changes":{"add":1,"change":1,"import":0,"remove":0,"operation":"plan"}}
+`
+
+func TestPlanSummary(t *testing.T) {
+	summary := parseJSONOutput([]byte(planJSONOutput)).planSummary()
+	if summary.Add != 1 || summary.Change != 1 || summary.Destroy != 0 || !summary.HasChanges() {
+		t.Fatalf("Expected 1 to add and 1 to change, got %+v", summary)
+	}
+
+	expected := `Warning: Deprecated attribute
+
+    + null_resource.a (create)
+    ~ null_resource.b (update)
+
+Plan: 1 to add, 1 to change, 0 to destroy.
+`
+	if got := FormatPlanSummary(summary); got != expected {
+		t.Errorf("Unexpected plan summary:\n%s\nexpected:\n%s", got, expected)
+	}
+
+	empty := parseJSONOutput([]byte(`{"type":"change_summary","changes":{"add":0,"change":0,"remove":0,"operation":"plan"}}`)).planSummary()
+	if got := FormatPlanSummary(empty); !strings.HasPrefix(got, "No changes.") {
+		t.Errorf("Expected no changes, got %q", got)
+	}
+}
+
+func TestPlanWorkspaceRejectsCustomDeploy(t *testing.T) {
+	ws := &workspace.Workspace{Name: "custom", Config: workspace.Config{CustomDeploy: &workspace.CustomDeployConfig{ApplyCommand: "make deploy"}}}
+	if _, err := (&Client{}).PlanWorkspace(ws, ""); err == nil || !strings.Contains(err.Error(), "custom commands") {
+		t.Errorf("Expected custom deploy workspaces to be rejected, got %v", err)
+	}
+}
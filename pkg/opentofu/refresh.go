@@ -0,0 +1,85 @@
+package opentofu
+
+import (
+	"fmt"
+	"os"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// RefreshWorkspace reconciles the state in a workspace's deployment directory with its real
+// resources using tofu apply -refresh-only, which changes no infrastructure. It is meant for
+// deploys and destroys that were interrupted, so the state records what they got to. The files
+// of the interrupted operation are refreshed as they are; mode sets deployment_mode as for
+// DeployInMode.
+func (c *Client) RefreshWorkspace(ws *workspace.Workspace, mode string) error {
+	_, err := c.refresh(ws, mode, false)
+	return err
+}
+
+// RefreshOutputs refreshes a workspace's state like RefreshWorkspace and returns its root module
+// outputs. Outputs only follow the real resources once the state is refreshed, and a workspace
+// with a remote backend keeps no state of its own to read them from.
+func (c *Client) RefreshOutputs(ws *workspace.Workspace, mode string) (map[string]OutputValue, error) {
+	return c.refresh(ws, mode, true)
+}
+
+// refresh runs a refresh of a workspace, reading its outputs afterwards if asked to
+func (c *Client) refresh(ws *workspace.Workspace, mode string, readOutputs bool) (map[string]OutputValue, error) {
+	if err := c.checkHost(ws); err != nil {
+		return nil, err
+	}
+	if ws.Config.CustomDeploy != nil || ws.Config.CustomDestroy != nil {
+		return nil, fmt.Errorf("workspace '%s' uses custom commands, which can't be refreshed", ws.Name)
+	}
+
+	workingDir := GetWorkingDir(ws.Name)
+	if _, err := os.Stat(workingDir); err != nil {
+		return nil, fmt.Errorf("workspace '%s' has no deployment directory to refresh: %w", ws.Name, err)
+	}
+
+	unlock, err := acquireDeploymentLock(workingDir, "refresh")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := pinTofuVersion(ws, workingDir); err != nil {
+		return nil, err
+	}
+
+	op, done := c.beginOperation(workingDir)
+	defer done()
+	op.workspace = ws.Name
+	op.correlationID = logging.CorrelationID(ws.Name)
+	if err := c.setEnvironment(op, ws); err != nil {
+		return nil, err
+	}
+	defer c.syncRemoteState(ws, workingDir)
+
+	if err := c.runStep(op, "init", func() error { return c.initWorkingDir(ws, workingDir) }); err != nil {
+		return nil, fmt.Errorf("init failed: %w", err)
+	}
+
+	args := []string{"apply", "-refresh-only", "-auto-approve"}
+	if mode != "" {
+		args = append(args, "-var", fmt.Sprintf("deployment_mode=%s", mode))
+	}
+	if err := c.runStep(op, "refresh", func() error { return c.runJSON(workingDir, args...) }); err != nil {
+		return nil, fmt.Errorf("refresh failed: %w", err)
+	}
+	if !readOutputs {
+		return nil, nil
+	}
+
+	var outputs map[string]OutputValue
+	err = c.runStep(op, "output", func() (err error) {
+		outputs, err = c.Output(workingDir)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading outputs failed: %w", err)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,276 @@
+package opentofu
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// OperationResult summarizes a deploy or destroy from OpenTofu's machine-readable (-json) output
+type OperationResult struct {
+	Operation    string        `json:"operation"` // "deploy" or "destroy"
+	StartedAt    time.Time     `json:"started_at"`
+	FinishedAt   time.Time     `json:"finished_at"`
+	Success      bool          `json:"success"`
+	Reported     bool          `json:"reported"` // OpenTofu reported changes; false for custom commands
+	Added        int           `json:"added"`
+	Changed      int           `json:"changed"`
+	Destroyed    int           `json:"destroyed"`
+	Resources    int           `json:"resources"`               // Managed resource instances in state afterwards
+	Diagnostics  []string      `json:"diagnostics,omitempty"`   // Error and warning summaries
+	CostEstimate *CostEstimate `json:"cost_estimate,omitempty"` // Estimated monthly cost of a deploy
+}
+
+// OperationResults holds the most recent result of each operation type for a workspace
+type OperationResults struct {
+	Deploy  *OperationResult `json:"deploy,omitempty"`
+	Destroy *OperationResult `json:"destroy,omitempty"`
+}
+
+// Duration returns how long the operation took
+func (r *OperationResult) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt).Round(time.Second)
+}
+
+// Summary returns a one-line description such as "12 resources (3 added, 1 changed, 0 destroyed), 3m42s"
+func (r *OperationResult) Summary() string {
+	if !r.Reported {
+		return r.Duration().String()
+	}
+	return fmt.Sprintf("%d resources (%d added, %d changed, %d destroyed), %s",
+		r.Resources, r.Added, r.Changed, r.Destroyed, r.Duration())
+}
+
+// jsonMessage is a single line of OpenTofu's machine-readable UI output
+type jsonMessage struct {
+	Type    string `json:"type"`
+	Changes *struct {
+		Add       int    `json:"add"`
+		Change    int    `json:"change"`
+		Remove    int    `json:"remove"`
+		Operation string `json:"operation"`
+	} `json:"changes,omitempty"`
+	Hook *struct {
+		Action string `json:"action"`
+	} `json:"hook,omitempty"`
+	Change *struct {
+		Resource struct {
+			Addr string `json:"addr"`
+		} `json:"resource"`
+		Action string `json:"action"`
+	} `json:"change,omitempty"`
+	Diagnostic *struct {
+		Severity string `json:"severity"`
+		Summary  string `json:"summary"`
+		Detail   string `json:"detail"`
+	} `json:"diagnostic,omitempty"`
+}
+
+// jsonOutput is the parsed output of a single tofu command run with -json
+type jsonOutput struct {
+	summary     *[3]int // add, change, remove from the apply/destroy change summary
+	completed   [3]int  // add, change, remove counted from apply_complete events
+	planned     *[3]int // add, change, remove from a plan's change summary
+	changes     []PlannedChange
+	errors      []string
+	diagnostics []string
+}
+
+// parseJSONOutput parses OpenTofu's machine-readable output, ignoring lines that are not JSON
+func parseJSONOutput(data []byte) *jsonOutput {
+	out := &jsonOutput{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var msg jsonMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+
+		switch msg.Type {
+		case "change_summary":
+			// Plans report planned changes; only applied changes are recorded
+			if msg.Changes == nil {
+				continue
+			}
+			counts := &[3]int{msg.Changes.Add, msg.Changes.Change, msg.Changes.Remove}
+			if msg.Changes.Operation == "plan" {
+				out.planned = counts
+			} else {
+				out.summary = counts
+			}
+		case "planned_change":
+			if msg.Change != nil {
+				out.changes = append(out.changes, PlannedChange{Address: msg.Change.Resource.Addr, Action: msg.Change.Action})
+			}
+		case "apply_complete":
+			if msg.Hook == nil {
+				continue
+			}
+			switch msg.Hook.Action {
+			case "create":
+				out.completed[0]++
+			case "update":
+				out.completed[1]++
+			case "delete":
+				out.completed[2]++
+			}
+		case "diagnostic":
+			if msg.Diagnostic == nil {
+				continue
+			}
+			text := fmt.Sprintf("%s: %s", capitalize(msg.Diagnostic.Severity), msg.Diagnostic.Summary)
+			out.diagnostics = append(out.diagnostics, text)
+			if msg.Diagnostic.Severity == "error" {
+				if msg.Diagnostic.Detail != "" {
+					text += "\n\n" + msg.Diagnostic.Detail
+				}
+				out.errors = append(out.errors, text)
+			}
+		}
+	}
+
+	return out
+}
+
+// capitalize upper-cases the first letter of a diagnostic severity
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// record adds a command's parsed output to the operation result
+func (r *OperationResult) record(out *jsonOutput) {
+	counts := out.completed
+	if out.summary != nil {
+		counts = *out.summary
+	}
+	if out.summary != nil || counts != [3]int{} {
+		r.Reported = true
+	}
+	r.Added += counts[0]
+	r.Changed += counts[1]
+	r.Destroyed += counts[2]
+	r.Diagnostics = append(r.Diagnostics, out.diagnostics...)
+}
+
+// countStateResources counts managed resource instances in a working directory's state
+func countStateResources(workingDir string) int {
+	data, err := os.ReadFile(stateFilePath(workingDir))
+	if err != nil {
+		return 0
+	}
+	return countResources(data)
+}
+
+// countResources counts managed resource instances in an OpenTofu state
+func countResources(data []byte) int {
+	var state struct {
+		Resources []struct {
+			Mode      string            `json:"mode"`
+			Instances []json.RawMessage `json:"instances"`
+		} `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0
+	}
+
+	count := 0
+	for _, resource := range state.Resources {
+		if resource.Mode == "managed" {
+			count += len(resource.Instances)
+		}
+	}
+	return count
+}
+
+// GetOperationResultsPath returns the path of a workspace's stored operation results
+func GetOperationResultsPath(stateDir, wsName string) string {
+	return filepath.Join(stateDir, "results", wsName+".json")
+}
+
+// LoadOperationResults loads the last deploy and destroy results for a workspace.
+// Returns empty results if none have been recorded.
+func LoadOperationResults(wsName string) (*OperationResults, error) {
+	data, err := os.ReadFile(GetOperationResultsPath(getStateDir(), wsName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &OperationResults{}, nil
+		}
+		return nil, fmt.Errorf("failed to read operation results: %w", err)
+	}
+
+	var results OperationResults
+	if err := json.Unmarshal(data, &results); err != nil {
+		return nil, fmt.Errorf("failed to parse operation results: %w", err)
+	}
+	return &results, nil
+}
+
+// saveOperationResult stores a finished operation's result as the workspace's latest of its type
+func saveOperationResult(wsName string, result *OperationResult) error {
+	results, err := LoadOperationResults(wsName)
+	if err != nil {
+		results = &OperationResults{}
+	}
+
+	switch result.Operation {
+	case "destroy":
+		results.Destroy = result
+	default:
+		results.Deploy = result
+	}
+
+	path := GetOperationResultsPath(getStateDir(), wsName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create results directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal operation results: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write operation results: %w", err)
+	}
+	return nil
+}
+
+// beginResult starts collecting the structured result of an operation
+func (c *Client) beginResult(op *operation, operationName string) {
+	c.mu.Lock()
+	op.result = &OperationResult{Operation: operationName, StartedAt: time.Now()}
+	c.mu.Unlock()
+}
+
+// finishResult stores an operation's result so status can show what it changed
+func (c *Client) finishResult(op *operation, wsName, workingDir string, err error) {
+	c.mu.Lock()
+	result := op.result
+	c.mu.Unlock()
+	if result == nil {
+		return
+	}
+
+	result.FinishedAt = time.Now()
+	result.Success = err == nil
+	result.Resources = countStateResources(workingDir)
+
+	if saveErr := saveOperationResult(wsName, result); saveErr != nil {
+		logging.LogWorkspace(wsName, "Failed to save %s result: %v", result.Operation, saveErr)
+		return
+	}
+	if result.Success {
+		logging.LogWorkspace(wsName, "%s result: %s", capitalize(result.Operation), result.Summary())
+	}
+}
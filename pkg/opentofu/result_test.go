@@ -0,0 +1,150 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+const applyJSONOutput = `{"@level":"info","@message":"OpenTofu 1.8.0","type":"version","tofu":"1.8.0"}
+{"@level":"info","@message":"null_resource.a: Creation complete after 0s","type":"apply_complete","hook":{"action":"create"}}
+{"@level":"info","@message":"null_resource.b: Modifications complete after 0s","type":"apply_complete","hook":{"action":"update"}}
+{"@level":"warn","@message":"Warning: Deprecated attribute","type":"diagnostic","diagnostic":{"severity":"warning","summary":"Deprecated attribute","detail":"Use something else"}}
+{"@level":"info","@message":"Apply complete! Resources: 1 added, 1 changed, 0 destroyed.","type":"change_summary","changes":{"add":1,"change":1,"import":0,"remove":0,"operation":"apply"}}
+`
+
+func TestParseJSONOutput(t *testing.T) {
+	out := parseJSONOutput([]byte(applyJSONOutput))
+	if out.summary == nil || *out.summary != [3]int{1, 1, 0} {
+		t.Errorf("Expected change summary [1 1 0], got %v", out.summary)
+	}
+	if out.completed != [3]int{1, 1, 0} {
+		t.Errorf("Expected completed counts [1 1 0], got %v", out.completed)
+	}
+	if len(out.diagnostics) != 1 || out.diagnostics[0] != "Warning: Deprecated attribute" {
+		t.Errorf("Expected one warning diagnostic, got %v", out.diagnostics)
+	}
+	if len(out.errors) != 0 {
+		t.Errorf("Expected no errors, got %v", out.errors)
+	}
+}
+
+func TestParseJSONOutputIgnoresPlanSummary(t *testing.T) {
+	out := parseJSONOutput([]byte(`{"type":"change_summary","changes":{"add":5,"change":0,"remove":0,"operation":"plan"}}
+not json
+`))
+	if out.summary != nil {
+		t.Errorf("Expected plan change summary to be ignored, got %v", *out.summary)
+	}
+
+	result := &OperationResult{}
+	result.record(out)
+	if result.Reported {
+		t.Error("Expected plan-only output not to be reported")
+	}
+}
+
+func TestOperationResultSummary(t *testing.T) {
+	started := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	result := &OperationResult{
+		StartedAt:  started,
+		FinishedAt: started.Add(3*time.Minute + 42*time.Second),
+		Reported:   true,
+		Added:      3,
+		Changed:    1,
+		Resources:  12,
+	}
+	if got := result.Summary(); got != "12 resources (3 added, 1 changed, 0 destroyed), 3m42s" {
+		t.Errorf("Unexpected summary: %s", got)
+	}
+
+	result.Reported = false
+	if got := result.Summary(); got != "3m42s" {
+		t.Errorf("Expected duration only for unreported result, got %s", got)
+	}
+}
+
+// writeFakeTofu writes a tofu stand-in that prints canned -json output for apply
+func writeFakeTofu(t *testing.T, applyOutput string, applyExit int) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "apply.json"), []byte(applyOutput), 0644); err != nil {
+		t.Fatalf("Failed to write fake output: %v", err)
+	}
+
+	script := `#!/bin/sh
+case "$1" in
+  apply)
+    cat "` + filepath.Join(dir, "apply.json") + `"
+    echo '{"resources":[{"mode":"managed","instances":[{},{}]},{"mode":"data","instances":[{}]}]}' > terraform.tfstate
+    exit ` + strconv.Itoa(applyExit) + `
+    ;;
+esac
+exit 0
+`
+	return writeTestTofu(t, script)
+}
+
+// newResultTestWorkspace returns the workspace the fake tofu of writeFakeTofu applies
+func newResultTestWorkspace(t *testing.T) *workspace.Workspace {
+	return newTestWorkspace(t, "result-test", `resource "null_resource" "a" {}`)
+}
+
+func TestDeployRecordsOperationResult(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	client := &Client{binaryPath: writeFakeTofu(t, applyJSONOutput, 0)}
+	if err := client.Deploy(newResultTestWorkspace(t)); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	results, err := LoadOperationResults("result-test")
+	if err != nil {
+		t.Fatalf("Failed to load results: %v", err)
+	}
+	deploy := results.Deploy
+	if deploy == nil {
+		t.Fatal("Expected a deploy result")
+	}
+	if !deploy.Success || !deploy.Reported {
+		t.Errorf("Expected successful reported deploy, got %+v", deploy)
+	}
+	if deploy.Added != 1 || deploy.Changed != 1 || deploy.Destroyed != 0 {
+		t.Errorf("Expected 1 added, 1 changed, 0 destroyed, got %d/%d/%d", deploy.Added, deploy.Changed, deploy.Destroyed)
+	}
+	if deploy.Resources != 2 {
+		t.Errorf("Expected 2 managed resources in state, got %d", deploy.Resources)
+	}
+	if results.Destroy != nil {
+		t.Error("Expected no destroy result")
+	}
+}
+
+func TestDeployFailureUsesDiagnostics(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	output := `{"@level":"error","type":"diagnostic","diagnostic":{"severity":"error","summary":"Invalid provider credentials","detail":"The token was rejected."}}
+`
+	client := &Client{binaryPath: writeFakeTofu(t, output, 1)}
+	err := client.Deploy(newResultTestWorkspace(t))
+	if err == nil {
+		t.Fatal("Expected deploy to fail")
+	}
+	if !strings.Contains(err.Error(), "Error: Invalid provider credentials\n\nThe token was rejected.") {
+		t.Errorf("Expected error diagnostic in error detail, got: %v", err)
+	}
+
+	results, loadErr := LoadOperationResults("result-test")
+	if loadErr != nil {
+		t.Fatalf("Failed to load results: %v", loadErr)
+	}
+	if results.Deploy == nil || results.Deploy.Success {
+		t.Errorf("Expected failed deploy result, got %+v", results.Deploy)
+	}
+}
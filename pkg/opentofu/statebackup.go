@@ -0,0 +1,305 @@
+package opentofu
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// State backup reasons
+const (
+	BackupReasonApply   = "apply"   // Taken before a deploy applied changes
+	BackupReasonDestroy = "destroy" // Taken before a destroy
+	BackupReasonRestore = "restore" // The state a restore replaced
+	BackupReasonManual  = "manual"  // Taken with "workspacectl state backup"
+)
+
+// stateBackupTimeFormat is the sortable UTC timestamp in backup file names
+const stateBackupTimeFormat = "20060102T150405Z"
+
+// StateBackup is a copy of a workspace's OpenTofu state taken before it changed
+type StateBackup struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Reason    string    `json:"reason"`
+	Serial    int       `json:"serial"`    // OpenTofu's state serial
+	Resources int       `json:"resources"` // Managed resource instances in the backup
+	Size      int64     `json:"size"`
+	Path      string    `json:"path"`
+}
+
+// GetStateBackupDir returns the directory holding a workspace's state backups
+func GetStateBackupDir(stateDir, wsName string) string {
+	return filepath.Join(stateDir, "state-backups", wsName)
+}
+
+// ListStateBackups returns a workspace's state backups, newest first
+func ListStateBackups(wsName string) ([]StateBackup, error) {
+	backupDir := GetStateBackupDir(getStateDir(), wsName)
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read state backup directory: %w", err)
+	}
+
+	var backups []StateBackup
+	for _, entry := range entries {
+		backup, ok := parseStateBackupName(entry.Name())
+		if !ok || entry.IsDir() {
+			continue
+		}
+		backup.Path = filepath.Join(backupDir, entry.Name())
+		if info, err := entry.Info(); err == nil {
+			backup.Size = info.Size()
+		}
+		if data, err := os.ReadFile(backup.Path); err == nil {
+			backup.Serial = stateSerial(data)
+			backup.Resources = countResources(data)
+		}
+		backups = append(backups, backup)
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Version > backups[j].Version })
+	return backups, nil
+}
+
+// parseStateBackupName parses a backup file name: VERSION-TIME-REASON.tfstate
+func parseStateBackupName(name string) (StateBackup, bool) {
+	base, ok := strings.CutSuffix(name, ".tfstate")
+	if !ok {
+		return StateBackup{}, false
+	}
+	parts := strings.SplitN(base, "-", 3)
+	if len(parts) != 3 {
+		return StateBackup{}, false
+	}
+
+	version, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return StateBackup{}, false
+	}
+	createdAt, err := time.Parse(stateBackupTimeFormat, parts[1])
+	if err != nil {
+		return StateBackup{}, false
+	}
+	return StateBackup{Version: version, CreatedAt: createdAt, Reason: parts[2]}, true
+}
+
+// stateSerial returns the serial of an OpenTofu state, 0 if it has none
+func stateSerial(data []byte) int {
+	var state struct {
+		Serial int `json:"serial"`
+	}
+	_ = json.Unmarshal(data, &state)
+	return state.Serial
+}
+
+// BackupState backs up a workspace's current OpenTofu state, holding the deployment lock so no
+// operation changes it meanwhile. Returns nil if there is no state or it is already backed up.
+// The state of a workspace with a remote backend is pulled fresh first.
+func (c *Client) BackupState(ws *workspace.Workspace) (*StateBackup, error) {
+	workingDir := GetWorkingDir(ws.Name)
+	if _, err := os.Stat(workingDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	unlock, err := acquireDeploymentLock(workingDir, "state backup")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := c.pullRemoteState(ws, workingDir); err != nil {
+		return nil, err
+	}
+	return backupState(ws, BackupReasonManual, time.Now())
+}
+
+// backupState copies the working directory's state into a new backup version and prunes backups
+// beyond the workspace's retention. The caller holds the deployment lock.
+func backupState(ws *workspace.Workspace, reason string, now time.Time) (*StateBackup, error) {
+	data, err := os.ReadFile(stateFilePath(GetWorkingDir(ws.Name)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	backups, err := ListStateBackups(ws.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Applies that change nothing would otherwise crowd out older versions
+	version := 1
+	if len(backups) > 0 {
+		if latest, err := os.ReadFile(backups[0].Path); err == nil && bytes.Equal(latest, data) {
+			return nil, nil
+		}
+		version = backups[0].Version + 1
+	}
+
+	backupDir := GetStateBackupDir(getStateDir(), ws.Name)
+	if err := os.MkdirAll(backupDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create state backup directory: %w", err)
+	}
+
+	// State holds secrets, keep backups private to the provisioner user
+	name := fmt.Sprintf("%06d-%s-%s.tfstate", version, now.UTC().Format(stateBackupTimeFormat), reason)
+	path := filepath.Join(backupDir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write state backup: %w", err)
+	}
+
+	maxVersions, maxAge := ws.Config.GetStateBackupRetention()
+	if err := pruneStateBackups(ws.Name, maxVersions, maxAge, now); err != nil {
+		logging.LogWorkspace(ws.Name, "Failed to prune state backups: %v", err)
+	}
+
+	return &StateBackup{
+		Version:   version,
+		CreatedAt: now,
+		Reason:    reason,
+		Serial:    stateSerial(data),
+		Resources: countResources(data),
+		Size:      int64(len(data)),
+		Path:      path,
+	}, nil
+}
+
+// pruneStateBackups removes backups older than maxAge and all but the newest maxVersions. The
+// newest backup is always kept.
+func pruneStateBackups(wsName string, maxVersions int, maxAge time.Duration, now time.Time) error {
+	backups, err := ListStateBackups(wsName)
+	if err != nil {
+		return err
+	}
+
+	for i, backup := range backups {
+		if i == 0 || (i < maxVersions && now.Sub(backup.CreatedAt) <= maxAge) {
+			continue
+		}
+		if err := os.Remove(backup.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove state backup %d: %w", backup.Version, err)
+		}
+	}
+	return nil
+}
+
+// RestoreState replaces a workspace's OpenTofu state with a backup version, the newest if version
+// is 0. The state it replaces is backed up first, so a restore can be undone. A remote backend
+// gets the backup pushed over its state.
+func (c *Client) RestoreState(ws *workspace.Workspace, version int) (*StateBackup, error) {
+	backups, err := ListStateBackups(ws.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	var backup *StateBackup
+	for i := range backups {
+		if version == 0 || backups[i].Version == version {
+			backup = &backups[i]
+			break
+		}
+	}
+	if backup == nil {
+		if version == 0 {
+			return nil, fmt.Errorf("workspace '%s' has no state backups", ws.Name)
+		}
+		return nil, fmt.Errorf("state backup version %d of workspace '%s' not found", version, ws.Name)
+	}
+
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state backup: %w", err)
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("state backup version %d is not valid JSON", backup.Version)
+	}
+
+	workingDir := GetWorkingDir(ws.Name)
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+	unlock, err := acquireDeploymentLock(workingDir, "state restore")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := c.pullRemoteState(ws, workingDir); err != nil {
+		return nil, err
+	}
+	if _, err := backupState(ws, BackupReasonRestore, time.Now()); err != nil {
+		return nil, fmt.Errorf("failed to back up the current state: %w", err)
+	}
+
+	if ws.Config.HasRemoteBackend() {
+		if err := c.pushRemoteState(ws, workingDir, backup.Path); err != nil {
+			return nil, err
+		}
+		logging.LogWorkspace(ws.Name, "Restored remote OpenTofu state from backup version %d (serial %d, %d resources)",
+			backup.Version, backup.Serial, backup.Resources)
+		return backup, nil
+	}
+
+	// Replace atomically so a crash never leaves a partial state file
+	statePath := filepath.Join(workingDir, "terraform.tfstate")
+	tmpPath := statePath + ".restore"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmpPath, statePath); err != nil {
+		_ = os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to replace state: %w", err)
+	}
+
+	logging.LogWorkspace(ws.Name, "Restored OpenTofu state from backup version %d (serial %d, %d resources)",
+		backup.Version, backup.Serial, backup.Resources)
+	return backup, nil
+}
+
+// pushRemoteState replaces a remote backend's state with a backup, even one with an older serial,
+// and refreshes the local copy
+func (c *Client) pushRemoteState(ws *workspace.Workspace, workingDir, backupPath string) error {
+	if err := writeBackendFiles(ws, workingDir); err != nil {
+		return err
+	}
+	if err := c.Init(workingDir); err != nil {
+		return fmt.Errorf("init failed: %w", err)
+	}
+	if err := c.run(workingDir, "state", "push", "-force", backupPath); err != nil {
+		return fmt.Errorf("failed to push state to the remote backend: %w", err)
+	}
+	return c.pullRemoteState(ws, workingDir)
+}
+
+// backupStep backs up the state as a step of an operation before it applies or destroys.
+// Without a backup the operation does not go ahead.
+func (c *Client) backupStep(op *operation, ws *workspace.Workspace, reason string) error {
+	if err := c.runStep(op, "state backup", func() error {
+		if err := c.pullRemoteState(ws, GetWorkingDir(ws.Name)); err != nil {
+			return err
+		}
+		_, err := backupState(ws, reason, time.Now())
+		return err
+	}); err != nil {
+		return fmt.Errorf("state backup failed: %w", err)
+	}
+	return nil
+}
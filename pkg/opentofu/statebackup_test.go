@@ -0,0 +1,131 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+// writeState writes a workspace's OpenTofu state with a serial and the given managed resources
+func writeState(t *testing.T, wsName string, serial, resources int) {
+	t.Helper()
+	workingDir := GetWorkingDir(wsName)
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+	instances := strings.TrimSuffix(strings.Repeat(`{},`, resources), ",")
+	state := `{"serial":` + strconv.Itoa(serial) + `,"resources":[{"mode":"managed","instances":[` + instances + `]}]}`
+	if err := os.WriteFile(filepath.Join(workingDir, "terraform.tfstate"), []byte(state), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+}
+
+func TestBackupAndRestoreState(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+	ws := &workspace.Workspace{Name: "backup-test"}
+	client := &Client{}
+
+	if backup, err := client.BackupState(ws); err != nil || backup != nil {
+		t.Fatalf("Expected no backup without a working directory, got %+v, %v", backup, err)
+	}
+
+	writeState(t, ws.Name, 1, 2)
+	backup, err := client.BackupState(ws)
+	if err != nil || backup == nil {
+		t.Fatalf("BackupState failed: %+v, %v", backup, err)
+	}
+	if backup.Version != 1 || backup.Serial != 1 || backup.Resources != 2 || backup.Reason != BackupReasonManual {
+		t.Errorf("Unexpected backup %+v", backup)
+	}
+	if info, err := os.Stat(backup.Path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("Expected a private backup file, got %v, %v", info, err)
+	}
+
+	// An unchanged state is not backed up again
+	if backup, err := client.BackupState(ws); err != nil || backup != nil {
+		t.Errorf("Expected no backup of an unchanged state, got %+v, %v", backup, err)
+	}
+
+	// An accidental destroy empties the state; restoring the newest backup brings it back
+	writeState(t, ws.Name, 2, 0)
+	restored, err := client.RestoreState(ws, 0)
+	if err != nil {
+		t.Fatalf("RestoreState failed: %v", err)
+	}
+	if restored.Version != 1 {
+		t.Errorf("Expected version 1 to be restored, got %d", restored.Version)
+	}
+	if count := countStateResources(GetWorkingDir(ws.Name)); count != 2 {
+		t.Errorf("Expected the restored state to hold 2 resources, got %d", count)
+	}
+
+	// The replaced state was backed up, so the restore can be undone
+	backups, err := ListStateBackups(ws.Name)
+	if err != nil {
+		t.Fatalf("ListStateBackups failed: %v", err)
+	}
+	if len(backups) != 2 || backups[0].Version != 2 || backups[0].Reason != BackupReasonRestore || backups[0].Serial != 2 {
+		t.Errorf("Expected the replaced state as newest backup, got %+v", backups)
+	}
+
+	if _, err := client.RestoreState(ws, 9); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected an unknown version to fail, got %v", err)
+	}
+}
+
+func TestStateBackupRetention(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+	ws := &workspace.Workspace{Name: "retention-test", Config: workspace.Config{
+		StateBackup: &workspace.StateBackupConfig{MaxVersions: 3, RetentionDays: 1},
+	}}
+
+	start := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	for serial := 1; serial <= 5; serial++ {
+		writeState(t, ws.Name, serial, 1)
+		if _, err := backupState(ws, BackupReasonApply, start.Add(time.Duration(serial)*time.Hour)); err != nil {
+			t.Fatalf("backupState failed: %v", err)
+		}
+	}
+
+	backups, _ := ListStateBackups(ws.Name)
+	var versions []int
+	for _, backup := range backups {
+		versions = append(versions, backup.Version)
+	}
+	if len(versions) != 3 || versions[0] != 5 || versions[2] != 3 {
+		t.Errorf("Expected the newest 3 versions to be kept, got %v", versions)
+	}
+
+	// Expired backups are removed, except the newest
+	if err := pruneStateBackups(ws.Name, 3, 24*time.Hour, start.Add(72*time.Hour)); err != nil {
+		t.Fatalf("pruneStateBackups failed: %v", err)
+	}
+	if backups, _ := ListStateBackups(ws.Name); len(backups) != 1 || backups[0].Version != 5 {
+		t.Errorf("Expected only the newest backup to outlive retention, got %+v", backups)
+	}
+}
+
+func TestDeployBacksUpStateBeforeApply(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	client := &Client{binaryPath: writeFakeTofu(t, applyJSONOutput, 0)}
+	ws := newResultTestWorkspace(t)
+	writeState(t, ws.Name, 4, 1)
+
+	if err := client.Deploy(ws); err != nil {
+		t.Fatalf("Deploy failed: %v", err)
+	}
+
+	backups, err := ListStateBackups(ws.Name)
+	if err != nil {
+		t.Fatalf("ListStateBackups failed: %v", err)
+	}
+	if len(backups) != 1 || backups[0].Reason != BackupReasonApply || backups[0].Serial != 4 || backups[0].Resources != 1 {
+		t.Errorf("Expected the state before the apply to be backed up, got %+v", backups)
+	}
+}
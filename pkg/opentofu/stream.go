@@ -0,0 +1,96 @@
+package opentofu
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"strings"
+
+	"provisioner/pkg/logging"
+)
+
+// streamOutput connects a command's output to stdout and stderr. When the command runs for a
+// workspace operation, each line is also written to the workspace log as soon as it is printed,
+// prefixed with the operation's step, so `workspacectl logs --follow` shows the progress of long
+// applies and destroys. Lines of -json commands are logged as their human-readable message.
+// The returned function logs unterminated last lines and must be called after the command ran.
+func (c *Client) streamOutput(workingDir string, cmd *exec.Cmd, stdout, stderr *bytes.Buffer, jsonOutput bool) func() {
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	c.mu.Lock()
+	op, ok := c.operations[workingDir]
+	var workspaceName, step string
+	if ok {
+		workspaceName, step = op.workspace, op.step
+	}
+	c.mu.Unlock()
+	if workspaceName == "" {
+		return func() {}
+	}
+
+	logLine := func(line string) {
+		if line = strings.TrimRight(line, "\r "); line != "" {
+			logging.LogWorkspaceOnly(workspaceName, "%s: %s", step, line)
+		}
+	}
+	outLines := &lineWriter{emit: logLine}
+	if jsonOutput {
+		outLines.emit = func(line string) { logLine(jsonLogMessage(line)) }
+	}
+	// Errors outside the JSON stream, e.g. of a crashing provider, are plain text
+	errLines := &lineWriter{emit: logLine}
+	cmd.Stdout = io.MultiWriter(stdout, outLines)
+	cmd.Stderr = io.MultiWriter(stderr, errLines)
+
+	return func() {
+		outLines.flush()
+		errLines.flush()
+	}
+}
+
+// jsonLogMessage returns the human-readable message of a line of -json output, such as
+// "aws_instance.web: Still creating... [1m0s elapsed]". Lines that aren't JSON are returned
+// unchanged; the version banner is dropped.
+func jsonLogMessage(line string) string {
+	var msg struct {
+		Type    string `json:"type"`
+		Message string `json:"@message"`
+	}
+	if err := json.Unmarshal([]byte(line), &msg); err != nil {
+		return line
+	}
+	if msg.Type == "version" {
+		return ""
+	}
+	return msg.Message
+}
+
+// lineWriter passes every complete line written to it to emit, keeping the rest until the next
+// write or flush
+type lineWriter struct {
+	emit func(line string)
+	buf  []byte
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.emit(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// flush emits what remains after the last newline
+func (w *lineWriter) flush() {
+	if len(w.buf) > 0 {
+		w.emit(string(w.buf))
+		w.buf = nil
+	}
+}
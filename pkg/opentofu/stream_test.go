@@ -0,0 +1,82 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/logging"
+)
+
+func TestLineWriter(t *testing.T) {
+	var lines []string
+	w := &lineWriter{emit: func(line string) { lines = append(lines, line) }}
+
+	_, _ = w.Write([]byte("first\nsec"))
+	_, _ = w.Write([]byte("ond\nlast"))
+	if strings.Join(lines, "|") != "first|second" {
+		t.Errorf("Expected complete lines only, got %q", lines)
+	}
+	w.flush()
+	if strings.Join(lines, "|") != "first|second|last" {
+		t.Errorf("Expected flush to emit the unterminated line, got %q", lines)
+	}
+}
+
+func TestJSONLogMessage(t *testing.T) {
+	tests := map[string]string{
+		`{"@message":"aws_instance.web: Creating...","type":"apply_start"}`: "aws_instance.web: Creating...",
+		`{"@message":"OpenTofu 1.8.2","type":"version"}`:                    "",
+		"Error: provider crashed":                                           "Error: provider crashed",
+	}
+	for line, want := range tests {
+		if got := jsonLogMessage(line); got != want {
+			t.Errorf("jsonLogMessage(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
+func TestStreamOutputToWorkspaceLog(t *testing.T) {
+	logDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+	t.Setenv("PROVISIONER_LOG_DIR", logDir)
+	logging.ResetSingleton()
+	defer logging.ResetSingleton()
+
+	workingDir := GetWorkingDir("stream-test")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+
+	client := &Client{binaryPath: "tofu"}
+	op, done := client.beginOperation(workingDir)
+	defer done()
+	op.workspace = "stream-test"
+
+	err := client.runStep(op, "apply", func() error {
+		return client.executeCustomCommand("echo creating; echo warning >&2; printf finished", workingDir)
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(logDir, "stream-test.log"))
+	if err != nil {
+		t.Fatalf("Failed to read workspace log: %v", err)
+	}
+	for _, want := range []string{"apply: creating", "apply: warning", "apply: finished"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %q in workspace log, got:\n%s", want, data)
+		}
+	}
+
+	// Commands outside a workspace operation are not logged
+	logging.ResetSingleton()
+	if err := client.executeCustomCommand("echo unlogged", t.TempDir()); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(logDir, "stream-test.log")); strings.Contains(string(data), "unlogged") {
+		t.Error("Expected output outside an operation not to be logged")
+	}
+}
@@ -0,0 +1,100 @@
+package opentofu
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"provisioner/pkg/paths"
+	"provisioner/pkg/workspace"
+)
+
+// ErrTimedOut is returned (wrapped in a *TimeoutError) when an operation ran longer than its timeout
+var ErrTimedOut = errors.New("operation timed out")
+
+// DefaultOperationTimeout limits deploys and destroys without a deploy_timeout or destroy_timeout
+const DefaultOperationTimeout = 30 * time.Minute
+
+// TimeoutError describes how far an operation stopped for running too long got
+type TimeoutError struct {
+	Timeout        time.Duration
+	Step           string   // Step that was running when the timeout expired
+	CompletedSteps []string // Steps that finished before
+}
+
+func (e *TimeoutError) Error() string {
+	if len(e.CompletedSteps) == 0 {
+		return fmt.Sprintf("%s after %v during %s", ErrTimedOut, e.Timeout, e.Step)
+	}
+	return fmt.Sprintf("%s after %v during %s (completed: %s)", ErrTimedOut, e.Timeout, e.Step, strings.Join(e.CompletedSteps, ", "))
+}
+
+// Unwrap allows errors.Is(err, ErrTimedOut)
+func (e *TimeoutError) Unwrap() error {
+	return ErrTimedOut
+}
+
+// DefaultOperationTimeouts returns deploy_timeout and destroy_timeout of the daemon config,
+// DefaultOperationTimeout for those not set
+func DefaultOperationTimeouts() (deploy, destroy time.Duration) {
+	deploy, destroy = DefaultOperationTimeout, DefaultOperationTimeout
+	data, err := os.ReadFile(filepath.Join(paths.ConfigDir(), "provisioner.json"))
+	if err != nil {
+		return deploy, destroy
+	}
+
+	var config struct {
+		DeployTimeout  string `json:"deploy_timeout"`
+		DestroyTimeout string `json:"destroy_timeout"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return deploy, destroy
+	}
+	if timeout, err := workspace.ParseOperationTimeout("deploy_timeout", config.DeployTimeout); err == nil && timeout > 0 {
+		deploy = timeout
+	}
+	if timeout, err := workspace.ParseOperationTimeout("destroy_timeout", config.DestroyTimeout); err == nil && timeout > 0 {
+		destroy = timeout
+	}
+	return deploy, destroy
+}
+
+// DeployTimeout returns how long a deploy of the workspace may run: its own deploy_timeout,
+// otherwise the daemon default
+func DeployTimeout(ws *workspace.Workspace) time.Duration {
+	if timeout := ws.Config.GetDeployTimeout(); timeout > 0 {
+		return timeout
+	}
+	deploy, _ := DefaultOperationTimeouts()
+	return deploy
+}
+
+// DestroyTimeout returns how long a destroy of the workspace may run: its own destroy_timeout,
+// otherwise the daemon default
+func DestroyTimeout(ws *workspace.Workspace) time.Duration {
+	if timeout := ws.Config.GetDestroyTimeout(); timeout > 0 {
+		return timeout
+	}
+	_, destroy := DefaultOperationTimeouts()
+	return destroy
+}
+
+// limitOperation stops an operation once it has run for timeout. Its running command is
+// terminated like a cancelled one, and the operation fails with a *TimeoutError.
+func (c *Client) limitOperation(op *operation, timeout time.Duration) {
+	timer := time.AfterFunc(timeout, func() {
+		c.mu.Lock()
+		op.timedOut = true
+		c.mu.Unlock()
+		op.cancel()
+	})
+
+	c.mu.Lock()
+	op.timeout = timeout
+	op.timer = timer
+	c.mu.Unlock()
+}
@@ -0,0 +1,74 @@
+package opentofu
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestOperationTimeoutStopsCommand(t *testing.T) {
+	t.Setenv("PROVISIONER_STATE_DIR", t.TempDir())
+
+	workingDir := GetWorkingDir("timeout-test")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+
+	client := &Client{binaryPath: "tofu"}
+	op, done := client.beginOperation(workingDir)
+	defer done()
+	client.limitOperation(op, 300*time.Millisecond)
+
+	if err := client.runStep(op, "init", func() error { return nil }); err != nil {
+		t.Fatalf("Unexpected error from init step: %v", err)
+	}
+
+	start := time.Now()
+	err := client.runStep(op, "apply", func() error {
+		return client.executeCustomCommand("sleep 30; echo done", workingDir)
+	})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Timed out command ran for %v", elapsed)
+	}
+
+	if !errors.Is(err, ErrTimedOut) || errors.Is(err, ErrCancelled) {
+		t.Fatalf("Expected ErrTimedOut, got %v", err)
+	}
+	var timedOut *TimeoutError
+	if !errors.As(err, &timedOut) {
+		t.Fatalf("Expected *TimeoutError, got %T", err)
+	}
+	if timedOut.Step != "apply" || len(timedOut.CompletedSteps) != 1 || timedOut.Timeout != 300*time.Millisecond {
+		t.Errorf("Unexpected timeout details: %+v", timedOut)
+	}
+	if msg := timedOut.Error(); msg != "operation timed out after 300ms during apply (completed: init)" {
+		t.Errorf("Unexpected message: %s", msg)
+	}
+}
+
+func TestOperationTimeouts(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+
+	ws := &workspace.Workspace{Name: "app"}
+	if DeployTimeout(ws) != DefaultOperationTimeout || DestroyTimeout(ws) != DefaultOperationTimeout {
+		t.Errorf("Expected the %v default, got %v and %v", DefaultOperationTimeout, DeployTimeout(ws), DestroyTimeout(ws))
+	}
+
+	config := `{"deploy_timeout": "1h", "destroy_timeout": "45m"}`
+	if err := os.WriteFile(filepath.Join(configDir, "provisioner.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if DeployTimeout(ws) != time.Hour || DestroyTimeout(ws) != 45*time.Minute {
+		t.Errorf("Expected the daemon defaults, got %v and %v", DeployTimeout(ws), DestroyTimeout(ws))
+	}
+
+	ws.Config.DeployTimeout = "2h"
+	if DeployTimeout(ws) != 2*time.Hour || DestroyTimeout(ws) != 45*time.Minute {
+		t.Errorf("Expected the workspace's deploy_timeout, got %v and %v", DeployTimeout(ws), DestroyTimeout(ws))
+	}
+}
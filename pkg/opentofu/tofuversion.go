@@ -0,0 +1,212 @@
+package opentofu
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/paths"
+	"provisioner/pkg/workspace"
+
+	"github.com/opentofu/tofudl"
+)
+
+// TofuVersionFile in a working directory pins the OpenTofu version its commands run with
+const TofuVersionFile = ".tofu-version"
+
+// VersionManager downloads OpenTofu releases and caches them in the state directory under
+// tofu/VERSION/. The SHA-256 of each binary is recorded when it is downloaded and checked
+// before every use, so a corrupted or replaced binary is downloaded again instead of run.
+type VersionManager struct {
+	dir      string
+	download func(version string) ([]byte, error)
+
+	mu sync.Mutex
+}
+
+// NewVersionManager creates a version manager caching releases in stateDir
+func NewVersionManager(stateDir string) *VersionManager {
+	return &VersionManager{dir: filepath.Join(stateDir, "tofu"), download: downloadTofu}
+}
+
+// downloadTofu downloads a release for this platform with TofuDL, which verifies the signature
+// of its checksums and the checksum of the binary
+func downloadTofu(version string) ([]byte, error) {
+	downloader, err := tofudl.New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create downloader: %w", err)
+	}
+	return downloader.Download(context.Background(), tofudl.DownloadOptVersion(tofudl.Version(version)))
+}
+
+// Binary returns the path of the cached binary of an OpenTofu version, downloading it first
+func (m *VersionManager) Binary(version string) (string, error) {
+	if err := workspace.ValidateTofuVersion(version); err != nil {
+		return "", err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	dir := filepath.Join(m.dir, version)
+	binaryPath := filepath.Join(dir, "tofu")
+	err := verifyCachedBinary(binaryPath)
+	if err == nil {
+		return binaryPath, nil
+	}
+	if !os.IsNotExist(err) {
+		logging.LogSystemd("Downloading OpenTofu %s again: %v", version, err)
+	}
+
+	data, err := m.download(version)
+	if err != nil {
+		return "", fmt.Errorf("failed to download OpenTofu %s: %w", version, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+
+	// Write next to the final path and rename, so other processes never run a partial binary
+	tmpFile, err := os.CreateTemp(dir, "tofu-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmpFile.Name()) }()
+	if _, err := tmpFile.Write(data); err != nil {
+		_ = tmpFile.Close()
+		return "", fmt.Errorf("failed to write binary: %w", err)
+	}
+	_ = tmpFile.Close()
+	if err := os.Chmod(tmpFile.Name(), 0755); err != nil {
+		return "", fmt.Errorf("failed to make binary executable: %w", err)
+	}
+
+	if actual, err := readBinaryVersion(tmpFile.Name()); err != nil {
+		return "", err
+	} else if actual != version {
+		return "", fmt.Errorf("downloaded OpenTofu reports version %s instead of %s", actual, version)
+	}
+
+	if err := os.Rename(tmpFile.Name(), binaryPath); err != nil {
+		return "", fmt.Errorf("failed to install OpenTofu %s: %w", version, err)
+	}
+	sum := fmt.Sprintf("%x", sha256.Sum256(data))
+	if err := os.WriteFile(binaryPath+".sha256", []byte(sum+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to record checksum of OpenTofu %s: %w", version, err)
+	}
+	return binaryPath, nil
+}
+
+// verifyCachedBinary checks a cached binary against the checksum recorded when it was downloaded
+func verifyCachedBinary(binaryPath string) error {
+	want, err := os.ReadFile(binaryPath + ".sha256")
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(binaryPath)
+	if err != nil {
+		return err
+	}
+	if fmt.Sprintf("%x", sha256.Sum256(data)) != strings.TrimSpace(string(want)) {
+		return fmt.Errorf("checksum of %s does not match the one recorded at download", binaryPath)
+	}
+	return nil
+}
+
+// DefaultTofuVersion returns tofu_version of the daemon config, empty if it is not set
+func DefaultTofuVersion() string {
+	data, err := os.ReadFile(filepath.Join(paths.ConfigDir(), "provisioner.json"))
+	if err != nil {
+		return ""
+	}
+
+	var config struct {
+		TofuVersion string `json:"tofu_version"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return ""
+	}
+	return config.TofuVersion
+}
+
+// WorkspaceTofuVersion returns the OpenTofu version a workspace runs with: its own tofu_version,
+// otherwise the daemon default. Empty means the tofu in PATH or the latest release.
+func WorkspaceTofuVersion(ws *workspace.Workspace) string {
+	if ws.Config.TofuVersion != "" {
+		return ws.Config.TofuVersion
+	}
+	return DefaultTofuVersion()
+}
+
+// pinTofuVersion writes the workspace's OpenTofu version to the working directory's
+// TofuVersionFile, or removes the file when none is configured. A version older than the one
+// that last wrote the state is refused, as it can't read that state.
+func pinTofuVersion(ws *workspace.Workspace, workingDir string) error {
+	pinPath := filepath.Join(workingDir, TofuVersionFile)
+	version := WorkspaceTofuVersion(ws)
+	if version == "" {
+		if err := os.Remove(pinPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", TofuVersionFile, err)
+		}
+		return nil
+	}
+
+	if stateVersion, _ := ws.GetStateTofuVersion(); workspace.ValidateTofuVersion(stateVersion) == nil &&
+		tofudl.Version(version).Compare(tofudl.Version(stateVersion)) < 0 {
+		return fmt.Errorf("tofu_version %s is older than OpenTofu %s, which last wrote the state", version, stateVersion)
+	}
+
+	if err := os.WriteFile(pinPath, []byte(version+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", TofuVersionFile, err)
+	}
+	return nil
+}
+
+// pinScratchVersion pins a scratch copy of a configuration to tofuVersion. Without one the
+// client's binary is used, which is the daemon default if one is set.
+func pinScratchVersion(scratchDir, tofuVersion string) error {
+	if tofuVersion == "" {
+		return nil
+	}
+	if err := os.WriteFile(filepath.Join(scratchDir, TofuVersionFile), []byte(tofuVersion+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", TofuVersionFile, err)
+	}
+	return nil
+}
+
+// tofuBinary returns the tofu binary for a working directory: the version pinned in its
+// TofuVersionFile, otherwise the client's binary
+func (c *Client) tofuBinary(workingDir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(workingDir, TofuVersionFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return c.binaryPath, nil
+		}
+		return "", fmt.Errorf("failed to read %s: %w", TofuVersionFile, err)
+	}
+
+	c.mu.Lock()
+	if c.versions == nil {
+		c.versions = NewVersionManager(getStateDir())
+	}
+	versions := c.versions
+	c.mu.Unlock()
+
+	return versions.Binary(strings.TrimSpace(string(data)))
+}
+
+// tofuCommand creates a tofu command for a working directory with the binary of its version
+func (c *Client) tofuCommand(workingDir string, args ...string) (*exec.Cmd, error) {
+	binaryPath, err := c.tofuBinary(workingDir)
+	if err != nil {
+		return nil, err
+	}
+	return c.command(workingDir, binaryPath, args...), nil
+}
@@ -0,0 +1,145 @@
+package opentofu
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/workspace"
+)
+
+// newFakeVersionManager returns a version manager whose downloads are scripts reporting reported,
+// or the version asked for if reported is empty, and a counter of the downloads
+func newFakeVersionManager(t *testing.T, reported string) (*VersionManager, *int) {
+	t.Helper()
+	downloads := 0
+	manager := NewVersionManager(t.TempDir())
+	manager.download = func(version string) ([]byte, error) {
+		downloads++
+		if reported != "" {
+			version = reported
+		}
+		return []byte(fmt.Sprintf("#!/bin/sh\necho '{\"terraform_version\":\"%s\"}'\n", version)), nil
+	}
+	return manager, &downloads
+}
+
+func TestVersionManagerBinary(t *testing.T) {
+	manager, downloads := newFakeVersionManager(t, "")
+
+	binaryPath, err := manager.Binary("1.8.2")
+	if err != nil {
+		t.Fatalf("Binary() error: %v", err)
+	}
+	if binaryPath != filepath.Join(manager.dir, "1.8.2", "tofu") {
+		t.Errorf("Unexpected binary path %s", binaryPath)
+	}
+	if version, err := readBinaryVersion(binaryPath); err != nil || version != "1.8.2" {
+		t.Errorf("Expected the cached binary to report 1.8.2, got %q (%v)", version, err)
+	}
+
+	// Cached binaries are reused
+	if _, err := manager.Binary("1.8.2"); err != nil || *downloads != 1 {
+		t.Errorf("Expected the cached binary to be used, got %d downloads (%v)", *downloads, err)
+	}
+
+	// A binary changed after its download is downloaded again
+	if err := os.WriteFile(binaryPath, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatalf("Failed to modify binary: %v", err)
+	}
+	if _, err := manager.Binary("1.8.2"); err != nil || *downloads != 2 {
+		t.Errorf("Expected the modified binary to be downloaded again, got %d downloads (%v)", *downloads, err)
+	}
+	if version, _ := readBinaryVersion(binaryPath); version != "1.8.2" {
+		t.Errorf("Expected the modified binary to be replaced, got version %q", version)
+	}
+
+	if _, err := manager.Binary("latest"); err == nil {
+		t.Error("Expected an invalid version to be refused")
+	}
+}
+
+func TestVersionManagerRefusesWrongVersion(t *testing.T) {
+	manager, _ := newFakeVersionManager(t, "1.7.0")
+
+	_, err := manager.Binary("1.8.2")
+	if err == nil || !strings.Contains(err.Error(), "reports version 1.7.0") {
+		t.Fatalf("Expected the wrong version to be refused, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(manager.dir, "1.8.2", "tofu")); !os.IsNotExist(err) {
+		t.Error("Expected the wrong binary not to be cached")
+	}
+
+	manager.download = func(string) ([]byte, error) { return nil, errors.New("no such version") }
+	if _, err := manager.Binary("1.8.2"); err == nil {
+		t.Error("Expected the failed download to be reported")
+	}
+}
+
+func TestPinTofuVersion(t *testing.T) {
+	stateDir := t.TempDir()
+	configDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+
+	ws := &workspace.Workspace{Name: "pinned", Config: workspace.Config{TofuVersion: "1.8.2"}}
+	workingDir := GetWorkingDir(ws.Name)
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working directory: %v", err)
+	}
+	pinPath := filepath.Join(workingDir, TofuVersionFile)
+
+	if err := pinTofuVersion(ws, workingDir); err != nil {
+		t.Fatalf("pinTofuVersion() error: %v", err)
+	}
+	if data, _ := os.ReadFile(pinPath); strings.TrimSpace(string(data)) != "1.8.2" {
+		t.Errorf("Expected 1.8.2 to be pinned, got %q", data)
+	}
+
+	// Commands in the working directory run the pinned version
+	manager, _ := newFakeVersionManager(t, "")
+	client := &Client{binaryPath: "/usr/bin/tofu", versions: manager}
+	cmd, err := client.tofuCommand(workingDir, "version")
+	if err != nil {
+		t.Fatalf("tofuCommand() error: %v", err)
+	}
+	if cmd.Path != filepath.Join(manager.dir, "1.8.2", "tofu") {
+		t.Errorf("Expected the pinned binary, got %s", cmd.Path)
+	}
+
+	// The daemon default applies to workspaces without their own version
+	ws.Config.TofuVersion = ""
+	if err := os.WriteFile(filepath.Join(configDir, "provisioner.json"), []byte(`{"tofu_version": "1.9.0"}`), 0644); err != nil {
+		t.Fatalf("Failed to write provisioner.json: %v", err)
+	}
+	if err := pinTofuVersion(ws, workingDir); err != nil {
+		t.Fatalf("pinTofuVersion() error: %v", err)
+	}
+	if data, _ := os.ReadFile(pinPath); strings.TrimSpace(string(data)) != "1.9.0" {
+		t.Errorf("Expected the default 1.9.0 to be pinned, got %q", data)
+	}
+
+	// Older than the version that wrote the state is refused
+	ws.Config.TofuVersion = "1.6.0"
+	if err := os.WriteFile(filepath.Join(workingDir, "terraform.tfstate"), []byte(`{"terraform_version": "1.8.2"}`), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+	if err := pinTofuVersion(ws, workingDir); err == nil || !strings.Contains(err.Error(), "older than OpenTofu 1.8.2") {
+		t.Errorf("Expected the downgrade to be refused, got %v", err)
+	}
+
+	// Without any version the pin is removed and the client's binary is used
+	ws.Config.TofuVersion = ""
+	if err := os.Remove(filepath.Join(configDir, "provisioner.json")); err != nil {
+		t.Fatalf("Failed to remove provisioner.json: %v", err)
+	}
+	if err := pinTofuVersion(ws, workingDir); err != nil {
+		t.Fatalf("pinTofuVersion() error: %v", err)
+	}
+	if cmd, _ := client.tofuCommand(workingDir, "version"); cmd.Path != "/usr/bin/tofu" {
+		t.Errorf("Expected the client's binary without a pin, got %s", cmd.Path)
+	}
+}
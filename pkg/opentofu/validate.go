@@ -0,0 +1,96 @@
+package opentofu
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"provisioner/pkg/lint"
+	"provisioner/pkg/workspace"
+)
+
+// NewConfigChecker returns the checker of workspacectl and templatectl validate: tofu validate,
+// and with format also tofu fmt -check, on a rendered scratch copy of the configuration
+func NewConfigChecker(format bool) (workspace.ConfigChecker, error) {
+	client, err := New()
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+	}
+	return func(dir string, vars map[string]interface{}, tofuVersion string) error {
+		return client.CheckConfig(dir, vars, tofuVersion, format)
+	}, nil
+}
+
+// CheckConfig copies a configuration to a scratch directory with the config variables, like a
+// deployment, and checks it with tofu validate after an init without backend, in tofuVersion if
+// set. With format, files tofu fmt would change are reported too. Warnings are ignored; errors
+// are returned one per line.
+func (c *Client) CheckConfig(srcDir string, vars map[string]interface{}, tofuVersion string, format bool) error {
+	scratchDir, err := os.MkdirTemp("", "provisioner-validate-*")
+	if err != nil {
+		return fmt.Errorf("failed to create validate directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(scratchDir) }()
+
+	if err := copyDirectoryFiles(srcDir, scratchDir); err != nil {
+		return fmt.Errorf("failed to copy configuration: %w", err)
+	}
+	if err := workspace.WriteConfigVarsFile(scratchDir, vars); err != nil {
+		return err
+	}
+	if err := pinScratchVersion(scratchDir, tofuVersion); err != nil {
+		return err
+	}
+
+	var problems []string
+	if format {
+		files, err := c.FormatCheck(scratchDir)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			problems = append(problems, fmt.Sprintf("%s: not formatted, run tofu fmt", file))
+		}
+	}
+
+	if err := c.run(scratchDir, "init", "-backend=false", "-input=false"); err != nil {
+		return fmt.Errorf("init failed: %w", err)
+	}
+	findings, err := c.Validate(scratchDir)
+	if err != nil {
+		return fmt.Errorf("validate failed: %w", err)
+	}
+	for _, finding := range findings {
+		if finding.Severity == lint.SeverityError {
+			problems = append(problems, finding.String())
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("OpenTofu reported %d problem(s):\n    %s", len(problems), strings.Join(problems, "\n    "))
+	}
+	return nil
+}
+
+// FormatCheck runs tofu fmt -check on a directory and its modules and returns the files it would
+// change. Files tofu cannot parse are left to validate, which reports the syntax errors.
+func (c *Client) FormatCheck(workingDir string) ([]string, error) {
+	cmd, err := c.tofuCommand(workingDir, "fmt", "-check", "-list=true", "-recursive", "-no-color")
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	// Unformatted files make fmt exit non-zero with their names on stdout
+	runErr := cmd.Run()
+
+	files := strings.Fields(stdout.String())
+	if runErr != nil && len(files) == 0 && stderr.Len() == 0 {
+		return nil, fmt.Errorf("fmt failed: %w", runErr)
+	}
+	return files, nil
+}
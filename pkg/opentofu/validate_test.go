@@ -0,0 +1,56 @@
+package opentofu
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeFakeCheck writes a tofu binary whose fmt lists unformatted and whose validate prints
+// output, failing validate when the config variables were not rendered into the directory
+func writeFakeCheck(t *testing.T, unformatted, output string) string {
+	t.Helper()
+	script := `#!/bin/sh
+case "$1" in
+fmt)
+  [ -n "` + unformatted + `" ] && printf '%s\n' "` + unformatted + `" && exit 3
+  exit 0 ;;
+validate)
+  [ -f terraform.tfvars.json ] || exit 9
+  printf '%s\n' '` + output + `'
+  exit 1 ;;
+esac
+exit 0
+`
+	return writeTestTofu(t, script)
+}
+
+func TestCheckConfig(t *testing.T) {
+	srcDir := writeLintConfig(t)
+	vars := map[string]interface{}{"instance_count": 2}
+	output := `{"valid":false,"diagnostics":[{"severity":"error","summary":"Unsupported argument","range":{"filename":"main.tf","start":{"line":3}}},{"severity":"warning","summary":"Deprecated attribute"}]}`
+	client := &Client{binaryPath: writeFakeCheck(t, "modules/vpc/main.tf", output)}
+
+	err := client.CheckConfig(srcDir, vars, "", true)
+	if err == nil {
+		t.Fatal("Expected the validate error and unformatted file to be reported")
+	}
+	for _, want := range []string{"2 problem(s)", "modules/vpc/main.tf: not formatted", "main.tf:3: error: Unsupported argument [validate]"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected %q in %v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "Deprecated attribute") {
+		t.Errorf("Expected warnings to be ignored, got %v", err)
+	}
+
+	// Without format the formatting is not checked, and a clean validate passes
+	client.binaryPath = writeFakeCheck(t, "main.tf", `{"valid":true,"diagnostics":[]}`)
+	if err := client.CheckConfig(srcDir, vars, "", false); err != nil {
+		t.Errorf("Expected a valid configuration to pass, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(srcDir, "terraform.tfvars.json")); !os.IsNotExist(err) {
+		t.Error("Expected the variables to be rendered in the scratch copy only")
+	}
+}
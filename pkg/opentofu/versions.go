@@ -0,0 +1,238 @@
+package opentofu
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"provisioner/pkg/paths"
+	"provisioner/pkg/template"
+	"provisioner/pkg/version"
+	"provisioner/pkg/workspace"
+)
+
+// VersionReport summarizes tool, provider and template versions across all workspaces
+type VersionReport struct {
+	GeneratedAt        time.Time                `json:"generated_at"`
+	ProvisionerVersion string                   `json:"provisioner_version"`
+	TofuBinary         string                   `json:"tofu_binary,omitempty"`
+	TofuVersion        string                   `json:"tofu_version,omitempty"`
+	Workspaces         []WorkspaceVersionReport `json:"workspaces"`
+}
+
+// WorkspaceVersionReport holds the versions in use by a single workspace
+type WorkspaceVersionReport struct {
+	Name             string                   `json:"name"`
+	TofuVersion      string                   `json:"tofu_version,omitempty"` // Version that last wrote the state
+	Template         string                   `json:"template,omitempty"`
+	TemplateRef      string                   `json:"template_ref,omitempty"`
+	TemplateVersion  string                   `json:"template_version,omitempty"`
+	TemplateHash     string                   `json:"template_hash,omitempty"`          // Current template content hash
+	DeployedHash     string                   `json:"deployed_template_hash,omitempty"` // Hash of the template last deployed
+	TemplateOutdated bool                     `json:"template_outdated,omitempty"`
+	Providers        []workspace.ProviderLock `json:"providers,omitempty"`
+	Errors           []string                 `json:"errors,omitempty"`
+}
+
+// GetBinaryVersion returns the path and version of the tofu binary found in PATH
+func GetBinaryVersion() (string, string, error) {
+	binaryPath, err := exec.LookPath("tofu")
+	if err != nil {
+		return "", "", fmt.Errorf("tofu binary not found in PATH")
+	}
+
+	version, err := readBinaryVersion(binaryPath)
+	return binaryPath, version, err
+}
+
+// readBinaryVersion returns the version a tofu binary reports
+func readBinaryVersion(binaryPath string) (string, error) {
+	output, err := exec.Command(binaryPath, "version", "-json").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get tofu version: %w", err)
+	}
+
+	var info struct {
+		TerraformVersion string `json:"terraform_version"`
+	}
+	if err := json.Unmarshal(output, &info); err != nil {
+		return "", fmt.Errorf("failed to parse tofu version: %w", err)
+	}
+
+	return info.TerraformVersion, nil
+}
+
+// BuildVersionReport collects version information for the given workspaces
+func BuildVersionReport(workspaces []workspace.Workspace, templateManager *template.Manager, stateDir string) *VersionReport {
+	report := &VersionReport{
+		GeneratedAt:        time.Now(),
+		ProvisionerVersion: version.GetVersion(),
+		Workspaces:         []WorkspaceVersionReport{},
+	}
+
+	// A missing binary is not fatal, the daemon downloads one on demand
+	report.TofuBinary, report.TofuVersion, _ = GetBinaryVersion()
+
+	for i := range workspaces {
+		report.Workspaces = append(report.Workspaces, buildWorkspaceVersionReport(&workspaces[i], templateManager, stateDir))
+	}
+
+	sort.Slice(report.Workspaces, func(i, j int) bool {
+		return report.Workspaces[i].Name < report.Workspaces[j].Name
+	})
+
+	return report
+}
+
+// buildWorkspaceVersionReport collects version information for a single workspace
+func buildWorkspaceVersionReport(ws *workspace.Workspace, templateManager *template.Manager, stateDir string) WorkspaceVersionReport {
+	entry := WorkspaceVersionReport{
+		Name:     ws.Name,
+		Template: ws.Config.Template,
+	}
+
+	tofuVersion, err := ws.GetStateTofuVersion()
+	if err != nil {
+		entry.Errors = append(entry.Errors, err.Error())
+	}
+	entry.TofuVersion = tofuVersion
+
+	providers, err := ws.GetProviderLocks()
+	if err != nil {
+		entry.Errors = append(entry.Errors, err.Error())
+	}
+	entry.Providers = providers
+
+	if metadata, err := workspace.LoadDeploymentMetadata(stateDir, ws.Name); err == nil {
+		entry.DeployedHash = metadata.TemplateHash
+	} else {
+		entry.Errors = append(entry.Errors, err.Error())
+	}
+
+	if ws.Config.Template != "" && templateManager != nil {
+		tmpl, err := templateManager.GetTemplate(ws.Config.Template)
+		if err != nil {
+			entry.Errors = append(entry.Errors, err.Error())
+		} else {
+			entry.TemplateRef = tmpl.SourceRef
+			entry.TemplateVersion = tmpl.Version
+			entry.TemplateHash = tmpl.ContentHash
+			entry.TemplateOutdated = entry.DeployedHash != "" && tmpl.ContentHash != "" && entry.DeployedHash != tmpl.ContentHash
+		}
+	}
+
+	return entry
+}
+
+// RunVersionsCommand prints the fleet-wide version report
+func RunVersionsCommand(args []string) error {
+	jsonOutput := false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			jsonOutput = true
+		default:
+			return fmt.Errorf("unknown option '%s'", arg)
+		}
+	}
+
+	workspaces, err := workspace.LoadWorkspaces(filepath.Join(paths.ConfigDir(), "workspaces"))
+	if err != nil {
+		return err
+	}
+
+	report := BuildVersionReport(workspaces, template.NewManager(getTemplatesDir()), getStateDir())
+
+	if jsonOutput {
+		// Keep provider constraints such as "~> 5.0" readable
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(report); err != nil {
+			return fmt.Errorf("failed to marshal version report: %w", err)
+		}
+		return nil
+	}
+
+	printVersionReport(report)
+	return nil
+}
+
+// printVersionReport prints the version report as tables
+func printVersionReport(report *VersionReport) {
+	fmt.Printf("Provisioner: %s\n", report.ProvisionerVersion)
+	if report.TofuVersion != "" {
+		fmt.Printf("OpenTofu:    %s (%s)\n", report.TofuVersion, report.TofuBinary)
+	} else {
+		fmt.Println("OpenTofu:    not found in PATH")
+	}
+	fmt.Println()
+
+	if len(report.Workspaces) == 0 {
+		fmt.Println("No workspaces found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "WORKSPACE\tTOFU\tTEMPLATE\tREF\tHASH\tSTATUS")
+	for _, ws := range report.Workspaces {
+		status := "current"
+		if ws.Template == "" {
+			status = "-"
+		} else if ws.TemplateOutdated {
+			status = "outdated"
+		} else if ws.DeployedHash == "" {
+			status = "not deployed"
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			ws.Name, orDash(ws.TofuVersion), orDash(ws.Template), orDash(ws.TemplateRef), orDash(shortHash(ws.TemplateHash)), status)
+	}
+	_ = w.Flush()
+
+	// Providers grouped by source across the fleet
+	providers := make(map[string][]string)
+	for _, ws := range report.Workspaces {
+		for _, p := range ws.Providers {
+			providers[p.Source] = append(providers[p.Source], fmt.Sprintf("%s=%s", ws.Name, p.Version))
+		}
+	}
+	if len(providers) == 0 {
+		return
+	}
+
+	sources := make([]string, 0, len(providers))
+	for source := range providers {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "PROVIDER\tVERSIONS")
+	for _, source := range sources {
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", source, strings.Join(providers[source], ", "))
+	}
+	_ = w.Flush()
+}
+
+// shortHash abbreviates a content hash for display
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
+
+// orDash returns "-" for empty values in table output
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
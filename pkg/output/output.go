@@ -0,0 +1,120 @@
+// Package output renders the results of CLI list, status and show commands as tables for
+// people or as JSON or YAML for scripts and dashboards.
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// yaml11Bools are the plain scalars YAML 1.1 reads as booleans while YAML 1.2 reads strings
+var yaml11Bools = map[string]bool{"yes": true, "no": true, "on": true, "off": true, "y": true, "n": true}
+
+// Format is the output format of a command
+type Format string
+
+const (
+	Table Format = "table" // Human-readable text, the default
+	JSON  Format = "json"
+	YAML  Format = "yaml"
+)
+
+// Structured returns true for machine-readable formats
+func (f Format) Structured() bool {
+	return f == JSON || f == YAML
+}
+
+// ParseFormat parses an --output value
+func ParseFormat(value string) (Format, error) {
+	switch format := Format(strings.ToLower(value)); format {
+	case Table, JSON, YAML:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid output format '%s' (must be json, yaml or table)", value)
+}
+
+// ParseArgs extracts --output FORMAT (or -o FORMAT) from a command's arguments, returning the
+// format, table if not given, and the remaining arguments
+func ParseArgs(args []string) (Format, []string, error) {
+	format := Table
+	var remaining []string
+
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(args[i], "=")
+		if name != "--output" && name != "-o" {
+			remaining = append(remaining, args[i])
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return format, nil, fmt.Errorf("%s requires a format (json, yaml or table)", name)
+			}
+			i++
+			value = args[i]
+		}
+
+		var err error
+		if format, err = ParseFormat(value); err != nil {
+			return format, nil, err
+		}
+	}
+	return format, remaining, nil
+}
+
+// Print writes v to standard output in a structured format
+func Print(format Format, v interface{}) error {
+	return Write(os.Stdout, format, v)
+}
+
+// Write encodes v as JSON or YAML. Field names and order follow v's JSON encoding, so both
+// formats carry the same structure.
+func Write(w io.Writer, format Format, v interface{}) error {
+	var data bytes.Buffer
+	encoder := json.NewEncoder(&data)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+
+	switch format {
+	case JSON:
+		_, err := w.Write(data.Bytes())
+		return err
+	case YAML:
+		// JSON is YAML, so decoding it keeps the keys in order and strings as strings
+		var document yaml.Node
+		if err := yaml.Unmarshal(data.Bytes(), &document); err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		blockStyle(&document)
+
+		out, err := yaml.Marshal(&document)
+		if err != nil {
+			return fmt.Errorf("failed to encode output: %w", err)
+		}
+		_, err = w.Write(out)
+		return err
+	}
+	return fmt.Errorf("format %s is not a structured format", format)
+}
+
+// blockStyle drops the flow style and quotes of decoded JSON, so the encoder writes block
+// collections and quotes only the strings that need it. Strings YAML 1.1 readers take for
+// booleans stay quoted.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" && yaml11Bools[strings.ToLower(node.Value)] {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
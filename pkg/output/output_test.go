@@ -0,0 +1,93 @@
+package output
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseArgs(t *testing.T) {
+	format, rest, err := ParseArgs([]string{"web", "--output", "json", "--detailed"})
+	if err != nil || format != JSON || strings.Join(rest, " ") != "web --detailed" {
+		t.Errorf("Unexpected result %s %v %v", format, rest, err)
+	}
+	if format, _, err := ParseArgs([]string{"-o=YAML"}); err != nil || format != YAML {
+		t.Errorf("Expected yaml, got %s (%v)", format, err)
+	}
+	if format, _, _ := ParseArgs(nil); format != Table || format.Structured() {
+		t.Errorf("Expected table by default, got %s", format)
+	}
+	if _, _, err := ParseArgs([]string{"--output", "xml"}); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+	if _, _, err := ParseArgs([]string{"--output"}); err == nil {
+		t.Error("Expected a missing format to be rejected")
+	}
+}
+
+type testItem struct {
+	Name     string            `json:"name"`
+	Enabled  bool              `json:"enabled"`
+	Count    int               `json:"count"`
+	Schedule []string          `json:"schedule"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Deployed *time.Time        `json:"deployed"`
+	Nested   []testNested      `json:"nested,omitempty"`
+}
+
+type testNested struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+func TestWriteYAML(t *testing.T) {
+	deployed := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+	items := []testItem{
+		{Name: "web", Enabled: true, Count: 2, Schedule: []string{"0 9 * * 1-5"}, Deployed: &deployed,
+			Labels: map[string]string{"team": "a: b"}, Nested: []testNested{{Key: "on", Value: "<x> & 'y'"}, {Key: "command", Value: "# \"a\" && b\n"}}},
+		{Name: "api", Schedule: []string{}},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, YAML, items); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	expected := `- name: web
+  enabled: true
+  count: 2
+  schedule:
+    - 0 9 * * 1-5
+  labels:
+    team: 'a: b'
+  deployed: "2025-03-10T09:00:00Z"
+  nested:
+    - key: "on"
+      value: <x> & 'y'
+    - key: command
+      value: |
+        # "a" && b
+- name: api
+  enabled: false
+  count: 0
+  schedule: []
+  deployed: null
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected YAML:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, JSON, map[string]string{"command": "a && b"}); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if buf.String() != "{\n  \"command\": \"a && b\"\n}\n" {
+		t.Errorf("Unexpected JSON: %s", buf.String())
+	}
+	if err := Write(&buf, Table, nil); err == nil {
+		t.Error("Expected table to be rejected as a structured format")
+	}
+}
@@ -0,0 +1,63 @@
+// Package paths locates the configuration, state and log directories shared by the daemon and the
+// CLIs. Environment variables override the system installation, which overrides the
+// development defaults relative to the working directory.
+package paths
+
+import "os"
+
+const (
+	// SystemConfigDir is the configuration directory of a system installation
+	SystemConfigDir = "/etc/provisioner"
+	// SystemStateDir is the state directory of a system installation
+	SystemStateDir = "/var/lib/provisioner"
+	// SystemLogDir is the log directory of a system installation
+	SystemLogDir = "/var/log/provisioner"
+)
+
+// ConfigDir determines the configuration directory using auto-discovery
+func ConfigDir() string {
+	// First check environment variable (explicit override)
+	if configDir := os.Getenv("PROVISIONER_CONFIG_DIR"); configDir != "" {
+		return configDir
+	}
+
+	// Auto-detect system installation
+	if _, err := os.Stat(SystemConfigDir); err == nil {
+		return SystemConfigDir
+	}
+
+	// Fall back to development default
+	return "."
+}
+
+// StateDir determines the state directory using auto-discovery
+func StateDir() string {
+	// First check environment variable (explicit override)
+	if stateDir := os.Getenv("PROVISIONER_STATE_DIR"); stateDir != "" {
+		return stateDir
+	}
+
+	// Auto-detect system installation
+	if _, err := os.Stat(SystemStateDir); err == nil {
+		return SystemStateDir
+	}
+
+	// Fall back to development default
+	return "state"
+}
+
+// LogDir determines the log directory using auto-discovery
+func LogDir() string {
+	// First check environment variable (explicit override)
+	if logDir := os.Getenv("PROVISIONER_LOG_DIR"); logDir != "" {
+		return logDir
+	}
+
+	// Auto-detect system installation
+	if _, err := os.Stat(SystemLogDir); err == nil {
+		return SystemLogDir
+	}
+
+	// Fall back to development default
+	return "logs"
+}
@@ -0,0 +1,19 @@
+package paths
+
+import "testing"
+
+func TestEnvironmentOverrides(t *testing.T) {
+	t.Setenv("PROVISIONER_CONFIG_DIR", "/tmp/config")
+	t.Setenv("PROVISIONER_STATE_DIR", "/tmp/state")
+	t.Setenv("PROVISIONER_LOG_DIR", "/tmp/logs")
+
+	if dir := ConfigDir(); dir != "/tmp/config" {
+		t.Errorf("Expected the config directory from the environment, got %s", dir)
+	}
+	if dir := StateDir(); dir != "/tmp/state" {
+		t.Errorf("Expected the state directory from the environment, got %s", dir)
+	}
+	if dir := LogDir(); dir != "/tmp/logs" {
+		t.Errorf("Expected the log directory from the environment, got %s", dir)
+	}
+}
@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
+	"provisioner/pkg/workspace"
+)
+
+// requestApproval holds a scheduled deploy or destroy of a workspace that requires approval until
+// an operator approves it with 'workspacectl approve'. due is when the schedule fired; a run whose
+// request expired is not requested again. Each request is logged and notified only once.
+func (s *Scheduler) requestApproval(workspace workspace.Workspace, workspaceState *WorkspaceState, operation, mode string, due, now time.Time) {
+	if workspaceState.PendingApproval() == operation && workspaceState.ApprovalMode == mode {
+		return
+	}
+	if workspaceState.ApprovalExpired != nil && !due.After(*workspaceState.ApprovalExpired) {
+		return
+	}
+
+	s.state.RequestApproval(workspace.Name, operation, mode, now)
+	message := fmt.Sprintf("Scheduled %s awaits approval", describeApproval(operation, mode))
+	if timeout := workspace.Config.GetApprovalTimeout(); timeout > 0 {
+		message += fmt.Sprintf(" until %s", logging.FormatTime(now.Add(timeout)))
+	}
+	logging.LogWorkspace(workspace.Name, "%s, run 'workspacectl approve %s' to approve", message, workspace.Name)
+
+	if !s.notifier.Enabled() {
+		return
+	}
+	s.notifier.Send(notify.Notification{
+		Event:     notify.EventApprovalRequired,
+		Workspace: workspace.Name,
+		Mode:      mode,
+		Message:   message,
+		Channel:   workspace.Config.NotificationChannel,
+	})
+}
+
+// expireApproval drops an approval request nobody approved within the workspace's
+// approval_timeout. The schedule run it was requested for is skipped; the next one asks again.
+func (s *Scheduler) expireApproval(workspace workspace.Workspace, workspaceState *WorkspaceState, now time.Time) {
+	timeout := workspace.Config.GetApprovalTimeout()
+	if workspaceState.ApprovalRequested == nil || timeout == 0 || now.Before(workspaceState.ApprovalRequested.Add(timeout)) {
+		return
+	}
+
+	message := fmt.Sprintf("Scheduled %s was not approved within %v", describeApproval(workspaceState.PendingApproval(), workspaceState.ApprovalMode), timeout)
+	s.state.ExpireApproval(workspace.Name)
+	logging.LogWorkspace(workspace.Name, "%s, skipping it until the schedule fires again", message)
+
+	if !s.notifier.Enabled() {
+		return
+	}
+	s.notifier.Send(notify.Notification{
+		Event:     notify.EventApprovalExpired,
+		Workspace: workspace.Name,
+		Message:   message,
+		Channel:   workspace.Config.NotificationChannel,
+	})
+}
+
+// ApproveWorkspace runs the scheduled deploy or destroy awaiting the workspace's approval
+func (s *Scheduler) ApproveWorkspace(workspaceName string) error {
+	if s.GetWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+	workspaceState := s.state.Workspace(workspaceName)
+	operation, mode := workspaceState.PendingApproval(), workspaceState.ApprovalMode
+	if operation == "" {
+		return fmt.Errorf("workspace '%s' has no scheduled operation awaiting approval", workspaceName)
+	}
+
+	logging.LogWorkspace(workspaceName, "Scheduled %s approved", describeApproval(operation, mode))
+	switch {
+	case operation == OperationDestroy:
+		return s.ManualDestroy(workspaceName)
+	case mode != "":
+		return s.ManualDeployInMode(workspaceName, mode)
+	}
+	return s.ManualDeploy(workspaceName)
+}
+
+// AwaitsApproval reports whether a scheduled deploy or destroy of the workspace awaits approval
+func (s *Scheduler) AwaitsApproval(workspaceName string) bool {
+	if s.state == nil {
+		return false
+	}
+	return s.state.Workspace(workspaceName).PendingApproval() != ""
+}
+
+// describeApproval names an operation awaiting approval, e.g. "deployment in mode busy"
+func describeApproval(operation, mode string) string {
+	switch {
+	case operation == OperationDestroy:
+		return "destruction"
+	case mode != "":
+		return "deployment in mode " + mode
+	}
+	return "deployment"
+}
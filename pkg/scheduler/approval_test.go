@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScheduledDestroyAwaitsApproval(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 9, 30, 0, 0, time.UTC))
+	sc.workspace("reports", `{"enabled": true, "require_approval": true, "deploy_schedule": "0 9 * * *", "destroy_schedule": "0 18 * * *"}`).start()
+	sc.scheduler.state.SetWorkspaceStatus("reports", StatusDeployed)
+	workspaceState := sc.scheduler.state.GetWorkspaceState("reports")
+
+	if err := sc.scheduler.ApproveWorkspace("reports"); err == nil || !strings.Contains(err.Error(), "no scheduled operation") {
+		t.Errorf("Expected an error without a pending approval, got %v", err)
+	}
+
+	sc.runUntil(time.Date(2025, 3, 10, 18, 5, 0, 0, time.UTC))
+	sc.expectOperations()
+	if workspaceState.PendingApproval() != OperationDestroy {
+		t.Fatalf("Expected the scheduled destroy to await approval, got %q", workspaceState.PendingApproval())
+	}
+	reports := *sc.scheduler.GetWorkspace("reports")
+	if summary := sc.scheduler.summarizeWorkspace(reports, workspaceState, sc.clock.Now()); summary.Status != string(StatusPendingApproval) {
+		t.Errorf("Expected status %s, got %s", StatusPendingApproval, summary.Status)
+	}
+
+	// A manual deploy doesn't settle the destroy
+	if err := sc.scheduler.ManualDeploy("reports"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+	if workspaceState.PendingApproval() != OperationDestroy {
+		t.Error("Expected the destroy to keep awaiting approval after a deploy")
+	}
+
+	if err := sc.scheduler.ApproveWorkspace("reports"); err != nil {
+		t.Fatalf("Approval failed: %v", err)
+	}
+	sc.scheduler.operations.Wait()
+	sc.expectOperations("2025-03-10 18:05 deploy reports", "2025-03-10 18:05 destroy reports")
+	if workspaceState.ApprovalRequested != nil {
+		t.Error("Expected the approval to be settled")
+	}
+}
+
+func TestApprovalExpires(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("billing", `{"enabled": true, "require_approval": true, "approval_timeout": "2h", "deploy_schedule": "0 9 * * *"}`).start()
+	workspaceState := sc.scheduler.state.GetWorkspaceState("billing")
+
+	sc.runUntil(time.Date(2025, 3, 10, 9, 1, 0, 0, time.UTC))
+	if workspaceState.PendingApproval() != OperationDeploy {
+		t.Fatal("Expected the scheduled deploy to await approval")
+	}
+	requested := *workspaceState.ApprovalRequested
+
+	// Nobody approved it in time: the run is skipped, not requested again
+	sc.run(2 * time.Hour)
+	if workspaceState.ApprovalRequested != nil {
+		t.Fatal("Expected the approval request to expire")
+	}
+	if workspaceState.ApprovalExpired == nil || !workspaceState.ApprovalExpired.Equal(requested) {
+		t.Errorf("Expected the expired request to be remembered, got %v", workspaceState.ApprovalExpired)
+	}
+	sc.run(time.Hour)
+	if workspaceState.ApprovalRequested != nil {
+		t.Error("Expected the expired run not to be requested again")
+	}
+
+	// The next run asks again
+	sc.runUntil(time.Date(2025, 3, 11, 9, 1, 0, 0, time.UTC))
+	if workspaceState.ApprovalRequested == nil || !workspaceState.ApprovalRequested.Equal(time.Date(2025, 3, 11, 9, 0, 30, 0, time.UTC)) {
+		t.Errorf("Expected the next scheduled deploy to request approval, got %v", workspaceState.ApprovalRequested)
+	}
+	sc.expectOperations()
+}
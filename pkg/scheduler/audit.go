@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"path/filepath"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/logging"
+)
+
+// AuditLog returns the audit log next to the scheduler state
+func (s *Scheduler) AuditLog() *audit.Log {
+	return audit.NewLog(filepath.Dir(s.statePath))
+}
+
+// beginTrigger records fallback as the trigger of an operation that is starting unless the caller
+// (control socket, webhook or dashboard) set one. The returned function clears it again.
+func (s *Scheduler) beginTrigger(workspaceName string, fallback audit.Trigger) func() {
+	if _, ok := audit.CurrentTrigger(workspaceName); ok {
+		return func() {}
+	}
+
+	audit.SetTrigger(workspaceName, fallback)
+	return func() {
+		audit.ClearTrigger(workspaceName)
+	}
+}
+
+// WithTrigger runs fn with trigger recorded in the audit log as the source of the operation it
+// starts. Like WithCorrelationID, it is ignored when the workspace is already busy.
+func (s *Scheduler) WithTrigger(workspaceName string, trigger audit.Trigger, fn func() error) error {
+	if s.IsWorkspaceBusy(workspaceName) {
+		return fn()
+	}
+
+	audit.SetTrigger(workspaceName, trigger)
+	defer audit.ClearTrigger(workspaceName)
+	return fn()
+}
+
+// auditOperation records the outcome of a deploy or destroy in the audit log
+func (s *Scheduler) auditOperation(operation, workspaceName, mode string, err error) {
+	trigger, ok := audit.CurrentTrigger(workspaceName)
+	if !ok {
+		trigger = audit.Trigger{Source: audit.SourceSchedule}
+	}
+
+	entry := audit.Entry{
+		Operation:     operation,
+		Workspace:     workspaceName,
+		Mode:          mode,
+		Trigger:       trigger,
+		Outcome:       audit.OutcomeSucceeded,
+		CorrelationID: logging.CorrelationID(workspaceName),
+	}
+	switch {
+	case isCancelled(err):
+		entry.Outcome = audit.OutcomeCancelled
+	case isTimedOut(err):
+		entry.Outcome = audit.OutcomeTimedOut
+		entry.Error = stripANSIColors(getHighLevelError(err))
+	case isDegraded(err):
+		entry.Outcome = audit.OutcomeDegraded
+		entry.Error = stripANSIColors(getHighLevelError(err))
+	case err != nil:
+		entry.Outcome = audit.OutcomeFailed
+		entry.Error = stripANSIColors(getHighLevelError(err))
+	}
+
+	s.AuditLog().Record(entry)
+}
@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"provisioner/pkg/audit"
+)
+
+// auditWeb is deployed at 09:00 and destroyed at 17:00
+const auditWeb = `{"enabled": true, "deploy_schedule": "0 9 * * *", "destroy_schedule": "0 17 * * *"}`
+
+func readAudit(t *testing.T, sched *Scheduler) []audit.Entry {
+	t.Helper()
+	entries, err := sched.AuditLog().Read(audit.Filter{})
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	return entries
+}
+
+func TestAuditRecordsManualOperations(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	sched := sc.workspace("web", auditWeb).start().scheduler
+
+	if err := sched.ManualDeploy("web"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+
+	sc.failNext("destroy web", 1)
+	trigger := audit.Trigger{Source: audit.SourceWebhook, Actor: "teardown"}
+	_ = sched.WithTrigger("web", trigger, func() error {
+		return sched.WithCorrelationID("web", "hook-1", func() error { return sched.ManualDestroy("web") })
+	})
+
+	entries := readAudit(t, sched)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit entries, got %+v", entries)
+	}
+
+	deploy := entries[0]
+	if deploy.Operation != audit.OperationDeploy || deploy.Source != audit.SourceCLI || deploy.Actor == "" || deploy.Outcome != audit.OutcomeSucceeded {
+		t.Errorf("Expected a successful deploy by the CLI user, got %+v", deploy)
+	}
+	if deploy.CorrelationID == "" {
+		t.Error("Expected the deploy's correlation ID to be recorded")
+	}
+
+	destroy := entries[1]
+	if destroy.Operation != audit.OperationDestroy || destroy.Source != audit.SourceWebhook || destroy.Actor != "teardown" {
+		t.Errorf("Expected a destroy by the webhook, got %+v", destroy)
+	}
+	if destroy.Outcome != audit.OutcomeFailed || destroy.Error != "destroy web failed" || destroy.CorrelationID != "hook-1" {
+		t.Errorf("Expected the failed destroy with its error and correlation ID, got %+v", destroy)
+	}
+
+	if _, ok := audit.CurrentTrigger("web"); ok {
+		t.Error("Expected the trigger to be cleared after the operation")
+	}
+}
+
+func TestAuditRecordsScheduledOperations(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("web", auditWeb).start()
+
+	sc.runUntil(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-10 09:00 deploy web")
+	entries := readAudit(t, sc.scheduler)
+	if len(entries) != 1 || entries[0].Source != audit.SourceSchedule || entries[0].Actor != "" {
+		t.Errorf("Expected a scheduled deploy, got %+v", entries)
+	}
+}
+
+func TestAuditRecordsOneShotRequester(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 7, 0, 0, 0, time.UTC))
+	sc.workspace("web", auditWeb).start()
+
+	if err := sc.scheduler.ScheduleOperation("web", OperationDeploy, "", time.Date(2025, 3, 10, 7, 30, 0, 0, time.UTC), "alice"); err != nil {
+		t.Fatalf("Scheduling the deploy failed: %v", err)
+	}
+
+	sc.runUntil(time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-10 07:30 deploy web")
+	entries := readAudit(t, sc.scheduler)
+	if len(entries) != 1 || entries[0].Source != audit.SourceCLI || entries[0].Actor != "alice" {
+		t.Errorf("Expected the one-shot deploy by the user who scheduled it, got %+v", entries)
+	}
+	if _, ok := audit.CurrentTrigger("web"); ok {
+		t.Error("Expected the trigger to be cleared after the operation")
+	}
+}
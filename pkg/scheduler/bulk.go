@@ -0,0 +1,147 @@
+package scheduler
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"provisioner/pkg/listing"
+	"provisioner/pkg/logging"
+)
+
+// Selection selects the workspaces of a bulk operation by name pattern, template and whether
+// they are enabled. The zero value selects all workspaces.
+type Selection struct {
+	Pattern     string // Glob matched against workspace names, e.g. pr-*
+	Template    string // Glob matched against the workspaces' templates
+	EnabledOnly bool
+}
+
+// IsWorkspacePattern returns true if a workspace argument is a glob pattern rather than a name
+func IsWorkspacePattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[")
+}
+
+// Filters returns the selection as filters of the workspace list fields
+func (sel Selection) Filters() []listing.Filter {
+	var filters []listing.Filter
+	if sel.Pattern != "" {
+		filters = append(filters, listing.Filter{Field: "name", Pattern: sel.Pattern})
+	}
+	if sel.Template != "" {
+		filters = append(filters, listing.Filter{Field: "template", Pattern: sel.Template})
+	}
+	if sel.EnabledOnly {
+		filters = append(filters, listing.Filter{Field: "enabled", Pattern: "true"})
+	}
+	return filters
+}
+
+// String describes the selection for messages, e.g. "'pr-*' with template 'web-app'"
+func (sel Selection) String() string {
+	var parts []string
+	if sel.Pattern != "" {
+		parts = append(parts, fmt.Sprintf("'%s'", sel.Pattern))
+	} else {
+		parts = append(parts, "all workspaces")
+	}
+	if sel.Template != "" {
+		parts = append(parts, fmt.Sprintf("with template '%s'", sel.Template))
+	}
+	if sel.EnabledOnly {
+		parts = append(parts, "enabled only")
+	}
+	return strings.Join(parts, " ")
+}
+
+// SelectWorkspaces returns the names of the loaded workspaces matching a selection, sorted
+func (s *Scheduler) SelectWorkspaces(sel Selection) ([]string, error) {
+	for _, pattern := range []string{sel.Pattern, sel.Template} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %w", pattern, err)
+		}
+	}
+
+	opts := listing.Options{Filters: sel.Filters()}
+	var names []string
+	for _, summary := range listing.Apply(s.WorkspaceSummaries(time.Now()), opts).Entries {
+		names = append(names, summary.Workspace.Name)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no workspaces match %s", sel)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// BulkResult is the outcome of a bulk operation on one workspace
+type BulkResult struct {
+	Workspace     string
+	CorrelationID string
+	Duration      time.Duration
+	Err           error
+}
+
+// RunBulk runs an operation on each workspace at once and returns their results in the order of
+// the workspaces. Deploys and destroys wait for their operation slot and throttle buckets as
+// single ones do, so max_concurrent_operations bounds how many run at a time.
+func RunBulk(workspaces []string, operation func(workspaceName, correlationID string) error) []BulkResult {
+	results := make([]BulkResult, len(workspaces))
+	var wg sync.WaitGroup
+	for i, workspaceName := range workspaces {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started := time.Now()
+			correlationID := logging.NewCorrelationID(started)
+			err := operation(workspaceName, correlationID)
+			results[i] = BulkResult{
+				Workspace:     workspaceName,
+				CorrelationID: correlationID,
+				Duration:      time.Since(started),
+				Err:           err,
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// PrintBulkResults prints a table of the results of a bulk operation and returns an error if
+// any of them failed
+func PrintBulkResults(w io.Writer, operation string, results []BulkResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "WORKSPACE\tRESULT\tDURATION\tCORRELATION ID\tERROR"); err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		outcome, message := "succeeded", ""
+		if result.Err != nil {
+			outcome, message = "failed", logging.RedactWorkspace(result.Workspace, result.Err.Error())
+			failed++
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%v\t%s\t%s\n", result.Workspace, outcome,
+			result.Duration.Round(time.Second), result.CorrelationID, message); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "\n%s: %d succeeded, %d failed\n", operation, len(results)-failed, failed); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%s failed for %d of %d workspaces", operation, failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestSelectWorkspaces(t *testing.T) {
+	scheduler := newScenario(t, time.Now()).
+		workspace("pr-12", `{"enabled": true, "template": "web-app"}`).
+		workspace("pr-7", `{"enabled": false, "template": "api"}`).
+		workspace("main", `{"enabled": true, "template": "web-app"}`).
+		start().scheduler
+
+	tests := []struct {
+		selection Selection
+		expected  string
+	}{
+		{Selection{}, "main,pr-12,pr-7"},
+		{Selection{Pattern: "pr-*"}, "pr-12,pr-7"},
+		{Selection{Pattern: "pr-*", EnabledOnly: true}, "pr-12"},
+		{Selection{Template: "web-app"}, "main,pr-12"},
+	}
+	for _, tt := range tests {
+		names, err := scheduler.SelectWorkspaces(tt.selection)
+		if err != nil {
+			t.Fatalf("SelectWorkspaces(%s) failed: %v", tt.selection, err)
+		}
+		if got := strings.Join(names, ","); got != tt.expected {
+			t.Errorf("SelectWorkspaces(%s) = %s, expected %s", tt.selection, got, tt.expected)
+		}
+	}
+
+	if _, err := scheduler.SelectWorkspaces(Selection{Pattern: "staging-*"}); err == nil {
+		t.Error("Expected an error when no workspace matches")
+	}
+	if _, err := scheduler.SelectWorkspaces(Selection{Pattern: "pr-["}); err == nil {
+		t.Error("Expected an error for an invalid pattern")
+	}
+}
+
+func TestRunBulkWaitsForOperationSlots(t *testing.T) {
+	sc := newScenario(t, time.Now()).
+		daemonConfig(`{"max_concurrent_operations": 1}`).
+		workspace("pr-1", scenarioDaily).
+		workspace("pr-2", scenarioDaily).
+		workspace("pr-3", scenarioDaily)
+	mockClient := sc.mock()
+	scheduler := sc.start().scheduler
+
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	mockClient.DeployFunc = func(ws *workspace.Workspace) error {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		if ws.Name == "pr-2" {
+			return errors.New("quota exceeded")
+		}
+		return nil
+	}
+
+	results := RunBulk([]string{"pr-1", "pr-2", "pr-3"}, func(workspaceName, correlationID string) error {
+		err := scheduler.WithCorrelationID(workspaceName, correlationID, func() error {
+			return scheduler.ManualDeploy(workspaceName)
+		})
+		if err == nil {
+			err = scheduler.GetDeployError(workspaceName)
+		}
+		return err
+	})
+
+	if maxRunning != 1 {
+		t.Errorf("Expected one deploy at a time under max_concurrent_operations 1, got %d", maxRunning)
+	}
+	if results[0].Workspace != "pr-1" || results[0].Err != nil || results[1].Err == nil || results[2].Err != nil {
+		t.Errorf("Expected only pr-2 to fail, got %+v", results)
+	}
+
+	var out bytes.Buffer
+	err := PrintBulkResults(&out, "deploy", results)
+	if err == nil || !strings.Contains(err.Error(), "1 of 3") {
+		t.Errorf("Expected an error for the failed deploy, got %v", err)
+	}
+	if !strings.Contains(out.String(), "deploy: 2 succeeded, 1 failed") || !strings.Contains(out.String(), "quota exceeded") {
+		t.Errorf("Unexpected summary:\n%s", out.String())
+	}
+}
@@ -0,0 +1,95 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+)
+
+// CancelWorkspace cancels the in-flight deploy or destroy of a workspace.
+// The operation stops asynchronously; its goroutine records the cancellation in state.
+func (s *Scheduler) CancelWorkspace(workspaceName string) error {
+	if s.findWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
+	if workspaceState.Status != StatusDeploying && workspaceState.Status != StatusDestroying {
+		return fmt.Errorf("workspace '%s' has no deploy or destroy in progress (status: %s)", workspaceName, workspaceState.Status)
+	}
+
+	if s.client == nil || !s.client.Cancel(workspaceName) {
+		return fmt.Errorf("workspace '%s' is %s but the operation is not running in this process", workspaceName, workspaceState.Status)
+	}
+
+	logging.LogWorkspaceOperation(workspaceName, "CANCEL", "Cancellation requested while %s", workspaceState.Status)
+	return nil
+}
+
+// IsWorkspaceCancelled returns true if the workspace's last operation was cancelled
+func (s *Scheduler) IsWorkspaceCancelled(workspaceName string) bool {
+	return s.state.Workspace(workspaceName).Status == StatusCancelled
+}
+
+// recordCancellation stores how far a cancelled operation got so an operator can follow up
+func (s *Scheduler) recordCancellation(workspaceName, operationName, operation, mode string, err error) {
+	cancellation := &Cancellation{
+		Operation:   operation,
+		Mode:        mode,
+		CancelledAt: s.currentTime(),
+	}
+
+	var cancelled *opentofu.CancelledError
+	if errors.As(err, &cancelled) {
+		cancellation.Step = cancelled.Step
+		cancellation.CompletedSteps = cancelled.CompletedSteps
+	}
+
+	if ws := s.findWorkspace(workspaceName); ws != nil {
+		if count, err := ws.GetStateResourceCount(); err == nil {
+			cancellation.StateResources = count
+		}
+	}
+
+	s.state.SetWorkspaceCancelled(workspaceName, cancellation)
+	logging.LogWorkspaceOperation(workspaceName, operationName, "Cancelled: %s", formatCancellation(cancellation))
+}
+
+// isCancelled reports whether an operation error was caused by cancellation
+func isCancelled(err error) bool {
+	return errors.Is(err, opentofu.ErrCancelled)
+}
+
+// wasStopped reports whether the workspace is stopped after the given operation was cancelled or
+// interrupted
+func (ws *WorkspaceState) wasStopped(operation string) bool {
+	switch ws.Status {
+	case StatusCancelled:
+		return ws.LastCancellation != nil && ws.LastCancellation.Operation == operation
+	case StatusInterrupted:
+		return ws.LastInterruption != nil && ws.LastInterruption.Operation == operation
+	}
+	return false
+}
+
+// formatCancellation summarizes a cancellation for status output and logs
+func formatCancellation(c *Cancellation) string {
+	operation := c.Operation
+	if c.Mode != "" {
+		operation = fmt.Sprintf("%s (mode %s)", operation, c.Mode)
+	}
+
+	summary := fmt.Sprintf("%s at %s", operation, logging.FormatTime(c.CancelledAt))
+	if c.Step != "" {
+		summary += fmt.Sprintf(" during %s", c.Step)
+	}
+
+	completed := "none"
+	if len(c.CompletedSteps) > 0 {
+		completed = strings.Join(c.CompletedSteps, ", ")
+	}
+	return fmt.Sprintf("%s; completed steps: %s; %d resources in state", summary, completed, c.StateResources)
+}
@@ -0,0 +1,93 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+func TestCancelledDeployRecordsProgress(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	ws.Config.DestroySchedule = "* * * * *"
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	mockClient.DeployFunc = func(*workspace.Workspace) error {
+		// A destroy queued during the deploy must not run after cancellation
+		scheduler.state.QueuePendingOperation(ws.Name, OperationDestroy, time.Now(), PendingOperationTTL)
+		return &opentofu.CancelledError{Step: "apply", CompletedSteps: []string{"init", "plan"}}
+	}
+
+	scheduler.deployWorkspace(ws)
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.Status != StatusCancelled {
+		t.Fatalf("expected status %s, got %s", StatusCancelled, workspaceState.Status)
+	}
+	if workspaceState.LastDeployError != "" {
+		t.Errorf("expected no deploy error for a cancellation, got %q", workspaceState.LastDeployError)
+	}
+	if workspaceState.PendingOperation != nil {
+		t.Errorf("expected queued operation to be dropped, got %+v", workspaceState.PendingOperation)
+	}
+	if mockClient.GetDestroyCallCount() != 0 {
+		t.Errorf("expected no destroy after cancellation, got %d", mockClient.GetDestroyCallCount())
+	}
+
+	cancellation := workspaceState.LastCancellation
+	if cancellation == nil {
+		t.Fatal("expected cancellation details to be recorded")
+	}
+	if cancellation.Operation != OperationDeploy || cancellation.Step != "apply" {
+		t.Errorf("unexpected cancellation details: %+v", cancellation)
+	}
+	if got := strings.Join(cancellation.CompletedSteps, ","); got != "init,plan" {
+		t.Errorf("expected completed steps init,plan, got %s", got)
+	}
+
+	// The cancelled deploy is not restarted by its schedule, but destroy may still clean up
+	now := time.Now().Truncate(time.Minute).Add(30 * time.Second)
+	if scheduler.ShouldRunDeploySchedule([]string{"* * * * *"}, now, workspaceState) {
+		t.Error("expected cancelled deploy not to be rescheduled")
+	}
+	if !scheduler.ShouldRunDestroySchedule([]string{"* * * * *"}, now, workspaceState) {
+		t.Error("expected destroy schedule to run after a cancelled deploy")
+	}
+
+	// A config change allows the deploy to run again
+	scheduler.state.SetWorkspaceConfigModified(ws.Name, time.Now())
+	if workspaceState.Status != StatusDestroyed {
+		t.Errorf("expected config change to reset status to %s, got %s", StatusDestroyed, workspaceState.Status)
+	}
+}
+
+func TestCancelWorkspace(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	if err := scheduler.CancelWorkspace("missing"); err == nil {
+		t.Error("expected error for unknown workspace")
+	}
+	if err := scheduler.CancelWorkspace(ws.Name); err == nil {
+		t.Error("expected error when nothing is in progress")
+	}
+
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDeploying)
+
+	// Operation started by another process
+	if err := scheduler.CancelWorkspace(ws.Name); err == nil {
+		t.Error("expected error when the operation is not running in this process")
+	}
+
+	mockClient.CancelFunc = func(name string) bool { return name == ws.Name }
+	if err := scheduler.CancelWorkspace(ws.Name); err != nil {
+		t.Errorf("expected cancellation to be requested, got %v", err)
+	}
+	if mockClient.CancelCallCount != 2 {
+		t.Errorf("expected 2 cancel calls, got %d", mockClient.CancelCallCount)
+	}
+}
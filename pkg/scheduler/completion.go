@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+// startRunIfOneShot moves a freshly deployed run-to-completion workspace into the running state
+func (s *Scheduler) startRunIfOneShot(workspace workspace.Workspace) {
+	if !workspace.Config.IsRunToCompletion() {
+		return
+	}
+
+	s.state.SetWorkspaceRunning(workspace.Name)
+	logging.LogWorkspace(workspace.Name, "RUN: Waiting for completion signal before automatic destroy")
+}
+
+// checkRunCompletion destroys a running one-shot workspace once it signals completion or times out.
+// Returns true if destruction was triggered.
+func (s *Scheduler) checkRunCompletion(workspace workspace.Workspace, workspaceState *WorkspaceState, now time.Time) bool {
+	if workspaceState.Status != StatusRunning {
+		return false
+	}
+
+	if s.isRunComplete(workspace, workspaceState) {
+		// A run found without its start time, e.g. from an older state, counts as due now
+		due := now
+		if workspaceState.RunStarted != nil {
+			due = *workspaceState.RunStarted
+		}
+		if s.skipIfFrozen(workspace.Name, OperationDestroy, "run completed", due) ||
+			s.waitForDependencies(workspace, OperationDestroy) {
+			return false
+		}
+		logging.LogWorkspaceOperation(workspace.Name, "RUN", "Completion signal received, destroying workspace")
+		s.state.SetWorkspaceRunResult(workspace.Name, RunResultCompleted)
+		s.goOperation(func() { s.destroyWorkspace(workspace) })
+		return true
+	}
+
+	timeout, err := workspace.Config.GetCompletionTimeout()
+	if err != nil {
+		logging.LogWorkspace(workspace.Name, "Invalid run_to_completion timeout: %v", err)
+		return false
+	}
+
+	if workspaceState.RunStarted != nil && now.Sub(*workspaceState.RunStarted) >= timeout {
+		if s.skipIfFrozen(workspace.Name, OperationDestroy, "run timed out", workspaceState.RunStarted.Add(timeout)) ||
+			s.waitForDependencies(workspace, OperationDestroy) {
+			return false
+		}
+		logging.LogWorkspaceOperation(workspace.Name, "RUN", "Timed out after %v waiting for completion, destroying workspace", timeout)
+		s.state.SetWorkspaceRunResult(workspace.Name, RunResultTimedOut)
+		s.goOperation(func() { s.destroyWorkspace(workspace) })
+		return true
+	}
+
+	return false
+}
+
+// isRunComplete checks the configured completion signals for a running one-shot workspace
+func (s *Scheduler) isRunComplete(workspace workspace.Workspace, workspaceState *WorkspaceState) bool {
+	rtc := workspace.Config.RunToCompletion
+
+	if rtc.CompletionOutput != "" {
+		if workspaceState.RunCompletionSeen != nil {
+			return true
+		}
+		if !workspaceState.completionChecking {
+			s.state.UpdateWorkspace(workspace.Name, func(state *WorkspaceState) { state.completionChecking = true })
+			s.goOperation(func() { s.pollCompletionOutput(workspace, workspaceState.DeploymentMode) })
+		}
+	}
+
+	if rtc.CompletionJob != "" && s.jobManager != nil {
+		jobState := s.jobManager.GetJobState(workspace.Name, rtc.CompletionJob)
+		if jobState.LastSuccess != nil && workspaceState.RunStarted != nil && !jobState.LastSuccess.Before(*workspaceState.RunStarted) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// pollCompletionOutput reads a running one-shot workspace's outputs after a refresh, as the state
+// only follows the run's resources once refreshed, and records when the completion output is seen.
+// Refreshes take a while, so polls run in the background and the next schedule check acts on them.
+func (s *Scheduler) pollCompletionOutput(workspace workspace.Workspace, mode string) {
+	outputs, err := s.client.RefreshOutputs(&workspace, mode)
+	now := s.currentTime()
+
+	s.state.UpdateWorkspace(workspace.Name, func(state *WorkspaceState) {
+		state.completionChecking = false
+		if err != nil {
+			logging.LogWorkspace(workspace.Name, "Failed to read outputs for completion check: %v", err)
+			return
+		}
+		output, exists := outputs[workspace.Config.RunToCompletion.CompletionOutput]
+		if exists && isCompletionOutput(output) && state.Status == StatusRunning && state.RunCompletionSeen == nil {
+			state.RunCompletionSeen = &now
+		}
+	})
+
+	if err := s.SaveState(); err != nil {
+		logging.LogSystemd("Error saving state: %v", err)
+	}
+}
+
+// isCompletionOutput reports whether an output's value signals that a one-shot run has finished
+func isCompletionOutput(output opentofu.OutputValue) bool {
+	var value interface{}
+	if err := json.Unmarshal(output.Value, &value); err != nil {
+		return false
+	}
+	return workspace.IsCompletionValue(value)
+}
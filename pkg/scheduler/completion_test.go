@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+func newRunToCompletionWorkspace(rtc *workspace.RunToCompletionConfig) workspace.Workspace {
+	return workspace.Workspace{
+		Name: "batch-job",
+		Config: workspace.Config{
+			Enabled:         true,
+			DeploySchedule:  "0 2 * * *",
+			DestroySchedule: false,
+			RunToCompletion: rtc,
+		},
+	}
+}
+
+func waitForDestroyCalls(t *testing.T, mockClient *opentofu.MockTofuClient, expected int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if mockClient.GetDestroyCallCount() >= expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d destroy calls, got %d", expected, mockClient.GetDestroyCallCount())
+}
+
+func TestRunToCompletionDeployEntersRunningState(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", tempDir)
+
+	mockClient := opentofu.NewMockTofuClient()
+	scheduler := &Scheduler{state: NewState(), client: mockClient, statePath: filepath.Join(tempDir, "scheduler.json")}
+
+	ws := newRunToCompletionWorkspace(&workspace.RunToCompletionConfig{CompletionOutput: "done"})
+	scheduler.deployWorkspace(ws)
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.Status != StatusRunning {
+		t.Fatalf("expected status %s after deploy, got %s", StatusRunning, workspaceState.Status)
+	}
+	if workspaceState.RunStarted == nil {
+		t.Fatal("expected run start time to be recorded")
+	}
+
+	// No completion signal yet
+	if scheduler.checkRunCompletion(ws, workspaceState, time.Now()) {
+		t.Error("expected no destruction before completion signal")
+	}
+}
+
+func TestRunToCompletionDestroysOnCompletionOutput(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", tempDir)
+
+	mockClient := opentofu.NewMockTofuClient()
+	scheduler := &Scheduler{state: NewState(), client: mockClient, statePath: filepath.Join(tempDir, "scheduler.json")}
+
+	ws := newRunToCompletionWorkspace(&workspace.RunToCompletionConfig{CompletionOutput: "done"})
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDeployed)
+	scheduler.state.SetWorkspaceRunning(ws.Name)
+
+	// The refreshed outputs carry the completion output
+	mockClient.RefreshOutputsFunc = func(*workspace.Workspace, string) (map[string]opentofu.OutputValue, error) {
+		return map[string]opentofu.OutputValue{"done": {Value: json.RawMessage("true")}}, nil
+	}
+
+	// The first check polls the outputs in the background, the next one acts on them
+	if scheduler.checkRunCompletion(ws, scheduler.state.Workspace(ws.Name), time.Now()) {
+		t.Fatal("expected no destruction before the outputs were polled")
+	}
+	scheduler.operations.Wait()
+	if mockClient.RefreshOutputsCallCount != 1 {
+		t.Errorf("expected 1 refresh of the outputs, got %d", mockClient.RefreshOutputsCallCount)
+	}
+	if !scheduler.checkRunCompletion(ws, scheduler.state.Workspace(ws.Name), time.Now()) {
+		t.Fatal("expected destruction to be triggered by completion output")
+	}
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.LastRunResult != RunResultCompleted {
+		t.Errorf("expected run result %s, got %s", RunResultCompleted, workspaceState.LastRunResult)
+	}
+
+	waitForDestroyCalls(t, mockClient, 1)
+}
+
+func TestRunToCompletionWithoutStartTime(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", tempDir)
+
+	mockClient := opentofu.NewMockTofuClient()
+	mockClient.RefreshOutputsFunc = func(*workspace.Workspace, string) (map[string]opentofu.OutputValue, error) {
+		return map[string]opentofu.OutputValue{"done": {Value: json.RawMessage(`"done"`)}}, nil
+	}
+	scheduler := &Scheduler{state: NewState(), client: mockClient, statePath: filepath.Join(tempDir, "scheduler.json")}
+
+	// A running workspace recorded without its start time still completes
+	ws := newRunToCompletionWorkspace(&workspace.RunToCompletionConfig{CompletionOutput: "done"})
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusRunning)
+	scheduler.checkRunCompletion(ws, scheduler.state.Workspace(ws.Name), time.Now())
+	scheduler.operations.Wait()
+	if !scheduler.checkRunCompletion(ws, scheduler.state.Workspace(ws.Name), time.Now()) {
+		t.Fatal("expected destruction to be triggered by completion output")
+	}
+
+	waitForDestroyCalls(t, mockClient, 1)
+}
+
+func TestRunToCompletionTimeout(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", tempDir)
+
+	mockClient := opentofu.NewMockTofuClient()
+	scheduler := &Scheduler{state: NewState(), client: mockClient, statePath: filepath.Join(tempDir, "scheduler.json")}
+
+	ws := newRunToCompletionWorkspace(&workspace.RunToCompletionConfig{CompletionOutput: "done", Timeout: "1h"})
+	scheduler.state.SetWorkspaceRunning(ws.Name)
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+
+	if scheduler.checkRunCompletion(ws, workspaceState, time.Now().Add(30*time.Minute)) {
+		t.Error("expected no destruction before timeout")
+	}
+
+	if !scheduler.checkRunCompletion(ws, workspaceState, time.Now().Add(61*time.Minute)) {
+		t.Fatal("expected destruction after timeout")
+	}
+
+	if workspaceState.LastRunResult != RunResultTimedOut {
+		t.Errorf("expected run result %s, got %s", RunResultTimedOut, workspaceState.LastRunResult)
+	}
+
+	waitForDestroyCalls(t, mockClient, 1)
+}
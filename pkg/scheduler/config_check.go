@@ -0,0 +1,61 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"provisioner/pkg/configfile"
+	"provisioner/pkg/environment"
+	"provisioner/pkg/job"
+	"provisioner/pkg/notify"
+)
+
+// ConfigFile is a config file read by the daemon with a value of the type it is decoded into,
+// for checking the file against its schema
+type ConfigFile struct {
+	Name  string // Path relative to the config directory, e.g. jobs/backup.json
+	Path  string
+	Value interface{}
+}
+
+// SharedConfigFiles returns the existing config files other than workspace configs:
+// provisioner.json, notifications.json, standalone jobs and environments
+func SharedConfigFiles() ([]ConfigFile, error) {
+	configDir := getConfigDir()
+
+	var files []ConfigFile
+	for _, file := range []ConfigFile{
+		{Path: filepath.Join(configDir, DaemonConfigFile), Value: &DaemonConfig{}},
+		{Path: filepath.Join(configDir, "notifications.json"), Value: &notify.Config{}},
+	} {
+		if _, err := os.Stat(file.Path); err == nil {
+			file.Name = filepath.Base(file.Path)
+			files = append(files, file)
+		}
+	}
+
+	jobsDir := filepath.Join(configDir, "jobs")
+	entries, err := os.ReadDir(jobsDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read jobs directory: %w", err)
+	}
+	for _, entry := range entries {
+		if _, ok := configfile.TrimExtension(entry.Name()); ok && !entry.IsDir() {
+			files = append(files, ConfigFile{
+				Name:  filepath.Join("jobs", entry.Name()),
+				Path:  filepath.Join(jobsDir, entry.Name()),
+				Value: &job.StandaloneJobConfig{},
+			})
+		}
+	}
+
+	environments, err := environment.ConfigFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range environments {
+		files = append(files, ConfigFile{Name: filepath.Base(path), Path: path, Value: &environment.Config{}})
+	}
+	return files, nil
+}
@@ -0,0 +1,323 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"provisioner/pkg/events"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+// configPollInterval is how often workspace configs are scanned for changes without a config
+// watcher, and how often changed standalone job configs are looked for
+const configPollInterval = 30 * time.Second
+
+// configWatcher collects changes of workspace configs from filesystem events, so the workspaces
+// tree does not have to be scanned. fsnotify watches are not recursive: the workspaces directory
+// reports added and removed workspaces, and each workspace directory its config file and .tf files.
+type configWatcher struct {
+	watcher       *fsnotify.Watcher
+	workspacesDir string
+	now           func() time.Time
+
+	mu      sync.Mutex
+	changed map[string]time.Time // Workspaces added or whose config file or .tf files changed, with the time of the change
+	removed map[string]bool      // Workspace directories removed or renamed away
+	missed  bool                 // Events were lost, the workspaces tree must be scanned
+}
+
+// newConfigWatcher watches the workspaces directory and every workspace directory in it
+func newConfigWatcher(workspacesDir string, now func() time.Time) (*configWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &configWatcher{
+		watcher:       watcher,
+		workspacesDir: workspacesDir,
+		now:           now,
+		changed:       make(map[string]time.Time),
+		removed:       make(map[string]bool),
+	}
+
+	if err := watcher.Add(workspacesDir); err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", workspacesDir, err)
+	}
+	entries, err := os.ReadDir(workspacesDir)
+	if err != nil {
+		_ = watcher.Close()
+		return nil, fmt.Errorf("failed to read workspaces directory: %w", err)
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if err := watcher.Add(filepath.Join(workspacesDir, entry.Name())); err != nil {
+			_ = watcher.Close()
+			return nil, fmt.Errorf("failed to watch workspace %s: %w", entry.Name(), err)
+		}
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// run records filesystem events until the watcher is closed
+func (w *configWatcher) run() {
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			logging.LogSystemd("Config watcher error: %v", err)
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				w.mu.Lock()
+				w.missed = true
+				w.mu.Unlock()
+			}
+		}
+	}
+}
+
+// handle records a workspace directory being added or removed, or a config file of a workspace
+// being written, created or removed
+func (w *configWatcher) handle(event fsnotify.Event) {
+	if event.Op == fsnotify.Chmod {
+		return
+	}
+	rel, err := filepath.Rel(w.workspacesDir, event.Name)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	switch len(parts) {
+	case 1:
+		name := parts[0]
+		if event.Has(fsnotify.Create) {
+			info, err := os.Stat(event.Name)
+			if err != nil || !info.IsDir() {
+				return
+			}
+			// Files written before the watch was added are picked up by the reload
+			if err := w.watcher.Add(event.Name); err != nil {
+				logging.LogSystemd("Failed to watch workspace %s, scanning for changes: %v", name, err)
+				w.missed = true
+			}
+			delete(w.removed, name)
+			w.changed[name] = w.now()
+		} else if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+			delete(w.changed, name)
+			w.removed[name] = true
+		}
+	case 2:
+		if workspace.IsConfigFile(parts[1]) || filepath.Ext(parts[1]) == ".tf" {
+			w.changed[parts[0]] = w.now()
+		}
+	}
+}
+
+// takeChanges returns and clears the changes recorded since the last call
+func (w *configWatcher) takeChanges() (changed map[string]time.Time, removed map[string]bool, missed bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	changed, removed, missed = w.changed, w.removed, w.missed
+	w.changed = make(map[string]time.Time)
+	w.removed = make(map[string]bool)
+	w.missed = false
+	return changed, removed, missed
+}
+
+// Close stops watching
+func (w *configWatcher) Close() error {
+	return w.watcher.Close()
+}
+
+// startConfigWatcher starts watching workspace configs, falling back to polling if the
+// filesystem cannot be watched, e.g. when the inotify watch limit is reached
+func (s *Scheduler) startConfigWatcher() {
+	watcher, err := newConfigWatcher(filepath.Join(s.configDir, "workspaces"), s.currentTime)
+	if err != nil {
+		logging.LogSystemd("Config watcher unavailable, checking for config changes every %v: %v", configPollInterval, err)
+		return
+	}
+	s.configWatcher = watcher
+	logging.LogSystemd("Watching workspace configs for changes")
+}
+
+// stopConfigWatcher stops watching workspace configs
+func (s *Scheduler) stopConfigWatcher() {
+	if s.configWatcher == nil {
+		return
+	}
+	_ = s.configWatcher.Close()
+	s.configWatcher = nil
+}
+
+// checkConfigChanges reloads the workspaces when the config watcher reported changes, or when
+// polling finds changed files, and applies the changes to the changed workspaces' state
+func (s *Scheduler) checkConfigChanges(now time.Time) {
+	lastCheck := s.lastConfigCheck
+	var changed map[string]time.Time
+	var listChanged bool
+
+	if s.configWatcher != nil {
+		var removed map[string]bool
+		var missed bool
+		changed, removed, missed = s.configWatcher.takeChanges()
+		listChanged = len(removed) > 0
+		if missed {
+			logging.LogSystemd("Config watcher missed changes, scanning workspaces")
+			scanned, scanListChanged := s.scanConfigChanges()
+			for name, modTime := range scanned {
+				changed[name] = modTime
+			}
+			listChanged = listChanged || scanListChanged
+		}
+	} else if now.Sub(lastCheck) > configPollInterval {
+		changed, listChanged = s.scanConfigChanges()
+	}
+
+	reload := len(changed) > 0 || listChanged
+	if reload {
+		logging.LogSystemd("Configuration changes detected, reloading workspaces...")
+		if err := s.LoadWorkspaces(); err != nil {
+			logging.LogSystemd("Error reloading workspaces: %v", err)
+		}
+		s.applyConfigChanges(changed, now)
+	}
+
+	if reload || now.Sub(lastCheck) > configPollInterval {
+		s.unloadRemovedWorkspaces()
+
+		// Standalone jobs don't need a reload, only their failed states reset
+		if s.standaloneJobManager != nil {
+			s.standaloneJobManager.ResetChangedJobs(lastCheck)
+		}
+		s.lastConfigCheck = now
+	}
+}
+
+// scanConfigChanges walks the workspaces tree for config files and .tf files modified since the
+// last check. Workspaces added or removed since then change the workspaces directory itself.
+func (s *Scheduler) scanConfigChanges() (changed map[string]time.Time, listChanged bool) {
+	workspacesDir := filepath.Join(s.configDir, "workspaces")
+	changed = make(map[string]time.Time)
+
+	err := filepath.Walk(workspacesDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // Continue on error
+		}
+
+		if path == workspacesDir {
+			listChanged = info.ModTime().After(s.lastConfigCheck)
+			return nil
+		}
+
+		// Check config files and .tf files
+		if workspace.IsConfigFile(filepath.Base(path)) || filepath.Ext(path) == ".tf" {
+			if info.ModTime().After(s.lastConfigCheck) {
+				logging.LogSystemd("Config file changed: %s (modified: %s)", path, logging.FormatTime(info.ModTime()))
+
+				// Extract workspace name from path
+				workspaceName := filepath.Base(filepath.Dir(path))
+				if existingTime, exists := changed[workspaceName]; !exists || info.ModTime().After(existingTime) {
+					changed[workspaceName] = info.ModTime()
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		logging.LogSystemd("Error walking config directory: %v", err)
+	}
+
+	return changed, listChanged
+}
+
+// applyConfigChanges records config changes in the changed workspaces' state, resetting failed
+// deploys, and deploys workspaces whose schedule should already have run
+func (s *Scheduler) applyConfigChanges(changed map[string]time.Time, now time.Time) {
+	// Changed defaults change the config of every workspace
+	if modTime, ok := changed[workspace.DefaultsDir]; ok {
+		delete(changed, workspace.DefaultsDir)
+		for _, ws := range s.loadedWorkspaces() {
+			if existing, exists := changed[ws.Name]; !exists || modTime.After(existing) {
+				changed[ws.Name] = modTime
+			}
+		}
+	}
+
+	for workspaceName, modTime := range changed {
+		if s.GetWorkspace(workspaceName) == nil {
+			continue // Removed again or invalid, reported by the reload
+		}
+
+		s.Events().Publish(events.Event{Type: events.ConfigReloaded, Time: modTime, Workspace: workspaceName})
+
+		// Frozen workspaces only note the change; it is applied when they are unfrozen
+		if s.skipIfFrozen(workspaceName, "redeploy", "config change", modTime) {
+			s.state.UpdateWorkspace(workspaceName, func(workspaceState *WorkspaceState) { workspaceState.LastConfigModified = &modTime })
+			continue
+		}
+
+		s.state.SetWorkspaceConfigModified(workspaceName, modTime)
+		logging.LogSystemd("Workspace %s configuration updated, resetting failed state if applicable", workspaceName)
+
+		// Check if this workspace should be deployed immediately
+		s.checkWorkspaceForImmediateDeployment(workspaceName, now)
+	}
+}
+
+// unloadRemovedWorkspaces drops the state of workspaces whose directory was removed. Deployed
+// resources are not destroyed: the workspace's deployment directory and OpenTofu state are kept,
+// so adding the workspace again picks them up. Busy workspaces are unloaded once idle.
+func (s *Scheduler) unloadRemovedWorkspaces() {
+	workspacesDir := filepath.Join(s.configDir, "workspaces")
+
+	for name, state := range s.state.WorkspaceStates() {
+		if s.GetWorkspace(name) != nil {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(workspacesDir, name)); !os.IsNotExist(err) {
+			continue // Config invalid or missing, not removed
+		}
+		if state.IsBusy() {
+			logging.LogSystemd("Workspace %s was removed while %s, unloading it once the operation finished", name, state.Status)
+			continue
+		}
+
+		if hasDeployment(state.Status) {
+			logging.LogSystemd("Workspace %s was removed while deployed, its resources were not destroyed (state in %s), run 'workspacectl prune' to destroy them",
+				name, opentofu.GetWorkingDir(name))
+		} else {
+			logging.LogSystemd("Workspace %s was removed, unloading it", name)
+		}
+		s.state.RemoveWorkspaceState(name)
+		delete(s.missingTemplates, name)
+		_ = logging.SetWorkspaceRedactPatterns(name, nil)
+	}
+}
@@ -0,0 +1,140 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+// waitForConfigChanges collects the watcher's changes until check accepts them
+func waitForConfigChanges(t *testing.T, w *configWatcher, check func(changed map[string]time.Time, removed map[string]bool) bool) {
+	t.Helper()
+	changed := make(map[string]time.Time)
+	removed := make(map[string]bool)
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		c, r, _ := w.takeChanges()
+		for name, at := range c {
+			changed[name] = at
+		}
+		for name := range r {
+			removed[name] = true
+		}
+		if check(changed, removed) {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for config changes, got changed %v, removed %v", changed, removed)
+}
+
+func TestConfigWatcherReportsChanges(t *testing.T) {
+	workspacesDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(workspacesDir, "app"), 0755); err != nil {
+		t.Fatalf("Failed to create workspace: %v", err)
+	}
+
+	w, err := newConfigWatcher(workspacesDir, time.Now)
+	if err != nil {
+		t.Fatalf("Failed to start config watcher: %v", err)
+	}
+	defer func() { _ = w.Close() }()
+
+	// Config and OpenTofu files of existing workspaces, other files are ignored
+	writeTestFile(t, filepath.Join(workspacesDir, "app", "notes.txt"), "ignored")
+	writeTestFile(t, filepath.Join(workspacesDir, "app", "config.json"), `{}`)
+	waitForConfigChanges(t, w, func(changed map[string]time.Time, _ map[string]bool) bool {
+		_, ok := changed["app"]
+		return ok
+	})
+
+	// Added workspaces are watched for their own changes
+	addedDir := filepath.Join(workspacesDir, "added")
+	if err := os.Mkdir(addedDir, 0755); err != nil {
+		t.Fatalf("Failed to add workspace: %v", err)
+	}
+	waitForConfigChanges(t, w, func(changed map[string]time.Time, _ map[string]bool) bool {
+		_, ok := changed["added"]
+		return ok
+	})
+	writeTestFile(t, filepath.Join(addedDir, "main.tf"), "# added")
+	waitForConfigChanges(t, w, func(changed map[string]time.Time, _ map[string]bool) bool {
+		_, ok := changed["added"]
+		return ok
+	})
+
+	if err := os.RemoveAll(filepath.Join(workspacesDir, "app")); err != nil {
+		t.Fatalf("Failed to remove workspace: %v", err)
+	}
+	waitForConfigChanges(t, w, func(_ map[string]time.Time, removed map[string]bool) bool {
+		return removed["app"]
+	})
+}
+
+func TestRemovedWorkspaceIsUnloaded(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", scenarioOfficeHours).
+		workspace("old", `{"enabled": true, "deploy_schedule": "0 10 * * 1-5", "destroy_schedule": "0 17 * * 1-5"}`).
+		start()
+	sc.runUntil(time.Date(2025, 3, 10, 19, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 09:00 deploy app",
+		"2025-03-10 10:00 deploy old",
+		"2025-03-10 17:00 destroy old",
+		"2025-03-10 18:00 destroy app",
+	)
+
+	// Without a config watcher, polling notices the workspaces directory changed
+	sc.removeWorkspace("old").run(time.Minute)
+
+	if sc.scheduler.GetWorkspace("old") != nil {
+		t.Error("Expected the removed workspace to be unloaded")
+	}
+	if _, exists := sc.scheduler.state.Workspaces["old"]; exists {
+		t.Error("Expected the removed workspace's state to be dropped")
+	}
+	if _, exists := sc.scheduler.state.Workspaces["app"]; !exists {
+		t.Error("Expected the remaining workspace's state to be kept")
+	}
+
+	sc.runUntil(time.Date(2025, 3, 11, 10, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-11 09:00 deploy app")
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestWorkspaceDefaultsApplyAndReload(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace(workspace.DefaultsDir, `{"destroy_schedule": "0 17 * * 1-5"}`).
+		workspace("app", `{"enabled": true, "deploy_schedule": "0 9 * * 1-5"}`).
+		workspace("late", `{"enabled": true, "deploy_schedule": "0 10 * * 1-5", "destroy_schedule": "0 20 * * 1-5"}`).
+		start()
+	sc.runUntil(time.Date(2025, 3, 10, 21, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 09:00 deploy app",
+		"2025-03-10 10:00 deploy late",
+		"2025-03-10 17:00 destroy app",
+		"2025-03-10 20:00 destroy late",
+	)
+	if sc.scheduler.GetWorkspace(workspace.DefaultsDir) != nil {
+		t.Error("Expected the defaults not to be loaded as a workspace")
+	}
+
+	// Editing the defaults reloads every workspace that inherits them
+	sc.editConfig(workspace.DefaultsDir, `{"destroy_schedule": "0 15 * * 1-5"}`).run(time.Minute)
+	sc.runUntil(time.Date(2025, 3, 11, 21, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-11 09:00 deploy app",
+		"2025-03-11 10:00 deploy late",
+		"2025-03-11 15:00 destroy app",
+		"2025-03-11 20:00 destroy late",
+	)
+}
@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// beginCorrelation assigns the correlation ID of an operation that is starting, reusing one
+// chosen by the caller (schedule wave, CLI or webhook) and recording it in state.
+// The returned function clears the ID when the operation finishes.
+func (s *Scheduler) beginCorrelation(workspaceName string) func() {
+	id := logging.CorrelationID(workspaceName)
+	if id == "" {
+		id = logging.NewCorrelationID(time.Now())
+		logging.SetCorrelationID(workspaceName, id)
+	}
+	s.state.SetWorkspaceCorrelationID(workspaceName, id)
+
+	return func() {
+		logging.ClearCorrelationID(workspaceName)
+	}
+}
+
+// WithCorrelationID runs fn with id as the correlation ID of the operation it starts.
+// The ID is ignored when the workspace is already busy so it can't relabel another operation's logs.
+func (s *Scheduler) WithCorrelationID(workspaceName, id string, fn func() error) error {
+	if id == "" || s.IsWorkspaceBusy(workspaceName) {
+		return fn()
+	}
+
+	logging.SetCorrelationID(workspaceName, id)
+	defer logging.ClearCorrelationID(workspaceName)
+	return fn()
+}
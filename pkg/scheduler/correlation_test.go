@@ -0,0 +1,91 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+func TestOperationCorrelationID(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	var seen string
+	mockClient.DeployFunc = func(*workspace.Workspace) error {
+		seen = logging.CorrelationID(ws.Name)
+		return nil
+	}
+
+	// A caller-supplied ID is used for the operation and recorded in state
+	err := scheduler.WithCorrelationID(ws.Name, "20250310T090000Z-abc123", func() error {
+		return scheduler.ManualDeploy(ws.Name)
+	})
+	if err != nil {
+		t.Fatalf("ManualDeploy failed: %v", err)
+	}
+	if seen != "20250310T090000Z-abc123" {
+		t.Errorf("expected deploy to run with caller's correlation ID, got %q", seen)
+	}
+	if got := scheduler.state.GetWorkspaceState(ws.Name).LastCorrelationID; got != seen {
+		t.Errorf("expected state to record correlation ID %q, got %q", seen, got)
+	}
+	if id := logging.CorrelationID(ws.Name); id != "" {
+		t.Errorf("expected correlation ID to be cleared after the operation, got %q", id)
+	}
+
+	// Without one, each operation gets a fresh ID
+	scheduler.destroyWorkspace(ws)
+	first := scheduler.state.GetWorkspaceState(ws.Name).LastCorrelationID
+	scheduler.deployWorkspace(ws)
+	second := scheduler.state.GetWorkspaceState(ws.Name).LastCorrelationID
+	if first == "" || first == second || second != seen {
+		t.Errorf("expected distinct generated IDs, got %q, %q (deploy saw %q)", first, second, seen)
+	}
+}
+
+func TestCorrelationIDIgnoredWhileBusy(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	logging.SetCorrelationID(ws.Name, "running-op")
+	defer logging.ClearCorrelationID(ws.Name)
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDeploying)
+
+	_ = scheduler.WithCorrelationID(ws.Name, "new-request", func() error { return nil })
+	if id := logging.CorrelationID(ws.Name); id != "running-op" {
+		t.Errorf("expected running operation's ID to be kept, got %q", id)
+	}
+}
+
+func TestScheduleWaveSharesTimestamp(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	now := time.Date(2025, 3, 10, 9, 0, 42, 0, time.UTC)
+
+	seen := make(chan string, 2)
+	mockClient.DeployFunc = func(ws *workspace.Workspace) error {
+		seen <- logging.CorrelationID(ws.Name)
+		return nil
+	}
+
+	for _, name := range []string{"wave-a", "wave-b"} {
+		ws := workspace.Workspace{Name: name, Config: workspace.Config{Enabled: true, DeploySchedule: "0 9 * * *"}}
+		scheduler.workspaces = append(scheduler.workspaces, ws)
+		scheduler.checkWorkspaceSchedules(ws, now)
+	}
+
+	ids := []string{<-seen, <-seen}
+	waitForStatus(t, scheduler, "wave-a", StatusDeployed)
+	waitForStatus(t, scheduler, "wave-b", StatusDeployed)
+	for _, id := range ids {
+		if len(id) < 17 || id[:17] != "20250310T090000Z-" {
+			t.Errorf("expected wave timestamp prefix, got %q", id)
+		}
+	}
+	if ids[0] == ids[1] {
+		t.Errorf("expected unique IDs within a wave, got %q twice", ids[0])
+	}
+}
@@ -0,0 +1,203 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"provisioner/pkg/configfile"
+	"provisioner/pkg/gitops"
+	"provisioner/pkg/holidays"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
+	"provisioner/pkg/workspace"
+)
+
+// DaemonConfigFile is the daemon-wide configuration file in the config directory
+const DaemonConfigFile = "provisioner.json"
+
+// DaemonConfig holds daemon-wide settings (provisioner.json in the config directory)
+type DaemonConfig struct {
+	MaxConcurrentOperations int                               `json:"max_concurrent_operations,omitempty"` // 0 means unlimited
+	ThrottleBuckets         map[string]int                    `json:"throttle_buckets,omitempty"`          // Named concurrency limits referenced by workspaces and jobs
+	DisplayTimezone         string                            `json:"display_timezone,omitempty"`          // IANA timezone for rendered timestamps, default local time
+	Tiers                   map[string]workspace.TierDefaults `json:"tiers,omitempty"`                     // Defaults for workspaces of each deployment tier
+	LabelPolicies           []workspace.LabelPolicy           `json:"label_policies,omitempty"`            // Defaults for workspaces whose labels match a selector
+	Notifications           *notify.Config                    `json:"notifications,omitempty"`             // Notification destinations, replacing notifications.json
+	Previews                *workspace.PreviewConfig          `json:"previews,omitempty"`                  // Workspaces created per pull request by the webhook listener
+	TofuVersion             string                            `json:"tofu_version,omitempty"`              // OpenTofu version of workspaces without their own tofu_version
+	InterruptedRecovery     string                            `json:"interrupted_recovery,omitempty"`      // What to do with operations interrupted by a crash, default none
+	GitOps                  *gitops.Config                    `json:"gitops,omitempty"`                    // Sync workspace, job and environment configs from a git repository
+	HighAvailability        *HAConfig                         `json:"high_availability,omitempty"`         // Elect one of several daemons sharing the state directory to lead
+	TickInterval            string                            `json:"tick_interval,omitempty"`             // How often schedules are checked, default 1m
+	MissedSchedulePolicy    string                            `json:"missed_schedule_policy,omitempty"`    // What to do with schedules that fired while the daemon was down, default run_once
+	DeployTimeout           string                            `json:"deploy_timeout,omitempty"`            // Limit of deploys of workspaces without their own, default 30m
+	DestroyTimeout          string                            `json:"destroy_timeout,omitempty"`           // Limit of destroys of workspaces without their own, default 30m
+	HolidayCalendars        map[string]holidays.Calendar      `json:"holiday_calendars,omitempty"`         // Holidays skipped by schedules with skip_holidays
+}
+
+// LoadDaemonConfig loads daemon settings, returning defaults if the file doesn't exist
+func LoadDaemonConfig(configPath string) (*DaemonConfig, error) {
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &DaemonConfig{}, nil
+		}
+		return nil, fmt.Errorf("failed to read daemon config: %w", err)
+	}
+
+	var config DaemonConfig
+	if err := configfile.Unmarshal(configPath, data, data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon config: %w", err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// Validate checks daemon settings for invalid values
+func (c *DaemonConfig) Validate() error {
+	if c.MaxConcurrentOperations < 0 {
+		return fmt.Errorf("max_concurrent_operations must not be negative: %d", c.MaxConcurrentOperations)
+	}
+	for name, limit := range c.ThrottleBuckets {
+		if limit <= 0 {
+			return fmt.Errorf("throttle bucket '%s' must allow at least one operation: %d", name, limit)
+		}
+	}
+	if _, err := logging.LoadDisplayTimezone(c.DisplayTimezone); err != nil {
+		return err
+	}
+	for name, defaults := range c.Tiers {
+		if !workspace.IsValidTier(name) {
+			return fmt.Errorf("unknown tier '%s' (must be dev, staging or prod)", name)
+		}
+		if err := defaults.Validate(); err != nil {
+			return fmt.Errorf("tier '%s': %w", name, err)
+		}
+	}
+	for i, policy := range c.LabelPolicies {
+		if err := policy.Validate(); err != nil {
+			return fmt.Errorf("label policy %d: %w", i+1, err)
+		}
+	}
+	if c.Notifications != nil {
+		if err := c.Notifications.Validate(); err != nil {
+			return fmt.Errorf("notifications: %w", err)
+		}
+	}
+	if c.Previews != nil {
+		if err := c.Previews.Validate(); err != nil {
+			return fmt.Errorf("previews: %w", err)
+		}
+	}
+	if c.TofuVersion != "" {
+		if err := workspace.ValidateTofuVersion(c.TofuVersion); err != nil {
+			return err
+		}
+	}
+	if c.GitOps != nil {
+		if err := c.GitOps.Validate(); err != nil {
+			return fmt.Errorf("gitops: %w", err)
+		}
+	}
+	if c.HighAvailability != nil {
+		if err := c.HighAvailability.Validate(); err != nil {
+			return fmt.Errorf("high_availability: %w", err)
+		}
+	}
+	if _, err := workspace.ParseOperationTimeout("deploy_timeout", c.DeployTimeout); err != nil {
+		return err
+	}
+	if _, err := workspace.ParseOperationTimeout("destroy_timeout", c.DestroyTimeout); err != nil {
+		return err
+	}
+	if _, err := parseTickInterval(c.TickInterval); err != nil {
+		return err
+	}
+	for name, calendar := range c.HolidayCalendars {
+		if !holidays.NamePattern.MatchString(name) {
+			return fmt.Errorf("holiday calendar name '%s' must contain only letters, numbers, '-' and '_'", name)
+		}
+		if err := calendar.Validate(); err != nil {
+			return fmt.Errorf("holiday calendar '%s': %w", name, err)
+		}
+	}
+	switch c.MissedSchedulePolicy {
+	case "", MissedRunOnce, MissedSkip:
+	default:
+		return fmt.Errorf("missed_schedule_policy must be run_once or skip: %s", c.MissedSchedulePolicy)
+	}
+	switch c.InterruptedRecovery {
+	case "", RecoveryNone, RecoveryRetry, RecoveryRefresh:
+	default:
+		return fmt.Errorf("interrupted_recovery must be none, retry or refresh: %s", c.InterruptedRecovery)
+	}
+	return nil
+}
+
+// initDaemonConfig loads provisioner.json and applies its settings, falling back to defaults on error
+func (s *Scheduler) initDaemonConfig() {
+	config, err := LoadDaemonConfig(filepath.Join(s.configDir, DaemonConfigFile))
+	if err != nil {
+		logging.LogSystemd("Using default daemon settings: %v", err)
+		config = &DaemonConfig{}
+	}
+	s.daemonConfig = config
+
+	if config.MaxConcurrentOperations > 0 {
+		s.operationSlots = make(chan struct{}, config.MaxConcurrentOperations)
+	}
+
+	if config.HighAvailability != nil {
+		s.election = newLeaderElection(getStateDir(), config.HighAvailability.GetLeaseDuration())
+	}
+
+	s.throttleBuckets = make(map[string]chan struct{}, len(config.ThrottleBuckets))
+	for name, limit := range config.ThrottleBuckets {
+		s.throttleBuckets[name] = make(chan struct{}, limit)
+	}
+	s.initJobThrottle()
+	s.initHolidayCalendars()
+}
+
+// GetPreviewConfig returns the pull request preview settings, nil if previews are not configured
+func (s *Scheduler) GetPreviewConfig() *workspace.PreviewConfig {
+	if s.daemonConfig == nil {
+		return nil
+	}
+	return s.daemonConfig.Previews
+}
+
+// applyLabelPolicies fills unset workspace settings from the label policies matching the
+// workspace's labels. Policies apply in the order of provisioner.json, so the first policy
+// setting a default wins, and before the tier's defaults.
+func (s *Scheduler) applyLabelPolicies(workspaces []workspace.Workspace) {
+	if s.daemonConfig == nil {
+		return
+	}
+	for i := range workspaces {
+		config := &workspaces[i].Config
+		for _, policy := range s.daemonConfig.LabelPolicies {
+			if policy.Matches(config.Labels) {
+				config.ApplyTierDefaults(policy.TierDefaults)
+			}
+		}
+	}
+}
+
+// applyTierDefaults fills unset workspace settings from the defaults of the workspace's tier
+func (s *Scheduler) applyTierDefaults(workspaces []workspace.Workspace) {
+	if s.daemonConfig == nil {
+		return
+	}
+	for i := range workspaces {
+		config := &workspaces[i].Config
+		if defaults, ok := s.daemonConfig.Tiers[config.Tier]; ok && config.Tier != "" {
+			config.ApplyTierDefaults(defaults)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"fmt"
+	"strings"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// waitForDependencies holds back an automatic deploy until the workspaces it depends on are
+// deployed, or an automatic destroy until the workspaces depending on it are destroyed.
+// Returns true if the operation must wait; schedules catch up on a later check.
+func (s *Scheduler) waitForDependencies(workspace workspace.Workspace, operation string) bool {
+	blockers := s.dependencyBlockers(workspace.Name, operation)
+	var waitingFor string
+	if len(blockers) > 0 {
+		waitingFor = fmt.Sprintf("%s waits for %s", operation, strings.Join(blockers, ", "))
+	}
+
+	// Log once per change of what the operation waits for
+	s.state.UpdateWorkspace(workspace.Name, func(workspaceState *WorkspaceState) {
+		if waitingFor != "" && workspaceState.WaitingFor != waitingFor {
+			logging.LogWorkspace(workspace.Name, "Scheduled %s", waitingFor)
+		}
+		workspaceState.WaitingFor = waitingFor
+	})
+	return waitingFor != ""
+}
+
+// checkDependencies refuses manual operations that would break the dependency order
+func (s *Scheduler) checkDependencies(workspaceName, operation string) error {
+	blockers := s.dependencyBlockers(workspaceName, operation)
+	if len(blockers) == 0 {
+		return nil
+	}
+	if operation == OperationDestroy {
+		return fmt.Errorf("workspace '%s' is needed by %s, destroy them first", workspaceName, strings.Join(blockers, ", "))
+	}
+	return fmt.Errorf("workspace '%s' depends on %s, deploy them first", workspaceName, strings.Join(blockers, ", "))
+}
+
+// dependencyBlockers returns the workspaces, with their status, that keep an operation from
+// running: dependencies not deployed for a deploy, dependents not destroyed for a destroy
+func (s *Scheduler) dependencyBlockers(workspaceName, operation string) []string {
+	var names []string
+	if operation == OperationDestroy {
+		names = workspace.Dependents(s.loadedWorkspaces(), workspaceName)
+	} else if ws := s.GetWorkspace(workspaceName); ws != nil {
+		names = ws.Config.DependsOn
+	}
+
+	var blockers []string
+	for _, name := range names {
+		status := s.state.Workspace(name).Status
+		if operation == OperationDestroy && !isReleased(status) || operation != OperationDestroy && !isUp(status) {
+			blockers = append(blockers, fmt.Sprintf("%s (%s)", name, status))
+		}
+	}
+	return blockers
+}
+
+// isUp reports whether a dependency is deployed
+func isUp(status WorkspaceStatus) bool {
+	return status == StatusDeployed || status == StatusRunning
+}
+
+// isReleased reports whether a dependent no longer uses its dependencies
+func isReleased(status WorkspaceStatus) bool {
+	return status == StatusDestroyed || status == StatusPending || status == StatusDeployFailed
+}
@@ -0,0 +1,63 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// dependentApp is deployed and destroyed with scenarioDaily's schedules, after workspace network
+const dependentApp = `{"enabled": true, "deploy_schedule": "0 9 * * *", "destroy_schedule": "0 12 * * *", "depends_on": ["network"]}`
+
+func TestDeployWaitsForDependencies(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", dependentApp).workspace("network", scenarioDaily).start()
+
+	// Both deploy schedules fire at 09:00: only the dependency deploys
+	sc.runUntil(time.Date(2025, 3, 10, 9, 0, 45, 0, time.UTC))
+	sc.expectOperations("2025-03-10 09:00 deploy network")
+	appState := sc.scheduler.state.GetWorkspaceState("app")
+	if appState.Status != StatusDestroyed || !strings.Contains(appState.WaitingFor, "network") {
+		t.Fatalf("Expected app to wait for network, got status %s waiting %q", appState.Status, appState.WaitingFor)
+	}
+
+	// The next check catches up once the dependency is deployed
+	sc.run(time.Minute)
+	sc.expectOperations("2025-03-10 09:01 deploy app")
+	if appState.WaitingFor != "" {
+		t.Errorf("Expected the wait to be cleared, got %q", appState.WaitingFor)
+	}
+}
+
+func TestDestroyWaitsForDependents(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", dependentApp).workspace("network", scenarioDaily).start()
+	sc.runUntil(time.Date(2025, 3, 10, 11, 0, 0, 0, time.UTC))
+	sc.engine.takeOperations()
+
+	// Both destroy schedules fire at 12:00: the dependent is destroyed first
+	sc.runUntil(time.Date(2025, 3, 10, 13, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-10 12:00 destroy app", "2025-03-10 12:01 destroy network")
+}
+
+func TestManualOperationsRespectDependencies(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", dependentApp).workspace("network", scenarioDaily).start()
+
+	err := sc.scheduler.ManualDeploy("app")
+	if err == nil || !strings.Contains(err.Error(), "depends on network (destroyed)") {
+		t.Fatalf("Expected deploy before the dependency to be refused, got %v", err)
+	}
+
+	if err := sc.scheduler.ManualDeploy("network"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+	if err := sc.scheduler.ManualDeploy("app"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+
+	err = sc.scheduler.ManualDestroy("network")
+	if err == nil || !strings.Contains(err.Error(), "needed by app (deployed)") {
+		t.Fatalf("Expected destroy before the dependent to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"provisioner/pkg/environment"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
+)
+
+// checkEnvironments starts the health check of every monitored environment whose monitor interval
+// elapsed since its last check. Checks run in the background and record their outcome in the
+// environment's health.
+func (s *Scheduler) checkEnvironments(now time.Time) {
+	environments, err := environment.LoadAllEnvironments()
+	if err != nil {
+		logging.LogSystemd("Error loading environments: %v", err)
+		return
+	}
+
+	monitored := make(map[string]bool, len(environments))
+	for _, env := range environments {
+		if env.Config.Monitor.Disabled {
+			continue
+		}
+		monitored[env.Name] = true
+
+		start := false
+		s.state.UpdateEnvironmentHealth(env.Name, func(health *EnvironmentHealth) {
+			if health.checking {
+				return
+			}
+			if health.Workspace != env.Config.AssignedWorkspace {
+				// Failures of the previously assigned workspace say nothing about the new one
+				*health = EnvironmentHealth{Workspace: env.Config.AssignedWorkspace}
+			} else if interval, _ := env.Config.Monitor.GetIntervalDuration(); health.LastCheck != nil && now.Sub(*health.LastCheck) < interval {
+				return
+			}
+			health.checking = true
+			start = true
+		})
+		if start {
+			s.goOperation(func() { s.checkEnvironmentHealth(env, now) })
+		}
+	}
+
+	// Forget environments that were removed or are no longer monitored
+	s.state.ForgetEnvironments(monitored)
+}
+
+// checkEnvironmentHealth runs an environment's health check and records the outcome, alerting
+// when the environment becomes degraded after failure_threshold consecutive failed checks and
+// when it recovers
+func (s *Scheduler) checkEnvironmentHealth(env environment.Environment, now time.Time) {
+	check := s.environmentCheck
+	if check == nil {
+		check = (*environment.Environment).CheckHealth
+	}
+	err := check(&env)
+
+	workspaceName := env.Config.AssignedWorkspace
+	var event, message string
+	s.state.UpdateEnvironmentHealth(env.Name, func(health *EnvironmentHealth) {
+		health.LastCheck = &now
+		if err == nil {
+			health.LastHealthy = &now
+			health.ConsecutiveFailures = 0
+			health.LastError = ""
+			if health.DegradedSince != nil {
+				event = notify.EventEnvironmentRecovered
+				message = fmt.Sprintf("healthy again after being degraded for %v", now.Sub(*health.DegradedSince).Round(time.Minute))
+				health.DegradedSince = nil
+				logging.LogWorkspaceOperation(workspaceName, "HEALTH", "Environment '%s' is %s", env.Name, message)
+			}
+		} else {
+			health.ConsecutiveFailures++
+			health.LastError = err.Error()
+			logging.LogWorkspace(workspaceName, "Health check of environment '%s' failed (%d in a row): %v", env.Name, health.ConsecutiveFailures, err)
+			if health.DegradedSince == nil && health.ConsecutiveFailures >= env.Config.Monitor.FailureThreshold {
+				health.DegradedSince = &now
+				event = notify.EventEnvironmentDegraded
+				message = fmt.Sprintf("%d consecutive failed health checks", health.ConsecutiveFailures)
+				logging.LogWorkspaceOperation(workspaceName, "HEALTH", "Environment '%s' is degraded after %s", env.Name, message)
+			}
+		}
+		health.checking = false
+	})
+
+	// Alerts go out once the health is recorded, without holding the state
+	switch event {
+	case notify.EventEnvironmentRecovered:
+		s.notifyEnvironmentHealth(event, env.Name, workspaceName, message, "")
+	case notify.EventEnvironmentDegraded:
+		s.notifyEnvironmentHealth(event, env.Name, workspaceName, message, err.Error())
+	}
+
+	if err := s.SaveState(); err != nil {
+		logging.LogSystemd("Error saving state: %v", err)
+	}
+}
+
+// notifyEnvironmentHealth sends an alert for an environment that became degraded or recovered
+func (s *Scheduler) notifyEnvironmentHealth(event, environmentName, workspaceName, message, errMsg string) {
+	if !s.notifier.Enabled() {
+		return
+	}
+
+	s.notifier.Send(notify.Notification{
+		Event:       event,
+		Workspace:   workspaceName,
+		Environment: environmentName,
+		Message:     message,
+		Error:       errMsg,
+		Channel:     s.notificationChannel(workspaceName),
+		LogFile:     s.getWorkspaceLogFile(workspaceName),
+	})
+}
+
+// GetEnvironmentHealth returns the health recorded by the daemon's checks of an environment, or
+// nil if it was not checked yet
+func (s *Scheduler) GetEnvironmentHealth(environmentName string) *EnvironmentHealth {
+	return s.state.GetEnvironmentHealth(environmentName)
+}
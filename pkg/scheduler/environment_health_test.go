@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"provisioner/pkg/environment"
+	"provisioner/pkg/notify"
+)
+
+// recordingSender records the notifications it receives
+type recordingSender struct {
+	mu            sync.Mutex
+	notifications []notify.Notification
+}
+
+func (r *recordingSender) Send(notification notify.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.notifications = append(r.notifications, notification)
+	return nil
+}
+
+func (r *recordingSender) take() []notify.Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	notifications := r.notifications
+	r.notifications = nil
+	return notifications
+}
+
+const productionConfig = `{
+	"domain": "example.com",
+	"reserved_ips": ["203.0.113.10"],
+	"assigned_workspace": "blue",
+	"healthcheck": {"type": "tcp", "port": 443},
+	"monitor": {"interval": "5m", "failure_threshold": 3}
+}`
+
+func TestEnvironmentHealthMonitoring(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("blue", scenarioOfficeHours).workspace("green", scenarioOfficeHours)
+	sc.writeFile(filepath.Join(sc.dir, "production.json"), productionConfig)
+	sc.start()
+
+	sender := &recordingSender{}
+	sc.scheduler.notifier = notify.NewWithConfig(&notify.Config{})
+	sc.scheduler.notifier.AddSender("test", notify.Subscription{}, sender)
+
+	var mu sync.Mutex
+	var checks []string
+	var failing bool
+	sc.scheduler.environmentCheck = func(env *environment.Environment) error {
+		mu.Lock()
+		defer mu.Unlock()
+		checks = append(checks, env.Config.AssignedWorkspace)
+		if failing {
+			return errors.New("Server 203.0.113.10: connection refused")
+		}
+		return nil
+	}
+	setFailing := func(f bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		failing = f
+	}
+
+	// Checked right away, then every interval
+	sc.run(12 * time.Minute)
+	if len(checks) != 3 {
+		t.Errorf("Expected 3 checks in 12 minutes, got %d", len(checks))
+	}
+	if status := sc.scheduler.GetEnvironmentHealth("production").Status(); status != EnvironmentHealthy {
+		t.Errorf("Expected healthy, got %s", status)
+	}
+
+	// Failures below the threshold don't alert
+	setFailing(true)
+	sc.run(10 * time.Minute)
+	health := sc.scheduler.GetEnvironmentHealth("production")
+	if health.Status() != EnvironmentFailing || health.ConsecutiveFailures != 2 {
+		t.Errorf("Expected 2 failed checks, got %+v", health)
+	}
+	if notifications := sender.take(); len(notifications) != 0 {
+		t.Errorf("Expected no alert below the threshold, got %+v", notifications)
+	}
+
+	// The third failure degrades the environment and alerts once
+	sc.run(15 * time.Minute)
+	health = sc.scheduler.GetEnvironmentHealth("production")
+	if health.Status() != EnvironmentDegraded || !strings.Contains(health.LastError, "connection refused") {
+		t.Errorf("Expected degraded, got %+v", health)
+	}
+	notifications := sender.take()
+	if len(notifications) != 1 || notifications[0].Event != notify.EventEnvironmentDegraded ||
+		notifications[0].Environment != "production" || notifications[0].Workspace != "blue" {
+		t.Fatalf("Expected one degraded alert, got %+v", notifications)
+	}
+
+	// Degradation survives a restart, and recovery is reported
+	sc.downFor(time.Minute)
+	sc.scheduler.notifier = notify.NewWithConfig(&notify.Config{})
+	sc.scheduler.notifier.AddSender("test", notify.Subscription{}, sender)
+	sc.scheduler.environmentCheck = func(env *environment.Environment) error { return nil }
+	if status := sc.scheduler.GetEnvironmentHealth("production").Status(); status != EnvironmentDegraded {
+		t.Errorf("Expected degraded after restart, got %s", status)
+	}
+	sc.run(5 * time.Minute)
+	notifications = sender.take()
+	if len(notifications) != 1 || notifications[0].Event != notify.EventEnvironmentRecovered {
+		t.Errorf("Expected one recovery notification, got %+v", notifications)
+	}
+	if status := sc.scheduler.GetEnvironmentHealth("production").Status(); status != EnvironmentHealthy {
+		t.Errorf("Expected healthy after recovery, got %s", status)
+	}
+
+	// Switching the environment starts over with the new workspace
+	sc.scheduler.environmentCheck = func(env *environment.Environment) error {
+		return errors.New("Server 203.0.113.10: connection refused")
+	}
+	sc.run(15 * time.Minute)
+	sc.writeFile(filepath.Join(sc.dir, "production.json"), strings.Replace(productionConfig, `"blue"`, `"green"`, 1))
+	sc.run(time.Minute)
+	health = sc.scheduler.GetEnvironmentHealth("production")
+	if health.Workspace != "green" || health.ConsecutiveFailures != 1 || health.Status() != EnvironmentFailing {
+		t.Errorf("Expected the failures of blue to be forgotten, got %+v", health)
+	}
+
+	sc.writeFile(filepath.Join(sc.dir, "production.json"), strings.Replace(productionConfig, `"interval": "5m"`, `"disabled": true`, 1))
+	sc.run(time.Minute)
+	if health := sc.scheduler.GetEnvironmentHealth("production"); health != nil {
+		t.Errorf("Expected no health for an environment that isn't monitored, got %+v", health)
+	}
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"provisioner/pkg/cron"
+	"provisioner/pkg/logging"
+)
+
+// maxSkippedWhileFrozen is the number of skipped operations kept per freeze
+const maxSkippedWhileFrozen = 50
+
+// FreezeWorkspace pins a workspace to its current deployment: scheduled deploys, destroys and mode
+// changes, retries and config-change redeploys are skipped and recorded until it is unfrozen.
+// Manual deploys and destroys are refused while frozen.
+func (s *Scheduler) FreezeWorkspace(workspaceName, reason string) error {
+	if s.GetWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
+	if workspaceState.Freeze != nil {
+		return fmt.Errorf("workspace '%s' is already frozen since %s", workspaceName, logging.FormatTime(workspaceState.Freeze.Since))
+	}
+
+	s.state.FreezeWorkspace(workspaceName, reason, s.currentTime())
+	if reason != "" {
+		logging.LogWorkspaceOperation(workspaceName, "FREEZE", "Workspace frozen: %s", reason)
+	} else {
+		logging.LogWorkspaceOperation(workspaceName, "FREEZE", "Workspace frozen")
+	}
+	if workspaceState.IsBusy() {
+		logging.LogWorkspace(workspaceName, "The running %s is not affected by the freeze", workspaceState.ActiveOperation())
+	}
+
+	return s.SaveState()
+}
+
+// UnfreezeWorkspace resumes automatic operations. Config changes made while frozen are applied,
+// so the next check redeploys the workspace as if they had just been made.
+func (s *Scheduler) UnfreezeWorkspace(workspaceName string) error {
+	if s.GetWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
+	freeze := s.state.UnfreezeWorkspace(workspaceName)
+	if freeze == nil {
+		return fmt.Errorf("workspace '%s' is not frozen", workspaceName)
+	}
+
+	logging.LogWorkspaceOperation(workspaceName, "FREEZE", "Workspace unfrozen after %v, %d operation(s) were skipped",
+		s.currentTime().Sub(freeze.Since).Round(time.Minute), len(workspaceState.SkippedWhileFrozen))
+
+	if modified := workspaceState.LastConfigModified; modified != nil && modified.After(freeze.Since) {
+		logging.LogWorkspace(workspaceName, "Applying config change made while frozen (%s)", logging.FormatTime(*modified))
+		s.state.SetWorkspaceConfigModified(workspaceName, *modified)
+	}
+
+	return s.SaveState()
+}
+
+// IsWorkspaceFrozen reports whether a workspace is frozen
+func (s *Scheduler) IsWorkspaceFrozen(workspaceName string) bool {
+	return s.state != nil && s.state.Workspace(workspaceName).Freeze != nil
+}
+
+// skipIfFrozen suppresses an automatic operation of a frozen workspace, recording it once per
+// trigger. Returns true if the operation must not run.
+func (s *Scheduler) skipIfFrozen(workspaceName, operation, reason string, dueAt time.Time) bool {
+	if !s.IsWorkspaceFrozen(workspaceName) {
+		return false
+	}
+
+	skipped := SkippedOperation{Operation: operation, Reason: reason, DueAt: dueAt, SkippedAt: s.currentTime()}
+	if s.state.RecordFrozenSkip(workspaceName, skipped) {
+		logging.LogWorkspace(workspaceName, "Workspace is frozen, skipping %s (%s at %s)", operation, reason, logging.FormatTime(dueAt))
+	}
+	return true
+}
+
+// checkNotFrozen refuses manual operations on a frozen workspace
+func (s *Scheduler) checkNotFrozen(workspaceName, operation string) error {
+	if freeze := s.state.Workspace(workspaceName).Freeze; freeze != nil {
+		return fmt.Errorf("workspace '%s' is frozen since %s, cannot %s. Use 'workspacectl unfreeze %s' first",
+			workspaceName, logging.FormatTime(freeze.Since), operation, workspaceName)
+	}
+	return nil
+}
+
+// formatSkippedOperation formats a skipped operation for display
+func formatSkippedOperation(skipped SkippedOperation) string {
+	return fmt.Sprintf("%s  %s (%s)", logging.FormatTime(skipped.DueAt), skipped.Operation, skipped.Reason)
+}
+
+// lastDueTime returns the latest time one of the schedules fired that is still due, the due time
+// of a deploy or destroy schedule
+func (s *Scheduler) lastDueTime(schedules []string, now time.Time) time.Time {
+	var latest time.Time
+	for _, scheduleStr := range schedules {
+		schedule, err := cron.Parse(scheduleStr)
+		if err != nil {
+			continue
+		}
+		if run := s.lastScheduledRun(schedule, now); run != nil && !run.After(now) && run.After(latest) {
+			latest = *run
+		}
+	}
+	return latest
+}
@@ -0,0 +1,102 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestFrozenWorkspaceSkipsSchedules(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+	now := time.Date(2025, 3, 10, 10, 0, 0, 0, time.Local)
+
+	if err := scheduler.FreezeWorkspace(ws.Name, "release demo"); err != nil {
+		t.Fatalf("Freeze failed: %v", err)
+	}
+	if err := scheduler.FreezeWorkspace(ws.Name, ""); err == nil {
+		t.Error("Expected freezing a frozen workspace to fail")
+	}
+
+	// The deploy schedule fired at 09:00; later passes don't record it again
+	scheduler.checkWorkspaceSchedules(ws, now)
+	scheduler.checkWorkspaceSchedules(ws, now.Add(time.Minute))
+	time.Sleep(50 * time.Millisecond)
+	if mockClient.GetDeployCallCount() != 0 {
+		t.Fatalf("Expected no deploy while frozen, got %d", mockClient.GetDeployCallCount())
+	}
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if len(workspaceState.SkippedWhileFrozen) != 1 || workspaceState.SkippedWhileFrozen[0].Operation != OperationDeploy ||
+		!workspaceState.SkippedWhileFrozen[0].DueAt.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Expected the skipped deploy schedule to be recorded once, got %+v", workspaceState.SkippedWhileFrozen)
+	}
+
+	err := scheduler.ManualDeploy(ws.Name)
+	if err == nil || !strings.Contains(err.Error(), "frozen") {
+		t.Fatalf("Expected manual deploy of a frozen workspace to be refused, got %v", err)
+	}
+
+	// Once unfrozen, the schedule that fired today runs
+	if err := scheduler.UnfreezeWorkspace(ws.Name); err != nil {
+		t.Fatalf("Unfreeze failed: %v", err)
+	}
+	if workspaceState.Freeze != nil || len(workspaceState.SkippedWhileFrozen) != 1 {
+		t.Errorf("Expected freeze to be cleared with the skips kept, got %+v and %+v", workspaceState.Freeze, workspaceState.SkippedWhileFrozen)
+	}
+	scheduler.checkWorkspaceSchedules(ws, now.Add(2*time.Minute))
+	waitForStatus(t, scheduler, ws.Name, StatusDeployed)
+	if mockClient.GetDeployCallCount() != 1 {
+		t.Errorf("Expected deploy after unfreeze, got %d", mockClient.GetDeployCallCount())
+	}
+}
+
+func TestUnfreezeAppliesConfigChange(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDeployed)
+
+	if err := scheduler.FreezeWorkspace(ws.Name, ""); err != nil {
+		t.Fatalf("Freeze failed: %v", err)
+	}
+	modified := time.Now().Add(time.Second)
+	if !scheduler.skipIfFrozen(ws.Name, "redeploy", "config change", modified) {
+		t.Fatal("Expected config change redeploy to be skipped while frozen")
+	}
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	workspaceState.LastConfigModified = &modified
+
+	if err := scheduler.UnfreezeWorkspace(ws.Name); err != nil {
+		t.Fatalf("Unfreeze failed: %v", err)
+	}
+	if workspaceState.Status != StatusDestroyed {
+		t.Errorf("Expected the config change to mark the workspace for redeployment, got status %s", workspaceState.Status)
+	}
+	if err := scheduler.UnfreezeWorkspace(ws.Name); err == nil {
+		t.Error("Expected unfreezing a workspace that is not frozen to fail")
+	}
+}
+
+func TestFrozenSkipsAreBounded(t *testing.T) {
+	state := NewState()
+	state.FreezeWorkspace("frozen-app", "", time.Now())
+	dueAt := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	if !state.RecordFrozenSkip("frozen-app", SkippedOperation{Operation: OperationDeploy, Reason: "retry", DueAt: dueAt}) {
+		t.Fatal("Expected the first skip to be recorded")
+	}
+	if state.RecordFrozenSkip("frozen-app", SkippedOperation{Operation: OperationDeploy, Reason: "retry", DueAt: dueAt}) {
+		t.Error("Expected a repeated skip not to be recorded again")
+	}
+
+	for i := 1; i < maxSkippedWhileFrozen+5; i++ {
+		state.RecordFrozenSkip("frozen-app", SkippedOperation{Operation: OperationDeploy, Reason: "retry", DueAt: dueAt.Add(time.Duration(i) * time.Minute)})
+	}
+	skipped := state.GetWorkspaceState("frozen-app").SkippedWhileFrozen
+	if len(skipped) != maxSkippedWhileFrozen || !skipped[0].DueAt.Equal(dueAt.Add(5*time.Minute)) {
+		t.Errorf("Expected the latest %d skips, got %d starting at %v", maxSkippedWhileFrozen, len(skipped), skipped[0].DueAt)
+	}
+}
@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"strings"
+	"time"
+
+	"provisioner/pkg/gitops"
+	"provisioner/pkg/logging"
+)
+
+// syncGitOps syncs the workspace, job and environment configs from the repository of gitops in
+// provisioner.json when its interval has passed since the last sync, or always with force. The
+// config watcher and the job config polling pick up the files it changes.
+func (s *Scheduler) syncGitOps(now time.Time, force bool) {
+	if s.daemonConfig == nil || s.daemonConfig.GitOps == nil {
+		return
+	}
+	config := s.daemonConfig.GitOps
+	if !force && now.Sub(s.lastGitOpsSync) < config.GetInterval() {
+		return
+	}
+	s.lastGitOpsSync = now
+
+	result, err := gitops.Sync(config, s.configDir, getStateDir())
+	if err != nil {
+		// A failing remote is reported once until the error changes or a sync succeeds
+		if err.Error() != s.lastGitOpsError {
+			logging.LogSystemd("GitOps sync of %s failed, keeping the current configs: %v", config.Repository, err)
+		}
+		s.lastGitOpsError = err.Error()
+		return
+	}
+	if s.lastGitOpsError != "" {
+		logging.LogSystemd("GitOps sync of %s recovered", config.Repository)
+		s.lastGitOpsError = ""
+	}
+
+	if result.Commit != result.Previous {
+		logging.LogSystemd("GitOps synced configs from %s at %s", config.Repository, gitops.ShortCommit(result.Commit))
+	}
+	if len(result.Written) > 0 {
+		logging.LogSystemd("GitOps updated %s", strings.Join(result.Written, ", "))
+	}
+	if len(result.Reverted) > 0 {
+		logging.LogSystemd("GitOps reverted local edits of %s", strings.Join(result.Reverted, ", "))
+	}
+	if len(result.Removed) > 0 {
+		logging.LogSystemd("GitOps removed %s", strings.Join(result.Removed, ", "))
+	}
+}
@@ -0,0 +1,128 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"time"
+
+	"provisioner/pkg/cron"
+	"provisioner/pkg/holidays"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/statefile"
+	"provisioner/pkg/workspace"
+)
+
+// holidayRetryInterval is how soon a calendar URL that failed to fetch is tried again
+const holidayRetryInterval = time.Hour
+
+// holidayCachePath returns where the days last fetched from a calendar's URL are kept, so a
+// restart without network access still skips them
+func holidayCachePath(name string) string {
+	return filepath.Join(getStateDir(), "holidays", name+".json")
+}
+
+// initHolidayCalendars registers the holiday calendars of provisioner.json with the schedules
+// skipping them. Calendars with a URL start from the days last fetched until they are refreshed.
+func (s *Scheduler) initHolidayCalendars() {
+	for name, calendar := range s.daemonConfig.HolidayCalendars {
+		var fetched []time.Time
+		if calendar.URL != "" {
+			if data, _, err := statefile.Read(holidayCachePath(name)); err == nil {
+				fetched, _ = holidays.Parse(data)
+			}
+		}
+		if err := s.registerHolidays(name, calendar, fetched); err != nil {
+			logging.LogSystemd("Holiday calendar '%s': %v", name, err)
+		}
+	}
+}
+
+// registerHolidays registers the inline and file days of a calendar along with those fetched from its URL
+func (s *Scheduler) registerHolidays(name string, calendar holidays.Calendar, fetched []time.Time) error {
+	days, err := calendar.Days(s.configDir)
+	if err != nil {
+		return err
+	}
+	cron.SetHolidays(name, append(days, fetched...))
+	return nil
+}
+
+// refreshHolidayCalendars fetches the holiday calendars with a URL once a day, or always with
+// force. A calendar that fails to fetch keeps its last days and is tried again after an hour.
+func (s *Scheduler) refreshHolidayCalendars(now time.Time, force bool) {
+	if s.daemonConfig == nil || (!force && now.Before(s.nextHolidayRefresh)) {
+		return
+	}
+	s.nextHolidayRefresh = now.Add(holidays.RefreshInterval)
+
+	for name, calendar := range s.daemonConfig.HolidayCalendars {
+		if calendar.URL == "" {
+			continue
+		}
+		days, err := fetchHolidays(calendar.URL)
+		if err == nil {
+			err = s.registerHolidays(name, calendar, days)
+		}
+		if err != nil {
+			logging.LogSystemd("Failed to refresh holiday calendar '%s' from %s, keeping its last days: %v", name, calendar.URL, err)
+			if retry := now.Add(holidayRetryInterval); retry.Before(s.nextHolidayRefresh) {
+				s.nextHolidayRefresh = retry
+			}
+			continue
+		}
+
+		dates := make([]string, len(days))
+		for i, day := range days {
+			dates[i] = day.Format(time.DateOnly)
+		}
+		data, _ := json.Marshal(dates)
+		if err := statefile.Write(holidayCachePath(name), data); err != nil {
+			logging.LogSystemd("Failed to cache holiday calendar '%s': %v", name, err)
+		}
+	}
+}
+
+// fetchHolidays fetches and parses the calendar at url
+func fetchHolidays(url string) ([]time.Time, error) {
+	data, err := holidays.Fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	return holidays.Parse(data)
+}
+
+// checkHolidayCalendars logs schedules skipping the holidays of a calendar provisioner.json doesn't
+// define; they run on every day their CRON expression matches
+func (s *Scheduler) checkHolidayCalendars() {
+	for _, ws := range s.loadedWorkspaces() {
+		reported := map[string]bool{}
+		for _, expr := range workspaceSchedules(ws) {
+			schedule, err := cron.Parse(expr)
+			if err != nil || schedule.Holidays == "" || reported[schedule.Holidays] {
+				continue
+			}
+			if _, ok := s.daemonConfig.HolidayCalendars[schedule.Holidays]; !ok {
+				logging.LogWorkspace(ws.Name, "Holiday calendar '%s' is not defined in %s, holidays are not skipped", schedule.Holidays, DaemonConfigFile)
+				reported[schedule.Holidays] = true
+			}
+		}
+	}
+}
+
+// workspaceSchedules returns all deploy, destroy and mode schedules of a workspace
+func workspaceSchedules(ws workspace.Workspace) []string {
+	var schedules []string
+	if ws.Config.DeploySchedule != nil {
+		deploy, _ := ws.Config.GetDeploySchedules()
+		schedules = append(schedules, deploy...)
+	}
+	if ws.Config.DestroySchedule != nil {
+		destroy, _ := ws.Config.GetDestroySchedules()
+		schedules = append(schedules, destroy...)
+	}
+	modes, _ := ws.Config.GetModeSchedules()
+	for _, modeSchedules := range modes {
+		schedules = append(schedules, modeSchedules...)
+	}
+	return schedules
+}
@@ -0,0 +1,75 @@
+package scheduler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"provisioner/pkg/cron"
+	"provisioner/pkg/holidays"
+)
+
+func TestHolidayCalendarRefresh(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:20250825\nEND:VEVENT\nEND:VCALENDAR\n"))
+	}))
+	defer server.Close()
+
+	scheduler.daemonConfig = &DaemonConfig{HolidayCalendars: map[string]holidays.Calendar{
+		"refresh-test": {URL: server.URL, Dates: []string{"2025-12-25"}},
+	}}
+	bankHoliday := time.Date(2025, 8, 25, 8, 0, 0, 0, time.UTC)
+	now := time.Date(2025, 8, 1, 12, 0, 0, 0, time.UTC)
+	scheduler.refreshHolidayCalendars(now, true)
+	if !cron.IsHoliday("refresh-test", bankHoliday) || !cron.IsHoliday("refresh-test", time.Date(2025, 12, 25, 0, 0, 0, 0, time.UTC)) {
+		t.Fatal("Expected the fetched and inline days to be holidays")
+	}
+	if _, err := os.Stat(holidayCachePath("refresh-test")); err != nil {
+		t.Errorf("Expected the fetched days to be cached: %v", err)
+	}
+
+	// A failing URL keeps the last days and is retried sooner than the next daily refresh
+	failing = true
+	scheduler.refreshHolidayCalendars(now.Add(time.Minute), true)
+	if !cron.IsHoliday("refresh-test", bankHoliday) {
+		t.Error("Expected a failed refresh to keep the fetched days")
+	}
+	if want := now.Add(time.Minute + holidayRetryInterval); !scheduler.nextHolidayRefresh.Equal(want) {
+		t.Errorf("Expected a retry at %v, got %v", want, scheduler.nextHolidayRefresh)
+	}
+
+	// After a restart the cached days are used until the URL is fetched again
+	cron.SetHolidays("refresh-test", nil)
+	scheduler.initHolidayCalendars()
+	if !cron.IsHoliday("refresh-test", bankHoliday) {
+		t.Error("Expected the cached days to be registered on startup")
+	}
+
+	schedule, _ := cron.Parse("SKIP_HOLIDAYS=refresh-test CRON_TZ=UTC 0 8 * * 1-5")
+	if schedule.ShouldRun(bankHoliday) {
+		t.Error("Expected the schedule not to run on the bank holiday")
+	}
+}
+
+func TestDaemonConfigHolidayCalendars(t *testing.T) {
+	config := DaemonConfig{HolidayCalendars: map[string]holidays.Calendar{"uk": {Dates: []string{"2025-12-25"}}}}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected the calendar to be valid, got %v", err)
+	}
+	config.HolidayCalendars = map[string]holidays.Calendar{"uk holidays": {Dates: []string{"2025-12-25"}}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected an invalid calendar name to fail")
+	}
+	config.HolidayCalendars = map[string]holidays.Calendar{"uk": {}}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected a calendar without days to fail")
+	}
+}
@@ -0,0 +1,28 @@
+package scheduler
+
+import (
+	"errors"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+)
+
+// isDegraded reports whether a deploy applied its changes but a post_deploy hook failed
+func isDegraded(err error) bool {
+	return errors.Is(err, opentofu.ErrDegraded)
+}
+
+// recordDegraded marks a workspace whose deploy applied but failed its post_deploy hooks as
+// deploy_degraded: its resources exist and are destroyed as usual, but it is not redeployed on
+// schedule until its config changes or an operator deploys it.
+func (s *Scheduler) recordDegraded(workspaceName, operationName string, err error) {
+	logging.LogWorkspaceOperation(workspaceName, operationName, "Degraded: %s", getHighLevelError(err))
+	logging.LogWorkspaceOnly(workspaceName, "%s: Degraded: %s", operationName, stripANSIColors(err.Error()))
+	s.state.SetWorkspaceDegraded(workspaceName, err.Error())
+}
+
+// hasDeployment reports whether a workspace's resources are deployed, whether or not its
+// post_deploy hooks passed
+func hasDeployment(status WorkspaceStatus) bool {
+	return status == StatusDeployed || status == StatusRunning || status == StatusDeployDegraded
+}
@@ -0,0 +1,60 @@
+package scheduler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/events"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+func TestDegradedDeploy(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	var published []events.Type
+	scheduler.Events().Subscribe(func(event events.Event) { published = append(published, event.Type) })
+
+	mockClient.DeployFunc = func(*workspace.Workspace) error {
+		return &opentofu.DegradedError{Hook: "curl -f https://app.example.com/health", Err: errors.New("exit status 22")}
+	}
+	scheduler.deployWorkspace(ws)
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.Status != StatusDeployDegraded || workspaceState.LastDeployed == nil {
+		t.Fatalf("expected a degraded deployed workspace, got status %s, deployed %v", workspaceState.Status, workspaceState.LastDeployed)
+	}
+	if !strings.Contains(workspaceState.LastDeployError, "post_deploy hook failed: curl -f https://app.example.com/health") {
+		t.Errorf("expected the failed hook as deploy error, got %q", workspaceState.LastDeployError)
+	}
+	if len(published) != 1 || published[0] != events.DeployDegraded {
+		t.Errorf("expected a deploy_degraded event, got %v", published)
+	}
+	entries, err := scheduler.AuditLog().Read(audit.Filter{})
+	if err != nil || len(entries) != 1 || entries[0].Outcome != audit.OutcomeDegraded {
+		t.Errorf("expected a degraded audit entry, got %+v %v", entries, err)
+	}
+
+	// The resources exist: not deployed again on schedule, but limits and destroys still apply
+	now := time.Now()
+	if scheduler.ShouldRunDeploySchedule([]string{"* * * * *"}, now, workspaceState) {
+		t.Error("expected a degraded workspace not to be redeployed on schedule")
+	}
+	if !scheduler.ShouldRunDestroySchedule([]string{"* * * * *"}, now, workspaceState) {
+		t.Error("expected a degraded workspace to be destroyed on schedule")
+	}
+	if !hasDeployment(workspaceState.Status) {
+		t.Error("expected a degraded workspace to count as deployed")
+	}
+
+	// A config change redeploys it
+	scheduler.state.SetWorkspaceConfigModified(ws.Name, now)
+	if workspaceState.Status != StatusDestroyed || workspaceState.LastDeployError != "" {
+		t.Errorf("expected the config change to allow a redeploy, got %s %q", workspaceState.Status, workspaceState.LastDeployError)
+	}
+}
@@ -0,0 +1,171 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+// checkIdle destroys or hibernates a deployed workspace whose idle check has found it idle for
+// idle_minutes, and otherwise starts the next idle check once its interval has elapsed. Checks run
+// in the background and record their outcome in the workspace state. Returns true if an
+// operation was triggered.
+func (s *Scheduler) checkIdle(ws workspace.Workspace, workspaceState *WorkspaceState, now time.Time) bool {
+	check := ws.Config.IdleCheck
+	if check == nil {
+		return false
+	}
+
+	hibernating := check.GetIdleAction() == workspace.IdleActionHibernate &&
+		workspaceState.DeploymentMode == check.GetHibernationMode()
+	if workspaceState.Status != StatusDeployed || hibernating {
+		if workspaceState.IdleSince != nil {
+			s.state.UpdateWorkspace(ws.Name, func(state *WorkspaceState) { state.IdleSince = nil })
+		}
+		return false
+	}
+
+	if workspaceState.IdleSince != nil && now.Sub(*workspaceState.IdleSince) >= check.GetIdleDuration() {
+		return s.actOnIdle(ws, workspaceState, now)
+	}
+
+	if workspaceState.idleChecking {
+		return false
+	}
+	if workspaceState.LastIdleCheck != nil && now.Sub(*workspaceState.LastIdleCheck) < check.GetInterval() {
+		return false
+	}
+	s.state.UpdateWorkspace(ws.Name, func(state *WorkspaceState) { state.idleChecking = true })
+	s.goOperation(func() { s.runIdleCheck(ws, now) })
+	return false
+}
+
+// runIdleCheck measures a workspace's activity and starts or ends its idle streak. A failed
+// check ends the streak, so a workspace is never acted on without evidence that it is idle.
+func (s *Scheduler) runIdleCheck(ws workspace.Workspace, now time.Time) {
+	check := ws.Config.IdleCheck
+	measure := s.idleCheck
+	if measure == nil {
+		measure = func(checked workspace.Workspace) (float64, error) {
+			return checked.Config.IdleCheck.Measure(checked.Name, opentofu.GetWorkingDir(checked.Name))
+		}
+	}
+	metric, err := measure(ws)
+
+	s.state.UpdateWorkspace(ws.Name, func(state *WorkspaceState) {
+		state.LastIdleCheck = &now
+		switch {
+		case err != nil:
+			state.LastIdleError = err.Error()
+			state.IdleSince = nil
+			logging.LogWorkspace(ws.Name, "Idle check failed: %v", err)
+		case metric <= check.Threshold:
+			state.LastIdleMetric = &metric
+			state.LastIdleError = ""
+			if state.IdleSince == nil {
+				state.IdleSince = &now
+				logging.LogWorkspace(ws.Name, "Idle (activity %g, threshold %g), %s after %v idle",
+					metric, check.Threshold, describeIdleAction(check), check.GetIdleDuration())
+			}
+		default:
+			state.LastIdleMetric = &metric
+			state.LastIdleError = ""
+			if state.IdleSince != nil {
+				logging.LogWorkspace(ws.Name, "Active again (activity %g) after being idle for %v",
+					metric, now.Sub(*state.IdleSince).Round(time.Minute))
+			}
+			state.IdleSince = nil
+		}
+		state.idleChecking = false
+	})
+
+	if err := s.SaveState(); err != nil {
+		logging.LogSystemd("Error saving state: %v", err)
+	}
+}
+
+// actOnIdle destroys or hibernates a workspace that has been idle for idle_minutes. Workspaces
+// that must not be destroyed start a new idle streak, so they are reported once per idle_minutes.
+func (s *Scheduler) actOnIdle(ws workspace.Workspace, workspaceState *WorkspaceState, now time.Time) bool {
+	check := ws.Config.IdleCheck
+	idleFor := now.Sub(*workspaceState.IdleSince).Round(time.Minute)
+	dueAt := workspaceState.IdleSince.Add(check.GetIdleDuration())
+	message := fmt.Sprintf("Idle for %v (activity at or below %g)", idleFor, check.Threshold)
+
+	if check.GetIdleAction() == workspace.IdleActionHibernate {
+		mode := check.GetHibernationMode()
+		if s.skipIfFrozen(ws.Name, "deploy in mode "+mode, "idle check", dueAt) ||
+			s.waitForDependencies(ws, OperationDeploy) {
+			return false
+		}
+
+		s.state.UpdateWorkspace(ws.Name, func(state *WorkspaceState) { state.IdleSince = nil })
+		logging.SetCorrelationID(ws.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+		logging.LogWorkspaceOperation(ws.Name, "IDLE", "%s, hibernating in mode %s", message, mode)
+		s.notifyIdle(ws.Name, fmt.Sprintf("%s, hibernating in mode %s", message, mode))
+		s.goOperation(func() { s.deployWorkspaceInMode(ws, mode, ModeTriggerIdle) })
+		return true
+	}
+
+	if protectedBy, isProtected := s.isWorkspaceProtectedByEnvironment(ws.Name); isProtected {
+		message += fmt.Sprintf(", not destroyed because it is assigned to environment '%s'", protectedBy)
+	} else if ws.Config.IsProtected() {
+		message += ", not destroyed because the workspace is protected"
+	} else if s.skipIfFrozen(ws.Name, OperationDestroy, "idle check", dueAt) ||
+		s.waitForDependencies(ws, OperationDestroy) {
+		return false
+	} else {
+		s.state.UpdateWorkspace(ws.Name, func(state *WorkspaceState) { state.IdleSince = nil })
+		logging.SetCorrelationID(ws.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+		logging.LogWorkspaceOperation(ws.Name, "IDLE", "%s, destroying workspace", message)
+		s.notifyIdle(ws.Name, message+", destroying workspace")
+		s.goOperation(func() { s.destroyWorkspace(ws) })
+		return true
+	}
+
+	s.state.UpdateWorkspace(ws.Name, func(state *WorkspaceState) { state.IdleSince = &now })
+	logging.LogWorkspaceOperation(ws.Name, "IDLE", "%s", message)
+	s.notifyIdle(ws.Name, message)
+	return false
+}
+
+// describeIdleAction describes what happens to an idle workspace, e.g. "hibernating in mode night"
+func describeIdleAction(check *workspace.IdleCheckConfig) string {
+	if check.GetIdleAction() == workspace.IdleActionHibernate {
+		return "hibernating in mode " + check.GetHibernationMode()
+	}
+	return "destroying"
+}
+
+// formatIdleStatus describes a workspace's idle detection for status output
+func formatIdleStatus(check *workspace.IdleCheckConfig, state *WorkspaceState, now time.Time) string {
+	status := fmt.Sprintf("%s after %v idle", describeIdleAction(check), check.GetIdleDuration())
+	switch {
+	case state.LastIdleError != "":
+		status += fmt.Sprintf(", last check failed: %s", state.LastIdleError)
+	case state.IdleSince != nil:
+		status += fmt.Sprintf(", idle since %s (%v)", logging.FormatTime(*state.IdleSince), now.Sub(*state.IdleSince).Round(time.Minute))
+	case state.LastIdleMetric != nil:
+		status += fmt.Sprintf(", active (activity %g)", *state.LastIdleMetric)
+	}
+	return status
+}
+
+// notifyIdle sends an alert for a workspace found idle for idle_minutes
+func (s *Scheduler) notifyIdle(workspaceName, message string) {
+	if !s.notifier.Enabled() {
+		return
+	}
+
+	s.notifier.Send(notify.Notification{
+		Event:     notify.EventWorkspaceIdle,
+		Workspace: workspaceName,
+		Message:   message,
+		Channel:   s.notificationChannel(workspaceName),
+		LogFile:   s.getWorkspaceLogFile(workspaceName),
+	})
+}
@@ -0,0 +1,131 @@
+package scheduler
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+// idleActivity is the activity metric reported to the scheduler's idle checks
+type idleActivity struct {
+	mu     sync.Mutex
+	metric float64
+	err    error
+	checks int
+}
+
+func (a *idleActivity) set(metric float64, err error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.metric, a.err = metric, err
+}
+
+func (a *idleActivity) measure(workspace.Workspace) (float64, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.checks++
+	return a.metric, a.err
+}
+
+// startWithIdleActivity starts the scenario daemon with idle checks reporting activity
+func (sc *scenario) startWithIdleActivity(activity *idleActivity) *scenario {
+	sc.start()
+	sc.scheduler.idleCheck = activity.measure
+	return sc
+}
+
+const scenarioIdleDestroy = `{"enabled": true, "deploy_schedule": "0 8 * * *", "destroy_schedule": false,
+	"idle_check": {"type": "command", "command": "who | wc -l", "idle_minutes": 30}}`
+
+func TestIdleWorkspaceIsDestroyed(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 7, 55, 0, 0, time.UTC))
+	activity := &idleActivity{}
+	sc.workspace("app", scenarioIdleDestroy).startWithIdleActivity(activity)
+
+	// Idle from the first check after the deploy, destroyed 30 minutes later
+	sc.runUntil(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 08:00 deploy app",
+		"2025-03-10 08:31 destroy app",
+	)
+	if status := sc.status("app"); status != StatusDestroyed {
+		t.Errorf("Expected app destroyed, got %s", status)
+	}
+
+	// Destroyed workspaces are not checked
+	checks := activity.checks
+	sc.run(time.Hour)
+	if activity.checks != checks {
+		t.Errorf("Expected no idle checks of a destroyed workspace, got %d", activity.checks-checks)
+	}
+}
+
+func TestIdleStreakEndsOnActivity(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 7, 55, 0, 0, time.UTC))
+	activity := &idleActivity{}
+	sc.workspace("app", scenarioIdleDestroy).startWithIdleActivity(activity)
+
+	// Active at 08:21, the streak starts again with the next idle check at 08:26
+	sc.runUntil(time.Date(2025, 3, 10, 8, 20, 0, 0, time.UTC))
+	activity.set(3, nil)
+	sc.run(5 * time.Minute)
+	if state := sc.scheduler.state.GetWorkspaceState("app"); state.IdleSince != nil {
+		t.Errorf("Expected no idle streak while active, got idle since %v", state.IdleSince)
+	}
+	activity.set(0, nil)
+
+	sc.runUntil(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 08:00 deploy app",
+		"2025-03-10 08:56 destroy app",
+	)
+}
+
+func TestFailedIdleCheckKeepsWorkspace(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 7, 55, 0, 0, time.UTC))
+	activity := &idleActivity{}
+	activity.set(0, errors.New("metrics unavailable"))
+	sc.workspace("app", scenarioIdleDestroy).startWithIdleActivity(activity)
+
+	sc.runUntil(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-10 08:00 deploy app")
+
+	state := sc.scheduler.state.GetWorkspaceState("app")
+	if state.LastIdleError != "metrics unavailable" || state.IdleSince != nil {
+		t.Errorf("Expected the failed check recorded without an idle streak, got %q, %v", state.LastIdleError, state.IdleSince)
+	}
+}
+
+func TestIdleWorkspaceHibernates(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 7, 55, 0, 0, time.UTC))
+	activity := &idleActivity{}
+	sc.workspace("app", `{"enabled": true,
+		"mode_schedules": {"busy": "0 8 * * *", "hibernation": "0 20 * * *"},
+		"idle_check": {"type": "command", "command": "who | wc -l", "idle_minutes": 30, "action": "hibernate"}}`)
+	sc.startWithIdleActivity(activity)
+
+	// Hibernated once, then left alone until the next scheduled mode
+	sc.runUntil(time.Date(2025, 3, 10, 19, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 07:55 deploy app (hibernation)",
+		"2025-03-10 08:00 deploy app (busy)",
+		"2025-03-10 08:31 deploy app (hibernation)",
+	)
+	if mode := sc.scheduler.state.GetWorkspaceState("app").DeploymentMode; mode != "hibernation" {
+		t.Errorf("Expected app in mode hibernation, got %s", mode)
+	}
+}
+
+func TestIdleProtectedWorkspaceIsKept(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 7, 55, 0, 0, time.UTC))
+	activity := &idleActivity{}
+	sc.workspace("app", `{"enabled": true, "deploy_schedule": "0 8 * * *", "destroy_schedule": false, "protected": true,
+		"idle_check": {"type": "command", "command": "who | wc -l", "idle_minutes": 30}}`)
+	sc.startWithIdleActivity(activity)
+
+	sc.runUntil(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-10 08:00 deploy app")
+}
@@ -62,8 +62,8 @@ func TestImmediateDeploymentOnConfigChange(t *testing.T) {
 		now := time.Now()
 		scheduler.checkWorkspaceForImmediateDeployment("test-immediate", now)
 
-		// Give goroutine a moment to execute
-		time.Sleep(10 * time.Millisecond)
+		// Wait for the triggered deployment to finish
+		scheduler.operations.Wait()
 
 		if !deploymentTriggered {
 			t.Error("expected deployment to be triggered immediately on config change")
@@ -89,8 +89,8 @@ func TestImmediateDeploymentOnConfigChange(t *testing.T) {
 		scheduler.state.SetWorkspaceConfigModified("test-immediate", now)
 		scheduler.checkWorkspaceForImmediateDeployment("test-immediate", now)
 
-		// Give goroutine a moment to execute
-		time.Sleep(10 * time.Millisecond)
+		// Wait for the triggered deployment to finish
+		scheduler.operations.Wait()
 
 		if !deploymentTriggered {
 			t.Error("expected deployment to be triggered immediately after config change on failed workspace")
@@ -116,8 +116,8 @@ func TestImmediateDeploymentOnConfigChange(t *testing.T) {
 		scheduler.state.SetWorkspaceConfigModified("test-immediate", now)
 		scheduler.checkWorkspaceForImmediateDeployment("test-immediate", now)
 
-		// Give goroutine a moment to execute
-		time.Sleep(10 * time.Millisecond)
+		// Wait for the triggered deployment to finish
+		scheduler.operations.Wait()
 
 		if !deploymentTriggered {
 			t.Error("expected redeployment to be triggered immediately after config change on deployed workspace")
@@ -137,8 +137,8 @@ func TestImmediateDeploymentOnConfigChange(t *testing.T) {
 		now := time.Now()
 		scheduler.checkWorkspaceForImmediateDeployment("test-immediate", now)
 
-		// Give potential goroutine a moment
-		time.Sleep(10 * time.Millisecond)
+		// Wait for any triggered deployment to finish
+		scheduler.operations.Wait()
 
 		if deploymentTriggered {
 			t.Error("expected NO deployment when workspace is busy")
@@ -156,6 +156,7 @@ func TestImmediateDeploymentWithScheduleCheck(t *testing.T) {
 
 	mockClient := opentofu.NewMockTofuClient()
 	scheduler := NewWithClient(mockClient)
+	scheduler.statePath = filepath.Join(t.TempDir(), "scheduler.json")
 	scheduler.state = NewState()
 
 	// Workspace with specific schedule (9 AM only)
@@ -180,7 +181,7 @@ func TestImmediateDeploymentWithScheduleCheck(t *testing.T) {
 	}
 
 	scheduler.checkWorkspaceForImmediateDeployment("test-scheduled", morningTime)
-	time.Sleep(10 * time.Millisecond)
+	scheduler.operations.Wait()
 
 	if deploymentTriggered {
 		t.Error("expected NO deployment at 8 AM when schedule is 9 AM")
@@ -191,7 +192,7 @@ func TestImmediateDeploymentWithScheduleCheck(t *testing.T) {
 	deploymentTriggered = false
 
 	scheduler.checkWorkspaceForImmediateDeployment("test-scheduled", laterTime)
-	time.Sleep(10 * time.Millisecond)
+	scheduler.operations.Wait()
 
 	if !deploymentTriggered {
 		t.Error("expected deployment at 10 AM when 9 AM schedule has passed")
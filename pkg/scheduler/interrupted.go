@@ -0,0 +1,125 @@
+package scheduler
+
+import (
+	"fmt"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+// Recoveries of operations interrupted by a daemon crash (interrupted_recovery)
+const (
+	RecoveryNone    = "none"    // Mark the workspace interrupted and wait for an operator
+	RecoveryRetry   = "retry"   // Run the interrupted deploy or destroy again
+	RecoveryRefresh = "refresh" // Reconcile the state with tofu apply -refresh-only
+)
+
+// interruptedRecovery returns the configured recovery of interrupted operations
+func (s *Scheduler) interruptedRecovery() string {
+	if s.daemonConfig == nil || s.daemonConfig.InterruptedRecovery == "" {
+		return RecoveryNone
+	}
+	return s.daemonConfig.InterruptedRecovery
+}
+
+// recoverInterruptedOperations marks workspaces left deploying or destroying by a daemon that died
+// as interrupted, then retries or refreshes them as configured. A workspace whose deployment lock
+// is still held, e.g. by a workspacectl deploy, is left alone: its operation is alive.
+func (s *Scheduler) recoverInterruptedOperations() {
+	if s.state == nil {
+		return
+	}
+
+	recovery := s.interruptedRecovery()
+	recovered := false
+	states := s.state.WorkspaceStates()
+	for _, ws := range s.loadedWorkspaces() {
+		workspaceState, exists := states[ws.Name]
+		if !exists || (workspaceState.Status != StatusDeploying && workspaceState.Status != StatusDestroying) {
+			continue
+		}
+		if holder, held := opentofu.DeploymentLockHolder(ws.Name); held {
+			if holder != nil {
+				logging.LogWorkspace(ws.Name, "Workspace is %s by %s, leaving it running", workspaceState.Status, holder)
+			}
+			continue
+		}
+
+		interruption := &Interruption{
+			Operation:  workspaceState.ActiveOperation(),
+			DetectedAt: s.currentTime(),
+			Recovery:   recovery,
+		}
+		if interruption.Operation == OperationDeploy && len(ws.Config.ModeSchedules) > 0 {
+			interruption.Mode = workspaceState.DeploymentMode
+		}
+		if count, err := ws.GetStateResourceCount(); err == nil {
+			interruption.StateResources = count
+		}
+
+		s.state.SetWorkspaceInterrupted(ws.Name, interruption)
+		logging.LogWorkspaceOperation(ws.Name, "RECOVERY", "Interrupted: %s", formatInterruption(interruption))
+		recovered = true
+
+		switch recovery {
+		case RecoveryRetry:
+			s.retryInterrupted(ws, interruption)
+		case RecoveryRefresh:
+			s.goOperation(func() { s.refreshInterrupted(ws, interruption) })
+		}
+	}
+
+	if recovered {
+		_ = s.SaveState()
+	}
+}
+
+// retryInterrupted runs an interrupted deploy or destroy again
+func (s *Scheduler) retryInterrupted(ws workspace.Workspace, interruption *Interruption) {
+	logging.LogWorkspaceOperation(ws.Name, "RECOVERY", "Running the interrupted %s again", interruption.Operation)
+	switch {
+	case interruption.Operation == OperationDestroy:
+		s.goOperation(func() { s.destroyWorkspace(ws) })
+	case interruption.Mode != "":
+		s.goOperation(func() { s.deployWorkspaceInMode(ws, interruption.Mode, ModeTriggerSchedule) })
+	default:
+		s.goOperation(func() { s.deployWorkspace(ws) })
+	}
+}
+
+// refreshInterrupted reconciles the state of an interrupted operation with the real resources and
+// sets the workspace's status from the refreshed state. The operation is not completed; schedules
+// or an operator take it from there.
+func (s *Scheduler) refreshInterrupted(ws workspace.Workspace, interruption *Interruption) {
+	defer s.beginCorrelation(ws.Name)()
+	logging.LogWorkspaceOperation(ws.Name, "RECOVERY", "Refreshing state after the interrupted %s", interruption.Operation)
+
+	if err := s.client.RefreshWorkspace(&ws, interruption.Mode); err != nil {
+		logging.LogWorkspaceOperation(ws.Name, "RECOVERY", "Refresh failed: %s", getHighLevelError(err))
+		logging.LogWorkspaceOnly(ws.Name, "RECOVERY: Refresh failed: %s", stripANSIColors(err.Error()))
+		return
+	}
+
+	// Like a queued operation recovered after a restart, the state decides the status
+	status := WorkspaceStatus(ws.GetDeploymentStatus())
+	count, _ := ws.GetStateResourceCount()
+	logging.LogWorkspaceOperation(ws.Name, "RECOVERY", "State refreshed, %d resources in state, status %s", count, status)
+
+	s.state.UpdateWorkspace(ws.Name, func(workspaceState *WorkspaceState) {
+		if workspaceState.Status == StatusInterrupted {
+			workspaceState.Status = status
+		}
+	})
+	_ = s.SaveState()
+}
+
+// formatInterruption summarizes an interruption for status output and logs
+func formatInterruption(i *Interruption) string {
+	operation := i.Operation
+	if i.Mode != "" {
+		operation = fmt.Sprintf("%s (mode %s)", operation, i.Mode)
+	}
+	return fmt.Sprintf("%s found interrupted at %s; %d resources in state; recovery: %s",
+		operation, logging.FormatTime(i.DetectedAt), i.StateResources, i.Recovery)
+}
@@ -0,0 +1,77 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+func TestRecoverInterruptedOperations(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDeploying)
+	scheduler.state.QueuePendingOperation(ws.Name, OperationDestroy, time.Now(), PendingOperationTTL)
+	scheduler.recoverInterruptedOperations()
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.Status != StatusInterrupted {
+		t.Fatalf("expected status %s, got %s", StatusInterrupted, workspaceState.Status)
+	}
+	if workspaceState.PendingOperation != nil {
+		t.Errorf("expected queued operation to be dropped, got %+v", workspaceState.PendingOperation)
+	}
+	interruption := workspaceState.LastInterruption
+	if interruption == nil || interruption.Operation != OperationDeploy || interruption.Recovery != RecoveryNone {
+		t.Fatalf("unexpected interruption details: %+v", interruption)
+	}
+	if mockClient.GetDeployCallCount() != 0 || mockClient.RefreshCallCount != 0 {
+		t.Errorf("expected no recovery by default, got %d deploys and %d refreshes", mockClient.GetDeployCallCount(), mockClient.RefreshCallCount)
+	}
+
+	// The interrupted deploy waits for an operator, but destroy may still clean up
+	now := time.Now().Truncate(time.Minute).Add(30 * time.Second)
+	if scheduler.ShouldRunDeploySchedule([]string{"* * * * *"}, now, workspaceState) {
+		t.Error("expected interrupted deploy not to be rescheduled")
+	}
+	if !scheduler.ShouldRunDestroySchedule([]string{"* * * * *"}, now, workspaceState) {
+		t.Error("expected destroy schedule to run after an interrupted deploy")
+	}
+
+	scheduler.state.SetWorkspaceConfigModified(ws.Name, time.Now())
+	if workspaceState.Status != StatusDestroyed {
+		t.Errorf("expected config change to reset status to %s, got %s", StatusDestroyed, workspaceState.Status)
+	}
+}
+
+func TestRecoverInterruptedOperationsSkipsLiveOperations(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDestroying)
+
+	// A workspacectl destroy holding the deployment lock is still running
+	workingDir := opentofu.GetWorkingDir(ws.Name)
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("failed to create working dir: %v", err)
+	}
+	lockFile, err := os.OpenFile(filepath.Join(workingDir, opentofu.LockFileName), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		t.Fatalf("failed to open lock file: %v", err)
+	}
+	defer func() { _ = lockFile.Close() }()
+	if err := syscall.Flock(int(lockFile.Fd()), syscall.LOCK_EX); err != nil {
+		t.Fatalf("failed to acquire lock: %v", err)
+	}
+
+	scheduler.recoverInterruptedOperations()
+	if status := scheduler.state.GetWorkspaceState(ws.Name).Status; status != StatusDestroying {
+		t.Errorf("expected live destroy to be left %s, got %s", StatusDestroying, status)
+	}
+}
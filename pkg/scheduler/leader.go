@@ -0,0 +1,409 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/systemd"
+)
+
+// LeaderDir holds the leader lease generations in the state directory
+const LeaderDir = "leader"
+
+// defaultLeaseDuration is how long a leader lease lasts without renewal unless configured
+const defaultLeaseDuration = 30 * time.Second
+
+// minLeaseDuration keeps renewals, every third of the lease, from hammering shared storage
+const minLeaseDuration = 6 * time.Second
+
+// HAConfig runs the daemon on several hosts sharing the state directory, of which only the
+// leader executes schedules while the others stand by (high_availability in provisioner.json)
+type HAConfig struct {
+	LeaseDuration string `json:"lease_duration,omitempty"` // How long the leader leads without renewing its lease, default 30s
+}
+
+// Validate checks the high availability settings for invalid values
+func (c *HAConfig) Validate() error {
+	if c.LeaseDuration == "" {
+		return nil
+	}
+	duration, err := time.ParseDuration(c.LeaseDuration)
+	if err != nil {
+		return fmt.Errorf("invalid lease_duration '%s'", c.LeaseDuration)
+	}
+	if duration < minLeaseDuration {
+		return fmt.Errorf("lease_duration must be at least %v: %s", minLeaseDuration, c.LeaseDuration)
+	}
+	return nil
+}
+
+// GetLeaseDuration returns how long a leader lease lasts without renewal
+func (c *HAConfig) GetLeaseDuration() time.Duration {
+	if duration, err := time.ParseDuration(c.LeaseDuration); err == nil && duration >= minLeaseDuration {
+		return duration
+	}
+	return defaultLeaseDuration
+}
+
+// LeaderLease records which daemon leads. The leader renews it by claiming the next generation
+// before it expires; a standby claims the next generation once it has expired.
+type LeaderLease struct {
+	Generation int       `json:"generation"`
+	Holder     string    `json:"holder"` // hostname:pid of the leading daemon
+	Since      time.Time `json:"since"`  // When the holder became leader
+	RenewedAt  time.Time `json:"renewed_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// Active returns true until the lease expires
+func (l *LeaderLease) Active(now time.Time) bool {
+	return now.Before(l.ExpiresAt)
+}
+
+// leaderElection claims and renews the leader lease through generation-numbered files created
+// with link(2), which is atomic on NFS, so at most one daemon holds each generation. Like job run
+// leases, it relies on the hosts' clocks being synchronized.
+type leaderElection struct {
+	dir      string
+	holder   string
+	duration time.Duration
+
+	mu    sync.Mutex
+	lease *LeaderLease // Lease held by this daemon, nil while standing by
+}
+
+// newLeaderElection creates an election over the leases in stateDir
+func newLeaderElection(stateDir string, duration time.Duration) *leaderElection {
+	host, _ := os.Hostname()
+	return &leaderElection{
+		dir:      filepath.Join(stateDir, LeaderDir),
+		holder:   fmt.Sprintf("%s:%d", host, os.Getpid()),
+		duration: duration,
+	}
+}
+
+// renewInterval is how often the leader renews its lease and a standby checks for an expired one
+func (e *leaderElection) renewInterval() time.Duration {
+	return e.duration / 3
+}
+
+// generationPath returns the lease file of a generation; zero padding keeps them sorted by name
+func (e *leaderElection) generationPath(generation int) string {
+	return filepath.Join(e.dir, fmt.Sprintf("%010d.json", generation))
+}
+
+// CurrentLeader returns the newest leader lease in stateDir, nil if no daemon ever led
+func CurrentLeader(stateDir string) (*LeaderLease, error) {
+	return newLeaderElection(stateDir, 0).current()
+}
+
+// current returns the newest lease, nil if there is none
+func (e *leaderElection) current() (*LeaderLease, error) {
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read leader directory: %w", err)
+	}
+
+	var generations []int
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if generation, err := strconv.Atoi(name); err == nil {
+			generations = append(generations, generation)
+		}
+	}
+	if len(generations) == 0 {
+		return nil, nil
+	}
+	sort.Ints(generations)
+
+	data, err := os.ReadFile(e.generationPath(generations[len(generations)-1]))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read leader lease: %w", err)
+	}
+	var lease LeaderLease
+	if err := json.Unmarshal(data, &lease); err != nil {
+		return nil, fmt.Errorf("failed to parse leader lease: %w", err)
+	}
+	return &lease, nil
+}
+
+// campaign renews the lease this daemon holds, or claims it when no other daemon holds an
+// active one. It returns whether this daemon leads until the lease's expiry, and the current lease.
+func (e *leaderElection) campaign(now time.Time) (bool, *LeaderLease, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	current, err := e.current()
+	if err != nil {
+		return false, nil, err
+	}
+	held := e.lease != nil && current != nil && current.Generation == e.lease.Generation && current.Holder == e.holder
+	if current != nil && current.Active(now) && !held {
+		e.lease = nil
+		return false, current, nil
+	}
+
+	next := &LeaderLease{Generation: 1, Holder: e.holder, Since: now, RenewedAt: now, ExpiresAt: now.Add(e.duration)}
+	if current != nil {
+		next.Generation = current.Generation + 1
+	}
+	if held {
+		next.Since = e.lease.Since
+	}
+
+	if err := os.MkdirAll(e.dir, 0755); err != nil {
+		return false, nil, fmt.Errorf("failed to create leader directory: %w", err)
+	}
+	// Write the complete lease first so other daemons never read a partial one
+	tempPath, err := e.writeTemp(next)
+	if err != nil {
+		return false, nil, err
+	}
+	defer func() { _ = os.Remove(tempPath) }()
+
+	if err := os.Link(tempPath, e.generationPath(next.Generation)); err != nil {
+		e.lease = nil
+		if errors.Is(err, os.ErrExist) {
+			// Another daemon claimed this generation first
+			current, err := e.current()
+			return false, current, err
+		}
+		return false, nil, fmt.Errorf("failed to claim leader lease: %w", err)
+	}
+
+	e.lease = next
+	e.prune(next.Generation)
+	return true, next, nil
+}
+
+// isLeader returns true while this daemon holds an unexpired lease
+func (e *leaderElection) isLeader(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lease != nil && e.lease.Active(now)
+}
+
+// release expires the lease this daemon holds, so a standby takes over without waiting for it
+func (e *leaderElection) release(now time.Time) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.lease == nil {
+		return nil
+	}
+
+	released := *e.lease
+	released.ExpiresAt = now
+	e.lease = nil
+
+	current, err := e.current()
+	if err != nil || current == nil || current.Generation != released.Generation || current.Holder != e.holder {
+		return err // No longer ours to release
+	}
+	tempPath, err := e.writeTemp(&released)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tempPath, e.generationPath(released.Generation)); err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("failed to release leader lease: %w", err)
+	}
+	return nil
+}
+
+// writeTemp writes a lease to a file unique to this process
+func (e *leaderElection) writeTemp(lease *LeaderLease) (string, error) {
+	data, err := json.MarshalIndent(lease, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal leader lease: %w", err)
+	}
+	tempPath := filepath.Join(e.dir, fmt.Sprintf(".%d.%s.tmp", lease.Generation, strings.ReplaceAll(e.holder, string(filepath.Separator), "_")))
+	if err := os.WriteFile(tempPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write leader lease: %w", err)
+	}
+	return tempPath, nil
+}
+
+// prune removes lease generations older than the previous one
+func (e *leaderElection) prune(latest int) {
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".json")
+		if !ok {
+			continue
+		}
+		if generation, err := strconv.Atoi(name); err == nil && generation < latest-1 {
+			_ = os.Remove(e.generationPath(generation))
+		}
+	}
+}
+
+// HighAvailability reports whether the daemon runs with leader election
+func (s *Scheduler) HighAvailability() bool {
+	return s.election != nil
+}
+
+// Standby reports whether this daemon stands by for another one to fail, and the leader
+func (s *Scheduler) Standby() (string, bool) {
+	if s.election == nil || s.election.isLeader(s.currentTime()) {
+		return "", false
+	}
+	leader := "none"
+	if lease, err := s.election.current(); err == nil && lease != nil && lease.Active(s.currentTime()) {
+		leader = lease.Holder
+	}
+	return leader, true
+}
+
+// LeadershipLost reports whether the scheduler loop stopped because another daemon took over
+func (s *Scheduler) LeadershipLost() bool {
+	if s.leadershipLost == nil {
+		return false
+	}
+	select {
+	case <-s.leadershipLost:
+		return true
+	default:
+		return false
+	}
+}
+
+// ReleaseLeadership hands the lease over on shutdown, so a standby takes over right away
+func (s *Scheduler) ReleaseLeadership() {
+	if s.election == nil {
+		return
+	}
+	if err := s.election.release(s.currentTime()); err != nil {
+		logging.LogSystemd("Failed to release the leader lease: %v", err)
+	}
+}
+
+// waitForLeadership stands by until this daemon leads, then reloads the workspaces and state the
+// previous leader left behind. It returns false if the scheduler was stopped while standing by.
+func (s *Scheduler) waitForLeadership() bool {
+	interval := s.election.renewInterval()
+	var watchdog <-chan time.Time
+	if watchdogInterval := systemd.WatchdogInterval(); watchdogInterval > 0 {
+		watchdogTicker := time.NewTicker(watchdogInterval)
+		defer watchdogTicker.Stop()
+		watchdog = watchdogTicker.C
+	}
+
+	// A standby is up as far as systemd is concerned
+	if err := systemd.Ready(); err != nil {
+		logging.LogSystemd("Failed to notify systemd: %v", err)
+	}
+
+	lastLeader := ""
+	for {
+		leading, lease, err := s.election.campaign(s.currentTime())
+		switch {
+		case err != nil:
+			logging.LogSystemd("Leader election failed, standing by: %v", err)
+		case leading:
+			logging.LogSystemd("Leading as %s (lease generation %d)", lease.Holder, lease.Generation)
+			if err := s.LoadWorkspaces(); err != nil {
+				logging.LogSystemd("Error loading workspaces: %v", err)
+			}
+			if err := s.LoadState(); err != nil {
+				logging.LogSystemd("Error loading state: %v", err)
+			}
+			s.leadershipLost = make(chan struct{})
+			go s.keepLeadership()
+			return true
+		case lease != nil && lease.Holder != lastLeader:
+			lastLeader = lease.Holder
+			logging.LogSystemd("Standing by, %s leads", lease.Holder)
+			_ = systemd.Status("Standing by, %s leads", lease.Holder)
+		}
+
+		timer := time.NewTimer(interval)
+	wait:
+		for {
+			select {
+			case <-timer.C:
+				break wait
+			case <-watchdog:
+				_ = systemd.Watchdog()
+			case <-s.stopChan:
+				timer.Stop()
+				return false
+			}
+		}
+	}
+}
+
+// keepLeadership renews the lease until the scheduler stops, closing leadershipLost when another
+// daemon took over or the lease expired because shared storage could not be written
+func (s *Scheduler) keepLeadership() {
+	ticker := time.NewTicker(s.election.renewInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			leading, lease, err := s.election.campaign(s.currentTime())
+			if leading {
+				continue
+			}
+			if err != nil {
+				logging.LogSystemd("Failed to renew the leader lease: %v", err)
+				if s.election.isLeader(s.currentTime()) {
+					continue // Retried before the lease expires
+				}
+			} else if lease != nil {
+				logging.LogSystemd("%s took over as leader", lease.Holder)
+			}
+			close(s.leadershipLost)
+			return
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// leading returns false once this daemon must no longer execute schedules
+func (s *Scheduler) leading() bool {
+	return s.election == nil || s.election.isLeader(s.currentTime())
+}
+
+// RunLeaderCommand shows which daemon leads and since when
+func RunLeaderCommand(args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("leader takes no arguments")
+	}
+
+	lease, err := CurrentLeader(getStateDir())
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if lease == nil || !lease.Active(now) {
+		fmt.Println("No daemon leads")
+		if lease != nil {
+			fmt.Printf("Last leader: %s until %s\n", lease.Holder, logging.FormatTime(lease.ExpiresAt))
+		}
+		return nil
+	}
+	fmt.Printf("Leader:      %s\n", lease.Holder)
+	fmt.Printf("Since:       %s\n", logging.FormatTime(lease.Since))
+	fmt.Printf("Renewed:     %s\n", logging.FormatTime(lease.RenewedAt))
+	fmt.Printf("Expires:     %s\n", logging.FormatTime(lease.ExpiresAt))
+	return nil
+}
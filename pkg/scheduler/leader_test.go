@@ -0,0 +1,163 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestElection creates an election whose holder is named, so several can share a directory
+func newTestElection(stateDir, holder string) *leaderElection {
+	e := newLeaderElection(stateDir, 30*time.Second)
+	e.holder = holder
+	return e
+}
+
+func TestLeaderElection(t *testing.T) {
+	stateDir := t.TempDir()
+	first, second := newTestElection(stateDir, "node-a:1"), newTestElection(stateDir, "node-b:1")
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	leading, lease, err := first.campaign(now)
+	if err != nil || !leading || lease.Generation != 1 {
+		t.Fatalf("Expected the first daemon to lead with generation 1, got %v %+v %v", leading, lease, err)
+	}
+	leading, lease, err = second.campaign(now.Add(time.Second))
+	if err != nil || leading || lease == nil || lease.Holder != "node-a:1" {
+		t.Fatalf("Expected the second daemon to stand by for node-a, got %v %+v %v", leading, lease, err)
+	}
+
+	// Renewing keeps the leader and when it became leader
+	leading, lease, err = first.campaign(now.Add(10 * time.Second))
+	if err != nil || !leading || lease.Generation != 2 || !lease.Since.Equal(now) {
+		t.Fatalf("Expected the leader to renew into generation 2, got %v %+v %v", leading, lease, err)
+	}
+	if !first.isLeader(now.Add(39*time.Second)) || first.isLeader(now.Add(40*time.Second)) {
+		t.Error("Expected the leader to lead until its renewed lease expires")
+	}
+
+	// A standby takes over an expired lease, and the old leader learns it lost
+	leading, lease, err = second.campaign(now.Add(41 * time.Second))
+	if err != nil || !leading || lease.Generation != 3 || lease.Holder != "node-b:1" {
+		t.Fatalf("Expected the standby to take over the expired lease, got %v %+v %v", leading, lease, err)
+	}
+	leading, lease, err = first.campaign(now.Add(42 * time.Second))
+	if err != nil || leading || lease.Holder != "node-b:1" {
+		t.Fatalf("Expected the old leader to stand by, got %v %+v %v", leading, lease, err)
+	}
+	if first.isLeader(now.Add(42 * time.Second)) {
+		t.Error("Expected the old leader to no longer lead")
+	}
+
+	// Old generations are pruned
+	entries, _ := os.ReadDir(filepath.Join(stateDir, LeaderDir))
+	for _, entry := range entries {
+		if entry.Name() == "0000000001.json" {
+			t.Error("Expected generation 1 to be pruned")
+		}
+	}
+}
+
+func TestLeaderElectionRelease(t *testing.T) {
+	stateDir := t.TempDir()
+	first, second := newTestElection(stateDir, "node-a:1"), newTestElection(stateDir, "node-b:1")
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+
+	if leading, _, err := first.campaign(now); err != nil || !leading {
+		t.Fatalf("Expected the first daemon to lead, got %v %v", leading, err)
+	}
+	if err := first.release(now.Add(5 * time.Second)); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	if first.isLeader(now.Add(5 * time.Second)) {
+		t.Error("Expected a released lease to end leadership")
+	}
+
+	leading, lease, err := second.campaign(now.Add(6 * time.Second))
+	if err != nil || !leading || lease.Generation != 2 {
+		t.Fatalf("Expected the standby to take over right after the release, got %v %+v %v", leading, lease, err)
+	}
+	// Releasing a lease that was taken over leaves the new leader's lease alone
+	if err := first.release(now.Add(7 * time.Second)); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	current, err := CurrentLeader(stateDir)
+	if err != nil || current.Holder != "node-b:1" || !current.Active(now.Add(7*time.Second)) {
+		t.Errorf("Expected node-b to keep leading, got %+v %v", current, err)
+	}
+}
+
+func TestLeaderElectionRace(t *testing.T) {
+	stateDir := t.TempDir()
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	elections := []*leaderElection{
+		newTestElection(stateDir, "node-a:1"), newTestElection(stateDir, "node-b:1"), newTestElection(stateDir, "node-c:1"),
+	}
+
+	results := make(chan bool, len(elections))
+	for _, e := range elections {
+		go func(e *leaderElection) {
+			leading, _, _ := e.campaign(now)
+			results <- leading
+		}(e)
+	}
+	leaders := 0
+	for range elections {
+		if <-results {
+			leaders++
+		}
+	}
+	if leaders != 1 {
+		t.Errorf("Expected exactly one leader, got %d", leaders)
+	}
+}
+
+func TestSchedulerStandby(t *testing.T) {
+	stateDir := t.TempDir()
+	leader := newTestElection(stateDir, "node-a:1")
+	if leading, _, err := leader.campaign(time.Now()); err != nil || !leading {
+		t.Fatalf("Expected node-a to lead, got %v %v", leading, err)
+	}
+
+	s := &Scheduler{stopChan: make(chan bool), election: newTestElection(stateDir, "node-b:1")}
+	if holder, standby := s.Standby(); !standby || holder != "node-a:1" {
+		t.Errorf("Expected the scheduler to stand by for node-a, got %q %v", holder, standby)
+	}
+	if s.leading() {
+		t.Error("Expected a standby not to execute schedules")
+	}
+
+	// Stopping a standby ends its wait for the lease
+	done := make(chan bool)
+	go func() { done <- s.waitForLeadership() }()
+	close(s.stopChan)
+	select {
+	case leading := <-done:
+		if leading {
+			t.Error("Expected a stopped standby not to lead")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the standby to stop waiting")
+	}
+
+	if (&Scheduler{}).LeadershipLost() {
+		t.Error("Expected a scheduler without election never to lose leadership")
+	}
+}
+
+func TestHAConfigValidate(t *testing.T) {
+	if err := (&HAConfig{LeaseDuration: "20s"}).Validate(); err != nil {
+		t.Errorf("Expected 20s to be valid, got %v", err)
+	}
+	if err := (&HAConfig{LeaseDuration: "2s"}).Validate(); err == nil || !strings.Contains(err.Error(), "at least") {
+		t.Errorf("Expected a too short lease to fail, got %v", err)
+	}
+	if err := (&HAConfig{LeaseDuration: "soon"}).Validate(); err == nil {
+		t.Error("Expected an invalid duration to fail")
+	}
+	if duration := (&HAConfig{}).GetLeaseDuration(); duration != defaultLeaseDuration {
+		t.Errorf("Expected the default lease duration, got %v", duration)
+	}
+}
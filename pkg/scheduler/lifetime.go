@@ -0,0 +1,143 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
+	"provisioner/pkg/workspace"
+)
+
+// checkMaxLifetime enforces max_lifetime on a deployed workspace, destroying it or alerting once
+// it has been deployed for longer than the limit. Returns true if destruction was triggered.
+func (s *Scheduler) checkMaxLifetime(workspace workspace.Workspace, workspaceState *WorkspaceState, now time.Time) bool {
+	if !workspace.Config.HasMaxLifetime() {
+		return false
+	}
+	if !hasDeployment(workspaceState.Status) {
+		return false
+	}
+
+	maxLifetime, err := workspace.Config.GetMaxLifetime()
+	if err != nil {
+		logging.LogWorkspace(workspace.Name, "Invalid max_lifetime: %v", err)
+		return false
+	}
+
+	age := workspaceState.DeploymentAge(now)
+	if age < maxLifetime {
+		return false
+	}
+
+	message := fmt.Sprintf("Deployed for %v, exceeding max_lifetime of %v", age.Round(time.Minute), maxLifetime)
+
+	if workspace.Config.DestroysOnMaxLifetime() {
+		if protectedBy, isProtected := s.isWorkspaceProtectedByEnvironment(workspace.Name); isProtected {
+			message += fmt.Sprintf(", not destroyed because it is assigned to environment '%s'", protectedBy)
+		} else if workspace.Config.IsProtected() {
+			message += ", not destroyed because the workspace is protected"
+		} else if s.skipIfFrozen(workspace.Name, OperationDestroy, "max_lifetime", now.Add(maxLifetime-age)) ||
+			s.waitForDependencies(workspace, OperationDestroy) {
+			return false
+		} else {
+			logging.SetCorrelationID(workspace.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+			logging.LogWorkspaceOperation(workspace.Name, "LIFETIME", "%s, destroying workspace", message)
+			if workspaceState.Status == StatusRunning {
+				s.state.SetWorkspaceRunResult(workspace.Name, RunResultTimedOut)
+			}
+			s.goOperation(func() { s.destroyWorkspace(workspace) })
+			return true
+		}
+	}
+
+	// Alert once per deployment
+	if workspaceState.LifetimeAlerted {
+		return false
+	}
+	s.state.UpdateWorkspace(workspace.Name, func(ws *WorkspaceState) { ws.LifetimeAlerted = true })
+	logging.LogWorkspaceOperation(workspace.Name, "LIFETIME", "%s", message)
+	s.notifyLifetimeExceeded(workspace.Name, message)
+	return false
+}
+
+// checkTTL destroys a deployed workspace once its ttl has passed since its last deploy, whatever
+// its destroy schedules say. Returns true if destruction was triggered.
+func (s *Scheduler) checkTTL(workspace workspace.Workspace, workspaceState *WorkspaceState, now time.Time) bool {
+	if !workspace.Config.HasTTL() {
+		return false
+	}
+	if !hasDeployment(workspaceState.Status) {
+		return false
+	}
+
+	ttl, err := workspace.Config.GetTTL()
+	if err != nil {
+		logging.LogWorkspace(workspace.Name, "Invalid ttl: %v", err)
+		return false
+	}
+
+	expiry := workspaceState.TTLExpiry(ttl)
+	if expiry == nil || now.Before(*expiry) {
+		return false
+	}
+
+	message := fmt.Sprintf("TTL of %v since the last deploy expired at %s", ttl, logging.FormatTime(*expiry))
+
+	if protectedBy, isProtected := s.isWorkspaceProtectedByEnvironment(workspace.Name); isProtected {
+		message += fmt.Sprintf(", not destroyed because it is assigned to environment '%s'", protectedBy)
+	} else if workspace.Config.IsProtected() {
+		message += ", not destroyed because the workspace is protected"
+	} else if s.skipIfFrozen(workspace.Name, OperationDestroy, "ttl", *expiry) ||
+		s.waitForDependencies(workspace, OperationDestroy) {
+		return false
+	} else {
+		logging.SetCorrelationID(workspace.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+		logging.LogWorkspaceOperation(workspace.Name, "TTL", "%s, destroying workspace", message)
+		if workspaceState.Status == StatusRunning {
+			s.state.SetWorkspaceRunResult(workspace.Name, RunResultTimedOut)
+		}
+		s.goOperation(func() { s.destroyWorkspace(workspace) })
+		return true
+	}
+
+	// Alert once per deploy
+	if workspaceState.TTLAlerted {
+		return false
+	}
+	s.state.UpdateWorkspace(workspace.Name, func(ws *WorkspaceState) { ws.TTLAlerted = true })
+	logging.LogWorkspaceOperation(workspace.Name, "TTL", "%s", message)
+	s.notifyLifetimeExceeded(workspace.Name, message)
+	return false
+}
+
+// formatTTL describes a workspace's ttl and when its deployment expires for status output
+func formatTTL(ttl time.Duration, state *WorkspaceState, now time.Time) string {
+	status := ttl.String()
+	if !hasDeployment(state.Status) {
+		return status
+	}
+	expiry := state.TTLExpiry(ttl)
+	if expiry == nil {
+		return status
+	}
+	if now.Before(*expiry) {
+		return fmt.Sprintf("%s, expires %s (in %v)", status, logging.FormatTime(*expiry), expiry.Sub(now).Round(time.Minute))
+	}
+	return fmt.Sprintf("%s, expired %s", status, logging.FormatTime(*expiry))
+}
+
+// notifyLifetimeExceeded sends an alert for a deployment that outlived max_lifetime
+func (s *Scheduler) notifyLifetimeExceeded(workspaceName, message string) {
+	if !s.notifier.Enabled() {
+		return
+	}
+
+	s.notifier.Send(notify.Notification{
+		Event:     notify.EventLifetimeExceeded,
+		Workspace: workspaceName,
+		Message:   message,
+		Channel:   s.notificationChannel(workspaceName),
+		LogFile:   s.getWorkspaceLogFile(workspaceName),
+	})
+}
@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxLifetimeDestroysOldDeployment(t *testing.T) {
+	// Monday, deployed weekly and never destroyed by a schedule
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("forgotten-env", `{"enabled": true, "deploy_schedule": "0 9 * * 1", "destroy_schedule": false, "max_lifetime": "24h"}`).start()
+
+	sc.runUntil(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	deployedAt := *sc.scheduler.state.GetWorkspaceState("forgotten-env").DeployedSince
+
+	// Redeploys keep the original deployment time
+	if err := sc.scheduler.ManualDeploy("forgotten-env"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+	sc.scheduler.operations.Wait()
+	if since := sc.scheduler.state.GetWorkspaceState("forgotten-env").DeployedSince; !since.Equal(deployedAt) {
+		t.Errorf("Expected redeploy to keep deployed_since %v, got %v", deployedAt, *since)
+	}
+
+	sc.runUntil(time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 09:00 deploy forgotten-env",
+		"2025-03-10 12:00 deploy forgotten-env",
+		"2025-03-11 09:00 destroy forgotten-env",
+	)
+	if workspaceState := sc.scheduler.state.GetWorkspaceState("forgotten-env"); workspaceState.DeployedSince != nil {
+		t.Error("Expected deployed_since to be cleared after destroy")
+	}
+}
+
+func TestMaxLifetimeAlertsOnce(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("forgotten-env", `{"enabled": true, "deploy_schedule": "0 9 * * 1", "destroy_schedule": false, "max_lifetime": "24h", "max_lifetime_action": "alert"}`).start()
+
+	sc.runUntil(time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-10 09:00 deploy forgotten-env")
+	workspaceState := sc.scheduler.state.GetWorkspaceState("forgotten-env")
+	if !workspaceState.LifetimeAlerted {
+		t.Error("Expected alert to be recorded")
+	}
+
+	if err := sc.scheduler.ManualDestroy("forgotten-env"); err != nil {
+		t.Fatalf("Manual destroy failed: %v", err)
+	}
+	sc.scheduler.operations.Wait()
+	if workspaceState.LifetimeAlerted {
+		t.Error("Expected alert flag to reset after destroy")
+	}
+}
+
+func TestDeploymentAgeFallsBackToLastDeployed(t *testing.T) {
+	deployed := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	workspaceState := &WorkspaceState{Status: StatusDeployed, LastDeployed: &deployed}
+
+	if age := workspaceState.DeploymentAge(deployed.Add(5 * time.Hour)); age != 5*time.Hour {
+		t.Errorf("Expected age of 5h from last_deployed, got %v", age)
+	}
+}
+
+func TestTTLCountsFromLastDeploy(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("forgotten-env", `{"enabled": true, "deploy_schedule": "0 9 * * 1", "destroy_schedule": false, "ttl": "4h"}`).start()
+
+	// A redeploy restarts the ttl
+	sc.runUntil(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	if err := sc.scheduler.ManualDeploy("forgotten-env"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+	sc.scheduler.operations.Wait()
+
+	sc.runUntil(time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC))
+	workspaceState := sc.scheduler.state.GetWorkspaceState("forgotten-env")
+	if status := formatTTL(4*time.Hour, workspaceState, sc.clock.Now()); !strings.Contains(status, "in 2h0m0s") {
+		t.Errorf("Expected the status to show the remaining ttl, got %q", status)
+	}
+
+	sc.runUntil(time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 09:00 deploy forgotten-env",
+		"2025-03-10 12:00 deploy forgotten-env",
+		"2025-03-10 16:00 destroy forgotten-env",
+	)
+}
+
+func TestTTLAlertsOnceForProtectedWorkspace(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("forgotten-env", `{"enabled": true, "deploy_schedule": "0 9 * * 1", "destroy_schedule": false, "ttl": "4h", "protected": true}`).start()
+
+	sc.runUntil(time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-10 09:00 deploy forgotten-env")
+	workspaceState := sc.scheduler.state.GetWorkspaceState("forgotten-env")
+	if !workspaceState.TTLAlerted {
+		t.Error("Expected the expired ttl to be alerted")
+	}
+
+	// The next deploy starts a new ttl
+	if err := sc.scheduler.ManualDeploy("forgotten-env"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+	sc.scheduler.operations.Wait()
+	if workspaceState.TTLAlerted {
+		t.Error("Expected the alert flag to reset after a deploy")
+	}
+}
+
+func TestRelativeDestroySchedule(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC))
+	sc.workspace("demo", `{"enabled": true, "deploy_schedule": "30 9 * * *", "destroy_schedule": "+8h"}`).start()
+
+	sc.runUntil(time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC))
+	workspaceState := sc.scheduler.state.GetWorkspaceState("demo")
+	deployed := *workspaceState.LastDeployed
+	if next := nextRelativeDestroy([]string{"+8h"}, workspaceState); next == nil || !next.Equal(deployed.Add(8*time.Hour)) {
+		t.Errorf("Expected the next destroy 8h after the deploy, got %v", next)
+	}
+
+	// A later deploy moves the destroy, which runs once per deploy
+	sc.runUntil(time.Date(2025, 7, 2, 15, 0, 0, 0, time.UTC))
+	if err := sc.scheduler.ManualDeploy("demo"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+	sc.scheduler.operations.Wait()
+	sc.runUntil(time.Date(2025, 7, 3, 9, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-07-01 09:30 deploy demo",
+		"2025-07-01 17:30 destroy demo",
+		"2025-07-02 09:30 deploy demo",
+		"2025-07-02 15:00 deploy demo",
+		"2025-07-02 23:00 destroy demo",
+	)
+}
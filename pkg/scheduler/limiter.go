@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"sort"
+
+	"provisioner/pkg/job"
+	"provisioner/pkg/logging"
+)
+
+// acquireOperationSlot blocks until the workspace may run a deploy or destroy under its
+// throttle buckets and max_concurrent_operations. While waiting the workspace is shown as queued.
+// The returned function releases the slots.
+func (s *Scheduler) acquireOperationSlot(workspaceName, operation string, throttle []string) func() {
+	// Buckets are always taken before the global slot and in name order, so operations
+	// waiting for each other's slots cannot deadlock
+	queued := false
+	markQueued := func() {
+		if !queued {
+			queued = true
+			s.state.SetWorkspaceQueued(workspaceName, operation)
+			_ = s.SaveState()
+		}
+	}
+
+	releaseBuckets := s.acquireThrottleBuckets(workspaceName, throttle, func(bucket string, limit int) {
+		markQueued()
+		logging.LogWorkspace(workspaceName, "Queued %s, waiting for one of %d slots in throttle bucket '%s'", operation, limit, bucket)
+	})
+
+	if s.operationSlots == nil {
+		return releaseBuckets
+	}
+
+	select {
+	case s.operationSlots <- struct{}{}:
+	default:
+		markQueued()
+		logging.LogWorkspace(workspaceName, "Queued %s, waiting for one of %d operation slots", operation, cap(s.operationSlots))
+
+		s.operationSlots <- struct{}{}
+	}
+
+	return func() {
+		<-s.operationSlots
+		releaseBuckets()
+	}
+}
+
+// acquireThrottleBuckets takes a slot in each named throttle bucket, calling onWait before blocking
+// on a full bucket. Unknown bucket names are logged and ignored. The returned function releases the slots.
+func (s *Scheduler) acquireThrottleBuckets(logName string, throttle []string, onWait func(bucket string, limit int)) func() {
+	if len(throttle) == 0 {
+		return func() {}
+	}
+
+	names := append([]string(nil), throttle...)
+	sort.Strings(names)
+
+	var held []chan struct{}
+	for i, name := range names {
+		if i > 0 && name == names[i-1] {
+			continue
+		}
+
+		bucket, exists := s.throttleBuckets[name]
+		if !exists {
+			logging.LogWorkspace(logName, "Throttle bucket '%s' is not defined in %s, ignoring", name, DaemonConfigFile)
+			continue
+		}
+
+		select {
+		case bucket <- struct{}{}:
+		default:
+			onWait(name, cap(bucket))
+			bucket <- struct{}{}
+		}
+		held = append(held, bucket)
+	}
+
+	return func() {
+		for _, bucket := range held {
+			<-bucket
+		}
+	}
+}
+
+// initJobThrottle makes the job manager wait for the throttle buckets of jobs that reference them
+func (s *Scheduler) initJobThrottle() {
+	if s.jobManager != nil && len(s.throttleBuckets) > 0 {
+		s.jobManager.SetThrottleHandler(s.acquireJobThrottle)
+	}
+}
+
+// acquireJobThrottle blocks until a job may run under its throttle buckets
+func (s *Scheduler) acquireJobThrottle(j *job.Job) func() {
+	return s.acquireThrottleBuckets(j.WorkspaceID, j.Throttle, func(bucket string, limit int) {
+		logging.LogWorkspace(j.WorkspaceID, "JOB %s: Waiting for one of %d slots in throttle bucket '%s'", j.Name, limit, bucket)
+	})
+}
+
+// recoverQueuedOperations resets workspaces left queued by a previous daemon to their actual
+// deployment status so their schedules trigger them again
+func (s *Scheduler) recoverQueuedOperations() {
+	if s.state == nil {
+		return
+	}
+
+	recovered := false
+	for _, ws := range s.loadedWorkspaces() {
+		workspaceState := s.state.Workspace(ws.Name)
+		if workspaceState.Status != StatusQueued {
+			continue
+		}
+
+		status := WorkspaceStatus(ws.GetDeploymentStatus())
+		logging.LogWorkspace(ws.Name, "Queued %s did not start before restart, resetting status to %s", workspaceState.QueuedOperation, status)
+		s.state.SetWorkspaceStatus(ws.Name, status)
+		recovered = true
+	}
+
+	if recovered {
+		_ = s.SaveState()
+	}
+}
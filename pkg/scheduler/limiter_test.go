@@ -0,0 +1,228 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"provisioner/pkg/job"
+	"provisioner/pkg/workspace"
+)
+
+func TestLoadDaemonConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := filepath.Join(tempDir, DaemonConfigFile)
+
+	config, err := LoadDaemonConfig(configPath)
+	if err != nil {
+		t.Fatalf("expected defaults for missing file, got error: %v", err)
+	}
+	if config.MaxConcurrentOperations != 0 {
+		t.Errorf("expected unlimited operations by default, got %d", config.MaxConcurrentOperations)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"max_concurrent_operations": 3}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	config, err = LoadDaemonConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if config.MaxConcurrentOperations != 3 {
+		t.Errorf("expected 3 concurrent operations, got %d", config.MaxConcurrentOperations)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"max_concurrent_operations": -1}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadDaemonConfig(configPath); err == nil {
+		t.Error("expected error for negative max_concurrent_operations")
+	}
+}
+
+func TestOperationsQueueForFreeSlot(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	scheduler.operationSlots = make(chan struct{}, 1)
+
+	first := busyApp(scheduler)
+	second := busyApp(scheduler)
+	second.Name = "waiting-app"
+	scheduler.workspaces = []workspace.Workspace{first, second}
+
+	// Create both state records up front so the goroutines only update existing entries
+	scheduler.state.GetWorkspaceState(first.Name)
+	scheduler.state.GetWorkspaceState(second.Name)
+
+	started := make(chan string, 2)
+	release := make(chan struct{})
+	mockClient.DeployFunc = func(ws *workspace.Workspace) error {
+		started <- ws.Name
+		if ws.Name == first.Name {
+			<-release
+		}
+		return nil
+	}
+
+	done := make(chan struct{}, 2)
+	go func() { scheduler.deployWorkspace(first); done <- struct{}{} }()
+	if name := <-started; name != first.Name {
+		t.Fatalf("expected %s to start first, got %s", first.Name, name)
+	}
+
+	go func() { scheduler.deployWorkspace(second); done <- struct{}{} }()
+	waitForStatus(t, scheduler, second.Name, StatusQueued)
+
+	secondState := scheduler.state.GetWorkspaceState(second.Name)
+	if secondState.QueuedOperation != OperationDeploy || !secondState.IsBusy() {
+		t.Errorf("expected queued deploy to count as busy, got %+v", secondState)
+	}
+
+	close(release)
+	if name := <-started; name != second.Name {
+		t.Fatalf("expected %s to start once the slot was free, got %s", second.Name, name)
+	}
+	<-done
+	<-done
+
+	if status := scheduler.state.GetWorkspaceState(second.Name).Status; status != StatusDeployed {
+		t.Errorf("expected queued workspace to be deployed, got %s", status)
+	}
+	if secondState.QueuedOperation != "" {
+		t.Errorf("expected queued operation to be cleared, got %q", secondState.QueuedOperation)
+	}
+	if len(scheduler.operationSlots) != 0 {
+		t.Errorf("expected all slots to be released, %d still held", len(scheduler.operationSlots))
+	}
+}
+
+func TestRecoverQueuedOperations(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	scheduler.state.SetWorkspaceQueued(ws.Name, OperationDeploy)
+	scheduler.recoverQueuedOperations()
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.Status != StatusDestroyed || workspaceState.QueuedOperation != "" {
+		t.Errorf("expected queued workspace without state to reset to destroyed, got %+v", workspaceState)
+	}
+}
+
+// waitForStatus polls until a workspace reaches the given status
+func waitForStatus(t *testing.T, scheduler *Scheduler, workspaceName string, status WorkspaceStatus) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if scheduler.state.Workspace(workspaceName).Status == status {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("workspace %s did not reach status %s", workspaceName, status)
+}
+
+func TestThrottleBucketQueuesOperations(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	scheduler.throttleBuckets = map[string]chan struct{}{"do-fra1": make(chan struct{}, 1)}
+
+	first := busyApp(scheduler)
+	first.Config.Throttle = []string{"do-fra1"}
+	second := busyApp(scheduler)
+	second.Name = "waiting-app"
+	second.Config.Throttle = []string{"do-fra1", "undefined-bucket"}
+	unrelated := busyApp(scheduler)
+	unrelated.Name = "other-region-app"
+	scheduler.workspaces = []workspace.Workspace{first, second, unrelated}
+
+	for _, ws := range scheduler.workspaces {
+		scheduler.state.GetWorkspaceState(ws.Name)
+	}
+
+	started := make(chan string, 3)
+	release := make(chan struct{})
+	mockClient.DeployFunc = func(ws *workspace.Workspace) error {
+		started <- ws.Name
+		if ws.Name == first.Name {
+			<-release
+		}
+		return nil
+	}
+
+	done := make(chan struct{}, 3)
+	go func() { scheduler.deployWorkspace(first); done <- struct{}{} }()
+	if name := <-started; name != first.Name {
+		t.Fatalf("expected %s to start first, got %s", first.Name, name)
+	}
+
+	go func() { scheduler.deployWorkspace(second); done <- struct{}{} }()
+	waitForStatus(t, scheduler, second.Name, StatusQueued)
+
+	// Workspaces outside the bucket are not held back
+	go func() { scheduler.deployWorkspace(unrelated); done <- struct{}{} }()
+	if name := <-started; name != unrelated.Name {
+		t.Fatalf("expected %s to start while the bucket is full, got %s", unrelated.Name, name)
+	}
+	<-done
+
+	close(release)
+	if name := <-started; name != second.Name {
+		t.Fatalf("expected %s to start once the bucket was free, got %s", second.Name, name)
+	}
+	<-done
+	<-done
+
+	if len(scheduler.throttleBuckets["do-fra1"]) != 0 {
+		t.Errorf("expected bucket slots to be released, %d still held", len(scheduler.throttleBuckets["do-fra1"]))
+	}
+}
+
+func TestThrottleBucketLimitsJobs(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	scheduler.throttleBuckets = map[string]chan struct{}{"do-fra1": make(chan struct{}, 1)}
+
+	releaseFirst := scheduler.acquireJobThrottle(&job.Job{Name: "snapshot", WorkspaceID: "busy-app", Throttle: []string{"do-fra1"}})
+
+	acquired := make(chan struct{})
+	go func() {
+		release := scheduler.acquireJobThrottle(&job.Job{Name: "cleanup", WorkspaceID: "busy-app", Throttle: []string{"do-fra1"}})
+		close(acquired)
+		release()
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("expected second job to wait for the bucket")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseFirst()
+	select {
+	case <-acquired:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected second job to run once the bucket was free")
+	}
+}
+
+func TestLoadDaemonConfigThrottleBuckets(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), DaemonConfigFile)
+
+	if err := os.WriteFile(configPath, []byte(`{"throttle_buckets": {"digitalocean-fra1": 2}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	config, err := LoadDaemonConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if config.ThrottleBuckets["digitalocean-fra1"] != 2 {
+		t.Errorf("expected bucket limit 2, got %v", config.ThrottleBuckets)
+	}
+
+	if err := os.WriteFile(configPath, []byte(`{"throttle_buckets": {"digitalocean-fra1": 0}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadDaemonConfig(configPath); err == nil {
+		t.Error("expected error for bucket without slots")
+	}
+}
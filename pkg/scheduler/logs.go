@@ -0,0 +1,218 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// DefaultLogLines is the number of trailing log lines shown when no limit is given
+const DefaultLogLines = 100
+
+const (
+	tailChunkSize  = 64 * 1024
+	followInterval = 500 * time.Millisecond
+)
+
+// LogOptions selects which part of a workspace log ShowLogs prints
+type LogOptions struct {
+	Lines  int           // Print at most this many trailing lines, 0 for the whole file
+	Since  time.Duration // Only print entries logged within this duration, 0 for no limit
+	Follow bool          // Keep printing lines as they are appended until interrupted
+}
+
+// tailOffset returns the offset of the first line to print: the start of the last `lines` lines,
+// moved forward past entries logged before `since`. It reads the file backwards in chunks so
+// large logs are not read in full. Lines without a timestamp (multi-line output) belong to the
+// entry before them and never stop the search.
+func tailOffset(f io.ReaderAt, size int64, lines int, since time.Time) (int64, error) {
+	offset := size
+	entryOffset := size // Start of the earliest accepted line with a timestamp
+	lineEnd := size
+	count := 0
+
+	// accept records the line [start, end) and reports whether to keep scanning backwards
+	accept := func(start, end int64) (bool, error) {
+		count++
+		if lines > 0 && count > lines {
+			return false, nil
+		}
+		if !since.IsZero() {
+			prefix := make([]byte, min(int64(len(logging.LogTimestampLayout)), end-start))
+			if _, err := f.ReadAt(prefix, start); err != nil && err != io.EOF {
+				return false, err
+			}
+			if ts, ok := parseLogTimestamp(string(prefix)); ok {
+				if ts.Before(since) {
+					// Continuation lines after an old entry belong to that entry
+					offset = entryOffset
+					return false, nil
+				}
+				entryOffset = start
+			}
+		}
+		offset = start
+		return true, nil
+	}
+
+	buf := make([]byte, tailChunkSize)
+	pos := size
+	for pos > 0 {
+		n := min(int64(tailChunkSize), pos)
+		pos -= n
+		if _, err := f.ReadAt(buf[:n], pos); err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		for i := n - 1; i >= 0; i-- {
+			if buf[i] != '\n' {
+				continue
+			}
+			newline := pos + i
+			if newline == size-1 {
+				// Trailing newline of the last line
+				lineEnd = newline
+				continue
+			}
+			keepGoing, err := accept(newline+1, lineEnd)
+			if err != nil || !keepGoing {
+				return offset, err
+			}
+			lineEnd = newline
+		}
+	}
+
+	if lineEnd > 0 {
+		if _, err := accept(0, lineEnd); err != nil {
+			return 0, err
+		}
+	}
+	return offset, nil
+}
+
+// parseLogTimestamp parses the timestamp prefix of a log line, accepting lines written
+// before timestamps carried an offset as server local time
+func parseLogTimestamp(prefix string) (time.Time, bool) {
+	if ts, err := time.Parse(logging.LogTimestampLayout, prefix); err == nil {
+		return ts, true
+	}
+	if len(prefix) < len(logging.LegacyLogTimestampLayout) {
+		return time.Time{}, false
+	}
+	ts, err := time.ParseInLocation(logging.LegacyLogTimestampLayout, prefix[:len(logging.LegacyLogTimestampLayout)], time.Local)
+	return ts, err == nil
+}
+
+// printLog writes the selected part of a log file to w and returns the offset printed up to
+func printLog(w io.Writer, path string, opts LogOptions, now time.Time) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = file.Close() }()
+
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	var since time.Time
+	if opts.Since > 0 {
+		since = now.Add(-opts.Since)
+	}
+
+	offset, err := tailOffset(file, info.Size(), opts.Lines, since)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := io.Copy(w, io.NewSectionReader(file, offset, info.Size()-offset)); err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// followLog writes lines appended to a log file after offset until ctx is done, including
+// those appended since the last check when it is done.
+// A log that shrinks or is replaced (rotation) is printed again from its start.
+func followLog(ctx context.Context, w io.Writer, path string, offset int64) error {
+	previous, _ := os.Stat(path)
+
+	ticker := time.NewTicker(followInterval)
+	defer ticker.Stop()
+
+	var err error
+	for {
+		select {
+		case <-ctx.Done():
+			_, _, err := copyAppended(w, path, offset, previous)
+			return err
+		case <-ticker.C:
+		}
+
+		if offset, previous, err = copyAppended(w, path, offset, previous); err != nil {
+			return err
+		}
+	}
+}
+
+// copyAppended writes what was appended to a log file after offset and returns the new offset
+// and the file's info. A log rotated away and not yet recreated is skipped.
+func copyAppended(w io.Writer, path string, offset int64, previous os.FileInfo) (int64, os.FileInfo, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return offset, previous, nil
+		}
+		return offset, previous, err
+	}
+	if info.Size() < offset || (previous != nil && !os.SameFile(previous, info)) {
+		offset = 0
+	}
+	if info.Size() == offset {
+		return offset, info, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return offset, info, err
+	}
+	written, err := io.Copy(w, io.NewSectionReader(file, offset, info.Size()-offset))
+	_ = file.Close()
+	if err != nil {
+		return offset, info, fmt.Errorf("failed to read log file: %w", err)
+	}
+	return offset + written, info, nil
+}
+
+// FollowLog prints what a workspace logs from now on to w until the returned function is
+// called, e.g. while a deploy started from the CLI runs. The function returns once the lines
+// logged until then are printed.
+func (s *Scheduler) FollowLog(w io.Writer, workspaceName string) func() error {
+	path := s.getWorkspaceLogFile(workspaceName)
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- followLog(ctx, w, path, offset) }()
+	return func() error {
+		cancel()
+		return <-done
+	}
+}
+
+// followLogUntilInterrupted follows a log file until the process receives SIGINT or SIGTERM
+func followLogUntilInterrupted(w io.Writer, path string, offset int64) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return followLog(ctx, w, path, offset)
+}
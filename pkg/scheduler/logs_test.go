@@ -0,0 +1,174 @@
+package scheduler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+func writeTestLog(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "my-app.log")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write log: %v", err)
+	}
+	return path
+}
+
+func TestPrintLogLines(t *testing.T) {
+	path := writeTestLog(t, "one\ntwo\nthree\nfour\n")
+
+	tests := []struct {
+		lines    int
+		expected string
+	}{
+		{2, "three\nfour\n"},
+		{4, "one\ntwo\nthree\nfour\n"},
+		{10, "one\ntwo\nthree\nfour\n"},
+		{0, "one\ntwo\nthree\nfour\n"},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if _, err := printLog(&buf, path, LogOptions{Lines: tt.lines}, time.Now()); err != nil {
+			t.Fatalf("printLog failed: %v", err)
+		}
+		if buf.String() != tt.expected {
+			t.Errorf("lines=%d: expected %q, got %q", tt.lines, tt.expected, buf.String())
+		}
+	}
+}
+
+func TestPrintLogLinesWithoutTrailingNewline(t *testing.T) {
+	path := writeTestLog(t, "one\ntwo\nthree")
+
+	var buf bytes.Buffer
+	if _, err := printLog(&buf, path, LogOptions{Lines: 2}, time.Now()); err != nil {
+		t.Fatalf("printLog failed: %v", err)
+	}
+	if buf.String() != "two\nthree" {
+		t.Errorf("expected last two lines, got %q", buf.String())
+	}
+}
+
+func TestPrintLogAcrossChunks(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 5000; i++ {
+		fmt.Fprintf(&content, "line %04d with some padding to cross chunk boundaries\n", i)
+	}
+	path := writeTestLog(t, content.String())
+
+	var buf bytes.Buffer
+	if _, err := printLog(&buf, path, LogOptions{Lines: 3000}, time.Now()); err != nil {
+		t.Fatalf("printLog failed: %v", err)
+	}
+	output := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(output) != 3000 || output[0] != "line 2000 with some padding to cross chunk boundaries" {
+		t.Errorf("expected lines 2000-4999, got %d lines starting with %q", len(output), output[0])
+	}
+}
+
+func TestPrintLogSince(t *testing.T) {
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.Local)
+	// Entries carry their offset, so a display timezone other than the server's still compares correctly
+	zone := time.FixedZone("UTC+5", 5*60*60)
+	stamp := func(ago time.Duration) string { return now.Add(-ago).In(zone).Format(logging.LogTimestampLayout) }
+	legacy := func(ago time.Duration) string { return now.Add(-ago).Format(logging.LegacyLogTimestampLayout) }
+
+	path := writeTestLog(t, legacy(3*time.Hour)+" Deploy failed\nDetailed output:\nError: old\n"+
+		stamp(30*time.Minute)+" Starting deployment\n"+
+		stamp(10*time.Minute)+" Apply failed\nError: new\n")
+
+	var buf bytes.Buffer
+	if _, err := printLog(&buf, path, LogOptions{Since: time.Hour}, now); err != nil {
+		t.Fatalf("printLog failed: %v", err)
+	}
+	expected := stamp(30*time.Minute) + " Starting deployment\n" + stamp(10*time.Minute) + " Apply failed\nError: new\n"
+	if buf.String() != expected {
+		t.Errorf("expected entries from the last hour, got %q", buf.String())
+	}
+
+	buf.Reset()
+	if _, err := printLog(&buf, path, LogOptions{Since: time.Minute}, now); err != nil {
+		t.Fatalf("printLog failed: %v", err)
+	}
+	if buf.String() != "" {
+		t.Errorf("expected no entries from the last minute, got %q", buf.String())
+	}
+}
+
+func TestFollowLog(t *testing.T) {
+	path := writeTestLog(t, "existing\n")
+
+	var buf bytes.Buffer
+	offset, err := printLog(&buf, path, LogOptions{}, time.Now())
+	if err != nil {
+		t.Fatalf("printLog failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	var followed bytes.Buffer
+	go func() { done <- followLog(ctx, &followed, path, offset) }()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	if _, err := file.WriteString("appended\n"); err != nil {
+		t.Fatalf("failed to append: %v", err)
+	}
+	_ = file.Close()
+	time.Sleep(3 * followInterval)
+
+	// Rotation replaces the file; the new file is followed from its start
+	if err := os.Remove(path); err != nil {
+		t.Fatalf("failed to remove log: %v", err)
+	}
+	if err := os.WriteFile(path, []byte("rotated\n"), 0644); err != nil {
+		t.Fatalf("failed to write rotated log: %v", err)
+	}
+	time.Sleep(3 * followInterval)
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("followLog failed: %v", err)
+	}
+	if followed.String() != "appended\nrotated\n" {
+		t.Errorf("expected appended and rotated lines, got %q", followed.String())
+	}
+}
+
+func TestFollowLogPrintsLinesBeforeStop(t *testing.T) {
+	path := writeTestLog(t, "existing\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	var followed bytes.Buffer
+	go func() { done <- followLog(ctx, &followed, path, int64(len("existing\n"))) }()
+
+	// Lines logged just before stopping, e.g. the last of a deploy, are not lost
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatalf("failed to open log: %v", err)
+	}
+	if _, err := file.WriteString("last\n"); err != nil {
+		t.Fatalf("failed to append: %v", err)
+	}
+	_ = file.Close()
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("followLog failed: %v", err)
+	}
+	if followed.String() != "last\n" {
+		t.Errorf("expected the last line, got %q", followed.String())
+	}
+}
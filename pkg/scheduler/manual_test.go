@@ -59,8 +59,8 @@ func TestManualDeploy(t *testing.T) {
 	}
 
 	// Verify deployment was called
-	if mockClient.DeployCallCount != 1 {
-		t.Errorf("Expected Deploy to be called once, got %d calls", mockClient.DeployCallCount)
+	if mockClient.GetDeployCallCount() != 1 {
+		t.Errorf("Expected Deploy to be called once, got %d calls", mockClient.GetDeployCallCount())
 	}
 	if len(mockClient.DeployCallWorkspaces) == 0 || mockClient.DeployCallWorkspaces[0].Name != workspaceName {
 		t.Errorf("Deploy was not called with correct workspace. Expected %s, got %v", workspaceName, mockClient.DeployCallWorkspaces)
@@ -129,8 +129,8 @@ func TestManualDestroy(t *testing.T) {
 	}
 
 	// Verify destruction was called
-	if mockClient.DestroyCallCount != 1 {
-		t.Errorf("Expected DestroyWorkspace to be called once, got %d calls", mockClient.DestroyCallCount)
+	if mockClient.GetDestroyCallCount() != 1 {
+		t.Errorf("Expected DestroyWorkspace to be called once, got %d calls", mockClient.GetDestroyCallCount())
 	}
 	if len(mockClient.DestroyCallWorkspaces) == 0 || mockClient.DestroyCallWorkspaces[0].Name != workspaceName {
 		t.Errorf("DestroyWorkspace was not called with correct workspace. Expected %s, got %v", workspaceName, mockClient.DestroyCallWorkspaces)
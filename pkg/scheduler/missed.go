@@ -0,0 +1,110 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"provisioner/pkg/cron"
+	"provisioner/pkg/logging"
+)
+
+// Policies for schedules that fired while the daemon was down (missed_schedule_policy)
+const (
+	MissedRunOnce = "run_once" // Run the latest missed deploy or destroy once on startup
+	MissedSkip    = "skip"     // Wait for the next time the schedule fires
+)
+
+// Schedule check intervals (tick_interval)
+const (
+	defaultTickInterval = time.Minute
+	minTickInterval     = 10 * time.Second
+)
+
+// parseTickInterval parses a tick_interval value, the default interval if empty
+func parseTickInterval(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultTickInterval, nil
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tick_interval '%s': %w", value, err)
+	}
+	if interval < minTickInterval {
+		return 0, fmt.Errorf("tick_interval must be at least %v: %s", minTickInterval, value)
+	}
+	return interval, nil
+}
+
+// tickInterval returns how often the daemon checks schedules
+func (s *Scheduler) tickInterval() time.Duration {
+	if s.daemonConfig == nil {
+		return defaultTickInterval
+	}
+	interval, err := parseTickInterval(s.daemonConfig.TickInterval)
+	if err != nil {
+		return defaultTickInterval
+	}
+	return interval
+}
+
+// missedSchedulePolicy returns the configured handling of schedules missed while the daemon was down
+func (s *Scheduler) missedSchedulePolicy() string {
+	if s.daemonConfig == nil || s.daemonConfig.MissedSchedulePolicy == "" {
+		return MissedRunOnce
+	}
+	return s.daemonConfig.MissedSchedulePolicy
+}
+
+// noteDowntime records the time since the last schedule check saved in the state as downtime on
+// the first check after the daemon started: schedules that fired in it were missed.
+func (s *Scheduler) noteDowntime(now time.Time) {
+	if s.lastCheck != nil || s.state == nil {
+		return
+	}
+	lastChecked := s.state.GetLastChecked()
+	if lastChecked == nil || !lastChecked.Before(now) {
+		return
+	}
+	s.downSince, s.downUntil = *lastChecked, now
+	if now.Sub(s.downSince) > 2*s.tickInterval() {
+		logging.LogSystemd("Schedules were not checked since %s, missed schedules: %s",
+			logging.FormatTime(s.downSince), s.missedSchedulePolicy())
+	}
+}
+
+// recordScheduleCheck remembers when schedules were last checked, in the state for the next start
+func (s *Scheduler) recordScheduleCheck(now time.Time) {
+	checked := now
+	s.lastCheck = &checked
+	if s.state != nil {
+		s.state.SetLastChecked(checked)
+	}
+}
+
+// lastScheduledRun returns the time a deploy or destroy schedule last fired that is still due at
+// now, or nil. That is its latest run today, one after the previous check when the check interval
+// spans midnight, or, on the first check with the run_once policy, the latest run missed while the
+// daemon was down. With the skip policy, runs missed while the daemon was down are never due.
+func (s *Scheduler) lastScheduledRun(schedule *cron.Schedule, now time.Time) *time.Time {
+	prev := schedule.PrevRun(now.Truncate(time.Second).Add(time.Second))
+	if prev == nil || prev.After(now) {
+		return nil
+	}
+
+	if !s.downSince.IsZero() && prev.After(s.downSince) && prev.Before(s.downUntil) {
+		if s.missedSchedulePolicy() == MissedSkip {
+			return nil
+		}
+		// Runs of earlier days are caught up on the first check only, so workspaces added later don't run them
+		if s.lastCheck == nil {
+			return prev
+		}
+	}
+	if run := schedule.LastRunToday(now); run != nil {
+		return run
+	}
+	if s.lastCheck != nil && prev.After(*s.lastCheck) {
+		return prev
+	}
+	return nil
+}
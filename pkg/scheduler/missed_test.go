@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMissedDestroyAcrossMidnightRunsOnce(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	lastChecked := time.Date(2025, 3, 3, 23, 50, 0, 0, time.Local)
+	scheduler.state.LastChecked = &lastChecked
+	deployed := time.Date(2025, 3, 3, 9, 0, 0, 0, time.Local)
+	workspaceState := &WorkspaceState{Status: StatusDeployed, LastDeployed: &deployed}
+	schedules := []string{"55 23 * * *"}
+
+	// Down over midnight, the 23:55 destroy was never run
+	now := time.Date(2025, 3, 4, 0, 10, 0, 0, time.Local)
+	scheduler.noteDowntime(now)
+	if !scheduler.ShouldRunDestroySchedule(schedules, now, workspaceState) {
+		t.Fatal("Expected the destroy missed while the daemon was down to run")
+	}
+	if due := scheduler.lastDueTime(schedules, now); !due.Equal(lastChecked.Add(5 * time.Minute)) {
+		t.Errorf("Expected the missed run to be due, got %v", due)
+	}
+
+	// Once destroyed, and deployed again by hand, the missed run is done
+	destroyed := now
+	workspaceState.LastDestroyed = &destroyed
+	scheduler.recordScheduleCheck(now)
+	if scheduler.ShouldRunDestroySchedule(schedules, now.Add(time.Minute), workspaceState) {
+		t.Error("Expected the missed destroy to run only once")
+	}
+	if scheduler.state.LastChecked == nil || !scheduler.state.LastChecked.Equal(now) {
+		t.Errorf("Expected the check to be recorded in the state, got %v", scheduler.state.LastChecked)
+	}
+}
+
+func TestMissedSchedulesSkipped(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	scheduler.daemonConfig = &DaemonConfig{MissedSchedulePolicy: MissedSkip}
+	lastChecked := time.Date(2025, 3, 4, 7, 30, 0, 0, time.Local)
+	scheduler.state.LastChecked = &lastChecked
+	workspaceState := &WorkspaceState{Status: StatusDestroyed}
+	schedules := []string{"0 8 * * *"}
+
+	now := time.Date(2025, 3, 4, 10, 0, 0, 0, time.Local)
+	scheduler.noteDowntime(now)
+	scheduler.recordScheduleCheck(now)
+	if scheduler.ShouldRunDeploySchedule(schedules, now, workspaceState) {
+		t.Error("Expected the deploy missed while the daemon was down to be skipped")
+	}
+	if scheduler.ShouldRunDeploySchedule(schedules, now.Add(time.Hour), workspaceState) {
+		t.Error("Expected the skipped deploy to stay skipped")
+	}
+
+	// The next time the schedule fires it runs as usual
+	tomorrow := time.Date(2025, 3, 5, 8, 0, 30, 0, time.Local)
+	if !scheduler.ShouldRunDeploySchedule(schedules, tomorrow, workspaceState) {
+		t.Error("Expected the next deploy to run")
+	}
+}
+
+func TestCheckIntervalSpanningMidnight(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	scheduler.daemonConfig = &DaemonConfig{TickInterval: "10m", MissedSchedulePolicy: MissedSkip}
+	workspaceState := &WorkspaceState{Status: StatusDeployed}
+	schedules := []string{"55 23 * * *"}
+
+	// Without a previous check only today's runs are due
+	now := time.Date(2025, 3, 4, 0, 2, 0, 0, time.Local)
+	if scheduler.ShouldRunDestroySchedule(schedules, now, workspaceState) {
+		t.Error("Expected yesterday's destroy not to be due on the first check")
+	}
+
+	// The 23:55 destroy fell between the 23:52 and 00:02 checks
+	scheduler.recordScheduleCheck(time.Date(2025, 3, 3, 23, 52, 0, 0, time.Local))
+	if !scheduler.ShouldRunDestroySchedule(schedules, now, workspaceState) {
+		t.Error("Expected the destroy between two checks to run after midnight")
+	}
+}
+
+func TestDaemonConfigScheduleSettings(t *testing.T) {
+	for _, config := range []DaemonConfig{
+		{TickInterval: "15s", MissedSchedulePolicy: MissedSkip},
+		{TickInterval: "5m", MissedSchedulePolicy: MissedRunOnce},
+	} {
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected %+v to be valid, got %v", config, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		config DaemonConfig
+		errMsg string
+	}{
+		{DaemonConfig{TickInterval: "1s"}, "at least"},
+		{DaemonConfig{TickInterval: "often"}, "invalid tick_interval"},
+		{DaemonConfig{MissedSchedulePolicy: "run_all"}, "missed_schedule_policy"},
+	} {
+		if err := tt.config.Validate(); err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+			t.Errorf("Expected error containing %q for %+v, got %v", tt.errMsg, tt.config, err)
+		}
+	}
+
+	scheduler := &Scheduler{daemonConfig: &DaemonConfig{TickInterval: "30s"}}
+	if interval := scheduler.tickInterval(); interval != 30*time.Second {
+		t.Errorf("Expected a 30s tick interval, got %v", interval)
+	}
+	if interval := (&Scheduler{}).tickInterval(); interval != time.Minute {
+		t.Errorf("Expected the default 1m tick interval, got %v", interval)
+	}
+}
@@ -1,10 +1,12 @@
 package scheduler
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"provisioner/pkg/opentofu"
 	"provisioner/pkg/workspace"
@@ -79,8 +81,8 @@ resource "null_resource" "test" {
 	}
 
 	// Verify mock client was called
-	if mockClient.DeployInModeCallCount != 1 {
-		t.Errorf("expected DeployInModeCallCount = 1, got %d", mockClient.DeployInModeCallCount)
+	if mockClient.GetDeployInModeCallCount() != 1 {
+		t.Errorf("expected DeployInModeCallCount = 1, got %d", mockClient.GetDeployInModeCallCount())
 	}
 
 	if len(mockClient.DeployInModeCalls) != 1 || mockClient.DeployInModeCalls[0] != "busy" {
@@ -105,8 +107,8 @@ resource "null_resource" "test" {
 	}
 
 	// Verify no additional calls to DeployInMode (should be idempotent)
-	if mockClient.DeployInModeCallCount != 0 {
-		t.Errorf("expected no additional DeployInMode calls for same mode, got %d", mockClient.DeployInModeCallCount)
+	if mockClient.GetDeployInModeCallCount() != 0 {
+		t.Errorf("expected no additional DeployInMode calls for same mode, got %d", mockClient.GetDeployInModeCallCount())
 	}
 
 	// Test deploy in different mode on new workspace to avoid confirmation prompt
@@ -373,3 +375,124 @@ func writeConfigFile(path string, config workspace.Config) error {
 
 	return os.WriteFile(path, []byte(data), 0644)
 }
+
+func TestScheduledMode(t *testing.T) {
+	modeSchedules := map[string][]string{
+		"busy":        {"0 8 * * 1-5"},
+		"hibernation": {"0 20 * * *", "0 12 * * 0,6"},
+	}
+
+	tests := []struct {
+		now      time.Time
+		expected string
+		at       time.Time
+	}{
+		// Monday noon: busy since 08:00
+		{time.Date(2026, 1, 5, 12, 0, 0, 0, time.Local), "busy", time.Date(2026, 1, 5, 8, 0, 0, 0, time.Local)},
+		// Monday evening: hibernation since 20:00
+		{time.Date(2026, 1, 5, 21, 0, 0, 0, time.Local), "hibernation", time.Date(2026, 1, 5, 20, 0, 0, 0, time.Local)},
+		// Tuesday early morning: still hibernation from the evening before
+		{time.Date(2026, 1, 6, 7, 0, 0, 0, time.Local), "hibernation", time.Date(2026, 1, 5, 20, 0, 0, 0, time.Local)},
+		// Exactly at the schedule time
+		{time.Date(2026, 1, 6, 8, 0, 0, 0, time.Local), "busy", time.Date(2026, 1, 6, 8, 0, 0, 0, time.Local)},
+		// Saturday afternoon: the weekend schedule of hibernation matched last
+		{time.Date(2026, 1, 10, 15, 0, 0, 0, time.Local), "hibernation", time.Date(2026, 1, 10, 12, 0, 0, 0, time.Local)},
+	}
+
+	for _, tt := range tests {
+		mode, at := scheduledMode(modeSchedules, tt.now)
+		if mode != tt.expected || at == nil || !at.Equal(tt.at) {
+			t.Errorf("At %v: expected %s since %v, got %s since %v", tt.now, tt.expected, tt.at, mode, at)
+		}
+	}
+
+	if mode, at := scheduledMode(map[string][]string{"busy": {"@deployment-completed"}}, time.Now()); mode != "" || at != nil {
+		t.Errorf("Expected no mode for event-based schedules, got %s since %v", mode, at)
+	}
+}
+
+func TestModeSchedulesSwitchMode(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+
+	// Busy matched two hours ago, hibernation one hour ago
+	now := time.Now()
+	busyAt := now.Add(-2 * time.Hour)
+	hibernationAt := now.Add(-time.Hour)
+	ws := workspace.Workspace{
+		Name: "mode-app",
+		Config: workspace.Config{
+			Enabled:  true,
+			Template: "web-app",
+			ModeSchedules: map[string]interface{}{
+				"busy":        fmt.Sprintf("%d %d * * *", busyAt.Minute(), busyAt.Hour()),
+				"hibernation": fmt.Sprintf("%d %d * * *", hibernationAt.Minute(), hibernationAt.Hour()),
+			},
+		},
+	}
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	// A workspace that was never deployed switches to the mode that matched last
+	scheduler.checkModeSchedules(ws, scheduler.state.GetWorkspaceState(ws.Name), now)
+	waitForStatus(t, scheduler, ws.Name, StatusDeployed)
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.DeploymentMode != "hibernation" || len(mockClient.DeployInModeCalls) != 1 || mockClient.DeployInModeCalls[0] != "hibernation" {
+		t.Fatalf("Expected deployment in hibernation mode, got mode %q with calls %v", workspaceState.DeploymentMode, mockClient.DeployInModeCalls)
+	}
+	if len(workspaceState.ModeHistory) != 1 || workspaceState.ModeHistory[0] != (ModeChange{To: "hibernation", At: workspaceState.ModeHistory[0].At, Trigger: ModeTriggerSchedule}) {
+		t.Errorf("Expected scheduled mode change in history, got %+v", workspaceState.ModeHistory)
+	}
+
+	// The same match is not acted on again
+	scheduler.checkModeSchedules(ws, workspaceState, now)
+	time.Sleep(50 * time.Millisecond)
+	if mockClient.GetDeployInModeCallCount() != 1 {
+		t.Errorf("Expected no further deploys while in the scheduled mode, got %d", mockClient.GetDeployInModeCallCount())
+	}
+
+	// An operator's mode choice stands until the next mode schedule matches
+	scheduler.deployWorkspaceInMode(ws, "busy", ModeTriggerManual)
+	scheduler.checkModeSchedules(ws, workspaceState, now)
+	time.Sleep(50 * time.Millisecond)
+	if mockClient.GetDeployInModeCallCount() != 2 || workspaceState.DeploymentMode != "busy" {
+		t.Errorf("Expected manual mode to stand, got mode %q after %d deploys", workspaceState.DeploymentMode, mockClient.GetDeployInModeCallCount())
+	}
+	if last := workspaceState.ModeHistory[len(workspaceState.ModeHistory)-1]; last.From != "hibernation" || last.To != "busy" || last.Trigger != ModeTriggerManual {
+		t.Errorf("Expected manual change from hibernation to busy, got %+v", last)
+	}
+
+	// The manual deploy happened before the latest match: the schedule switches the mode back
+	deployedBefore := now.Add(-90 * time.Minute)
+	workspaceState.LastDeployed = &deployedBefore
+	workspaceState.ModeScheduledAt = nil
+	scheduler.checkModeSchedules(ws, workspaceState, now)
+	waitForDeployInModeCalls(t, mockClient, 3)
+	waitForStatus(t, scheduler, ws.Name, StatusDeployed)
+	if workspaceState.DeploymentMode != "hibernation" || len(workspaceState.ModeHistory) != 3 {
+		t.Errorf("Expected switch back to hibernation, got mode %q with history %+v", workspaceState.DeploymentMode, workspaceState.ModeHistory)
+	}
+}
+
+func TestModeHistoryIsBounded(t *testing.T) {
+	state := NewState()
+	for i := 0; i < maxModeHistory+5; i++ {
+		state.RecordModeChange("mode-app", ModeChange{To: fmt.Sprintf("mode-%d", i), Trigger: ModeTriggerSchedule})
+	}
+
+	history := state.GetWorkspaceState("mode-app").ModeHistory
+	if len(history) != maxModeHistory || history[0].To != "mode-5" || history[len(history)-1].To != fmt.Sprintf("mode-%d", maxModeHistory+4) {
+		t.Errorf("Expected the latest %d mode changes, got %d starting with %s", maxModeHistory, len(history), history[0].To)
+	}
+}
+
+func waitForDeployInModeCalls(t *testing.T, mockClient *opentofu.MockTofuClient, expected int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if mockClient.GetDeployInModeCallCount() >= expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d deploy in mode calls", expected)
+}
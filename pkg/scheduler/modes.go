@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"provisioner/pkg/cron"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// Mode change triggers recorded in mode history
+const (
+	ModeTriggerSchedule = "schedule"
+	ModeTriggerManual   = "manual"
+	ModeTriggerIdle     = "idle" // Hibernation of an idle workspace
+)
+
+// maxModeHistory is the number of mode changes kept per workspace
+const maxModeHistory = 20
+
+// scheduledMode returns the mode whose schedule matched most recently at or before now and when
+// it matched, or "" if no mode schedule has matched. Ties go to the alphabetically first mode.
+func scheduledMode(modeSchedules map[string][]string, now time.Time) (string, *time.Time) {
+	modes := make([]string, 0, len(modeSchedules))
+	for mode := range modeSchedules {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+
+	var latestMode string
+	var latest *time.Time
+	for _, mode := range modes {
+		for _, scheduleStr := range modeSchedules[mode] {
+			schedule, err := cron.Parse(scheduleStr)
+			if err != nil {
+				logging.LogSystemd("Failed to parse schedule '%s' of mode '%s': %v", scheduleStr, mode, err)
+				continue
+			}
+			matched := schedule.PrevRun(now.Truncate(time.Second).Add(time.Second))
+			if matched != nil && (latest == nil || matched.After(*latest)) {
+				latestMode, latest = mode, matched
+			}
+		}
+	}
+	return latestMode, latest
+}
+
+// checkModeSchedules switches a mode-scheduled workspace to the mode whose schedule matched most
+// recently. A match is acted on once; a deploy or destroy after it, e.g. an operator choosing
+// another mode, stands until the next mode schedule matches.
+func (s *Scheduler) checkModeSchedules(workspace workspace.Workspace, workspaceState *WorkspaceState, now time.Time) {
+	modeSchedules, err := workspace.Config.GetModeSchedules()
+	if err != nil {
+		logging.LogWorkspace(workspace.Name, "Invalid mode schedule: %v", err)
+		return
+	}
+
+	mode, matchedAt := scheduledMode(modeSchedules, now)
+	if mode == "" {
+		return
+	}
+	if workspaceState.Status == StatusDeployed && workspaceState.DeploymentMode == mode {
+		return
+	}
+	if workspaceState.ModeScheduledAt != nil && !workspaceState.ModeScheduledAt.Before(*matchedAt) {
+		return // Already switched, failed or retrying for this match
+	}
+	for _, operatorAction := range []*time.Time{workspaceState.LastDeployed, workspaceState.LastDestroyed} {
+		if operatorAction != nil && operatorAction.After(*matchedAt) {
+			return
+		}
+	}
+	if workspaceState.wasStopped(OperationDeploy) {
+		return
+	}
+
+	if s.skipIfFrozen(workspace.Name, "deploy in mode "+mode, "mode schedule", *matchedAt) ||
+		s.waitForDependencies(workspace, OperationDeploy) {
+		return
+	}
+
+	if workspace.Config.RequiresApproval() {
+		s.requestApproval(workspace, workspaceState, OperationDeploy, mode, *matchedAt, now)
+		return
+	}
+
+	s.state.UpdateWorkspace(workspace.Name, func(ws *WorkspaceState) { ws.ModeScheduledAt = matchedAt })
+	logging.SetCorrelationID(workspace.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+	logging.LogWorkspace(workspace.Name, "Mode schedule of '%s' matched at %s, triggering deployment in mode %s",
+		mode, logging.FormatTime(*matchedAt), mode)
+	s.goOperation(func() { s.deployWorkspaceInMode(workspace, mode, ModeTriggerSchedule) })
+}
+
+// formatModeSchedules formats mode schedules for display, e.g. "busy: 0 8 * * 1-5; hibernation: 0 20 * * *"
+func formatModeSchedules(modeSchedules map[string][]string) string {
+	modes := make([]string, 0, len(modeSchedules))
+	for mode := range modeSchedules {
+		modes = append(modes, mode)
+	}
+	sort.Strings(modes)
+
+	parts := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		parts = append(parts, fmt.Sprintf("%s: %s", mode, strings.Join(modeSchedules[mode], ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// formatModeChange formats a mode history entry for display
+func formatModeChange(change ModeChange) string {
+	from := change.From
+	if from == "" {
+		from = "(not deployed)"
+	}
+	return fmt.Sprintf("%s  %s -> %s (%s)", logging.FormatTime(change.At), from, change.To, change.Trigger)
+}
@@ -0,0 +1,92 @@
+package scheduler
+
+import (
+	"provisioner/pkg/events"
+	"provisioner/pkg/job"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
+)
+
+// standaloneWorkspaceID is the workspace ID used for standalone jobs
+const standaloneWorkspaceID = "_standalone_"
+
+// initNotifier creates the notifier from the notifications section of provisioner.json, or from
+// notifications.json if provisioner.json has none, and publishes finished jobs on the event bus
+func (s *Scheduler) initNotifier() {
+	defer s.initJobFinishedHandler()
+
+	var notifier *notify.Notifier
+	if s.daemonConfig != nil && s.daemonConfig.Notifications != nil {
+		notifier = notify.NewWithConfig(s.daemonConfig.Notifications)
+	} else {
+		var err error
+		if notifier, err = notify.New(s.configDir); err != nil {
+			logging.LogSystemd("Notifications disabled: %v", err)
+			return
+		}
+	}
+	s.notifier = notifier
+}
+
+// initJobFinishedHandler publishes finished job runs on the event bus
+func (s *Scheduler) initJobFinishedHandler() {
+	if s.jobManager != nil {
+		s.jobManager.SetJobFinishedHandler(s.jobFinished)
+	}
+}
+
+// notifyOperation sends a notification for a deploy or destroy outcome
+func (s *Scheduler) notifyOperation(event, workspaceName, mode, errMsg string) {
+	if !s.notifier.Enabled() {
+		return
+	}
+
+	s.notifier.Send(notify.Notification{
+		Event:         event,
+		Workspace:     workspaceName,
+		Mode:          mode,
+		Error:         stripANSIColors(errMsg),
+		CorrelationID: logging.CorrelationID(workspaceName),
+		Channel:       s.notificationChannel(workspaceName),
+		LogFile:       s.getWorkspaceLogFile(workspaceName),
+	})
+}
+
+// notificationChannel returns the notification channel of a workspace, empty for standalone jobs
+func (s *Scheduler) notificationChannel(workspaceName string) string {
+	if ws := s.findWorkspace(workspaceName); ws != nil {
+		return ws.Config.NotificationChannel
+	}
+	return ""
+}
+
+// notifyJobFinished sends a notification when a job fails or times out, unless its on_failure
+// policy keeps failures quiet
+func (s *Scheduler) notifyJobFinished(event events.Event) {
+	if event.JobStatus != string(job.JobStatusFailed) && event.JobStatus != string(job.JobStatusTimeout) {
+		return
+	}
+	if event.OnFailure != "" && event.OnFailure != job.OnFailureNotify {
+		return
+	}
+
+	// Standalone jobs have no workspace but log to their own file
+	workspaceName := event.Workspace
+	if workspaceName == standaloneWorkspaceID {
+		workspaceName = ""
+	}
+	errMsg := ""
+	if event.Err != nil {
+		errMsg = event.Err.Error()
+	}
+
+	s.notifier.Send(notify.Notification{
+		Event:         notify.EventJobFailed,
+		Workspace:     workspaceName,
+		Job:           event.Job,
+		Error:         errMsg,
+		CorrelationID: event.CorrelationID,
+		Channel:       s.notificationChannel(workspaceName),
+		LogFile:       s.getWorkspaceLogFile(event.Workspace),
+	})
+}
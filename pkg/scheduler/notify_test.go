@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"provisioner/pkg/notify"
+	"provisioner/pkg/opentofu"
+)
+
+func TestNotificationsFromDaemonConfig(t *testing.T) {
+	received := make(chan notify.Notification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notify.Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Failed to decode payload: %v", err)
+		}
+		received <- n
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", tempDir)
+	t.Setenv("PROVISIONER_CONFIG_DIR", tempDir)
+
+	daemonConfig := fmt.Sprintf(`{
+		"notifications": {
+			"webhooks": [{"url": %q, "events": ["deploy_succeeded"]}],
+			"templates": {"deploy_succeeded": "{{.Workspace}} is live"}
+		}
+	}`, server.URL)
+	if err := os.WriteFile(filepath.Join(tempDir, DaemonConfigFile), []byte(daemonConfig), 0644); err != nil {
+		t.Fatalf("Failed to write daemon config: %v", err)
+	}
+	// provisioner.json takes precedence over notifications.json
+	if err := os.WriteFile(filepath.Join(tempDir, "notifications.json"), []byte(`{"webhooks": [{"url": "http://127.0.0.1:1"}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write notifications.json: %v", err)
+	}
+
+	scheduler := NewWithClient(opentofu.NewMockTofuClient())
+	scheduler.statePath = filepath.Join(t.TempDir(), "scheduler.json")
+	scheduler.notifyOperation(notify.EventDeploySucceeded, "web", "", "")
+
+	n := <-received
+	if n.Event != notify.EventDeploySucceeded || n.Text != "web is live" {
+		t.Errorf("Expected templated deploy notification, got %+v", n)
+	}
+}
+
+func TestLoadDaemonConfigNotifications(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), DaemonConfigFile)
+
+	if err := os.WriteFile(configPath, []byte(`{"notifications": {"email": [{"smtp_host": "smtp.example.com"}]}}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadDaemonConfig(configPath); err == nil {
+		t.Error("expected error for email destination without recipients")
+	}
+}
@@ -0,0 +1,196 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// ScheduleOperation schedules a one-shot deploy or destroy of a workspace at the given time, e.g. for
+// an ad-hoc demo, without editing its schedules. It replaces an earlier one-shot of the same
+// operation and is cleared once it ran. Deploys of mode-scheduled workspaces require a mode.
+// requestedBy is recorded as the actor of the operation in the audit log.
+func (s *Scheduler) ScheduleOperation(workspaceName, operation, mode string, at time.Time, requestedBy string) error {
+	ws := s.GetWorkspace(workspaceName)
+	if ws == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+	if !ws.Config.Enabled {
+		return fmt.Errorf("workspace '%s' is disabled, scheduled operations only run for enabled workspaces", workspaceName)
+	}
+
+	now := s.currentTime()
+	if !at.After(now) {
+		return fmt.Errorf("scheduled time %s is not in the future", logging.FormatTime(at))
+	}
+
+	switch operation {
+	case OperationDeploy:
+		if err := checkScheduledMode(ws, mode); err != nil {
+			return err
+		}
+	case OperationDestroy:
+		if mode != "" {
+			return fmt.Errorf("destroys take no mode")
+		}
+		if ws.Config.IsProtected() {
+			return fmt.Errorf("workspace '%s' is protected, destroy it with 'workspacectl destroy %s --force' instead", workspaceName, workspaceName)
+		}
+	default:
+		return fmt.Errorf("unknown operation '%s'", operation)
+	}
+
+	op := ScheduledOperation{Operation: operation, Mode: mode, At: at, RequestedAt: now, RequestedBy: requestedBy}
+	s.state.ScheduleOperation(workspaceName, op)
+	logging.LogWorkspaceOperation(workspaceName, "SCHEDULE", "Scheduled %s at %s", describeScheduledOperation(op), logging.FormatTime(at))
+	return s.SaveState()
+}
+
+// checkScheduledMode checks the mode of a scheduled deploy against the workspace's schedules
+func checkScheduledMode(ws *workspace.Workspace, mode string) error {
+	if len(ws.Config.ModeSchedules) == 0 {
+		if mode != "" {
+			return fmt.Errorf("workspace '%s' uses traditional scheduling and takes no mode", ws.Name)
+		}
+		return nil
+	}
+
+	modeSchedules, err := ws.Config.GetModeSchedules()
+	if err != nil {
+		return err
+	}
+	modes := make([]string, 0, len(modeSchedules))
+	for available := range modeSchedules {
+		if available == mode {
+			return nil
+		}
+		modes = append(modes, available)
+	}
+	sort.Strings(modes)
+	if mode == "" {
+		return fmt.Errorf("workspace '%s' uses mode scheduling, specify one of the modes %s", ws.Name, strings.Join(modes, ", "))
+	}
+	return fmt.Errorf("mode '%s' not available for workspace '%s'. Available modes: %s", mode, ws.Name, strings.Join(modes, ", "))
+}
+
+// UnscheduleOperation drops the one-shot operations of a workspace, only those of operation if set
+func (s *Scheduler) UnscheduleOperation(workspaceName, operation string) error {
+	if s.GetWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+
+	removed := s.state.UnscheduleOperation(workspaceName, operation)
+	if len(removed) == 0 {
+		if operation != "" {
+			return fmt.Errorf("workspace '%s' has no scheduled %s", workspaceName, operation)
+		}
+		return fmt.Errorf("workspace '%s' has no scheduled operations", workspaceName)
+	}
+	for _, op := range removed {
+		logging.LogWorkspaceOperation(workspaceName, "SCHEDULE", "Unscheduled %s at %s", describeScheduledOperation(op), logging.FormatTime(op.At))
+	}
+	return s.SaveState()
+}
+
+// runScheduledOperation runs a due one-shot operation and clears it once it starts. When several
+// are due, e.g. after the daemon was down, only the latest runs. Returns true if no other schedule
+// may run.
+func (s *Scheduler) runScheduledOperation(ws workspace.Workspace, workspaceState *WorkspaceState, now time.Time) bool {
+	due := workspaceState.DueScheduledOperations(now)
+	if len(due) == 0 {
+		return false
+	}
+	op := due[len(due)-1]
+	if s.waitForDependencies(ws, op.Operation) {
+		return true
+	}
+
+	// Frozen or environment-protected workspaces keep the operation, it runs once that is lifted
+	if s.skipIfFrozen(ws.Name, op.Operation, "scheduled with --at", op.At) {
+		return true
+	}
+	if op.Operation == OperationDestroy {
+		if protectedBy, isProtected := s.isWorkspaceProtectedByEnvironment(ws.Name); isProtected {
+			logging.LogWorkspace(ws.Name, "Destroy scheduled at %s postponed, the workspace is assigned to environment '%s'", logging.FormatTime(op.At), protectedBy)
+			return false
+		}
+	}
+
+	for _, done := range due {
+		s.state.UnscheduleOperation(ws.Name, done.Operation)
+	}
+	for _, superseded := range due[:len(due)-1] {
+		logging.LogWorkspace(ws.Name, "Skipping %s scheduled at %s, superseded by the %s scheduled at %s",
+			describeScheduledOperation(superseded), logging.FormatTime(superseded.At), op.Operation, logging.FormatTime(op.At))
+	}
+
+	switch op.Operation {
+	case OperationDestroy:
+		if workspaceState.Status == StatusDestroyed {
+			logging.LogWorkspace(ws.Name, "Destroy scheduled at %s skipped, the workspace is already destroyed", logging.FormatTime(op.At))
+			return false
+		}
+		logging.SetCorrelationID(ws.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+		logging.LogWorkspace(ws.Name, "Running destroy scheduled at %s", logging.FormatTime(op.At))
+		s.goOperation(func() {
+			defer s.beginTrigger(ws.Name, scheduledOperationTrigger(op))()
+			s.destroyWorkspace(ws)
+		})
+	case OperationDeploy:
+		logging.SetCorrelationID(ws.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+		logging.LogWorkspace(ws.Name, "Running %s scheduled at %s", describeScheduledOperation(op), logging.FormatTime(op.At))
+		s.goOperation(func() {
+			defer s.beginTrigger(ws.Name, scheduledOperationTrigger(op))()
+			if op.Mode != "" {
+				s.deployWorkspaceInMode(ws, op.Mode, ModeTriggerManual)
+			} else {
+				s.deployWorkspace(ws)
+			}
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+// scheduledOperationTrigger returns the audit log trigger of a one-shot operation: the user who
+// scheduled it, or the schedule for operations scheduled before the user was recorded
+func scheduledOperationTrigger(op ScheduledOperation) audit.Trigger {
+	if op.RequestedBy == "" {
+		return audit.Trigger{Source: audit.SourceSchedule}
+	}
+	return audit.Trigger{Source: audit.SourceCLI, Actor: op.RequestedBy}
+}
+
+// nextScheduledOperation returns the time of the one-shot operation, nil if it isn't scheduled
+func nextScheduledOperation(state *WorkspaceState, operation string) *time.Time {
+	for _, op := range state.ScheduledOperations {
+		if op.Operation == operation {
+			at := op.At
+			return &at
+		}
+	}
+	return nil
+}
+
+// describeScheduledOperation names a one-shot operation for logs and status output
+func describeScheduledOperation(op ScheduledOperation) string {
+	if op.Mode != "" {
+		return fmt.Sprintf("%s in mode %s", op.Operation, op.Mode)
+	}
+	return op.Operation
+}
+
+// formatScheduledOperations describes the one-shot operations of a workspace for status output
+func formatScheduledOperations(ops []ScheduledOperation) string {
+	parts := make([]string, len(ops))
+	for i, op := range ops {
+		parts[i] = fmt.Sprintf("%s at %s", describeScheduledOperation(op), logging.FormatTime(op.At))
+	}
+	return strings.Join(parts, ", ")
+}
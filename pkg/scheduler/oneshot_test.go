@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScheduleOperation(t *testing.T) {
+	// Saturday, outside the office hours schedules
+	sc := newScenario(t, time.Date(2025, 3, 8, 8, 0, 0, 0, time.UTC))
+	sc.workspace("demo", scenarioOfficeHours).start()
+
+	deployAt := time.Date(2025, 3, 8, 10, 0, 0, 0, time.UTC)
+	destroyAt := time.Date(2025, 3, 8, 17, 0, 0, 0, time.UTC)
+	if err := sc.scheduler.ScheduleOperation("demo", OperationDestroy, "", destroyAt, "alice"); err != nil {
+		t.Fatalf("Scheduling the destroy failed: %v", err)
+	}
+	if err := sc.scheduler.ScheduleOperation("demo", OperationDeploy, "", deployAt.Add(-time.Hour), "alice"); err != nil {
+		t.Fatalf("Scheduling the deploy failed: %v", err)
+	}
+	// Scheduling again moves the deploy
+	if err := sc.scheduler.ScheduleOperation("demo", OperationDeploy, "", deployAt, "alice"); err != nil {
+		t.Fatalf("Rescheduling the deploy failed: %v", err)
+	}
+
+	state := sc.scheduler.state.GetWorkspaceState("demo")
+	if len(state.ScheduledOperations) != 2 || state.ScheduledOperations[0].Operation != OperationDeploy {
+		t.Fatalf("Expected the deploy and then the destroy to be scheduled, got %+v", state.ScheduledOperations)
+	}
+	if next := nextScheduledOperation(state, OperationDeploy); next == nil || !next.Equal(deployAt) {
+		t.Errorf("Expected the one-shot deploy at %v, got %v", deployAt, next)
+	}
+
+	// They come before the office hours schedules of Monday
+	summary := sc.scheduler.summarizeWorkspace(*sc.scheduler.GetWorkspace("demo"), state, sc.clock.Now())
+	if summary.NextDeploy == nil || !summary.NextDeploy.Equal(deployAt) || summary.NextDestroy == nil || !summary.NextDestroy.Equal(destroyAt) {
+		t.Errorf("Expected the next deploy at %v and destroy at %v, got %v and %v", deployAt, destroyAt, summary.NextDeploy, summary.NextDestroy)
+	}
+
+	// Scheduled operations survive a restart and are cleared once they ran
+	sc.downFor(time.Minute)
+	sc.runUntil(time.Date(2025, 3, 8, 20, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-08 10:00 deploy demo",
+		"2025-03-08 17:00 destroy demo",
+	)
+	if ops := sc.scheduler.state.GetWorkspaceState("demo").ScheduledOperations; len(ops) != 0 {
+		t.Errorf("Expected the scheduled operations to be cleared, got %+v", ops)
+	}
+}
+
+func TestScheduleOperationRunsLatestDue(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 8, 8, 0, 0, 0, time.UTC))
+	sc.workspace("demo", scenarioOfficeHours).start()
+
+	if err := sc.scheduler.ScheduleOperation("demo", OperationDestroy, "", time.Date(2025, 3, 8, 9, 0, 0, 0, time.UTC), "alice"); err != nil {
+		t.Fatalf("Scheduling the destroy failed: %v", err)
+	}
+	if err := sc.scheduler.ScheduleOperation("demo", OperationDeploy, "", time.Date(2025, 3, 8, 10, 0, 0, 0, time.UTC), "alice"); err != nil {
+		t.Fatalf("Scheduling the deploy failed: %v", err)
+	}
+
+	// Both were due while the daemon was down, only the later deploy runs
+	sc.downFor(3 * time.Hour)
+	sc.run(time.Minute)
+	sc.expectOperations("2025-03-08 11:00 deploy demo")
+	if ops := sc.scheduler.state.GetWorkspaceState("demo").ScheduledOperations; len(ops) != 0 {
+		t.Errorf("Expected the scheduled operations to be cleared, got %+v", ops)
+	}
+}
+
+func TestScheduleOperationValidation(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 8, 8, 0, 0, 0, time.UTC))
+	sc.workspace("demo", scenarioOfficeHours).
+		workspace("modes", `{"enabled": true, "mode_schedules": {"busy": "0 8 * * 1-5", "hibernation": "0 20 * * 1-5"}}`).
+		workspace("off", `{"enabled": false, "deploy_schedule": "0 9 * * 1-5"}`).
+		start()
+	later := time.Date(2025, 3, 8, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		workspace, operation, mode string
+		at                         time.Time
+	}{
+		{"missing", OperationDeploy, "", later},
+		{"off", OperationDeploy, "", later},
+		{"demo", OperationDeploy, "", time.Date(2025, 3, 8, 7, 0, 0, 0, time.UTC)},
+		{"demo", OperationDeploy, "busy", later},
+		{"demo", OperationDestroy, "busy", later},
+		{"demo", "refresh", "", later},
+		{"modes", OperationDeploy, "", later},
+		{"modes", OperationDeploy, "idle", later},
+	}
+	for _, tt := range tests {
+		if err := sc.scheduler.ScheduleOperation(tt.workspace, tt.operation, tt.mode, tt.at, "alice"); err == nil {
+			t.Errorf("Expected scheduling %s %q of %s at %v to fail", tt.operation, tt.mode, tt.workspace, tt.at)
+		}
+	}
+
+	if err := sc.scheduler.ScheduleOperation("modes", OperationDeploy, "busy", later, "alice"); err != nil {
+		t.Fatalf("Scheduling a deploy in mode busy failed: %v", err)
+	}
+	if err := sc.scheduler.UnscheduleOperation("modes", OperationDestroy); err == nil {
+		t.Error("Expected unscheduling a destroy that isn't scheduled to fail")
+	}
+	if err := sc.scheduler.UnscheduleOperation("modes", ""); err != nil {
+		t.Errorf("Unscheduling failed: %v", err)
+	}
+	if ops := sc.scheduler.state.GetWorkspaceState("modes").ScheduledOperations; len(ops) != 0 {
+		t.Errorf("Expected no scheduled operations, got %+v", ops)
+	}
+}
+
+func TestScheduleOperationKeptWhileFrozen(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 8, 8, 0, 0, 0, time.UTC))
+	sc.workspace("demo", scenarioOfficeHours).start()
+
+	deployAt := time.Date(2025, 3, 8, 10, 0, 0, 0, time.UTC)
+	if err := sc.scheduler.ScheduleOperation("demo", OperationDeploy, "", deployAt, "alice"); err != nil {
+		t.Fatalf("Scheduling the deploy failed: %v", err)
+	}
+	if err := sc.scheduler.FreezeWorkspace("demo", "release demo"); err != nil {
+		t.Fatalf("Freeze failed: %v", err)
+	}
+
+	// The frozen deploy is recorded as skipped and stays scheduled
+	sc.runUntil(time.Date(2025, 3, 8, 11, 0, 0, 0, time.UTC))
+	sc.expectOperations()
+	state := sc.scheduler.state.GetWorkspaceState("demo")
+	if len(state.SkippedWhileFrozen) != 1 || state.SkippedWhileFrozen[0].Operation != OperationDeploy || !state.SkippedWhileFrozen[0].DueAt.Equal(deployAt) {
+		t.Errorf("Expected the scheduled deploy to be recorded as skipped once, got %+v", state.SkippedWhileFrozen)
+	}
+	if len(state.ScheduledOperations) != 1 {
+		t.Fatalf("Expected the deploy to stay scheduled while frozen, got %+v", state.ScheduledOperations)
+	}
+
+	// It runs once the workspace is unfrozen
+	if err := sc.scheduler.UnfreezeWorkspace("demo"); err != nil {
+		t.Fatalf("Unfreeze failed: %v", err)
+	}
+	sc.run(time.Minute)
+	sc.expectOperations("2025-03-08 11:00 deploy demo")
+	if ops := sc.scheduler.state.GetWorkspaceState("demo").ScheduledOperations; len(ops) != 0 {
+		t.Errorf("Expected the scheduled deploy to be cleared, got %+v", ops)
+	}
+}
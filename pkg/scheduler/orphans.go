@@ -0,0 +1,160 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/paths"
+)
+
+// Orphan is what a workspace left behind after its directory was removed from workspaces/
+type Orphan struct {
+	Name       string          `json:"name"`
+	Status     WorkspaceStatus `json:"status,omitempty"` // Status of its entry in scheduler.json, empty without one
+	Deployment bool            `json:"deployment"`       // Its deployment directory still exists
+	Resources  int             `json:"resources"`        // Managed resources still recorded in its deployment state
+	Logs       []string        `json:"logs,omitempty"`   // Its log file and debug log directory
+}
+
+// Leftovers describes what the orphan left behind, e.g. "3 resources, deployment, logs"
+func (o Orphan) Leftovers() string {
+	var parts []string
+	if o.Resources > 0 {
+		parts = append(parts, fmt.Sprintf("%d resources", o.Resources))
+	}
+	if o.Deployment {
+		parts = append(parts, "deployment")
+	}
+	if o.Status != "" {
+		parts = append(parts, "state entry")
+	}
+	if len(o.Logs) > 0 {
+		parts = append(parts, "logs")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FindOrphans returns the state entries, deployment directories and logs of workspaces that no
+// longer exist in workspaces/. A workspace whose config is invalid still has its directory and is
+// not an orphan. Names starting with "_", such as the standalone jobs' "_standalone_", are internal.
+func (s *Scheduler) FindOrphans() ([]Orphan, error) {
+	orphans := make(map[string]*Orphan)
+	orphan := func(name string) *Orphan {
+		if strings.HasPrefix(name, "_") || s.GetWorkspace(name) != nil {
+			return nil
+		}
+		if _, err := os.Stat(filepath.Join(s.configDir, "workspaces", name)); !os.IsNotExist(err) {
+			return nil
+		}
+		if orphans[name] == nil {
+			orphans[name] = &Orphan{Name: name}
+		}
+		return orphans[name]
+	}
+
+	if s.state != nil {
+		for name, state := range s.state.WorkspaceStates() {
+			if o := orphan(name); o != nil {
+				o.Status = state.Status
+			}
+		}
+	}
+
+	deployments, err := opentofu.ListDeployments()
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range deployments {
+		if o := orphan(name); o != nil {
+			o.Deployment = true
+			o.Resources = opentofu.DeploymentResources(name)
+		}
+	}
+
+	logDir := paths.LogDir()
+	logFiles, _ := filepath.Glob(filepath.Join(logDir, "*.log"))
+	for _, path := range logFiles {
+		if o := orphan(strings.TrimSuffix(filepath.Base(path), ".log")); o != nil {
+			o.Logs = append(o.Logs, path)
+		}
+	}
+	debugDirs, _ := os.ReadDir(filepath.Join(logDir, "debug"))
+	for _, entry := range debugDirs {
+		if !entry.IsDir() {
+			continue
+		}
+		if o := orphan(entry.Name()); o != nil {
+			o.Logs = append(o.Logs, filepath.Join(logDir, "debug", entry.Name()))
+		}
+	}
+
+	result := make([]Orphan, 0, len(orphans))
+	for _, o := range orphans {
+		result = append(result, *o)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
+
+// PruneOrphan destroys the resources an orphan left deployed, then removes its deployment
+// directory, history, state backups and logs. Its state entry is removed too if removeState is
+// set; the caller saves the state. If the destroy fails nothing is removed.
+func (s *Scheduler) PruneOrphan(orphan Orphan, removeState bool) error {
+	if state, exists := s.state.WorkspaceStates()[orphan.Name]; exists && state.IsBusy() {
+		return fmt.Errorf("workspace '%s' is %s, prune it once the operation finished", orphan.Name, state.Status)
+	}
+
+	// Messages go to the daemon log: the workspace's own log is about to be removed
+	if orphan.Resources > 0 {
+		if s.client == nil {
+			client, err := opentofu.New()
+			if err != nil {
+				return fmt.Errorf("failed to initialize OpenTofu client: %w", err)
+			}
+			s.client = client
+		}
+		logging.LogSystemd("PRUNE: Destroying %d resources left by removed workspace %s", orphan.Resources, orphan.Name)
+		if err := opentofu.DestroyDeployment(s.client, orphan.Name); err != nil {
+			return fmt.Errorf("failed to destroy resources of workspace '%s': %w", orphan.Name, err)
+		}
+	}
+
+	if _, err := opentofu.RemoveDeployment(orphan.Name); err != nil {
+		return err
+	}
+	for _, path := range orphan.Logs {
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", path, err)
+		}
+	}
+	if removeState && orphan.Status != "" {
+		s.state.RemoveWorkspaceState(orphan.Name)
+	}
+
+	logging.LogSystemd("PRUNE: Removed what workspace %s left behind", orphan.Name)
+	return nil
+}
+
+// reportOrphans logs the workspaces removed while the daemon was not watching that left
+// something behind, so they can be pruned with workspacectl prune
+func (s *Scheduler) reportOrphans() {
+	orphans, err := s.FindOrphans()
+	if err != nil {
+		logging.LogSystemd("Failed to look for orphaned workspaces: %v", err)
+		return
+	}
+	for _, orphan := range orphans {
+		if orphan.Resources > 0 {
+			logging.LogSystemd("Workspace %s was removed but %d of its resources are still deployed, run 'workspacectl prune' to destroy them",
+				orphan.Name, orphan.Resources)
+		} else {
+			logging.LogSystemd("Workspace %s was removed but left %s behind, run 'workspacectl prune' to clean up",
+				orphan.Name, orphan.Leftovers())
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"provisioner/pkg/opentofu"
+)
+
+const orphanTestState = `{"resources": [{"mode": "managed", "type": "null_resource", "name": "web", "instances": [{}, {}]}]}`
+
+// orphanApp is the only configured workspace of the orphan tests
+const orphanApp = `{"enabled": true, "deploy_schedule": "0 9 * * *", "destroy_schedule": false}`
+
+// leaveOrphans adds the leftovers of removed workspaces to a started scenario: "deployed" with
+// resources in its deployment, "logged" with only a log file and "stale" with only a state entry
+func leaveOrphans(sc *scenario) {
+	t := sc.t
+	t.Helper()
+	logDir := t.TempDir()
+	t.Setenv("PROVISIONER_LOG_DIR", logDir)
+
+	mkdirTest(t, sc.dir, "deployments", "app")
+	mkdirTest(t, sc.dir, "deployments", "_standalone_")
+	writeTestFile(t, filepath.Join(mkdirTest(t, sc.dir, "deployments", "deployed"), "terraform.tfstate"), orphanTestState)
+	mkdirTest(t, sc.dir, "history", "deployed")
+	for _, name := range []string{"app", "logged", "_standalone_", "deployed"} {
+		writeTestFile(t, filepath.Join(logDir, name+".log"), "log\n")
+	}
+
+	sc.scheduler.state.SetWorkspaceStatus("app", StatusDeployed)
+	sc.scheduler.state.SetWorkspaceStatus("deployed", StatusDeployed)
+	sc.scheduler.state.SetWorkspaceStatus("stale", StatusDestroyed)
+}
+
+func mkdirTest(t *testing.T, elem ...string) string {
+	t.Helper()
+	dir := filepath.Join(elem...)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", dir, err)
+	}
+	return dir
+}
+
+func TestFindOrphans(t *testing.T) {
+	sc := newScenario(t, time.Now()).workspace("app", orphanApp).start()
+	leaveOrphans(sc)
+	s := sc.scheduler
+
+	orphans, err := s.FindOrphans()
+	if err != nil {
+		t.Fatalf("FindOrphans() error: %v", err)
+	}
+	if len(orphans) != 3 {
+		t.Fatalf("Expected deployed, logged and stale to be orphans, got %+v", orphans)
+	}
+
+	deployed, logged, stale := orphans[0], orphans[1], orphans[2]
+	if deployed.Name != "deployed" || !deployed.Deployment || deployed.Resources != 2 || deployed.Status != StatusDeployed || len(deployed.Logs) != 1 {
+		t.Errorf("Unexpected orphan %+v", deployed)
+	}
+	if deployed.Leftovers() != "2 resources, deployment, state entry, logs" {
+		t.Errorf("Unexpected leftovers %q", deployed.Leftovers())
+	}
+	if logged.Name != "logged" || logged.Deployment || logged.Status != "" || len(logged.Logs) != 1 {
+		t.Errorf("Unexpected orphan %+v", logged)
+	}
+	if stale.Name != "stale" || stale.Status != StatusDestroyed || stale.Deployment || len(stale.Logs) != 0 {
+		t.Errorf("Unexpected orphan %+v", stale)
+	}
+}
+
+func TestPruneOrphanDestroysAndRemoves(t *testing.T) {
+	sc := newScenario(t, time.Now()).workspace("app", orphanApp)
+	mockClient := sc.mock()
+	s := sc.start().scheduler
+	leaveOrphans(sc)
+	orphans, _ := s.FindOrphans()
+
+	for _, orphan := range orphans {
+		if err := s.PruneOrphan(orphan, true); err != nil {
+			t.Fatalf("PruneOrphan(%s) error: %v", orphan.Name, err)
+		}
+	}
+
+	deploymentDir := opentofu.GetWorkingDir("deployed")
+	if mockClient.DestroyDirCallCount != 1 || mockClient.DestroyDirCallDirs[0] != deploymentDir {
+		t.Errorf("Expected one destroy in %s, got %v", deploymentDir, mockClient.DestroyDirCallDirs)
+	}
+	for _, path := range []string{deploymentDir, opentofu.GetHistoryDir(os.Getenv("PROVISIONER_STATE_DIR"), "deployed"), orphans[0].Logs[0], orphans[1].Logs[0]} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+	for _, name := range []string{"deployed", "stale"} {
+		if _, exists := s.state.Workspaces[name]; exists {
+			t.Errorf("Expected the state entry of %s to be removed", name)
+		}
+	}
+
+	// Leftovers of existing and internal workspaces are kept
+	if !opentofu.WorkingDirExists("app") || !opentofu.WorkingDirExists("_standalone_") {
+		t.Error("Expected the deployments of app and _standalone_ to be kept")
+	}
+	if _, exists := s.state.Workspaces["app"]; !exists {
+		t.Error("Expected the state entry of app to be kept")
+	}
+	if orphans, _ := s.FindOrphans(); len(orphans) != 0 {
+		t.Errorf("Expected no orphans after pruning, got %+v", orphans)
+	}
+}
+
+func TestPruneOrphanKeepsEverythingWhenDestroyFails(t *testing.T) {
+	sc := newScenario(t, time.Now()).workspace("app", orphanApp)
+	mockClient := sc.mock()
+	s := sc.start().scheduler
+	leaveOrphans(sc)
+	mockClient.DestroyDirFunc = func(string) error { return errors.New("provider unavailable") }
+	orphans, _ := s.FindOrphans()
+
+	if err := s.PruneOrphan(orphans[0], true); err == nil {
+		t.Fatal("Expected the failed destroy to be reported")
+	}
+	if !opentofu.WorkingDirExists("deployed") {
+		t.Error("Expected the deployment to be kept")
+	}
+	if _, err := os.Stat(orphans[0].Logs[0]); err != nil {
+		t.Error("Expected the log to be kept")
+	}
+	if _, exists := s.state.Workspaces["deployed"]; !exists {
+		t.Error("Expected the state entry to be kept")
+	}
+}
+
+func TestPruneOrphanSkipsBusyWorkspace(t *testing.T) {
+	sc := newScenario(t, time.Now()).workspace("app", orphanApp)
+	mockClient := sc.mock()
+	s := sc.start().scheduler
+	leaveOrphans(sc)
+	s.state.SetWorkspaceStatus("deployed", StatusDestroying)
+	orphans, _ := s.FindOrphans()
+
+	if err := s.PruneOrphan(orphans[0], true); err == nil {
+		t.Fatal("Expected a busy workspace not to be pruned")
+	}
+	if mockClient.DestroyDirCallCount != 0 || !opentofu.WorkingDirExists("deployed") {
+		t.Error("Expected the busy workspace's deployment to be left alone")
+	}
+}
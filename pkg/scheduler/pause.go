@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"provisioner/pkg/logging"
+)
+
+// PauseWorkspace stops the scheduler from running a workspace's scheduled operations until it is
+// resumed, without touching its config. Unlike a freeze, manual operations still run.
+func (s *Scheduler) PauseWorkspace(workspaceName string) error {
+	if s.GetWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
+	if workspaceState.PausedSince != nil {
+		return fmt.Errorf("workspace '%s' is already paused since %s", workspaceName, logging.FormatTime(*workspaceState.PausedSince))
+	}
+
+	now := s.currentTime()
+	s.state.UpdateWorkspace(workspaceName, func(workspaceState *WorkspaceState) { workspaceState.PausedSince = &now })
+	logging.LogWorkspaceOperation(workspaceName, "PAUSE", "Scheduling paused")
+	if workspaceState.IsBusy() {
+		logging.LogWorkspace(workspaceName, "The running %s is not affected by the pause", workspaceState.ActiveOperation())
+	}
+
+	return s.SaveState()
+}
+
+// ResumeWorkspace resumes a paused workspace's scheduled operations. Schedules that fired today
+// while paused run on the next check, as after a daemon restart.
+func (s *Scheduler) ResumeWorkspace(workspaceName string) error {
+	if s.GetWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
+	if workspaceState.PausedSince == nil {
+		return fmt.Errorf("workspace '%s' is not paused", workspaceName)
+	}
+
+	logging.LogWorkspaceOperation(workspaceName, "PAUSE", "Scheduling resumed after %v",
+		s.currentTime().Sub(*workspaceState.PausedSince).Round(time.Minute))
+	s.state.UpdateWorkspace(workspaceName, func(workspaceState *WorkspaceState) { workspaceState.PausedSince = nil })
+	if s.state.GetPausedSince() != nil {
+		logging.LogWorkspace(workspaceName, "Scheduling of all workspaces is still paused")
+	}
+
+	return s.SaveState()
+}
+
+// PauseAll stops the scheduler from running scheduled operations of any workspace until
+// ResumeAll. Workspaces paused individually stay paused after ResumeAll.
+func (s *Scheduler) PauseAll() error {
+	if pausedSince := s.state.GetPausedSince(); pausedSince != nil {
+		return fmt.Errorf("scheduling is already paused since %s", logging.FormatTime(*pausedSince))
+	}
+
+	now := s.currentTime()
+	s.state.SetPausedSince(&now)
+	logging.LogSystemd("Scheduling paused for all workspaces")
+
+	return s.SaveState()
+}
+
+// ResumeAll lifts PauseAll
+func (s *Scheduler) ResumeAll() error {
+	pausedSince := s.state.GetPausedSince()
+	if pausedSince == nil {
+		return fmt.Errorf("scheduling is not paused")
+	}
+
+	logging.LogSystemd("Scheduling resumed for all workspaces after %v",
+		s.currentTime().Sub(*pausedSince).Round(time.Minute))
+	s.state.SetPausedSince(nil)
+
+	return s.SaveState()
+}
+
+// IsWorkspacePaused reports whether a workspace's scheduled operations are paused, on their own
+// or together with all workspaces
+func (s *Scheduler) IsWorkspacePaused(workspaceName string) bool {
+	if s.state == nil {
+		return false
+	}
+	return s.state.GetPausedSince() != nil || s.state.Workspace(workspaceName).PausedSince != nil
+}
+
+// formatPaused describes since when a workspace's scheduled operations are paused, or "" if they aren't
+func (s *Scheduler) formatPaused(workspaceState *WorkspaceState) string {
+	if workspaceState.PausedSince != nil {
+		return fmt.Sprintf("since %s (scheduled operations skipped)", logging.FormatTime(*workspaceState.PausedSince))
+	}
+	if pausedSince := s.state.GetPausedSince(); pausedSince != nil {
+		return fmt.Sprintf("since %s (all workspaces, scheduled operations skipped)", logging.FormatTime(*pausedSince))
+	}
+	return ""
+}
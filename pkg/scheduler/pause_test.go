@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPauseWorkspace(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", scenarioOfficeHours).workspace("db", scenarioOfficeHours).start()
+
+	if err := sc.scheduler.PauseWorkspace("app"); err != nil {
+		t.Fatalf("PauseWorkspace failed: %v", err)
+	}
+	if err := sc.scheduler.PauseWorkspace("app"); err == nil {
+		t.Error("Expected pausing a paused workspace to fail")
+	}
+
+	// The paused workspace skips its deploy, the other one is scheduled as usual
+	sc.runUntil(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-10 09:00 deploy db")
+
+	// The pause survives a restart, and resuming catches up today's deploy
+	sc.downFor(time.Minute)
+	if !sc.scheduler.IsWorkspacePaused("app") {
+		t.Fatal("Expected app to stay paused after a restart")
+	}
+	if err := sc.scheduler.ResumeWorkspace("app"); err != nil {
+		t.Fatalf("ResumeWorkspace failed: %v", err)
+	}
+	sc.run(time.Minute)
+	sc.expectOperations("2025-03-10 10:01 deploy app")
+
+	// Manual operations still run while paused
+	if err := sc.scheduler.PauseWorkspace("app"); err != nil {
+		t.Fatalf("PauseWorkspace failed: %v", err)
+	}
+	if err := sc.scheduler.ManualDestroy("app"); err != nil {
+		t.Fatalf("Manual destroy of a paused workspace failed: %v", err)
+	}
+	sc.scheduler.operations.Wait()
+	sc.expectOperations("2025-03-10 10:02 destroy app")
+}
+
+func TestPauseAll(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", scenarioOfficeHours).workspace("db", scenarioOfficeHours).start()
+
+	if err := sc.scheduler.PauseWorkspace("app"); err != nil {
+		t.Fatalf("PauseWorkspace failed: %v", err)
+	}
+	if err := sc.scheduler.PauseAll(); err != nil {
+		t.Fatalf("PauseAll failed: %v", err)
+	}
+	sc.runUntil(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	sc.expectOperations()
+
+	// Resuming all workspaces leaves the one paused on its own paused
+	if err := sc.scheduler.ResumeAll(); err != nil {
+		t.Fatalf("ResumeAll failed: %v", err)
+	}
+	if err := sc.scheduler.ResumeAll(); err == nil {
+		t.Error("Expected resuming without a pause to fail")
+	}
+	sc.run(time.Minute)
+	sc.expectOperations("2025-03-10 10:00 deploy db")
+	if !sc.scheduler.IsWorkspacePaused("app") {
+		t.Error("Expected app to stay paused")
+	}
+}
@@ -0,0 +1,97 @@
+package scheduler
+
+import (
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// PendingOperationTTL is how long a queued follow-up operation stays valid
+const PendingOperationTTL = 2 * time.Hour
+
+// Operations that can be queued while a workspace is busy
+const (
+	OperationDeploy  = "deploy"
+	OperationDestroy = "destroy"
+)
+
+// queueFollowUpOperation queues a schedule that fired while the workspace was busy
+// so it runs when the current operation completes instead of being skipped
+func (s *Scheduler) queueFollowUpOperation(workspace workspace.Workspace, workspaceState *WorkspaceState, now time.Time) {
+	if workspaceState.PendingOperation != nil {
+		return
+	}
+
+	switch workspaceState.ActiveOperation() {
+	case OperationDeploy:
+		destroySchedules, err := workspace.Config.GetDestroySchedules()
+		if err != nil || len(destroySchedules) == 0 {
+			return
+		}
+		// Destroys awaiting approval are requested once the workspace is idle again
+		if _, isProtected := s.isWorkspaceProtectedByEnvironment(workspace.Name); isProtected || workspace.Config.IsProtected() || workspace.Config.RequiresApproval() {
+			return
+		}
+		if s.ShouldRunDestroySchedule(destroySchedules, now, workspaceState) {
+			s.state.QueuePendingOperation(workspace.Name, OperationDestroy, now, PendingOperationTTL)
+			logging.LogWorkspace(workspace.Name, "Destroy schedule fired while deploying, queued until deployment completes")
+		}
+	case OperationDestroy:
+		// Deploys awaiting approval are requested once the workspace is idle again
+		deploySchedules, err := workspace.Config.GetDeploySchedules()
+		if err != nil || workspace.Config.RequiresApproval() {
+			return
+		}
+		if s.ShouldRunDeploySchedule(deploySchedules, now, workspaceState) {
+			s.state.QueuePendingOperation(workspace.Name, OperationDeploy, now, PendingOperationTTL)
+			logging.LogWorkspace(workspace.Name, "Deploy schedule fired while destroying, queued until destruction completes")
+		}
+	}
+}
+
+// runPendingOperation executes a queued follow-up operation once the workspace is idle.
+// Returns true if an operation was run.
+func (s *Scheduler) runPendingOperation(workspace workspace.Workspace) bool {
+	workspaceState := s.state.Workspace(workspace.Name)
+	if workspaceState.IsBusy() {
+		return false
+	}
+
+	pending := workspaceState.PendingOperation
+	if pending == nil {
+		return false
+	}
+
+	op := s.state.TakePendingOperation(workspace.Name, s.currentTime())
+	if op == nil {
+		logging.LogWorkspace(workspace.Name, "Queued %s expired at %s, dropping it",
+			pending.Operation, logging.FormatTime(pending.ExpiresAt))
+		return false
+	}
+	if s.skipIfFrozen(workspace.Name, op.Operation, "queued while busy", op.QueuedAt) {
+		return false
+	}
+
+	switch op.Operation {
+	case OperationDestroy:
+		if workspaceState.Status == StatusDestroyed {
+			return false
+		}
+		// The follow-up is a separate operation with its own correlation ID
+		logging.SetCorrelationID(workspace.Name, logging.NewCorrelationID(s.currentTime()))
+		logging.LogWorkspace(workspace.Name, "Running queued destroy (queued at %s)", logging.FormatTime(op.QueuedAt))
+		s.destroyWorkspace(workspace)
+	case OperationDeploy:
+		if hasDeployment(workspaceState.Status) {
+			return false
+		}
+		logging.SetCorrelationID(workspace.Name, logging.NewCorrelationID(s.currentTime()))
+		logging.LogWorkspace(workspace.Name, "Running queued deploy (queued at %s)", logging.FormatTime(op.QueuedAt))
+		s.deployWorkspace(workspace)
+	default:
+		return false
+	}
+
+	return true
+}
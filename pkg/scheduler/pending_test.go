@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestDestroyQueuedWhileDeploying(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+
+	// Destroy schedule fires every minute so it has always passed at now
+	ws.Config.DestroySchedule = "* * * * *"
+	scheduler.workspaces = []workspace.Workspace{ws}
+	now := time.Now().Truncate(time.Minute).Add(30 * time.Second)
+
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDeploying)
+	scheduler.checkWorkspaceSchedules(ws, now)
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.PendingOperation == nil || workspaceState.PendingOperation.Operation != OperationDestroy {
+		t.Fatalf("expected queued destroy, got %+v", workspaceState.PendingOperation)
+	}
+	if mockClient.GetDestroyCallCount() != 0 {
+		t.Fatalf("expected no destroy while deploying, got %d", mockClient.GetDestroyCallCount())
+	}
+
+	// Completing the deployment runs the queued destroy
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDestroyed)
+	scheduler.deployWorkspace(ws)
+
+	if mockClient.GetDestroyCallCount() != 1 {
+		t.Errorf("expected queued destroy to run after deploy, got %d destroy calls", mockClient.GetDestroyCallCount())
+	}
+	if workspaceState := scheduler.state.GetWorkspaceState(ws.Name); workspaceState.PendingOperation != nil {
+		t.Errorf("expected pending operation to be cleared, got %+v", workspaceState.PendingOperation)
+	}
+}
+
+func TestPendingOperationExpires(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDeployed)
+	scheduler.state.QueuePendingOperation(ws.Name, OperationDestroy, time.Now().Add(-3*time.Hour), PendingOperationTTL)
+
+	if scheduler.runPendingOperation(ws) {
+		t.Error("expected expired operation not to run")
+	}
+	if mockClient.GetDestroyCallCount() != 0 {
+		t.Errorf("expected no destroy calls, got %d", mockClient.GetDestroyCallCount())
+	}
+	if scheduler.state.GetWorkspaceState(ws.Name).PendingOperation != nil {
+		t.Error("expected expired operation to be dropped")
+	}
+}
+
+func TestPendingOperationSkippedWhenAlreadyDone(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDestroyed)
+	scheduler.state.QueuePendingOperation(ws.Name, OperationDestroy, time.Now(), PendingOperationTTL)
+
+	if scheduler.runPendingOperation(ws) {
+		t.Error("expected destroy of an already destroyed workspace to be skipped")
+	}
+	if mockClient.GetDestroyCallCount() != 0 {
+		t.Errorf("expected no destroy calls, got %d", mockClient.GetDestroyCallCount())
+	}
+}
+
+func TestPendingOperationNotRunWhileBusy(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	ws := busyApp(scheduler)
+
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDestroying)
+	scheduler.state.QueuePendingOperation(ws.Name, OperationDeploy, time.Now(), PendingOperationTTL)
+
+	if scheduler.runPendingOperation(ws) {
+		t.Error("expected no operation to run while busy")
+	}
+	if scheduler.state.GetWorkspaceState(ws.Name).PendingOperation == nil {
+		t.Error("expected pending operation to remain queued")
+	}
+}
@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"errors"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/events"
+	"provisioner/pkg/job"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
+)
+
+// operationEvent describes how the scheduler reacts to the outcome of a deploy or destroy
+type operationEvent struct {
+	operation    string              // Audited operation
+	notification string              // Notification event, empty to send none
+	jobTrigger   DeploymentEventType // Event triggering jobs, empty to trigger none
+}
+
+var operationEvents = map[events.Type]operationEvent{
+	events.WorkspaceDeployed:  {audit.OperationDeploy, notify.EventDeploySucceeded, EventDeploymentCompleted},
+	events.DeployFailed:       {audit.OperationDeploy, notify.EventDeployFailed, EventDeploymentFailed},
+	events.DeployCancelled:    {audit.OperationDeploy, "", ""},
+	events.DeployDegraded:     {audit.OperationDeploy, notify.EventDeployDegraded, ""},
+	events.WorkspaceDestroyed: {audit.OperationDestroy, notify.EventDestroySucceeded, EventDestroyCompleted},
+	events.DestroyFailed:      {audit.OperationDestroy, notify.EventDestroyFailed, EventDestroyFailed},
+	events.DestroyCancelled:   {audit.OperationDestroy, "", ""},
+}
+
+var operationEventTypes = []events.Type{
+	events.WorkspaceDeployed, events.DeployFailed, events.DeployCancelled, events.DeployDegraded,
+	events.WorkspaceDestroyed, events.DestroyFailed, events.DestroyCancelled,
+}
+
+// Events returns the scheduler's event bus. Subscribers run on the goroutine of the operation
+// that published the event, after the scheduler's own reactions.
+func (s *Scheduler) Events() *events.Bus {
+	s.busOnce.Do(func() {
+		s.bus = events.NewBus()
+		s.subscribeReactions(s.bus)
+	})
+	return s.bus
+}
+
+// subscribeReactions subscribes the scheduler's own reactions to events. The audit log is written
+// first and notifications are sent once the other reactions have run.
+func (s *Scheduler) subscribeReactions(bus *events.Bus) {
+	bus.Subscribe(s.auditEvent, operationEventTypes...)
+	bus.Subscribe(s.recordSuccessRate, events.WorkspaceDeployed, events.DeployFailed, events.DeployDegraded, events.JobCompleted)
+	bus.Subscribe(s.triggerJobs, events.WorkspaceDeployed, events.DeployFailed, events.WorkspaceDestroyed, events.DestroyFailed)
+	bus.Subscribe(s.notifyEvent, append(operationEventTypes, events.JobCompleted)...)
+	bus.Subscribe(s.recordModeChange, events.ModeChanged)
+	bus.Subscribe(s.markJobConfigModified, events.ConfigReloaded)
+}
+
+// publishOperation publishes the outcome of a deploy or destroy of a workspace
+func (s *Scheduler) publishOperation(operation, workspaceName, mode string, err error) {
+	eventType := events.WorkspaceDeployed
+	switch {
+	case operation == OperationDestroy && isCancelled(err):
+		eventType = events.DestroyCancelled
+	case operation == OperationDestroy && err != nil:
+		eventType = events.DestroyFailed
+	case operation == OperationDestroy:
+		eventType = events.WorkspaceDestroyed
+	case isCancelled(err):
+		eventType = events.DeployCancelled
+	case isDegraded(err):
+		eventType = events.DeployDegraded
+	case err != nil:
+		eventType = events.DeployFailed
+	}
+
+	s.Events().Publish(events.Event{
+		Type:          eventType,
+		Time:          s.currentTime(),
+		Workspace:     workspaceName,
+		Mode:          mode,
+		CorrelationID: logging.CorrelationID(workspaceName),
+		Err:           err,
+	})
+}
+
+// jobFinished publishes a finished job run
+func (s *Scheduler) jobFinished(execution *job.JobExecution) {
+	event := events.Event{
+		Type:          events.JobCompleted,
+		Time:          s.currentTime(),
+		Workspace:     execution.WorkspaceID,
+		Job:           execution.JobName,
+		JobStatus:     string(execution.Status),
+		OnFailure:     execution.OnFailure,
+		CorrelationID: execution.CorrelationID,
+	}
+	if execution.Error != "" {
+		event.Err = errors.New(execution.Error)
+	}
+	s.Events().Publish(event)
+}
+
+// auditEvent records the outcome of a deploy or destroy in the audit log
+func (s *Scheduler) auditEvent(event events.Event) {
+	s.auditOperation(operationEvents[event.Type].operation, event.Workspace, event.Mode, event.Err)
+}
+
+// triggerJobs runs the workspace's jobs scheduled on the outcome of a deploy or destroy
+func (s *Scheduler) triggerJobs(event events.Event) {
+	deploymentEvent := NewDeploymentEventWithMode(operationEvents[event.Type].jobTrigger, event.Workspace, event.Mode)
+	if event.Err != nil {
+		deploymentEvent.Error = event.Err.Error()
+	}
+	s.triggerJobEvent(event.Workspace, deploymentEvent)
+}
+
+// notifyEvent sends the notification of a deploy or destroy outcome or a failed job run
+func (s *Scheduler) notifyEvent(event events.Event) {
+	if !s.notifier.Enabled() {
+		return
+	}
+
+	if event.Type == events.JobCompleted {
+		s.notifyJobFinished(event)
+		return
+	}
+
+	if notification := operationEvents[event.Type].notification; notification != "" {
+		errMsg := ""
+		if event.Err != nil {
+			errMsg = event.Err.Error()
+		}
+		s.notifyOperation(notification, event.Workspace, event.Mode, errMsg)
+	}
+}
+
+// recordModeChange adds a mode change to the workspace's mode history
+func (s *Scheduler) recordModeChange(event events.Event) {
+	s.state.RecordModeChange(event.Workspace, ModeChange{From: event.PreviousMode, To: event.Mode, At: event.Time, Trigger: event.Trigger})
+}
+
+// markJobConfigModified marks the jobs of a workspace whose config changed as modified
+func (s *Scheduler) markJobConfigModified(event events.Event) {
+	if s.jobManager != nil {
+		s.jobManager.SetJobConfigModified(event.Workspace, event.Time)
+	}
+}
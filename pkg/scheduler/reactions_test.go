@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/events"
+	"provisioner/pkg/job"
+	"provisioner/pkg/notify"
+	"provisioner/pkg/workspace"
+)
+
+func TestOperationsPublishEvents(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	var published []events.Event
+	scheduler.Events().Subscribe(func(e events.Event) { published = append(published, e) })
+
+	scheduler.manualDeployWorkspace(ws)
+	mockClient.DestroyFunc = func(*workspace.Workspace) error { return errors.New("destroy failed") }
+	scheduler.manualDestroyWorkspace(ws)
+
+	var types []events.Type
+	for _, e := range published {
+		types = append(types, e.Type)
+	}
+	if want := []events.Type{events.WorkspaceDeployed, events.DestroyFailed}; !reflect.DeepEqual(types, want) {
+		t.Fatalf("Expected events %v, got %v", want, types)
+	}
+	if published[1].Workspace != ws.Name || published[1].Err == nil {
+		t.Errorf("Expected the failure of %s with its error, got %+v", ws.Name, published[1])
+	}
+}
+
+func TestOperationEventReactions(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	sender := &recordingSender{}
+	scheduler.notifier = notify.NewWithConfig(&notify.Config{})
+	scheduler.notifier.AddSender("test", notify.Subscription{}, sender)
+
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	// Subscribers run after the audit log was written
+	var audited int
+	scheduler.Events().Subscribe(func(events.Event) {
+		entries, _ := scheduler.AuditLog().Read(audit.Filter{Workspace: ws.Name})
+		audited = len(entries)
+	}, events.DeployFailed)
+
+	mockClient.DeployFunc = func(*workspace.Workspace) error { return errors.New("apply failed") }
+	scheduler.manualDeployWorkspace(ws)
+
+	if audited != 1 {
+		t.Errorf("Expected the failed deploy to be audited before other subscribers ran, found %d entries", audited)
+	}
+	notifications := sender.take()
+	if len(notifications) != 1 || notifications[0].Event != notify.EventDeployFailed || notifications[0].Error != "apply failed" {
+		t.Errorf("Expected a deploy_failed notification, got %+v", notifications)
+	}
+}
+
+func TestModeChangedRecordsModeHistory(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	var changes []events.Event
+	scheduler.Events().Subscribe(func(e events.Event) { changes = append(changes, e) }, events.ModeChanged)
+
+	scheduler.deployWorkspaceInMode(ws, "hibernation", ModeTriggerManual)
+	scheduler.deployWorkspaceInMode(ws, "busy", ModeTriggerManual)
+	scheduler.deployWorkspaceInMode(ws, "busy", ModeTriggerManual)
+
+	if len(changes) != 2 || changes[1].PreviousMode != "hibernation" || changes[1].Mode != "busy" {
+		t.Fatalf("Expected two mode changes ending in hibernation -> busy, got %+v", changes)
+	}
+	history := scheduler.state.GetWorkspaceState(ws.Name).ModeHistory
+	if len(history) != 2 || history[1].From != "hibernation" || history[1].To != "busy" || history[1].Trigger != ModeTriggerManual {
+		t.Errorf("Expected the mode changes in the mode history, got %+v", history)
+	}
+}
+
+func TestJobFailureNotificationFollowsOnFailure(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	sender := &recordingSender{}
+	scheduler.notifier = notify.NewWithConfig(&notify.Config{})
+	scheduler.notifier.AddSender("test", notify.Subscription{}, sender)
+	scheduler.workspaces = []workspace.Workspace{busyApp(scheduler)}
+
+	for _, tt := range []struct {
+		onFailure string
+		notified  bool
+	}{
+		{"", true},
+		{job.OnFailureNotify, true},
+		{job.OnFailureAbortDependents, false},
+		{job.OnFailureContinue, false},
+	} {
+		scheduler.jobFinished(&job.JobExecution{JobName: "backup", WorkspaceID: "busy-app", Status: job.JobStatusFailed, Error: "exit status 1", OnFailure: tt.onFailure})
+
+		notifications := sender.take()
+		if notified := len(notifications) == 1 && notifications[0].Event == notify.EventJobFailed; notified != tt.notified {
+			t.Errorf("on_failure %q: expected notified %v, got %+v", tt.onFailure, tt.notified, notifications)
+		}
+	}
+}
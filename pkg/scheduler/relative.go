@@ -0,0 +1,46 @@
+package scheduler
+
+import (
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+// relativeDestroyTime returns when a destroy schedule relative to the last deploy, such as "+8h",
+// destroys the workspace: that long after its last successful deploy. relative is false for CRON
+// schedules; the time is nil for invalid offsets and workspaces never deployed.
+func relativeDestroyTime(scheduleStr string, state *WorkspaceState) (due *time.Time, relative bool) {
+	offset, relative, err := workspace.ParseRelativeSchedule(scheduleStr)
+	if !relative || err != nil || state.LastDeployed == nil {
+		return nil, relative
+	}
+	at := state.LastDeployed.Add(offset)
+	return &at, true
+}
+
+// nextRelativeDestroy returns the earliest time a relative destroy schedule destroys the current
+// deployment, nil if the workspace isn't deployed or has no relative destroy schedule
+func nextRelativeDestroy(schedules []string, state *WorkspaceState) *time.Time {
+	if !hasDeployment(state.Status) {
+		return nil
+	}
+	var next *time.Time
+	for _, scheduleStr := range schedules {
+		if due, _ := relativeDestroyTime(scheduleStr, state); due != nil && (next == nil || due.Before(*next)) {
+			next = due
+		}
+	}
+	return next
+}
+
+// lastDestroyDueTime returns the due time of a destroy schedule like lastDueTime, including
+// schedules relative to the last deploy
+func (s *Scheduler) lastDestroyDueTime(schedules []string, state *WorkspaceState, now time.Time) time.Time {
+	latest := s.lastDueTime(schedules, now)
+	for _, scheduleStr := range schedules {
+		if due, _ := relativeDestroyTime(scheduleStr, state); due != nil && !due.After(now) && due.After(latest) {
+			latest = *due
+		}
+	}
+	return latest
+}
@@ -0,0 +1,35 @@
+package scheduler
+
+import (
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// scheduleDeployRetry plans the next automatic retry after a scheduled deploy failed
+func (s *Scheduler) scheduleDeployRetry(workspace workspace.Workspace, now time.Time) {
+	retry := workspace.Config.Retry
+	if retry == nil {
+		return
+	}
+
+	workspaceState := s.state.Workspace(workspace.Name)
+	if workspaceState.DeployRetries >= retry.MaxAttempts {
+		logging.LogWorkspace(workspace.Name, "Deploy failed after %d retries, waiting for a config change or manual deploy", workspaceState.DeployRetries)
+		return
+	}
+
+	at := now.Add(retry.Delay(workspaceState.DeployRetries))
+	s.state.ScheduleDeployRetry(workspace.Name, at)
+	logging.LogWorkspace(workspace.Name, "Retrying deploy at %s (retry %d of %d)",
+		logging.FormatTime(at), workspaceState.DeployRetries+1, retry.MaxAttempts)
+}
+
+// shouldRetryDeploy reports whether a failed deploy's retry is due
+func shouldRetryDeploy(workspace workspace.Workspace, workspaceState *WorkspaceState, now time.Time) bool {
+	return workspace.Config.Retry != nil &&
+		workspaceState.Status == StatusDeployFailed &&
+		workspaceState.NextDeployRetry != nil &&
+		!now.Before(*workspaceState.NextDeployRetry)
+}
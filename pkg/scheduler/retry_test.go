@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+func TestFailedDeployRetriesWithBackoff(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	noJitter := 0.0
+	ws.Config.Retry = &workspace.RetryConfig{MaxAttempts: 2, Backoff: "10m", Jitter: &noJitter}
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	mockClient.DeployFunc = func(*workspace.Workspace) error {
+		return errors.New("provider timeout")
+	}
+
+	scheduler.deployWorkspace(ws)
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.Status != StatusDeployFailed {
+		t.Fatalf("expected status %s, got %s", StatusDeployFailed, workspaceState.Status)
+	}
+	if workspaceState.NextDeployRetry == nil {
+		t.Fatal("expected a retry to be scheduled")
+	}
+	firstRetry := *workspaceState.NextDeployRetry
+	if delay := time.Until(firstRetry); delay < 9*time.Minute || delay > 10*time.Minute {
+		t.Errorf("expected first retry in about 10m, got %v", delay)
+	}
+
+	// Not retried before the backoff has passed
+	if shouldRetryDeploy(ws, workspaceState, firstRetry.Add(-time.Second)) {
+		t.Error("expected no retry before the backoff has passed")
+	}
+
+	// First retry fails again and backs off further
+	scheduler.checkWorkspaceSchedules(ws, firstRetry)
+	waitForRetryScheduled(t, scheduler, ws.Name, 1)
+	secondRetry := *workspaceState.NextDeployRetry
+	if delay := time.Until(secondRetry); delay < 19*time.Minute || delay > 20*time.Minute {
+		t.Errorf("expected second retry to back off to about 20m, got %v", delay)
+	}
+
+	// Second retry fails and retries are exhausted
+	scheduler.checkWorkspaceSchedules(ws, secondRetry)
+	waitForDeployCalls(t, mockClient, 3)
+	waitForStatus(t, scheduler, ws.Name, StatusDeployFailed)
+	if workspaceState.NextDeployRetry != nil || workspaceState.DeployRetries != 2 {
+		t.Errorf("expected retries to be exhausted at 2, got %d (next %v)", workspaceState.DeployRetries, workspaceState.NextDeployRetry)
+	}
+
+	// A config change resets the retry count
+	scheduler.state.SetWorkspaceConfigModified(ws.Name, time.Now())
+	if workspaceState.DeployRetries != 0 {
+		t.Errorf("expected config change to reset retries, got %d", workspaceState.DeployRetries)
+	}
+}
+
+func TestSuccessfulRetryResetsCount(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	ws.Config.Retry = &workspace.RetryConfig{MaxAttempts: 3, Backoff: "1m"}
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	mockClient.DeployFunc = func(*workspace.Workspace) error {
+		return errors.New("provider timeout")
+	}
+	scheduler.deployWorkspace(ws)
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	mockClient.DeployFunc = nil
+	scheduler.checkWorkspaceSchedules(ws, workspaceState.NextDeployRetry.Add(time.Second))
+	waitForStatus(t, scheduler, ws.Name, StatusDeployed)
+
+	if workspaceState.DeployRetries != 0 || workspaceState.NextDeployRetry != nil {
+		t.Errorf("expected successful retry to reset retries, got %d (next %v)", workspaceState.DeployRetries, workspaceState.NextDeployRetry)
+	}
+}
+
+func TestFailedDeployWithoutRetryConfigWaits(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	mockClient.DeployFunc = func(*workspace.Workspace) error {
+		return errors.New("provider timeout")
+	}
+	scheduler.deployWorkspace(ws)
+
+	if next := scheduler.state.GetWorkspaceState(ws.Name).NextDeployRetry; next != nil {
+		t.Errorf("expected no retry without retry config, got %v", next)
+	}
+}
+
+func waitForRetryScheduled(t *testing.T, scheduler *Scheduler, workspaceName string, retries int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		workspaceState := scheduler.state.Workspace(workspaceName)
+		if workspaceState.DeployRetries == retries && workspaceState.NextDeployRetry != nil {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("workspace %s did not schedule retry %d", workspaceName, retries+1)
+}
+
+func waitForDeployCalls(t *testing.T, mockClient *opentofu.MockTofuClient, expected int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if mockClient.GetDeployCallCount() >= expected {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("expected %d deploy calls", expected)
+}
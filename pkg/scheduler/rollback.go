@@ -0,0 +1,76 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+)
+
+// CheckRollback returns the deploy a rollback of a workspace redeploys: revision, or with
+// revision 0 the successful deploy before the most recent one
+func (s *Scheduler) CheckRollback(workspaceName string, revision int) (*opentofu.DeploymentRecord, error) {
+	ws := s.GetWorkspace(workspaceName)
+	if ws == nil {
+		return nil, fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+
+	records, err := opentofu.LoadDeploymentHistory(workspaceName)
+	if err != nil {
+		return nil, err
+	}
+	target, err := opentofu.FindRollbackTarget(records, revision)
+	if err != nil {
+		return nil, fmt.Errorf("cannot roll back workspace '%s': %w", workspaceName, err)
+	}
+	if target.Mode == "" && len(ws.Config.ModeSchedules) > 0 {
+		return nil, fmt.Errorf("revision %d of workspace '%s' was deployed without a mode, which its mode schedules no longer allow", target.Revision, workspaceName)
+	}
+	return target, nil
+}
+
+// RollbackWorkspace redeploys a workspace with the template files, variables and mode of an
+// earlier deploy from its deployment history
+func (s *Scheduler) RollbackWorkspace(workspaceName string, revision int) error {
+	target, err := s.CheckRollback(workspaceName, revision)
+	if err != nil {
+		return err
+	}
+
+	ws := s.GetWorkspace(workspaceName)
+	if !ws.Config.Enabled {
+		return fmt.Errorf("workspace '%s' is disabled in configuration", workspaceName)
+	}
+	if err := s.checkNotFrozen(workspaceName, "deploy"); err != nil {
+		return err
+	}
+	if err := s.checkDependencies(workspaceName, OperationDeploy); err != nil {
+		return err
+	}
+	if workspaceState := s.state.Workspace(workspaceName); workspaceState.IsBusy() {
+		return fmt.Errorf("workspace '%s' is currently %s, cannot roll back", workspaceName, workspaceState.Status)
+	}
+
+	// The template isn't needed, the revision's files are redeployed
+	rollback := *ws
+	rollback.Revision = target.Revision
+
+	logging.LogWorkspaceOperation(workspaceName, "ROLLBACK", "Redeploying revision %d", target.Revision)
+	if target.Mode != "" {
+		s.deployWorkspaceInMode(rollback, target.Mode, ModeTriggerManual)
+	} else {
+		s.manualDeployWorkspace(rollback)
+	}
+
+	if err := s.SaveState(); err != nil {
+		logging.LogSystemd("Error saving state after rollback: %v", err)
+		return fmt.Errorf("rollback completed but failed to save state: %w", err)
+	}
+
+	// Deploy failures, and failed post_deploy hooks, are recorded in the state rather than returned
+	if workspaceState := s.state.Workspace(workspaceName); workspaceState.Status == StatusDeployFailed || workspaceState.Status == StatusDeployDegraded {
+		return fmt.Errorf("rollback of workspace '%s' failed: %s", workspaceName, getHighLevelError(errors.New(workspaceState.LastDeployError)))
+	}
+	return nil
+}
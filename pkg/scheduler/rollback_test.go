@@ -0,0 +1,88 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/opentofu"
+)
+
+// writeHistory records deploys in a workspace's deployment history
+func writeHistory(t *testing.T, sc *scenario, name string, records []opentofu.DeploymentRecord) {
+	t.Helper()
+	historyDir := opentofu.GetHistoryDir(sc.dir, name)
+	if err := os.MkdirAll(historyDir, 0755); err != nil {
+		t.Fatalf("Failed to create history directory: %v", err)
+	}
+	data, err := json.Marshal(records)
+	if err != nil {
+		t.Fatalf("Failed to marshal history: %v", err)
+	}
+	sc.writeFile(filepath.Join(historyDir, "history.json"), string(data))
+}
+
+func TestRollbackWorkspace(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("web", `{"enabled": true}`)
+	sc.start()
+
+	if err := sc.scheduler.RollbackWorkspace("web", 0); err == nil || !strings.Contains(err.Error(), "no earlier successful deploy") {
+		t.Errorf("Expected a rollback without history to fail, got %v", err)
+	}
+
+	writeHistory(t, sc, "web", []opentofu.DeploymentRecord{
+		{Revision: 1, Success: true, Snapshot: false},
+		{Revision: 2, Success: true, Snapshot: true},
+		{Revision: 3, Success: false},
+		{Revision: 4, Success: true, Snapshot: true},
+	})
+
+	// The previous successful deploy, skipping the failed one
+	if err := sc.scheduler.RollbackWorkspace("web", 0); err != nil {
+		t.Fatalf("RollbackWorkspace failed: %v", err)
+	}
+	sc.expectOperations("2025-03-10 08:00 deploy web revision 2")
+
+	if err := sc.scheduler.RollbackWorkspace("web", 1); err == nil || !strings.Contains(err.Error(), "no longer kept") {
+		t.Errorf("Expected a rollback to pruned files to fail, got %v", err)
+	}
+	if err := sc.scheduler.RollbackWorkspace("web", 3); err == nil || !strings.Contains(err.Error(), "failed to deploy") {
+		t.Errorf("Expected a rollback to a failed deploy to fail, got %v", err)
+	}
+
+	// A failed rollback is reported
+	sc.failNext("deploy web revision 4", 1)
+	if err := sc.scheduler.RollbackWorkspace("web", 4); err == nil || !strings.Contains(err.Error(), "rollback of workspace 'web' failed") {
+		t.Errorf("Expected the failed rollback to be reported, got %v", err)
+	}
+	sc.expectOperations("2025-03-10 08:00 deploy web revision 4")
+}
+
+func TestRollbackWorkspaceInMode(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("web", `{"enabled": true, "mode_schedules": {"busy": "0 9 * * 1-5", "quiet": "0 18 * * 1-5"}}`)
+	sc.start()
+
+	writeHistory(t, sc, "web", []opentofu.DeploymentRecord{
+		{Revision: 1, Success: true, Snapshot: true},
+		{Revision: 2, Mode: "quiet", Success: true, Snapshot: true},
+		{Revision: 3, Mode: "busy", Success: true, Snapshot: true},
+	})
+
+	if err := sc.scheduler.RollbackWorkspace("web", 0); err != nil {
+		t.Fatalf("RollbackWorkspace failed: %v", err)
+	}
+	sc.expectOperations("2025-03-10 08:00 deploy web (quiet) revision 2")
+	if mode := sc.scheduler.state.GetWorkspaceState("web").DeploymentMode; mode != "quiet" {
+		t.Errorf("Expected the rollback to restore mode quiet, got %q", mode)
+	}
+
+	// Revisions deployed before the workspace had mode schedules have no mode to deploy in
+	if _, err := sc.scheduler.CheckRollback("web", 1); err == nil || !strings.Contains(err.Error(), "without a mode") {
+		t.Errorf("Expected a rollback without a mode to be refused, got %v", err)
+	}
+}
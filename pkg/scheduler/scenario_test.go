@@ -0,0 +1,300 @@
+package scheduler
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+// The scenario harness runs the scheduler loop against a fake clock and a fake tofu engine.
+// A scenario writes workspace configs, advances time tick by tick through days, stops and
+// restarts the daemon or edits configs along the way, and asserts the exact operations run:
+//
+//	sc := newScenario(t, time.Date(2025, 3, 7, 8, 0, 0, 0, time.UTC))
+//	sc.workspace("app", `{"enabled": true, "deploy_schedule": "0 9 * * 1-5", "destroy_schedule": "0 18 * * 1-5"}`)
+//	sc.start()
+//	sc.runUntil(time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC))
+//	sc.expectOperations("2025-03-07 09:00 deploy app", "2025-03-07 18:00 destroy app")
+
+// scenarioTickOffset places each minute's schedule check half a minute into the minute, like
+// a daemon whose ticker started at an arbitrary second
+const scenarioTickOffset = 30 * time.Second
+
+// scenarioClock is a settable clock
+type scenarioClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *scenarioClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *scenarioClock) set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = now
+}
+
+// scenarioEngine is a fake tofu engine recording each operation with the clock time it started
+type scenarioEngine struct {
+	*opentofu.MockTofuClient
+	clock *scenarioClock
+
+	mu         sync.Mutex
+	operations []string
+	failures   map[string]int // "deploy app" -> number of upcoming attempts that fail
+}
+
+func newScenarioEngine(clock *scenarioClock) *scenarioEngine {
+	return &scenarioEngine{MockTofuClient: opentofu.NewMockTofuClient(), clock: clock, failures: make(map[string]int)}
+}
+
+func (e *scenarioEngine) record(operation string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.operations = append(e.operations, e.clock.Now().Format("2006-01-02 15:04")+" "+operation)
+	if e.failures[operation] > 0 {
+		e.failures[operation]--
+		return fmt.Errorf("%s failed", operation)
+	}
+	return nil
+}
+
+func (e *scenarioEngine) Deploy(ws *workspace.Workspace) error {
+	return e.record("deploy " + ws.Name + revisionSuffix(ws))
+}
+
+func (e *scenarioEngine) DeployInMode(ws *workspace.Workspace, mode string) error {
+	return e.record(fmt.Sprintf("deploy %s (%s)%s", ws.Name, mode, revisionSuffix(ws)))
+}
+
+// revisionSuffix marks rollback deploys of an earlier revision
+func revisionSuffix(ws *workspace.Workspace) string {
+	if ws.Revision == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" revision %d", ws.Revision)
+}
+
+func (e *scenarioEngine) DestroyWorkspace(ws *workspace.Workspace) error {
+	return e.record("destroy " + ws.Name)
+}
+
+// takeOperations returns and clears the recorded operations
+func (e *scenarioEngine) takeOperations() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	operations := e.operations
+	e.operations = nil
+	return operations
+}
+
+// scenario drives a scheduler through time
+type scenario struct {
+	t         *testing.T
+	dir       string
+	clock     *scenarioClock
+	engine    *scenarioEngine
+	client    opentofu.TofuClient // The engine, or the mock of a mock scenario
+	scheduler *Scheduler
+}
+
+// scenarioDaily deploys a workspace at 09:00 and destroys it at 12:00 every day
+const scenarioDaily = `{"enabled": true, "deploy_schedule": "0 9 * * *", "destroy_schedule": "0 12 * * *"}`
+
+// newScenario prepares empty config and state directories with the clock at start
+func newScenario(t *testing.T, start time.Time) *scenario {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", dir)
+	t.Setenv("PROVISIONER_CONFIG_DIR", dir)
+
+	clock := &scenarioClock{now: start}
+	engine := newScenarioEngine(clock)
+	sc := &scenario{t: t, dir: dir, clock: clock, engine: engine, client: engine}
+	// Let background operations and their state saves finish before the directory is removed
+	t.Cleanup(func() {
+		if sc.scheduler != nil {
+			sc.scheduler.operations.Wait()
+		}
+	})
+	return sc
+}
+
+// newMockScenario starts a daemon at the current time with the daily workspace "busy-app" whose
+// operations run on a mock client. It suits tests that call scheduler methods directly and
+// script the mock or count its calls, rather than running the schedule loop.
+func newMockScenario(t *testing.T) (*Scheduler, *opentofu.MockTofuClient) {
+	t.Helper()
+	sc := newScenario(t, time.Now()).workspace("busy-app", scenarioDaily)
+	mockClient := sc.mock()
+	return sc.start().scheduler, mockClient
+}
+
+// mock runs the daemon's operations on a mock client instead of the fake engine, for tests that
+// script operations or count calls. Call it before start.
+func (sc *scenario) mock() *opentofu.MockTofuClient {
+	mockClient := opentofu.NewMockTofuClient()
+	sc.client = mockClient
+	return mockClient
+}
+
+// busyApp returns the daily workspace of a mock scenario, to adjust its config in a test
+func busyApp(scheduler *Scheduler) workspace.Workspace {
+	return *scheduler.GetWorkspace("busy-app")
+}
+
+// workspace writes a workspace's config.json and main.tf, stamped with the clock time
+func (sc *scenario) workspace(name, config string) *scenario {
+	sc.t.Helper()
+	workspaceDir := filepath.Join(sc.dir, "workspaces", name)
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		sc.t.Fatalf("Failed to create workspace directory: %v", err)
+	}
+	sc.writeFile(filepath.Join(workspaceDir, "main.tf"), `resource "null_resource" "test" {}`)
+	sc.writeFile(filepath.Join(workspaceDir, "config.json"), config)
+	// Adding a workspace changes the workspaces directory, which polling checks too
+	sc.touch(filepath.Join(sc.dir, "workspaces"))
+	return sc
+}
+
+// daemonConfig writes provisioner.json, read when the daemon starts
+func (sc *scenario) daemonConfig(config string) *scenario {
+	sc.t.Helper()
+	sc.writeFile(filepath.Join(sc.dir, DaemonConfigFile), config)
+	return sc
+}
+
+// removeWorkspace deletes a workspace's directory while the daemon is running
+func (sc *scenario) removeWorkspace(name string) *scenario {
+	sc.t.Helper()
+	if err := os.RemoveAll(filepath.Join(sc.dir, "workspaces", name)); err != nil {
+		sc.t.Fatalf("Failed to remove workspace %s: %v", name, err)
+	}
+	sc.touch(filepath.Join(sc.dir, "workspaces"))
+	return sc
+}
+
+// editConfig replaces a workspace's config.json while the daemon is running
+func (sc *scenario) editConfig(name, config string) *scenario {
+	sc.t.Helper()
+	sc.writeFile(filepath.Join(sc.dir, "workspaces", name, "config.json"), config)
+	return sc
+}
+
+// failNext makes the next attempts of an operation, e.g. "deploy app", fail
+func (sc *scenario) failNext(operation string, attempts int) *scenario {
+	sc.engine.mu.Lock()
+	defer sc.engine.mu.Unlock()
+	sc.engine.failures[operation] = attempts
+	return sc
+}
+
+func (sc *scenario) writeFile(path, content string) {
+	sc.t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		sc.t.Fatalf("Failed to write %s: %v", path, err)
+	}
+	sc.touch(path)
+}
+
+// touch sets a path's modification time to the fake clock, config changes are detected by it
+func (sc *scenario) touch(path string) {
+	sc.t.Helper()
+	if err := os.Chtimes(path, sc.clock.Now(), sc.clock.Now()); err != nil {
+		sc.t.Fatalf("Failed to set modification time of %s: %v", path, err)
+	}
+}
+
+// start starts the daemon: workspaces and state are loaded from disk
+func (sc *scenario) start() *scenario {
+	sc.t.Helper()
+	sc.scheduler = &Scheduler{
+		client:    sc.client,
+		statePath: filepath.Join(sc.dir, "scheduler.json"),
+		configDir: sc.dir,
+		quietMode: true,
+		now:       sc.clock.Now,
+	}
+	sc.scheduler.initDaemonConfig()
+	if err := sc.scheduler.LoadWorkspaces(); err != nil {
+		sc.t.Fatalf("Failed to load workspaces: %v", err)
+	}
+	if err := sc.scheduler.LoadState(); err != nil {
+		sc.t.Fatalf("Failed to load state: %v", err)
+	}
+	sc.scheduler.recoverQueuedOperations()
+	return sc
+}
+
+// stop stops the daemon after its running operations finished
+func (sc *scenario) stop() *scenario {
+	sc.t.Helper()
+	sc.scheduler.operations.Wait()
+	if err := sc.scheduler.SaveState(); err != nil {
+		sc.t.Fatalf("Failed to save state: %v", err)
+	}
+	sc.scheduler = nil
+	return sc
+}
+
+// downFor stops the daemon, moves the clock on without schedule checks and starts it again
+func (sc *scenario) downFor(d time.Duration) *scenario {
+	sc.t.Helper()
+	sc.stop()
+	sc.clock.set(sc.clock.Now().Add(d))
+	return sc.start()
+}
+
+// run advances the clock by d
+func (sc *scenario) run(d time.Duration) *scenario {
+	sc.t.Helper()
+	return sc.runUntil(sc.clock.Now().Add(d))
+}
+
+// runUntil runs the schedule check of every minute up to end, waiting for the operations each
+// check starts, and leaves the clock at end
+func (sc *scenario) runUntil(end time.Time) *scenario {
+	sc.t.Helper()
+	if sc.scheduler == nil {
+		sc.t.Fatal("Scenario daemon is not running")
+	}
+
+	tick := sc.clock.Now().Truncate(time.Minute).Add(scenarioTickOffset)
+	if tick.Before(sc.clock.Now()) {
+		tick = tick.Add(time.Minute)
+	}
+	for ; !tick.After(end); tick = tick.Add(time.Minute) {
+		sc.clock.set(tick)
+		sc.scheduler.checkSchedules()
+		sc.scheduler.operations.Wait()
+	}
+	sc.clock.set(end)
+	return sc
+}
+
+// status returns a workspace's scheduler status
+func (sc *scenario) status(name string) WorkspaceStatus {
+	return sc.scheduler.state.GetWorkspaceState(name).Status
+}
+
+// expectOperations asserts the operations run since the last expectation, in order
+func (sc *scenario) expectOperations(expected ...string) *scenario {
+	sc.t.Helper()
+	actual := sc.engine.takeOperations()
+	if strings.Join(actual, "\n") != strings.Join(expected, "\n") {
+		sc.t.Errorf("Unexpected operations\nexpected:\n  %s\nactual:\n  %s",
+			strings.Join(expected, "\n  "), strings.Join(actual, "\n  "))
+	}
+	return sc
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+const scenarioOfficeHours = `{"enabled": true, "deploy_schedule": "0 9 * * 1-5", "destroy_schedule": "0 18 * * 1-5"}`
+
+func TestScenarioOfficeHoursAcrossWeekend(t *testing.T) {
+	// Friday morning to Tuesday morning
+	sc := newScenario(t, time.Date(2025, 3, 7, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", scenarioOfficeHours).start()
+
+	sc.runUntil(time.Date(2025, 3, 11, 8, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-07 09:00 deploy app",
+		"2025-03-07 18:00 destroy app",
+		"2025-03-10 09:00 deploy app",
+		"2025-03-10 18:00 destroy app",
+	)
+}
+
+func TestScenarioMissedWindows(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", scenarioOfficeHours).start()
+
+	// Down over the deploy time: the deploy runs on restart the same day
+	sc.run(50 * time.Minute).downFor(90 * time.Minute)
+	sc.run(time.Minute)
+	sc.expectOperations("2025-03-10 10:20 deploy app")
+
+	// Down from Monday afternoon to Wednesday morning: the latest missed destroy runs once on
+	// restart, the missed deploy it came after is not caught up
+	sc.runUntil(time.Date(2025, 3, 10, 17, 0, 0, 0, time.UTC)).downFor(39 * time.Hour)
+	sc.runUntil(time.Date(2025, 3, 12, 20, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-12 08:00 destroy app",
+		"2025-03-12 09:00 deploy app",
+		"2025-03-12 18:00 destroy app",
+	)
+
+	// Down over midnight: the 23:55 destroy runs on restart the next day
+	sc.workspace("late", `{"enabled": true, "deploy_schedule": "0 20 * * *", "destroy_schedule": "55 23 * * *"}`)
+	sc.run(time.Minute).expectOperations("2025-03-12 20:00 deploy late")
+	sc.runUntil(time.Date(2025, 3, 12, 23, 50, 0, 0, time.UTC)).downFor(20 * time.Minute)
+	sc.run(time.Minute)
+	sc.expectOperations("2025-03-13 00:10 destroy late")
+}
+
+func TestScenarioMissedWindowsSkipped(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.daemonConfig(`{"missed_schedule_policy": "skip"}`)
+	sc.workspace("app", scenarioOfficeHours).start()
+
+	// Down over the deploy time: the deploy waits for the next day
+	sc.run(50 * time.Minute).downFor(90 * time.Minute)
+	sc.runUntil(time.Date(2025, 3, 11, 12, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-11 09:00 deploy app")
+
+	// Down over the destroy time: the deployment lives until the next destroy
+	sc.runUntil(time.Date(2025, 3, 11, 17, 0, 0, 0, time.UTC)).downFor(2 * time.Hour)
+	sc.runUntil(time.Date(2025, 3, 12, 20, 0, 0, 0, time.UTC))
+	sc.expectOperations("2025-03-12 18:00 destroy app")
+}
+
+func TestScenarioDaylightSavingTime(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Timezone data not available: %v", err)
+	}
+
+	// 02:30 does not exist on 2025-03-30 and happens twice on 2025-10-26
+	config := `{"enabled": true, "timezone": "Europe/Berlin", "deploy_schedule": "30 2 * * *", "destroy_schedule": "0 12 * * *"}`
+
+	// Spring forward: the schedule runs an hour late, when 02:30 is normalized to 03:30
+	sc := newScenario(t, time.Date(2025, 3, 29, 0, 0, 0, 0, berlin))
+	sc.workspace("app", config).start()
+	sc.runUntil(time.Date(2025, 3, 31, 0, 0, 0, 0, berlin))
+	sc.expectOperations(
+		"2025-03-29 02:30 deploy app",
+		"2025-03-29 12:00 destroy app",
+		"2025-03-30 03:30 deploy app",
+		"2025-03-30 12:00 destroy app",
+	)
+
+	// Fall back: the schedule runs once, at the second 02:30 after the clocks went back
+	sc = newScenario(t, time.Date(2025, 10, 25, 0, 0, 0, 0, berlin))
+	sc.workspace("app", config).start()
+	sc.runUntil(time.Date(2025, 10, 26, 11, 0, 0, 0, berlin))
+	sc.expectOperations(
+		"2025-10-25 02:30 deploy app",
+		"2025-10-25 12:00 destroy app",
+		"2025-10-26 02:30 deploy app",
+	)
+	if deployed := sc.scheduler.state.GetWorkspaceState("app").LastDeployed; deployed.UTC().Hour() != 1 {
+		t.Errorf("Expected the deploy at 02:30 CET, got %v", deployed)
+	}
+}
+
+func TestScenarioConfigEditMidWindow(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", scenarioOfficeHours).start()
+	sc.runUntil(time.Date(2025, 3, 10, 11, 0, 10, 0, time.UTC))
+	sc.expectOperations("2025-03-10 09:00 deploy app")
+
+	// Editing a deployed workspace's config redeploys it once on the next check, and the
+	// edited destroy schedule applies the same day
+	sc.editConfig("app", `{"enabled": true, "deploy_schedule": "0 9 * * 1-5", "destroy_schedule": "0 17 * * 1-5"}`)
+	sc.runUntil(time.Date(2025, 3, 10, 20, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 11:00 deploy app",
+		"2025-03-10 17:00 destroy app",
+	)
+
+	// A deploy schedule added after its time has passed deploys right away
+	sc.editConfig("app", `{"enabled": true, "deploy_schedule": ["0 9 * * 1-5", "0 19 * * 1-5"], "destroy_schedule": "0 17 * * 1-5"}`)
+	sc.run(time.Minute)
+	sc.expectOperations("2025-03-10 20:00 deploy app")
+	if status := sc.status("app"); status != StatusDeployed {
+		t.Errorf("Expected app to be deployed, got %s", status)
+	}
+}
+
+func TestScenarioRetryWithBackoff(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("app", `{"enabled": true, "deploy_schedule": "0 9 * * *", "destroy_schedule": "0 18 * * *",
+		"retry": {"max_attempts": 3, "backoff": "5m", "jitter": 0}}`).start()
+	sc.failNext("deploy app", 2)
+
+	// Retries back off 5m, then 10m; the third attempt succeeds
+	sc.runUntil(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	sc.expectOperations(
+		"2025-03-10 09:00 deploy app",
+		"2025-03-10 09:05 deploy app",
+		"2025-03-10 09:15 deploy app",
+	)
+	if status := sc.status("app"); status != StatusDeployed {
+		t.Errorf("Expected app to be deployed after retries, got %s", status)
+	}
+}
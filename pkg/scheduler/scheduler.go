@@ -2,22 +2,33 @@ package scheduler
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
+	"provisioner/pkg/audit"
+	"provisioner/pkg/cron"
 	"provisioner/pkg/environment"
+	"provisioner/pkg/events"
 	"provisioner/pkg/job"
+	"provisioner/pkg/listing"
 	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
 	"provisioner/pkg/opentofu"
+	"provisioner/pkg/output"
+	"provisioner/pkg/paths"
+	"provisioner/pkg/systemd"
 	"provisioner/pkg/template"
 	"provisioner/pkg/workspace"
 )
 
 type Scheduler struct {
-	workspaces           []workspace.Workspace
+	workspaces           []workspace.Workspace // Replaced as a whole on reloads, read with loadedWorkspaces
+	workspacesMu         sync.RWMutex          // Guards workspaces, read by operations and control requests during reloads
 	state                *State
 	client               opentofu.TofuClient
 	jobManager           *job.Manager
@@ -26,8 +37,28 @@ type Scheduler struct {
 	statePath            string
 	stopChan             chan bool
 	lastConfigCheck      time.Time
+	configWatcher        *configWatcher // Reports workspace config changes, nil while polling for them
 	configDir            string
 	quietMode            bool
+	notifier             *notify.Notifier
+	bus                  *events.Bus // Created with the reactions subscribed to it on first use, see Events
+	busOnce              sync.Once
+	daemonConfig         *DaemonConfig
+	operationSlots       chan struct{}                                 // Limits concurrent deploys/destroys, nil when unlimited
+	throttleBuckets      map[string]chan struct{}                      // Named limits shared by operations and jobs using the same provider/region
+	missingTemplates     map[string]bool                               // Workspaces already reported as missing their template
+	now                  func() time.Time                              // Clock schedules are checked against, time.Now if nil
+	environmentCheck     func(*environment.Environment) error          // Health check of an environment, Environment.CheckHealth if nil
+	idleCheck            func(workspace.Workspace) (float64, error)    // Activity of a deployed workspace, IdleCheckConfig.Measure if nil
+	operations           sync.WaitGroup                                // Deploys, destroys, environment health checks and idle checks running in the background
+	lastGitOpsSync       time.Time                                     // Last sync of the configs from the gitops repository
+	lastGitOpsError      string                                        // Error of the last sync, logged again only when it changes
+	nextHolidayRefresh   time.Time                                     // When holiday calendars with a URL are fetched again
+	election             *leaderElection                               // Leader lease shared with standby daemons, nil without high_availability
+	leadershipLost       chan struct{}                                 // Closed when another daemon took over the lease
+	wrapClient           func(opentofu.TofuClient) opentofu.TofuClient // Routes operations of agent workspaces to their agents, nil without agents
+	lastCheck            *time.Time                                    // Previous schedule check of this daemon, nil before the first
+	downSince, downUntil time.Time                                     // Daemon downtime before the first check; schedules that fired in it were missed
 }
 
 func New() *Scheduler {
@@ -38,12 +69,16 @@ func New() *Scheduler {
 	templatesDir := filepath.Join(stateDir, "templates")
 	templateManager := template.NewManager(templatesDir)
 
-	return &Scheduler{
+	s := &Scheduler{
 		statePath:       filepath.Join(stateDir, "scheduler.json"),
 		stopChan:        make(chan bool),
 		configDir:       configDir,
 		templateManager: templateManager,
 	}
+	s.initDaemonConfig()
+	s.initNotifier()
+
+	return s
 }
 
 func NewWithClient(client opentofu.TofuClient) *Scheduler {
@@ -61,7 +96,7 @@ func NewWithClient(client opentofu.TofuClient) *Scheduler {
 	jobsDir := filepath.Join(configDir, "jobs")
 	standaloneJobManager := job.NewStandaloneJobManager(jobsDir, stateDir, jobManager)
 
-	return &Scheduler{
+	s := &Scheduler{
 		client:               client,
 		statePath:            filepath.Join(stateDir, "scheduler.json"),
 		stopChan:             make(chan bool),
@@ -70,6 +105,10 @@ func NewWithClient(client opentofu.TofuClient) *Scheduler {
 		jobManager:           jobManager,
 		standaloneJobManager: standaloneJobManager,
 	}
+	s.initDaemonConfig()
+	s.initNotifier()
+
+	return s
 }
 
 // NewQuiet creates a new scheduler for CLI operations (suppresses verbose loading output)
@@ -89,7 +128,7 @@ func NewQuiet() *Scheduler {
 	jobsDir := filepath.Join(configDir, "jobs")
 	standaloneJobManager := job.NewStandaloneJobManager(jobsDir, stateDir, jobManager)
 
-	return &Scheduler{
+	s := &Scheduler{
 		statePath:            filepath.Join(stateDir, "scheduler.json"),
 		stopChan:             make(chan bool),
 		configDir:            configDir,
@@ -98,6 +137,10 @@ func NewQuiet() *Scheduler {
 		jobManager:           jobManager,
 		standaloneJobManager: standaloneJobManager,
 	}
+	s.initDaemonConfig()
+	s.initNotifier()
+
+	return s
 }
 
 func (s *Scheduler) LoadWorkspaces() error {
@@ -108,20 +151,34 @@ func (s *Scheduler) LoadWorkspaces() error {
 		return fmt.Errorf("failed to load workspaces: %w", err)
 	}
 
+	s.applyLabelPolicies(workspaces)
+	s.applyTierDefaults(workspaces)
+	s.workspacesMu.Lock()
 	s.workspaces = workspaces
-	s.lastConfigCheck = time.Now()
+	s.workspacesMu.Unlock()
+	s.lastConfigCheck = s.currentTime()
+	if !s.quietMode && s.daemonConfig != nil {
+		s.checkHolidayCalendars()
+	}
+
+	// Register workspace-specific redaction patterns before anything is logged for them
+	for _, workspace := range workspaces {
+		if err := logging.SetWorkspaceRedactPatterns(workspace.Name, workspace.Config.RedactPatterns); err != nil {
+			logging.LogSystemd("Workspace %s: %v", workspace.Name, err)
+		}
+	}
 
 	enabledCount := 0
-	for _, workspace := range s.workspaces {
+	for _, workspace := range workspaces {
 		if workspace.Config.Enabled {
 			enabledCount++
 		}
 	}
 
 	if !s.quietMode {
-		logging.LogSystemd("Loaded %d workspaces (%d enabled, %d disabled)", len(s.workspaces), enabledCount, len(s.workspaces)-enabledCount)
+		logging.LogSystemd("Loaded %d workspaces (%d enabled, %d disabled)", len(workspaces), enabledCount, len(workspaces)-enabledCount)
 
-		for _, workspace := range s.workspaces {
+		for _, workspace := range workspaces {
 			status := "disabled"
 			if workspace.Config.Enabled {
 				status = "enabled"
@@ -141,6 +198,14 @@ func (s *Scheduler) LoadWorkspaces() error {
 	return nil
 }
 
+// loadedWorkspaces returns the workspaces of the last load. The slice is never changed after a
+// load, only replaced by the next one.
+func (s *Scheduler) loadedWorkspaces() []workspace.Workspace {
+	s.workspacesMu.RLock()
+	defer s.workspacesMu.RUnlock()
+	return s.workspaces
+}
+
 func (s *Scheduler) LoadState() error {
 	state, err := LoadState(s.statePath)
 	if err != nil {
@@ -148,6 +213,7 @@ func (s *Scheduler) LoadState() error {
 	}
 
 	s.state = state
+	s.state.now = s.now
 	if !s.quietMode {
 		logging.LogSystemd("State loaded with %d workspace records", len(s.state.Workspaces))
 	}
@@ -165,6 +231,12 @@ func (s *Scheduler) SaveState() error {
 func (s *Scheduler) Start() {
 	logging.LogSystemd("Starting scheduler loop...")
 
+	// Only the leader touches the shared state; a standby waits for it to go away
+	if s.election != nil && !s.waitForLeadership() {
+		logging.LogSystemd("Scheduler stopped")
+		return
+	}
+
 	// Initialize OpenTofu client if not provided
 	if s.client == nil {
 		client, err := opentofu.New()
@@ -173,6 +245,9 @@ func (s *Scheduler) Start() {
 			return
 		}
 		s.client = client
+		if s.wrapClient != nil {
+			s.client = s.wrapClient(client)
+		}
 	}
 
 	// Initialize job manager now that we have a client
@@ -183,6 +258,8 @@ func (s *Scheduler) Start() {
 		// Initialize standalone job manager
 		jobsDir := filepath.Join(s.configDir, "jobs")
 		s.standaloneJobManager = job.NewStandaloneJobManager(jobsDir, stateDir, s.jobManager)
+		s.initJobThrottle()
+		s.initJobFinishedHandler()
 
 		// Load job state
 		if err := s.jobManager.LoadState(); err != nil {
@@ -190,13 +267,51 @@ func (s *Scheduler) Start() {
 		}
 	}
 
-	ticker := time.NewTicker(1 * time.Minute)
+	// Operations queued for a slot before a restart never started
+	s.recoverQueuedOperations()
+	s.recoverInterruptedOperations()
+	s.reportOrphans()
+
+	// Sync before watching, so a workspaces directory created by the first sync is watched
+	s.syncGitOps(s.currentTime(), true)
+	s.refreshHolidayCalendars(s.currentTime(), true)
+	s.startConfigWatcher()
+	defer s.stopConfigWatcher()
+
+	ticker := time.NewTicker(s.tickInterval())
 	defer ticker.Stop()
 
+	// Under systemd with WatchdogSec, the loop resets the watchdog, so a hung scheduler is restarted
+	var watchdog <-chan time.Time
+	if interval := systemd.WatchdogInterval(); interval > 0 {
+		watchdogTicker := time.NewTicker(interval)
+		defer watchdogTicker.Stop()
+		watchdog = watchdogTicker.C
+	}
+
+	if err := systemd.Ready(); err != nil {
+		logging.LogSystemd("Failed to notify systemd: %v", err)
+	}
+	s.notifyStatus()
+
 	for {
 		select {
 		case <-ticker.C:
+			if !s.leading() {
+				// The lease expired unrenewed; schedules wait until it is renewed or lost
+				logging.LogSystemd("Leader lease expired, skipping schedules")
+				continue
+			}
+			s.syncGitOps(s.currentTime(), false)
+			s.refreshHolidayCalendars(s.currentTime(), false)
 			s.checkSchedules()
+			s.notifyStatus()
+			_ = systemd.Watchdog()
+		case <-watchdog:
+			_ = systemd.Watchdog()
+		case <-s.leadershipLost:
+			logging.LogSystemd("Lost the leader lease, stopping the scheduler loop")
+			return
 		case <-s.stopChan:
 			logging.LogSystemd("Scheduler stopped")
 			return
@@ -204,32 +319,61 @@ func (s *Scheduler) Start() {
 	}
 }
 
+// notifyStatus reports the workspaces and running operations to systemd, shown by systemctl status
+func (s *Scheduler) notifyStatus() {
+	workspaces := s.loadedWorkspaces()
+	busy := 0
+	if s.state != nil {
+		states := s.state.WorkspaceStates()
+		for _, ws := range workspaces {
+			if state, ok := states[ws.Name]; ok && state.IsBusy() {
+				busy++
+			}
+		}
+	}
+	_ = systemd.Status("%d workspaces, %d deploying or destroying, last checked %s",
+		len(workspaces), busy, s.currentTime().Format("15:04:05"))
+}
+
 func (s *Scheduler) Stop() {
 	close(s.stopChan)
 }
 
-func (s *Scheduler) checkSchedules() {
-	now := time.Now()
-
-	// Check for configuration changes every 30 seconds
-	if now.Sub(s.lastConfigCheck) > 30*time.Second {
-		if s.hasConfigChanged() {
-			logging.LogSystemd("Configuration changes detected, reloading workspaces...")
-			if err := s.LoadWorkspaces(); err != nil {
-				logging.LogSystemd("Error reloading workspaces: %v", err)
-			}
-		} else {
-			s.lastConfigCheck = now
-		}
+// currentTime returns the time on the scheduler's clock
+func (s *Scheduler) currentTime() time.Time {
+	if s.now != nil {
+		return s.now()
 	}
+	return time.Now()
+}
+
+// goOperation runs a deploy, destroy or environment health check in the background
+func (s *Scheduler) goOperation(operation func()) {
+	s.operations.Add(1)
+	go func() {
+		defer s.operations.Done()
+		operation()
+	}()
+}
+
+func (s *Scheduler) checkSchedules() {
+	now := s.currentTime()
+	s.noteDowntime(now)
+
+	// Reload changed, added and removed workspace configurations
+	s.checkConfigChanges(now)
 
-	for _, workspace := range s.workspaces {
+	for _, workspace := range s.loadedWorkspaces() {
 		// Only check schedules for enabled workspaces
 		if workspace.Config.Enabled {
 			s.checkWorkspaceSchedules(workspace, now)
 		}
 	}
 
+	// Health check the environments' assigned workspaces
+	s.checkEnvironments(now)
+	s.recordScheduleCheck(now)
+
 	// Save state after checking all schedules
 	if err := s.SaveState(); err != nil {
 		logging.LogSystemd("Error saving state: %v", err)
@@ -251,37 +395,120 @@ func (s *Scheduler) checkSchedules() {
 }
 
 func (s *Scheduler) checkWorkspaceSchedules(workspace workspace.Workspace, now time.Time) {
-	workspaceState := s.state.GetWorkspaceState(workspace.Name)
+	workspaceState := s.state.Workspace(workspace.Name)
 
-	// Skip if workspace is currently being deployed or destroyed
-	if workspaceState.Status == StatusDeploying || workspaceState.Status == StatusDestroying {
+	// Evaluate schedules in the workspace's timezone
+	now = now.In(workspace.Config.GetLocation())
+
+	// Skip workspaces whose template was removed; deploying or destroying them would fail
+	if s.checkTemplateMissing(workspace) {
+		return
+	}
+
+	// Paused workspaces run no scheduled operations or jobs until they are resumed
+	if s.IsWorkspacePaused(workspace.Name) {
+		return
+	}
+
+	// Skip if workspace is currently being deployed or destroyed, queueing any schedule that fired meanwhile
+	if workspaceState.IsBusy() {
 		logging.LogWorkspace(workspace.Name, "Workspace is busy (%s), skipping", workspaceState.Status)
+		s.queueFollowUpOperation(workspace, workspaceState, now)
 		return
 	}
 
-	// Check deploy schedules
+	// Run an operation queued while a previous (e.g. manual) operation was in progress
+	if workspaceState.PendingOperation != nil {
+		s.goOperation(func() { s.runPendingOperation(workspace) })
+		return
+	}
+
+	// Run a one-shot deploy or destroy scheduled with --at
+	if s.runScheduledOperation(workspace, workspaceState, now) {
+		return
+	}
+
+	// Check whether a running one-shot workspace has finished its work
+	if workspace.Config.IsRunToCompletion() && s.checkRunCompletion(workspace, workspaceState, now) {
+		return
+	}
+
+	// Destroy or alert on deployments that outlived max_lifetime
+	if s.checkMaxLifetime(workspace, workspaceState, now) {
+		return
+	}
+
+	// Destroy ephemeral workspaces whose ttl has passed since their last deploy
+	if s.checkTTL(workspace, workspaceState, now) {
+		return
+	}
+
+	// Destroy or hibernate workspaces idle for idle_minutes
+	if s.checkIdle(workspace, workspaceState, now) {
+		return
+	}
+
+	// Retry a failed deploy once its backoff has passed
+	if shouldRetryDeploy(workspace, workspaceState, now) {
+		if s.skipIfFrozen(workspace.Name, OperationDeploy, "retry", *workspaceState.NextDeployRetry) ||
+			s.waitForDependencies(workspace, OperationDeploy) {
+			return
+		}
+		s.state.StartDeployRetry(workspace.Name)
+		logging.SetCorrelationID(workspace.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+		logging.LogWorkspace(workspace.Name, "Retrying failed deployment (retry %d of %d)",
+			workspaceState.DeployRetries+1, workspace.Config.Retry.MaxAttempts)
+		if len(workspace.Config.ModeSchedules) > 0 && workspaceState.DeploymentMode != "" {
+			s.goOperation(func() { s.deployWorkspaceInMode(workspace, workspaceState.DeploymentMode, ModeTriggerSchedule) })
+		} else {
+			s.goOperation(func() { s.deployWorkspace(workspace) })
+		}
+		return
+	}
+
+	// Drop approval requests nobody approved in time, checking schedules against the state without them
+	s.expireApproval(workspace, workspaceState, now)
+	workspaceState = s.state.Workspace(workspace.Name)
+
+	// Check deploy or mode schedules
 	deploySchedules, err := workspace.Config.GetDeploySchedules()
-	if err != nil {
+	if len(workspace.Config.ModeSchedules) > 0 {
+		s.checkModeSchedules(workspace, workspaceState, now)
+	} else if err != nil {
 		logging.LogWorkspace(workspace.Name, "Invalid deploy schedule: %v", err)
-	} else if s.ShouldRunDeploySchedule(deploySchedules, now, workspaceState) {
-		logging.LogWorkspace(workspace.Name, "Triggering deployment")
-		go s.deployWorkspace(workspace)
+	} else if s.ShouldRunDeploySchedule(deploySchedules, now, workspaceState) &&
+		!s.skipIfFrozen(workspace.Name, OperationDeploy, "deploy schedule", s.lastDueTime(deploySchedules, now)) &&
+		!s.waitForDependencies(workspace, OperationDeploy) {
+		if workspace.Config.RequiresApproval() {
+			s.requestApproval(workspace, workspaceState, OperationDeploy, "", s.lastDueTime(deploySchedules, now), now)
+		} else {
+			logging.SetCorrelationID(workspace.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+			logging.LogWorkspace(workspace.Name, "Triggering deployment")
+			s.goOperation(func() { s.deployWorkspace(workspace) })
+		}
 	}
 
 	// Check destroy schedules
 	destroySchedules, err := workspace.Config.GetDestroySchedules()
 	if err != nil {
 		logging.LogWorkspace(workspace.Name, "Invalid destroy schedule: %v", err)
-	} else if len(destroySchedules) == 0 {
-		// Permanent deployment - no destroy schedules (destroy_schedule: false)
+	} else if len(destroySchedules) == 0 || workspace.Config.IsProtected() {
+		// Permanent deployment - no destroy schedules (destroy_schedule: false) or a protected workspace
 		// Log only in verbose mode to avoid spam
 	} else {
 		// Check if workspace is protected by environment assignment
 		if protectedBy, isProtected := s.isWorkspaceProtectedByEnvironment(workspace.Name); isProtected {
 			logging.LogWorkspace(workspace.Name, "Skipping scheduled destruction - workspace is assigned to environment '%s'", protectedBy)
-		} else if s.ShouldRunDestroySchedule(destroySchedules, now, workspaceState) {
-			logging.LogWorkspace(workspace.Name, "Triggering destruction")
-			go s.destroyWorkspace(workspace)
+		} else if s.ShouldRunDestroySchedule(destroySchedules, now, workspaceState) &&
+			!s.skipIfFrozen(workspace.Name, OperationDestroy, "destroy schedule", s.lastDestroyDueTime(destroySchedules, workspaceState, now)) &&
+			!s.waitForDependencies(workspace, OperationDestroy) {
+			if workspace.Config.RequiresApproval() {
+				s.requestApproval(workspace, workspaceState, OperationDestroy, "", s.lastDestroyDueTime(destroySchedules, workspaceState, now), now)
+			} else {
+				logging.SetCorrelationID(workspace.Name, logging.NewCorrelationID(now.Truncate(time.Minute)))
+				logging.LogWorkspace(workspace.Name, "Triggering destruction")
+				s.goOperation(func() { s.destroyWorkspace(workspace) })
+			}
 		}
 	}
 
@@ -293,17 +520,30 @@ func (s *Scheduler) checkWorkspaceSchedules(workspace workspace.Workspace, now t
 			jobConfigInterfaces := make([]interface{}, len(jobConfigs))
 			for i, jobConfig := range jobConfigs {
 				jobConfigInterfaces[i] = map[string]interface{}{
-					"name":        jobConfig.Name,
-					"type":        jobConfig.Type,
-					"schedule":    jobConfig.Schedule,
-					"script":      jobConfig.Script,
-					"command":     jobConfig.Command,
-					"template":    jobConfig.Template,
-					"environment": jobConfig.Environment,
-					"working_dir": jobConfig.WorkingDir,
-					"timeout":     jobConfig.Timeout,
-					"enabled":     jobConfig.Enabled,
-					"description": jobConfig.Description,
+					"name":               jobConfig.Name,
+					"type":               jobConfig.Type,
+					"schedule":           jobConfig.Schedule,
+					"script":             jobConfig.Script,
+					"command":            jobConfig.Command,
+					"template":           jobConfig.Template,
+					"environment":        jobConfig.Environment,
+					"working_dir":        jobConfig.WorkingDir,
+					"timeout":            jobConfig.Timeout,
+					"enabled":            jobConfig.Enabled,
+					"description":        jobConfig.Description,
+					"throttle":           jobConfig.Throttle,
+					"jitter":             jobConfig.Jitter,
+					"spread_by_name":     jobConfig.SpreadByName,
+					"retries":            jobConfig.Retries,
+					"retry_delay":        jobConfig.RetryDelay,
+					"on_failure":         jobConfig.OnFailure,
+					"destroy_after":      jobConfig.DestroyAfter,
+					"destroy_schedule":   jobConfig.DestroySchedule,
+					"max_cpu":            jobConfig.MaxCPU,
+					"max_memory":         jobConfig.MaxMemory,
+					"nice":               jobConfig.Nice,
+					"artifacts":          jobConfig.Artifacts,
+					"artifact_retention": jobConfig.ArtifactRetention,
 				}
 			}
 			s.jobManager.ProcessWorkspaceJobs(workspace.Name, jobConfigInterfaces, now)
@@ -313,8 +553,8 @@ func (s *Scheduler) checkWorkspaceSchedules(workspace workspace.Workspace, now t
 
 // ShouldRunDeploySchedule checks if workspace should be deployed based on schedule and current state
 func (s *Scheduler) ShouldRunDeploySchedule(schedules []string, now time.Time, workspaceState *WorkspaceState) bool {
-	// Don't deploy if already deployed
-	if workspaceState.Status == StatusDeployed {
+	// Don't deploy if already deployed, also when its post_deploy hooks failed (wait for config change)
+	if workspaceState.Status == StatusDeployed || workspaceState.Status == StatusDeployDegraded {
 		return false
 	}
 
@@ -323,25 +563,33 @@ func (s *Scheduler) ShouldRunDeploySchedule(schedules []string, now time.Time, w
 		return false
 	}
 
-	// Check if any deploy schedule has passed today and we haven't deployed since then
+	// Don't restart a deployment an operator cancelled or a crash interrupted (wait for manual action or config change)
+	if workspaceState.wasStopped(OperationDeploy) {
+		return false
+	}
+
+	// Check if any deploy schedule is due and we haven't deployed since then
 	for _, scheduleStr := range schedules {
-		schedule, err := ParseCron(scheduleStr)
+		schedule, err := cron.Parse(scheduleStr)
 		if err != nil {
 			logging.LogSystemd("Failed to parse deploy schedule '%s': %v", scheduleStr, err)
 			continue
 		}
 
-		// Find the most recent time this schedule should have run today
-		lastScheduledTime := s.getLastScheduledTimeToday(schedule, now)
+		// Find the most recent time this schedule should have run
+		lastScheduledTime := s.lastScheduledRun(schedule, now)
 		if lastScheduledTime == nil {
-			continue // No scheduled time today
+			continue // No scheduled time due
 		}
 
 		// Check if we should deploy:
 		// 1. The scheduled time has passed
 		// 2. We haven't deployed since that scheduled time
+		// 3. We haven't destroyed since then either, e.g. a deployment kept from an earlier day
+		//    that a destroy schedule just took down
 		if now.After(*lastScheduledTime) {
-			if workspaceState.LastDeployed == nil || workspaceState.LastDeployed.Before(*lastScheduledTime) {
+			if (workspaceState.LastDeployed == nil || workspaceState.LastDeployed.Before(*lastScheduledTime)) &&
+				(workspaceState.LastDestroyed == nil || workspaceState.LastDestroyed.Before(*lastScheduledTime)) {
 				// Note: We don't log here since this will be logged in checkWorkspaceSchedules
 				return true
 			}
@@ -362,18 +610,31 @@ func (s *Scheduler) ShouldRunDestroySchedule(schedules []string, now time.Time,
 		return false
 	}
 
-	// Check if any destroy schedule has passed today and we haven't destroyed since then
+	// Don't restart a destruction an operator cancelled or a crash interrupted (wait for manual action or config change)
+	if workspaceState.wasStopped(OperationDestroy) {
+		return false
+	}
+
+	// Check if any destroy schedule is due and we haven't destroyed since then
 	for _, scheduleStr := range schedules {
-		schedule, err := ParseCron(scheduleStr)
+		// Relative schedules are due a fixed time after the last deploy
+		if due, relative := relativeDestroyTime(scheduleStr, workspaceState); relative {
+			if due != nil && !now.Before(*due) && (workspaceState.LastDestroyed == nil || workspaceState.LastDestroyed.Before(*due)) {
+				return true
+			}
+			continue
+		}
+
+		schedule, err := cron.Parse(scheduleStr)
 		if err != nil {
 			logging.LogSystemd("Failed to parse destroy schedule '%s': %v", scheduleStr, err)
 			continue
 		}
 
-		// Find the most recent time this schedule should have run today
-		lastScheduledTime := s.getLastScheduledTimeToday(schedule, now)
+		// Find the most recent time this schedule should have run
+		lastScheduledTime := s.lastScheduledRun(schedule, now)
 		if lastScheduledTime == nil {
-			continue // No scheduled time today
+			continue // No scheduled time due
 		}
 
 		// Check if we should destroy:
@@ -389,29 +650,10 @@ func (s *Scheduler) ShouldRunDestroySchedule(schedules []string, now time.Time,
 	return false
 }
 
-// getLastScheduledTimeToday finds the most recent time today that matches the CRON schedule
-func (s *Scheduler) getLastScheduledTimeToday(schedule *CronSchedule, now time.Time) *time.Time {
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-
-	// Check each minute of today to find the most recent match
-	var lastMatch *time.Time
-	for minute := 0; minute < 24*60; minute++ {
-		checkTime := today.Add(time.Duration(minute) * time.Minute)
-		if checkTime.After(now) {
-			break // Don't check future times
-		}
-		if schedule.ShouldRun(checkTime) {
-			lastMatch = &checkTime
-		}
-	}
-
-	return lastMatch
-}
-
 // shouldRunAnySchedule checks if any of the provided schedules should run at the given time (legacy exact match)
 func (s *Scheduler) shouldRunAnySchedule(schedules []string, now time.Time) bool {
 	for _, scheduleStr := range schedules {
-		schedule, err := ParseCron(scheduleStr)
+		schedule, err := cron.Parse(scheduleStr)
 		if err != nil {
 			logging.LogSystemd("Failed to parse schedule '%s': %v", scheduleStr, err)
 			continue
@@ -425,12 +667,22 @@ func (s *Scheduler) shouldRunAnySchedule(schedules []string, now time.Time) bool
 
 func (s *Scheduler) deployWorkspace(workspace workspace.Workspace) {
 	workspaceName := workspace.Name
+	defer s.beginCorrelation(workspaceName)()
+	defer s.beginTrigger(workspaceName, audit.Trigger{Source: audit.SourceSchedule})()
+	release := s.acquireOperationSlot(workspaceName, OperationDeploy, workspace.Config.Throttle)
 	logging.LogWorkspaceOperation(workspaceName, "DEPLOY", "Starting deployment")
 
 	s.state.SetWorkspaceStatus(workspaceName, StatusDeploying)
 	_ = s.SaveState()
 
-	if err := s.client.Deploy(&workspace); err != nil {
+	err := s.client.Deploy(&workspace)
+	if isCancelled(err) {
+		s.recordCancellation(workspaceName, "DEPLOY", OperationDeploy, "", err)
+	} else if isDegraded(err) {
+		s.recordDegraded(workspaceName, "DEPLOY", err)
+	} else if err != nil {
+		s.recordTimeout(workspaceName, "DEPLOY", OperationDeploy, "", err)
+
 		// Log high-level failure to systemd
 		logging.LogWorkspaceOperation(workspaceName, "DEPLOY", "Failed: %s", getHighLevelError(err))
 
@@ -443,28 +695,39 @@ func (s *Scheduler) deployWorkspace(workspace workspace.Workspace) {
 		logging.LogSystemd("For detailed error information see: %s", logFile)
 
 		s.state.SetWorkspaceError(workspaceName, true, err.Error())
-
-		// Trigger deployment-failed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEventWithError(EventDeploymentFailed, workspaceName, err.Error()))
+		s.scheduleDeployRetry(workspace, s.currentTime())
 	} else {
 		logging.LogWorkspaceOperation(workspaceName, "DEPLOY", "Successfully completed")
 		s.state.SetWorkspaceStatus(workspaceName, StatusDeployed)
-
-		// Trigger deployment-completed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEvent(EventDeploymentCompleted, workspaceName))
+		s.startRunIfOneShot(workspace)
 	}
+	s.publishOperation(OperationDeploy, workspaceName, "", err)
 
 	_ = s.SaveState()
+	release()
+
+	// Run any operation whose schedule fired while this one was in progress
+	if s.runPendingOperation(workspace) {
+		_ = s.SaveState()
+	}
 }
 
 func (s *Scheduler) destroyWorkspace(workspace workspace.Workspace) {
 	workspaceName := workspace.Name
+	defer s.beginCorrelation(workspaceName)()
+	defer s.beginTrigger(workspaceName, audit.Trigger{Source: audit.SourceSchedule})()
+	release := s.acquireOperationSlot(workspaceName, OperationDestroy, workspace.Config.Throttle)
 	logging.LogWorkspaceOperation(workspaceName, "DESTROY", "Starting destruction")
 
 	s.state.SetWorkspaceStatus(workspaceName, StatusDestroying)
 	_ = s.SaveState()
 
-	if err := s.client.DestroyWorkspace(&workspace); err != nil {
+	err := s.client.DestroyWorkspace(&workspace)
+	if isCancelled(err) {
+		s.recordCancellation(workspaceName, "DESTROY", OperationDestroy, "", err)
+	} else if err != nil {
+		s.recordTimeout(workspaceName, "DESTROY", OperationDestroy, "", err)
+
 		// Log high-level failure to systemd
 		logging.LogWorkspaceOperation(workspaceName, "DESTROY", "Failed: %s", getHighLevelError(err))
 
@@ -477,102 +740,35 @@ func (s *Scheduler) destroyWorkspace(workspace workspace.Workspace) {
 		logging.LogSystemd("For detailed error information see: %s", logFile)
 
 		s.state.SetWorkspaceError(workspaceName, false, err.Error())
-
-		// Trigger destroy-failed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEventWithError(EventDestroyFailed, workspaceName, err.Error()))
 	} else {
 		logging.LogWorkspaceOperation(workspaceName, "DESTROY", "Successfully completed")
 		s.state.SetWorkspaceStatus(workspaceName, StatusDestroyed)
-
-		// Trigger destroy-completed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEvent(EventDestroyCompleted, workspaceName))
 	}
+	s.publishOperation(OperationDestroy, workspaceName, "", err)
 
 	_ = s.SaveState()
-}
-
-// hasConfigChanged checks if any configuration files have been modified
-func (s *Scheduler) hasConfigChanged() bool {
-	workspacesDir := filepath.Join(s.configDir, "workspaces")
-
-	var hasChanged bool
-	workspaceConfigChanges := make(map[string]time.Time)
-
-	// Walk through all workspace directories
-	err := filepath.Walk(workspacesDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return nil // Continue on error
-		}
-
-		// Check config.json and .tf files
-		if filepath.Base(path) == "config.json" || filepath.Ext(path) == ".tf" {
-			if info.ModTime().After(s.lastConfigCheck) {
-				logging.LogSystemd("Config file changed: %s (modified: %s)", path, info.ModTime().Format("2006-01-02 15:04:05"))
-				hasChanged = true
-
-				// Extract workspace name from path
-				workspaceName := filepath.Base(filepath.Dir(path))
-				if existingTime, exists := workspaceConfigChanges[workspaceName]; !exists || info.ModTime().After(existingTime) {
-					workspaceConfigChanges[workspaceName] = info.ModTime()
-				}
-			}
-		}
-
-		return nil
-	})
-
-	if err != nil {
-		logging.LogSystemd("Error walking config directory: %v", err)
-	}
-
-	// Update per-workspace config modification times and check for immediate deployment
-	now := time.Now()
-	for workspaceName, modTime := range workspaceConfigChanges {
-		s.state.SetWorkspaceConfigModified(workspaceName, modTime)
-		logging.LogSystemd("Workspace %s configuration updated, resetting failed state if applicable", workspaceName)
-
-		// Notify job manager of config changes
-		if s.jobManager != nil {
-			s.jobManager.SetJobConfigModified(workspaceName, modTime)
-		}
+	release()
 
-		// Check if this workspace should be deployed immediately
-		s.checkWorkspaceForImmediateDeployment(workspaceName, now)
+	// Run any operation whose schedule fired while this one was in progress
+	if s.runPendingOperation(workspace) {
+		_ = s.SaveState()
 	}
-
-	return hasChanged
 }
 
 // getWorkspaceLogFile returns the log file path for an workspace
 func (s *Scheduler) getWorkspaceLogFile(workspaceName string) string {
-	logDir := getLogDir()
+	logDir := paths.LogDir()
 	return filepath.Join(logDir, fmt.Sprintf("%s.log", workspaceName))
 }
 
-// getLogDir determines the log directory using auto-discovery (same logic as logging package)
-func getLogDir() string {
-	// First check workspace variable (explicit override)
-	if logDir := os.Getenv("PROVISIONER_LOG_DIR"); logDir != "" {
-		return logDir
-	}
-
-	// Auto-detect system installation by checking if /var/log/provisioner exists
-	systemLogDir := "/var/log/provisioner"
-	if _, err := os.Stat(systemLogDir); err == nil {
-		return systemLogDir
-	}
-
-	// Fall back to development default
-	return "logs"
-}
-
 // checkWorkspaceForImmediateDeployment checks if an workspace should be deployed immediately after config change
 func (s *Scheduler) checkWorkspaceForImmediateDeployment(workspaceName string, now time.Time) {
 	// Find the workspace by name
 	var targetWorkspace *workspace.Workspace
-	for i, workspace := range s.workspaces {
+	workspaces := s.loadedWorkspaces()
+	for i, workspace := range workspaces {
 		if workspace.Name == workspaceName {
-			targetWorkspace = &s.workspaces[i]
+			targetWorkspace = &workspaces[i]
 			break
 		}
 	}
@@ -588,10 +784,15 @@ func (s *Scheduler) checkWorkspaceForImmediateDeployment(workspaceName string, n
 		return
 	}
 
-	workspaceState := s.state.GetWorkspaceState(workspaceName)
+	if s.IsWorkspacePaused(workspaceName) {
+		logging.LogWorkspace(workspaceName, "Scheduling is paused, skipping immediate deployment")
+		return
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
 
 	// Skip if workspace is currently being deployed or destroyed
-	if workspaceState.Status == StatusDeploying || workspaceState.Status == StatusDestroying {
+	if workspaceState.IsBusy() {
 		logging.LogWorkspace(workspaceName, "Workspace is busy (%s), skipping immediate deployment", workspaceState.Status)
 		return
 	}
@@ -603,9 +804,17 @@ func (s *Scheduler) checkWorkspaceForImmediateDeployment(workspaceName string, n
 		return
 	}
 
-	if s.ShouldRunDeploySchedule(deploySchedules, now, workspaceState) {
+	if s.ShouldRunDeploySchedule(deploySchedules, now.In(targetWorkspace.Config.GetLocation()), workspaceState) &&
+		!s.waitForDependencies(*targetWorkspace, OperationDeploy) {
+		if targetWorkspace.Config.RequiresApproval() {
+			s.requestApproval(*targetWorkspace, workspaceState, OperationDeploy, "", s.lastDueTime(deploySchedules, now), now)
+			return
+		}
 		logging.LogWorkspace(workspaceName, "Triggering immediate deployment after config change")
-		go s.deployWorkspace(*targetWorkspace)
+		// Mark the workspace busy now so the schedule check of this pass doesn't deploy it again
+		s.state.SetWorkspaceStatus(workspaceName, StatusDeploying)
+		ws := *targetWorkspace
+		s.goOperation(func() { s.deployWorkspace(ws) })
 	}
 }
 
@@ -662,11 +871,22 @@ func getStateDir() string {
 
 // ManualDeploy deploys a specific workspace immediately, bypassing schedule checks
 func (s *Scheduler) ManualDeploy(workspaceName string) error {
+	return s.manualDeploy(workspaceName, false)
+}
+
+// ManualDeployIgnoringBudget deploys a specific workspace immediately, even if its estimated
+// cost exceeds its max_monthly_cost
+func (s *Scheduler) ManualDeployIgnoringBudget(workspaceName string) error {
+	return s.manualDeploy(workspaceName, true)
+}
+
+func (s *Scheduler) manualDeploy(workspaceName string, ignoreBudget bool) error {
 	// Find the workspace by name
 	var targetWorkspace *workspace.Workspace
-	for i, workspace := range s.workspaces {
+	workspaces := s.loadedWorkspaces()
+	for i, workspace := range workspaces {
 		if workspace.Name == workspaceName {
-			targetWorkspace = &s.workspaces[i]
+			targetWorkspace = &workspaces[i]
 			break
 		}
 	}
@@ -680,17 +900,30 @@ func (s *Scheduler) ManualDeploy(workspaceName string) error {
 		return fmt.Errorf("workspace '%s' is disabled in configuration", workspaceName)
 	}
 
-	workspaceState := s.state.GetWorkspaceState(workspaceName)
+	if targetWorkspace.IsTemplateMissing() {
+		return fmt.Errorf("workspace '%s' uses template '%s' which is not installed, cannot deploy", workspaceName, targetWorkspace.Config.Template)
+	}
+
+	if err := s.checkNotFrozen(workspaceName, "deploy"); err != nil {
+		return err
+	}
+	if err := s.checkDependencies(workspaceName, OperationDeploy); err != nil {
+		return err
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
 
 	// Check if workspace is currently busy
-	if workspaceState.Status == StatusDeploying || workspaceState.Status == StatusDestroying {
+	if workspaceState.IsBusy() {
 		return fmt.Errorf("workspace '%s' is currently %s, cannot deploy", workspaceName, workspaceState.Status)
 	}
 
 	logging.LogSystemd("Manual deployment requested for workspace: %s", workspaceName)
 
 	// Execute deployment directly (not in goroutine for immediate feedback)
-	s.manualDeployWorkspace(*targetWorkspace)
+	deploy := *targetWorkspace
+	deploy.IgnoreBudget = ignoreBudget
+	s.manualDeployWorkspace(deploy)
 
 	// Save state after manual operation
 	if err := s.SaveState(); err != nil {
@@ -701,8 +934,18 @@ func (s *Scheduler) ManualDeploy(workspaceName string) error {
 	return nil
 }
 
-// ManualDestroy destroys a specific workspace immediately, bypassing schedule checks
+// ManualDestroy destroys a specific workspace immediately, bypassing schedule checks.
+// Protected workspaces are refused; use ManualDestroyForce for them.
 func (s *Scheduler) ManualDestroy(workspaceName string) error {
+	return s.manualDestroy(workspaceName, false)
+}
+
+// ManualDestroyForce destroys a specific workspace immediately, even if it is protected
+func (s *Scheduler) ManualDestroyForce(workspaceName string) error {
+	return s.manualDestroy(workspaceName, true)
+}
+
+func (s *Scheduler) manualDestroy(workspaceName string, force bool) error {
 	// Check if workspace is protected by environment assignment
 	if protectedBy, isProtected := s.isWorkspaceProtectedByEnvironment(workspaceName); isProtected {
 		return fmt.Errorf("cannot destroy workspace '%s' - it is currently assigned to environment '%s'. Use 'environmentctl switch %s OTHERWORKSPACE' first", workspaceName, protectedBy, protectedBy)
@@ -710,9 +953,10 @@ func (s *Scheduler) ManualDestroy(workspaceName string) error {
 
 	// Find the workspace by name
 	var targetWorkspace *workspace.Workspace
-	for i, workspace := range s.workspaces {
+	workspaces := s.loadedWorkspaces()
+	for i, workspace := range workspaces {
 		if workspace.Name == workspaceName {
-			targetWorkspace = &s.workspaces[i]
+			targetWorkspace = &workspaces[i]
 			break
 		}
 	}
@@ -726,10 +970,25 @@ func (s *Scheduler) ManualDestroy(workspaceName string) error {
 		return fmt.Errorf("workspace '%s' is disabled in configuration", workspaceName)
 	}
 
-	workspaceState := s.state.GetWorkspaceState(workspaceName)
+	if targetWorkspace.Config.IsProtected() && !force {
+		return fmt.Errorf("workspace '%s' is protected, use 'workspacectl destroy %s --force' to destroy it anyway", workspaceName, workspaceName)
+	}
+
+	if targetWorkspace.IsTemplateMissing() {
+		return fmt.Errorf("workspace '%s' uses template '%s' which is not installed, cannot destroy", workspaceName, targetWorkspace.Config.Template)
+	}
+
+	if err := s.checkNotFrozen(workspaceName, "destroy"); err != nil {
+		return err
+	}
+	if err := s.checkDependencies(workspaceName, OperationDestroy); err != nil {
+		return err
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
 
 	// Check if workspace is currently busy
-	if workspaceState.Status == StatusDeploying || workspaceState.Status == StatusDestroying {
+	if workspaceState.IsBusy() {
 		return fmt.Errorf("workspace '%s' is currently %s, cannot destroy", workspaceName, workspaceState.Status)
 	}
 
@@ -749,16 +1008,34 @@ func (s *Scheduler) ManualDestroy(workspaceName string) error {
 
 // GetWorkspace returns a workspace by name
 func (s *Scheduler) GetWorkspace(workspaceName string) *workspace.Workspace {
-	for i, workspace := range s.workspaces {
+	workspaces := s.loadedWorkspaces()
+	for i, workspace := range workspaces {
 		if workspace.Name == workspaceName {
-			return &s.workspaces[i]
+			return &workspaces[i]
 		}
 	}
 	return nil
 }
 
+// GetWorkspaceOutputs returns the outputs of a workspace's deployment
+func (s *Scheduler) GetWorkspaceOutputs(workspaceName string) (map[string]opentofu.OutputValue, error) {
+	return s.client.Output(opentofu.GetWorkingDir(workspaceName))
+}
+
 // ManualDeployInMode deploys a specific workspace in a specific mode immediately
 func (s *Scheduler) ManualDeployInMode(workspaceName, mode string) error {
+	return s.manualDeployInMode(workspaceName, mode, false, false)
+}
+
+// ManualDeployInModeIgnoringBudget deploys a specific workspace in a specific mode immediately,
+// even if its estimated cost exceeds its max_monthly_cost
+func (s *Scheduler) ManualDeployInModeIgnoringBudget(workspaceName, mode string) error {
+	return s.manualDeployInMode(workspaceName, mode, false, true)
+}
+
+// manualDeployInMode deploys a workspace in a mode; redeploy also deploys a workspace already
+// deployed in that mode instead of reporting it as done
+func (s *Scheduler) manualDeployInMode(workspaceName, mode string, redeploy, ignoreBudget bool) error {
 	// Find the workspace by name
 	targetWorkspace := s.GetWorkspace(workspaceName)
 	if targetWorkspace == nil {
@@ -790,16 +1067,27 @@ func (s *Scheduler) ManualDeployInMode(workspaceName, mode string) error {
 		return fmt.Errorf("workspace '%s' uses traditional scheduling. Use 'deploy' command without mode parameter", workspaceName)
 	}
 
-	workspaceState := s.state.GetWorkspaceState(workspaceName)
+	if targetWorkspace.IsTemplateMissing() {
+		return fmt.Errorf("workspace '%s' uses template '%s' which is not installed, cannot deploy", workspaceName, targetWorkspace.Config.Template)
+	}
+
+	if err := s.checkNotFrozen(workspaceName, "deploy"); err != nil {
+		return err
+	}
+	if err := s.checkDependencies(workspaceName, OperationDeploy); err != nil {
+		return err
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
 
 	// Check if workspace is currently busy
-	if workspaceState.Status == StatusDeploying || workspaceState.Status == StatusDestroying {
+	if workspaceState.IsBusy() {
 		return fmt.Errorf("workspace '%s' is currently %s, cannot deploy", workspaceName, workspaceState.Status)
 	}
 
 	// Get current deployment mode
 	currentMode := workspaceState.DeploymentMode
-	if currentMode == mode && workspaceState.Status == StatusDeployed {
+	if currentMode == mode && workspaceState.Status == StatusDeployed && !redeploy {
 		fmt.Printf("Workspace '%s' is already deployed in '%s' mode.\n", workspaceName, mode)
 		return nil
 	}
@@ -821,12 +1109,10 @@ func (s *Scheduler) ManualDeployInMode(workspaceName, mode string) error {
 
 	logging.LogSystemd("Manual deployment requested for workspace: %s in mode: %s", workspaceName, mode)
 
-	// Set the deployment mode in state
-	workspaceState.DeploymentMode = mode
-	s.state.SetWorkspaceState(workspaceName, workspaceState)
-
 	// Execute deployment directly (not in goroutine for immediate feedback)
-	s.manualDeployWorkspaceInMode(*targetWorkspace, mode)
+	deploy := *targetWorkspace
+	deploy.IgnoreBudget = ignoreBudget
+	s.deployWorkspaceInMode(deploy, mode, ModeTriggerManual)
 
 	// Save state after manual operation
 	if err := s.SaveState(); err != nil {
@@ -840,8 +1126,16 @@ func (s *Scheduler) ManualDeployInMode(workspaceName, mode string) error {
 // manualDeployWorkspace is similar to deployWorkspace but for manual operations
 func (s *Scheduler) manualDeployWorkspace(workspace workspace.Workspace) {
 	workspaceName := workspace.Name
+	defer s.beginCorrelation(workspaceName)()
+	defer s.beginTrigger(workspaceName, audit.CLITrigger())()
+	release := s.acquireOperationSlot(workspaceName, OperationDeploy, workspace.Config.Throttle)
+	defer release()
+
 	logging.LogWorkspaceOperation(workspaceName, "MANUAL DEPLOY", "Starting manual deployment")
 
+	// A manual deploy starts over; its failure is not retried automatically
+	s.state.ResetDeployRetries(workspaceName)
+
 	s.state.SetWorkspaceStatus(workspaceName, StatusDeploying)
 	_ = s.SaveState()
 
@@ -851,12 +1145,20 @@ func (s *Scheduler) manualDeployWorkspace(workspace workspace.Workspace) {
 		if err != nil {
 			logging.LogWorkspaceOperation(workspaceName, "MANUAL DEPLOY", "Failed to initialize OpenTofu client: %s", err.Error())
 			s.state.SetWorkspaceError(workspaceName, true, fmt.Sprintf("Failed to initialize OpenTofu client: %s", err.Error()))
+			s.publishOperation(OperationDeploy, workspaceName, "", err)
 			return
 		}
 		s.client = client
 	}
 
-	if err := s.client.Deploy(&workspace); err != nil {
+	err := s.client.Deploy(&workspace)
+	if isCancelled(err) {
+		s.recordCancellation(workspaceName, "MANUAL DEPLOY", OperationDeploy, "", err)
+	} else if isDegraded(err) {
+		s.recordDegraded(workspaceName, "MANUAL DEPLOY", err)
+	} else if err != nil {
+		s.recordTimeout(workspaceName, "MANUAL DEPLOY", OperationDeploy, "", err)
+
 		// Log high-level failure to systemd
 		logging.LogWorkspaceOperation(workspaceName, "MANUAL DEPLOY", "Failed: %s", getHighLevelError(err))
 
@@ -869,22 +1171,45 @@ func (s *Scheduler) manualDeployWorkspace(workspace workspace.Workspace) {
 		logging.LogSystemd("For detailed error information see: %s", logFile)
 
 		s.state.SetWorkspaceError(workspaceName, true, err.Error())
-
-		// Trigger deployment-failed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEventWithError(EventDeploymentFailed, workspaceName, err.Error()))
 	} else {
 		logging.LogWorkspaceOperation(workspaceName, "MANUAL DEPLOY", "Successfully completed")
 		s.state.SetWorkspaceStatus(workspaceName, StatusDeployed)
-
-		// Trigger deployment-completed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEvent(EventDeploymentCompleted, workspaceName))
+		s.startRunIfOneShot(workspace)
 	}
+	s.publishOperation(OperationDeploy, workspaceName, "", err)
 }
 
-// manualDeployWorkspaceInMode is similar to manualDeployWorkspace but deploys in a specific mode
-func (s *Scheduler) manualDeployWorkspaceInMode(workspace workspace.Workspace, mode string) {
+// deployWorkspaceInMode deploys a workspace in a specific mode, started by its mode schedules (or a
+// retry) or by an operator. Successful deploys that change the mode are recorded in mode history.
+func (s *Scheduler) deployWorkspaceInMode(workspace workspace.Workspace, mode, trigger string) {
 	workspaceName := workspace.Name
-	logging.LogWorkspaceOperation(workspaceName, "MANUAL DEPLOY MODE", "Starting manual deployment in mode: %s", mode)
+	defer s.beginCorrelation(workspaceName)()
+	if trigger == ModeTriggerManual {
+		defer s.beginTrigger(workspaceName, audit.CLITrigger())()
+	} else {
+		defer s.beginTrigger(workspaceName, audit.Trigger{Source: audit.SourceSchedule})()
+	}
+	release := s.acquireOperationSlot(workspaceName, OperationDeploy, workspace.Config.Throttle)
+
+	operation := "DEPLOY MODE"
+	if trigger == ModeTriggerManual {
+		operation = "MANUAL DEPLOY MODE"
+		logging.LogWorkspaceOperation(workspaceName, operation, "Starting manual deployment in mode: %s", mode)
+
+		// A manual deploy starts over; its failure is not retried automatically
+		s.state.ResetDeployRetries(workspaceName)
+	} else {
+		logging.LogWorkspaceOperation(workspaceName, operation, "Starting deployment in mode: %s", mode)
+	}
+
+	// Record the target mode; the mode the workspace is leaving is kept for mode history
+	previousMode := ""
+	s.state.UpdateWorkspace(workspaceName, func(workspaceState *WorkspaceState) {
+		if hasDeployment(workspaceState.Status) {
+			previousMode = workspaceState.DeploymentMode
+		}
+		workspaceState.DeploymentMode = mode
+	})
 
 	s.state.SetWorkspaceStatus(workspaceName, StatusDeploying)
 	_ = s.SaveState()
@@ -893,46 +1218,78 @@ func (s *Scheduler) manualDeployWorkspaceInMode(workspace workspace.Workspace, m
 	if s.client == nil {
 		client, err := opentofu.New()
 		if err != nil {
-			logging.LogWorkspaceOperation(workspaceName, "MANUAL DEPLOY MODE", "Failed to initialize OpenTofu client: %s", err.Error())
+			logging.LogWorkspaceOperation(workspaceName, operation, "Failed to initialize OpenTofu client: %s", err.Error())
 			s.state.SetWorkspaceError(workspaceName, true, fmt.Sprintf("Failed to initialize OpenTofu client: %s", err.Error()))
+			s.publishOperation(OperationDeploy, workspaceName, mode, err)
+			release()
 			return
 		}
 		s.client = client
 	}
 
-	if err := s.client.DeployInMode(&workspace, mode); err != nil {
+	err := s.client.DeployInMode(&workspace, mode)
+	if isCancelled(err) {
+		s.recordCancellation(workspaceName, operation, OperationDeploy, mode, err)
+	} else if isDegraded(err) {
+		s.recordDegraded(workspaceName, operation, err)
+	} else if err != nil {
+		s.recordTimeout(workspaceName, operation, OperationDeploy, mode, err)
+
 		// Log high-level failure to systemd
-		logging.LogWorkspaceOperation(workspaceName, "MANUAL DEPLOY MODE", "Failed in mode %s: %s", mode, getHighLevelError(err))
+		logging.LogWorkspaceOperation(workspaceName, operation, "Failed in mode %s: %s", mode, getHighLevelError(err))
 
 		// Log detailed error only to workspace file (strip ANSI colors)
 		cleanError := stripANSIColors(err.Error())
-		logging.LogWorkspaceOnly(workspaceName, "MANUAL DEPLOY MODE (%s): Failed: %s", mode, cleanError)
+		logging.LogWorkspaceOnly(workspaceName, "%s (%s): Failed: %s", operation, mode, cleanError)
 
 		// Add log file location reference to systemd logs for easier debugging
 		logFile := s.getWorkspaceLogFile(workspaceName)
 		logging.LogSystemd("For detailed error information see: %s", logFile)
 
 		s.state.SetWorkspaceError(workspaceName, true, err.Error())
-
-		// Trigger deployment-failed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEventWithError(EventDeploymentFailed, workspaceName, err.Error()))
+		if trigger == ModeTriggerSchedule {
+			s.scheduleDeployRetry(workspace, s.currentTime())
+		}
 	} else {
-		logging.LogWorkspaceOperation(workspaceName, "MANUAL DEPLOY MODE", "Successfully completed in mode: %s", mode)
+		logging.LogWorkspaceOperation(workspaceName, operation, "Successfully completed in mode: %s", mode)
 		s.state.SetWorkspaceStatus(workspaceName, StatusDeployed)
+		s.startRunIfOneShot(workspace)
 
-		// Update deployment mode in state
-		workspaceState := s.state.GetWorkspaceState(workspaceName)
-		workspaceState.DeploymentMode = mode
-		s.state.SetWorkspaceState(workspaceName, workspaceState)
+	}
+	s.publishOperation(OperationDeploy, workspaceName, mode, err)
+	if (err == nil || isDegraded(err)) && previousMode != mode {
+		s.Events().Publish(events.Event{
+			Type:          events.ModeChanged,
+			Time:          s.currentTime(),
+			Workspace:     workspaceName,
+			Mode:          mode,
+			PreviousMode:  previousMode,
+			Trigger:       trigger,
+			CorrelationID: logging.CorrelationID(workspaceName),
+		})
+	}
+
+	release()
+	if trigger == ModeTriggerManual {
+		return
+	}
+
+	_ = s.SaveState()
 
-		// Trigger deployment-completed event with mode information for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEventWithMode(EventDeploymentCompleted, workspaceName, mode))
+	// Run any operation whose schedule fired while this one was in progress
+	if s.runPendingOperation(workspace) {
+		_ = s.SaveState()
 	}
 }
 
 // manualDestroyWorkspace is similar to destroyWorkspace but for manual operations
 func (s *Scheduler) manualDestroyWorkspace(workspace workspace.Workspace) {
 	workspaceName := workspace.Name
+	defer s.beginCorrelation(workspaceName)()
+	defer s.beginTrigger(workspaceName, audit.CLITrigger())()
+	release := s.acquireOperationSlot(workspaceName, OperationDestroy, workspace.Config.Throttle)
+	defer release()
+
 	logging.LogWorkspaceOperation(workspaceName, "MANUAL DESTROY", "Starting manual destruction")
 
 	s.state.SetWorkspaceStatus(workspaceName, StatusDestroying)
@@ -944,12 +1301,18 @@ func (s *Scheduler) manualDestroyWorkspace(workspace workspace.Workspace) {
 		if err != nil {
 			logging.LogWorkspaceOperation(workspaceName, "MANUAL DESTROY", "Failed to initialize OpenTofu client: %s", err.Error())
 			s.state.SetWorkspaceError(workspaceName, false, fmt.Sprintf("Failed to initialize OpenTofu client: %s", err.Error()))
+			s.publishOperation(OperationDestroy, workspaceName, "", err)
 			return
 		}
 		s.client = client
 	}
 
-	if err := s.client.DestroyWorkspace(&workspace); err != nil {
+	err := s.client.DestroyWorkspace(&workspace)
+	if isCancelled(err) {
+		s.recordCancellation(workspaceName, "MANUAL DESTROY", OperationDestroy, "", err)
+	} else if err != nil {
+		s.recordTimeout(workspaceName, "MANUAL DESTROY", OperationDestroy, "", err)
+
 		// Log high-level failure to systemd
 		logging.LogWorkspaceOperation(workspaceName, "MANUAL DESTROY", "Failed: %s", getHighLevelError(err))
 
@@ -962,20 +1325,31 @@ func (s *Scheduler) manualDestroyWorkspace(workspace workspace.Workspace) {
 		logging.LogSystemd("For detailed error information see: %s", logFile)
 
 		s.state.SetWorkspaceError(workspaceName, false, err.Error())
-
-		// Trigger destroy-failed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEventWithError(EventDestroyFailed, workspaceName, err.Error()))
 	} else {
 		logging.LogWorkspaceOperation(workspaceName, "MANUAL DESTROY", "Successfully completed")
 		s.state.SetWorkspaceStatus(workspaceName, StatusDestroyed)
-
-		// Trigger destroy-completed event for jobs
-		s.triggerJobEvent(workspaceName, NewDeploymentEvent(EventDestroyCompleted, workspaceName))
 	}
+	s.publishOperation(OperationDestroy, workspaceName, "", err)
+}
+
+// workspaceStatusOutput is the status of a single workspace as printed by --output json and yaml
+type workspaceStatusOutput struct {
+	Summary WorkspaceSummary `json:"summary"`
+	State   WorkspaceState   `json:"state"` // Scheduler state with errors redacted
 }
 
-// ShowStatus displays the status of workspaces
-func (s *Scheduler) ShowStatus(workspaceName string) error {
+// statusOutput is the status of all workspaces as printed by --output json and yaml
+type statusOutput struct {
+	PausedSince *time.Time         `json:"paused_since"` // Set while scheduling is paused for all workspaces
+	Workspaces  []WorkspaceSummary `json:"workspaces"`
+}
+
+// ShowStatus displays the status of a workspace, or of all workspaces selected by opts. The
+// columns of opts replace the status table, and the details of a single workspace.
+func (s *Scheduler) ShowStatus(workspaceName string, opts listing.Options, format output.Format) error {
+	if err := opts.Validate(WorkspaceListFields); err != nil {
+		return err
+	}
 	if err := s.LoadWorkspaces(); err != nil {
 		return fmt.Errorf("failed to load workspaces: %w", err)
 	}
@@ -990,15 +1364,42 @@ func (s *Scheduler) ShowStatus(workspaceName string) error {
 		if workspace == nil {
 			return fmt.Errorf("workspace '%s' not found", workspaceName)
 		}
+		if len(opts.Columns) > 0 && !format.Structured() {
+			summary := s.summarizeWorkspace(*workspace, s.state.Workspace(workspace.Name), time.Now())
+			return listing.WriteColumns(os.Stdout, []WorkspaceSummary{summary}, opts.Columns)
+		}
+		if format.Structured() {
+			state := s.state.Workspace(workspace.Name)
+			redacted := *state
+			redacted.LastDeployError = logging.RedactWorkspace(workspace.Name, state.LastDeployError)
+			redacted.LastDestroyError = logging.RedactWorkspace(workspace.Name, state.LastDestroyError)
+			return output.Print(format, workspaceStatusOutput{
+				Summary: s.summarizeWorkspace(*workspace, state, time.Now()),
+				State:   redacted,
+			})
+		}
 		s.printWorkspaceStatus(*workspace)
+	} else if format.Structured() {
+		result := listing.Apply(s.WorkspaceSummaries(time.Now()), opts)
+		return output.Print(format, statusOutput{PausedSince: s.state.GetPausedSince(), Workspaces: result.Entries})
 	} else {
 		// Show all workspaces status
-		fmt.Printf("%-15s %-12s %-20s %-20s %-10s\n", "WORKSPACE", "STATUS", "LAST DEPLOYED", "LAST DESTROYED", "ERRORS")
-		fmt.Printf("%-15s %-12s %-20s %-20s %-10s\n", "-----------", "------", "-------------", "--------------", "------")
+		if pausedSince := s.state.GetPausedSince(); pausedSince != nil {
+			fmt.Printf("Scheduling paused for all workspaces since %s (run 'provisioner resume-all' to resume)\n\n",
+				logging.FormatTime(*pausedSince))
+		}
+		if len(opts.Columns) > 0 {
+			return showColumns(listing.Apply(s.WorkspaceSummaries(time.Now()), opts), opts.Columns)
+		}
+		fmt.Printf("%-15s %-12s %-22s %-22s %-22s %-22s %-10s\n", "WORKSPACE", "STATUS", "LAST DEPLOYED", "LAST DESTROYED", "NEXT DEPLOY", "NEXT DESTROY", "ERRORS")
+		fmt.Printf("%-15s %-12s %-22s %-22s %-22s %-22s %-10s\n", "-----------", "------", "-------------", "--------------", "-----------", "------------", "------")
 
-		for _, workspace := range s.workspaces {
-			state := s.state.GetWorkspaceState(workspace.Name)
-			s.printWorkspaceStatusLine(workspace, state)
+		result := listing.Apply(s.WorkspaceSummaries(time.Now()), opts)
+		for _, summary := range result.Entries {
+			s.printWorkspaceStatusLine(summary)
+		}
+		if summary := result.Summary(); summary != "" {
+			fmt.Printf("\n%s\n", summary)
 		}
 	}
 
@@ -1014,7 +1415,7 @@ func (s *Scheduler) ListWorkspaces() error {
 	fmt.Printf("%-15s %-8s %-30s %-30s\n", "WORKSPACE", "ENABLED", "DEPLOY SCHEDULE", "DESTROY SCHEDULE")
 	fmt.Printf("%-15s %-8s %-30s %-30s\n", "-----------", "-------", "---------------", "----------------")
 
-	for _, workspace := range s.workspaces {
+	for _, workspace := range s.loadedWorkspaces() {
 		deploySchedules, _ := workspace.Config.GetDeploySchedules()
 		destroySchedules, _ := workspace.Config.GetDestroySchedules()
 
@@ -1031,8 +1432,8 @@ func (s *Scheduler) ListWorkspaces() error {
 	return nil
 }
 
-// ShowLogs displays recent logs for an workspace
-func (s *Scheduler) ShowLogs(workspaceName string) error {
+// ShowLogs displays recent logs for an workspace, optionally following new lines until interrupted
+func (s *Scheduler) ShowLogs(workspaceName string, opts LogOptions) error {
 	if err := s.LoadWorkspaces(); err != nil {
 		return fmt.Errorf("failed to load workspaces: %w", err)
 	}
@@ -1045,29 +1446,46 @@ func (s *Scheduler) ShowLogs(workspaceName string) error {
 	logFile := s.getWorkspaceLogFile(workspaceName)
 
 	// Check if log file exists
-	if _, err := os.Stat(logFile); os.IsNotExist(err) {
+	if _, err := os.Stat(logFile); os.IsNotExist(err) && !opts.Follow {
 		fmt.Printf("No log file found for workspace '%s'\n", workspaceName)
 		fmt.Printf("Expected location: %s\n", logFile)
 		return nil
 	}
 
-	// Read and display the log file
-	data, err := os.ReadFile(logFile)
-	if err != nil {
+	fmt.Printf("=== Recent logs for workspace '%s' ===\n", workspaceName)
+	fmt.Printf("Log file: %s\n\n", logFile)
+
+	// Display the selected part of the log file
+	offset, err := printLog(os.Stdout, logFile, opts, time.Now())
+	if err != nil && !(opts.Follow && os.IsNotExist(err)) {
 		return fmt.Errorf("failed to read log file: %w", err)
 	}
 
-	fmt.Printf("=== Recent logs for workspace '%s' ===\n", workspaceName)
-	fmt.Printf("Log file: %s\n\n", logFile)
-	fmt.Print(string(data))
+	if !opts.Follow {
+		return nil
+	}
+
+	return followLogUntilInterrupted(os.Stdout, logFile, offset)
+}
 
+// WriteLogs writes the selected part of a workspace log to w without following it. A workspace
+// that has not logged anything yet writes nothing.
+func (s *Scheduler) WriteLogs(w io.Writer, workspaceName string, opts LogOptions) error {
+	if s.findWorkspace(workspaceName) == nil {
+		return fmt.Errorf("workspace '%s' not found", workspaceName)
+	}
+
+	opts.Follow = false
+	if _, err := printLog(w, s.getWorkspaceLogFile(workspaceName), opts, time.Now()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read log file: %w", err)
+	}
 	return nil
 }
 
 // Helper methods for CLI commands
 
 func (s *Scheduler) findWorkspace(name string) *workspace.Workspace {
-	for _, workspace := range s.workspaces {
+	for _, workspace := range s.loadedWorkspaces() {
 		if workspace.Name == name {
 			return &workspace
 		}
@@ -1076,7 +1494,7 @@ func (s *Scheduler) findWorkspace(name string) *workspace.Workspace {
 }
 
 func (s *Scheduler) printWorkspaceStatus(workspace workspace.Workspace) {
-	state := s.state.GetWorkspaceState(workspace.Name)
+	state := s.state.Workspace(workspace.Name)
 
 	deploySchedules, _ := workspace.Config.GetDeploySchedules()
 	destroySchedules, _ := workspace.Config.GetDestroySchedules()
@@ -1084,103 +1502,281 @@ func (s *Scheduler) printWorkspaceStatus(workspace workspace.Workspace) {
 	// Use actual OpenTofu state as source of truth for deployment status
 	actualStatus := workspace.GetDeploymentStatus()
 
+	// A deployed one-shot workspace is still working towards completion
+	displayStatus := actualStatus
+	if actualStatus == "deployed" && state.Status == StatusRunning {
+		displayStatus = string(StatusRunning)
+	}
+	if state.Status == StatusCancelled || state.Status == StatusInterrupted {
+		displayStatus = string(state.Status)
+	}
+	if state.Status == StatusQueued {
+		displayStatus = fmt.Sprintf("%s (%s waiting for an operation slot)", StatusQueued, state.QueuedOperation)
+	}
+	if workspace.IsTemplateMissing() {
+		displayStatus = fmt.Sprintf("%s (template '%s' not installed, schedules skipped)", StatusTemplateMissing, workspace.Config.Template)
+	}
+
 	fmt.Printf("Workspace: %s\n", workspace.Name)
-	fmt.Printf("Status: %s\n", actualStatus)
+	fmt.Printf("Status: %s\n", displayStatus)
 	fmt.Printf("Enabled: %t\n", workspace.Config.Enabled)
 	fmt.Printf("Deploy Schedule: %s\n", formatSchedules(deploySchedules))
 	fmt.Printf("Destroy Schedule: %s\n", formatSchedules(destroySchedules))
+	if len(state.ScheduledOperations) > 0 {
+		fmt.Printf("Scheduled Once: %s\n", formatScheduledOperations(state.ScheduledOperations))
+	}
+	if due := nextRelativeDestroy(destroySchedules, state); due != nil && !workspace.Config.IsProtected() {
+		fmt.Printf("Destroy Due: %s (relative to the last deploy at %s)\n", logging.FormatTime(*due), logging.FormatTime(*state.LastDeployed))
+	}
+	if workspace.Config.Enabled {
+		now := time.Now()
+		fmt.Printf("Next Deploy: %s\n", formatNextRun(nextDeployRun(workspace, state, now)))
+		fmt.Printf("Next Destroy: %s\n", formatNextRun(nextDestroyRun(workspace, state, now)))
+	}
+	if modeSchedules, err := workspace.Config.GetModeSchedules(); err == nil && len(modeSchedules) > 0 {
+		fmt.Printf("Mode Schedules: %s\n", formatModeSchedules(modeSchedules))
+		if state.DeploymentMode != "" {
+			fmt.Printf("Deployment Mode: %s\n", state.DeploymentMode)
+		}
+		if len(state.ModeHistory) > 0 {
+			fmt.Printf("Mode History:\n")
+			for _, change := range state.ModeHistory[max(0, len(state.ModeHistory)-5):] {
+				fmt.Printf("  %s\n", formatModeChange(change))
+			}
+		}
+	}
+	if workspace.Config.Timezone != "" {
+		fmt.Printf("Timezone: %s\n", workspace.Config.Timezone)
+	}
+	if workspace.Config.Tier != "" {
+		fmt.Printf("Tier: %s\n", workspace.Config.Tier)
+	}
+	if len(workspace.Config.DependsOn) > 0 {
+		fmt.Printf("Depends On: %s\n", strings.Join(workspace.Config.DependsOn, ", "))
+	}
+	if state.WaitingFor != "" {
+		fmt.Printf("Waiting: %s\n", state.WaitingFor)
+	}
+	if workspace.Config.IsProtected() {
+		fmt.Printf("Protected: yes (destroy schedules skipped, manual destroy needs --force)\n")
+	}
+	if workspace.Config.RequiresApproval() {
+		if timeout := workspace.Config.GetApprovalTimeout(); timeout > 0 {
+			fmt.Printf("Requires Approval: yes (requests expire after %v)\n", timeout)
+		} else {
+			fmt.Printf("Requires Approval: yes\n")
+		}
+	}
+	if operation := state.PendingApproval(); operation != "" {
+		fmt.Printf("Awaiting Approval: %s since %s (run 'workspacectl approve %s' to approve)\n",
+			describeApproval(operation, state.ApprovalMode), logging.FormatTime(*state.ApprovalRequested), workspace.Name)
+	}
+	if paused := s.formatPaused(state); paused != "" {
+		fmt.Printf("Paused: %s\n", paused)
+	}
+	if freeze := state.Freeze; freeze != nil {
+		if freeze.Reason != "" {
+			fmt.Printf("Frozen: since %s (%s)\n", logging.FormatTime(freeze.Since), freeze.Reason)
+		} else {
+			fmt.Printf("Frozen: since %s\n", logging.FormatTime(freeze.Since))
+		}
+		if len(state.SkippedWhileFrozen) > 0 {
+			fmt.Printf("Skipped While Frozen:\n")
+			for _, skipped := range state.SkippedWhileFrozen[max(0, len(state.SkippedWhileFrozen)-5):] {
+				fmt.Printf("  %s\n", formatSkippedOperation(skipped))
+			}
+		}
+	}
+	if len(state.EnvironmentHistory) > 0 {
+		fmt.Printf("Environment History:\n")
+		for _, change := range state.EnvironmentHistory[max(0, len(state.EnvironmentHistory)-5):] {
+			fmt.Printf("  %s\n", formatEnvironmentSwitch(change))
+		}
+	}
+	if workspace.IsDebugLoggingEnabled() {
+		fmt.Printf("Debug Logging: on (%s)\n", workspace.GetDebugLogDir())
+	}
 
 	// Use filesystem timestamps as more accurate source, fall back to managed state
 	if stateChangeTime := workspace.GetLastStateChangeTime(); stateChangeTime != nil {
 		if actualStatus == "deployed" {
-			fmt.Printf("Last Deployed: %s\n", stateChangeTime.Format("2006-01-02 15:04:05"))
+			fmt.Printf("Last Deployed: %s\n", logging.FormatTime(*stateChangeTime))
 			if state.LastDestroyed != nil {
-				fmt.Printf("Last Destroyed: %s\n", state.LastDestroyed.Format("2006-01-02 15:04:05"))
+				fmt.Printf("Last Destroyed: %s\n", logging.FormatTime(*state.LastDestroyed))
 			} else {
 				fmt.Printf("Last Destroyed: Never\n")
 			}
 		} else {
 			if state.LastDeployed != nil {
-				fmt.Printf("Last Deployed: %s\n", state.LastDeployed.Format("2006-01-02 15:04:05"))
+				fmt.Printf("Last Deployed: %s\n", logging.FormatTime(*state.LastDeployed))
 			} else {
 				fmt.Printf("Last Deployed: Never\n")
 			}
-			fmt.Printf("Last Destroyed: %s\n", stateChangeTime.Format("2006-01-02 15:04:05"))
+			fmt.Printf("Last Destroyed: %s\n", logging.FormatTime(*stateChangeTime))
 		}
 	} else {
 		// Fall back to managed state timestamps
 		if state.LastDeployed != nil {
-			fmt.Printf("Last Deployed: %s\n", state.LastDeployed.Format("2006-01-02 15:04:05"))
+			fmt.Printf("Last Deployed: %s\n", logging.FormatTime(*state.LastDeployed))
 		} else {
 			fmt.Printf("Last Deployed: Never\n")
 		}
 
 		if state.LastDestroyed != nil {
-			fmt.Printf("Last Destroyed: %s\n", state.LastDestroyed.Format("2006-01-02 15:04:05"))
+			fmt.Printf("Last Destroyed: %s\n", logging.FormatTime(*state.LastDestroyed))
 		} else {
 			fmt.Printf("Last Destroyed: Never\n")
 		}
 	}
 
 	if state.LastConfigModified != nil {
-		fmt.Printf("Config Modified: %s\n", state.LastConfigModified.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Config Modified: %s\n", logging.FormatTime(*state.LastConfigModified))
 	}
 
 	if state.LastDeployError != "" {
-		fmt.Printf("Last Deploy Error: %s\n", state.LastDeployError)
+		fmt.Printf("Last Deploy Error: %s\n", logging.RedactWorkspace(workspace.Name, state.LastDeployError))
 	}
 
 	if state.LastDestroyError != "" {
-		fmt.Printf("Last Destroy Error: %s\n", state.LastDestroyError)
+		fmt.Printf("Last Destroy Error: %s\n", logging.RedactWorkspace(workspace.Name, state.LastDestroyError))
 	}
 
-	logFile := s.getWorkspaceLogFile(workspace.Name)
-	fmt.Printf("Log File: %s\n", logFile)
-}
-
-func (s *Scheduler) printWorkspaceStatusLine(workspace workspace.Workspace, state *WorkspaceState) {
-	// Use actual OpenTofu state as source of truth for deployment status
-	actualStatus := workspace.GetDeploymentStatus()
+	if results, err := opentofu.LoadOperationResults(workspace.Name); err == nil {
+		if results.Deploy != nil {
+			fmt.Printf("Last Deploy Result: %s\n", formatOperationResult(results.Deploy))
+		}
+		if results.Destroy != nil {
+			fmt.Printf("Last Destroy Result: %s\n", formatOperationResult(results.Destroy))
+		}
+		if cost := formatCostEstimate(results.Deploy, workspace.Config.MaxMonthlyCost); cost != "" {
+			fmt.Printf("Estimated Cost: %s\n", cost)
+		}
+	}
 
-	// Use filesystem timestamps as more accurate source, fall back to managed state
-	lastDeployed := "Never"
-	lastDestroyed := "Never"
+	if workspace.Config.IsRunToCompletion() {
+		timeout, _ := workspace.Config.GetCompletionTimeout()
+		fmt.Printf("Run To Completion: yes (timeout %v)\n", timeout)
+		if state.Status == StatusRunning && state.RunStarted != nil {
+			fmt.Printf("Run Started: %s (deadline %s)\n",
+				logging.FormatTime(*state.RunStarted),
+				logging.FormatTime(state.RunStarted.Add(timeout)))
+		}
+		if state.LastRunResult != "" && state.LastRunFinished != nil {
+			fmt.Printf("Last Run: %s at %s\n", state.LastRunResult, logging.FormatTime(*state.LastRunFinished))
+		}
+	}
 
-	if stateChangeTime := workspace.GetLastStateChangeTime(); stateChangeTime != nil {
-		if actualStatus == "deployed" {
-			lastDeployed = stateChangeTime.Format("2006-01-02 15:04")
-			// Use managed state for last destroyed if available
-			if state.LastDestroyed != nil {
-				lastDestroyed = state.LastDestroyed.Format("2006-01-02 15:04")
-			}
-		} else {
-			lastDestroyed = stateChangeTime.Format("2006-01-02 15:04")
-			// Use managed state for last deployed if available
-			if state.LastDeployed != nil {
-				lastDeployed = state.LastDeployed.Format("2006-01-02 15:04")
+	if workspace.Config.HasMaxLifetime() {
+		maxLifetime, _ := workspace.Config.GetMaxLifetime()
+		lifetime := fmt.Sprintf("%v (%s)", maxLifetime, workspace.Config.GetMaxLifetimeAction())
+		if hasDeployment(state.Status) {
+			if age := state.DeploymentAge(time.Now()); age > 0 {
+				lifetime += fmt.Sprintf(", deployed for %v", age.Round(time.Minute))
 			}
 		}
-	} else {
-		// Fall back to managed state timestamps
-		if state.LastDeployed != nil {
-			lastDeployed = state.LastDeployed.Format("2006-01-02 15:04")
+		fmt.Printf("Max Lifetime: %s\n", lifetime)
+	}
+
+	if workspace.Config.HasTTL() {
+		ttl, _ := workspace.Config.GetTTL()
+		fmt.Printf("TTL: %s\n", formatTTL(ttl, state, time.Now()))
+	}
+
+	if check := workspace.Config.IdleCheck; check != nil {
+		fmt.Printf("Idle Check: %s\n", formatIdleStatus(check, state, time.Now()))
+	}
+
+	if pending := state.PendingOperation; pending != nil {
+		fmt.Printf("Pending Operation: %s (queued %s, expires %s)\n", pending.Operation,
+			logging.FormatTime(pending.QueuedAt),
+			logging.FormatTime(pending.ExpiresAt))
+	}
+
+	if cancellation := state.LastCancellation; cancellation != nil {
+		fmt.Printf("Last Cancellation: %s\n", formatCancellation(cancellation))
+	}
+
+	if interruption := state.LastInterruption; interruption != nil {
+		fmt.Printf("Last Interruption: %s\n", formatInterruption(interruption))
+	}
+
+	if timeout := state.LastTimeout; timeout != nil {
+		fmt.Printf("Last Timeout: %s\n", formatTimeout(timeout))
+	}
+
+	if state.DeployRetries > 0 || state.NextDeployRetry != nil {
+		retries := fmt.Sprintf("%d", state.DeployRetries)
+		if workspace.Config.Retry != nil {
+			retries = fmt.Sprintf("%d of %d", state.DeployRetries, workspace.Config.Retry.MaxAttempts)
 		}
-		if state.LastDestroyed != nil {
-			lastDestroyed = state.LastDestroyed.Format("2006-01-02 15:04")
+		if state.NextDeployRetry != nil {
+			retries += fmt.Sprintf(" (next retry %s)", logging.FormatTime(*state.NextDeployRetry))
+		}
+		fmt.Printf("Deploy Retries: %s\n", retries)
+	}
+
+	s.printSuccessRates(workspace, time.Now())
+
+	if state.LastCorrelationID != "" {
+		fmt.Printf("Last Correlation ID: %s\n", state.LastCorrelationID)
+	}
+
+	logFile := s.getWorkspaceLogFile(workspace.Name)
+	fmt.Printf("Log File: %s\n", logFile)
+}
+
+// formatOperationResult describes a recorded deploy or destroy result
+func formatOperationResult(r *opentofu.OperationResult) string {
+	outcome := "succeeded"
+	if !r.Success {
+		outcome = "failed"
+	}
+	return fmt.Sprintf("%s at %s, %s", outcome, logging.FormatTime(r.FinishedAt), r.Summary())
+}
+
+// formatCostEstimate describes the estimated cost of the last deploy against the budget, empty
+// if the deploy was not estimated
+func formatCostEstimate(deploy *opentofu.OperationResult, budget float64) string {
+	if deploy == nil || deploy.CostEstimate == nil {
+		return ""
+	}
+	estimate := deploy.CostEstimate
+	description := fmt.Sprintf("%s (estimated %s)", estimate, logging.FormatTime(estimate.EstimatedAt))
+	if budget > 0 {
+		description += fmt.Sprintf(", budget %.2f", budget)
+		if estimate.MonthlyCost > budget {
+			description += " EXCEEDED"
 		}
 	}
+	return description
+}
 
-	errors := "None"
-	if state.LastDeployError != "" || state.LastDestroyError != "" {
-		errors = "Yes"
+func (s *Scheduler) printWorkspaceStatusLine(summary WorkspaceSummary) {
+	lastDeployed := "Never"
+	if summary.LastDeployed != nil {
+		lastDeployed = logging.FormatTimeShort(*summary.LastDeployed)
+	}
+	lastDestroyed := "Never"
+	if summary.LastDestroyed != nil {
+		lastDestroyed = logging.FormatTimeShort(*summary.LastDestroyed)
 	}
 
-	fmt.Printf("%-15s %-12s %-20s %-20s %-10s\n",
-		workspace.Name,
-		actualStatus,
+	fmt.Printf("%-15s %-12s %-22s %-22s %-22s %-22s %-10s\n",
+		summary.Workspace.Name,
+		summary.Status,
 		lastDeployed,
 		lastDestroyed,
-		errors)
+		formatNextRun(summary.NextDeploy),
+		formatNextRun(summary.NextDestroy),
+		summary.Errors)
+}
+
+// formatNextRun formats the next run of a schedule for status output, "-" if none is scheduled
+func formatNextRun(next *time.Time) string {
+	if next == nil {
+		return "-"
+	}
+	return logging.FormatTimeShort(*next)
 }
 
 func formatSchedules(schedules []string) string {
@@ -1200,6 +1796,56 @@ func (s *Scheduler) GetJobManager() *job.Manager {
 	return s.jobManager
 }
 
+// GetTemplateManager returns the template manager
+func (s *Scheduler) GetTemplateManager() *template.Manager {
+	return s.templateManager
+}
+
+// IsWorkspaceBusy returns true if the workspace is currently deploying, destroying or queued
+func (s *Scheduler) IsWorkspaceBusy(workspaceName string) bool {
+	if s.state == nil {
+		return false
+	}
+	return s.state.Workspace(workspaceName).IsBusy()
+}
+
+// GetDeployError returns why a workspace's last deploy failed, nil if the workspace is deployed
+func (s *Scheduler) GetDeployError(workspaceName string) error {
+	state := s.state.Workspace(workspaceName)
+	switch {
+	case state.Status == StatusDeployed || state.Status == StatusRunning:
+		return nil
+	case state.LastDeployError != "":
+		return fmt.Errorf("%s", state.LastDeployError)
+	default:
+		return fmt.Errorf("workspace is %s", state.Status)
+	}
+}
+
+// GetDestroyError returns why a workspace's last destroy failed, nil if the workspace is destroyed
+func (s *Scheduler) GetDestroyError(workspaceName string) error {
+	state := s.state.Workspace(workspaceName)
+	switch {
+	case state.Status == StatusDestroyed:
+		return nil
+	case state.LastDestroyError != "":
+		return fmt.Errorf("%s", state.LastDestroyError)
+	default:
+		return fmt.Errorf("workspace is %s", state.Status)
+	}
+}
+
+// WrapClient makes the scheduler loop run its operations through the client wrap returns for its
+// OpenTofu client, e.g. to run workspaces on remote agents. It must be called before Start.
+func (s *Scheduler) WrapClient(wrap func(opentofu.TofuClient) opentofu.TofuClient) {
+	s.wrapClient = wrap
+}
+
+// IsReady returns true once the scheduler loop has initialized its OpenTofu client
+func (s *Scheduler) IsReady() bool {
+	return s.client != nil && s.jobManager != nil
+}
+
 // GetStandaloneJobManager returns the standalone job manager (for CLI access)
 func (s *Scheduler) GetStandaloneJobManager() *job.StandaloneJobManager {
 	return s.standaloneJobManager
@@ -1207,17 +1853,47 @@ func (s *Scheduler) GetStandaloneJobManager() *job.StandaloneJobManager {
 
 // ManualExecuteJob executes a job immediately via CLI
 func (s *Scheduler) ManualExecuteJob(workspaceID, jobName string) error {
+	configMap, err := s.getWorkspaceJobConfigMap(workspaceID, jobName)
+	if err != nil {
+		return err
+	}
+
+	return s.jobManager.ManualExecuteJob(workspaceID, jobName, configMap)
+}
+
+// ManualExecuteJobRun executes a job via CLI, recording progress under an existing run record
+func (s *Scheduler) ManualExecuteJobRun(workspaceID, jobName, runID string) error {
+	configMap, err := s.getWorkspaceJobConfigMap(workspaceID, jobName)
+	if err != nil {
+		// Record the failure so anyone waiting on the run sees it
+		if s.jobManager != nil {
+			if run, getErr := s.jobManager.GetRun(workspaceID, jobName, runID); getErr == nil {
+				now := time.Now()
+				run.Status = job.JobStatusFailed
+				run.Error = err.Error()
+				run.EndTime = &now
+				_ = s.jobManager.SaveRun(run)
+			}
+		}
+		return err
+	}
+
+	return s.jobManager.ManualExecuteJobRun(workspaceID, jobName, runID, configMap)
+}
+
+// getWorkspaceJobConfigMap finds a workspace job and converts it to the job manager's config format
+func (s *Scheduler) getWorkspaceJobConfigMap(workspaceID, jobName string) (map[string]interface{}, error) {
 	if s.jobManager == nil {
 		// Initialize job manager if not already done
 		if err := s.initJobManager(); err != nil {
-			return fmt.Errorf("failed to initialize job manager: %w", err)
+			return nil, fmt.Errorf("failed to initialize job manager: %w", err)
 		}
 	}
 
 	// Get the workspace
 	workspace := s.GetWorkspace(workspaceID)
 	if workspace == nil {
-		return fmt.Errorf("workspace '%s' not found", workspaceID)
+		return nil, fmt.Errorf("workspace '%s' not found", workspaceID)
 	}
 
 	// Find the job configuration
@@ -1229,17 +1905,27 @@ func (s *Scheduler) ManualExecuteJob(workspaceID, jobName string) error {
 		if jc.Name == jobName {
 			// Convert to interface{} format expected by job manager
 			configMap = map[string]interface{}{
-				"name":        jc.Name,
-				"type":        jc.Type,
-				"schedule":    jc.Schedule,
-				"script":      jc.Script,
-				"command":     jc.Command,
-				"template":    jc.Template,
-				"environment": jc.Environment,
-				"working_dir": jc.WorkingDir,
-				"timeout":     jc.Timeout,
-				"enabled":     jc.Enabled,
-				"description": jc.Description,
+				"name":               jc.Name,
+				"type":               jc.Type,
+				"schedule":           jc.Schedule,
+				"script":             jc.Script,
+				"command":            jc.Command,
+				"template":           jc.Template,
+				"environment":        jc.Environment,
+				"working_dir":        jc.WorkingDir,
+				"timeout":            jc.Timeout,
+				"enabled":            jc.Enabled,
+				"description":        jc.Description,
+				"retries":            jc.Retries,
+				"retry_delay":        jc.RetryDelay,
+				"on_failure":         jc.OnFailure,
+				"destroy_after":      jc.DestroyAfter,
+				"destroy_schedule":   jc.DestroySchedule,
+				"max_cpu":            jc.MaxCPU,
+				"max_memory":         jc.MaxMemory,
+				"nice":               jc.Nice,
+				"artifacts":          jc.Artifacts,
+				"artifact_retention": jc.ArtifactRetention,
 			}
 			hasJob = true
 			break
@@ -1247,10 +1933,10 @@ func (s *Scheduler) ManualExecuteJob(workspaceID, jobName string) error {
 	}
 
 	if !hasJob {
-		return fmt.Errorf("job '%s' not found in workspace '%s'", jobName, workspaceID)
+		return nil, fmt.Errorf("job '%s' not found in workspace '%s'", jobName, workspaceID)
 	}
 
-	return s.jobManager.ManualExecuteJob(workspaceID, jobName, configMap)
+	return configMap, nil
 }
 
 // KillJob kills a running job
@@ -1280,6 +1966,69 @@ func (s *Scheduler) GetJobState(workspaceID, jobName string) *job.JobState {
 	return s.jobManager.GetJobState(workspaceID, jobName)
 }
 
+// GetJobHistory returns the kept runs of a workspace job, newest first
+func (s *Scheduler) GetJobHistory(workspaceID, jobName string) ([]job.HistoryEntry, error) {
+	if _, err := s.getWorkspaceJobConfigMap(workspaceID, jobName); err != nil {
+		return nil, err
+	}
+
+	return s.jobManager.GetHistory(workspaceID, jobName), nil
+}
+
+// GetJobHistoryEntry returns a run from the history of a workspace job
+func (s *Scheduler) GetJobHistoryEntry(workspaceID, jobName, runID string) (*job.HistoryEntry, error) {
+	if s.jobManager == nil {
+		return nil, fmt.Errorf("job manager not initialized")
+	}
+
+	return s.jobManager.GetHistoryEntry(workspaceID, jobName, runID)
+}
+
+// GetJobArtifacts returns the artifacts of a workspace job's run, or of all its runs if runID is empty
+func (s *Scheduler) GetJobArtifacts(workspaceID, jobName, runID string) ([]job.Artifact, error) {
+	if _, err := s.getWorkspaceJobConfigMap(workspaceID, jobName); err != nil {
+		return nil, err
+	}
+
+	return s.jobManager.ListArtifacts(workspaceID, jobName, runID)
+}
+
+// DownloadJobArtifacts copies the artifacts of a workspace job's run into destDir
+func (s *Scheduler) DownloadJobArtifacts(workspaceID, jobName, runID, destDir string) ([]job.Artifact, error) {
+	if _, err := s.getWorkspaceJobConfigMap(workspaceID, jobName); err != nil {
+		return nil, err
+	}
+
+	return s.jobManager.DownloadArtifacts(workspaceID, jobName, runID, destDir)
+}
+
+// CreateJobRun registers a new pending run for a workspace job
+func (s *Scheduler) CreateJobRun(workspaceID, jobName string) (*job.RunRecord, error) {
+	if _, err := s.getWorkspaceJobConfigMap(workspaceID, jobName); err != nil {
+		return nil, err
+	}
+
+	return s.jobManager.CreateRun(workspaceID, jobName)
+}
+
+// GetJobRun returns the record of a workspace job run
+func (s *Scheduler) GetJobRun(workspaceID, jobName, runID string) (*job.RunRecord, error) {
+	if s.jobManager == nil {
+		return nil, fmt.Errorf("job manager not initialized")
+	}
+
+	return s.jobManager.GetRun(workspaceID, jobName, runID)
+}
+
+// WaitForJobRun blocks until a workspace job run finishes or the timeout expires
+func (s *Scheduler) WaitForJobRun(workspaceID, jobName, runID string, timeout time.Duration) (*job.RunRecord, error) {
+	if s.jobManager == nil {
+		return nil, fmt.Errorf("job manager not initialized")
+	}
+
+	return s.jobManager.WaitForRun(workspaceID, jobName, runID, timeout, time.Second)
+}
+
 // initJobManager initializes the job manager if not already done
 func (s *Scheduler) initJobManager() error {
 	if s.jobManager != nil {
@@ -1302,6 +2051,8 @@ func (s *Scheduler) initJobManager() error {
 	// Initialize standalone job manager
 	jobsDir := filepath.Join(s.configDir, "jobs")
 	s.standaloneJobManager = job.NewStandaloneJobManager(jobsDir, stateDir, s.jobManager)
+	s.initJobThrottle()
+	s.initJobFinishedHandler()
 
 	// Load job state
 	if err := s.jobManager.LoadState(); err != nil {
@@ -1335,18 +2086,31 @@ func (s *Scheduler) triggerJobEvent(workspaceID string, event *DeploymentEvent)
 	jobConfigInterfaces := make([]interface{}, len(jobConfigs))
 	for i, jobConfig := range jobConfigs {
 		jobConfigInterfaces[i] = map[string]interface{}{
-			"name":        jobConfig.Name,
-			"type":        jobConfig.Type,
-			"schedule":    jobConfig.Schedule,
-			"script":      jobConfig.Script,
-			"command":     jobConfig.Command,
-			"template":    jobConfig.Template,
-			"environment": jobConfig.Environment,
-			"working_dir": jobConfig.WorkingDir,
-			"timeout":     jobConfig.Timeout,
-			"enabled":     jobConfig.Enabled,
-			"description": jobConfig.Description,
-			"depends_on":  jobConfig.DependsOn,
+			"name":               jobConfig.Name,
+			"type":               jobConfig.Type,
+			"schedule":           jobConfig.Schedule,
+			"script":             jobConfig.Script,
+			"command":            jobConfig.Command,
+			"template":           jobConfig.Template,
+			"environment":        jobConfig.Environment,
+			"working_dir":        jobConfig.WorkingDir,
+			"timeout":            jobConfig.Timeout,
+			"enabled":            jobConfig.Enabled,
+			"description":        jobConfig.Description,
+			"depends_on":         jobConfig.DependsOn,
+			"throttle":           jobConfig.Throttle,
+			"jitter":             jobConfig.Jitter,
+			"spread_by_name":     jobConfig.SpreadByName,
+			"retries":            jobConfig.Retries,
+			"retry_delay":        jobConfig.RetryDelay,
+			"on_failure":         jobConfig.OnFailure,
+			"destroy_after":      jobConfig.DestroyAfter,
+			"destroy_schedule":   jobConfig.DestroySchedule,
+			"max_cpu":            jobConfig.MaxCPU,
+			"max_memory":         jobConfig.MaxMemory,
+			"nice":               jobConfig.Nice,
+			"artifacts":          jobConfig.Artifacts,
+			"artifact_retention": jobConfig.ArtifactRetention,
 		}
 	}
 
@@ -50,8 +50,8 @@ func TestSchedulerDeployWorkspace(t *testing.T) {
 	scheduler.deployWorkspace(workspace)
 
 	// Verify mock was called
-	if mockClient.DeployCallCount != 1 {
-		t.Errorf("expected 1 deploy call, got %d", mockClient.DeployCallCount)
+	if mockClient.GetDeployCallCount() != 1 {
+		t.Errorf("expected 1 deploy call, got %d", mockClient.GetDeployCallCount())
 	}
 
 	deployWorkspace := mockClient.GetLastDeployWorkspace()
@@ -146,8 +146,8 @@ func TestSchedulerDestroyWorkspace(t *testing.T) {
 	scheduler.destroyWorkspace(workspace)
 
 	// Verify mock was called
-	if mockClient.DestroyCallCount != 1 {
-		t.Errorf("expected 1 destroy call, got %d", mockClient.DestroyCallCount)
+	if mockClient.GetDestroyCallCount() != 1 {
+		t.Errorf("expected 1 destroy call, got %d", mockClient.GetDestroyCallCount())
 	}
 
 	destroyWorkspace := mockClient.GetLastDestroyWorkspace()
@@ -179,6 +179,7 @@ func TestSchedulerCheckWorkspaceSchedules(t *testing.T) {
 
 	// Create scheduler
 	scheduler := NewWithClient(mockClient)
+	scheduler.statePath = filepath.Join(t.TempDir(), "scheduler.json")
 	scheduler.state = NewState()
 
 	// Create test workspace with schedules that should trigger
@@ -203,14 +204,14 @@ func TestSchedulerCheckWorkspaceSchedules(t *testing.T) {
 	maxRetries := 50
 	for i := 0; i < maxRetries; i++ {
 		time.Sleep(10 * time.Millisecond)
-		if mockClient.DeployCallCount == 1 {
+		if mockClient.GetDeployCallCount() == 1 {
 			break
 		}
 	}
 
 	// Verify deploy was called
-	if mockClient.DeployCallCount != 1 {
-		t.Errorf("expected 1 deploy call, got %d", mockClient.DeployCallCount)
+	if mockClient.GetDeployCallCount() != 1 {
+		t.Errorf("expected 1 deploy call, got %d", mockClient.GetDeployCallCount())
 	}
 
 	// Reset mock and set workspace as deployed
@@ -223,14 +224,14 @@ func TestSchedulerCheckWorkspaceSchedules(t *testing.T) {
 	// Wait for goroutine to complete with retries for coverage runs
 	for i := 0; i < maxRetries; i++ {
 		time.Sleep(10 * time.Millisecond)
-		if mockClient.DestroyCallCount == 1 {
+		if mockClient.GetDestroyCallCount() == 1 {
 			break
 		}
 	}
 
 	// Verify destroy was called
-	if mockClient.DestroyCallCount != 1 {
-		t.Errorf("expected 1 destroy call, got %d", mockClient.DestroyCallCount)
+	if mockClient.GetDestroyCallCount() != 1 {
+		t.Errorf("expected 1 destroy call, got %d", mockClient.GetDestroyCallCount())
 	}
 }
 
@@ -243,6 +244,7 @@ func TestSchedulerSkipsBusyWorkspaces(t *testing.T) {
 
 	mockClient := opentofu.NewMockTofuClient()
 	scheduler := NewWithClient(mockClient)
+	scheduler.statePath = filepath.Join(t.TempDir(), "scheduler.json")
 	scheduler.state = NewState()
 
 	workspace := workspace.Workspace{
@@ -266,12 +268,12 @@ func TestSchedulerSkipsBusyWorkspaces(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 
 	// Verify no operations were called
-	if mockClient.DeployCallCount != 0 {
-		t.Errorf("expected 0 deploy calls for busy workspace, got %d", mockClient.DeployCallCount)
+	if mockClient.GetDeployCallCount() != 0 {
+		t.Errorf("expected 0 deploy calls for busy workspace, got %d", mockClient.GetDeployCallCount())
 	}
 
-	if mockClient.DestroyCallCount != 0 {
-		t.Errorf("expected 0 destroy calls for busy workspace, got %d", mockClient.DestroyCallCount)
+	if mockClient.GetDestroyCallCount() != 0 {
+		t.Errorf("expected 0 destroy calls for busy workspace, got %d", mockClient.GetDestroyCallCount())
 	}
 }
 
@@ -390,6 +392,7 @@ func TestSchedulerMultipleDeploySchedules(t *testing.T) {
 
 	// Create scheduler with mock
 	scheduler := NewWithClient(mockClient)
+	scheduler.statePath = filepath.Join(t.TempDir(), "scheduler.json")
 
 	// Initialize state properly
 	scheduler.state = NewState()
@@ -412,11 +415,11 @@ func TestSchedulerMultipleDeploySchedules(t *testing.T) {
 	scheduler.checkWorkspaceSchedules(scheduler.workspaces[0], mondayAM)
 
 	// Wait for goroutine to complete
-	time.Sleep(10 * time.Millisecond)
+	scheduler.operations.Wait()
 
 	// Verify deployment was attempted
-	if mockClient.DeployCallCount != 1 {
-		t.Errorf("expected 1 deploy call for Monday 9am schedule, got %d", mockClient.DeployCallCount)
+	if mockClient.GetDeployCallCount() != 1 {
+		t.Errorf("expected 1 deploy call for Monday 9am schedule, got %d", mockClient.GetDeployCallCount())
 	}
 
 	// Reset mock
@@ -428,15 +431,16 @@ func TestSchedulerMultipleDeploySchedules(t *testing.T) {
 	// so the new schedule window can trigger
 	scheduler.state.SetWorkspaceStatus("test-workspace", StatusDestroyed)
 	workspaceState := scheduler.state.GetWorkspaceState("test-workspace")
-	workspaceState.LastDeployed = nil // Clear last deployed time to allow new schedule
+	workspaceState.LastDeployed = nil  // Clear last deployed time to allow new schedule
+	workspaceState.LastDestroyed = nil // A destroy after the scheduled time would hold the deploy back
 	scheduler.checkWorkspaceSchedules(scheduler.workspaces[0], mondayPM)
 
 	// Wait for goroutine to complete
-	time.Sleep(10 * time.Millisecond)
+	scheduler.operations.Wait()
 
 	// Verify deployment was attempted again
-	if mockClient.DeployCallCount != 1 {
-		t.Errorf("expected 1 deploy call for Monday 2pm schedule, got %d", mockClient.DeployCallCount)
+	if mockClient.GetDeployCallCount() != 1 {
+		t.Errorf("expected 1 deploy call for Monday 2pm schedule, got %d", mockClient.GetDeployCallCount())
 	}
 
 	// Test Monday 10:00 AM - should NOT trigger deploy (no schedule)
@@ -446,10 +450,24 @@ func TestSchedulerMultipleDeploySchedules(t *testing.T) {
 	scheduler.checkWorkspaceSchedules(scheduler.workspaces[0], mondayMid)
 
 	// Wait for potential goroutine (shouldn't happen)
-	time.Sleep(10 * time.Millisecond)
+	scheduler.operations.Wait()
 
 	// Verify deployment was NOT attempted
-	if mockClient.DeployCallCount != 0 {
-		t.Errorf("expected 0 deploy calls for Monday 10am (no matching schedule), got %d", mockClient.DeployCallCount)
+	if mockClient.GetDeployCallCount() != 0 {
+		t.Errorf("expected 0 deploy calls for Monday 10am (no matching schedule), got %d", mockClient.GetDeployCallCount())
+	}
+}
+
+func BenchmarkShouldRunDeploySchedule(b *testing.B) {
+	scheduler := &Scheduler{}
+	workspaceState := &WorkspaceState{Status: StatusDestroyed}
+	schedules := []string{"0 9 * * 1-5", "30 8,12,17 * * 1-5", "*/15 6-20 * * *"}
+	now := time.Date(2024, 6, 17, 23, 59, 30, 0, time.UTC)
+
+	// Roughly one tick for a fleet of 500 workspaces
+	for i := 0; i < b.N; i++ {
+		for w := 0; w < 500; w++ {
+			scheduler.ShouldRunDeploySchedule(schedules, now, workspaceState)
+		}
 	}
 }
@@ -0,0 +1,274 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"provisioner/pkg/events"
+	"provisioner/pkg/job"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/notify"
+	"provisioner/pkg/slo"
+	"provisioner/pkg/workspace"
+)
+
+// successRateKey returns the key of a success-rate tracker in the state: the workspace's deploys
+// without a job name, otherwise the job's runs
+func successRateKey(workspaceID, jobName string) string {
+	if jobName == "" {
+		return "deploy:" + workspaceID
+	}
+	return "job:" + workspaceID + "/" + jobName
+}
+
+// recordSuccessRate records a deploy or job run in its success rate. Cancelled deploys are not
+// published to it; degraded deploys and timed-out job runs count as failed.
+func (s *Scheduler) recordSuccessRate(event events.Event) {
+	if event.Type == events.JobCompleted {
+		s.recordJobRun(event.Workspace, event.Job, event.JobStatus == string(job.JobStatusSuccess))
+		return
+	}
+	s.recordDeploy(event.Workspace, event.Type == events.WorkspaceDeployed)
+}
+
+// recordDeploy records the outcome of a deploy in the workspace's deploy success rate
+func (s *Scheduler) recordDeploy(workspaceName string, succeeded bool) {
+	var window slo.Window
+	var objective float64
+	if ws := s.findWorkspace(workspaceName); ws != nil {
+		window, objective = ws.Config.GetSLOWindow(), ws.Config.GetDeployObjective()
+	}
+	s.recordRun(workspaceName, "", window, objective, succeeded)
+}
+
+// recordJobRun records the outcome of a job run in the job's success rate
+func (s *Scheduler) recordJobRun(workspaceID, jobName string, succeeded bool) {
+	var window slo.Window
+	var objective float64
+	if workspaceID == standaloneWorkspaceID {
+		config := s.standaloneJobSLO(jobName)
+		window, objective = config.GetWindow(), config.GetObjective()
+	} else if ws := s.findWorkspace(workspaceID); ws != nil {
+		window, objective = ws.Config.GetSLOWindow(), ws.Config.GetJobObjective()
+	}
+	s.recordRun(workspaceID, jobName, window, objective, succeeded)
+}
+
+// recordRun adds a run to its success-rate tracker and compares the rate with its objective,
+// notifying once when a breach starts and logging when it ends
+func (s *Scheduler) recordRun(workspaceID, jobName string, window slo.Window, objective float64, succeeded bool) {
+	if s.state == nil {
+		return
+	}
+
+	now := s.currentTime()
+	var rate slo.Rate
+	var changed, breached bool
+	s.state.UpdateSuccessRate(successRateKey(workspaceID, jobName), func(tracker *slo.Tracker) {
+		tracker.Record(slo.Run{Time: now, Succeeded: succeeded}, window)
+		rate = tracker.Rate(window, now)
+		changed = tracker.Evaluate(rate, objective)
+		breached = tracker.Breached
+	})
+
+	if changed {
+		subject := "deploys"
+		if jobName != "" {
+			subject = fmt.Sprintf("runs of job %s", jobName)
+		}
+		message := fmt.Sprintf("%s of %s succeeded, objective %g%%", rate, subject, objective)
+		if breached {
+			logging.LogWorkspaceOperation(workspaceID, "SLO", "Breached: %s", message)
+			s.notifySLOBreached(workspaceID, jobName, message)
+		} else {
+			logging.LogWorkspaceOperation(workspaceID, "SLO", "Recovered: %s", message)
+		}
+	}
+	_ = s.SaveState()
+}
+
+// standaloneJobSLO returns the success-rate objective of a standalone job, nil without one
+func (s *Scheduler) standaloneJobSLO(jobName string) *job.SLOConfig {
+	if s.standaloneJobManager == nil {
+		return nil
+	}
+	jobs, err := s.standaloneJobManager.ListStandaloneJobs()
+	if err != nil {
+		return nil
+	}
+	for _, config := range jobs {
+		if config.Name == jobName {
+			return config.SLO
+		}
+	}
+	return nil
+}
+
+// notifySLOBreached sends an alert for a success rate that fell below its objective
+func (s *Scheduler) notifySLOBreached(workspaceID, jobName, message string) {
+	if !s.notifier.Enabled() {
+		return
+	}
+
+	// Standalone jobs have no workspace but log to their own file
+	workspaceName := workspaceID
+	if workspaceName == standaloneWorkspaceID {
+		workspaceName = ""
+	}
+
+	s.notifier.Send(notify.Notification{
+		Event:     notify.EventSLOBreached,
+		Workspace: workspaceName,
+		Job:       jobName,
+		Message:   message,
+		Channel:   s.notificationChannel(workspaceID),
+		LogFile:   s.getWorkspaceLogFile(workspaceID),
+	})
+}
+
+// SuccessRateReport is the success rate of a workspace's deploys, of one of its jobs or of a
+// standalone job
+type SuccessRateReport struct {
+	Workspace string   `json:"workspace,omitempty"` // Empty for standalone jobs
+	Job       string   `json:"job,omitempty"`       // Empty for deploys
+	Rate      slo.Rate `json:"rate"`
+	Objective float64  `json:"objective,omitempty"` // 0 without an objective
+	Breached  bool     `json:"breached"`
+}
+
+// SuccessRateReports returns the deploy and job success rates of all loaded workspaces and those
+// of standalone jobs, each within its SLO window
+func (s *Scheduler) SuccessRateReports(now time.Time) []SuccessRateReport {
+	var reports []SuccessRateReport
+	for _, ws := range s.loadedWorkspaces() {
+		window := ws.Config.GetSLOWindow()
+		reports = append(reports, s.successRateReport(ws.Name, "", window, ws.Config.GetDeployObjective(), now))
+		for _, jobConfig := range ws.Config.Jobs {
+			reports = append(reports, s.successRateReport(ws.Name, jobConfig.Name, window, ws.Config.GetJobObjective(), now))
+		}
+	}
+
+	if s.standaloneJobManager != nil {
+		jobs, err := s.standaloneJobManager.ListStandaloneJobs()
+		if err != nil {
+			logging.LogSystemd("Failed to load standalone jobs for success rates: %v", err)
+		}
+		for _, config := range jobs {
+			report := s.successRateReport(standaloneWorkspaceID, config.Name, config.SLO.GetWindow(), config.SLO.GetObjective(), now)
+			report.Workspace = ""
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// successRateReport computes a single success rate from its tracker
+func (s *Scheduler) successRateReport(workspaceID, jobName string, window slo.Window, objective float64, now time.Time) SuccessRateReport {
+	var rate slo.Rate
+	if s.state != nil {
+		rate = s.state.SuccessRate(successRateKey(workspaceID, jobName), window, now)
+	}
+
+	return SuccessRateReport{
+		Workspace: workspaceID,
+		Job:       jobName,
+		Rate:      rate,
+		Objective: objective,
+		Breached:  slo.IsBreached(rate, objective),
+	}
+}
+
+// printSuccessRates prints the deploy success rate of a workspace and, with a job objective,
+// those of its jobs
+func (s *Scheduler) printSuccessRates(ws workspace.Workspace, now time.Time) {
+	window := ws.Config.GetSLOWindow()
+	deploy := s.successRateReport(ws.Name, "", window, ws.Config.GetDeployObjective(), now)
+	fmt.Printf("Deploy Success Rate: %s\n", formatSuccessRate(deploy))
+
+	if ws.Config.GetJobObjective() == 0 {
+		return
+	}
+	for _, jobConfig := range ws.Config.Jobs {
+		report := s.successRateReport(ws.Name, jobConfig.Name, window, ws.Config.GetJobObjective(), now)
+		fmt.Printf("Job Success Rate (%s): %s\n", jobConfig.Name, formatSuccessRate(report))
+	}
+}
+
+// formatSuccessRate describes a success rate and its objective for status output
+func formatSuccessRate(report SuccessRateReport) string {
+	if report.Objective == 0 {
+		return report.Rate.String()
+	}
+	status := "met"
+	if report.Breached {
+		status = "breached"
+	}
+	return fmt.Sprintf("%s, objective %g%% %s", report.Rate, report.Objective, status)
+}
+
+// RunSuccessRatesCommand prints the success rates of all deploys and jobs against their objectives
+func RunSuccessRatesCommand(args []string) error {
+	jsonOutput := false
+	for _, arg := range args {
+		switch arg {
+		case "--json":
+			jsonOutput = true
+		default:
+			return fmt.Errorf("unknown option '%s'", arg)
+		}
+	}
+
+	sched := NewQuiet()
+	if err := sched.LoadWorkspaces(); err != nil {
+		return err
+	}
+	if err := sched.LoadState(); err != nil {
+		return err
+	}
+	reports := sched.SuccessRateReports(time.Now())
+
+	if jsonOutput {
+		data, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal success rates: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("No workspaces or standalone jobs found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "WORKSPACE\tOPERATION\tRUNS\tSUCCEEDED\tRATE\tOBJECTIVE\tSTATUS")
+	for _, report := range reports {
+		workspaceName, operation := report.Workspace, OperationDeploy
+		if workspaceName == "" {
+			workspaceName = "-"
+		}
+		if report.Job != "" {
+			operation = "job " + report.Job
+		}
+		rate, objective, status := "-", "-", "-"
+		if report.Rate.Runs > 0 {
+			rate = fmt.Sprintf("%.1f%%", report.Rate.Percent)
+		}
+		if report.Objective > 0 {
+			objective = fmt.Sprintf("%g%%", report.Objective)
+			if report.Rate.Runs > 0 {
+				status = "met"
+				if report.Breached {
+					status = "BREACHED"
+				}
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%s\n", workspaceName, operation,
+			report.Rate.Runs, report.Rate.Succeeded, rate, objective, status)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"provisioner/pkg/job"
+	"provisioner/pkg/notify"
+	"provisioner/pkg/workspace"
+)
+
+// sloWebhook collects the slo_breached notifications sent to a test webhook
+type sloWebhook struct {
+	mu       sync.Mutex
+	received []notify.Notification
+}
+
+// newSLOWebhook subscribes a test webhook to slo_breached notifications of the scheduler
+func newSLOWebhook(t *testing.T, scheduler *Scheduler) *sloWebhook {
+	t.Helper()
+	webhook := &sloWebhook{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n notify.Notification
+		if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+			t.Errorf("Failed to decode notification: %v", err)
+		}
+		webhook.mu.Lock()
+		webhook.received = append(webhook.received, n)
+		webhook.mu.Unlock()
+	}))
+	t.Cleanup(server.Close)
+
+	scheduler.notifier = notify.NewWithConfig(&notify.Config{Webhooks: []notify.WebhookConfig{
+		{URL: server.URL, Subscription: notify.Subscription{Events: []string{notify.EventSLOBreached}}, LogLines: -1},
+	}})
+	return webhook
+}
+
+// take returns and clears the notifications received so far
+func (w *sloWebhook) take() []notify.Notification {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	received := w.received
+	w.received = nil
+	return received
+}
+
+func TestDeploySLOBreachNotifiesOnce(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	webhook := newSLOWebhook(t, scheduler)
+
+	ws := busyApp(scheduler)
+	ws.Config.SLO = &workspace.SLOConfig{DeploySuccessRate: 60, WindowRuns: 3}
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	outcomes := []struct {
+		fail     bool
+		breached bool
+		notified bool
+	}{
+		{fail: true, breached: true, notified: true}, // 0 of 1
+		{fail: true, breached: true},                 // 0 of 2, already notified
+		{fail: false, breached: true},                // 1 of 3
+		{fail: false, breached: false},               // 2 of the last 3
+		{fail: true, breached: false},                // 2 of the last 3
+		{fail: true, breached: true, notified: true}, // 1 of the last 3, a new breach
+	}
+	for i, outcome := range outcomes {
+		mockClient.DeployFunc = func(*workspace.Workspace) error {
+			if outcome.fail {
+				return errors.New("apply failed")
+			}
+			return nil
+		}
+		scheduler.manualDeployWorkspace(ws)
+
+		tracker := scheduler.state.SuccessRates[successRateKey(ws.Name, "")]
+		if tracker.Breached != outcome.breached {
+			t.Fatalf("Deploy %d: expected breached %v, got %v", i+1, outcome.breached, tracker.Breached)
+		}
+		notifications := webhook.take()
+		if outcome.notified != (len(notifications) == 1) || len(notifications) > 1 {
+			t.Fatalf("Deploy %d: expected notified %v, got %+v", i+1, outcome.notified, notifications)
+		}
+		if len(tracker.Runs) > 3 {
+			t.Fatalf("Deploy %d: expected at most the 3 runs of the window to be kept, got %d", i+1, len(tracker.Runs))
+		}
+	}
+
+	reports := scheduler.SuccessRateReports(time.Now())
+	if len(reports) != 1 || reports[0].Rate.Runs != 3 || reports[0].Rate.Succeeded != 1 || !reports[0].Breached {
+		t.Errorf("Expected 1 of the last 3 deploys to have succeeded, got %+v", reports)
+	}
+}
+
+func TestJobSLOBreach(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	webhook := newSLOWebhook(t, scheduler)
+
+	ws := busyApp(scheduler)
+	ws.Config.SLO = &workspace.SLOConfig{JobSuccessRate: 99}
+	ws.Config.Jobs = []workspace.JobConfig{{Name: "backup", Type: "command", Command: "false", Enabled: true}}
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	scheduler.jobFinished(&job.JobExecution{WorkspaceID: ws.Name, JobName: "backup", Status: job.JobStatusTimeout})
+
+	notifications := webhook.take()
+	if len(notifications) != 1 || notifications[0].Job != "backup" || notifications[0].Workspace != ws.Name {
+		t.Fatalf("Expected a breach of the backup job's objective, got %+v", notifications)
+	}
+
+	reports := scheduler.SuccessRateReports(time.Now())
+	if len(reports) != 2 || reports[1].Job != "backup" || !reports[1].Breached || reports[0].Breached {
+		t.Errorf("Expected the job's objective to be reported breached, got %+v", reports)
+	}
+}
+
+func TestStandaloneJobSLO(t *testing.T) {
+	scheduler, _ := newMockScenario(t)
+	webhook := newSLOWebhook(t, scheduler)
+
+	jobsDir := filepath.Join(t.TempDir(), "jobs")
+	if err := os.MkdirAll(jobsDir, 0755); err != nil {
+		t.Fatalf("Failed to create jobs dir: %v", err)
+	}
+	config := `{"name": "cleanup", "type": "command", "command": "true", "schedule": "0 2 * * *", "enabled": true, "slo": {"success_rate": 50}}`
+	if err := os.WriteFile(filepath.Join(jobsDir, "cleanup.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write job config: %v", err)
+	}
+	scheduler.standaloneJobManager = job.NewStandaloneJobManager(jobsDir, t.TempDir(), nil)
+
+	scheduler.jobFinished(&job.JobExecution{WorkspaceID: standaloneWorkspaceID, JobName: "cleanup", Status: job.JobStatusSuccess})
+	scheduler.jobFinished(&job.JobExecution{WorkspaceID: standaloneWorkspaceID, JobName: "cleanup", Status: job.JobStatusFailed})
+	if notifications := webhook.take(); len(notifications) != 0 {
+		t.Fatalf("Expected 1 of 2 runs to meet the objective, got %+v", notifications)
+	}
+
+	scheduler.jobFinished(&job.JobExecution{WorkspaceID: standaloneWorkspaceID, JobName: "cleanup", Status: job.JobStatusFailed})
+	notifications := webhook.take()
+	if len(notifications) != 1 || notifications[0].Job != "cleanup" || notifications[0].Workspace != "" {
+		t.Fatalf("Expected a breach of the standalone job's objective, got %+v", notifications)
+	}
+
+	// Standalone jobs are reported after the workspaces
+	reports := scheduler.SuccessRateReports(time.Now())
+	standalone := reports[len(reports)-1]
+	if standalone.Workspace != "" || standalone.Job != "cleanup" || standalone.Rate.Runs != 3 || !standalone.Breached {
+		t.Errorf("Expected the standalone job's rate to be reported, got %+v", reports)
+	}
+}
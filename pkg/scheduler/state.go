@@ -4,8 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"path/filepath"
+	"sort"
+	"sync"
 	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/slo"
+	"provisioner/pkg/statefile"
 )
 
 type WorkspaceStatus string
@@ -18,22 +23,255 @@ const (
 	StatusDestroying    WorkspaceStatus = "destroying"
 	StatusDeployFailed  WorkspaceStatus = "deploy_failed"
 	StatusDestroyFailed WorkspaceStatus = "destroy_failed"
+	StatusRunning       WorkspaceStatus = "running"     // Run-to-completion workspace deployed and awaiting completion
+	StatusCancelled     WorkspaceStatus = "cancelled"   // Deploy or destroy cancelled by an operator
+	StatusQueued        WorkspaceStatus = "queued"      // Waiting for a free slot under max_concurrent_operations
+	StatusInterrupted   WorkspaceStatus = "interrupted" // Deploy or destroy stopped by a daemon crash or restart
+
+	// StatusDeployDegraded is a deployed workspace whose post_deploy hooks failed
+	StatusDeployDegraded WorkspaceStatus = "deploy_degraded"
+
+	// StatusTemplateMissing is shown (never stored) for workspaces whose template is not installed
+	StatusTemplateMissing WorkspaceStatus = "template_missing"
+
+	// StatusPendingApproval is shown (never stored) for workspaces with a scheduled operation awaiting approval
+	StatusPendingApproval WorkspaceStatus = "pending_approval"
 )
 
+// Outcomes of a run-to-completion workspace run
+const (
+	RunResultCompleted = "completed"
+	RunResultTimedOut  = "timed_out"
+)
+
+// PendingOperation is a scheduled operation queued while the workspace was busy
+type PendingOperation struct {
+	Operation string    `json:"operation"`
+	QueuedAt  time.Time `json:"queued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ScheduledOperation is a one-shot deploy or destroy an operator scheduled with workspacectl --at
+type ScheduledOperation struct {
+	Operation   string    `json:"operation"`
+	Mode        string    `json:"mode,omitempty"` // Deployment mode of a deploy, empty for a normal deploy
+	At          time.Time `json:"at"`
+	RequestedAt time.Time `json:"requested_at"`
+	RequestedBy string    `json:"requested_by,omitempty"` // User who scheduled it, the audit log actor when it runs
+}
+
+// Cancellation records how far a cancelled deploy or destroy got
+type Cancellation struct {
+	Operation      string    `json:"operation"`
+	Mode           string    `json:"mode,omitempty"`
+	CancelledAt    time.Time `json:"cancelled_at"`
+	Step           string    `json:"step,omitempty"`
+	CompletedSteps []string  `json:"completed_steps,omitempty"`
+	StateResources int       `json:"state_resources"` // Resources left in state after cancellation
+}
+
+// Timeout records a deploy or destroy stopped for running longer than its deploy_timeout or
+// destroy_timeout
+type Timeout struct {
+	Operation      string    `json:"operation"`
+	Mode           string    `json:"mode,omitempty"`
+	Timeout        string    `json:"timeout"` // The limit that expired, e.g. "30m0s"
+	TimedOutAt     time.Time `json:"timed_out_at"`
+	Step           string    `json:"step,omitempty"`
+	CompletedSteps []string  `json:"completed_steps,omitempty"`
+}
+
+// Interruption records a deploy or destroy found still running when the daemon started, whose
+// process had died
+type Interruption struct {
+	Operation      string    `json:"operation"`
+	Mode           string    `json:"mode,omitempty"`
+	DetectedAt     time.Time `json:"detected_at"`
+	StateResources int       `json:"state_resources"` // Resources in state when it was detected
+	Recovery       string    `json:"recovery"`        // interrupted_recovery applied: none, retry or refresh
+}
+
+// ModeChange records a successful deploy that changed a workspace's deployment mode
+type ModeChange struct {
+	From    string    `json:"from,omitempty"` // Empty if the workspace was not deployed
+	To      string    `json:"to"`
+	At      time.Time `json:"at"`
+	Trigger string    `json:"trigger"` // "schedule" or "manual"
+}
+
+// EnvironmentSwitch records an environment switched from one workspace to another
+type EnvironmentSwitch struct {
+	Environment string    `json:"environment"`
+	From        string    `json:"from,omitempty"` // Empty if the environment had no workspace assigned
+	To          string    `json:"to"`
+	At          time.Time `json:"at"`
+}
+
+// Health of an environment as recorded by the daemon's periodic health checks
+const (
+	EnvironmentHealthy   = "healthy"
+	EnvironmentFailing   = "failing"  // Latest checks failed, but fewer than the failure threshold
+	EnvironmentDegraded  = "degraded" // Failure threshold reached, alert sent
+	EnvironmentUnchecked = "unchecked"
+)
+
+// EnvironmentHealth records the daemon's health checks of an environment
+type EnvironmentHealth struct {
+	Workspace           string     `json:"workspace"` // Workspace the environment was assigned to when checked
+	LastCheck           *time.Time `json:"last_check,omitempty"`
+	LastHealthy         *time.Time `json:"last_healthy,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	DegradedSince       *time.Time `json:"degraded_since,omitempty"` // Set once the failure threshold was reached
+
+	checking bool // A check is running in the background
+}
+
+// Status returns whether the environment is healthy, failing, degraded or not checked yet
+func (h *EnvironmentHealth) Status() string {
+	switch {
+	case h.DegradedSince != nil:
+		return EnvironmentDegraded
+	case h.ConsecutiveFailures > 0:
+		return EnvironmentFailing
+	case h.LastCheck == nil:
+		return EnvironmentUnchecked
+	}
+	return EnvironmentHealthy
+}
+
+// Freeze pins a workspace to its current deployment until it is unfrozen
+type Freeze struct {
+	Since  time.Time `json:"since"`
+	Reason string    `json:"reason,omitempty"`
+}
+
+// SkippedOperation records an automatic operation suppressed while the workspace was frozen
+type SkippedOperation struct {
+	Operation string    `json:"operation"` // e.g. "destroy" or "deploy in mode busy"
+	Reason    string    `json:"reason"`    // What triggered it, e.g. "destroy schedule"
+	DueAt     time.Time `json:"due_at"`    // When the trigger fired
+	SkippedAt time.Time `json:"skipped_at"`
+}
+
 type WorkspaceState struct {
-	Name               string          `json:"name"`
-	Status             WorkspaceStatus `json:"status"`
-	LastDeployed       *time.Time      `json:"last_deployed,omitempty"`
-	LastDestroyed      *time.Time      `json:"last_destroyed,omitempty"`
-	LastDeployError    string          `json:"last_deploy_error,omitempty"`
-	LastDestroyError   string          `json:"last_destroy_error,omitempty"`
-	LastConfigModified *time.Time      `json:"last_config_modified,omitempty"`
-	DeploymentMode     string          `json:"deployment_mode,omitempty"`
+	Name                string               `json:"name"`
+	Status              WorkspaceStatus      `json:"status"`
+	LastDeployed        *time.Time           `json:"last_deployed,omitempty"`
+	LastDestroyed       *time.Time           `json:"last_destroyed,omitempty"`
+	LastDeployError     string               `json:"last_deploy_error,omitempty"`
+	LastDestroyError    string               `json:"last_destroy_error,omitempty"`
+	LastConfigModified  *time.Time           `json:"last_config_modified,omitempty"`
+	DeploymentMode      string               `json:"deployment_mode,omitempty"`
+	RunStarted          *time.Time           `json:"run_started,omitempty"`
+	LastRunResult       string               `json:"last_run_result,omitempty"`
+	LastRunFinished     *time.Time           `json:"last_run_finished,omitempty"`
+	RunCompletionSeen   *time.Time           `json:"run_completion_seen,omitempty"` // When a poll first saw the run's completion output
+	PendingOperation    *PendingOperation    `json:"pending_operation,omitempty"`
+	ScheduledOperations []ScheduledOperation `json:"scheduled_operations,omitempty"` // One-shot deploys and destroys scheduled with --at, earliest first
+	LastCancellation    *Cancellation        `json:"last_cancellation,omitempty"`
+	LastInterruption    *Interruption        `json:"last_interruption,omitempty"`
+	LastTimeout         *Timeout             `json:"last_timeout,omitempty"`     // Last deploy or destroy stopped by its timeout
+	QueuedOperation     string               `json:"queued_operation,omitempty"` // Operation waiting while status is queued
+	LastCorrelationID   string               `json:"last_correlation_id,omitempty"`
+	DeployRetries       int                  `json:"deploy_retries,omitempty"`       // Automatic retries since the last successful deploy
+	NextDeployRetry     *time.Time           `json:"next_deploy_retry,omitempty"`    // When the failed deploy is retried next
+	DeployedSince       *time.Time           `json:"deployed_since,omitempty"`       // First deploy since the workspace was last destroyed
+	LifetimeAlerted     bool                 `json:"lifetime_alerted,omitempty"`     // max_lifetime alert already sent for this deployment
+	TTLAlerted          bool                 `json:"ttl_alerted,omitempty"`          // ttl alert already sent since the last deploy
+	ApprovalRequested   *time.Time           `json:"approval_requested,omitempty"`   // Scheduled deploy or destroy waiting for an operator to approve it
+	ApprovalOperation   string               `json:"approval_operation,omitempty"`   // Operation awaiting approval, a deploy if empty
+	ApprovalMode        string               `json:"approval_mode,omitempty"`        // Mode of a mode schedule deploy awaiting approval
+	ApprovalExpired     *time.Time           `json:"approval_expired,omitempty"`     // Request time of the last expired approval, its schedule run is skipped
+	ModeScheduledAt     *time.Time           `json:"mode_scheduled_at,omitempty"`    // Mode schedule match the last scheduled mode deploy was started for
+	ModeHistory         []ModeChange         `json:"mode_history,omitempty"`         // Latest mode changes, oldest first
+	Freeze              *Freeze              `json:"freeze,omitempty"`               // Set while automatic operations are suppressed
+	SkippedWhileFrozen  []SkippedOperation   `json:"skipped_while_frozen,omitempty"` // Operations suppressed by the latest freeze
+	WaitingFor          string               `json:"waiting_for,omitempty"`          // Operation held back by depends_on and the workspaces it waits for
+	PausedSince         *time.Time           `json:"paused_since,omitempty"`         // Set while the workspace's scheduled operations are paused
+	EnvironmentHistory  []EnvironmentSwitch  `json:"environment_history,omitempty"`  // Latest environment switches to or away from the workspace, oldest first
+	IdleSince           *time.Time           `json:"idle_since,omitempty"`           // First idle check of the current idle streak
+	LastIdleCheck       *time.Time           `json:"last_idle_check,omitempty"`
+	LastIdleMetric      *float64             `json:"last_idle_metric,omitempty"` // Activity measured by the last successful idle check
+	LastIdleError       string               `json:"last_idle_error,omitempty"`
+
+	idleChecking       bool // An idle check is running
+	completionChecking bool // A poll of the completion output is running
+}
+
+// PendingApproval returns the scheduled operation awaiting approval, empty if there is none
+func (ws *WorkspaceState) PendingApproval() string {
+	if ws.ApprovalRequested == nil {
+		return ""
+	}
+	if ws.ApprovalOperation == "" {
+		// State written before destroys could await approval
+		return OperationDeploy
+	}
+	return ws.ApprovalOperation
+}
+
+// settleApproval clears a pending approval of the operation once it runs, whoever started it
+func (ws *WorkspaceState) settleApproval(operation string) {
+	if ws.PendingApproval() == operation {
+		ws.ApprovalRequested = nil
+		ws.ApprovalOperation = ""
+		ws.ApprovalMode = ""
+	}
+}
+
+// TTLExpiry returns when a deployment with the given ttl expires, nil if it was never deployed
+func (ws *WorkspaceState) TTLExpiry(ttl time.Duration) *time.Time {
+	if ws.LastDeployed == nil {
+		return nil
+	}
+	expiry := ws.LastDeployed.Add(ttl)
+	return &expiry
+}
+
+// DeploymentAge returns how long the workspace has been deployed without being destroyed
+func (ws *WorkspaceState) DeploymentAge(now time.Time) time.Duration {
+	since := ws.DeployedSince
+	if since == nil {
+		// State written before deployed_since was tracked
+		since = ws.LastDeployed
+	}
+	if since == nil {
+		return 0
+	}
+	return now.Sub(*since)
+}
+
+// IsBusy returns true while a deploy or destroy is running or waiting for an operation slot
+func (ws *WorkspaceState) IsBusy() bool {
+	return ws.Status == StatusDeploying || ws.Status == StatusDestroying || ws.Status == StatusQueued
+}
+
+// ActiveOperation returns the operation that is running or queued, or "" when idle
+func (ws *WorkspaceState) ActiveOperation() string {
+	switch ws.Status {
+	case StatusDeploying:
+		return OperationDeploy
+	case StatusDestroying:
+		return OperationDestroy
+	case StatusQueued:
+		return ws.QueuedOperation
+	}
+	return ""
 }
 
 type State struct {
 	Workspaces  map[string]*WorkspaceState `json:"workspaces"`
 	LastUpdated time.Time                  `json:"last_updated"`
+	PausedSince *time.Time                 `json:"paused_since,omitempty"` // Set while scheduled operations of all workspaces are paused
+	LastChecked *time.Time                 `json:"last_checked,omitempty"` // Last schedule check of the daemon, where missed schedules are looked for after a restart
+
+	SuccessRates map[string]*slo.Tracker `json:"success_rates,omitempty"` // Recent deploy and job outcomes, keyed by successRateKey
+
+	Environments map[string]*EnvironmentHealth `json:"environments,omitempty"` // Health of monitored environments
+
+	mu  sync.Mutex       // Guards the state, changed by the scheduler loop, operations and control requests at once
+	now func() time.Time // Clock operation times are recorded with, time.Now if nil
 }
 
 func NewState() *State {
@@ -43,15 +281,26 @@ func NewState() *State {
 	}
 }
 
+// currentTime returns the time on the state's clock
+func (s *State) currentTime() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
 func LoadState(statePath string) (*State, error) {
 	if _, err := os.Stat(statePath); os.IsNotExist(err) {
 		return NewState(), nil
 	}
 
-	data, err := os.ReadFile(statePath)
+	data, recovered, err := statefile.Read(statePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read state file: %w", err)
 	}
+	if recovered {
+		logging.LogSystemd("Warning: %s was corrupt and has been restored from its backup", statePath)
+	}
 
 	var state State
 	if err := json.Unmarshal(data, &state); err != nil {
@@ -66,26 +315,61 @@ func LoadState(statePath string) (*State, error) {
 }
 
 func (s *State) SaveState(statePath string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.LastUpdated = time.Now()
 
-	// Ensure state directory exists
-	if err := os.MkdirAll(filepath.Dir(statePath), 0755); err != nil {
-		return fmt.Errorf("failed to create state directory: %w", err)
-	}
-
 	data, err := json.MarshalIndent(s, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal state: %w", err)
 	}
 
-	if err := os.WriteFile(statePath, data, 0644); err != nil {
+	// Replace atomically under a lock so the daemon and CLIs can't truncate or interleave writes
+	if err := statefile.Write(statePath, data); err != nil {
 		return fmt.Errorf("failed to write state file: %w", err)
 	}
 
 	return nil
 }
 
+// GetWorkspaceState returns the workspace's state, creating it if needed. While the scheduler runs,
+// read it with Workspace and change it with UpdateWorkspace or the Set methods instead.
 func (s *State) GetWorkspaceState(name string) *WorkspaceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.workspace(name)
+}
+
+// Workspace returns a copy of the workspace's state, creating it if needed
+func (s *State) Workspace(name string) *WorkspaceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := *s.workspace(name)
+	return &workspace
+}
+
+// WorkspaceStates returns copies of the states of all workspaces
+func (s *State) WorkspaceStates() map[string]*WorkspaceState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspaces := make(map[string]*WorkspaceState, len(s.Workspaces))
+	for name, workspace := range s.Workspaces {
+		copied := *workspace
+		workspaces[name] = &copied
+	}
+	return workspaces
+}
+
+// UpdateWorkspace changes the workspace's state under the state's lock. update must not call
+// methods of the state.
+func (s *State) UpdateWorkspace(name string, update func(*WorkspaceState)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	update(s.workspace(name))
+}
+
+// workspace returns the workspace's state, creating it if needed. The caller holds the lock.
+func (s *State) workspace(name string) *WorkspaceState {
 	if workspace, exists := s.Workspaces[name]; exists {
 		return workspace
 	}
@@ -99,23 +383,403 @@ func (s *State) GetWorkspaceState(name string) *WorkspaceState {
 	return workspace
 }
 
+// UpdateSuccessRate changes the tracker of a deploy or job success rate under the state's lock,
+// creating it on first use
+func (s *State) UpdateSuccessRate(key string, update func(*slo.Tracker)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.SuccessRates == nil {
+		s.SuccessRates = make(map[string]*slo.Tracker)
+	}
+	tracker, exists := s.SuccessRates[key]
+	if !exists {
+		tracker = &slo.Tracker{}
+		s.SuccessRates[key] = tracker
+	}
+	update(tracker)
+}
+
+// SuccessRate returns a deploy or job success rate within its window
+func (s *State) SuccessRate(key string, window slo.Window, now time.Time) slo.Rate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.SuccessRates[key].Rate(window, now)
+}
+
+// GetEnvironmentHealth returns a copy of the recorded health of an environment, nil if it was not
+// checked yet
+func (s *State) GetEnvironmentHealth(name string) *EnvironmentHealth {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	health, exists := s.Environments[name]
+	if !exists {
+		return nil
+	}
+	copied := *health
+	return &copied
+}
+
+// UpdateEnvironmentHealth changes the recorded health of an environment under the state's lock,
+// creating it if needed
+func (s *State) UpdateEnvironmentHealth(name string, update func(*EnvironmentHealth)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Environments == nil {
+		s.Environments = make(map[string]*EnvironmentHealth)
+	}
+	health, exists := s.Environments[name]
+	if !exists {
+		health = &EnvironmentHealth{}
+		s.Environments[name] = health
+	}
+	update(health)
+}
+
+// ForgetEnvironments drops the health of environments that are no longer monitored, keeping those
+// with a check running
+func (s *State) ForgetEnvironments(monitored map[string]bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, health := range s.Environments {
+		if !monitored[name] && !health.checking {
+			delete(s.Environments, name)
+		}
+	}
+}
+
+// GetPausedSince returns since when scheduled operations of all workspaces are paused, nil if they
+// are not
+func (s *State) GetPausedSince() *time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.PausedSince
+}
+
+// SetPausedSince pauses scheduled operations of all workspaces, or resumes them with nil
+func (s *State) SetPausedSince(since *time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.PausedSince = since
+}
+
+// GetLastChecked returns the daemon's last schedule check
+func (s *State) GetLastChecked() *time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.LastChecked
+}
+
+// SetLastChecked records the daemon's last schedule check
+func (s *State) SetLastChecked(checked time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.LastChecked = &checked
+}
+
 func (s *State) SetWorkspaceStatus(name string, status WorkspaceStatus) {
-	workspace := s.GetWorkspaceState(name)
-	workspace.Status = status
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workspace(name).setStatus(status, s.currentTime())
+}
+
+// setStatus changes the workspace's status, recording when it was deployed or destroyed
+func (ws *WorkspaceState) setStatus(status WorkspaceStatus, now time.Time) {
+	ws.Status = status
+	ws.QueuedOperation = ""
 
-	now := time.Now()
 	switch status {
-	case StatusDeployed:
-		workspace.LastDeployed = &now
-		workspace.LastDeployError = ""
+	case StatusDeploying:
+		// Any deploy or destroy, including the operator's manual one, settles a pending approval of it
+		ws.settleApproval(OperationDeploy)
+		ws.WaitingFor = ""
+		ws.IdleSince = nil
+	case StatusDestroying:
+		ws.settleApproval(OperationDestroy)
+		ws.WaitingFor = ""
+	case StatusDeployed, StatusDeployDegraded:
+		ws.LastDeployed = &now
+		ws.LastDeployError = ""
+		ws.resetDeployRetries()
+		ws.TTLAlerted = false
+		if ws.DeployedSince == nil {
+			ws.DeployedSince = &now
+		}
 	case StatusDestroyed:
-		workspace.LastDestroyed = &now
-		workspace.LastDestroyError = ""
+		ws.LastDestroyed = &now
+		ws.LastDestroyError = ""
+		ws.resetDeployRetries()
+		ws.DeployedSince = nil
+		ws.LifetimeAlerted = false
+		ws.TTLAlerted = false
+		ws.IdleSince = nil
+	}
+}
+
+// RemoveWorkspaceState forgets a workspace that is no longer configured
+func (s *State) RemoveWorkspaceState(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Workspaces, name)
+}
+
+// RequestApproval records that a scheduled deploy, optionally in a mode, or destroy is waiting for approval
+func (s *State) RequestApproval(name, operation, mode string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.ApprovalRequested = &at
+	workspace.ApprovalOperation = operation
+	workspace.ApprovalMode = mode
+}
+
+// ExpireApproval drops a pending approval, remembering when it was requested so its schedule
+// run is not requested again
+func (s *State) ExpireApproval(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.ApprovalExpired = workspace.ApprovalRequested
+	workspace.ApprovalRequested = nil
+	workspace.ApprovalOperation = ""
+	workspace.ApprovalMode = ""
+}
+
+// RecordModeChange appends a mode change to the workspace's history, keeping the latest entries
+func (s *State) RecordModeChange(name string, change ModeChange) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.ModeHistory = append(workspace.ModeHistory, change)
+	if len(workspace.ModeHistory) > maxModeHistory {
+		workspace.ModeHistory = workspace.ModeHistory[len(workspace.ModeHistory)-maxModeHistory:]
+	}
+}
+
+// RecordEnvironmentSwitch appends an environment switch to the workspace's history, keeping the latest entries
+func (s *State) RecordEnvironmentSwitch(name string, change EnvironmentSwitch) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.EnvironmentHistory = append(workspace.EnvironmentHistory, change)
+	if len(workspace.EnvironmentHistory) > maxEnvironmentHistory {
+		workspace.EnvironmentHistory = workspace.EnvironmentHistory[len(workspace.EnvironmentHistory)-maxEnvironmentHistory:]
+	}
+}
+
+// FreezeWorkspace pins a workspace to its current deployment, starting a new skipped operations record
+func (s *State) FreezeWorkspace(name, reason string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.Freeze = &Freeze{Since: now, Reason: reason}
+	workspace.SkippedWhileFrozen = nil
+	workspace.PendingOperation = nil
+}
+
+// UnfreezeWorkspace lifts a freeze, returning it or nil if the workspace wasn't frozen.
+// The skipped operations stay recorded until the next freeze.
+func (s *State) UnfreezeWorkspace(name string) *Freeze {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	freeze := workspace.Freeze
+	workspace.Freeze = nil
+	return freeze
+}
+
+// RecordFrozenSkip records a suppressed operation once per operation, reason and due time.
+// Returns true if it wasn't recorded before.
+func (s *State) RecordFrozenSkip(name string, skipped SkippedOperation) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	for _, existing := range workspace.SkippedWhileFrozen {
+		if existing.Operation == skipped.Operation && existing.Reason == skipped.Reason && existing.DueAt.Equal(skipped.DueAt) {
+			return false
+		}
+	}
+	workspace.SkippedWhileFrozen = append(workspace.SkippedWhileFrozen, skipped)
+	if len(workspace.SkippedWhileFrozen) > maxSkippedWhileFrozen {
+		workspace.SkippedWhileFrozen = workspace.SkippedWhileFrozen[len(workspace.SkippedWhileFrozen)-maxSkippedWhileFrozen:]
+	}
+	return true
+}
+
+// ScheduleDeployRetry sets when a failed deploy is retried
+func (s *State) ScheduleDeployRetry(name string, at time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.NextDeployRetry = &at
+}
+
+// StartDeployRetry counts a retry that is starting
+func (s *State) StartDeployRetry(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.DeployRetries++
+	workspace.NextDeployRetry = nil
+}
+
+// ResetDeployRetries clears the retry count and any planned retry
+func (s *State) ResetDeployRetries(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.workspace(name).resetDeployRetries()
+}
+
+func (ws *WorkspaceState) resetDeployRetries() {
+	ws.DeployRetries = 0
+	ws.NextDeployRetry = nil
+}
+
+// SetWorkspaceQueued marks a workspace as waiting for an operation slot
+func (s *State) SetWorkspaceQueued(name, operation string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.Status = StatusQueued
+	workspace.QueuedOperation = operation
+}
+
+// SetWorkspaceCorrelationID records the correlation ID of the workspace's latest operation
+func (s *State) SetWorkspaceCorrelationID(name, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.LastCorrelationID = id
+}
+
+// SetWorkspaceRunning marks a run-to-completion workspace as deployed and awaiting completion
+func (s *State) SetWorkspaceRunning(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.Status = StatusRunning
+
+	now := s.currentTime()
+	workspace.RunStarted = &now
+	workspace.RunCompletionSeen = nil
+}
+
+// SetWorkspaceRunResult records how a run-to-completion workspace run ended
+func (s *State) SetWorkspaceRunResult(name, result string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.LastRunResult = result
+
+	now := s.currentTime()
+	workspace.LastRunFinished = &now
+	workspace.RunStarted = nil
+	workspace.RunCompletionSeen = nil
+}
+
+// QueuePendingOperation queues an operation to run when the workspace's current operation completes
+func (s *State) QueuePendingOperation(name, operation string, now time.Time, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.PendingOperation = &PendingOperation{
+		Operation: operation,
+		QueuedAt:  now,
+		ExpiresAt: now.Add(ttl),
 	}
 }
 
+// ScheduleOperation records a one-shot operation, replacing one of the same operation
+func (s *State) ScheduleOperation(name string, op ScheduledOperation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	scheduled := []ScheduledOperation{op}
+	for _, existing := range workspace.ScheduledOperations {
+		if existing.Operation != op.Operation {
+			scheduled = append(scheduled, existing)
+		}
+	}
+	sort.Slice(scheduled, func(i, j int) bool { return scheduled[i].At.Before(scheduled[j].At) })
+	workspace.ScheduledOperations = scheduled
+}
+
+// UnscheduleOperation removes the one-shot operations of the given operation, all if empty, and
+// returns them
+func (s *State) UnscheduleOperation(name, operation string) []ScheduledOperation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	var kept, removed []ScheduledOperation
+	for _, op := range workspace.ScheduledOperations {
+		if operation == "" || op.Operation == operation {
+			removed = append(removed, op)
+		} else {
+			kept = append(kept, op)
+		}
+	}
+	workspace.ScheduledOperations = kept
+	return removed
+}
+
+// DueScheduledOperations returns the one-shot operations due at now, earliest first
+func (ws *WorkspaceState) DueScheduledOperations(now time.Time) []ScheduledOperation {
+	var due []ScheduledOperation
+	for _, op := range ws.ScheduledOperations {
+		if !op.At.After(now) {
+			due = append(due, op)
+		}
+	}
+	return due
+}
+
+// TakePendingOperation removes and returns the queued operation, or nil if none is queued or it expired
+func (s *State) TakePendingOperation(name string, now time.Time) *PendingOperation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	pending := workspace.PendingOperation
+	workspace.PendingOperation = nil
+
+	if pending == nil || now.After(pending.ExpiresAt) {
+		return nil
+	}
+	return pending
+}
+
+// SetWorkspaceCancelled marks a workspace whose operation was cancelled and drops any queued follow-up
+func (s *State) SetWorkspaceCancelled(name string, cancellation *Cancellation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.Status = StatusCancelled
+	workspace.LastCancellation = cancellation
+	workspace.PendingOperation = nil
+}
+
+// SetWorkspaceInterrupted marks a workspace whose operation died with the daemon and drops any
+// queued follow-up
+func (s *State) SetWorkspaceInterrupted(name string, interruption *Interruption) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.Status = StatusInterrupted
+	workspace.LastInterruption = interruption
+	workspace.PendingOperation = nil
+}
+
+// SetWorkspaceDegraded records a deploy that applied its changes but failed its post_deploy hooks
+func (s *State) SetWorkspaceDegraded(name string, errorMsg string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
+	workspace.setStatus(StatusDeployDegraded, s.currentTime())
+	workspace.LastDeployError = errorMsg
+}
+
 func (s *State) SetWorkspaceError(name string, isDeployError bool, errorMsg string) {
-	workspace := s.GetWorkspaceState(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
 
 	if isDeployError {
 		workspace.LastDeployError = errorMsg
@@ -128,8 +792,11 @@ func (s *State) SetWorkspaceError(name string, isDeployError bool, errorMsg stri
 
 // SetWorkspaceConfigModified updates the last config modification time for an workspace
 func (s *State) SetWorkspaceConfigModified(name string, modTime time.Time) {
-	workspace := s.GetWorkspaceState(name)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workspace := s.workspace(name)
 	workspace.LastConfigModified = &modTime
+	workspace.resetDeployRetries()
 
 	// Handle state transitions based on current status when config is modified
 	switch workspace.Status {
@@ -141,9 +808,24 @@ func (s *State) SetWorkspaceConfigModified(name string, modTime time.Time) {
 		// If workspace was in destroy failed state, allow retries
 		workspace.Status = StatusDeployed
 		workspace.LastDestroyError = ""
-	case StatusDeployed:
+	case StatusCancelled:
+		// Allow the cancelled operation to run again with the new config
+		if workspace.LastCancellation != nil && workspace.LastCancellation.Operation == OperationDestroy {
+			workspace.Status = StatusDeployed
+		} else {
+			workspace.Status = StatusDestroyed
+		}
+	case StatusInterrupted:
+		// Likewise for an operation interrupted by a daemon crash
+		if workspace.LastInterruption != nil && workspace.LastInterruption.Operation == OperationDestroy {
+			workspace.Status = StatusDeployed
+		} else {
+			workspace.Status = StatusDestroyed
+		}
+	case StatusDeployed, StatusDeployDegraded:
 		// If workspace is deployed and config was modified, trigger redeployment
 		workspace.Status = StatusDestroyed
+		workspace.LastDeployError = ""
 		// Clear deployment timestamp to ensure redeployment
 		workspace.LastDeployed = nil
 	}
@@ -151,5 +833,7 @@ func (s *State) SetWorkspaceConfigModified(name string, modTime time.Time) {
 
 // SetWorkspaceState updates the entire workspace state
 func (s *State) SetWorkspaceState(name string, workspaceState *WorkspaceState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	s.Workspaces[name] = workspaceState
 }
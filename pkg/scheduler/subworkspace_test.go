@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"provisioner/pkg/cron"
 	"provisioner/pkg/job"
 	"provisioner/pkg/opentofu"
 	"provisioner/pkg/workspace"
@@ -380,7 +381,7 @@ func TestSpecialScheduleParsing(t *testing.T) {
 	}
 
 	for _, tc := range testCases {
-		_, err := ParseCron(tc.schedule)
+		_, err := cron.Parse(tc.schedule)
 		if tc.valid && err != nil {
 			t.Errorf("expected schedule '%s' to be valid, got error: %v", tc.schedule, err)
 		}
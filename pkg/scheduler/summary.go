@@ -0,0 +1,497 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"provisioner/pkg/cron"
+	"provisioner/pkg/job"
+	"provisioner/pkg/listing"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/output"
+	"provisioner/pkg/workspace"
+)
+
+// WorkspaceListFields are the fields workspace lists can be filtered and sorted by and show as
+// columns; workspace is an alias of name
+var WorkspaceListFields = []string{"name", "workspace", "status", "enabled", "tier", "template", "labels", "outdated", "errors", "last-deployed", "last-destroyed", "next-run", "next-deploy", "next-destroy"}
+
+// WorkspaceSummary is the status of a workspace as shown in list and status tables
+type WorkspaceSummary struct {
+	Workspace     workspace.Workspace
+	Status        string // Actual deployment status, refined by the scheduler state
+	Errors        string // "None", "Yes" or the pending retry
+	LastDeployed  *time.Time
+	LastDestroyed *time.Time
+	NextRun       *time.Time // Next deploy or destroy schedule, the earlier of NextDeploy and NextDestroy
+	NextDeploy    *time.Time // Next deploy, mode or one-shot deploy schedule
+	NextDestroy   *time.Time // Next destroy or one-shot destroy schedule, including relative ones
+	Outdated      bool       // Deployed from an older version of its template
+}
+
+// ListField returns a field of the summary for filtering and sorting
+func (ws WorkspaceSummary) ListField(name string) string {
+	switch name {
+	case "name", "workspace":
+		return ws.Workspace.Name
+	case "status":
+		return ws.Status
+	case "enabled":
+		return strconv.FormatBool(ws.Workspace.Config.Enabled)
+	case "tier":
+		return ws.Workspace.Config.Tier
+	case "template":
+		return ws.Workspace.Config.Template
+	case "labels":
+		return workspace.FormatLabels(ws.Workspace.Config.Labels)
+	case "outdated":
+		return strconv.FormatBool(ws.Outdated)
+	case "errors":
+		return strings.ToLower(ws.Errors)
+	case "last-deployed":
+		return listing.FormatField(ws.LastDeployed)
+	case "last-destroyed":
+		return listing.FormatField(ws.LastDestroyed)
+	case "next-run":
+		return listing.FormatField(ws.NextRun)
+	case "next-deploy":
+		return listing.FormatField(ws.NextDeploy)
+	case "next-destroy":
+		return listing.FormatField(ws.NextDestroy)
+	}
+	return ""
+}
+
+// TableField returns a field of the summary for the columns of --columns
+func (ws WorkspaceSummary) TableField(name string) string {
+	switch name {
+	case "errors":
+		return ws.Errors
+	case "last-deployed":
+		return formatTableTime(ws.LastDeployed)
+	case "last-destroyed":
+		return formatTableTime(ws.LastDestroyed)
+	case "next-run":
+		return formatTableTime(ws.NextRun)
+	case "next-deploy":
+		return formatTableTime(ws.NextDeploy)
+	case "next-destroy":
+		return formatTableTime(ws.NextDestroy)
+	}
+	return ws.ListField(name)
+}
+
+// formatTableTime formats a time field for tables, "" if it is not set
+func formatTableTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return logging.FormatTimeShort(*t)
+}
+
+// showColumns prints a listing result as a table of the given columns, followed by its summary
+func showColumns[E listing.TableEntry](result listing.Result[E], columns []string) error {
+	if err := listing.WriteColumns(os.Stdout, result.Entries, columns); err != nil {
+		return err
+	}
+	if summary := result.Summary(); summary != "" {
+		fmt.Printf("\n%s\n", summary)
+	}
+	return nil
+}
+
+// workspaceSummaryOutput is a WorkspaceSummary as printed by --output json and yaml
+type workspaceSummaryOutput struct {
+	Name             string            `json:"name"`
+	Description      string            `json:"description,omitempty"`
+	Status           string            `json:"status"`
+	Enabled          bool              `json:"enabled"`
+	Tier             string            `json:"tier,omitempty"`
+	Template         string            `json:"template,omitempty"`
+	Labels           map[string]string `json:"labels,omitempty"`
+	Outdated         bool              `json:"outdated"`
+	Errors           string            `json:"errors"`
+	DeploySchedules  []string          `json:"deploy_schedules"`
+	DestroySchedules []string          `json:"destroy_schedules"`
+	LastDeployed     *time.Time        `json:"last_deployed"`
+	LastDestroyed    *time.Time        `json:"last_destroyed"`
+	NextRun          *time.Time        `json:"next_run"`
+	NextDeploy       *time.Time        `json:"next_deploy"`
+	NextDestroy      *time.Time        `json:"next_destroy"`
+}
+
+// MarshalJSON encodes the summary with the fields shown in list and status tables
+func (ws WorkspaceSummary) MarshalJSON() ([]byte, error) {
+	deploySchedules, _ := ws.Workspace.Config.GetDeploySchedules()
+	destroySchedules, _ := ws.Workspace.Config.GetDestroySchedules()
+	return json.Marshal(workspaceSummaryOutput{
+		Name:             ws.Workspace.Name,
+		Description:      ws.Workspace.Config.Description,
+		Status:           ws.Status,
+		Enabled:          ws.Workspace.Config.Enabled,
+		Tier:             ws.Workspace.Config.Tier,
+		Template:         ws.Workspace.Config.Template,
+		Labels:           ws.Workspace.Config.Labels,
+		Outdated:         ws.Outdated,
+		Errors:           ws.Errors,
+		DeploySchedules:  append([]string{}, deploySchedules...),
+		DestroySchedules: append([]string{}, destroySchedules...),
+		LastDeployed:     ws.LastDeployed,
+		LastDestroyed:    ws.LastDestroyed,
+		NextRun:          ws.NextRun,
+		NextDeploy:       ws.NextDeploy,
+		NextDestroy:      ws.NextDestroy,
+	})
+}
+
+// WorkspaceSummaries returns the status of all loaded workspaces
+func (s *Scheduler) WorkspaceSummaries(now time.Time) []WorkspaceSummary {
+	workspaces := s.loadedWorkspaces()
+	summaries := make([]WorkspaceSummary, 0, len(workspaces))
+	for _, workspace := range workspaces {
+		summaries = append(summaries, s.summarizeWorkspace(workspace, s.state.Workspace(workspace.Name), now))
+	}
+	return summaries
+}
+
+// summarizeWorkspace combines a workspace's OpenTofu state, scheduler state and schedules
+func (s *Scheduler) summarizeWorkspace(workspace workspace.Workspace, state *WorkspaceState, now time.Time) WorkspaceSummary {
+	// Use actual OpenTofu state as source of truth for deployment status
+	summary := WorkspaceSummary{
+		Workspace:     workspace,
+		Status:        workspace.GetDeploymentStatus(),
+		Errors:        "None",
+		LastDeployed:  state.LastDeployed,
+		LastDestroyed: state.LastDestroyed,
+	}
+
+	// Use filesystem timestamps as more accurate source, fall back to managed state
+	if stateChangeTime := workspace.GetLastStateChangeTime(); stateChangeTime != nil {
+		if summary.Status == "deployed" {
+			summary.LastDeployed = stateChangeTime
+		} else {
+			summary.LastDestroyed = stateChangeTime
+		}
+	}
+
+	if state.LastDeployError != "" || state.LastDestroyError != "" {
+		summary.Errors = "Yes"
+	}
+	if state.NextDeployRetry != nil && workspace.Config.Retry != nil {
+		summary.Errors = fmt.Sprintf("Retry %d/%d", state.DeployRetries+1, workspace.Config.Retry.MaxAttempts)
+	}
+
+	if summary.Status == "deployed" && state.Status == StatusRunning {
+		summary.Status = string(StatusRunning)
+	}
+	if state.Status == StatusCancelled || state.Status == StatusQueued || state.Status == StatusInterrupted {
+		summary.Status = string(state.Status)
+	}
+	if workspace.IsTemplateMissing() {
+		summary.Status = string(StatusTemplateMissing)
+	}
+	summary.Outdated = s.isTemplateOutdated(workspace, summary.Status)
+	if state.ApprovalRequested != nil && !state.IsBusy() && !workspace.IsTemplateMissing() {
+		summary.Status = string(StatusPendingApproval)
+	}
+
+	if workspace.Config.Enabled {
+		summary.NextDeploy = nextDeployRun(workspace, state, now)
+		summary.NextDestroy = nextDestroyRun(workspace, state, now)
+		summary.NextRun = earliest(summary.NextDeploy, summary.NextDestroy)
+	}
+	return summary
+}
+
+// nextDeployRun returns the next time a deploy or mode schedule of the workspace fires, or a
+// one-shot deploy is due
+func nextDeployRun(workspace workspace.Workspace, state *WorkspaceState, now time.Time) *time.Time {
+	schedules, _ := workspace.Config.GetDeploySchedules()
+	modeSchedules, _ := workspace.Config.GetModeSchedules()
+	for _, mode := range modeSchedules {
+		schedules = append(schedules, mode...)
+	}
+	return earliest(nextCronRun(schedules, now.In(workspace.Config.GetLocation())), nextScheduledOperation(state, OperationDeploy))
+}
+
+// nextDestroyRun returns the next time a destroy schedule of the workspace fires, including
+// schedules relative to its last deploy, or a one-shot destroy is due. Protected workspaces are
+// only destroyed manually.
+func nextDestroyRun(workspace workspace.Workspace, state *WorkspaceState, now time.Time) *time.Time {
+	next := nextScheduledOperation(state, OperationDestroy)
+	if workspace.Config.IsProtected() {
+		return next
+	}
+	schedules, _ := workspace.Config.GetDestroySchedules()
+	return earliest(next, nextCronRun(schedules, now.In(workspace.Config.GetLocation())), nextRelativeDestroy(schedules, state))
+}
+
+// earliest returns the earliest of the given times, nil if none is set
+func earliest(times ...*time.Time) *time.Time {
+	var first *time.Time
+	for _, t := range times {
+		if t != nil && (first == nil || t.Before(*first)) {
+			first = t
+		}
+	}
+	return first
+}
+
+// nextCronRun returns the earliest next run of the given CRON schedules; special and invalid
+// schedules are ignored
+func nextCronRun(schedules []string, now time.Time) *time.Time {
+	var next *time.Time
+	for _, scheduleStr := range schedules {
+		schedule, err := cron.Parse(scheduleStr)
+		if err != nil {
+			continue
+		}
+		if run := schedule.NextRun(now); run != nil && (next == nil || run.Before(*next)) {
+			next = run
+		}
+	}
+	return next
+}
+
+// JobListFields are the fields job lists can be filtered and sorted by and show as columns; job
+// is an alias of name
+var JobListFields = []string{"name", "job", "type", "enabled", "status", "last-run", "next-run"}
+
+// JobSummary is the status of a workspace or standalone job as shown in job lists
+type JobSummary struct {
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Enabled     bool       `json:"enabled"`
+	Description string     `json:"description,omitempty"`
+	Status      string     `json:"status"`
+	LastRun     *time.Time `json:"last_run"`
+	NextRun     *time.Time `json:"next_run"`
+}
+
+// ListField returns a field of the summary for filtering and sorting
+func (js JobSummary) ListField(name string) string {
+	switch name {
+	case "name", "job":
+		return js.Name
+	case "type":
+		return js.Type
+	case "enabled":
+		return strconv.FormatBool(js.Enabled)
+	case "status":
+		return js.Status
+	case "last-run":
+		return listing.FormatField(js.LastRun)
+	case "next-run":
+		return listing.FormatField(js.NextRun)
+	}
+	return ""
+}
+
+// TableField returns a field of the summary for the columns of --columns
+func (js JobSummary) TableField(name string) string {
+	switch name {
+	case "last-run":
+		return formatTableTime(js.LastRun)
+	case "next-run":
+		return formatTableTime(js.NextRun)
+	}
+	return js.ListField(name)
+}
+
+// WorkspaceJobSummaries returns the status of a workspace's jobs; job state must be loaded
+func (s *Scheduler) WorkspaceJobSummaries(workspaceName string, now time.Time) ([]JobSummary, error) {
+	ws := s.GetWorkspace(workspaceName)
+	if ws == nil {
+		return nil, fmt.Errorf("workspace '%s' not found", workspaceName)
+	}
+
+	states := s.GetJobStates(workspaceName)
+	jobConfigs := ws.Config.GetJobConfigs()
+	summaries := make([]JobSummary, 0, len(jobConfigs))
+	for _, jobConfig := range jobConfigs {
+		summaries = append(summaries, summarizeJob(jobConfig.Name, jobConfig.Type, jobConfig.Enabled,
+			jobConfig.Description, jobConfig.Schedule, states[jobConfig.Name], now.In(ws.Config.GetLocation())))
+	}
+	return summaries, nil
+}
+
+// StandaloneJobSummaries returns the status of all standalone jobs; job state must be loaded
+func (s *Scheduler) StandaloneJobSummaries(now time.Time) ([]JobSummary, error) {
+	if s.standaloneJobManager == nil {
+		return nil, fmt.Errorf("standalone job manager not available")
+	}
+
+	jobConfigs, err := s.standaloneJobManager.ListStandaloneJobs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load standalone jobs: %w", err)
+	}
+
+	states := s.standaloneJobManager.GetStandaloneJobStates()
+	summaries := make([]JobSummary, 0, len(jobConfigs))
+	for _, jobConfig := range jobConfigs {
+		summaries = append(summaries, summarizeJob(jobConfig.Name, jobConfig.Type, jobConfig.Enabled,
+			jobConfig.Description, jobConfig.Schedule, states[jobConfig.Name], now))
+	}
+	return summaries, nil
+}
+
+// summarizeJob combines a job's configuration, state and schedules
+func summarizeJob(name, jobType string, enabled bool, description string, schedule interface{}, state *job.JobState, now time.Time) JobSummary {
+	summary := JobSummary{
+		Name:        name,
+		Type:        jobType,
+		Enabled:     enabled,
+		Description: description,
+		Status:      string(job.JobStatusPending),
+	}
+
+	if !enabled {
+		summary.Status = string(job.JobStatusDisabled)
+	}
+	if state != nil {
+		summary.Status = string(state.Status)
+		summary.LastRun = state.LastRun
+	}
+
+	if enabled {
+		schedules, _ := (&job.Job{Schedule: schedule}).GetSchedules()
+		summary.NextRun = nextCronRun(schedules, now)
+	}
+	return summary
+}
+
+// ShowJobList prints the job summaries selected by opts, with the columns of opts if given
+func ShowJobList(summaries []JobSummary, opts listing.Options, format output.Format) error {
+	result := listing.Apply(summaries, opts)
+	if format.Structured() {
+		return output.Print(format, result.Entries)
+	}
+	if len(opts.Columns) > 0 {
+		return showColumns(result, opts.Columns)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if _, err := fmt.Fprintln(w, "JOB NAME\tTYPE\tENABLED\tSTATUS\tLAST RUN\tNEXT RUN\tDESCRIPTION"); err != nil {
+		return err
+	}
+	for _, summary := range result.Entries {
+		lastRun, nextRun := "Never", "-"
+		if summary.LastRun != nil {
+			lastRun = logging.FormatTimeShort(*summary.LastRun)
+		}
+		if summary.NextRun != nil {
+			nextRun = logging.FormatTimeShort(*summary.NextRun)
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\t%s\n",
+			summary.Name,
+			summary.Type,
+			summary.Enabled,
+			summary.Status,
+			lastRun,
+			nextRun,
+			summary.Description); err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if summary := result.Summary(); summary != "" {
+		fmt.Printf("\n%s\n", summary)
+	}
+	return nil
+}
+
+// ShowWorkspaceList prints the workspaces selected by opts; detailed adds schedules and the next run
+// to the table, structured formats always include them. The columns of opts replace the table.
+func (s *Scheduler) ShowWorkspaceList(opts listing.Options, detailed bool, format output.Format) error {
+	if err := opts.Validate(WorkspaceListFields); err != nil {
+		return err
+	}
+	if err := s.LoadWorkspaces(); err != nil {
+		return fmt.Errorf("failed to load workspaces: %w", err)
+	}
+	if err := s.LoadState(); err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	result := listing.Apply(s.WorkspaceSummaries(time.Now()), opts)
+	if format.Structured() {
+		return output.Print(format, result.Entries)
+	}
+
+	if len(s.loadedWorkspaces()) == 0 {
+		fmt.Println("No workspaces found")
+		return nil
+	}
+	if len(opts.Columns) > 0 {
+		return showColumns(result, opts.Columns)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	if detailed {
+		if _, err := fmt.Fprintln(w, "NAME\tSTATUS\tENABLED\tTIER\tSOURCE\tDEPLOY SCHEDULE\tDESTROY SCHEDULE\tNEXT RUN\tDESCRIPTION"); err != nil {
+			return err
+		}
+	} else {
+		if _, err := fmt.Fprintln(w, "NAME\tSTATUS\tENABLED\tSOURCE\tDESCRIPTION"); err != nil {
+			return err
+		}
+	}
+
+	for _, summary := range result.Entries {
+		workspace := summary.Workspace
+		source := "Local"
+		if workspace.IsUsingTemplate() {
+			source = fmt.Sprintf("Template(%s)", workspace.Config.Template)
+			if summary.Outdated {
+				source = fmt.Sprintf("Template(%s, outdated)", workspace.Config.Template)
+			}
+		}
+
+		var err error
+		if detailed {
+			deploySchedules, _ := workspace.Config.GetDeploySchedules()
+			destroySchedules, _ := workspace.Config.GetDestroySchedules()
+			nextRun := "-"
+			if summary.NextRun != nil {
+				nextRun = logging.FormatTimeShort(*summary.NextRun)
+			}
+			_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				workspace.Name,
+				summary.Status,
+				workspace.Config.Enabled,
+				workspace.Config.Tier,
+				source,
+				strings.Join(deploySchedules, ","),
+				strings.Join(destroySchedules, ","),
+				nextRun,
+				workspace.Config.Description)
+		} else {
+			_, err = fmt.Fprintf(w, "%s\t%s\t%t\t%s\t%s\n",
+				workspace.Name,
+				summary.Status,
+				workspace.Config.Enabled,
+				source,
+				workspace.Config.Description)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if summary := result.Summary(); summary != "" {
+		fmt.Printf("\n%s\n", summary)
+	}
+	return nil
+}
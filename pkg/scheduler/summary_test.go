@@ -0,0 +1,85 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"provisioner/pkg/job"
+	"provisioner/pkg/listing"
+	"provisioner/pkg/logging"
+)
+
+func TestWorkspaceSummariesNextRun(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.Local)
+	scheduler := newScenario(t, now).daemonConfig(tierDaemonConfig).
+		workspace("billing", tierBilling).workspace("sandbox", tierSandbox).start().scheduler
+
+	summaries := make(map[string]WorkspaceSummary)
+	for _, summary := range scheduler.WorkspaceSummaries(now) {
+		summaries[summary.Workspace.Name] = summary
+	}
+
+	// Billing only deploys, sandbox also destroys on its tier's schedule
+	billing := summaries["billing"]
+	if billing.NextRun == nil || !billing.NextRun.Equal(time.Date(2026, 1, 2, 9, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected billing to run next at tomorrow's deploy, got %v", billing.NextRun)
+	}
+	sandbox := summaries["sandbox"]
+	if sandbox.NextRun == nil || !sandbox.NextRun.Equal(time.Date(2026, 1, 1, 19, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected sandbox to run next at today's tier destroy, got %v", sandbox.NextRun)
+	}
+	if billing.NextDestroy != nil || billing.NextDeploy == nil || !billing.NextDeploy.Equal(*billing.NextRun) {
+		t.Errorf("Expected billing to only have a next deploy, got deploy %v, destroy %v", billing.NextDeploy, billing.NextDestroy)
+	}
+	if sandbox.NextDestroy == nil || !sandbox.NextDestroy.Equal(*sandbox.NextRun) || sandbox.NextDeploy == nil || !sandbox.NextDeploy.After(*sandbox.NextRun) {
+		t.Errorf("Expected sandbox to destroy before its next deploy, got deploy %v, destroy %v", sandbox.NextDeploy, sandbox.NextDestroy)
+	}
+	if sandbox.ListField("tier") != "dev" || sandbox.ListField("status") != "destroyed" {
+		t.Errorf("Unexpected list fields: tier %q, status %q", sandbox.ListField("tier"), sandbox.ListField("status"))
+	}
+
+	result := listing.Apply(scheduler.WorkspaceSummaries(now), listing.Options{Sort: "next-run"})
+	if len(result.Entries) != 2 || result.Entries[0].Workspace.Name != "sandbox" {
+		t.Errorf("Expected sandbox first when sorting by next run")
+	}
+
+	// Status reports select with == and show the fields of --columns
+	opts, _, err := listing.ParseArgs([]string{"--filter", "tier==dev", "--columns", "workspace,errors,next_run"})
+	if err == nil {
+		err = opts.Validate(WorkspaceListFields)
+	}
+	if err != nil {
+		t.Fatalf("Failed to parse status options: %v", err)
+	}
+	result = listing.Apply(scheduler.WorkspaceSummaries(now), opts)
+	if len(result.Entries) != 1 || result.Entries[0].TableField("workspace") != "sandbox" {
+		t.Fatalf("Expected only sandbox to be selected, got %d entries", len(result.Entries))
+	}
+	if errors, nextRun := result.Entries[0].TableField("errors"), result.Entries[0].TableField("next-run"); errors != "None" || nextRun != logging.FormatTimeShort(*sandbox.NextRun) {
+		t.Errorf("Unexpected table fields: errors %q, next run %q", errors, nextRun)
+	}
+}
+
+func TestSummarizeJob(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	summary := summarizeJob("cleanup", "command", true, "", []interface{}{"0 18 * * *", "30 13 * * *"}, nil, now)
+	if summary.Status != string(job.JobStatusPending) || summary.LastRun != nil {
+		t.Errorf("Expected pending job without last run, got %q/%v", summary.Status, summary.LastRun)
+	}
+	if summary.NextRun == nil || !summary.NextRun.Equal(time.Date(2026, 1, 1, 13, 30, 0, 0, time.UTC)) {
+		t.Errorf("Expected earliest schedule as next run, got %v", summary.NextRun)
+	}
+
+	lastRun := now.Add(-time.Hour)
+	state := &job.JobState{Status: job.JobStatusFailed, LastRun: &lastRun}
+	summary = summarizeJob("backup", "script", true, "", "0 2 * * *", state, now)
+	if summary.ListField("status") != "failed" || summary.ListField("last-run") != "2026-01-01T11:00:00Z" {
+		t.Errorf("Expected state to be reflected, got status %q, last run %q", summary.ListField("status"), summary.ListField("last-run"))
+	}
+
+	summary = summarizeJob("report", "command", false, "", "0 2 * * *", nil, now)
+	if summary.Status != string(job.JobStatusDisabled) || summary.NextRun != nil {
+		t.Errorf("Expected disabled job without next run, got %q/%v", summary.Status, summary.NextRun)
+	}
+}
@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+
+	"provisioner/pkg/logging"
+)
+
+// maxEnvironmentHistory is the number of environment switches kept per workspace
+const maxEnvironmentHistory = 20
+
+// ErrSwitchTargetNotDeployed is returned by CheckSwitchTarget for a workspace that could take
+// over an environment once deployed
+var ErrSwitchTargetNotDeployed = errors.New("switch target is not deployed")
+
+// CheckSwitchTarget verifies that a workspace can take over an environment's traffic: it must be
+// enabled, idle and deployed according to the scheduler state. An enabled, idle workspace that is
+// not deployed yields an error wrapping ErrSwitchTargetNotDeployed.
+func (s *Scheduler) CheckSwitchTarget(workspaceName string) error {
+	ws := s.GetWorkspace(workspaceName)
+	if ws == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+	if !ws.Config.Enabled {
+		return fmt.Errorf("workspace '%s' is disabled in configuration", workspaceName)
+	}
+
+	workspaceState := s.state.Workspace(workspaceName)
+	if workspaceState.IsBusy() {
+		return fmt.Errorf("workspace '%s' is currently %s, wait for it to finish", workspaceName, workspaceState.Status)
+	}
+	if workspaceState.Status != StatusDeployed {
+		status := workspaceState.Status
+		if status == "" {
+			status = StatusPending
+		}
+		return fmt.Errorf("%w: workspace '%s' is %s", ErrSwitchTargetNotDeployed, workspaceName, status)
+	}
+	return nil
+}
+
+// RecordEnvironmentSwitch records a completed environment switch in the history of the workspace
+// that took over the environment and of the one it was switched away from
+func (s *Scheduler) RecordEnvironmentSwitch(environmentName, from, to string) error {
+	if s.GetWorkspace(to) == nil {
+		return fmt.Errorf("workspace '%s' not found in configuration", to)
+	}
+
+	change := EnvironmentSwitch{Environment: environmentName, From: from, To: to, At: s.currentTime()}
+	s.state.RecordEnvironmentSwitch(to, change)
+	logging.LogWorkspaceOperation(to, "SWITCH", "Environment '%s' switched to this workspace", environmentName)
+	if from != "" && from != to && s.GetWorkspace(from) != nil {
+		s.state.RecordEnvironmentSwitch(from, change)
+		logging.LogWorkspaceOperation(from, "SWITCH", "Environment '%s' switched to workspace '%s'", environmentName, to)
+	}
+
+	return s.SaveState()
+}
+
+// formatEnvironmentSwitch formats an environment switch for display
+func formatEnvironmentSwitch(change EnvironmentSwitch) string {
+	from := change.From
+	if from == "" {
+		from = "(unassigned)"
+	}
+	return fmt.Sprintf("%s  %s: %s -> %s", logging.FormatTime(change.At), change.Environment, from, change.To)
+}
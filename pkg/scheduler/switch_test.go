@@ -0,0 +1,68 @@
+package scheduler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckSwitchTarget(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("blue", scenarioOfficeHours).
+		workspace("green", scenarioOfficeHours).
+		workspace("old", `{"enabled": false, "deploy_schedule": "0 9 * * 1-5"}`).
+		start()
+
+	if err := sc.scheduler.CheckSwitchTarget("green"); !errors.Is(err, ErrSwitchTargetNotDeployed) {
+		t.Errorf("Expected an undeployed workspace to need a deploy, got %v", err)
+	}
+	if err := sc.scheduler.CheckSwitchTarget("old"); err == nil || errors.Is(err, ErrSwitchTargetNotDeployed) {
+		t.Errorf("Expected a disabled workspace to be refused outright, got %v", err)
+	}
+	if err := sc.scheduler.CheckSwitchTarget("missing"); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected an unknown workspace to be refused, got %v", err)
+	}
+
+	sc.runUntil(time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC))
+	if err := sc.scheduler.CheckSwitchTarget("green"); err != nil {
+		t.Errorf("Expected the deployed workspace to be a valid target, got %v", err)
+	}
+
+	// A workspace that is being destroyed can't take over
+	sc.scheduler.state.SetWorkspaceStatus("green", StatusDestroying)
+	if err := sc.scheduler.CheckSwitchTarget("green"); err == nil || errors.Is(err, ErrSwitchTargetNotDeployed) {
+		t.Errorf("Expected a busy workspace to be refused outright, got %v", err)
+	}
+}
+
+func TestRecordEnvironmentSwitch(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+	sc.workspace("blue", scenarioOfficeHours).workspace("green", scenarioOfficeHours).start()
+
+	if err := sc.scheduler.RecordEnvironmentSwitch("production", "", "blue"); err != nil {
+		t.Fatalf("RecordEnvironmentSwitch failed: %v", err)
+	}
+	sc.run(time.Hour)
+	if err := sc.scheduler.RecordEnvironmentSwitch("production", "blue", "green"); err != nil {
+		t.Fatalf("RecordEnvironmentSwitch failed: %v", err)
+	}
+
+	// Both workspaces keep the switch in their history, which survives a restart
+	sc.downFor(time.Minute)
+	blue := sc.scheduler.state.GetWorkspaceState("blue").EnvironmentHistory
+	green := sc.scheduler.state.GetWorkspaceState("green").EnvironmentHistory
+	if len(blue) != 2 || blue[0].From != "" || blue[1].To != "green" {
+		t.Errorf("Unexpected history of blue: %+v", blue)
+	}
+	if len(green) != 1 || green[0].Environment != "production" || green[0].From != "blue" || !green[0].At.Equal(time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected history of green: %+v", green)
+	}
+	if formatted := formatEnvironmentSwitch(blue[0]); !strings.Contains(formatted, "production: (unassigned) -> blue") {
+		t.Errorf("Unexpected formatting '%s'", formatted)
+	}
+
+	if err := sc.scheduler.RecordEnvironmentSwitch("production", "green", "missing"); err == nil {
+		t.Error("Expected recording a switch to an unknown workspace to fail")
+	}
+}
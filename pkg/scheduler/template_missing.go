@@ -0,0 +1,28 @@
+package scheduler
+
+import (
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// checkTemplateMissing reports whether a workspace's template is missing,
+// logging once when the template disappears and once when it is back
+func (s *Scheduler) checkTemplateMissing(workspace workspace.Workspace) bool {
+	missing := workspace.IsTemplateMissing()
+	if missing == s.missingTemplates[workspace.Name] {
+		return missing
+	}
+
+	if s.missingTemplates == nil {
+		s.missingTemplates = make(map[string]bool)
+	}
+
+	if missing {
+		s.missingTemplates[workspace.Name] = true
+		logging.LogWorkspace(workspace.Name, "Template '%s' is not installed, skipping schedules until it is added again", workspace.Config.Template)
+	} else {
+		delete(s.missingTemplates, workspace.Name)
+		logging.LogWorkspace(workspace.Name, "Template '%s' is installed again, resuming schedules", workspace.Config.Template)
+	}
+	return missing
+}
@@ -0,0 +1,57 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/workspace"
+)
+
+func TestMissingTemplateSkipsSchedules(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	ws.Path = t.TempDir()
+	ws.Config.Template = "web"
+	ws.Config.DeploySchedule = "* * * * *"
+	scheduler.workspaces = []workspace.Workspace{ws}
+	now := time.Now().Truncate(time.Minute).Add(30 * time.Second)
+
+	if !ws.IsTemplateMissing() {
+		t.Fatal("expected template to be missing")
+	}
+
+	scheduler.checkWorkspaceSchedules(ws, now)
+	time.Sleep(50 * time.Millisecond)
+	if mockClient.GetDeployCallCount() != 0 {
+		t.Fatalf("expected no deploy while template is missing, got %d", mockClient.GetDeployCallCount())
+	}
+	if !scheduler.missingTemplates[ws.Name] {
+		t.Error("expected missing template to be recorded")
+	}
+
+	if err := scheduler.ManualDeploy(ws.Name); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("expected manual deploy to be refused, got %v", err)
+	}
+	if err := scheduler.ManualDestroy(ws.Name); err == nil || !strings.Contains(err.Error(), "not installed") {
+		t.Errorf("expected manual destroy to be refused, got %v", err)
+	}
+
+	// Installing the template resumes scheduling
+	templateDir := ws.GetTemplateDir()
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("failed to create template dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "main.tf"), []byte("# web"), 0644); err != nil {
+		t.Fatalf("failed to write template main.tf: %v", err)
+	}
+
+	if scheduler.checkTemplateMissing(ws) {
+		t.Error("expected template to be found after installing it")
+	}
+	if scheduler.missingTemplates[ws.Name] {
+		t.Error("expected missing template record to be cleared")
+	}
+}
@@ -0,0 +1,112 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// tierDaemonConfig defines a protected prod tier that requires approval and a dev tier that is
+// destroyed every evening, the tiers of tierBilling and tierSandbox
+const (
+	tierDaemonConfig = `{
+		"tiers": {
+			"prod": {"protected": true, "require_approval": true, "notification_channel": "prod-oncall"},
+			"dev": {"destroy_schedule": "0 19 * * *"}
+		}
+	}`
+	tierBilling = `{"enabled": true, "tier": "prod", "deploy_schedule": "0 9 * * *"}`
+	tierSandbox = `{"enabled": true, "tier": "dev", "deploy_schedule": "0 9 * * *"}`
+)
+
+func TestTierDefaultsApplied(t *testing.T) {
+	scheduler := newScenario(t, time.Now()).daemonConfig(tierDaemonConfig).
+		workspace("billing", tierBilling).workspace("sandbox", tierSandbox).start().scheduler
+
+	billing := scheduler.GetWorkspace("billing")
+	if !billing.Config.IsProtected() || !billing.Config.RequiresApproval() || billing.Config.NotificationChannel != "prod-oncall" {
+		t.Errorf("Expected prod tier defaults, got %+v", billing.Config)
+	}
+	sandbox := scheduler.GetWorkspace("sandbox")
+	if sandbox.Config.IsProtected() || sandbox.Config.DestroySchedule != "0 19 * * *" {
+		t.Errorf("Expected dev tier defaults, got %+v", sandbox.Config)
+	}
+}
+
+func TestLabelPoliciesApplied(t *testing.T) {
+	daemonConfig := `{
+		"tiers": {"dev": {"destroy_schedule": "0 20 * * *", "max_lifetime": "24h"}},
+		"label_policies": [
+			{"selector": "env=dev", "destroy_schedule": "0 19 * * *"},
+			{"selector": "env=dev,team=data", "destroy_schedule": "0 22 * * *", "protected": true},
+			{"selector": "env!=dev", "notification_channel": "ops"}
+		]
+	}`
+	scheduler := newScenario(t, time.Now()).daemonConfig(daemonConfig).
+		workspace("web-dev", `{"enabled": true, "tier": "dev", "labels": {"env": "dev"}, "deploy_schedule": "0 9 * * *"}`).
+		workspace("data-dev", `{"enabled": true, "labels": {"env": "dev", "team": "data"}, "deploy_schedule": "0 9 * * *"}`).
+		workspace("late-dev", `{"enabled": true, "labels": {"env": "dev"}, "deploy_schedule": "0 9 * * *", "destroy_schedule": "0 23 * * *"}`).
+		workspace("web-prod", `{"enabled": true, "labels": {"env": "prod"}, "deploy_schedule": "0 9 * * *"}`).
+		start().scheduler
+
+	webDev := scheduler.GetWorkspace("web-dev").Config
+	if webDev.DestroySchedule != "0 19 * * *" || webDev.MaxLifetime != "24h" {
+		t.Errorf("Expected the policy's destroy schedule and the tier's max_lifetime, got %v and %q", webDev.DestroySchedule, webDev.MaxLifetime)
+	}
+	dataDev := scheduler.GetWorkspace("data-dev").Config
+	if dataDev.DestroySchedule != "0 19 * * *" || !dataDev.IsProtected() {
+		t.Errorf("Expected the first policy's destroy schedule and the second's protection, got %v, protected %t", dataDev.DestroySchedule, dataDev.IsProtected())
+	}
+	if lateDev := scheduler.GetWorkspace("late-dev").Config; lateDev.DestroySchedule != "0 23 * * *" {
+		t.Errorf("Expected the workspace's own destroy schedule to win, got %v", lateDev.DestroySchedule)
+	}
+	webProd := scheduler.GetWorkspace("web-prod").Config
+	if webProd.DestroySchedule != nil || webProd.NotificationChannel != "ops" {
+		t.Errorf("Expected only the env!=dev policy, got destroy %v and channel %q", webProd.DestroySchedule, webProd.NotificationChannel)
+	}
+}
+
+func TestProtectedWorkspaceRequiresForce(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC)).daemonConfig(tierDaemonConfig).
+		workspace("billing", tierBilling).start()
+	sc.scheduler.state.SetWorkspaceStatus("billing", StatusDeployed)
+
+	err := sc.scheduler.ManualDestroy("billing")
+	if err == nil || !strings.Contains(err.Error(), "protected") {
+		t.Fatalf("Expected protected workspace to refuse destroy, got %v", err)
+	}
+	sc.expectOperations()
+
+	if err := sc.scheduler.ManualDestroyForce("billing"); err != nil {
+		t.Fatalf("Expected forced destroy to succeed, got %v", err)
+	}
+	sc.expectOperations("2025-03-10 10:00 destroy billing")
+}
+
+func TestScheduledDeployAwaitsApproval(t *testing.T) {
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)).daemonConfig(tierDaemonConfig).
+		workspace("billing", tierBilling).start()
+
+	sc.runUntil(time.Date(2025, 3, 10, 9, 5, 0, 0, time.UTC))
+	workspaceState := sc.scheduler.state.GetWorkspaceState("billing")
+	if workspaceState.ApprovalRequested == nil {
+		t.Fatal("Expected scheduled deploy to request approval")
+	}
+	requested := *workspaceState.ApprovalRequested
+
+	// Later checks keep waiting without a new request
+	sc.run(time.Hour)
+	sc.expectOperations()
+	if !workspaceState.ApprovalRequested.Equal(requested) {
+		t.Error("Expected the approval request to be kept")
+	}
+
+	// The operator approves by deploying manually
+	if err := sc.scheduler.ManualDeploy("billing"); err != nil {
+		t.Fatalf("Manual deploy failed: %v", err)
+	}
+	sc.expectOperations("2025-03-10 10:05 deploy billing")
+	if workspaceState.ApprovalRequested != nil {
+		t.Error("Expected manual deploy to settle the approval request")
+	}
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+)
+
+// isTimedOut reports whether an operation error was caused by its deploy_timeout or destroy_timeout
+func isTimedOut(err error) bool {
+	return errors.Is(err, opentofu.ErrTimedOut)
+}
+
+// recordTimeout stores how far an operation stopped by its timeout got, if err is a timeout. The
+// operation still fails as usual.
+func (s *Scheduler) recordTimeout(workspaceName, operationName, operation, mode string, err error) {
+	var timedOut *opentofu.TimeoutError
+	if !errors.As(err, &timedOut) {
+		return
+	}
+
+	timeout := &Timeout{
+		Operation:      operation,
+		Mode:           mode,
+		Timeout:        timedOut.Timeout.String(),
+		TimedOutAt:     s.currentTime(),
+		Step:           timedOut.Step,
+		CompletedSteps: timedOut.CompletedSteps,
+	}
+	s.state.UpdateWorkspace(workspaceName, func(workspaceState *WorkspaceState) { workspaceState.LastTimeout = timeout })
+	logging.LogWorkspaceOperation(workspaceName, operationName, "Timed out: %s", formatTimeout(timeout))
+}
+
+// formatTimeout summarizes a timeout for status output and logs
+func formatTimeout(t *Timeout) string {
+	operation := t.Operation
+	if t.Mode != "" {
+		operation = fmt.Sprintf("%s (mode %s)", operation, t.Mode)
+	}
+
+	summary := fmt.Sprintf("%s at %s after %s", operation, logging.FormatTime(t.TimedOutAt), t.Timeout)
+	if t.Step != "" {
+		summary += fmt.Sprintf(" during %s", t.Step)
+	}
+
+	completed := "none"
+	if len(t.CompletedSteps) > 0 {
+		completed = strings.Join(t.CompletedSteps, ", ")
+	}
+	return fmt.Sprintf("%s; completed steps: %s", summary, completed)
+}
@@ -0,0 +1,55 @@
+package scheduler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+func TestTimedOutDeployRecordsTimeout(t *testing.T) {
+	scheduler, mockClient := newMockScenario(t)
+	ws := busyApp(scheduler)
+	scheduler.workspaces = []workspace.Workspace{ws}
+
+	mockClient.DeployFunc = func(*workspace.Workspace) error {
+		return &opentofu.TimeoutError{Timeout: 30 * time.Minute, Step: "apply", CompletedSteps: []string{"init", "plan"}}
+	}
+	scheduler.deployWorkspace(ws)
+
+	workspaceState := scheduler.state.GetWorkspaceState(ws.Name)
+	if workspaceState.Status != StatusDeployFailed {
+		t.Fatalf("expected a timed out deploy to fail, got status %s", workspaceState.Status)
+	}
+	if !strings.Contains(workspaceState.LastDeployError, "timed out after 30m0s during apply") {
+		t.Errorf("expected the timeout as deploy error, got %q", workspaceState.LastDeployError)
+	}
+
+	timeout := workspaceState.LastTimeout
+	if timeout == nil || timeout.Operation != OperationDeploy || timeout.Timeout != "30m0s" || timeout.Step != "apply" {
+		t.Fatalf("unexpected timeout details: %+v", timeout)
+	}
+	if summary := formatTimeout(timeout); !strings.HasSuffix(summary, "after 30m0s during apply; completed steps: init, plan") {
+		t.Errorf("unexpected summary: %s", summary)
+	}
+
+	// Other failures don't record a timeout
+	mockClient.DestroyFunc = func(*workspace.Workspace) error { return errors.New("provider error") }
+	scheduler.state.SetWorkspaceStatus(ws.Name, StatusDeployed)
+	scheduler.destroyWorkspace(ws)
+	if workspaceState.LastTimeout.Operation != OperationDeploy {
+		t.Errorf("expected the destroy failure not to be recorded as timeout, got %+v", workspaceState.LastTimeout)
+	}
+
+	entries, err := scheduler.AuditLog().Read(audit.Filter{})
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Outcome != audit.OutcomeTimedOut || entries[1].Outcome != audit.OutcomeFailed {
+		t.Errorf("expected a timed_out and a failed audit entry, got %+v", entries)
+	}
+}
@@ -0,0 +1,152 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/workspace"
+)
+
+// TemplateUpgrade compares the template version a workspace last deployed with the installed one
+type TemplateUpgrade struct {
+	Workspace      string
+	Template       string
+	Mode           string // Deployment mode an upgrade redeploys in, empty for workspaces without mode schedules
+	DeployedHash   string // Empty if the deployment predates template tracking
+	DeployedCommit string
+	DeployedAt     *time.Time
+	CurrentHash    string
+	CurrentCommit  string
+}
+
+// Outdated reports whether the installed template differs from the deployed one. A deployment
+// without a recorded version counts as outdated, so upgrading it records the version.
+func (u *TemplateUpgrade) Outdated() bool {
+	return u.DeployedHash != u.CurrentHash
+}
+
+// String describes the template versions, e.g. "template 'web' changed since the last deploy (commit 3f2a9c1d8e7b -> commit 91c4e02b5a6f)"
+func (u *TemplateUpgrade) String() string {
+	if !u.Outdated() {
+		return fmt.Sprintf("template '%s' is unchanged since the last deploy (%s)", u.Template, templateVersion(u.CurrentHash, u.CurrentCommit))
+	}
+	if u.DeployedHash == "" {
+		return fmt.Sprintf("version of template '%s' last deployed is unknown, installed is %s", u.Template, templateVersion(u.CurrentHash, u.CurrentCommit))
+	}
+	return fmt.Sprintf("template '%s' changed since the last deploy (%s -> %s)", u.Template,
+		templateVersion(u.DeployedHash, u.DeployedCommit), templateVersion(u.CurrentHash, u.CurrentCommit))
+}
+
+// templateVersion identifies a template version by its source commit, or its content hash for
+// templates without one
+func templateVersion(hash, commit string) string {
+	if strings.HasPrefix(commit, "sha256:") {
+		return "digest " + shortVersion(strings.TrimPrefix(commit, "sha256:"))
+	}
+	if commit != "" {
+		return "commit " + shortVersion(commit)
+	}
+	return "hash " + shortVersion(hash)
+}
+
+func shortVersion(version string) string {
+	if len(version) > 12 {
+		return version[:12]
+	}
+	return version
+}
+
+// TemplateUpgradeStatus compares the template version a workspace last deployed with the installed one
+func (s *Scheduler) TemplateUpgradeStatus(workspaceName string) (*TemplateUpgrade, error) {
+	ws := s.GetWorkspace(workspaceName)
+	if ws == nil {
+		return nil, fmt.Errorf("workspace '%s' not found in configuration", workspaceName)
+	}
+	if !ws.IsUsingTemplate() {
+		return nil, fmt.Errorf("workspace '%s' does not deploy from a template", workspaceName)
+	}
+
+	tmpl, err := s.templateManager.GetTemplate(ws.Config.Template)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := workspace.LoadDeploymentMetadata(getStateDir(), workspaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	upgrade := &TemplateUpgrade{
+		Workspace:     workspaceName,
+		Template:      tmpl.Name,
+		CurrentHash:   tmpl.ContentHash,
+		CurrentCommit: tmpl.Commit,
+	}
+	// A workspace that switched templates has not deployed any version of the current one
+	if metadata.TemplateName == tmpl.Name {
+		upgrade.DeployedHash = metadata.TemplateHash
+		upgrade.DeployedCommit = metadata.TemplateCommit
+		upgrade.DeployedAt = metadata.DeployedAt
+	}
+	if len(ws.Config.ModeSchedules) > 0 {
+		upgrade.Mode = s.state.Workspace(workspaceName).DeploymentMode
+	}
+	return upgrade, nil
+}
+
+// isTemplateOutdated reports whether a deployed workspace runs an older version of its template
+func (s *Scheduler) isTemplateOutdated(ws workspace.Workspace, status string) bool {
+	if status != "deployed" || !ws.IsUsingTemplate() {
+		return false
+	}
+	upgrade, err := s.TemplateUpgradeStatus(ws.Name)
+	return err == nil && upgrade.Outdated()
+}
+
+// CheckUpgrade returns the template versions of a workspace that can be upgraded: it must be
+// deployed and, with mode schedules, have a deployment mode to redeploy in
+func (s *Scheduler) CheckUpgrade(workspaceName string) (*TemplateUpgrade, error) {
+	upgrade, err := s.TemplateUpgradeStatus(workspaceName)
+	if err != nil {
+		return nil, err
+	}
+
+	ws := s.GetWorkspace(workspaceName)
+	if ws.GetDeploymentStatus() != "deployed" {
+		return nil, fmt.Errorf("workspace '%s' is not deployed, use deploy instead", workspaceName)
+	}
+	if upgrade.Mode == "" && len(ws.Config.ModeSchedules) > 0 {
+		return nil, fmt.Errorf("workspace '%s' has no recorded deployment mode, deploy it in a mode instead", workspaceName)
+	}
+	return upgrade, nil
+}
+
+// UpgradeWorkspace redeploys a deployed workspace whose template changed since its last deploy,
+// in its current deployment mode
+func (s *Scheduler) UpgradeWorkspace(workspaceName string) error {
+	upgrade, err := s.CheckUpgrade(workspaceName)
+	if err != nil {
+		return err
+	}
+	if !upgrade.Outdated() {
+		return fmt.Errorf("workspace '%s' is up to date, %s", workspaceName, upgrade)
+	}
+
+	logging.LogWorkspaceOperation(workspaceName, "UPGRADE", "Redeploying, %s", upgrade)
+	if upgrade.Mode != "" {
+		err = s.manualDeployInMode(workspaceName, upgrade.Mode, true, false)
+	} else {
+		err = s.ManualDeploy(workspaceName)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Deploy failures, and failed post_deploy hooks, are recorded in the state rather than returned
+	if workspaceState := s.state.Workspace(workspaceName); workspaceState.Status == StatusDeployFailed || workspaceState.Status == StatusDeployDegraded {
+		return fmt.Errorf("upgrade of workspace '%s' failed: %s", workspaceName, getHighLevelError(errors.New(workspaceState.LastDeployError)))
+	}
+	return nil
+}
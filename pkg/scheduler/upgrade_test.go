@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/template"
+	"provisioner/pkg/workspace"
+)
+
+// templateScenario starts a scenario with a web-app template at content hash v1 and the given
+// workspaces deploying from it, each deployed with v1
+func templateScenario(t *testing.T, configs map[string]string) (*scenario, *template.Manager) {
+	t.Helper()
+	sc := newScenario(t, time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC))
+
+	manager := template.NewManager(filepath.Join(sc.dir, "templates"))
+	if err := os.MkdirAll(manager.GetTemplatePath("web-app"), 0755); err != nil {
+		t.Fatalf("Failed to create template directory: %v", err)
+	}
+	sc.writeFile(filepath.Join(manager.GetTemplatePath("web-app"), "main.tf"), `resource "null_resource" "web" {}`)
+	setTemplateHash(t, manager, "v1")
+
+	for name, config := range configs {
+		if err := os.MkdirAll(filepath.Join(sc.dir, "workspaces", name), 0755); err != nil {
+			t.Fatalf("Failed to create workspace directory: %v", err)
+		}
+		sc.editConfig(name, config)
+
+		deploymentDir := filepath.Join(sc.dir, "deployments", name)
+		if err := os.MkdirAll(deploymentDir, 0755); err != nil {
+			t.Fatalf("Failed to create deployment directory: %v", err)
+		}
+		sc.writeFile(filepath.Join(deploymentDir, "terraform.tfstate"), `{"resources":[{"type":"null_resource"}]}`)
+		if err := workspace.UpdateDeploymentTemplate(sc.dir, name, "web-app", "v1", ""); err != nil {
+			t.Fatalf("Failed to record deployed template: %v", err)
+		}
+	}
+
+	sc.start()
+	sc.scheduler.templateManager = manager
+	return sc, manager
+}
+
+// setTemplateHash stands in for a template update changing the template's content
+func setTemplateHash(t *testing.T, manager *template.Manager, hash string) {
+	t.Helper()
+	registry, err := manager.LoadRegistry()
+	if err != nil {
+		t.Fatalf("Failed to load registry: %v", err)
+	}
+	registry.Templates["web-app"] = template.Template{Name: "web-app", ContentHash: hash}
+	if err := manager.SaveRegistry(registry); err != nil {
+		t.Fatalf("Failed to save registry: %v", err)
+	}
+}
+
+func TestUpgradeWorkspace(t *testing.T) {
+	sc, manager := templateScenario(t, map[string]string{
+		"web": `{"enabled": true, "template": "web-app"}`,
+	})
+
+	if err := sc.scheduler.UpgradeWorkspace("web"); err == nil || !strings.Contains(err.Error(), "up to date") {
+		t.Errorf("Expected an up to date workspace not to be upgraded, got %v", err)
+	}
+	if summaries := sc.scheduler.WorkspaceSummaries(sc.clock.Now()); summaries[0].Outdated {
+		t.Error("Expected the workspace not to be outdated")
+	}
+
+	setTemplateHash(t, manager, "v2")
+	summaries := sc.scheduler.WorkspaceSummaries(sc.clock.Now())
+	if !summaries[0].Outdated || summaries[0].ListField("outdated") != "true" {
+		t.Error("Expected the workspace to be outdated after the template changed")
+	}
+
+	upgrade, err := sc.scheduler.CheckUpgrade("web")
+	if err != nil {
+		t.Fatalf("CheckUpgrade failed: %v", err)
+	}
+	if upgrade.DeployedHash != "v1" || upgrade.CurrentHash != "v2" || !strings.Contains(upgrade.String(), "hash v1 -> hash v2") {
+		t.Errorf("Unexpected upgrade %+v: %s", upgrade, upgrade)
+	}
+
+	if err := sc.scheduler.UpgradeWorkspace("web"); err != nil {
+		t.Fatalf("UpgradeWorkspace failed: %v", err)
+	}
+	sc.expectOperations("2025-03-10 08:00 deploy web")
+
+	// A failed redeploy is reported
+	sc.failNext("deploy web", 1)
+	if err := sc.scheduler.UpgradeWorkspace("web"); err == nil || !strings.Contains(err.Error(), "deploy web failed") {
+		t.Errorf("Expected the failed upgrade to be reported, got %v", err)
+	}
+	sc.expectOperations("2025-03-10 08:00 deploy web")
+}
+
+func TestUpgradeWorkspaceInMode(t *testing.T) {
+	sc, manager := templateScenario(t, map[string]string{
+		"web": `{"enabled": true, "template": "web-app", "mode_schedules": {"busy": "0 9 * * 1-5", "quiet": "0 18 * * 1-5"}}`,
+	})
+
+	// Without a recorded mode there is nothing to redeploy in
+	setTemplateHash(t, manager, "v2")
+	if _, err := sc.scheduler.CheckUpgrade("web"); err == nil || !strings.Contains(err.Error(), "deployment mode") {
+		t.Errorf("Expected a missing deployment mode to be refused, got %v", err)
+	}
+
+	// Redeploys in the current mode, which a manual deploy in that mode would skip
+	workspaceState := sc.scheduler.state.GetWorkspaceState("web")
+	workspaceState.Status = StatusDeployed
+	workspaceState.DeploymentMode = "busy"
+	if err := sc.scheduler.UpgradeWorkspace("web"); err != nil {
+		t.Fatalf("UpgradeWorkspace failed: %v", err)
+	}
+	sc.expectOperations("2025-03-10 08:00 deploy web (busy)")
+}
+
+func TestUpgradeWorkspaceNotDeployed(t *testing.T) {
+	sc, manager := templateScenario(t, map[string]string{
+		"web": `{"enabled": true, "template": "web-app"}`,
+	})
+	if err := os.Remove(filepath.Join(sc.dir, "deployments", "web", "terraform.tfstate")); err != nil {
+		t.Fatalf("Failed to remove state: %v", err)
+	}
+	setTemplateHash(t, manager, "v2")
+
+	if summaries := sc.scheduler.WorkspaceSummaries(sc.clock.Now()); summaries[0].Outdated {
+		t.Error("Expected a destroyed workspace not to be listed as outdated")
+	}
+	if err := sc.scheduler.UpgradeWorkspace("web"); err == nil || !strings.Contains(err.Error(), "not deployed") {
+		t.Errorf("Expected upgrading a destroyed workspace to fail, got %v", err)
+	}
+	sc.expectOperations()
+}
@@ -0,0 +1,137 @@
+// Package slo tracks the success rates of deploys and job runs over a rolling window and compares
+// them with their objectives.
+package slo
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default window success rates are computed over
+const (
+	DefaultWindowRuns = 30
+	DefaultWindowDays = 30
+)
+
+// Window selects the runs a success rate is computed over: the most recent Runs runs of the last
+// Days days. Zero values use the defaults.
+type Window struct {
+	Runs int
+	Days int
+}
+
+// Since returns the earliest time of runs counted by the window at now
+func (w Window) Since(now time.Time) time.Time {
+	days := w.Days
+	if days <= 0 {
+		days = DefaultWindowDays
+	}
+	return now.AddDate(0, 0, -days)
+}
+
+// MaxRuns returns the number of runs counted by the window
+func (w Window) MaxRuns() int {
+	if w.Runs <= 0 {
+		return DefaultWindowRuns
+	}
+	return w.Runs
+}
+
+// Validate checks the window for negative sizes
+func (w Window) Validate() error {
+	if w.Runs < 0 {
+		return fmt.Errorf("window_runs must not be negative")
+	}
+	if w.Days < 0 {
+		return fmt.Errorf("window_days must not be negative")
+	}
+	return nil
+}
+
+// ValidateObjective checks that an objective is a percentage, 0 disabling it
+func ValidateObjective(name string, objective float64) error {
+	if objective < 0 || objective > 100 {
+		return fmt.Errorf("%s must be a percentage between 0 and 100, got %g", name, objective)
+	}
+	return nil
+}
+
+// Run is the outcome of a single deploy or job run
+type Run struct {
+	Time      time.Time `json:"time"`
+	Succeeded bool      `json:"succeeded"`
+}
+
+// Tracker keeps the outcomes of an operation's most recent runs, never more than its window
+// counts, so its success rate is computed without reading the full history
+type Tracker struct {
+	Runs     []Run `json:"runs,omitempty"` // Oldest first
+	Breached bool  `json:"breached,omitempty"`
+}
+
+// Record adds a run and drops the runs that fell out of the window
+func (t *Tracker) Record(run Run, window Window) {
+	t.Runs = append(t.Runs, run)
+
+	since := window.Since(run.Time)
+	first := 0
+	for first < len(t.Runs) && t.Runs[first].Time.Before(since) {
+		first++
+	}
+	if excess := len(t.Runs) - first - window.MaxRuns(); excess > 0 {
+		first += excess
+	}
+	t.Runs = append(t.Runs[:0], t.Runs[first:]...)
+}
+
+// Rate returns the success rate of the runs within the window at now
+func (t *Tracker) Rate(window Window, now time.Time) Rate {
+	var rate Rate
+	if t == nil {
+		return rate
+	}
+
+	since := window.Since(now)
+	for i := len(t.Runs) - 1; i >= 0 && rate.Runs < window.MaxRuns(); i-- {
+		if t.Runs[i].Time.Before(since) {
+			break
+		}
+		rate.Runs++
+		if t.Runs[i].Succeeded {
+			rate.Succeeded++
+		}
+	}
+
+	if rate.Runs > 0 {
+		rate.Percent = 100 * float64(rate.Succeeded) / float64(rate.Runs)
+	}
+	return rate
+}
+
+// Evaluate records whether the rate is below the objective and reports whether that changed.
+// Objectives of 0 and rates without runs are never breached.
+func (t *Tracker) Evaluate(rate Rate, objective float64) bool {
+	breached := IsBreached(rate, objective)
+	changed := breached != t.Breached
+	t.Breached = breached
+	return changed
+}
+
+// IsBreached reports whether a rate is below its objective
+func IsBreached(rate Rate, objective float64) bool {
+	return objective > 0 && rate.Runs > 0 && rate.Percent < objective
+}
+
+// Rate is the share of successful runs within a window
+type Rate struct {
+	Runs      int     `json:"runs"`
+	Succeeded int     `json:"succeeded"`
+	Percent   float64 `json:"percent"` // 0 without runs
+}
+
+func (r Rate) String() string {
+	if r.Runs == 0 {
+		return "no runs"
+	}
+	return fmt.Sprintf("%.1f%% (%d of %d)", r.Percent, r.Succeeded, r.Runs)
+}
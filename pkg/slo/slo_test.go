@@ -0,0 +1,63 @@
+package slo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrackerKeepsOnlyTheWindow(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	window := Window{Runs: 3, Days: 7}
+
+	var tracker Tracker
+	tracker.Record(Run{Time: now.AddDate(0, 0, -20), Succeeded: false}, window)
+	tracker.Record(Run{Time: now.AddDate(0, 0, -4), Succeeded: false}, window)
+	if len(tracker.Runs) != 1 {
+		t.Fatalf("Expected runs 7 days older than the last one to be dropped, got %+v", tracker.Runs)
+	}
+
+	for i := 3; i > 0; i-- {
+		tracker.Record(Run{Time: now.AddDate(0, 0, -i), Succeeded: true}, window)
+	}
+	if len(tracker.Runs) != 3 {
+		t.Fatalf("Expected the last 3 runs to be kept, got %+v", tracker.Runs)
+	}
+
+	rate := tracker.Rate(window, now)
+	if rate.Runs != 3 || rate.Succeeded != 3 || rate.String() != "100.0% (3 of 3)" {
+		t.Errorf("Expected 3 of 3 runs to have succeeded, got %s", rate)
+	}
+
+	// Runs age out of the window without new runs
+	rate = tracker.Rate(window, now.AddDate(0, 0, 6))
+	if rate.Runs != 1 {
+		t.Errorf("Expected a single run in the last 7 days, got %+v", rate)
+	}
+	if rate := tracker.Rate(window, now.AddDate(0, 1, 0)); rate.Runs != 0 || rate.String() != "no runs" {
+		t.Errorf("Expected no runs, got %+v", rate)
+	}
+}
+
+func TestTrackerEvaluate(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 0, 0, 0, time.UTC)
+	var tracker Tracker
+
+	tracker.Record(Run{Time: now, Succeeded: false}, Window{})
+	if changed := tracker.Evaluate(tracker.Rate(Window{}, now), 90); !changed || !tracker.Breached {
+		t.Fatalf("Expected a failed run to breach the objective")
+	}
+	if changed := tracker.Evaluate(tracker.Rate(Window{}, now), 90); changed {
+		t.Error("Expected an ongoing breach not to change")
+	}
+
+	for i := 0; i < 9; i++ {
+		tracker.Record(Run{Time: now, Succeeded: true}, Window{})
+	}
+	if changed := tracker.Evaluate(tracker.Rate(Window{}, now), 90); !changed || tracker.Breached {
+		t.Errorf("Expected 9 of 10 runs to meet the objective, got %s", tracker.Rate(Window{}, now))
+	}
+
+	if IsBreached(Rate{}, 90) || IsBreached(Rate{Runs: 1}, 0) {
+		t.Error("Expected rates without runs or objective never to be breached")
+	}
+}
@@ -0,0 +1,132 @@
+// Package statefile reads and writes JSON state files that the daemon and CLIs share.
+// Writes are serialized with an advisory lock and replace the file atomically, and the
+// previous content is kept as a backup that reads fall back to if the file is corrupt.
+package statefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+const (
+	// BackupSuffix is appended to a state file's path for the copy of its previous content
+	BackupSuffix = ".bak"
+	// LockSuffix is appended to a state file's path for the lock file serializing writers
+	LockSuffix = ".lock"
+)
+
+// ErrCorrupt is returned when a state file and its backup both hold invalid JSON
+var ErrCorrupt = errors.New("state file is corrupt")
+
+// Write replaces the file at path with data. Concurrent writers in other processes wait for
+// each other, readers always see either the old or the new content, and the old content is
+// kept in path.bak if it was valid.
+func Write(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	unlock, err := Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Keep the last good content; a corrupt file must not replace a good backup
+	if previous, err := os.ReadFile(path); err == nil && json.Valid(previous) {
+		if err := replace(path+BackupSuffix, previous); err != nil {
+			return fmt.Errorf("failed to back up state file: %w", err)
+		}
+	}
+
+	return replace(path, data)
+}
+
+// Read returns the content of the state file at path. If the file holds invalid JSON, e.g.
+// after a crash during a write by an older version, the backup is restored and returned with
+// recovered set. A missing file is reported as an error satisfying os.IsNotExist.
+func Read(path string) (data []byte, recovered bool, err error) {
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return nil, false, err
+	}
+	if json.Valid(data) {
+		return data, false, nil
+	}
+
+	backup, err := os.ReadFile(path + BackupSuffix)
+	if err != nil || !json.Valid(backup) {
+		return nil, false, fmt.Errorf("%w: %s has no valid backup", ErrCorrupt, path)
+	}
+
+	unlock, err := Lock(path)
+	if err != nil {
+		return nil, false, err
+	}
+	defer unlock()
+
+	// Another process may have written a good file since we read it
+	if current, err := os.ReadFile(path); err == nil && json.Valid(current) {
+		return current, false, nil
+	}
+	if err := replace(path, backup); err != nil {
+		return nil, false, fmt.Errorf("failed to restore state file from backup: %w", err)
+	}
+	return backup, true, nil
+}
+
+// Lock takes an exclusive advisory lock on path's lock file, waiting for other writers, and
+// returns the function releasing it. Write takes it, so callers only need it to read, modify
+// and write a file without losing concurrent changes.
+func Lock(path string) (func(), error) {
+	file, err := os.OpenFile(path+LockSuffix, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open state lock: %w", err)
+	}
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to lock state file: %w", err)
+	}
+	return func() {
+		_ = syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		_ = file.Close()
+	}, nil
+}
+
+// replace writes data to a temporary file, syncs it and renames it over path
+func replace(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
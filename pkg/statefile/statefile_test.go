@@ -0,0 +1,116 @@
+package statefile
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriteAndRead(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "scheduler.json")
+
+	if _, _, err := Read(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected not-exist error for missing file, got %v", err)
+	}
+
+	if err := Write(path, []byte(`{"version":1}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := Write(path, []byte(`{"version":2}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	data, recovered, err := Read(path)
+	if err != nil || recovered || string(data) != `{"version":2}` {
+		t.Fatalf("Expected latest content, got %q (recovered %v, err %v)", data, recovered, err)
+	}
+
+	backup, err := os.ReadFile(path + BackupSuffix)
+	if err != nil || string(backup) != `{"version":1}` {
+		t.Errorf("Expected previous content in backup, got %q (err %v)", backup, err)
+	}
+
+	// No temporary files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	for _, entry := range entries {
+		switch entry.Name() {
+		case "scheduler.json", "scheduler.json" + BackupSuffix, "scheduler.json" + LockSuffix:
+		default:
+			t.Errorf("Unexpected file left in state directory: %s", entry.Name())
+		}
+	}
+}
+
+func TestReadRecoversFromBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.json")
+	if err := Write(path, []byte(`{"version":1}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if err := Write(path, []byte(`{"version":2}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+
+	// Simulate a torn write
+	if err := os.WriteFile(path, []byte(`{"versi`), 0644); err != nil {
+		t.Fatalf("Failed to corrupt state file: %v", err)
+	}
+
+	data, recovered, err := Read(path)
+	if err != nil || !recovered || string(data) != `{"version":1}` {
+		t.Fatalf("Expected recovery from backup, got %q (recovered %v, err %v)", data, recovered, err)
+	}
+	if restored, _ := os.ReadFile(path); string(restored) != `{"version":1}` {
+		t.Errorf("Expected state file to be restored on disk, got %q", restored)
+	}
+
+	// A corrupt file never replaces the good backup
+	if err := os.WriteFile(path, []byte(`garbage`), 0644); err != nil {
+		t.Fatalf("Failed to corrupt state file: %v", err)
+	}
+	if err := Write(path, []byte(`{"version":3}`)); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if backup, _ := os.ReadFile(path + BackupSuffix); string(backup) != `{"version":1}` {
+		t.Errorf("Expected good backup to be kept, got %q", backup)
+	}
+}
+
+func TestReadCorruptWithoutBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler.json")
+	if err := os.WriteFile(path, []byte(`{`), 0644); err != nil {
+		t.Fatalf("Failed to write state file: %v", err)
+	}
+
+	if _, _, err := Read(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestConcurrentWritesStayValid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scheduler.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := Write(path, []byte(fmt.Sprintf(`{"writer":%d}`, i))); err != nil {
+				t.Errorf("Write failed: %v", err)
+			}
+			if data, _, err := Read(path); err != nil || !json.Valid(data) {
+				t.Errorf("Expected valid JSON during concurrent writes, got %q (err %v)", data, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	for _, file := range []string{path, path + BackupSuffix} {
+		if data, err := os.ReadFile(file); err != nil || !json.Valid(data) {
+			t.Errorf("Expected %s to hold valid JSON, got %q (err %v)", filepath.Base(file), data, err)
+		}
+	}
+}
@@ -0,0 +1,452 @@
+package support
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"provisioner/pkg/configfile"
+	"provisioner/pkg/control"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/paths"
+	"provisioner/pkg/template"
+	"provisioner/pkg/version"
+	"provisioner/pkg/workspace"
+)
+
+// DefaultLogLines is the number of lines kept from the end of each log file
+const DefaultLogLines = 500
+
+// maxFileSize skips files that are too large to be useful in a bug report
+const maxFileSize = 1 << 20
+
+// Options controls what is collected into a support bundle
+type Options struct {
+	ConfigDir    string
+	StateDir     string
+	LogDir       string
+	LogLines     int  // Lines kept from the end of each log file
+	IncludeLogs  bool // Add recent workspace and daemon logs
+	IncludeState bool // Add scheduler, job and deployment metadata state files
+}
+
+// DefaultOptions returns options using the auto-discovered directories
+func DefaultOptions() Options {
+	return Options{
+		ConfigDir:    paths.ConfigDir(),
+		StateDir:     paths.StateDir(),
+		LogDir:       paths.LogDir(),
+		LogLines:     DefaultLogLines,
+		IncludeLogs:  true,
+		IncludeState: true,
+	}
+}
+
+// Diagnostics summarizes the environment the bundle was collected in
+type Diagnostics struct {
+	GeneratedAt     time.Time               `json:"generated_at"`
+	Build           version.BuildInfo       `json:"build"`
+	ConfigDir       string                  `json:"config_dir"`
+	StateDir        string                  `json:"state_dir"`
+	LogDir          string                  `json:"log_dir"`
+	DaemonRunning   bool                    `json:"daemon_running"` // Control socket accepted a connection
+	Versions        *opentofu.VersionReport `json:"versions,omitempty"`
+	WorkspaceErrors map[string]string       `json:"workspace_errors,omitempty"` // Validation errors by workspace
+	Errors          []string                `json:"errors,omitempty"`           // Problems while collecting the bundle
+}
+
+// sensitiveKeyPattern matches JSON keys whose values are masked in config and state files
+var sensitiveKeyPattern = regexp.MustCompile(`(?i)(secret|token|password|passwd|api[_-]?key|access[_-]?key|private[_-]?key|credential|webhook_url)`)
+
+// tfvarsAssignment matches "name = value" lines in .tfvars files
+var tfvarsAssignment = regexp.MustCompile(`^(\s*[A-Za-z_][A-Za-z0-9_-]*\s*=\s*).+$`)
+
+// bundle writes files into a tar archive below a common root directory
+type bundle struct {
+	tw      *tar.Writer
+	root    string
+	modTime time.Time
+	diag    *Diagnostics
+}
+
+// WriteBundle writes a gzipped tarball with sanitized configuration, state, logs and diagnostics
+func WriteBundle(w io.Writer, opts Options) error {
+	now := time.Now()
+	gz := gzip.NewWriter(w)
+	b := &bundle{
+		tw:      tar.NewWriter(gz),
+		root:    "provisioner-support-" + now.Format("20060102-150405"),
+		modTime: now,
+		diag: &Diagnostics{
+			GeneratedAt: now,
+			Build:       version.GetBuildInfo(),
+			ConfigDir:   opts.ConfigDir,
+			StateDir:    opts.StateDir,
+			LogDir:      opts.LogDir,
+		},
+	}
+
+	if err := b.addFile("version.txt", []byte(version.GetFullVersion()+"\n")); err != nil {
+		return err
+	}
+
+	// Register workspace redaction patterns before anything of theirs is added
+	workspaces, err := workspace.LoadWorkspaces(filepath.Join(opts.ConfigDir, "workspaces"))
+	if err != nil {
+		b.addError("workspaces: %v", err)
+	}
+	for _, ws := range workspaces {
+		if err := logging.SetWorkspaceRedactPatterns(ws.Name, ws.Config.RedactPatterns); err != nil {
+			b.addError("workspace %s: %v", ws.Name, err)
+		}
+	}
+
+	b.collectConfig(opts.ConfigDir)
+	if opts.IncludeState {
+		b.collectState(opts.StateDir)
+	}
+	if opts.IncludeLogs {
+		b.collectLogs(opts.LogDir, opts.LogLines)
+	}
+	b.collectDiagnostics(opts, workspaces)
+
+	// Diagnostics go last so they include any collection errors
+	data, err := json.MarshalIndent(b.diag, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal diagnostics: %w", err)
+	}
+	if err := b.addFile("diagnostics.json", []byte(logging.Redact(string(data)))); err != nil {
+		return err
+	}
+
+	if err := b.tw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+	return nil
+}
+
+// addFile adds a file to the archive below the bundle root
+func (b *bundle) addFile(name string, data []byte) error {
+	header := &tar.Header{
+		Name:    filepath.ToSlash(filepath.Join(b.root, name)),
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: b.modTime,
+	}
+	if err := b.tw.WriteHeader(header); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	if _, err := b.tw.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s: %w", name, err)
+	}
+	return nil
+}
+
+// addError records a collection problem in the diagnostics instead of failing the bundle
+func (b *bundle) addError(format string, v ...interface{}) {
+	b.diag.Errors = append(b.diag.Errors, fmt.Sprintf(format, v...))
+}
+
+// collectConfig adds daemon-wide settings and all workspace and job configuration
+func (b *bundle) collectConfig(configDir string) {
+	// Only top-level settings files; the development config dir is the repository root
+	var files []string
+	for _, ext := range configfile.Extensions {
+		matches, _ := filepath.Glob(filepath.Join(configDir, "*"+ext))
+		files = append(files, matches...)
+	}
+	for _, path := range files {
+		b.addSanitizedFile(path, filepath.Join("config", filepath.Base(path)), "")
+	}
+
+	for _, dir := range []string{"workspaces", "jobs"} {
+		root := filepath.Join(configDir, dir)
+		if _, err := os.Stat(root); os.IsNotExist(err) {
+			continue
+		}
+
+		err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				b.addError("config: %v", err)
+				return nil
+			}
+			if info.IsDir() {
+				if info.Name() == ".terraform" || info.Name() == ".git" {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !isConfigFile(path) {
+				return nil
+			}
+
+			rel, _ := filepath.Rel(configDir, path)
+			b.addSanitizedFile(path, filepath.Join("config", rel), workspaceNameFromPath(dir, rel))
+			return nil
+		})
+		if err != nil {
+			b.addError("config: failed to walk %s: %v", root, err)
+		}
+	}
+}
+
+// collectState adds scheduler and job state plus per-deployment metadata, never tofu state
+func (b *bundle) collectState(stateDir string) {
+	files, _ := filepath.Glob(filepath.Join(stateDir, "*.json"))
+	for _, path := range files {
+		b.addSanitizedFile(path, filepath.Join("state", filepath.Base(path)), "")
+	}
+
+	metadataFiles, _ := filepath.Glob(filepath.Join(stateDir, "deployments", "*", ".provisioner-metadata.json"))
+	debugFiles, _ := filepath.Glob(filepath.Join(stateDir, "debug", "*.json"))
+	resultFiles, _ := filepath.Glob(filepath.Join(stateDir, "results", "*.json"))
+	for _, path := range append(append(metadataFiles, debugFiles...), resultFiles...) {
+		rel, _ := filepath.Rel(stateDir, path)
+		b.addSanitizedFile(path, filepath.Join("state", rel), "")
+	}
+}
+
+// collectLogs adds the last lines of each log file
+func (b *bundle) collectLogs(logDir string, lines int) {
+	files, _ := filepath.Glob(filepath.Join(logDir, "*.log"))
+	for _, path := range files {
+		data, err := tailFile(path, lines)
+		if err != nil {
+			b.addError("logs: %v", err)
+			continue
+		}
+
+		wsName := strings.TrimSuffix(filepath.Base(path), ".log")
+		if err := b.addFile(filepath.Join("logs", filepath.Base(path)), []byte(logging.RedactWorkspace(wsName, data))); err != nil {
+			b.addError("logs: %v", err)
+		}
+	}
+}
+
+// collectDiagnostics fills in the version report, workspace validation and daemon status
+func (b *bundle) collectDiagnostics(opts Options, workspaces []workspace.Workspace) {
+	if client, err := control.DialPath(filepath.Join(opts.StateDir, control.SocketName)); err == nil {
+		b.diag.DaemonRunning = true
+		_ = client.Close()
+	}
+
+	for _, ws := range workspaces {
+		if err := ws.Config.Validate(); err != nil {
+			if b.diag.WorkspaceErrors == nil {
+				b.diag.WorkspaceErrors = make(map[string]string)
+			}
+			b.diag.WorkspaceErrors[ws.Name] = err.Error()
+		}
+	}
+
+	templateManager := template.NewManager(filepath.Join(opts.StateDir, "templates"))
+	b.diag.Versions = opentofu.BuildVersionReport(workspaces, templateManager, opts.StateDir)
+}
+
+// addSanitizedFile adds a config or state file with secrets masked
+func (b *bundle) addSanitizedFile(path, name, workspaceName string) {
+	info, err := os.Stat(path)
+	if err != nil {
+		b.addError("%s: %v", name, err)
+		return
+	}
+	if info.Size() > maxFileSize {
+		b.addError("%s: skipped, %d bytes exceeds %d byte limit", name, info.Size(), maxFileSize)
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		b.addError("%s: %v", name, err)
+		return
+	}
+
+	if err := b.addFile(name, []byte(Sanitize(path, data, workspaceName))); err != nil {
+		b.addError("%s: %v", name, err)
+	}
+}
+
+// Sanitize masks secrets in a config or state file based on its type
+func Sanitize(path string, data []byte, workspaceName string) string {
+	name := filepath.Base(path)
+
+	switch {
+	case strings.HasSuffix(name, ".tfvars.json"):
+		// Variable values are often credentials, keep only the names
+		var vars map[string]interface{}
+		if err := json.Unmarshal(data, &vars); err == nil {
+			for key := range vars {
+				vars[key] = logging.RedactionMask
+			}
+			if masked, err := json.MarshalIndent(vars, "", "  "); err == nil {
+				return string(masked) + "\n"
+			}
+		}
+		return logging.RedactionMask + "\n"
+	case strings.HasSuffix(name, ".tfvars"):
+		var out strings.Builder
+		for _, line := range strings.Split(string(data), "\n") {
+			if !strings.HasPrefix(strings.TrimSpace(line), "#") {
+				line = tfvarsAssignment.ReplaceAllString(line, "${1}\""+logging.RedactionMask+"\"")
+			}
+			out.WriteString(line + "\n")
+		}
+		return strings.TrimSuffix(out.String(), "\n")
+	case filepath.Ext(name) == ".json":
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err == nil {
+			if masked, err := json.MarshalIndent(maskSensitiveKeys(value), "", "  "); err == nil {
+				data = append(masked, '\n')
+			}
+		}
+	case filepath.Ext(name) == ".yaml" || filepath.Ext(name) == ".yml" || filepath.Ext(name) == ".hcl":
+		// YAML and HCL configs are masked as JSON; other HCL files, e.g. lock files, don't convert
+		var value interface{}
+		if converted, err := configfile.ToJSON(path, data); err == nil && json.Unmarshal(converted, &value) == nil {
+			if masked, err := json.MarshalIndent(maskSensitiveKeys(value), "", "  "); err == nil {
+				data = append(masked, '\n')
+			}
+		}
+	}
+
+	return logging.RedactWorkspace(workspaceName, string(data))
+}
+
+// maskSensitiveKeys replaces string values of keys that look like credentials
+func maskSensitiveKeys(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if _, isString := child.(string); isString && sensitiveKeyPattern.MatchString(key) && child != "" {
+				v[key] = logging.RedactionMask
+				continue
+			}
+			v[key] = maskSensitiveKeys(child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = maskSensitiveKeys(child)
+		}
+	}
+	return value
+}
+
+// tailFile returns the last lines of a file
+func tailFile(path string, lines int) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = file.Close() }()
+
+	var tail []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxFileSize)
+	for scanner.Scan() {
+		tail = append(tail, scanner.Text())
+		if lines > 0 && len(tail) > lines {
+			tail = tail[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if len(tail) == 0 {
+		return "", nil
+	}
+	return strings.Join(tail, "\n") + "\n", nil
+}
+
+// isConfigFile reports whether a file under workspaces/ or jobs/ belongs in the bundle
+func isConfigFile(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasSuffix(name, ".tfstate") || strings.HasSuffix(name, ".tfstate.backup") {
+		return false
+	}
+	switch filepath.Ext(name) {
+	case ".json", ".yaml", ".yml", ".tf", ".tfvars", ".hcl", ".sh":
+		return true
+	}
+	return false
+}
+
+// workspaceNameFromPath returns the workspace a config file belongs to, for workspace redaction patterns
+func workspaceNameFromPath(dir, rel string) string {
+	if dir != "workspaces" {
+		return ""
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// RunSupportBundleCommand writes a support bundle for attaching to bug reports
+func RunSupportBundleCommand(args []string) error {
+	opts := DefaultOptions()
+	output := ""
+
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "--output", "-o":
+			if i+1 >= len(args) {
+				return fmt.Errorf("%s requires a file path", args[i])
+			}
+			i++
+			output = args[i]
+		case "--log-lines":
+			if i+1 >= len(args) {
+				return fmt.Errorf("--log-lines requires a number")
+			}
+			i++
+			lines, err := strconv.Atoi(args[i])
+			if err != nil || lines < 0 {
+				return fmt.Errorf("invalid --log-lines '%s': must be a non-negative number", args[i])
+			}
+			opts.LogLines = lines
+		case "--no-logs":
+			opts.IncludeLogs = false
+		case "--no-state":
+			opts.IncludeState = false
+		default:
+			return fmt.Errorf("unknown option '%s'", args[i])
+		}
+	}
+
+	if output == "" {
+		output = fmt.Sprintf("provisioner-support-%s.tar.gz", time.Now().Format("20060102-150405"))
+	}
+
+	file, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create support bundle: %w", err)
+	}
+
+	if err := WriteBundle(file, opts); err != nil {
+		_ = file.Close()
+		_ = os.Remove(output)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write support bundle: %w", err)
+	}
+
+	fmt.Printf("Support bundle written to %s\n", output)
+	fmt.Println("Secrets matching the redaction patterns and tfvars values are masked; review the bundle before sharing it.")
+	return nil
+}
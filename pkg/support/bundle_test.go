@@ -0,0 +1,193 @@
+package support
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/logging"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+// readBundle returns the bundle's files keyed by path below the bundle root
+func readBundle(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to open bundle: %v", err)
+	}
+	files := make(map[string]string)
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Failed to read bundle: %v", err)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", header.Name, err)
+		}
+		_, name, _ := strings.Cut(header.Name, "/")
+		files[name] = string(content)
+	}
+	return files
+}
+
+func TestWriteBundle(t *testing.T) {
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	stateDir := filepath.Join(tempDir, "state")
+	logDir := filepath.Join(tempDir, "logs")
+
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	logging.ResetRedaction()
+	t.Cleanup(logging.ResetRedaction)
+
+	writeTestFile(t, filepath.Join(configDir, "notifications.json"), `{"slack": {"webhook_url": "https://hooks.slack.com/services/T000/B000/XXXX"}}`)
+	writeTestFile(t, filepath.Join(configDir, "workspaces", "web", "config.json"), `{
+  "enabled": true,
+  "deploy_schedule": "0 9 * * *",
+  "destroy_schedule": "0 17 * * *",
+  "redact_patterns": ["internal-[0-9]+"],
+  "webhooks": [{"name": "deploy", "action": "deploy", "secret": "hunter2hunter2"}]
+}`)
+	writeTestFile(t, filepath.Join(configDir, "workspaces", "web", "main.tf"), `resource "null_resource" "web" {}`)
+	writeTestFile(t, filepath.Join(configDir, "workspaces", "web", "terraform.tfvars"), "region = \"eu-west-1\"\ndb_pass = \"supersecret\"\n")
+	writeTestFile(t, filepath.Join(configDir, "workspaces", "web", "terraform.tfstate"), `{"secret": "state"}`)
+	writeTestFile(t, filepath.Join(configDir, "jobs", "backup.json"), `{"name": "backup", "environment": {"DB_PASSWORD": "p4ss"}}`)
+	writeTestFile(t, filepath.Join(stateDir, "scheduler.json"), `{"workspaces": {"web": {"status": "deployed"}}}`)
+	writeTestFile(t, filepath.Join(stateDir, "deployments", "web", "terraform.tfstate"), `{"secret": "state"}`)
+	writeTestFile(t, filepath.Join(logDir, "web.log"), "line 1\nline 2 host internal-42\nline 3\n")
+
+	opts := Options{
+		ConfigDir:    configDir,
+		StateDir:     stateDir,
+		LogDir:       logDir,
+		LogLines:     2,
+		IncludeLogs:  true,
+		IncludeState: true,
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, opts); err != nil {
+		t.Fatalf("WriteBundle failed: %v", err)
+	}
+	files := readBundle(t, buf.Bytes())
+
+	for _, name := range []string{
+		"version.txt",
+		"diagnostics.json",
+		"config/notifications.json",
+		"config/workspaces/web/config.json",
+		"config/workspaces/web/main.tf",
+		"config/workspaces/web/terraform.tfvars",
+		"config/jobs/backup.json",
+		"state/scheduler.json",
+		"logs/web.log",
+	} {
+		if _, exists := files[name]; !exists {
+			t.Errorf("Expected %s in bundle", name)
+		}
+	}
+
+	for name := range files {
+		if strings.HasSuffix(name, ".tfstate") {
+			t.Errorf("Expected tofu state to be excluded, found %s", name)
+		}
+	}
+
+	for name, content := range files {
+		for _, secret := range []string{"hunter2hunter2", "supersecret", "p4ss", "XXXX", "internal-42"} {
+			if strings.Contains(content, secret) {
+				t.Errorf("Expected %q to be masked in %s:\n%s", secret, name, content)
+			}
+		}
+	}
+
+	if !strings.Contains(files["config/workspaces/web/terraform.tfvars"], "region = ") {
+		t.Errorf("Expected tfvars variable names to be kept, got:\n%s", files["config/workspaces/web/terraform.tfvars"])
+	}
+
+	if files["logs/web.log"] != "line 2 host [REDACTED]\nline 3\n" {
+		t.Errorf("Expected last 2 log lines with workspace pattern masked, got %q", files["logs/web.log"])
+	}
+
+	if !strings.Contains(files["diagnostics.json"], `"config_dir"`) {
+		t.Errorf("Expected diagnostics to include directories, got:\n%s", files["diagnostics.json"])
+	}
+}
+
+func TestWriteBundleExcludesLogsAndState(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTestFile(t, filepath.Join(tempDir, "state", "scheduler.json"), `{}`)
+	writeTestFile(t, filepath.Join(tempDir, "logs", "web.log"), "line\n")
+
+	opts := Options{
+		ConfigDir: filepath.Join(tempDir, "config"),
+		StateDir:  filepath.Join(tempDir, "state"),
+		LogDir:    filepath.Join(tempDir, "logs"),
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBundle(&buf, opts); err != nil {
+		t.Fatalf("WriteBundle failed: %v", err)
+	}
+	files := readBundle(t, buf.Bytes())
+
+	if _, exists := files["state/scheduler.json"]; exists {
+		t.Error("Expected state to be excluded")
+	}
+	if _, exists := files["logs/web.log"]; exists {
+		t.Error("Expected logs to be excluded")
+	}
+	if _, exists := files["diagnostics.json"]; !exists {
+		t.Error("Expected diagnostics even without logs and state")
+	}
+}
+
+func TestSanitizeTfvarsJSON(t *testing.T) {
+	got := Sanitize("provisioner.auto.tfvars.json", []byte(`{"db_password": "secret", "replicas": 3}`), "")
+	if strings.Contains(got, "secret") || strings.Contains(got, "3") {
+		t.Errorf("Expected all tfvars values masked, got %s", got)
+	}
+	if !strings.Contains(got, "db_password") || !strings.Contains(got, "replicas") {
+		t.Errorf("Expected tfvars names kept, got %s", got)
+	}
+}
+
+func TestSanitizeYAMLAndHCLConfigs(t *testing.T) {
+	configs := map[string]string{
+		"config.yaml": "# Staging\nvariables:\n  api_token: secret\n  region: fra1\n",
+		"config.hcl":  "variables = {\n  api_token = \"secret\"\n  region    = \"fra1\"\n}\n",
+	}
+	for name, content := range configs {
+		got := Sanitize(name, []byte(content), "")
+		if strings.Contains(got, "secret") || !strings.Contains(got, "fra1") {
+			t.Errorf("Expected the token of %s masked and the region kept, got %s", name, got)
+		}
+	}
+
+	// HCL files that aren't configs are kept as they are
+	lockFile := "provider \"registry.opentofu.org/digitalocean/digitalocean\" {\n  version = \"2.34.1\"\n}\n"
+	if got := Sanitize(".terraform.lock.hcl", []byte(lockFile), ""); got != lockFile {
+		t.Errorf("Expected the lock file unchanged, got %s", got)
+	}
+}
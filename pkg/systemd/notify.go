@@ -0,0 +1,68 @@
+// Package systemd implements the sd_notify protocol, so the daemon can report readiness and
+// status to systemd and keep its watchdog from restarting it.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Notify sends a state, e.g. "READY=1", to the socket in NOTIFY_SOCKET. It does nothing when the
+// daemon was not started by systemd with Type=notify or WatchdogSec.
+func Notify(state string) error {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return nil
+	}
+	// Abstract sockets are given with a leading @
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("failed to connect to systemd notify socket: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return fmt.Errorf("failed to notify systemd: %w", err)
+	}
+	return nil
+}
+
+// Ready tells systemd the daemon finished starting
+func Ready() error {
+	return Notify("READY=1")
+}
+
+// Stopping tells systemd the daemon is shutting down
+func Stopping() error {
+	return Notify("STOPPING=1")
+}
+
+// Status sets the status line systemctl status shows for the daemon
+func Status(format string, v ...interface{}) error {
+	return Notify("STATUS=" + fmt.Sprintf(format, v...))
+}
+
+// Watchdog resets systemd's watchdog timer
+func Watchdog() error {
+	return Notify("WATCHDOG=1")
+}
+
+// WatchdogInterval returns how often the watchdog must be reset: half of WatchdogSec, as systemd
+// recommends. It returns 0 when the watchdog is disabled or meant for another process.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond / 2
+}
@@ -0,0 +1,64 @@
+package systemd
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+
+	if err := Ready(); err != nil {
+		t.Fatalf("Ready() error: %v", err)
+	}
+	if err := Status("%d workspaces", 3); err != nil {
+		t.Fatalf("Status() error: %v", err)
+	}
+
+	buf := make([]byte, 256)
+	for _, want := range []string{"READY=1", "STATUS=3 workspaces"} {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		n, err := conn.Read(buf)
+		if err != nil {
+			t.Fatalf("Failed to read notification: %v", err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Errorf("Expected %q, got %q", want, got)
+		}
+	}
+
+	// Outside systemd notifications are skipped
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := Ready(); err != nil {
+		t.Errorf("Expected no error without NOTIFY_SOCKET, got %v", err)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()))
+	if interval := WatchdogInterval(); interval != 15*time.Second {
+		t.Errorf("Expected half of WatchdogSec, got %v", interval)
+	}
+
+	t.Setenv("WATCHDOG_PID", "1")
+	if interval := WatchdogInterval(); interval != 0 {
+		t.Errorf("Expected no watchdog for another process, got %v", interval)
+	}
+
+	t.Setenv("WATCHDOG_PID", "")
+	t.Setenv("WATCHDOG_USEC", "")
+	if interval := WatchdogInterval(); interval != 0 {
+		t.Errorf("Expected no watchdog without WATCHDOG_USEC, got %v", interval)
+	}
+}
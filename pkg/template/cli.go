@@ -6,10 +6,14 @@ import (
 	"path/filepath"
 	"strings"
 	"text/tabwriter"
-	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/output"
+	"provisioner/pkg/workspace"
 )
 
-func getDefaultTemplatesDir() string {
+// GetDefaultTemplatesDir returns the templates directory using auto-discovery
+func GetDefaultTemplatesDir() string {
 	// First check for explicit state directory override
 	if stateDir := os.Getenv("PROVISIONER_STATE_DIR"); stateDir != "" {
 		return filepath.Join(stateDir, "templates")
@@ -33,6 +37,7 @@ func RunAddCommand(args []string) error {
 	sourceURL := args[1]
 
 	var sourcePath, sourceRef, description string
+	var auth SourceAuth
 
 	// Parse optional flags
 	for i := 2; i < len(args); i++ {
@@ -52,22 +57,62 @@ func RunAddCommand(args []string) error {
 		} else if arg == "--description" && i+1 < len(args) {
 			description = args[i+1]
 			i++
+		} else if strings.HasPrefix(arg, "--ssh-key=") {
+			auth.SSHKey = strings.TrimPrefix(arg, "--ssh-key=")
+		} else if arg == "--ssh-key" && i+1 < len(args) {
+			auth.SSHKey = args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--token-env=") {
+			auth.TokenEnv = strings.TrimPrefix(arg, "--token-env=")
+		} else if arg == "--token-env" && i+1 < len(args) {
+			auth.TokenEnv = args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--verify-key=") {
+			auth.VerifyKey = strings.TrimPrefix(arg, "--verify-key=")
+		} else if arg == "--verify-key" && i+1 < len(args) {
+			auth.VerifyKey = args[i+1]
+			i++
+		}
+	}
+
+	// The keys are used by the daemon too, which runs in another working directory
+	if auth.SSHKey != "" {
+		absKey, err := filepath.Abs(auth.SSHKey)
+		if err != nil {
+			return fmt.Errorf("invalid ssh key path: %w", err)
+		}
+		auth.SSHKey = absKey
+	}
+	if auth.VerifyKey != "" {
+		absKey, err := filepath.Abs(auth.VerifyKey)
+		if err != nil {
+			return fmt.Errorf("invalid verify key path: %w", err)
 		}
+		auth.VerifyKey = absKey
 	}
 
-	manager := NewManager(getDefaultTemplatesDir())
+	manager := NewManager(GetDefaultTemplatesDir())
 
-	if err := manager.AddTemplate(name, sourceURL, sourcePath, sourceRef, description); err != nil {
+	if err := manager.AddTemplate(name, sourceURL, sourcePath, sourceRef, description, auth); err != nil {
 		return err
 	}
 
-	fmt.Printf("Template '%s' added successfully\n", name)
+	template, err := manager.GetTemplate(name)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Template '%s' added successfully at %s (%s)\n", name, shortCommit(template.Commit), template.SourceRef)
 	return nil
 }
 
 func RunListCommand(args []string) error {
 	detailed := false
 
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return err
+	}
+
 	// Parse flags
 	for _, arg := range args {
 		if arg == "--detailed" {
@@ -75,12 +120,16 @@ func RunListCommand(args []string) error {
 		}
 	}
 
-	manager := NewManager(getDefaultTemplatesDir())
+	manager := NewManager(GetDefaultTemplatesDir())
 	templates, err := manager.ListTemplates()
 	if err != nil {
 		return err
 	}
 
+	if format.Structured() {
+		return output.Print(format, templates)
+	}
+
 	if len(templates) == 0 {
 		fmt.Println("No templates found")
 		return nil
@@ -89,17 +138,18 @@ func RunListCommand(args []string) error {
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
 
 	if detailed {
-		if _, err := fmt.Fprintln(w, "NAME\tSOURCE\tPATH\tREF\tCREATED\tUPDATED\tDESCRIPTION"); err != nil {
+		if _, err := fmt.Fprintln(w, "NAME\tSOURCE\tPATH\tREF\tCOMMIT\tCREATED\tUPDATED\tDESCRIPTION"); err != nil {
 			return err
 		}
 		for _, template := range templates {
-			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
 				template.Name,
 				template.SourceURL,
 				template.SourcePath,
 				template.SourceRef,
-				template.CreatedAt.Format("2006-01-02"),
-				template.UpdatedAt.Format("2006-01-02"),
+				shortCommit(template.Commit),
+				template.CreatedAt.In(logging.DisplayLocation()).Format("2006-01-02"),
+				template.UpdatedAt.In(logging.DisplayLocation()).Format("2006-01-02"),
 				template.Description,
 			); err != nil {
 				return err
@@ -124,27 +174,53 @@ func RunListCommand(args []string) error {
 	return w.Flush()
 }
 
+// showOutput is the structured output of template show
+type showOutput struct {
+	*Template
+	Path string `json:"path"`
+}
+
 func RunShowCommand(args []string) error {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return err
+	}
 	if len(args) != 1 {
 		return fmt.Errorf("template show requires exactly one NAME argument")
 	}
 
 	name := args[0]
-	manager := NewManager(getDefaultTemplatesDir())
+	manager := NewManager(GetDefaultTemplatesDir())
 
 	template, err := manager.GetTemplate(name)
 	if err != nil {
 		return err
 	}
 
+	if format.Structured() {
+		return output.Print(format, showOutput{Template: template, Path: manager.GetTemplatePath(name)})
+	}
+
 	fmt.Printf("Name:        %s\n", template.Name)
 	fmt.Printf("Source URL:  %s\n", template.SourceURL)
 	if template.SourcePath != "" {
 		fmt.Printf("Source Path: %s\n", template.SourcePath)
 	}
 	fmt.Printf("Source Ref:  %s\n", template.SourceRef)
-	fmt.Printf("Created:     %s\n", template.CreatedAt.Format(time.RFC3339))
-	fmt.Printf("Updated:     %s\n", template.UpdatedAt.Format(time.RFC3339))
+	if template.Commit != "" {
+		fmt.Printf("Commit:      %s\n", template.Commit)
+	}
+	if template.SSHKey != "" {
+		fmt.Printf("SSH Key:     %s\n", template.SSHKey)
+	}
+	if template.TokenEnv != "" {
+		fmt.Printf("Token Env:   %s\n", template.TokenEnv)
+	}
+	if template.VerifyKey != "" {
+		fmt.Printf("Verify Key:  %s\n", template.VerifyKey)
+	}
+	fmt.Printf("Created:     %s\n", logging.FormatTime(template.CreatedAt))
+	fmt.Printf("Updated:     %s\n", logging.FormatTime(template.UpdatedAt))
 	if template.Description != "" {
 		fmt.Printf("Description: %s\n", template.Description)
 	}
@@ -159,39 +235,69 @@ func RunShowCommand(args []string) error {
 	return nil
 }
 
+// RunUpdateCommand updates NAME or --all templates; --force accepts rewritten branches and moved tags
 func RunUpdateCommand(args []string) error {
-	if len(args) == 0 {
-		return fmt.Errorf("template update requires NAME or --all argument")
+	name, force, err := ParseUpdateArgs(args)
+	if err != nil {
+		return err
 	}
 
-	manager := NewManager(getDefaultTemplatesDir())
+	manager := NewManager(GetDefaultTemplatesDir())
 
-	if args[0] == "--all" {
+	if name == "" {
 		templates, err := manager.ListTemplates()
 		if err != nil {
 			return err
 		}
 
+		failed := 0
 		for _, template := range templates {
 			fmt.Printf("Updating template '%s'...\n", template.Name)
-			if err := manager.UpdateTemplate(template.Name); err != nil {
+			if result, err := manager.UpdateTemplate(template.Name, force); err != nil {
 				fmt.Printf("  Error: %v\n", err)
+				failed++
 			} else {
-				fmt.Printf("  Updated successfully\n")
+				fmt.Printf("  %s\n", result)
 			}
 		}
+		if failed > 0 {
+			return fmt.Errorf("%d of %d templates failed to update", failed, len(templates))
+		}
 		return nil
 	}
 
-	name := args[0]
-	if err := manager.UpdateTemplate(name); err != nil {
+	result, err := manager.UpdateTemplate(name, force)
+	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Template '%s' updated successfully\n", name)
+	fmt.Println(result)
 	return nil
 }
 
+// ParseUpdateArgs parses NAME|--all [--force], returning an empty name for --all
+func ParseUpdateArgs(args []string) (name string, force bool, err error) {
+	all := false
+	for _, arg := range args {
+		switch {
+		case arg == "--all":
+			all = true
+		case arg == "--force":
+			force = true
+		case strings.HasPrefix(arg, "-"):
+			return "", false, fmt.Errorf("unknown update option '%s'", arg)
+		case name != "":
+			return "", false, fmt.Errorf("template update takes a single NAME")
+		default:
+			name = arg
+		}
+	}
+	if all == (name != "") {
+		return "", false, fmt.Errorf("template update requires NAME or --all argument")
+	}
+	return name, force, nil
+}
+
 func RunRemoveCommand(args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("template remove requires NAME argument")
@@ -207,7 +313,21 @@ func RunRemoveCommand(args []string) error {
 		}
 	}
 
-	manager := NewManager(getDefaultTemplatesDir())
+	manager := NewManager(GetDefaultTemplatesDir())
+
+	// Removing a template breaks the next deploy or destroy of every workspace using it
+	references, err := workspace.FindTemplateReferences(workspace.GetDefaultWorkspacesDir(), name)
+	if err != nil {
+		return fmt.Errorf("failed to check workspace references: %w", err)
+	}
+	if len(references) > 0 {
+		if !force {
+			return fmt.Errorf("template '%s' is used by workspaces: %s\nChange or remove these workspaces first, or use --force to remove the template anyway",
+				name, strings.Join(references, ", "))
+		}
+		fmt.Printf("WARNING: template '%s' is used by workspaces: %s\n", name, strings.Join(references, ", "))
+		fmt.Println("These workspaces will show status 'template_missing' and skip scheduled deploys and destroys until the template is added again.")
+	}
 
 	// Confirm removal if not forced
 	if !force {
@@ -231,12 +351,22 @@ func RunRemoveCommand(args []string) error {
 	return nil
 }
 
-func RunValidateCommand(args []string) error {
+// RunValidateCommand validates template NAME or --all templates, also with check if given
+func RunValidateCommand(args []string, check workspace.ConfigChecker) error {
 	if len(args) == 0 {
 		return fmt.Errorf("template validate requires NAME or --all argument")
 	}
 
-	manager := NewManager(getDefaultTemplatesDir())
+	manager := NewManager(GetDefaultTemplatesDir())
+	validate := func(name string) error {
+		if err := manager.ValidateTemplate(name); err != nil {
+			return err
+		}
+		if check != nil {
+			return check(manager.GetTemplatePath(name), nil, "")
+		}
+		return nil
+	}
 
 	if args[0] == "--all" {
 		templates, err := manager.ListTemplates()
@@ -246,7 +376,7 @@ func RunValidateCommand(args []string) error {
 
 		hasErrors := false
 		for _, template := range templates {
-			if err := manager.ValidateTemplate(template.Name); err != nil {
+			if err := validate(template.Name); err != nil {
 				fmt.Printf("✗ %s: %v\n", template.Name, err)
 				hasErrors = true
 			} else {
@@ -261,7 +391,7 @@ func RunValidateCommand(args []string) error {
 	}
 
 	name := args[0]
-	if err := manager.ValidateTemplate(name); err != nil {
+	if err := validate(name); err != nil {
 		return fmt.Errorf("template '%s' validation failed: %v", name, err)
 	}
 
@@ -0,0 +1,56 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunRemoveCommandBlocksReferencedTemplate(t *testing.T) {
+	stateDir := t.TempDir()
+	workspacesDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+	t.Setenv("PROVISIONER_WORKSPACES_DIR", workspacesDir)
+
+	templateDir := filepath.Join(GetDefaultTemplatesDir(), "web")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "main.tf"), []byte("# web"), 0644); err != nil {
+		t.Fatalf("Failed to write template main.tf: %v", err)
+	}
+
+	manager := NewManager(GetDefaultTemplatesDir())
+	registry := &Registry{Templates: map[string]Template{"web": {Name: "web"}}}
+	if err := manager.SaveRegistry(registry); err != nil {
+		t.Fatalf("Failed to save registry: %v", err)
+	}
+	wsDir := filepath.Join(workspacesDir, "frontend")
+	if err := os.MkdirAll(wsDir, 0755); err != nil {
+		t.Fatalf("Failed to create workspace dir: %v", err)
+	}
+	config, _ := json.Marshal(map[string]interface{}{"enabled": true, "template": "web"})
+	if err := os.WriteFile(filepath.Join(wsDir, "config.json"), config, 0644); err != nil {
+		t.Fatalf("Failed to write workspace config: %v", err)
+	}
+
+	err := RunRemoveCommand([]string{"web"})
+	if err == nil {
+		t.Fatal("Expected removal of a referenced template to fail")
+	}
+	if !strings.Contains(err.Error(), "frontend") || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("Expected error to list referencing workspaces and mention --force, got: %v", err)
+	}
+	if _, err := manager.GetTemplate("web"); err != nil {
+		t.Errorf("Expected template to still be registered: %v", err)
+	}
+
+	if err := RunRemoveCommand([]string{"web", "--force"}); err != nil {
+		t.Fatalf("Expected forced removal to succeed: %v", err)
+	}
+	if _, err := manager.GetTemplate("web"); err == nil {
+		t.Error("Expected template to be removed with --force")
+	}
+}
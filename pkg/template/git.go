@@ -0,0 +1,247 @@
+package template
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Ref kinds a template source ref resolves to
+const (
+	refKindBranch = "branch"
+	refKindTag    = "tag"
+	refKindCommit = "commit"
+)
+
+// commitPattern matches a full commit hash, the only form of commit a ref can pin
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// gitRepo is a template's source clone, kept between updates so they can fast-forward
+type gitRepo struct {
+	dir string
+	url string
+	env []string
+}
+
+// newGitRepo prepares git access to a template's source with its configured authentication
+func newGitRepo(dir string, template Template) (*gitRepo, error) {
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	if template.SSHKey != "" {
+		if _, err := os.Stat(template.SSHKey); err != nil {
+			return nil, fmt.Errorf("ssh key for template '%s': %w", template.Name, err)
+		}
+		env = append(env, "GIT_SSH_COMMAND=ssh -i "+shellQuote(template.SSHKey)+" -o IdentitiesOnly=yes -o StrictHostKeyChecking=accept-new")
+	}
+
+	if template.TokenEnv != "" {
+		token := os.Getenv(template.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("token for template '%s': environment variable %s is not set", template.Name, template.TokenEnv)
+		}
+		// Passed as config through the environment so the token never shows up in process arguments
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		env = append(env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http.extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials)
+	}
+
+	return &gitRepo{dir: dir, url: template.SourceURL, env: env}, nil
+}
+
+// run runs git in the clone, including its output in the error if it fails
+func (r *gitRepo) run(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = r.env
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if detail := strings.TrimSpace(stderr.String()); detail != "" {
+			return "", fmt.Errorf("git %s failed: %w\n\nDetailed output:\n%s", args[0], err, detail)
+		}
+		return "", fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// init creates the clone on first use
+func (r *gitRepo) init() error {
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); err == nil {
+		_, err := r.run("remote", "set-url", "origin", r.url)
+		return err
+	}
+
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create source directory: %w", err)
+	}
+	if _, err := r.run("init", "-q"); err != nil {
+		return err
+	}
+	_, err := r.run("remote", "add", "origin", r.url)
+	return err
+}
+
+// resolveRef looks up whether ref is a branch, a tag or a full commit hash on the remote
+func (r *gitRepo) resolveRef(ref string) (string, error) {
+	output, err := r.run("ls-remote", "origin", "refs/heads/"+ref, "refs/tags/"+ref)
+	if err != nil {
+		return "", err
+	}
+
+	kind := ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[1] {
+		case "refs/tags/" + ref:
+			kind = refKindTag
+		case "refs/heads/" + ref:
+			if kind == "" {
+				kind = refKindBranch
+			}
+		}
+	}
+	if kind != "" {
+		return kind, nil
+	}
+	if commitPattern.MatchString(ref) {
+		return refKindCommit, nil
+	}
+	return "", fmt.Errorf("ref '%s' not found in %s", ref, r.url)
+}
+
+// fetch fetches ref and returns the commit it points to. The first fetch is shallow; later
+// fetches only add the commits since the checked out one, so fast-forwards can be verified.
+func (r *gitRepo) fetch(ref, kind string, shallow bool) (string, error) {
+	refspec := ref
+	switch kind {
+	case refKindBranch:
+		refspec = "refs/heads/" + ref
+	case refKindTag:
+		refspec = "refs/tags/" + ref
+	}
+
+	args := []string{"fetch", "-q", "--no-tags"}
+	if shallow {
+		args = append(args, "--depth", "1")
+	}
+	if _, err := r.run(append(args, "origin", refspec)...); err != nil {
+		return "", err
+	}
+	return r.run("rev-parse", "FETCH_HEAD^{commit}")
+}
+
+// hasCommit reports whether a commit is present in the clone
+func (r *gitRepo) hasCommit(commit string) bool {
+	_, err := r.run("cat-file", "-e", commit+"^{commit}")
+	return err == nil
+}
+
+// isAncestor reports whether ancestor is reachable from commit
+func (r *gitRepo) isAncestor(ancestor, commit string) bool {
+	_, err := r.run("merge-base", "--is-ancestor", ancestor, commit)
+	return err == nil
+}
+
+// checkout checks out a commit, detached
+func (r *gitRepo) checkout(commit string) error {
+	_, err := r.run("checkout", "-q", "--force", "--detach", commit)
+	return err
+}
+
+// sourceSubdir returns the directory inside the clone holding the template files
+func sourceSubdir(cloneDir, sourcePath string) (string, error) {
+	if sourcePath == "" {
+		return cloneDir, nil
+	}
+	clean := filepath.Clean(sourcePath)
+	if filepath.IsAbs(clean) || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path '%s' must be relative to the repository root", sourcePath)
+	}
+
+	dir := filepath.Join(cloneDir, clean)
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		return "", fmt.Errorf("path '%s' not found in repository", sourcePath)
+	}
+	return dir, nil
+}
+
+// copyTemplateFiles copies the template files to dst, leaving out git metadata
+func copyTemplateFiles(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		switch {
+		case info.IsDir():
+			return os.MkdirAll(target, 0755)
+		case info.Mode()&os.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case info.Mode().IsRegular():
+			return copyFile(path, target, info.Mode().Perm())
+		}
+		return nil
+	})
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// shellQuote quotes a path for GIT_SSH_COMMAND, which git runs through the shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shortCommit abbreviates a commit hash or manifest digest for display
+func shortCommit(commit string) string {
+	if strings.HasPrefix(commit, "sha256:") && len(commit) > 19 {
+		return commit[:19]
+	}
+	if len(commit) > 12 {
+		return commit[:12]
+	}
+	return commit
+}
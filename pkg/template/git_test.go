@@ -0,0 +1,184 @@
+package template
+
+import (
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestGitRepo creates a local repository on branch main with one commit of files and returns its URL
+func newTestGitRepo(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	testGit(t, dir, "init", "-q", "-b", "main")
+	commitTestFiles(t, dir, files, "initial")
+	return "file://" + dir
+}
+
+// commitTestFiles writes files to a test repository and commits them
+func commitTestFiles(t *testing.T, dir string, files map[string]string, message string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	testGit(t, dir, "add", "-A")
+	testGit(t, dir, "commit", "-q", "-m", message)
+}
+
+func testGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s failed: %v\n%s", strings.Join(args, " "), err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func TestGitTemplateFastForward(t *testing.T) {
+	repoURL := newTestGitRepo(t, map[string]string{"web/main.tf": "# v1", "README.md": "docs"})
+	repoDir := strings.TrimPrefix(repoURL, "file://")
+	manager := NewManager(t.TempDir())
+
+	if err := manager.AddTemplate("web", repoURL, "web", "main", "", SourceAuth{}); err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
+	template, _ := manager.GetTemplate("web")
+	if template.Commit != testGit(t, repoDir, "rev-parse", "HEAD") {
+		t.Errorf("Expected commit %s, got %s", testGit(t, repoDir, "rev-parse", "HEAD"), template.Commit)
+	}
+
+	// Only the sub-path is extracted, without git metadata
+	if data, err := os.ReadFile(filepath.Join(manager.GetTemplatePath("web"), "main.tf")); err != nil || string(data) != "# v1" {
+		t.Errorf("Expected main.tf from the sub-path, got %q (%v)", data, err)
+	}
+	for _, name := range []string{"README.md", ".git", "web"} {
+		if _, err := os.Stat(filepath.Join(manager.GetTemplatePath("web"), name)); err == nil {
+			t.Errorf("Expected %s not to be extracted", name)
+		}
+	}
+
+	result, err := manager.UpdateTemplate("web", false)
+	if err != nil || result.Changed || result.Commit != result.PreviousCommit {
+		t.Errorf("Expected an unchanged update, got %+v (%v)", result, err)
+	}
+
+	// A change outside the sub-path moves the commit but not the content
+	commitTestFiles(t, repoDir, map[string]string{"README.md": "more docs"}, "docs")
+	if result, err = manager.UpdateTemplate("web", false); err != nil || result.Changed || result.Commit == result.PreviousCommit {
+		t.Errorf("Expected a new commit with unchanged content, got %+v (%v)", result, err)
+	}
+
+	commitTestFiles(t, repoDir, map[string]string{"web/main.tf": "# v2"}, "v2")
+	if result, err = manager.UpdateTemplate("web", false); err != nil || !result.Changed {
+		t.Fatalf("Expected changed content, got %+v (%v)", result, err)
+	}
+	if !strings.Contains(result.String(), "content changed") {
+		t.Errorf("Unexpected result message %q", result)
+	}
+	if data, _ := os.ReadFile(filepath.Join(manager.GetTemplatePath("web"), "main.tf")); string(data) != "# v2" {
+		t.Errorf("Expected updated main.tf, got %q", data)
+	}
+}
+
+func TestGitTemplateRefusesRewrittenRefs(t *testing.T) {
+	repoURL := newTestGitRepo(t, map[string]string{"main.tf": "# v1"})
+	repoDir := strings.TrimPrefix(repoURL, "file://")
+	testGit(t, repoDir, "tag", "v1")
+	manager := NewManager(t.TempDir())
+
+	for _, ref := range []string{"main", "v1"} {
+		if err := manager.AddTemplate("at-"+ref, repoURL, "", ref, "", SourceAuth{}); err != nil {
+			t.Fatalf("AddTemplate %s failed: %v", ref, err)
+		}
+	}
+
+	// Rewrite main and move the tag to the rewritten commit
+	if err := os.WriteFile(filepath.Join(repoDir, "main.tf"), []byte("# v1 amended"), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+	testGit(t, repoDir, "commit", "-q", "-a", "--amend", "-m", "rewritten")
+	testGit(t, repoDir, "tag", "-f", "v1")
+
+	if _, err := manager.UpdateTemplate("at-main", false); err == nil || !strings.Contains(err.Error(), "not a fast-forward") {
+		t.Errorf("Expected the rewritten branch to be refused, got %v", err)
+	}
+	if _, err := manager.UpdateTemplate("at-v1", false); err == nil || !strings.Contains(err.Error(), "moved") {
+		t.Errorf("Expected the moved tag to be refused, got %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(manager.GetTemplatePath("at-main"), "main.tf")); string(data) != "# v1" {
+		t.Errorf("Expected a refused update to keep the template files, got %q", data)
+	}
+
+	if result, err := manager.UpdateTemplate("at-main", true); err != nil || !result.Changed {
+		t.Errorf("Expected the forced update to apply, got %+v (%v)", result, err)
+	}
+}
+
+func TestGitTemplatePinnedCommit(t *testing.T) {
+	repoURL := newTestGitRepo(t, map[string]string{"main.tf": "# v1"})
+	repoDir := strings.TrimPrefix(repoURL, "file://")
+	testGit(t, repoDir, "config", "uploadpack.allowAnySHA1InWant", "true")
+	pinned := testGit(t, repoDir, "rev-parse", "HEAD")
+	commitTestFiles(t, repoDir, map[string]string{"main.tf": "# v2"}, "v2")
+	manager := NewManager(t.TempDir())
+
+	if err := manager.AddTemplate("pinned", repoURL, "", pinned, "", SourceAuth{}); err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(manager.GetTemplatePath("pinned"), "main.tf")); string(data) != "# v1" {
+		t.Errorf("Expected the pinned commit's content, got %q", data)
+	}
+	if result, err := manager.UpdateTemplate("pinned", false); err != nil || result.Commit != pinned {
+		t.Errorf("Expected the update to stay on %s, got %+v (%v)", pinned, result, err)
+	}
+
+	err := manager.AddTemplate("missing", repoURL, "", "no-such-branch", "", SourceAuth{})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected an unknown ref to fail, got %v", err)
+	}
+	if _, err := os.Stat(manager.sourceDir("missing")); !os.IsNotExist(err) {
+		t.Error("Expected the failed add to remove its clone")
+	}
+	if err := manager.AddTemplate("escape", repoURL, "../outside", "main", "", SourceAuth{}); err == nil {
+		t.Error("Expected a path outside the repository to fail")
+	}
+}
+
+func TestGitRepoAuthentication(t *testing.T) {
+	key := filepath.Join(t.TempDir(), "deploy key")
+	if err := os.WriteFile(key, []byte("key"), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	t.Setenv("TEMPLATE_TOKEN", "s3cret")
+
+	repo, err := newGitRepo(t.TempDir(), Template{Name: "private", SourceAuth: SourceAuth{SSHKey: key, TokenEnv: "TEMPLATE_TOKEN"}})
+	if err != nil {
+		t.Fatalf("newGitRepo failed: %v", err)
+	}
+	env := strings.Join(repo.env, "\n")
+	if !strings.Contains(env, "GIT_SSH_COMMAND=ssh -i '"+key+"'") {
+		t.Errorf("Expected the ssh key in GIT_SSH_COMMAND, got %s", env)
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:s3cret"))
+	if !strings.Contains(env, "GIT_CONFIG_VALUE_0=Authorization: Basic "+credentials) {
+		t.Error("Expected the token as an authorization header")
+	}
+
+	if _, err := newGitRepo(t.TempDir(), Template{Name: "private", SourceAuth: SourceAuth{TokenEnv: "UNSET_TEMPLATE_TOKEN"}}); err == nil {
+		t.Error("Expected an unset token variable to fail")
+	}
+}
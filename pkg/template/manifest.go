@@ -0,0 +1,52 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ManifestFile is the optional file at the root of a template describing it
+const ManifestFile = "template.json"
+
+// Manifest describes a template. Its version and description are shown for templates added
+// from a source that has one.
+type Manifest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	Modes       []string `json:"modes,omitempty"` // Deployment modes the template handles in var.deployment_mode
+}
+
+// LoadManifest reads the manifest of the template in dir, returning nil if it has none
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ManifestFile, err)
+	}
+
+	var manifest Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", ManifestFile, err)
+	}
+	if manifest.Name == "" {
+		return nil, fmt.Errorf("invalid %s: name is required", ManifestFile)
+	}
+	return &manifest, nil
+}
+
+// applyManifest takes the template's version from its manifest and fills in the description
+// if the template has none
+func (t *Template) applyManifest(manifest *Manifest) {
+	if manifest == nil {
+		return
+	}
+	t.Version = manifest.Version
+	if t.Description == "" {
+		t.Description = manifest.Description
+	}
+}
@@ -0,0 +1,539 @@
+package template
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const ociScheme = "oci://"
+
+// Media types and annotations of OCI registries and cosign signatures
+const (
+	ociManifestMediaType      = "application/vnd.oci.image.manifest.v1+json"
+	dockerManifestMediaType   = "application/vnd.docker.distribution.manifest.v2+json"
+	cosignSignatureAnnotation = "dev.cosignproject.cosign/signature"
+)
+
+// Limits on what a registry may send
+const (
+	maxOCIManifestSize = 4 << 20
+	maxOCILayerSize    = 1 << 30
+)
+
+// ociDigestPattern matches the sha256 digests templates can be pinned to
+var ociDigestPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// ociHTTPClient sends registry requests; tests replace it to trust their registry
+var ociHTTPClient = &http.Client{Timeout: 10 * time.Minute}
+
+// isOCISource reports whether a template source URL is an OCI registry reference
+func isOCISource(sourceURL string) bool {
+	return strings.HasPrefix(sourceURL, ociScheme)
+}
+
+// splitOCISource splits oci://REGISTRY/REPOSITORY[:TAG|@DIGEST] into the repository URL and the
+// ref, which may instead be given separately. The ref defaults to "latest".
+func splitOCISource(sourceURL, sourceRef string) (string, string, error) {
+	rest := strings.TrimPrefix(sourceURL, ociScheme)
+	slash := strings.Index(rest, "/")
+	if slash <= 0 || slash == len(rest)-1 {
+		return "", "", fmt.Errorf("invalid OCI reference '%s' (expected oci://REGISTRY/REPOSITORY[:TAG|@DIGEST])", sourceURL)
+	}
+
+	repository, ref := rest, ""
+	if at := strings.Index(rest, "@"); at >= 0 {
+		repository, ref = rest[:at], rest[at+1:]
+		if !ociDigestPattern.MatchString(ref) {
+			return "", "", fmt.Errorf("invalid digest '%s' in OCI reference (expected sha256:HEX)", ref)
+		}
+	} else if colon := strings.LastIndex(rest, ":"); colon > slash {
+		repository, ref = rest[:colon], rest[colon+1:]
+	}
+
+	if ref != "" && sourceRef != "" && ref != sourceRef {
+		return "", "", fmt.Errorf("OCI reference '%s' already has ref '%s', don't use --ref", sourceURL, ref)
+	}
+	if ref == "" {
+		ref = sourceRef
+	}
+	if ref == "" {
+		ref = "latest"
+	}
+	return ociScheme + repository, ref, nil
+}
+
+// ociDescriptor references a blob in a registry
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ociManifest is an OCI image manifest; each layer of a template is a tar archive, optionally gzipped
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Layers    []ociDescriptor `json:"layers"`
+}
+
+// ociRegistry pulls from one repository of an OCI registry
+type ociRegistry struct {
+	host       string
+	repository string
+	username   string
+	password   string
+	auth       string // Authorization header obtained from the registry's challenge
+}
+
+// newOCIRegistry prepares access to a template's registry. Credentials are read from the
+// template's token variable as USER:PASSWORD, or a bare token sent as the password.
+func newOCIRegistry(template Template) (*ociRegistry, error) {
+	if template.SSHKey != "" {
+		return nil, fmt.Errorf("ssh keys only apply to git sources")
+	}
+	rest := strings.TrimPrefix(template.SourceURL, ociScheme)
+	host, repository, ok := strings.Cut(rest, "/")
+	if !ok || host == "" || repository == "" {
+		return nil, fmt.Errorf("invalid OCI repository '%s'", template.SourceURL)
+	}
+
+	registry := &ociRegistry{host: host, repository: repository}
+	if template.TokenEnv != "" {
+		credentials := os.Getenv(template.TokenEnv)
+		if credentials == "" {
+			return nil, fmt.Errorf("credentials for template '%s': environment variable %s is not set", template.Name, template.TokenEnv)
+		}
+		registry.username, registry.password, ok = strings.Cut(credentials, ":")
+		if !ok {
+			registry.username, registry.password = "x-access-token", credentials
+		}
+	}
+	return registry, nil
+}
+
+// get requests a path of the repository, authenticating when the registry asks for it
+func (r *ociRegistry) get(path, accept string) (*http.Response, error) {
+	endpoint := fmt.Sprintf("https://%s/v2/%s/%s", r.host, r.repository, path)
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if r.auth != "" {
+			req.Header.Set("Authorization", r.auth)
+		}
+
+		resp, err := ociHTTPClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("registry request failed: %w", err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			challenge := resp.Header.Get("WWW-Authenticate")
+			_ = resp.Body.Close()
+			if err := r.authenticate(challenge); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			return nil, fmt.Errorf("registry %s returned %s for %s", r.host, resp.Status, path)
+		}
+		return resp, nil
+	}
+}
+
+// authenticate answers a WWW-Authenticate challenge with basic credentials or a bearer token
+// from the registry's token service
+func (r *ociRegistry) authenticate(challenge string) error {
+	scheme, params, _ := strings.Cut(challenge, " ")
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if r.username == "" {
+			return fmt.Errorf("registry %s requires credentials, use --token-env", r.host)
+		}
+		req := &http.Request{Header: http.Header{}}
+		req.SetBasicAuth(r.username, r.password)
+		r.auth = req.Header.Get("Authorization")
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("registry %s requires unsupported authentication '%s'", r.host, scheme)
+	}
+
+	values := parseChallengeParams(params)
+	realm, err := url.Parse(values["realm"])
+	if err != nil || realm.Host == "" {
+		return fmt.Errorf("registry %s sent an invalid token realm '%s'", r.host, values["realm"])
+	}
+	query := realm.Query()
+	if service := values["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := values["scope"]
+	if scope == "" {
+		scope = fmt.Sprintf("repository:%s:pull", r.repository)
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+	resp, err := ociHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("registry token request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("registry token service returned %s", resp.Status)
+	}
+
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxOCIManifestSize)).Decode(&token); err != nil {
+		return fmt.Errorf("invalid registry token response: %w", err)
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	if token.Token == "" {
+		return fmt.Errorf("registry token service returned no token")
+	}
+	r.auth = "Bearer " + token.Token
+	return nil
+}
+
+// parseChallengeParams parses the key="value" pairs of a WWW-Authenticate header
+func parseChallengeParams(params string) map[string]string {
+	values := make(map[string]string)
+	for _, match := range regexp.MustCompile(`(\w+)="([^"]*)"`).FindAllStringSubmatch(params, -1) {
+		values[strings.ToLower(match[1])] = match[2]
+	}
+	return values
+}
+
+// manifest fetches the manifest of a tag or digest and returns it with its digest
+func (r *ociRegistry) manifest(ref string) (*ociManifest, string, error) {
+	resp, err := r.get("manifests/"+ref, ociManifestMediaType+", "+dockerManifestMediaType)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOCIManifestSize+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read manifest: %w", err)
+	}
+	if len(data) > maxOCIManifestSize {
+		return nil, "", fmt.Errorf("manifest of '%s' exceeds %d bytes", ref, maxOCIManifestSize)
+	}
+	sum := sha256.Sum256(data)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+
+	var manifest ociManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, "", fmt.Errorf("invalid manifest of '%s': %w", ref, err)
+	}
+	if manifest.MediaType == "" {
+		manifest.MediaType = resp.Header.Get("Content-Type")
+	}
+	if manifest.MediaType != ociManifestMediaType && manifest.MediaType != dockerManifestMediaType {
+		return nil, "", fmt.Errorf("'%s' is a %s, expected an image manifest", ref, manifest.MediaType)
+	}
+	return &manifest, digest, nil
+}
+
+// blob downloads a blob to w, verifying its size and digest
+func (r *ociRegistry) blob(desc ociDescriptor, limit int64, w io.Writer) error {
+	if !ociDigestPattern.MatchString(desc.Digest) {
+		return fmt.Errorf("unsupported blob digest '%s'", desc.Digest)
+	}
+	if desc.Size > limit {
+		return fmt.Errorf("blob %s exceeds %d bytes", desc.Digest, limit)
+	}
+
+	resp, err := r.get("blobs/"+desc.Digest, "")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	var digester hash.Hash = sha256.New()
+	n, err := io.Copy(io.MultiWriter(w, digester), io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return fmt.Errorf("failed to download blob %s: %w", desc.Digest, err)
+	}
+	if n > limit || (desc.Size > 0 && n != desc.Size) {
+		return fmt.Errorf("blob %s has %d bytes, expected %d", desc.Digest, n, desc.Size)
+	}
+	if digest := "sha256:" + hex.EncodeToString(digester.Sum(nil)); digest != desc.Digest {
+		return fmt.Errorf("blob digest mismatch: expected %s, got %s", desc.Digest, digest)
+	}
+	return nil
+}
+
+// fetchOCITemplate pulls the template's tag or digest from its registry. Digests must match,
+// tags must keep pointing at the digest fetched before unless force is set, and with a verify
+// key the manifest must carry a valid cosign signature.
+func (m *Manager) fetchOCITemplate(template *Template, force bool) error {
+	registry, err := newOCIRegistry(*template)
+	if err != nil {
+		return err
+	}
+
+	manifest, digest, err := registry.manifest(template.SourceRef)
+	if err != nil {
+		return err
+	}
+	pinned := ociDigestPattern.MatchString(template.SourceRef)
+	if pinned && digest != template.SourceRef {
+		return fmt.Errorf("fetched manifest %s does not match pinned digest %s", digest, template.SourceRef)
+	}
+	if previous := template.Commit; previous != "" && digest != previous && !force {
+		return fmt.Errorf("tag '%s' moved from %s to %s, use --force to accept it",
+			template.SourceRef, shortCommit(previous), shortCommit(digest))
+	}
+	if template.VerifyKey != "" {
+		if err := registry.verifySignature(digest, template.VerifyKey); err != nil {
+			return fmt.Errorf("signature verification failed: %w", err)
+		}
+	}
+	if len(manifest.Layers) == 0 {
+		return fmt.Errorf("'%s' has no layers", template.SourceRef)
+	}
+
+	sourcesDir := filepath.Dir(m.sourceDir(template.Name))
+	if err := os.MkdirAll(sourcesDir, 0755); err != nil {
+		return fmt.Errorf("failed to create sources directory: %w", err)
+	}
+	extractDir, err := os.MkdirTemp(sourcesDir, template.Name+"-oci-*")
+	if err != nil {
+		return fmt.Errorf("failed to create extraction directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(extractDir) }()
+
+	contentDir := filepath.Join(extractDir, "content")
+	for _, layer := range manifest.Layers {
+		if err := registry.pullLayer(layer, extractDir, contentDir); err != nil {
+			return err
+		}
+	}
+
+	srcDir, err := sourceSubdir(contentDir, template.SourcePath)
+	if err != nil {
+		return err
+	}
+	return m.installTemplateFiles(template, srcDir, digest)
+}
+
+// pullLayer downloads a layer next to contentDir and extracts it there once its digest is verified
+func (r *ociRegistry) pullLayer(layer ociDescriptor, workDir, contentDir string) error {
+	archive, err := os.CreateTemp(workDir, "layer-*")
+	if err != nil {
+		return fmt.Errorf("failed to create layer file: %w", err)
+	}
+	defer func() { _ = archive.Close() }()
+
+	if err := r.blob(layer, maxOCILayerSize, archive); err != nil {
+		return err
+	}
+	if _, err := archive.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if err := extractTar(archive, contentDir); err != nil {
+		return fmt.Errorf("failed to extract layer %s: %w", layer.Digest, err)
+	}
+	return nil
+}
+
+// extractTar extracts a tar archive, gzipped or not, into dst. Only directories and regular
+// files inside dst are accepted.
+func extractTar(r io.Reader, dst string) error {
+	buffered := bufio.NewReader(r)
+	var reader io.Reader = buffered
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = gz.Close() }()
+		reader = gz
+	}
+
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	archive := tar.NewReader(reader)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := filepath.Clean(filepath.FromSlash(header.Name))
+		if name == "." {
+			continue
+		}
+		if filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("entry '%s' is outside the archive", header.Name)
+		}
+		target := filepath.Join(dst, name)
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm()|0600)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(file, archive); err != nil {
+				_ = file.Close()
+				return err
+			}
+			if err := file.Close(); err != nil {
+				return err
+			}
+		case tar.TypeXGlobalHeader:
+		default:
+			return fmt.Errorf("entry '%s' has unsupported type %c", header.Name, header.Typeflag)
+		}
+	}
+}
+
+// cosignPayload is the signed payload of a cosign signature
+type cosignPayload struct {
+	Critical struct {
+		Image struct {
+			DockerManifestDigest string `json:"docker-manifest-digest"`
+		} `json:"image"`
+	} `json:"critical"`
+}
+
+// verifySignature verifies that a cosign signature of the manifest digest, stored under the tag
+// sha256-HEX.sig as cosign does, was made with the private key of keyFile
+func (r *ociRegistry) verifySignature(digest, keyFile string) error {
+	key, err := loadPublicKey(keyFile)
+	if err != nil {
+		return err
+	}
+
+	signatures, _, err := r.manifest(strings.Replace(digest, ":", "-", 1) + ".sig")
+	if err != nil {
+		return fmt.Errorf("no signature found for %s: %w", digest, err)
+	}
+
+	var lastErr error = fmt.Errorf("no signature found for %s", digest)
+	for _, layer := range signatures.Layers {
+		encoded := layer.Annotations[cosignSignatureAnnotation]
+		if encoded == "" {
+			continue
+		}
+		signature, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			lastErr = fmt.Errorf("invalid signature encoding: %w", err)
+			continue
+		}
+
+		var payload bytes.Buffer
+		if err := r.blob(layer, maxOCIManifestSize, &payload); err != nil {
+			lastErr = err
+			continue
+		}
+		if err := verifyPayloadSignature(key, payload.Bytes(), signature); err != nil {
+			lastErr = err
+			continue
+		}
+
+		var signed cosignPayload
+		if err := json.Unmarshal(payload.Bytes(), &signed); err != nil {
+			lastErr = fmt.Errorf("invalid signature payload: %w", err)
+			continue
+		}
+		if signed.Critical.Image.DockerManifestDigest != digest {
+			lastErr = fmt.Errorf("signature is for %s, not %s", signed.Critical.Image.DockerManifestDigest, digest)
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
+
+// loadPublicKey reads a PEM encoded ECDSA, RSA or Ed25519 public key
+func loadPublicKey(keyFile string) (crypto.PublicKey, error) {
+	data, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read verify key: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("verify key %s is not PEM encoded", keyFile)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verify key %s: %w", keyFile, err)
+	}
+	return key, nil
+}
+
+// verifyPayloadSignature checks a signature over a payload, hashed with SHA-256 except for Ed25519
+func verifyPayloadSignature(key crypto.PublicKey, payload, signature []byte) error {
+	sum := sha256.Sum256(payload)
+	switch key := key.(type) {
+	case *ecdsa.PublicKey:
+		if ecdsa.VerifyASN1(key, sum[:], signature) {
+			return nil
+		}
+	case *rsa.PublicKey:
+		if rsa.VerifyPKCS1v15(key, crypto.SHA256, sum[:], signature) == nil {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if ed25519.Verify(key, payload, signature) {
+			return nil
+		}
+	default:
+		return fmt.Errorf("unsupported verify key type %T", key)
+	}
+	return fmt.Errorf("signature does not match the verify key")
+}
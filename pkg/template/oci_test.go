@@ -0,0 +1,314 @@
+package template
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testRegistry is an OCI registry serving one repository, requiring a bearer token if token is set
+type testRegistry struct {
+	mu        sync.Mutex
+	server    *httptest.Server
+	token     string
+	manifests map[string][]byte // by tag and digest
+	blobs     map[string][]byte
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	t.Helper()
+	registry := &testRegistry{manifests: make(map[string][]byte), blobs: make(map[string][]byte)}
+	registry.server = httptest.NewTLSServer(http.HandlerFunc(registry.serve))
+	t.Cleanup(registry.server.Close)
+
+	previous := ociHTTPClient
+	ociHTTPClient = registry.server.Client()
+	t.Cleanup(func() { ociHTTPClient = previous })
+	return registry
+}
+
+// url returns the oci:// URL of the registry's templates/web repository
+func (r *testRegistry) url() string {
+	return ociScheme + strings.TrimPrefix(r.server.URL, "https://") + "/templates/web"
+}
+
+func (r *testRegistry) serve(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.URL.Path == "/token" {
+		if user, password, _ := req.BasicAuth(); user != "ci" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": r.token})
+		return
+	}
+	if r.token != "" && req.Header.Get("Authorization") != "Bearer "+r.token {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+r.server.URL+`/token",service="test"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/templates/web/")
+	if ref, ok := strings.CutPrefix(path, "manifests/"); ok && r.manifests[ref] != nil {
+		w.Header().Set("Content-Type", ociManifestMediaType)
+		_, _ = w.Write(r.manifests[ref])
+		return
+	}
+	if digest, ok := strings.CutPrefix(path, "blobs/"); ok && r.blobs[digest] != nil {
+		_, _ = w.Write(r.blobs[digest])
+		return
+	}
+	http.NotFound(w, req)
+}
+
+// addBlob stores a blob and returns its descriptor
+func (r *testRegistry) addBlob(data []byte, annotations map[string]string) ociDescriptor {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	digest := testDigest(data)
+	r.blobs[digest] = data
+	return ociDescriptor{MediaType: "application/vnd.oci.image.layer.v1.tar+gzip", Digest: digest, Size: int64(len(data)), Annotations: annotations}
+}
+
+// push stores a manifest of layers under tag and returns its digest
+func (r *testRegistry) push(t *testing.T, tag string, layers ...ociDescriptor) string {
+	t.Helper()
+	data, err := json.Marshal(ociManifest{MediaType: ociManifestMediaType, Layers: layers})
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	digest := testDigest(data)
+	r.manifests[tag] = data
+	r.manifests[digest] = data
+	return digest
+}
+
+// pushTemplate pushes files as a gzipped tar layer under tag
+func (r *testRegistry) pushTemplate(t *testing.T, tag string, files map[string]string) string {
+	t.Helper()
+	var entries []tar.Header
+	for name, content := range files {
+		entries = append(entries, tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg, Linkname: content})
+	}
+	return r.push(t, tag, r.addBlob(testLayer(t, entries), nil))
+}
+
+// sign pushes a cosign signature of digest made with key
+func (r *testRegistry) sign(t *testing.T, digest string, key *ecdsa.PrivateKey) {
+	t.Helper()
+	payload := []byte(`{"critical":{"identity":{"docker-reference":"templates/web"},"image":{"docker-manifest-digest":"` + digest + `"},"type":"cosign container image signature"},"optional":null}`)
+	sum := sha256.Sum256(payload)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, sum[:])
+	if err != nil {
+		t.Fatalf("Failed to sign: %v", err)
+	}
+	layer := r.addBlob(payload, map[string]string{cosignSignatureAnnotation: base64.StdEncoding.EncodeToString(signature)})
+	r.push(t, strings.Replace(digest, ":", "-", 1)+".sig", layer)
+}
+
+// testLayer builds a gzipped tar archive; the Linkname of regular file entries holds their content
+func testLayer(t *testing.T, entries []tar.Header) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	for _, entry := range entries {
+		content := ""
+		if entry.Typeflag == tar.TypeReg {
+			content, entry.Linkname = entry.Linkname, ""
+		}
+		if err := archive.WriteHeader(&entry); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar entry: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func testDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// writeTestPublicKey generates a signing key and writes its public key as PEM
+func writeTestPublicKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal public key: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "cosign.pub")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0644); err != nil {
+		t.Fatalf("Failed to write public key: %v", err)
+	}
+	return key, path
+}
+
+func TestSplitOCISource(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("ab", 32)
+	tests := []struct {
+		url, ref, wantURL, wantRef string
+		wantErr                    bool
+	}{
+		{"oci://registry.example.com/templates/web:1.2.0", "", "oci://registry.example.com/templates/web", "1.2.0", false},
+		{"oci://registry.example.com:5000/templates/web", "", "oci://registry.example.com:5000/templates/web", "latest", false},
+		{"oci://registry.example.com:5000/templates/web", "1.0", "oci://registry.example.com:5000/templates/web", "1.0", false},
+		{"oci://registry.example.com/web@" + digest, "", "oci://registry.example.com/web", digest, false},
+		{"oci://registry.example.com/web@sha256:abc", "", "", "", true},
+		{"oci://registry.example.com/web:1.0", "2.0", "", "", true},
+		{"oci://registry.example.com", "", "", "", true},
+	}
+	for _, tt := range tests {
+		url, ref, err := splitOCISource(tt.url, tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("splitOCISource(%q, %q) error = %v, want error %v", tt.url, tt.ref, err, tt.wantErr)
+			continue
+		}
+		if url != tt.wantURL || ref != tt.wantRef {
+			t.Errorf("splitOCISource(%q, %q) = %q, %q, want %q, %q", tt.url, tt.ref, url, ref, tt.wantURL, tt.wantRef)
+		}
+	}
+}
+
+func TestOCITemplate(t *testing.T) {
+	registry := newTestRegistry(t)
+	registry.token = "pull-token"
+	t.Setenv("TEST_REGISTRY_CREDENTIALS", "ci:secret")
+	v1 := registry.pushTemplate(t, "1.2.0", map[string]string{"web/main.tf": "# v1", ManifestFile: `{"name": "web", "version": "1.2.0"}`})
+	manager := NewManager(t.TempDir())
+
+	if err := manager.AddTemplate("web", registry.url()+":1.2.0", "web", "", "", SourceAuth{}); err == nil {
+		t.Error("Expected pulling without credentials to fail")
+	}
+	if err := manager.AddTemplate("web", registry.url()+":1.2.0", "web", "", "", SourceAuth{TokenEnv: "TEST_REGISTRY_CREDENTIALS"}); err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
+	template, _ := manager.GetTemplate("web")
+	if template.SourceURL != registry.url() || template.SourceRef != "1.2.0" || template.Commit != v1 {
+		t.Errorf("Unexpected template %+v", template)
+	}
+	if data, _ := os.ReadFile(filepath.Join(manager.GetTemplatePath("web"), "main.tf")); string(data) != "# v1" {
+		t.Errorf("Expected the layer's web directory to be installed, got '%s'", data)
+	}
+
+	// A moved tag is refused unless forced
+	v2 := registry.pushTemplate(t, "1.2.0", map[string]string{"web/main.tf": "# v2"})
+	if _, err := manager.UpdateTemplate("web", false); err == nil || !strings.Contains(err.Error(), "moved") {
+		t.Errorf("Expected the moved tag to be refused, got %v", err)
+	}
+	result, err := manager.UpdateTemplate("web", true)
+	if err != nil {
+		t.Fatalf("Forced update failed: %v", err)
+	}
+	if !result.Changed || result.Commit != v2 {
+		t.Errorf("Expected the forced update to install %s, got %+v", v2, result)
+	}
+
+	// A pinned digest must match what the registry serves
+	if err := manager.AddTemplate("pinned", registry.url()+"@"+v1, "web", "", "", SourceAuth{TokenEnv: "TEST_REGISTRY_CREDENTIALS"}); err != nil {
+		t.Fatalf("AddTemplate with digest failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(manager.GetTemplatePath("pinned"), "main.tf")); string(data) != "# v1" {
+		t.Errorf("Expected the pinned digest's content, got '%s'", data)
+	}
+	registry.manifests[v1] = registry.manifests["1.2.0"]
+	if _, err := manager.UpdateTemplate("pinned", true); err == nil || !strings.Contains(err.Error(), "does not match pinned digest") {
+		t.Errorf("Expected a mismatching digest to be refused, got %v", err)
+	}
+}
+
+func TestOCITemplateSignature(t *testing.T) {
+	registry := newTestRegistry(t)
+	key, publicKey := writeTestPublicKey(t)
+	otherKey, _ := writeTestPublicKey(t)
+	manager := NewManager(t.TempDir())
+
+	digest := registry.pushTemplate(t, "1.0", map[string]string{"main.tf": "# v1"})
+	if err := manager.AddTemplate("web", registry.url()+":1.0", "", "", "", SourceAuth{VerifyKey: publicKey}); err == nil || !strings.Contains(err.Error(), "no signature") {
+		t.Errorf("Expected an unsigned template to be refused, got %v", err)
+	}
+
+	registry.sign(t, digest, otherKey)
+	if err := manager.AddTemplate("web", registry.url()+":1.0", "", "", "", SourceAuth{VerifyKey: publicKey}); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("Expected a signature by another key to be refused, got %v", err)
+	}
+	if _, err := os.Stat(manager.GetTemplatePath("web")); !os.IsNotExist(err) {
+		t.Error("Expected no template files after the refused signature")
+	}
+
+	registry.sign(t, digest, key)
+	if err := manager.AddTemplate("web", registry.url()+":1.0", "", "", "", SourceAuth{VerifyKey: publicKey}); err != nil {
+		t.Fatalf("AddTemplate with a valid signature failed: %v", err)
+	}
+
+	// A signature of another digest doesn't verify the template
+	other := registry.pushTemplate(t, "2.0", map[string]string{"main.tf": "# v2"})
+	registry.manifests[strings.Replace(other, ":", "-", 1)+".sig"] = registry.manifests[strings.Replace(digest, ":", "-", 1)+".sig"]
+	if err := manager.AddTemplate("other", registry.url()+":2.0", "", "", "", SourceAuth{VerifyKey: publicKey}); err == nil || !strings.Contains(err.Error(), "signature is for") {
+		t.Errorf("Expected a signature of another digest to be refused, got %v", err)
+	}
+
+	if err := manager.AddTemplate("git", "https://example.com/templates.git", "", "", "", SourceAuth{VerifyKey: publicKey}); err == nil || !strings.Contains(err.Error(), "only supported for OCI") {
+		t.Errorf("Expected a verify key on a git source to be refused, got %v", err)
+	}
+}
+
+func TestOCITemplateRejectsUnsafeLayers(t *testing.T) {
+	registry := newTestRegistry(t)
+	manager := NewManager(t.TempDir())
+
+	tests := map[string]tar.Header{
+		"traversal": {Name: "../escape.tf", Mode: 0644, Size: 1, Typeflag: tar.TypeReg, Linkname: "x"},
+		"absolute":  {Name: "/etc/escape.tf", Mode: 0644, Size: 1, Typeflag: tar.TypeReg, Linkname: "x"},
+		"symlink":   {Name: "main.tf", Typeflag: tar.TypeSymlink, Linkname: "/etc/passwd"},
+	}
+	for name, entry := range tests {
+		registry.push(t, name, registry.addBlob(testLayer(t, []tar.Header{entry}), nil))
+		if err := manager.AddTemplate(name, registry.url()+":"+name, "", "", "", SourceAuth{}); err == nil {
+			t.Errorf("Expected the %s layer to be refused", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(manager.templatesDir), "escape.tf")); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be written outside the templates directory")
+	}
+
+	// A layer whose content doesn't match its digest is refused
+	layer := registry.addBlob(testLayer(t, []tar.Header{{Name: "main.tf", Mode: 0644, Size: 1, Typeflag: tar.TypeReg, Linkname: "x"}}), nil)
+	registry.blobs[layer.Digest] = testLayer(t, []tar.Header{{Name: "main.tf", Mode: 0644, Size: 1, Typeflag: tar.TypeReg, Linkname: "y"}})
+	registry.push(t, "tampered", layer)
+	if err := manager.AddTemplate("tampered", registry.url()+":tampered", "", "", "", SourceAuth{}); err == nil || !strings.Contains(err.Error(), "digest mismatch") {
+		t.Errorf("Expected a tampered layer to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,157 @@
+package template
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// templateNamePattern matches names usable as template directory names
+var templateNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// scaffoldModes are the deployment modes of a scaffolded template besides "default"
+var scaffoldModes = []string{"busy", "hibernation"}
+
+const scaffoldMainTF = `terraform {
+  required_version = ">= 1.6.0"
+}
+
+locals {
+  # Settings per deployment mode. Workspaces without mode_schedules deploy in "default".
+  modes = {
+    default     = { size = "small" }
+    busy        = { size = "large" }
+    hibernation = { size = "none" }
+  }
+  settings = lookup(local.modes, var.deployment_mode, local.modes["default"])
+}
+
+# Replace with the resources of the template
+resource "terraform_data" "example" {
+  input = {
+    name = var.name
+    mode = var.deployment_mode
+    size = local.settings.size
+  }
+}
+`
+
+const scaffoldVariablesTF = `variable "deployment_mode" {
+  description = "Deployment mode, set by the provisioner for workspaces with mode_schedules"
+  type        = string
+  default     = "default"
+}
+
+variable "name" {
+  description = "Name of the resources, set per workspace in its config.json variables"
+  type        = string
+  default     = "%s"
+}
+`
+
+const scaffoldOutputsTF = `output "settings" {
+  description = "Settings of the current deployment mode"
+  value       = terraform_data.example.output
+}
+`
+
+// scaffoldExampleConfig is an example workspace config.json deploying the template
+const scaffoldExampleConfig = `{
+  "enabled": true,
+  "template": "%s",
+  "description": "Example workspace using the %s template",
+  "mode_schedules": {
+    "busy": "0 9 * * 1-5",
+    "hibernation": "0 18 * * 1-5"
+  },
+  "variables": {
+    "name": "%s-example"
+  }
+}
+`
+
+// Scaffold writes a skeleton template named name into dir: main.tf, variables.tf with
+// deployment_mode, outputs.tf, the manifest and an example workspace config. dir must not
+// exist or be empty. Returns the files written, relative to dir.
+func Scaffold(dir, name, description string) ([]string, error) {
+	if !templateNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid template name '%s' (letters, digits, '.', '_' and '-' only)", name)
+	}
+	if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+		return nil, fmt.Errorf("directory %s already exists and is not empty", dir)
+	}
+	if description == "" {
+		description = fmt.Sprintf("%s template", name)
+	}
+
+	manifest, err := json.MarshalIndent(Manifest{Name: name, Description: description, Version: "0.1.0", Modes: scaffoldModes}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	files := []struct{ path, content string }{
+		{"main.tf", scaffoldMainTF},
+		{"variables.tf", fmt.Sprintf(scaffoldVariablesTF, name)},
+		{"outputs.tf", scaffoldOutputsTF},
+		{ManifestFile, string(manifest) + "\n"},
+		{filepath.Join("examples", "config.json"), fmt.Sprintf(scaffoldExampleConfig, name, name, name)},
+	}
+
+	written := make([]string, 0, len(files))
+	for _, file := range files {
+		path := filepath.Join(dir, file.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory: %w", err)
+		}
+		if err := os.WriteFile(path, []byte(file.content), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", file.path, err)
+		}
+		written = append(written, file.path)
+	}
+	return written, nil
+}
+
+// RunInitCommand generates a skeleton template in a local directory: NAME [--dir DIR] [--description DESC]
+func RunInitCommand(args []string) error {
+	if len(args) < 1 || strings.HasPrefix(args[0], "--") {
+		return fmt.Errorf("template init requires NAME argument")
+	}
+
+	name := args[0]
+	dir, description := name, ""
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "--dir=") {
+			dir = strings.TrimPrefix(arg, "--dir=")
+		} else if arg == "--dir" && i+1 < len(args) {
+			dir = args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--description=") {
+			description = strings.TrimPrefix(arg, "--description=")
+		} else if arg == "--description" && i+1 < len(args) {
+			description = args[i+1]
+			i++
+		} else {
+			return fmt.Errorf("unknown option '%s'", arg)
+		}
+	}
+
+	files, err := Scaffold(dir, name, description)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Template '%s' created in %s:\n", name, dir)
+	for _, file := range files {
+		fmt.Printf("  %s\n", file)
+	}
+	fmt.Printf("\nNext steps:\n")
+	fmt.Printf("  1. Replace the example resource in main.tf and adjust the modes\n")
+	fmt.Printf("  2. Push the directory to a git repository\n")
+	fmt.Printf("  3. templatectl add %s URL --path PATH\n", name)
+	fmt.Printf("  4. Copy examples/config.json to a workspace directory, or: workspacectl add NAME --template %s\n", name)
+	return nil
+}
@@ -0,0 +1,99 @@
+package template
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/lint"
+	"provisioner/pkg/workspace"
+)
+
+func TestScaffold(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "web-app")
+	files, err := Scaffold(dir, "web-app", "")
+	if err != nil {
+		t.Fatalf("Scaffold failed: %v", err)
+	}
+	if len(files) != 5 {
+		t.Errorf("Expected 5 files, got %v", files)
+	}
+
+	manifest, err := LoadManifest(dir)
+	if err != nil || manifest == nil {
+		t.Fatalf("Expected a valid manifest, got %v", err)
+	}
+	if manifest.Name != "web-app" || manifest.Version != "0.1.0" || len(manifest.Modes) != 2 {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+
+	variables, _ := os.ReadFile(filepath.Join(dir, "variables.tf"))
+	if !strings.Contains(string(variables), `variable "deployment_mode"`) {
+		t.Error("Expected variables.tf to declare deployment_mode")
+	}
+	if findings, err := lint.CheckDir(dir); err != nil || len(findings) > 0 {
+		t.Errorf("Expected the skeleton to pass lint checks, got %v (%v)", findings, err)
+	}
+
+	// The example config is a valid workspace config using the template and its modes
+	data, _ := os.ReadFile(filepath.Join(dir, "examples", "config.json"))
+	var config workspace.Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("Invalid example config: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Example config failed validation: %v", err)
+	}
+	if config.Template != "web-app" {
+		t.Errorf("Expected the example to use the template, got '%s'", config.Template)
+	}
+	for _, mode := range manifest.Modes {
+		if _, ok := config.ModeSchedules[mode]; !ok {
+			t.Errorf("Expected a schedule for mode '%s' in the example", mode)
+		}
+	}
+
+	if _, err := Scaffold(dir, "web-app", ""); err == nil {
+		t.Error("Expected scaffolding into a non-empty directory to fail")
+	}
+	if _, err := Scaffold(t.TempDir(), "../escape", ""); err == nil {
+		t.Error("Expected an invalid name to fail")
+	}
+}
+
+func TestManifestSetsVersion(t *testing.T) {
+	repoURL := newTestGitRepo(t, map[string]string{
+		"main.tf":    "# v1",
+		ManifestFile: `{"name": "web", "description": "Web servers", "version": "1.2.0"}`,
+	})
+	repoDir := strings.TrimPrefix(repoURL, "file://")
+	manager := NewManager(t.TempDir())
+
+	if err := manager.AddTemplate("web", repoURL, "", "main", "", SourceAuth{}); err != nil {
+		t.Fatalf("AddTemplate failed: %v", err)
+	}
+	template, _ := manager.GetTemplate("web")
+	if template.Version != "1.2.0" || template.Description != "Web servers" {
+		t.Errorf("Expected version and description from the manifest, got %+v", template)
+	}
+
+	commitTestFiles(t, repoDir, map[string]string{ManifestFile: `{"name": "web", "version": "1.3.0"}`}, "release")
+	if _, err := manager.UpdateTemplate("web", false); err != nil {
+		t.Fatalf("UpdateTemplate failed: %v", err)
+	}
+	template, _ = manager.GetTemplate("web")
+	if template.Version != "1.3.0" || template.Description != "Web servers" {
+		t.Errorf("Expected the updated version and the kept description, got %+v", template)
+	}
+
+	// An invalid manifest is refused and keeps the current files
+	commitTestFiles(t, repoDir, map[string]string{ManifestFile: `{"version": "2.0.0"}`}, "broken")
+	if _, err := manager.UpdateTemplate("web", false); err == nil || !strings.Contains(err.Error(), "name is required") {
+		t.Errorf("Expected the invalid manifest to be refused, got %v", err)
+	}
+	if err := manager.ValidateTemplate("web"); err != nil {
+		t.Errorf("Expected the current template to stay valid, got %v", err)
+	}
+}
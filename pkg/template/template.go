@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"sort"
 	"time"
+
+	"provisioner/pkg/workspace"
 )
 
 type Template struct {
@@ -22,6 +24,36 @@ type Template struct {
 	Description string    `json:"description,omitempty"`
 	Version     string    `json:"version,omitempty"`
 	ContentHash string    `json:"content_hash,omitempty"`
+	Commit      string    `json:"commit,omitempty"` // Commit or OCI manifest digest the template files were taken from
+	SourceAuth
+}
+
+// SourceAuth is how a template's source is accessed and verified. Tokens are read from the
+// environment on every fetch and never stored.
+type SourceAuth struct {
+	SSHKey    string `json:"ssh_key,omitempty"`    // Private key file for SSH URLs
+	TokenEnv  string `json:"token_env,omitempty"`  // Environment variable holding an HTTPS access token or registry credentials
+	VerifyKey string `json:"verify_key,omitempty"` // Public key file the signatures of OCI templates must verify against
+}
+
+// UpdateResult describes what a template update fetched
+type UpdateResult struct {
+	Name           string
+	Ref            string
+	PreviousCommit string
+	Commit         string
+	Changed        bool // The content hash changed; workspaces using the template redeploy
+}
+
+func (r *UpdateResult) String() string {
+	switch {
+	case r.Changed:
+		return fmt.Sprintf("Template '%s' updated to %s (%s), content changed", r.Name, shortCommit(r.Commit), r.Ref)
+	case r.Commit != r.PreviousCommit:
+		return fmt.Sprintf("Template '%s' moved to %s (%s), content unchanged", r.Name, shortCommit(r.Commit), r.Ref)
+	default:
+		return fmt.Sprintf("Template '%s' is up to date at %s (%s)", r.Name, shortCommit(r.Commit), r.Ref)
+	}
 }
 
 type Registry struct {
@@ -81,7 +113,7 @@ func (m *Manager) SaveRegistry(registry *Registry) error {
 	return nil
 }
 
-func (m *Manager) AddTemplate(name, sourceURL, sourcePath, sourceRef, description string) error {
+func (m *Manager) AddTemplate(name, sourceURL, sourcePath, sourceRef, description string, auth SourceAuth) error {
 	registry, err := m.LoadRegistry()
 	if err != nil {
 		return fmt.Errorf("failed to load registry: %w", err)
@@ -92,8 +124,14 @@ func (m *Manager) AddTemplate(name, sourceURL, sourcePath, sourceRef, descriptio
 		return fmt.Errorf("template '%s' already exists", name)
 	}
 
-	// Default ref to 'main' if not specified
-	if sourceRef == "" {
+	if isOCISource(sourceURL) {
+		// OCI sources carry their tag or digest in the URL
+		sourceURL, sourceRef, err = splitOCISource(sourceURL, sourceRef)
+		if err != nil {
+			return err
+		}
+	} else if sourceRef == "" {
+		// Default ref to 'main' if not specified
 		sourceRef = "main"
 	}
 
@@ -105,10 +143,13 @@ func (m *Manager) AddTemplate(name, sourceURL, sourcePath, sourceRef, descriptio
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 		Description: description,
+		SourceAuth:  auth,
 	}
 
 	// Download the template and calculate content hash
-	if err := m.downloadTemplate(template); err != nil {
+	if err := m.fetchTemplate(&template, false); err != nil {
+		_ = os.RemoveAll(m.sourceDir(name))
+		_ = os.RemoveAll(m.GetTemplatePath(name))
 		return fmt.Errorf("failed to download template: %w", err)
 	}
 
@@ -146,6 +187,9 @@ func (m *Manager) RemoveTemplate(name string, force bool) error {
 	if err := os.RemoveAll(templatePath); err != nil {
 		return fmt.Errorf("failed to remove template directory: %w", err)
 	}
+	if err := os.RemoveAll(m.sourceDir(name)); err != nil {
+		return fmt.Errorf("failed to remove template source: %w", err)
+	}
 
 	// Remove from registry
 	delete(registry.Templates, name)
@@ -158,51 +202,43 @@ func (m *Manager) RemoveTemplate(name string, force bool) error {
 	return nil
 }
 
-func (m *Manager) UpdateTemplate(name string) error {
+// UpdateTemplate fetches the template's ref again. Branches must fast-forward and tags must not
+// move unless force is set; the content hash tells whether the files actually changed.
+func (m *Manager) UpdateTemplate(name string, force bool) (*UpdateResult, error) {
 	registry, err := m.LoadRegistry()
 	if err != nil {
-		return fmt.Errorf("failed to load registry: %w", err)
+		return nil, fmt.Errorf("failed to load registry: %w", err)
 	}
 
 	template, exists := registry.Templates[name]
 	if !exists {
-		return fmt.Errorf("template '%s' does not exist", name)
-	}
-
-	// Remove existing template directory
-	templatePath := filepath.Join(m.templatesDir, name)
-	if err := os.RemoveAll(templatePath); err != nil {
-		return fmt.Errorf("failed to remove existing template: %w", err)
+		return nil, fmt.Errorf("template '%s' does not exist", name)
 	}
 
-	// Download updated template
-	if err := m.downloadTemplate(template); err != nil {
-		return fmt.Errorf("failed to download updated template: %w", err)
+	result := &UpdateResult{Name: name, Ref: template.SourceRef, PreviousCommit: template.Commit}
+	if err := m.fetchTemplate(&template, force); err != nil {
+		return nil, fmt.Errorf("failed to download updated template: %w", err)
 	}
+	result.Commit = template.Commit
 
 	// Calculate new content hash
 	newContentHash, err := m.calculateTemplateHash(template.Name)
 	if err != nil {
-		return fmt.Errorf("failed to calculate template hash: %w", err)
+		return nil, fmt.Errorf("failed to calculate template hash: %w", err)
 	}
 
-	// Check if content actually changed
-	if newContentHash == template.ContentHash {
-		// No changes, just update timestamp
-		template.UpdatedAt = time.Now()
-	} else {
-		// Content changed - this will trigger workspace redeployment
-		template.ContentHash = newContentHash
-		template.UpdatedAt = time.Now()
-	}
+	// A changed content hash triggers the redeployment of workspaces using the template
+	result.Changed = newContentHash != template.ContentHash
+	template.ContentHash = newContentHash
+	template.UpdatedAt = time.Now()
 	registry.Templates[name] = template
 
 	// Save registry
 	if err := m.SaveRegistry(registry); err != nil {
-		return fmt.Errorf("failed to save registry: %w", err)
+		return nil, fmt.Errorf("failed to save registry: %w", err)
 	}
 
-	return nil
+	return result, nil
 }
 
 func (m *Manager) ListTemplates() ([]Template, error) {
@@ -245,60 +281,110 @@ func (m *Manager) ValidateTemplate(name string) error {
 		return fmt.Errorf("template directory does not exist: %s", templatePath)
 	}
 
-	// Check for main.tf file
-	mainTFPath := filepath.Join(templatePath, "main.tf")
-	if _, err := os.Stat(mainTFPath); os.IsNotExist(err) {
-		return fmt.Errorf("template missing main.tf file: %s", mainTFPath)
+	// Check the OpenTofu configuration, including local modules and templatefile() paths
+	if err := workspace.ValidateTFDir(templatePath); err != nil {
+		return fmt.Errorf("invalid template: %w", err)
+	}
+
+	// The manifest is optional, but must be valid if present
+	if _, err := LoadManifest(templatePath); err != nil {
+		return err
 	}
 
 	return nil
 }
 
-func (m *Manager) downloadTemplate(template Template) error {
-	// TODO: Implement actual GitHub download logic
-	// For now, create a placeholder directory with a sample main.tf
-	templatePath := filepath.Join(m.templatesDir, template.Name)
+// sourceDir returns the directory of a template's git clone
+func (m *Manager) sourceDir(name string) string {
+	return filepath.Join(m.templatesDir, ".sources", name)
+}
+
+// fetchTemplate fetches the template's ref from its git or OCI source, verifies it against the
+// revision fetched before and replaces the template files with the content of its path. Sets
+// template.Commit and the version from the template's manifest.
+func (m *Manager) fetchTemplate(template *Template, force bool) error {
+	if isOCISource(template.SourceURL) {
+		return m.fetchOCITemplate(template, force)
+	}
+	if template.VerifyKey != "" {
+		return fmt.Errorf("signature verification is only supported for OCI sources")
+	}
 
-	if err := os.MkdirAll(templatePath, 0755); err != nil {
-		return fmt.Errorf("failed to create template directory: %w", err)
+	repo, err := newGitRepo(m.sourceDir(template.Name), *template)
+	if err != nil {
+		return err
+	}
+	if err := repo.init(); err != nil {
+		return err
 	}
 
-	// Create a placeholder main.tf
-	mainTFContent := fmt.Sprintf(`# Template: %s
-# Source: %s
-# Ref: %s
-# Path: %s
+	kind, err := repo.resolveRef(template.SourceRef)
+	if err != nil {
+		return err
+	}
 
-terraform {
-  required_providers {
-    local = {
-      source  = "hashicorp/local"
-      version = "~> 2.0"
-    }
-  }
-}
+	// With the previous commit in the clone only the new commits are fetched, which verifies
+	// fast-forwards; otherwise a shallow fetch gets just the ref's commit
+	previous := template.Commit
+	known := previous != "" && repo.hasCommit(previous)
+	commit, err := repo.fetch(template.SourceRef, kind, !known)
+	if err != nil {
+		return err
+	}
 
-resource "local_file" "template_marker" {
-  content  = "Template: %s\nDeployed at: $${timestamp()}\n"
-  filename = "/tmp/$${var.workspace_name}_%s_deployed.txt"
-}
+	if kind == refKindCommit && commit != template.SourceRef {
+		return fmt.Errorf("fetched commit %s does not match pinned commit %s", commit, template.SourceRef)
+	}
+	if previous != "" && commit != previous && !force {
+		if kind == refKindTag {
+			return fmt.Errorf("tag '%s' moved from %s to %s, use --force to accept it",
+				template.SourceRef, shortCommit(previous), shortCommit(commit))
+		}
+		if kind == refKindBranch && known && !repo.isAncestor(previous, commit) {
+			return fmt.Errorf("branch '%s' was rewritten, %s is not a fast-forward from %s, use --force to accept it",
+				template.SourceRef, shortCommit(commit), shortCommit(previous))
+		}
+	}
 
-variable "workspace_name" {
-  description = "Name of the workspace"
-  type        = string
-  default     = "template"
+	if err := repo.checkout(commit); err != nil {
+		return err
+	}
+	srcDir, err := sourceSubdir(repo.dir, template.SourcePath)
+	if err != nil {
+		return err
+	}
+	return m.installTemplateFiles(template, srcDir, commit)
 }
 
-output "deployment_file" {
-  value = local_file.template_marker.filename
-}
-`, template.Name, template.SourceURL, template.SourceRef, template.SourcePath, template.Name, template.Name)
+// installTemplateFiles replaces the template files with the content of srcDir, fetched at revision
+func (m *Manager) installTemplateFiles(template *Template, srcDir, revision string) error {
+	// Copy next to the sources first so a failure leaves the current template files in place
+	staging, err := os.MkdirTemp(filepath.Dir(m.sourceDir(template.Name)), template.Name+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+	if err := os.Chmod(staging, 0755); err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	if err := copyTemplateFiles(srcDir, staging); err != nil {
+		return fmt.Errorf("failed to copy template files: %w", err)
+	}
+	manifest, err := LoadManifest(staging)
+	if err != nil {
+		return err
+	}
 
-	mainTFPath := filepath.Join(templatePath, "main.tf")
-	if err := os.WriteFile(mainTFPath, []byte(mainTFContent), 0644); err != nil {
-		return fmt.Errorf("failed to write main.tf: %w", err)
+	templatePath := m.GetTemplatePath(template.Name)
+	if err := os.RemoveAll(templatePath); err != nil {
+		return fmt.Errorf("failed to remove existing template: %w", err)
+	}
+	if err := os.Rename(staging, templatePath); err != nil {
+		return fmt.Errorf("failed to install template files: %w", err)
 	}
 
+	template.Commit = revision
+	template.applyManifest(manifest)
 	return nil
 }
 
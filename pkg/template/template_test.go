@@ -16,9 +16,10 @@ func TestTemplateManager(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	manager := NewManager(tempDir)
+	repoURL := newTestGitRepo(t, map[string]string{"path/to/template/main.tf": "# test"})
 
 	// Test adding a template
-	err = manager.AddTemplate("test-template", "https://github.com/test/repo", "path/to/template", "main", "Test template")
+	err = manager.AddTemplate("test-template", repoURL, "path/to/template", "main", "Test template", SourceAuth{})
 	if err != nil {
 		t.Fatalf("Failed to add template: %v", err)
 	}
@@ -32,8 +33,8 @@ func TestTemplateManager(t *testing.T) {
 	if template.Name != "test-template" {
 		t.Errorf("Expected name 'test-template', got '%s'", template.Name)
 	}
-	if template.SourceURL != "https://github.com/test/repo" {
-		t.Errorf("Expected source URL 'https://github.com/test/repo', got '%s'", template.SourceURL)
+	if template.SourceURL != repoURL {
+		t.Errorf("Expected source URL '%s', got '%s'", repoURL, template.SourceURL)
 	}
 	if template.SourcePath != "path/to/template" {
 		t.Errorf("Expected source path 'path/to/template', got '%s'", template.SourcePath)
@@ -142,9 +143,10 @@ func TestTemplateDefaults(t *testing.T) {
 	defer os.RemoveAll(tempDir)
 
 	manager := NewManager(tempDir)
+	repoURL := newTestGitRepo(t, map[string]string{"main.tf": "# test"})
 
 	// Test adding template with empty ref (should default to "main")
-	err = manager.AddTemplate("default-ref", "https://github.com/test/repo", "", "", "")
+	err = manager.AddTemplate("default-ref", repoURL, "", "", "", SourceAuth{})
 	if err != nil {
 		t.Fatalf("Failed to add template with defaults: %v", err)
 	}
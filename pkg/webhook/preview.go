@@ -0,0 +1,247 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+// previewActor identifies preview operations in the audit log
+const previewActor = "previews"
+
+// pullRequestEvent is the part of a GitHub pull_request event previews use
+type pullRequestEvent struct {
+	Action      string `json:"action"`
+	Number      int    `json:"number"`
+	PullRequest struct {
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+}
+
+// handlePreview deploys a pull request's preview workspace when the pull request is opened or
+// updated, answering with its outputs, and destroys and removes it when the pull request closes
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	previews := s.sched.GetPreviewConfig()
+	if previews == nil {
+		writeResponse(w, http.StatusNotFound, response{Status: "error", Error: "webhook not found"})
+		return
+	}
+
+	body, err := readBody(w, r)
+	if err != nil {
+		return
+	}
+
+	secret := previews.GetSecret()
+	if secret == "" {
+		logging.LogSystemd("WEBHOOK: previews have no secret configured, rejecting request")
+		writeResponse(w, http.StatusForbidden, response{Status: "error", Error: "webhook secret not configured"})
+		return
+	}
+	if !verifyRequest(r, body, secret) {
+		logging.LogSystemd("WEBHOOK: previews rejected request from %s with invalid signature", r.RemoteAddr)
+		writeResponse(w, http.StatusUnauthorized, response{Status: "error", Error: "invalid signature"})
+		return
+	}
+
+	if r.Header.Get("X-GitHub-Event") == "ping" {
+		writeResponse(w, http.StatusOK, response{Status: "ok"})
+		return
+	}
+
+	var event pullRequestEvent
+	if err := json.Unmarshal(body, &event); err != nil || event.Number <= 0 {
+		writeResponse(w, http.StatusBadRequest, response{Status: "error", Error: "expected a pull_request event"})
+		return
+	}
+	pr := workspace.PullRequest{Number: event.Number, Branch: event.PullRequest.Head.Ref, SHA: event.PullRequest.Head.SHA}
+	workspaceName := workspace.PreviewWorkspaceName(pr.Number)
+
+	if !s.sched.IsReady() {
+		writeResponse(w, http.StatusServiceUnavailable, response{Status: "error", Error: "daemon is still starting"})
+		return
+	}
+
+	correlationID := r.Header.Get(CorrelationIDHeader)
+	if correlationID == "" {
+		correlationID = logging.NewCorrelationID(time.Now())
+	}
+	w.Header().Set(CorrelationIDHeader, correlationID)
+
+	switch event.Action {
+	case "opened", "reopened", "synchronize":
+		s.deployPreview(w, previews, pr, correlationID)
+	case "closed":
+		s.destroyPreview(w, workspaceName, correlationID)
+	default:
+		writeResponse(w, http.StatusOK, response{Status: "ignored", Workspace: workspaceName, Action: event.Action})
+	}
+}
+
+// deployPreview creates or updates a preview workspace and deploys it before answering, so the
+// caller can comment the outputs on the pull request
+func (s *Server) deployPreview(w http.ResponseWriter, previews *workspace.PreviewConfig, pr workspace.PullRequest, correlationID string) {
+	workspaceName := workspace.PreviewWorkspaceName(pr.Number)
+	if s.sched.IsWorkspaceBusy(workspaceName) {
+		writeResponse(w, http.StatusConflict, response{Status: "error", Workspace: workspaceName, Error: "workspace is busy"})
+		return
+	}
+
+	if _, err := previews.SavePreviewWorkspace(pr); err != nil {
+		logging.LogSystemd("WEBHOOK: failed to create preview workspace %s: %v", workspaceName, err)
+		writeResponse(w, http.StatusInternalServerError, response{Status: "error", Workspace: workspaceName, Error: err.Error()})
+		return
+	}
+	if err := s.sched.LoadWorkspaces(); err != nil {
+		writeResponse(w, http.StatusInternalServerError, response{Status: "error", Workspace: workspaceName, Error: err.Error()})
+		return
+	}
+
+	logging.LogWorkspaceOperation(workspaceName, "WEBHOOK", "Pull request #%d (%s at %s) triggered preview deploy (correlation ID %s)",
+		pr.Number, pr.Branch, shortSHA(pr.SHA), correlationID)
+
+	err := s.runPreviewOperation(workspaceName, correlationID, func() error {
+		return s.sched.ManualDeploy(workspaceName)
+	})
+	if err == nil {
+		err = s.sched.GetDeployError(workspaceName)
+	}
+	if err != nil {
+		logging.LogWorkspace(workspaceName, "WEBHOOK: preview deploy failed: %v", err)
+		writeResponse(w, http.StatusInternalServerError, response{
+			Status:        "error",
+			Workspace:     workspaceName,
+			Action:        workspace.WebhookActionDeploy,
+			CorrelationID: correlationID,
+			Error:         logging.RedactWorkspace(workspaceName, err.Error()),
+			Comment:       fmt.Sprintf("Preview `%s` failed to deploy, see `workspacectl logs %s`.", workspaceName, workspaceName),
+		})
+		return
+	}
+
+	outputs, err := s.sched.GetWorkspaceOutputs(workspaceName)
+	if err != nil {
+		logging.LogWorkspace(workspaceName, "WEBHOOK: failed to read preview outputs: %v", err)
+	}
+	values := previewOutputs(workspaceName, outputs)
+	writeResponse(w, http.StatusOK, response{
+		Status:        "deployed",
+		Workspace:     workspaceName,
+		Action:        workspace.WebhookActionDeploy,
+		CorrelationID: correlationID,
+		Outputs:       values,
+		Comment:       previewComment(workspaceName, pr, values),
+	})
+}
+
+// destroyPreview destroys and removes a closed pull request's preview workspace in the background
+func (s *Server) destroyPreview(w http.ResponseWriter, workspaceName, correlationID string) {
+	if s.sched.GetWorkspace(workspaceName) == nil {
+		writeResponse(w, http.StatusOK, response{Status: "ignored", Workspace: workspaceName, Action: workspace.WebhookActionDestroy})
+		return
+	}
+	if s.sched.IsWorkspaceBusy(workspaceName) {
+		writeResponse(w, http.StatusConflict, response{Status: "error", Workspace: workspaceName, Error: "workspace is busy"})
+		return
+	}
+
+	logging.LogWorkspaceOperation(workspaceName, "WEBHOOK", "Pull request closed, destroying preview (correlation ID %s)", correlationID)
+
+	s.actions.Add(1)
+	go func() {
+		defer s.actions.Done()
+		err := s.runPreviewOperation(workspaceName, correlationID, func() error {
+			return s.sched.ManualDestroy(workspaceName)
+		})
+		if err == nil {
+			err = s.sched.GetDestroyError(workspaceName)
+		}
+		if err != nil {
+			logging.LogWorkspace(workspaceName, "WEBHOOK: preview destroy failed, keeping the workspace: %v", err)
+			return
+		}
+		if err := workspace.RemoveWorkspace(workspaceName); err != nil {
+			logging.LogWorkspace(workspaceName, "WEBHOOK: failed to remove preview workspace: %v", err)
+			return
+		}
+		if err := s.sched.LoadWorkspaces(); err != nil {
+			logging.LogSystemd("Error reloading workspaces: %v", err)
+		}
+		logging.LogWorkspace(workspaceName, "WEBHOOK: preview destroyed and removed")
+	}()
+
+	writeResponse(w, http.StatusAccepted, response{
+		Status:        "accepted",
+		Workspace:     workspaceName,
+		Action:        workspace.WebhookActionDestroy,
+		CorrelationID: correlationID,
+	})
+}
+
+// runPreviewOperation runs a preview operation recorded as triggered by the previews webhook
+func (s *Server) runPreviewOperation(workspaceName, correlationID string, operation func() error) error {
+	trigger := audit.Trigger{Source: audit.SourceWebhook, Actor: previewActor}
+	return s.sched.WithTrigger(workspaceName, trigger, func() error {
+		return s.sched.WithCorrelationID(workspaceName, correlationID, operation)
+	})
+}
+
+// previewOutputs converts a preview's outputs to text, hiding sensitive values
+func previewOutputs(workspaceName string, outputs map[string]opentofu.OutputValue) map[string]string {
+	if len(outputs) == 0 {
+		return nil
+	}
+	values := make(map[string]string, len(outputs))
+	for name, output := range outputs {
+		if output.Sensitive {
+			values[name] = "(sensitive)"
+		} else {
+			values[name] = logging.RedactWorkspace(workspaceName, output.String())
+		}
+	}
+	return values
+}
+
+// previewComment renders a Markdown pull request comment listing a preview's outputs
+func previewComment(workspaceName string, pr workspace.PullRequest, outputs map[string]string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Preview `%s` deployed", workspaceName)
+	if pr.SHA != "" {
+		fmt.Fprintf(&b, " at %s", shortSHA(pr.SHA))
+	}
+	b.WriteString(".\n")
+	if len(outputs) == 0 {
+		return b.String()
+	}
+
+	names := make([]string, 0, len(outputs))
+	for name := range outputs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	b.WriteString("\n| Output | Value |\n|--------|-------|\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "| %s | %s |\n", name, strings.ReplaceAll(outputs[name], "|", "\\|"))
+	}
+	return b.String()
+}
+
+// shortSHA abbreviates a commit SHA for logs and comments
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,133 @@
+package webhook
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/workspace"
+)
+
+const previewDaemonConfig = `{"previews": {"template": "web-app", "secret": "` + testSecret + `", "ttl": "72h",
+	"variables": {"branch": "{branch}", "replicas": 1}}}`
+
+func setupPreviewServer(t *testing.T) (*Server, *opentofu.MockTofuClient) {
+	t.Helper()
+	server, mockClient := setupServerWithDaemonConfig(t, previewDaemonConfig)
+
+	templateDir := filepath.Join(os.Getenv("PROVISIONER_STATE_DIR"), "templates", "web-app")
+	if err := os.MkdirAll(templateDir, 0755); err != nil {
+		t.Fatalf("Failed to create template dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(templateDir, "main.tf"), []byte("# template\n"), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	return server, mockClient
+}
+
+func pullRequestBody(action string, number int) string {
+	return `{"action":"` + action + `","number":` + strconv.Itoa(number) +
+		`,"pull_request":{"head":{"ref":"feature/login","sha":"0123456789abcdef"}}}`
+}
+
+func postPullRequest(server *Server, body string) *http.Response {
+	recorder := post(server, "/previews", body, map[string]string{SignatureHeader: sign(body), "X-GitHub-Event": "pull_request"})
+	return recorder.Result()
+}
+
+func TestPreviewDeployedWithOutputs(t *testing.T) {
+	server, mockClient := setupPreviewServer(t)
+	mockClient.OutputFunc = func(string) (map[string]opentofu.OutputValue, error) {
+		return map[string]opentofu.OutputValue{
+			"url":      {Value: json.RawMessage(`"https://pr-4.example.com"`)},
+			"password": {Sensitive: true, Value: json.RawMessage(`"hunter2"`)},
+		}, nil
+	}
+
+	resp := postPullRequest(server, pullRequestBody("opened", 4))
+	var body response
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || body.Status != "deployed" || body.Workspace != "pr-4" {
+		t.Fatalf("Expected pr-4 deployed, got %d %+v", resp.StatusCode, body)
+	}
+	if body.Outputs["url"] != "https://pr-4.example.com" || body.Outputs["password"] != "(sensitive)" {
+		t.Errorf("Unexpected outputs %v", body.Outputs)
+	}
+	if !strings.Contains(body.Comment, "| url | https://pr-4.example.com |") || strings.Contains(body.Comment, "hunter2") {
+		t.Errorf("Unexpected comment %q", body.Comment)
+	}
+
+	ws := server.sched.GetWorkspace("pr-4")
+	if ws == nil {
+		t.Fatal("Expected the preview workspace to be loaded")
+	}
+	if ws.Config.Template != "web-app" || ws.Config.TTL != "72h" || ws.Config.Variables["branch"] != "feature/login" {
+		t.Errorf("Unexpected preview config %+v", ws.Config)
+	}
+	if mockClient.DeployCallCount != 1 {
+		t.Errorf("Expected one deploy, got %d", mockClient.DeployCallCount)
+	}
+}
+
+func TestPreviewDestroyedWhenClosed(t *testing.T) {
+	server, mockClient := setupPreviewServer(t)
+	if resp := postPullRequest(server, pullRequestBody("opened", 5)); resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected the preview to deploy, got %d", resp.StatusCode)
+	}
+
+	destroyed := make(chan string, 1)
+	mockClient.DestroyFunc = func(ws *workspace.Workspace) error {
+		destroyed <- ws.Name
+		return nil
+	}
+	if resp := postPullRequest(server, pullRequestBody("closed", 5)); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d", resp.StatusCode)
+	}
+	waitForCall(t, destroyed, "pr-5")
+	server.actions.Wait()
+
+	workspaceDir := filepath.Join(os.Getenv("PROVISIONER_CONFIG_DIR"), "workspaces", "pr-5")
+	if _, err := os.Stat(workspaceDir); !os.IsNotExist(err) {
+		t.Errorf("Expected the preview workspace to be removed, got %v", err)
+	}
+	if server.sched.GetWorkspace("pr-5") != nil {
+		t.Error("Expected the preview workspace to be unloaded")
+	}
+}
+
+func TestPreviewIgnoresOtherEvents(t *testing.T) {
+	server, mockClient := setupPreviewServer(t)
+
+	// Labels and unknown pull requests do nothing
+	if resp := postPullRequest(server, pullRequestBody("labeled", 6)); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for an ignored action, got %d", resp.StatusCode)
+	}
+	if resp := postPullRequest(server, pullRequestBody("closed", 7)); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 for closing a pull request without preview, got %d", resp.StatusCode)
+	}
+
+	body := pullRequestBody("opened", 8)
+	recorder := post(server, "/previews", body, map[string]string{SignatureHeader: sign(body + "x")})
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for an invalid signature, got %d", recorder.Code)
+	}
+
+	if mockClient.DeployCallCount != 0 || mockClient.DestroyCallCount != 0 {
+		t.Errorf("Expected no operations, got %d deploys and %d destroys", mockClient.DeployCallCount, mockClient.DestroyCallCount)
+	}
+}
+
+func TestPreviewsNotConfigured(t *testing.T) {
+	server, _ := setupServer(t)
+	body := pullRequestBody("opened", 4)
+	if recorder := post(server, "/previews", body, map[string]string{SignatureHeader: sign(body)}); recorder.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 without previews, got %d", recorder.Code)
+	}
+}
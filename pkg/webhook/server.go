@@ -0,0 +1,264 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"provisioner/pkg/audit"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/workspace"
+)
+
+// maxBodySize limits incoming payloads; only the signature over the body is checked
+const maxBodySize = 1 << 20
+
+// Headers carrying the shared secret or a signature over the body
+const (
+	SignatureHeader = "X-Hub-Signature-256" // GitHub-style "sha256=<hex hmac>"
+	TokenHeader     = "X-Provisioner-Token" // Plain shared secret for simple CI systems
+)
+
+// CorrelationIDHeader lets callers supply the correlation ID for the triggered operation
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// Server listens for incoming webhook triggers and runs workspace operations
+type Server struct {
+	sched      *scheduler.Scheduler
+	httpServer *http.Server
+	actions    sync.WaitGroup // Operations still running after their request was answered
+}
+
+// response is the JSON body returned to callers
+type response struct {
+	Status        string            `json:"status"`
+	Workspace     string            `json:"workspace,omitempty"`
+	Action        string            `json:"action,omitempty"`
+	Mode          string            `json:"mode,omitempty"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	Outputs       map[string]string `json:"outputs,omitempty"` // Outputs of a deployed preview, sensitive values hidden
+	Comment       string            `json:"comment,omitempty"` // Markdown pull request comment describing a preview
+}
+
+// NewServer creates a webhook server for the scheduler listening on addr
+func NewServer(sched *scheduler.Scheduler, addr string) *Server {
+	s := &Server{sched: sched}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /hooks/{workspace}/{name}", s.handleTrigger)
+	mux.HandleFunc("POST /previews", s.handlePreview)
+
+	s.httpServer = &http.Server{
+		Addr:              addr,
+		Handler:           accessLog(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler (for testing)
+func (s *Server) Handler() http.Handler {
+	return s.httpServer.Handler
+}
+
+// Start listens for webhook requests in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for webhooks: %w", err)
+	}
+
+	go func() {
+		if err := s.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logging.LogSystemd("Webhook server stopped: %v", err)
+		}
+	}()
+
+	logging.LogSystemd("Webhook listener on %s", listener.Addr())
+	return nil
+}
+
+// Close stops the webhook server
+func (s *Server) Close() error {
+	return s.httpServer.Close()
+}
+
+// handleTrigger validates and runs a workspace webhook
+func (s *Server) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	workspaceName := r.PathValue("workspace")
+	hookName := r.PathValue("name")
+
+	body, err := readBody(w, r)
+	if err != nil {
+		return
+	}
+
+	// Unknown workspaces and hooks look the same to avoid leaking names
+	ws := s.sched.GetWorkspace(workspaceName)
+	var hook *workspace.WebhookConfig
+	if ws != nil {
+		hook = ws.Config.GetWebhook(hookName)
+	}
+	if hook == nil {
+		writeResponse(w, http.StatusNotFound, response{Status: "error", Error: "webhook not found"})
+		return
+	}
+
+	secret := hook.GetSecret()
+	if secret == "" {
+		logging.LogWorkspace(workspaceName, "WEBHOOK: '%s' has no secret configured, rejecting request", hookName)
+		writeResponse(w, http.StatusForbidden, response{Status: "error", Error: "webhook secret not configured"})
+		return
+	}
+	if !verifyRequest(r, body, secret) {
+		logging.LogWorkspace(workspaceName, "WEBHOOK: '%s' rejected request from %s with invalid signature", hookName, r.RemoteAddr)
+		writeResponse(w, http.StatusUnauthorized, response{Status: "error", Error: "invalid signature"})
+		return
+	}
+
+	// GitHub sends a ping when a webhook is first configured
+	if r.Header.Get("X-GitHub-Event") == "ping" {
+		writeResponse(w, http.StatusOK, response{Status: "ok", Workspace: workspaceName})
+		return
+	}
+
+	if !s.sched.IsReady() {
+		writeResponse(w, http.StatusServiceUnavailable, response{Status: "error", Error: "daemon is still starting"})
+		return
+	}
+	if !ws.Config.Enabled {
+		writeResponse(w, http.StatusConflict, response{Status: "error", Error: "workspace is disabled"})
+		return
+	}
+	if s.sched.IsWorkspaceBusy(workspaceName) {
+		writeResponse(w, http.StatusConflict, response{Status: "error", Error: "workspace is busy"})
+		return
+	}
+
+	correlationID := r.Header.Get(CorrelationIDHeader)
+	if correlationID == "" {
+		correlationID = logging.NewCorrelationID(time.Now())
+	}
+	w.Header().Set(CorrelationIDHeader, correlationID)
+
+	logging.LogWorkspaceOperation(workspaceName, "WEBHOOK", "'%s' triggered %s from %s (correlation ID %s)",
+		hookName, describeAction(hook), r.RemoteAddr, correlationID)
+
+	// Operations take minutes, so run them after responding
+	s.actions.Add(1)
+	go func() {
+		defer s.actions.Done()
+		s.runAction(workspaceName, hook, correlationID)
+	}()
+
+	writeResponse(w, http.StatusAccepted, response{
+		Status:        "accepted",
+		Workspace:     workspaceName,
+		Action:        hook.Action,
+		Mode:          hook.Mode,
+		CorrelationID: correlationID,
+	})
+}
+
+// runAction performs the webhook's workspace operation
+func (s *Server) runAction(workspaceName string, hook *workspace.WebhookConfig, correlationID string) {
+	trigger := audit.Trigger{Source: audit.SourceWebhook, Actor: hook.Name}
+	err := s.sched.WithTrigger(workspaceName, trigger, func() error {
+		return s.sched.WithCorrelationID(workspaceName, correlationID, func() error {
+			switch {
+			case hook.Action == workspace.WebhookActionDestroy:
+				return s.sched.ManualDestroy(workspaceName)
+			case hook.Mode != "":
+				return s.sched.ManualDeployInMode(workspaceName, hook.Mode)
+			default:
+				return s.sched.ManualDeploy(workspaceName)
+			}
+		})
+	})
+
+	if err != nil {
+		logging.LogWorkspace(workspaceName, "WEBHOOK: '%s' %s failed: %v", hook.Name, describeAction(hook), err)
+	}
+}
+
+// readBody reads a request's payload, answering oversized payloads with an error
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeResponse(w, http.StatusRequestEntityTooLarge, response{Status: "error", Error: "payload too large"})
+		return nil, err
+	}
+	return body, nil
+}
+
+// verifyRequest checks the GitHub-style HMAC signature or the plain token header
+func verifyRequest(r *http.Request, body []byte, secret string) bool {
+	if signature := r.Header.Get(SignatureHeader); signature != "" {
+		expected, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+		if err != nil {
+			return false
+		}
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		return hmac.Equal(mac.Sum(nil), expected)
+	}
+
+	if token := r.Header.Get(TokenHeader); token != "" {
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+
+	return false
+}
+
+// describeAction returns a readable description of a webhook action for logs
+func describeAction(hook *workspace.WebhookConfig) string {
+	if hook.Mode != "" {
+		return fmt.Sprintf("%s (mode %s)", hook.Action, hook.Mode)
+	}
+	return hook.Action
+}
+
+// statusRecorder captures the response status for the access log
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// accessLog logs every webhook request with its outcome and correlation ID
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		line := fmt.Sprintf("ACCESS webhook %s %s %d %s from %s",
+			r.Method, r.URL.Path, recorder.status, time.Since(start).Round(time.Millisecond), r.RemoteAddr)
+		if correlationID := w.Header().Get(CorrelationIDHeader); correlationID != "" {
+			line += " correlation_id=" + correlationID
+		}
+		logging.LogSystemd("%s", line)
+	})
+}
+
+// writeResponse writes a JSON response
+func writeResponse(w http.ResponseWriter, status int, resp response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,209 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"provisioner/pkg/logging"
+	"provisioner/pkg/opentofu"
+	"provisioner/pkg/scheduler"
+	"provisioner/pkg/workspace"
+)
+
+const testSecret = "hook-secret"
+
+func setupServer(t *testing.T) (*Server, *opentofu.MockTofuClient) {
+	t.Helper()
+	return setupServerWithDaemonConfig(t, "")
+}
+
+// setupServerWithDaemonConfig sets up the test server with provisioner.json, if not empty
+func setupServerWithDaemonConfig(t *testing.T, daemonConfig string) (*Server, *opentofu.MockTofuClient) {
+	t.Helper()
+
+	tempDir := t.TempDir()
+	configDir := filepath.Join(tempDir, "config")
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_STATE_DIR", filepath.Join(tempDir, "state"))
+	t.Setenv("PROVISIONER_LOG_DIR", filepath.Join(tempDir, "logs"))
+
+	workspaceDir := filepath.Join(configDir, "workspaces", "web")
+	if err := os.MkdirAll(workspaceDir, 0755); err != nil {
+		t.Fatalf("Failed to create workspace dir: %v", err)
+	}
+	config := `{
+  "enabled": true,
+  "deploy_schedule": "0 9 * * *",
+  "destroy_schedule": "0 17 * * *",
+  "webhooks": [
+    {"name": "ci", "action": "deploy", "secret": "` + testSecret + `"},
+    {"name": "teardown", "action": "destroy", "secret": "` + testSecret + `"}
+  ]
+}`
+	if err := os.WriteFile(filepath.Join(workspaceDir, "config.json"), []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(workspaceDir, "main.tf"), []byte("# test\n"), 0644); err != nil {
+		t.Fatalf("Failed to write main.tf: %v", err)
+	}
+
+	if daemonConfig != "" {
+		if err := os.WriteFile(filepath.Join(configDir, scheduler.DaemonConfigFile), []byte(daemonConfig), 0644); err != nil {
+			t.Fatalf("Failed to write daemon config: %v", err)
+		}
+	}
+
+	mockClient := opentofu.NewMockTofuClient()
+	sched := scheduler.NewWithClient(mockClient)
+	if err := sched.LoadWorkspaces(); err != nil {
+		t.Fatalf("Failed to load workspaces: %v", err)
+	}
+	if err := sched.LoadState(); err != nil {
+		t.Fatalf("Failed to load state: %v", err)
+	}
+
+	server := NewServer(sched, "127.0.0.1:0")
+	// Let triggered operations finish saving state before the next test or directory cleanup
+	t.Cleanup(server.actions.Wait)
+	return server, mockClient
+}
+
+func sign(body string) string {
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func post(server *Server, path, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	recorder := httptest.NewRecorder()
+	server.Handler().ServeHTTP(recorder, req)
+	return recorder
+}
+
+func waitForCall(t *testing.T, calls <-chan string, expected string) {
+	t.Helper()
+	select {
+	case name := <-calls:
+		if name != expected {
+			t.Errorf("expected operation on %s, got %s", expected, name)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for operation")
+	}
+}
+
+func TestSignedRequestTriggersDeploy(t *testing.T) {
+	server, mockClient := setupServer(t)
+	calls := make(chan string, 1)
+	mockClient.DeployFunc = func(ws *workspace.Workspace) error {
+		calls <- ws.Name
+		return nil
+	}
+
+	body := `{"ref":"refs/heads/main"}`
+	recorder := post(server, "/hooks/web/ci", body, map[string]string{SignatureHeader: sign(body)})
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	waitForCall(t, calls, "web")
+}
+
+func TestTokenRequestTriggersDestroy(t *testing.T) {
+	server, mockClient := setupServer(t)
+	calls := make(chan string, 1)
+	mockClient.DestroyFunc = func(ws *workspace.Workspace) error {
+		calls <- ws.Name
+		return nil
+	}
+
+	recorder := post(server, "/hooks/web/teardown", "", map[string]string{TokenHeader: testSecret})
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+
+	waitForCall(t, calls, "web")
+}
+
+func TestCorrelationIDPropagatedToOperation(t *testing.T) {
+	server, mockClient := setupServer(t)
+	calls := make(chan string, 1)
+	mockClient.DeployFunc = func(ws *workspace.Workspace) error {
+		calls <- logging.CorrelationID(ws.Name)
+		return nil
+	}
+
+	recorder := post(server, "/hooks/web/ci", "", map[string]string{
+		TokenHeader:         testSecret,
+		CorrelationIDHeader: "ci-run-42",
+	})
+	if recorder.Code != http.StatusAccepted {
+		t.Fatalf("Expected 202, got %d: %s", recorder.Code, recorder.Body.String())
+	}
+	if got := recorder.Header().Get(CorrelationIDHeader); got != "ci-run-42" {
+		t.Errorf("Expected correlation ID header to be echoed, got %q", got)
+	}
+	if !strings.Contains(recorder.Body.String(), `"correlation_id":"ci-run-42"`) {
+		t.Errorf("Expected correlation ID in response, got %s", recorder.Body.String())
+	}
+
+	waitForCall(t, calls, "ci-run-42")
+}
+
+func TestRejectsInvalidRequests(t *testing.T) {
+	server, mockClient := setupServer(t)
+
+	tests := []struct {
+		name     string
+		path     string
+		headers  map[string]string
+		expected int
+	}{
+		{"bad signature", "/hooks/web/ci", map[string]string{SignatureHeader: sign("other body")}, http.StatusUnauthorized},
+		{"bad token", "/hooks/web/ci", map[string]string{TokenHeader: "wrong"}, http.StatusUnauthorized},
+		{"no credentials", "/hooks/web/ci", nil, http.StatusUnauthorized},
+		{"unknown hook", "/hooks/web/missing", map[string]string{TokenHeader: testSecret}, http.StatusNotFound},
+		{"unknown workspace", "/hooks/other/ci", map[string]string{TokenHeader: testSecret}, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := post(server, tt.path, `{"ref":"main"}`, tt.headers)
+			if recorder.Code != tt.expected {
+				t.Errorf("Expected %d, got %d: %s", tt.expected, recorder.Code, recorder.Body.String())
+			}
+		})
+	}
+
+	if mockClient.DeployCallCount != 0 || mockClient.DestroyCallCount != 0 {
+		t.Errorf("Expected no operations, got %d deploys and %d destroys", mockClient.DeployCallCount, mockClient.DestroyCallCount)
+	}
+}
+
+func TestGitHubPingDoesNotDeploy(t *testing.T) {
+	server, mockClient := setupServer(t)
+
+	body := `{"zen":"Keep it simple."}`
+	recorder := post(server, "/hooks/web/ci", body, map[string]string{
+		SignatureHeader:  sign(body),
+		"X-GitHub-Event": "ping",
+	})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", recorder.Code)
+	}
+	if mockClient.DeployCallCount != 0 {
+		t.Errorf("Expected ping not to deploy, got %d deploys", mockClient.DeployCallCount)
+	}
+}
@@ -0,0 +1,17 @@
+package workspace
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// AgentNamePattern is what agent names may consist of
+var AgentNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// validateAgent validates the name of the agent running the workspace's operations
+func (c *Config) validateAgent() error {
+	if c.Agent != "" && !AgentNamePattern.MatchString(c.Agent) {
+		return fmt.Errorf("agent '%s' must contain only letters, numbers, '-' and '_'", c.Agent)
+	}
+	return nil
+}
@@ -0,0 +1,17 @@
+package workspace
+
+import "testing"
+
+func TestAgentValidation(t *testing.T) {
+	config := Config{DeploySchedule: "0 9 * * *", Agent: "aws-eu_1"}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected agent 'aws-eu_1' to be valid, got %v", err)
+	}
+
+	for _, agent := range []string{"aws eu", "aws/eu", "../agent"} {
+		config.Agent = agent
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected error for agent %q", agent)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package workspace
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Remote state backend types
+const (
+	BackendS3     = "s3"
+	BackendGCS    = "gcs"
+	BackendSpaces = "spaces" // DigitalOcean Spaces, through OpenTofu's s3 backend
+	BackendConsul = "consul"
+)
+
+// RemoteStateFileName is the copy of a remote backend's state pulled into the deployment directory
+// after each operation. Status, resource counts and state backups read it; OpenTofu never does.
+const RemoteStateFileName = "remote.tfstate"
+
+// BackendConfig keeps a workspace's OpenTofu state in a remote backend instead of the deployment
+// directory, so it survives the loss of the provisioner host
+type BackendConfig struct {
+	Type   string            `json:"type"`             // s3, gcs, spaces or consul
+	Config map[string]string `json:"config,omitempty"` // Backend settings, e.g. bucket and region
+}
+
+// backendRequiredSettings lists the settings each backend type cannot do without. The state's
+// key, prefix or path defaults to one derived from the workspace name.
+var backendRequiredSettings = map[string][]string{
+	BackendS3:     {"bucket", "region"},
+	BackendGCS:    {"bucket"},
+	BackendSpaces: {"bucket", "region"},
+	BackendConsul: {},
+}
+
+// HasRemoteBackend reports whether the workspace keeps its state in a remote backend
+func (c *Config) HasRemoteBackend() bool {
+	return c.Backend != nil
+}
+
+// StateFileName returns the file in the deployment directory that holds the workspace's state
+func (c *Config) StateFileName() string {
+	if c.HasRemoteBackend() {
+		return RemoteStateFileName
+	}
+	return "terraform.tfstate"
+}
+
+// validateBackendConfig checks a remote backend's type and required settings
+func validateBackendConfig(cfg *BackendConfig) error {
+	required, ok := backendRequiredSettings[cfg.Type]
+	if !ok {
+		types := make([]string, 0, len(backendRequiredSettings))
+		for backendType := range backendRequiredSettings {
+			types = append(types, backendType)
+		}
+		sort.Strings(types)
+		return fmt.Errorf("unsupported type '%s', must be one of: %s", cfg.Type, strings.Join(types, ", "))
+	}
+
+	for _, key := range required {
+		if strings.TrimSpace(cfg.Config[key]) == "" {
+			return fmt.Errorf("%s backend requires config setting '%s'", cfg.Type, key)
+		}
+	}
+	for key := range cfg.Config {
+		if key == "" || strings.ContainsAny(key, " \t\n=\"") {
+			return fmt.Errorf("invalid config setting name '%s'", key)
+		}
+	}
+	return nil
+}
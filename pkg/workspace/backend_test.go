@@ -0,0 +1,59 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateBackendConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		backend BackendConfig
+		wantErr bool
+	}{
+		{"s3", BackendConfig{Type: BackendS3, Config: map[string]string{"bucket": "state", "region": "eu-west-1"}}, false},
+		{"gcs", BackendConfig{Type: BackendGCS, Config: map[string]string{"bucket": "state"}}, false},
+		{"spaces", BackendConfig{Type: BackendSpaces, Config: map[string]string{"bucket": "state", "region": "fra1"}}, false},
+		{"consul without settings", BackendConfig{Type: BackendConsul}, false},
+		{"unknown type", BackendConfig{Type: "azurerm"}, true},
+		{"s3 without region", BackendConfig{Type: BackendS3, Config: map[string]string{"bucket": "state"}}, true},
+		{"invalid setting name", BackendConfig{Type: BackendGCS, Config: map[string]string{"bucket": "state", "a b": "c"}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Enabled: true, DeploySchedule: "0 9 * * *", Backend: &tt.backend}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRemoteBackendStatusReadsPulledState(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+
+	workingDir := filepath.Join(stateDir, "deployments", "web")
+	if err := os.MkdirAll(workingDir, 0755); err != nil {
+		t.Fatalf("Failed to create working dir: %v", err)
+	}
+	// Local state left from before the backend was configured no longer counts
+	if err := os.WriteFile(filepath.Join(workingDir, "terraform.tfstate"), []byte(`{"resources":[{},{}]}`), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	ws := &Workspace{Name: "web", Config: Config{Backend: &BackendConfig{Type: BackendConsul}}}
+	if status := ws.GetDeploymentStatus(); status != "destroyed" {
+		t.Errorf("Expected destroyed before the remote state is pulled, got %s", status)
+	}
+
+	if err := os.WriteFile(filepath.Join(workingDir, RemoteStateFileName), []byte(`{"resources":[{}]}`), 0600); err != nil {
+		t.Fatalf("Failed to write remote state copy: %v", err)
+	}
+	if count, err := ws.GetStateResourceCount(); err != nil || count != 1 {
+		t.Errorf("Expected 1 resource from the remote state copy, got %d, %v", count, err)
+	}
+}
@@ -2,12 +2,19 @@ package workspace
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/tabwriter"
 	"time"
+
+	"provisioner/pkg/configfile"
+	"provisioner/pkg/gitops"
+	"provisioner/pkg/logging"
+	"provisioner/pkg/output"
 )
 
 func RunAddCommand(args []string) error {
@@ -15,6 +22,10 @@ func RunAddCommand(args []string) error {
 		return fmt.Errorf("workspace add requires NAME argument")
 	}
 
+	if err := gitops.CheckEditable(); err != nil {
+		return err
+	}
+
 	name := args[0]
 	var template, description, deploySchedule, destroySchedule string
 	enabled := true
@@ -69,7 +80,182 @@ func RunAddCommand(args []string) error {
 	return nil
 }
 
+// RunImportCommand creates a workspace from an existing OpenTofu project:
+// NAME --from-dir DIR [--move-state] and the schedule options of add
+func RunImportCommand(args []string) error {
+	if len(args) < 1 || strings.HasPrefix(args[0], "--") {
+		return fmt.Errorf("workspace import requires NAME argument")
+	}
+
+	if err := gitops.CheckEditable(); err != nil {
+		return err
+	}
+
+	name := args[0]
+	opts := ImportOptions{Enabled: true}
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "--from-dir=") {
+			opts.FromDir = strings.TrimPrefix(arg, "--from-dir=")
+		} else if arg == "--from-dir" && i+1 < len(args) {
+			opts.FromDir = args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--description=") {
+			opts.Description = strings.TrimPrefix(arg, "--description=")
+		} else if arg == "--description" && i+1 < len(args) {
+			opts.Description = args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--deploy-schedule=") {
+			opts.DeploySchedule = strings.TrimPrefix(arg, "--deploy-schedule=")
+		} else if arg == "--deploy-schedule" && i+1 < len(args) {
+			opts.DeploySchedule = args[i+1]
+			i++
+		} else if strings.HasPrefix(arg, "--destroy-schedule=") {
+			opts.DestroySchedule = strings.TrimPrefix(arg, "--destroy-schedule=")
+		} else if arg == "--destroy-schedule" && i+1 < len(args) {
+			opts.DestroySchedule = args[i+1]
+			i++
+		} else if arg == "--move-state" {
+			opts.MoveState = true
+		} else if arg == "--disabled" {
+			opts.Enabled = false
+		} else {
+			return fmt.Errorf("unknown option '%s'", arg)
+		}
+	}
+	if opts.FromDir == "" {
+		return fmt.Errorf("workspace import requires --from-dir DIR")
+	}
+
+	result, err := ImportWorkspace(name, opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Workspace '%s' imported from %s\n", name, opts.FromDir)
+	fmt.Printf("Copied %d files\n", len(result.Files))
+
+	if len(result.Variables) > 0 {
+		fmt.Println("Variables:")
+		var missing []string
+		for _, variable := range result.Variables {
+			switch {
+			case variable.Source != "" && variable.Sensitive:
+				fmt.Printf("  %s: secret from %s, set as workspace variable\n", variable.Name, variable.Source)
+			case variable.Source != "":
+				fmt.Printf("  %s: from %s, in config.json\n", variable.Name, variable.Source)
+			case variable.Default:
+				fmt.Printf("  %s: default\n", variable.Name)
+			default:
+				fmt.Printf("  %s: required, no value found\n", variable.Name)
+				missing = append(missing, variable.Name)
+			}
+		}
+		for _, variable := range missing {
+			fmt.Printf("Set %s before deploying: workspacectl vars set %s %s=VALUE\n", variable, name, variable)
+		}
+	}
+
+	switch {
+	case result.StateFile != "":
+		verb := "Copied"
+		if opts.MoveState {
+			verb = "Moved"
+		}
+		fmt.Printf("%s state with %d resources to %s\n", verb, result.Resources, result.StateFile)
+	case result.Backend != "":
+		fmt.Printf("State stays in the project's %s backend\n", result.Backend)
+	default:
+		fmt.Println("No terraform.tfstate found, the first deploy creates the resources")
+	}
+
+	if opts.DeploySchedule == "" {
+		fmt.Printf("Add a schedule before the daemon loads it: workspacectl update %s --deploy-schedule \"CRON\"\n", name)
+	}
+	return nil
+}
+
+// showOutput is a workspace as printed by show --output json and yaml
+type showOutput struct {
+	Name             string   `json:"name"`
+	Enabled          bool     `json:"enabled"`
+	Description      string   `json:"description,omitempty"`
+	Path             string   `json:"path"`
+	Template         string   `json:"template,omitempty"`
+	Source           string   `json:"source"` // "template", "local" or "local_override" (local .tf files replacing the template)
+	DeploySchedules  []string `json:"deploy_schedules"`
+	DestroySchedules []string `json:"destroy_schedules"`
+	DependsOn        []string `json:"depends_on"`
+	OpenTofuConfig   string   `json:"opentofu_config"`
+	OpenTofuFiles    []string `json:"opentofu_files"` // .tf and .tftpl files of the configuration, including modules
+	OpenTofuMissing  bool     `json:"opentofu_config_missing"`
+	TofuVersion      string   `json:"tofu_version,omitempty"` // Pinned OpenTofu version, empty for the daemon default
+	Inherited        []string `json:"inherited"`              // Fields taken from the _defaults config
+	Config           Config   `json:"config"`                 // Effective config, the config file merged with the defaults, secrets masked
+}
+
+// maskedConfig returns a copy of a config for display, with webhook secrets and the values of
+// variables, environment variables and backend settings whose names suggest secrets masked.
+// Secret references are shown as they are.
+func maskedConfig(config Config) Config {
+	maskMap := func(values map[string]interface{}) map[string]interface{} {
+		if values == nil {
+			return nil
+		}
+		masked := make(map[string]interface{}, len(values))
+		for key, value := range values {
+			if IsSecretVar(key, nil) {
+				value = MaskValue(formatConfigVar(value))
+			}
+			masked[key] = value
+		}
+		return masked
+	}
+
+	config.Variables = maskMap(config.Variables)
+	if config.ModeVariables != nil {
+		modeVariables := make(map[string]map[string]interface{}, len(config.ModeVariables))
+		for mode, values := range config.ModeVariables {
+			modeVariables[mode] = maskMap(values)
+		}
+		config.ModeVariables = modeVariables
+	}
+	if config.Environment != nil {
+		environment := make(map[string]string, len(config.Environment))
+		for key, value := range config.Environment {
+			if IsSecretVar(key, nil) && !IsSecretRef(value) {
+				value = MaskValue(value)
+			}
+			environment[key] = value
+		}
+		config.Environment = environment
+	}
+	if config.Webhooks != nil {
+		webhooks := append([]WebhookConfig(nil), config.Webhooks...)
+		for i := range webhooks {
+			webhooks[i].Secret = MaskValue(webhooks[i].Secret)
+		}
+		config.Webhooks = webhooks
+	}
+	if config.Backend != nil {
+		backend := *config.Backend
+		backend.Config = make(map[string]string, len(config.Backend.Config))
+		for key, value := range config.Backend.Config {
+			if IsSecretVar(key, nil) {
+				value = MaskValue(value)
+			}
+			backend.Config[key] = value
+		}
+		config.Backend = &backend
+	}
+	return config
+}
+
 func RunShowCommand(args []string) error {
+	format, args, err := output.ParseArgs(args)
+	if err != nil {
+		return err
+	}
 	if len(args) != 1 {
 		return fmt.Errorf("workspace show requires exactly one NAME argument")
 	}
@@ -83,9 +269,8 @@ func RunShowCommand(args []string) error {
 		return fmt.Errorf("workspace '%s' does not exist", name)
 	}
 
-	// Load workspace config
-	configPath := filepath.Join(workspacePath, "config.json")
-	config, err := loadConfig(configPath)
+	// Load workspace config with the defaults it inherits
+	config, inherited, err := loadEffectiveConfig(workspacePath)
 	if err != nil {
 		return fmt.Errorf("failed to load workspace config: %w", err)
 	}
@@ -97,6 +282,35 @@ func RunShowCommand(args []string) error {
 		Path:   workspacePath,
 	}
 
+	if format.Structured() {
+		source := "local"
+		if config.Template != "" && workspace.IsUsingTemplate() {
+			source = "template"
+		} else if config.Template != "" {
+			source = "local_override"
+		}
+		deploySchedules, _ := config.GetDeploySchedules()
+		destroySchedules, _ := config.GetDestroySchedules()
+		tfFiles, _ := ListTFFiles(workspace.GetTFDir())
+		return output.Print(format, showOutput{
+			Name:             name,
+			Enabled:          config.Enabled,
+			Description:      config.Description,
+			Path:             workspacePath,
+			Template:         config.Template,
+			Source:           source,
+			DeploySchedules:  append([]string{}, deploySchedules...),
+			DestroySchedules: append([]string{}, destroySchedules...),
+			DependsOn:        append([]string{}, config.DependsOn...),
+			OpenTofuConfig:   workspace.GetTFDir(),
+			OpenTofuFiles:    append([]string{}, tfFiles...),
+			OpenTofuMissing:  !workspace.HasTFConfig(),
+			TofuVersion:      config.TofuVersion,
+			Inherited:        append([]string{}, inherited...),
+			Config:           maskedConfig(config),
+		})
+	}
+
 	// Show basic info
 	fmt.Printf("Name:        %s\n", name)
 	fmt.Printf("Enabled:     %t\n", config.Enabled)
@@ -111,10 +325,10 @@ func RunShowCommand(args []string) error {
 		if workspace.IsUsingTemplate() {
 			fmt.Printf("Source:      Template-based\n")
 		} else {
-			fmt.Printf("Source:      Local main.tf (template overridden)\n")
+			fmt.Printf("Source:      Local .tf files (template overridden)\n")
 		}
 	} else {
-		fmt.Printf("Source:      Local main.tf\n")
+		fmt.Printf("Source:      Local .tf files\n")
 	}
 
 	// Show schedules
@@ -135,13 +349,39 @@ func RunShowCommand(args []string) error {
 	} else {
 		fmt.Printf("Destroy Schedule: %s\n", strings.Join(destroySchedules, ", "))
 	}
+	if len(config.DependsOn) > 0 {
+		fmt.Printf("Depends On:  %s\n", strings.Join(config.DependsOn, ", "))
+	}
+	if len(config.Labels) > 0 {
+		fmt.Printf("Labels:      %s\n", FormatLabels(config.Labels))
+	}
+	if config.Timezone != "" {
+		fmt.Printf("Timezone:    %s\n", config.Timezone)
+	}
+	if len(inherited) > 0 {
+		fmt.Printf("Inherited:   %s (from %s)\n", strings.Join(inherited, ", "), DefaultsDir)
+	}
 
 	// Show OpenTofu file status
-	mainTFPath := workspace.GetMainTFPath()
-	if _, err := os.Stat(mainTFPath); err == nil {
-		fmt.Printf("OpenTofu Config: %s\n", mainTFPath)
+	tfDir := workspace.GetTFDir()
+	if workspace.HasTFConfig() {
+		tfFiles, _ := ListTFFiles(tfDir)
+		fmt.Printf("OpenTofu Config: %s (%d files)\n", tfDir, len(tfFiles))
+		for _, file := range tfFiles {
+			fmt.Printf("  %s\n", file)
+		}
 	} else {
-		fmt.Printf("OpenTofu Config: Missing (%s)\n", mainTFPath)
+		fmt.Printf("OpenTofu Config: Missing (no .tf files in %s)\n", tfDir)
+	}
+	if config.TofuVersion != "" {
+		fmt.Printf("OpenTofu Version: %s\n", config.TofuVersion)
+	}
+
+	// Show the merged config when defaults apply, as the daemon sees it
+	if len(inherited) > 0 {
+		if data, err := json.MarshalIndent(maskedConfig(config), "  ", "  "); err == nil {
+			fmt.Printf("\nEffective Config:\n  %s\n", data)
+		}
 	}
 
 	// Show current deployment status if possible by reading state directly
@@ -167,10 +407,10 @@ func RunShowCommand(args []string) error {
 				fmt.Printf("\nCurrent Status:\n")
 				fmt.Printf("  State:       %s\n", workspaceState.Status)
 				if workspaceState.LastDeployed != nil {
-					fmt.Printf("  Last Deploy: %s\n", workspaceState.LastDeployed.Format(time.RFC3339))
+					fmt.Printf("  Last Deploy: %s\n", logging.FormatTime(*workspaceState.LastDeployed))
 				}
 				if workspaceState.LastDestroyed != nil {
-					fmt.Printf("  Last Destroy: %s\n", workspaceState.LastDestroyed.Format(time.RFC3339))
+					fmt.Printf("  Last Destroy: %s\n", logging.FormatTime(*workspaceState.LastDestroyed))
 				}
 				if workspaceState.LastDeployError != "" {
 					fmt.Printf("  Deploy Error: %s\n", workspaceState.LastDeployError)
@@ -190,6 +430,10 @@ func RunUpdateCommand(args []string) error {
 		return fmt.Errorf("workspace update requires NAME argument")
 	}
 
+	if err := gitops.CheckEditable(); err != nil {
+		return err
+	}
+
 	name := args[0]
 	var template, description, deploySchedule, destroySchedule string
 	var enabled *bool
@@ -248,6 +492,10 @@ func RunRemoveCommand(args []string) error {
 		return fmt.Errorf("workspace remove requires NAME argument")
 	}
 
+	if err := gitops.CheckEditable(); err != nil {
+		return err
+	}
+
 	name := args[0]
 	force := false
 
@@ -301,25 +549,41 @@ func RunRemoveCommand(args []string) error {
 	return nil
 }
 
-func RunValidateCommand(args []string) error {
+// RunValidateCommand validates workspace NAME or --all workspaces, also with check if given.
+// Unknown config fields, e.g. misspelt ones, are warnings, or errors with strict.
+func RunValidateCommand(args []string, check ConfigChecker, strict bool) error {
 	if len(args) == 0 {
 		return fmt.Errorf("workspace validate requires NAME or --all argument")
 	}
 
+	workspacesDir := getDefaultWorkspacesDir()
 	if args[0] == "--all" {
-		workspacesDir := getDefaultWorkspacesDir()
-		workspaces, err := LoadWorkspaces(workspacesDir)
+		// Every directory with a config file, including those LoadWorkspaces skips as invalid
+		entries, err := os.ReadDir(workspacesDir)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to read workspaces directory: %w", err)
 		}
 
 		hasErrors := false
-		for _, workspace := range workspaces {
-			if err := ValidateWorkspace(workspace.Name); err != nil {
-				fmt.Printf("✗ %s: %v\n", workspace.Name, err)
+		if err := checkDefaults(workspacesDir, strict); err != nil {
+			fmt.Printf("✗ %s: %v\n", DefaultsDir, err)
+			hasErrors = true
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() || entry.Name() == DefaultsDir {
+				continue
+			}
+			if configPath, err := findConfigFile(filepath.Join(workspacesDir, entry.Name())); err == nil {
+				if _, err := os.Stat(configPath); os.IsNotExist(err) {
+					continue
+				}
+			}
+
+			if err := validateWorkspace(entry.Name(), check, strict); err != nil {
+				fmt.Printf("✗ %s: %v\n", entry.Name(), err)
 				hasErrors = true
 			} else {
-				fmt.Printf("✓ %s: valid\n", workspace.Name)
+				fmt.Printf("✓ %s: valid\n", entry.Name())
 			}
 		}
 
@@ -330,7 +594,7 @@ func RunValidateCommand(args []string) error {
 	}
 
 	name := args[0]
-	if err := ValidateWorkspace(name); err != nil {
+	if err := validateWorkspace(name, check, strict); err != nil {
 		return fmt.Errorf("workspace '%s' validation failed: %v", name, err)
 	}
 
@@ -338,74 +602,347 @@ func RunValidateCommand(args []string) error {
 	return nil
 }
 
-func RunListCommand(args []string) error {
-	detailed := false
+// validateWorkspace checks a workspace's config file against the schema before validating it
+func validateWorkspace(name string, check ConfigChecker, strict bool) error {
+	wsPath := filepath.Join(getDefaultWorkspacesDir(), name)
+	if _, err := os.Stat(wsPath); err == nil {
+		configPath, err := findConfigFile(wsPath)
+		if err != nil {
+			return err
+		}
+		if err := CheckConfigFile(configPath, &Config{}, strict); err != nil {
+			return err
+		}
+	}
+	return ValidateWorkspace(name, check)
+}
 
-	// Parse flags
+// checkDefaults checks the config file of the workspaces' defaults, if there is one
+func checkDefaults(workspacesDir string, strict bool) error {
+	configPath, err := findConfigFile(filepath.Join(workspacesDir, DefaultsDir))
+	if err != nil {
+		return err
+	}
+	return CheckConfigFile(configPath, &Config{}, strict)
+}
+
+// CheckConfigFile checks a config file against the schema of v, see configfile.Check. Fields of
+// the wrong type are errors; unknown fields are printed as warnings, or errors with strict.
+// A file that doesn't exist is not checked.
+func CheckConfigFile(path string, v interface{}, strict bool) error {
+	problems, err := configfile.CheckFile(path, v)
+	if err != nil {
+		return err
+	}
+
+	var errs []string
+	for _, problem := range problems {
+		problem.File = configRelativePath(path)
+		if problem.Unknown && !strict {
+			fmt.Printf("Warning: %s\n", problem)
+			continue
+		}
+		errs = append(errs, problem.String())
+	}
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// configRelativePath returns a path in the config directory relative to it, as in
+// workspaces/my-app/config.json
+func configRelativePath(path string) string {
+	if rel, err := filepath.Rel(filepath.Dir(getDefaultWorkspacesDir()), path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// RunGraphCommand prints the workspace dependency graph in deploy order, or as Graphviz
+// DOT with --dot, edges pointing from each dependency to the workspaces deployed after it
+func RunGraphCommand(args []string) error {
+	dot := false
 	for _, arg := range args {
-		if arg == "--detailed" {
-			detailed = true
+		if arg != "--dot" {
+			return fmt.Errorf("unknown graph option '%s'", arg)
 		}
+		dot = true
 	}
 
-	workspacesDir := getDefaultWorkspacesDir()
-	workspaces, err := LoadWorkspaces(workspacesDir)
+	workspaces, err := LoadWorkspaces(getDefaultWorkspacesDir())
 	if err != nil {
 		return err
 	}
+	ordered, err := DeployOrder(workspaces)
+	if err != nil {
+		return err
+	}
+
+	if dot {
+		fmt.Println("digraph workspaces {")
+		fmt.Println("  rankdir=LR;")
+		for _, ws := range ordered {
+			fmt.Printf("  %q;\n", ws.Name)
+			for _, depName := range ws.Config.DependsOn {
+				fmt.Printf("  %q -> %q;\n", depName, ws.Name)
+			}
+		}
+		fmt.Println("}")
+		return nil
+	}
 
-	if len(workspaces) == 0 {
-		fmt.Println("No workspaces found")
+	if len(ordered) == 0 {
+		fmt.Println("No workspaces configured")
 		return nil
 	}
 
+	fmt.Println("Deploy order (destroys run in reverse):")
 	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-
-	if detailed {
-		if _, err := fmt.Fprintln(w, "NAME\tENABLED\tSOURCE\tTEMPLATE\tDEPLOY SCHEDULE\tDESTROY SCHEDULE\tDESCRIPTION"); err != nil {
+	if _, err := fmt.Fprintln(w, "#\tWORKSPACE\tSTATUS\tDEPENDS ON"); err != nil {
+		return err
+	}
+	for i, ws := range ordered {
+		status := ws.GetDeploymentStatus()
+		if !ws.Config.Enabled {
+			status += " (disabled)"
+		}
+		dependsOn := "-"
+		if len(ws.Config.DependsOn) > 0 {
+			dependsOn = strings.Join(ws.Config.DependsOn, ", ")
+		}
+		if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, ws.Name, status, dependsOn); err != nil {
 			return err
 		}
-		for _, workspace := range workspaces {
-			source := "Local"
-			if workspace.IsUsingTemplate() {
-				source = "Template"
+	}
+	return w.Flush()
+}
+
+func RunVarsCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("workspace vars requires SUBCOMMAND and NAME arguments (set, get, list, unset)")
+	}
+
+	subcommand := args[0]
+	name := args[1]
+
+	// Check if workspace exists
+	workspacePath := filepath.Join(getDefaultWorkspacesDir(), name)
+	if _, err := os.Stat(workspacePath); os.IsNotExist(err) {
+		return fmt.Errorf("workspace '%s' does not exist", name)
+	}
+
+	stateDir := getStateDir()
+
+	switch subcommand {
+	case "set":
+		secret := false
+		var assignments []string
+		for _, arg := range args[2:] {
+			if arg == "--secret" {
+				secret = true
+			} else {
+				assignments = append(assignments, arg)
 			}
+		}
 
-			deploySchedules, _ := workspace.Config.GetDeploySchedules()
-			destroySchedules, _ := workspace.Config.GetDestroySchedules()
-
-			if _, err := fmt.Fprintf(w, "%s\t%t\t%s\t%s\t%s\t%s\t%s\n",
-				workspace.Name,
-				workspace.Config.Enabled,
-				source,
-				workspace.Config.Template,
-				strings.Join(deploySchedules, ","),
-				strings.Join(destroySchedules, ","),
-				workspace.Config.Description,
-			); err != nil {
+		if len(assignments) == 0 {
+			return fmt.Errorf("workspace vars set requires at least one KEY=VALUE argument")
+		}
+
+		for _, assignment := range assignments {
+			key, value, found := strings.Cut(assignment, "=")
+			if !found {
+				return fmt.Errorf("invalid assignment '%s', expected KEY=VALUE", assignment)
+			}
+
+			if err := SetVar(stateDir, name, key, value, secret); err != nil {
 				return err
 			}
+			fmt.Printf("Set variable '%s' for workspace '%s'\n", key, name)
 		}
-	} else {
-		if _, err := fmt.Fprintln(w, "NAME\tENABLED\tSOURCE\tDESCRIPTION"); err != nil {
+		return nil
+
+	case "unset":
+		if len(args) < 3 {
+			return fmt.Errorf("workspace vars unset requires at least one KEY argument")
+		}
+
+		for _, key := range args[2:] {
+			if err := UnsetVar(stateDir, name, key); err != nil {
+				return err
+			}
+			fmt.Printf("Unset variable '%s' for workspace '%s'\n", key, name)
+		}
+		return nil
+
+	case "get":
+		if len(args) != 3 {
+			return fmt.Errorf("workspace vars get requires exactly one KEY argument")
+		}
+
+		vars, err := LoadVars(stateDir, name)
+		if err != nil {
+			return err
+		}
+		if value, exists := vars[args[2]]; exists {
+			fmt.Println(value)
+			return nil
+		}
+
+		config, _, err := loadEffectiveConfig(workspacePath)
+		if err != nil {
+			return err
+		}
+		if value, exists := config.Variables[args[2]]; exists {
+			fmt.Println(formatConfigVar(value))
+			return nil
+		}
+		return fmt.Errorf("variable '%s' is not set for workspace '%s'", args[2], name)
+
+	case "list":
+		showSecrets := false
+		for _, arg := range args[2:] {
+			if arg == "--show-secrets" {
+				showSecrets = true
+			}
+		}
+
+		vars, err := LoadVars(stateDir, name)
+		if err != nil {
 			return err
 		}
-		for _, workspace := range workspaces {
-			source := "Local"
-			if workspace.IsUsingTemplate() {
-				source = fmt.Sprintf("Template(%s)", workspace.Config.Template)
+
+		config, _, err := loadEffectiveConfig(workspacePath)
+		if err != nil {
+			return err
+		}
+
+		if len(vars) == 0 && len(config.Variables) == 0 && len(config.ModeVariables) == 0 {
+			fmt.Printf("No variables set for workspace '%s'\n", name)
+			return nil
+		}
+
+		metadata, err := LoadDeploymentMetadata(stateDir, name)
+		if err != nil {
+			return err
+		}
+
+		// Rows are listed in precedence order: config, mode overrides, then variables set with `vars set`
+		type varRow struct{ key, value, source string }
+		var rows []varRow
+		for _, key := range sortedKeys(config.Variables) {
+			rows = append(rows, varRow{key, formatConfigVar(config.Variables[key]), "config"})
+		}
+		for _, mode := range sortedKeys(config.ModeVariables) {
+			for _, key := range sortedKeys(config.ModeVariables[mode]) {
+				rows = append(rows, varRow{key, formatConfigVar(config.ModeVariables[mode][key]), "mode:" + mode})
 			}
+		}
+		for _, key := range sortedKeys(vars) {
+			rows = append(rows, varRow{key, vars[key], "set"})
+		}
 
-			if _, err := fmt.Fprintf(w, "%s\t%t\t%s\t%s\n",
-				workspace.Name,
-				workspace.Config.Enabled,
-				source,
-				workspace.Config.Description,
-			); err != nil {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if _, err := fmt.Fprintln(w, "KEY\tVALUE\tSOURCE"); err != nil {
+			return err
+		}
+		for _, row := range rows {
+			value := row.value
+			if !showSecrets && IsSecretVar(row.key, metadata.SecretVars) {
+				value = MaskValue(value)
+			}
+			if _, err := fmt.Fprintf(w, "%s\t%s\t%s\n", row.key, value, row.source); err != nil {
 				return err
 			}
 		}
+		return w.Flush()
+
+	default:
+		return fmt.Errorf("unknown vars subcommand '%s' (expected set, get, list or unset)", subcommand)
 	}
+}
 
-	return w.Flush()
+// formatConfigVar renders a config variable for display: strings as-is, anything else as JSON
+func formatConfigVar(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func RunDebugCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("workspace debug requires NAME and on, off or status arguments")
+	}
+
+	name := args[0]
+	action := args[1]
+
+	// Check if workspace exists
+	workspacePath := filepath.Join(getDefaultWorkspacesDir(), name)
+	if _, err := os.Stat(workspacePath); os.IsNotExist(err) {
+		return fmt.Errorf("workspace '%s' does not exist", name)
+	}
+
+	switch action {
+	case "on", "off":
+		if err := SetDebugOverride(getStateDir(), name, action == "on"); err != nil {
+			return err
+		}
+		fmt.Printf("Debug logging %s for workspace '%s' (applies from the next operation)\n", action, name)
+		if action == "on" {
+			fmt.Printf("Debug logs are written to %s and may contain secrets\n", debugLogDir(name))
+		}
+		return nil
+
+	case "status":
+		config, _, err := loadEffectiveConfig(workspacePath)
+		if err != nil {
+			return err
+		}
+		ws := Workspace{Name: name, Config: config, Path: workspacePath}
+
+		state := "off"
+		if ws.IsDebugLoggingEnabled() {
+			state = "on"
+		}
+		source, _ := findConfigFile(workspacePath)
+		source = filepath.Base(source)
+		if override, err := LoadDebugOverride(getStateDir(), name); err == nil && override != nil {
+			source = fmt.Sprintf("runtime override set %s", logging.FormatTime(override.UpdatedAt))
+		}
+		fmt.Printf("Debug logging: %s (%s)\n", state, source)
+
+		logs, err := ListDebugLogs(name)
+		if err != nil {
+			return err
+		}
+		if len(logs) == 0 {
+			fmt.Println("No debug logs captured")
+			return nil
+		}
+		fmt.Println("Debug logs (newest first):")
+		for _, logPath := range logs {
+			fmt.Printf("  %s\n", logPath)
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown debug action '%s' (expected on, off or status)", action)
+	}
 }
@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultRunToCompletionTimeout bounds how long a one-shot workspace may stay deployed
+const DefaultRunToCompletionTimeout = 24 * time.Hour
+
+// IsRunToCompletion returns true if the workspace is a one-shot deployment
+func (c *Config) IsRunToCompletion() bool {
+	return c.RunToCompletion != nil
+}
+
+// GetCompletionTimeout returns the run-to-completion timeout, falling back to the default
+func (c *Config) GetCompletionTimeout() (time.Duration, error) {
+	if c.RunToCompletion == nil || c.RunToCompletion.Timeout == "" {
+		return DefaultRunToCompletionTimeout, nil
+	}
+
+	timeout, err := time.ParseDuration(c.RunToCompletion.Timeout)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout '%s': %w", c.RunToCompletion.Timeout, err)
+	}
+
+	return timeout, nil
+}
+
+// validateRunToCompletionConfig validates the one-shot workspace settings
+func (c *Config) validateRunToCompletionConfig() error {
+	rtc := c.RunToCompletion
+
+	if rtc.CompletionOutput == "" && rtc.CompletionJob == "" {
+		return fmt.Errorf("must specify 'completion_output' or 'completion_job'")
+	}
+
+	timeout, err := c.GetCompletionTimeout()
+	if err != nil {
+		return err
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("timeout must be positive")
+	}
+
+	if rtc.CompletionJob != "" {
+		found := false
+		for _, jobConfig := range c.Jobs {
+			if jobConfig.Name == rtc.CompletionJob {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("completion_job '%s' is not defined in workspace jobs", rtc.CompletionJob)
+		}
+	}
+
+	return nil
+}
+
+// IsCompletionValue reports whether an output value signals that a one-shot run has finished
+func IsCompletionValue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case float64:
+		return v != 0
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "yes", "1", "done", "complete", "completed":
+			return true
+		}
+	}
+	return false
+}
@@ -5,20 +5,58 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+
+	"provisioner/pkg/bytesize"
+	"provisioner/pkg/configfile"
+	"provisioner/pkg/cron"
+	"provisioner/pkg/holidays"
 )
 
 type Config struct {
-	Enabled         bool                   `json:"enabled"`
-	Template        string                 `json:"template,omitempty"`
-	DeploySchedule  interface{}            `json:"deploy_schedule"`
-	DestroySchedule interface{}            `json:"destroy_schedule"`
-	ModeSchedules   map[string]interface{} `json:"mode_schedules,omitempty"`
-	Jobs            []JobConfig            `json:"jobs,omitempty"`
-	Description     string                 `json:"description"`
-	CustomDeploy    *CustomDeployConfig    `json:"custom_deploy,omitempty"`
-	CustomDestroy   *CustomDestroyConfig   `json:"custom_destroy,omitempty"`
+	Enabled             bool                              `json:"enabled"`
+	Template            string                            `json:"template,omitempty"`
+	DeploySchedule      interface{}                       `json:"deploy_schedule"`
+	DestroySchedule     interface{}                       `json:"destroy_schedule"`
+	ModeSchedules       map[string]interface{}            `json:"mode_schedules,omitempty"`
+	Jobs                []JobConfig                       `json:"jobs,omitempty"`
+	Description         string                            `json:"description"`
+	CustomDeploy        *CustomDeployConfig               `json:"custom_deploy,omitempty"`
+	CustomDestroy       *CustomDestroyConfig              `json:"custom_destroy,omitempty"`
+	RunToCompletion     *RunToCompletionConfig            `json:"run_to_completion,omitempty"`
+	RedactPatterns      []string                          `json:"redact_patterns,omitempty"`      // Extra patterns masked in this workspace's logs and status
+	Webhooks            []WebhookConfig                   `json:"webhooks,omitempty"`             // Incoming HTTP triggers for this workspace
+	Timezone            string                            `json:"timezone,omitempty"`             // IANA zone schedules are evaluated in (default: daemon local time)
+	DebugLogging        *DebugLoggingConfig               `json:"debug_logging,omitempty"`        // Capture TF_LOG=DEBUG output to separate debug logs
+	Retry               *RetryConfig                      `json:"retry,omitempty"`                // Retry failed scheduled deploys with backoff
+	Variables           map[string]interface{}            `json:"variables,omitempty"`            // OpenTofu variables written to terraform.tfvars.json
+	ModeVariables       map[string]map[string]interface{} `json:"mode_variables,omitempty"`       // Per-mode overrides of variables
+	MaxLifetime         string                            `json:"max_lifetime,omitempty"`         // Longest a deployment may live regardless of destroy schedules
+	MaxLifetimeAction   string                            `json:"max_lifetime_action,omitempty"`  // "destroy" (default) or "alert" once max_lifetime is exceeded
+	TTL                 string                            `json:"ttl,omitempty"`                  // Destroy the workspace this long after its last deploy, e.g. "4h"
+	Throttle            []string                          `json:"throttle,omitempty"`             // Throttle buckets (provisioner.json) limiting concurrent operations
+	Tier                string                            `json:"tier,omitempty"`                 // dev, staging or prod; applies the tier's defaults from provisioner.json
+	Labels              map[string]string                 `json:"labels,omitempty"`               // Key/value pairs label policies in provisioner.json select workspaces by
+	Protected           *bool                             `json:"protected,omitempty"`            // Never destroy on schedule; manual destroys need --force
+	RequireApproval     *bool                             `json:"require_approval,omitempty"`     // Hold scheduled deploys and destroys until an operator approves them
+	ApprovalTimeout     string                            `json:"approval_timeout,omitempty"`     // Drop approval requests nobody approved within this long, e.g. "12h"
+	NotificationChannel string                            `json:"notification_channel,omitempty"` // Only notification destinations of this channel receive this workspace's events
+	SLO                 *SLOConfig                        `json:"slo,omitempty"`                  // Success-rate objectives of deploys and jobs
+	Lint                *LintConfig                       `json:"lint,omitempty"`                 // Lint the OpenTofu configuration before deploys
+	DependsOn           []string                          `json:"depends_on,omitempty"`           // Workspaces deployed before and destroyed after this one
+	StateBackup         *StateBackupConfig                `json:"state_backup,omitempty"`         // Retention of state backups taken before applies and destroys
+	Backend             *BackendConfig                    `json:"backend,omitempty"`              // Remote backend holding the OpenTofu state (default: local)
+	MaxMonthlyCost      float64                           `json:"max_monthly_cost,omitempty"`     // Block deploys whose estimated monthly cost exceeds this
+	IdleCheck           *IdleCheckConfig                  `json:"idle_check,omitempty"`           // Destroy or hibernate the workspace once it is idle
+	TofuVersion         string                            `json:"tofu_version,omitempty"`         // OpenTofu version the workspace runs with (default: tofu_version in provisioner.json)
+	Agent               string                            `json:"agent,omitempty"`                // Remote agent running deploys and destroys (default: the daemon host)
+	DeployTimeout       string                            `json:"deploy_timeout,omitempty"`       // Stop deploys running longer than this (default: deploy_timeout in provisioner.json, 30m)
+	DestroyTimeout      string                            `json:"destroy_timeout,omitempty"`      // Stop destroys running longer than this (default: destroy_timeout in provisioner.json, 30m)
+	Environment         map[string]string                 `json:"environment,omitempty"`          // Environment variables of the workspace's tofu commands, values may be env:NAME or file:PATH secret references
+	AlwaysInit          bool                              `json:"always_init,omitempty"`          // Run "tofu init" before every operation, even if nothing it depends on changed
+	Hooks               *HooksConfig                      `json:"hooks,omitempty"`                // Commands run around deploys, e.g. post-deploy smoke tests
 }
 
 // CustomDeployConfig allows overriding default OpenTofu deployment commands
@@ -34,137 +72,155 @@ type CustomDestroyConfig struct {
 	DestroyCommand string `json:"destroy_command,omitempty"` // Override "tofu destroy -auto-approve"
 }
 
+// RunToCompletionConfig marks a one-shot workspace that is destroyed automatically once its work is done
+type RunToCompletionConfig struct {
+	CompletionOutput string `json:"completion_output,omitempty"` // OpenTofu output that becomes true when done
+	CompletionJob    string `json:"completion_job,omitempty"`    // Workspace job whose success signals completion
+	Timeout          string `json:"timeout,omitempty"`           // Destroy anyway after this duration (default 24h)
+}
+
 // JobConfig represents a job configuration in the workspace
 // This avoids circular imports by not depending on the job package
 type JobConfig struct {
-	Name        string            `json:"name"`
-	Type        string            `json:"type"`     // "script", "command", "template"
-	Schedule    interface{}       `json:"schedule"` // String or []string for CRON expressions
-	Script      string            `json:"script,omitempty"`
-	Command     string            `json:"command,omitempty"`
-	Template    string            `json:"template,omitempty"`
-	Environment map[string]string `json:"environment,omitempty"`
-	WorkingDir  string            `json:"working_dir,omitempty"`
-	Timeout     string            `json:"timeout,omitempty"`
-	Enabled     bool              `json:"enabled"`
-	Description string            `json:"description,omitempty"`
-	DependsOn   []string          `json:"depends_on,omitempty"` // Job dependencies
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`     // "script", "command", "template"
+	Schedule     interface{}       `json:"schedule"` // String or []string for CRON expressions
+	Script       string            `json:"script,omitempty"`
+	Command      string            `json:"command,omitempty"`
+	Template     string            `json:"template,omitempty"`
+	Environment  map[string]string `json:"environment,omitempty"`
+	WorkingDir   string            `json:"working_dir,omitempty"`
+	Timeout      string            `json:"timeout,omitempty"`
+	Enabled      bool              `json:"enabled"`
+	Description  string            `json:"description,omitempty"`
+	DependsOn    []string          `json:"depends_on,omitempty"`     // Job dependencies
+	Throttle     []string          `json:"throttle,omitempty"`       // Throttle buckets (provisioner.json) limiting concurrent runs
+	Jitter       string            `json:"jitter,omitempty"`         // Random delay of scheduled starts up to this duration
+	SpreadByName bool              `json:"spread_by_name,omitempty"` // Delay scheduled starts by a stable offset derived from the job name
+	Retries      int               `json:"retries,omitempty"`        // Attempts after a failed one before the run fails
+	RetryDelay   string            `json:"retry_delay,omitempty"`    // Wait between attempts (default 30s)
+	OnFailure    string            `json:"on_failure,omitempty"`     // continue, abort-dependents or notify (default)
+
+	// Lifecycle of a template job's deployment
+	DestroyAfter    string      `json:"destroy_after,omitempty"`    // Destroy the deployment this long after a deploy
+	DestroySchedule interface{} `json:"destroy_schedule,omitempty"` // String or []string of CRON expressions or events destroying the deployment
+
+	// Resource limits of a script or command job's processes
+	MaxCPU    float64 `json:"max_cpu,omitempty"`    // CPU cores (e.g., 0.5)
+	MaxMemory string  `json:"max_memory,omitempty"` // Memory (e.g., "512M", "2G")
+	Nice      int     `json:"nice,omitempty"`       // Scheduling priority from -20 to 19
+
+	// Files collected from the working directory after each run
+	Artifacts         interface{} `json:"artifacts,omitempty"`          // String or []string of glob patterns
+	ArtifactRetention string      `json:"artifact_retention,omitempty"` // Remove a run's artifacts after this long
 }
 
 type Workspace struct {
-	Name   string // Derived from folder name
-	Config Config
-	Path   string
+	Name         string // Derived from folder name
+	Config       Config
+	Path         string
+	Revision     int  // Deployment history revision to redeploy instead of the current files (rollback)
+	IgnoreBudget bool // Deploy even if the estimated monthly cost exceeds max_monthly_cost
 }
 
+// LoadWorkspaces loads and validates the workspaces in workspacesDir. Directories are loaded by
+// a pool of workers, and configs unchanged since the previous load are taken from a cache instead
+// of being parsed again.
 func LoadWorkspaces(workspacesDir string) ([]Workspace, error) {
-	var workspaces []Workspace
-
 	entries, err := os.ReadDir(workspacesDir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read workspaces directory: %w", err)
 	}
 
+	var names []string
 	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
+		if entry.IsDir() && entry.Name() != DefaultsDir {
+			names = append(names, entry.Name())
 		}
+	}
 
-		wsPath := filepath.Join(workspacesDir, entry.Name())
-		configPath := filepath.Join(wsPath, "config.json")
+	defaults, err := loadDefaults(workspacesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace defaults: %w", err)
+	}
 
-		// Check if config.json exists
-		if _, err := os.Stat(configPath); os.IsNotExist(err) {
-			continue
-		}
+	results := loadWorkspaceDirs(workspacesDir, names, defaults)
+	parsedConfigs.retain(workspacesDir, names)
 
-		config, err := loadConfig(configPath)
-		if err != nil {
-			fmt.Printf("Warning: failed to load config for %s: %v\n", entry.Name(), err)
-			continue
+	// Report in directory order, as a sequential load would
+	var workspaces []Workspace
+	for i, result := range results {
+		if result.warning != "" {
+			fmt.Printf("Warning: %s\n", result.warning)
 		}
-
-		// Create workspace
-		ws := Workspace{
-			Name:   entry.Name(), // Use folder name as workspace name
-			Config: config,
-			Path:   wsPath,
+		if result.err != nil {
+			return nil, fmt.Errorf("workspace %s has invalid job dependencies: %w", names[i], result.err)
 		}
-
-		// Validate that the workspace has either a local main.tf or a valid template
-		if !ws.HasMainTF() {
-			if ws.Config.Template == "" {
-				fmt.Printf("Warning: workspace %s has no main.tf and no template specified\n", entry.Name())
-			} else {
-				fmt.Printf("Warning: workspace %s references template '%s' but template not found\n", entry.Name(), ws.Config.Template)
-			}
-			continue
-		}
-
-		// Validate job dependencies for circular dependencies
-		if err := ValidateJobDependencies(ws.Config.Jobs); err != nil {
-			return nil, fmt.Errorf("workspace %s has invalid job dependencies: %w", entry.Name(), err)
+		if result.workspace != nil {
+			// Load all workspaces (enabled check will be done during scheduling)
+			workspaces = append(workspaces, *result.workspace)
 		}
+	}
 
-		// Load all workspaces (enabled check will be done during scheduling)
-		workspaces = append(workspaces, ws)
+	// Validate dependencies between workspaces
+	if err := ValidateWorkspaceDependencies(workspaces); err != nil {
+		return nil, fmt.Errorf("invalid workspace dependencies: %w", err)
 	}
 
 	return workspaces, nil
 }
 
+// configFileName is the name of a workspace's config file without its extension: config.json,
+// config.yaml or config.hcl
+const configFileName = "config"
+
+// IsConfigFile returns true if a file in a workspace directory is its config file
+func IsConfigFile(filename string) bool {
+	name, ok := configfile.TrimExtension(filename)
+	return ok && name == configFileName
+}
+
+// findConfigFile returns the path of the config file in a workspace directory, config.json if
+// there is none
+func findConfigFile(wsPath string) (string, error) {
+	return configfile.Path(wsPath, configFileName)
+}
+
 func loadConfig(configPath string) (Config, error) {
 	var config Config
 
-	data, err := os.ReadFile(configPath)
+	content, err := os.ReadFile(configPath)
 	if err != nil {
 		return config, fmt.Errorf("failed to read config file: %w", err)
 	}
+	data, err := configfile.ToJSON(configPath, content)
+	if err != nil {
+		return config, err
+	}
 
-	if err := json.Unmarshal(data, &config); err != nil {
-		return config, fmt.Errorf("failed to unmarshal config: %w", err)
+	if err := configfile.Unmarshal(configPath, content, data, &config); err != nil {
+		return config, err
 	}
 
 	return config, nil
 }
 
-func (w *Workspace) GetMainTFPath() string {
-	// Check for local main.tf first (highest priority)
-	localPath := filepath.Join(w.Path, "main.tf")
-	if _, err := os.Stat(localPath); err == nil {
-		return localPath
+// GetTFDir returns the directory holding the workspace's OpenTofu configuration: its own
+// directory if it has .tf files, otherwise its template's. Workspaces without either get their own
+// directory, for error messages.
+func (w *Workspace) GetTFDir() string {
+	if w.hasLocalTFFiles() {
+		return w.Path
 	}
-
-	// If no local main.tf and template is specified, use template path
-	if w.Config.Template != "" {
-		templatesDir := getTemplatesDir()
-		templatePath := filepath.Join(templatesDir, w.Config.Template, "main.tf")
-		if _, err := os.Stat(templatePath); err == nil {
-			return templatePath
-		}
+	if w.Config.Template != "" && HasTFFiles(w.GetTemplateDir()) {
+		return w.GetTemplateDir()
 	}
-
-	// Return local path even if it doesn't exist (for error handling)
-	return localPath
+	return w.Path
 }
 
-func (w *Workspace) HasMainTF() bool {
-	// Check for local main.tf first
-	localPath := filepath.Join(w.Path, "main.tf")
-	if _, err := os.Stat(localPath); err == nil {
-		return true
-	}
-
-	// Check for template main.tf if template is specified
-	if w.Config.Template != "" {
-		templatesDir := getTemplatesDir()
-		templatePath := filepath.Join(templatesDir, w.Config.Template, "main.tf")
-		if _, err := os.Stat(templatePath); err == nil {
-			return true
-		}
-	}
-
-	return false
+// HasTFConfig reports whether the workspace or its template has OpenTofu configuration files
+func (w *Workspace) HasTFConfig() bool {
+	return w.hasLocalTFFiles() || (w.Config.Template != "" && HasTFFiles(w.GetTemplateDir()))
 }
 
 // GetTemplateDir returns the directory path for the template if one is specified
@@ -178,7 +234,35 @@ func (w *Workspace) GetTemplateDir() string {
 
 // IsUsingTemplate returns true if the workspace is using a template
 func (w *Workspace) IsUsingTemplate() bool {
-	return w.Config.Template != "" && !w.hasLocalMainTF()
+	return w.Config.Template != "" && !w.hasLocalTFFiles()
+}
+
+// IsTemplateMissing returns true if the workspace relies on a template that is not installed
+func (w *Workspace) IsTemplateMissing() bool {
+	if !w.IsUsingTemplate() {
+		return false
+	}
+	return !HasTFFiles(w.GetTemplateDir())
+}
+
+// FindTemplateReferences returns the names of workspaces that deploy from the given template
+func FindTemplateReferences(workspacesDir, templateName string) ([]string, error) {
+	if _, err := os.Stat(workspacesDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	workspaces, err := LoadWorkspaces(workspacesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, ws := range workspaces {
+		if ws.Config.Template == templateName && !ws.hasLocalTFFiles() {
+			names = append(names, ws.Name)
+		}
+	}
+	return names, nil
 }
 
 // GetTemplateReference returns the template name if using a template
@@ -189,11 +273,10 @@ func (w *Workspace) GetTemplateReference() string {
 	return ""
 }
 
-// hasLocalMainTF checks if there's a local main.tf file
-func (w *Workspace) hasLocalMainTF() bool {
-	localPath := filepath.Join(w.Path, "main.tf")
-	_, err := os.Stat(localPath)
-	return err == nil
+// hasLocalTFFiles reports whether the workspace has its own OpenTofu configuration files, which
+// take precedence over its template
+func (w *Workspace) hasLocalTFFiles() bool {
+	return HasTFFiles(w.Path)
 }
 
 // GetDeploymentStatus returns the actual deployment status based on OpenTofu state files
@@ -218,10 +301,35 @@ func (w *Workspace) GetDeploymentStatus() string {
 	return "deployed"
 }
 
-// getStateFilePath returns the path to the terraform.tfstate file for this workspace
+// GetStateResourceCount returns the number of resources recorded in the workspace's OpenTofu state
+func (w *Workspace) GetStateResourceCount() (int, error) {
+	data, err := os.ReadFile(w.getStateFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state struct {
+		Resources []json.RawMessage `json:"resources"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return 0, fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	return len(state.Resources), nil
+}
+
+// getStateFilePath returns the path to the terraform.tfstate file for this workspace, or for
+// workspaces with a remote backend the copy of the remote state pulled after each operation
 func (w *Workspace) getStateFilePath() string {
 	stateDir := getStateDir()
 
+	if w.Config.HasRemoteBackend() {
+		return filepath.Join(stateDir, "deployments", w.Name, RemoteStateFileName)
+	}
+
 	// Try new deployment structure first
 	deploymentStateFile := filepath.Join(stateDir, "deployments", w.Name, "terraform.tfstate")
 	if _, err := os.Stat(deploymentStateFile); err == nil {
@@ -275,12 +383,53 @@ func getTemplatesDir() string {
 
 // GetDeploySchedules returns deploy schedules as a slice, handling both string and []string formats
 func (c *Config) GetDeploySchedules() ([]string, error) {
-	return normalizeScheduleField(c.DeploySchedule)
+	return normalizeSchedules(c.DeploySchedule)
 }
 
 // GetDestroySchedules returns destroy schedules as a slice, handling both string and []string formats
 func (c *Config) GetDestroySchedules() ([]string, error) {
-	return normalizeScheduleField(c.DestroySchedule)
+	return normalizeSchedules(c.DestroySchedule)
+}
+
+// GetLocation returns the timezone schedules are evaluated in, falling back to local time
+func (c *Config) GetLocation() *time.Location {
+	if c.Timezone == "" {
+		return time.Local
+	}
+	location, err := time.LoadLocation(c.Timezone)
+	if err != nil {
+		return time.Local
+	}
+	return location
+}
+
+// normalizeSchedules converts a workspace schedule field to []string like normalizeScheduleField,
+// also accepting schedule objects such as {"cron": "0 8 * * 1-5", "skip_holidays": "uk"}, alone or
+// in an array. Their expressions get a SKIP_HOLIDAYS= prefix.
+func normalizeSchedules(field interface{}) ([]string, error) {
+	switch v := field.(type) {
+	case map[string]interface{}:
+		return normalizeScheduleObject(v)
+	case []interface{}:
+		var schedules []string
+		for i, item := range v {
+			switch item := item.(type) {
+			case string:
+				schedules = append(schedules, item)
+			case map[string]interface{}:
+				expanded, err := normalizeScheduleObject(item)
+				if err != nil {
+					return nil, fmt.Errorf("schedule at index %d: %w", i, err)
+				}
+				schedules = append(schedules, expanded...)
+			default:
+				return nil, fmt.Errorf("schedule array must contain strings or schedule objects, got %T at index %d", item, i)
+			}
+		}
+		return schedules, nil
+	default:
+		return normalizeScheduleField(field)
+	}
 }
 
 // normalizeScheduleField converts interface{} schedule field to []string
@@ -314,6 +463,55 @@ func normalizeScheduleField(field interface{}) ([]string, error) {
 	}
 }
 
+// normalizeScheduleObject converts a {"cron": ..., "skip_holidays": ...} schedule object to its
+// CRON expressions, each prefixed with SKIP_HOLIDAYS= when a holiday calendar is set
+func normalizeScheduleObject(object map[string]interface{}) ([]string, error) {
+	for key := range object {
+		if key != "cron" && key != "skip_holidays" {
+			return nil, fmt.Errorf("unknown schedule object field '%s'", key)
+		}
+	}
+
+	var expressions []string
+	switch cronField := object["cron"].(type) {
+	case string:
+		expressions = []string{cronField}
+	case []interface{}:
+		for i, item := range cronField {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("schedule object cron must contain strings, got %T at index %d", item, i)
+			}
+			expressions = append(expressions, str)
+		}
+	case nil:
+		return nil, fmt.Errorf("schedule object requires a cron expression")
+	default:
+		return nil, fmt.Errorf("schedule object cron must be a string or array of strings, got %T", cronField)
+	}
+
+	calendar, ok := object["skip_holidays"].(string)
+	if !ok && object["skip_holidays"] != nil {
+		return nil, fmt.Errorf("schedule object skip_holidays must be a calendar name, got %T", object["skip_holidays"])
+	}
+	if calendar == "" {
+		return expressions, nil
+	}
+	if !holidays.NamePattern.MatchString(calendar) {
+		return nil, fmt.Errorf("invalid holiday calendar name '%s'", calendar)
+	}
+	schedules := make([]string, len(expressions))
+	for i, expr := range expressions {
+		schedules[i] = "SKIP_HOLIDAYS=" + calendar + " " + strings.TrimSpace(expr)
+	}
+	return schedules, nil
+}
+
+// GetDefaultWorkspacesDir returns the default workspaces directory
+func GetDefaultWorkspacesDir() string {
+	return getDefaultWorkspacesDir()
+}
+
 // getDefaultWorkspacesDir returns the default workspaces directory
 func getDefaultWorkspacesDir() string {
 	// First check for explicit workspaces directory override
@@ -338,6 +536,9 @@ func getDefaultWorkspacesDir() string {
 // CreateWorkspace creates a new workspace with the given configuration
 func CreateWorkspace(name, template, description, deploySchedule, destroySchedule string, enabled bool) error {
 	workspacesDir := getDefaultWorkspacesDir()
+	if name == DefaultsDir {
+		return fmt.Errorf("'%s' holds the defaults of all workspaces and cannot be a workspace", DefaultsDir)
+	}
 	wsPath := filepath.Join(workspacesDir, name)
 
 	// Check if workspace already exists
@@ -402,7 +603,6 @@ terraform {
 func UpdateWorkspace(name, template, description, deploySchedule, destroySchedule string, enabled *bool) error {
 	workspacesDir := getDefaultWorkspacesDir()
 	wsPath := filepath.Join(workspacesDir, name)
-	configPath := filepath.Join(wsPath, "config.json")
 
 	// Check if workspace exists
 	if _, err := os.Stat(wsPath); os.IsNotExist(err) {
@@ -410,6 +610,10 @@ func UpdateWorkspace(name, template, description, deploySchedule, destroySchedul
 	}
 
 	// Load existing config
+	configPath, err := findConfigFile(wsPath)
+	if err != nil {
+		return err
+	}
 	config, err := loadConfig(configPath)
 	if err != nil {
 		return fmt.Errorf("failed to load existing config: %w", err)
@@ -432,17 +636,8 @@ func UpdateWorkspace(name, template, description, deploySchedule, destroySchedul
 		config.Enabled = *enabled
 	}
 
-	// Write updated config
-	configData, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
-	}
-
-	if err := os.WriteFile(configPath, configData, 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
-	}
-
-	return nil
+	// Write updated config in its own format
+	return configfile.Write(configPath, config)
 }
 
 // RemoveWorkspace removes a workspace and its directory
@@ -485,7 +680,7 @@ func (c *Config) Validate() error {
 	// Validate individual mode schedules
 	if hasModeSchedules {
 		for mode, schedule := range c.ModeSchedules {
-			if _, err := normalizeScheduleField(schedule); err != nil {
+			if _, err := normalizeSchedules(schedule); err != nil {
 				return fmt.Errorf("invalid schedule for mode '%s': %w", mode, err)
 			}
 		}
@@ -512,6 +707,147 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate debug log retention if specified
+	if c.DebugLogging != nil {
+		if err := validateDebugLoggingConfig(c.DebugLogging); err != nil {
+			return fmt.Errorf("debug_logging validation failed: %w", err)
+		}
+	}
+
+	// Validate state backup retention if specified
+	if c.StateBackup != nil {
+		if err := validateStateBackupConfig(c.StateBackup); err != nil {
+			return fmt.Errorf("state_backup validation failed: %w", err)
+		}
+	}
+
+	if c.MaxMonthlyCost < 0 {
+		return fmt.Errorf("max_monthly_cost must not be negative")
+	}
+
+	if c.TofuVersion != "" {
+		if err := ValidateTofuVersion(c.TofuVersion); err != nil {
+			return err
+		}
+	}
+
+	// Validate remote backend if specified
+	if c.Backend != nil {
+		if err := validateBackendConfig(c.Backend); err != nil {
+			return fmt.Errorf("backend validation failed: %w", err)
+		}
+	}
+
+	// Validate lint settings if specified
+	if c.Lint != nil {
+		if err := validateLintConfig(c.Lint); err != nil {
+			return fmt.Errorf("lint validation failed: %w", err)
+		}
+	}
+
+	// Validate redaction patterns compile
+	for _, pattern := range c.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid redact pattern '%s': %w", pattern, err)
+		}
+	}
+
+	// Validate timezone
+	if c.Timezone != "" {
+		if _, err := time.LoadLocation(c.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone '%s': %w", c.Timezone, err)
+		}
+	}
+
+	// Validate webhook triggers
+	if err := c.validateWebhooks(); err != nil {
+		return fmt.Errorf("webhooks validation failed: %w", err)
+	}
+
+	// Validate run-to-completion settings if specified
+	if c.RunToCompletion != nil {
+		if err := c.validateRunToCompletionConfig(); err != nil {
+			return fmt.Errorf("run_to_completion validation failed: %w", err)
+		}
+	}
+
+	// Validate retry policy if specified
+	if c.Retry != nil {
+		if err := validateRetryConfig(c.Retry); err != nil {
+			return fmt.Errorf("retry validation failed: %w", err)
+		}
+	}
+
+	// Validate OpenTofu variables and their mode overrides
+	if err := c.validateVariables(); err != nil {
+		return fmt.Errorf("variables validation failed: %w", err)
+	}
+
+	// Validate deployment lifetime limit
+	if err := c.validateMaxLifetime(); err != nil {
+		return err
+	}
+
+	// Validate time to live after deploys
+	if err := c.validateTTL(); err != nil {
+		return err
+	}
+
+	// Validate destroy schedules relative to the last deploy
+	if err := c.validateRelativeSchedules(); err != nil {
+		return err
+	}
+
+	// Validate idle detection
+	if err := c.validateIdleCheck(); err != nil {
+		return fmt.Errorf("idle_check validation failed: %w", err)
+	}
+
+	// Validate success-rate objectives
+	if err := c.validateSLO(); err != nil {
+		return fmt.Errorf("slo validation failed: %w", err)
+	}
+
+	// Validate deployment environment tier
+	if err := c.validateTier(); err != nil {
+		return err
+	}
+
+	// Validate how long approval requests wait
+	if err := c.validateApprovalTimeout(); err != nil {
+		return err
+	}
+
+	// Validate labels
+	if err := c.validateLabels(); err != nil {
+		return err
+	}
+
+	// Validate workspace dependencies; references are checked when all workspaces are loaded
+	if err := c.validateDependsOn(); err != nil {
+		return err
+	}
+
+	// Validate the agent running the workspace
+	if err := c.validateAgent(); err != nil {
+		return err
+	}
+
+	// Validate the limits of deploys and destroys
+	if err := c.validateOperationTimeouts(); err != nil {
+		return err
+	}
+
+	// Validate the environment of the tofu commands
+	if err := c.validateEnvironment(); err != nil {
+		return fmt.Errorf("environment validation failed: %w", err)
+	}
+
+	// Validate the hooks around operations
+	if err := c.validateHooks(); err != nil {
+		return fmt.Errorf("hooks validation failed: %w", err)
+	}
+
 	return nil
 }
 
@@ -546,9 +882,15 @@ func validateJobConfig(j JobConfig) error {
 
 	// Validate schedule if provided
 	if j.Schedule != nil {
-		if _, err := normalizeScheduleField(j.Schedule); err != nil {
+		schedules, err := normalizeScheduleField(j.Schedule)
+		if err != nil {
 			return fmt.Errorf("invalid schedule: %w", err)
 		}
+		for _, schedule := range schedules {
+			if _, err := cron.Parse(schedule); err != nil {
+				return fmt.Errorf("invalid schedule '%s': %w", schedule, err)
+			}
+		}
 	}
 
 	// Validate timeout if provided
@@ -558,6 +900,88 @@ func validateJobConfig(j JobConfig) error {
 		}
 	}
 
+	// Validate jitter if provided
+	if j.Jitter != "" {
+		if jitter, err := time.ParseDuration(j.Jitter); err != nil || jitter < 0 {
+			return fmt.Errorf("invalid jitter duration '%s'", j.Jitter)
+		}
+	}
+
+	// Validate retry policy
+	if j.Retries < 0 {
+		return fmt.Errorf("retries must not be negative, got %d", j.Retries)
+	}
+	if j.RetryDelay != "" {
+		if delay, err := time.ParseDuration(j.RetryDelay); err != nil || delay < 0 {
+			return fmt.Errorf("invalid retry_delay duration '%s'", j.RetryDelay)
+		}
+	}
+	switch j.OnFailure {
+	case "", "continue", "abort-dependents", "notify":
+	default:
+		return fmt.Errorf("invalid on_failure '%s' (must be continue, abort-dependents or notify)", j.OnFailure)
+	}
+
+	// Validate the template deployment's lifecycle
+	if j.DestroyAfter != "" || j.DestroySchedule != nil {
+		if j.Type != "template" {
+			return fmt.Errorf("destroy_after and destroy_schedule are only supported for template jobs")
+		}
+	}
+	if j.DestroyAfter != "" {
+		if destroyAfter, err := time.ParseDuration(j.DestroyAfter); err != nil || destroyAfter <= 0 {
+			return fmt.Errorf("invalid destroy_after duration '%s'", j.DestroyAfter)
+		}
+	}
+	if j.DestroySchedule != nil {
+		schedules, err := normalizeScheduleField(j.DestroySchedule)
+		if err != nil {
+			return fmt.Errorf("invalid destroy_schedule: %w", err)
+		}
+		for _, schedule := range schedules {
+			if _, err := cron.Parse(schedule); err != nil {
+				return fmt.Errorf("invalid destroy_schedule '%s': %w", schedule, err)
+			}
+		}
+	}
+
+	// Validate resource limits
+	if j.MaxCPU < 0 {
+		return fmt.Errorf("max_cpu must not be negative, got %g", j.MaxCPU)
+	}
+	if j.MaxMemory != "" {
+		if _, err := bytesize.Parse(j.MaxMemory); err != nil {
+			return fmt.Errorf("invalid max_memory '%s': %w", j.MaxMemory, err)
+		}
+	}
+	if j.Nice < -20 || j.Nice > 19 {
+		return fmt.Errorf("nice must be between -20 and 19, got %d", j.Nice)
+	}
+	if (j.MaxCPU != 0 || j.MaxMemory != "" || j.Nice != 0) && j.Type == "template" {
+		return fmt.Errorf("max_cpu, max_memory and nice are only supported for script and command jobs")
+	}
+
+	// Validate artifacts
+	if j.Artifacts != nil {
+		patterns, err := normalizeScheduleField(j.Artifacts)
+		if err != nil {
+			return fmt.Errorf("invalid artifacts: %w", err)
+		}
+		for _, pattern := range patterns {
+			if pattern == "" || filepath.IsAbs(pattern) || strings.HasPrefix(filepath.Clean(pattern), "..") {
+				return fmt.Errorf("invalid artifacts pattern '%s': must be relative to the working directory", pattern)
+			}
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid artifacts pattern '%s': %w", pattern, err)
+			}
+		}
+	}
+	if j.ArtifactRetention != "" {
+		if retention, err := time.ParseDuration(j.ArtifactRetention); err != nil || retention <= 0 {
+			return fmt.Errorf("invalid artifact_retention duration '%s'", j.ArtifactRetention)
+		}
+	}
+
 	return nil
 }
 
@@ -569,7 +993,7 @@ func (c *Config) GetModeSchedules() (map[string][]string, error) {
 
 	result := make(map[string][]string)
 	for mode, schedule := range c.ModeSchedules {
-		schedules, err := normalizeScheduleField(schedule)
+		schedules, err := normalizeSchedules(schedule)
 		if err != nil {
 			return nil, fmt.Errorf("invalid schedule for mode '%s': %w", mode, err)
 		}
@@ -578,19 +1002,24 @@ func (c *Config) GetModeSchedules() (map[string][]string, error) {
 	return result, nil
 }
 
-// ValidateWorkspace validates a workspace's configuration and OpenTofu syntax
-func ValidateWorkspace(name string) error {
+// ConfigChecker checks a configuration directory rendered with the given variables using
+// OpenTofu itself, in tofuVersion if set. The opentofu package provides it, this package
+// cannot run tofu.
+type ConfigChecker func(dir string, vars map[string]interface{}, tofuVersion string) error
+
+// ValidateWorkspace validates a workspace's configuration and OpenTofu syntax. With a checker,
+// the configuration it deploys is also checked by OpenTofu with the workspace's variables.
+func ValidateWorkspace(name string, check ConfigChecker) error {
 	workspacesDir := getDefaultWorkspacesDir()
 	wsPath := filepath.Join(workspacesDir, name)
-	configPath := filepath.Join(wsPath, "config.json")
 
 	// Check if workspace exists
 	if _, err := os.Stat(wsPath); os.IsNotExist(err) {
 		return fmt.Errorf("workspace does not exist")
 	}
 
-	// Load and validate config
-	config, err := loadConfig(configPath)
+	// Load and validate config, with the defaults it inherits
+	config, _, err := loadEffectiveConfig(wsPath)
 	if err != nil {
 		return fmt.Errorf("invalid config: %w", err)
 	}
@@ -608,8 +1037,11 @@ func ValidateWorkspace(name string) error {
 	}
 
 	// Validate that workspace has a valid OpenTofu configuration
-	if !ws.HasMainTF() {
-		return fmt.Errorf("no valid OpenTofu configuration found (missing main.tf)")
+	if !ws.HasTFConfig() {
+		return fmt.Errorf("no valid OpenTofu configuration found (no .tf files)")
+	}
+	if err := ValidateTFDir(ws.GetTFDir()); err != nil {
+		return fmt.Errorf("invalid OpenTofu configuration: %w", err)
 	}
 
 	// Validate schedules (legacy validation for backward compatibility)
@@ -634,6 +1066,23 @@ func ValidateWorkspace(name string) error {
 		}
 	}
 
+	// Validate dependency references; cycles are reported when all workspaces are loaded
+	for _, depName := range config.DependsOn {
+		if depName == name {
+			return fmt.Errorf("workspace cannot depend on itself")
+		}
+		depConfigPath, _ := findConfigFile(filepath.Join(workspacesDir, depName))
+		if _, err := os.Stat(depConfigPath); os.IsNotExist(err) {
+			return fmt.Errorf("depends on non-existent workspace '%s'", depName)
+		}
+	}
+
+	if check != nil {
+		if err := check(ws.GetTFDir(), config.GetVariables(""), config.TofuVersion); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -95,7 +95,7 @@ func TestLoadWorkspaces(t *testing.T) {
 			if !workspace.Config.Enabled {
 				t.Errorf("expected test-workspace-1 to be enabled")
 			}
-			if !workspace.HasMainTF() {
+			if !workspace.HasTFConfig() {
 				t.Errorf("expected main.tf to exist for test-workspace-1")
 			}
 		}
@@ -142,7 +142,7 @@ func TestLoadConfig(t *testing.T) {
 	}
 }
 
-func TestWorkspaceHasMainTF(t *testing.T) {
+func TestWorkspaceHasTFConfig(t *testing.T) {
 	// Create temporary directory
 	tempDir, err := os.MkdirTemp("", "test-workspace-*")
 	if err != nil {
@@ -154,26 +154,24 @@ func TestWorkspaceHasMainTF(t *testing.T) {
 		Path: tempDir,
 	}
 
-	// Should not have main.tf initially
-	if workspace.HasMainTF() {
-		t.Errorf("expected HasMainTF() to be false initially")
+	// Should not have .tf files initially
+	if workspace.HasTFConfig() {
+		t.Errorf("expected HasTFConfig() to be false initially")
 	}
 
-	// Create main.tf
-	mainTFPath := filepath.Join(tempDir, "main.tf")
-	if err := os.WriteFile(mainTFPath, []byte("# test"), 0644); err != nil {
-		t.Fatalf("failed to create main.tf: %v", err)
+	// Any .tf file makes a configuration, main.tf is not required
+	if err := os.WriteFile(filepath.Join(tempDir, "network.tf"), []byte("# test"), 0644); err != nil {
+		t.Fatalf("failed to create network.tf: %v", err)
 	}
 
-	// Should have main.tf now
-	if !workspace.HasMainTF() {
-		t.Errorf("expected HasMainTF() to be true after creating main.tf")
+	// Should have configuration now
+	if !workspace.HasTFConfig() {
+		t.Errorf("expected HasTFConfig() to be true after creating network.tf")
 	}
 
-	// Check path is correct
-	expectedPath := filepath.Join(tempDir, "main.tf")
-	if workspace.GetMainTFPath() != expectedPath {
-		t.Errorf("expected main.tf path '%s', got '%s'", expectedPath, workspace.GetMainTFPath())
+	// Check directory is correct
+	if workspace.GetTFDir() != tempDir {
+		t.Errorf("expected configuration directory '%s', got '%s'", tempDir, workspace.GetTFDir())
 	}
 }
 
@@ -215,6 +213,25 @@ func TestConfigMultipleSchedules(t *testing.T) {
 			expectedDestroy: []string{"0 18 * * 1-5"},
 			expectError:     false,
 		},
+		{
+			name: "schedule object skipping holidays",
+			config: Config{
+				DeploySchedule:  map[string]interface{}{"cron": "0 8 * * 1-5", "skip_holidays": "uk"},
+				DestroySchedule: []interface{}{map[string]interface{}{"cron": []interface{}{"0 18 * * 1-5"}, "skip_holidays": "uk"}, "0 20 * * 0"},
+			},
+			expectedDeploy:  []string{"SKIP_HOLIDAYS=uk 0 8 * * 1-5"},
+			expectedDestroy: []string{"SKIP_HOLIDAYS=uk 0 18 * * 1-5", "0 20 * * 0"},
+			expectError:     false,
+		},
+		{
+			name: "schedule object without cron",
+			config: Config{
+				DeploySchedule:  map[string]interface{}{"skip_holidays": "uk"},
+				DestroySchedule: "0 17 * * 1-5",
+			},
+			expectedDeploy: nil,
+			expectError:    true,
+		},
 		{
 			name: "invalid type in array",
 			config: Config{
@@ -476,3 +493,72 @@ func contains(s, substr string) bool {
 		(s[:len(substr)] == substr ||
 			(len(s) > len(substr) && contains(s[1:], substr)))
 }
+
+func TestLoadWorkspacesKeepsMissingTemplate(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+
+	workspacesDir := t.TempDir()
+	writeWorkspace := func(name string, config Config, mainTF bool) {
+		t.Helper()
+		wsDir := filepath.Join(workspacesDir, name)
+		if err := os.MkdirAll(wsDir, 0755); err != nil {
+			t.Fatalf("failed to create workspace directory: %v", err)
+		}
+		data, _ := json.Marshal(config)
+		if err := os.WriteFile(filepath.Join(wsDir, "config.json"), data, 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		if mainTF {
+			if err := os.WriteFile(filepath.Join(wsDir, "main.tf"), []byte("# local"), 0644); err != nil {
+				t.Fatalf("failed to write main.tf: %v", err)
+			}
+		}
+	}
+
+	writeWorkspace("uses-web", Config{Enabled: true, Template: "web"}, false)
+	writeWorkspace("overrides-web", Config{Enabled: true, Template: "web"}, true)
+	writeWorkspace("uses-db", Config{Enabled: true, Template: "db"}, false)
+	writeWorkspace("no-config", Config{Enabled: true}, false)
+
+	// Only the db template is installed
+	dbTemplate := filepath.Join(stateDir, "templates", "db")
+	if err := os.MkdirAll(dbTemplate, 0755); err != nil {
+		t.Fatalf("failed to create template directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dbTemplate, "main.tf"), []byte("# db"), 0644); err != nil {
+		t.Fatalf("failed to write template main.tf: %v", err)
+	}
+
+	workspaces, err := LoadWorkspaces(workspacesDir)
+	if err != nil {
+		t.Fatalf("LoadWorkspaces failed: %v", err)
+	}
+
+	missing := make(map[string]bool)
+	for _, ws := range workspaces {
+		missing[ws.Name] = ws.IsTemplateMissing()
+	}
+	if len(missing) != 3 {
+		t.Fatalf("expected 3 workspaces (no-config dropped), got %v", missing)
+	}
+	if !missing["uses-web"] {
+		t.Error("expected uses-web to be kept and report its template missing")
+	}
+	if missing["overrides-web"] || missing["uses-db"] {
+		t.Errorf("expected local main.tf and installed template not to be missing, got %v", missing)
+	}
+
+	refs, err := FindTemplateReferences(workspacesDir, "web")
+	if err != nil {
+		t.Fatalf("FindTemplateReferences failed: %v", err)
+	}
+	if len(refs) != 1 || refs[0] != "uses-web" {
+		t.Errorf("expected only uses-web to reference web, got %v", refs)
+	}
+
+	refs, err = FindTemplateReferences(filepath.Join(workspacesDir, "missing"), "web")
+	if err != nil || len(refs) != 0 {
+		t.Errorf("expected no references for missing workspaces dir, got %v (err %v)", refs, err)
+	}
+}
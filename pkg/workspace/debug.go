@@ -0,0 +1,196 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"provisioner/pkg/paths"
+)
+
+// Debug log retention defaults
+const (
+	DefaultDebugRetentionDays = 7
+	DefaultDebugMaxFiles      = 20
+)
+
+// DebugLoggingConfig enables verbose OpenTofu logging (TF_LOG=DEBUG) for a workspace
+type DebugLoggingConfig struct {
+	Enabled       bool `json:"enabled"`
+	RetentionDays int  `json:"retention_days,omitempty"` // Delete debug logs older than this (default 7)
+	MaxFiles      int  `json:"max_files,omitempty"`      // Keep at most this many debug logs (default 20)
+}
+
+// DebugOverride is a runtime toggle set with "workspacectl debug" that takes precedence over config.json
+type DebugOverride struct {
+	Enabled   bool      `json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetDebugOverridePath returns the path to a workspace's runtime debug toggle
+func GetDebugOverridePath(stateDir, wsName string) string {
+	return filepath.Join(stateDir, "debug", wsName+".json")
+}
+
+// LoadDebugOverride loads the runtime debug toggle, returning nil if none is set
+func LoadDebugOverride(stateDir, wsName string) (*DebugOverride, error) {
+	data, err := os.ReadFile(GetDebugOverridePath(stateDir, wsName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read debug override: %w", err)
+	}
+
+	var override DebugOverride
+	if err := json.Unmarshal(data, &override); err != nil {
+		return nil, fmt.Errorf("failed to parse debug override: %w", err)
+	}
+
+	return &override, nil
+}
+
+// SetDebugOverride turns debug logging on or off for a workspace until changed again
+func SetDebugOverride(stateDir, wsName string, enabled bool) error {
+	overridePath := GetDebugOverridePath(stateDir, wsName)
+	if err := os.MkdirAll(filepath.Dir(overridePath), 0755); err != nil {
+		return fmt.Errorf("failed to create debug directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(DebugOverride{Enabled: enabled, UpdatedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal debug override: %w", err)
+	}
+
+	if err := os.WriteFile(overridePath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write debug override: %w", err)
+	}
+
+	return nil
+}
+
+// IsDebugLoggingEnabled reports whether OpenTofu debug logs should be captured for the next operation.
+// The runtime toggle wins over config.json so it can be changed without restarting the daemon.
+func (w *Workspace) IsDebugLoggingEnabled() bool {
+	if override, err := LoadDebugOverride(getStateDir(), w.Name); err == nil && override != nil {
+		return override.Enabled
+	}
+	return w.Config.DebugLogging != nil && w.Config.DebugLogging.Enabled
+}
+
+// GetDebugLogDir returns the directory holding the workspace's OpenTofu debug logs
+func (w *Workspace) GetDebugLogDir() string {
+	return debugLogDir(w.Name)
+}
+
+// debugLogDir returns the debug log directory for a workspace name
+func debugLogDir(wsName string) string {
+	return filepath.Join(paths.LogDir(), "debug", wsName)
+}
+
+// NewDebugLogFile creates an empty debug log for an operation and prunes old ones.
+// The file is private because OpenTofu debug output can include secrets.
+func (w *Workspace) NewDebugLogFile(operation string, now time.Time) (string, error) {
+	debugDir := w.GetDebugLogDir()
+	if err := os.MkdirAll(debugDir, 0750); err != nil {
+		return "", fmt.Errorf("failed to create debug log directory: %w", err)
+	}
+
+	retentionDays, maxFiles := DefaultDebugRetentionDays, DefaultDebugMaxFiles
+	if cfg := w.Config.DebugLogging; cfg != nil {
+		if cfg.RetentionDays > 0 {
+			retentionDays = cfg.RetentionDays
+		}
+		if cfg.MaxFiles > 0 {
+			maxFiles = cfg.MaxFiles
+		}
+	}
+
+	// Leave room for the new file within max_files
+	if err := PruneDebugLogs(debugDir, time.Duration(retentionDays)*24*time.Hour, maxFiles-1, now); err != nil {
+		return "", err
+	}
+
+	logPath := filepath.Join(debugDir, fmt.Sprintf("%s-%s.log", now.Format("20060102-150405"), operation))
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create debug log: %w", err)
+	}
+	_ = file.Close()
+
+	return logPath, nil
+}
+
+// ListDebugLogs returns a workspace's debug logs, newest first
+func ListDebugLogs(wsName string) ([]string, error) {
+	debugDir := debugLogDir(wsName)
+	entries, err := os.ReadDir(debugDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read debug log directory: %w", err)
+	}
+
+	var logs []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".log") {
+			logs = append(logs, filepath.Join(debugDir, entry.Name()))
+		}
+	}
+
+	// File names start with a sortable timestamp
+	sort.Sort(sort.Reverse(sort.StringSlice(logs)))
+	return logs, nil
+}
+
+// PruneDebugLogs removes debug logs older than maxAge and all but the newest keep files
+func PruneDebugLogs(debugDir string, maxAge time.Duration, keep int, now time.Time) error {
+	entries, err := os.ReadDir(debugDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read debug log directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".log") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+
+	for i, name := range names {
+		path := filepath.Join(debugDir, name)
+
+		expired := false
+		if info, err := os.Stat(path); err == nil && now.Sub(info.ModTime()) > maxAge {
+			expired = true
+		}
+
+		if i >= keep || expired {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove old debug log %s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateDebugLoggingConfig checks debug retention settings
+func validateDebugLoggingConfig(cfg *DebugLoggingConfig) error {
+	if cfg.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative")
+	}
+	if cfg.MaxFiles < 0 {
+		return fmt.Errorf("max_files must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDebugOverrideTakesPrecedence(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+
+	ws := &Workspace{Name: "my-app", Config: Config{DebugLogging: &DebugLoggingConfig{Enabled: true}}}
+	if !ws.IsDebugLoggingEnabled() {
+		t.Error("Expected debug logging enabled from config")
+	}
+
+	if err := SetDebugOverride(stateDir, "my-app", false); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+	if ws.IsDebugLoggingEnabled() {
+		t.Error("Expected runtime override to disable debug logging")
+	}
+
+	plain := &Workspace{Name: "my-app"}
+	if err := SetDebugOverride(stateDir, "my-app", true); err != nil {
+		t.Fatalf("Failed to set override: %v", err)
+	}
+	if !plain.IsDebugLoggingEnabled() {
+		t.Error("Expected runtime override to enable debug logging without config")
+	}
+
+	other := &Workspace{Name: "other"}
+	if other.IsDebugLoggingEnabled() {
+		t.Error("Expected debug logging off for workspace without config or override")
+	}
+}
+
+func TestNewDebugLogFilePrunesOldLogs(t *testing.T) {
+	t.Setenv("PROVISIONER_LOG_DIR", t.TempDir())
+
+	ws := &Workspace{Name: "my-app", Config: Config{DebugLogging: &DebugLoggingConfig{Enabled: true, RetentionDays: 1, MaxFiles: 3}}}
+	debugDir := ws.GetDebugLogDir()
+	if err := os.MkdirAll(debugDir, 0750); err != nil {
+		t.Fatalf("Failed to create debug dir: %v", err)
+	}
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	// One expired log and four recent ones
+	expired := filepath.Join(debugDir, "20250301-120000-deploy.log")
+	if err := os.WriteFile(expired, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(expired, now.AddDate(0, 0, -9), now.AddDate(0, 0, -9)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		path := filepath.Join(debugDir, fmt.Sprintf("20250310-11%02d00-deploy.log", i))
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-time.Hour), now.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logPath, err := ws.NewDebugLogFile("destroy", now)
+	if err != nil {
+		t.Fatalf("Failed to create debug log: %v", err)
+	}
+
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("Debug log not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected debug log mode 0600, got %v", info.Mode().Perm())
+	}
+
+	logs, err := ListDebugLogs("my-app")
+	if err != nil {
+		t.Fatalf("Failed to list debug logs: %v", err)
+	}
+	expected := []string{
+		logPath,
+		filepath.Join(debugDir, "20250310-110300-deploy.log"),
+		filepath.Join(debugDir, "20250310-110200-deploy.log"),
+	}
+	if len(logs) != len(expected) {
+		t.Fatalf("Expected %d debug logs, got %v", len(expected), logs)
+	}
+	for i := range expected {
+		if logs[i] != expected[i] {
+			t.Errorf("Expected log %d to be %s, got %s", i, expected[i], logs[i])
+		}
+	}
+}
+
+func TestValidateDebugLoggingConfig(t *testing.T) {
+	config := Config{
+		Enabled:        true,
+		DeploySchedule: "0 9 * * *",
+		DebugLogging:   &DebugLoggingConfig{Enabled: true, MaxFiles: -1},
+	}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected validation error for negative max_files")
+	}
+
+	config.DebugLogging.MaxFiles = 5
+	if err := config.Validate(); err != nil {
+		t.Errorf("Unexpected validation error: %v", err)
+	}
+}
@@ -0,0 +1,142 @@
+package workspace
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+
+	"provisioner/pkg/configfile"
+)
+
+// DefaultsDir is the directory in the workspaces directory whose config file holds settings
+// merged into the config of every workspace that doesn't set them itself. It is not a workspace.
+const DefaultsDir = "_defaults"
+
+// scheduleFields are mutually exclusive; a workspace setting either gets neither default
+var scheduleFields = []string{"deploy_schedule", "mode_schedules"}
+
+// configDefaults are the fields of _defaults/config.json, or its YAML or HCL equivalent
+type configDefaults struct {
+	fields map[string]json.RawMessage
+	sum    [sha256.Size]byte
+}
+
+// loadDefaults reads the defaults of the workspaces in workspacesDir, nil if there are none
+func loadDefaults(workspacesDir string) (*configDefaults, error) {
+	path, err := findConfigFile(filepath.Join(workspacesDir, DefaultsDir))
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	defaults := &configDefaults{sum: sha256.Sum256(data)}
+	converted, err := configfile.ToJSON(path, data)
+	if err != nil {
+		return nil, err
+	}
+	// Check the field types once rather than in every workspace's error
+	var config Config
+	if err := configfile.Unmarshal(path, data, converted, &config); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(converted, &defaults.fields); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return defaults, nil
+}
+
+// merge returns a workspace's config, as JSON, with the default fields it leaves unset, and the names
+// of those fields. A field set to null is unset; false turns off a default schedule.
+func (d *configDefaults) merge(data []byte) ([]byte, []string, error) {
+	if d == nil || len(d.fields) == 0 {
+		return data, nil, nil
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+	isSet := func(name string) bool {
+		value, ok := fields[name]
+		return ok && !bytes.Equal(bytes.TrimSpace(value), []byte("null"))
+	}
+	schedulesSet := false
+	for _, name := range scheduleFields {
+		schedulesSet = schedulesSet || isSet(name)
+	}
+
+	var inherited []string
+	for name, value := range d.fields {
+		if isSet(name) || (schedulesSet && slices.Contains(scheduleFields, name)) {
+			continue
+		}
+		if fields == nil {
+			fields = make(map[string]json.RawMessage)
+		}
+		fields[name] = value
+		inherited = append(inherited, name)
+	}
+	if len(inherited) == 0 {
+		return data, nil, nil
+	}
+	sort.Strings(inherited)
+
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to merge defaults: %w", err)
+	}
+	return merged, inherited, nil
+}
+
+// loadEffectiveConfig loads the config file of the workspace in wsPath merged with the defaults of
+// its workspaces directory, returning the fields taken from the defaults. Commands changing the
+// config file use loadConfig instead, so the defaults are not written into it.
+func loadEffectiveConfig(wsPath string) (Config, []string, error) {
+	var config Config
+	defaults, err := loadDefaults(filepath.Dir(wsPath))
+	if err != nil {
+		return config, nil, err
+	}
+
+	configPath, err := findConfigFile(wsPath)
+	if err != nil {
+		return config, nil, err
+	}
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return config, nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return parseConfig(configPath, data, defaults)
+}
+
+// parseConfig decodes the content of a workspace's config file merged with defaults, returning
+// the fields taken from the defaults. Errors give the line of the problem in the file.
+func parseConfig(configPath string, content []byte, defaults *configDefaults) (Config, []string, error) {
+	var config Config
+	data, err := configfile.ToJSON(configPath, content)
+	if err != nil {
+		return config, nil, err
+	}
+	merged, inherited, err := defaults.merge(data)
+	if err != nil {
+		if located := configfile.Unmarshal(configPath, content, data, &config); located != nil {
+			return config, nil, located
+		}
+		return config, nil, err
+	}
+	if err := configfile.Unmarshal(configPath, content, merged, &config); err != nil {
+		return config, nil, err
+	}
+	return config, inherited, nil
+}
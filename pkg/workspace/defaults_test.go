@@ -0,0 +1,148 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func writeDefaultsTestConfig(t *testing.T, dir, config string) string {
+	t.Helper()
+	defaultsDir := filepath.Join(dir, DefaultsDir)
+	if err := os.MkdirAll(defaultsDir, 0755); err != nil {
+		t.Fatalf("failed to create defaults directory: %v", err)
+	}
+	path := filepath.Join(defaultsDir, "config.json")
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write defaults: %v", err)
+	}
+	return path
+}
+
+func TestLoadWorkspacesMergesDefaults(t *testing.T) {
+	tempDir := t.TempDir()
+	writeDefaultsTestConfig(t, tempDir, `{
+		"deploy_schedule": "0 8 * * 1-5",
+		"destroy_schedule": "0 19 * * 1-5",
+		"timezone": "Europe/Berlin",
+		"retry": {"max_attempts": 3}
+	}`)
+	writeLoaderTestWorkspace(t, tempDir, "inherits", `{"enabled": true, "destroy_schedule": null}`)
+	writeLoaderTestWorkspace(t, tempDir, "overrides", `{"enabled": true, "deploy_schedule": "0 6 * * *", "destroy_schedule": false, "timezone": "UTC"}`)
+	writeLoaderTestWorkspace(t, tempDir, "modes", `{"enabled": true, "template": "web", "mode_schedules": {"busy": "0 8 * * *"}}`)
+
+	workspaces, err := LoadWorkspaces(tempDir)
+	if err != nil {
+		t.Fatalf("failed to load workspaces: %v", err)
+	}
+	if len(workspaces) != 3 {
+		t.Fatalf("expected the defaults not to be loaded as a workspace, got %d workspaces", len(workspaces))
+	}
+
+	byName := make(map[string]Config)
+	for _, ws := range workspaces {
+		byName[ws.Name] = ws.Config
+	}
+
+	inherits := byName["inherits"]
+	if inherits.DeploySchedule != "0 8 * * 1-5" || inherits.DestroySchedule != "0 19 * * 1-5" || inherits.Timezone != "Europe/Berlin" {
+		t.Errorf("expected the defaults, got %+v", inherits)
+	}
+	if inherits.Retry == nil || inherits.Retry.MaxAttempts != 3 {
+		t.Errorf("expected the default retry policy, got %+v", inherits.Retry)
+	}
+
+	overrides := byName["overrides"]
+	if overrides.DeploySchedule != "0 6 * * *" || overrides.DestroySchedule != false || overrides.Timezone != "UTC" {
+		t.Errorf("expected the workspace's own settings, got %+v", overrides)
+	}
+
+	modes := byName["modes"]
+	if modes.DeploySchedule != nil || len(modes.ModeSchedules) != 1 {
+		t.Errorf("expected no default deploy_schedule next to mode_schedules, got %v", modes.DeploySchedule)
+	}
+	if err := modes.Validate(); err != nil {
+		t.Errorf("expected the merged config to be valid, got %v", err)
+	}
+}
+
+func TestLoadWorkspacesReloadsChangedDefaults(t *testing.T) {
+	tempDir := t.TempDir()
+	defaultsPath := writeDefaultsTestConfig(t, tempDir, `{"timezone": "Europe/Berlin"}`)
+	writeLoaderTestWorkspace(t, tempDir, "cached", `{"enabled": true, "deploy_schedule": "0 9 * * *"}`)
+
+	if _, err := LoadWorkspaces(tempDir); err != nil {
+		t.Fatalf("failed to load workspaces: %v", err)
+	}
+
+	// The workspace's config.json is unchanged, only the defaults are
+	writeDefaultsTestConfig(t, tempDir, `{"timezone": "America/New_York"}`)
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(defaultsPath, later, later); err != nil {
+		t.Fatalf("failed to touch defaults: %v", err)
+	}
+
+	workspaces, err := LoadWorkspaces(tempDir)
+	if err != nil {
+		t.Fatalf("failed to load workspaces: %v", err)
+	}
+	if workspaces[0].Config.Timezone != "America/New_York" {
+		t.Errorf("expected the changed default, got %q", workspaces[0].Config.Timezone)
+	}
+
+	writeDefaultsTestConfig(t, tempDir, `{"timezone": 5}`)
+	if _, err := LoadWorkspaces(tempDir); err == nil {
+		t.Error("expected an error for invalid defaults")
+	}
+}
+
+func TestLoadEffectiveConfig(t *testing.T) {
+	tempDir := t.TempDir()
+	writeDefaultsTestConfig(t, tempDir, `{"deploy_schedule": "0 8 * * *", "timezone": "Europe/Berlin", "notification_channel": "dev"}`)
+	configPath := writeLoaderTestWorkspace(t, tempDir, "web", `{"enabled": true, "timezone": "UTC"}`)
+
+	config, inherited, err := loadEffectiveConfig(filepath.Dir(configPath))
+	if err != nil {
+		t.Fatalf("failed to load effective config: %v", err)
+	}
+	if config.DeploySchedule != "0 8 * * *" || config.Timezone != "UTC" || config.NotificationChannel != "dev" {
+		t.Errorf("unexpected effective config %+v", config)
+	}
+	if expected := []string{"deploy_schedule", "notification_channel"}; !reflect.DeepEqual(inherited, expected) {
+		t.Errorf("expected inherited fields %v, got %v", expected, inherited)
+	}
+
+	// Commands writing config.json must not bake the defaults into it
+	raw, err := loadConfig(configPath)
+	if err != nil {
+		t.Fatalf("failed to load config: %v", err)
+	}
+	if raw.DeploySchedule != nil {
+		t.Errorf("expected the raw config without defaults, got deploy_schedule %v", raw.DeploySchedule)
+	}
+}
+
+func TestMaskedConfig(t *testing.T) {
+	config := Config{
+		Variables:   map[string]interface{}{"region": "fra1", "api_token": "abc"},
+		Webhooks:    []WebhookConfig{{Name: "deploy", Action: "deploy", Secret: "s3cret"}},
+		Backend:     &BackendConfig{Type: BackendS3, Config: map[string]string{"bucket": "state", "secret_key": "xyz"}},
+		Environment: map[string]string{"AWS_PROFILE": "staging", "DIGITALOCEAN_TOKEN": "dop_123", "AWS_SECRET_ACCESS_KEY": "env:AWS_KEY"},
+	}
+
+	masked := maskedConfig(config)
+	if masked.Variables["region"] != "fra1" || masked.Variables["api_token"] != "********" {
+		t.Errorf("expected only the token variable masked, got %v", masked.Variables)
+	}
+	if masked.Webhooks[0].Secret != "********" || masked.Backend.Config["secret_key"] != "********" || masked.Backend.Config["bucket"] != "state" {
+		t.Errorf("expected secrets masked, got %+v and %v", masked.Webhooks, masked.Backend.Config)
+	}
+	if masked.Environment["AWS_PROFILE"] != "staging" || masked.Environment["DIGITALOCEAN_TOKEN"] != "********" || masked.Environment["AWS_SECRET_ACCESS_KEY"] != "env:AWS_KEY" {
+		t.Errorf("expected secret environment values masked and references shown, got %v", masked.Environment)
+	}
+	if config.Variables["api_token"] != "abc" || config.Webhooks[0].Secret != "s3cret" || config.Backend.Config["secret_key"] != "xyz" {
+		t.Error("expected the original config to be unchanged")
+	}
+}
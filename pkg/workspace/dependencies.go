@@ -0,0 +1,119 @@
+package workspace
+
+import (
+	"fmt"
+	"slices"
+)
+
+// validateDependsOn checks a workspace's own dependency list
+func (c *Config) validateDependsOn() error {
+	for i, name := range c.DependsOn {
+		if name == "" {
+			return fmt.Errorf("depends_on entry %d is empty", i)
+		}
+		if slices.Contains(c.DependsOn[:i], name) {
+			return fmt.Errorf("depends_on lists workspace '%s' twice", name)
+		}
+	}
+	return nil
+}
+
+// ValidateWorkspaceDependencies checks for circular dependencies and missing workspace references
+func ValidateWorkspaceDependencies(workspaces []Workspace) error {
+	workspacesByName := make(map[string]*Workspace)
+	for i, ws := range workspaces {
+		workspacesByName[ws.Name] = &workspaces[i]
+	}
+
+	// Check for missing dependencies
+	for _, ws := range workspaces {
+		for _, depName := range ws.Config.DependsOn {
+			if _, exists := workspacesByName[depName]; !exists {
+				return fmt.Errorf("workspace '%s' depends on non-existent workspace '%s'", ws.Name, depName)
+			}
+		}
+	}
+
+	// Check for circular dependencies using DFS
+	// States: 0 = unvisited, 1 = visiting, 2 = visited
+	state := make(map[string]int)
+
+	var dfs func(name string) error
+	dfs = func(name string) error {
+		if state[name] == 1 {
+			return fmt.Errorf("circular dependency detected involving workspace '%s'", name)
+		}
+		if state[name] == 2 {
+			return nil // Already processed
+		}
+
+		state[name] = 1 // Mark as visiting
+
+		for _, depName := range workspacesByName[name].Config.DependsOn {
+			if err := dfs(depName); err != nil {
+				return err
+			}
+		}
+
+		state[name] = 2 // Mark as visited
+		return nil
+	}
+
+	// Visit in load order so the reported workspace is deterministic
+	for _, ws := range workspaces {
+		if state[ws.Name] == 0 {
+			if err := dfs(ws.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// DeployOrder returns the workspaces with every workspace after its dependencies, keeping
+// the given order otherwise. Destroys run in the reverse order.
+func DeployOrder(workspaces []Workspace) ([]Workspace, error) {
+	if err := ValidateWorkspaceDependencies(workspaces); err != nil {
+		return nil, err
+	}
+
+	workspacesByName := make(map[string]Workspace)
+	for _, ws := range workspaces {
+		workspacesByName[ws.Name] = ws
+	}
+
+	visited := make(map[string]bool)
+	result := make([]Workspace, 0, len(workspaces))
+
+	var visit func(ws Workspace)
+	visit = func(ws Workspace) {
+		if visited[ws.Name] {
+			return
+		}
+		visited[ws.Name] = true
+
+		// Visit dependencies first
+		for _, depName := range ws.Config.DependsOn {
+			visit(workspacesByName[depName])
+		}
+		result = append(result, ws)
+	}
+
+	for _, ws := range workspaces {
+		visit(ws)
+	}
+
+	return result, nil
+}
+
+// Dependents returns the names of the workspaces that depend on the named workspace
+func Dependents(workspaces []Workspace, name string) []string {
+	var dependents []string
+	for _, ws := range workspaces {
+		if slices.Contains(ws.Config.DependsOn, name) {
+			dependents = append(dependents, ws.Name)
+		}
+	}
+	return dependents
+}
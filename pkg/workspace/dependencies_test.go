@@ -0,0 +1,76 @@
+package workspace
+
+import (
+	"strings"
+	"testing"
+)
+
+func dependencyTestWorkspaces(dependsOn map[string][]string, names ...string) []Workspace {
+	workspaces := make([]Workspace, len(names))
+	for i, name := range names {
+		workspaces[i] = Workspace{Name: name, Config: Config{DependsOn: dependsOn[name]}}
+	}
+	return workspaces
+}
+
+func TestValidateWorkspaceDependencies(t *testing.T) {
+	tests := []struct {
+		name      string
+		dependsOn map[string][]string
+		wantError string
+	}{
+		{"no dependencies", nil, ""},
+		{"chain", map[string][]string{"app": {"database"}, "database": {"network"}}, ""},
+		{"missing workspace", map[string][]string{"app": {"cache"}}, "non-existent workspace 'cache'"},
+		{"self dependency", map[string][]string{"app": {"app"}}, "circular dependency"},
+		{"cycle", map[string][]string{"app": {"database"}, "database": {"network"}, "network": {"app"}}, "circular dependency"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWorkspaceDependencies(dependencyTestWorkspaces(tt.dependsOn, "app", "database", "network"))
+			if tt.wantError == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if tt.wantError != "" && (err == nil || !strings.Contains(err.Error(), tt.wantError)) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+func TestDeployOrder(t *testing.T) {
+	workspaces := dependencyTestWorkspaces(map[string][]string{
+		"app":      {"network", "database"},
+		"database": {"network"},
+	}, "app", "database", "network", "solo")
+
+	ordered, err := DeployOrder(workspaces)
+	if err != nil {
+		t.Fatalf("DeployOrder failed: %v", err)
+	}
+
+	var names []string
+	for _, ws := range ordered {
+		names = append(names, ws.Name)
+	}
+	if got := strings.Join(names, ","); got != "network,database,app,solo" {
+		t.Errorf("Expected dependencies first, got %s", got)
+	}
+
+	if dependents := Dependents(workspaces, "network"); strings.Join(dependents, ",") != "app,database" {
+		t.Errorf("Expected app and database to depend on network, got %v", dependents)
+	}
+}
+
+func TestValidateDependsOn(t *testing.T) {
+	config := Config{DeploySchedule: "0 9 * * *", DependsOn: []string{"network", "network"}}
+	if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "twice") {
+		t.Errorf("Expected duplicate dependency to be rejected, got %v", err)
+	}
+
+	config.DependsOn = []string{""}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected empty dependency to be rejected")
+	}
+}
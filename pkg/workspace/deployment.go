@@ -10,11 +10,14 @@ import (
 
 // DeploymentMetadata tracks template information for workspace deployments
 type DeploymentMetadata struct {
-	WorkspaceName string    `json:"workspace_name"`
-	TemplateName  string    `json:"template_name,omitempty"`
-	TemplateHash  string    `json:"template_hash,omitempty"`
-	LastUpdated   time.Time `json:"last_updated"`
-	CreatedAt     time.Time `json:"created_at"`
+	WorkspaceName  string     `json:"workspace_name"`
+	TemplateName   string     `json:"template_name,omitempty"`
+	TemplateHash   string     `json:"template_hash,omitempty"`   // Content hash of the template last deployed successfully
+	TemplateCommit string     `json:"template_commit,omitempty"` // Source commit of the template last deployed successfully
+	DeployedAt     *time.Time `json:"deployed_at,omitempty"`     // When the template was last deployed successfully
+	SecretVars     []string   `json:"secret_vars,omitempty"`
+	LastUpdated    time.Time  `json:"last_updated"`
+	CreatedAt      time.Time  `json:"created_at"`
 }
 
 // GetDeploymentMetadataPath returns the path to deployment metadata file
@@ -89,15 +92,18 @@ func IsTemplateOutdated(stateDir, wsName, currentTemplateHash string) (bool, err
 	return metadata.TemplateHash != currentTemplateHash, nil
 }
 
-// UpdateDeploymentTemplate updates deployment metadata with new template information
-func UpdateDeploymentTemplate(stateDir, wsName, templateName, templateHash string) error {
+// UpdateDeploymentTemplate records the template version a successful deploy applied
+func UpdateDeploymentTemplate(stateDir, wsName, templateName, templateHash, templateCommit string) error {
 	metadata, err := LoadDeploymentMetadata(stateDir, wsName)
 	if err != nil {
 		return err
 	}
 
+	now := time.Now()
 	metadata.TemplateName = templateName
 	metadata.TemplateHash = templateHash
+	metadata.TemplateCommit = templateCommit
+	metadata.DeployedAt = &now
 
 	return SaveDeploymentMetadata(stateDir, wsName, metadata)
 }
@@ -0,0 +1,85 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Secret references in environment values, resolved when the workspace's tofu commands run so
+// the secret itself stays out of the config
+const (
+	SecretRefEnv  = "env:"  // env:NAME takes the value of the daemon's environment variable NAME
+	SecretRefFile = "file:" // file:PATH takes the content of the file at PATH, without trailing newlines
+)
+
+// envNamePattern is what environment variable names may consist of
+var envNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// IsSecretRef returns true if an environment value references a secret rather than holding it
+func IsSecretRef(value string) bool {
+	return strings.HasPrefix(value, SecretRefEnv) || strings.HasPrefix(value, SecretRefFile)
+}
+
+// ResolveEnvironment returns the workspace's environment variables as KEY=value pairs, sorted by
+// name, with secret references replaced by the secrets
+func (c *Config) ResolveEnvironment() ([]string, error) {
+	keys := make([]string, 0, len(c.Environment))
+	for key := range c.Environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, 0, len(keys))
+	for _, key := range keys {
+		value, err := resolveSecretRef(c.Environment[key])
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w", key, err)
+		}
+		env = append(env, key+"="+value)
+	}
+	return env, nil
+}
+
+// resolveSecretRef returns the secret a value references, or the value itself
+func resolveSecretRef(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SecretRefEnv):
+		name := strings.TrimPrefix(value, SecretRefEnv)
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("referenced environment variable %s is not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, SecretRefFile):
+		data, err := os.ReadFile(strings.TrimPrefix(value, SecretRefFile))
+		if err != nil {
+			return "", fmt.Errorf("failed to read referenced secret: %w", err)
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return value, nil
+}
+
+// validateEnvironment checks environment variable names and secret references
+func (c *Config) validateEnvironment() error {
+	for key, value := range c.Environment {
+		if !envNamePattern.MatchString(key) {
+			return fmt.Errorf("invalid environment variable name '%s'", key)
+		}
+		switch {
+		case strings.HasPrefix(value, SecretRefEnv):
+			if !envNamePattern.MatchString(strings.TrimPrefix(value, SecretRefEnv)) {
+				return fmt.Errorf("environment variable %s: invalid reference '%s'", key, value)
+			}
+		case strings.HasPrefix(value, SecretRefFile):
+			if !filepath.IsAbs(strings.TrimPrefix(value, SecretRefFile)) {
+				return fmt.Errorf("environment variable %s: referenced file must be an absolute path: %s", key, value)
+			}
+		}
+	}
+	return nil
+}
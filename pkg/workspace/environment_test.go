@@ -0,0 +1,54 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveEnvironment(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenFile, []byte("do-secret\n"), 0600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+	t.Setenv("PROD_AWS_KEY", "aws-secret")
+
+	config := Config{Environment: map[string]string{
+		"AWS_PROFILE":        "production",
+		"AWS_ACCESS_KEY_ID":  "env:PROD_AWS_KEY",
+		"DIGITALOCEAN_TOKEN": "file:" + tokenFile,
+	}}
+	if err := config.validateEnvironment(); err != nil {
+		t.Fatalf("expected a valid environment, got %v", err)
+	}
+	env, err := config.ResolveEnvironment()
+	if err != nil {
+		t.Fatalf("failed to resolve environment: %v", err)
+	}
+	want := "AWS_ACCESS_KEY_ID=aws-secret|AWS_PROFILE=production|DIGITALOCEAN_TOKEN=do-secret"
+	if strings.Join(env, "|") != want {
+		t.Errorf("expected %s, got %v", want, env)
+	}
+
+	for _, missing := range []string{"env:PROVISIONER_TEST_UNSET_SECRET", "file:" + filepath.Join(t.TempDir(), "missing")} {
+		config := Config{Environment: map[string]string{"TOKEN": missing}}
+		if _, err := config.ResolveEnvironment(); err == nil || !strings.Contains(err.Error(), "TOKEN") {
+			t.Errorf("expected an error for %s, got %v", missing, err)
+		}
+	}
+}
+
+func TestValidateEnvironment(t *testing.T) {
+	for _, environment := range []map[string]string{
+		{"1PASSWORD": "x"},
+		{"AWS-PROFILE": "x"},
+		{"TOKEN": "env:"},
+		{"TOKEN": "file:secrets/token"},
+	} {
+		config := Config{Environment: environment}
+		if err := config.validateEnvironment(); err == nil {
+			t.Errorf("expected error for %v", environment)
+		}
+	}
+}
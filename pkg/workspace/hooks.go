@@ -0,0 +1,41 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HooksConfig holds commands run around a workspace's operations. Commands run with "sh -c" in
+// the deployment directory, with the workspace's environment.
+type HooksConfig struct {
+	PostDeploy []string `json:"post_deploy,omitempty"` // Smoke tests run in turn after a successful apply; a failing one marks the deploy degraded
+	PreDestroy []string `json:"pre_destroy,omitempty"` // Safety checks run in turn before a destroy; a failing one stops it
+}
+
+// GetPostDeployHooks returns the commands run after a successful apply
+func (c *Config) GetPostDeployHooks() []string {
+	if c.Hooks == nil {
+		return nil
+	}
+	return c.Hooks.PostDeploy
+}
+
+// GetPreDestroyHooks returns the commands that must pass before a destroy
+func (c *Config) GetPreDestroyHooks() []string {
+	if c.Hooks == nil {
+		return nil
+	}
+	return c.Hooks.PreDestroy
+}
+
+// validateHooks checks that hooks have commands
+func (c *Config) validateHooks() error {
+	for name, hooks := range map[string][]string{"post_deploy": c.GetPostDeployHooks(), "pre_destroy": c.GetPreDestroyHooks()} {
+		for i, command := range hooks {
+			if strings.TrimSpace(command) == "" {
+				return fmt.Errorf("%s hook %d has no command", name, i+1)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package workspace
+
+import "testing"
+
+func TestValidateHooks(t *testing.T) {
+	config := Config{Hooks: &HooksConfig{PostDeploy: []string{"./smoke-test.sh", "curl -fsS $TF_OUTPUT_URL/health"}}}
+	if err := config.validateHooks(); err != nil {
+		t.Errorf("expected valid hooks, got %v", err)
+	}
+	if hooks := config.GetPostDeployHooks(); len(hooks) != 2 {
+		t.Errorf("expected 2 post_deploy hooks, got %v", hooks)
+	}
+
+	config.Hooks.PostDeploy = append(config.Hooks.PostDeploy, " ")
+	if err := config.validateHooks(); err == nil {
+		t.Error("expected an error for an empty hook")
+	}
+	if hooks := (&Config{}).GetPostDeployHooks(); hooks != nil {
+		t.Errorf("expected no hooks without a hooks config, got %v", hooks)
+	}
+
+	config = Config{Hooks: &HooksConfig{PreDestroy: []string{"./no-active-sessions.sh", ""}}}
+	if err := config.validateHooks(); err == nil || err.Error() != "pre_destroy hook 2 has no command" {
+		t.Errorf("expected an error for an empty pre_destroy hook, got %v", err)
+	}
+	if hooks := (&Config{}).GetPreDestroyHooks(); hooks != nil {
+		t.Errorf("expected no pre_destroy hooks without a hooks config, got %v", hooks)
+	}
+}
@@ -0,0 +1,238 @@
+package workspace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Idle check types
+const (
+	IdleCheckCommand = "command"
+	IdleCheckHTTP    = "http"
+)
+
+// Actions taken on a workspace idle for idle_minutes
+const (
+	IdleActionDestroy   = "destroy"
+	IdleActionHibernate = "hibernate"
+)
+
+// Idle check defaults
+const (
+	DefaultIdleCheckInterval = 5 * time.Minute
+	DefaultIdleCheckTimeout  = 30 * time.Second
+	DefaultHibernationMode   = "hibernation"
+)
+
+// maxIdleCheckResponse limits how much of an HTTP idle check response is read
+const maxIdleCheckResponse = 1 << 20
+
+// IdleCheckConfig measures a deployed workspace's activity, e.g. active sessions or requests per
+// minute. A workspace whose metric stays at or below the threshold for idle_minutes is destroyed
+// or switched to its hibernation mode.
+type IdleCheckConfig struct {
+	Type            string  `json:"type"`                       // "command" or "http"
+	Command         string  `json:"command,omitempty"`          // Shell command printing the metric (command type)
+	URL             string  `json:"url,omitempty"`              // URL returning the metric (http type)
+	Field           string  `json:"field,omitempty"`            // Dotted path of the metric in a JSON response, e.g. data.result.0.value.1
+	Threshold       float64 `json:"threshold,omitempty"`        // Idle while the metric is at or below this (default 0)
+	IdleMinutes     int     `json:"idle_minutes"`               // Minutes idle before the action is taken
+	Interval        string  `json:"interval,omitempty"`         // Time between checks (default 5m)
+	Timeout         string  `json:"timeout,omitempty"`          // Time limit of a check (default 30s)
+	Action          string  `json:"action,omitempty"`           // "destroy" (default) or "hibernate"
+	HibernationMode string  `json:"hibernation_mode,omitempty"` // Mode the hibernate action deploys (default "hibernation")
+}
+
+// GetIdleAction returns the action taken on an idle workspace (default destroy)
+func (c *IdleCheckConfig) GetIdleAction() string {
+	if c.Action == "" {
+		return IdleActionDestroy
+	}
+	return c.Action
+}
+
+// GetHibernationMode returns the mode the hibernate action deploys
+func (c *IdleCheckConfig) GetHibernationMode() string {
+	if c.HibernationMode == "" {
+		return DefaultHibernationMode
+	}
+	return c.HibernationMode
+}
+
+// GetIdleDuration returns how long a workspace must be idle before the action is taken
+func (c *IdleCheckConfig) GetIdleDuration() time.Duration {
+	return time.Duration(c.IdleMinutes) * time.Minute
+}
+
+// GetInterval returns the time between idle checks
+func (c *IdleCheckConfig) GetInterval() time.Duration {
+	return parseDurationOr(c.Interval, DefaultIdleCheckInterval)
+}
+
+// GetTimeout returns the time limit of an idle check
+func (c *IdleCheckConfig) GetTimeout() time.Duration {
+	return parseDurationOr(c.Timeout, DefaultIdleCheckTimeout)
+}
+
+// parseDurationOr parses a validated duration, returning fallback if it is unset
+func parseDurationOr(value string, fallback time.Duration) time.Duration {
+	if duration, err := time.ParseDuration(value); err == nil && duration > 0 {
+		return duration
+	}
+	return fallback
+}
+
+// Measure runs the idle check and returns the activity metric. Commands run with sh in the
+// deployment directory with WORKSPACE_ID and WORKSPACE_DEPLOYMENT_DIR set, and print the metric
+// as their last line of output.
+func (c *IdleCheckConfig) Measure(workspaceName, deploymentDir string) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.GetTimeout())
+	defer cancel()
+
+	switch c.Type {
+	case IdleCheckCommand:
+		cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+		cmd.Dir = deploymentDir
+		cmd.Env = append(os.Environ(),
+			"WORKSPACE_ID="+workspaceName,
+			"WORKSPACE_DEPLOYMENT_DIR="+deploymentDir,
+		)
+		output, err := cmd.Output()
+		if err != nil {
+			return 0, fmt.Errorf("idle check command failed: %w", err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+		return parseIdleMetric(lines[len(lines)-1])
+
+	case IdleCheckHTTP:
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.URL, nil)
+		if err != nil {
+			return 0, fmt.Errorf("invalid idle check URL: %w", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return 0, fmt.Errorf("idle check request failed: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return 0, fmt.Errorf("idle check request failed with status %d", resp.StatusCode)
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxIdleCheckResponse))
+		if err != nil {
+			return 0, fmt.Errorf("failed to read idle check response: %w", err)
+		}
+		if c.Field == "" {
+			return parseIdleMetric(string(body))
+		}
+		return extractIdleMetric(body, c.Field)
+
+	default:
+		return 0, fmt.Errorf("unknown idle check type: %s", c.Type)
+	}
+}
+
+// extractIdleMetric returns the metric at a dotted path in a JSON document. Numeric path
+// elements index arrays, and the metric may be a number or a numeric string, as in Prometheus
+// query results.
+func extractIdleMetric(body []byte, field string) (float64, error) {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return 0, fmt.Errorf("idle check response is not JSON: %w", err)
+	}
+
+	for _, key := range strings.Split(field, ".") {
+		switch node := value.(type) {
+		case map[string]interface{}:
+			value = node[key]
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return 0, fmt.Errorf("field '%s' not found in idle check response", field)
+			}
+			value = node[index]
+		default:
+			return 0, fmt.Errorf("field '%s' not found in idle check response", field)
+		}
+	}
+
+	switch metric := value.(type) {
+	case float64:
+		return metric, nil
+	case string:
+		return parseIdleMetric(metric)
+	case nil:
+		return 0, fmt.Errorf("field '%s' not found in idle check response", field)
+	default:
+		return 0, fmt.Errorf("field '%s' of idle check response is not a number", field)
+	}
+}
+
+// parseIdleMetric parses a metric printed by an idle check
+func parseIdleMetric(text string) (float64, error) {
+	metric, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+	if err != nil {
+		return 0, fmt.Errorf("idle check returned '%s', not a number", strings.TrimSpace(text))
+	}
+	return metric, nil
+}
+
+// validateIdleCheck checks the idle check and that a hibernating workspace has its mode
+func (c *Config) validateIdleCheck() error {
+	check := c.IdleCheck
+	if check == nil {
+		return nil
+	}
+
+	switch check.Type {
+	case IdleCheckCommand:
+		if strings.TrimSpace(check.Command) == "" {
+			return fmt.Errorf("command idle check requires 'command'")
+		}
+	case IdleCheckHTTP:
+		if !strings.HasPrefix(check.URL, "http://") && !strings.HasPrefix(check.URL, "https://") {
+			return fmt.Errorf("http idle check requires an http:// or https:// 'url'")
+		}
+	default:
+		return fmt.Errorf("invalid type '%s' (must be command or http)", check.Type)
+	}
+
+	if check.IdleMinutes <= 0 {
+		return fmt.Errorf("idle_minutes must be positive")
+	}
+	for name, value := range map[string]string{"interval": check.Interval, "timeout": check.Timeout} {
+		if value == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(value); err != nil || duration <= 0 {
+			return fmt.Errorf("invalid %s '%s'", name, value)
+		}
+	}
+
+	switch check.GetIdleAction() {
+	case IdleActionDestroy:
+		if check.HibernationMode != "" {
+			return fmt.Errorf("'hibernation_mode' requires action 'hibernate'")
+		}
+	case IdleActionHibernate:
+		if len(c.ModeSchedules) == 0 {
+			return fmt.Errorf("action 'hibernate' requires mode_schedules")
+		}
+		mode := check.GetHibernationMode()
+		_, scheduled := c.ModeSchedules[mode]
+		_, hasVariables := c.ModeVariables[mode]
+		if !scheduled && !hasVariables {
+			return fmt.Errorf("hibernation mode '%s' is not in mode_schedules or mode_variables", mode)
+		}
+	default:
+		return fmt.Errorf("invalid action '%s' (must be destroy or hibernate)", check.Action)
+	}
+	return nil
+}
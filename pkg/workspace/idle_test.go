@@ -0,0 +1,92 @@
+package workspace
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValidateIdleCheck(t *testing.T) {
+	modes := map[string]interface{}{"busy": "0 8 * * *", "hibernation": "0 20 * * *"}
+	tests := []struct {
+		name    string
+		check   IdleCheckConfig
+		modes   map[string]interface{}
+		wantErr bool
+	}{
+		{"command", IdleCheckConfig{Type: IdleCheckCommand, Command: "who | wc -l", IdleMinutes: 30}, nil, false},
+		{"http with interval", IdleCheckConfig{Type: IdleCheckHTTP, URL: "http://metrics/q", IdleMinutes: 30, Interval: "1m"}, nil, false},
+		{"hibernate", IdleCheckConfig{Type: IdleCheckCommand, Command: "true", IdleMinutes: 30, Action: IdleActionHibernate}, modes, false},
+		{"unknown type", IdleCheckConfig{Type: "ping", IdleMinutes: 30}, nil, true},
+		{"command missing", IdleCheckConfig{Type: IdleCheckCommand, IdleMinutes: 30}, nil, true},
+		{"url without scheme", IdleCheckConfig{Type: IdleCheckHTTP, URL: "metrics/q", IdleMinutes: 30}, nil, true},
+		{"idle_minutes missing", IdleCheckConfig{Type: IdleCheckCommand, Command: "true"}, nil, true},
+		{"invalid interval", IdleCheckConfig{Type: IdleCheckCommand, Command: "true", IdleMinutes: 30, Interval: "often"}, nil, true},
+		{"hibernate without modes", IdleCheckConfig{Type: IdleCheckCommand, Command: "true", IdleMinutes: 30, Action: IdleActionHibernate}, nil, true},
+		{"unknown hibernation mode", IdleCheckConfig{Type: IdleCheckCommand, Command: "true", IdleMinutes: 30, Action: IdleActionHibernate, HibernationMode: "night"}, modes, true},
+		{"hibernation mode with destroy", IdleCheckConfig{Type: IdleCheckCommand, Command: "true", IdleMinutes: 30, HibernationMode: "hibernation"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Enabled: true, IdleCheck: &tt.check}
+			if tt.modes != nil {
+				config.Template = "web-app"
+				config.ModeSchedules = tt.modes
+			} else {
+				config.DeploySchedule = "0 9 * * *"
+			}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMeasureCommand(t *testing.T) {
+	check := &IdleCheckConfig{Type: IdleCheckCommand, Command: `echo checking $WORKSPACE_ID; echo 4`}
+	metric, err := check.Measure("web", t.TempDir())
+	if err != nil || metric != 4 {
+		t.Errorf("Expected metric 4 from the last line, got %g, %v", metric, err)
+	}
+
+	check.Command = "echo none"
+	if _, err := check.Measure("web", t.TempDir()); err == nil {
+		t.Error("Expected an error for a non-numeric metric")
+	}
+
+	check.Command = "exit 1"
+	if _, err := check.Measure("web", t.TempDir()); err == nil {
+		t.Error("Expected an error for a failing command")
+	}
+}
+
+func TestMeasureHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		// Prometheus instant query result
+		fmt.Fprint(w, `{"status":"success","data":{"result":[{"value":[1741593600,"2.5"]}]}}`)
+	}))
+	defer server.Close()
+
+	check := &IdleCheckConfig{Type: IdleCheckHTTP, URL: server.URL + "/query", Field: "data.result.0.value.1"}
+	metric, err := check.Measure("web", t.TempDir())
+	if err != nil || metric != 2.5 {
+		t.Errorf("Expected metric 2.5, got %g, %v", metric, err)
+	}
+
+	check.Field = "data.result.1.value.1"
+	if _, err := check.Measure("web", t.TempDir()); err == nil {
+		t.Error("Expected an error for a missing field")
+	}
+
+	check.URL = server.URL + "/missing"
+	if _, err := check.Measure("web", t.TempDir()); err == nil {
+		t.Error("Expected an error for a failed request")
+	}
+}
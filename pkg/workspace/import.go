@@ -0,0 +1,393 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ImportOptions are the settings of a workspace imported from an existing OpenTofu project
+type ImportOptions struct {
+	FromDir         string // Project directory
+	MoveState       bool   // Move terraform.tfstate instead of copying it
+	Description     string
+	DeploySchedule  string
+	DestroySchedule string
+	Enabled         bool
+}
+
+// ImportedVariable is a variable declared by an imported project
+type ImportedVariable struct {
+	Name      string
+	Source    string // tfvars file the value was taken from, empty if none set it
+	Default   bool   // Declared with a default
+	Sensitive bool   // Declared sensitive or named like a secret; its value is set with vars set
+}
+
+// ImportResult describes what an import brought into the workspace
+type ImportResult struct {
+	Files     []string // Files copied, relative to the workspace directory
+	Variables []ImportedVariable
+	StateFile string // Deployment state file the project's state was written to, empty without one
+	Resources int    // Resources in the imported state
+	Backend   string // Backend of the project's terraform block, whose state stays where it is
+}
+
+// tfvarsFileNames are the variable files OpenTofu loads automatically besides *.auto.tfvars(.json)
+var tfvarsFileNames = []string{"terraform.tfvars", "terraform.tfvars.json"}
+
+// ImportWorkspace creates workspace name from the OpenTofu project in opts.FromDir: its
+// configuration files are copied, values of its tfvars files become config variables, or secret
+// workspace variables for sensitive ones, and its local state becomes the workspace's deployment
+// state. Nothing is left behind if the import fails.
+func ImportWorkspace(name string, opts ImportOptions) (*ImportResult, error) {
+	if name == DefaultsDir {
+		return nil, fmt.Errorf("'%s' holds the defaults of all workspaces and cannot be a workspace", DefaultsDir)
+	}
+	if !HasTFFiles(opts.FromDir) {
+		return nil, fmt.Errorf("no OpenTofu configuration found in %s", opts.FromDir)
+	}
+	wsPath := filepath.Join(getDefaultWorkspacesDir(), name)
+	if _, err := os.Stat(wsPath); err == nil {
+		return nil, fmt.Errorf("workspace '%s' already exists", name)
+	}
+
+	stateDir := getStateDir()
+	deploymentDir := filepath.Join(stateDir, "deployments", name)
+	projectState := filepath.Join(opts.FromDir, "terraform.tfstate")
+	hasState := fileExists(projectState)
+	if hasState && fileExists(filepath.Join(deploymentDir, "terraform.tfstate")) {
+		return nil, fmt.Errorf("%s already has a state file, left by a removed workspace '%s'; destroy it with 'workspacectl prune' first", deploymentDir, name)
+	}
+
+	files, err := parseTFFiles(opts.FromDir)
+	if err != nil {
+		return nil, err
+	}
+	variables, err := detectVariables(files)
+	if err != nil {
+		return nil, err
+	}
+	values, sources, err := readTFVars(opts.FromDir)
+	if err != nil {
+		return nil, err
+	}
+	result := &ImportResult{Backend: detectBackend(files)}
+
+	config := Config{
+		Enabled:     opts.Enabled,
+		Description: opts.Description,
+	}
+	if opts.DeploySchedule != "" {
+		config.DeploySchedule = opts.DeploySchedule
+	}
+	if opts.DestroySchedule != "" {
+		config.DestroySchedule = opts.DestroySchedule
+	}
+	secrets := make(map[string]string)
+	for _, variable := range variables {
+		value, ok := values[variable.Name]
+		if ok {
+			variable.Source = sources[variable.Name]
+			if secret, isString := value.(string); variable.Sensitive && isString {
+				secrets[variable.Name] = secret
+			} else {
+				if config.Variables == nil {
+					config.Variables = make(map[string]interface{})
+				}
+				config.Variables[variable.Name] = value
+			}
+		}
+		result.Variables = append(result.Variables, variable)
+	}
+
+	if err := os.MkdirAll(wsPath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+	imported, deploymentExisted := false, fileExists(deploymentDir)
+	defer func() {
+		if !imported {
+			_ = os.RemoveAll(wsPath)
+			if !deploymentExisted {
+				_ = os.RemoveAll(deploymentDir)
+			}
+		}
+	}()
+
+	if result.Files, err = copyProjectFiles(opts.FromDir, wsPath); err != nil {
+		return nil, err
+	}
+	configData, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(wsPath, "config.json"), append(configData, '\n'), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+
+	for key, value := range secrets {
+		if err := SetVar(stateDir, name, key, value, true); err != nil {
+			return nil, fmt.Errorf("failed to set variable '%s': %w", key, err)
+		}
+	}
+
+	// The state goes last, so a failed import never leaves a project without its state
+	if hasState {
+		result.StateFile = filepath.Join(deploymentDir, "terraform.tfstate")
+		if err := importState(opts.FromDir, deploymentDir, opts.MoveState); err != nil {
+			return nil, err
+		}
+		// The state may have been moved already; an unreadable one is left to the first deploy
+		ws := Workspace{Name: name, Config: config}
+		result.Resources, _ = ws.GetStateResourceCount()
+	}
+
+	imported = true
+	return result, nil
+}
+
+// copyProjectFiles copies a project into a workspace directory, leaving out hidden directories
+// such as .terraform and .git, state, plan and tfvars files. Returns the files copied.
+func copyProjectFiles(fromDir, wsPath string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(fromDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(fromDir, path)
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != fromDir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(filepath.Join(wsPath, rel), 0755)
+		}
+		if skipImportFile(entry.Name()) || !entry.Type().IsRegular() {
+			return nil
+		}
+		if err := copyFile(path, filepath.Join(wsPath, rel)); err != nil {
+			return fmt.Errorf("failed to copy %s: %w", rel, err)
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// skipImportFile reports whether a project file stays out of the workspace: state is imported
+// separately and tfvars values become config variables
+func skipImportFile(name string) bool {
+	return strings.HasPrefix(name, "terraform.tfstate") ||
+		strings.HasSuffix(name, ".tfplan") ||
+		strings.HasSuffix(name, ".tfvars") ||
+		strings.HasSuffix(name, ".tfvars.json")
+}
+
+// importState copies or moves a project's terraform.tfstate and its backup into the deployment directory
+func importState(fromDir, deploymentDir string, move bool) error {
+	if err := os.MkdirAll(deploymentDir, 0755); err != nil {
+		return fmt.Errorf("failed to create deployment directory: %w", err)
+	}
+	for _, name := range []string{"terraform.tfstate", "terraform.tfstate.backup"} {
+		src, dst := filepath.Join(fromDir, name), filepath.Join(deploymentDir, name)
+		if !fileExists(src) {
+			continue
+		}
+		if err := copyFile(src, dst); err != nil {
+			return fmt.Errorf("failed to import %s: %w", name, err)
+		}
+	}
+	if move {
+		for _, name := range []string{"terraform.tfstate", "terraform.tfstate.backup"} {
+			if err := os.Remove(filepath.Join(fromDir, name)); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s from the project: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// copyFile copies a file, keeping its permissions
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// fileExists reports whether path exists
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// variableSchema reads the settings of a variable block that matter to an import
+var variableSchema = &hcl.BodySchema{
+	Attributes: []hcl.AttributeSchema{{Name: "default"}, {Name: "sensitive"}},
+}
+
+// parseTFFiles parses the configuration files of the root module in dir
+func parseTFFiles(dir string) ([]*hcl.File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	parser := hclparse.NewParser()
+	var files []*hcl.File
+	for _, entry := range entries {
+		if entry.IsDir() || !IsTFFile(entry.Name()) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		var file *hcl.File
+		var diags hcl.Diagnostics
+		if strings.HasSuffix(entry.Name(), ".json") {
+			file, diags = parser.ParseJSONFile(path)
+		} else {
+			file, diags = parser.ParseHCLFile(path)
+		}
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid OpenTofu configuration: %w", diags)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// detectVariables returns the variables declared by the files of a root module, sorted by name
+func detectVariables(files []*hcl.File) ([]ImportedVariable, error) {
+	var variables []ImportedVariable
+	for _, file := range files {
+		content, _, diags := file.Body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{{Type: "variable", LabelNames: []string{"name"}}},
+		})
+		if diags.HasErrors() {
+			return nil, fmt.Errorf("invalid OpenTofu configuration: %w", diags)
+		}
+		for _, block := range content.Blocks {
+			variable := ImportedVariable{Name: block.Labels[0], Sensitive: IsSecretVar(block.Labels[0], nil)}
+			attributes, _, diags := block.Body.PartialContent(variableSchema)
+			if diags.HasErrors() {
+				return nil, fmt.Errorf("invalid variable '%s': %w", variable.Name, diags)
+			}
+			_, variable.Default = attributes.Attributes["default"]
+			if sensitive, ok := attributes.Attributes["sensitive"]; ok {
+				value, diags := sensitive.Expr.Value(nil)
+				if !diags.HasErrors() && value.Type() == cty.Bool && value.IsKnown() && !value.IsNull() && value.True() {
+					variable.Sensitive = true
+				}
+			}
+			variables = append(variables, variable)
+		}
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables, nil
+}
+
+// detectBackend returns the type of the backend configured in the terraform block of a root
+// module's files, empty for local state
+func detectBackend(files []*hcl.File) string {
+	for _, file := range files {
+		content, _, _ := file.Body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{{Type: "terraform"}},
+		})
+		for _, block := range content.Blocks {
+			inner, _, _ := block.Body.PartialContent(&hcl.BodySchema{
+				Blocks: []hcl.BlockHeaderSchema{{Type: "backend", LabelNames: []string{"type"}}},
+			})
+			for _, backend := range inner.Blocks {
+				if backend.Labels[0] != "local" {
+					return backend.Labels[0]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// readTFVars reads the variable values of the tfvars files OpenTofu loads automatically in dir, in
+// its order, so later files override earlier ones. Returns the values and the file setting each.
+func readTFVars(dir string) (map[string]interface{}, map[string]string, error) {
+	names := append([]string(nil), tfvarsFileNames...)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	var autoNames []string
+	for _, entry := range entries {
+		if !entry.IsDir() && (strings.HasSuffix(entry.Name(), ".auto.tfvars") || strings.HasSuffix(entry.Name(), ".auto.tfvars.json")) {
+			autoNames = append(autoNames, entry.Name())
+		}
+	}
+	sort.Strings(autoNames)
+	names = append(names, autoNames...)
+
+	parser := hclparse.NewParser()
+	values := make(map[string]interface{})
+	sources := make(map[string]string)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if !fileExists(path) {
+			continue
+		}
+		var file *hcl.File
+		var diags hcl.Diagnostics
+		if strings.HasSuffix(name, ".json") {
+			file, diags = parser.ParseJSONFile(path)
+		} else {
+			file, diags = parser.ParseHCLFile(path)
+		}
+		if diags.HasErrors() {
+			return nil, nil, fmt.Errorf("invalid variables file: %w", diags)
+		}
+		attributes, diags := file.Body.JustAttributes()
+		if diags.HasErrors() {
+			return nil, nil, fmt.Errorf("invalid variables file: %w", diags)
+		}
+		for key, attribute := range attributes {
+			value, diags := attribute.Expr.Value(nil)
+			if diags.HasErrors() {
+				return nil, nil, fmt.Errorf("invalid value of '%s' in %s: %w", key, name, diags)
+			}
+			encoded, err := ctyjson.Marshal(value, value.Type())
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid value of '%s' in %s: %w", key, name, err)
+			}
+			var decoded interface{}
+			if err := json.Unmarshal(encoded, &decoded); err != nil {
+				return nil, nil, fmt.Errorf("invalid value of '%s' in %s: %w", key, name, err)
+			}
+			values[key], sources[key] = decoded, name
+		}
+	}
+	return values, sources, nil
+}
@@ -0,0 +1,147 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeImportTestProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestImportWorkspace(t *testing.T) {
+	configDir, stateDir := t.TempDir(), t.TempDir()
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_WORKSPACES_DIR", "")
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+
+	project := writeImportTestProject(t, map[string]string{
+		"main.tf": `module "network" {
+  source = "./modules/network"
+}
+`,
+		"variables.tf": `variable "region" {
+  default = "fra1"
+}
+
+variable "instance_count" {
+  type = number
+  validation {
+    condition     = var.instance_count > 0
+    error_message = "At least one instance."
+  }
+}
+
+variable "db_password" {
+  type      = string
+  sensitive = true
+}
+
+variable "owner" {}
+`,
+		"modules/network/main.tf":  "# network\n",
+		"terraform.tfvars":         "instance_count = 2\ndb_password = \"s3cret\"\n",
+		"prod.auto.tfvars":         "region = \"ams3\"\n",
+		"terraform.tfstate":        `{"version": 4, "resources": [{"type": "a"}, {"type": "b"}]}`,
+		"terraform.tfstate.backup": `{"version": 4, "resources": []}`,
+		".terraform/providers/x":   "cache",
+	})
+
+	result, err := ImportWorkspace("billing", ImportOptions{FromDir: project, MoveState: true, DeploySchedule: "0 8 * * 1-5", Enabled: true})
+	if err != nil {
+		t.Fatalf("failed to import: %v", err)
+	}
+
+	expectedFiles := []string{"main.tf", filepath.Join("modules", "network", "main.tf"), "variables.tf"}
+	if !reflect.DeepEqual(result.Files, expectedFiles) {
+		t.Errorf("expected files %v, got %v", expectedFiles, result.Files)
+	}
+	expectedVariables := []ImportedVariable{
+		{Name: "db_password", Source: "terraform.tfvars", Sensitive: true},
+		{Name: "instance_count", Source: "terraform.tfvars"},
+		{Name: "owner"},
+		{Name: "region", Source: "prod.auto.tfvars", Default: true},
+	}
+	if !reflect.DeepEqual(result.Variables, expectedVariables) {
+		t.Errorf("expected variables %+v, got %+v", expectedVariables, result.Variables)
+	}
+
+	config, err := loadConfig(filepath.Join(configDir, "workspaces", "billing", "config.json"))
+	if err != nil {
+		t.Fatalf("failed to load the generated config: %v", err)
+	}
+	expectedConfigVars := map[string]interface{}{"instance_count": float64(2), "region": "ams3"}
+	if !reflect.DeepEqual(config.Variables, expectedConfigVars) || config.DeploySchedule != "0 8 * * 1-5" || !config.Enabled {
+		t.Errorf("unexpected config %+v", config)
+	}
+	if err := config.Validate(); err != nil {
+		t.Errorf("expected the generated config to be valid, got %v", err)
+	}
+
+	// The sensitive value is kept out of config.json
+	vars, err := LoadVars(stateDir, "billing")
+	if err != nil || vars["db_password"] != "s3cret" {
+		t.Errorf("expected db_password as a workspace variable, got %v, %v", vars, err)
+	}
+
+	// --move-state takes the state away from the project
+	if result.Resources != 2 || result.StateFile != filepath.Join(stateDir, "deployments", "billing", "terraform.tfstate") {
+		t.Errorf("unexpected state import %+v", result)
+	}
+	for _, name := range []string{"terraform.tfstate", "terraform.tfstate.backup"} {
+		if fileExists(filepath.Join(project, name)) {
+			t.Errorf("expected %s to be moved out of the project", name)
+		}
+		if !fileExists(filepath.Join(stateDir, "deployments", "billing", name)) {
+			t.Errorf("expected %s in the deployment directory", name)
+		}
+	}
+
+	if _, err := ImportWorkspace("billing", ImportOptions{FromDir: project}); err == nil {
+		t.Error("expected importing over an existing workspace to fail")
+	}
+}
+
+func TestImportWorkspaceFailureLeavesNothing(t *testing.T) {
+	configDir, stateDir := t.TempDir(), t.TempDir()
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_WORKSPACES_DIR", "")
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+
+	project := writeImportTestProject(t, map[string]string{
+		"main.tf":           "resource \"terraform_data\" \"x\" {}\n",
+		"terraform.tfvars":  "count = var.other\n",
+		"terraform.tfstate": `{"version": 4, "resources": []}`,
+	})
+	if _, err := ImportWorkspace("broken", ImportOptions{FromDir: project, MoveState: true}); err == nil {
+		t.Fatal("expected the import to fail for tfvars that aren't values")
+	}
+	if fileExists(filepath.Join(configDir, "workspaces", "broken")) || fileExists(filepath.Join(stateDir, "deployments", "broken")) {
+		t.Error("expected a failed import to leave nothing behind")
+	}
+	if !fileExists(filepath.Join(project, "terraform.tfstate")) {
+		t.Error("expected a failed import to keep the project's state")
+	}
+
+	// Copying keeps the project's state and a remote backend is reported
+	remote := writeImportTestProject(t, map[string]string{
+		"main.tf": "terraform {\n  backend \"s3\" {\n    bucket = \"state\"\n  }\n}\n",
+	})
+	result, err := ImportWorkspace("remote", ImportOptions{FromDir: remote})
+	if err != nil || result.Backend != "s3" || result.StateFile != "" {
+		t.Errorf("expected the s3 backend to be detected, got %+v, %v", result, err)
+	}
+}
@@ -0,0 +1,125 @@
+package workspace
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// labelPattern matches label keys and values: letters, digits, '-', '_', '.' and '/', starting
+// and ending with a letter or digit
+var labelPattern = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._/-]*[a-zA-Z0-9])?$`)
+
+// LabelPolicy applies defaults to every workspace whose labels match its selector, e.g. a
+// destroy_schedule for all "env=dev" workspaces. The defaults are those of a tier, and a
+// workspace's own settings always take precedence.
+type LabelPolicy struct {
+	Selector string `json:"selector"` // Comma-separated requirements, e.g. "env=dev,team!=data"
+	TierDefaults
+}
+
+// Validate checks the policy's selector and defaults
+func (p *LabelPolicy) Validate() error {
+	if strings.TrimSpace(p.Selector) == "" {
+		return fmt.Errorf("selector is required")
+	}
+	if _, err := ParseLabelSelector(p.Selector); err != nil {
+		return err
+	}
+	return p.TierDefaults.Validate()
+}
+
+// Matches returns true if the policy applies to a workspace with these labels
+func (p *LabelPolicy) Matches(labels map[string]string) bool {
+	selector, err := ParseLabelSelector(p.Selector)
+	return err == nil && selector.Matches(labels)
+}
+
+// labelRequirement is one requirement of a label selector
+type labelRequirement struct {
+	key    string
+	value  string
+	negate bool // key!=value; also matches workspaces without the label
+	exists bool // Only the key is given, the label must be set
+}
+
+// LabelSelector selects workspaces by their labels; all its requirements must hold
+type LabelSelector []labelRequirement
+
+// ParseLabelSelector parses a selector of comma-separated "key=value", "key!=value" and "key"
+// requirements
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var requirements LabelSelector
+	for _, part := range strings.Split(selector, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("invalid label selector '%s': empty requirement", selector)
+		}
+
+		var requirement labelRequirement
+		if key, value, ok := strings.Cut(part, "!="); ok {
+			requirement = labelRequirement{key: key, value: value, negate: true}
+		} else if key, value, ok := strings.Cut(part, "="); ok {
+			requirement = labelRequirement{key: key, value: value}
+		} else {
+			requirement = labelRequirement{key: part, exists: true}
+		}
+
+		requirement.key = strings.TrimSpace(requirement.key)
+		requirement.value = strings.TrimSpace(requirement.value)
+		if !labelPattern.MatchString(requirement.key) {
+			return nil, fmt.Errorf("invalid label selector '%s': invalid key '%s'", selector, requirement.key)
+		}
+		if !requirement.exists && requirement.value != "" && !labelPattern.MatchString(requirement.value) {
+			return nil, fmt.Errorf("invalid label selector '%s': invalid value '%s'", selector, requirement.value)
+		}
+		requirements = append(requirements, requirement)
+	}
+	return requirements, nil
+}
+
+// Matches returns true if the labels meet all requirements of the selector
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, requirement := range s {
+		value, ok := labels[requirement.key]
+		switch {
+		case requirement.exists:
+			if !ok {
+				return false
+			}
+		case requirement.negate:
+			if ok && value == requirement.value {
+				return false
+			}
+		default:
+			if !ok || value != requirement.value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// FormatLabels lists labels as "key=value" pairs sorted by key, separated by commas
+func FormatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// validateLabels checks the keys and values of the workspace's labels
+func (c *Config) validateLabels() error {
+	for key, value := range c.Labels {
+		if !labelPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key '%s'", key)
+		}
+		if value != "" && !labelPattern.MatchString(value) {
+			return fmt.Errorf("invalid value '%s' of label '%s'", value, key)
+		}
+	}
+	return nil
+}
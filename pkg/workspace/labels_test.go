@@ -0,0 +1,68 @@
+package workspace
+
+import "testing"
+
+func TestLabelSelectorMatches(t *testing.T) {
+	labels := map[string]string{"env": "dev", "team": "web"}
+
+	tests := []struct {
+		selector string
+		expected bool
+	}{
+		{"env=dev", true},
+		{"env=dev,team=web", true},
+		{"env=dev, team=data", false},
+		{"env!=prod", true},
+		{"env!=dev", false},
+		{"owner!=alice", true},
+		{"team", true},
+		{"owner", false},
+		{"owner=", false},
+	}
+	for _, tt := range tests {
+		selector, err := ParseLabelSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("ParseLabelSelector(%q) failed: %v", tt.selector, err)
+		}
+		if got := selector.Matches(labels); got != tt.expected {
+			t.Errorf("%q matches %v = %t, expected %t", tt.selector, labels, got, tt.expected)
+		}
+	}
+
+	for _, selector := range []string{"", "env=dev,", "=dev", "env=dev value", "-env=dev"} {
+		if _, err := ParseLabelSelector(selector); err == nil {
+			t.Errorf("Expected error for selector %q", selector)
+		}
+	}
+}
+
+func TestLabelValidation(t *testing.T) {
+	config := Config{DeploySchedule: "0 9 * * *", Labels: map[string]string{"env": "dev", "cost-center": "cc/42", "owner": ""}}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected valid labels, got %v", err)
+	}
+
+	for _, labels := range []map[string]string{{"env name": "dev"}, {"env": "dev!"}, {"": "dev"}} {
+		config.Labels = labels
+		if err := config.Validate(); err == nil {
+			t.Errorf("Expected error for labels %v", labels)
+		}
+	}
+
+	invalid := []LabelPolicy{
+		{TierDefaults: TierDefaults{DestroySchedule: "0 19 * * *"}},
+		{Selector: "env=dev,", TierDefaults: TierDefaults{DestroySchedule: "0 19 * * *"}},
+		{Selector: "env=dev", TierDefaults: TierDefaults{MaxLifetime: "soon"}},
+	}
+	for _, policy := range invalid {
+		if err := policy.Validate(); err == nil {
+			t.Errorf("Expected error for label policy %+v", policy)
+		}
+	}
+}
+
+func TestFormatLabels(t *testing.T) {
+	if got := FormatLabels(map[string]string{"team": "web", "env": "dev"}); got != "env=dev,team=web" {
+		t.Errorf("Expected labels sorted by key, got %q", got)
+	}
+}
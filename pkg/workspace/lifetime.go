@@ -0,0 +1,143 @@
+package workspace
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Actions taken when a deployment outlives max_lifetime
+const (
+	LifetimeActionDestroy = "destroy"
+	LifetimeActionAlert   = "alert"
+)
+
+// HasMaxLifetime returns true if deployments of the workspace have a lifetime limit
+func (c *Config) HasMaxLifetime() bool {
+	return c.MaxLifetime != ""
+}
+
+// GetMaxLifetime returns how long a deployment may live before max_lifetime_action is taken
+func (c *Config) GetMaxLifetime() (time.Duration, error) {
+	lifetime, err := time.ParseDuration(c.MaxLifetime)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_lifetime '%s': %w", c.MaxLifetime, err)
+	}
+	return lifetime, nil
+}
+
+// GetMaxLifetimeAction returns the action for deployments older than max_lifetime (default destroy)
+func (c *Config) GetMaxLifetimeAction() string {
+	if c.MaxLifetimeAction == "" {
+		return LifetimeActionDestroy
+	}
+	return c.MaxLifetimeAction
+}
+
+// DestroysOnMaxLifetime returns true if deployments are destroyed rather than alerted on once too old
+func (c *Config) DestroysOnMaxLifetime() bool {
+	return c.GetMaxLifetimeAction() == LifetimeActionDestroy
+}
+
+// validateMaxLifetime validates the deployment lifetime limit
+func (c *Config) validateMaxLifetime() error {
+	if !c.HasMaxLifetime() {
+		if c.MaxLifetimeAction != "" {
+			return fmt.Errorf("'max_lifetime_action' requires 'max_lifetime'")
+		}
+		return nil
+	}
+
+	lifetime, err := c.GetMaxLifetime()
+	if err != nil {
+		return err
+	}
+	if lifetime <= 0 {
+		return fmt.Errorf("max_lifetime must be positive")
+	}
+
+	switch c.GetMaxLifetimeAction() {
+	case LifetimeActionDestroy, LifetimeActionAlert:
+		return nil
+	default:
+		return fmt.Errorf("invalid max_lifetime_action '%s' (must be destroy or alert)", c.MaxLifetimeAction)
+	}
+}
+
+// HasTTL returns true if the workspace is destroyed a fixed time after its last deploy
+func (c *Config) HasTTL() bool {
+	return c.TTL != ""
+}
+
+// GetTTL returns how long after its last deploy the workspace is destroyed
+func (c *Config) GetTTL() (time.Duration, error) {
+	ttl, err := time.ParseDuration(c.TTL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid ttl '%s': %w", c.TTL, err)
+	}
+	return ttl, nil
+}
+
+// validateTTL validates the time to live after deploys
+func (c *Config) validateTTL() error {
+	if !c.HasTTL() {
+		return nil
+	}
+
+	ttl, err := c.GetTTL()
+	if err != nil {
+		return err
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("ttl must be positive")
+	}
+	return nil
+}
+
+// ParseRelativeSchedule parses a destroy schedule relative to the last successful deploy, such as
+// "+8h". ok is false for CRON expressions and events.
+func ParseRelativeSchedule(schedule string) (offset time.Duration, ok bool, err error) {
+	value, ok := strings.CutPrefix(strings.TrimSpace(schedule), "+")
+	if !ok {
+		return 0, false, nil
+	}
+	offset, err = time.ParseDuration(value)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid relative schedule '%s': %w", schedule, err)
+	}
+	if offset <= 0 {
+		return 0, true, fmt.Errorf("relative schedule must be positive: %s", schedule)
+	}
+	return offset, true, nil
+}
+
+// validateRelativeSchedules validates relative schedules, which only destroy schedules support
+func (c *Config) validateRelativeSchedules() error {
+	if c.DestroySchedule != nil {
+		schedules, err := c.GetDestroySchedules()
+		if err != nil {
+			return nil // Reported with the other schedule errors
+		}
+		for _, schedule := range schedules {
+			if _, _, err := ParseRelativeSchedule(schedule); err != nil {
+				return err
+			}
+		}
+	}
+
+	var others []string
+	if c.DeploySchedule != nil {
+		deploy, _ := c.GetDeploySchedules()
+		others = append(others, deploy...)
+	}
+	modes, _ := c.GetModeSchedules()
+	for _, schedules := range modes {
+		others = append(others, schedules...)
+	}
+	for _, schedule := range others {
+		if _, ok, _ := ParseRelativeSchedule(schedule); ok {
+			return fmt.Errorf("relative schedule '%s' is only supported in destroy_schedule", schedule)
+		}
+	}
+	return nil
+}
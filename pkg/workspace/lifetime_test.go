@@ -0,0 +1,119 @@
+package workspace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMaxLifetimeDefaults(t *testing.T) {
+	config := Config{MaxLifetime: "72h"}
+
+	lifetime, err := config.GetMaxLifetime()
+	if err != nil {
+		t.Fatalf("Failed to parse max_lifetime: %v", err)
+	}
+	if lifetime != 72*time.Hour {
+		t.Errorf("Expected 72h, got %v", lifetime)
+	}
+	if !config.DestroysOnMaxLifetime() {
+		t.Error("Expected destroy to be the default action")
+	}
+
+	config.MaxLifetimeAction = LifetimeActionAlert
+	if config.DestroysOnMaxLifetime() {
+		t.Error("Expected alert action not to destroy")
+	}
+}
+
+func TestValidateMaxLifetime(t *testing.T) {
+	tests := []struct {
+		name      string
+		lifetime  string
+		action    string
+		expectErr bool
+	}{
+		{"unset", "", "", false},
+		{"destroy", "72h", "destroy", false},
+		{"alert", "168h", "alert", false},
+		{"invalid duration", "3 days", "", true},
+		{"zero", "0s", "", true},
+		{"unknown action", "72h", "email", true},
+		{"action without lifetime", "", "alert", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{
+				DeploySchedule:    "0 9 * * *",
+				MaxLifetime:       tt.lifetime,
+				MaxLifetimeAction: tt.action,
+			}
+			err := config.Validate()
+			if tt.expectErr && err == nil {
+				t.Error("Expected validation error")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		ttl       string
+		expectErr bool
+	}{
+		{"unset", "", false},
+		{"hours", "4h", false},
+		{"invalid duration", "4 hours", true},
+		{"negative", "-1h", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{DeploySchedule: false, TTL: tt.ttl}
+			err := config.Validate()
+			if tt.expectErr && err == nil {
+				t.Error("Expected validation error")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateRelativeSchedules(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    Config
+		expectErr bool
+	}{
+		{"relative destroy", Config{DeploySchedule: "0 9 * * 1-5", DestroySchedule: "+8h"}, false},
+		{"relative and cron destroy", Config{DeploySchedule: "0 9 * * 1-5", DestroySchedule: []interface{}{"+8h", "0 22 * * *"}}, false},
+		{"invalid offset", Config{DeploySchedule: "0 9 * * 1-5", DestroySchedule: "+8 hours"}, true},
+		{"zero offset", Config{DeploySchedule: "0 9 * * 1-5", DestroySchedule: "+0s"}, true},
+		{"relative deploy", Config{DeploySchedule: "+1h", DestroySchedule: false}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.expectErr && err == nil {
+				t.Error("Expected validation error")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("Unexpected validation error: %v", err)
+			}
+		})
+	}
+
+	if offset, ok, err := ParseRelativeSchedule("+90m"); !ok || err != nil || offset != 90*time.Minute {
+		t.Errorf("Expected a 90m offset, got %v %v %v", offset, ok, err)
+	}
+	if _, ok, _ := ParseRelativeSchedule("0 18 * * *"); ok {
+		t.Error("Expected a CRON expression not to be relative")
+	}
+}
@@ -0,0 +1,28 @@
+package workspace
+
+import "provisioner/pkg/lint"
+
+// LintConfig enables linting of the workspace's OpenTofu configuration before deploys
+type LintConfig struct {
+	PreDeploy      bool     `json:"pre_deploy"`                 // Lint after init and stop the deploy on errors
+	FailOnWarnings bool     `json:"fail_on_warnings,omitempty"` // Also stop the deploy on warnings
+	Skip           []string `json:"skip,omitempty"`             // Rules to ignore, e.g. "missing-required-version"
+}
+
+// GetLintSkip returns the lint rules the workspace ignores
+func (c *Config) GetLintSkip() []string {
+	if c.Lint == nil {
+		return nil
+	}
+	return c.Lint.Skip
+}
+
+// IsLintGateEnabled reports whether deploys are linted first
+func (c *Config) IsLintGateEnabled() bool {
+	return c.Lint != nil && c.Lint.PreDeploy
+}
+
+// validateLintConfig checks that skipped rules exist
+func validateLintConfig(cfg *LintConfig) error {
+	return lint.ValidateSkip(cfg.Skip)
+}
@@ -0,0 +1,180 @@
+package workspace
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// parsedConfigs caches parsed workspace configs across LoadWorkspaces calls, so reloads only parse
+// configs that changed
+var parsedConfigs = &configCache{entries: make(map[string]*cachedConfig)}
+
+// configCache holds parsed configs by path
+type configCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedConfig
+}
+
+// cachedConfig is a parsed config with the file attributes and content hash it was parsed from,
+// and the hash of the defaults merged into it
+type cachedConfig struct {
+	modTime     time.Time
+	size        int64
+	sum         [sha256.Size]byte
+	defaultsSum [sha256.Size]byte
+	config      Config
+}
+
+// load returns the config at configPath merged with defaults. A file with the cached modification
+// time and size is not read at all; one whose content hashes to the cached sum, e.g. after a
+// touch, is not parsed again. Changed defaults parse every config again.
+func (c *configCache) load(configPath string, info os.FileInfo, defaults *configDefaults) (Config, error) {
+	var defaultsSum [sha256.Size]byte
+	if defaults != nil {
+		defaultsSum = defaults.sum
+	}
+
+	c.mu.Lock()
+	entry := c.entries[configPath]
+	c.mu.Unlock()
+	if entry != nil && entry.defaultsSum != defaultsSum {
+		entry = nil
+	}
+
+	if entry != nil && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+		return entry.config.clone(), nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+	sum := sha256.Sum256(data)
+
+	var config Config
+	if entry != nil && entry.sum == sum {
+		config = entry.config
+	} else if config, _, err = parseConfig(configPath, data, defaults); err != nil {
+		return Config{}, err
+	}
+
+	c.mu.Lock()
+	c.entries[configPath] = &cachedConfig{modTime: info.ModTime(), size: info.Size(), sum: sum, defaultsSum: defaultsSum, config: config}
+	c.mu.Unlock()
+	return config.clone(), nil
+}
+
+// retain drops cached configs of workspacesDir's workspaces that are no longer present
+func (c *configCache) retain(workspacesDir string, names []string) {
+	present := make(map[string]bool, len(names))
+	for _, name := range names {
+		present[filepath.Join(workspacesDir, name)] = true
+	}
+	prefix := filepath.Clean(workspacesDir) + string(filepath.Separator)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for path := range c.entries {
+		if strings.HasPrefix(path, prefix) && !present[filepath.Dir(path)] {
+			delete(c.entries, path)
+		}
+	}
+}
+
+// clone copies a config so callers can fill in defaults (see ApplyTierDefaults) without changing
+// the cached one
+func (c Config) clone() Config {
+	if c.Jobs != nil {
+		c.Jobs = append([]JobConfig(nil), c.Jobs...)
+	}
+	if c.RunToCompletion != nil {
+		runToCompletion := *c.RunToCompletion
+		c.RunToCompletion = &runToCompletion
+	}
+	return c
+}
+
+// loadResult is the outcome of loading one workspace directory
+type loadResult struct {
+	workspace *Workspace // nil if the directory is skipped
+	warning   string     // Why the directory was skipped
+	err       error      // Invalid job dependencies, which fail the whole load
+}
+
+// loadWorkspaceDirs loads the named directories of workspacesDir in parallel, returning their
+// results in the order of names
+func loadWorkspaceDirs(workspacesDir string, names []string, defaults *configDefaults) []loadResult {
+	results := make([]loadResult, len(names))
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(names) {
+		workers = len(names)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = loadWorkspaceDir(workspacesDir, names[i], defaults)
+			}
+		}()
+	}
+	for i := range names {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// loadWorkspaceDir loads and validates the workspace in one directory
+func loadWorkspaceDir(workspacesDir, name string, defaults *configDefaults) loadResult {
+	wsPath := filepath.Join(workspacesDir, name)
+	configPath, err := findConfigFile(wsPath)
+	if err != nil {
+		return loadResult{warning: fmt.Sprintf("failed to load config for %s: %v", name, err)}
+	}
+
+	// Directories without a config file are not workspaces
+	info, err := os.Stat(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return loadResult{}
+		}
+		return loadResult{warning: fmt.Sprintf("failed to load config for %s: %v", name, err)}
+	}
+
+	config, err := parsedConfigs.load(configPath, info, defaults)
+	if err != nil {
+		return loadResult{warning: fmt.Sprintf("failed to load config for %s: %v", name, err)}
+	}
+
+	ws := Workspace{
+		Name:   name, // Use folder name as workspace name
+		Config: config,
+		Path:   wsPath,
+	}
+
+	// Validate that the workspace has either local .tf files or a template.
+	// Workspaces whose template is missing are kept so they show up with their own status.
+	if !ws.HasTFConfig() && ws.Config.Template == "" {
+		return loadResult{warning: fmt.Sprintf("workspace %s has no .tf files and no template specified", name)}
+	}
+
+	// Validate job dependencies for circular dependencies
+	if err := ValidateJobDependencies(ws.Config.Jobs); err != nil {
+		return loadResult{err: err}
+	}
+
+	return loadResult{workspace: &ws}
+}
@@ -0,0 +1,163 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeLoaderTestWorkspace(t *testing.T, dir, name, config string) string {
+	t.Helper()
+	wsDir := filepath.Join(dir, name)
+	if err := os.MkdirAll(wsDir, 0755); err != nil {
+		t.Fatalf("failed to create workspace directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(wsDir, "main.tf"), []byte("# test tf"), 0644); err != nil {
+		t.Fatalf("failed to write main.tf: %v", err)
+	}
+	configPath := filepath.Join(wsDir, "config.json")
+	if err := os.WriteFile(configPath, []byte(config), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return configPath
+}
+
+func TestLoadWorkspacesKeepsDirectoryOrder(t *testing.T) {
+	tempDir := t.TempDir()
+	for i := 0; i < 50; i++ {
+		writeLoaderTestWorkspace(t, tempDir, fmt.Sprintf("ws-%02d", i), `{"enabled": true, "deploy_schedule": "0 9 * * *"}`)
+	}
+
+	workspaces, err := LoadWorkspaces(tempDir)
+	if err != nil {
+		t.Fatalf("failed to load workspaces: %v", err)
+	}
+	if len(workspaces) != 50 {
+		t.Fatalf("expected 50 workspaces, got %d", len(workspaces))
+	}
+	for i, ws := range workspaces {
+		if expected := fmt.Sprintf("ws-%02d", i); ws.Name != expected {
+			t.Fatalf("expected workspace %d to be %s, got %s", i, expected, ws.Name)
+		}
+	}
+}
+
+func TestLoadWorkspacesCachesConfigs(t *testing.T) {
+	tempDir := t.TempDir()
+	configPath := writeLoaderTestWorkspace(t, tempDir, "cached", `{"enabled": true, "description": "first", "jobs": [{"name": "backup", "type": "command", "command": "true", "schedule": "0 2 * * *"}]}`)
+
+	workspaces, err := LoadWorkspaces(tempDir)
+	if err != nil {
+		t.Fatalf("failed to load workspaces: %v", err)
+	}
+
+	// Defaults filled into a loaded config must not leak into the cache
+	workspaces[0].Config.ApplyTierDefaults(TierDefaults{JobTimeout: "1h"})
+
+	workspaces, err = LoadWorkspaces(tempDir)
+	if err != nil {
+		t.Fatalf("failed to reload workspaces: %v", err)
+	}
+	if timeout := workspaces[0].Config.Jobs[0].Timeout; timeout != "" {
+		t.Errorf("expected cached config to be unchanged, got job timeout %q", timeout)
+	}
+
+	// A changed config is parsed again even if its modification time is unchanged
+	info, err := os.Stat(configPath)
+	if err != nil {
+		t.Fatalf("failed to stat config: %v", err)
+	}
+	if err := os.WriteFile(configPath, []byte(`{"enabled": true, "description": "second, longer"}`), 0644); err != nil {
+		t.Fatalf("failed to rewrite config: %v", err)
+	}
+	if err := os.Chtimes(configPath, time.Now(), info.ModTime()); err != nil {
+		t.Fatalf("failed to reset modification time: %v", err)
+	}
+
+	workspaces, err = LoadWorkspaces(tempDir)
+	if err != nil {
+		t.Fatalf("failed to reload workspaces: %v", err)
+	}
+	if description := workspaces[0].Config.Description; description != "second, longer" {
+		t.Errorf("expected changed config to be reloaded, got description %q", description)
+	}
+
+	// Removed workspaces are dropped from the cache
+	if err := os.RemoveAll(filepath.Dir(configPath)); err != nil {
+		t.Fatalf("failed to remove workspace: %v", err)
+	}
+	if _, err := LoadWorkspaces(tempDir); err != nil {
+		t.Fatalf("failed to reload workspaces: %v", err)
+	}
+	parsedConfigs.mu.Lock()
+	_, cached := parsedConfigs.entries[configPath]
+	parsedConfigs.mu.Unlock()
+	if cached {
+		t.Error("expected removed workspace's config to be dropped from the cache")
+	}
+}
+
+func BenchmarkReloadWorkspaces(b *testing.B) {
+	tempDir := b.TempDir()
+	for i := 0; i < 1000; i++ {
+		wsDir := filepath.Join(tempDir, fmt.Sprintf("ws-%04d", i))
+		if err := os.MkdirAll(wsDir, 0755); err != nil {
+			b.Fatal(err)
+		}
+		_ = os.WriteFile(filepath.Join(wsDir, "main.tf"), []byte("# test tf"), 0644)
+		_ = os.WriteFile(filepath.Join(wsDir, "config.json"), []byte(`{"enabled": true, "deploy_schedule": "0 9 * * *"}`), 0644)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := LoadWorkspaces(tempDir); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLoadWorkspacesConfigFormats(t *testing.T) {
+	tempDir := t.TempDir()
+	writeDefaultsTestConfig(t, tempDir, `{"timezone": "Europe/Berlin"}`)
+	writeLoaderTestWorkspace(t, tempDir, "json", `{"enabled": true, "deploy_schedule": "0 9 * * 1-5"}`)
+
+	configs := map[string]string{
+		"yaml/config.yaml": "# Office hours\nenabled: true\ndeploy_schedule: \"0 9 * * 1-5\"\n",
+		"hcl/config.hcl":   "# Office hours\nenabled = true\ndeploy_schedule = \"0 9 * * 1-5\"\n",
+		"both/config.json": `{"enabled": true}`,
+		"both/config.yaml": "enabled: true\n",
+	}
+	for path, content := range configs {
+		wsDir := filepath.Join(tempDir, filepath.Dir(path))
+		if err := os.MkdirAll(wsDir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(wsDir, "main.tf"), []byte("# test tf"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	workspaces, err := LoadWorkspaces(tempDir)
+	if err != nil {
+		t.Fatalf("failed to load workspaces: %v", err)
+	}
+
+	// A workspace with a config in two formats is skipped
+	loaded := make(map[string]Config)
+	for _, ws := range workspaces {
+		loaded[ws.Name] = ws.Config
+	}
+	if _, ok := loaded["both"]; ok || len(loaded) != 3 {
+		t.Fatalf("expected the json, yaml and hcl workspaces, got %v", loaded)
+	}
+	for name, config := range loaded {
+		if !config.Enabled || config.DeploySchedule != "0 9 * * 1-5" || config.Timezone != "Europe/Berlin" {
+			t.Errorf("unexpected config of %s: %+v", name, config)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PreviewNamePrefix prefixes the workspaces created for pull requests, e.g. pr-42
+const PreviewNamePrefix = "pr-"
+
+// PreviewConfig creates a workspace per pull request from a template (previews in provisioner.json)
+type PreviewConfig struct {
+	Template  string                 `json:"template"`             // Template every preview workspace is created from
+	Secret    string                 `json:"secret,omitempty"`     // Shared secret of the pull request webhook
+	SecretEnv string                 `json:"secret_env,omitempty"` // Environment variable holding the shared secret
+	TTL       string                 `json:"ttl,omitempty"`        // Destroy previews this long after their last deploy, in case the close event is missed
+	Variables map[string]interface{} `json:"variables,omitempty"`  // OpenTofu variables; {number}, {branch} and {sha} in strings are replaced
+}
+
+// PullRequest identifies the pull request a preview workspace is deployed for
+type PullRequest struct {
+	Number int
+	Branch string
+	SHA    string
+}
+
+// PreviewWorkspaceName returns the name of a pull request's preview workspace
+func PreviewWorkspaceName(number int) string {
+	return PreviewNamePrefix + strconv.Itoa(number)
+}
+
+// GetSecret returns the webhook's shared secret, reading it from the environment if configured
+func (p *PreviewConfig) GetSecret() string {
+	if p.SecretEnv != "" {
+		return os.Getenv(p.SecretEnv)
+	}
+	return p.Secret
+}
+
+// Validate checks the preview settings for invalid values
+func (p *PreviewConfig) Validate() error {
+	if p.Template == "" {
+		return fmt.Errorf("'template' is required")
+	}
+	if p.Secret == "" && p.SecretEnv == "" {
+		return fmt.Errorf("must specify 'secret' or 'secret_env'")
+	}
+	if p.Secret != "" && p.SecretEnv != "" {
+		return fmt.Errorf("cannot specify both 'secret' and 'secret_env'")
+	}
+	if p.TTL != "" {
+		if ttl, err := time.ParseDuration(p.TTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("invalid ttl '%s'", p.TTL)
+		}
+	}
+	return nil
+}
+
+// WorkspaceConfig returns the config of a pull request's preview workspace: deployed and
+// destroyed only by pull request events, or by its ttl
+func (p *PreviewConfig) WorkspaceConfig(pr PullRequest) Config {
+	config := Config{
+		Enabled:         true,
+		Template:        p.Template,
+		Description:     fmt.Sprintf("Preview of pull request #%d (%s)", pr.Number, pr.Branch),
+		DeploySchedule:  false,
+		DestroySchedule: false,
+		TTL:             p.TTL,
+	}
+
+	if len(p.Variables) > 0 {
+		replacer := strings.NewReplacer("{number}", strconv.Itoa(pr.Number), "{branch}", pr.Branch, "{sha}", pr.SHA)
+		config.Variables = make(map[string]interface{}, len(p.Variables))
+		for name, value := range p.Variables {
+			if text, ok := value.(string); ok {
+				value = replacer.Replace(text)
+			}
+			config.Variables[name] = value
+		}
+	}
+	return config
+}
+
+// SavePreviewWorkspace creates or updates a pull request's preview workspace, returning its name
+func (p *PreviewConfig) SavePreviewWorkspace(pr PullRequest) (string, error) {
+	name := PreviewWorkspaceName(pr.Number)
+	wsPath := filepath.Join(getDefaultWorkspacesDir(), name)
+	if err := os.MkdirAll(wsPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create workspace directory: %w", err)
+	}
+
+	config := p.WorkspaceConfig(pr)
+	if err := config.Validate(); err != nil {
+		return "", fmt.Errorf("invalid preview workspace config: %w", err)
+	}
+	configData, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal config: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(wsPath, "config.json"), configData, 0644); err != nil {
+		return "", fmt.Errorf("failed to write config file: %w", err)
+	}
+	return name, nil
+}
@@ -0,0 +1,69 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidatePreviewConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  PreviewConfig
+		wantErr bool
+	}{
+		{"valid", PreviewConfig{Template: "web-app", SecretEnv: "PREVIEW_SECRET", TTL: "72h"}, false},
+		{"no template", PreviewConfig{Secret: "s"}, true},
+		{"no secret", PreviewConfig{Template: "web-app"}, true},
+		{"both secrets", PreviewConfig{Template: "web-app", Secret: "s", SecretEnv: "PREVIEW_SECRET"}, true},
+		{"invalid ttl", PreviewConfig{Template: "web-app", Secret: "s", TTL: "3 days"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSavePreviewWorkspace(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_WORKSPACES_DIR", "")
+
+	previews := &PreviewConfig{
+		Template:  "web-app",
+		Secret:    "s",
+		TTL:       "48h",
+		Variables: map[string]interface{}{"hostname": "pr-{number}.example.com", "image_tag": "{sha}", "replicas": 1.0},
+	}
+	pr := PullRequest{Number: 42, Branch: "feature/login", SHA: "abc123"}
+
+	name, err := previews.SavePreviewWorkspace(pr)
+	if err != nil || name != "pr-42" {
+		t.Fatalf("Expected pr-42, got %s, %v", name, err)
+	}
+
+	// Updates of the pull request rewrite the config
+	pr.SHA = "def456"
+	if _, err := previews.SavePreviewWorkspace(pr); err != nil {
+		t.Fatalf("Failed to update preview: %v", err)
+	}
+
+	config, err := loadConfig(filepath.Join(configDir, "workspaces", "pr-42", "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to load preview config: %v", err)
+	}
+	if config.Template != "web-app" || config.TTL != "48h" || config.DeploySchedule != false || config.DestroySchedule != false {
+		t.Errorf("Unexpected preview config %+v", config)
+	}
+	if config.Variables["hostname"] != "pr-42.example.com" || config.Variables["image_tag"] != "def456" || config.Variables["replicas"] != 1.0 {
+		t.Errorf("Unexpected preview variables %v", config.Variables)
+	}
+	if _, err := os.Stat(filepath.Join(configDir, "workspaces", "pr-42", "main.tf")); !os.IsNotExist(err) {
+		t.Errorf("Expected previews to deploy their template only, got main.tf: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package workspace
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Retry defaults
+const (
+	DefaultRetryBackoff    = 5 * time.Minute
+	DefaultRetryMaxBackoff = time.Hour
+	DefaultRetryJitter     = 0.1
+)
+
+// RetryConfig retries failed scheduled deploys with exponential backoff
+type RetryConfig struct {
+	MaxAttempts int      `json:"max_attempts"`          // Automatic retries after the first failure
+	Backoff     string   `json:"backoff,omitempty"`     // Delay before the first retry, doubled for each further retry (default 5m)
+	MaxBackoff  string   `json:"max_backoff,omitempty"` // Upper bound for the delay (default 1h)
+	Jitter      *float64 `json:"jitter,omitempty"`      // Random +/- fraction applied to each delay (default 0.1)
+}
+
+// GetBackoff returns the delay before the first retry
+func (r *RetryConfig) GetBackoff() (time.Duration, error) {
+	if r.Backoff == "" {
+		return DefaultRetryBackoff, nil
+	}
+	backoff, err := time.ParseDuration(r.Backoff)
+	if err != nil {
+		return 0, fmt.Errorf("invalid backoff '%s': %w", r.Backoff, err)
+	}
+	return backoff, nil
+}
+
+// GetMaxBackoff returns the upper bound for retry delays
+func (r *RetryConfig) GetMaxBackoff() (time.Duration, error) {
+	if r.MaxBackoff == "" {
+		return DefaultRetryMaxBackoff, nil
+	}
+	maxBackoff, err := time.ParseDuration(r.MaxBackoff)
+	if err != nil {
+		return 0, fmt.Errorf("invalid max_backoff '%s': %w", r.MaxBackoff, err)
+	}
+	return maxBackoff, nil
+}
+
+// GetJitter returns the jitter fraction
+func (r *RetryConfig) GetJitter() float64 {
+	if r.Jitter == nil {
+		return DefaultRetryJitter
+	}
+	return *r.Jitter
+}
+
+// Delay returns how long to wait before the given retry (0 for the first retry).
+// Jitter spreads out retries of workspaces that failed together.
+func (r *RetryConfig) Delay(retry int) time.Duration {
+	return r.delay(retry, rand.Float64())
+}
+
+// delay computes the backoff for a retry with a random value in [0, 1)
+func (r *RetryConfig) delay(retry int, random float64) time.Duration {
+	backoff, err := r.GetBackoff()
+	if err != nil {
+		backoff = DefaultRetryBackoff
+	}
+	maxBackoff, err := r.GetMaxBackoff()
+	if err != nil {
+		maxBackoff = DefaultRetryMaxBackoff
+	}
+
+	delay := backoff
+	for i := 0; i < retry && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+
+	jitter := r.GetJitter()
+	return time.Duration(float64(delay) * (1 + jitter*(2*random-1)))
+}
+
+// validateRetryConfig validates the retry settings
+func validateRetryConfig(r *RetryConfig) error {
+	if r.MaxAttempts <= 0 {
+		return fmt.Errorf("max_attempts must be positive")
+	}
+
+	backoff, err := r.GetBackoff()
+	if err != nil {
+		return err
+	}
+	if backoff <= 0 {
+		return fmt.Errorf("backoff must be positive")
+	}
+
+	maxBackoff, err := r.GetMaxBackoff()
+	if err != nil {
+		return err
+	}
+	if maxBackoff < backoff {
+		return fmt.Errorf("max_backoff must not be less than backoff")
+	}
+
+	if jitter := r.GetJitter(); jitter < 0 || jitter > 1 {
+		return fmt.Errorf("jitter must be between 0 and 1")
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package workspace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRetryDelay(t *testing.T) {
+	noJitter := 0.0
+	retry := &RetryConfig{MaxAttempts: 5, Backoff: "1m", MaxBackoff: "5m", Jitter: &noJitter}
+
+	expected := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, want := range expected {
+		if got := retry.delay(i, 0.5); got != want {
+			t.Errorf("retry %d: expected %v, got %v", i, want, got)
+		}
+	}
+
+	// Jitter spreads the delay around the backoff
+	jitter := 0.5
+	retry.Jitter = &jitter
+	if got := retry.delay(0, 0); got != 30*time.Second {
+		t.Errorf("expected lowest jittered delay 30s, got %v", got)
+	}
+	if got := retry.delay(0, 0.999); got < 89*time.Second || got > 90*time.Second {
+		t.Errorf("expected highest jittered delay near 90s, got %v", got)
+	}
+
+	// Defaults apply when only max_attempts is set
+	defaults := &RetryConfig{MaxAttempts: 3}
+	if got := defaults.delay(0, 0.5); got != DefaultRetryBackoff {
+		t.Errorf("expected default backoff %v, got %v", DefaultRetryBackoff, got)
+	}
+	if got := defaults.delay(10, 0.5); got != DefaultRetryMaxBackoff {
+		t.Errorf("expected default max backoff %v, got %v", DefaultRetryMaxBackoff, got)
+	}
+}
+
+func TestValidateRetryConfig(t *testing.T) {
+	tooMuchJitter := 1.5
+
+	tests := []struct {
+		name    string
+		retry   RetryConfig
+		wantErr bool
+	}{
+		{"defaults", RetryConfig{MaxAttempts: 3}, false},
+		{"custom", RetryConfig{MaxAttempts: 3, Backoff: "30s", MaxBackoff: "10m"}, false},
+		{"no attempts", RetryConfig{}, true},
+		{"invalid backoff", RetryConfig{MaxAttempts: 3, Backoff: "soon"}, true},
+		{"max below backoff", RetryConfig{MaxAttempts: 3, Backoff: "10m", MaxBackoff: "1m"}, true},
+		{"jitter out of range", RetryConfig{MaxAttempts: 3, Jitter: &tooMuchJitter}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Enabled: true, DeploySchedule: "0 9 * * *", Retry: &tt.retry}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
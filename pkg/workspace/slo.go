@@ -0,0 +1,55 @@
+package workspace
+
+import (
+	"provisioner/pkg/slo"
+)
+
+// SLOConfig sets objectives for the success rates of a workspace's deploys and jobs. Rates are
+// computed over the most recent window_runs runs of the last window_days days; a rate falling
+// below its objective sends an slo_breached notification.
+type SLOConfig struct {
+	DeploySuccessRate float64 `json:"deploy_success_rate,omitempty"` // Minimum percentage of successful deploys
+	JobSuccessRate    float64 `json:"job_success_rate,omitempty"`    // Minimum percentage of successful runs of each job
+	WindowRuns        int     `json:"window_runs,omitempty"`         // Runs counted (default 30)
+	WindowDays        int     `json:"window_days,omitempty"`         // Days counted (default 30)
+}
+
+// GetSLOWindow returns the window the workspace's success rates are computed over, the default
+// window if it has no objectives
+func (c *Config) GetSLOWindow() slo.Window {
+	if c.SLO == nil {
+		return slo.Window{}
+	}
+	return slo.Window{Runs: c.SLO.WindowRuns, Days: c.SLO.WindowDays}
+}
+
+// GetDeployObjective returns the minimum deploy success rate, 0 without an objective
+func (c *Config) GetDeployObjective() float64 {
+	if c.SLO == nil {
+		return 0
+	}
+	return c.SLO.DeploySuccessRate
+}
+
+// GetJobObjective returns the minimum success rate of each job, 0 without an objective
+func (c *Config) GetJobObjective() float64 {
+	if c.SLO == nil {
+		return 0
+	}
+	return c.SLO.JobSuccessRate
+}
+
+// validateSLO validates success-rate objectives
+func (c *Config) validateSLO() error {
+	if c.SLO == nil {
+		return nil
+	}
+
+	if err := slo.ValidateObjective("deploy_success_rate", c.SLO.DeploySuccessRate); err != nil {
+		return err
+	}
+	if err := slo.ValidateObjective("job_success_rate", c.SLO.JobSuccessRate); err != nil {
+		return err
+	}
+	return c.GetSLOWindow().Validate()
+}
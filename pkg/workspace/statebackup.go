@@ -0,0 +1,44 @@
+package workspace
+
+import (
+	"fmt"
+	"time"
+)
+
+// State backup retention defaults
+const (
+	DefaultStateBackupMaxVersions   = 20
+	DefaultStateBackupRetentionDays = 30
+)
+
+// StateBackupConfig sets how long OpenTofu state backups taken before applies and destroys are kept
+type StateBackupConfig struct {
+	MaxVersions   int `json:"max_versions,omitempty"`   // Keep at most this many backups (default 20)
+	RetentionDays int `json:"retention_days,omitempty"` // Delete backups older than this (default 30)
+}
+
+// GetStateBackupRetention returns how many state backups to keep and for how long. The newest
+// backup is kept regardless of its age.
+func (c *Config) GetStateBackupRetention() (maxVersions int, maxAge time.Duration) {
+	maxVersions, retentionDays := DefaultStateBackupMaxVersions, DefaultStateBackupRetentionDays
+	if cfg := c.StateBackup; cfg != nil {
+		if cfg.MaxVersions > 0 {
+			maxVersions = cfg.MaxVersions
+		}
+		if cfg.RetentionDays > 0 {
+			retentionDays = cfg.RetentionDays
+		}
+	}
+	return maxVersions, time.Duration(retentionDays) * 24 * time.Hour
+}
+
+// validateStateBackupConfig checks state backup retention settings
+func validateStateBackupConfig(cfg *StateBackupConfig) error {
+	if cfg.MaxVersions < 0 {
+		return fmt.Errorf("max_versions must not be negative")
+	}
+	if cfg.RetentionDays < 0 {
+		return fmt.Errorf("retention_days must not be negative")
+	}
+	return nil
+}
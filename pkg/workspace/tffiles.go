@@ -0,0 +1,148 @@
+package workspace
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"provisioner/pkg/lint"
+)
+
+// tfFileSuffixes are the files OpenTofu loads as the configuration of a module
+var tfFileSuffixes = []string{".tf", ".tf.json", ".tofu", ".tofu.json"}
+
+// TemplateFileSuffix marks files rendered with templatefile(), copied along with the configuration
+const TemplateFileSuffix = ".tftpl"
+
+// pathModuleFileRe matches file() and templatefile() calls reading a file next to the module
+var pathModuleFileRe = regexp.MustCompile(`\b(?:templatefile|file)\(\s*"\$\{path\.module\}/([^"$]+)"`)
+
+// IsTFFile reports whether a file name is OpenTofu configuration
+func IsTFFile(name string) bool {
+	for _, suffix := range tfFileSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasTFFiles reports whether dir directly holds OpenTofu configuration files, i.e. is a module
+func HasTFFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && IsTFFile(entry.Name()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTFFiles returns the configuration and .tftpl files in dir and its subdirectories such as
+// modules/, relative to dir. Hidden directories like .terraform are skipped.
+func ListTFFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if path != dir && strings.HasPrefix(entry.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if IsTFFile(entry.Name()) || strings.HasSuffix(entry.Name(), TemplateFileSuffix) {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// ValidateTFDir checks a workspace or template directory: it must hold a root module, and the
+// local modules and the files its configuration reads with file() or templatefile() must exist
+// inside it, since only the directory is copied to the deployment
+func ValidateTFDir(dir string) error {
+	if !HasTFFiles(dir) {
+		return fmt.Errorf("no .tf files found in %s", dir)
+	}
+	return validateModuleDir(dir, dir, make(map[string]bool))
+}
+
+// validateModuleDir checks the local references of one module, then the local modules it calls
+func validateModuleDir(root, dir string, visited map[string]bool) error {
+	if visited[dir] {
+		return nil
+	}
+	visited[dir] = true
+
+	relDir, _ := filepath.Rel(root, dir)
+	location := func(file string) string {
+		return filepath.Join(relDir, file)
+	}
+
+	paths, err := filepath.Glob(filepath.Join(dir, "*.tf"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", location(filepath.Base(path)), err)
+		}
+		for _, match := range pathModuleFileRe.FindAllStringSubmatch(string(data), -1) {
+			target := filepath.Join(dir, filepath.FromSlash(match[1]))
+			if !insideDir(root, target) {
+				return fmt.Errorf("%s: %s is outside the configuration directory", location(filepath.Base(path)), match[1])
+			}
+			if _, err := os.Stat(target); err != nil {
+				return fmt.Errorf("%s: file %s not found", location(filepath.Base(path)), match[1])
+			}
+		}
+	}
+
+	sources, err := lint.ModuleSources(dir)
+	if err != nil {
+		return err
+	}
+	for _, source := range sources {
+		if !strings.HasPrefix(source.Source, "./") && !strings.HasPrefix(source.Source, "../") {
+			continue // Registry and remote modules are downloaded by tofu init
+		}
+		moduleDir := filepath.Join(dir, filepath.FromSlash(source.Source))
+		if !insideDir(root, moduleDir) {
+			return fmt.Errorf("%s:%d: module %q source %s is outside the configuration directory",
+				location(source.File), source.Line, source.Module, source.Source)
+		}
+		if !HasTFFiles(moduleDir) {
+			return fmt.Errorf("%s:%d: module %q source %s has no .tf files",
+				location(source.File), source.Line, source.Module, source.Source)
+		}
+		if err := validateModuleDir(root, moduleDir, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// insideDir reports whether path is dir or below it
+func insideDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
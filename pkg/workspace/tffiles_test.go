@@ -0,0 +1,116 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTFFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory for %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestListTFFiles(t *testing.T) {
+	dir := writeTFFiles(t, map[string]string{
+		"network.tf":                      "# network",
+		"outputs.tf.json":                 "{}",
+		"README.md":                       "docs",
+		"modules/vpc/main.tofu":           "# vpc",
+		"modules/vpc/cloud-init.tftpl":    "#cloud-config",
+		"modules/vpc/.terraform/cache.tf": "# provider cache",
+	})
+
+	files, err := ListTFFiles(dir)
+	if err != nil {
+		t.Fatalf("ListTFFiles() error: %v", err)
+	}
+	want := []string{"modules/vpc/cloud-init.tftpl", "modules/vpc/main.tofu", "network.tf", "outputs.tf.json"}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("Expected %v, got %v", want, files)
+	}
+	if !HasTFFiles(dir) || HasTFFiles(filepath.Join(dir, "modules")) {
+		t.Error("Expected only the root and module directories to hold configuration")
+	}
+}
+
+func TestValidateTFDir(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name: "modules and templates",
+			files: map[string]string{
+				"main.tf": `module "vpc" {
+  source = "./modules/vpc"
+}
+module "dns" {
+  source  = "terraform-aws-modules/route53/aws"
+  version = "2.0.0"
+}`,
+				"modules/vpc/main.tf":          `locals { user_data = templatefile("${path.module}/cloud-init.tftpl", {}) }`,
+				"modules/vpc/cloud-init.tftpl": "#cloud-config",
+			},
+		},
+		{
+			name:    "no configuration",
+			files:   map[string]string{"README.md": "docs"},
+			wantErr: "no .tf files",
+		},
+		{
+			name: "missing module",
+			files: map[string]string{
+				"main.tf": `module "vpc" {
+  source = "./modules/vpc"
+}`,
+			},
+			wantErr: `main.tf:2: module "vpc" source ./modules/vpc has no .tf files`,
+		},
+		{
+			name: "module outside the directory",
+			files: map[string]string{
+				"main.tf": `module "shared" {
+  source = "../shared"
+}`,
+			},
+			wantErr: "outside the configuration directory",
+		},
+		{
+			name: "missing template in module",
+			files: map[string]string{
+				"main.tf":             `module "vpc" { source = "./modules/vpc" }`,
+				"modules/vpc/main.tf": `locals { user_data = templatefile("${path.module}/cloud-init.tftpl", {}) }`,
+			},
+			wantErr: "modules/vpc/main.tf: file cloud-init.tftpl not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateTFDir(writeTFFiles(t, tt.files))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateTFDir() error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package workspace
+
+import (
+	"fmt"
+	"time"
+)
+
+// Deployment environment tiers
+const (
+	TierDev     = "dev"
+	TierStaging = "staging"
+	TierProd    = "prod"
+)
+
+// TierDefaults are the settings provisioner.json applies to every workspace of a tier.
+// A workspace's own settings always take precedence over its tier's defaults.
+type TierDefaults struct {
+	Protected           bool        `json:"protected,omitempty"`            // Never destroy on schedule; manual destroys need --force
+	RequireApproval     bool        `json:"require_approval,omitempty"`     // Hold scheduled deploys and destroys until an operator approves them
+	ApprovalTimeout     string      `json:"approval_timeout,omitempty"`     // Used by workspaces without an approval_timeout
+	DestroySchedule     interface{} `json:"destroy_schedule,omitempty"`     // Used by workspaces without a destroy_schedule
+	MaxLifetime         string      `json:"max_lifetime,omitempty"`         // Used by workspaces without a max_lifetime
+	JobTimeout          string      `json:"job_timeout,omitempty"`          // Used by workspace jobs without a timeout
+	CompletionTimeout   string      `json:"completion_timeout,omitempty"`   // Used by run_to_completion without a timeout
+	NotificationChannel string      `json:"notification_channel,omitempty"` // Used by workspaces without a notification_channel
+}
+
+// IsValidTier returns true if name is a known tier
+func IsValidTier(name string) bool {
+	switch name {
+	case TierDev, TierStaging, TierProd:
+		return true
+	default:
+		return false
+	}
+}
+
+// Validate checks tier defaults for invalid values
+func (t *TierDefaults) Validate() error {
+	if t.DestroySchedule != nil {
+		if _, err := normalizeSchedules(t.DestroySchedule); err != nil {
+			return fmt.Errorf("invalid destroy_schedule: %w", err)
+		}
+	}
+
+	durations := []struct {
+		field string
+		value string
+	}{
+		{"max_lifetime", t.MaxLifetime},
+		{"job_timeout", t.JobTimeout},
+		{"completion_timeout", t.CompletionTimeout},
+		{"approval_timeout", t.ApprovalTimeout},
+	}
+	for _, d := range durations {
+		if d.value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid %s '%s': %w", d.field, d.value, err)
+		}
+		if duration <= 0 {
+			return fmt.Errorf("%s must be positive", d.field)
+		}
+	}
+
+	return nil
+}
+
+// ApplyTierDefaults fills settings the workspace leaves unset from its tier's defaults. Label
+// policies apply the same defaults, so only guardrails that are on are filled in and a later
+// policy or the tier can still turn them on.
+func (c *Config) ApplyTierDefaults(defaults TierDefaults) {
+	if c.Protected == nil && defaults.Protected {
+		c.Protected = &defaults.Protected
+	}
+	if c.RequireApproval == nil && defaults.RequireApproval {
+		c.RequireApproval = &defaults.RequireApproval
+	}
+	if c.DestroySchedule == nil {
+		c.DestroySchedule = defaults.DestroySchedule
+	}
+	if c.MaxLifetime == "" && c.MaxLifetimeAction == "" {
+		c.MaxLifetime = defaults.MaxLifetime
+	}
+	if c.NotificationChannel == "" {
+		c.NotificationChannel = defaults.NotificationChannel
+	}
+	if c.ApprovalTimeout == "" {
+		c.ApprovalTimeout = defaults.ApprovalTimeout
+	}
+	if c.RunToCompletion != nil && c.RunToCompletion.Timeout == "" {
+		c.RunToCompletion.Timeout = defaults.CompletionTimeout
+	}
+	for i := range c.Jobs {
+		if c.Jobs[i].Timeout == "" {
+			c.Jobs[i].Timeout = defaults.JobTimeout
+		}
+	}
+}
+
+// IsProtected returns true if the workspace must not be destroyed by schedules or without --force
+func (c *Config) IsProtected() bool {
+	return c.Protected != nil && *c.Protected
+}
+
+// RequiresApproval returns true if scheduled deploys and destroys wait for an operator
+func (c *Config) RequiresApproval() bool {
+	return c.RequireApproval != nil && *c.RequireApproval
+}
+
+// GetApprovalTimeout returns how long approval requests wait before they expire, 0 if they never do
+func (c *Config) GetApprovalTimeout() time.Duration {
+	if c.ApprovalTimeout == "" {
+		return 0
+	}
+	timeout, err := time.ParseDuration(c.ApprovalTimeout)
+	if err != nil {
+		return 0
+	}
+	return timeout
+}
+
+// validateApprovalTimeout validates how long approval requests wait
+func (c *Config) validateApprovalTimeout() error {
+	if c.ApprovalTimeout == "" {
+		return nil
+	}
+	timeout, err := time.ParseDuration(c.ApprovalTimeout)
+	if err != nil {
+		return fmt.Errorf("invalid approval_timeout '%s': %w", c.ApprovalTimeout, err)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("approval_timeout must be positive")
+	}
+	return nil
+}
+
+// validateTier validates the workspace's tier
+func (c *Config) validateTier() error {
+	if c.Tier != "" && !IsValidTier(c.Tier) {
+		return fmt.Errorf("invalid tier '%s' (must be dev, staging or prod)", c.Tier)
+	}
+	return nil
+}
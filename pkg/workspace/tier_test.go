@@ -0,0 +1,100 @@
+package workspace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyTierDefaults(t *testing.T) {
+	defaults := TierDefaults{
+		Protected:           true,
+		RequireApproval:     true,
+		DestroySchedule:     "0 19 * * *",
+		MaxLifetime:         "12h",
+		JobTimeout:          "2h",
+		CompletionTimeout:   "48h",
+		NotificationChannel: "prod-oncall",
+		ApprovalTimeout:     "12h",
+	}
+
+	config := Config{
+		Tier:            TierProd,
+		DeploySchedule:  "0 9 * * *",
+		RunToCompletion: &RunToCompletionConfig{CompletionOutput: "done"},
+		Jobs: []JobConfig{
+			{Name: "backup", Type: "command", Command: "backup.sh", Schedule: "0 2 * * *"},
+			{Name: "report", Type: "command", Command: "report.sh", Schedule: "0 3 * * *", Timeout: "5m"},
+		},
+	}
+	config.ApplyTierDefaults(defaults)
+
+	if !config.IsProtected() || !config.RequiresApproval() {
+		t.Error("Expected tier guardrails to apply")
+	}
+	if timeout := config.GetApprovalTimeout(); timeout != 12*time.Hour {
+		t.Errorf("Expected approval timeout default, got %v", timeout)
+	}
+	if config.DestroySchedule != "0 19 * * *" || config.MaxLifetime != "12h" || config.NotificationChannel != "prod-oncall" {
+		t.Errorf("Expected tier defaults, got destroy %v, max_lifetime %q, channel %q",
+			config.DestroySchedule, config.MaxLifetime, config.NotificationChannel)
+	}
+	if config.RunToCompletion.Timeout != "48h" {
+		t.Errorf("Expected completion timeout default, got %q", config.RunToCompletion.Timeout)
+	}
+	if config.Jobs[0].Timeout != "2h" || config.Jobs[1].Timeout != "5m" {
+		t.Errorf("Expected job timeout default only for jobs without one, got %q and %q", config.Jobs[0].Timeout, config.Jobs[1].Timeout)
+	}
+}
+
+func TestApplyTierDefaultsKeepsWorkspaceSettings(t *testing.T) {
+	unprotected := false
+	config := Config{
+		Tier:              TierProd,
+		DeploySchedule:    "0 9 * * *",
+		DestroySchedule:   false,
+		Protected:         &unprotected,
+		MaxLifetimeAction: LifetimeActionAlert,
+	}
+	config.ApplyTierDefaults(TierDefaults{Protected: true, DestroySchedule: "0 19 * * *", MaxLifetime: "12h"})
+
+	if config.IsProtected() {
+		t.Error("Expected explicit protected: false to override the tier")
+	}
+	if config.DestroySchedule != false {
+		t.Errorf("Expected workspace destroy_schedule to be kept, got %v", config.DestroySchedule)
+	}
+	if config.MaxLifetime != "" {
+		t.Errorf("Expected no max_lifetime default when the workspace sets max_lifetime_action, got %q", config.MaxLifetime)
+	}
+}
+
+func TestTierValidation(t *testing.T) {
+	config := Config{Tier: "production", DeploySchedule: "0 9 * * *"}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for unknown tier")
+	}
+	config.Tier = TierStaging
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected valid tier, got %v", err)
+	}
+	config.ApprovalTimeout = "tomorrow"
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for invalid approval_timeout")
+	}
+	if timeout := (&Config{}).GetApprovalTimeout(); timeout != 0 {
+		t.Errorf("Expected approvals without a timeout never to expire, got %v", timeout)
+	}
+
+	invalid := []TierDefaults{
+		{DestroySchedule: true},
+		{MaxLifetime: "soon"},
+		{JobTimeout: "-1h"},
+		{CompletionTimeout: "0s"},
+		{ApprovalTimeout: "later"},
+	}
+	for _, defaults := range invalid {
+		if err := defaults.Validate(); err == nil {
+			t.Errorf("Expected error for tier defaults %+v", defaults)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package workspace
+
+import (
+	"fmt"
+	"time"
+)
+
+// ParseOperationTimeout parses a deploy_timeout or destroy_timeout, 0 if value is empty
+func ParseOperationTimeout(field, value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s '%s': %w", field, value, err)
+	}
+	if timeout <= 0 {
+		return 0, fmt.Errorf("%s must be positive", field)
+	}
+	return timeout, nil
+}
+
+// GetDeployTimeout returns how long a deploy of the workspace may run, 0 for the daemon default
+func (c *Config) GetDeployTimeout() time.Duration {
+	timeout, _ := ParseOperationTimeout("deploy_timeout", c.DeployTimeout)
+	return timeout
+}
+
+// GetDestroyTimeout returns how long a destroy of the workspace may run, 0 for the daemon default
+func (c *Config) GetDestroyTimeout() time.Duration {
+	timeout, _ := ParseOperationTimeout("destroy_timeout", c.DestroyTimeout)
+	return timeout
+}
+
+// validateOperationTimeouts validates the deploy and destroy timeouts
+func (c *Config) validateOperationTimeouts() error {
+	if _, err := ParseOperationTimeout("deploy_timeout", c.DeployTimeout); err != nil {
+		return err
+	}
+	_, err := ParseOperationTimeout("destroy_timeout", c.DestroyTimeout)
+	return err
+}
@@ -0,0 +1,22 @@
+package workspace
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOperationTimeouts(t *testing.T) {
+	config := Config{DeployTimeout: "45m"}
+	if err := config.validateOperationTimeouts(); err != nil {
+		t.Fatalf("Expected valid timeouts, got %v", err)
+	}
+	if config.GetDeployTimeout() != 45*time.Minute || config.GetDestroyTimeout() != 0 {
+		t.Errorf("Expected 45m and the default, got %v and %v", config.GetDeployTimeout(), config.GetDestroyTimeout())
+	}
+
+	for _, invalid := range []Config{{DeployTimeout: "soon"}, {DestroyTimeout: "-5m"}, {DestroyTimeout: "0s"}} {
+		if err := invalid.validateOperationTimeouts(); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+}
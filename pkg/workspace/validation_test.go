@@ -1,6 +1,7 @@
 package workspace
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -189,3 +190,199 @@ func TestValidateJobDependencies_RealWorldExample(t *testing.T) {
 		t.Errorf("expected valid configuration to pass validation, got: %v", err)
 	}
 }
+
+func TestValidateRunToCompletion(t *testing.T) {
+	tests := []struct {
+		name        string
+		rtc         *RunToCompletionConfig
+		jobs        []JobConfig
+		expectError bool
+	}{
+		{"output signal", &RunToCompletionConfig{CompletionOutput: "done"}, nil, false},
+		{"job signal", &RunToCompletionConfig{CompletionJob: "check"}, []JobConfig{{Name: "check", Type: "command", Command: "true", Schedule: "* * * * *", Enabled: true}}, false},
+		{"no signal", &RunToCompletionConfig{Timeout: "1h"}, nil, true},
+		{"unknown job", &RunToCompletionConfig{CompletionJob: "missing"}, nil, true},
+		{"invalid timeout", &RunToCompletionConfig{CompletionOutput: "done", Timeout: "soon"}, nil, true},
+		{"negative timeout", &RunToCompletionConfig{CompletionOutput: "done", Timeout: "-1h"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{
+				Enabled:         true,
+				DeploySchedule:  "0 2 * * *",
+				DestroySchedule: false,
+				Jobs:            tt.jobs,
+				RunToCompletion: tt.rtc,
+			}
+
+			err := config.Validate()
+			if tt.expectError && err == nil {
+				t.Error("expected validation error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestIsCompletionValue(t *testing.T) {
+	for _, value := range []interface{}{true, "done", "Completed", "1", float64(2)} {
+		if !IsCompletionValue(value) {
+			t.Errorf("expected %v to signal completion", value)
+		}
+	}
+	for _, value := range []interface{}{false, "", "running", float64(0), nil} {
+		if IsCompletionValue(value) {
+			t.Errorf("expected %v not to signal completion", value)
+		}
+	}
+}
+
+func TestValidateWebhooks(t *testing.T) {
+	modes := map[string]interface{}{"busy": "0 8 * * 1-5"}
+
+	tests := []struct {
+		name        string
+		webhooks    []WebhookConfig
+		modes       map[string]interface{}
+		expectError bool
+	}{
+		{"deploy with secret", []WebhookConfig{{Name: "ci", Action: "deploy", Secret: "s3cret"}}, nil, false},
+		{"destroy with secret env", []WebhookConfig{{Name: "ci", Action: "destroy", SecretEnv: "HOOK_SECRET"}}, nil, false},
+		{"mode change", []WebhookConfig{{Name: "scale-up", Action: "mode", Mode: "busy", Secret: "s"}}, modes, false},
+		{"missing secret", []WebhookConfig{{Name: "ci", Action: "deploy"}}, nil, true},
+		{"both secrets", []WebhookConfig{{Name: "ci", Action: "deploy", Secret: "s", SecretEnv: "E"}}, nil, true},
+		{"invalid action", []WebhookConfig{{Name: "ci", Action: "restart", Secret: "s"}}, nil, true},
+		{"mode without mode", []WebhookConfig{{Name: "ci", Action: "mode", Secret: "s"}}, modes, true},
+		{"unknown mode", []WebhookConfig{{Name: "ci", Action: "mode", Mode: "idle", Secret: "s"}}, modes, true},
+		{"invalid name", []WebhookConfig{{Name: "ci/deploy", Action: "deploy", Secret: "s"}}, nil, true},
+		{"duplicate name", []WebhookConfig{{Name: "ci", Action: "deploy", Secret: "s"}, {Name: "ci", Action: "destroy", Secret: "s"}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Enabled: true, Webhooks: tt.webhooks}
+			if tt.modes != nil {
+				config.ModeSchedules = tt.modes
+				config.Template = "web-app"
+			} else {
+				config.DeploySchedule = "0 9 * * *"
+				config.DestroySchedule = "0 17 * * *"
+			}
+
+			err := config.Validate()
+			if tt.expectError && err == nil {
+				t.Error("expected validation error")
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected validation error: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateTimezone(t *testing.T) {
+	tests := []struct {
+		timezone    string
+		expectError bool
+	}{
+		{"", false},
+		{"UTC", false},
+		{"Europe/Berlin", false},
+		{"America/New_York", false},
+		{"Mars/Olympus_Mons", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			config := Config{Enabled: true, DeploySchedule: "0 9 * * *", DestroySchedule: "0 17 * * *", Timezone: tt.timezone}
+
+			err := config.Validate()
+			if tt.expectError && err == nil {
+				t.Errorf("expected error for timezone %q", tt.timezone)
+			}
+			if !tt.expectError && err != nil {
+				t.Errorf("unexpected error for timezone %q: %v", tt.timezone, err)
+			}
+
+			if !tt.expectError && tt.timezone != "" && config.GetLocation().String() != tt.timezone {
+				t.Errorf("expected location %s, got %s", tt.timezone, config.GetLocation())
+			}
+		})
+	}
+}
+
+func TestValidateSLO(t *testing.T) {
+	tests := []struct {
+		name    string
+		slo     SLOConfig
+		wantErr bool
+	}{
+		{"deploy and job objectives", SLOConfig{DeploySuccessRate: 95, JobSuccessRate: 99.5, WindowRuns: 20}, false},
+		{"window only", SLOConfig{WindowDays: 7}, false},
+		{"rate above 100", SLOConfig{DeploySuccessRate: 120}, true},
+		{"negative rate", SLOConfig{JobSuccessRate: -1}, true},
+		{"negative window", SLOConfig{DeploySuccessRate: 90, WindowRuns: -5}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{Enabled: true, DeploySchedule: "0 9 * * *", DestroySchedule: "0 17 * * *", SLO: &tt.slo}
+			err := config.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateLint(t *testing.T) {
+	config := Config{Enabled: true, DeploySchedule: "0 9 * * *", DestroySchedule: false,
+		Lint: &LintConfig{PreDeploy: true, Skip: []string{"missing-required-version"}}}
+	if err := config.Validate(); err != nil {
+		t.Errorf("unexpected error for known lint rule: %v", err)
+	}
+	if !config.IsLintGateEnabled() {
+		t.Error("expected lint gate to be enabled")
+	}
+
+	config.Lint.Skip = []string{"no-tabs"}
+	if err := config.Validate(); err == nil {
+		t.Error("expected error for unknown lint rule")
+	}
+}
+
+func TestRunValidateCommandStrict(t *testing.T) {
+	configDir := t.TempDir()
+	t.Setenv("PROVISIONER_CONFIG_DIR", configDir)
+	t.Setenv("PROVISIONER_WORKSPACES_DIR", "")
+	workspacesDir := filepath.Join(configDir, "workspaces")
+	writeLoaderTestWorkspace(t, workspacesDir, "my-app", `{
+  "enabled": true,
+  "deploy_schedule": "0 9 * * 1-5",
+  "destroy_schedul": "0 18 * * 1-5"
+}`)
+
+	// Unknown fields are only warnings without --strict
+	if err := RunValidateCommand([]string{"my-app"}, nil, false); err != nil {
+		t.Fatalf("expected my-app to be valid, got %v", err)
+	}
+	err := RunValidateCommand([]string{"my-app"}, nil, true)
+	expected := "workspaces/my-app/config.json: line 4: unknown field 'destroy_schedul', did you mean 'destroy_schedule'?"
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("expected an error containing %q, got %v", expected, err)
+	}
+
+	// --all includes workspaces whose config doesn't load
+	writeLoaderTestWorkspace(t, workspacesDir, "broken", "{\n  \"enabled\": \"yes\"\n}")
+	if err := RunValidateCommand([]string{"--all"}, nil, false); err == nil {
+		t.Error("expected validating all workspaces to fail for the broken one")
+	}
+	err = CheckConfigFile(filepath.Join(workspacesDir, "broken", "config.json"), &Config{}, false)
+	expected = "workspaces/broken/config.json: line 2: 'enabled' must be true or false, got a string"
+	if err == nil || err.Error() != expected {
+		t.Errorf("expected %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,237 @@
+package workspace
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// VarsFileName is the provisioner-owned variables file in the deployment working directory.
+// OpenTofu loads *.auto.tfvars.json automatically and the working directory cleanup preserves it.
+const VarsFileName = "provisioner.auto.tfvars.json"
+
+// ConfigVarsFileName is the tfvars file generated from the workspace config's variables.
+// OpenTofu loads it before *.auto.tfvars.json, so variables set with `vars set` take precedence.
+const ConfigVarsFileName = "terraform.tfvars.json"
+
+// secretNameHints are substrings that mark a variable as secret when it was not flagged explicitly
+var secretNameHints = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "private_key", "credential"}
+
+// GetVarsFilePath returns the path to the provisioner-owned tfvars file
+func GetVarsFilePath(stateDir, wsName string) string {
+	return filepath.Join(stateDir, "deployments", wsName, VarsFileName)
+}
+
+// LoadVars loads workspace variables from the provisioner-owned tfvars file
+func LoadVars(stateDir, wsName string) (map[string]string, error) {
+	varsPath := GetVarsFilePath(stateDir, wsName)
+
+	// Return empty vars if file doesn't exist
+	if _, err := os.Stat(varsPath); os.IsNotExist(err) {
+		return make(map[string]string), nil
+	}
+
+	data, err := os.ReadFile(varsPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vars file: %w", err)
+	}
+
+	vars := make(map[string]string)
+	if err := json.Unmarshal(data, &vars); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal vars file: %w", err)
+	}
+
+	return vars, nil
+}
+
+// SaveVars writes workspace variables to the provisioner-owned tfvars file
+func SaveVars(stateDir, wsName string, vars map[string]string) error {
+	varsPath := GetVarsFilePath(stateDir, wsName)
+
+	// Ensure deployment directory exists
+	if err := os.MkdirAll(filepath.Dir(varsPath), 0755); err != nil {
+		return fmt.Errorf("failed to create deployment directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vars: %w", err)
+	}
+
+	// Variables may hold secrets, keep the file private to the provisioner user
+	if err := os.WriteFile(varsPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write vars file: %w", err)
+	}
+
+	return nil
+}
+
+// SetVar sets a workspace variable, optionally marking it as secret
+func SetVar(stateDir, wsName, key, value string, secret bool) error {
+	if err := validateVarName(key); err != nil {
+		return err
+	}
+
+	vars, err := LoadVars(stateDir, wsName)
+	if err != nil {
+		return err
+	}
+
+	vars[key] = value
+	if err := SaveVars(stateDir, wsName, vars); err != nil {
+		return err
+	}
+
+	metadata, err := LoadDeploymentMetadata(stateDir, wsName)
+	if err != nil {
+		return err
+	}
+
+	metadata.SecretVars = removeString(metadata.SecretVars, key)
+	if secret {
+		metadata.SecretVars = append(metadata.SecretVars, key)
+		sort.Strings(metadata.SecretVars)
+	}
+
+	return SaveDeploymentMetadata(stateDir, wsName, metadata)
+}
+
+// UnsetVar removes a workspace variable
+func UnsetVar(stateDir, wsName, key string) error {
+	vars, err := LoadVars(stateDir, wsName)
+	if err != nil {
+		return err
+	}
+
+	if _, exists := vars[key]; !exists {
+		return fmt.Errorf("variable '%s' is not set for workspace '%s'", key, wsName)
+	}
+
+	delete(vars, key)
+	if err := SaveVars(stateDir, wsName, vars); err != nil {
+		return err
+	}
+
+	metadata, err := LoadDeploymentMetadata(stateDir, wsName)
+	if err != nil {
+		return err
+	}
+
+	metadata.SecretVars = removeString(metadata.SecretVars, key)
+	return SaveDeploymentMetadata(stateDir, wsName, metadata)
+}
+
+// GetVariables returns the config variables for a deployment, with the mode's overrides applied
+func (c *Config) GetVariables(mode string) map[string]interface{} {
+	vars := make(map[string]interface{}, len(c.Variables))
+	for key, value := range c.Variables {
+		vars[key] = value
+	}
+	for key, value := range c.ModeVariables[mode] {
+		vars[key] = value
+	}
+	return vars
+}
+
+// WriteConfigVarsFile writes config variables to terraform.tfvars.json in a working directory.
+// Without config variables the file of an earlier deploy is removed, so its values aren't applied again.
+func WriteConfigVarsFile(workingDir string, vars map[string]interface{}) error {
+	path := filepath.Join(workingDir, ConfigVarsFileName)
+	if len(vars) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove variables file: %w", err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(vars, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal variables: %w", err)
+	}
+
+	// Variables may hold secrets, keep the file private to the provisioner user
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write variables file: %w", err)
+	}
+
+	return nil
+}
+
+// validateVariables checks variable names and that mode overrides refer to configured modes
+func (c *Config) validateVariables() error {
+	for key := range c.Variables {
+		if err := validateVarName(key); err != nil {
+			return err
+		}
+	}
+
+	for mode, vars := range c.ModeVariables {
+		if _, exists := c.ModeSchedules[mode]; !exists {
+			return fmt.Errorf("mode_variables refers to unknown mode '%s'", mode)
+		}
+		for key := range vars {
+			if err := validateVarName(key); err != nil {
+				return fmt.Errorf("mode '%s': %w", mode, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// IsSecretVar reports whether a variable should be masked in output
+func IsSecretVar(key string, secretVars []string) bool {
+	for _, secretVar := range secretVars {
+		if secretVar == key {
+			return true
+		}
+	}
+
+	lowerKey := strings.ToLower(key)
+	for _, hint := range secretNameHints {
+		if strings.Contains(lowerKey, hint) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MaskValue hides a secret value for display
+func MaskValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "********"
+}
+
+// validateVarName checks that a variable name is a valid OpenTofu identifier
+func validateVarName(key string) error {
+	if key == "" {
+		return fmt.Errorf("variable name cannot be empty")
+	}
+
+	for i, r := range key {
+		isLetter := (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_'
+		isDigit := r >= '0' && r <= '9'
+		if !isLetter && !(i > 0 && (isDigit || r == '-')) {
+			return fmt.Errorf("invalid variable name '%s'", key)
+		}
+	}
+
+	return nil
+}
+
+// removeString returns the slice without any occurrences of value
+func removeString(values []string, value string) []string {
+	result := values[:0]
+	for _, v := range values {
+		if v != value {
+			result = append(result, v)
+		}
+	}
+	return result
+}
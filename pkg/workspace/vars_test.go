@@ -0,0 +1,172 @@
+package workspace
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetAndUnsetVars(t *testing.T) {
+	stateDir := t.TempDir()
+
+	if err := SetVar(stateDir, "my-app", "instance_count", "2", false); err != nil {
+		t.Fatalf("Failed to set var: %v", err)
+	}
+	if err := SetVar(stateDir, "my-app", "db_host", "db.internal", true); err != nil {
+		t.Fatalf("Failed to set secret var: %v", err)
+	}
+
+	vars, err := LoadVars(stateDir, "my-app")
+	if err != nil {
+		t.Fatalf("Failed to load vars: %v", err)
+	}
+	if vars["instance_count"] != "2" || vars["db_host"] != "db.internal" {
+		t.Errorf("Unexpected vars: %v", vars)
+	}
+
+	info, err := os.Stat(GetVarsFilePath(stateDir, "my-app"))
+	if err != nil {
+		t.Fatalf("Vars file not created: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected vars file mode 0600, got %o", info.Mode().Perm())
+	}
+
+	metadata, err := LoadDeploymentMetadata(stateDir, "my-app")
+	if err != nil {
+		t.Fatalf("Failed to load metadata: %v", err)
+	}
+	if !IsSecretVar("db_host", metadata.SecretVars) {
+		t.Error("Expected db_host to be recorded as secret")
+	}
+
+	// Re-setting without --secret clears the secret flag
+	if err := SetVar(stateDir, "my-app", "db_host", "db2.internal", false); err != nil {
+		t.Fatalf("Failed to update var: %v", err)
+	}
+	metadata, _ = LoadDeploymentMetadata(stateDir, "my-app")
+	if IsSecretVar("db_host", metadata.SecretVars) {
+		t.Error("Expected db_host to no longer be secret")
+	}
+
+	if err := UnsetVar(stateDir, "my-app", "instance_count"); err != nil {
+		t.Fatalf("Failed to unset var: %v", err)
+	}
+	vars, _ = LoadVars(stateDir, "my-app")
+	if _, exists := vars["instance_count"]; exists {
+		t.Error("Expected instance_count to be removed")
+	}
+
+	if err := UnsetVar(stateDir, "my-app", "missing"); err == nil {
+		t.Error("Expected error when unsetting unknown var")
+	}
+}
+
+func TestSetVarRejectsInvalidNames(t *testing.T) {
+	stateDir := t.TempDir()
+
+	for _, name := range []string{"", "1abc", "has space", "a.b"} {
+		if err := SetVar(stateDir, "my-app", name, "x", false); err == nil {
+			t.Errorf("Expected error for invalid variable name %q", name)
+		}
+	}
+}
+
+func TestIsSecretVar(t *testing.T) {
+	tests := []struct {
+		key      string
+		explicit []string
+		expected bool
+	}{
+		{"instance_count", nil, false},
+		{"db_password", nil, true},
+		{"GITHUB_TOKEN", nil, true},
+		{"do_api_key", nil, true},
+		{"region", []string{"region"}, true},
+	}
+
+	for _, tt := range tests {
+		if got := IsSecretVar(tt.key, tt.explicit); got != tt.expected {
+			t.Errorf("IsSecretVar(%q) = %v, expected %v", tt.key, got, tt.expected)
+		}
+	}
+}
+
+func TestGetVariablesAppliesModeOverrides(t *testing.T) {
+	config := Config{
+		Variables: map[string]interface{}{"instance_count": float64(1), "region": "eu-west-1"},
+		ModeVariables: map[string]map[string]interface{}{
+			"busy": {"instance_count": float64(4)},
+		},
+	}
+
+	base := config.GetVariables("")
+	if base["instance_count"] != float64(1) || base["region"] != "eu-west-1" {
+		t.Errorf("Unexpected base variables: %v", base)
+	}
+
+	busy := config.GetVariables("busy")
+	if busy["instance_count"] != float64(4) || busy["region"] != "eu-west-1" {
+		t.Errorf("Expected busy override with base region, got %v", busy)
+	}
+	if config.Variables["instance_count"] != float64(1) {
+		t.Error("Expected config variables to be left unmodified")
+	}
+}
+
+func TestWriteConfigVarsFile(t *testing.T) {
+	workingDir := t.TempDir()
+	path := filepath.Join(workingDir, ConfigVarsFileName)
+
+	if err := WriteConfigVarsFile(workingDir, nil); err != nil {
+		t.Fatalf("Failed to write empty vars: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected no file to be written without variables")
+	}
+
+	if err := WriteConfigVarsFile(workingDir, map[string]interface{}{"subnets": []interface{}{"a", "b"}}); err != nil {
+		t.Fatalf("Failed to write vars: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read vars file: %v", err)
+	}
+	var vars map[string][]string
+	if err := json.Unmarshal(data, &vars); err != nil {
+		t.Fatalf("Failed to parse vars file: %v", err)
+	}
+	if len(vars["subnets"]) != 2 {
+		t.Errorf("Expected subnets list, got %v", vars)
+	}
+
+	// Dropping the variables, e.g. deploying a mode without overrides, removes the earlier file
+	if err := WriteConfigVarsFile(workingDir, map[string]interface{}{}); err != nil {
+		t.Fatalf("Failed to write empty vars: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Expected the earlier variables file to be removed")
+	}
+}
+
+func TestValidateModeVariables(t *testing.T) {
+	config := Config{
+		Template:      "web-app",
+		ModeSchedules: map[string]interface{}{"busy": "0 9 * * *"},
+		ModeVariables: map[string]map[string]interface{}{"quiet": {"instance_count": 1}},
+	}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for mode_variables of unknown mode")
+	}
+
+	config.ModeVariables = map[string]map[string]interface{}{"busy": {"instance_count": 1}}
+	if err := config.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %v", err)
+	}
+
+	config.Variables = map[string]interface{}{"bad name": 1}
+	if err := config.Validate(); err == nil {
+		t.Error("Expected error for invalid variable name")
+	}
+}
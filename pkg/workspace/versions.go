@@ -0,0 +1,103 @@
+package workspace
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// ProviderLock is a provider version pinned in a workspace's .terraform.lock.hcl
+type ProviderLock struct {
+	Source      string `json:"source"`
+	Version     string `json:"version"`
+	Constraints string `json:"constraints,omitempty"`
+}
+
+var (
+	tofuVersionPattern  = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+(-(alpha|beta|rc)[0-9]+)?$`)
+	lockProviderPattern = regexp.MustCompile(`^\s*provider\s+"([^"]+)"\s*\{`)
+	lockAttrPattern     = regexp.MustCompile(`^\s*(version|constraints)\s*=\s*"([^"]*)"`)
+)
+
+// ValidateTofuVersion checks that an OpenTofu version is an exact release such as 1.8.2 or 1.9.0-rc1
+func ValidateTofuVersion(version string) error {
+	if !tofuVersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid tofu_version '%s' (must be an exact release such as 1.8.2)", version)
+	}
+	return nil
+}
+
+// GetStateTofuVersion returns the OpenTofu version that last wrote the workspace state
+func (w *Workspace) GetStateTofuVersion() (string, error) {
+	data, err := os.ReadFile(w.getStateFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	var state struct {
+		TerraformVersion string `json:"terraform_version"`
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return "", fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	return state.TerraformVersion, nil
+}
+
+// GetProviderLocks returns the provider versions locked in the workspace deployment
+func (w *Workspace) GetProviderLocks() ([]ProviderLock, error) {
+	lockPath := filepath.Join(getStateDir(), "deployments", w.Name, ".terraform.lock.hcl")
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read lock file: %w", err)
+	}
+
+	return ParseLockFile(data), nil
+}
+
+// ParseLockFile extracts provider versions from .terraform.lock.hcl content
+func ParseLockFile(data []byte) []ProviderLock {
+	var locks []ProviderLock
+	var current *ProviderLock
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if match := lockProviderPattern.FindStringSubmatch(line); match != nil {
+			locks = append(locks, ProviderLock{Source: match[1]})
+			current = &locks[len(locks)-1]
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if match := lockAttrPattern.FindStringSubmatch(line); match != nil {
+			switch match[1] {
+			case "version":
+				current.Version = match[2]
+			case "constraints":
+				current.Constraints = match[2]
+			}
+		}
+	}
+
+	sort.Slice(locks, func(i, j int) bool {
+		return locks[i].Source < locks[j].Source
+	})
+
+	return locks
+}
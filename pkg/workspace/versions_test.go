@@ -0,0 +1,82 @@
+package workspace
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseLockFile(t *testing.T) {
+	content := `# This file is maintained automatically by "tofu init".
+
+provider "registry.opentofu.org/hashicorp/random" {
+  version = "3.6.0"
+  hashes = [
+    "h1:abc",
+  ]
+}
+
+provider "registry.opentofu.org/digitalocean/digitalocean" {
+  version     = "2.34.1"
+  constraints = "~> 2.0"
+  hashes = [
+    "h1:def",
+  ]
+}
+`
+
+	locks := ParseLockFile([]byte(content))
+	if len(locks) != 2 {
+		t.Fatalf("Expected 2 providers, got %d", len(locks))
+	}
+
+	// Sorted by source
+	if locks[0].Source != "registry.opentofu.org/digitalocean/digitalocean" || locks[0].Version != "2.34.1" || locks[0].Constraints != "~> 2.0" {
+		t.Errorf("Unexpected first provider: %+v", locks[0])
+	}
+	if locks[1].Source != "registry.opentofu.org/hashicorp/random" || locks[1].Version != "3.6.0" || locks[1].Constraints != "" {
+		t.Errorf("Unexpected second provider: %+v", locks[1])
+	}
+}
+
+func TestGetStateTofuVersion(t *testing.T) {
+	stateDir := t.TempDir()
+	t.Setenv("PROVISIONER_STATE_DIR", stateDir)
+
+	ws := &Workspace{Name: "versions-test"}
+
+	// No state yet
+	version, err := ws.GetStateTofuVersion()
+	if err != nil || version != "" {
+		t.Fatalf("Expected empty version without state, got %q, %v", version, err)
+	}
+
+	deploymentDir := filepath.Join(stateDir, "deployments", ws.Name)
+	if err := os.MkdirAll(deploymentDir, 0755); err != nil {
+		t.Fatalf("Failed to create deployment dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(deploymentDir, "terraform.tfstate"), []byte(`{"version":4,"terraform_version":"1.8.2"}`), 0644); err != nil {
+		t.Fatalf("Failed to write state: %v", err)
+	}
+
+	version, err = ws.GetStateTofuVersion()
+	if err != nil {
+		t.Fatalf("GetStateTofuVersion failed: %v", err)
+	}
+	if version != "1.8.2" {
+		t.Errorf("Expected 1.8.2, got %q", version)
+	}
+}
+
+func TestValidateTofuVersion(t *testing.T) {
+	for _, version := range []string{"1.8.2", "1.10.0", "1.9.0-rc1", "1.10.0-alpha2"} {
+		if err := ValidateTofuVersion(version); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", version, err)
+		}
+	}
+	for _, version := range []string{"", "latest", "1.8", "v1.8.2", "~> 1.8", "1.8.2-dev"} {
+		if err := ValidateTofuVersion(version); err == nil {
+			t.Errorf("Expected %q to be invalid", version)
+		}
+	}
+}
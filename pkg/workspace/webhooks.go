@@ -0,0 +1,81 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Webhook trigger actions
+const (
+	WebhookActionDeploy  = "deploy"
+	WebhookActionDestroy = "destroy"
+	WebhookActionMode    = "mode"
+)
+
+// WebhookConfig configures an incoming HTTP trigger for a workspace
+type WebhookConfig struct {
+	Name      string `json:"name"`                 // URL path segment: /hooks/WORKSPACE/NAME
+	Action    string `json:"action"`               // "deploy", "destroy" or "mode"
+	Mode      string `json:"mode,omitempty"`       // Mode to deploy in (required for "mode", optional for "deploy")
+	Secret    string `json:"secret,omitempty"`     // Shared secret
+	SecretEnv string `json:"secret_env,omitempty"` // Environment variable holding the shared secret
+}
+
+var webhookNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// GetSecret returns the webhook's shared secret, reading it from the environment if configured
+func (w *WebhookConfig) GetSecret() string {
+	if w.SecretEnv != "" {
+		return os.Getenv(w.SecretEnv)
+	}
+	return w.Secret
+}
+
+// GetWebhook returns the webhook trigger with the given name, or nil if none exists
+func (c *Config) GetWebhook(name string) *WebhookConfig {
+	for i := range c.Webhooks {
+		if c.Webhooks[i].Name == name {
+			return &c.Webhooks[i]
+		}
+	}
+	return nil
+}
+
+// validateWebhooks validates the workspace webhook triggers
+func (c *Config) validateWebhooks() error {
+	seen := make(map[string]bool)
+	for i, webhook := range c.Webhooks {
+		if !webhookNamePattern.MatchString(webhook.Name) {
+			return fmt.Errorf("webhook %d: name '%s' must contain only letters, numbers, '-' and '_'", i, webhook.Name)
+		}
+		if seen[webhook.Name] {
+			return fmt.Errorf("webhook %d: duplicate name '%s'", i, webhook.Name)
+		}
+		seen[webhook.Name] = true
+
+		if webhook.Secret == "" && webhook.SecretEnv == "" {
+			return fmt.Errorf("webhook '%s': must specify 'secret' or 'secret_env'", webhook.Name)
+		}
+		if webhook.Secret != "" && webhook.SecretEnv != "" {
+			return fmt.Errorf("webhook '%s': cannot specify both 'secret' and 'secret_env'", webhook.Name)
+		}
+
+		switch webhook.Action {
+		case WebhookActionDeploy, WebhookActionDestroy:
+		case WebhookActionMode:
+			if webhook.Mode == "" {
+				return fmt.Errorf("webhook '%s': action 'mode' requires 'mode'", webhook.Name)
+			}
+		default:
+			return fmt.Errorf("webhook '%s': invalid action '%s' (must be deploy, destroy or mode)", webhook.Name, webhook.Action)
+		}
+
+		if webhook.Mode != "" {
+			if _, exists := c.ModeSchedules[webhook.Mode]; !exists {
+				return fmt.Errorf("webhook '%s': mode '%s' is not defined in mode_schedules", webhook.Name, webhook.Mode)
+			}
+		}
+	}
+	return nil
+}
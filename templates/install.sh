@@ -42,7 +42,7 @@ trap "rm -rf $TEMP_DIR" EXIT
 cd "$TEMP_DIR"
 
 # Download all binaries
-BINARIES="provisioner workspacectl templatectl jobctl"
+BINARIES="provisioner provisionerctl workspacectl templatectl jobctl"
 if [ "$VERSION" = "latest" ]; then
     echo "🔍 Finding latest release..."
     BASE_URL="https://github.com/${REPO_OWNER}/${REPO_NAME}/releases/latest/download"
@@ -294,6 +294,7 @@ echo "  - System logs: journalctl -u $SERVICE_NAME"
 echo ""
 echo "💻 Available commands:"
 echo "  - provisioner --help        # Scheduler daemon"
+echo "  - provisionerctl --help     # All commands (workspace, job, template, environment, daemon)"
 echo "  - workspacectl --help     # Workspace management"
 echo "  - templatectl --help        # Template management"
 echo "  - jobctl --help             # Job management"